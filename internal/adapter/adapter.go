@@ -4,15 +4,22 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/objectfs/objectfs/internal/buffer"
 	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/internal/circuit"
 	"github.com/objectfs/objectfs/internal/config"
 	"github.com/objectfs/objectfs/internal/fuse"
 	"github.com/objectfs/objectfs/internal/metrics"
 	"github.com/objectfs/objectfs/internal/storage/s3"
+	"github.com/objectfs/objectfs/pkg/types"
 )
 
 // Adapter represents the main ObjectFS adapter
@@ -23,15 +30,61 @@ type Adapter struct {
 
 	// Core components
 	backend     *s3.Backend
-	cache       *cache.MultiLevelCache
+	fallbacks   []*s3.Backend
+	mirror      *MirrorBackend
+	mirrorS3    *s3.Backend
+	packer      *s3.Packer
+	compressor  *CompressingBackend
+	blocks      *BlockBackend
+	dedup       *DedupBackend
+	cow         *CopyOnWriteBackend
+	expiry      *ExpiryBackend
+	overlay     *ListingOverlay
+	limiter     *ConcurrencyLimiter
+	rateLimiter *RateLimiter
+	usage       *UsageBackend
+	storage     types.Backend // backend the mount reads through
+	cache       types.Cache
 	writeBuffer *buffer.WriteBuffer
 	mountMgr    fuse.PlatformFileSystem
 	metrics     *metrics.Collector
+	coordinator types.DistributedCoordinator
+	logger      *slog.Logger
 
 	// Internal state
 	started    bool
 	bucketName string
 	s3Config   *s3.Config
+
+	// Cache shared with other mounts in this process; nil creates a private one
+	sharedCache *cache.CacheNamespaces
+
+	// Cache underneath the namespaces, private or shared
+	cacheBase types.Cache
+
+	// Registry shared with other mounts in this process; nil for a private one
+	sharedMetrics *prometheus.Registry
+
+	// Stops periodic housekeeping; nil when it is not running
+	stopHousekeeping func()
+
+	// Stops periodic backend pings; nil when they are not running
+	stopLatencyProbe func()
+
+	// Stops reaping expired objects; nil when it is not running
+	stopExpiryReaper func()
+
+	// Stops saving usage totals, saving them once more; nil when they are
+	// not persisted
+	stopUsageSaver func()
+
+	// Per-phase shutdown timeout overrides
+	phaseTimeouts map[string]time.Duration
+
+	// Preflight hooks; nil uses the real implementations
+	resolveCredentials func(ctx context.Context, cfg *s3.Config) error
+	newBackend         func(ctx context.Context, bucket string, cfg *s3.Config) (types.Backend, error)
+	checkFUSE          func() error
 }
 
 // New creates a new ObjectFS adapter instance
@@ -57,6 +110,18 @@ func New(ctx context.Context, storageURI, mountPoint string, cfg *config.Configu
 		return nil, fmt.Errorf("bucket name cannot be empty")
 	}
 
+	for _, fallbackURI := range cfg.Storage.Fallback {
+		if err := validateStorageURI(fallbackURI); err != nil {
+			return nil, fmt.Errorf("invalid fallback storage URI %q: %w", fallbackURI, err)
+		}
+	}
+
+	if mirror := cfg.Storage.Mirror; mirror.Enabled {
+		if err := validateStorageURI(mirror.URI); err != nil {
+			return nil, fmt.Errorf("invalid mirror storage URI %q: %w", mirror.URI, err)
+		}
+	}
+
 	adapter := &Adapter{
 		storageURI: storageURI,
 		mountPoint: mountPoint,
@@ -73,6 +138,10 @@ func (a *Adapter) Start(ctx context.Context) error {
 		return fmt.Errorf("adapter already started")
 	}
 
+	if err := a.initLogging(); err != nil {
+		return err
+	}
+
 	log.Printf("Starting ObjectFS adapter...")
 	log.Printf("Storage URI: %s", a.storageURI)
 	log.Printf("Mount Point: %s", a.mountPoint)
@@ -82,47 +151,318 @@ func (a *Adapter) Start(ctx context.Context) error {
 	// 1. Initialize metrics collector
 	var err error
 	a.metrics, err = metrics.NewCollector(&metrics.Config{
-		Enabled: a.config.Monitoring.Metrics.Enabled,
-		Port:    a.config.Global.MetricsPort,
-		Labels:  a.config.Monitoring.Metrics.CustomLabels,
+		Enabled:        a.config.Monitoring.Metrics.Enabled,
+		Port:           a.config.Global.MetricsPort,
+		Path:           "/metrics",
+		Labels:         a.config.Monitoring.Metrics.CustomLabels,
+		Namespace:      "objectfs",
+		Subsystem:      a.config.Monitoring.Metrics.Subsystem,
+		UpdateInterval: 30 * time.Second,
+		MountID:        a.metricsMountID(),
+		Registry:       a.sharedMetrics,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize metrics collector: %w", err)
 	}
 
 	// 2. Initialize S3 backend
-	a.s3Config = &s3.Config{
-		Region:   "us-west-2", // Default, should be configurable
-		Endpoint: "",          // Use default AWS endpoint
-	}
+	a.s3Config = a.newS3Config()
 
 	a.backend, err = s3.NewBackend(ctx, a.bucketName, a.s3Config)
 	if err != nil {
 		return fmt.Errorf("failed to initialize S3 backend: %w", err)
 	}
 
-	// 3. Initialize cache system
-	cacheConfig := &cache.MultiLevelConfig{
-		L1Config: &cache.L1Config{
-			Enabled:    true,
-			Size:       parseSize(a.config.Performance.CacheSize),
-			MaxEntries: a.config.Cache.MaxEntries,
-			TTL:        a.config.Cache.TTL,
-			Prefetch:   true,
-		},
-		L2Config: &cache.L2Config{
-			Enabled:     a.config.Cache.PersistentCache.Enabled,
-			Size:        parseSize(a.config.Cache.PersistentCache.MaxSize),
-			Directory:   a.config.Cache.PersistentCache.Directory,
-			TTL:         a.config.Cache.TTL,
-			Compression: true,
-		},
-		Policy: a.config.Cache.EvictionPolicy,
+	// Open connections now so the first filesystem operation does not wait
+	// on connection setup
+	if prewarm := a.config.Storage.S3.Prewarm; prewarm.Enabled {
+		if err := a.backend.Prewarm(ctx, prewarm.Connections); err != nil {
+			a.logger.Warn("Failed to prewarm S3 connections", "error", err)
+		}
 	}
 
-	a.cache, err = cache.NewMultiLevelCache(cacheConfig)
+	a.storage = a.backend
+
+	// Cap the steady request and byte rates of every call to the bucket,
+	// including those issued by the layers above on the mount's behalf
+	if rate := a.config.Performance.RateLimit; rate.Enabled {
+		a.rateLimiter, err = NewRateLimiter(a.storage, RateLimitOptions{
+			Total: RateLimit{RequestsPerSecond: rate.RequestsPerSecond, BytesPerSecond: parseRate(rate.BytesPerSecond)},
+			Classes: map[string]RateLimit{
+				RateClassRead:  {RequestsPerSecond: rate.Read.RequestsPerSecond, BytesPerSecond: parseRate(rate.Read.BytesPerSecond)},
+				RateClassWrite: {RequestsPerSecond: rate.Write.RequestsPerSecond, BytesPerSecond: parseRate(rate.Write.BytesPerSecond)},
+				RateClassList:  {RequestsPerSecond: rate.List.RequestsPerSecond, BytesPerSecond: parseRate(rate.List.BytesPerSecond)},
+			},
+			Mode:    rate.Mode,
+			MaxWait: rate.MaxWait,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize rate limiter: %w", err)
+		}
+		a.storage = a.rateLimiter
+	}
+
+	// Apply writes to the mirror bucket too, beneath every layer that
+	// transforms objects, so both buckets hold the same stored objects
+	if mirror := a.config.Storage.Mirror; mirror.Enabled {
+		parsed, err := url.Parse(mirror.URI)
+		if err != nil {
+			return fmt.Errorf("failed to parse mirror storage URI: %w", err)
+		}
+		a.mirrorS3, err = s3.NewBackend(ctx, parsed.Host, a.s3Config)
+		if err != nil {
+			return fmt.Errorf("failed to initialize mirror S3 backend %s: %w", mirror.URI, err)
+		}
+		a.mirror, err = NewMirrorBackend(a.storage, a.mirrorS3, MirrorOptions{
+			Mode:          mirror.Mode,
+			MaxBacklog:    mirror.MaxBacklog,
+			RetryInterval: mirror.RetryInterval,
+			Logger:        a.logger,
+			Metrics:       a.metrics,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize mirror: %w", err)
+		}
+		a.storage = a.mirror
+	}
+
+	// Store each distinct content once, keeping references at the written
+	// keys. Compression above it compresses content before it is hashed.
+	if dedup := a.config.Storage.S3.Dedup; dedup.Enabled {
+		var minSize int64
+		if strings.TrimSpace(dedup.MinSize) != "" {
+			minSize = parseSize(dedup.MinSize)
+		}
+		a.dedup, err = NewDedupBackend(a.storage, DedupOptions{
+			Hash:       dedup.Hash,
+			Prefix:     dedup.Prefix,
+			MinSize:    minSize,
+			GCInterval: dedup.GCInterval,
+			GCGrace:    dedup.GCGrace,
+			Logger:     a.logger,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize dedup: %w", err)
+		}
+		a.storage = a.dedup
+	}
+
+	// Compress compressible content on write and decompress it on read
+	if compression := a.config.WriteBuffer.Compression; compression.Enabled {
+		a.compressor, err = NewCompressingBackend(a.storage, CompressionOptions{
+			Codec:          compression.Algorithm,
+			Level:          compression.Level,
+			MinSize:        parseSize(compression.MinSize),
+			SkipExtensions: compression.SkipExtensions,
+			Metrics:        a.metrics,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize compression: %w", err)
+		}
+		a.storage = a.compressor
+	}
+
+	// Split large objects into blocks so small random writes rewrite one
+	// block. Configuration validation keeps this exclusive with compression.
+	if blocks := a.config.Storage.S3.Blocks; blocks.Enabled {
+		a.blocks, err = NewBlockBackend(a.storage, BlockOptions{
+			BlockSize: parseSize(blocks.BlockSize),
+			Prefix:    blocks.Prefix,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize block storage: %w", err)
+		}
+		a.storage = a.blocks
+	}
+
+	// Keep this mount's edits of a shared dataset under its own prefix
+	if cow := a.config.Storage.CopyOnWrite; cow.Enabled {
+		a.cow, err = NewCopyOnWriteBackend(ctx, a.storage, cow.Prefix)
+		if err != nil {
+			return fmt.Errorf("failed to initialize copy-on-write overlay: %w", err)
+		}
+		a.storage = a.cow
+	}
+
+	// Show local writes in listings on backends whose listings lag writes.
+	// The listing cache lives in the overlay, which sees every local write.
+	overlay, listCache := a.config.Storage.S3.ListOverlay, a.config.Storage.S3.ListCache
+	if overlay.Enabled || listCache.Enabled {
+		a.overlay, err = NewListingOverlay(a.storage, overlay.Window)
+		if err != nil {
+			return fmt.Errorf("failed to initialize listing overlay: %w", err)
+		}
+		if listCache.Enabled {
+			a.overlay.EnableListCache(listCache.TTL)
+		}
+		a.storage = a.overlay
+	}
+	// Layer fallback buckets beneath the primary for overlay/union mounts
+	if len(a.config.Storage.Fallback) > 0 {
+		layers := make([]types.Backend, 0, len(a.config.Storage.Fallback))
+		for _, fallbackURI := range a.config.Storage.Fallback {
+			parsed, err := url.Parse(fallbackURI)
+			if err != nil {
+				return fmt.Errorf("failed to parse fallback storage URI: %w", err)
+			}
+
+			fallback, err := s3.NewBackend(ctx, parsed.Host, a.s3Config)
+			if err != nil {
+				return fmt.Errorf("failed to initialize fallback S3 backend %s: %w", fallbackURI, err)
+			}
+			a.fallbacks = append(a.fallbacks, fallback)
+			layers = append(layers, fallback)
+		}
+
+		a.storage, err = NewFallbackBackend(a.storage, layers...)
+		if err != nil {
+			return fmt.Errorf("failed to initialize fallback backend: %w", err)
+		}
+	}
+
+	// Pack small objects to avoid per-object minimum billable sizes
+	if packConfig := a.config.Storage.S3.Pack; packConfig.Enabled {
+		defaults := s3.NewDefaultConfig().Pack
+		pack := &s3.PackConfig{
+			Enabled:       true,
+			MaxPackSize:   defaults.MaxPackSize,
+			FlushInterval: defaults.FlushInterval,
+			Prefix:        defaults.Prefix,
+			CompactRatio:  defaults.CompactRatio,
+		}
+		if packConfig.Threshold != "" {
+			pack.Threshold = parseSize(packConfig.Threshold)
+		}
+		if packConfig.MaxPackSize != "" {
+			pack.MaxPackSize = parseSize(packConfig.MaxPackSize)
+		}
+		if packConfig.FlushInterval > 0 {
+			pack.FlushInterval = packConfig.FlushInterval
+		}
+
+		a.packer, err = s3.NewPacker(a.storage, pack, a.s3Config.StorageTier)
+		if err != nil {
+			return fmt.Errorf("failed to initialize object packer: %w", err)
+		}
+		if err := a.packer.Load(ctx); err != nil {
+			return fmt.Errorf("failed to load object packs: %w", err)
+		}
+		a.storage = a.packer
+	}
+
+	// Hide and reap objects past their application-layer expiry
+	if expiry := a.config.Storage.Expiry; expiry.Enabled {
+		rules := make([]ExpiryRule, 0, len(expiry.Rules))
+		for _, rule := range expiry.Rules {
+			rules = append(rules, ExpiryRule{Prefix: rule.Prefix, TTL: rule.TTL})
+		}
+		a.expiry, err = NewExpiryBackend(ctx, a.storage, ExpiryOptions{
+			Prefix:  expiry.Prefix,
+			Rules:   rules,
+			Embargo: a.backend.DeletionEmbargo(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize object expiry: %w", err)
+		}
+		a.storage = a.expiry
+	}
+
+	// Bound concurrent backend calls so bursts of filesystem operations
+	// queue instead of exhausting connections
+	a.limiter, err = NewConcurrencyLimiter(a.storage, ConcurrencyOptions{
+		MaxConcurrency: a.config.Performance.MaxConcurrency,
+		MaxReads:       a.config.Performance.MaxReadConcurrency,
+		MaxWrites:      a.config.Performance.MaxWriteConcurrency,
+		Metrics:        a.metrics,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize concurrency limiter: %w", err)
+	}
+	a.storage = a.limiter
+
+	// Count what the mount transfers over its lifetime
+	usage := a.config.Monitoring.Usage
+	a.usage, err = NewUsageBackend(a.storage, UsageOptions{
+		StateFile:    usage.StateFile,
+		SaveInterval: usage.SaveInterval,
+		PrefixDepth:  usage.PrefixDepth,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize usage accounting: %w", err)
+	}
+	a.storage = a.usage
+
+	// 3. Initialize cache system, reusing a shared cache when one was provided
+	namespaces := a.sharedCache
+	if namespaces == nil {
+		scorer, err := a.evictionScorer()
+		if err != nil {
+			return fmt.Errorf("failed to initialize cache: %w", err)
+		}
+
+		cacheConfig := &cache.MultiLevelConfig{
+			L1Config: &cache.L1Config{
+				Enabled:           true,
+				Size:              parseSize(a.config.Performance.CacheSize),
+				MaxEntries:        a.config.Cache.MaxEntries,
+				TTL:               a.config.Cache.TTL,
+				TTLJitter:         a.config.Cache.TTLJitter,
+				Prefetch:          true,
+				Scorer:            scorer,
+				CorrelationWindow: a.config.Cache.CorrelationWindow,
+
+				MaxInflightPrefetch: a.maxInflightPrefetch(),
+				OnInflightPrefetch:  a.metrics.UpdatePrefetchInflight,
+			},
+			L2Config: &cache.L2Config{
+				Enabled:     a.config.Cache.PersistentCache.Enabled,
+				Size:        parseSize(a.config.Cache.PersistentCache.MaxSize),
+				Directory:   a.config.Cache.PersistentCache.Directory,
+				TTL:         a.config.Cache.TTL,
+				TTLJitter:   a.config.Cache.TTLJitter,
+				Compression: true,
+				AutoSize:    a.persistentCacheAutoSize(),
+
+				Paths:        a.persistentCachePaths(),
+				PromoteAfter: a.config.Cache.PersistentCache.PromoteAfter,
+				DemoteAfter:  a.config.Cache.PersistentCache.DemoteAfter,
+			},
+			Policy: a.config.Cache.EvictionPolicy,
+		}
+
+		multiCache, err := cache.NewMultiLevelCache(cacheConfig)
+		if err != nil {
+			return fmt.Errorf("failed to initialize cache: %w", err)
+		}
+		if namespaces, err = cache.NewCacheNamespaces(multiCache); err != nil {
+			return fmt.Errorf("failed to initialize cache: %w", err)
+		}
+	}
+
+	// Prefix cache keys so mounts sharing a cache never collide
+	namespacedCache, err := namespaces.Namespace(a.cacheNamespace())
 	if err != nil {
-		return fmt.Errorf("failed to initialize cache: %w", err)
+		return fmt.Errorf("failed to initialize cache namespace: %w", err)
+	}
+	a.cache = namespacedCache
+	a.cacheBase = namespaces.Base()
+
+	// Revalidate expired entries with conditional GETs instead of refetching.
+	// Presigned URLs cannot carry the conditions, so entries are refetched.
+	if getter, ok := a.storage.(conditionalGetter); ok && !a.backend.Presigned() {
+		namespacedCache.SetRevalidator(getter.GetObjectIfModified)
+	}
+
+	// Optionally align cached reads to fixed blocks so overlapping ranges share entries
+	var fsCache types.Cache = a.cache
+	if blockSize := strings.TrimSpace(a.config.Cache.BlockSize); blockSize != "" {
+		fsCache, err = cache.NewBlockCache(a.cache, &cache.BlockCacheConfig{
+			BlockSize: parseSize(blockSize),
+			Backend:   a.storage,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize block cache: %w", err)
+		}
 	}
 
 	// 4. Initialize write buffer - use simple WriteBuffer for now
@@ -131,11 +471,20 @@ func (a *Adapter) Start(ctx context.Context) error {
 		FlushThreshold: parseSize(a.config.WriteBuffer.MaxMemory) / 200,
 		AsyncFlush:     true,
 		MaxWriteDelay:  a.config.WriteBuffer.FlushInterval,
+		MaxMemory:      parseSize(a.config.WriteBuffer.MaxMemory),
+		HighWatermark:  a.config.WriteBuffer.HighWatermark,
+		LowWatermark:   a.config.WriteBuffer.LowWatermark,
+		SpillDir:       a.writeSpillDir(),
+		SpillWatermark: a.config.WriteBuffer.Spill.Watermark,
 	}
 
-	// Create a simple flush callback that writes to S3
+	// Create a simple flush callback that writes to S3. With block storage,
+	// writes past the start of an object only rewrite the blocks they cover.
 	flushCallback := func(key string, data []byte, offset int64) error {
-		return a.backend.PutObject(ctx, key, data)
+		if offset > 0 && a.blocks != nil {
+			return a.writeAt(ctx, key, offset, data)
+		}
+		return a.putObject(ctx, key, data)
 	}
 
 	a.writeBuffer, err = buffer.NewWriteBuffer(writeBufferConfig, flushCallback)
@@ -144,6 +493,28 @@ func (a *Adapter) Start(ctx context.Context) error {
 	}
 
 	// 5. Initialize platform-specific FUSE filesystem
+	// Coalesce small sequential writes per handle; unset sizes fall back to
+	// the filesystem defaults
+	coalesceConfig := a.config.WriteBuffer.Coalesce
+	writeCoalesce := &fuse.WriteCoalescerConfig{
+		Enabled:      coalesceConfig.Enabled,
+		MaxDelay:     coalesceConfig.Window,
+		BufferSize:   1024 * 1024,
+		MaxWriteSize: 64 * 1024,
+	}
+	if coalesceConfig.MaxSize != "" {
+		writeCoalesce.BufferSize = parseSize(coalesceConfig.MaxSize)
+	}
+	if coalesceConfig.MaxWriteSize != "" {
+		writeCoalesce.MaxWriteSize = parseSize(coalesceConfig.MaxWriteSize)
+	}
+
+	// Decide how to treat existing entries before attempting the FUSE mount
+	nonEmpty, err := checkNonEmptyMount(a.mountPoint, a.onNonEmptyMount())
+	if err != nil {
+		return err
+	}
+
 	mountConfig := &fuse.MountConfig{
 		MountPoint: a.mountPoint,
 		Options: &fuse.MountOptions{
@@ -152,10 +523,68 @@ func (a *Adapter) Start(ctx context.Context) error {
 			MaxRead:  128 * 1024,
 			MaxWrite: 128 * 1024,
 			Debug:    false,
+			NonEmpty: nonEmpty,
+
+			WritebackCache:      a.config.Global.KernelCache.WritebackCache,
+			MaxBackground:       a.config.Global.KernelCache.MaxBackground,
+			CongestionThreshold: a.config.Global.KernelCache.CongestionThreshold,
 		},
+		WriteCoalesce: writeCoalesce,
+		MaxObjectSize: a.objectSizeLimits(),
+		SyncOnClose:   a.config.WriteBuffer.SyncOnClose,
+
+		OpenPrefetch: a.openPrefetchConfig(),
+		Streaming:    a.streamingConfig(),
+
+		ListingPrefetch: a.listingPrefetchConfig(),
+
+		VerifyCachedReads: a.config.Cache.VerifyReads,
+
+		DisableImplicitDirs: a.config.Cache.DisableImplicitDirs,
+		ImplicitDirTTL:      a.config.Cache.ImplicitDirTTL,
+		CaseInsensitive:     a.config.Global.CaseInsensitive,
+		DirectoryMarkers: fuse.DirectoryMarkers{
+			ContentType: a.config.Storage.DirectoryMarker.ContentType,
+			MetadataKey: a.config.Storage.DirectoryMarker.MetadataKey,
+		},
+
+		Degradation: fuse.DegradationPolicy{
+			OnReadUnavailable:  a.config.Network.Degradation.OnReadUnavailable,
+			OnWriteUnavailable: a.config.Network.Degradation.OnWriteUnavailable,
+			MaxStaleness:       a.config.Network.Degradation.MaxStaleness,
+		},
+		Availability: a.backend,
+
+		AutoRemount: fuse.AutoRemountConfig(a.config.Global.AutoRemount),
+	}
+
+	a.mountMgr = fuse.CreatePlatformMountManager(a.storage, fsCache, a.writeBuffer, a.metrics, mountConfig)
+
+	// 6. Expose aggregated status alongside metrics
+	a.metrics.RegisterHandler("/debug/status", a.StatusHandler())
+	a.metrics.RegisterHandler("/debug/recent-ops", a.RecentOpsHandler())
+	a.metrics.RegisterHandler("/debug/usage", a.UsageHandler())
+	if err := a.metrics.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start metrics server: %w", err)
+	}
+
+	// Abort incomplete multipart uploads left behind by failed writes
+	if a.config.Storage.S3.Housekeeping.Enabled {
+		a.startHousekeeping(a.backend)
+	}
+
+	// Keep a rolling backend latency estimate for hedging and monitoring
+	if a.config.Storage.S3.LatencyProbe.Enabled {
+		a.startLatencyProbe(a.backend)
 	}
 
-	a.mountMgr = fuse.CreatePlatformMountManager(a.backend, a.cache, a.writeBuffer, a.metrics, mountConfig)
+	if a.expiry != nil {
+		a.startExpiryReaper(a.expiry)
+	}
+
+	if a.config.Monitoring.Usage.StateFile != "" {
+		a.startUsageSaver(a.usage)
+	}
 
 	// 7. Initialize health monitor (simplified for now)
 	// TODO: Implement proper health monitoring when components are ready
@@ -170,7 +599,44 @@ func (a *Adapter) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop gracefully stops the adapter
+// SetSharedCache makes the adapter cache through namespaces instead of
+// creating its own cache. It must be called before Start.
+func (a *Adapter) SetSharedCache(namespaces *cache.CacheNamespaces) {
+	a.sharedCache = namespaces
+}
+
+// SetSharedMetrics makes the adapter report metrics into registry, labeled
+// with this mount's ID, instead of serving its own. The caller serves the
+// registry, for example with metrics.RegistryHandler. It must be called
+// before Start.
+func (a *Adapter) SetSharedMetrics(registry *prometheus.Registry) {
+	a.sharedMetrics = registry
+}
+
+// metricsMountID returns the mount label for this adapter's metrics. Mounts
+// sharing a registry default to their cache namespace so they never collide.
+func (a *Adapter) metricsMountID() string {
+	if id := strings.TrimSpace(a.config.Monitoring.Metrics.MountID); id != "" {
+		return id
+	}
+	if a.sharedMetrics != nil {
+		return a.cacheNamespace()
+	}
+	return ""
+}
+
+// cacheNamespace returns the cache key namespace for this mount
+func (a *Adapter) cacheNamespace() string {
+	if namespace := strings.TrimSpace(a.config.Cache.Namespace); namespace != "" {
+		return namespace
+	}
+	return a.bucketName
+}
+
+// Stop gracefully stops the adapter. Shutdown runs in fixed phases (drain
+// filesystem operations, flush writes, unmount, close storage, stop metrics),
+// each under its own timeout. Failed phases do not prevent later ones; their
+// errors are combined in the result.
 func (a *Adapter) Stop(ctx context.Context) error {
 	if !a.started {
 		return fmt.Errorf("adapter not started")
@@ -178,45 +644,269 @@ func (a *Adapter) Stop(ctx context.Context) error {
 
 	log.Printf("Stopping ObjectFS adapter...")
 
-	var lastErr error
+	err := a.runShutdownPhases(ctx)
 
-	// 1. Unmount filesystem
-	if a.mountMgr != nil && a.mountMgr.IsMounted() {
-		if err := a.mountMgr.Unmount(); err != nil {
-			log.Printf("Error unmounting filesystem: %v", err)
-			lastErr = err
-		}
+	a.started = false
+	if err != nil {
+		log.Printf("ObjectFS adapter stopped with errors")
+		return err
 	}
+	log.Printf("ObjectFS adapter stopped successfully")
+	return nil
+}
 
-	// 2. Flush write buffers
-	if a.writeBuffer != nil {
-		if err := a.writeBuffer.FlushAll(); err != nil {
-			log.Printf("Error flushing write buffers: %v", err)
-			lastErr = err
-		}
-		if err := a.writeBuffer.Close(); err != nil {
-			log.Printf("Error closing write buffer: %v", err)
-			lastErr = err
-		}
+// newS3Config returns the S3 backend configuration for this adapter
+func (a *Adapter) newS3Config() *s3.Config {
+	hedge := a.config.Storage.S3.Hedge
+	budget := a.config.Storage.S3.CostBudget
+	tracking := a.config.Storage.S3.AccessTracking
+	index := a.config.Storage.S3.MetadataIndex
+	return &s3.Config{
+		Region:   "us-west-2", // Default, should be configurable
+		Endpoint: "",          // Use default AWS endpoint
+		Hedge: s3.HedgeConfig{
+			Enabled:    hedge.Enabled,
+			Percentile: hedge.Percentile,
+			MaxRate:    hedge.MaxRate,
+		},
+		CostBudget: s3.CostBudgetConfig{
+			USDPerHour: budget.USDPerHour,
+			BurstUSD:   budget.BurstUSD,
+			Mode:       budget.Mode,
+			MaxWait:    budget.MaxWait,
+		},
+		Retention: s3.RetentionConfig{
+			Mode: a.config.Storage.S3.Retention.Mode,
+			Days: a.config.Storage.S3.Retention.Days,
+		},
+		AccessTracking: s3.AccessTrackingConfig{
+			Enabled:         tracking.Enabled,
+			MaxEntries:      tracking.MaxEntries,
+			Path:            tracking.Path,
+			PersistInterval: tracking.PersistInterval,
+		},
+		MetadataIndex: s3.MetadataIndexConfig{
+			Enabled:         index.Enabled,
+			Prefix:          index.Prefix,
+			Tags:            index.Tags,
+			MaxEntries:      index.MaxEntries,
+			MaxAge:          index.MaxAge,
+			Path:            index.Path,
+			PersistInterval: index.PersistInterval,
+		},
+		PoolHealth: s3.PoolHealthConfig{
+			FailureThreshold:   a.config.Storage.S3.PoolHealth.FailureThreshold,
+			ValidationInterval: a.config.Storage.S3.PoolHealth.ValidationInterval,
+		},
+		ReadReplicaRegions: a.config.Storage.S3.ReadReplicaRegions,
+		ReadFailover: s3.ReadFailoverConfig{
+			LatencyThreshold: a.config.Storage.S3.ReadFailover.LatencyThreshold,
+			Buckets:          a.config.Storage.S3.ReadFailover.Buckets,
+			Endpoints:        a.config.Storage.S3.ReadFailover.Endpoints,
+		},
+		CircuitBreakers:        a.circuitBreakers(),
+		RecentOps:              a.recentOpsConfig(),
+		RequesterPays:          a.config.Storage.S3.RequesterPays,
+		StableListings:         a.config.Storage.S3.StableListings,
+		MultipartFailurePolicy: a.config.Storage.S3.MultipartFailurePolicy,
+		AdaptiveMultipart:      a.adaptiveMultipartConfig(),
+		StreamChecksum:         a.config.Storage.S3.StreamChecksum,
+		MaxObjectSize:          a.objectSizeLimits(),
+		TierPolicy:             a.tierPolicyConfig(),
+		Logger:                 a.logger,
 	}
+}
 
-	// 3. Close backend connections
-	if a.backend != nil {
-		if err := a.backend.Close(); err != nil {
-			log.Printf("Error closing backend: %v", err)
-			lastErr = err
-		}
+// tierPolicyConfig returns the rules the S3 backend recommends tiers by
+func (a *Adapter) tierPolicyConfig() s3.TierPolicyConfig {
+	var policy s3.TierPolicyConfig
+	for _, rule := range a.config.Storage.S3.TierPolicy.Rules {
+		policy.Rules = append(policy.Rules, s3.TierPolicyRule{
+			Prefix:          rule.Prefix,
+			MinSize:         rule.MinSize,
+			MaxSize:         rule.MaxSize,
+			MinAge:          rule.MinAge,
+			MaxAge:          rule.MaxAge,
+			AccessFrequency: rule.AccessFrequency,
+			Tier:            rule.Tier,
+			Reason:          rule.Reason,
+		})
+	}
+	return policy
+}
+
+// adaptiveMultipartConfig returns how the S3 backend adapts its multipart
+// threshold to measured upload throughput
+func (a *Adapter) adaptiveMultipartConfig() s3.AdaptiveMultipartConfig {
+	adaptive := a.config.Storage.S3.AdaptiveMultipart
+	config := s3.AdaptiveMultipartConfig{
+		Enabled:        adaptive.Enabled,
+		MaxConcurrency: adaptive.MaxConcurrency,
+		Window:         adaptive.Window,
 	}
+	if adaptive.MinThreshold != "" {
+		config.MinThreshold = parseSize(adaptive.MinThreshold)
+	}
+	if adaptive.MaxThreshold != "" {
+		config.MaxThreshold = parseSize(adaptive.MaxThreshold)
+	}
+	return config
+}
 
-	// 4. Clear cache (simplified)
-	// TODO: Implement proper cache clearing when available
+// circuitBreakers returns the S3 breaker settings selected by
+// network.circuit_breaker.mode, or nil to keep the backend's defaults
+func (a *Adapter) circuitBreakers() map[string]circuit.Config {
+	cb := a.config.Network.CircuitBreaker
+	if !cb.Enabled || cb.Mode == "" {
+		return nil
+	}
+	breaker := circuit.Config{
+		Timeout:            cb.Timeout,
+		Mode:               circuit.TripMode(cb.Mode),
+		FailureThreshold:   uint32(cb.FailureThreshold),
+		ErrorRateThreshold: cb.ErrorRateThreshold,
+		MinimumRequests:    uint32(cb.MinimumRequests),
+		Window:             cb.Window,
+	}
+	return map[string]circuit.Config{"s3-get": breaker, "s3-put": breaker}
+}
 
-	// 5. Stop metrics collection (simplified)
-	// TODO: Implement proper metrics stopping
+// writeSpillDir returns where the write buffer stages writes under memory
+// pressure, or "" when spilling is off. It defaults to a directory inside
+// the persistent cache's first path.
+func (a *Adapter) writeSpillDir() string {
+	spill := a.config.WriteBuffer.Spill
+	if !spill.Enabled {
+		return ""
+	}
+	if spill.Directory != "" {
+		return spill.Directory
+	}
+	directory := a.config.Cache.PersistentCache.Directory
+	if paths := a.config.Cache.PersistentCache.Paths; len(paths) > 0 {
+		directory = paths[0].Directory
+	}
+	return filepath.Join(directory, "write-spill")
+}
 
-	a.started = false
-	log.Printf("ObjectFS adapter stopped successfully")
-	return lastErr
+// persistentCachePaths returns the paths of a tiered L2 cache, or nil for a
+// single directory
+func (a *Adapter) persistentCachePaths() []cache.L2Path {
+	var paths []cache.L2Path
+	for _, path := range a.config.Cache.PersistentCache.Paths {
+		paths = append(paths, cache.L2Path{
+			Directory: path.Directory,
+			Size:      parseSize(path.MaxSize),
+		})
+	}
+	return paths
+}
+
+// persistentCacheAutoSize returns how L2 sizes itself from free disk space,
+// or nil when it has a fixed max_size
+func (a *Adapter) persistentCacheAutoSize() *cache.AutoSizeConfig {
+	persistent := a.config.Cache.PersistentCache
+	if !strings.EqualFold(strings.TrimSpace(persistent.MaxSize), "auto") {
+		return nil
+	}
+	autoSize := &cache.AutoSizeConfig{
+		Fraction: persistent.AutoSize.Fraction,
+		Interval: persistent.AutoSize.CheckInterval,
+	}
+	if persistent.AutoSize.Reserve != "" {
+		autoSize.Reserve = parseSize(persistent.AutoSize.Reserve)
+	}
+	return autoSize
+}
+
+// evictionScorer returns the configured cache eviction scorer, or nil for
+// the access predictor's default ranking
+func (a *Adapter) evictionScorer() (types.EvictionScorer, error) {
+	if a.config.Cache.EvictionScorer == "cost_aware" {
+		return cache.ObjectKeyScorer(a.backend.EvictionScorer()), nil
+	}
+	return cache.NewEvictionScorer(a.config.Cache.EvictionScorer)
+}
+
+// recentOpsConfig returns the ring buffer of recent backend operations,
+// disabled unless configured
+func (a *Adapter) recentOpsConfig() s3.RecentOpsConfig {
+	recent := a.config.Monitoring.RecentOps
+	if !recent.Enabled {
+		return s3.RecentOpsConfig{}
+	}
+	size := recent.Size
+	if size == 0 {
+		size = s3.NewDefaultConfig().RecentOps.Size
+	}
+	return s3.RecentOpsConfig{Size: size, HashKeys: recent.HashKeys}
+}
+
+// openPrefetchConfig returns the open prefetch settings for this mount,
+// bounded by the read-ahead prefetch caps
+func (a *Adapter) openPrefetchConfig() fuse.OpenPrefetchConfig {
+	readAhead := a.config.Performance.ReadAhead
+	config := fuse.OpenPrefetchConfig{
+		Enabled:        readAhead.OpenPrefetch,
+		MaxInFlight:    readAhead.MaxConcurrentFetch,
+		BytesPerSecond: int64(readAhead.PrefetchBandwidthMBs) * 1024 * 1024,
+	}
+	if size := strings.TrimSpace(readAhead.OpenPrefetchMaxSize); size != "" {
+		config.MaxFileSize = parseSize(size)
+	}
+	if size := strings.TrimSpace(a.config.Performance.ReadAheadSize); size != "" {
+		config.Size = parseSize(size)
+	}
+	return config
+}
+
+// streamingConfig returns which files of this mount are read around the
+// cache
+func (a *Adapter) streamingConfig() fuse.StreamingConfig {
+	config := fuse.StreamingConfig{Prefixes: a.config.Cache.StreamingPrefixes}
+	if size := strings.TrimSpace(a.config.Cache.StreamingMinSize); size != "" {
+		config.MinFileSize = parseSize(size)
+	}
+	return config
+}
+
+// maxInflightPrefetch returns the bytes this mount prefetches at once, or
+// 0 for unlimited
+func (a *Adapter) maxInflightPrefetch() int64 {
+	if size := strings.TrimSpace(a.config.Cache.MaxInflightPrefetch); size != "" {
+		return parseSize(size)
+	}
+	return 0
+}
+
+// listingPrefetchConfig returns what listing a directory of this mount
+// loads ahead
+func (a *Adapter) listingPrefetchConfig() fuse.ListingPrefetchConfig {
+	listing := a.config.Cache.ListingPrefetch
+	config := fuse.ListingPrefetchConfig{
+		Mode:         fuse.ListingPrefetchMode(listing.Mode),
+		MaxDataFiles: listing.MaxDataFiles,
+		Concurrency:  listing.Concurrency,
+	}
+	if size := strings.TrimSpace(listing.MaxDataSize); size != "" {
+		config.MaxDataSize = parseSize(size)
+	}
+	return config
+}
+
+// objectSizeLimits returns the configured object size limits for this mount
+func (a *Adapter) objectSizeLimits() types.ObjectSizeLimits {
+	var limits types.ObjectSizeLimits
+	if size := strings.TrimSpace(a.config.Storage.MaxObjectSize); size != "" {
+		limits.Default = parseSize(size)
+	}
+	if len(a.config.Storage.MaxObjectSizeByPrefix) > 0 {
+		limits.Prefixes = make(map[string]int64, len(a.config.Storage.MaxObjectSizeByPrefix))
+		for prefix, size := range a.config.Storage.MaxObjectSizeByPrefix {
+			limits.Prefixes[prefix] = parseSize(size)
+		}
+	}
+	return limits
 }
 
 // validateStorageURI validates the storage URI format
@@ -238,6 +928,14 @@ func validateStorageURI(uri string) error {
 	return nil
 }
 
+// parseRate parses a bytes-per-second size string, where empty is unlimited
+func parseRate(rate string) int64 {
+	if strings.TrimSpace(rate) == "" {
+		return 0
+	}
+	return parseSize(rate)
+}
+
 // parseSize parses a human-readable size string (e.g., "2GB", "512MB") to bytes
 func parseSize(sizeStr string) int64 {
 	// Simple implementation - in practice you'd use a proper parsing library
@@ -465,3 +465,26 @@ func stringContains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestNewS3ConfigMapsTierPolicy(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Storage.S3.TierPolicy.Rules = []config.S3TierPolicyRule{
+		{Prefix: "reports/", Tier: "STANDARD", Reason: "reports stay hot"},
+		{MinSize: 1 << 20, MinAge: 720 * time.Hour, Tier: "GLACIER_IR"},
+	}
+	adapter, err := New(context.Background(), "s3://test-bucket", "/mnt/test", cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rules := adapter.newS3Config().TierPolicy.Rules
+	if len(rules) != 2 {
+		t.Fatalf("TierPolicy.Rules = %+v, want 2 rules", rules)
+	}
+	if rules[0].Prefix != "reports/" || rules[0].Tier != "STANDARD" || rules[0].Reason != "reports stay hot" {
+		t.Errorf("rule 0 = %+v", rules[0])
+	}
+	if rules[1].MinSize != 1<<20 || rules[1].MinAge != 720*time.Hour || rules[1].Tier != "GLACIER_IR" {
+		t.Errorf("rule 1 = %+v", rules[1])
+	}
+}
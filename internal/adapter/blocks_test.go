@@ -0,0 +1,193 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// putRecordingBackend is a metadataBackend that records the keys written
+type putRecordingBackend struct {
+	*metadataBackend
+	puts []string
+}
+
+func (b *putRecordingBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	return b.PutObjectWithMetadata(ctx, key, data, nil)
+}
+
+func (b *putRecordingBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		if err := b.PutObject(ctx, key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *putRecordingBackend) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	b.puts = append(b.puts, key)
+	return b.metadataBackend.PutObjectWithMetadata(ctx, key, data, metadata)
+}
+
+func TestBlockBackendSmallWriteRewritesOneBlock(t *testing.T) {
+	backend := &putRecordingBackend{metadataBackend: newMetadataBackend()}
+	blocks, err := NewBlockBackend(backend, BlockOptions{BlockSize: 64 * 1024})
+	if err != nil {
+		t.Fatalf("NewBlockBackend failed: %v", err)
+	}
+	ctx := context.Background()
+
+	file := bytes.Repeat([]byte("0123456789abcdef"), 64*1024) // 1MB, 16 blocks
+	if err := blocks.PutObject(ctx, "db/data.db", file); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if len(backend.puts) != 17 {
+		t.Fatalf("PutObject wrote %d objects, want 16 blocks and a manifest", len(backend.puts))
+	}
+
+	// An 8KB page write inside block 5 rewrites only that block
+	page := bytes.Repeat([]byte{0xAB}, 8*1024)
+	offset := int64(5*64*1024 + 4096)
+	backend.puts = nil
+	if err := blocks.WriteAt(ctx, "db/data.db", offset, page); err != nil {
+		t.Fatalf("WriteAt failed: %v", err)
+	}
+
+	var written []string
+	for _, key := range backend.puts {
+		if strings.HasPrefix(key, defaultBlockPrefix) {
+			written = append(written, key)
+		}
+	}
+	if len(written) != 1 || written[0] != defaultBlockPrefix+"db/data.db/5" {
+		t.Errorf("WriteAt rewrote blocks %v, want only block 5", written)
+	}
+
+	// A fresh wrapper reassembles the object from the stored manifest
+	reader, err := NewBlockBackend(backend, BlockOptions{BlockSize: 64 * 1024})
+	if err != nil {
+		t.Fatalf("NewBlockBackend failed: %v", err)
+	}
+	want := append([]byte(nil), file...)
+	copy(want[offset:], page)
+	got, err := reader.GetObject(ctx, "db/data.db", 0, 0)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("reassembled object does not match the written data")
+	}
+	got, err = reader.GetObject(ctx, "db/data.db", offset-10, 8*1024+20)
+	if err != nil {
+		t.Fatalf("ranged GetObject failed: %v", err)
+	}
+	if !bytes.Equal(got, want[offset-10:offset+8*1024+10]) {
+		t.Error("ranged read across the written page does not match")
+	}
+
+	info, err := reader.HeadObject(ctx, "db/data.db")
+	if err != nil {
+		t.Fatalf("HeadObject failed: %v", err)
+	}
+	if info.Size != int64(len(file)) {
+		t.Errorf("HeadObject size = %d, want %d", info.Size, len(file))
+	}
+
+	listed, err := reader.ListObjects(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("ListObjects failed: %v", err)
+	}
+	if len(listed) != 1 || listed[0].Key != "db/data.db" {
+		t.Errorf("ListObjects = %v, want only the object", listed)
+	}
+}
+
+func TestBlockBackendGrowsSmallObjectIntoBlocks(t *testing.T) {
+	backend := newMetadataBackend()
+	blocks, err := NewBlockBackend(backend, BlockOptions{BlockSize: 1024})
+	if err != nil {
+		t.Fatalf("NewBlockBackend failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := blocks.WriteAt(ctx, "log", 0, []byte("header")); err != nil {
+		t.Fatalf("WriteAt failed: %v", err)
+	}
+	if string(backend.objects["log"]) != "header" {
+		t.Fatalf("small object stored as %q, want it stored whole", backend.objects["log"])
+	}
+
+	// Writing past the block size splits the object, leaving the gap a hole
+	if err := blocks.WriteAt(ctx, "log", 5000, []byte("tail")); err != nil {
+		t.Fatalf("WriteAt failed: %v", err)
+	}
+	if _, ok := backend.objects[defaultBlockPrefix+"log/2"]; ok {
+		t.Error("all-zero block stored, want a hole")
+	}
+
+	data, err := blocks.GetObject(ctx, "log", 0, 0)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	want := make([]byte, 5004)
+	copy(want, "header")
+	copy(want[5000:], "tail")
+	if !bytes.Equal(data, want) {
+		t.Errorf("GetObject returned %d bytes, want the header, a zero gap, and the tail", len(data))
+	}
+
+	if err := blocks.DeleteObject(ctx, "log"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	if len(backend.objects) != 0 {
+		t.Errorf("DeleteObject left %d objects, want the blocks removed", len(backend.objects))
+	}
+}
+
+func TestBlockBackendRevalidatesChangedObject(t *testing.T) {
+	backend := newMetadataBackend()
+	blocks, err := NewBlockBackend(backend, BlockOptions{BlockSize: 1024})
+	if err != nil {
+		t.Fatalf("NewBlockBackend failed: %v", err)
+	}
+	ctx := context.Background()
+
+	before := bytes.Repeat([]byte("a"), 3000)
+	if err := blocks.PutObject(ctx, "db/data.db", before); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := blocks.WriteAt(ctx, "db/data.db", 0, []byte("changed")); err != nil {
+		t.Fatalf("WriteAt failed: %v", err)
+	}
+	after, err := blocks.GetObject(ctx, "db/data.db", 0, 0)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+
+	c := newRevalidatingCache(t, blocks.GetObjectIfModified)
+	expectRevalidatedContent(t, c, "db/data.db", 0, before, after)
+}
+
+func TestBlockBackendListingLimitSkipsBlockObjects(t *testing.T) {
+	backend := &limitedMetadataBackend{newMetadataBackend()}
+	blocks, err := NewBlockBackend(backend, BlockOptions{BlockSize: 1024})
+	if err != nil {
+		t.Fatalf("NewBlockBackend failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := blocks.PutObject(ctx, "data.db", bytes.Repeat([]byte("x"), 4096)); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	// Block objects sort first and must not fill the page
+	listed, err := blocks.ListObjects(ctx, "", 1)
+	if err != nil {
+		t.Fatalf("ListObjects failed: %v", err)
+	}
+	if len(listed) != 1 || listed[0].Key != "data.db" || listed[0].Size != 4096 {
+		t.Errorf("listing = %+v, want data.db at 4096 bytes", listed)
+	}
+}
@@ -0,0 +1,554 @@
+package adapter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/metrics"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+const (
+	// codecMetadataKey names the codec a stored object was compressed with
+	codecMetadataKey = "objectfs-codec"
+	// originalSizeMetadataKey holds the uncompressed size of a stored object
+	originalSizeMetadataKey = "objectfs-original-size"
+
+	// entropySampleSize is how much of an object is sampled to judge
+	// whether it is worth compressing
+	entropySampleSize = 4096
+	// maxCompressibleEntropy is the sampled entropy, in bits per byte, above
+	// which data is treated as already compressed or random
+	maxCompressibleEntropy = 7.5
+
+	defaultCompressionMinSize = 1024
+)
+
+// compressedExtensions are formats that are already compressed
+var compressedExtensions = []string{
+	".jpg", ".jpeg", ".png", ".gif", ".webp", ".heic",
+	".mp3", ".mp4", ".m4a", ".mkv", ".mov", ".avi", ".webm",
+	".zip", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".lz4", ".7z", ".rar", ".br",
+	".parquet", ".orc", ".avro",
+}
+
+// CompressionOptions configures a CompressingBackend
+type CompressionOptions struct {
+	Codec          string   // gzip (default) or zlib
+	Level          int      // Codec compression level; 0 uses the codec default
+	MinSize        int64    // Smaller objects are stored uncompressed (default 1KB)
+	SkipExtensions []string // Extensions stored uncompressed, added to the built-in list
+
+	Metrics *metrics.Collector // Receives compress and decompress timings when set
+}
+
+// CompressionStats reports the effect of compression
+type CompressionStats struct {
+	Compressed     uint64        `json:"compressed"`
+	Skipped        uint64        `json:"skipped"`
+	BytesIn        int64         `json:"bytes_in"`  // Uncompressed bytes of compressed objects
+	BytesOut       int64         `json:"bytes_out"` // Stored bytes of compressed objects
+	Ratio          float64       `json:"ratio"`     // BytesOut / BytesIn
+	CompressTime   time.Duration `json:"compress_time"`
+	DecompressTime time.Duration `json:"decompress_time"`
+}
+
+// storedEncoding records how a key is stored
+type storedEncoding struct {
+	codec string // Empty when stored uncompressed
+	size  int64  // Uncompressed size
+}
+
+// CompressingBackend compresses objects on write and decompresses them on
+// read. Formats that are already compressed, detected by extension or by a
+// high-entropy sample, are stored as-is. The codec and uncompressed size are
+// kept in object metadata, and HeadObject and listings report the
+// uncompressed size. Stored ETags are those of the compressed bytes.
+type CompressingBackend struct {
+	backend types.Backend
+	writer  types.ObjectMetadataWriter
+	codec   string
+	level   int
+	minSize int64
+	skip    map[string]bool
+	metrics *metrics.Collector
+
+	mu        sync.Mutex
+	encodings map[string]storedEncoding
+	stats     CompressionStats
+}
+
+// NewCompressingBackend wraps backend, which must store object metadata
+func NewCompressingBackend(backend types.Backend, options CompressionOptions) (*CompressingBackend, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	writer, ok := backend.(types.ObjectMetadataWriter)
+	if !ok {
+		return nil, fmt.Errorf("backend does not support object metadata")
+	}
+
+	if options.Codec == "" {
+		options.Codec = "gzip"
+	}
+	if options.Codec != "gzip" && options.Codec != "zlib" {
+		return nil, fmt.Errorf("unsupported compression codec: %s", options.Codec)
+	}
+	if options.Level == 0 {
+		options.Level = gzip.DefaultCompression
+	}
+	if options.MinSize <= 0 {
+		options.MinSize = defaultCompressionMinSize
+	}
+
+	skip := make(map[string]bool)
+	for _, ext := range append(compressedExtensions, options.SkipExtensions...) {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		skip[strings.ToLower(ext)] = true
+	}
+
+	return &CompressingBackend{
+		backend:   backend,
+		writer:    writer,
+		codec:     options.Codec,
+		level:     options.Level,
+		minSize:   options.MinSize,
+		skip:      skip,
+		metrics:   options.Metrics,
+		encodings: make(map[string]storedEncoding),
+	}, nil
+}
+
+// Stats returns compression statistics
+func (c *CompressingBackend) Stats() CompressionStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	if stats.BytesIn > 0 {
+		stats.Ratio = float64(stats.BytesOut) / float64(stats.BytesIn)
+	}
+	return stats
+}
+
+// worthCompressing reports whether data stored at key should be compressed
+func (c *CompressingBackend) worthCompressing(key string, data []byte) bool {
+	if int64(len(data)) < c.minSize {
+		return false
+	}
+	if c.skip[strings.ToLower(path.Ext(key))] {
+		return false
+	}
+	sample := data
+	if len(sample) > entropySampleSize {
+		sample = sample[:entropySampleSize]
+	}
+	return entropy(sample) <= maxCompressibleEntropy
+}
+
+// entropy returns the Shannon entropy of data in bits per byte
+func entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	var bits float64
+	total := float64(len(data))
+	for _, n := range counts {
+		if n > 0 {
+			p := float64(n) / total
+			bits -= p * math.Log2(p)
+		}
+	}
+	return bits
+}
+
+// compress encodes data with the configured codec
+func (c *CompressingBackend) compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch c.codec {
+	case "zlib":
+		w, err = zlib.NewWriterLevel(&buf, c.level)
+	default:
+		w, err = gzip.NewWriterLevel(&buf, c.level)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress decodes data stored with codec
+func (c *CompressingBackend) decompress(key string, data []byte, enc storedEncoding) ([]byte, error) {
+	start := time.Now()
+
+	var r io.ReadCloser
+	var err error
+	switch enc.codec {
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case "zlib":
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("object %s uses unsupported codec %s", key, enc.codec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", key, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	buf := bytes.NewBuffer(make([]byte, 0, enc.size))
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", key, err)
+	}
+
+	elapsed := time.Since(start)
+	c.mu.Lock()
+	c.stats.DecompressTime += elapsed
+	c.mu.Unlock()
+	if c.metrics != nil {
+		c.metrics.RecordOperation("decompress", elapsed, int64(buf.Len()), true)
+	}
+	return buf.Bytes(), nil
+}
+
+// remember records how key is stored
+func (c *CompressingBackend) remember(key string, enc storedEncoding) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.encodings[key] = enc
+}
+
+// encodingFromInfo reads the stored encoding from object metadata
+func encodingFromInfo(info *types.ObjectInfo) storedEncoding {
+	enc := storedEncoding{codec: info.Metadata[codecMetadataKey], size: info.Size}
+	if enc.codec != "" {
+		if size, err := strconv.ParseInt(info.Metadata[originalSizeMetadataKey], 10, 64); err == nil {
+			enc.size = size
+		}
+	}
+	return enc
+}
+
+// encoding returns how key is stored, asking the backend on first use
+func (c *CompressingBackend) encoding(ctx context.Context, key string) (storedEncoding, error) {
+	c.mu.Lock()
+	enc, ok := c.encodings[key]
+	c.mu.Unlock()
+	if ok {
+		return enc, nil
+	}
+
+	info, err := c.HeadObject(ctx, key)
+	if err != nil {
+		return storedEncoding{}, err
+	}
+	return encodingFromInfo(info), nil
+}
+
+// GetObject reads key, decompressing it when it was stored compressed
+func (c *CompressingBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	enc, err := c.encoding(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if enc.codec == "" {
+		return c.backend.GetObject(ctx, key, offset, size)
+	}
+
+	// Compressed streams cannot be read from an offset
+	stored, err := c.backend.GetObject(ctx, key, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.decompress(key, stored, enc)
+	if err != nil {
+		return nil, err
+	}
+	return sliceRange(data, offset, size), nil
+}
+
+// sliceRange returns size bytes of data from offset; size <= 0 reads to the end
+func sliceRange(data []byte, offset, size int64) []byte {
+	if offset >= int64(len(data)) {
+		return []byte{}
+	}
+	end := int64(len(data))
+	if size > 0 && offset+size < end {
+		end = offset + size
+	}
+	return data[offset:end]
+}
+
+// PutObject stores data, compressed when that is worthwhile
+func (c *CompressingBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	if !c.worthCompressing(key, data) {
+		return c.putRaw(ctx, key, data)
+	}
+
+	start := time.Now()
+	compressed, err := c.compress(data)
+	elapsed := time.Since(start)
+	if c.metrics != nil {
+		c.metrics.RecordOperation("compress", elapsed, int64(len(data)), err == nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compress %s: %w", key, err)
+	}
+
+	c.mu.Lock()
+	c.stats.CompressTime += elapsed
+	c.mu.Unlock()
+
+	if len(compressed) >= len(data) {
+		return c.putRaw(ctx, key, data)
+	}
+
+	metadata := map[string]string{
+		codecMetadataKey:        c.codec,
+		originalSizeMetadataKey: strconv.Itoa(len(data)),
+	}
+	if err := c.writer.PutObjectWithMetadata(ctx, key, compressed, metadata); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.encodings[key] = storedEncoding{codec: c.codec, size: int64(len(data))}
+	c.stats.Compressed++
+	c.stats.BytesIn += int64(len(data))
+	c.stats.BytesOut += int64(len(compressed))
+	c.mu.Unlock()
+	return nil
+}
+
+// putRaw stores data uncompressed
+func (c *CompressingBackend) putRaw(ctx context.Context, key string, data []byte) error {
+	if err := c.backend.PutObject(ctx, key, data); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.encodings[key] = storedEncoding{size: int64(len(data))}
+	c.stats.Skipped++
+	c.mu.Unlock()
+	return nil
+}
+
+// DeleteObject deletes key
+func (c *CompressingBackend) DeleteObject(ctx context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.encodings, key)
+	c.mu.Unlock()
+	return c.backend.DeleteObject(ctx, key)
+}
+
+// HeadObject returns metadata for key with its uncompressed size
+func (c *CompressingBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	info, err := c.backend.HeadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	enc := encodingFromInfo(info)
+	c.remember(key, enc)
+	info.Size = enc.size
+	return info, nil
+}
+
+// GetObjects reads keys, decompressing those stored compressed
+func (c *CompressingBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	objects, err := c.backend.GetObjects(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for key, data := range objects {
+		enc, err := c.encoding(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if enc.codec == "" {
+			continue
+		}
+		if objects[key], err = c.decompress(key, data, enc); err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+// PutObjects stores objects, compressing each when that is worthwhile
+func (c *CompressingBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		if err := c.PutObject(ctx, key, data); err != nil {
+			return fmt.Errorf("failed to put %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// uncompressedSize corrects the listed size of objects known to be compressed
+func (c *CompressingBackend) uncompressedSize(obj *types.ObjectInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if enc, ok := c.encodings[obj.Key]; ok && enc.codec != "" {
+		obj.Size = enc.size
+	}
+}
+
+// ListObjects lists the wrapped backend. Listings carry no metadata, so
+// only objects whose encoding is already known report their uncompressed size.
+func (c *CompressingBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	objects, err := c.backend.ListObjects(ctx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range objects {
+		c.uncompressedSize(&objects[i])
+	}
+	return objects, nil
+}
+
+// ListObjectsChan streams the wrapped backend's listing
+func (c *CompressingBackend) ListObjectsChan(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	streamer, ok := c.backend.(types.ObjectStreamer)
+	if !ok {
+		objCh := make(chan types.ObjectInfo)
+		errCh := make(chan error, 1)
+		go func() {
+			defer close(objCh)
+			defer close(errCh)
+			objects, err := c.ListObjects(ctx, prefix, 0)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			for _, obj := range objects {
+				select {
+				case objCh <- obj:
+				case <-ctx.Done():
+					errCh <- ctx.Err()
+					return
+				}
+			}
+		}()
+		return objCh, errCh
+	}
+
+	rawCh, rawErrCh := streamer.ListObjectsChan(ctx, prefix)
+	objCh := make(chan types.ObjectInfo)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(objCh)
+		defer close(errCh)
+		for obj := range rawCh {
+			c.uncompressedSize(&obj)
+			select {
+			case objCh <- obj:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+		if err := <-rawErrCh; err != nil {
+			errCh <- err
+		}
+	}()
+
+	return objCh, errCh
+}
+
+// GetObjectIfModified revalidates through the wrapped backend. Only the
+// requested range is transferred for objects stored uncompressed; compressed
+// streams cannot be read from an offset, so they are fetched whole and
+// decompressed when changed.
+func (c *CompressingBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := c.backend.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	cached, err := c.encoding(ctx, key)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	ranged := cached.codec == ""
+	storedOffset, storedSize := offset, size
+	if !ranged {
+		storedOffset, storedSize = 0, 0
+	}
+
+	data, notModified, info, err := getter.GetObjectIfModified(ctx, key, storedOffset, storedSize, since, etag)
+	if err != nil || notModified || info == nil {
+		return data, notModified, info, err
+	}
+
+	enc := encodingFromInfo(info)
+	c.remember(key, enc)
+	info.Size = enc.size
+	switch {
+	case enc.codec == "" && ranged:
+		return data, false, info, nil
+	case enc.codec == "":
+		return sliceRange(data, offset, size), false, info, nil
+	case ranged:
+		// The object is now compressed, so the range fetched is not its content
+		if data, err = c.GetObject(ctx, key, offset, size); err != nil {
+			return nil, false, nil, err
+		}
+		return data, false, info, nil
+	}
+	if data, err = c.decompress(key, data, enc); err != nil {
+		return nil, false, nil, err
+	}
+	return sliceRange(data, offset, size), false, info, nil
+}
+
+// Touch updates the last-modified time of key in the wrapped backend
+func (c *CompressingBackend) Touch(ctx context.Context, key string) error {
+	toucher, ok := c.backend.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	return toucher.Touch(ctx, key)
+}
+
+// MoveObject moves srcKey to dstKey. Stored objects are moved as they are,
+// compressed or not, with the codec recorded in their metadata.
+func (c *CompressingBackend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	mover, ok := c.backend.(types.ObjectMover)
+	if !ok {
+		return fmt.Errorf("backend does not support move")
+	}
+	c.mu.Lock()
+	delete(c.encodings, srcKey)
+	delete(c.encodings, dstKey)
+	c.mu.Unlock()
+	return mover.MoveObject(ctx, srcKey, dstKey)
+}
+
+// HealthCheck checks the wrapped backend
+func (c *CompressingBackend) HealthCheck(ctx context.Context) error {
+	return c.backend.HealthCheck(ctx)
+}
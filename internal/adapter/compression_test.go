@@ -0,0 +1,269 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// metadataBackend is a memoryBackend that stores object metadata
+type metadataBackend struct {
+	*memoryBackend
+	metadata map[string]map[string]string
+}
+
+func newMetadataBackend() *metadataBackend {
+	return &metadataBackend{
+		memoryBackend: newMemoryBackend(nil),
+		metadata:      make(map[string]map[string]string),
+	}
+}
+
+func (b *metadataBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	return b.PutObjectWithMetadata(ctx, key, data, nil)
+}
+
+func (b *metadataBackend) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	if err := b.memoryBackend.PutObject(ctx, key, data); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.metadata[key] = metadata
+	return nil
+}
+
+func (b *metadataBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	info, err := b.memoryBackend.HeadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	info.Metadata = make(map[string]string)
+	for k, v := range b.metadata[key] {
+		info.Metadata[k] = v
+	}
+	return info, nil
+}
+
+// GetObjectIfModified returns the range of key with its metadata unless its
+// content still matches etag
+func (b *metadataBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	data, err := b.memoryBackend.GetObject(ctx, key, 0, 0)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	sum := md5.Sum(data)
+	current := `"` + hex.EncodeToString(sum[:]) + `"`
+	if etag == current {
+		return nil, true, nil, nil
+	}
+	info, err := b.HeadObject(ctx, key)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	info.ETag = current
+	return sliceRange(data, offset, size), false, info, nil
+}
+
+// newRevalidatingCache returns a cache whose entries expire at once and
+// revalidate through fn
+func newRevalidatingCache(t *testing.T, fn cache.RevalidateFunc) *cache.LRUCache {
+	t.Helper()
+	c := cache.NewLRUCache(&cache.CacheConfig{
+		MaxSize:         1024 * 1024,
+		MaxEntries:      100,
+		TTL:             20 * time.Millisecond,
+		CleanupInterval: time.Hour,
+	})
+	t.Cleanup(func() { _ = c.Close() })
+	c.SetRevalidator(fn)
+	return c
+}
+
+// expectRevalidatedContent caches a range of stale at offset for key, lets
+// it expire, and checks that the revalidated entry holds that range of want
+func expectRevalidatedContent(t *testing.T, c *cache.LRUCache, key string, offset int, stale, want []byte) {
+	t.Helper()
+	n := min(len(stale)-offset, len(want)-offset, 64)
+	c.Put(key, int64(offset), stale[offset:offset+n])
+	time.Sleep(30 * time.Millisecond)
+	for _, check := range []string{"revalidated", "cached"} {
+		if got := c.Get(key, int64(offset), int64(n)); !bytes.Equal(got, want[offset:offset+n]) {
+			t.Errorf("%s %s = %q, want %q", check, key, got, want[offset:offset+n])
+		}
+	}
+}
+
+func TestCompressingBackendCompressesText(t *testing.T) {
+	backend := newMetadataBackend()
+	compressor, err := NewCompressingBackend(backend, CompressionOptions{})
+	if err != nil {
+		t.Fatalf("NewCompressingBackend failed: %v", err)
+	}
+	ctx := context.Background()
+
+	text := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200))
+	if err := compressor.PutObject(ctx, "logs/app.log", text); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	stored := backend.objects["logs/app.log"]
+	if len(stored) >= len(text) {
+		t.Errorf("stored %d bytes for %d bytes of text, want it compressed", len(stored), len(text))
+	}
+	if codec := backend.metadata["logs/app.log"][codecMetadataKey]; codec != "gzip" {
+		t.Errorf("codec metadata = %q, want gzip", codec)
+	}
+
+	// A fresh wrapper learns the encoding from object metadata
+	reader, err := NewCompressingBackend(backend, CompressionOptions{})
+	if err != nil {
+		t.Fatalf("NewCompressingBackend failed: %v", err)
+	}
+	data, err := reader.GetObject(ctx, "logs/app.log", 0, 0)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	if !bytes.Equal(data, text) {
+		t.Error("decompressed object differs from what was written")
+	}
+
+	part, err := reader.GetObject(ctx, "logs/app.log", 4, 5)
+	if err != nil {
+		t.Fatalf("ranged GetObject failed: %v", err)
+	}
+	if string(part) != "quick" {
+		t.Errorf("ranged read = %q, want %q", part, "quick")
+	}
+
+	info, err := reader.HeadObject(ctx, "logs/app.log")
+	if err != nil {
+		t.Fatalf("HeadObject failed: %v", err)
+	}
+	if info.Size != int64(len(text)) {
+		t.Errorf("HeadObject size = %d, want the uncompressed %d", info.Size, len(text))
+	}
+
+	stats := compressor.Stats()
+	if stats.Compressed != 1 || stats.Ratio <= 0 || stats.Ratio >= 1 {
+		t.Errorf("stats = %+v, want one compressed object with a ratio below 1", stats)
+	}
+}
+
+func TestCompressingBackendRevalidatesChangedObject(t *testing.T) {
+	backend := newMetadataBackend()
+	compressor, err := NewCompressingBackend(backend, CompressionOptions{})
+	if err != nil {
+		t.Fatalf("NewCompressingBackend failed: %v", err)
+	}
+	ctx := context.Background()
+
+	before := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200))
+	after := []byte(strings.Repeat("THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG\n", 200))
+	if err := compressor.PutObject(ctx, "logs/app.log", before); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := compressor.PutObject(ctx, "logs/app.log", after); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if codec := backend.metadata["logs/app.log"][codecMetadataKey]; codec != "gzip" {
+		t.Fatalf("codec metadata = %q, want the new version compressed", codec)
+	}
+
+	c := newRevalidatingCache(t, compressor.GetObjectIfModified)
+	expectRevalidatedContent(t, c, "logs/app.log", 44, before, after)
+}
+
+func TestCompressingBackendRevalidatesNewlyCompressedObject(t *testing.T) {
+	backend := newMetadataBackend()
+	compressor, err := NewCompressingBackend(backend, CompressionOptions{})
+	if err != nil {
+		t.Fatalf("NewCompressingBackend failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// Every byte value once per 256 bytes is too random to compress
+	before := make([]byte, 4096)
+	for i := range before {
+		before[i] = byte(i)
+	}
+	after := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200))
+	if err := compressor.PutObject(ctx, "logs/app.log", before); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if codec := backend.metadata["logs/app.log"][codecMetadataKey]; codec != "" {
+		t.Fatalf("codec metadata = %q, want the first version stored as is", codec)
+	}
+
+	// Another writer replaces it, so compressor still expects it stored as is
+	writer, err := NewCompressingBackend(backend, CompressionOptions{})
+	if err != nil {
+		t.Fatalf("NewCompressingBackend failed: %v", err)
+	}
+	if err := writer.PutObject(ctx, "logs/app.log", after); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	c := newRevalidatingCache(t, compressor.GetObjectIfModified)
+	expectRevalidatedContent(t, c, "logs/app.log", 100, before, after)
+}
+
+func TestCompressingBackendSkipsCompressedContent(t *testing.T) {
+	backend := newMetadataBackend()
+	compressor, err := NewCompressingBackend(backend, CompressionOptions{})
+	if err != nil {
+		t.Fatalf("NewCompressingBackend failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// Repetitive bytes would compress well, so only the extension skips them
+	jpeg := append([]byte{0xff, 0xd8, 0xff, 0xe0}, bytes.Repeat([]byte{0x42}, 4096)...)
+	if err := compressor.PutObject(ctx, "photos/cat.JPG", jpeg); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if !bytes.Equal(backend.objects["photos/cat.JPG"], jpeg) {
+		t.Error("JPEG was not stored as-is")
+	}
+
+	random := make([]byte, 8192)
+	rand.New(rand.NewSource(1)).Read(random)
+	if err := compressor.PutObject(ctx, "data/blob.bin", random); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if !bytes.Equal(backend.objects["data/blob.bin"], random) {
+		t.Error("high-entropy data was not stored as-is")
+	}
+
+	for key, want := range map[string][]byte{"photos/cat.JPG": jpeg, "data/blob.bin": random} {
+		if codec := backend.metadata[key][codecMetadataKey]; codec != "" {
+			t.Errorf("%s codec metadata = %q, want none", key, codec)
+		}
+		data, err := compressor.GetObject(ctx, key, 0, 0)
+		if err != nil {
+			t.Fatalf("GetObject(%s) failed: %v", key, err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("GetObject(%s) returned different data", key)
+		}
+	}
+
+	if stats := compressor.Stats(); stats.Compressed != 0 || stats.Skipped != 2 {
+		t.Errorf("stats = %+v, want two skipped objects", stats)
+	}
+}
+
+func TestCompressingBackendRequiresMetadata(t *testing.T) {
+	if _, err := NewCompressingBackend(newMemoryBackend(nil), CompressionOptions{}); err == nil {
+		t.Error("expected an error for a backend without object metadata")
+	}
+}
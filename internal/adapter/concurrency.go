@@ -0,0 +1,453 @@
+package adapter
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/metrics"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// opClass separates reads from writes for their sub-limits
+type opClass int
+
+const (
+	classRead opClass = iota
+	classWrite
+)
+
+// String returns the metric label of the class
+func (c opClass) String() string {
+	if c == classWrite {
+		return "write"
+	}
+	return "read"
+}
+
+// ConcurrencyOptions configures a ConcurrencyLimiter
+type ConcurrencyOptions struct {
+	MaxConcurrency int                // Backend calls in flight across reads and writes
+	MaxReads       int                // Sub-limit for reads; 0 allows up to MaxConcurrency
+	MaxWrites      int                // Sub-limit for writes; 0 allows up to MaxConcurrency
+	Metrics        *metrics.Collector // Receives queue depth per class; optional
+}
+
+// ConcurrencyStats reports the state of a ConcurrencyLimiter
+type ConcurrencyStats struct {
+	Limit          int           `json:"limit"`
+	ReadLimit      int           `json:"read_limit"`
+	WriteLimit     int           `json:"write_limit"`
+	InFlight       int           `json:"in_flight"`
+	ReadsInFlight  int           `json:"reads_in_flight"`
+	WritesInFlight int           `json:"writes_in_flight"`
+	QueuedReads    int           `json:"queued_reads"`
+	QueuedWrites   int           `json:"queued_writes"`
+	MaxQueued      int           `json:"max_queued"`
+	Waits          int64         `json:"waits"`     // Calls that had to queue
+	WaitTime       time.Duration `json:"wait_time"` // Total time calls spent queued
+}
+
+// concurrencyWaiter is a call waiting for capacity
+type concurrencyWaiter struct {
+	class   opClass
+	weight  int
+	ready   chan struct{}
+	granted bool
+}
+
+// ConcurrencyLimiter caps the backend calls in flight so a burst of
+// filesystem operations cannot exhaust connections. Each call takes a weight
+// (the number of objects it touches) from the total and its class limit.
+// Calls queue in arrival order: a call that does not fit the total holds
+// back every later call, and one that only exceeds its class limit holds
+// back later calls of the same class.
+type ConcurrencyLimiter struct {
+	backend types.Backend
+	limits  [2]int
+	limit   int
+	metrics *metrics.Collector
+
+	mu       sync.Mutex
+	inFlight [2]int
+	total    int
+	queued   [2]int
+	waiters  *list.List
+	stats    ConcurrencyStats
+}
+
+// NewConcurrencyLimiter wraps backend, limiting its concurrent calls
+func NewConcurrencyLimiter(backend types.Backend, opts ConcurrencyOptions) (*ConcurrencyLimiter, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	if opts.MaxConcurrency <= 0 {
+		return nil, fmt.Errorf("max concurrency must be greater than 0")
+	}
+	if opts.MaxReads <= 0 || opts.MaxReads > opts.MaxConcurrency {
+		opts.MaxReads = opts.MaxConcurrency
+	}
+	if opts.MaxWrites <= 0 || opts.MaxWrites > opts.MaxConcurrency {
+		opts.MaxWrites = opts.MaxConcurrency
+	}
+
+	return &ConcurrencyLimiter{
+		backend: backend,
+		limit:   opts.MaxConcurrency,
+		limits:  [2]int{classRead: opts.MaxReads, classWrite: opts.MaxWrites},
+		metrics: opts.Metrics,
+		waiters: list.New(),
+	}, nil
+}
+
+// acquire waits until weight slots of class are free and returns the
+// function releasing them
+func (l *ConcurrencyLimiter) acquire(ctx context.Context, class opClass, weight int) (func(), error) {
+	if weight < 1 {
+		weight = 1
+	}
+	if weight > l.limits[class] {
+		weight = l.limits[class]
+	}
+
+	l.mu.Lock()
+	w := &concurrencyWaiter{class: class, weight: weight, ready: make(chan struct{})}
+	elem := l.waiters.PushBack(w)
+	l.queued[class]++
+	l.grantLocked()
+	if w.granted {
+		l.mu.Unlock()
+		return l.releaser(class, weight), nil
+	}
+	l.stats.Waits++
+	if queued := l.waiters.Len(); queued > l.stats.MaxQueued {
+		l.stats.MaxQueued = queued
+	}
+	l.mu.Unlock()
+
+	start := time.Now()
+	select {
+	case <-w.ready:
+		l.recordWait(time.Since(start))
+		return l.releaser(class, weight), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		granted := w.granted
+		if !granted {
+			l.waiters.Remove(elem)
+			l.queued[class]--
+			// Calls queued behind this one may fit now
+			l.grantLocked()
+		}
+		l.mu.Unlock()
+		l.recordWait(time.Since(start))
+		if granted {
+			l.releaser(class, weight)()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// releaser returns a function freeing weight slots of class once
+func (l *ConcurrencyLimiter) releaser(class opClass, weight int) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.total -= weight
+			l.inFlight[class] -= weight
+			l.grantLocked()
+		})
+	}
+}
+
+// grantLocked admits queued calls in arrival order while they fit. The
+// caller must hold l.mu.
+func (l *ConcurrencyLimiter) grantLocked() {
+	var blocked [2]bool
+	for e := l.waiters.Front(); e != nil; {
+		next := e.Next()
+		w := e.Value.(*concurrencyWaiter)
+		switch {
+		case blocked[w.class]:
+		case l.total+w.weight > l.limit:
+			next = nil
+		case l.inFlight[w.class]+w.weight > l.limits[w.class]:
+			blocked[w.class] = true
+		default:
+			l.waiters.Remove(e)
+			l.queued[w.class]--
+			l.total += w.weight
+			l.inFlight[w.class] += w.weight
+			w.granted = true
+			close(w.ready)
+		}
+		e = next
+	}
+
+	if l.metrics != nil {
+		l.metrics.UpdateQueueDepth(classRead.String(), l.queued[classRead])
+		l.metrics.UpdateQueueDepth(classWrite.String(), l.queued[classWrite])
+	}
+}
+
+// recordWait adds d to the time calls spent queued
+func (l *ConcurrencyLimiter) recordWait(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.WaitTime += d
+}
+
+// Stats returns the current limits, in-flight calls, and queue depth
+func (l *ConcurrencyLimiter) Stats() ConcurrencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := l.stats
+	stats.Limit = l.limit
+	stats.ReadLimit = l.limits[classRead]
+	stats.WriteLimit = l.limits[classWrite]
+	stats.InFlight = l.total
+	stats.ReadsInFlight = l.inFlight[classRead]
+	stats.WritesInFlight = l.inFlight[classWrite]
+	stats.QueuedReads = l.queued[classRead]
+	stats.QueuedWrites = l.queued[classWrite]
+	return stats
+}
+
+// GetObject reads from the wrapped backend once a read slot is free
+func (l *ConcurrencyLimiter) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	release, err := l.acquire(ctx, classRead, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return l.backend.GetObject(ctx, key, offset, size)
+}
+
+// PutObject writes to the wrapped backend once a write slot is free
+func (l *ConcurrencyLimiter) PutObject(ctx context.Context, key string, data []byte) error {
+	release, err := l.acquire(ctx, classWrite, 1)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return l.backend.PutObject(ctx, key, data)
+}
+
+// DeleteObject deletes from the wrapped backend once a write slot is free
+func (l *ConcurrencyLimiter) DeleteObject(ctx context.Context, key string) error {
+	release, err := l.acquire(ctx, classWrite, 1)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return l.backend.DeleteObject(ctx, key)
+}
+
+// HeadObject reads metadata once a read slot is free
+func (l *ConcurrencyLimiter) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	release, err := l.acquire(ctx, classRead, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return l.backend.HeadObject(ctx, key)
+}
+
+// GetObjects reads keys, taking one read slot per key up to the read limit
+func (l *ConcurrencyLimiter) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	release, err := l.acquire(ctx, classRead, len(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return l.backend.GetObjects(ctx, keys)
+}
+
+// HeadObjects reads metadata for keys. Backends with a batch metadata API
+// receive the whole batch with one read slot per key, up to the read limit;
+// otherwise each key is a separate HeadObject, queued like any other read.
+func (l *ConcurrencyLimiter) HeadObjects(ctx context.Context, keys []string) (map[string]*types.ObjectInfo, map[string]error) {
+	if header, ok := l.backend.(types.BatchHeader); ok {
+		release, err := l.acquire(ctx, classRead, len(keys))
+		if err != nil {
+			errs := make(map[string]error, len(keys))
+			for _, key := range keys {
+				errs[key] = err
+			}
+			return make(map[string]*types.ObjectInfo), errs
+		}
+		defer release()
+		return header.HeadObjects(ctx, keys)
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		infos = make(map[string]*types.ObjectInfo, len(keys))
+		errs  = make(map[string]error)
+		work  = make(chan string)
+	)
+	for i := 0; i < min(l.limits[classRead], len(keys)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				info, err := l.HeadObject(ctx, key)
+				mu.Lock()
+				if err != nil {
+					errs[key] = err
+				} else {
+					infos[key] = info
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, key := range keys {
+		work <- key
+	}
+	close(work)
+	wg.Wait()
+
+	return infos, errs
+}
+
+// PutObjects writes objects, taking one write slot per object up to the
+// write limit
+func (l *ConcurrencyLimiter) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	release, err := l.acquire(ctx, classWrite, len(objects))
+	if err != nil {
+		return err
+	}
+	defer release()
+	return l.backend.PutObjects(ctx, objects)
+}
+
+// ListObjects lists the wrapped backend once a read slot is free
+func (l *ConcurrencyLimiter) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	release, err := l.acquire(ctx, classRead, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return l.backend.ListObjects(ctx, prefix, limit)
+}
+
+// ListObjectsChan streams the wrapped backend's listing, holding a read
+// slot until the stream ends
+func (l *ConcurrencyLimiter) ListObjectsChan(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	objCh := make(chan types.ObjectInfo)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(objCh)
+		defer close(errCh)
+
+		release, err := l.acquire(ctx, classRead, 1)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer release()
+
+		streamer, ok := l.backend.(types.ObjectStreamer)
+		if !ok {
+			objects, err := l.backend.ListObjects(ctx, prefix, 0)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			for _, obj := range objects {
+				select {
+				case objCh <- obj:
+				case <-ctx.Done():
+					errCh <- ctx.Err()
+					return
+				}
+			}
+			return
+		}
+
+		rawCh, rawErrCh := streamer.ListObjectsChan(ctx, prefix)
+		for obj := range rawCh {
+			select {
+			case objCh <- obj:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+		if err := <-rawErrCh; err != nil {
+			errCh <- err
+		}
+	}()
+
+	return objCh, errCh
+}
+
+// GetObjectIfModified revalidates through the wrapped backend once a read
+// slot is free
+func (l *ConcurrencyLimiter) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := l.backend.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	release, err := l.acquire(ctx, classRead, 1)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	defer release()
+	return getter.GetObjectIfModified(ctx, key, offset, size, since, etag)
+}
+
+// Touch updates the last-modified time of key once a write slot is free
+func (l *ConcurrencyLimiter) Touch(ctx context.Context, key string) error {
+	toucher, ok := l.backend.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	release, err := l.acquire(ctx, classWrite, 1)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return toucher.Touch(ctx, key)
+}
+
+// MoveObject moves srcKey to dstKey once a write slot is free
+func (l *ConcurrencyLimiter) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	mover, ok := l.backend.(types.ObjectMover)
+	if !ok {
+		return fmt.Errorf("backend does not support move")
+	}
+	release, err := l.acquire(ctx, classWrite, 1)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return mover.MoveObject(ctx, srcKey, dstKey)
+}
+
+// WriteAt writes part of key once a write slot is free
+func (l *ConcurrencyLimiter) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	writer, ok := l.backend.(types.RangeWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support range writes")
+	}
+	release, err := l.acquire(ctx, classWrite, 1)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return writer.WriteAt(ctx, key, offset, data)
+}
+
+// HealthCheck checks the wrapped backend without waiting for a slot
+func (l *ConcurrencyLimiter) HealthCheck(ctx context.Context) error {
+	return l.backend.HealthCheck(ctx)
+}
@@ -0,0 +1,181 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// slowBackend is a memoryBackend whose reads and writes hold until
+// released, tracking how many are in flight
+type slowBackend struct {
+	*memoryBackend
+	delay       time.Duration
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	reads       atomic.Int32
+	maxReads    atomic.Int32
+}
+
+func (b *slowBackend) enter(counter, peak *atomic.Int32) func() {
+	n := counter.Add(1)
+	for {
+		if old := peak.Load(); n <= old || peak.CompareAndSwap(old, n) {
+			break
+		}
+	}
+	return func() { counter.Add(-1) }
+}
+
+func (b *slowBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	defer b.enter(&b.inFlight, &b.maxInFlight)()
+	defer b.enter(&b.reads, &b.maxReads)()
+	time.Sleep(b.delay)
+	return b.memoryBackend.GetObject(ctx, key, offset, size)
+}
+
+func (b *slowBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	defer b.enter(&b.inFlight, &b.maxInFlight)()
+	time.Sleep(b.delay)
+	return b.memoryBackend.HeadObject(ctx, key)
+}
+
+func (b *slowBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	defer b.enter(&b.inFlight, &b.maxInFlight)()
+	time.Sleep(b.delay)
+	return b.memoryBackend.PutObject(ctx, key, data)
+}
+
+func TestConcurrencyLimiterCapsInFlightReads(t *testing.T) {
+	backend := &slowBackend{memoryBackend: newMemoryBackend(map[string]string{"a.txt": "a"}), delay: 5 * time.Millisecond}
+	limiter, err := NewConcurrencyLimiter(backend, ConcurrencyOptions{MaxConcurrency: 4})
+	if err != nil {
+		t.Fatalf("NewConcurrencyLimiter failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := limiter.GetObject(context.Background(), "a.txt", 0, -1); err != nil {
+				t.Errorf("GetObject failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := backend.maxInFlight.Load(); peak > 4 {
+		t.Errorf("%d backend calls were in flight, want at most 4", peak)
+	}
+	stats := limiter.Stats()
+	if stats.InFlight != 0 || stats.QueuedReads != 0 {
+		t.Errorf("stats after completion = %+v, want nothing in flight or queued", stats)
+	}
+	if stats.Waits == 0 || stats.MaxQueued == 0 {
+		t.Errorf("stats = %+v, want reads to have queued", stats)
+	}
+}
+
+func TestConcurrencyLimiterReadSubLimit(t *testing.T) {
+	backend := &slowBackend{memoryBackend: newMemoryBackend(map[string]string{"a.txt": "a"}), delay: 5 * time.Millisecond}
+	limiter, err := NewConcurrencyLimiter(backend, ConcurrencyOptions{MaxConcurrency: 4, MaxReads: 2})
+	if err != nil {
+		t.Fatalf("NewConcurrencyLimiter failed: %v", err)
+	}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var writesDone atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = limiter.GetObject(ctx, "a.txt", 0, -1)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			if err := limiter.PutObject(ctx, fmt.Sprintf("w%d", i), []byte("w")); err == nil {
+				writesDone.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak := backend.maxReads.Load(); peak > 2 {
+		t.Errorf("%d reads were in flight, want at most 2", peak)
+	}
+	if peak := backend.maxInFlight.Load(); peak > 4 {
+		t.Errorf("%d calls were in flight, want at most 4", peak)
+	}
+	if writesDone.Load() != 20 {
+		t.Errorf("%d writes completed, want 20", writesDone.Load())
+	}
+}
+
+func TestConcurrencyLimiterCancelledWaiterLeavesQueue(t *testing.T) {
+	limiter, err := NewConcurrencyLimiter(newMemoryBackend(nil), ConcurrencyOptions{MaxConcurrency: 1})
+	if err != nil {
+		t.Fatalf("NewConcurrencyLimiter failed: %v", err)
+	}
+	release, err := limiter.acquire(context.Background(), classWrite, 1)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx, classRead, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued acquire error = %v, want deadline exceeded", err)
+	}
+	if stats := limiter.Stats(); stats.QueuedReads != 0 {
+		t.Errorf("queued reads = %d after cancellation, want 0", stats.QueuedReads)
+	}
+
+	release()
+	release() // Releasing twice frees the slot once
+	if stats := limiter.Stats(); stats.InFlight != 0 {
+		t.Errorf("in flight = %d after release, want 0", stats.InFlight)
+	}
+	if _, err := limiter.acquire(context.Background(), classRead, 1); err != nil {
+		t.Errorf("acquire after release failed: %v", err)
+	}
+}
+
+func TestConcurrencyLimiterHeadObjectsBoundsRequests(t *testing.T) {
+	objects := make(map[string]string)
+	keys := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("dir/file%02d", i)
+		objects[key] = strings.Repeat("x", i)
+		keys = append(keys, key)
+	}
+	backend := &slowBackend{memoryBackend: newMemoryBackend(objects), delay: 2 * time.Millisecond}
+	limiter, err := NewConcurrencyLimiter(backend, ConcurrencyOptions{MaxConcurrency: 8, MaxReads: 4})
+	if err != nil {
+		t.Fatalf("NewConcurrencyLimiter failed: %v", err)
+	}
+
+	infos, errs := limiter.HeadObjects(context.Background(), append(keys, "dir/missing"))
+	if len(infos) != len(keys) {
+		t.Fatalf("got metadata for %d keys, want %d", len(infos), len(keys))
+	}
+	for i, key := range keys {
+		if info := infos[key]; info == nil || info.Size != int64(i) {
+			t.Errorf("%s metadata = %+v, want size %d", key, info, i)
+		}
+	}
+	if len(errs) != 1 || errs["dir/missing"] == nil {
+		t.Errorf("errors = %v, want only dir/missing", errs)
+	}
+	if peak := backend.maxInFlight.Load(); peak > 4 || peak < 2 {
+		t.Errorf("%d HEAD requests were in flight, want concurrent requests up to the read limit of 4", peak)
+	}
+}
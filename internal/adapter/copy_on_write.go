@@ -0,0 +1,522 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// DefaultCopyOnWritePrefix is where edited objects are kept when no overlay
+// prefix is configured
+const DefaultCopyOnWritePrefix = ".objectfs-overlay/"
+
+// Subdirectories of the overlay prefix holding edited copies and the
+// markers of deleted base objects
+const (
+	overlayDataDir     = "data/"
+	overlayWhiteoutDir = "whiteout/"
+)
+
+// CopyOnWriteStats reports the changes held in the overlay
+type CopyOnWriteStats struct {
+	Prefix  string `json:"prefix"`
+	Edited  int    `json:"edited"`  // Keys read from their overlay copy
+	Deleted int    `json:"deleted"` // Base keys hidden by a deletion
+}
+
+// CopyOnWriteBackend serves a shared, read-mostly dataset while keeping this
+// mount's edits apart from it. Reads come from the base keys until a key is
+// first written; the write then goes to a copy under the overlay prefix of
+// the same bucket, copying the base object first when only part of it is
+// written, and later reads and writes of the key use the copy. Deleting a
+// base key records a whiteout instead. The base keys are never modified
+// until Commit; Discard drops the edits.
+type CopyOnWriteBackend struct {
+	backend types.Backend
+	prefix  string
+
+	// Writes hold changes for reading while they update the overlay, so
+	// Commit and Discard see every write either fully or not at all
+	changes sync.RWMutex
+
+	mu        sync.RWMutex
+	edited    map[string]struct{} // Keys with a copy in the overlay
+	whiteouts map[string]struct{} // Base keys deleted in the overlay
+}
+
+// NewCopyOnWriteBackend creates a copy-on-write overlay over backend under
+// prefix, resuming the edits a previous mount left there
+func NewCopyOnWriteBackend(ctx context.Context, backend types.Backend, prefix string) (*CopyOnWriteBackend, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	prefix = strings.TrimPrefix(prefix, "/")
+	if prefix == "" {
+		prefix = DefaultCopyOnWritePrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	c := &CopyOnWriteBackend{
+		backend:   backend,
+		prefix:    prefix,
+		edited:    make(map[string]struct{}),
+		whiteouts: make(map[string]struct{}),
+	}
+
+	objects, err := listAll(ctx, backend, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overlay %s: %w", prefix, err)
+	}
+	for _, obj := range objects {
+		rest := strings.TrimPrefix(obj.Key, prefix)
+		switch {
+		case strings.HasPrefix(rest, overlayDataDir):
+			c.edited[strings.TrimPrefix(rest, overlayDataDir)] = struct{}{}
+		case strings.HasPrefix(rest, overlayWhiteoutDir):
+			c.whiteouts[strings.TrimPrefix(rest, overlayWhiteoutDir)] = struct{}{}
+		}
+	}
+	return c, nil
+}
+
+// Prefix returns the key prefix the overlay is stored under
+func (c *CopyOnWriteBackend) Prefix() string {
+	return c.prefix
+}
+
+// Stats returns how many changes the overlay holds
+func (c *CopyOnWriteBackend) Stats() CopyOnWriteStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return CopyOnWriteStats{Prefix: c.prefix, Edited: len(c.edited), Deleted: len(c.whiteouts)}
+}
+
+// dataKey returns where the overlay copy of key is stored
+func (c *CopyOnWriteBackend) dataKey(key string) string {
+	return c.prefix + overlayDataDir + key
+}
+
+// whiteoutKey returns where the deletion marker of key is stored
+func (c *CopyOnWriteBackend) whiteoutKey(key string) string {
+	return c.prefix + overlayWhiteoutDir + key
+}
+
+// resolve returns the key that currently stores key's content, or false
+// when key was deleted in the overlay or lies inside the overlay itself
+func (c *CopyOnWriteBackend) resolve(key string) (string, bool) {
+	if strings.HasPrefix(key, c.prefix) {
+		return "", false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if _, ok := c.edited[key]; ok {
+		return c.dataKey(key), true
+	}
+	if _, ok := c.whiteouts[key]; ok {
+		return "", false
+	}
+	return key, true
+}
+
+// hiddenByOverlay returns the error for a key the overlay hides
+func hiddenByOverlay(key string) error {
+	return errors.NewError(errors.ErrCodeObjectNotFound, "object not found").
+		WithComponent("copy-on-write").
+		WithContext("key", key)
+}
+
+// GetObject reads key from its overlay copy when it was edited, or from
+// the base otherwise
+func (c *CopyOnWriteBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	stored, ok := c.resolve(key)
+	if !ok {
+		return nil, hiddenByOverlay(key)
+	}
+	return c.backend.GetObject(ctx, stored, offset, size)
+}
+
+// HeadObject returns metadata of key's overlay copy or base object
+func (c *CopyOnWriteBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	stored, ok := c.resolve(key)
+	if !ok {
+		return nil, hiddenByOverlay(key)
+	}
+	info, err := c.backend.HeadObject(ctx, stored)
+	if err != nil {
+		return nil, err
+	}
+	info.Key = key
+	return info, nil
+}
+
+// GetObjectIfModified revalidates key against its overlay copy when it was
+// edited, or against the base otherwise
+func (c *CopyOnWriteBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := c.backend.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	stored, ok := c.resolve(key)
+	if !ok {
+		return nil, false, nil, hiddenByOverlay(key)
+	}
+	data, notModified, info, err := getter.GetObjectIfModified(ctx, stored, offset, size, since, etag)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if info != nil {
+		info.Key = key
+	}
+	return data, notModified, info, nil
+}
+
+// Touch updates the last-modified time of key's overlay copy. Touching a
+// base object copies it into the overlay, so the base stays untouched.
+func (c *CopyOnWriteBackend) Touch(ctx context.Context, key string) error {
+	if err := c.writable(key); err != nil {
+		return err
+	}
+	c.changes.RLock()
+	defer c.changes.RUnlock()
+
+	stored, ok := c.resolve(key)
+	if !ok {
+		return hiddenByOverlay(key)
+	}
+	if stored != key {
+		toucher, ok := c.backend.(types.ObjectToucher)
+		if !ok {
+			return fmt.Errorf("backend does not support touch")
+		}
+		return toucher.Touch(ctx, stored)
+	}
+
+	data, err := c.backend.GetObject(ctx, key, 0, 0)
+	if err != nil {
+		return err
+	}
+	if err := c.backend.PutObject(ctx, c.dataKey(key), data); err != nil {
+		return err
+	}
+	return c.edit(ctx, key)
+}
+
+// edit records that key now lives in the overlay, dropping any whiteout
+func (c *CopyOnWriteBackend) edit(ctx context.Context, key string) error {
+	c.mu.Lock()
+	c.edited[key] = struct{}{}
+	_, whiteout := c.whiteouts[key]
+	delete(c.whiteouts, key)
+	c.mu.Unlock()
+
+	if whiteout {
+		return c.backend.DeleteObject(ctx, c.whiteoutKey(key))
+	}
+	return nil
+}
+
+// writable rejects writes to keys inside the overlay itself
+func (c *CopyOnWriteBackend) writable(key string) error {
+	if strings.HasPrefix(key, c.prefix) {
+		return fmt.Errorf("cannot write %s inside the copy-on-write overlay %s", key, c.prefix)
+	}
+	return nil
+}
+
+// PutObject writes key to the overlay, leaving any base object untouched
+func (c *CopyOnWriteBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	if err := c.writable(key); err != nil {
+		return err
+	}
+	c.changes.RLock()
+	defer c.changes.RUnlock()
+
+	if err := c.backend.PutObject(ctx, c.dataKey(key), data); err != nil {
+		return err
+	}
+	return c.edit(ctx, key)
+}
+
+// PutObjectWithMetadata writes key and its metadata to the overlay
+func (c *CopyOnWriteBackend) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	writer, ok := c.backend.(types.ObjectMetadataWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support object metadata")
+	}
+	if err := c.writable(key); err != nil {
+		return err
+	}
+	c.changes.RLock()
+	defer c.changes.RUnlock()
+
+	if err := writer.PutObjectWithMetadata(ctx, c.dataKey(key), data, metadata); err != nil {
+		return err
+	}
+	return c.edit(ctx, key)
+}
+
+// WriteAt writes part of key. The first write to a base object copies it
+// into the overlay with the write applied; later writes patch the copy.
+func (c *CopyOnWriteBackend) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	if err := c.writable(key); err != nil {
+		return err
+	}
+	c.changes.RLock()
+	defer c.changes.RUnlock()
+
+	stored, ok := c.resolve(key)
+	if ok && stored != key {
+		if writer, ok := c.backend.(types.RangeWriter); ok {
+			return writer.WriteAt(ctx, stored, offset, data)
+		}
+	}
+
+	var current []byte
+	if ok {
+		var err error
+		current, err = c.backend.GetObject(ctx, stored, 0, 0)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	if err := c.backend.PutObject(ctx, c.dataKey(key), patchBytes(current, offset, data)); err != nil {
+		return err
+	}
+	return c.edit(ctx, key)
+}
+
+// DeleteObject deletes key's overlay copy and hides its base object
+func (c *CopyOnWriteBackend) DeleteObject(ctx context.Context, key string) error {
+	if err := c.writable(key); err != nil {
+		return err
+	}
+	c.changes.RLock()
+	defer c.changes.RUnlock()
+
+	c.mu.RLock()
+	_, edited := c.edited[key]
+	_, whiteout := c.whiteouts[key]
+	c.mu.RUnlock()
+
+	if edited {
+		if err := c.backend.DeleteObject(ctx, c.dataKey(key)); err != nil && !isNotFound(err) {
+			return err
+		}
+		c.mu.Lock()
+		delete(c.edited, key)
+		c.mu.Unlock()
+	}
+	if whiteout {
+		return nil
+	}
+
+	if _, err := c.backend.HeadObject(ctx, key); err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if err := c.backend.PutObject(ctx, c.whiteoutKey(key), []byte{}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.whiteouts[key] = struct{}{}
+	c.mu.Unlock()
+	return nil
+}
+
+// MoveObject moves srcKey to dstKey within the overlay by copying
+func (c *CopyOnWriteBackend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	return moveByCopy(ctx, c, srcKey, dstKey)
+}
+
+// GetObjects reads each key through the overlay
+func (c *CopyOnWriteBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	results := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		data, err := c.GetObject(ctx, key, 0, 0)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		results[key] = data
+	}
+	return results, nil
+}
+
+// PutObjects writes each object to the overlay
+func (c *CopyOnWriteBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		if err := c.PutObject(ctx, key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListObjects lists the base keys under prefix with edited keys replaced by
+// their overlay copies and deleted keys left out. The overlay itself is
+// never listed. Both are listed in full and limit applies to the merged
+// listing, since whiteouts and edits may hide any of the first base keys.
+func (c *CopyOnWriteBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	base, err := c.backend.ListObjects(ctx, prefix, 0)
+	if err != nil {
+		return nil, err
+	}
+	copies, err := c.backend.ListObjects(ctx, c.dataKey(prefix), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	objects := make([]types.ObjectInfo, 0, len(base)+len(copies))
+	for _, obj := range base {
+		_, edited := c.edited[obj.Key]
+		_, whiteout := c.whiteouts[obj.Key]
+		if edited || whiteout || strings.HasPrefix(obj.Key, c.prefix) {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	c.mu.RUnlock()
+	for _, obj := range copies {
+		obj.Key = strings.TrimPrefix(obj.Key, c.prefix+overlayDataDir)
+		objects = append(objects, obj)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	if limit > 0 && len(objects) > limit {
+		objects = objects[:limit]
+	}
+	return objects, nil
+}
+
+// HealthCheck checks the underlying backend
+func (c *CopyOnWriteBackend) HealthCheck(ctx context.Context) error {
+	return c.backend.HealthCheck(ctx)
+}
+
+// Commit applies the overlay to the base keys, writing each edited copy
+// over its base object and deleting each whiteout's base object, then
+// clears the overlay. It returns how many keys were changed. A failed
+// commit leaves the changes not yet applied in the overlay, so it can be
+// retried.
+func (c *CopyOnWriteBackend) Commit(ctx context.Context) (int, error) {
+	c.changes.Lock()
+	defer c.changes.Unlock()
+
+	edited, whiteouts := c.pending()
+	committed := 0
+	for _, key := range edited {
+		data, err := c.backend.GetObject(ctx, c.dataKey(key), 0, 0)
+		if err != nil {
+			return committed, fmt.Errorf("failed to read overlay copy of %s: %w", key, err)
+		}
+		if err := c.backend.PutObject(ctx, key, data); err != nil {
+			return committed, fmt.Errorf("failed to commit %s: %w", key, err)
+		}
+		if err := c.drop(ctx, key); err != nil {
+			return committed, err
+		}
+		committed++
+	}
+	for _, key := range whiteouts {
+		if err := c.backend.DeleteObject(ctx, key); err != nil && !isNotFound(err) {
+			return committed, fmt.Errorf("failed to commit deletion of %s: %w", key, err)
+		}
+		if err := c.drop(ctx, key); err != nil {
+			return committed, err
+		}
+		committed++
+	}
+	return committed, nil
+}
+
+// Discard drops every change in the overlay, so reads see the base again,
+// and returns the keys whose content reverted
+func (c *CopyOnWriteBackend) Discard(ctx context.Context) ([]string, error) {
+	c.changes.Lock()
+	defer c.changes.Unlock()
+
+	edited, whiteouts := c.pending()
+	var discarded []string
+	for _, key := range append(edited, whiteouts...) {
+		if err := c.drop(ctx, key); err != nil {
+			return discarded, err
+		}
+		discarded = append(discarded, key)
+	}
+	return discarded, nil
+}
+
+// pending returns the edited and deleted keys, sorted
+func (c *CopyOnWriteBackend) pending() (edited, whiteouts []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for key := range c.edited {
+		edited = append(edited, key)
+	}
+	for key := range c.whiteouts {
+		whiteouts = append(whiteouts, key)
+	}
+	sort.Strings(edited)
+	sort.Strings(whiteouts)
+	return edited, whiteouts
+}
+
+// drop removes key's overlay copy or whiteout
+func (c *CopyOnWriteBackend) drop(ctx context.Context, key string) error {
+	c.mu.RLock()
+	_, edited := c.edited[key]
+	c.mu.RUnlock()
+
+	stored := c.whiteoutKey(key)
+	if edited {
+		stored = c.dataKey(key)
+	}
+	if err := c.backend.DeleteObject(ctx, stored); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove overlay entry for %s: %w", key, err)
+	}
+
+	c.mu.Lock()
+	delete(c.edited, key)
+	delete(c.whiteouts, key)
+	c.mu.Unlock()
+	return nil
+}
+
+// CommitOverlay applies this mount's copy-on-write edits to the shared
+// dataset and returns how many keys changed
+func (a *Adapter) CommitOverlay(ctx context.Context) (int, error) {
+	if a.cow == nil {
+		return 0, fmt.Errorf("copy-on-write overlay is not enabled")
+	}
+	return a.cow.Commit(ctx)
+}
+
+// DiscardOverlay drops this mount's copy-on-write edits, evicting the
+// reverted keys from the cache so reads see the shared dataset again
+func (a *Adapter) DiscardOverlay(ctx context.Context) error {
+	if a.cow == nil {
+		return fmt.Errorf("copy-on-write overlay is not enabled")
+	}
+	discarded, err := a.cow.Discard(ctx)
+	if a.cache != nil {
+		for _, key := range discarded {
+			a.cache.Delete(key)
+		}
+	}
+	return err
+}
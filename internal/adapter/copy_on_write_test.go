@@ -0,0 +1,226 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// listedKeys returns the keys c lists under prefix
+func listedKeys(t *testing.T, c *CopyOnWriteBackend, prefix string) []string {
+	t.Helper()
+	objects, err := c.ListObjects(context.Background(), prefix, 0)
+	if err != nil {
+		t.Fatalf("ListObjects(%q) failed: %v", prefix, err)
+	}
+	keys := make([]string, len(objects))
+	for i, obj := range objects {
+		keys[i] = obj.Key
+	}
+	return keys
+}
+
+// limitedListBackend returns at most limit keys from each listing
+type limitedListBackend struct {
+	*memoryBackend
+}
+
+func (b *limitedListBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	objects, err := b.memoryBackend.ListObjects(ctx, prefix, limit)
+	if limit > 0 && len(objects) > limit {
+		objects = objects[:limit]
+	}
+	return objects, err
+}
+
+func TestCopyOnWriteKeepsBaseUntouched(t *testing.T) {
+	backend := newMemoryBackend(map[string]string{
+		"shared/config.txt": "base config",
+		"shared/data.bin":   "0123456789",
+		"shared/old.log":    "old",
+	})
+	ctx := context.Background()
+	c, err := NewCopyOnWriteBackend(ctx, backend, "overlays/node-1")
+	if err != nil {
+		t.Fatalf("NewCopyOnWriteBackend failed: %v", err)
+	}
+
+	// A partial write copies the base object into the overlay first
+	if err := c.WriteAt(ctx, "shared/data.bin", 2, []byte("ab")); err != nil {
+		t.Fatalf("WriteAt failed: %v", err)
+	}
+	if err := c.PutObject(ctx, "shared/config.txt", []byte("local config")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := c.DeleteObject(ctx, "shared/old.log"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	if got := string(backend.objects["overlays/node-1/data/shared/data.bin"]); got != "01ab456789" {
+		t.Errorf("overlay copy = %q, want the base content with the write applied", got)
+	}
+	for key, want := range map[string]string{
+		"shared/config.txt": "base config",
+		"shared/data.bin":   "0123456789",
+		"shared/old.log":    "old",
+	} {
+		if got := string(backend.objects[key]); got != want {
+			t.Errorf("base %s = %q, want it untouched (%q)", key, got, want)
+		}
+	}
+
+	// Later reads see the overlay, and writes keep patching the copy
+	if err := c.WriteAt(ctx, "shared/data.bin", 8, []byte("yz")); err != nil {
+		t.Fatalf("second WriteAt failed: %v", err)
+	}
+	for key, want := range map[string]string{
+		"shared/config.txt": "local config",
+		"shared/data.bin":   "01ab4567yz",
+	} {
+		data, err := c.GetObject(ctx, key, 0, 0)
+		if err != nil || string(data) != want {
+			t.Errorf("GetObject(%s) = %q, %v; want %q", key, data, err, want)
+		}
+	}
+	if _, err := c.HeadObject(ctx, "shared/old.log"); !isNotFound(err) {
+		t.Errorf("HeadObject of a deleted base key error = %v, want not found", err)
+	}
+	if keys := listedKeys(t, c, ""); len(keys) != 2 || keys[0] != "shared/config.txt" || keys[1] != "shared/data.bin" {
+		t.Errorf("listing = %v, want the edited keys without the overlay or deleted key", keys)
+	}
+
+	// A remount resumes the overlay
+	remounted, err := NewCopyOnWriteBackend(ctx, backend, "overlays/node-1/")
+	if err != nil {
+		t.Fatalf("NewCopyOnWriteBackend failed: %v", err)
+	}
+	if stats := remounted.Stats(); stats.Edited != 2 || stats.Deleted != 1 {
+		t.Errorf("remounted overlay = %+v, want 2 edited and 1 deleted", stats)
+	}
+}
+
+func TestCopyOnWriteCommitAndDiscard(t *testing.T) {
+	backend := newMemoryBackend(map[string]string{
+		"shared/a": "base a",
+		"shared/b": "base b",
+	})
+	ctx := context.Background()
+	c, err := NewCopyOnWriteBackend(ctx, backend, "")
+	if err != nil {
+		t.Fatalf("NewCopyOnWriteBackend failed: %v", err)
+	}
+
+	if err := c.PutObject(ctx, "shared/a", []byte("edited a")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if discarded, err := c.Discard(ctx); err != nil || len(discarded) != 1 || discarded[0] != "shared/a" {
+		t.Fatalf("Discard = %v, %v; want shared/a", discarded, err)
+	}
+	if data, _ := c.GetObject(ctx, "shared/a", 0, 0); string(data) != "base a" {
+		t.Errorf("after discard GetObject = %q, want the base content", data)
+	}
+
+	if err := c.PutObject(ctx, "shared/a", []byte("edited a")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := c.DeleteObject(ctx, "shared/b"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	committed, err := c.Commit(ctx)
+	if err != nil || committed != 2 {
+		t.Fatalf("Commit = %d, %v; want 2 changes", committed, err)
+	}
+	if got := string(backend.objects["shared/a"]); got != "edited a" {
+		t.Errorf("committed base a = %q", got)
+	}
+	if _, ok := backend.objects["shared/b"]; ok {
+		t.Error("committed deletion left base b")
+	}
+	if len(backend.objects) != 1 {
+		t.Errorf("bucket after commit = %v, want only shared/a", backend.objects)
+	}
+	if stats := c.Stats(); stats.Edited != 0 || stats.Deleted != 0 {
+		t.Errorf("overlay after commit = %+v, want empty", stats)
+	}
+}
+
+func TestCopyOnWriteRevalidatesAndTouchesResolvedKeys(t *testing.T) {
+	backend := newMemoryBackend(map[string]string{
+		"shared/edited.txt": "base edited",
+		"shared/plain.txt":  "base plain",
+		"shared/gone.txt":   "base gone",
+	})
+	ctx := context.Background()
+	c, err := NewCopyOnWriteBackend(ctx, backend, "")
+	if err != nil {
+		t.Fatalf("NewCopyOnWriteBackend failed: %v", err)
+	}
+	if err := c.PutObject(ctx, "shared/edited.txt", []byte("local edited")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := c.DeleteObject(ctx, "shared/gone.txt"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	data, _, info, err := c.GetObjectIfModified(ctx, "shared/edited.txt", 6, 6, time.Time{}, "")
+	if err != nil || string(data) != "edited" || info.Key != "shared/edited.txt" {
+		t.Errorf("GetObjectIfModified(edited) = %q, %+v, %v; want the overlay copy's range under the base key", data, info, err)
+	}
+	if _, _, _, err := c.GetObjectIfModified(ctx, "shared/gone.txt", 0, 0, time.Time{}, ""); !isNotFound(err) {
+		t.Errorf("GetObjectIfModified of a deleted base key error = %v, want not found", err)
+	}
+
+	if err := c.Touch(ctx, "shared/edited.txt"); err != nil {
+		t.Fatalf("Touch(edited) failed: %v", err)
+	}
+	if want := DefaultCopyOnWritePrefix + "data/shared/edited.txt"; fmt.Sprint(backend.touched) != fmt.Sprint([]string{want}) {
+		t.Errorf("touched %v, want only the overlay copy %s", backend.touched, want)
+	}
+	if err := c.Touch(ctx, "shared/plain.txt"); err != nil {
+		t.Fatalf("Touch(plain) failed: %v", err)
+	}
+	if got := string(backend.objects[DefaultCopyOnWritePrefix+"data/shared/plain.txt"]); got != "base plain" || len(backend.touched) != 1 {
+		t.Errorf("touching a base key copied %q and touched %v, want a copy in the overlay and the base left alone", got, backend.touched)
+	}
+	if err := c.Touch(ctx, "shared/gone.txt"); !isNotFound(err) {
+		t.Errorf("Touch of a deleted base key error = %v, want not found", err)
+	}
+}
+
+func TestCopyOnWriteListLimitCountsLiveKeys(t *testing.T) {
+	backend := &limitedListBackend{newMemoryBackend(map[string]string{
+		"shared/1": "one",
+		"shared/2": "two",
+		"shared/3": "three",
+		"shared/4": "four",
+		"shared/5": "five",
+	})}
+	ctx := context.Background()
+	c, err := NewCopyOnWriteBackend(ctx, backend, "")
+	if err != nil {
+		t.Fatalf("NewCopyOnWriteBackend failed: %v", err)
+	}
+	for _, key := range []string{"shared/1", "shared/2"} {
+		if err := c.DeleteObject(ctx, key); err != nil {
+			t.Fatalf("DeleteObject(%s) failed: %v", key, err)
+		}
+	}
+	if err := c.PutObject(ctx, "shared/3", []byte("edited")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	objects, err := c.ListObjects(ctx, "shared/", 3)
+	if err != nil {
+		t.Fatalf("ListObjects failed: %v", err)
+	}
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	if want := []string{"shared/3", "shared/4", "shared/5"}; fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("listing with limit 3 = %v, want %v", keys, want)
+	}
+}
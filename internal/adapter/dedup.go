@@ -0,0 +1,784 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+const (
+	// contentLayout marks objects stored as a reference to a content object
+	contentLayout = "content"
+	// contentMetadataKey holds the content reference, "<hash>:<hex digest>"
+	contentMetadataKey = "objectfs-content"
+	// contentSizeMetadataKey holds the size of the referenced content
+	contentSizeMetadataKey = "objectfs-content-size"
+
+	defaultDedupHash       = "sha256"
+	defaultDedupPrefix     = ".objectfs/content/"
+	defaultDedupMinSize    = 4096
+	defaultDedupGCInterval = 24 * time.Hour
+	defaultDedupGCGrace    = time.Hour
+
+	// dedupLockStripes is the number of locks serializing writes by key and
+	// reference counting by content
+	dedupLockStripes = 64
+)
+
+// dedupHashes are the supported content hashes
+var dedupHashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// DedupOptions configures a DedupBackend
+type DedupOptions struct {
+	Hash       string        // Content hash: sha256 (default) or sha512
+	Prefix     string        // Key prefix content objects and references are stored under (default .objectfs/content/)
+	MinSize    int64         // Smaller objects are stored whole (default 4KB)
+	GCInterval time.Duration // How often unreferenced content is collected (default 24h; negative disables)
+	GCGrace    time.Duration // Content and references younger than this are never collected (default 1h)
+	Logger     *slog.Logger  // Receives garbage collection results; optional
+}
+
+// DedupStats reports the effect of deduplication
+type DedupStats struct {
+	Stored       uint64    `json:"stored"`         // Content objects written
+	Deduplicated uint64    `json:"deduplicated"`   // Writes that reused stored content
+	BytesSaved   int64     `json:"bytes_saved"`    // Bytes not uploaded because the content was stored
+	Released     uint64    `json:"released"`       // Content objects deleted with their last reference
+	Collected    uint64    `json:"collected"`      // Content objects deleted by garbage collection
+	StaleRefs    uint64    `json:"stale_refs"`     // Reference markers dropped by garbage collection
+	LastGC       time.Time `json:"last_gc"`        // When garbage collection last completed
+	LastGCErrors int       `json:"last_gc_errors"` // Objects the last collection failed to delete
+}
+
+// DedupBackend stores each distinct content once. An object at least
+// MinSize bytes is hashed and stored under <prefix>objects/<hash>/<digest>;
+// its key then holds a reference: the body and objectfs-content metadata
+// are "<hash>:<digest>", with objectfs-layout set to "content". Reads
+// resolve the reference, and HeadObject and listings report the content
+// size. Each key referencing a content object has an empty marker at
+// <prefix>refs/<hash>/<digest>/<escaped key>, and the content object is
+// deleted along with its last marker. Garbage collection removes markers
+// whose key no longer holds the reference and content left without
+// markers, as interrupted writes or concurrent mounts can leave behind.
+type DedupBackend struct {
+	backend types.Backend
+	writer  types.ObjectMetadataWriter
+	hash    string
+	newHash func() hash.Hash
+	prefix  string
+	minSize int64
+	gcGrace time.Duration
+	logger  *slog.Logger
+
+	// Serialize writes by key, and reference counting by content
+	keyLocks     [dedupLockStripes]sync.Mutex
+	contentLocks [dedupLockStripes]sync.Mutex
+
+	mu    sync.Mutex
+	refs  map[string]string // Key to content reference; empty for keys stored whole
+	sizes map[string]int64  // Content reference to size
+	stats DedupStats
+
+	stopCh    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewDedupBackend wraps backend, which must store object metadata, and
+// starts collecting unreferenced content
+func NewDedupBackend(backend types.Backend, options DedupOptions) (*DedupBackend, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	writer, ok := backend.(types.ObjectMetadataWriter)
+	if !ok {
+		return nil, fmt.Errorf("backend does not support object metadata")
+	}
+
+	if options.Hash == "" {
+		options.Hash = defaultDedupHash
+	}
+	newHash, ok := dedupHashes[options.Hash]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dedup hash: %s (must be sha256 or sha512)", options.Hash)
+	}
+	if options.Prefix == "" {
+		options.Prefix = defaultDedupPrefix
+	}
+	if !strings.HasSuffix(options.Prefix, "/") {
+		options.Prefix += "/"
+	}
+	if options.MinSize <= 0 {
+		options.MinSize = defaultDedupMinSize
+	}
+	if options.GCInterval == 0 {
+		options.GCInterval = defaultDedupGCInterval
+	}
+	if options.GCGrace <= 0 {
+		options.GCGrace = defaultDedupGCGrace
+	}
+	if options.Logger == nil {
+		options.Logger = slog.Default()
+	}
+
+	d := &DedupBackend{
+		backend: backend,
+		writer:  writer,
+		hash:    options.Hash,
+		newHash: newHash,
+		prefix:  options.Prefix,
+		minSize: options.MinSize,
+		gcGrace: options.GCGrace,
+		logger:  options.Logger.With("component", "dedup"),
+		refs:    make(map[string]string),
+		sizes:   make(map[string]int64),
+		stopCh:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if options.GCInterval > 0 {
+		go d.gcLoop(options.GCInterval)
+	} else {
+		close(d.done)
+	}
+	return d, nil
+}
+
+// Stats returns deduplication counters
+func (d *DedupBackend) Stats() DedupStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// Close stops garbage collection
+func (d *DedupBackend) Close() error {
+	d.closeOnce.Do(func() {
+		close(d.stopCh)
+		<-d.done
+	})
+	return nil
+}
+
+// keyLock returns the lock serializing writes to key
+func (d *DedupBackend) keyLock(key string) *sync.Mutex {
+	return &d.keyLocks[dedupStripe(key)]
+}
+
+// contentLock returns the lock serializing reference counting of ref
+func (d *DedupBackend) contentLock(ref string) *sync.Mutex {
+	return &d.contentLocks[dedupStripe(ref)]
+}
+
+// dedupStripe returns the lock stripe of s
+func dedupStripe(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32() % dedupLockStripes
+}
+
+// reference returns the content reference of data
+func (d *DedupBackend) reference(data []byte) string {
+	h := d.newHash()
+	_, _ = h.Write(data)
+	return d.hash + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// splitReference returns the hash and digest of ref
+func splitReference(ref string) (string, string, error) {
+	hashName, digest, ok := strings.Cut(ref, ":")
+	if !ok || hashName == "" || digest == "" || strings.Contains(digest, "/") {
+		return "", "", fmt.Errorf("invalid content reference %q", ref)
+	}
+	return hashName, digest, nil
+}
+
+// contentKey returns the key the content of ref is stored at
+func (d *DedupBackend) contentKey(ref string) string {
+	hashName, digest, _ := splitReference(ref)
+	return d.prefix + "objects/" + hashName + "/" + digest
+}
+
+// markersPrefix returns the prefix of the reference markers of ref
+func (d *DedupBackend) markersPrefix(ref string) string {
+	hashName, digest, _ := splitReference(ref)
+	return d.prefix + "refs/" + hashName + "/" + digest + "/"
+}
+
+// markerKey returns the key marking that key references ref
+func (d *DedupBackend) markerKey(ref, key string) string {
+	return d.markersPrefix(ref) + url.PathEscape(key)
+}
+
+func (d *DedupBackend) remember(key, ref string, size int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.refs[key] = ref
+	if ref != "" {
+		d.sizes[ref] = size
+	}
+}
+
+func (d *DedupBackend) forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.refs, key)
+}
+
+// referenceFromInfo returns the content reference recorded in info, or
+// empty when the object is stored whole
+func (d *DedupBackend) referenceFromInfo(key string, info *types.ObjectInfo) (string, error) {
+	if info.Metadata[layoutMetadataKey] != contentLayout {
+		return "", nil
+	}
+	ref := info.Metadata[contentMetadataKey]
+	if _, _, err := splitReference(ref); err != nil {
+		return "", fmt.Errorf("object %s: %w", key, err)
+	}
+	return ref, nil
+}
+
+// resolve returns the content reference of key, or empty when key is stored
+// whole, asking the backend on first use
+func (d *DedupBackend) resolve(ctx context.Context, key string) (string, error) {
+	d.mu.Lock()
+	ref, ok := d.refs[key]
+	d.mu.Unlock()
+	if ok {
+		return ref, nil
+	}
+
+	info, err := d.backend.HeadObject(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if ref, err = d.referenceFromInfo(key, info); err != nil {
+		return "", err
+	}
+	d.remember(key, ref, contentSize(info))
+	return ref, nil
+}
+
+// contentSize returns the content size recorded in a reference's metadata
+func contentSize(info *types.ObjectInfo) int64 {
+	size, err := strconv.ParseInt(info.Metadata[contentSizeMetadataKey], 10, 64)
+	if err != nil {
+		return info.Size
+	}
+	return size
+}
+
+// retain records that key references ref and stores data as the content
+// of ref unless it is already stored. The marker is written first, so a
+// concurrent release of the last other reference keeps the content.
+func (d *DedupBackend) retain(ctx context.Context, ref, key string, data []byte) error {
+	mu := d.contentLock(ref)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := d.backend.PutObject(ctx, d.markerKey(ref, key), []byte{}); err != nil {
+		return fmt.Errorf("failed to write reference marker for %s: %w", key, err)
+	}
+
+	_, err := d.backend.HeadObject(ctx, d.contentKey(ref))
+	if err == nil {
+		d.mu.Lock()
+		d.stats.Deduplicated++
+		d.stats.BytesSaved += int64(len(data))
+		d.mu.Unlock()
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("failed to check content of %s: %w", key, err)
+	}
+
+	if err := d.backend.PutObject(ctx, d.contentKey(ref), data); err != nil {
+		return fmt.Errorf("failed to write content of %s: %w", key, err)
+	}
+	d.mu.Lock()
+	d.stats.Stored++
+	d.mu.Unlock()
+	return nil
+}
+
+// release drops key's reference to ref, deleting the content once no
+// references remain. Failures leave content for garbage collection.
+func (d *DedupBackend) release(ctx context.Context, ref, key string) {
+	if ref == "" {
+		return
+	}
+
+	mu := d.contentLock(ref)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := d.backend.DeleteObject(ctx, d.markerKey(ref, key)); err != nil {
+		return
+	}
+	markers, err := d.backend.ListObjects(ctx, d.markersPrefix(ref), 1)
+	if err != nil || len(markers) > 0 {
+		return
+	}
+	if err := d.backend.DeleteObject(ctx, d.contentKey(ref)); err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	d.stats.Released++
+	delete(d.sizes, ref)
+	d.mu.Unlock()
+}
+
+// GetObject reads key, resolving its content reference
+func (d *DedupBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	ref, err := d.resolve(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		return d.backend.GetObject(ctx, key, offset, size)
+	}
+	return d.backend.GetObject(ctx, d.contentKey(ref), offset, size)
+}
+
+// PutObject replaces key with data, stored once per distinct content
+func (d *DedupBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	return d.PutObjectWithMetadata(ctx, key, data, nil)
+}
+
+// PutObjectWithMetadata replaces key with data, keeping metadata on the
+// reference stored at key
+func (d *DedupBackend) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	mu := d.keyLock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	old, err := d.resolve(ctx, key)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	if int64(len(data)) < d.minSize {
+		if err := d.writer.PutObjectWithMetadata(ctx, key, data, metadata); err != nil {
+			return err
+		}
+		d.remember(key, "", 0)
+		d.release(ctx, old, key)
+		return nil
+	}
+
+	ref := d.reference(data)
+	if err := d.retain(ctx, ref, key, data); err != nil {
+		return err
+	}
+
+	stored := make(map[string]string, len(metadata)+3)
+	for k, v := range metadata {
+		stored[k] = v
+	}
+	stored[layoutMetadataKey] = contentLayout
+	stored[contentMetadataKey] = ref
+	stored[contentSizeMetadataKey] = strconv.Itoa(len(data))
+	if err := d.writer.PutObjectWithMetadata(ctx, key, []byte(ref), stored); err != nil {
+		return err
+	}
+	d.remember(key, ref, int64(len(data)))
+	if old != ref {
+		d.release(ctx, old, key)
+	}
+	return nil
+}
+
+// DeleteObject deletes key, and its content once no other key references it
+func (d *DedupBackend) DeleteObject(ctx context.Context, key string) error {
+	mu := d.keyLock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	ref, _ := d.resolve(ctx, key)
+	if err := d.backend.DeleteObject(ctx, key); err != nil {
+		return err
+	}
+	d.forget(key)
+	d.release(ctx, ref, key)
+	return nil
+}
+
+// HeadObject returns metadata for key with its content size
+func (d *DedupBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	info, err := d.backend.HeadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := d.referenceFromInfo(key, info)
+	if err != nil {
+		return nil, err
+	}
+	if ref != "" {
+		info.Size = contentSize(info)
+	}
+	d.remember(key, ref, info.Size)
+	return info, nil
+}
+
+// GetObjects reads keys, resolving content references
+func (d *DedupBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	objects, err := d.backend.GetObjects(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for key := range objects {
+		ref, err := d.resolve(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ref == "" {
+			continue
+		}
+		if objects[key], err = d.backend.GetObject(ctx, d.contentKey(ref), 0, 0); err != nil {
+			return nil, fmt.Errorf("failed to read content of %s: %w", key, err)
+		}
+	}
+	return objects, nil
+}
+
+// PutObjects stores objects, each content once
+func (d *DedupBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		if err := d.PutObject(ctx, key, data); err != nil {
+			return fmt.Errorf("failed to put %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// listed reports whether obj belongs in listings, correcting the size of
+// references whose content size is known
+func (d *DedupBackend) listed(obj *types.ObjectInfo) bool {
+	if strings.HasPrefix(obj.Key, d.prefix) {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ref := d.refs[obj.Key]; ref != "" {
+		if size, ok := d.sizes[ref]; ok {
+			obj.Size = size
+		}
+	}
+	return true
+}
+
+// ListObjects lists the wrapped backend without content objects and
+// markers. Listings carry no metadata, so only references already resolved
+// report their content size.
+func (d *DedupBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	objects, err := d.backend.ListObjects(ctx, prefix, 0)
+	if err != nil {
+		return nil, err
+	}
+	visible := objects[:0]
+	for _, obj := range objects {
+		if limit > 0 && len(visible) == limit {
+			break
+		}
+		if d.listed(&obj) {
+			visible = append(visible, obj)
+		}
+	}
+	return visible, nil
+}
+
+// ListObjectsChan streams the wrapped backend's listing without content
+// objects and markers
+func (d *DedupBackend) ListObjectsChan(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	objCh := make(chan types.ObjectInfo)
+	errCh := make(chan error, 1)
+
+	streamer, ok := d.backend.(types.ObjectStreamer)
+	if !ok {
+		go func() {
+			defer close(objCh)
+			defer close(errCh)
+			objects, err := d.ListObjects(ctx, prefix, 0)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			for _, obj := range objects {
+				select {
+				case objCh <- obj:
+				case <-ctx.Done():
+					errCh <- ctx.Err()
+					return
+				}
+			}
+		}()
+		return objCh, errCh
+	}
+
+	rawCh, rawErrCh := streamer.ListObjectsChan(ctx, prefix)
+	go func() {
+		defer close(objCh)
+		defer close(errCh)
+		for obj := range rawCh {
+			if !d.listed(&obj) {
+				continue
+			}
+			select {
+			case objCh <- obj:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+		if err := <-rawErrCh; err != nil {
+			errCh <- err
+		}
+	}()
+
+	return objCh, errCh
+}
+
+// GetObjectIfModified revalidates the reference at key through the wrapped
+// backend. Only the requested range is transferred for keys stored whole;
+// for a changed reference it is read from the referenced content.
+func (d *DedupBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := d.backend.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	cached, err := d.resolve(ctx, key)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	ranged := cached == ""
+	storedOffset, storedSize := offset, size
+	if !ranged {
+		storedOffset, storedSize = 0, 0
+	}
+
+	data, notModified, info, err := getter.GetObjectIfModified(ctx, key, storedOffset, storedSize, since, etag)
+	if err != nil || notModified || info == nil {
+		return data, notModified, info, err
+	}
+	ref, err := d.referenceFromInfo(key, info)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if ref == "" {
+		d.remember(key, "", 0)
+		if !ranged {
+			data = sliceRange(data, offset, size)
+		}
+		return data, false, info, nil
+	}
+
+	info.Size = contentSize(info)
+	d.remember(key, ref, info.Size)
+	if data, err = d.backend.GetObject(ctx, d.contentKey(ref), offset, size); err != nil {
+		return nil, false, nil, fmt.Errorf("failed to read content of %s: %w", key, err)
+	}
+	return data, false, info, nil
+}
+
+// Touch updates the last-modified time of key
+func (d *DedupBackend) Touch(ctx context.Context, key string) error {
+	toucher, ok := d.backend.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	return toucher.Touch(ctx, key)
+}
+
+// MoveObject moves srcKey to dstKey. A key stored whole is moved by the
+// wrapped backend; a reference is rewritten under dstKey, so the content
+// gains a marker for dstKey before losing the one for srcKey.
+func (d *DedupBackend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	ref, err := d.resolve(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	dstRef, err := d.resolve(ctx, dstKey)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if ref == "" && dstRef == "" {
+		mover, ok := d.backend.(types.ObjectMover)
+		if !ok {
+			return fmt.Errorf("backend does not support move")
+		}
+		err := mover.MoveObject(ctx, srcKey, dstKey)
+		d.forget(srcKey)
+		d.forget(dstKey)
+		return err
+	}
+	return moveByCopy(ctx, d, srcKey, dstKey)
+}
+
+// HealthCheck checks the wrapped backend
+func (d *DedupBackend) HealthCheck(ctx context.Context) error {
+	return d.backend.HealthCheck(ctx)
+}
+
+// CollectGarbage drops reference markers whose key no longer holds the
+// reference, then deletes content objects left without markers. Markers
+// and content younger than the grace period are kept, since a write may
+// still be completing. It returns how many content objects were deleted.
+func (d *DedupBackend) CollectGarbage(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-d.gcGrace)
+	failures := 0
+
+	markers, err := d.backend.ListObjects(ctx, d.prefix+"refs/", 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list reference markers: %w", err)
+	}
+	live := make(map[string]bool)
+	for _, marker := range markers {
+		ref, key, ok := d.parseMarker(marker.Key)
+		if !ok {
+			continue
+		}
+		if marker.LastModified.After(cutoff) || d.references(ctx, key, ref) {
+			live[ref] = true
+			continue
+		}
+		if err := d.backend.DeleteObject(ctx, marker.Key); err != nil {
+			live[ref] = true
+			failures++
+			continue
+		}
+		d.mu.Lock()
+		d.stats.StaleRefs++
+		d.mu.Unlock()
+	}
+
+	contents, err := d.backend.ListObjects(ctx, d.prefix+"objects/", 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list content objects: %w", err)
+	}
+	collected := 0
+	for _, content := range contents {
+		ref, ok := d.parseContentKey(content.Key)
+		if !ok || live[ref] || content.LastModified.After(cutoff) {
+			continue
+		}
+		deleted, err := d.collect(ctx, ref)
+		if err != nil {
+			failures++
+		}
+		if deleted {
+			collected++
+		}
+	}
+
+	d.mu.Lock()
+	d.stats.Collected += uint64(collected)
+	d.stats.LastGC = time.Now()
+	d.stats.LastGCErrors = failures
+	d.mu.Unlock()
+
+	if failures > 0 {
+		return collected, fmt.Errorf("garbage collection failed to delete %d objects", failures)
+	}
+	return collected, nil
+}
+
+// references reports whether key currently holds ref. Holding key's write
+// lock keeps a write in progress from being seen half done.
+func (d *DedupBackend) references(ctx context.Context, key, ref string) bool {
+	mu := d.keyLock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	info, err := d.backend.HeadObject(ctx, key)
+	if err != nil {
+		// Only a missing key proves the marker stale
+		return !isNotFound(err)
+	}
+	current, err := d.referenceFromInfo(key, info)
+	return err != nil || current == ref
+}
+
+// collect deletes the content of ref if it still has no markers
+func (d *DedupBackend) collect(ctx context.Context, ref string) (bool, error) {
+	mu := d.contentLock(ref)
+	mu.Lock()
+	defer mu.Unlock()
+
+	markers, err := d.backend.ListObjects(ctx, d.markersPrefix(ref), 1)
+	if err != nil || len(markers) > 0 {
+		return false, err
+	}
+	if err := d.backend.DeleteObject(ctx, d.contentKey(ref)); err != nil {
+		return false, err
+	}
+	d.mu.Lock()
+	delete(d.sizes, ref)
+	d.mu.Unlock()
+	return true, nil
+}
+
+// parseMarker returns the reference and key a marker key records
+func (d *DedupBackend) parseMarker(markerKey string) (string, string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(markerKey, d.prefix+"refs/"), "/", 3)
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	key, err := url.PathUnescape(parts[2])
+	if err != nil {
+		return "", "", false
+	}
+	return parts[0] + ":" + parts[1], key, true
+}
+
+// parseContentKey returns the reference a content object is stored for
+func (d *DedupBackend) parseContentKey(contentKey string) (string, bool) {
+	hashName, digest, ok := strings.Cut(strings.TrimPrefix(contentKey, d.prefix+"objects/"), "/")
+	if !ok || strings.Contains(digest, "/") {
+		return "", false
+	}
+	return hashName + ":" + digest, true
+}
+
+func (d *DedupBackend) gcLoop(interval time.Duration) {
+	defer close(d.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-d.stopCh
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+			collected, err := d.CollectGarbage(ctx)
+			if err != nil {
+				d.logger.Warn("Dedup garbage collection failed", "error", err)
+			}
+			if collected > 0 {
+				d.logger.Info("Dedup garbage collection deleted unreferenced content", "objects", collected)
+			}
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+func newTestDedup(t *testing.T, backend *metadataBackend) *DedupBackend {
+	t.Helper()
+	dedup, err := NewDedupBackend(backend, DedupOptions{MinSize: 1, GCInterval: -1})
+	if err != nil {
+		t.Fatalf("NewDedupBackend failed: %v", err)
+	}
+	t.Cleanup(func() { _ = dedup.Close() })
+	return dedup
+}
+
+// storedUnder returns the keys backend holds under prefix
+func storedUnder(backend *metadataBackend, prefix string) []string {
+	objects, _ := backend.ListObjects(context.Background(), prefix, 0)
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+func TestDedupStoresIdenticalContentOnce(t *testing.T) {
+	backend := newMetadataBackend()
+	dedup := newTestDedup(t, backend)
+	ctx := context.Background()
+
+	payload := bytes.Repeat([]byte("backup block "), 1000)
+	for _, key := range []string{"backups/mon/db.tar", "backups/tue/db.tar"} {
+		if err := dedup.PutObject(ctx, key, payload); err != nil {
+			t.Fatalf("PutObject(%s) failed: %v", key, err)
+		}
+	}
+
+	contents := storedUnder(backend, defaultDedupPrefix+"objects/")
+	if len(contents) != 1 {
+		t.Fatalf("stored %d content objects, want 1", len(contents))
+	}
+	if stats := dedup.Stats(); stats.Stored != 1 || stats.Deduplicated != 1 || stats.BytesSaved != int64(len(payload)) {
+		t.Errorf("stats = %+v, want 1 stored, 1 deduplicated, %d bytes saved", stats, len(payload))
+	}
+
+	listed, err := dedup.ListObjects(ctx, "backups/", 0)
+	if err != nil {
+		t.Fatalf("ListObjects failed: %v", err)
+	}
+	if len(listed) != 2 || listed[0].Size != int64(len(payload)) {
+		t.Errorf("listing = %+v, want both keys at %d bytes", listed, len(payload))
+	}
+
+	// Deleting one key keeps the content the other still references
+	if err := dedup.DeleteObject(ctx, "backups/mon/db.tar"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	if len(storedUnder(backend, contents[0])) != 1 {
+		t.Fatal("content deleted while a key still references it")
+	}
+	got, err := dedup.GetObject(ctx, "backups/tue/db.tar", 0, 0)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("GetObject after deleting the other key = %d bytes, %v; want the payload", len(got), err)
+	}
+
+	// Deleting the last key deletes the content and its markers
+	if err := dedup.DeleteObject(ctx, "backups/tue/db.tar"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	if left := storedUnder(backend, defaultDedupPrefix); len(left) != 0 {
+		t.Errorf("objects left after deleting every reference: %v", left)
+	}
+	if stats := dedup.Stats(); stats.Released != 1 {
+		t.Errorf("released = %d, want 1", stats.Released)
+	}
+}
+
+func TestDedupOverwriteReleasesOldContent(t *testing.T) {
+	backend := newMetadataBackend()
+	dedup := newTestDedup(t, backend)
+	ctx := context.Background()
+
+	if err := dedup.PutObject(ctx, "data.bin", []byte("first version")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := dedup.PutObject(ctx, "data.bin", []byte("second version")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	if contents := storedUnder(backend, defaultDedupPrefix+"objects/"); len(contents) != 1 {
+		t.Errorf("stored content objects %v, want only the second version", contents)
+	}
+	info, err := dedup.HeadObject(ctx, "data.bin")
+	if err != nil {
+		t.Fatalf("HeadObject failed: %v", err)
+	}
+	if info.Size != int64(len("second version")) {
+		t.Errorf("size = %d, want %d", info.Size, len("second version"))
+	}
+}
+
+func TestDedupGarbageCollectsUnreferencedContent(t *testing.T) {
+	backend := newMetadataBackend()
+	dedup := newTestDedup(t, backend)
+	ctx := context.Background()
+
+	if err := dedup.PutObject(ctx, "kept.bin", []byte("kept content")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := dedup.PutObject(ctx, "stale.bin", []byte("stale content")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	// Another client overwrote stale.bin directly, leaving its marker and
+	// content behind, and an interrupted write left content with no marker
+	if err := backend.PutObject(ctx, "stale.bin", []byte("raw")); err != nil {
+		t.Fatalf("raw PutObject failed: %v", err)
+	}
+	orphan := dedup.reference([]byte("orphan"))
+	if err := backend.PutObject(ctx, dedup.contentKey(orphan), []byte("orphan")); err != nil {
+		t.Fatalf("raw PutObject failed: %v", err)
+	}
+
+	collected, err := dedup.CollectGarbage(ctx)
+	if err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if collected != 2 {
+		t.Errorf("collected %d content objects, want 2", collected)
+	}
+
+	kept := dedup.contentKey(dedup.reference([]byte("kept content")))
+	if contents := storedUnder(backend, defaultDedupPrefix+"objects/"); len(contents) != 1 || contents[0] != kept {
+		t.Errorf("content left = %v, want only %s", contents, kept)
+	}
+	for _, marker := range storedUnder(backend, defaultDedupPrefix+"refs/") {
+		if !strings.HasSuffix(marker, "/kept.bin") {
+			t.Errorf("stale marker %s not collected", marker)
+		}
+	}
+	if stats := dedup.Stats(); stats.StaleRefs != 1 || stats.Collected != 2 {
+		t.Errorf("stats = %+v, want 1 stale marker and 2 collected", stats)
+	}
+}
+
+func TestDedupMoveRewritesReference(t *testing.T) {
+	backend := newMetadataBackend()
+	dedup := newTestDedup(t, backend)
+	ctx := context.Background()
+
+	payload := bytes.Repeat([]byte("shared "), 100)
+	if err := dedup.PutObjectWithMetadata(ctx, "a.bin", payload, map[string]string{"owner": "ops"}); err != nil {
+		t.Fatalf("PutObjectWithMetadata failed: %v", err)
+	}
+	if err := dedup.PutObject(ctx, "b.bin", payload); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	if err := dedup.MoveObject(ctx, "a.bin", "moved/a.bin"); err != nil {
+		t.Fatalf("MoveObject failed: %v", err)
+	}
+
+	if _, err := dedup.HeadObject(ctx, "a.bin"); !isNotFound(err) {
+		t.Errorf("HeadObject of the moved source error = %v, want not found", err)
+	}
+	info, err := dedup.HeadObject(ctx, "moved/a.bin")
+	if err != nil {
+		t.Fatalf("HeadObject failed: %v", err)
+	}
+	if info.Metadata["owner"] != "ops" {
+		t.Errorf("metadata = %v, want the source's", info.Metadata)
+	}
+	got, err := dedup.GetObject(ctx, "moved/a.bin", 0, 0)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("GetObject = %d bytes, %v; want the payload", len(got), err)
+	}
+
+	if contents := storedUnder(backend, defaultDedupPrefix+"objects/"); len(contents) != 1 {
+		t.Errorf("stored %d content objects, want the shared one", len(contents))
+	}
+	for _, marker := range storedUnder(backend, defaultDedupPrefix+"refs/") {
+		if strings.HasSuffix(marker, "/a.bin") {
+			t.Errorf("marker %s for the moved source left behind", marker)
+		}
+	}
+}
+
+func TestDedupRevalidatesChangedReference(t *testing.T) {
+	backend := newMetadataBackend()
+	dedup := newTestDedup(t, backend)
+	ctx := context.Background()
+
+	before := bytes.Repeat([]byte("monday "), 100)
+	after := bytes.Repeat([]byte("TUESDAY "), 100)
+	if err := dedup.PutObject(ctx, "backups/db.tar", before); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := dedup.PutObject(ctx, "backups/db.tar", after); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	c := newRevalidatingCache(t, dedup.GetObjectIfModified)
+	expectRevalidatedContent(t, c, "backups/db.tar", 300, before, after)
+}
+
+// limitedMetadataBackend returns at most limit keys from each listing
+type limitedMetadataBackend struct {
+	*metadataBackend
+}
+
+func (b *limitedMetadataBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	objects, err := b.metadataBackend.ListObjects(ctx, prefix, limit)
+	if limit > 0 && len(objects) > limit {
+		objects = objects[:limit]
+	}
+	return objects, err
+}
+
+func TestDedupListingLimitSkipsContentObjects(t *testing.T) {
+	backend := &limitedMetadataBackend{newMetadataBackend()}
+	dedup, err := NewDedupBackend(backend, DedupOptions{MinSize: 1, GCInterval: -1})
+	if err != nil {
+		t.Fatalf("NewDedupBackend failed: %v", err)
+	}
+	defer func() { _ = dedup.Close() }()
+	ctx := context.Background()
+
+	for _, key := range []string{"a.bin", "b.bin"} {
+		if err := dedup.PutObject(ctx, key, []byte("same content")); err != nil {
+			t.Fatalf("PutObject(%s) failed: %v", key, err)
+		}
+	}
+
+	// Content objects and markers sort first and must not fill the page
+	listed, err := dedup.ListObjects(ctx, "", 1)
+	if err != nil {
+		t.Fatalf("ListObjects failed: %v", err)
+	}
+	if len(listed) != 1 || listed[0].Key != "a.bin" {
+		t.Errorf("listing = %+v, want only a.bin", listed)
+	}
+}
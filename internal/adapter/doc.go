@@ -41,9 +41,138 @@ Handles object storage operations with multi-region support, connection pooling,
 and intelligent retry logic. Configured with bucket-specific settings and
 CargoShip optimization features.
 
+Concurrency Limiter (performance.max_concurrency):
+Caps backend calls in flight so a parallel find or copy queues instead of
+exhausting connections. Reads and writes may have their own sub-limits
+(max_read_concurrency, max_write_concurrency). Calls are admitted in arrival
+order, and queue depth per class is exported as a metric and in Stats.
+
+Rate Limiter (performance.rate_limit):
+Caps the steady rate of requests and bytes sent to the bucket with token
+buckets, overall and per class (read, write, list), for buckets whose request
+quota is shared. It sits beneath every other layer, so internal calls count
+too. Excess calls queue, or fail with ErrCodeQuotaExceeded in reject mode or
+past max_wait. Stats reports each limit against the rate measured over the
+last second.
+
+Listing Cache (storage.s3.list_cache):
+Serves repeated listings of the same prefix from memory for a short TTL, so
+polling workloads do not pay for a LIST request each time. It lives in the
+listing overlay, which drops a prefix's cached listings on any local write,
+delete, or touch beneath it. Hit rate is reported in Stats.
+
+Housekeeping (storage.s3.housekeeping):
+Periodically aborts multipart uploads left incomplete for longer than
+abort_after, whose parts are otherwise billed indefinitely. With dry_run the
+uploads are only logged. Housekeeping runs the same pass on demand, and
+ReclaimIncompleteUploads works with any types.MultipartHousekeeper.
+
+Latency Probe (storage.s3.latency_probe):
+Pings the backend every interval and exports the rolling latency as
+objectfs_backend_latency_seconds. Failed pings set objectfs_backend_reachable
+to 0 and leave the latency alone, so an outage never looks like a slow
+backend.
+
+Connection Prewarm (storage.s3.prewarm):
+Before the mount is ready, opens up to connections pooled connections (by
+default the whole pool) and validates each with a HEAD of the bucket, so the
+first listing does not pay for DNS, TCP and TLS setup. A failed prewarm is
+logged and the mount proceeds.
+
+Key Priming:
+PrimeKeys readies the mount for a job whose files are known in advance,
+loading their attributes (fuse.PrimeMetadata) or their attributes and
+content (fuse.PrimeData) before the job starts. PrimeProgress reports the
+latest call, and the filesystem stats count primed and failed keys.
+
+Usage Accounting (monitoring.usage):
+Every mount counts the bytes it reads from and writes to its backend, and
+the requests it sends, in total and by key prefix up to prefix_depth path
+segments. The totals are reported by Stats and served at /debug/usage. With
+a state_file they are saved every save_interval and at shutdown, and a
+restarted mount continues from them instead of from zero.
+
+Read Failover (storage.s3.read_replica_regions, storage.s3.read_failover):
+Reads of a bucket replicated to other regions fail over to a replica when
+a primary read gets no response from S3, or go to a replica first while the
+primary's pinged latency exceeds latency_threshold. Healthy replicas are
+tried in order of pinged latency, so enable storage.s3.latency_probe to keep
+the estimates current. A key missing from a reachable primary is not looked
+for elsewhere, and writes always go to the primary. Stats report each
+region's health, and recent operations record the region that served each
+read.
+
+Connection Pool Health (storage.s3.pool_health):
+A pooled client whose requests get no response from S3 failure_threshold
+times in a row is replaced with a new client, and its connections closed,
+when it is returned to the pool. Idle clients not used successfully within
+validation_interval are validated with a HEAD of the bucket and replaced if
+it fails. Backend stats report healthy, unhealthy and replaced clients.
+
+Mirror (storage.mirror):
+Applies every write, delete, touch, and move to a second bucket after the
+primary, for migrating between buckets or providers without downtime. Reads are
+served by the primary alone. In best-effort mode a failed mirror write is
+logged and the operation succeeds; in strict mode it fails the operation,
+though the primary write stands. Either way the key is backlogged and
+retried every retry_interval by copying the primary's current object, so
+the mirror converges on the primary. Backlog size and the age of its oldest
+change are exported as objectfs_mirror_backlog and
+objectfs_mirror_lag_seconds and reported in Stats.
+
+Block Storage (storage.s3.blocks):
+Splits objects larger than block_size into fixed-size blocks stored under
+prefix, with a JSON manifest at the object's key recording the block size,
+assembled size, and which blocks are stored. A small write into a large
+object, such as a database page write, rewrites only the blocks it covers;
+reads reassemble the requested range. All-zero blocks are left as holes.
+Split objects are only readable through objectfs, and block storage cannot
+be combined with compression.
+
+Deduplication (storage.s3.dedup):
+Stores each distinct content once, for backup-style workloads that write
+many identical files. Objects of at least min_size are hashed and stored at
+<prefix>objects/<hash>/<digest>; the written key holds a reference,
+"<hash>:<digest>", in its body and objectfs-content metadata. Each key
+referencing content has a marker under <prefix>refs/, and the content is
+deleted along with its last marker. Every gc_interval, markers whose key no
+longer holds the reference and content left without markers are deleted,
+sparing anything younger than gc_grace. Deduplicated objects are only
+readable through objectfs, and dedup cannot be combined with block storage.
+
+Copy-On-Write Overlay (storage.copy_on_write):
+Lets a mount edit a read-mostly shared dataset without changing it for
+other mounts. Reads come from the shared keys until a key is first written;
+the write then lands in a copy under prefix (one per mount) in the same
+bucket, with the shared object copied first when only part of it is
+written, and later reads and writes of the key use the copy. Deletes record
+a whiteout under the prefix instead. A remount resumes the overlay it finds
+there. CommitOverlay writes the edits and deletions over the shared keys;
+DiscardOverlay drops them so the mount sees the shared dataset again.
+
+Object Expiry (storage.expiry):
+Deletes objects after a time to live without bucket lifecycle rules. An
+object expires at the RFC 3339 time in the "expires" metadata it is written
+with, after the ttl of the longest matching rule prefix, or when SetExpiry
+says. Expiries are recorded under prefix so they survive remounts. Expired
+objects read as not found and are left out of listings at once; every
+interval the reaper deletes them, except that objects younger than the S3
+storage tier's minimum storage period are kept hidden until it has passed.
+
+Integrity Manifests:
+GenerateManifest records every object under a prefix with its size, ETag,
+and SHA-256 of its content as read through the mount, plus a digest over
+all entries. VerifyManifest re-lists and re-reads the prefix and reports
+objects added, removed, or changed since, so a CI step can assert a
+published dataset is intact. Manifests are versioned JSON written by
+WriteManifest; ReadManifest rejects unknown versions and edited entries.
+
 Multi-Level Cache:
 Orchestrates L1 (memory) and L2 (persistent disk) caches with configurable
 eviction policies, TTLs, and prefetching strategies for optimal performance.
+With cache.persistent_cache.max_size set to "auto", L2 sizes itself from the
+free space of its directory per persistent_cache.auto_size and Stats reports
+the effective size as L2AutoSize.
 
 Write Buffer:
 Manages intelligent write buffering with configurable flush policies,
@@ -70,11 +199,11 @@ Startup Sequence:
  6. Platform-specific FUSE filesystem mounting
  7. Health monitoring activation
 
-Shutdown Sequence:
- 1. FUSE filesystem unmounting
- 2. Write buffer flushing and closure
- 3. Backend connection cleanup
- 4. Cache persistence and cleanup
+Shutdown Sequence (each phase bounded by its own timeout):
+ 1. New FUSE operations rejected and in-flight operations drained
+ 2. Write buffer and packed object flushing
+ 3. FUSE filesystem unmounting
+ 4. Write buffer closure and backend connection cleanup
  5. Metrics collection finalization
 
 # Configuration Integration
@@ -149,6 +278,7 @@ Shutdown Failures:
 - Resource leak detection and prevention
 - Flush operations for data consistency
 - Timeout handling for unresponsive components
+- Phase failures are combined into the error returned by Stop
 
 # Storage URI Support
 
@@ -211,6 +341,8 @@ Logging:
 - Component lifecycle events
 - Error conditions with full context
 - Performance milestone tracking
+- JSON or text output at global.log_level to global.log_file, with configured static fields
+- Credential-like fields (secrets, tokens, authorization) and AWS access key IDs are redacted
 
 This package represents the heart of ObjectFS, coordinating all subsystems
 to provide a seamless, high-performance object storage filesystem experience.
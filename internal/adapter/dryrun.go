@@ -0,0 +1,308 @@
+package adapter
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/config"
+	"github.com/objectfs/objectfs/internal/storage/s3"
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Preflight check names
+const (
+	CheckConfig      = "config"
+	CheckCredentials = "credentials"
+	CheckBucket      = "bucket"
+	CheckMountPoint  = "mount_point"
+	CheckFUSE        = "fuse"
+)
+
+// dryRunTimeout bounds network checks performed during a dry run
+const dryRunTimeout = 30 * time.Second
+
+// DryRunCheck is the outcome of a single preflight check
+type DryRunCheck struct {
+	Name           string           `json:"name"`
+	Target         string           `json:"target,omitempty"`
+	Passed         bool             `json:"passed"`
+	Code           errors.ErrorCode `json:"code,omitempty"`
+	Message        string           `json:"message"`
+	Recommendation string           `json:"recommendation,omitempty"`
+}
+
+// DryRunResult collects the outcome of every preflight check. It implements
+// error so DryRun can return it when any check fails.
+type DryRunResult struct {
+	Checks []DryRunCheck `json:"checks"`
+}
+
+// Passed reports whether every check passed
+func (r *DryRunResult) Passed() bool {
+	return len(r.Failed()) == 0
+}
+
+// Failed returns the checks that did not pass
+func (r *DryRunResult) Failed() []DryRunCheck {
+	var failed []DryRunCheck
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// Check returns the first check with the given name
+func (r *DryRunResult) Check(name string) (DryRunCheck, bool) {
+	for _, check := range r.Checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+	return DryRunCheck{}, false
+}
+
+// Error summarises the failed checks
+func (r *DryRunResult) Error() string {
+	failed := r.Failed()
+	parts := make([]string, 0, len(failed))
+	for _, check := range failed {
+		parts = append(parts, fmt.Sprintf("%s: %s", check.Name, check.Message))
+	}
+	return fmt.Sprintf("dry run failed %d of %d checks: %s", len(failed), len(r.Checks), strings.Join(parts, "; "))
+}
+
+// DryRun validates configuration, credentials, bucket access, the mount
+// point, and FUSE availability without mounting. It returns nil when every
+// check passes and a *DryRunResult describing all checks otherwise.
+func (a *Adapter) DryRun(ctx context.Context) error {
+	result := a.Preflight(ctx)
+	if result.Passed() {
+		return nil
+	}
+	return result
+}
+
+// Preflight runs every dry-run check and returns their outcomes
+func (a *Adapter) Preflight(ctx context.Context) *DryRunResult {
+	result := &DryRunResult{}
+
+	result.add(CheckConfig, "", a.checkConfig())
+
+	s3Config := a.newS3Config()
+	result.add(CheckCredentials, "", a.checkCredentials(ctx, s3Config))
+
+	result.add(CheckBucket, a.storageURI, a.checkBucket(ctx, a.bucketName, s3Config))
+	if a.config != nil {
+		for _, fallbackURI := range a.config.Storage.Fallback {
+			result.add(CheckBucket, fallbackURI, a.checkBucketURI(ctx, fallbackURI, s3Config))
+		}
+		if mirror := a.config.Storage.Mirror; mirror.Enabled {
+			result.add(CheckBucket, mirror.URI, a.checkBucketURI(ctx, mirror.URI, s3Config))
+		}
+	}
+
+	result.add(CheckMountPoint, a.mountPoint, checkMountPoint(a.mountPoint, a.onNonEmptyMount() == config.NonEmptyMountFail))
+
+	checkFUSE := a.checkFUSE
+	if checkFUSE == nil {
+		checkFUSE = checkFUSEAvailable
+	}
+	var fuseErr error
+	if err := checkFUSE(); err != nil {
+		fuseErr = errors.NewError(errors.ErrCodeMountFailed, "FUSE is not available").WithCause(err)
+	}
+	result.add(CheckFUSE, runtime.GOOS, fuseErr)
+
+	return result
+}
+
+// add records a check, deriving the remediation hint from err
+func (r *DryRunResult) add(name, target string, err error) {
+	check := DryRunCheck{Name: name, Target: target, Passed: err == nil, Message: "ok"}
+	if err != nil {
+		check.Message = err.Error()
+
+		var objErr *errors.ObjectFSError
+		if !stderrors.As(err, &objErr) {
+			objErr = errors.NewError(errors.ErrCodeConnectionFailed, err.Error())
+		}
+		check.Code = objErr.Code
+		check.Recommendation = objErr.GetRecommendation()
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+func (a *Adapter) checkConfig() error {
+	if a.config == nil {
+		return errors.NewError(errors.ErrCodeMissingConfig, "no configuration provided")
+	}
+	if err := a.config.Validate(); err != nil {
+		return errors.NewError(errors.ErrCodeInvalidConfig, "configuration is invalid").WithCause(err)
+	}
+	return nil
+}
+
+func (a *Adapter) checkCredentials(ctx context.Context, s3Config *s3.Config) error {
+	resolve := a.resolveCredentials
+	if resolve == nil {
+		resolve = s3.ResolveCredentials
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dryRunTimeout)
+	defer cancel()
+
+	if err := resolve(ctx, s3Config); err != nil {
+		var objErr *errors.ObjectFSError
+		if stderrors.As(err, &objErr) {
+			return err
+		}
+		return errors.NewError(errors.ErrCodeCredentialsMissing, "failed to resolve AWS credentials").WithCause(err)
+	}
+	return nil
+}
+
+func (a *Adapter) checkBucket(ctx context.Context, bucket string, s3Config *s3.Config) error {
+	newBackend := a.newBackend
+	if newBackend == nil {
+		newBackend = func(ctx context.Context, bucket string, cfg *s3.Config) (types.Backend, error) {
+			return s3.NewBackend(ctx, bucket, cfg)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dryRunTimeout)
+	defer cancel()
+
+	backend, err := newBackend(ctx, bucket, s3Config)
+	if err != nil {
+		return bucketError(bucket, err)
+	}
+	if closer, ok := backend.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	if err := backend.HealthCheck(ctx); err != nil {
+		return bucketError(bucket, err)
+	}
+	return nil
+}
+
+// checkBucketURI checks the bucket of a fallback or mirror storage URI
+func (a *Adapter) checkBucketURI(ctx context.Context, storageURI string, s3Config *s3.Config) error {
+	if err := validateStorageURI(storageURI); err != nil {
+		return errors.NewError(errors.ErrCodeInvalidConfig, "invalid storage URI").WithCause(err)
+	}
+	bucket, _, _ := strings.Cut(strings.TrimPrefix(storageURI, "s3://"), "/")
+	return a.checkBucket(ctx, bucket, s3Config)
+}
+
+// bucketError classifies a bucket access failure, preserving codes from the
+// backend where available
+func bucketError(bucket string, err error) error {
+	var objErr *errors.ObjectFSError
+	if stderrors.As(err, &objErr) {
+		return err
+	}
+
+	code, message := errors.ErrCodeConnectionFailed, "cannot reach bucket"
+	var statusErr interface{ HTTPStatusCode() int }
+	if stderrors.As(err, &statusErr) {
+		switch statusErr.HTTPStatusCode() {
+		case 404:
+			code, message = errors.ErrCodeBucketNotFound, "bucket not found"
+		case 401, 403:
+			code, message = errors.ErrCodeAccessDenied, "access to bucket denied"
+		}
+	}
+
+	return errors.NewError(code, fmt.Sprintf("%s: %s", message, bucket)).
+		WithContext("bucket", bucket).
+		WithCause(err)
+}
+
+// checkMountPoint verifies the mount point is a writable directory that is
+// empty unless requireEmpty is false
+func checkMountPoint(path string, requireEmpty bool) error {
+	if path == "" {
+		return errors.NewError(errors.ErrCodePathInvalid, "mount point is empty")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.NewError(errors.ErrCodePathInvalid, "mount point does not exist").
+				WithContext("path", path).
+				WithCause(err)
+		}
+		return errors.NewError(errors.ErrCodePermissionDenied, "cannot access mount point").
+			WithContext("path", path).
+			WithCause(err)
+	}
+	if !info.IsDir() {
+		return errors.NewError(errors.ErrCodeNotDirectory, "mount point is not a directory").
+			WithContext("path", path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return errors.NewError(errors.ErrCodePermissionDenied, "cannot read mount point").
+			WithContext("path", path).
+			WithCause(err)
+	}
+	if requireEmpty && len(entries) > 0 {
+		return errors.NewError(errors.ErrCodeNotEmpty, "mount point is not empty").
+			WithContext("path", path).
+			WithDetail("entries", len(entries))
+	}
+
+	probe, err := os.CreateTemp(path, ".objectfs-dryrun-*")
+	if err != nil {
+		return errors.NewError(errors.ErrCodePermissionDenied, "mount point is not writable").
+			WithContext("path", path).
+			WithCause(err)
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+
+	return nil
+}
+
+// checkFUSEAvailable checks that the platform FUSE implementation is installed
+func checkFUSEAvailable() error {
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := os.Stat("/dev/fuse"); err != nil {
+			return fmt.Errorf("/dev/fuse is not available: %w", err)
+		}
+		if _, err := exec.LookPath("fusermount3"); err != nil {
+			if _, err := exec.LookPath("fusermount"); err != nil {
+				return fmt.Errorf("fusermount not found in PATH")
+			}
+		}
+	case "darwin":
+		if _, err := os.Stat("/Library/Filesystems/macfuse.fs"); err != nil {
+			return fmt.Errorf("macFUSE is not installed: %w", err)
+		}
+	case "windows":
+		programFiles := os.Getenv("ProgramFiles(x86)")
+		if programFiles == "" {
+			programFiles = os.Getenv("ProgramFiles")
+		}
+		if _, err := os.Stat(filepath.Join(programFiles, "WinFsp")); err != nil {
+			return fmt.Errorf("WinFsp is not installed: %w", err)
+		}
+	default:
+		return fmt.Errorf("FUSE is not supported on %s", runtime.GOOS)
+	}
+	return nil
+}
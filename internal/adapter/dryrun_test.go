@@ -0,0 +1,155 @@
+package adapter
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/objectfs/objectfs/internal/storage/s3"
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// httpStatusError mimics an SDK response error carrying an HTTP status
+type httpStatusError struct{ status int }
+
+func (e *httpStatusError) Error() string       { return fmt.Sprintf("http status %d", e.status) }
+func (e *httpStatusError) HTTPStatusCode() int { return e.status }
+
+// newDryRunAdapter returns an adapter whose preflight checks all pass
+func newDryRunAdapter(t *testing.T) *Adapter {
+	t.Helper()
+
+	adapter, err := New(context.Background(), "s3://test-bucket", t.TempDir(), createTestConfig())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	adapter.resolveCredentials = func(ctx context.Context, cfg *s3.Config) error { return nil }
+	adapter.newBackend = func(ctx context.Context, bucket string, cfg *s3.Config) (types.Backend, error) {
+		return newMemoryBackend(nil), nil
+	}
+	adapter.checkFUSE = func() error { return nil }
+	return adapter
+}
+
+// requireFailedCheck asserts that DryRun failed only the named check
+func requireFailedCheck(t *testing.T, err error, name string, code errors.ErrorCode) DryRunCheck {
+	t.Helper()
+
+	var result *DryRunResult
+	if !stderrors.As(err, &result) {
+		t.Fatalf("DryRun() error = %v, want *DryRunResult", err)
+	}
+
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].Name != name {
+		t.Fatalf("failed checks = %+v, want only %s", failed, name)
+	}
+	if failed[0].Code != code {
+		t.Errorf("%s check code = %s, want %s", name, failed[0].Code, code)
+	}
+	if failed[0].Recommendation == "" {
+		t.Errorf("%s check missing remediation hint", name)
+	}
+	return failed[0]
+}
+
+func TestDryRunPasses(t *testing.T) {
+	adapter := newDryRunAdapter(t)
+
+	if err := adapter.DryRun(context.Background()); err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+
+	result := adapter.Preflight(context.Background())
+	for _, name := range []string{CheckConfig, CheckCredentials, CheckBucket, CheckMountPoint, CheckFUSE} {
+		if check, ok := result.Check(name); !ok || !check.Passed {
+			t.Errorf("check %s = %+v, want passed", name, check)
+		}
+	}
+
+	// The writability probe must not leave files behind
+	if entries, _ := os.ReadDir(adapter.mountPoint); len(entries) != 0 {
+		t.Errorf("mount point not empty after dry run: %v", entries)
+	}
+	if adapter.started || adapter.mountMgr != nil {
+		t.Error("DryRun() must not start or mount the adapter")
+	}
+}
+
+func TestDryRunMissingMountPoint(t *testing.T) {
+	adapter := newDryRunAdapter(t)
+	adapter.mountPoint = filepath.Join(t.TempDir(), "missing")
+
+	err := adapter.DryRun(context.Background())
+	check := requireFailedCheck(t, err, CheckMountPoint, errors.ErrCodePathInvalid)
+	if check.Target != adapter.mountPoint {
+		t.Errorf("mount point check target = %q, want %q", check.Target, adapter.mountPoint)
+	}
+}
+
+func TestDryRunNonEmptyMountPoint(t *testing.T) {
+	adapter := newDryRunAdapter(t)
+	if err := os.WriteFile(filepath.Join(adapter.mountPoint, "existing"), []byte("x"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	requireFailedCheck(t, adapter.DryRun(context.Background()), CheckMountPoint, errors.ErrCodeNotEmpty)
+}
+
+func TestDryRunBadCredentials(t *testing.T) {
+	adapter := newDryRunAdapter(t)
+	adapter.resolveCredentials = func(ctx context.Context, cfg *s3.Config) error {
+		return fmt.Errorf("no EC2 IMDS role found")
+	}
+
+	requireFailedCheck(t, adapter.DryRun(context.Background()), CheckCredentials, errors.ErrCodeCredentialsMissing)
+}
+
+func TestDryRunUnreachableBucket(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code errors.ErrorCode
+	}{
+		{"network", fmt.Errorf("dial tcp: connection refused"), errors.ErrCodeConnectionFailed},
+		{"missing", &httpStatusError{status: 404}, errors.ErrCodeBucketNotFound},
+		{"forbidden", &httpStatusError{status: 403}, errors.ErrCodeAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := newDryRunAdapter(t)
+			adapter.newBackend = func(ctx context.Context, bucket string, cfg *s3.Config) (types.Backend, error) {
+				return nil, fmt.Errorf("S3 backend health check failed: %w", tt.err)
+			}
+
+			check := requireFailedCheck(t, adapter.DryRun(context.Background()), CheckBucket, tt.code)
+			if check.Target != "s3://test-bucket" {
+				t.Errorf("bucket check target = %q", check.Target)
+			}
+		})
+	}
+}
+
+func TestDryRunUnhealthyBackend(t *testing.T) {
+	adapter := newDryRunAdapter(t)
+	adapter.newBackend = func(ctx context.Context, bucket string, cfg *s3.Config) (types.Backend, error) {
+		backend := newMemoryBackend(nil)
+		backend.failErr = fmt.Errorf("timeout")
+		return backend, nil
+	}
+
+	requireFailedCheck(t, adapter.DryRun(context.Background()), CheckBucket, errors.ErrCodeConnectionFailed)
+}
+
+func TestDryRunFUSEUnavailable(t *testing.T) {
+	adapter := newDryRunAdapter(t)
+	adapter.checkFUSE = func() error { return fmt.Errorf("/dev/fuse is not available") }
+
+	requireFailedCheck(t, adapter.DryRun(context.Background()), CheckFUSE, errors.ErrCodeMountFailed)
+}
@@ -0,0 +1,532 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// defaultExpiryReapInterval is how often expired objects are reaped when
+// no interval is configured
+const defaultExpiryReapInterval = time.Minute
+
+// DefaultExpiryPrefix is where expiry records are kept when no prefix is
+// configured
+const DefaultExpiryPrefix = ".objectfs-expiry/"
+
+// ExpiresMetadataKey is the user metadata key that gives an object an
+// expiry time when it is written, in RFC 3339 format
+const ExpiresMetadataKey = "expires"
+
+// ExpiryRule gives objects written under Prefix a time to live
+type ExpiryRule struct {
+	Prefix string
+	TTL    time.Duration
+}
+
+// ExpiryOptions configures an ExpiryBackend
+type ExpiryOptions struct {
+	Prefix string       // Where expiry records are kept; default DefaultExpiryPrefix
+	Rules  []ExpiryRule // TTLs for objects written under a prefix; the longest prefix wins
+
+	// Objects younger than Embargo are not deleted once expired, only
+	// hidden, so the storage tier's minimum storage period is not cut short
+	Embargo time.Duration
+}
+
+// ExpiryStats reports tracked expiries and the reaper's progress
+type ExpiryStats struct {
+	Prefix   string `json:"prefix"`
+	Tracked  int    `json:"tracked"`  // Objects with an expiry
+	Expired  int    `json:"expired"`  // Tracked objects past their expiry, hidden until reaped
+	Reaped   int64  `json:"reaped"`   // Expired objects deleted
+	Deferred int64  `json:"deferred"` // Reaps put off by the deletion embargo
+	Failed   int64  `json:"failed"`   // Reaps that failed and will be retried
+}
+
+// ExpiryBackend deletes objects after a time to live without bucket
+// lifecycle rules. An object gets an expiry from the expires metadata it is
+// written with, from the rule for its prefix, or from SetExpiry; each is
+// recorded as a small object under the expiry prefix so later mounts keep
+// it. Once expired an object reads as not found and is left out of
+// listings, and Reap deletes it.
+type ExpiryBackend struct {
+	backend types.Backend
+	prefix  string
+	rules   []ExpiryRule
+	embargo time.Duration
+	now     func() time.Time
+
+	mu       sync.RWMutex
+	expiries map[string]time.Time
+	reaped   int64
+	deferred int64
+	failed   int64
+}
+
+// NewExpiryBackend creates an expiry layer over backend, loading the
+// expiries a previous mount recorded
+func NewExpiryBackend(ctx context.Context, backend types.Backend, options ExpiryOptions) (*ExpiryBackend, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	prefix := strings.TrimPrefix(options.Prefix, "/")
+	if prefix == "" {
+		prefix = DefaultExpiryPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	for _, rule := range options.Rules {
+		if rule.TTL <= 0 {
+			return nil, fmt.Errorf("expiry rule for prefix %q needs a positive ttl", rule.Prefix)
+		}
+	}
+
+	rules := append([]ExpiryRule(nil), options.Rules...)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].Prefix) > len(rules[j].Prefix)
+	})
+
+	e := &ExpiryBackend{
+		backend:  backend,
+		prefix:   prefix,
+		rules:    rules,
+		embargo:  options.Embargo,
+		now:      time.Now,
+		expiries: make(map[string]time.Time),
+	}
+
+	records, err := listAll(ctx, backend, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiry records %s: %w", prefix, err)
+	}
+	for _, record := range records {
+		data, err := backend.GetObject(ctx, record.Key, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read expiry record %s: %w", record.Key, err)
+		}
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry record %s: %w", record.Key, err)
+		}
+		e.expiries[strings.TrimPrefix(record.Key, prefix)] = at
+	}
+	return e, nil
+}
+
+// Stats returns the tracked expiries and reaper counters
+func (e *ExpiryBackend) Stats() ExpiryStats {
+	now := e.now()
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	stats := ExpiryStats{
+		Prefix:   e.prefix,
+		Tracked:  len(e.expiries),
+		Reaped:   e.reaped,
+		Deferred: e.deferred,
+		Failed:   e.failed,
+	}
+	for _, at := range e.expiries {
+		if !now.Before(at) {
+			stats.Expired++
+		}
+	}
+	return stats
+}
+
+// recordKey returns where the expiry of key is recorded
+func (e *ExpiryBackend) recordKey(key string) string {
+	return e.prefix + key
+}
+
+// Expiry returns when key expires, or false when it has no expiry
+func (e *ExpiryBackend) Expiry(key string) (time.Time, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	at, ok := e.expiries[key]
+	return at, ok
+}
+
+// hidden reports whether key expired or lies inside the expiry records
+func (e *ExpiryBackend) hidden(key string) bool {
+	if strings.HasPrefix(key, e.prefix) {
+		return true
+	}
+	at, ok := e.Expiry(key)
+	return ok && !e.now().Before(at)
+}
+
+// expiredObject returns the error for a key that is hidden
+func expiredObject(key string) error {
+	return errors.NewError(errors.ErrCodeObjectNotFound, "object not found").
+		WithComponent("expiry").
+		WithContext("key", key)
+}
+
+// SetExpiry sets when key expires. A zero time removes its expiry.
+func (e *ExpiryBackend) SetExpiry(ctx context.Context, key string, at time.Time) error {
+	if e.hidden(key) {
+		return expiredObject(key)
+	}
+	if _, err := e.backend.HeadObject(ctx, key); err != nil {
+		return err
+	}
+	return e.record(ctx, key, at)
+}
+
+// record stores key's expiry, or removes it when at is zero
+func (e *ExpiryBackend) record(ctx context.Context, key string, at time.Time) error {
+	if at.IsZero() {
+		return e.forget(ctx, key)
+	}
+	at = at.UTC()
+	if err := e.backend.PutObject(ctx, e.recordKey(key), []byte(at.Format(time.RFC3339Nano))); err != nil {
+		return fmt.Errorf("failed to record expiry of %s: %w", key, err)
+	}
+	e.mu.Lock()
+	e.expiries[key] = at
+	e.mu.Unlock()
+	return nil
+}
+
+// forget removes key's expiry record if it has one
+func (e *ExpiryBackend) forget(ctx context.Context, key string) error {
+	if _, ok := e.Expiry(key); !ok {
+		return nil
+	}
+	if err := e.backend.DeleteObject(ctx, e.recordKey(key)); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove expiry of %s: %w", key, err)
+	}
+	e.mu.Lock()
+	delete(e.expiries, key)
+	e.mu.Unlock()
+	return nil
+}
+
+// ruleExpiry returns the expiry the longest matching rule gives key when
+// written now, or the zero time when no rule matches
+func (e *ExpiryBackend) ruleExpiry(key string) time.Time {
+	for _, rule := range e.rules {
+		if strings.HasPrefix(key, rule.Prefix) {
+			return e.now().Add(rule.TTL)
+		}
+	}
+	return time.Time{}
+}
+
+// metadataExpiry returns the expiry in metadata, matching the key case
+// insensitively, or the zero time when there is none
+func metadataExpiry(metadata map[string]string) (time.Time, error) {
+	for k, v := range metadata {
+		if strings.EqualFold(k, ExpiresMetadataKey) {
+			at, err := time.Parse(time.RFC3339, strings.TrimSpace(v))
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid %s metadata %q: %w", ExpiresMetadataKey, v, err)
+			}
+			return at, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// writable rejects writes into the expiry records
+func (e *ExpiryBackend) writable(key string) error {
+	if strings.HasPrefix(key, e.prefix) {
+		return fmt.Errorf("key %s is inside the expiry prefix %s", key, e.prefix)
+	}
+	return nil
+}
+
+// GetObject reads key unless it has expired
+func (e *ExpiryBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	if e.hidden(key) {
+		return nil, expiredObject(key)
+	}
+	return e.backend.GetObject(ctx, key, offset, size)
+}
+
+// HeadObject returns key's metadata unless it has expired
+func (e *ExpiryBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	if e.hidden(key) {
+		return nil, expiredObject(key)
+	}
+	return e.backend.HeadObject(ctx, key)
+}
+
+// GetObjectIfModified revalidates key unless it has expired
+func (e *ExpiryBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := e.backend.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	if e.hidden(key) {
+		return nil, false, nil, expiredObject(key)
+	}
+	return getter.GetObjectIfModified(ctx, key, offset, size, since, etag)
+}
+
+// Touch updates the last-modified time of key unless it has expired. Its
+// expiry is unchanged.
+func (e *ExpiryBackend) Touch(ctx context.Context, key string) error {
+	toucher, ok := e.backend.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	if e.hidden(key) {
+		return expiredObject(key)
+	}
+	return toucher.Touch(ctx, key)
+}
+
+// PutObject writes key, giving it the expiry of its prefix rule. A key
+// written again without a rule loses any earlier expiry.
+func (e *ExpiryBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	if err := e.writable(key); err != nil {
+		return err
+	}
+	if err := e.backend.PutObject(ctx, key, data); err != nil {
+		return err
+	}
+	return e.record(ctx, key, e.ruleExpiry(key))
+}
+
+// PutObjectWithMetadata writes key with metadata. Expires metadata sets
+// the object's expiry; otherwise its prefix rule does.
+func (e *ExpiryBackend) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	writer, ok := e.backend.(types.ObjectMetadataWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support object metadata")
+	}
+	if err := e.writable(key); err != nil {
+		return err
+	}
+	at, err := metadataExpiry(metadata)
+	if err != nil {
+		return err
+	}
+	if err := writer.PutObjectWithMetadata(ctx, key, data, metadata); err != nil {
+		return err
+	}
+	if at.IsZero() {
+		at = e.ruleExpiry(key)
+	}
+	return e.record(ctx, key, at)
+}
+
+// WriteAt writes part of key. Writing to an expired key starts a new
+// object with its prefix rule's expiry.
+func (e *ExpiryBackend) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	if err := e.writable(key); err != nil {
+		return err
+	}
+	if e.hidden(key) {
+		if err := e.backend.PutObject(ctx, key, patchBytes(nil, offset, data)); err != nil {
+			return err
+		}
+		return e.record(ctx, key, e.ruleExpiry(key))
+	}
+
+	if writer, ok := e.backend.(types.RangeWriter); ok {
+		return writer.WriteAt(ctx, key, offset, data)
+	}
+	current, err := e.backend.GetObject(ctx, key, 0, 0)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return e.backend.PutObject(ctx, key, patchBytes(current, offset, data))
+}
+
+// DeleteObject deletes key and its expiry
+func (e *ExpiryBackend) DeleteObject(ctx context.Context, key string) error {
+	if err := e.writable(key); err != nil {
+		return err
+	}
+	if err := e.backend.DeleteObject(ctx, key); err != nil {
+		return err
+	}
+	return e.forget(ctx, key)
+}
+
+// MoveObject moves srcKey to dstKey, carrying its expiry along
+func (e *ExpiryBackend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	at, tracked := e.Expiry(srcKey)
+	if err := moveByCopy(ctx, e, srcKey, dstKey); err != nil {
+		return err
+	}
+	if !tracked {
+		return nil
+	}
+	return e.record(ctx, dstKey, at)
+}
+
+// GetObjects reads each key that has not expired
+func (e *ExpiryBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	live := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !e.hidden(key) {
+			live = append(live, key)
+		}
+	}
+	return e.backend.GetObjects(ctx, live)
+}
+
+// PutObjects writes each object, applying prefix rules
+func (e *ExpiryBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		if err := e.PutObject(ctx, key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListObjects lists the keys under prefix, leaving out expired objects and
+// the expiry records. The whole prefix is listed so hidden keys do not use
+// up the limit.
+func (e *ExpiryBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	listed, err := e.backend.ListObjects(ctx, prefix, 0)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]types.ObjectInfo, 0, len(listed))
+	for _, obj := range listed {
+		if limit > 0 && len(objects) == limit {
+			break
+		}
+		if !e.hidden(obj.Key) {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// HealthCheck checks the underlying backend
+func (e *ExpiryBackend) HealthCheck(ctx context.Context) error {
+	return e.backend.HealthCheck(ctx)
+}
+
+// Reap deletes the objects past their expiry and returns how many it
+// deleted. An object younger than the deletion embargo stays hidden and is
+// deleted by a later Reap once the embargo has passed. Objects that fail to
+// delete are retried by the next Reap.
+func (e *ExpiryBackend) Reap(ctx context.Context) (int, error) {
+	now := e.now()
+	e.mu.RLock()
+	var expired []string
+	for key, at := range e.expiries {
+		if !now.Before(at) {
+			expired = append(expired, key)
+		}
+	}
+	e.mu.RUnlock()
+	sort.Strings(expired)
+
+	reaped := 0
+	var firstErr error
+	for _, key := range expired {
+		if err := ctx.Err(); err != nil {
+			return reaped, err
+		}
+		deleted, err := e.reap(ctx, key, now)
+		e.mu.Lock()
+		switch {
+		case err != nil:
+			e.failed++
+		case deleted:
+			e.reaped++
+		default:
+			e.deferred++
+		}
+		e.mu.Unlock()
+
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to reap %s: %w", key, err)
+			}
+			continue
+		}
+		if deleted {
+			reaped++
+		}
+	}
+	return reaped, firstErr
+}
+
+// reap deletes one expired object and its record, reporting false when
+// the deletion embargo puts it off
+func (e *ExpiryBackend) reap(ctx context.Context, key string, now time.Time) (bool, error) {
+	info, err := e.backend.HeadObject(ctx, key)
+	if isNotFound(err) {
+		return true, e.forget(ctx, key)
+	}
+	if err != nil {
+		return false, err
+	}
+	if e.embargo > 0 && now.Sub(info.LastModified) < e.embargo {
+		return false, nil
+	}
+	if err := e.backend.DeleteObject(ctx, key); err != nil && !isNotFound(err) {
+		return false, err
+	}
+	return true, e.forget(ctx, key)
+}
+
+// SetExpiry sets when key expires, or removes its expiry when at is zero
+func (a *Adapter) SetExpiry(ctx context.Context, key string, at time.Time) error {
+	if a.expiry == nil {
+		return fmt.Errorf("object expiry is not enabled")
+	}
+	if err := a.expiry.SetExpiry(ctx, key, at); err != nil {
+		return err
+	}
+	if a.cache != nil {
+		a.cache.Delete(key)
+	}
+	return nil
+}
+
+// startExpiryReaper reaps expired objects every configured interval until
+// stopExpiryReaper is called
+func (a *Adapter) startExpiryReaper(expiry *ExpiryBackend) {
+	interval := a.config.Storage.Expiry.Interval
+	if interval <= 0 {
+		interval = defaultExpiryReapInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	a.stopExpiryReaper = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reaped, err := expiry.Reap(ctx)
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Expiry reaper failed: %v", err)
+				}
+				if reaped > 0 {
+					log.Printf("Expiry reaper deleted %d expired objects", reaped)
+				}
+			}
+		}
+	}()
+}
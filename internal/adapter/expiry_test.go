@@ -0,0 +1,204 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// agedBackend reports every object as last modified at a fixed time
+type agedBackend struct {
+	*memoryBackend
+	modified time.Time
+}
+
+func (b *agedBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	info, err := b.memoryBackend.HeadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	info.LastModified = b.modified
+	return info, nil
+}
+
+func TestExpiredObjectsAreHiddenAndReaped(t *testing.T) {
+	ctx := context.Background()
+	backend := newMetadataBackend()
+	if err := backend.PutObject(ctx, "keep.txt", []byte("keep")); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+
+	expiry, err := NewExpiryBackend(ctx, backend, ExpiryOptions{
+		Rules: []ExpiryRule{
+			{Prefix: "tmp/", TTL: time.Hour},
+			{Prefix: "tmp/long/", TTL: 24 * time.Hour},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewExpiryBackend: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiry.now = func() time.Time { return now }
+
+	for _, key := range []string{"tmp/a", "tmp/long/b"} {
+		if err := expiry.PutObject(ctx, key, []byte("data")); err != nil {
+			t.Fatalf("PutObject %s: %v", key, err)
+		}
+	}
+	expires := now.Add(30 * time.Minute).Format(time.RFC3339)
+	if err := expiry.PutObjectWithMetadata(ctx, "report.csv", []byte("data"), map[string]string{ExpiresMetadataKey: expires}); err != nil {
+		t.Fatalf("PutObjectWithMetadata: %v", err)
+	}
+
+	if at, ok := expiry.Expiry("tmp/long/b"); !ok || !at.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("longest rule should apply, got %v %v", at, ok)
+	}
+
+	now = now.Add(2 * time.Hour)
+	for _, key := range []string{"tmp/a", "report.csv"} {
+		if _, err := expiry.HeadObject(ctx, key); !isNotFound(err) {
+			t.Fatalf("expired %s should not be found, got %v", key, err)
+		}
+	}
+	objects, err := expiry.ListObjects(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	var listed []string
+	for _, object := range objects {
+		listed = append(listed, object.Key)
+	}
+	if len(listed) != 2 || listed[0] != "keep.txt" || listed[1] != "tmp/long/b" {
+		t.Fatalf("listing should hide expired objects and records, got %v", listed)
+	}
+
+	reaped, err := expiry.Reap(ctx)
+	if err != nil {
+		t.Fatalf("Reap: %v", err)
+	}
+	if reaped != 2 {
+		t.Fatalf("expected 2 objects reaped, got %d", reaped)
+	}
+	for _, key := range []string{"tmp/a", "report.csv", DefaultExpiryPrefix + "tmp/a"} {
+		if _, err := backend.HeadObject(ctx, key); !isNotFound(err) {
+			t.Fatalf("%s should be deleted from the backend, got %v", key, err)
+		}
+	}
+
+	reloaded, err := NewExpiryBackend(ctx, backend, ExpiryOptions{})
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if stats := reloaded.Stats(); stats.Tracked != 1 {
+		t.Fatalf("expected the remaining expiry to be reloaded, got %+v", stats)
+	}
+}
+
+func TestExpiryReapWaitsForDeletionEmbargo(t *testing.T) {
+	ctx := context.Background()
+	written := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	backend := &agedBackend{memoryBackend: newMemoryBackend(nil), modified: written}
+
+	expiry, err := NewExpiryBackend(ctx, backend, ExpiryOptions{Embargo: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("NewExpiryBackend: %v", err)
+	}
+	now := written
+	expiry.now = func() time.Time { return now }
+
+	if err := expiry.PutObject(ctx, "cold.bin", []byte("data")); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	if err := expiry.SetExpiry(ctx, "cold.bin", written.Add(24*time.Hour)); err != nil {
+		t.Fatalf("SetExpiry: %v", err)
+	}
+
+	now = written.Add(48 * time.Hour)
+	reaped, err := expiry.Reap(ctx)
+	if err != nil || reaped != 0 {
+		t.Fatalf("embargoed object should not be reaped, got %d %v", reaped, err)
+	}
+	if _, err := expiry.GetObject(ctx, "cold.bin", 0, 0); !isNotFound(err) {
+		t.Fatalf("embargoed object should stay hidden, got %v", err)
+	}
+	if _, err := backend.HeadObject(ctx, "cold.bin"); err != nil {
+		t.Fatalf("embargoed object should stay in the backend: %v", err)
+	}
+	if stats := expiry.Stats(); stats.Deferred != 1 || stats.Expired != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	now = written.Add(31 * 24 * time.Hour)
+	reaped, err = expiry.Reap(ctx)
+	if err != nil || reaped != 1 {
+		t.Fatalf("object should be reaped after the embargo, got %d %v", reaped, err)
+	}
+	if stats := expiry.Stats(); stats.Reaped != 1 || stats.Tracked != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestExpiryRevalidatesAndTouchesLiveKeys(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryBackend(nil)
+	expiry, err := NewExpiryBackend(ctx, backend, ExpiryOptions{Rules: []ExpiryRule{{Prefix: "tmp/", TTL: time.Hour}}})
+	if err != nil {
+		t.Fatalf("NewExpiryBackend: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiry.now = func() time.Time { return now }
+	for _, key := range []string{"tmp/a", "keep.txt"} {
+		if err := expiry.PutObject(ctx, key, []byte("data")); err != nil {
+			t.Fatalf("PutObject %s: %v", key, err)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, _, _, err := expiry.GetObjectIfModified(ctx, "tmp/a", 0, 0, time.Time{}, ""); !isNotFound(err) {
+		t.Fatalf("revalidating an expired object should not find it, got %v", err)
+	}
+	if err := expiry.Touch(ctx, "tmp/a"); !isNotFound(err) {
+		t.Fatalf("touching an expired object should not find it, got %v", err)
+	}
+	if _, _, _, err := expiry.GetObjectIfModified(ctx, DefaultExpiryPrefix+"tmp/a", 0, 0, time.Time{}, ""); !isNotFound(err) {
+		t.Fatalf("revalidating an expiry record should not find it, got %v", err)
+	}
+
+	data, _, _, err := expiry.GetObjectIfModified(ctx, "keep.txt", 1, 2, time.Time{}, "")
+	if err != nil || string(data) != "at" {
+		t.Fatalf("GetObjectIfModified(keep.txt) = %q, %v; want the requested range", data, err)
+	}
+	if err := expiry.Touch(ctx, "keep.txt"); err != nil {
+		t.Fatalf("Touch(keep.txt): %v", err)
+	}
+	if len(backend.touched) != 1 || backend.touched[0] != "keep.txt" {
+		t.Fatalf("touched %v, want keep.txt", backend.touched)
+	}
+}
+
+func TestExpiryListingLimitSkipsHiddenKeys(t *testing.T) {
+	ctx := context.Background()
+	backend := &limitedListBackend{newMemoryBackend(nil)}
+	expiry, err := NewExpiryBackend(ctx, backend, ExpiryOptions{Rules: []ExpiryRule{{Prefix: "a/", TTL: time.Hour}}})
+	if err != nil {
+		t.Fatalf("NewExpiryBackend: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiry.now = func() time.Time { return now }
+	for _, key := range []string{"a/old", "b.txt", "c.txt"} {
+		if err := expiry.PutObject(ctx, key, []byte("data")); err != nil {
+			t.Fatalf("PutObject %s: %v", key, err)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	objects, err := expiry.ListObjects(ctx, "", 1)
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "b.txt" {
+		t.Fatalf("ListObjects with limit 1 = %v, want the first live key b.txt", objects)
+	}
+}
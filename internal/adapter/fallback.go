@@ -0,0 +1,253 @@
+package adapter
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// conditionalGetter is implemented by backends supporting conditional GETs
+type conditionalGetter interface {
+	GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error)
+}
+
+// FallbackBackend layers backends for overlay/union mounts. Reads consult
+// each layer in order and fall through on not-found; writes and deletes only
+// touch the primary (first) layer, so lower layers are never modified.
+type FallbackBackend struct {
+	layers []types.Backend
+}
+
+// NewFallbackBackend creates a backend that reads from primary and falls
+// back to each of fallbacks in order
+func NewFallbackBackend(primary types.Backend, fallbacks ...types.Backend) (*FallbackBackend, error) {
+	if primary == nil {
+		return nil, fmt.Errorf("primary backend cannot be nil")
+	}
+
+	layers := []types.Backend{primary}
+	for i, fallback := range fallbacks {
+		if fallback == nil {
+			return nil, fmt.Errorf("fallback backend %d cannot be nil", i)
+		}
+		layers = append(layers, fallback)
+	}
+
+	return &FallbackBackend{layers: layers}, nil
+}
+
+// Primary returns the layer that receives all writes
+func (f *FallbackBackend) Primary() types.Backend {
+	return f.layers[0]
+}
+
+// GetObject reads from the first layer that has the key
+func (f *FallbackBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	var lastErr error
+	for _, layer := range f.layers {
+		data, err := layer.GetObject(ctx, key, offset, size)
+		if err == nil {
+			return data, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// HeadObject returns metadata from the first layer that has the key
+func (f *FallbackBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	var lastErr error
+	for _, layer := range f.layers {
+		info, err := layer.HeadObject(ctx, key)
+		if err == nil {
+			return info, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// GetObjectIfModified revalidates against the first supporting layer that
+// has the key, allowing cached entries from lower layers to be revalidated
+func (f *FallbackBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	var lastErr error
+	for _, layer := range f.layers {
+		getter, ok := layer.(conditionalGetter)
+		if !ok {
+			continue
+		}
+		data, notModified, info, err := getter.GetObjectIfModified(ctx, key, offset, size, since, etag)
+		if err == nil {
+			return data, notModified, info, nil
+		}
+		if !isNotFound(err) {
+			return nil, false, nil, err
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no backend layer supports conditional reads")
+	}
+	return nil, false, nil, lastErr
+}
+
+// PutObject writes to the primary layer
+func (f *FallbackBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	return f.layers[0].PutObject(ctx, key, data)
+}
+
+// DeleteObject deletes from the primary layer. Copies of the key in lower
+// layers remain visible after deletion.
+func (f *FallbackBackend) DeleteObject(ctx context.Context, key string) error {
+	return f.layers[0].DeleteObject(ctx, key)
+}
+
+// Touch updates the last-modified time of key in the primary layer. Keys
+// that exist only in lower layers are not modified.
+func (f *FallbackBackend) Touch(ctx context.Context, key string) error {
+	toucher, ok := f.layers[0].(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("primary backend does not support touch")
+	}
+	return toucher.Touch(ctx, key)
+}
+
+// MoveObject moves srcKey to dstKey in the primary layer. A key that exists
+// only in a lower layer is copied up to dstKey; as with DeleteObject, its
+// lower-layer copy remains visible.
+func (f *FallbackBackend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	_, err := f.layers[0].HeadObject(ctx, srcKey)
+	if err == nil {
+		mover, ok := f.layers[0].(types.ObjectMover)
+		if !ok {
+			return fmt.Errorf("primary backend does not support move")
+		}
+		return mover.MoveObject(ctx, srcKey, dstKey)
+	}
+	if !isNotFound(err) {
+		return err
+	}
+	return moveByCopy(ctx, f, srcKey, dstKey)
+}
+
+// WriteAt writes part of key in the primary layer. A key that exists only
+// in a lower layer is first copied up with the write applied.
+func (f *FallbackBackend) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	writer, ok := f.layers[0].(types.RangeWriter)
+	if !ok {
+		return fmt.Errorf("primary backend does not support range writes")
+	}
+	_, err := f.layers[0].HeadObject(ctx, key)
+	if err == nil {
+		return writer.WriteAt(ctx, key, offset, data)
+	}
+	if !isNotFound(err) {
+		return err
+	}
+
+	current, err := f.GetObject(ctx, key, 0, 0)
+	if isNotFound(err) {
+		return writer.WriteAt(ctx, key, offset, data)
+	}
+	if err != nil {
+		return err
+	}
+	return f.layers[0].PutObject(ctx, key, patchBytes(current, offset, data))
+}
+
+// GetObjects retrieves keys from the primary, resolving missing keys from
+// lower layers
+func (f *FallbackBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	results := make(map[string][]byte, len(keys))
+	missing := keys
+
+	for _, layer := range f.layers {
+		if len(missing) == 0 {
+			break
+		}
+
+		found, err := layer.GetObjects(ctx, missing)
+		if err != nil && !isNotFound(err) {
+			return nil, err
+		}
+
+		var stillMissing []string
+		for _, key := range missing {
+			if data, ok := found[key]; ok {
+				results[key] = data
+			} else {
+				stillMissing = append(stillMissing, key)
+			}
+		}
+		missing = stillMissing
+	}
+
+	return results, nil
+}
+
+// PutObjects writes to the primary layer
+func (f *FallbackBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	return f.layers[0].PutObjects(ctx, objects)
+}
+
+// ListObjects returns the union of all layers, with upper layers shadowing
+// keys present in lower ones
+func (f *FallbackBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	seen := make(map[string]struct{})
+	var objects []types.ObjectInfo
+
+	for _, layer := range f.layers {
+		layerObjects, err := layer.ListObjects(ctx, prefix, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range layerObjects {
+			if _, ok := seen[obj.Key]; ok {
+				continue
+			}
+			seen[obj.Key] = struct{}{}
+			objects = append(objects, obj)
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+
+	if limit > 0 && len(objects) > limit {
+		objects = objects[:limit]
+	}
+
+	return objects, nil
+}
+
+// HealthCheck verifies every layer is reachable
+func (f *FallbackBackend) HealthCheck(ctx context.Context) error {
+	for i, layer := range f.layers {
+		if err := layer.HealthCheck(ctx); err != nil {
+			return fmt.Errorf("backend layer %d unhealthy: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// isNotFound reports whether err means the key does not exist
+func isNotFound(err error) bool {
+	var objErr *errors.ObjectFSError
+	if stderrors.As(err, &objErr) {
+		return objErr.Code == errors.ErrCodeObjectNotFound || objErr.Code == errors.ErrCodeFileNotFound
+	}
+	return false
+}
@@ -0,0 +1,312 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// memoryBackend is an in-memory types.Backend for layering tests
+type memoryBackend struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	failErr error
+	gets    int
+	touched []string
+}
+
+func newMemoryBackend(objects map[string]string) *memoryBackend {
+	b := &memoryBackend{objects: make(map[string][]byte)}
+	for key, data := range objects {
+		b.objects[key] = []byte(data)
+	}
+	return b
+}
+
+func (b *memoryBackend) notFound(key string) error {
+	return errors.NewError(errors.ErrCodeObjectNotFound, "object not found").WithContext("key", key)
+}
+
+func (b *memoryBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gets++
+
+	if b.failErr != nil {
+		return nil, b.failErr
+	}
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, b.notFound(key)
+	}
+	if size <= 0 || offset+size > int64(len(data)) {
+		size = int64(len(data)) - offset
+	}
+	return append([]byte(nil), data[offset:offset+size]...), nil
+}
+
+func (b *memoryBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (b *memoryBackend) DeleteObject(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.objects, key)
+	return nil
+}
+
+func (b *memoryBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failErr != nil {
+		return nil, b.failErr
+	}
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, b.notFound(key)
+	}
+	return &types.ObjectInfo{Key: key, Size: int64(len(data))}, nil
+}
+
+func (b *memoryBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	results := make(map[string][]byte)
+	for _, key := range keys {
+		if data, err := b.GetObject(ctx, key, 0, 0); err == nil {
+			results[key] = data
+		}
+	}
+	return results, nil
+}
+
+func (b *memoryBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		if err := b.PutObject(ctx, key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *memoryBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var objects []types.ObjectInfo
+	for key, data := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, types.ObjectInfo{Key: key, Size: int64(len(data))})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (b *memoryBackend) HealthCheck(ctx context.Context) error { return b.failErr }
+
+func (b *memoryBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	data, err := b.GetObject(ctx, key, 0, 0)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return sliceRange(data, offset, size), false, &types.ObjectInfo{Key: key, Size: int64(len(data))}, nil
+}
+
+func (b *memoryBackend) Touch(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.objects[key]; !ok {
+		return b.notFound(key)
+	}
+	b.touched = append(b.touched, key)
+	return nil
+}
+
+func newTestFallback(t *testing.T) (*FallbackBackend, *memoryBackend, *memoryBackend) {
+	t.Helper()
+
+	primary := newMemoryBackend(map[string]string{
+		"data/a.txt":     "primary-a",
+		"data/local.txt": "local",
+	})
+	secondary := newMemoryBackend(map[string]string{
+		"data/a.txt":    "base-a",
+		"data/base.txt": "base",
+	})
+
+	fallback, err := NewFallbackBackend(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFallbackBackend() error = %v", err)
+	}
+	return fallback, primary, secondary
+}
+
+func TestFallbackBackend_Reads(t *testing.T) {
+	fallback, _, _ := newTestFallback(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"data/a.txt", "primary-a"}, // primary shadows secondary
+		{"data/local.txt", "local"},
+		{"data/base.txt", "base"}, // read through to secondary
+	}
+
+	for _, tt := range tests {
+		data, err := fallback.GetObject(ctx, tt.key, 0, 0)
+		if err != nil {
+			t.Errorf("GetObject(%q) error = %v", tt.key, err)
+			continue
+		}
+		if string(data) != tt.want {
+			t.Errorf("GetObject(%q) = %q, want %q", tt.key, data, tt.want)
+		}
+
+		info, err := fallback.HeadObject(ctx, tt.key)
+		if err != nil || info.Size != int64(len(tt.want)) {
+			t.Errorf("HeadObject(%q) = %+v, %v", tt.key, info, err)
+		}
+	}
+
+	if _, err := fallback.GetObject(ctx, "data/missing.txt", 0, 0); !isNotFound(err) {
+		t.Errorf("GetObject(missing) error = %v, want not found", err)
+	}
+
+	batch, err := fallback.GetObjects(ctx, []string{"data/a.txt", "data/base.txt", "data/missing.txt"})
+	if err != nil {
+		t.Fatalf("GetObjects() error = %v", err)
+	}
+	if len(batch) != 2 || string(batch["data/a.txt"]) != "primary-a" || string(batch["data/base.txt"]) != "base" {
+		t.Errorf("GetObjects() = %q", batch)
+	}
+
+	data, _, _, err := fallback.GetObjectIfModified(ctx, "data/base.txt", 0, 0, time.Time{}, "")
+	if err != nil || string(data) != "base" {
+		t.Errorf("GetObjectIfModified(base) = %q, %v", data, err)
+	}
+}
+
+func TestFallbackBackend_ErrorsDoNotFallThrough(t *testing.T) {
+	fallback, primary, secondary := newTestFallback(t)
+	primary.failErr = fmt.Errorf("connection refused")
+
+	if _, err := fallback.GetObject(context.Background(), "data/base.txt", 0, 0); err == nil {
+		t.Error("GetObject() should surface non-not-found primary errors")
+	}
+	if secondary.gets != 0 {
+		t.Errorf("secondary consulted %d times after primary failure", secondary.gets)
+	}
+	if err := fallback.HealthCheck(context.Background()); err == nil {
+		t.Error("HealthCheck() should report unhealthy primary")
+	}
+}
+
+func TestFallbackBackend_WritesGoToPrimary(t *testing.T) {
+	fallback, primary, secondary := newTestFallback(t)
+	ctx := context.Background()
+
+	// Copy-on-write: modifying a base object lands in the primary only
+	if err := fallback.PutObject(ctx, "data/base.txt", []byte("modified")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+	if err := fallback.PutObjects(ctx, map[string][]byte{"data/new.txt": []byte("new")}); err != nil {
+		t.Fatalf("PutObjects() error = %v", err)
+	}
+
+	if string(primary.objects["data/base.txt"]) != "modified" || string(primary.objects["data/new.txt"]) != "new" {
+		t.Errorf("primary objects = %q", primary.objects)
+	}
+	if string(secondary.objects["data/base.txt"]) != "base" || len(secondary.objects) != 2 {
+		t.Errorf("secondary modified: %q", secondary.objects)
+	}
+
+	data, err := fallback.GetObject(ctx, "data/base.txt", 0, 0)
+	if err != nil || string(data) != "modified" {
+		t.Errorf("GetObject() after write = %q, %v", data, err)
+	}
+
+	if err := fallback.DeleteObject(ctx, "data/local.txt"); err != nil {
+		t.Fatalf("DeleteObject() error = %v", err)
+	}
+	if _, ok := primary.objects["data/local.txt"]; ok {
+		t.Error("DeleteObject() did not remove key from primary")
+	}
+}
+
+func TestFallbackBackend_ListObjectsUnion(t *testing.T) {
+	fallback, _, _ := newTestFallback(t)
+	ctx := context.Background()
+
+	objects, err := fallback.ListObjects(ctx, "data/", 0)
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+
+	want := map[string]int64{
+		"data/a.txt":     int64(len("primary-a")),
+		"data/base.txt":  int64(len("base")),
+		"data/local.txt": int64(len("local")),
+	}
+	if len(objects) != len(want) {
+		t.Fatalf("ListObjects() returned %d objects, want %d: %+v", len(objects), len(want), objects)
+	}
+	for i, obj := range objects {
+		if size, ok := want[obj.Key]; !ok || obj.Size != size {
+			t.Errorf("ListObjects()[%d] = %+v", i, obj)
+		}
+		if i > 0 && objects[i-1].Key >= obj.Key {
+			t.Errorf("ListObjects() not sorted at %d", i)
+		}
+	}
+
+	limited, err := fallback.ListObjects(ctx, "data/", 2)
+	if err != nil || len(limited) != 2 {
+		t.Errorf("ListObjects(limit=2) = %+v, %v", limited, err)
+	}
+}
+
+func TestNewFallbackBackend_Validation(t *testing.T) {
+	if _, err := NewFallbackBackend(nil); err == nil {
+		t.Error("NewFallbackBackend(nil) should fail")
+	}
+	if _, err := NewFallbackBackend(newMemoryBackend(nil), nil); err == nil {
+		t.Error("NewFallbackBackend with nil fallback should fail")
+	}
+
+	// Chains of more than two layers are consulted in order
+	top := newMemoryBackend(nil)
+	middle := newMemoryBackend(map[string]string{"k": "middle"})
+	bottom := newMemoryBackend(map[string]string{"k": "bottom", "only": "bottom"})
+	chain, err := NewFallbackBackend(top, middle, bottom)
+	if err != nil {
+		t.Fatalf("NewFallbackBackend() error = %v", err)
+	}
+	if data, _ := chain.GetObject(context.Background(), "k", 0, 0); string(data) != "middle" {
+		t.Errorf("GetObject(k) = %q, want middle", data)
+	}
+	if data, _ := chain.GetObject(context.Background(), "only", 0, 0); string(data) != "bottom" {
+		t.Errorf("GetObject(only) = %q, want bottom", data)
+	}
+}
+
+func TestNewRejectsInvalidFallbackURI(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Storage.Fallback = []string{"gs://base-dataset"}
+
+	if _, err := New(context.Background(), "s3://test-bucket", "/mnt/test", cfg); err == nil {
+		t.Error("New() should reject unsupported fallback storage URI")
+	}
+}
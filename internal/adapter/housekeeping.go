@@ -0,0 +1,121 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Housekeeping defaults
+const (
+	defaultHousekeepingInterval   = 24 * time.Hour
+	defaultHousekeepingAbortAfter = 7 * 24 * time.Hour
+)
+
+// HousekeepingOptions controls ReclaimIncompleteUploads
+type HousekeepingOptions struct {
+	OlderThan time.Duration // Abort uploads initiated longer ago than this
+	DryRun    bool          // Report stale uploads without aborting them
+}
+
+// HousekeepingResult summarizes a ReclaimIncompleteUploads run
+type HousekeepingResult struct {
+	Stale   []types.IncompleteUpload `json:"stale"`   // Uploads older than the threshold when listed
+	Aborted int                      `json:"aborted"` // Uploads aborted; always 0 in a dry run
+	DryRun  bool                     `json:"dry_run"`
+}
+
+// ReclaimIncompleteUploads finds the incomplete multipart uploads older than
+// opts.OlderThan and, unless opts.DryRun is set, aborts them so their parts
+// stop accruing storage charges
+func ReclaimIncompleteUploads(ctx context.Context, housekeeper types.MultipartHousekeeper, opts HousekeepingOptions) (*HousekeepingResult, error) {
+	uploads, err := housekeeper.ListIncompleteUploads(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incomplete uploads: %w", err)
+	}
+
+	result := &HousekeepingResult{DryRun: opts.DryRun}
+	cutoff := time.Now().Add(-opts.OlderThan)
+	for _, upload := range uploads {
+		if upload.Initiated.Before(cutoff) {
+			result.Stale = append(result.Stale, upload)
+		}
+	}
+	if opts.DryRun || len(result.Stale) == 0 {
+		return result, nil
+	}
+
+	result.Aborted, err = housekeeper.AbortIncompleteUploads(ctx, opts.OlderThan)
+	if err != nil {
+		return result, fmt.Errorf("failed to abort incomplete uploads: %w", err)
+	}
+	return result, nil
+}
+
+// Housekeeping reclaims incomplete multipart uploads in the mounted bucket
+// older than the configured age. With dryRun the uploads are only reported.
+func (a *Adapter) Housekeeping(ctx context.Context, dryRun bool) (*HousekeepingResult, error) {
+	if a.backend == nil {
+		return nil, fmt.Errorf("adapter not started")
+	}
+	return ReclaimIncompleteUploads(ctx, a.backend, a.housekeepingOptions(dryRun))
+}
+
+// housekeepingOptions returns the configured housekeeping options
+func (a *Adapter) housekeepingOptions(dryRun bool) HousekeepingOptions {
+	olderThan := a.config.Storage.S3.Housekeeping.AbortAfter
+	if olderThan <= 0 {
+		olderThan = defaultHousekeepingAbortAfter
+	}
+	return HousekeepingOptions{OlderThan: olderThan, DryRun: dryRun}
+}
+
+// startHousekeeping runs housekeeping every configured interval until
+// stopHousekeeping is called
+func (a *Adapter) startHousekeeping(housekeeper types.MultipartHousekeeper) {
+	settings := a.config.Storage.S3.Housekeeping
+	interval := settings.Interval
+	if interval <= 0 {
+		interval = defaultHousekeepingInterval
+	}
+	opts := a.housekeepingOptions(settings.DryRun)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	a.stopHousekeeping = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result, err := ReclaimIncompleteUploads(ctx, housekeeper, opts)
+				if err != nil {
+					log.Printf("Housekeeping failed: %v", err)
+				}
+				if result == nil {
+					continue
+				}
+				if result.DryRun {
+					for _, upload := range result.Stale {
+						log.Printf("Housekeeping dry run: would abort upload %s of %s initiated %s",
+							upload.UploadID, upload.Key, upload.Initiated.Format(time.RFC3339))
+					}
+				} else if result.Aborted > 0 {
+					log.Printf("Housekeeping aborted %d incomplete multipart uploads", result.Aborted)
+				}
+			}
+		}
+	}()
+}
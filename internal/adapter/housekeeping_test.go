@@ -0,0 +1,116 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/config"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// staleUploadsBackend reports incomplete uploads and aborts those older
+// than the requested age
+type staleUploadsBackend struct {
+	mu      sync.Mutex
+	uploads []types.IncompleteUpload
+	aborts  []time.Duration
+}
+
+func newStaleUploadsBackend() *staleUploadsBackend {
+	now := time.Now()
+	return &staleUploadsBackend{uploads: []types.IncompleteUpload{
+		{Key: "a.bin", UploadID: "stale", Initiated: now.Add(-10 * 24 * time.Hour)},
+		{Key: "b.bin", UploadID: "fresh", Initiated: now.Add(-time.Hour)},
+	}}
+}
+
+func (b *staleUploadsBackend) ListIncompleteUploads(ctx context.Context, prefix string) ([]types.IncompleteUpload, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]types.IncompleteUpload(nil), b.uploads...), nil
+}
+
+func (b *staleUploadsBackend) AbortIncompleteUploads(ctx context.Context, olderThan time.Duration) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.aborts = append(b.aborts, olderThan)
+
+	cutoff := time.Now().Add(-olderThan)
+	kept, aborted := b.uploads[:0], 0
+	for _, upload := range b.uploads {
+		if upload.Initiated.Before(cutoff) {
+			aborted++
+			continue
+		}
+		kept = append(kept, upload)
+	}
+	b.uploads = kept
+	return aborted, nil
+}
+
+func (b *staleUploadsBackend) abortCalls() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.aborts)
+}
+
+func TestReclaimIncompleteUploadsDryRun(t *testing.T) {
+	backend := newStaleUploadsBackend()
+
+	result, err := ReclaimIncompleteUploads(context.Background(), backend, HousekeepingOptions{OlderThan: 7 * 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("ReclaimIncompleteUploads failed: %v", err)
+	}
+	if len(result.Stale) != 1 || result.Stale[0].UploadID != "stale" {
+		t.Errorf("stale uploads = %+v, want only the 10 day old upload", result.Stale)
+	}
+	if result.Aborted != 0 || backend.abortCalls() != 0 {
+		t.Errorf("dry run aborted %d uploads with %d abort calls, want none", result.Aborted, backend.abortCalls())
+	}
+}
+
+func TestReclaimIncompleteUploadsAbortsOnlyStale(t *testing.T) {
+	backend := newStaleUploadsBackend()
+
+	result, err := ReclaimIncompleteUploads(context.Background(), backend, HousekeepingOptions{OlderThan: 7 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("ReclaimIncompleteUploads failed: %v", err)
+	}
+	if result.Aborted != 1 {
+		t.Errorf("aborted = %d, want 1", result.Aborted)
+	}
+	if len(backend.uploads) != 1 || backend.uploads[0].UploadID != "fresh" {
+		t.Errorf("remaining uploads = %+v, want only the fresh upload", backend.uploads)
+	}
+
+	// Nothing left to abort skips the abort call
+	if _, err := ReclaimIncompleteUploads(context.Background(), backend, HousekeepingOptions{OlderThan: 7 * 24 * time.Hour}); err != nil {
+		t.Fatalf("second run failed: %v", err)
+	}
+	if calls := backend.abortCalls(); calls != 1 {
+		t.Errorf("abort calls = %d, want 1", calls)
+	}
+}
+
+func TestPeriodicHousekeepingUsesConfiguredAge(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Storage.S3.Housekeeping.Interval = 10 * time.Millisecond
+	cfg.Storage.S3.Housekeeping.AbortAfter = 48 * time.Hour
+	a := &Adapter{config: cfg}
+	backend := newStaleUploadsBackend()
+
+	a.startHousekeeping(backend)
+	deadline := time.Now().Add(time.Second)
+	for backend.abortCalls() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	a.stopHousekeeping()
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if len(backend.aborts) == 0 || backend.aborts[0] != 48*time.Hour {
+		t.Errorf("abort calls = %v, want one with the configured 48h age", backend.aborts)
+	}
+}
@@ -0,0 +1,76 @@
+package adapter
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// defaultLatencyProbeInterval is how often the backend is pinged when no
+// interval is configured
+const defaultLatencyProbeInterval = 30 * time.Second
+
+// startLatencyProbe pings prober now and every configured interval until
+// stopLatencyProbe is called, exporting the rolling latency and whether
+// the backend could be reached
+func (a *Adapter) startLatencyProbe(prober types.LatencyProber) {
+	interval := a.config.Storage.S3.LatencyProbe.Interval
+	if interval <= 0 {
+		interval = defaultLatencyProbeInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	a.stopLatencyProbe = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		reachable := true
+		for {
+			reachable = a.probeLatency(ctx, prober, interval, reachable)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// probeLatency pings prober once and records the result, logging only when
+// reachability changes. It returns whether the backend was reached.
+func (a *Adapter) probeLatency(ctx context.Context, prober types.LatencyProber, timeout time.Duration, wasReachable bool) bool {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := prober.Ping(pingCtx)
+	if ctx.Err() != nil {
+		return wasReachable // Stopping, not a connectivity failure
+	}
+
+	reachable := err == nil
+	if !reachable && wasReachable {
+		log.Printf("Backend %s unreachable: %v", a.bucketName, err)
+	} else if reachable && !wasReachable {
+		log.Printf("Backend %s reachable again", a.bucketName)
+	}
+
+	if a.metrics != nil {
+		a.metrics.UpdateBackendReachable(a.bucketName, reachable)
+		if reachable {
+			a.metrics.UpdateBackendLatency(a.bucketName, prober.LatencyEstimate())
+		} else {
+			a.metrics.RecordError("backend_ping", err)
+		}
+	}
+	return reachable
+}
@@ -0,0 +1,143 @@
+package adapter
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// defaultListCacheTTL is how long listings are cached when no TTL is configured
+const defaultListCacheTTL = 5 * time.Second
+
+// ListCacheStats reports listing cache activity
+type ListCacheStats struct {
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	Invalidations int64   `json:"invalidations"`
+	Entries       int     `json:"entries"`
+	HitRate       float64 `json:"hit_rate"`
+}
+
+// listCacheKey identifies one listing request
+type listCacheKey struct {
+	prefix string
+	limit  int
+}
+
+// listCacheEntry is a cached listing and when it expires
+type listCacheEntry struct {
+	objects []types.ObjectInfo
+	expires time.Time
+}
+
+// listCache holds recent listings for a short TTL. Every mutation bumps
+// the generation, so a listing that raced with a write is never stored.
+type listCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu         sync.Mutex
+	entries    map[listCacheKey]listCacheEntry
+	generation uint64
+	stats      ListCacheStats
+}
+
+// newListCache creates a listing cache holding results for ttl
+func newListCache(ttl time.Duration, now func() time.Time) *listCache {
+	if ttl <= 0 {
+		ttl = defaultListCacheTTL
+	}
+	return &listCache{
+		ttl:     ttl,
+		now:     now,
+		entries: make(map[listCacheKey]listCacheEntry),
+	}
+}
+
+// get returns a copy of the cached listing, or the generation to pass to
+// put when the listing must be fetched
+func (c *listCache) get(key listCacheKey) ([]types.ObjectInfo, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok && c.now().Before(entry.expires) {
+		c.stats.Hits++
+		return append([]types.ObjectInfo(nil), entry.objects...), c.generation, true
+	}
+	if ok {
+		delete(c.entries, key)
+	}
+	c.stats.Misses++
+	return nil, c.generation, false
+}
+
+// put caches objects fetched at generation, unless a mutation happened since
+func (c *listCache) put(key listCacheKey, generation uint64, objects []types.ObjectInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	c.entries[key] = listCacheEntry{
+		objects: append([]types.ObjectInfo(nil), objects...),
+		expires: c.now().Add(c.ttl),
+	}
+}
+
+// invalidate drops every cached listing whose prefix covers key
+func (c *listCache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for cached := range c.entries {
+		if strings.HasPrefix(key, cached.prefix) {
+			delete(c.entries, cached)
+			c.stats.Invalidations++
+		}
+	}
+}
+
+// snapshot returns current cache statistics
+func (c *listCache) snapshot() ListCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// EnableListCache caches ListObjects results for ttl (default 5s). Writes,
+// deletes, and touches through the overlay drop the cached listings of
+// every prefix containing the key.
+func (o *ListingOverlay) EnableListCache(ttl time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.listCache = newListCache(ttl, func() time.Time { return o.now() })
+}
+
+// ListCacheStats returns listing cache activity, or nil when the cache is
+// not enabled
+func (o *ListingOverlay) ListCacheStats() *ListCacheStats {
+	cache := o.cache()
+	if cache == nil {
+		return nil
+	}
+	stats := cache.snapshot()
+	return &stats
+}
+
+// cache returns the listing cache, or nil when it is not enabled
+func (o *ListingOverlay) cache() *listCache {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.listCache
+}
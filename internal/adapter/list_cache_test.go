@@ -0,0 +1,93 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// countingBackend counts the listings that reach the wrapped backend
+type countingBackend struct {
+	*memoryBackend
+	lists int
+}
+
+func (b *countingBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	b.lists++
+	return b.memoryBackend.ListObjects(ctx, prefix, limit)
+}
+
+func TestListCacheServesRepeatedListings(t *testing.T) {
+	backend := &countingBackend{memoryBackend: newMemoryBackend(map[string]string{
+		"docs/a.txt": "a",
+		"logs/1.log": "1",
+	})}
+	overlay, err := NewListingOverlay(backend, time.Minute)
+	if err != nil {
+		t.Fatalf("NewListingOverlay failed: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	overlay.now = func() time.Time { return now }
+	overlay.EnableListCache(10 * time.Second)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if keys := listKeys(t, overlay, "docs/"); len(keys) != 1 {
+			t.Fatalf("listing %d = %v, want docs/a.txt", i, keys)
+		}
+	}
+	listKeys(t, overlay, "logs/")
+	if backend.lists != 2 {
+		t.Errorf("backend listed %d times, want 2 (one per prefix)", backend.lists)
+	}
+
+	// A write under a prefix drops its cached listing but not others
+	if err := overlay.PutObject(ctx, "docs/b.txt", []byte("b")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if keys := listKeys(t, overlay, "docs/"); len(keys) != 2 {
+		t.Errorf("listing after write = %v, want both documents", keys)
+	}
+	listKeys(t, overlay, "logs/")
+	if backend.lists != 3 {
+		t.Errorf("backend listed %d times, want 3 after one invalidation", backend.lists)
+	}
+
+	// Expired listings are fetched again
+	now = now.Add(10 * time.Second)
+	listKeys(t, overlay, "logs/")
+	if backend.lists != 4 {
+		t.Errorf("backend listed %d times, want 4 after the TTL", backend.lists)
+	}
+
+	stats := overlay.ListCacheStats()
+	if stats == nil || stats.Hits != 3 || stats.Misses != 4 || stats.HitRate != 3.0/7 {
+		t.Errorf("stats = %+v, want 3 hits and 4 misses", stats)
+	}
+}
+
+func TestListCacheDeleteInvalidatesParentPrefixes(t *testing.T) {
+	backend := &countingBackend{memoryBackend: newMemoryBackend(map[string]string{"a/b/c.txt": "c"})}
+	overlay, err := NewListingOverlay(backend, time.Minute)
+	if err != nil {
+		t.Fatalf("NewListingOverlay failed: %v", err)
+	}
+	overlay.EnableListCache(time.Minute)
+
+	listKeys(t, overlay, "")
+	listKeys(t, overlay, "a/")
+	listKeys(t, overlay, "a/b/")
+	if err := overlay.DeleteObject(context.Background(), "a/b/c.txt"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	for _, prefix := range []string{"", "a/", "a/b/"} {
+		if keys := listKeys(t, overlay, prefix); len(keys) != 0 {
+			t.Errorf("listing %q after delete = %v, want empty", prefix, keys)
+		}
+	}
+	if backend.lists != 6 {
+		t.Errorf("backend listed %d times, want every prefix listed again", backend.lists)
+	}
+}
@@ -0,0 +1,370 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// defaultOverlayWindow is how long local writes and deletes are merged into
+// listings when no window is configured
+const defaultOverlayWindow = 30 * time.Second
+
+// overlayEntry is a recent local mutation of one key
+type overlayEntry struct {
+	info    types.ObjectInfo
+	deleted bool
+	expires time.Time
+}
+
+// ListingOverlay makes listings reflect local writes immediately on backends
+// whose listings lag behind writes. Keys written or deleted through it are
+// remembered for a window and merged into list results: recent writes are
+// added and recent deletes hidden until the backend catches up.
+type ListingOverlay struct {
+	backend types.Backend
+	window  time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]overlayEntry
+	listCache *listCache // Optional; see EnableListCache
+}
+
+// NewListingOverlay wraps backend, remembering local mutations for window
+func NewListingOverlay(backend types.Backend, window time.Duration) (*ListingOverlay, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	if window <= 0 {
+		window = defaultOverlayWindow
+	}
+
+	return &ListingOverlay{
+		backend: backend,
+		window:  window,
+		now:     time.Now,
+		entries: make(map[string]overlayEntry),
+	}, nil
+}
+
+// recordWrite remembers that key now holds size bytes
+func (o *ListingOverlay) recordWrite(key string, size int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.now()
+	o.entries[key] = overlayEntry{
+		info:    types.ObjectInfo{Key: key, Size: size, LastModified: now},
+		expires: now.Add(o.window),
+	}
+	if o.listCache != nil {
+		o.listCache.invalidate(key)
+	}
+}
+
+// recordDelete remembers that key was removed
+func (o *ListingOverlay) recordDelete(key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.entries[key] = overlayEntry{
+		info:    types.ObjectInfo{Key: key},
+		deleted: true,
+		expires: o.now().Add(o.window),
+	}
+	if o.listCache != nil {
+		o.listCache.invalidate(key)
+	}
+}
+
+// pending returns the live mutations under prefix, dropping expired ones
+func (o *ListingOverlay) pending(prefix string) map[string]overlayEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.now()
+	result := make(map[string]overlayEntry)
+	for key, entry := range o.entries {
+		if !now.Before(entry.expires) {
+			delete(o.entries, key)
+			continue
+		}
+		if strings.HasPrefix(key, prefix) {
+			result[key] = entry
+		}
+	}
+	return result
+}
+
+// GetObject reads from the wrapped backend
+func (o *ListingOverlay) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	return o.backend.GetObject(ctx, key, offset, size)
+}
+
+// PutObject writes key and adds it to listings until the backend lists it
+func (o *ListingOverlay) PutObject(ctx context.Context, key string, data []byte) error {
+	if err := o.backend.PutObject(ctx, key, data); err != nil {
+		return err
+	}
+	o.recordWrite(key, int64(len(data)))
+	return nil
+}
+
+// DeleteObject deletes key and hides it from listings until the backend
+// stops listing it
+func (o *ListingOverlay) DeleteObject(ctx context.Context, key string) error {
+	if err := o.backend.DeleteObject(ctx, key); err != nil && !isNotFound(err) {
+		return err
+	}
+	o.recordDelete(key)
+	return nil
+}
+
+// HeadObject returns metadata from the wrapped backend
+func (o *ListingOverlay) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	return o.backend.HeadObject(ctx, key)
+}
+
+// GetObjects reads from the wrapped backend
+func (o *ListingOverlay) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	return o.backend.GetObjects(ctx, keys)
+}
+
+// PutObjects writes objects and adds them to listings
+func (o *ListingOverlay) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	if err := o.backend.PutObjects(ctx, objects); err != nil {
+		return err
+	}
+	for key, data := range objects {
+		o.recordWrite(key, int64(len(data)))
+	}
+	return nil
+}
+
+// ListObjects lists the wrapped backend, merging in recent local mutations.
+// Results are served from the listing cache when it is enabled.
+func (o *ListingOverlay) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	cache := o.cache()
+	if cache == nil {
+		return o.listObjects(ctx, prefix, limit)
+	}
+
+	key := listCacheKey{prefix: prefix, limit: limit}
+	objects, generation, ok := cache.get(key)
+	if ok {
+		return objects, nil
+	}
+	objects, err := o.listObjects(ctx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(key, generation, objects)
+	return objects, nil
+}
+
+// listObjects lists the wrapped backend, merging in recent local mutations
+func (o *ListingOverlay) listObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	objects, err := o.backend.ListObjects(ctx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := o.pending(prefix)
+	if len(pending) == 0 {
+		return objects, nil
+	}
+
+	merged := make([]types.ObjectInfo, 0, len(objects)+len(pending))
+	for _, obj := range objects {
+		if entry, ok := pending[obj.Key]; ok {
+			delete(pending, obj.Key)
+			if entry.deleted {
+				continue
+			}
+			// A stale listing may still show the previous version
+			if obj.LastModified.Before(entry.info.LastModified) {
+				obj.Size = entry.info.Size
+				obj.LastModified = entry.info.LastModified
+			}
+		}
+		merged = append(merged, obj)
+	}
+	for _, entry := range pending {
+		if !entry.deleted {
+			merged = append(merged, entry.info)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Key < merged[j].Key
+	})
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// ListObjectsChan streams the wrapped backend's listing with recent local
+// mutations merged in. Recent writes missing from the listing are sent last.
+func (o *ListingOverlay) ListObjectsChan(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	streamer, ok := o.backend.(types.ObjectStreamer)
+	if !ok {
+		objCh := make(chan types.ObjectInfo)
+		errCh := make(chan error, 1)
+		go func() {
+			defer close(objCh)
+			defer close(errCh)
+			objects, err := o.listObjects(ctx, prefix, 0)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			for _, obj := range objects {
+				select {
+				case objCh <- obj:
+				case <-ctx.Done():
+					errCh <- ctx.Err()
+					return
+				}
+			}
+		}()
+		return objCh, errCh
+	}
+
+	rawCh, rawErrCh := streamer.ListObjectsChan(ctx, prefix)
+	pending := o.pending(prefix)
+	objCh := make(chan types.ObjectInfo)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(objCh)
+		defer close(errCh)
+
+		send := func(obj types.ObjectInfo) bool {
+			select {
+			case objCh <- obj:
+				return true
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return false
+			}
+		}
+
+		for obj := range rawCh {
+			if entry, ok := pending[obj.Key]; ok {
+				delete(pending, obj.Key)
+				if entry.deleted {
+					continue
+				}
+				if obj.LastModified.Before(entry.info.LastModified) {
+					obj.Size = entry.info.Size
+					obj.LastModified = entry.info.LastModified
+				}
+			}
+			if !send(obj) {
+				return
+			}
+		}
+		if err := <-rawErrCh; err != nil {
+			errCh <- err
+			return
+		}
+
+		keys := make([]string, 0, len(pending))
+		for key, entry := range pending {
+			if !entry.deleted {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !send(pending[key].info) {
+				return
+			}
+		}
+	}()
+
+	return objCh, errCh
+}
+
+// GetObjectIfModified revalidates through the wrapped backend when it
+// supports conditional reads
+func (o *ListingOverlay) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := o.backend.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	return getter.GetObjectIfModified(ctx, key, offset, size, since, etag)
+}
+
+// Touch updates the last-modified time of key in the wrapped backend
+func (o *ListingOverlay) Touch(ctx context.Context, key string) error {
+	toucher, ok := o.backend.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	if err := toucher.Touch(ctx, key); err != nil {
+		return err
+	}
+	if cache := o.cache(); cache != nil {
+		cache.invalidate(key)
+	}
+	return nil
+}
+
+// MoveObject moves srcKey to dstKey in the wrapped backend, showing dstKey
+// and hiding srcKey in listings. A source left behind by a failed delete
+// stays listed.
+func (o *ListingOverlay) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	mover, ok := o.backend.(types.ObjectMover)
+	if !ok {
+		return fmt.Errorf("backend does not support move")
+	}
+	err := mover.MoveObject(ctx, srcKey, dstKey)
+	if cache := o.cache(); cache != nil {
+		cache.invalidate(srcKey)
+		cache.invalidate(dstKey)
+	}
+	info, headErr := o.backend.HeadObject(ctx, dstKey)
+	if headErr != nil {
+		return err
+	}
+	o.recordWrite(dstKey, info.Size)
+	if err == nil {
+		o.recordDelete(srcKey)
+	}
+	return err
+}
+
+// WriteAt writes part of key in the wrapped backend and shows it in
+// listings. The recorded size is a lower bound unless the key was written
+// through the overlay before.
+func (o *ListingOverlay) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	writer, ok := o.backend.(types.RangeWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support range writes")
+	}
+	if err := writer.WriteAt(ctx, key, offset, data); err != nil {
+		return err
+	}
+
+	size := offset + int64(len(data))
+	o.mu.Lock()
+	if entry, ok := o.entries[key]; ok && !entry.deleted && entry.info.Size > size {
+		size = entry.info.Size
+	}
+	o.mu.Unlock()
+	o.recordWrite(key, size)
+	return nil
+}
+
+// HealthCheck checks the wrapped backend
+func (o *ListingOverlay) HealthCheck(ctx context.Context) error {
+	return o.backend.HealthCheck(ctx)
+}
@@ -0,0 +1,139 @@
+package adapter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// laggingBackend is a memoryBackend whose listings omit keys written after
+// it was created and still show keys deleted since, like an eventually
+// consistent store
+type laggingBackend struct {
+	*memoryBackend
+	snapshot []types.ObjectInfo
+}
+
+func newLaggingBackend(objects map[string]string) *laggingBackend {
+	b := &laggingBackend{memoryBackend: newMemoryBackend(objects)}
+	b.snapshot, _ = b.memoryBackend.ListObjects(context.Background(), "", 0)
+	return b
+}
+
+func (b *laggingBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	var objects []types.ObjectInfo
+	for _, obj := range b.snapshot {
+		if strings.HasPrefix(obj.Key, prefix) {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+func listKeys(t *testing.T, backend types.Backend, prefix string) []string {
+	t.Helper()
+	objects, err := backend.ListObjects(context.Background(), prefix, 0)
+	if err != nil {
+		t.Fatalf("ListObjects(%q) failed: %v", prefix, err)
+	}
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+func TestListingOverlayShowsWriteBeforeBackendLists(t *testing.T) {
+	backend := newLaggingBackend(map[string]string{"docs/a.txt": "a"})
+	overlay, err := NewListingOverlay(backend, time.Minute)
+	if err != nil {
+		t.Fatalf("NewListingOverlay failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := overlay.PutObject(ctx, "docs/b.txt", []byte("bb")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if raw := listKeys(t, backend, "docs/"); len(raw) != 1 {
+		t.Fatalf("raw listing = %v, want the new key missing", raw)
+	}
+
+	keys := listKeys(t, overlay, "docs/")
+	if len(keys) != 2 || keys[0] != "docs/a.txt" || keys[1] != "docs/b.txt" {
+		t.Errorf("overlay listing = %v, want [docs/a.txt docs/b.txt]", keys)
+	}
+	if keys := listKeys(t, overlay, "other/"); len(keys) != 0 {
+		t.Errorf("listing of another prefix = %v, want none", keys)
+	}
+}
+
+func TestListingOverlayHidesDelete(t *testing.T) {
+	backend := newLaggingBackend(map[string]string{"docs/a.txt": "a", "docs/b.txt": "b"})
+	overlay, err := NewListingOverlay(backend, time.Minute)
+	if err != nil {
+		t.Fatalf("NewListingOverlay failed: %v", err)
+	}
+
+	if err := overlay.DeleteObject(context.Background(), "docs/a.txt"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	keys := listKeys(t, overlay, "docs/")
+	if len(keys) != 1 || keys[0] != "docs/b.txt" {
+		t.Errorf("overlay listing = %v, want [docs/b.txt]", keys)
+	}
+}
+
+func TestListingOverlayExpires(t *testing.T) {
+	backend := newLaggingBackend(nil)
+	overlay, err := NewListingOverlay(backend, time.Minute)
+	if err != nil {
+		t.Fatalf("NewListingOverlay failed: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	overlay.now = func() time.Time { return now }
+
+	if err := overlay.PutObject(context.Background(), "docs/a.txt", []byte("a")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if keys := listKeys(t, overlay, ""); len(keys) != 1 {
+		t.Fatalf("overlay listing = %v, want the pending write", keys)
+	}
+
+	// After the window the backend listing is trusted again
+	now = now.Add(time.Minute)
+	if keys := listKeys(t, overlay, ""); len(keys) != 0 {
+		t.Errorf("overlay listing = %v after the window, want the raw listing", keys)
+	}
+}
+
+func TestListingOverlayStream(t *testing.T) {
+	backend := newLaggingBackend(map[string]string{"docs/a.txt": "a", "docs/b.txt": "b"})
+	overlay, err := NewListingOverlay(backend, time.Minute)
+	if err != nil {
+		t.Fatalf("NewListingOverlay failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := overlay.PutObject(ctx, "docs/c.txt", []byte("c")); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if err := overlay.DeleteObject(ctx, "docs/a.txt"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	objCh, errCh := overlay.ListObjectsChan(ctx, "docs/")
+	var keys []string
+	for obj := range objCh {
+		keys = append(keys, obj.Key)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("ListObjectsChan failed: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "docs/b.txt" || keys[1] != "docs/c.txt" {
+		t.Errorf("streamed listing = %v, want [docs/b.txt docs/c.txt]", keys)
+	}
+}
@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/metrics"
+	"github.com/objectfs/objectfs/pkg/types"
+	"github.com/objectfs/objectfs/pkg/utils"
+)
+
+// initLogging builds the structured logger described by the monitoring
+// config. It becomes the process default, so the standard library log
+// calls of components without an injected logger emit the same records.
+// Without structured logging the existing default logger is kept.
+func (a *Adapter) initLogging() error {
+	if a.logger != nil {
+		return nil
+	}
+
+	settings := a.config.Monitoring.Logging
+	if !settings.Structured {
+		a.logger = slog.Default()
+		return nil
+	}
+
+	// The log file stays open for the life of the process, as the
+	// default logger keeps writing to it after the adapter stops
+	output, _, err := utils.OpenLogOutput(a.config.Global.LogFile)
+	if err != nil {
+		return err
+	}
+	logger, err := utils.NewSlogLogger(&utils.SlogConfig{
+		Level:      a.config.Global.LogLevel,
+		Format:     settings.Format,
+		Output:     output,
+		Fields:     settings.Fields,
+		RedactKeys: settings.RedactKeys,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize logging: %w", err)
+	}
+
+	a.logger = logger
+	slog.SetDefault(logger)
+	return nil
+}
+
+// putObject writes a flushed object to storage, logging the outcome with
+// the key, size, and duration
+func (a *Adapter) putObject(ctx context.Context, key string, data []byte) error {
+	start := time.Now()
+	err := a.storage.PutObject(ctx, key, data)
+	if err != nil {
+		a.logger.Error("Object write failed",
+			"key", key,
+			"size", len(data),
+			"duration", time.Since(start),
+			"error", err)
+		return err
+	}
+
+	if a.metrics != nil {
+		a.metrics.RecordObjectSize(metrics.ObjectSizePut, int64(len(data)))
+	}
+	a.logger.Debug("Object written",
+		"key", key,
+		"size", len(data),
+		"duration", time.Since(start))
+	return nil
+}
+
+// writeAt writes a flushed range of an object to storage, logging the
+// outcome with the key, offset, size, and duration
+func (a *Adapter) writeAt(ctx context.Context, key string, offset int64, data []byte) error {
+	writer, ok := a.storage.(types.RangeWriter)
+	if !ok {
+		return fmt.Errorf("storage does not support range writes")
+	}
+
+	start := time.Now()
+	if err := writer.WriteAt(ctx, key, offset, data); err != nil {
+		a.logger.Error("Object range write failed",
+			"key", key,
+			"offset", offset,
+			"size", len(data),
+			"duration", time.Since(start),
+			"error", err)
+		return err
+	}
+
+	a.logger.Debug("Object range written",
+		"key", key,
+		"offset", offset,
+		"size", len(data),
+		"duration", time.Since(start))
+	return nil
+}
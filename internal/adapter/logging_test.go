@@ -0,0 +1,42 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/objectfs/objectfs/pkg/utils"
+)
+
+func TestPutObjectLogsStructuredEvent(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := utils.NewSlogLogger(&utils.SlogConfig{Level: "DEBUG", Output: &buf})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	backend := newMemoryBackend(nil)
+	a := &Adapter{storage: backend, logger: logger.With("session_token", "FwoGZXIvYXdzEBY")}
+
+	if err := a.putObject(context.Background(), "dir/file.txt", []byte("hello world")); err != nil {
+		t.Fatalf("putObject failed: %v", err)
+	}
+	if string(backend.objects["dir/file.txt"]) != "hello world" {
+		t.Fatal("object not written to storage")
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("put did not log a JSON record: %v (%q)", err, buf.String())
+	}
+	if record["key"] != "dir/file.txt" {
+		t.Errorf("key = %v, want dir/file.txt", record["key"])
+	}
+	if record["size"] != float64(len("hello world")) {
+		t.Errorf("size = %v, want %d", record["size"], len("hello world"))
+	}
+	if record["session_token"] != utils.RedactedValue {
+		t.Errorf("session_token = %v, want it redacted", record["session_token"])
+	}
+}
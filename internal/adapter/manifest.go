@@ -0,0 +1,248 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// ManifestVersion is the manifest format written by GenerateManifest
+const ManifestVersion = 1
+
+// manifestConcurrency bounds concurrent object reads while checksumming
+const manifestConcurrency = 8
+
+// Discrepancy kinds
+const (
+	DiscrepancyAdded   = "added"   // Listed now but not in the manifest
+	DiscrepancyRemoved = "removed" // In the manifest but no longer listed
+	DiscrepancyChanged = "changed" // Size or content differs from the manifest
+)
+
+// Manifest records the objects under a prefix so the prefix can later be
+// checked for added, removed, or changed objects
+type Manifest struct {
+	Version   int             `json:"version"`
+	Prefix    string          `json:"prefix"` // Prefix the manifest was generated for
+	Generated time.Time       `json:"generated"`
+	Algorithm string          `json:"algorithm"` // Checksum algorithm of entries and digest
+	Digest    string          `json:"digest"`    // Checksum over every entry's key, size, and checksum
+	Objects   []ManifestEntry `json:"objects"`   // Sorted by key
+}
+
+// ManifestEntry describes one object, keyed relative to the manifest prefix
+type ManifestEntry struct {
+	Key      string `json:"key"`
+	Size     int64  `json:"size"`
+	ETag     string `json:"etag,omitempty"` // Informational; copies may change it
+	Checksum string `json:"checksum"`       // SHA-256 of the object's content
+}
+
+// Discrepancy is an object whose state differs from its manifest entry
+type Discrepancy struct {
+	Key      string         `json:"key"`
+	Kind     string         `json:"kind"`
+	Expected *ManifestEntry `json:"expected,omitempty"` // Manifest entry; nil when added
+	Actual   *ManifestEntry `json:"actual,omitempty"`   // Current object; nil when removed
+}
+
+// GenerateManifest lists every object under prefix and reads it through
+// backend to checksum its content
+func GenerateManifest(ctx context.Context, backend types.Backend, prefix string) (Manifest, error) {
+	entries, err := manifestEntries(ctx, backend, prefix)
+	if err != nil {
+		return Manifest{}, err
+	}
+	return Manifest{
+		Version:   ManifestVersion,
+		Prefix:    prefix,
+		Generated: time.Now().UTC(),
+		Algorithm: "sha256",
+		Digest:    manifestDigest(entries),
+		Objects:   entries,
+	}, nil
+}
+
+// VerifyManifest checksums the objects now under prefix and reports each
+// one added, removed, or changed since m was generated, sorted by key. An
+// ETag that differs alone is not a change, since copying an object can
+// change its ETag without changing its content. A manifest whose digest
+// does not match its entries is rejected.
+func VerifyManifest(ctx context.Context, backend types.Backend, prefix string, m Manifest) ([]Discrepancy, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	entries, err := manifestEntries(ctx, backend, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string]*ManifestEntry, len(m.Objects))
+	for i := range m.Objects {
+		expected[m.Objects[i].Key] = &m.Objects[i]
+	}
+
+	var discrepancies []Discrepancy
+	for i := range entries {
+		actual := &entries[i]
+		want, ok := expected[actual.Key]
+		delete(expected, actual.Key)
+		switch {
+		case !ok:
+			discrepancies = append(discrepancies, Discrepancy{Key: actual.Key, Kind: DiscrepancyAdded, Actual: actual})
+		case want.Size != actual.Size || want.Checksum != actual.Checksum:
+			discrepancies = append(discrepancies, Discrepancy{Key: actual.Key, Kind: DiscrepancyChanged, Expected: want, Actual: actual})
+		}
+	}
+	for key, want := range expected {
+		discrepancies = append(discrepancies, Discrepancy{Key: key, Kind: DiscrepancyRemoved, Expected: want})
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return discrepancies[i].Key < discrepancies[j].Key
+	})
+	return discrepancies, nil
+}
+
+// Validate checks that m is a supported version and that its digest
+// matches its entries, so an edited manifest is not trusted
+func (m Manifest) Validate() error {
+	if m.Version != ManifestVersion {
+		return fmt.Errorf("unsupported manifest version: %d (want %d)", m.Version, ManifestVersion)
+	}
+	if m.Algorithm != "sha256" {
+		return fmt.Errorf("unsupported manifest algorithm: %s", m.Algorithm)
+	}
+	if digest := manifestDigest(m.Objects); digest != m.Digest {
+		return fmt.Errorf("manifest digest %s does not match its entries (%s)", m.Digest, digest)
+	}
+	return nil
+}
+
+// WriteManifest writes m as indented JSON
+func WriteManifest(w io.Writer, m Manifest) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(m); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest reads a manifest written by WriteManifest and validates it
+func ReadManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return Manifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return Manifest{}, err
+	}
+	return m, nil
+}
+
+// GenerateManifest records the objects under prefix in the mounted bucket,
+// as the mount reads them
+func (a *Adapter) GenerateManifest(ctx context.Context, prefix string) (Manifest, error) {
+	if a.storage == nil {
+		return Manifest{}, fmt.Errorf("adapter not started")
+	}
+	return GenerateManifest(ctx, a.storage, prefix)
+}
+
+// VerifyManifest reports the objects under prefix in the mounted bucket
+// that were added, removed, or changed since m was generated
+func (a *Adapter) VerifyManifest(ctx context.Context, prefix string, m Manifest) ([]Discrepancy, error) {
+	if a.storage == nil {
+		return nil, fmt.Errorf("adapter not started")
+	}
+	return VerifyManifest(ctx, a.storage, prefix, m)
+}
+
+// manifestDigest checksums entries, which must be sorted by key
+func manifestDigest(entries []ManifestEntry) string {
+	h := sha256.New()
+	for _, entry := range entries {
+		fmt.Fprintf(h, "%s\x00%d\x00%s\n", entry.Key, entry.Size, entry.Checksum)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// manifestEntries lists prefix and checksums every object under it with
+// bounded concurrent reads, returning entries sorted by key
+func manifestEntries(ctx context.Context, backend types.Backend, prefix string) ([]ManifestEntry, error) {
+	objects, err := listAll(ctx, backend, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+
+	entries := make([]ManifestEntry, len(objects))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for i := 0; i < min(manifestConcurrency, len(objects)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				obj := objects[idx]
+				data, err := backend.GetObject(ctx, obj.Key, 0, 0)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to read %s: %w", obj.Key, err)
+					}
+					mu.Unlock()
+					continue
+				}
+				sum := sha256.Sum256(data)
+				entries[idx] = ManifestEntry{
+					Key:      strings.TrimPrefix(obj.Key, prefix),
+					Size:     int64(len(data)),
+					ETag:     obj.ETag,
+					Checksum: hex.EncodeToString(sum[:]),
+				}
+			}
+		}()
+	}
+	for i := range objects {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// listAll lists every object under prefix, across pages when backend can
+// stream its listing
+func listAll(ctx context.Context, backend types.Backend, prefix string) ([]types.ObjectInfo, error) {
+	streamer, ok := backend.(types.ObjectStreamer)
+	if !ok {
+		return backend.ListObjects(ctx, prefix, 0)
+	}
+
+	var objects []types.ObjectInfo
+	objCh, errCh := streamer.ListObjectsChan(ctx, prefix)
+	for obj := range objCh {
+		objects = append(objects, obj)
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
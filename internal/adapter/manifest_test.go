@@ -0,0 +1,103 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVerifyManifestReportsDiscrepancies(t *testing.T) {
+	backend := newMemoryBackend(map[string]string{
+		"datasets/v1/a.csv":      "1,2,3",
+		"datasets/v1/b.csv":      "4,5,6",
+		"datasets/v1/raw/c.bin":  "raw",
+		"datasets/v2/other.csv":  "not in the dataset",
+		"datasets/v1-old/d.csv":  "sibling prefix",
+		"datasets/v1/unchanged":  "same",
+		"datasets/v1/zero-bytes": "",
+	})
+	ctx := context.Background()
+
+	m, err := GenerateManifest(ctx, backend, "datasets/v1/")
+	if err != nil {
+		t.Fatalf("GenerateManifest failed: %v", err)
+	}
+	if len(m.Objects) != 5 || m.Objects[0].Key != "a.csv" {
+		t.Fatalf("manifest objects = %+v, want 5 keyed relative to the prefix", m.Objects)
+	}
+
+	// The manifest round-trips through its serialized form
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, m); err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+	m, err = ReadManifest(&buf)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+
+	discrepancies, err := VerifyManifest(ctx, backend, "datasets/v1/", m)
+	if err != nil {
+		t.Fatalf("VerifyManifest failed: %v", err)
+	}
+	if len(discrepancies) != 0 {
+		t.Fatalf("untouched dataset has discrepancies: %+v", discrepancies)
+	}
+
+	// Same size, different content
+	backend.objects["datasets/v1/a.csv"] = []byte("1,2,4")
+	backend.objects["datasets/v1/new.csv"] = []byte("added")
+	delete(backend.objects, "datasets/v1/raw/c.bin")
+
+	discrepancies, err = VerifyManifest(ctx, backend, "datasets/v1/", m)
+	if err != nil {
+		t.Fatalf("VerifyManifest failed: %v", err)
+	}
+	want := []struct{ key, kind string }{
+		{"a.csv", DiscrepancyChanged},
+		{"new.csv", DiscrepancyAdded},
+		{"raw/c.bin", DiscrepancyRemoved},
+	}
+	if len(discrepancies) != len(want) {
+		t.Fatalf("discrepancies = %+v, want %v", discrepancies, want)
+	}
+	for i, w := range want {
+		got := discrepancies[i]
+		if got.Key != w.key || got.Kind != w.kind {
+			t.Errorf("discrepancy %d = %s %s, want %s %s", i, got.Kind, got.Key, w.kind, w.key)
+		}
+	}
+	if changed := discrepancies[0]; changed.Expected == nil || changed.Actual == nil || changed.Expected.Checksum == changed.Actual.Checksum {
+		t.Errorf("changed discrepancy = %+v, want both checksums", changed)
+	}
+	if discrepancies[1].Expected != nil || discrepancies[2].Actual != nil {
+		t.Errorf("added and removed discrepancies = %+v, %+v", discrepancies[1], discrepancies[2])
+	}
+}
+
+func TestManifestRejectsEditsAndUnknownVersions(t *testing.T) {
+	backend := newMemoryBackend(map[string]string{"data/a": "a"})
+	ctx := context.Background()
+	m, err := GenerateManifest(ctx, backend, "data/")
+	if err != nil {
+		t.Fatalf("GenerateManifest failed: %v", err)
+	}
+
+	edited := m
+	edited.Objects = append([]ManifestEntry(nil), m.Objects...)
+	edited.Objects[0].Checksum = strings.Repeat("0", 64)
+	if _, err := VerifyManifest(ctx, backend, "data/", edited); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Errorf("VerifyManifest of an edited manifest error = %v, want a digest mismatch", err)
+	}
+
+	future := m
+	future.Version = ManifestVersion + 1
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, future); err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+	if _, err := ReadManifest(&buf); err == nil || !strings.Contains(err.Error(), "unsupported manifest version") {
+		t.Errorf("ReadManifest of a future version error = %v", err)
+	}
+}
@@ -0,0 +1,495 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/metrics"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Mirror modes
+const (
+	MirrorBestEffort = "best-effort" // Mirror failures are logged and retried from the backlog
+	MirrorStrict     = "strict"      // Mirror failures also fail the operation
+)
+
+const (
+	defaultMirrorMaxBacklog    = 10000
+	defaultMirrorRetryInterval = 5 * time.Second
+	mirrorLockStripes          = 64
+)
+
+// MirrorOptions configures a MirrorBackend
+type MirrorOptions struct {
+	Mode          string             // MirrorBestEffort (default) or MirrorStrict
+	MaxBacklog    int                // Keys awaiting a mirror retry before further failures are dropped (default 10000)
+	RetryInterval time.Duration      // How often backlogged keys are retried (default 5s)
+	Logger        *slog.Logger       // Receives mirror failures; optional
+	Metrics       *metrics.Collector // Receives backlog size and lag; optional
+}
+
+// MirrorStats reports how far the mirror trails the primary
+type MirrorStats struct {
+	Mode     string        `json:"mode"`
+	Mirrored int64         `json:"mirrored"` // Changes applied to the mirror, including retries
+	Failures int64         `json:"failures"` // Mirror attempts that failed
+	Dropped  int64         `json:"dropped"`  // Failed keys not retried because the backlog was full
+	Backlog  int           `json:"backlog"`  // Keys whose mirror copy is out of date
+	Lag      time.Duration `json:"lag"`      // Age of the oldest backlogged change
+}
+
+// MirrorBackend writes to a secondary backend as well as the primary, for
+// migrating between buckets or providers without downtime. Reads are served
+// by the primary alone. A key whose mirror write fails is backlogged and
+// later brought up to date by copying the primary's current object, so
+// retries converge on the primary's state rather than replaying writes.
+type MirrorBackend struct {
+	primary    types.Backend
+	mirror     types.Backend
+	strict     bool
+	maxBacklog int
+	logger     *slog.Logger
+	metrics    *metrics.Collector
+
+	// Serialize each key's primary and mirror writes with its retries, so a
+	// retry never overwrites a newer mirrored write with older data
+	locks [mirrorLockStripes]sync.Mutex
+
+	mu      sync.Mutex
+	backlog map[string]time.Time // Key to when its mirror copy fell behind
+	stats   MirrorStats
+
+	stopCh    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMirrorBackend creates a backend that applies writes and deletes to
+// both primary and mirror and starts retrying backlogged keys
+func NewMirrorBackend(primary, mirror types.Backend, options MirrorOptions) (*MirrorBackend, error) {
+	if primary == nil || mirror == nil {
+		return nil, fmt.Errorf("primary and mirror backends cannot be nil")
+	}
+
+	switch options.Mode {
+	case "":
+		options.Mode = MirrorBestEffort
+	case MirrorBestEffort, MirrorStrict:
+	default:
+		return nil, fmt.Errorf("invalid mirror mode: %s (must be %s or %s)", options.Mode, MirrorBestEffort, MirrorStrict)
+	}
+	if options.MaxBacklog <= 0 {
+		options.MaxBacklog = defaultMirrorMaxBacklog
+	}
+	if options.RetryInterval <= 0 {
+		options.RetryInterval = defaultMirrorRetryInterval
+	}
+	if options.Logger == nil {
+		options.Logger = slog.Default()
+	}
+
+	m := &MirrorBackend{
+		primary:    primary,
+		mirror:     mirror,
+		strict:     options.Mode == MirrorStrict,
+		maxBacklog: options.MaxBacklog,
+		logger:     options.Logger.With("component", "mirror"),
+		metrics:    options.Metrics,
+		backlog:    make(map[string]time.Time),
+		stats:      MirrorStats{Mode: options.Mode},
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go m.retryLoop(options.RetryInterval)
+	return m, nil
+}
+
+// Primary returns the backend reads are served from
+func (m *MirrorBackend) Primary() types.Backend {
+	return m.primary
+}
+
+// Stats returns mirror progress
+func (m *MirrorBackend) Stats() MirrorStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Backlog = len(m.backlog)
+	stats.Lag = m.lagLocked(time.Now())
+	return stats
+}
+
+// Close stops retrying backlogged keys. Keys still backlogged are logged;
+// their mirror copies stay out of date.
+func (m *MirrorBackend) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.stopCh)
+		<-m.done
+
+		if stats := m.Stats(); stats.Backlog > 0 {
+			m.logger.Warn("Mirror closed with out-of-date keys", "backlog", stats.Backlog, "lag", stats.Lag)
+		}
+	})
+	return nil
+}
+
+// lock returns the lock serializing mirroring of key
+func (m *MirrorBackend) lock(key string) *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &m.locks[h.Sum32()%mirrorLockStripes]
+}
+
+// lagLocked returns the age of the oldest backlogged change
+func (m *MirrorBackend) lagLocked(now time.Time) time.Duration {
+	var lag time.Duration
+	for _, since := range m.backlog {
+		lag = max(lag, now.Sub(since))
+	}
+	return lag
+}
+
+// publishLocked exports backlog size and lag
+func (m *MirrorBackend) publishLocked() {
+	if m.metrics != nil {
+		m.metrics.UpdateMirrorBacklog(len(m.backlog), m.lagLocked(time.Now()))
+	}
+}
+
+// applied records the outcome of mirroring a change of key. A failure
+// backlogs key for retry; success clears it.
+func (m *MirrorBackend) applied(key string, err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.publishLocked()
+
+	if err == nil {
+		m.stats.Mirrored++
+		delete(m.backlog, key)
+		return nil
+	}
+
+	m.stats.Failures++
+	if _, ok := m.backlog[key]; !ok {
+		if len(m.backlog) >= m.maxBacklog {
+			m.stats.Dropped++
+			m.logger.Error("Mirror backlog full, key will not be retried", "key", key, "error", err)
+			return m.failure(key, err)
+		}
+		m.backlog[key] = time.Now()
+	}
+	m.logger.Warn("Mirror write failed, will retry", "key", key, "error", err)
+	return m.failure(key, err)
+}
+
+// failure returns the error a caller sees for a failed mirror write, which
+// is nil unless mirroring is strict
+func (m *MirrorBackend) failure(key string, err error) error {
+	if !m.strict {
+		return nil
+	}
+	return fmt.Errorf("failed to mirror %s: %w", key, err)
+}
+
+// write applies a change of key to the primary and then, if that succeeds,
+// to the mirror
+func (m *MirrorBackend) write(key string, primary, mirror func() error) error {
+	lock := m.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := primary(); err != nil {
+		return err
+	}
+	return m.applied(key, mirror())
+}
+
+// GetObject reads from the primary
+func (m *MirrorBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	return m.primary.GetObject(ctx, key, offset, size)
+}
+
+// PutObject writes key to the primary and the mirror
+func (m *MirrorBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	return m.write(key,
+		func() error { return m.primary.PutObject(ctx, key, data) },
+		func() error { return m.mirror.PutObject(ctx, key, data) })
+}
+
+// PutObjectWithMetadata writes key and its metadata to the primary and the
+// mirror. A mirror that cannot store metadata receives only the data.
+func (m *MirrorBackend) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	writer, ok := m.primary.(types.ObjectMetadataWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support object metadata")
+	}
+	return m.write(key,
+		func() error { return writer.PutObjectWithMetadata(ctx, key, data, metadata) },
+		func() error {
+			if mirrorWriter, ok := m.mirror.(types.ObjectMetadataWriter); ok {
+				return mirrorWriter.PutObjectWithMetadata(ctx, key, data, metadata)
+			}
+			return m.mirror.PutObject(ctx, key, data)
+		})
+}
+
+// DeleteObject deletes key from the primary and the mirror. A key already
+// missing from the mirror counts as deleted there.
+func (m *MirrorBackend) DeleteObject(ctx context.Context, key string) error {
+	return m.write(key,
+		func() error { return m.primary.DeleteObject(ctx, key) },
+		func() error { return m.deleteMirror(ctx, key) })
+}
+
+// deleteMirror deletes key from the mirror, ignoring keys already missing
+func (m *MirrorBackend) deleteMirror(ctx context.Context, key string) error {
+	if err := m.mirror.DeleteObject(ctx, key); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// HeadObject returns metadata from the primary
+func (m *MirrorBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	return m.primary.HeadObject(ctx, key)
+}
+
+// HeadObjects returns metadata for keys from the primary
+func (m *MirrorBackend) HeadObjects(ctx context.Context, keys []string) (map[string]*types.ObjectInfo, map[string]error) {
+	if header, ok := m.primary.(types.BatchHeader); ok {
+		return header.HeadObjects(ctx, keys)
+	}
+	infos := make(map[string]*types.ObjectInfo, len(keys))
+	errs := make(map[string]error)
+	for _, key := range keys {
+		info, err := m.primary.HeadObject(ctx, key)
+		if err != nil {
+			errs[key] = err
+			continue
+		}
+		infos[key] = info
+	}
+	return infos, errs
+}
+
+// GetObjects reads keys from the primary
+func (m *MirrorBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	return m.primary.GetObjects(ctx, keys)
+}
+
+// PutObjects writes each object to the primary and the mirror
+func (m *MirrorBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		if err := m.PutObject(ctx, key, data); err != nil {
+			return fmt.Errorf("failed to put %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// ListObjects lists the primary
+func (m *MirrorBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	return m.primary.ListObjects(ctx, prefix, limit)
+}
+
+// ListObjectsChan streams the primary's listing
+func (m *MirrorBackend) ListObjectsChan(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	if streamer, ok := m.primary.(types.ObjectStreamer); ok {
+		return streamer.ListObjectsChan(ctx, prefix)
+	}
+
+	objCh := make(chan types.ObjectInfo)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(objCh)
+		defer close(errCh)
+		objects, err := m.primary.ListObjects(ctx, prefix, 0)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		for _, obj := range objects {
+			select {
+			case objCh <- obj:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return objCh, errCh
+}
+
+// GetObjectIfModified revalidates against the primary
+func (m *MirrorBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := m.primary.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	return getter.GetObjectIfModified(ctx, key, offset, size, since, etag)
+}
+
+// Touch updates the last-modified time of key in the primary and the mirror
+func (m *MirrorBackend) Touch(ctx context.Context, key string) error {
+	toucher, ok := m.primary.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	return m.write(key,
+		func() error { return toucher.Touch(ctx, key) },
+		func() error {
+			if mirrorToucher, ok := m.mirror.(types.ObjectToucher); ok {
+				return mirrorToucher.Touch(ctx, key)
+			}
+			return m.copyToMirror(ctx, key)
+		})
+}
+
+// MoveObject moves srcKey to dstKey in the primary, then makes the mirror's
+// copies of both keys match the primary's. The mirror is synced even when
+// the move fails, since the copy may have completed.
+func (m *MirrorBackend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	mover, ok := m.primary.(types.ObjectMover)
+	if !ok {
+		return fmt.Errorf("backend does not support move")
+	}
+	if srcKey == dstKey {
+		return nil
+	}
+
+	// Both keys are locked, in a fixed order so concurrent moves between
+	// the same keys cannot deadlock
+	keys := []string{srcKey, dstKey}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lock := m.lock(key)
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	err := mover.MoveObject(ctx, srcKey, dstKey)
+	mirrorErr := m.applied(dstKey, m.copyToMirror(ctx, dstKey))
+	if srcErr := m.applied(srcKey, m.copyToMirror(ctx, srcKey)); mirrorErr == nil {
+		mirrorErr = srcErr
+	}
+	if err != nil {
+		return err
+	}
+	return mirrorErr
+}
+
+// WriteAt writes part of key in the primary, then in the mirror. A mirror
+// without range writes receives the primary's whole updated object.
+func (m *MirrorBackend) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	writer, ok := m.primary.(types.RangeWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support range writes")
+	}
+	return m.write(key,
+		func() error { return writer.WriteAt(ctx, key, offset, data) },
+		func() error {
+			if mirrorWriter, ok := m.mirror.(types.RangeWriter); ok {
+				return mirrorWriter.WriteAt(ctx, key, offset, data)
+			}
+			return m.copyToMirror(ctx, key)
+		})
+}
+
+// HealthCheck checks the primary. An unreachable mirror only delays
+// mirroring, so it does not make the mount unhealthy.
+func (m *MirrorBackend) HealthCheck(ctx context.Context) error {
+	return m.primary.HealthCheck(ctx)
+}
+
+// copyToMirror makes the mirror's copy of key match the primary's, deleting
+// it when the primary no longer has key
+func (m *MirrorBackend) copyToMirror(ctx context.Context, key string) error {
+	info, err := m.primary.HeadObject(ctx, key)
+	if isNotFound(err) {
+		return m.deleteMirror(ctx, key)
+	}
+	if err != nil {
+		return err
+	}
+	data, err := m.primary.GetObject(ctx, key, 0, 0)
+	if isNotFound(err) {
+		return m.deleteMirror(ctx, key)
+	}
+	if err != nil {
+		return err
+	}
+
+	if writer, ok := m.mirror.(types.ObjectMetadataWriter); ok && len(info.Metadata) > 0 {
+		return writer.PutObjectWithMetadata(ctx, key, data, info.Metadata)
+	}
+	return m.mirror.PutObject(ctx, key, data)
+}
+
+// RetryBacklog copies every backlogged key from the primary to the mirror,
+// oldest first, and returns how many are still out of date
+func (m *MirrorBackend) RetryBacklog(ctx context.Context) int {
+	m.mu.Lock()
+	keys := make([]string, 0, len(m.backlog))
+	for key := range m.backlog {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return m.backlog[keys[i]].Before(m.backlog[keys[j]]) })
+	m.mu.Unlock()
+
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		lock := m.lock(key)
+		lock.Lock()
+		err := m.copyToMirror(ctx, key)
+		lock.Unlock()
+
+		m.mu.Lock()
+		if err == nil {
+			m.stats.Mirrored++
+			delete(m.backlog, key)
+		} else {
+			m.stats.Failures++
+		}
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.publishLocked()
+	return len(m.backlog)
+}
+
+// retryLoop retries backlogged keys every interval until Close
+func (m *MirrorBackend) retryLoop(interval time.Duration) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.stopCh
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			if remaining := m.RetryBacklog(ctx); remaining > 0 {
+				m.logger.Debug("Mirror backlog retried", "remaining", remaining)
+			}
+		}
+	}
+}
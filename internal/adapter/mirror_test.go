@@ -0,0 +1,174 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// unreliableBackend is a memoryBackend whose writes fail while writeErr is set
+type unreliableBackend struct {
+	*memoryBackend
+
+	mu       sync.Mutex
+	writeErr error
+}
+
+func (b *unreliableBackend) setWriteErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writeErr = err
+}
+
+func (b *unreliableBackend) failing() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.writeErr
+}
+
+func (b *unreliableBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	if err := b.failing(); err != nil {
+		return err
+	}
+	return b.memoryBackend.PutObject(ctx, key, data)
+}
+
+func (b *unreliableBackend) DeleteObject(ctx context.Context, key string) error {
+	if err := b.failing(); err != nil {
+		return err
+	}
+	return b.memoryBackend.DeleteObject(ctx, key)
+}
+
+func newTestMirror(t *testing.T, mode string) (*MirrorBackend, *memoryBackend, *unreliableBackend) {
+	t.Helper()
+	primary := newMemoryBackend(nil)
+	secondary := &unreliableBackend{memoryBackend: newMemoryBackend(nil)}
+
+	mirror, err := NewMirrorBackend(primary, secondary, MirrorOptions{Mode: mode, RetryInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewMirrorBackend() error = %v", err)
+	}
+	t.Cleanup(func() { _ = mirror.Close() })
+	return mirror, primary, secondary
+}
+
+func requireObject(t *testing.T, backend *memoryBackend, key, want string) {
+	t.Helper()
+	data, err := backend.GetObject(context.Background(), key, 0, 0)
+	if err != nil {
+		t.Fatalf("GetObject(%s) error = %v", key, err)
+	}
+	if string(data) != want {
+		t.Errorf("GetObject(%s) = %q, want %q", key, data, want)
+	}
+}
+
+func TestMirrorWritesReachBothBackends(t *testing.T) {
+	mirror, primary, secondary := newTestMirror(t, "")
+	ctx := context.Background()
+
+	if err := mirror.PutObject(ctx, "data/a.txt", []byte("hello")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+	requireObject(t, primary, "data/a.txt", "hello")
+	requireObject(t, secondary.memoryBackend, "data/a.txt", "hello")
+
+	if err := mirror.DeleteObject(ctx, "data/a.txt"); err != nil {
+		t.Fatalf("DeleteObject() error = %v", err)
+	}
+	if _, err := secondary.HeadObject(ctx, "data/a.txt"); !isNotFound(err) {
+		t.Errorf("mirror still has a deleted key, error = %v", err)
+	}
+
+	// Reads come from the primary alone
+	_ = secondary.memoryBackend.PutObject(ctx, "mirror-only.txt", []byte("x"))
+	if _, err := mirror.GetObject(ctx, "mirror-only.txt", 0, 0); !isNotFound(err) {
+		t.Errorf("GetObject() of a mirror-only key error = %v, want not found", err)
+	}
+
+	if stats := mirror.Stats(); stats.Mirrored != 2 || stats.Backlog != 0 {
+		t.Errorf("stats = %+v, want 2 mirrored and no backlog", stats)
+	}
+}
+
+func TestMirrorBestEffortFailureKeepsPrimaryWrite(t *testing.T) {
+	mirror, primary, secondary := newTestMirror(t, MirrorBestEffort)
+	ctx := context.Background()
+
+	secondary.setWriteErr(fmt.Errorf("connection reset"))
+	if err := mirror.PutObject(ctx, "data/a.txt", []byte("v1")); err != nil {
+		t.Fatalf("PutObject() with a failing mirror error = %v, want success", err)
+	}
+	if err := mirror.PutObject(ctx, "data/a.txt", []byte("v2")); err != nil {
+		t.Fatalf("PutObject() with a failing mirror error = %v, want success", err)
+	}
+	requireObject(t, primary, "data/a.txt", "v2")
+
+	stats := mirror.Stats()
+	if stats.Failures != 2 || stats.Backlog != 1 {
+		t.Errorf("stats = %+v, want 2 failures and 1 backlogged key", stats)
+	}
+
+	// Retrying while the mirror is down keeps the key backlogged
+	if remaining := mirror.RetryBacklog(ctx); remaining != 1 {
+		t.Errorf("RetryBacklog() with the mirror down = %d, want 1", remaining)
+	}
+
+	// Once the mirror recovers the retry copies the primary's latest data
+	secondary.setWriteErr(nil)
+	if remaining := mirror.RetryBacklog(ctx); remaining != 0 {
+		t.Errorf("RetryBacklog() = %d, want 0", remaining)
+	}
+	requireObject(t, secondary.memoryBackend, "data/a.txt", "v2")
+	if stats := mirror.Stats(); stats.Backlog != 0 || stats.Lag != 0 {
+		t.Errorf("stats after retry = %+v, want an empty backlog", stats)
+	}
+}
+
+func TestMirrorStrictFailureFailsWrite(t *testing.T) {
+	mirror, primary, secondary := newTestMirror(t, MirrorStrict)
+	ctx := context.Background()
+
+	secondary.setWriteErr(fmt.Errorf("connection reset"))
+	err := mirror.PutObject(ctx, "data/a.txt", []byte("v1"))
+	if err == nil || !strings.Contains(err.Error(), "mirror") {
+		t.Fatalf("PutObject() error = %v, want a mirror failure", err)
+	}
+
+	// The primary write stands and the mirror catches up later
+	requireObject(t, primary, "data/a.txt", "v1")
+	if stats := mirror.Stats(); stats.Backlog != 1 {
+		t.Errorf("backlog = %d, want 1", stats.Backlog)
+	}
+}
+
+func TestMirrorRetryDeletesKeysGoneFromPrimary(t *testing.T) {
+	mirror, _, secondary := newTestMirror(t, "")
+	ctx := context.Background()
+
+	if err := mirror.PutObject(ctx, "tmp.txt", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	secondary.setWriteErr(fmt.Errorf("timeout"))
+	if err := mirror.DeleteObject(ctx, "tmp.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	secondary.setWriteErr(nil)
+	if remaining := mirror.RetryBacklog(ctx); remaining != 0 {
+		t.Fatalf("RetryBacklog() = %d, want 0", remaining)
+	}
+	if _, err := secondary.HeadObject(ctx, "tmp.txt"); !isNotFound(err) {
+		t.Errorf("mirror kept a key deleted from the primary, error = %v", err)
+	}
+}
+
+func TestNewMirrorBackendRejectsUnknownMode(t *testing.T) {
+	if _, err := NewMirrorBackend(newMemoryBackend(nil), newMemoryBackend(nil), MirrorOptions{Mode: "sometimes"}); err == nil {
+		t.Error("NewMirrorBackend() accepted an unknown mode")
+	}
+}
@@ -0,0 +1,70 @@
+package adapter
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/objectfs/objectfs/internal/config"
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// maxListedEntries caps the entries named in a non-empty mount point error
+const maxListedEntries = 10
+
+// onNonEmptyMount returns the configured non-empty mount point behavior
+func (a *Adapter) onNonEmptyMount() string {
+	if a.config == nil {
+		return config.NonEmptyMountFail
+	}
+	if mode := strings.TrimSpace(a.config.Global.OnNonEmptyMount); mode != "" {
+		return mode
+	}
+	return config.NonEmptyMountFail
+}
+
+// checkNonEmptyMount inspects the mount point before mounting and applies
+// mode when it already has entries. It reports whether the FUSE nonempty
+// option is needed. A missing or unreadable mount point is left for the
+// mount itself to report.
+func checkNonEmptyMount(mountPoint, mode string) (bool, error) {
+	switch mode {
+	case config.NonEmptyMountFail, config.NonEmptyMountForce, config.NonEmptyMountUseNonEmpty:
+	default:
+		return false, errors.NewError(errors.ErrCodeInvalidConfig, "invalid on_nonempty_mount").
+			WithComponent("adapter").
+			WithOperation("mount").
+			WithContext("on_nonempty_mount", mode)
+	}
+
+	entries, err := os.ReadDir(mountPoint)
+	if err != nil || len(entries) == 0 {
+		return false, nil
+	}
+
+	switch mode {
+	case config.NonEmptyMountForce:
+		return false, nil
+	case config.NonEmptyMountUseNonEmpty:
+		return true, nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	listed := strings.Join(names, ", ")
+	if len(names) > maxListedEntries {
+		listed = fmt.Sprintf("%s, and %d more", strings.Join(names[:maxListedEntries], ", "), len(names)-maxListedEntries)
+	}
+
+	message := fmt.Sprintf("mount point %s is not empty (contains %s); set on_nonempty_mount to %q or %q to mount anyway",
+		mountPoint, listed, config.NonEmptyMountForce, config.NonEmptyMountUseNonEmpty)
+	return false, errors.NewError(errors.ErrCodeMountFailed, message).
+		WithComponent("adapter").
+		WithOperation("mount").
+		WithContext("mount_point", mountPoint).
+		WithDetail("entries", names)
+}
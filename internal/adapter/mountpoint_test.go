@@ -0,0 +1,66 @@
+package adapter
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/objectfs/objectfs/internal/config"
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// nonEmptyDir returns a temp directory containing one file
+func nonEmptyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	return dir
+}
+
+func TestCheckNonEmptyMount_Fail(t *testing.T) {
+	dir := nonEmptyDir(t)
+
+	_, err := checkNonEmptyMount(dir, config.NonEmptyMountFail)
+	var objErr *errors.ObjectFSError
+	if !stderrors.As(err, &objErr) || objErr.Code != errors.ErrCodeMountFailed {
+		t.Fatalf("checkNonEmptyMount() error = %v, want %s", err, errors.ErrCodeMountFailed)
+	}
+	if !strings.Contains(err.Error(), "existing.txt") {
+		t.Errorf("error %q does not list the conflicting entry", err)
+	}
+}
+
+func TestCheckNonEmptyMount_Force(t *testing.T) {
+	nonEmpty, err := checkNonEmptyMount(nonEmptyDir(t), config.NonEmptyMountForce)
+	if err != nil || nonEmpty {
+		t.Errorf("checkNonEmptyMount(force) = %v, %v; want false, nil", nonEmpty, err)
+	}
+}
+
+func TestCheckNonEmptyMount_UseNonEmpty(t *testing.T) {
+	nonEmpty, err := checkNonEmptyMount(nonEmptyDir(t), config.NonEmptyMountUseNonEmpty)
+	if err != nil || !nonEmpty {
+		t.Errorf("checkNonEmptyMount(use-nonempty) = %v, %v; want true, nil", nonEmpty, err)
+	}
+}
+
+func TestCheckNonEmptyMount_EmptyDirectory(t *testing.T) {
+	for _, mode := range []string{config.NonEmptyMountFail, config.NonEmptyMountForce, config.NonEmptyMountUseNonEmpty} {
+		nonEmpty, err := checkNonEmptyMount(t.TempDir(), mode)
+		if err != nil || nonEmpty {
+			t.Errorf("checkNonEmptyMount(empty, %s) = %v, %v; want false, nil", mode, nonEmpty, err)
+		}
+	}
+}
+
+func TestCheckNonEmptyMount_InvalidMode(t *testing.T) {
+	_, err := checkNonEmptyMount(t.TempDir(), "overwrite")
+	var objErr *errors.ObjectFSError
+	if !stderrors.As(err, &objErr) || objErr.Code != errors.ErrCodeInvalidConfig {
+		t.Errorf("checkNonEmptyMount(overwrite) error = %v, want %s", err, errors.ErrCodeInvalidConfig)
+	}
+}
@@ -0,0 +1,62 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// layoutMetadataKeys are the metadata keys wrappers write to describe how
+// they stored an object; they describe the stored copy, not the object
+var layoutMetadataKeys = []string{
+	layoutMetadataKey,
+	contentMetadataKey,
+	contentSizeMetadataKey,
+	codecMetadataKey,
+	originalSizeMetadataKey,
+}
+
+// moveByCopy moves srcKey to dstKey by reading it through backend and
+// writing it back under dstKey with its user metadata, then deleting
+// srcKey. It is used by wrappers that store an object under keys derived
+// from its name, where a server-side copy of the named object alone would
+// leave its parts behind. If the write fails srcKey is left alone; if the
+// delete fails the error is returned with both copies in place.
+func moveByCopy(ctx context.Context, backend types.Backend, srcKey, dstKey string) error {
+	if srcKey == dstKey {
+		return nil
+	}
+
+	info, err := backend.HeadObject(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	data, err := backend.GetObject(ctx, srcKey, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]string, len(info.Metadata))
+	for k, v := range info.Metadata {
+		metadata[k] = v
+	}
+	for _, k := range layoutMetadataKeys {
+		delete(metadata, k)
+	}
+
+	writer, ok := backend.(types.ObjectMetadataWriter)
+	if ok && len(metadata) > 0 {
+		err = writer.PutObjectWithMetadata(ctx, dstKey, data, metadata)
+	} else {
+		err = backend.PutObject(ctx, dstKey, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", srcKey, dstKey, err)
+	}
+
+	if err := backend.DeleteObject(ctx, srcKey); err != nil {
+		return fmt.Errorf("copied %s to %s but failed to delete the source: %w", srcKey, dstKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/objectfs/objectfs/internal/fuse"
+)
+
+// keyPrimer is implemented by mounts that can load known keys ahead of use
+type keyPrimer interface {
+	PrimeKeys(ctx context.Context, keys []string, mode fuse.PrimeMode) error
+	PrimeProgress() fuse.PrimeProgress
+}
+
+// PrimeKeys readies the mount for a job that will touch a known set of
+// keys, such as the inputs of a workflow manifest. fuse.PrimeMetadata
+// loads their attributes so stats are answered without a HEAD, and
+// fuse.PrimeData also reads their content into the cache. Progress is
+// reported by PrimeProgress, and primed and failed totals in the
+// filesystem stats.
+func (a *Adapter) PrimeKeys(ctx context.Context, keys []string, mode fuse.PrimeMode) error {
+	primer, ok := a.mountMgr.(keyPrimer)
+	if !ok {
+		return fmt.Errorf("the mounted filesystem does not support priming keys")
+	}
+	return primer.PrimeKeys(ctx, keys, mode)
+}
+
+// PrimeProgress returns the progress of the latest PrimeKeys call
+func (a *Adapter) PrimeProgress() fuse.PrimeProgress {
+	if primer, ok := a.mountMgr.(keyPrimer); ok {
+		return primer.PrimeProgress()
+	}
+	return fuse.PrimeProgress{}
+}
@@ -0,0 +1,528 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Rate limit classes
+const (
+	RateClassRead  = "read"
+	RateClassWrite = "write"
+	RateClassList  = "list"
+)
+
+// Rate limit modes
+const (
+	// RateLimitQueue makes calls over the limit wait for capacity
+	RateLimitQueue = "queue"
+	// RateLimitReject fails calls over the limit with ErrCodeQuotaExceeded
+	RateLimitReject = "reject"
+)
+
+// defaultRateLimitMaxWait bounds how long a queued call waits
+const defaultRateLimitMaxWait = time.Minute
+
+// rateClasses lists the classes a limit can be set for
+var rateClasses = []string{RateClassRead, RateClassWrite, RateClassList}
+
+// RateLimit caps a request and byte rate. Zero fields are unlimited.
+type RateLimit struct {
+	RequestsPerSecond float64
+	BytesPerSecond    int64
+}
+
+// RateLimitOptions configures a RateLimiter
+type RateLimitOptions struct {
+	Total   RateLimit            // Across every call
+	Classes map[string]RateLimit // Per class: read, write, or list
+	Mode    string               // RateLimitQueue (default) or RateLimitReject
+	MaxWait time.Duration        // Longest a queued call waits before it is rejected
+}
+
+// RateStats reports a limit against the rate measured over the last second
+type RateStats struct {
+	RequestLimit float64 `json:"request_limit"` // 0 is unlimited
+	ByteLimit    int64   `json:"byte_limit"`    // 0 is unlimited
+	RequestRate  float64 `json:"request_rate"`
+	ByteRate     float64 `json:"byte_rate"`
+}
+
+// RateLimitStats reports the state of a RateLimiter
+type RateLimitStats struct {
+	Mode     string               `json:"mode"`
+	Total    RateStats            `json:"total"`
+	Classes  map[string]RateStats `json:"classes"`
+	Waits    int64                `json:"waits"`     // Calls that had to wait
+	WaitTime time.Duration        `json:"wait_time"` // Total time calls spent waiting
+	Rejected int64                `json:"rejected"`  // Calls over the limit that were failed
+}
+
+// tokenBucket holds up to one second of rate. A charge larger than the
+// bucket waits for a full bucket, then leaves it in debt, so large
+// transfers are delayed rather than starved and the average holds.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, now time.Time) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	burst := max(rate, 1)
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// delay returns how long a charge of cost must wait
+func (b *tokenBucket) delay(cost float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	short := min(cost, b.burst) - b.tokens
+	if short <= 0 {
+		return 0
+	}
+	return time.Duration(short / b.rate * float64(time.Second))
+}
+
+// charge takes cost from the bucket, which may go into debt
+func (b *tokenBucket) charge(cost float64) {
+	if b != nil {
+		b.tokens -= cost
+	}
+}
+
+// refund returns cost charged for a call that never ran
+func (b *tokenBucket) refund(cost float64) {
+	if b != nil {
+		b.tokens = min(b.burst, b.tokens+cost)
+	}
+}
+
+// rateMeter measures a request and byte rate over one-second windows
+type rateMeter struct {
+	start    time.Time
+	requests float64
+	bytes    float64
+	reqRate  float64
+	byteRate float64
+}
+
+func (m *rateMeter) roll(now time.Time) {
+	if elapsed := now.Sub(m.start).Seconds(); elapsed >= 1 {
+		m.reqRate, m.byteRate = m.requests/elapsed, m.bytes/elapsed
+		m.start, m.requests, m.bytes = now, 0, 0
+	}
+}
+
+func (m *rateMeter) record(requests, bytes float64, now time.Time) {
+	m.roll(now)
+	m.requests += requests
+	m.bytes += bytes
+}
+
+// rateLane is the buckets and meter of one class, or of the total
+type rateLane struct {
+	limit    RateLimit
+	requests *tokenBucket
+	bytes    *tokenBucket
+	meter    rateMeter
+}
+
+func newRateLane(limit RateLimit, now time.Time) *rateLane {
+	return &rateLane{
+		limit:    limit,
+		requests: newTokenBucket(limit.RequestsPerSecond, now),
+		bytes:    newTokenBucket(float64(limit.BytesPerSecond), now),
+		meter:    rateMeter{start: now},
+	}
+}
+
+func (l *rateLane) stats(now time.Time) RateStats {
+	l.meter.roll(now)
+	return RateStats{
+		RequestLimit: l.limit.RequestsPerSecond,
+		ByteLimit:    l.limit.BytesPerSecond,
+		RequestRate:  l.meter.reqRate,
+		ByteRate:     l.meter.byteRate,
+	}
+}
+
+// RateLimiter caps the steady rate of calls and bytes sent to the backend,
+// for buckets whose request quota is shared with other systems. Each call
+// draws from the total limit and its class's limit; reads whose size is
+// unknown up front are charged their bytes once they complete. Unlike the
+// retry budget, which bounds retries after failures, this caps throughput
+// while everything succeeds.
+type RateLimiter struct {
+	backend types.Backend
+	mode    string
+	maxWait time.Duration
+	now     func() time.Time
+	wait    func(ctx context.Context, d time.Duration) error
+
+	mu      sync.Mutex
+	total   *rateLane
+	classes map[string]*rateLane
+	stats   RateLimitStats
+}
+
+// NewRateLimiter wraps backend, limiting its request and byte rates
+func NewRateLimiter(backend types.Backend, opts RateLimitOptions) (*RateLimiter, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = RateLimitQueue
+	case RateLimitQueue, RateLimitReject:
+	default:
+		return nil, fmt.Errorf("invalid rate limit mode: %s (must be %s or %s)", opts.Mode, RateLimitQueue, RateLimitReject)
+	}
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = defaultRateLimitMaxWait
+	}
+	for class := range opts.Classes {
+		if !validRateClass(class) {
+			return nil, fmt.Errorf("invalid rate limit class: %s", class)
+		}
+	}
+
+	r := &RateLimiter{
+		backend: backend,
+		mode:    opts.Mode,
+		maxWait: opts.MaxWait,
+		now:     time.Now,
+		wait:    waitContext,
+		classes: make(map[string]*rateLane, len(rateClasses)),
+	}
+	now := r.now()
+	r.total = newRateLane(opts.Total, now)
+	for _, class := range rateClasses {
+		r.classes[class] = newRateLane(opts.Classes[class], now)
+	}
+	return r, nil
+}
+
+func validRateClass(class string) bool {
+	for _, c := range rateClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// waitContext sleeps for d or until ctx is done
+func waitContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acquire waits until requests calls carrying bytes bytes of class fit
+// the limits, then charges them. In reject mode, or when the wait would
+// exceed MaxWait, it fails instead.
+func (r *RateLimiter) acquire(ctx context.Context, class string, requests int, bytes int64) error {
+	lane := r.classes[class]
+	reqCost, byteCost := float64(requests), float64(bytes)
+
+	r.mu.Lock()
+	now := r.now()
+	wait := max(
+		r.total.requests.delay(reqCost, now),
+		r.total.bytes.delay(byteCost, now),
+		lane.requests.delay(reqCost, now),
+		lane.bytes.delay(byteCost, now),
+	)
+	if wait > 0 && (r.mode == RateLimitReject || wait > r.maxWait) {
+		r.stats.Rejected++
+		r.mu.Unlock()
+		return errors.NewError(errors.ErrCodeQuotaExceeded, "backend request rate limit exceeded").
+			WithComponent("rate-limiter").
+			WithContext("class", class).
+			WithDetail("retry_after", wait.String())
+	}
+
+	// Charging before waiting reserves this call's place, so later calls
+	// queue behind it
+	for _, l := range []*rateLane{r.total, lane} {
+		l.requests.charge(reqCost)
+		l.bytes.charge(byteCost)
+		l.meter.record(reqCost, byteCost, now)
+	}
+	if wait > 0 {
+		r.stats.Waits++
+		r.stats.WaitTime += wait
+	}
+	r.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if err := r.wait(ctx, wait); err != nil {
+		r.mu.Lock()
+		for _, l := range []*rateLane{r.total, lane} {
+			l.requests.refund(reqCost)
+			l.bytes.refund(byteCost)
+		}
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// chargeBytes charges bytes transferred by a completed call of class
+func (r *RateLimiter) chargeBytes(class string, bytes int64) {
+	if bytes <= 0 {
+		return
+	}
+	lane := r.classes[class]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	for _, l := range []*rateLane{r.total, lane} {
+		l.bytes.delay(0, now)
+		l.bytes.charge(float64(bytes))
+		l.meter.record(0, float64(bytes), now)
+	}
+}
+
+// Stats returns the limits and the rates measured against them
+func (r *RateLimiter) Stats() RateLimitStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	stats := r.stats
+	stats.Mode = r.mode
+	stats.Total = r.total.stats(now)
+	stats.Classes = make(map[string]RateStats, len(r.classes))
+	for class, lane := range r.classes {
+		stats.Classes[class] = lane.stats(now)
+	}
+	return stats
+}
+
+// GetObject reads key once the read rate allows, charging the bytes read
+func (r *RateLimiter) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	if err := r.acquire(ctx, RateClassRead, 1, size); err != nil {
+		return nil, err
+	}
+	data, err := r.backend.GetObject(ctx, key, offset, size)
+	if size <= 0 {
+		r.chargeBytes(RateClassRead, int64(len(data)))
+	}
+	return data, err
+}
+
+// PutObject writes key once the write rate allows
+func (r *RateLimiter) PutObject(ctx context.Context, key string, data []byte) error {
+	if err := r.acquire(ctx, RateClassWrite, 1, int64(len(data))); err != nil {
+		return err
+	}
+	return r.backend.PutObject(ctx, key, data)
+}
+
+// PutObjectWithMetadata writes key and its metadata once the write rate
+// allows
+func (r *RateLimiter) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	writer, ok := r.backend.(types.ObjectMetadataWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support object metadata")
+	}
+	if err := r.acquire(ctx, RateClassWrite, 1, int64(len(data))); err != nil {
+		return err
+	}
+	return writer.PutObjectWithMetadata(ctx, key, data, metadata)
+}
+
+// DeleteObject deletes key once the write rate allows
+func (r *RateLimiter) DeleteObject(ctx context.Context, key string) error {
+	if err := r.acquire(ctx, RateClassWrite, 1, 0); err != nil {
+		return err
+	}
+	return r.backend.DeleteObject(ctx, key)
+}
+
+// HeadObject returns metadata for key once the read rate allows
+func (r *RateLimiter) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	if err := r.acquire(ctx, RateClassRead, 1, 0); err != nil {
+		return nil, err
+	}
+	return r.backend.HeadObject(ctx, key)
+}
+
+// GetObjects reads keys once the read rate allows one request per key
+func (r *RateLimiter) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if err := r.acquire(ctx, RateClassRead, len(keys), 0); err != nil {
+		return nil, err
+	}
+	objects, err := r.backend.GetObjects(ctx, keys)
+	var read int64
+	for _, data := range objects {
+		read += int64(len(data))
+	}
+	r.chargeBytes(RateClassRead, read)
+	return objects, err
+}
+
+// HeadObjects returns metadata for keys once the read rate allows one
+// request per key
+func (r *RateLimiter) HeadObjects(ctx context.Context, keys []string) (map[string]*types.ObjectInfo, map[string]error) {
+	if err := r.acquire(ctx, RateClassRead, len(keys), 0); err != nil {
+		errs := make(map[string]error, len(keys))
+		for _, key := range keys {
+			errs[key] = err
+		}
+		return nil, errs
+	}
+	if header, ok := r.backend.(types.BatchHeader); ok {
+		return header.HeadObjects(ctx, keys)
+	}
+	infos := make(map[string]*types.ObjectInfo, len(keys))
+	errs := make(map[string]error)
+	for _, key := range keys {
+		info, err := r.backend.HeadObject(ctx, key)
+		if err != nil {
+			errs[key] = err
+			continue
+		}
+		infos[key] = info
+	}
+	return infos, errs
+}
+
+// PutObjects writes objects once the write rate allows one request per
+// object and their combined size
+func (r *RateLimiter) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	var size int64
+	for _, data := range objects {
+		size += int64(len(data))
+	}
+	if err := r.acquire(ctx, RateClassWrite, len(objects), size); err != nil {
+		return err
+	}
+	return r.backend.PutObjects(ctx, objects)
+}
+
+// ListObjects lists prefix once the list rate allows
+func (r *RateLimiter) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	if err := r.acquire(ctx, RateClassList, 1, 0); err != nil {
+		return nil, err
+	}
+	return r.backend.ListObjects(ctx, prefix, limit)
+}
+
+// ListObjectsChan streams the listing of prefix once the list rate allows
+// its first page. Later pages are not charged, since their requests are
+// issued inside the backend.
+func (r *RateLimiter) ListObjectsChan(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	if err := r.acquire(ctx, RateClassList, 1, 0); err != nil {
+		objCh := make(chan types.ObjectInfo)
+		errCh := make(chan error, 1)
+		close(objCh)
+		errCh <- err
+		close(errCh)
+		return objCh, errCh
+	}
+	if streamer, ok := r.backend.(types.ObjectStreamer); ok {
+		return streamer.ListObjectsChan(ctx, prefix)
+	}
+
+	objCh := make(chan types.ObjectInfo)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(objCh)
+		defer close(errCh)
+		objects, err := r.backend.ListObjects(ctx, prefix, 0)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		for _, obj := range objects {
+			select {
+			case objCh <- obj:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return objCh, errCh
+}
+
+// GetObjectIfModified revalidates key once the read rate allows
+func (r *RateLimiter) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := r.backend.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	if err := r.acquire(ctx, RateClassRead, 1, 0); err != nil {
+		return nil, false, nil, err
+	}
+	data, notModified, info, err := getter.GetObjectIfModified(ctx, key, offset, size, since, etag)
+	r.chargeBytes(RateClassRead, int64(len(data)))
+	return data, notModified, info, err
+}
+
+// Touch updates the last-modified time of key once the write rate allows
+func (r *RateLimiter) Touch(ctx context.Context, key string) error {
+	toucher, ok := r.backend.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	if err := r.acquire(ctx, RateClassWrite, 1, 0); err != nil {
+		return err
+	}
+	return toucher.Touch(ctx, key)
+}
+
+// MoveObject moves srcKey to dstKey once the write rate allows its copy
+// and delete
+func (r *RateLimiter) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	mover, ok := r.backend.(types.ObjectMover)
+	if !ok {
+		return fmt.Errorf("backend does not support move")
+	}
+	if err := r.acquire(ctx, RateClassWrite, 2, 0); err != nil {
+		return err
+	}
+	return mover.MoveObject(ctx, srcKey, dstKey)
+}
+
+// WriteAt writes part of key once the write rate allows
+func (r *RateLimiter) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	writer, ok := r.backend.(types.RangeWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support range writes")
+	}
+	if err := r.acquire(ctx, RateClassWrite, 1, int64(len(data))); err != nil {
+		return err
+	}
+	return writer.WriteAt(ctx, key, offset, data)
+}
+
+// HealthCheck checks the wrapped backend without charging the limits, so
+// a saturated limit does not make the mount look unhealthy
+func (r *RateLimiter) HealthCheck(ctx context.Context) error {
+	return r.backend.HealthCheck(ctx)
+}
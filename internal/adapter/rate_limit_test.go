@@ -0,0 +1,201 @@
+package adapter
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// clockedBackend records the fake time at which each call reaches it
+type clockedBackend struct {
+	*memoryBackend
+	now   *time.Time
+	calls []time.Time
+}
+
+func (b *clockedBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.calls = append(b.calls, *b.now)
+	return b.memoryBackend.HeadObject(ctx, key)
+}
+
+func (b *clockedBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	b.calls = append(b.calls, *b.now)
+	return b.memoryBackend.PutObject(ctx, key, data)
+}
+
+// newTestRateLimiter returns a RateLimiter on a fake clock whose waits
+// advance it, and the backend it wraps
+func newTestRateLimiter(t *testing.T, opts RateLimitOptions) (*RateLimiter, *clockedBackend, *time.Time) {
+	t.Helper()
+	now := time.Now()
+	backend := &clockedBackend{memoryBackend: newMemoryBackend(map[string]string{"a.txt": "a"}), now: &now}
+	limiter, err := NewRateLimiter(backend, opts)
+	if err != nil {
+		t.Fatalf("NewRateLimiter failed: %v", err)
+	}
+	limiter.now = func() time.Time { return now }
+	limiter.wait = func(ctx context.Context, d time.Duration) error {
+		now = now.Add(d)
+		return nil
+	}
+	return limiter, backend, &now
+}
+
+func TestRateLimiterSmoothsBurstToRequestRate(t *testing.T) {
+	limiter, backend, now := newTestRateLimiter(t, RateLimitOptions{
+		Total: RateLimit{RequestsPerSecond: 10},
+	})
+	start := *now
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		if _, err := limiter.HeadObject(ctx, "a.txt"); err != nil {
+			t.Fatalf("HeadObject %d failed: %v", i, err)
+		}
+	}
+
+	// One second of burst goes at once, the other 40 calls at 10/s
+	if elapsed := now.Sub(start); elapsed < 3900*time.Millisecond || elapsed > 4100*time.Millisecond {
+		t.Errorf("50 calls took %v, want about 4s", elapsed)
+	}
+	for i := 11; i < len(backend.calls); i++ {
+		if gap := backend.calls[i].Sub(backend.calls[i-1]); gap < 99*time.Millisecond {
+			t.Fatalf("calls %d and %d were %v apart, want 100ms after the burst", i-1, i, gap)
+		}
+	}
+
+	stats := limiter.Stats()
+	if stats.Total.RequestLimit != 10 {
+		t.Errorf("RequestLimit = %v, want 10", stats.Total.RequestLimit)
+	}
+	if stats.Total.RequestRate < 9 || stats.Total.RequestRate > 11 {
+		t.Errorf("RequestRate = %v, want about 10", stats.Total.RequestRate)
+	}
+	if stats.Waits != 40 {
+		t.Errorf("Waits = %d, want 40", stats.Waits)
+	}
+}
+
+func TestRateLimiterEnforcesByteRateIndependently(t *testing.T) {
+	limiter, backend, now := newTestRateLimiter(t, RateLimitOptions{
+		Total:   RateLimit{RequestsPerSecond: 1000},
+		Classes: map[string]RateLimit{RateClassWrite: {BytesPerSecond: 1000}},
+	})
+	start := *now
+	ctx := context.Background()
+	data := make([]byte, 500)
+
+	// The request rate alone would admit every write at once; the byte
+	// rate admits two per second after the first second's burst
+	for i := 0; i < 10; i++ {
+		if err := limiter.PutObject(ctx, fmt.Sprintf("obj-%d", i), data); err != nil {
+			t.Fatalf("PutObject %d failed: %v", i, err)
+		}
+	}
+	if elapsed := now.Sub(start); elapsed < 3900*time.Millisecond || elapsed > 4100*time.Millisecond {
+		t.Errorf("10 writes of 500 bytes took %v, want about 4s", elapsed)
+	}
+
+	// Reads have their own class and are not held behind the writes
+	before := *now
+	for i := 0; i < 10; i++ {
+		if _, err := limiter.HeadObject(ctx, "a.txt"); err != nil {
+			t.Fatalf("HeadObject failed: %v", err)
+		}
+	}
+	if !now.Equal(before) {
+		t.Errorf("reads waited %v behind the write byte rate", now.Sub(before))
+	}
+	if len(backend.calls) != 20 {
+		t.Errorf("backend saw %d calls, want 20", len(backend.calls))
+	}
+
+	stats := limiter.Stats()
+	if write := stats.Classes[RateClassWrite]; write.ByteLimit != 1000 {
+		t.Errorf("write ByteLimit = %d, want 1000", write.ByteLimit)
+	}
+}
+
+func TestRateLimiterRejectMode(t *testing.T) {
+	limiter, backend, _ := newTestRateLimiter(t, RateLimitOptions{
+		Classes: map[string]RateLimit{RateClassRead: {RequestsPerSecond: 1}},
+		Mode:    RateLimitReject,
+	})
+	ctx := context.Background()
+
+	if _, err := limiter.HeadObject(ctx, "a.txt"); err != nil {
+		t.Fatalf("first HeadObject failed: %v", err)
+	}
+	_, err := limiter.HeadObject(ctx, "a.txt")
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeQuotaExceeded, "")) {
+		t.Fatalf("expected ErrCodeQuotaExceeded, got %v", err)
+	}
+	if len(backend.calls) != 1 {
+		t.Errorf("backend saw %d calls, want the rejected call held back", len(backend.calls))
+	}
+	if got := limiter.Stats().Rejected; got != 1 {
+		t.Errorf("Rejected = %d, want 1", got)
+	}
+
+	// Writes are not limited
+	if err := limiter.PutObject(ctx, "b.txt", []byte("b")); err != nil {
+		t.Errorf("PutObject failed: %v", err)
+	}
+}
+
+func TestRateLimiterRejectsPastMaxWaitAndRefundsCancelledWaits(t *testing.T) {
+	limiter, _, _ := newTestRateLimiter(t, RateLimitOptions{
+		Total:   RateLimit{BytesPerSecond: 100},
+		MaxWait: time.Second,
+	})
+	ctx := context.Background()
+
+	// A full bucket admits a write larger than the burst, leaving it in debt
+	if err := limiter.PutObject(ctx, "big", make([]byte, 300)); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	err := limiter.PutObject(ctx, "small", make([]byte, 10))
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeQuotaExceeded, "")) {
+		t.Fatalf("expected ErrCodeQuotaExceeded past max wait, got %v", err)
+	}
+
+	limiter, backend, _ := newTestRateLimiter(t, RateLimitOptions{Total: RateLimit{RequestsPerSecond: 1}})
+	if _, err := limiter.HeadObject(ctx, "a.txt"); err != nil {
+		t.Fatalf("HeadObject failed: %v", err)
+	}
+	limiter.wait = func(ctx context.Context, d time.Duration) error { return context.Canceled }
+	if _, err := limiter.HeadObject(ctx, "a.txt"); !stderrors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled wait's error, got %v", err)
+	}
+	// The cancelled call gave its token back, so the next one waits the
+	// same second rather than two
+	var waited time.Duration
+	limiter.wait = func(ctx context.Context, d time.Duration) error {
+		waited = d
+		return nil
+	}
+	if _, err := limiter.HeadObject(ctx, "a.txt"); err != nil {
+		t.Fatalf("HeadObject failed: %v", err)
+	}
+	if waited > time.Second {
+		t.Errorf("waited %v after a cancelled call, want at most 1s", waited)
+	}
+	if len(backend.calls) != 2 {
+		t.Errorf("backend saw %d calls, want 2", len(backend.calls))
+	}
+}
+
+func TestNewRateLimiterValidatesOptions(t *testing.T) {
+	backend := newMemoryBackend(nil)
+	if _, err := NewRateLimiter(backend, RateLimitOptions{Mode: "fail"}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+	if _, err := NewRateLimiter(backend, RateLimitOptions{Classes: map[string]RateLimit{"stat": {RequestsPerSecond: 1}}}); err == nil {
+		t.Error("expected an error for an unknown class")
+	}
+}
@@ -0,0 +1,209 @@
+package adapter
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// Shutdown phase names, in the order Stop runs them
+const (
+	PhaseDrain   = "drain"
+	PhaseFlush   = "flush"
+	PhaseUnmount = "unmount"
+	PhaseBackend = "backend"
+	PhaseCache   = "cache"
+	PhaseMetrics = "metrics"
+)
+
+// defaultPhaseTimeouts bounds each shutdown phase
+var defaultPhaseTimeouts = map[string]time.Duration{
+	PhaseDrain:   30 * time.Second,
+	PhaseFlush:   60 * time.Second,
+	PhaseUnmount: 30 * time.Second,
+	PhaseBackend: 60 * time.Second,
+	PhaseCache:   30 * time.Second,
+	PhaseMetrics: 10 * time.Second,
+}
+
+// quiescer is implemented by mounts that can reject new filesystem
+// operations and wait for in-flight ones to finish
+type quiescer interface {
+	Quiesce(ctx context.Context) error
+}
+
+// shutdownPhase is one ordered step of Stop
+type shutdownPhase struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// PhaseError records a shutdown phase that failed or timed out
+type PhaseError struct {
+	Phase string
+	Err   error
+}
+
+func (e *PhaseError) Error() string {
+	return fmt.Sprintf("shutdown phase %s: %v", e.Phase, e.Err)
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// shutdownPhases returns the shutdown sequence. Filesystem operations are
+// drained and writes flushed before unmounting so nothing is lost mid-write,
+// and storage is closed only once nothing can write to it. The cache is
+// closed after storage, once no flush or prefetch can still fill it.
+func (a *Adapter) shutdownPhases() []shutdownPhase {
+	return []shutdownPhase{
+		{PhaseDrain, a.drainFilesystem},
+		{PhaseFlush, a.flushWrites},
+		{PhaseUnmount, a.unmountFilesystem},
+		{PhaseBackend, a.closeStorage},
+		{PhaseCache, a.closeCache},
+		{PhaseMetrics, a.stopMetrics},
+	}
+}
+
+// runShutdownPhases runs every phase in order under its own timeout,
+// continuing past failures, and returns the combined phase errors
+func (a *Adapter) runShutdownPhases(ctx context.Context) error {
+	var errs []error
+	for _, phase := range a.shutdownPhases() {
+		if err := a.runPhase(ctx, phase); err != nil {
+			log.Printf("Shutdown phase %s failed: %v", phase.name, err)
+			errs = append(errs, &PhaseError{Phase: phase.name, Err: err})
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// runPhase runs a phase, abandoning it once its timeout expires so a hung
+// component cannot block the phases after it
+func (a *Adapter) runPhase(ctx context.Context, phase shutdownPhase) error {
+	timeout := defaultPhaseTimeouts[phase.name]
+	if t, ok := a.phaseTimeouts[phase.name]; ok {
+		timeout = t
+	}
+
+	phaseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- phase.run(phaseCtx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-phaseCtx.Done():
+		return fmt.Errorf("timed out after %v: %w", timeout, phaseCtx.Err())
+	}
+}
+
+func (a *Adapter) drainFilesystem(ctx context.Context) error {
+	if q, ok := a.mountMgr.(quiescer); ok {
+		return q.Quiesce(ctx)
+	}
+	return nil
+}
+
+func (a *Adapter) flushWrites(ctx context.Context) error {
+	var errs []error
+	if a.writeBuffer != nil {
+		if err := a.writeBuffer.FlushAll(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush write buffer: %w", err))
+		}
+	}
+	if a.packer != nil {
+		if err := a.packer.Flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush packed objects: %w", err))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func (a *Adapter) unmountFilesystem(ctx context.Context) error {
+	if a.mountMgr != nil && a.mountMgr.IsMounted() {
+		return a.mountMgr.Unmount()
+	}
+	return nil
+}
+
+func (a *Adapter) closeStorage(ctx context.Context) error {
+	var errs []error
+	if a.stopHousekeeping != nil {
+		a.stopHousekeeping()
+		a.stopHousekeeping = nil
+	}
+	if a.stopLatencyProbe != nil {
+		a.stopLatencyProbe()
+		a.stopLatencyProbe = nil
+	}
+	if a.stopExpiryReaper != nil {
+		a.stopExpiryReaper()
+		a.stopExpiryReaper = nil
+	}
+	if a.stopUsageSaver != nil {
+		a.stopUsageSaver()
+		a.stopUsageSaver = nil
+	}
+	if a.writeBuffer != nil {
+		if err := a.writeBuffer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close write buffer: %w", err))
+		}
+	}
+	if a.packer != nil {
+		if err := a.packer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close packer: %w", err))
+		}
+	}
+	if a.dedup != nil {
+		_ = a.dedup.Close()
+	}
+	if a.mirror != nil {
+		_ = a.mirror.Close()
+	}
+	if a.mirrorS3 != nil {
+		if err := a.mirrorS3.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close mirror backend: %w", err))
+		}
+	}
+	if a.backend != nil {
+		if err := a.backend.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close backend: %w", err))
+		}
+	}
+	for _, fallback := range a.fallbacks {
+		if err := fallback.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close fallback backend: %w", err))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// closeCache closes a cache this adapter created, stopping its background
+// work and saving the persistent cache index. A shared cache is left to
+// its owner, as other mounts may still read through it.
+func (a *Adapter) closeCache(ctx context.Context) error {
+	if a.sharedCache != nil || a.cacheBase == nil {
+		return nil
+	}
+	if closer, ok := a.cacheBase.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return fmt.Errorf("failed to close cache: %w", err)
+		}
+	}
+	return nil
+}
+
+func (a *Adapter) stopMetrics(ctx context.Context) error {
+	if a.metrics != nil {
+		return a.metrics.Stop(ctx)
+	}
+	return nil
+}
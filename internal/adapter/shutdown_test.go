@@ -0,0 +1,283 @@
+package adapter
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/buffer"
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+var errMountClosed = fmt.Errorf("mount is shutting down")
+
+// quiescingMount simulates a FUSE mount whose writes land in the write
+// buffer after a delay, like an operation blocked mid-syscall
+type quiescingMount struct {
+	mockMountManager
+
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+
+	quiesceErr   error
+	unmountBlock chan struct{}
+	onUnmount    func()
+}
+
+// write starts a simulated FUSE write that completes after delay
+func (m *quiescingMount) write(wb *buffer.WriteBuffer, key string, data []byte, delay time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return errMountClosed
+	}
+
+	m.inflight.Add(1)
+	go func() {
+		defer m.inflight.Done()
+		time.Sleep(delay)
+		_ = wb.Write(key, 0, data)
+	}()
+	return nil
+}
+
+func (m *quiescingMount) Quiesce(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return m.quiesceErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *quiescingMount) Unmount() error {
+	if m.unmountBlock != nil {
+		<-m.unmountBlock
+	}
+	if m.onUnmount != nil {
+		m.onUnmount()
+	}
+	return m.mockMountManager.Unmount()
+}
+
+// storedObjects records objects flushed from the write buffer
+type storedObjects struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *storedObjects) put(key string, data []byte, offset int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *storedObjects) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.objects)
+}
+
+func newShutdownTestAdapter(t *testing.T, mount *quiescingMount) (*Adapter, *storedObjects) {
+	t.Helper()
+
+	stored := &storedObjects{objects: make(map[string][]byte)}
+	writeBuffer, err := buffer.NewWriteBuffer(nil, stored.put)
+	if err != nil {
+		t.Fatalf("Failed to create write buffer: %v", err)
+	}
+
+	if err := mount.Mount(context.Background()); err != nil {
+		t.Fatalf("Failed to mount: %v", err)
+	}
+
+	return &Adapter{
+		storageURI:  "s3://test-bucket",
+		mountPoint:  "/mnt/test",
+		config:      createTestConfig(),
+		bucketName:  "test-bucket",
+		writeBuffer: writeBuffer,
+		mountMgr:    mount,
+		started:     true,
+	}, stored
+}
+
+// stopWithin runs Stop and fails the test if it does not return in time
+func stopWithin(t *testing.T, adapter *Adapter, limit time.Duration) error {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() { done <- adapter.Stop(context.Background()) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(limit):
+		t.Fatalf("Stop() did not return within %v", limit)
+		return nil
+	}
+}
+
+func TestStopFlushesInFlightWritesBeforeUnmount(t *testing.T) {
+	mount := &quiescingMount{}
+	adapter, stored := newShutdownTestAdapter(t, mount)
+
+	const writes = 10
+	flushedAtUnmount := -1
+	mount.onUnmount = func() { flushedAtUnmount = stored.count() }
+
+	for i := 0; i < writes; i++ {
+		if err := mount.write(adapter.writeBuffer, fmt.Sprintf("file-%d", i), []byte("payload"), 50*time.Millisecond); err != nil {
+			t.Fatalf("write %d error = %v", i, err)
+		}
+	}
+
+	if err := stopWithin(t, adapter, 10*time.Second); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	if flushedAtUnmount != writes {
+		t.Errorf("%d of %d in-flight writes flushed before unmount", flushedAtUnmount, writes)
+	}
+	if mount.IsMounted() || adapter.started {
+		t.Error("adapter still mounted or started after Stop()")
+	}
+
+	// New operations are rejected once shutdown begins
+	if err := mount.write(adapter.writeBuffer, "late", []byte("x"), 0); !stderrors.Is(err, errMountClosed) {
+		t.Errorf("write after Stop() error = %v, want rejection", err)
+	}
+}
+
+func TestStopContinuesPastFailedPhases(t *testing.T) {
+	mount := &quiescingMount{
+		quiesceErr:   fmt.Errorf("drain interrupted"),
+		unmountBlock: make(chan struct{}),
+	}
+	defer close(mount.unmountBlock)
+
+	adapter, stored := newShutdownTestAdapter(t, mount)
+	adapter.phaseTimeouts = map[string]time.Duration{PhaseUnmount: 50 * time.Millisecond}
+
+	if err := adapter.writeBuffer.Write("pending", 0, []byte("data")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	err := stopWithin(t, adapter, 10*time.Second)
+	if err == nil {
+		t.Fatal("Stop() should report failed phases")
+	}
+
+	failed := map[string]bool{}
+	for _, phaseErr := range unwrapAll(err) {
+		var pe *PhaseError
+		if stderrors.As(phaseErr, &pe) {
+			failed[pe.Phase] = true
+		}
+	}
+	if len(failed) != 2 || !failed[PhaseDrain] || !failed[PhaseUnmount] {
+		t.Errorf("failed phases = %v, want drain and unmount; err = %v", failed, err)
+	}
+	if !stderrors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want unmount timeout", err)
+	}
+
+	// Phases after a failure still run
+	if stored.count() != 1 {
+		t.Error("pending write not flushed after drain failure")
+	}
+	if adapter.started {
+		t.Error("adapter still started after Stop()")
+	}
+}
+
+// closingCache records when the cache underneath the namespaces is closed
+type closingCache struct {
+	types.Cache
+	onClose func()
+}
+
+func (c *closingCache) Close() error {
+	c.onClose()
+	return nil
+}
+
+func TestShutdownPhaseOrder(t *testing.T) {
+	var names []string
+	for _, phase := range (&Adapter{}).shutdownPhases() {
+		names = append(names, phase.name)
+	}
+	want := []string{PhaseDrain, PhaseFlush, PhaseUnmount, PhaseBackend, PhaseCache, PhaseMetrics}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("shutdown phases = %v, want %v", names, want)
+	}
+}
+
+func TestStopClosesPrivateCacheAfterStorage(t *testing.T) {
+	mount := &quiescingMount{}
+	adapter, _ := newShutdownTestAdapter(t, mount)
+
+	var order []string
+	mount.onUnmount = func() { order = append(order, PhaseUnmount) }
+	adapter.stopUsageSaver = func() { order = append(order, PhaseBackend) }
+	adapter.cacheBase = &closingCache{
+		Cache:   cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20}),
+		onClose: func() { order = append(order, PhaseCache) },
+	}
+
+	if err := stopWithin(t, adapter, 10*time.Second); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	want := []string{PhaseUnmount, PhaseBackend, PhaseCache}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("shutdown order = %v, want %v", order, want)
+	}
+}
+
+func TestStopLeavesSharedCacheOpen(t *testing.T) {
+	mount := &quiescingMount{}
+	adapter, _ := newShutdownTestAdapter(t, mount)
+
+	closed := false
+	base := &closingCache{
+		Cache:   cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20}),
+		onClose: func() { closed = true },
+	}
+	namespaces, err := cache.NewCacheNamespaces(base)
+	if err != nil {
+		t.Fatalf("NewCacheNamespaces() error = %v", err)
+	}
+	adapter.SetSharedCache(namespaces)
+	adapter.cacheBase = base
+
+	if err := stopWithin(t, adapter, 10*time.Second); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if closed {
+		t.Error("Stop() closed a cache shared with other mounts")
+	}
+}
+
+// unwrapAll flattens a joined error into its parts
+func unwrapAll(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
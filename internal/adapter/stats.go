@@ -0,0 +1,171 @@
+package adapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/buffer"
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/internal/fuse"
+	"github.com/objectfs/objectfs/internal/storage/s3"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// AdapterStats aggregates health and performance state across components
+type AdapterStats struct {
+	StorageURI  string    `json:"storage_uri"`
+	MountPoint  string    `json:"mount_point"`
+	Started     bool      `json:"started"`
+	Mounted     bool      `json:"mounted"`
+	CollectedAt time.Time `json:"collected_at"`
+
+	Filesystem  *fuse.FilesystemStats    `json:"filesystem,omitempty"`
+	MountWatch  *fuse.MountWatchStats    `json:"mount_watch,omitempty"`
+	Backend     *s3.BackendMetrics       `json:"backend,omitempty"`
+	ReadRegions []s3.ReadRegionStats     `json:"read_regions,omitempty"`
+	Cache       *types.CacheStats        `json:"cache,omitempty"`
+	L2AutoSize  *cache.AutoSizeStats     `json:"l2_auto_size,omitempty"`
+	WriteBuffer *buffer.WriteBufferStats `json:"write_buffer,omitempty"`
+	ListCache   *ListCacheStats          `json:"list_cache,omitempty"`
+	Mirror      *MirrorStats             `json:"mirror,omitempty"`
+	Dedup       *DedupStats              `json:"dedup,omitempty"`
+	CopyOnWrite *CopyOnWriteStats        `json:"copy_on_write,omitempty"`
+	Expiry      *ExpiryStats             `json:"expiry,omitempty"`
+	Concurrency *ConcurrencyStats        `json:"concurrency,omitempty"`
+	RateLimit   *RateLimitStats          `json:"rate_limit,omitempty"`
+	Usage       *UsageStats              `json:"usage,omitempty"`
+	Cluster     map[string]interface{}   `json:"cluster,omitempty"`
+}
+
+// mountWatchReporter is implemented by mount managers that recover lost
+// mounts
+type mountWatchReporter interface {
+	MountWatchStats() *fuse.MountWatchStats
+}
+
+// l2AutoSizer is implemented by caches whose L2 sizes itself from free
+// disk space
+type l2AutoSizer interface {
+	L2AutoSizeStats() *cache.AutoSizeStats
+}
+
+// SetCoordinator attaches a cluster coordinator whose stats are reported
+// by Stats when running clustered
+func (a *Adapter) SetCoordinator(coordinator types.DistributedCoordinator) {
+	a.coordinator = coordinator
+}
+
+// Stats returns a snapshot of adapter state. It only reads state already
+// held by each component and never issues network calls.
+func (a *Adapter) Stats() AdapterStats {
+	stats := AdapterStats{
+		StorageURI:  a.storageURI,
+		MountPoint:  a.mountPoint,
+		Started:     a.started,
+		CollectedAt: time.Now(),
+	}
+
+	if a.mountMgr != nil {
+		stats.Mounted = a.mountMgr.IsMounted()
+		stats.Filesystem = a.mountMgr.GetStats()
+		if watched, ok := a.mountMgr.(mountWatchReporter); ok {
+			stats.MountWatch = watched.MountWatchStats()
+		}
+	}
+
+	if a.backend != nil {
+		backendMetrics := a.backend.GetMetrics()
+		stats.Backend = &backendMetrics
+		stats.ReadRegions = a.backend.ReadRegions()
+	}
+
+	if a.cache != nil {
+		cacheStats := a.cache.Stats()
+		stats.Cache = &cacheStats
+	}
+	if sizer, ok := a.cacheBase.(l2AutoSizer); ok {
+		stats.L2AutoSize = sizer.L2AutoSizeStats()
+	}
+
+	if a.writeBuffer != nil {
+		bufferStats := a.writeBuffer.GetStats()
+		stats.WriteBuffer = &bufferStats
+	}
+
+	if a.limiter != nil {
+		concurrencyStats := a.limiter.Stats()
+		stats.Concurrency = &concurrencyStats
+	}
+
+	if a.rateLimiter != nil {
+		rateStats := a.rateLimiter.Stats()
+		stats.RateLimit = &rateStats
+	}
+
+	if a.usage != nil {
+		usageStats := a.usage.Stats()
+		stats.Usage = &usageStats
+	}
+
+	if a.mirror != nil {
+		mirrorStats := a.mirror.Stats()
+		stats.Mirror = &mirrorStats
+	}
+
+	if a.dedup != nil {
+		dedupStats := a.dedup.Stats()
+		stats.Dedup = &dedupStats
+	}
+
+	if a.cow != nil {
+		cowStats := a.cow.Stats()
+		stats.CopyOnWrite = &cowStats
+	}
+
+	if a.expiry != nil {
+		expiryStats := a.expiry.Stats()
+		stats.Expiry = &expiryStats
+	}
+
+	if a.overlay != nil {
+		stats.ListCache = a.overlay.ListCacheStats()
+	}
+
+	if a.coordinator != nil {
+		stats.Cluster = a.coordinator.GetStats()
+	}
+
+	return stats
+}
+
+// StatusHandler serves Stats as JSON for the /debug/status endpoint
+func (a *Adapter) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(a.Stats()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// RecentOpsHandler serves the latest backend operations, oldest first, as
+// JSON for the /debug/recent-ops endpoint
+func (a *Adapter) RecentOpsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operations := []s3.OperationRecord{}
+		if a.backend != nil {
+			operations = a.backend.RecentOperations()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(operations); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
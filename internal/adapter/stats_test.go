@@ -0,0 +1,149 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/objectfs/objectfs/internal/buffer"
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/internal/fuse"
+)
+
+// mockMountManager implements fuse.PlatformFileSystem without FUSE
+type mockMountManager struct {
+	mounted bool
+	stats   fuse.FilesystemStats
+}
+
+func (m *mockMountManager) Mount(ctx context.Context) error { m.mounted = true; return nil }
+func (m *mockMountManager) Unmount() error                  { m.mounted = false; return nil }
+func (m *mockMountManager) IsMounted() bool                 { return m.mounted }
+func (m *mockMountManager) GetStats() *fuse.FilesystemStats { return &m.stats }
+
+// mockCoordinator implements types.DistributedCoordinator
+type mockCoordinator struct{}
+
+func (m *mockCoordinator) ExecuteOperation(ctx context.Context, op interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (m *mockCoordinator) GetStats() map[string]interface{} {
+	return map[string]interface{}{"node_id": "node-1", "cluster_size": 3}
+}
+
+// newRunningTestAdapter builds an adapter with live components and a mock
+// mount. The S3 backend is omitted since creating it requires AWS access.
+func newRunningTestAdapter(t *testing.T) *Adapter {
+	t.Helper()
+	ctx := context.Background()
+
+	multiCache, err := cache.NewMultiLevelCache(&cache.MultiLevelConfig{
+		L1Config: &cache.L1Config{Enabled: true, Size: 1024 * 1024, MaxEntries: 100},
+		Policy:   "inclusive",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	writeBuffer, err := buffer.NewWriteBuffer(nil, func(key string, data []byte, offset int64) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to create write buffer: %v", err)
+	}
+	t.Cleanup(func() { _ = writeBuffer.Close() })
+
+	mountMgr := &mockMountManager{}
+	if err := mountMgr.Mount(ctx); err != nil {
+		t.Fatalf("Failed to mount: %v", err)
+	}
+
+	return &Adapter{
+		storageURI:  "s3://test-bucket",
+		mountPoint:  "/mnt/test",
+		config:      createTestConfig(),
+		bucketName:  "test-bucket",
+		cache:       multiCache,
+		writeBuffer: writeBuffer,
+		mountMgr:    mountMgr,
+		started:     true,
+	}
+}
+
+func TestAdapterStats(t *testing.T) {
+	adapter := newRunningTestAdapter(t)
+	adapter.SetCoordinator(&mockCoordinator{})
+
+	adapter.cache.Put("file.txt", 0, []byte("hello"))
+	adapter.cache.Get("file.txt", 0, 5)
+	if err := adapter.writeBuffer.Write("pending.txt", 0, []byte("backlog")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	adapter.mountMgr.(*mockMountManager).stats.Reads = 7
+
+	stats := adapter.Stats()
+
+	if !stats.Started || !stats.Mounted {
+		t.Errorf("Stats() started=%v mounted=%v, want both true", stats.Started, stats.Mounted)
+	}
+	if stats.StorageURI != "s3://test-bucket" || stats.MountPoint != "/mnt/test" {
+		t.Errorf("Stats() identity = %q %q", stats.StorageURI, stats.MountPoint)
+	}
+	if stats.CollectedAt.IsZero() {
+		t.Error("Stats().CollectedAt is zero")
+	}
+	if stats.Filesystem == nil || stats.Filesystem.Reads != 7 {
+		t.Errorf("Stats().Filesystem = %+v, want Reads=7", stats.Filesystem)
+	}
+	if stats.Backend != nil {
+		t.Errorf("Stats().Backend = %+v, want nil without a backend", stats.Backend)
+	}
+	if stats.Cache == nil || stats.Cache.Hits == 0 || stats.Cache.Size == 0 {
+		t.Errorf("Stats().Cache = %+v, want hits and size", stats.Cache)
+	}
+	if stats.WriteBuffer == nil || stats.WriteBuffer.PendingBytes != int64(len("backlog")) {
+		t.Errorf("Stats().WriteBuffer = %+v, want pending backlog", stats.WriteBuffer)
+	}
+	if stats.Cluster["node_id"] != "node-1" {
+		t.Errorf("Stats().Cluster = %v, want coordinator stats", stats.Cluster)
+	}
+}
+
+func TestAdapterStatsNotStarted(t *testing.T) {
+	t.Parallel()
+
+	adapter := &Adapter{storageURI: "s3://test-bucket", mountPoint: "/mnt/test"}
+	stats := adapter.Stats()
+
+	if stats.Started || stats.Mounted {
+		t.Error("Stats() on idle adapter should report not started and not mounted")
+	}
+	if stats.Backend != nil || stats.Cache != nil || stats.WriteBuffer != nil || stats.Cluster != nil {
+		t.Errorf("Stats() on idle adapter should omit component stats, got %+v", stats)
+	}
+}
+
+func TestAdapterStatusHandler(t *testing.T) {
+	adapter := newRunningTestAdapter(t)
+
+	recorder := httptest.NewRecorder()
+	adapter.StatusHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/status", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var decoded AdapterStats
+	if err := json.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if !decoded.Mounted || decoded.Cache == nil || decoded.WriteBuffer == nil {
+		t.Errorf("decoded status missing fields: %+v", decoded)
+	}
+}
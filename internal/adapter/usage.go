@@ -0,0 +1,406 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Usage accounting defaults
+const (
+	defaultUsageSaveInterval = time.Minute
+	defaultUsagePrefixDepth  = 1
+	defaultUsageMaxPrefixes  = 1000
+)
+
+// Prefixes of the usage breakdown for keys outside any prefix, and for
+// prefixes past the limit
+const (
+	UsageRootPrefix  = "/"
+	UsageOtherPrefix = "(other)"
+)
+
+// UsageOptions configures a UsageBackend
+type UsageOptions struct {
+	StateFile    string        // Where totals are kept across restarts; empty keeps them in memory only
+	SaveInterval time.Duration // How often totals are saved to StateFile (default 1m)
+	PrefixDepth  int           // Key path segments grouped in the breakdown (default 1)
+	MaxPrefixes  int           // Prefixes broken down before the rest count as UsageOtherPrefix (default 1000)
+}
+
+// UsageCounters are cumulative transfer and request counts
+type UsageCounters struct {
+	BytesRead     int64 `json:"bytes_read"`
+	BytesWritten  int64 `json:"bytes_written"`
+	ReadRequests  int64 `json:"read_requests"`  // Gets, heads and lists
+	WriteRequests int64 `json:"write_requests"` // Puts, deletes, touches and moves
+}
+
+// add adds other to the counters
+func (c *UsageCounters) add(other UsageCounters) {
+	c.BytesRead += other.BytesRead
+	c.BytesWritten += other.BytesWritten
+	c.ReadRequests += other.ReadRequests
+	c.WriteRequests += other.WriteRequests
+}
+
+// UsageStats reports what a mount transferred over its lifetime
+type UsageStats struct {
+	Since    time.Time                `json:"since"` // When counting started, kept across restarts
+	SavedAt  time.Time                `json:"saved_at,omitempty"`
+	Total    UsageCounters            `json:"total"`
+	Prefixes map[string]UsageCounters `json:"prefixes,omitempty"`
+}
+
+// UsageBackend counts the bytes and requests a mount sends to its backend,
+// in total and by key prefix, for capacity planning and cost attribution.
+// Totals are loaded from and saved to a small state file, so they are not
+// reset when the mount restarts.
+type UsageBackend struct {
+	backend types.Backend
+	options UsageOptions
+
+	saveMu sync.Mutex // Serializes writes of the state file
+
+	mu    sync.Mutex
+	usage UsageStats
+}
+
+// NewUsageBackend creates a usage counting layer over backend, continuing
+// from the totals in the state file when it exists
+func NewUsageBackend(backend types.Backend, options UsageOptions) (*UsageBackend, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	if options.SaveInterval <= 0 {
+		options.SaveInterval = defaultUsageSaveInterval
+	}
+	if options.PrefixDepth <= 0 {
+		options.PrefixDepth = defaultUsagePrefixDepth
+	}
+	if options.MaxPrefixes <= 0 {
+		options.MaxPrefixes = defaultUsageMaxPrefixes
+	}
+
+	u := &UsageBackend{
+		backend: backend,
+		options: options,
+		usage:   UsageStats{Since: time.Now(), Prefixes: make(map[string]UsageCounters)},
+	}
+	if options.StateFile == "" {
+		return u, nil
+	}
+
+	data, err := os.ReadFile(options.StateFile)
+	if os.IsNotExist(err) {
+		return u, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage state %s: %w", options.StateFile, err)
+	}
+	if err := json.Unmarshal(data, &u.usage); err != nil {
+		return nil, fmt.Errorf("invalid usage state %s: %w", options.StateFile, err)
+	}
+	if u.usage.Prefixes == nil {
+		u.usage.Prefixes = make(map[string]UsageCounters)
+	}
+	return u, nil
+}
+
+// Stats returns the totals counted so far
+func (u *UsageBackend) Stats() UsageStats {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	stats := u.usage
+	stats.Prefixes = make(map[string]UsageCounters, len(u.usage.Prefixes))
+	for prefix, counters := range u.usage.Prefixes {
+		stats.Prefixes[prefix] = counters
+	}
+	return stats
+}
+
+// Save writes the totals to the state file, replacing it atomically so a
+// crash mid-save keeps the previous totals
+func (u *UsageBackend) Save() error {
+	if u.options.StateFile == "" {
+		return nil
+	}
+	u.saveMu.Lock()
+	defer u.saveMu.Unlock()
+
+	u.mu.Lock()
+	u.usage.SavedAt = time.Now()
+	u.mu.Unlock()
+	data, err := json.Marshal(u.Stats())
+	if err != nil {
+		return fmt.Errorf("failed to encode usage state: %w", err)
+	}
+
+	dir := filepath.Dir(u.options.StateFile)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create usage state directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, ".usage-*")
+	if err != nil {
+		return fmt.Errorf("failed to save usage state: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to save usage state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save usage state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), u.options.StateFile); err != nil {
+		return fmt.Errorf("failed to save usage state: %w", err)
+	}
+	return nil
+}
+
+// prefix returns the breakdown prefix of key
+func (u *UsageBackend) prefix(key string) string {
+	parts := strings.SplitN(strings.TrimPrefix(key, "/"), "/", u.options.PrefixDepth+1)
+	if len(parts) <= 1 {
+		return UsageRootPrefix
+	}
+	depth := min(len(parts)-1, u.options.PrefixDepth)
+	return strings.Join(parts[:depth], "/") + "/"
+}
+
+// record adds counters to the total and to the prefix of key
+func (u *UsageBackend) record(key string, counters UsageCounters) {
+	prefix := u.prefix(key)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.usage.Total.add(counters)
+	if _, ok := u.usage.Prefixes[prefix]; !ok && len(u.usage.Prefixes) >= u.options.MaxPrefixes {
+		prefix = UsageOtherPrefix
+	}
+	byPrefix := u.usage.Prefixes[prefix]
+	byPrefix.add(counters)
+	u.usage.Prefixes[prefix] = byPrefix
+}
+
+// GetObject reads from the wrapped backend, counting the bytes returned
+func (u *UsageBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	data, err := u.backend.GetObject(ctx, key, offset, size)
+	u.record(key, UsageCounters{ReadRequests: 1, BytesRead: int64(len(data))})
+	return data, err
+}
+
+// PutObject writes to the wrapped backend, counting the bytes once stored
+func (u *UsageBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	err := u.backend.PutObject(ctx, key, data)
+	counters := UsageCounters{WriteRequests: 1}
+	if err == nil {
+		counters.BytesWritten = int64(len(data))
+	}
+	u.record(key, counters)
+	return err
+}
+
+// DeleteObject deletes from the wrapped backend
+func (u *UsageBackend) DeleteObject(ctx context.Context, key string) error {
+	u.record(key, UsageCounters{WriteRequests: 1})
+	return u.backend.DeleteObject(ctx, key)
+}
+
+// HeadObject reads metadata from the wrapped backend
+func (u *UsageBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	u.record(key, UsageCounters{ReadRequests: 1})
+	return u.backend.HeadObject(ctx, key)
+}
+
+// GetObjects reads keys, counting each key's bytes under its prefix
+func (u *UsageBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	objects, err := u.backend.GetObjects(ctx, keys)
+	for _, key := range keys {
+		u.record(key, UsageCounters{ReadRequests: 1, BytesRead: int64(len(objects[key]))})
+	}
+	return objects, err
+}
+
+// HeadObjects reads metadata for keys from the wrapped backend
+func (u *UsageBackend) HeadObjects(ctx context.Context, keys []string) (map[string]*types.ObjectInfo, map[string]error) {
+	for _, key := range keys {
+		u.record(key, UsageCounters{ReadRequests: 1})
+	}
+	if header, ok := u.backend.(types.BatchHeader); ok {
+		return header.HeadObjects(ctx, keys)
+	}
+
+	infos := make(map[string]*types.ObjectInfo, len(keys))
+	errs := make(map[string]error)
+	for _, key := range keys {
+		info, err := u.backend.HeadObject(ctx, key)
+		if err != nil {
+			errs[key] = err
+			continue
+		}
+		infos[key] = info
+	}
+	return infos, errs
+}
+
+// PutObjects writes objects, counting the bytes once stored
+func (u *UsageBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	err := u.backend.PutObjects(ctx, objects)
+	for key, data := range objects {
+		counters := UsageCounters{WriteRequests: 1}
+		if err == nil {
+			counters.BytesWritten = int64(len(data))
+		}
+		u.record(key, counters)
+	}
+	return err
+}
+
+// ListObjects lists the wrapped backend
+func (u *UsageBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	u.record(prefix, UsageCounters{ReadRequests: 1})
+	return u.backend.ListObjects(ctx, prefix, limit)
+}
+
+// ListObjectsChan streams the wrapped backend's listing
+func (u *UsageBackend) ListObjectsChan(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	u.record(prefix, UsageCounters{ReadRequests: 1})
+	if streamer, ok := u.backend.(types.ObjectStreamer); ok {
+		return streamer.ListObjectsChan(ctx, prefix)
+	}
+
+	objCh := make(chan types.ObjectInfo)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(objCh)
+		defer close(errCh)
+
+		objects, err := u.backend.ListObjects(ctx, prefix, 0)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		for _, obj := range objects {
+			select {
+			case objCh <- obj:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return objCh, errCh
+}
+
+// GetObjectIfModified revalidates through the wrapped backend, counting the
+// bytes of a changed object
+func (u *UsageBackend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	getter, ok := u.backend.(conditionalGetter)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("backend does not support conditional reads")
+	}
+	data, notModified, info, err := getter.GetObjectIfModified(ctx, key, offset, size, since, etag)
+	u.record(key, UsageCounters{ReadRequests: 1, BytesRead: int64(len(data))})
+	return data, notModified, info, err
+}
+
+// Touch updates the last-modified time of key
+func (u *UsageBackend) Touch(ctx context.Context, key string) error {
+	toucher, ok := u.backend.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	u.record(key, UsageCounters{WriteRequests: 1})
+	return toucher.Touch(ctx, key)
+}
+
+// MoveObject moves srcKey to dstKey, counted under dstKey
+func (u *UsageBackend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	mover, ok := u.backend.(types.ObjectMover)
+	if !ok {
+		return fmt.Errorf("backend does not support move")
+	}
+	u.record(dstKey, UsageCounters{WriteRequests: 1})
+	return mover.MoveObject(ctx, srcKey, dstKey)
+}
+
+// WriteAt writes part of key, counting the bytes once stored
+func (u *UsageBackend) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	writer, ok := u.backend.(types.RangeWriter)
+	if !ok {
+		return fmt.Errorf("backend does not support range writes")
+	}
+	err := writer.WriteAt(ctx, key, offset, data)
+	counters := UsageCounters{WriteRequests: 1}
+	if err == nil {
+		counters.BytesWritten = int64(len(data))
+	}
+	u.record(key, counters)
+	return err
+}
+
+// HealthCheck checks the wrapped backend without counting a request
+func (u *UsageBackend) HealthCheck(ctx context.Context) error {
+	return u.backend.HealthCheck(ctx)
+}
+
+// startUsageSaver saves usage totals every configured interval until
+// stopUsageSaver is called, which saves them once more
+func (a *Adapter) startUsageSaver(usage *UsageBackend) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	a.stopUsageSaver = func() {
+		cancel()
+		<-done
+		if err := usage.Save(); err != nil {
+			log.Printf("Failed to save usage totals: %v", err)
+		}
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(usage.options.SaveInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := usage.Save(); err != nil {
+					log.Printf("Failed to save usage totals: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// UsageHandler serves the mount's lifetime usage, in total and by prefix,
+// as JSON for the /debug/usage endpoint
+func (a *Adapter) UsageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		usage := UsageStats{}
+		if a.usage != nil {
+			usage = a.usage.Stats()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(usage); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package adapter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestUsageBackendCountsAndPersists(t *testing.T) {
+	ctx := context.Background()
+	stateFile := filepath.Join(t.TempDir(), "state", "usage.json")
+	backend := newMemoryBackend(map[string]string{"data/a.txt": "hello", "top.txt": "abc"})
+
+	usage, err := NewUsageBackend(backend, UsageOptions{StateFile: stateFile})
+	if err != nil {
+		t.Fatalf("NewUsageBackend failed: %v", err)
+	}
+	if _, err := usage.GetObject(ctx, "data/a.txt", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := usage.GetObject(ctx, "top.txt", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := usage.PutObject(ctx, "logs/day1/run.log", []byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := usage.HeadObject(ctx, "data/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := usage.Stats()
+	want := UsageCounters{BytesRead: 7, BytesWritten: 10, ReadRequests: 3, WriteRequests: 1}
+	if stats.Total != want {
+		t.Fatalf("Total = %+v, want %+v", stats.Total, want)
+	}
+	if got := stats.Prefixes["data/"]; got != (UsageCounters{BytesRead: 5, ReadRequests: 2}) {
+		t.Errorf("data/ usage = %+v", got)
+	}
+	if got := stats.Prefixes["logs/"]; got != (UsageCounters{BytesWritten: 10, WriteRequests: 1}) {
+		t.Errorf("logs/ usage = %+v", got)
+	}
+	if got := stats.Prefixes[UsageRootPrefix]; got != (UsageCounters{BytesRead: 2, ReadRequests: 1}) {
+		t.Errorf("root usage = %+v", got)
+	}
+
+	if err := usage.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A restarted mount continues from the saved totals
+	restarted, err := NewUsageBackend(backend, UsageOptions{StateFile: stateFile})
+	if err != nil {
+		t.Fatalf("NewUsageBackend after restart failed: %v", err)
+	}
+	if err := restarted.PutObject(ctx, "logs/day2/run.log", []byte("xy")); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := restarted.Stats()
+	want.BytesWritten, want.WriteRequests = 12, 2
+	if reloaded.Total != want || !reloaded.Since.Equal(stats.Since) {
+		t.Fatalf("restarted totals = %+v since %v, want %+v since %v", reloaded.Total, reloaded.Since, want, stats.Since)
+	}
+	if got := reloaded.Prefixes["logs/"]; got != (UsageCounters{BytesWritten: 12, WriteRequests: 2}) {
+		t.Errorf("logs/ usage after restart = %+v", got)
+	}
+}
+
+func TestUsageBackendPrefixes(t *testing.T) {
+	usage, err := NewUsageBackend(newMemoryBackend(nil), UsageOptions{PrefixDepth: 2, MaxPrefixes: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"file":         UsageRootPrefix,
+		"a/file":       "a/",
+		"a/b/file":     "a/b/",
+		"a/b/c/d/file": "a/b/",
+		"a/b/":         "a/b/",
+	}
+	for key, want := range tests {
+		if got := usage.prefix(key); got != want {
+			t.Errorf("prefix(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	for _, key := range []string{"a/b/1", "c/d/1", "e/f/1"} {
+		usage.record(key, UsageCounters{ReadRequests: 1})
+	}
+	if stats := usage.Stats(); stats.Prefixes[UsageOtherPrefix].ReadRequests != 1 || len(stats.Prefixes) != 3 {
+		t.Errorf("prefixes past the limit = %+v, want e/f/ counted as %s", stats.Prefixes, UsageOtherPrefix)
+	}
+}
@@ -2,7 +2,11 @@ package buffer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"sync"
 	"time"
 )
@@ -38,6 +42,22 @@ type WriteBufferConfig struct {
 	VerifyWrites bool          `yaml:"verify_writes"`
 	MaxRetries   int           `yaml:"max_retries"`
 	RetryDelay   time.Duration `yaml:"retry_delay"`
+
+	// Memory pressure settings. Once buffered bytes across all files reach
+	// HighWatermark×MaxMemory, the largest and then oldest buffers are
+	// flushed until usage falls to LowWatermark×MaxMemory, independent of
+	// per-file timers. MaxMemory 0 disables the global cap.
+	MaxMemory     int64   `yaml:"max_memory"`
+	HighWatermark float64 `yaml:"high_watermark"` // Default 0.9
+	LowWatermark  float64 `yaml:"low_watermark"`  // Default 0.7
+
+	// Spill settings. With SpillDir set, once in-memory buffered bytes
+	// reach SpillWatermark×MaxMemory the largest buffers are written to
+	// files in SpillDir and dropped from memory until usage falls to
+	// LowWatermark×MaxMemory. Spilled buffers upload from their files,
+	// which are removed once stored.
+	SpillDir       string  `yaml:"spill_dir"`
+	SpillWatermark float64 `yaml:"spill_watermark"` // Default HighWatermark
 }
 
 // WriteBufferStats tracks write buffer performance metrics
@@ -52,6 +72,10 @@ type WriteBufferStats struct {
 	CompressionRatio float64       `json:"compression_ratio"`
 	Errors           uint64        `json:"errors"`
 	LastFlush        time.Time     `json:"last_flush"`
+	PressureFlushes  uint64        `json:"pressure_flushes"` // Flushes forced by the memory high watermark
+	SpilledBytes     int64         `json:"spilled_bytes"`    // Bytes written to spill files
+	SpillReads       uint64        `json:"spill_reads"`      // Spill files read back to upload
+	StagedBytes      int64         `json:"staged_bytes"`     // Bytes in spill files awaiting upload
 }
 
 // buffer represents a single write buffer for a file
@@ -65,6 +89,11 @@ type buffer struct {
 	pendingWrites int
 	dirty         bool
 	flushing      bool
+	pressured     bool // Scheduled to relieve memory pressure
+
+	// Bytes from offset on were written to spillPath before data
+	spillPath string
+	spilled   int64
 }
 
 // WriteRequest represents a write operation request
@@ -83,6 +112,10 @@ type WriteResponse struct {
 	FlushTime    time.Duration
 }
 
+// errFlushInProgress is returned by flushBuffer when another flush of the
+// same buffer has not finished
+var errFlushInProgress = errors.New("flush already in progress")
+
 // FlushCallback is called when a buffer is flushed
 type FlushCallback func(key string, data []byte, offset int64) error
 
@@ -118,6 +151,20 @@ func NewWriteBuffer(config *WriteBufferConfig, flushCallback FlushCallback) (*Wr
 	if config.RetryDelay <= 0 {
 		config.RetryDelay = time.Second
 	}
+	if config.HighWatermark <= 0 || config.HighWatermark > 1 {
+		config.HighWatermark = 0.9
+	}
+	if config.LowWatermark <= 0 || config.LowWatermark >= config.HighWatermark {
+		config.LowWatermark = config.HighWatermark * 7 / 9
+	}
+	if config.SpillWatermark <= 0 || config.SpillWatermark > config.HighWatermark {
+		config.SpillWatermark = config.HighWatermark
+	}
+	if config.SpillDir != "" {
+		if err := os.MkdirAll(config.SpillDir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create spill directory: %w", err)
+		}
+	}
 
 	wb := &WriteBuffer{
 		config:        config,
@@ -141,7 +188,6 @@ func (wb *WriteBuffer) WriteWithRequest(ctx context.Context, req *WriteRequest)
 	response := &WriteResponse{}
 
 	wb.mu.Lock()
-	defer wb.mu.Unlock()
 
 	// Update stats
 	wb.stats.TotalWrites++
@@ -187,7 +233,11 @@ func (wb *WriteBuffer) WriteWithRequest(ctx context.Context, req *WriteRequest)
 		if wb.shouldFlushBuffer(buf) || req.Sync {
 			wb.scheduleFlush(bufKey)
 		}
+		wb.mu.Unlock()
+		wb.relieveMemoryPressure()
 	} else {
+		wb.mu.Unlock()
+
 		// Direct write (buffer full or other constraint)
 		response.Buffered = false
 		response.Error = fmt.Errorf("buffer full or write cannot be buffered")
@@ -244,7 +294,7 @@ func (wb *WriteBuffer) GetStats() WriteBufferStats {
 	// Calculate pending bytes
 	stats.PendingBytes = 0
 	for _, buf := range wb.buffers {
-		stats.PendingBytes += int64(len(buf.data))
+		stats.PendingBytes += buf.size()
 	}
 
 	return stats
@@ -266,19 +316,25 @@ func (wb *WriteBuffer) Close() error {
 
 // Helper methods
 
+// size returns the bytes buf holds in memory and in its spill file. Must be
+// called with buf.mu held.
+func (buf *buffer) size() int64 {
+	return buf.spilled + int64(len(buf.data))
+}
+
 func (wb *WriteBuffer) canBufferWrite(buf *buffer, req *WriteRequest) bool {
 	buf.mu.RLock()
 	defer buf.mu.RUnlock()
 
 	// Check if adding this write would exceed buffer size
-	newSize := int64(len(buf.data)) + int64(len(req.Data))
+	newSize := buf.size() + int64(len(req.Data))
 	if newSize > wb.config.MaxBufferSize {
 		return false
 	}
 
 	// Check if write is contiguous (simplified logic)
-	if len(buf.data) > 0 {
-		expectedOffset := buf.offset + int64(len(buf.data))
+	if buf.size() > 0 {
+		expectedOffset := buf.offset + buf.size()
 		if req.Offset != expectedOffset {
 			return false // Non-contiguous write
 		}
@@ -291,7 +347,7 @@ func (wb *WriteBuffer) appendToBuffer(buf *buffer, req *WriteRequest) {
 	buf.mu.Lock()
 	defer buf.mu.Unlock()
 
-	if len(buf.data) == 0 {
+	if buf.size() == 0 {
 		buf.offset = req.Offset
 	}
 
@@ -311,7 +367,7 @@ func (wb *WriteBuffer) shouldFlushBuffer(buf *buffer) bool {
 	defer buf.mu.RUnlock()
 
 	// Flush if buffer size exceeds threshold
-	if int64(len(buf.data)) >= wb.config.FlushThreshold {
+	if buf.size() >= wb.config.FlushThreshold {
 		return true
 	}
 
@@ -334,8 +390,191 @@ func (wb *WriteBuffer) scheduleFlush(key string) {
 		// Successfully scheduled
 	default:
 		// Channel full, flush synchronously
-		go wb.flushBuffer(key, nil)
+		go wb.flushBuffer(key, wb.flushCallback)
+	}
+}
+
+// relieveMemoryPressure spills buffers to disk once in-memory buffered
+// bytes reach the spill watermark, then schedules flushes of the largest,
+// then oldest, buffers if they still reach the high watermark, until the
+// bytes they hold would bring usage down to the low watermark. Buffers
+// already being flushed count toward the target. Must be called without
+// wb.mu held.
+func (wb *WriteBuffer) relieveMemoryPressure() {
+	if wb.config.MaxMemory <= 0 {
+		return
+	}
+	wb.spillToDisk()
+
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	high := int64(float64(wb.config.MaxMemory) * wb.config.HighWatermark)
+	if wb.memoryBytes() < high {
+		return
+	}
+	low := int64(float64(wb.config.MaxMemory) * wb.config.LowWatermark)
+
+	remaining := wb.memoryBytes()
+	candidates := wb.pressureCandidates(func(buf *buffer) bool {
+		return buf.flushing || buf.pressured
+	}, &remaining)
+
+	for _, c := range candidates {
+		if remaining <= low {
+			break
+		}
+		c.buf.mu.Lock()
+		c.buf.pressured = true
+		c.buf.mu.Unlock()
+		remaining -= c.size
+
+		wb.stats.PressureFlushes++
+		wb.scheduleFlush(c.buf.key)
+	}
+}
+
+// memoryBytes returns the buffered bytes held in memory rather than in
+// spill files. Must be called with wb.mu held.
+func (wb *WriteBuffer) memoryBytes() int64 {
+	return wb.stats.PendingBytes - wb.stats.StagedBytes
+}
+
+// pressureCandidate is a buffer that can be flushed or spilled to relieve
+// memory pressure
+type pressureCandidate struct {
+	buf       *buffer
+	size      int64
+	lastWrite time.Time
+}
+
+// pressureCandidates returns the buffers holding data in memory that are
+// not busy, largest and then oldest first. The in-memory bytes of busy
+// buffers are subtracted from remaining. Must be called with wb.mu held.
+func (wb *WriteBuffer) pressureCandidates(busy func(*buffer) bool, remaining *int64) []pressureCandidate {
+	candidates := make([]pressureCandidate, 0, len(wb.buffers))
+	for _, buf := range wb.buffers {
+		buf.mu.RLock()
+		c := pressureCandidate{buf: buf, size: int64(len(buf.data)), lastWrite: buf.lastWrite}
+		skip := busy(buf)
+		buf.mu.RUnlock()
+		if skip {
+			*remaining -= c.size
+		} else if c.size > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].size != candidates[j].size {
+			return candidates[i].size > candidates[j].size
+		}
+		return candidates[i].lastWrite.Before(candidates[j].lastWrite)
+	})
+	return candidates
+}
+
+// spillToDisk writes the largest, then oldest, buffers to spill files once
+// in-memory buffered bytes reach the spill watermark, until usage falls to
+// the low watermark, and schedules their upload. A buffer that cannot be
+// spilled stays in memory for the high watermark to flush. The buffers are
+// chosen under wb.mu but written without it, so a slow disk does not stall
+// writes to other files. Must be called without wb.mu held.
+func (wb *WriteBuffer) spillToDisk() {
+	if wb.config.SpillDir == "" {
+		return
+	}
+	wb.mu.Lock()
+	watermark := int64(float64(wb.config.MaxMemory) * wb.config.SpillWatermark)
+	if wb.memoryBytes() < watermark {
+		wb.mu.Unlock()
+		return
+	}
+	low := int64(float64(wb.config.MaxMemory) * wb.config.LowWatermark)
+
+	// Buffers being flushed free their memory once uploaded
+	remaining := wb.memoryBytes()
+	candidates := wb.pressureCandidates(func(buf *buffer) bool {
+		return buf.flushing
+	}, &remaining)
+	wb.mu.Unlock()
+
+	for _, c := range candidates {
+		if remaining <= low {
+			break
+		}
+		n, err := wb.spillBuffer(c.buf)
+
+		wb.mu.Lock()
+		if err != nil {
+			wb.stats.Errors++
+			wb.mu.Unlock()
+			continue
+		}
+		wb.stats.SpilledBytes += n
+		wb.stats.StagedBytes += n
+		wb.mu.Unlock()
+
+		remaining -= n
+		wb.scheduleFlush(c.buf.key)
+	}
+}
+
+// spillBuffer appends the in-memory data of buf to its spill file and
+// releases it, returning the bytes spilled. buf.mu is held throughout, and
+// a buffer being flushed is skipped, so its data cannot change mid-write.
+func (wb *WriteBuffer) spillBuffer(buf *buffer) (int64, error) {
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+
+	// A flush may have started since the candidates were chosen
+	if buf.flushing || len(buf.data) == 0 {
+		return 0, nil
+	}
+
+	var file *os.File
+	var err error
+	if buf.spillPath == "" {
+		file, err = os.CreateTemp(wb.config.SpillDir, "spill-*")
+	} else {
+		file, err = os.OpenFile(buf.spillPath, os.O_WRONLY, 0600)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open spill file: %w", err)
+	}
+	if buf.spillPath == "" {
+		buf.spillPath = file.Name()
+	}
+
+	_, err = file.WriteAt(buf.data, buf.spilled)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Drop a partial write so the file holds only spilled bytes
+		_ = os.Truncate(buf.spillPath, buf.spilled)
+		return 0, fmt.Errorf("failed to write spill file: %w", err)
+	}
+
+	n := int64(len(buf.data))
+	buf.spilled += n
+	buf.data = nil
+	return n, nil
+}
+
+// readSpill returns the first size bytes of a spill file
+func readSpill(path string, size int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill file: %w", err)
+	}
+	defer file.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, fmt.Errorf("failed to read spill file: %w", err)
 	}
+	return data, nil
 }
 
 func (wb *WriteBuffer) evictLRUBuffer() {
@@ -388,32 +627,50 @@ func (wb *WriteBuffer) flushLoop(callback FlushCallback) {
 	}
 }
 
-func (wb *WriteBuffer) flushBuffer(key string, callback FlushCallback) {
+func (wb *WriteBuffer) flushBuffer(key string, callback FlushCallback) error {
 	wb.mu.Lock()
 	buf, exists := wb.buffers[key]
 	wb.mu.Unlock()
 
 	if !exists {
-		return
+		return nil
 	}
 
 	buf.mu.Lock()
-	if !buf.dirty || buf.flushing {
+	if buf.flushing {
 		buf.mu.Unlock()
-		return
+		return errFlushInProgress
+	}
+	if !buf.dirty {
+		buf.mu.Unlock()
+		return nil
 	}
 
 	buf.flushing = true
 	data := make([]byte, len(buf.data))
 	copy(data, buf.data)
+	buffered := len(data)
 	offset := buf.offset
+	spillPath, spilled := buf.spillPath, buf.spilled
 	buf.mu.Unlock()
 
 	// Perform the actual flush
 	start := time.Now()
 	var err error
 
-	if callback != nil {
+	// Spilled bytes precede the data still in memory
+	if spilled > 0 {
+		var staged []byte
+		staged, err = readSpill(spillPath, spilled)
+		if err == nil {
+			data = append(staged, data...)
+		}
+		wb.mu.Lock()
+		wb.stats.SpillReads++
+		wb.mu.Unlock()
+	}
+
+	if err == nil && callback != nil {
 		err = callback(key, data, offset)
 	}
 
@@ -422,8 +679,23 @@ func (wb *WriteBuffer) flushBuffer(key string, callback FlushCallback) {
 	// Update stats and clean up
 	wb.mu.Lock()
 	if err == nil {
-		// Successful flush - remove buffer
-		delete(wb.buffers, key)
+		// Successful flush - remove the buffer, keeping writes that
+		// arrived during the flush for the next one
+		buf.mu.Lock()
+		if len(buf.data) > buffered {
+			buf.data = append(buf.data[:0], buf.data[buffered:]...)
+			buf.offset += int64(len(data))
+			buf.flushing = false
+			buf.pressured = false
+		} else {
+			delete(wb.buffers, key)
+		}
+		buf.spillPath, buf.spilled = "", 0
+		buf.mu.Unlock()
+		if spilled > 0 {
+			_ = os.Remove(spillPath)
+			wb.stats.StagedBytes -= spilled
+		}
 		wb.stats.TotalFlushes++
 		wb.stats.PendingWrites--
 		wb.stats.PendingBytes -= int64(len(data))
@@ -446,6 +718,7 @@ func (wb *WriteBuffer) flushBuffer(key string, callback FlushCallback) {
 		if stillExists {
 			buf.mu.Lock()
 			buf.flushing = false
+			buf.pressured = false
 			buf.mu.Unlock()
 		}
 
@@ -453,6 +726,7 @@ func (wb *WriteBuffer) flushBuffer(key string, callback FlushCallback) {
 		wb.stats.Errors++
 		wb.mu.Unlock()
 	}
+	return err
 }
 
 func (wb *WriteBuffer) flushStaleBuffers(callback FlushCallback) {
@@ -495,7 +769,7 @@ func (wb *WriteBuffer) GetBufferInfo() []BufferInfo {
 	for _, buf := range wb.buffers {
 		info = append(info, BufferInfo{
 			Key:           buf.key,
-			Size:          int64(len(buf.data)),
+			Size:          buf.size(),
 			Offset:        buf.offset,
 			PendingWrites: buf.pendingWrites,
 			LastWrite:     buf.lastWrite,
@@ -600,6 +874,24 @@ func (wb *WriteBuffer) FlushWithContext(ctx context.Context, key string) error {
 	return nil
 }
 
+// SyncKey flushes the buffered writes of key through the flush callback and
+// waits until they are stored, returning the callback's error. A flush of
+// key already running is waited for before the remaining data is flushed.
+func (wb *WriteBuffer) SyncKey(ctx context.Context, key string) error {
+	for {
+		err := wb.flushBuffer(key, wb.flushCallback)
+		if !errors.Is(err, errFlushInProgress) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
 // FlushAll flushes all buffers (required by types.WriteBuffer interface)
 func (wb *WriteBuffer) FlushAll() error {
 	// For synchronous flushes, flush directly without going through the channel
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// AutoSizeConfig sizes a persistent cache from the free space of the
+// filesystem holding it, so a shared volume is neither left idle nor filled
+type AutoSizeConfig struct {
+	Fraction float64       `yaml:"fraction"` // Share of the space the cache could use, free space plus its own files (default 0.5)
+	Reserve  int64         `yaml:"reserve"`  // Free bytes always left on the filesystem, evicting to keep them (default 1GB)
+	Interval time.Duration `yaml:"interval"` // How often free space is rechecked (default 1m)
+}
+
+// withDefaults returns the config with zero values replaced by defaults
+func (c AutoSizeConfig) withDefaults() AutoSizeConfig {
+	if c.Fraction <= 0 || c.Fraction > 1 {
+		c.Fraction = 0.5
+	}
+	if c.Reserve <= 0 {
+		c.Reserve = 1024 * 1024 * 1024
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Minute
+	}
+	return c
+}
+
+// DiskSpace is the capacity and free space of a filesystem
+type DiskSpace struct {
+	Total int64 `json:"total"`
+	Free  int64 `json:"free"` // Available to unprivileged users
+}
+
+// AutoSizeStats reports the size an auto-sized cache has chosen
+type AutoSizeStats struct {
+	EffectiveSize int64     `json:"effective_size"`
+	Reserve       int64     `json:"reserve"`
+	Disk          DiskSpace `json:"disk"`
+	CheckedAt     time.Time `json:"checked_at"`
+	Error         string    `json:"error,omitempty"` // Why the latest check failed; the previous size is kept
+}
+
+// autoSizeFor returns the cache size using cfg.Fraction of the space the
+// cache could use without leaving less than cfg.Reserve free. The cache's
+// own files count as usable, so filling the cache does not shrink it.
+func autoSizeFor(cfg AutoSizeConfig, current int64, disk DiskSpace) int64 {
+	available := disk.Free + current
+	size := int64(float64(available) * cfg.Fraction)
+	if limit := available - cfg.Reserve; size > limit {
+		size = limit
+	}
+	if size < 0 {
+		size = 0
+	}
+	return size
+}
+
+// resizeToDisk measures the cache directory's filesystem and resizes the
+// cache to match, evicting entries when free space fell below the reserve
+func (c *PersistentCache) resizeToDisk() error {
+	disk, err := c.diskSpace(c.directory)
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.autoStats.CheckedAt = time.Now()
+	if err != nil {
+		c.autoStats.Error = err.Error()
+		return fmt.Errorf("failed to measure free space of %s: %w", c.directory, err)
+	}
+
+	size := autoSizeFor(*c.autoSize, c.currentSize, disk)
+	c.maxSize = size
+	c.stats.Capacity = size
+	c.pins.rebalance(size)
+	c.evictIfNeeded()
+
+	c.autoStats.EffectiveSize = size
+	c.autoStats.Disk = disk
+	c.autoStats.Error = ""
+	return nil
+}
+
+// autoSizeLoop rechecks free space every interval until the cache closes
+func (c *PersistentCache) autoSizeLoop() {
+	ticker := time.NewTicker(c.autoSize.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			_ = c.resizeToDisk() // Failures are reported in AutoSizeStats
+		}
+	}
+}
+
+// AutoSizeStats returns the size chosen from free disk space, or nil when
+// the cache has a fixed size
+func (c *PersistentCache) AutoSizeStats() *AutoSizeStats {
+	if c.autoSize == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := c.autoStats
+	stats.Reserve = c.autoSize.Reserve
+	return &stats
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDisk is a filesystem holding a cache and other files
+type fakeDisk struct {
+	mu    sync.Mutex
+	total int64
+	other int64 // Bytes used by files other than the cache's
+}
+
+func (d *fakeDisk) setOther(bytes int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.other = bytes
+}
+
+// spaceFor returns a disk space func that counts cache's own files as used
+func (d *fakeDisk) spaceFor(cache *PersistentCache) func(dir string) (DiskSpace, error) {
+	return func(dir string) (DiskSpace, error) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return DiskSpace{Total: d.total, Free: d.total - d.other - cache.Size()}, nil
+	}
+}
+
+func TestAutoSizedCacheTracksFreeSpace(t *testing.T) {
+	const kb = 1024
+	cache, err := NewPersistentCache(&PersistentCacheConfig{
+		Directory: t.TempDir(),
+		TTL:       time.Hour,
+		AutoSize:  &AutoSizeConfig{Fraction: 0.5, Reserve: 20 * kb, Interval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("NewPersistentCache() error = %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	disk := &fakeDisk{total: 200 * kb}
+	cache.diskSpace = disk.spaceFor(cache)
+	if err := cache.resizeToDisk(); err != nil {
+		t.Fatalf("resizeToDisk() error = %v", err)
+	}
+	if stats := cache.AutoSizeStats(); stats.EffectiveSize != 100*kb || stats.Disk.Free != 200*kb {
+		t.Fatalf("AutoSizeStats() on an empty disk = %+v, want half of 200KB", stats)
+	}
+
+	for i := 0; i < 10; i++ {
+		cache.Put(fmt.Sprintf("object-%d", i), 0, make([]byte, 4*kb))
+	}
+	if cache.Size() != 40*kb {
+		t.Fatalf("Size() = %d, want 40KB cached", cache.Size())
+	}
+
+	// Other files leave only 10KB free, under the 20KB reserve
+	disk.setOther(150 * kb)
+	if err := cache.resizeToDisk(); err != nil {
+		t.Fatalf("resizeToDisk() error = %v", err)
+	}
+	stats := cache.AutoSizeStats()
+	if stats.EffectiveSize != 25*kb {
+		t.Errorf("EffectiveSize = %d after free space shrank, want 25KB", stats.EffectiveSize)
+	}
+	if cache.Size() > stats.EffectiveSize {
+		t.Errorf("Size() = %d exceeds the effective size %d", cache.Size(), stats.EffectiveSize)
+	}
+	if free, _ := cache.diskSpace(""); free.Free < 20*kb {
+		t.Errorf("%d bytes free after eviction, want at least the 20KB reserve", free.Free)
+	}
+	if cache.Stats().Evictions == 0 {
+		t.Error("shrinking free space evicted nothing")
+	}
+
+	// Once the other files are gone the cache grows back
+	disk.setOther(0)
+	if err := cache.resizeToDisk(); err != nil {
+		t.Fatalf("resizeToDisk() error = %v", err)
+	}
+	if stats := cache.AutoSizeStats(); stats.EffectiveSize != 100*kb {
+		t.Errorf("EffectiveSize = %d after free space grew, want 100KB", stats.EffectiveSize)
+	}
+	if capacity := cache.Stats().Capacity; capacity != 100*kb {
+		t.Errorf("Capacity = %d, want the effective size", capacity)
+	}
+}
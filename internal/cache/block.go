@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// BlockCacheConfig represents block-aligned cache configuration
+type BlockCacheConfig struct {
+	BlockSize    int64         `yaml:"block_size"`
+	FetchTimeout time.Duration `yaml:"fetch_timeout"`
+
+	// Backend fetches missing blocks on Get; without it, Get only serves
+	// ranges whose blocks are already cached
+	Backend types.Backend
+}
+
+// BlockCache aligns cached ranges to fixed block boundaries so overlapping
+// reads at arbitrary offsets share the same cached blocks
+type BlockCache struct {
+	base      types.Cache
+	backend   types.Backend
+	blockSize int64
+	timeout   time.Duration
+
+	// Final short block per object, which is cached under its actual length
+	mu    sync.RWMutex
+	tails map[string]tailBlock
+}
+
+// tailBlock records the position and length of an object's last block
+type tailBlock struct {
+	offset int64
+	size   int64
+}
+
+// NewBlockCache creates a block-aligned cache on top of base
+func NewBlockCache(base types.Cache, config *BlockCacheConfig) (*BlockCache, error) {
+	if base == nil {
+		return nil, fmt.Errorf("base cache cannot be nil")
+	}
+	if config == nil {
+		config = &BlockCacheConfig{
+			BlockSize:    1024 * 1024, // 1MB
+			FetchTimeout: 30 * time.Second,
+		}
+	}
+	if config.BlockSize <= 0 {
+		return nil, fmt.Errorf("block size must be greater than 0")
+	}
+
+	timeout := config.FetchTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &BlockCache{
+		base:      base,
+		backend:   config.Backend,
+		blockSize: config.BlockSize,
+		timeout:   timeout,
+		tails:     make(map[string]tailBlock),
+	}, nil
+}
+
+// Get retrieves a range by assembling the aligned blocks that cover it
+func (c *BlockCache) Get(key string, offset, size int64) []byte {
+	return c.assemble(key, offset, size, c.getBlock)
+}
+
+// GetStale assembles a range from cached blocks even if they have expired,
+// without fetching missing blocks from the backend
+func (c *BlockCache) GetStale(key string, offset, size int64, maxStaleness time.Duration) []byte {
+	getter, ok := c.base.(staleGetter)
+	if !ok {
+		return nil
+	}
+	return c.assemble(key, offset, size, func(key string, blockStart int64) []byte {
+		return getter.GetStale(key, blockStart, c.blockLength(key, blockStart), maxStaleness)
+	})
+}
+
+// assemble builds a range from the aligned blocks returned by fetch
+func (c *BlockCache) assemble(key string, offset, size int64, fetch func(key string, blockStart int64) []byte) []byte {
+	if size <= 0 || offset < 0 {
+		return nil
+	}
+
+	end := offset + size
+	result := make([]byte, 0, size)
+
+	for blockStart := c.alignDown(offset); blockStart < end; blockStart += c.blockSize {
+		block := fetch(key, blockStart)
+		if block == nil {
+			return nil
+		}
+
+		lo := max(offset, blockStart) - blockStart
+		if lo >= int64(len(block)) {
+			break // Past end of object
+		}
+		hi := min(end, blockStart+int64(len(block))) - blockStart
+		result = append(result, block[lo:hi]...)
+
+		if int64(len(block)) < c.blockSize {
+			break // Short block marks end of object
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Put stores every complete aligned block covered by data
+func (c *BlockCache) Put(key string, offset int64, data []byte) {
+	c.PutWithETag(key, offset, data, "")
+}
+
+// PutWithETag stores blocks like Put, recording etag on each
+func (c *BlockCache) PutWithETag(key string, offset int64, data []byte, etag string) {
+	end := offset + int64(len(data))
+
+	for blockStart := c.alignUp(offset); blockStart+c.blockSize <= end; blockStart += c.blockSize {
+		lo := blockStart - offset
+		PutWithETag(c.base, key, blockStart, data[lo:lo+c.blockSize], etag)
+	}
+}
+
+// Delete removes all cached blocks for key
+func (c *BlockCache) Delete(key string) {
+	c.mu.Lock()
+	delete(c.tails, key)
+	c.mu.Unlock()
+
+	c.base.Delete(key)
+}
+
+// Evict evicts items from the underlying cache
+func (c *BlockCache) Evict(size int64) bool {
+	return c.base.Evict(size)
+}
+
+// Pin excludes all cached blocks for key from eviction
+func (c *BlockCache) Pin(key string) {
+	c.base.Pin(key)
+}
+
+// Unpin makes the cached blocks for key evictable again
+func (c *BlockCache) Unpin(key string) {
+	c.base.Unpin(key)
+}
+
+// OnEvict registers fn for evictions from the underlying cache, which
+// report block-aligned ranges
+func (c *BlockCache) OnEvict(fn types.EvictionCallback) {
+	c.base.OnEvict(fn)
+}
+
+// Size returns the underlying cache size
+func (c *BlockCache) Size() int64 {
+	return c.base.Size()
+}
+
+// Stats returns the underlying cache statistics
+func (c *BlockCache) Stats() types.CacheStats {
+	return c.base.Stats()
+}
+
+// BlockSize returns the alignment used for cached ranges
+func (c *BlockCache) BlockSize() int64 {
+	return c.blockSize
+}
+
+// SetRevalidator forwards the revalidator to the underlying cache
+func (c *BlockCache) SetRevalidator(fn RevalidateFunc) {
+	if revalidator, ok := c.base.(CacheRevalidator); ok {
+		revalidator.SetRevalidator(fn)
+	}
+}
+
+// getBlock returns the block starting at blockStart, fetching it from the
+// backend on a miss. A block past the end of the object is returned empty.
+func (c *BlockCache) getBlock(key string, blockStart int64) []byte {
+	if data := c.base.Get(key, blockStart, c.blockLength(key, blockStart)); data != nil {
+		return data
+	}
+
+	if c.backend == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	data, err := c.backend.GetObject(ctx, key, blockStart, c.blockSize)
+	if err != nil {
+		return nil
+	}
+
+	if len(data) == 0 {
+		return []byte{}
+	}
+
+	if int64(len(data)) < c.blockSize {
+		c.mu.Lock()
+		c.tails[key] = tailBlock{offset: blockStart, size: int64(len(data))}
+		c.mu.Unlock()
+	}
+	c.base.Put(key, blockStart, data)
+	return data
+}
+
+// blockLength returns the cached length of the block at blockStart, which
+// is shorter than the block size for the last block of an object
+func (c *BlockCache) blockLength(key string, blockStart int64) int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if tail, ok := c.tails[key]; ok && tail.offset == blockStart {
+		return tail.size
+	}
+	return c.blockSize
+}
+
+func (c *BlockCache) alignDown(offset int64) int64 {
+	return offset - offset%c.blockSize
+}
+
+func (c *BlockCache) alignUp(offset int64) int64 {
+	if rem := offset % c.blockSize; rem != 0 {
+		return offset + c.blockSize - rem
+	}
+	return offset
+}
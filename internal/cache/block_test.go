@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+)
+
+// objectBackend serves ranges of a single in-memory object and records
+// every fetched range
+type objectBackend struct {
+	slowBackend
+	data []byte
+
+	fetchMu sync.Mutex
+	ranges  [][2]int64
+}
+
+func (b *objectBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	b.fetchMu.Lock()
+	b.ranges = append(b.ranges, [2]int64{offset, size})
+	b.fetchMu.Unlock()
+
+	if offset >= int64(len(b.data)) {
+		return []byte{}, nil
+	}
+	end := min(offset+size, int64(len(b.data)))
+	return append([]byte(nil), b.data[offset:end]...), nil
+}
+
+func newTestBlockCache(t *testing.T, backend *objectBackend, blockSize int64) *BlockCache {
+	t.Helper()
+
+	base := NewLRUCache(&CacheConfig{MaxSize: 1024 * 1024, MaxEntries: 1000})
+	t.Cleanup(func() { _ = base.Close() })
+
+	config := &BlockCacheConfig{BlockSize: blockSize}
+	if backend != nil {
+		config.Backend = backend
+	}
+	bc, err := NewBlockCache(base, config)
+	if err != nil {
+		t.Fatalf("NewBlockCache() error = %v", err)
+	}
+	return bc
+}
+
+func TestBlockCache_OverlappingUnalignedReadsShareBlocks(t *testing.T) {
+	backend := &objectBackend{data: []byte("abcdefghijklmnopqrstuvwxyz")}
+	bc := newTestBlockCache(t, backend, 4)
+
+	// [2,8) spans blocks 0 and 4
+	if got := bc.Get("obj", 2, 6); string(got) != "cdefgh" {
+		t.Fatalf("Get(2, 6) = %q, want %q", got, "cdefgh")
+	}
+	// [5,11) spans blocks 4 and 8; block 4 must come from the cache
+	if got := bc.Get("obj", 5, 6); string(got) != "fghijk" {
+		t.Fatalf("Get(5, 6) = %q, want %q", got, "fghijk")
+	}
+
+	want := [][2]int64{{0, 4}, {4, 4}, {8, 4}}
+	if len(backend.ranges) != len(want) {
+		t.Fatalf("backend fetched %v, want %v", backend.ranges, want)
+	}
+	for i, r := range want {
+		if backend.ranges[i] != r {
+			t.Errorf("fetch %d = %v, want %v", i, backend.ranges[i], r)
+		}
+	}
+}
+
+func TestBlockCache_TailBlock(t *testing.T) {
+	backend := &objectBackend{data: []byte("abcdefghij")}
+	bc := newTestBlockCache(t, backend, 4)
+
+	// Reading past EOF returns the available bytes
+	if got := bc.Get("obj", 6, 10); string(got) != "ghij" {
+		t.Fatalf("Get(6, 10) = %q, want %q", got, "ghij")
+	}
+	fetches := len(backend.ranges)
+
+	// The short final block is served from the cache on repeat reads
+	if got := bc.Get("obj", 9, 1); string(got) != "j" {
+		t.Fatalf("Get(9, 1) = %q, want %q", got, "j")
+	}
+	if len(backend.ranges) != fetches {
+		t.Errorf("tail block refetched: %v", backend.ranges)
+	}
+
+	if got := bc.Get("obj", 12, 4); got != nil {
+		t.Errorf("Get past EOF = %q, want nil", got)
+	}
+	fetches = len(backend.ranges)
+	if got := bc.Get("obj", 8, 2); string(got) != "ij" {
+		t.Errorf("Get(8, 2) after EOF read = %q, want %q", got, "ij")
+	}
+	if len(backend.ranges) != fetches {
+		t.Errorf("tail block refetched after EOF read: %v", backend.ranges)
+	}
+}
+
+func TestBlockCache_PutWithoutBackend(t *testing.T) {
+	bc := newTestBlockCache(t, nil, 4)
+	data := []byte("0123456789ab")
+
+	// Only complete aligned blocks [4,8) and [8,12) are cached
+	bc.Put("obj", 2, data[2:])
+
+	if got := bc.Get("obj", 5, 6); !bytes.Equal(got, data[5:11]) {
+		t.Errorf("Get(5, 6) = %q, want %q", got, data[5:11])
+	}
+	if got := bc.Get("obj", 2, 4); got != nil {
+		t.Errorf("Get over partial block = %q, want nil", got)
+	}
+
+	bc.Delete("obj")
+	if got := bc.Get("obj", 4, 4); got != nil {
+		t.Errorf("Get after Delete = %q, want nil", got)
+	}
+}
+
+func TestNewBlockCache_InvalidConfig(t *testing.T) {
+	base := NewLRUCache(nil)
+	defer func() { _ = base.Close() }()
+
+	if _, err := NewBlockCache(nil, nil); err == nil {
+		t.Error("NewBlockCache(nil) should fail")
+	}
+	if _, err := NewBlockCache(base, &BlockCacheConfig{BlockSize: 0}); err == nil {
+		t.Error("NewBlockCache with zero block size should fail")
+	}
+}
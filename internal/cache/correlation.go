@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// correlationMinCount is the decayed number of times one key must have
+	// followed another, more than once, before it is predicted
+	correlationMinCount = 1.5
+
+	// correlationMaxFollowers bounds the followers tracked per key; the
+	// weakest is dropped to make room
+	correlationMaxFollowers = 16
+)
+
+// correlationModel learns which keys are read together, such as index.bam
+// and its companion index.bai. A read of a key makes it a follower of each
+// other key read within the window before it, once per run of reads of
+// that key. Counts decay by half every halfLife, and the number of keys
+// tracked is bounded.
+type correlationModel struct {
+	window   time.Duration
+	halfLife time.Duration
+	maxKeys  int
+
+	recent []correlationRead // Keys read within the window, oldest first
+	keys   map[string]*correlatedKey
+}
+
+// correlationRead is a run of reads of one key with no gap longer than the
+// window
+type correlationRead struct {
+	key   string
+	start time.Time
+	last  time.Time
+}
+
+// correlatedKey is what has been learned about the reads following a key
+type correlatedKey struct {
+	runs      float64 // Decayed count of runs of reads of the key
+	updated   time.Time
+	followers map[string]*association
+}
+
+// association counts how often a key was read soon after another
+type association struct {
+	count   float64 // Decayed
+	updated time.Time
+
+	// The follower's first range read after the key, which is prefetched
+	offset int64
+	size   int64
+}
+
+// correlatedFollower is a key predicted to be read after another
+type correlatedFollower struct {
+	key        string
+	offset     int64
+	size       int64
+	confidence float64 // Share of the key's runs the follower was read after
+}
+
+// newCorrelationModel creates a model learning from reads window apart
+func newCorrelationModel(window, halfLife time.Duration, maxKeys int) *correlationModel {
+	if halfLife <= 0 {
+		halfLife = time.Hour
+	}
+	if maxKeys <= 0 {
+		maxKeys = 10000
+	}
+	return &correlationModel{
+		window:   window,
+		halfLife: halfLife,
+		maxKeys:  maxKeys,
+		keys:     make(map[string]*correlatedKey),
+	}
+}
+
+// decayed returns a count last updated at updated as of now
+func (m *correlationModel) decayed(count float64, updated, now time.Time) float64 {
+	age := now.Sub(updated)
+	if age <= 0 {
+		return count
+	}
+	return count * math.Exp2(-float64(age)/float64(m.halfLife))
+}
+
+// observe records a read of a range of key at now
+func (m *correlationModel) observe(key string, offset, size int64, now time.Time) {
+	cutoff := now.Add(-m.window)
+	current := correlationRead{key: key, start: now}
+	kept := m.recent[:0]
+	for _, read := range m.recent {
+		switch {
+		case read.last.Before(cutoff):
+		case read.key == key:
+			current.start = read.start // Continues the run
+		default:
+			kept = append(kept, read)
+		}
+	}
+	m.recent = kept
+
+	for _, read := range m.recent {
+		m.follow(read, key, offset, size, now)
+	}
+
+	if current.start.Equal(now) {
+		entry := m.entry(key, now)
+		entry.runs = m.decayed(entry.runs, entry.updated, now) + 1
+		entry.updated = now
+	}
+	current.last = now
+	m.recent = append(m.recent, current)
+}
+
+// follow counts key as read after read's key, once per run of read
+func (m *correlationModel) follow(read correlationRead, key string, offset, size int64, now time.Time) {
+	entry, ok := m.keys[read.key]
+	if !ok {
+		return
+	}
+
+	a, ok := entry.followers[key]
+	if ok && !a.updated.Before(read.start) {
+		return
+	}
+	if !ok {
+		if len(entry.followers) >= correlationMaxFollowers {
+			m.dropWeakest(entry, now)
+		}
+		a = &association{}
+		entry.followers[key] = a
+	}
+	a.count = m.decayed(a.count, a.updated, now) + 1
+	a.updated = now
+	a.offset, a.size = offset, size
+}
+
+// entry returns the tracked state of key, making room when the table is
+// full by dropping the tenth of keys read longest ago
+func (m *correlationModel) entry(key string, now time.Time) *correlatedKey {
+	if entry, ok := m.keys[key]; ok {
+		return entry
+	}
+
+	if len(m.keys) >= m.maxKeys {
+		keys := make([]string, 0, len(m.keys))
+		for k := range m.keys {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return m.keys[keys[i]].updated.Before(m.keys[keys[j]].updated)
+		})
+		for _, k := range keys[:len(keys)-m.maxKeys+m.maxKeys/10+1] {
+			delete(m.keys, k)
+		}
+	}
+
+	entry := &correlatedKey{updated: now, followers: make(map[string]*association)}
+	m.keys[key] = entry
+	return entry
+}
+
+// dropWeakest removes the follower of entry with the lowest decayed count
+func (m *correlationModel) dropWeakest(entry *correlatedKey, now time.Time) {
+	weakest, lowest := "", math.Inf(1)
+	for key, a := range entry.followers {
+		if count := m.decayed(a.count, a.updated, now); count < lowest {
+			weakest, lowest = key, count
+		}
+	}
+	delete(entry.followers, weakest)
+}
+
+// followers returns the keys read after key at least minConfidence of the
+// time, most confident first
+func (m *correlationModel) followers(key string, minConfidence float64, now time.Time) []correlatedFollower {
+	entry, ok := m.keys[key]
+	if !ok {
+		return nil
+	}
+	runs := m.decayed(entry.runs, entry.updated, now)
+
+	var result []correlatedFollower
+	for follower, a := range entry.followers {
+		count := m.decayed(a.count, a.updated, now)
+		if count < correlationMinCount {
+			continue
+		}
+		confidence := math.Min(count/runs, 1)
+		if confidence >= minConfidence {
+			result = append(result, correlatedFollower{key: follower, offset: a.offset, size: a.size, confidence: confidence})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].confidence != result[j].confidence {
+			return result[i].confidence > result[j].confidence
+		}
+		return result[i].key < result[j].key
+	})
+	return result
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCorrelationModelDecaysAndBounds(t *testing.T) {
+	m := newCorrelationModel(time.Second, time.Minute, 10)
+	now := time.Unix(1700000000, 0)
+
+	// Repeated reads within a run count once
+	for i := 0; i < 3; i++ {
+		m.observe("a", 0, 10, now)
+		m.observe("b", 0, 20, now.Add(10*time.Millisecond))
+		m.observe("b", 20, 20, now.Add(20*time.Millisecond))
+		now = now.Add(5 * time.Second)
+	}
+	followers := m.followers("a", 0.7, now)
+	if len(followers) != 1 || followers[0].key != "b" || followers[0].size != 20 || followers[0].offset != 0 {
+		t.Fatalf("followers(a) = %+v, want b's first range", followers)
+	}
+	if followers[0].confidence < 0.99 {
+		t.Errorf("confidence = %v, want b to follow every run of a", followers[0].confidence)
+	}
+	if got := m.followers("b", 0.7, now); len(got) != 0 {
+		t.Errorf("followers(b) = %+v, want none since a never followed b", got)
+	}
+
+	// Old associations fade
+	if got := m.followers("a", 0.7, now.Add(5*time.Minute)); len(got) != 0 {
+		t.Errorf("followers(a) after five half-lives = %+v, want none", got)
+	}
+
+	// The table stays bounded
+	for i := 0; i < 50; i++ {
+		now = now.Add(2 * time.Second)
+		m.observe(fmt.Sprintf("key-%d", i), 0, 10, now)
+	}
+	if len(m.keys) > 10 {
+		t.Errorf("tracking %d keys, want at most 10", len(m.keys))
+	}
+	if _, ok := m.keys["key-49"]; !ok {
+		t.Error("the latest key was dropped instead of the oldest")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package cache
+
+import "fmt"
+
+// statDiskSpace is not supported on this platform, so auto-sized caches
+// cannot be created
+func statDiskSpace(dir string) (DiskSpace, error) {
+	return DiskSpace{}, fmt.Errorf("measuring free disk space is not supported on this platform")
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin
+
+package cache
+
+import "syscall"
+
+// statDiskSpace returns the capacity and free space of the filesystem
+// holding dir
+func statDiskSpace(dir string) (DiskSpace, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return DiskSpace{}, err
+	}
+	return DiskSpace{
+		Total: int64(st.Blocks) * int64(st.Bsize),
+		Free:  int64(st.Bavail) * int64(st.Bsize),
+	}, nil
+}
@@ -65,6 +65,18 @@ L2 Cache (Persistent):
 - Background cleanup and maintenance
 - Cold data retention
 
+Tiered L2 (L2Config.Paths):
+- Spreads L2 over several directories ordered fastest first, e.g. NVMe then bulk disk
+- New entries land on the slowest path; PromoteAfter reads move an entry one path faster
+- Entries not read for DemoteAfter move one path slower, as do the coldest entries of a full path
+- L2PathStats reports utilization, entries, hits, promotions and demotions per path
+
+Auto-sized L2 (L2Config.AutoSize):
+- Sizes a single-directory L2 to Fraction of the space it could use: its directory's free space plus its own files
+- Never leaves less than Reserve free on the filesystem, evicting when other files eat into it
+- Rechecks free space with statfs every Interval, so the cache grows back as space frees up
+- L2AutoSizeStats reports the effective size and the disk's total and free space
+
 # Eviction Policies
 
 Multiple intelligent eviction strategies:
@@ -87,6 +99,24 @@ Access Pattern Aware:
 - Seasonal and temporal patterns
 - Predictive prefetching integration
 
+Custom Scorers:
+The predictive cache ranks eviction candidates with a types.EvictionScorer.
+A higher score means the object is evicted sooner. EvictionAlgorithm selects
+the built-in "ml", "lru" (LRUScorer) or "lfu" (LFUScorer) ranking, and
+PredictiveCacheConfig.Scorer (L1Config.Scorer in a multi-level cache)
+plugs in any other, such as a cost-aware scorer that keeps objects expensive
+to refetch:
+
+	type sizeScorer struct{}
+
+	// Score evicts the largest objects first
+	func (sizeScorer) Score(entry types.CacheEntryInfo) float64 {
+		return float64(entry.Size)
+	}
+
+Scorers for object keys are wrapped with ObjectKeyScorer when the cache is
+shared through CacheNamespaces.
+
 # Usage Examples
 
 Basic cache configuration:
@@ -142,6 +172,31 @@ Cache operations:
 	fmt.Printf("Hit rate: %.2f%%\n", stats.HitRate*100)
 	fmt.Printf("Utilization: %.2f%%\n", stats.Utilization*100)
 
+Pinning hot data so it is never chosen for eviction (pins beyond half the
+capacity fall back to normal eviction, newest first):
+
+	cache.Pin("indexes/catalog.idx")
+	defer cache.Unpin("indexes/catalog.idx")
+	fmt.Printf("Pinned: %d bytes\n", cache.Stats().PinnedBytes)
+
+Observing evictions, for example to tell peers a range is gone (callbacks
+run after the cache lock is released; Delete and Clear do not trigger them):
+
+	cache.OnEvict(func(key string, offset, size int64) {
+		log.Printf("evicted %s [%d, %d)", key, offset, offset+size)
+	})
+
+Sharing one cache between mounts (keys are prefixed per namespace):
+
+	namespaces, _ := cache.NewCacheNamespaces(cache)
+	bucketA, _ := namespaces.Namespace("bucket-a")
+	bucketB, _ := namespaces.Namespace("bucket-b")
+	bucketA.Put("index.json", 0, data) // not visible through bucketB
+
+	for name, ns := range namespaces.Stats() {
+		fmt.Printf("%s: hit rate %.2f%%\n", name, ns.HitRate*100)
+	}
+
 # Performance Optimization
 
 Multiple optimization strategies:
@@ -151,6 +206,9 @@ Intelligent Prefetching:
 - Predictive data loading
 - Background prefetch workers
 - Adaptive prefetch size calculation
+- Keys read within CorrelationWindow of each other, such as a data file and its index, are learned as companions with decaying counts, and reading one prefetches the others
+- Unread prefetched entries are evicted last for a grace period (PrefetchGracePeriod) that adapts to the observed prefetch-to-read lead time
+- MaxInflightPrefetchBytes caps the bytes fetched at once; high priority jobs over the cap wait until running fetches release capacity, and OnInflightPrefetch reports the bytes in flight
 
 Memory Management:
 - Memory pressure monitoring
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// evictedEntry records an entry removed by eviction so callbacks can be run
+// once the cache lock is released
+type evictedEntry struct {
+	key    string
+	offset int64
+	size   int64
+}
+
+// evictedEntryOf parses a "key:offset:size" cache key into the evicted
+// object key and range
+func evictedEntryOf(cacheKey string) evictedEntry {
+	entry := evictedEntry{key: objectKeyOf(cacheKey)}
+	fields := strings.Split(cacheKey[len(entry.key):], ":")
+	if len(fields) == 3 {
+		entry.offset, _ = strconv.ParseInt(fields[1], 10, 64)
+		entry.size, _ = strconv.ParseInt(fields[2], 10, 64)
+	}
+	return entry
+}
+
+// evictionListeners holds the callbacks registered through OnEvict
+type evictionListeners struct {
+	mu        sync.RWMutex
+	callbacks []types.EvictionCallback
+}
+
+// add registers fn, ignoring nil
+func (l *evictionListeners) add(fn types.EvictionCallback) {
+	if fn == nil {
+		return
+	}
+	l.mu.Lock()
+	l.callbacks = append(l.callbacks, fn)
+	l.mu.Unlock()
+}
+
+// notify runs every callback for each evicted entry. It must be called
+// without holding the cache lock, since callbacks may call back into the
+// cache.
+func (l *evictionListeners) notify(entries []evictedEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	l.mu.RLock()
+	callbacks := l.callbacks
+	l.mu.RUnlock()
+
+	for _, entry := range entries {
+		for _, fn := range callbacks {
+			fn(entry.key, entry.offset, entry.size)
+		}
+	}
+}
@@ -23,6 +23,19 @@ type LRUCache struct {
 	// Statistics
 	stats types.CacheStats
 
+	// Revalidation of expired entries
+	revalidator RevalidateFunc
+
+	// Keys excluded from eviction
+	pins *pinSet
+
+	// Keys spared from eviction until a deadline, such as fresh prefetches
+	graceUntil map[string]time.Time
+
+	// Eviction callbacks, and entries evicted under the lock awaiting them
+	listeners evictionListeners
+	evicted   []evictedEntry
+
 	// Lifecycle management
 	stopCh chan struct{}
 	closed bool
@@ -35,6 +48,16 @@ type CacheConfig struct {
 	TTL             time.Duration `yaml:"ttl"`
 	EvictionPolicy  string        `yaml:"eviction_policy"`
 	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+
+	// MaxPinnedFraction caps pinned data as a fraction of MaxSize (default 0.5)
+	MaxPinnedFraction float64 `yaml:"max_pinned_fraction"`
+
+	// TTLJitter randomizes each entry's TTL by up to ±this fraction (e.g., 0.1)
+	TTLJitter float64 `yaml:"ttl_jitter"`
+
+	// RevalidateTimeout bounds the backend request revalidating an expired
+	// entry (default 30s)
+	RevalidateTimeout time.Duration `yaml:"revalidate_timeout"`
 }
 
 // cacheItem represents an item in the cache
@@ -44,6 +67,8 @@ type cacheItem struct {
 	offset      int64
 	size        int64
 	timestamp   time.Time
+	ttl         time.Duration
+	etag        string
 	accessTime  time.Time
 	accessCount int64
 	weight      float64
@@ -75,6 +100,7 @@ func NewLRUCache(config *CacheConfig) *LRUCache {
 		stats: types.CacheStats{
 			Capacity: config.MaxSize,
 		},
+		pins:   newPinSet(config.MaxPinnedFraction),
 		stopCh: make(chan struct{}),
 		closed: false,
 	}
@@ -87,22 +113,40 @@ func NewLRUCache(config *CacheConfig) *LRUCache {
 
 // Get retrieves data from the cache
 func (c *LRUCache) Get(key string, offset, size int64) []byte {
+	data, stale := c.get(key, offset, size)
+	if stale != nil {
+		return c.revalidate(key, offset, stale)
+	}
+	return data
+}
+
+// get looks up an entry, returning stale details instead of data when an
+// expired entry should be revalidated against the backend
+func (c *LRUCache) get(key string, offset, size int64) ([]byte, *staleEntry) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	cacheKey := c.makeCacheKey(key, offset, size)
 	item, exists := c.items[cacheKey]
 
 	if !exists {
 		c.stats.Misses++
-		return nil
+		return nil, nil
 	}
 
 	// Check if item has expired
 	if c.isExpired(item) {
-		c.removeItem(cacheKey)
+		if c.revalidator != nil {
+			return nil, &staleEntry{
+				cacheKey:  cacheKey,
+				size:      item.size,
+				timestamp: item.timestamp,
+				etag:      item.etag,
+			}
+		}
+		c.evictItem(cacheKey)
 		c.stats.Misses++
-		return nil
+		return nil, nil
 	}
 
 	// Update access information
@@ -119,17 +163,23 @@ func (c *LRUCache) Get(key string, offset, size int64) []byte {
 	// Return a copy of the data
 	result := make([]byte, len(item.data))
 	copy(result, item.data)
-	return result
+	return result, nil
 }
 
 // Put stores data in the cache
 func (c *LRUCache) Put(key string, offset int64, data []byte) {
+	c.PutWithETag(key, offset, data, "")
+}
+
+// PutWithETag stores data read from the object version etag, which its
+// revalidation sends as If-None-Match
+func (c *LRUCache) PutWithETag(key string, offset int64, data []byte, etag string) {
 	if len(data) == 0 {
 		return
 	}
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	size := int64(len(data))
 	cacheKey := c.makeCacheKey(key, offset, size)
@@ -141,7 +191,9 @@ func (c *LRUCache) Put(key string, offset int64, data []byte) {
 		item.data = make([]byte, len(data))
 		copy(item.data, data)
 		item.size = size
+		item.etag = etag
 		item.timestamp = time.Now()
+		item.ttl = c.entryTTL()
 		item.accessTime = time.Now()
 		item.accessCount++
 		item.weight = c.calculateWeight(item)
@@ -159,6 +211,8 @@ func (c *LRUCache) Put(key string, offset int64, data []byte) {
 		offset:      offset,
 		size:        size,
 		timestamp:   time.Now(),
+		ttl:         c.entryTTL(),
+		etag:        etag,
 		accessTime:  time.Now(),
 		accessCount: 1,
 	}
@@ -172,6 +226,9 @@ func (c *LRUCache) Put(key string, offset int64, data []byte) {
 	// Add to items map
 	c.items[cacheKey] = newItem
 	c.currentSize += size
+	if c.pins.track(cacheKey, size) {
+		c.pins.rebalance(c.capacity)
+	}
 
 	// Evict if necessary
 	c.evictIfNeeded()
@@ -193,29 +250,31 @@ func (c *LRUCache) Delete(key string) {
 	for _, cacheKey := range keysToDelete {
 		c.removeItem(cacheKey)
 	}
+	delete(c.graceUntil, key)
 }
 
 // Evict evicts items to free up the specified amount of space
 func (c *LRUCache) Evict(targetSize int64) bool {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	freedSize := int64(0)
 
-	// Evict from the back of the list (least recently used)
-	for freedSize < targetSize && c.evictList.Len() > 0 {
-		element := c.evictList.Back()
-		if element == nil {
-			break
-		}
-
-		entry := element.Value.(*cacheEntry)
-		item := c.items[entry.key]
-		if item != nil {
-			freedSize += item.size
-			c.removeItem(entry.key)
-		} else {
-			c.evictList.Remove(element)
+	// Evict from the back of the list (least recently used), skipping pins
+	// and, unless nothing else frees enough, entries in a grace period
+	for _, spareGrace := range []bool{true, false} {
+		for element := c.evictList.Back(); element != nil && freedSize < targetSize; {
+			prev := element.Prev()
+
+			entry := element.Value.(*cacheEntry)
+			item := c.items[entry.key]
+			if item == nil {
+				c.evictList.Remove(element)
+			} else if !c.pins.protected(entry.key) && !(spareGrace && c.inGrace(entry.key)) {
+				freedSize += item.size
+				c.evictItem(entry.key)
+			}
+			element = prev
 		}
 	}
 
@@ -237,9 +296,60 @@ func (c *LRUCache) Stats() types.CacheStats {
 	stats := c.stats
 	stats.Size = c.currentSize
 	stats.Utilization = float64(c.currentSize) / float64(c.capacity)
+	stats.PinnedKeys = len(c.pins.keys)
+	stats.PinnedBytes = c.pins.pinnedBytes()
 	return stats
 }
 
+// Pin excludes key from eviction until it is unpinned
+func (c *LRUCache) Pin(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := int64(0)
+	for cacheKey, item := range c.items {
+		if objectKeyOf(cacheKey) == key {
+			cached += item.size
+		}
+	}
+	if c.pins.pin(key, cached) {
+		c.pins.rebalance(c.capacity)
+	}
+}
+
+// OnEvict registers fn to run for every entry evicted for space or expiry
+func (c *LRUCache) OnEvict(fn types.EvictionCallback) {
+	c.listeners.add(fn)
+}
+
+// ProtectUntil spares key's entries from eviction until the given time.
+// Unlike a pin the protection is soft: protected entries are evicted only
+// when nothing else can be. A time in the past lifts the protection.
+func (c *LRUCache) ProtectUntil(key string, until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !until.After(time.Now()) {
+		delete(c.graceUntil, key)
+		return
+	}
+	if c.graceUntil == nil {
+		c.graceUntil = make(map[string]time.Time)
+	}
+	c.graceUntil[key] = until
+}
+
+// Unpin makes key evictable again
+func (c *LRUCache) Unpin(key string) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if c.pins.unpin(key) {
+		c.pins.rebalance(c.capacity)
+		c.evictIfNeeded()
+	}
+}
+
 // Clear clears all items from the cache
 func (c *LRUCache) Clear() {
 	c.mu.Lock()
@@ -261,6 +371,8 @@ func (c *LRUCache) Clear() {
 	c.evictList.Init()
 	c.currentSize = 0
 	c.stats.Evictions += evictCount
+	c.pins.clearBytes()
+	c.graceUntil = nil
 }
 
 // Close stops the cleanup goroutine and releases resources
@@ -279,6 +391,8 @@ func (c *LRUCache) Close() error {
 	c.items = make(map[string]*cacheItem)
 	c.evictList.Init()
 	c.currentSize = 0
+	c.pins.clearBytes()
+	c.graceUntil = nil
 
 	return nil
 }
@@ -298,10 +412,11 @@ func (c *LRUCache) GetKeys() []string {
 // Resize changes the cache capacity
 func (c *LRUCache) Resize(newCapacity int64) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	c.capacity = newCapacity
 	c.stats.Capacity = newCapacity
+	c.pins.rebalance(newCapacity)
 	c.evictIfNeeded()
 }
 
@@ -320,7 +435,16 @@ func (c *LRUCache) isExpired(item *cacheItem) bool {
 	if c.config.TTL == 0 {
 		return false
 	}
-	return time.Since(item.timestamp) > c.config.TTL
+	ttl := item.ttl
+	if ttl == 0 {
+		ttl = c.config.TTL
+	}
+	return time.Since(item.timestamp) > ttl
+}
+
+// entryTTL returns the TTL for a newly stored or refreshed entry
+func (c *LRUCache) entryTTL() time.Duration {
+	return jitteredTTL(c.config.TTL, c.config.TTLJitter)
 }
 
 func (c *LRUCache) calculateWeight(item *cacheItem) float64 {
@@ -355,31 +479,88 @@ func (c *LRUCache) removeItem(key string) {
 	// Update size
 	c.currentSize -= item.size
 	c.stats.Evictions++
+	if c.pins.track(key, -item.size) {
+		c.pins.rebalance(c.capacity)
+	}
+}
+
+// evictItem removes an entry for space or expiry, queueing it for the
+// eviction callbacks run by unlock
+func (c *LRUCache) evictItem(cacheKey string) {
+	if item, exists := c.items[cacheKey]; exists {
+		c.evicted = append(c.evicted, evictedEntry{
+			key:    objectKeyOf(cacheKey),
+			offset: item.offset,
+			size:   item.size,
+		})
+	}
+	c.removeItem(cacheKey)
+}
+
+// unlock releases the write lock, then runs the eviction callbacks for
+// entries evicted while it was held
+func (c *LRUCache) unlock() {
+	evicted := c.evicted
+	c.evicted = nil
+	c.mu.Unlock()
+	c.listeners.notify(evicted)
 }
 
 func (c *LRUCache) evictIfNeeded() {
 	// Evict by size
-	for c.currentSize > c.capacity && c.evictList.Len() > 0 {
-		c.evictOldest()
+	for c.currentSize > c.capacity && c.evictOldest() {
 	}
 
 	// Evict by count
 	maxEntries := c.config.MaxEntries
 	if maxEntries > 0 {
-		for len(c.items) > maxEntries && c.evictList.Len() > 0 {
-			c.evictOldest()
+		for len(c.items) > maxEntries && c.evictOldest() {
 		}
 	}
 }
 
-func (c *LRUCache) evictOldest() {
-	element := c.evictList.Back()
-	if element == nil {
-		return
+// evictOldest removes the least recently used unpinned entry, preferring
+// entries outside a grace period, reporting false when nothing can be
+// evicted
+func (c *LRUCache) evictOldest() bool {
+	var graceVictim string
+	for element := c.evictList.Back(); element != nil; element = element.Prev() {
+		entry := element.Value.(*cacheEntry)
+		if c.pins.protected(entry.key) {
+			continue
+		}
+		if c.inGrace(entry.key) {
+			if graceVictim == "" {
+				graceVictim = entry.key
+			}
+			continue
+		}
+		c.evictItem(entry.key)
+		return true
 	}
+	if graceVictim != "" {
+		c.evictItem(graceVictim)
+		return true
+	}
+	return false
+}
 
-	entry := element.Value.(*cacheEntry)
-	c.removeItem(entry.key)
+// inGrace reports whether the entry under cacheKey is within a grace
+// period set by ProtectUntil, dropping expired periods
+func (c *LRUCache) inGrace(cacheKey string) bool {
+	if len(c.graceUntil) == 0 {
+		return false
+	}
+	key := objectKeyOf(cacheKey)
+	until, ok := c.graceUntil[key]
+	if !ok {
+		return false
+	}
+	if time.Now().Before(until) {
+		return true
+	}
+	delete(c.graceUntil, key)
+	return false
 }
 
 func (c *LRUCache) updateHitRate() {
@@ -407,15 +588,16 @@ func (c *LRUCache) cleanupExpired() {
 			var expiredKeys []string
 
 			for key, item := range c.items {
-				if c.isExpired(item) {
+				// Expired entries are kept for cheap revalidation when possible
+				if c.revalidator == nil && c.isExpired(item) {
 					expiredKeys = append(expiredKeys, key)
 				}
 			}
 
 			for _, key := range expiredKeys {
-				c.removeItem(key)
+				c.evictItem(key)
 			}
-			c.mu.Unlock()
+			c.unlock()
 		}
 	}
 }
@@ -440,7 +622,7 @@ func NewWeightedLRUCache(config *CacheConfig) *WeightedLRUCache {
 // EvictByWeight evicts items based on their weight (frequency + recency + size)
 func (c *WeightedLRUCache) EvictByWeight(targetSize int64) bool {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	if len(c.items) == 0 {
 		return false
@@ -455,6 +637,9 @@ func (c *WeightedLRUCache) EvictByWeight(targetSize int64) bool {
 
 	items := make([]weightedItem, 0, len(c.items))
 	for key, item := range c.items {
+		if c.pins.protected(key) {
+			continue
+		}
 		items = append(items, weightedItem{
 			key:    key,
 			weight: item.weight,
@@ -477,7 +662,7 @@ func (c *WeightedLRUCache) EvictByWeight(targetSize int64) bool {
 		if freedSize >= targetSize {
 			break
 		}
-		c.removeItem(item.key)
+		c.evictItem(item.key)
 		freedSize += item.size
 	}
 
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -611,3 +612,175 @@ func TestLRUCache_DataIsolation(t *testing.T) {
 		t.Error("cached data was modified - should be isolated")
 	}
 }
+
+// TestLRUCache_PinSurvivesEviction tests that pinned entries are skipped by eviction
+func TestLRUCache_PinSurvivesEviction(t *testing.T) {
+	cache := NewLRUCache(&CacheConfig{
+		MaxSize:    100,
+		MaxEntries: 100,
+		TTL:        time.Hour,
+	})
+	defer func() { _ = cache.Close() }()
+
+	cache.Put("index", 0, make([]byte, 30))
+	cache.Pin("index")
+
+	// Enough unpinned data to cycle the cache several times
+	for i := 0; i < 20; i++ {
+		cache.Put(fmt.Sprintf("key%d", i), 0, make([]byte, 20))
+	}
+
+	if cache.Get("index", 0, 30) == nil {
+		t.Fatal("pinned entry was evicted")
+	}
+	if cache.Get("key0", 0, 20) != nil {
+		t.Error("unpinned key0 should have been evicted")
+	}
+	if cache.Size() > 100 {
+		t.Errorf("cache size %d exceeds capacity 100", cache.Size())
+	}
+
+	stats := cache.Stats()
+	if stats.PinnedKeys != 1 || stats.PinnedBytes != 30 {
+		t.Errorf("expected 1 pinned key with 30 bytes, got %d keys with %d bytes", stats.PinnedKeys, stats.PinnedBytes)
+	}
+
+	// Explicit eviction also leaves the pin alone
+	cache.Evict(1000)
+	if cache.Get("index", 0, 30) == nil {
+		t.Error("pinned entry removed by Evict")
+	}
+
+	cache.Unpin("index")
+	cache.Evict(1000)
+	if cache.Get("index", 0, 30) != nil {
+		t.Error("unpinned entry should be evictable")
+	}
+	if stats := cache.Stats(); stats.PinnedKeys != 0 || stats.PinnedBytes != 0 {
+		t.Errorf("expected no pinned data after Unpin, got %d keys with %d bytes", stats.PinnedKeys, stats.PinnedBytes)
+	}
+}
+
+// TestLRUCache_PinBudget tests that only the oldest pins are honored once
+// pinned data exceeds the configured fraction of capacity
+func TestLRUCache_PinBudget(t *testing.T) {
+	cache := NewLRUCache(&CacheConfig{
+		MaxSize:           100,
+		MaxEntries:        100,
+		TTL:               time.Hour,
+		MaxPinnedFraction: 0.5,
+	})
+	defer func() { _ = cache.Close() }()
+
+	cache.Pin("older")
+	cache.Put("older", 0, make([]byte, 30))
+	time.Sleep(time.Millisecond)
+	cache.Pin("newer")
+	cache.Put("newer", 0, make([]byte, 30))
+
+	for i := 0; i < 10; i++ {
+		cache.Put(fmt.Sprintf("key%d", i), 0, make([]byte, 20))
+	}
+
+	if cache.Get("older", 0, 30) == nil {
+		t.Error("oldest pin within budget should be protected")
+	}
+	if cache.Get("newer", 0, 30) != nil {
+		t.Error("pin beyond the budget should have been evicted")
+	}
+}
+
+// TestLRUCache_TTLJitter tests that entries stored together expire at different times
+func TestLRUCache_TTLJitter(t *testing.T) {
+	cache := NewLRUCache(&CacheConfig{
+		MaxSize:   1024 * 1024,
+		TTL:       time.Minute,
+		TTLJitter: 0.2,
+	})
+	defer func() { _ = cache.Close() }()
+
+	distinct := make(map[time.Duration]bool)
+	for i := 0; i < 50; i++ {
+		cache.Put(fmt.Sprintf("key-%d", i), 0, []byte("data"))
+	}
+
+	cache.mu.RLock()
+	for _, item := range cache.items {
+		if item.ttl < 48*time.Second || item.ttl > 72*time.Second {
+			t.Errorf("ttl %v outside ±20%% of 1m", item.ttl)
+		}
+		distinct[item.ttl] = true
+	}
+	cache.mu.RUnlock()
+
+	if len(distinct) < 2 {
+		t.Error("expected jittered TTLs to differ between entries")
+	}
+}
+
+func TestLRUCache_GraceProtectionIsSoft(t *testing.T) {
+	cache := NewLRUCache(&CacheConfig{
+		MaxSize:    100,
+		MaxEntries: 100,
+		TTL:        time.Hour,
+	})
+	defer func() { _ = cache.Close() }()
+
+	cache.Put("prefetched", 0, make([]byte, 30))
+	cache.ProtectUntil("prefetched", time.Now().Add(time.Hour))
+
+	for i := 0; i < 20; i++ {
+		cache.Put(fmt.Sprintf("key%d", i), 0, make([]byte, 20))
+	}
+	if cache.Get("prefetched", 0, 30) == nil {
+		t.Fatal("protected entry evicted while unprotected entries remained")
+	}
+
+	// With nothing else left to evict, protection gives way
+	if !cache.Evict(cache.Size()) {
+		t.Error("Evict could not free the whole cache")
+	}
+	if cache.Get("prefetched", 0, 30) != nil {
+		t.Error("protected entry survived a full eviction")
+	}
+}
+
+// TestLRUCache_OnEvict tests that eviction callbacks receive the evicted
+// range and run outside the cache lock
+func TestLRUCache_OnEvict(t *testing.T) {
+	cache := NewLRUCache(&CacheConfig{
+		MaxSize:    100,
+		MaxEntries: 100,
+		TTL:        time.Hour,
+	})
+	defer func() { _ = cache.Close() }()
+
+	type eviction struct {
+		key          string
+		offset, size int64
+	}
+	var evicted []eviction
+	cache.OnEvict(func(key string, offset, size int64) {
+		// Calling back into the cache deadlocks if the lock is still held
+		_ = cache.Size()
+		evicted = append(evicted, eviction{key, offset, size})
+	})
+
+	cache.Put("first", 40, make([]byte, 60))
+	cache.Put("second", 0, make([]byte, 60))
+	if len(evicted) != 1 || evicted[0] != (eviction{"first", 40, 60}) {
+		t.Fatalf("evictions = %+v, want first at offset 40 with 60 bytes", evicted)
+	}
+
+	// Explicit removal is not an eviction
+	cache.Delete("second")
+	if len(evicted) != 1 {
+		t.Errorf("Delete triggered eviction callbacks: %+v", evicted)
+	}
+
+	cache.Put("third", 0, make([]byte, 10))
+	cache.Evict(10)
+	if len(evicted) != 2 || evicted[1] != (eviction{"third", 0, 10}) {
+		t.Errorf("evictions = %+v, want third evicted by Evict", evicted)
+	}
+}
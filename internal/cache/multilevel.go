@@ -1,7 +1,9 @@
 package cache
 
 import (
+	stderrors "errors"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -15,6 +17,9 @@ type MultiLevelCache struct {
 	levels  []CacheLevel
 	config  *MultiLevelConfig
 	stats   MultiLevelStats
+
+	// Caches the levels are built from, in the order Close closes them
+	closers []io.Closer
 }
 
 // CacheLevel represents a single level in the cache hierarchy
@@ -38,7 +43,26 @@ type L1Config struct {
 	Size       int64         `yaml:"size"`
 	MaxEntries int           `yaml:"max_entries"`
 	TTL        time.Duration `yaml:"ttl"`
+	TTLJitter  float64       `yaml:"ttl_jitter"`
 	Prefetch   bool          `yaml:"prefetch"`
+
+	// CorrelationWindow learns objects read within this long of each other
+	// so reading one prefetches the rest; it takes effect with Prefetch
+	// (default 5s, negative disables)
+	CorrelationWindow time.Duration `yaml:"correlation_window"`
+
+	// MaxInflightPrefetch caps the bytes prefetched at once; jobs over the
+	// cap wait for running fetches to finish (0 for unlimited). It takes
+	// effect with Prefetch.
+	MaxInflightPrefetch int64 `yaml:"max_inflight_prefetch"`
+
+	// OnInflightPrefetch receives the bytes being prefetched each time they
+	// change
+	OnInflightPrefetch func(bytes int64) `yaml:"-"`
+
+	// Scorer ranks entries for eviction in place of the access predictor;
+	// it takes effect with Prefetch, which enables intelligent eviction
+	Scorer types.EvictionScorer `yaml:"-"`
 }
 
 // L2Config represents L2 (persistent) cache configuration
@@ -47,7 +71,19 @@ type L2Config struct {
 	Size        int64         `yaml:"size"`
 	Directory   string        `yaml:"directory"`
 	TTL         time.Duration `yaml:"ttl"`
+	TTLJitter   float64       `yaml:"ttl_jitter"`
 	Compression bool          `yaml:"compression"`
+
+	// AutoSize sizes L2 to a fraction of its directory's free disk space in
+	// place of Size, shrinking to keep a reserve free
+	AutoSize *AutoSizeConfig `yaml:"auto_size"`
+
+	// Paths, fastest first, split L2 across disks of different speeds in
+	// place of Directory and Size; frequently read entries move to earlier
+	// paths and idle ones to later paths
+	Paths        []L2Path      `yaml:"paths"`
+	PromoteAfter int           `yaml:"promote_after"` // Reads that move an entry to a faster path (default 3)
+	DemoteAfter  time.Duration `yaml:"demote_after"`  // Idle time that moves an entry to a slower path (default 10m)
 }
 
 // MultiLevelStats tracks multi-level cache statistics
@@ -128,6 +164,12 @@ func (c *MultiLevelCache) Get(key string, offset, size int64) []byte {
 
 // Put stores data in the cache hierarchy
 func (c *MultiLevelCache) Put(key string, offset int64, data []byte) {
+	c.PutWithETag(key, offset, data, "")
+}
+
+// PutWithETag stores data in the cache hierarchy, recording etag on the
+// levels that support it
+func (c *MultiLevelCache) PutWithETag(key string, offset int64, data []byte, etag string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -137,22 +179,22 @@ func (c *MultiLevelCache) Put(key string, offset int64, data []byte) {
 		// Store in all levels
 		for _, level := range c.levels {
 			if level.Enabled {
-				level.Cache.Put(key, offset, data)
+				PutWithETag(level.Cache, key, offset, data, etag)
 			}
 		}
 	case "exclusive":
 		// Store only in L1, evicted items go to L2
 		if len(c.levels) > 0 && c.levels[0].Enabled {
-			c.levels[0].Cache.Put(key, offset, data)
+			PutWithETag(c.levels[0].Cache, key, offset, data, etag)
 		}
 	case "hybrid":
 		// Store in L1, selectively promote to L2 based on access patterns
-		c.hybridPut(key, offset, data)
+		c.hybridPut(key, offset, data, etag)
 	default:
 		// Default to inclusive
 		for _, level := range c.levels {
 			if level.Enabled {
-				level.Cache.Put(key, offset, data)
+				PutWithETag(level.Cache, key, offset, data, etag)
 			}
 		}
 	}
@@ -170,6 +212,43 @@ func (c *MultiLevelCache) Delete(key string) {
 	}
 }
 
+// Pin excludes key from eviction on every level
+func (c *MultiLevelCache) Pin(key string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, level := range c.levels {
+		if level.Enabled {
+			level.Cache.Pin(key)
+		}
+	}
+}
+
+// Unpin makes key evictable again on every level
+func (c *MultiLevelCache) Unpin(key string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, level := range c.levels {
+		if level.Enabled {
+			level.Cache.Unpin(key)
+		}
+	}
+}
+
+// OnEvict registers fn for evictions from every level. An entry evicted
+// from one level may still be held by another.
+func (c *MultiLevelCache) OnEvict(fn types.EvictionCallback) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, level := range c.levels {
+		if level.Enabled {
+			level.Cache.OnEvict(fn)
+		}
+	}
+}
+
 // Evict evicts data from cache levels to free space
 func (c *MultiLevelCache) Evict(size int64) bool {
 	c.mu.Lock()
@@ -235,6 +314,12 @@ func (c *MultiLevelCache) Stats() types.CacheStats {
 		combined.Evictions += levelStats.Evictions
 		combined.Size += levelStats.Size
 		combined.Capacity += levelStats.Capacity
+		combined.Revalidations += levelStats.Revalidations
+		combined.RevalidatedNotModified += levelStats.RevalidatedNotModified
+		combined.PinnedBytes += levelStats.PinnedBytes
+
+		// Every level pins the same keys
+		combined.PinnedKeys = max(combined.PinnedKeys, levelStats.PinnedKeys)
 	}
 
 	// Calculate overall hit rate
@@ -265,6 +350,34 @@ func (c *MultiLevelCache) GetLevelStats(levelName string) (types.CacheStats, err
 	return types.CacheStats{}, fmt.Errorf("cache level %s not found or not enabled", levelName)
 }
 
+// L2PathStats returns per-path statistics of a tiered L2 cache, or nil
+// when L2 is a single directory
+func (c *MultiLevelCache) L2PathStats() []L2PathStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, level := range c.levels {
+		if tiered, ok := level.Cache.(*TieredCache); ok && level.Enabled {
+			return tiered.PathStats()
+		}
+	}
+	return nil
+}
+
+// L2AutoSizeStats returns the size L2 chose from free disk space, or nil
+// when L2 has a fixed size
+func (c *MultiLevelCache) L2AutoSizeStats() *AutoSizeStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, level := range c.levels {
+		if persistent, ok := level.Cache.(*PersistentCache); ok && level.Enabled {
+			return persistent.AutoSizeStats()
+		}
+	}
+	return nil
+}
+
 // Warmup preloads frequently accessed data
 func (c *MultiLevelCache) Warmup(keys []string) error {
 	// This would typically be implemented with knowledge of the backend
@@ -293,6 +406,21 @@ func (c *MultiLevelCache) Optimize() {
 	c.updateEfficiencyMetrics()
 }
 
+// Close stops prefetching and the background work of every level, and
+// saves the persistent cache index
+func (c *MultiLevelCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for _, closer := range c.closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
 // Helper methods
 
 func (c *MultiLevelCache) initializeLevels() error {
@@ -304,23 +432,35 @@ func (c *MultiLevelCache) initializeLevels() error {
 			MaxSize:    c.config.L1Config.Size,
 			MaxEntries: c.config.L1Config.MaxEntries,
 			TTL:        c.config.L1Config.TTL,
+			TTLJitter:  c.config.L1Config.TTLJitter,
 		})
 
 		// Wrap with predictive cache if prefetch is enabled
 		var finalCache types.Cache = l1Cache
 		if c.config.L1Config.Prefetch {
+			correlationWindow := c.config.L1Config.CorrelationWindow
+			if correlationWindow == 0 {
+				correlationWindow = 5 * time.Second
+			} else if correlationWindow < 0 {
+				correlationWindow = 0
+			}
+
 			predictiveConfig := &PredictiveCacheConfig{
 				BaseCache:                 l1Cache,
 				EnablePrediction:          true,
 				PredictionWindow:          100,
 				ConfidenceThreshold:       0.7,
 				LearningRate:              0.01,
+				CorrelationWindow:         correlationWindow,
 				EnablePrefetch:            true,
 				MaxConcurrentFetch:        4,
 				PrefetchAhead:             3,
 				PrefetchBandwidth:         10 * 1024 * 1024, // 10 MB/s
+				MaxInflightPrefetchBytes:  c.config.L1Config.MaxInflightPrefetch,
+				OnInflightPrefetch:        c.config.L1Config.OnInflightPrefetch,
 				EnableIntelligentEviction: true,
 				EvictionAlgorithm:         "ml",
+				Scorer:                    c.config.L1Config.Scorer,
 				StatisticsInterval:        30 * time.Second,
 				ModelUpdateInterval:       5 * time.Minute,
 				PatternAnalysisDepth:      1000,
@@ -331,7 +471,9 @@ func (c *MultiLevelCache) initializeLevels() error {
 				return fmt.Errorf("failed to create predictive cache: %w", err)
 			}
 			finalCache = predictiveCache
+			c.closers = append(c.closers, predictiveCache)
 		}
+		c.closers = append(c.closers, l1Cache)
 
 		c.levels = append(c.levels, CacheLevel{
 			Name:     "L1",
@@ -343,15 +485,36 @@ func (c *MultiLevelCache) initializeLevels() error {
 
 	// Initialize L2 (persistent) cache if enabled
 	if c.config.L2Config != nil && c.config.L2Config.Enabled {
-		l2Cache, err := NewPersistentCache(&PersistentCacheConfig{
-			Directory:   c.config.L2Config.Directory,
-			MaxSize:     c.config.L2Config.Size,
-			TTL:         c.config.L2Config.TTL,
-			Compression: c.config.L2Config.Compression,
-		})
+		var l2Cache types.Cache
+		var err error
+		if len(c.config.L2Config.Paths) > 0 && c.config.L2Config.AutoSize != nil {
+			return fmt.Errorf("L2 auto sizing cannot be used with multiple paths")
+		}
+		if len(c.config.L2Config.Paths) > 0 {
+			l2Cache, err = NewTieredCache(&TieredCacheConfig{
+				Paths:        c.config.L2Config.Paths,
+				TTL:          c.config.L2Config.TTL,
+				TTLJitter:    c.config.L2Config.TTLJitter,
+				Compression:  c.config.L2Config.Compression,
+				PromoteAfter: c.config.L2Config.PromoteAfter,
+				DemoteAfter:  c.config.L2Config.DemoteAfter,
+			})
+		} else {
+			l2Cache, err = NewPersistentCache(&PersistentCacheConfig{
+				Directory:   c.config.L2Config.Directory,
+				MaxSize:     c.config.L2Config.Size,
+				TTL:         c.config.L2Config.TTL,
+				TTLJitter:   c.config.L2Config.TTLJitter,
+				Compression: c.config.L2Config.Compression,
+				AutoSize:    c.config.L2Config.AutoSize,
+			})
+		}
 		if err != nil {
 			return fmt.Errorf("failed to create L2 cache: %w", err)
 		}
+		if closer, ok := l2Cache.(io.Closer); ok {
+			c.closers = append(c.closers, closer)
+		}
 
 		c.levels = append(c.levels, CacheLevel{
 			Name:     "L2",
@@ -394,16 +557,16 @@ func (c *MultiLevelCache) promoteToHigherLevels(key string, offset int64, data [
 	}
 }
 
-func (c *MultiLevelCache) hybridPut(key string, offset int64, data []byte) {
+func (c *MultiLevelCache) hybridPut(key string, offset int64, data []byte, etag string) {
 	// Store in L1 first
 	if len(c.levels) > 0 && c.levels[0].Enabled {
-		c.levels[0].Cache.Put(key, offset, data)
+		PutWithETag(c.levels[0].Cache, key, offset, data, etag)
 	}
 
 	// Decide whether to store in L2 based on access patterns
 	// This is a simplified heuristic - in practice, you'd use more sophisticated ML models
 	if c.shouldPromoteToL2(key, data) && len(c.levels) > 1 && c.levels[1].Enabled {
-		c.levels[1].Cache.Put(key, offset, data)
+		PutWithETag(c.levels[1].Cache, key, offset, data, etag)
 	}
 }
 
@@ -495,3 +658,15 @@ func (c *MultiLevelCache) ClearLevel(levelName string) error {
 type CacheClearer interface {
 	Clear()
 }
+
+// SetRevalidator enables revalidation on every level that supports it
+func (c *MultiLevelCache) SetRevalidator(fn RevalidateFunc) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, level := range c.levels {
+		if revalidator, ok := level.Cache.(CacheRevalidator); ok {
+			revalidator.SetRevalidator(fn)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
@@ -804,6 +805,63 @@ func TestMultiLevelCache_Optimize(t *testing.T) {
 	}
 }
 
+// TestMultiLevelCache_Close tests that closing stops prefetching and saves
+// the L2 index for the next cache over the same directory
+func TestMultiLevelCache_Close(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	l2Config := &L2Config{
+		Enabled:     true,
+		Size:        100 * 1024 * 1024,
+		Directory:   tmpDir,
+		TTL:         time.Hour,
+		Compression: false,
+	}
+	cache, err := NewMultiLevelCache(&MultiLevelConfig{
+		L1Config: &L1Config{
+			Enabled:    true,
+			Size:       10 * 1024 * 1024,
+			MaxEntries: 1000,
+			TTL:        time.Hour,
+			Prefetch:   true,
+		},
+		L2Config: l2Config,
+		Policy:   "inclusive",
+	})
+	if err != nil {
+		t.Fatalf("NewMultiLevelCache failed: %v", err)
+	}
+
+	cache.Put("test", 0, []byte("data"))
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+
+	predictive := cache.levels[0].Cache.(*PredictiveCache)
+	select {
+	case <-predictive.prefetcher.stopCh:
+	default:
+		t.Error("prefetch workers not stopped by Close")
+	}
+
+	reopened, err := NewPersistentCache(&PersistentCacheConfig{
+		Directory: tmpDir,
+		MaxSize:   l2Config.Size,
+		TTL:       l2Config.TTL,
+	})
+	if err != nil {
+		t.Fatalf("NewPersistentCache failed: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	if got := reopened.Get("test", 0, 4); string(got) != "data" {
+		t.Errorf("reopened L2 cache returned %q, want the entry saved by Close", got)
+	}
+}
+
 // TestMultiLevelCache_Warmup tests cache warmup functionality
 func TestMultiLevelCache_Warmup(t *testing.T) {
 	t.Parallel()
@@ -929,3 +987,49 @@ func TestMultiLevelCache_DefaultPolicy(t *testing.T) {
 		t.Error("should be able to retrieve data with default policy")
 	}
 }
+
+func TestMultiLevelCache_MaxInflightPrefetch(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var reported []int64
+	cache, err := NewMultiLevelCache(&MultiLevelConfig{
+		L1Config: &L1Config{
+			Enabled:             true,
+			Size:                10 * 1024 * 1024,
+			MaxEntries:          1000,
+			TTL:                 time.Hour,
+			Prefetch:            true,
+			MaxInflightPrefetch: 4096,
+			OnInflightPrefetch: func(bytes int64) {
+				mu.Lock()
+				reported = append(reported, bytes)
+				mu.Unlock()
+			},
+		},
+		Policy: "inclusive",
+	})
+	if err != nil {
+		t.Fatalf("NewMultiLevelCache failed: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	predictive, ok := cache.closers[0].(*PredictiveCache)
+	if !ok {
+		t.Fatalf("L1 is %T, want a predictive cache", cache.closers[0])
+	}
+	prefetcher := predictive.prefetcher
+	if !prefetcher.reserveInflight(3000) {
+		t.Fatal("Failed to reserve in-flight capacity under the cap")
+	}
+	if prefetcher.reserveInflight(2000) {
+		t.Error("Reserved in-flight capacity beyond max_inflight_prefetch")
+	}
+	prefetcher.releaseInflight(3000)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 || reported[0] != 3000 || reported[1] != 0 {
+		t.Errorf("Reported in-flight bytes %v, want [3000 0]", reported)
+	}
+}
@@ -0,0 +1,270 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// namespaceSeparator joins a namespace to an object key. Namespaces may not
+// contain it, so prefix-based deletes never cross into another namespace.
+const namespaceSeparator = "/"
+
+// NamespaceStats represents cache activity for a single namespace
+type NamespaceStats struct {
+	Namespace    string  `json:"namespace"`
+	Hits         uint64  `json:"hits"`
+	Misses       uint64  `json:"misses"`
+	Puts         uint64  `json:"puts"`
+	Deletes      uint64  `json:"deletes"`
+	BytesWritten int64   `json:"bytes_written"`
+	HitRate      float64 `json:"hit_rate"`
+}
+
+// CacheNamespaces shares one cache between several mounts, giving each its
+// own key namespace so identical object keys from different buckets never
+// collide or serve each other's data
+type CacheNamespaces struct {
+	base types.Cache
+
+	mu                   sync.RWMutex
+	namespaces           map[string]*NamespacedCache
+	revalidatorInstalled bool
+}
+
+// NewCacheNamespaces creates a namespace registry on top of base
+func NewCacheNamespaces(base types.Cache) (*CacheNamespaces, error) {
+	if base == nil {
+		return nil, fmt.Errorf("base cache cannot be nil")
+	}
+
+	return &CacheNamespaces{
+		base:       base,
+		namespaces: make(map[string]*NamespacedCache),
+	}, nil
+}
+
+// Namespace returns the cache view for name, creating it on first use
+func (n *CacheNamespaces) Namespace(name string) (*NamespacedCache, error) {
+	if name == "" {
+		return nil, fmt.Errorf("cache namespace cannot be empty")
+	}
+	if strings.Contains(name, namespaceSeparator) {
+		return nil, fmt.Errorf("cache namespace %q cannot contain %q", name, namespaceSeparator)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if existing, ok := n.namespaces[name]; ok {
+		return existing, nil
+	}
+
+	namespaced := &NamespacedCache{
+		parent:    n,
+		base:      n.base,
+		namespace: name,
+		prefix:    name + namespaceSeparator,
+	}
+	n.namespaces[name] = namespaced
+	return namespaced, nil
+}
+
+// Stats returns per-namespace statistics keyed by namespace
+func (n *CacheNamespaces) Stats() map[string]NamespaceStats {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	stats := make(map[string]NamespaceStats, len(n.namespaces))
+	for name, namespaced := range n.namespaces {
+		stats[name] = namespaced.NamespaceStats()
+	}
+	return stats
+}
+
+// Base returns the shared underlying cache
+func (n *CacheNamespaces) Base() types.Cache {
+	return n.base
+}
+
+// installRevalidator routes revalidation of the shared cache to the
+// namespace that owns each key
+func (n *CacheNamespaces) installRevalidator() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.revalidatorInstalled {
+		return
+	}
+	revalidator, ok := n.base.(CacheRevalidator)
+	if !ok {
+		return
+	}
+	n.revalidatorInstalled = true
+	revalidator.SetRevalidator(n.revalidate)
+}
+
+func (n *CacheNamespaces) revalidate(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	name, objectKey, ok := strings.Cut(key, namespaceSeparator)
+	if !ok {
+		return nil, false, nil, fmt.Errorf("cache key %q has no namespace", key)
+	}
+
+	n.mu.RLock()
+	namespaced := n.namespaces[name]
+	n.mu.RUnlock()
+	if namespaced == nil {
+		return nil, false, nil, fmt.Errorf("unknown cache namespace %q", name)
+	}
+
+	fn := namespaced.revalidator.Load()
+	if fn == nil {
+		return nil, false, nil, fmt.Errorf("no revalidator for cache namespace %q", name)
+	}
+	return (*fn)(ctx, objectKey, offset, size, since, etag)
+}
+
+// NamespacedCache is one mount's view of a shared cache. Keys are prefixed
+// with the namespace before reaching the underlying cache.
+type NamespacedCache struct {
+	parent    *CacheNamespaces
+	base      types.Cache
+	namespace string
+	prefix    string
+
+	revalidator atomic.Pointer[RevalidateFunc]
+
+	hits         atomic.Uint64
+	misses       atomic.Uint64
+	puts         atomic.Uint64
+	deletes      atomic.Uint64
+	bytesWritten atomic.Int64
+}
+
+// Get retrieves data for key from this namespace
+func (c *NamespacedCache) Get(key string, offset, size int64) []byte {
+	data := c.base.Get(c.prefix+key, offset, size)
+	if data != nil {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return data
+}
+
+// Put stores data for key in this namespace
+func (c *NamespacedCache) Put(key string, offset int64, data []byte) {
+	c.PutWithETag(key, offset, data, "")
+}
+
+// PutWithETag stores data for key in this namespace, recording etag when
+// the shared cache supports it
+func (c *NamespacedCache) PutWithETag(key string, offset int64, data []byte, etag string) {
+	PutWithETag(c.base, c.prefix+key, offset, data, etag)
+	c.puts.Add(1)
+	c.bytesWritten.Add(int64(len(data)))
+}
+
+// Delete removes cached data for key from this namespace only
+func (c *NamespacedCache) Delete(key string) {
+	c.base.Delete(c.prefix + key)
+	c.deletes.Add(1)
+}
+
+// Pin excludes key in this namespace from eviction
+func (c *NamespacedCache) Pin(key string) {
+	c.base.Pin(c.prefix + key)
+}
+
+// Unpin makes key in this namespace evictable again
+func (c *NamespacedCache) Unpin(key string) {
+	c.base.Unpin(c.prefix + key)
+}
+
+// OnEvict registers fn for evictions of this namespace's entries from the
+// shared cache, reporting keys without the namespace prefix
+func (c *NamespacedCache) OnEvict(fn types.EvictionCallback) {
+	if fn == nil {
+		return
+	}
+	c.base.OnEvict(func(key string, offset, size int64) {
+		if strings.HasPrefix(key, c.prefix) {
+			fn(key[len(c.prefix):], offset, size)
+		}
+	})
+}
+
+// Evict evicts items from the shared cache
+func (c *NamespacedCache) Evict(size int64) bool {
+	return c.base.Evict(size)
+}
+
+// Size returns the shared cache size
+func (c *NamespacedCache) Size() int64 {
+	return c.base.Size()
+}
+
+// Stats returns the shared cache statistics with hits and misses counted
+// for this namespace only
+func (c *NamespacedCache) Stats() types.CacheStats {
+	stats := c.base.Stats()
+	ns := c.NamespaceStats()
+	stats.Hits = ns.Hits
+	stats.Misses = ns.Misses
+	stats.HitRate = ns.HitRate
+	return stats
+}
+
+// NamespaceStats returns statistics for this namespace
+func (c *NamespacedCache) NamespaceStats() NamespaceStats {
+	stats := NamespaceStats{
+		Namespace:    c.namespace,
+		Hits:         c.hits.Load(),
+		Misses:       c.misses.Load(),
+		Puts:         c.puts.Load(),
+		Deletes:      c.deletes.Load(),
+		BytesWritten: c.bytesWritten.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// Namespace returns the namespace name
+func (c *NamespacedCache) Namespace() string {
+	return c.namespace
+}
+
+// SetRevalidator sets the revalidator for this namespace's keys. The
+// function receives object keys without the namespace prefix.
+func (c *NamespacedCache) SetRevalidator(fn RevalidateFunc) {
+	if fn == nil {
+		c.revalidator.Store(nil)
+		return
+	}
+	c.revalidator.Store(&fn)
+	c.parent.installRevalidator()
+}
+
+// ObjectKeyScorer adapts scorer, which expects object keys, to a cache
+// shared through CacheNamespaces, whose entry keys carry the namespace
+func ObjectKeyScorer(scorer types.EvictionScorer) types.EvictionScorer {
+	return objectKeyScorer{scorer: scorer}
+}
+
+type objectKeyScorer struct {
+	scorer types.EvictionScorer
+}
+
+func (s objectKeyScorer) Score(entry types.CacheEntryInfo) float64 {
+	if _, key, ok := strings.Cut(entry.Key, namespaceSeparator); ok {
+		entry.Key = key
+	}
+	return s.scorer.Score(entry)
+}
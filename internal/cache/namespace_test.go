@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+func TestCacheNamespaces_IsolateMounts(t *testing.T) {
+	shared := NewLRUCache(&CacheConfig{MaxSize: 1024 * 1024, MaxEntries: 100, TTL: time.Hour, CleanupInterval: time.Hour})
+	t.Cleanup(func() { _ = shared.Close() })
+
+	namespaces, err := NewCacheNamespaces(shared)
+	if err != nil {
+		t.Fatalf("NewCacheNamespaces() error = %v", err)
+	}
+	mountA, _ := namespaces.Namespace("bucket-a")
+	mountB, _ := namespaces.Namespace("bucket-b")
+
+	// Both mounts cache the same object key
+	mountA.Put("data/file.txt", 0, []byte("from-a"))
+	if got := mountB.Get("data/file.txt", 0, 6); got != nil {
+		t.Fatalf("mount B served mount A's entry: %q", got)
+	}
+	mountB.Put("data/file.txt", 0, []byte("from-b"))
+
+	if got := mountA.Get("data/file.txt", 0, 6); string(got) != "from-a" {
+		t.Errorf("mount A Get() = %q, want from-a", got)
+	}
+	if got := mountB.Get("data/file.txt", 0, 6); string(got) != "from-b" {
+		t.Errorf("mount B Get() = %q, want from-b", got)
+	}
+
+	// Deleting from one namespace leaves the other intact
+	mountA.Delete("data/file.txt")
+	if got := mountA.Get("data/file.txt", 0, 6); got != nil {
+		t.Errorf("mount A Get() after Delete = %q", got)
+	}
+	if got := mountB.Get("data/file.txt", 0, 6); string(got) != "from-b" {
+		t.Errorf("mount B Get() after mount A Delete = %q, want from-b", got)
+	}
+
+	stats := namespaces.Stats()
+	if a := stats["bucket-a"]; a.Hits != 1 || a.Misses != 1 || a.Puts != 1 || a.Deletes != 1 {
+		t.Errorf("bucket-a stats = %+v", a)
+	}
+	if b := stats["bucket-b"]; b.Hits != 2 || b.Misses != 1 || b.Puts != 1 || b.BytesWritten != 6 {
+		t.Errorf("bucket-b stats = %+v", b)
+	}
+	if same, _ := namespaces.Namespace("bucket-a"); same != mountA {
+		t.Error("Namespace() should return the existing view for a known name")
+	}
+}
+
+func TestCacheNamespaces_RejectsInvalidNames(t *testing.T) {
+	namespaces, _ := NewCacheNamespaces(NewLRUCache(nil))
+	for _, name := range []string{"", "a/b"} {
+		if _, err := namespaces.Namespace(name); err == nil {
+			t.Errorf("Namespace(%q) should fail", name)
+		}
+	}
+	if _, err := NewCacheNamespaces(nil); err == nil {
+		t.Error("NewCacheNamespaces(nil) should fail")
+	}
+}
+
+func TestCacheNamespaces_RevalidatesPerNamespace(t *testing.T) {
+	shared := newExpiringCache(t)
+	namespaces, _ := NewCacheNamespaces(shared)
+	mountA, _ := namespaces.Namespace("bucket-a")
+	mountB, _ := namespaces.Namespace("bucket-b")
+
+	var gotA, gotB []string
+	mountA.SetRevalidator(func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+		gotA = append(gotA, key)
+		return nil, true, nil, nil
+	})
+	mountB.SetRevalidator(func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+		gotB = append(gotB, key)
+		return []byte("fresh"), false, nil, nil
+	})
+
+	mountA.Put("obj", 0, []byte("hello"))
+	mountB.Put("obj", 0, []byte("stale"))
+	time.Sleep(30 * time.Millisecond)
+
+	if got := mountA.Get("obj", 0, 5); string(got) != "hello" {
+		t.Errorf("mount A Get() = %q, want hello", got)
+	}
+	if got := mountB.Get("obj", 0, 5); string(got) != "fresh" {
+		t.Errorf("mount B Get() = %q, want fresh", got)
+	}
+
+	// Each revalidator sees unprefixed keys for its own namespace only
+	if len(gotA) != 1 || gotA[0] != "obj" || len(gotB) != 1 || gotB[0] != "obj" {
+		t.Errorf("revalidated keys = A %v, B %v", gotA, gotB)
+	}
+}
+
+func TestNamespacedCache_OnEvictStripsPrefix(t *testing.T) {
+	shared := NewLRUCache(&CacheConfig{MaxSize: 100, MaxEntries: 100, TTL: time.Hour, CleanupInterval: time.Hour})
+	t.Cleanup(func() { _ = shared.Close() })
+
+	namespaces, err := NewCacheNamespaces(shared)
+	if err != nil {
+		t.Fatalf("NewCacheNamespaces() error = %v", err)
+	}
+	mountA, _ := namespaces.Namespace("bucket-a")
+	mountB, _ := namespaces.Namespace("bucket-b")
+
+	var evicted []string
+	mountA.OnEvict(func(key string, offset, size int64) {
+		evicted = append(evicted, key)
+	})
+
+	// Evictions from other namespaces are not reported
+	mountB.Put("other", 0, make([]byte, 60))
+	mountA.Put("mine", 0, make([]byte, 60))
+	mountB.Put("filler", 0, make([]byte, 60))
+
+	if len(evicted) != 1 || evicted[0] != "mine" {
+		t.Errorf("mount A evictions = %v, want only [mine]", evicted)
+	}
+}
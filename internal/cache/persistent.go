@@ -24,6 +24,17 @@ type PersistentCache struct {
 	index       map[string]*persistentItem
 	config      *PersistentCacheConfig
 	stats       types.CacheStats
+	pins        *pinSet
+
+	// Eviction callbacks, and entries evicted under the lock awaiting them
+	listeners evictionListeners
+	evicted   []evictedEntry
+
+	// Sizing from free disk space, when AutoSize is set
+	autoSize  *AutoSizeConfig
+	diskSpace func(dir string) (DiskSpace, error)
+	autoStats AutoSizeStats
+
 	// Lifecycle management
 	stopCh chan struct{}
 	closed bool
@@ -38,18 +49,29 @@ type PersistentCacheConfig struct {
 	IndexFile       string        `yaml:"index_file"`
 	CleanupInterval time.Duration `yaml:"cleanup_interval"`
 	SyncInterval    time.Duration `yaml:"sync_interval"`
+
+	// MaxPinnedFraction caps pinned data as a fraction of MaxSize (default 0.5)
+	MaxPinnedFraction float64 `yaml:"max_pinned_fraction"`
+
+	// TTLJitter randomizes each entry's TTL by up to ±this fraction (e.g., 0.1)
+	TTLJitter float64 `yaml:"ttl_jitter"`
+
+	// AutoSize sizes the cache from the directory's free disk space in
+	// place of MaxSize, rechecking it periodically
+	AutoSize *AutoSizeConfig `yaml:"auto_size"`
 }
 
 // persistentItem represents an item in the persistent cache
 type persistentItem struct {
-	Key        string    `json:"key"`
-	FilePath   string    `json:"file_path"`
-	Offset     int64     `json:"offset"`
-	Size       int64     `json:"size"`
-	Timestamp  time.Time `json:"timestamp"`
-	AccessTime time.Time `json:"access_time"`
-	Compressed bool      `json:"compressed"`
-	Checksum   string    `json:"checksum"`
+	Key        string        `json:"key"`
+	FilePath   string        `json:"file_path"`
+	Offset     int64         `json:"offset"`
+	Size       int64         `json:"size"`
+	Timestamp  time.Time     `json:"timestamp"`
+	TTL        time.Duration `json:"ttl,omitempty"`
+	AccessTime time.Time     `json:"access_time"`
+	Compressed bool          `json:"compressed"`
+	Checksum   string        `json:"checksum"`
 }
 
 // NewPersistentCache creates a new persistent cache
@@ -90,8 +112,10 @@ func NewPersistentCache(config *PersistentCacheConfig) (*PersistentCache, error)
 		stats: types.CacheStats{
 			Capacity: config.MaxSize,
 		},
-		stopCh: make(chan struct{}),
-		closed: false,
+		pins:      newPinSet(config.MaxPinnedFraction),
+		diskSpace: statDiskSpace,
+		stopCh:    make(chan struct{}),
+		closed:    false,
 	}
 
 	// Load existing index
@@ -99,6 +123,15 @@ func NewPersistentCache(config *PersistentCacheConfig) (*PersistentCache, error)
 		return nil, fmt.Errorf("failed to load cache index: %w", err)
 	}
 
+	if config.AutoSize != nil {
+		autoSize := config.AutoSize.withDefaults()
+		cache.autoSize = &autoSize
+		if err := cache.resizeToDisk(); err != nil {
+			return nil, fmt.Errorf("failed to size cache: %w", err)
+		}
+		go cache.autoSizeLoop()
+	}
+
 	// Start background goroutines
 	go cache.cleanupExpired()
 	go cache.syncIndex()
@@ -136,6 +169,7 @@ func (c *PersistentCache) Get(key string, offset, size int64) []byte {
 		c.mu.Lock()
 		delete(c.index, cacheKey)
 		c.currentSize -= item.Size
+		c.trackPinned(cacheKey, -item.Size)
 		c.stats.Misses++
 		c.mu.Unlock()
 		return nil
@@ -158,7 +192,7 @@ func (c *PersistentCache) Put(key string, offset int64, data []byte) {
 	}
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	cacheKey := c.makeCacheKey(key, offset, int64(len(data)))
 
@@ -167,6 +201,7 @@ func (c *PersistentCache) Put(key string, offset int64, data []byte) {
 		// Remove old file
 		_ = os.Remove(existingItem.FilePath) // Ignore error on cleanup
 		c.currentSize -= existingItem.Size
+		c.trackPinned(cacheKey, -existingItem.Size)
 	}
 
 	// Create new item
@@ -175,6 +210,7 @@ func (c *PersistentCache) Put(key string, offset int64, data []byte) {
 		Offset:     offset,
 		Size:       int64(len(data)),
 		Timestamp:  time.Now(),
+		TTL:        jitteredTTL(c.config.TTL, c.config.TTLJitter),
 		AccessTime: time.Now(),
 		Compressed: c.config.Compression,
 		Checksum:   c.calculateChecksum(data),
@@ -195,6 +231,7 @@ func (c *PersistentCache) Put(key string, offset int64, data []byte) {
 	// Add to index
 	c.index[cacheKey] = item
 	c.currentSize += actualSize
+	c.trackPinned(cacheKey, actualSize)
 
 	// Evict if necessary
 	c.evictIfNeeded()
@@ -220,6 +257,7 @@ func (c *PersistentCache) Delete(key string) {
 		// Remove from index
 		delete(c.index, item.Key)
 		c.currentSize -= item.Size
+		c.trackPinned(item.Key, -item.Size)
 		c.stats.Evictions++
 	}
 }
@@ -227,7 +265,7 @@ func (c *PersistentCache) Delete(key string) {
 // Evict evicts items to free up space
 func (c *PersistentCache) Evict(targetSize int64) bool {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	freedSize := int64(0)
 
@@ -238,7 +276,10 @@ func (c *PersistentCache) Evict(targetSize int64) bool {
 	}
 
 	items := make([]itemWithTime, 0, len(c.index))
-	for _, item := range c.index {
+	for key, item := range c.index {
+		if c.pins.protected(key) {
+			continue
+		}
 		items = append(items, itemWithTime{
 			item:       item,
 			accessTime: item.AccessTime,
@@ -267,8 +308,10 @@ func (c *PersistentCache) Evict(targetSize int64) bool {
 
 		// Remove from index
 		delete(c.index, item.Key)
+		c.queueEvicted(item)
 		freedSize += item.Size
 		c.currentSize -= item.Size
+		c.trackPinned(item.Key, -item.Size)
 		c.stats.Evictions++
 	}
 
@@ -289,10 +332,46 @@ func (c *PersistentCache) Stats() types.CacheStats {
 
 	stats := c.stats
 	stats.Size = c.currentSize
-	stats.Utilization = float64(c.currentSize) / float64(c.maxSize)
+	if c.maxSize > 0 {
+		stats.Utilization = float64(c.currentSize) / float64(c.maxSize)
+	}
+	stats.PinnedKeys = len(c.pins.keys)
+	stats.PinnedBytes = c.pins.pinnedBytes()
 	return stats
 }
 
+// Pin excludes key from eviction until it is unpinned
+func (c *PersistentCache) Pin(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := int64(0)
+	for cacheKey, item := range c.index {
+		if objectKeyOf(cacheKey) == key {
+			cached += item.Size
+		}
+	}
+	if c.pins.pin(key, cached) {
+		c.pins.rebalance(c.maxSize)
+	}
+}
+
+// Unpin makes key evictable again
+func (c *PersistentCache) Unpin(key string) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if c.pins.unpin(key) {
+		c.pins.rebalance(c.maxSize)
+		c.evictIfNeeded()
+	}
+}
+
+// OnEvict registers fn to run for every entry evicted for space or expiry
+func (c *PersistentCache) OnEvict(fn types.EvictionCallback) {
+	c.listeners.add(fn)
+}
+
 // Clear clears all cached data
 func (c *PersistentCache) Clear() {
 	c.mu.Lock()
@@ -306,6 +385,7 @@ func (c *PersistentCache) Clear() {
 	// Clear index
 	c.index = make(map[string]*persistentItem)
 	c.currentSize = 0
+	c.pins.clearBytes()
 	c.stats.Evictions += uint64(len(c.index))
 }
 
@@ -328,7 +408,7 @@ func (c *PersistentCache) Close() error {
 // Optimize optimizes the cache by defragmenting and cleaning up
 func (c *PersistentCache) Optimize() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
 	// Remove expired items
 	var expiredKeys []string
@@ -342,7 +422,9 @@ func (c *PersistentCache) Optimize() {
 		item := c.index[key]
 		_ = os.Remove(item.FilePath) // Ignore error on cleanup
 		delete(c.index, key)
+		c.queueEvicted(item)
 		c.currentSize -= item.Size
+		c.trackPinned(key, -item.Size)
 	}
 
 	// Force sync index
@@ -355,6 +437,30 @@ func (c *PersistentCache) makeCacheKey(key string, offset, size int64) string {
 	return fmt.Sprintf("%s:%d:%d", key, offset, size)
 }
 
+// contains reports whether one range of key is cached, expired or not
+func (c *PersistentCache) contains(key string, offset, size int64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.index[c.makeCacheKey(key, offset, size)]
+	return ok
+}
+
+// remove drops one cached range of key without counting an eviction
+func (c *PersistentCache) remove(key string, offset, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cacheKey := c.makeCacheKey(key, offset, size)
+	item, ok := c.index[cacheKey]
+	if !ok {
+		return
+	}
+	_ = os.Remove(item.FilePath) // Ignore error on cleanup
+	delete(c.index, cacheKey)
+	c.currentSize -= item.Size
+	c.trackPinned(cacheKey, -item.Size)
+}
+
 func (c *PersistentCache) keyMatches(cacheKey, key string) bool {
 	return len(cacheKey) >= len(key) && cacheKey[:len(key)] == key
 }
@@ -363,7 +469,11 @@ func (c *PersistentCache) isExpired(item *persistentItem) bool {
 	if c.config.TTL == 0 {
 		return false
 	}
-	return time.Since(item.Timestamp) > c.config.TTL
+	ttl := item.TTL
+	if ttl == 0 {
+		ttl = c.config.TTL
+	}
+	return time.Since(item.Timestamp) > ttl
 }
 
 func (c *PersistentCache) generateFilePath(key string) string {
@@ -520,9 +630,12 @@ func (c *PersistentCache) evictOldest() bool {
 	var oldestKey string
 	var oldestTime time.Time
 
-	// Find oldest item
+	// Find oldest unpinned item
 	first := true
 	for key, item := range c.index {
+		if c.pins.protected(key) {
+			continue
+		}
 		if first || item.AccessTime.Before(oldestTime) {
 			oldestKey = key
 			oldestTime = item.AccessTime
@@ -534,7 +647,9 @@ func (c *PersistentCache) evictOldest() bool {
 		item := c.index[oldestKey]
 		_ = os.Remove(item.FilePath) // Ignore error on cleanup
 		delete(c.index, oldestKey)
+		c.queueEvicted(item)
 		c.currentSize -= item.Size
+		c.trackPinned(oldestKey, -item.Size)
 		c.stats.Evictions++
 		return true
 	}
@@ -542,6 +657,29 @@ func (c *PersistentCache) evictOldest() bool {
 	return false
 }
 
+// queueEvicted records an entry removed for space or expiry for the
+// eviction callbacks run by unlock. The range comes from the cache key
+// since item.Size is the possibly compressed file size.
+func (c *PersistentCache) queueEvicted(item *persistentItem) {
+	c.evicted = append(c.evicted, evictedEntryOf(item.Key))
+}
+
+// unlock releases the write lock, then runs the eviction callbacks for
+// entries evicted while it was held
+func (c *PersistentCache) unlock() {
+	evicted := c.evicted
+	c.evicted = nil
+	c.mu.Unlock()
+	c.listeners.notify(evicted)
+}
+
+// trackPinned updates pinned byte accounting when an entry is added or removed
+func (c *PersistentCache) trackPinned(cacheKey string, delta int64) {
+	if c.pins.track(cacheKey, delta) {
+		c.pins.rebalance(c.maxSize)
+	}
+}
+
 func (c *PersistentCache) updateHitRate() {
 	total := c.stats.Hits + c.stats.Misses
 	if total > 0 {
@@ -571,9 +709,11 @@ func (c *PersistentCache) cleanupExpired() {
 				item := c.index[key]
 				_ = os.Remove(item.FilePath) // Ignore error on cleanup
 				delete(c.index, key)
+				c.queueEvicted(item)
 				c.currentSize -= item.Size
+				c.trackPinned(key, -item.Size)
 			}
-			c.mu.Unlock()
+			c.unlock()
 		}
 	}
 }
@@ -328,6 +328,45 @@ func TestPersistentCache_Eviction(t *testing.T) {
 	}
 }
 
+// TestPersistentCache_PinSurvivesEviction tests that pinned entries are
+// skipped when the cache evicts to make room
+func TestPersistentCache_PinSurvivesEviction(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache, err := NewPersistentCache(&PersistentCacheConfig{
+		Directory: tmpDir,
+		MaxSize:   100,
+		TTL:       time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewPersistentCache failed: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	cache.Put("index", 0, make([]byte, 30))
+	cache.Pin("index")
+
+	for i := 0; i < 5; i++ {
+		cache.Put("key", int64(i*100), make([]byte, 30))
+		time.Sleep(10 * time.Millisecond) // Ensure different access times
+	}
+
+	if cache.Get("index", 0, 30) == nil {
+		t.Fatal("pinned entry was evicted")
+	}
+	if cache.Get("key", 0, 30) != nil {
+		t.Error("oldest unpinned item should have been evicted")
+	}
+	if stats := cache.Stats(); stats.PinnedKeys != 1 || stats.PinnedBytes != 30 {
+		t.Errorf("expected 1 pinned key with 30 bytes, got %d keys with %d bytes", stats.PinnedKeys, stats.PinnedBytes)
+	}
+
+	cache.Unpin("index")
+	cache.Evict(1000)
+	if cache.Get("index", 0, 30) != nil {
+		t.Error("unpinned entry should be evictable")
+	}
+}
+
 // TestPersistentCache_EvictManual tests manual Evict operation
 func TestPersistentCache_EvictManual(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultMaxPinnedFraction caps pinned data at half of a cache's capacity
+const defaultMaxPinnedFraction = 0.5
+
+// pinnedKey tracks one pinned object key and the bytes cached for it
+type pinnedKey struct {
+	since   time.Time
+	bytes   int64
+	honored bool
+}
+
+// pinSet records pinned object keys for a cache. Pinned entries are skipped
+// when choosing eviction candidates. If pinned bytes exceed the configured
+// fraction of capacity, only the oldest pins that fit remain protected.
+// Callers hold the owning cache's lock.
+type pinSet struct {
+	keys       map[string]*pinnedKey
+	fraction   float64
+	overflowed bool
+}
+
+func newPinSet(fraction float64) *pinSet {
+	if fraction <= 0 || fraction > 1 {
+		fraction = defaultMaxPinnedFraction
+	}
+	return &pinSet{
+		keys:     make(map[string]*pinnedKey),
+		fraction: fraction,
+	}
+}
+
+// pin protects key, counting cachedBytes already stored for it. It reports
+// false if key was already pinned.
+func (p *pinSet) pin(key string, cachedBytes int64) bool {
+	if _, ok := p.keys[key]; ok {
+		return false
+	}
+	p.keys[key] = &pinnedKey{since: time.Now(), bytes: cachedBytes, honored: true}
+	return true
+}
+
+// unpin removes protection from key
+func (p *pinSet) unpin(key string) bool {
+	if _, ok := p.keys[key]; !ok {
+		return false
+	}
+	delete(p.keys, key)
+	return true
+}
+
+// track adjusts the pinned bytes for the object owning cacheKey, reporting
+// whether the object is pinned
+func (p *pinSet) track(cacheKey string, delta int64) bool {
+	if len(p.keys) == 0 {
+		return false
+	}
+	pinned, ok := p.keys[objectKeyOf(cacheKey)]
+	if !ok {
+		return false
+	}
+	pinned.bytes += delta
+	return true
+}
+
+// protected reports whether the entry stored under cacheKey must not be evicted
+func (p *pinSet) protected(cacheKey string) bool {
+	if len(p.keys) == 0 {
+		return false
+	}
+	pinned, ok := p.keys[objectKeyOf(cacheKey)]
+	return ok && pinned.honored
+}
+
+// rebalance honors pins oldest first until the pinned budget for capacity
+// is used up, warning when newer pins have to be dropped
+func (p *pinSet) rebalance(capacity int64) {
+	if len(p.keys) == 0 {
+		p.overflowed = false
+		return
+	}
+
+	keys := make([]string, 0, len(p.keys))
+	for key := range p.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return p.keys[keys[i]].since.Before(p.keys[keys[j]].since)
+	})
+
+	budget := int64(float64(capacity) * p.fraction)
+	used := int64(0)
+	overflowed := false
+	for _, key := range keys {
+		pinned := p.keys[key]
+		pinned.honored = used+pinned.bytes <= budget
+		if pinned.honored {
+			used += pinned.bytes
+		} else {
+			overflowed = true
+		}
+	}
+
+	if overflowed && !p.overflowed {
+		log.Printf("Warning: pinned cache data exceeds %.0f%% of the %d byte capacity; only the oldest pins are protected from eviction",
+			p.fraction*100, capacity)
+	}
+	p.overflowed = overflowed
+}
+
+// clearBytes resets pinned byte counts after a cache is emptied
+func (p *pinSet) clearBytes() {
+	for _, pinned := range p.keys {
+		pinned.bytes = 0
+		pinned.honored = true
+	}
+	p.overflowed = false
+}
+
+// pinnedBytes returns the bytes cached for pinned keys
+func (p *pinSet) pinnedBytes() int64 {
+	total := int64(0)
+	for _, pinned := range p.keys {
+		total += pinned.bytes
+	}
+	return total
+}
+
+// objectKeyOf strips the ":offset:size" suffix from a range cache key
+func objectKeyOf(cacheKey string) string {
+	key := cacheKey
+	for i := 0; i < 2; i++ {
+		idx := strings.LastIndexByte(key, ':')
+		if idx < 0 {
+			return cacheKey
+		}
+		key = key[:idx]
+	}
+	return key
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/objectfs/objectfs/pkg/types"
@@ -16,8 +19,10 @@ type PredictiveCache struct {
 	predictor   *AccessPredictor
 	prefetcher  *IntelligentPrefetcher
 	evictionMgr *IntelligentEvictionManager
+	grace       *prefetchGrace
 	config      *PredictiveCacheConfig
 	stats       *PredictiveStats
+	closeOnce   sync.Once
 }
 
 // PredictiveCacheConfig configures predictive caching behavior
@@ -32,17 +37,51 @@ type PredictiveCacheConfig struct {
 	ConfidenceThreshold float64 `yaml:"confidence_threshold"` // Min confidence to trigger prefetch
 	LearningRate        float64 `yaml:"learning_rate"`        // ML model learning rate
 
+	// CorrelationWindow learns keys read within this long of each other,
+	// such as a data file and its index, so reading one prefetches the
+	// others that usually follow it (0 disables). Associations decay by
+	// half every CorrelationHalfLife (default 1h), and at most
+	// CorrelationMaxKeys keys are tracked (default 10000).
+	CorrelationWindow   time.Duration `yaml:"correlation_window"`
+	CorrelationHalfLife time.Duration `yaml:"correlation_half_life"`
+	CorrelationMaxKeys  int           `yaml:"correlation_max_keys"`
+
 	// Prefetch settings
 	EnablePrefetch     bool  `yaml:"enable_prefetch"`
 	MaxConcurrentFetch int   `yaml:"max_concurrent_fetch"`
 	PrefetchAhead      int   `yaml:"prefetch_ahead"`     // Number of blocks to prefetch ahead
 	PrefetchBandwidth  int64 `yaml:"prefetch_bandwidth"` // Max bandwidth for prefetching
 
+	// MaxInflightPrefetchBytes caps bytes being fetched concurrently (0 for unlimited)
+	MaxInflightPrefetchBytes int64 `yaml:"max_inflight_prefetch_bytes"`
+
+	// OnInflightPrefetch receives the bytes being prefetched each time
+	// they change, such as to export them as a metric
+	OnInflightPrefetch func(bytes int64) `yaml:"-"`
+
+	// PrefetchOrder decides which queued job a free worker takes:
+	// "deadline" (default) runs the job whose candidates are needed soonest,
+	// then the highest priority; "fifo" runs jobs as they were queued.
+	// Candidates whose deadline has passed are dropped instead of fetched.
+	PrefetchOrder string `yaml:"prefetch_order"`
+
+	// PrefetchGracePeriod protects prefetched entries from eviction until
+	// they are read, so the predicted read has time to land. It is the
+	// starting value; the period then follows the observed lead time between
+	// prefetch and read, up to MaxPrefetchGracePeriod. Default 5s, negative
+	// disables protection.
+	PrefetchGracePeriod    time.Duration `yaml:"prefetch_grace_period"`
+	MaxPrefetchGracePeriod time.Duration `yaml:"max_prefetch_grace_period"` // Default 1m
+
 	// Eviction settings
 	EnableIntelligentEviction bool   `yaml:"enable_intelligent_eviction"`
-	EvictionAlgorithm         string `yaml:"eviction_algorithm"` // "lru", "lfu", "arc", "ml"
+	EvictionAlgorithm         string `yaml:"eviction_algorithm"` // "lru", "lfu" or "ml" (default)
 	MLModelPath               string `yaml:"ml_model_path"`      // Path to trained model
 
+	// Scorer ranks eviction candidates in place of EvictionAlgorithm, such
+	// as a cost-aware scorer supplied by the storage backend
+	Scorer types.EvictionScorer `yaml:"-"`
+
 	// Performance settings
 	StatisticsInterval   time.Duration `yaml:"statistics_interval"`
 	ModelUpdateInterval  time.Duration `yaml:"model_update_interval"`
@@ -65,6 +104,9 @@ type PredictiveStats struct {
 	PrefetchWaste      uint64  `json:"prefetch_waste"` // Prefetched but never used
 	PrefetchEfficiency float64 `json:"prefetch_efficiency"`
 
+	// Current eviction protection for unread prefetched entries
+	PrefetchGracePeriod time.Duration `json:"prefetch_grace_period"`
+
 	// Eviction metrics
 	EvictionsTotal       uint64  `json:"evictions_total"`
 	EvictionsIntelligent uint64  `json:"evictions_intelligent"` // ML-driven evictions
@@ -89,6 +131,7 @@ type AccessPredictor struct {
 	config       *PredictiveCacheConfig
 	recentAccess []AccessEvent
 	windowSize   int
+	correlations *correlationModel // Nil when correlation learning is disabled
 }
 
 // AccessPattern represents learned access patterns for a file/key
@@ -135,13 +178,24 @@ type TrainingExample struct {
 // IntelligentPrefetcher handles predictive prefetching
 type IntelligentPrefetcher struct {
 	backend       types.Backend
-	prefetchQueue chan *PrefetchJob
+	prefetchQueue *prefetchQueue
 	activeJobs    map[string]*PrefetchJob
 	workerPool    chan struct{}
 	stats         PrefetchStats
 	rateLimiter   *RateLimiter
 	config        *PredictiveCacheConfig
 	stopCh        chan struct{}
+
+	// In-flight accounting bounds prefetch memory independently of bandwidth
+	inflightBytes     int64
+	peakInflightBytes int64
+	jobsRequeued      uint64
+	jobsDropped       uint64
+	deadlineMisses    uint64
+
+	// Jobs waiting for in-flight capacity, re-queued as it is released
+	deferMu  sync.Mutex
+	deferred []*PrefetchJob
 }
 
 // PrefetchJob represents a prefetch operation
@@ -150,11 +204,13 @@ type PrefetchJob struct {
 	Candidates   []types.PrefetchCandidate
 	Priority     int
 	Confidence   float64
+	Deadline     time.Time // Soonest candidate deadline; zero if none has one
 	CreatedAt    time.Time
 	StartedAt    time.Time
 	CompletedAt  time.Time
 	Error        error
 	BytesFetched int64
+	Attempts     int
 }
 
 // PrefetchStats tracks prefetch performance
@@ -166,12 +222,20 @@ type PrefetchStats struct {
 	AverageLatency    time.Duration `json:"average_latency"`
 	QueueDepth        int           `json:"queue_depth"`
 	WorkerUtilization float64       `json:"worker_utilization"`
+	InflightBytes     int64         `json:"inflight_bytes"`
+	PeakInflightBytes int64         `json:"peak_inflight_bytes"`
+	JobsRequeued      uint64        `json:"jobs_requeued"`
+	JobsDropped       uint64        `json:"jobs_dropped"`
+	JobsDeferred      int           `json:"jobs_deferred"`   // Jobs waiting for in-flight capacity
+	DeadlineMisses    uint64        `json:"deadline_misses"` // Candidates dropped because their deadline passed first
 }
 
 // IntelligentEvictionManager handles ML-driven cache eviction
 type IntelligentEvictionManager struct {
 	cache         types.Cache
 	predictor     *AccessPredictor
+	grace         *prefetchGrace
+	scorer        types.EvictionScorer
 	evictionModel *EvictionModel
 	config        *PredictiveCacheConfig
 }
@@ -185,6 +249,7 @@ type EvictionCandidate struct {
 	PredictedReuse float64   `json:"predicted_reuse"` // Probability of future access
 	EvictionScore  float64   `json:"eviction_score"`  // Higher = more likely to evict
 	CacheLevel     string    `json:"cache_level"`
+	Protected      bool      `json:"protected"` // Prefetched, unread, and within its grace period
 }
 
 // EvictionModel implements ML-based eviction decisions
@@ -210,6 +275,7 @@ func NewPredictiveCache(config *PredictiveCacheConfig) (*PredictiveCache, error)
 			PredictionWindow:          100,
 			ConfidenceThreshold:       0.7,
 			LearningRate:              0.01,
+			CorrelationWindow:         5 * time.Second,
 			EnablePrefetch:            true,
 			MaxConcurrentFetch:        4,
 			PrefetchAhead:             3,
@@ -233,10 +299,18 @@ func NewPredictiveCache(config *PredictiveCacheConfig) (*PredictiveCache, error)
 			trainingData: make([]TrainingExample, 0, 10000),
 		},
 	}
+	if config.CorrelationWindow > 0 {
+		predictor.correlations = newCorrelationModel(config.CorrelationWindow, config.CorrelationHalfLife, config.CorrelationMaxKeys)
+	}
+
+	queue, err := newPrefetchQueue(config.PrefetchOrder, defaultPrefetchQueueSize)
+	if err != nil {
+		return nil, err
+	}
 
 	prefetcher := &IntelligentPrefetcher{
 		backend:       config.Backend,
-		prefetchQueue: make(chan *PrefetchJob, 1000),
+		prefetchQueue: queue,
 		activeJobs:    make(map[string]*PrefetchJob),
 		workerPool:    make(chan struct{}, config.MaxConcurrentFetch),
 		config:        config,
@@ -248,21 +322,37 @@ func NewPredictiveCache(config *PredictiveCacheConfig) (*PredictiveCache, error)
 		},
 	}
 
+	grace := newPrefetchGrace(config.PrefetchGracePeriod, config.MaxPrefetchGracePeriod)
+
+	scorer := config.Scorer
+	if scorer == nil {
+		var err error
+		if scorer, err = NewEvictionScorer(config.EvictionAlgorithm); err != nil {
+			return nil, err
+		}
+	}
+
 	evictionMgr := &IntelligentEvictionManager{
 		cache:     config.BaseCache,
 		predictor: predictor,
+		grace:     grace,
+		scorer:    scorer,
 		config:    config,
 		evictionModel: &EvictionModel{
 			weights:   make(map[string]float64),
 			threshold: 0.5,
 		},
 	}
+	if evictionMgr.scorer == nil {
+		evictionMgr.scorer = evictionMgr
+	}
 
 	pc := &PredictiveCache{
 		baseCache:   config.BaseCache,
 		predictor:   predictor,
 		prefetcher:  prefetcher,
 		evictionMgr: evictionMgr,
+		grace:       grace,
 		config:      config,
 		stats:       &PredictiveStats{},
 	}
@@ -296,6 +386,14 @@ func (pc *PredictiveCache) Get(key string, offset, size int64) []byte {
 	data := pc.baseCache.Get(key, offset, size)
 	event.Hit = data != nil
 
+	// The first read of prefetched data ends its eviction protection
+	if event.Hit && pc.grace.used(key, start) {
+		event.Prefetch = true
+		if protector, ok := pc.baseCache.(evictionProtector); ok {
+			protector.ProtectUntil(key, time.Time{})
+		}
+	}
+
 	// Update predictor with access pattern
 	if pc.config.EnablePrediction {
 		pc.predictor.RecordAccess(event)
@@ -314,13 +412,25 @@ func (pc *PredictiveCache) Get(key string, offset, size int64) []byte {
 
 // Put stores data with intelligent cache management
 func (pc *PredictiveCache) Put(key string, offset int64, data []byte) {
+	pc.PutWithETag(key, offset, data, "")
+}
+
+// PutWithETag stores data like Put, recording etag when the base cache
+// supports it
+func (pc *PredictiveCache) PutWithETag(key string, offset int64, data []byte, etag string) {
 	// Check if we need to evict before putting
 	if pc.config.EnableIntelligentEviction {
-		pc.intelligentEvict(int64(len(data)))
+		if capacity := pc.baseCache.Stats().Capacity; capacity > 0 {
+			if needed := pc.baseCache.Size() + int64(len(data)) - capacity; needed > 0 {
+				pc.intelligentEvict(needed)
+			}
+		} else {
+			pc.intelligentEvict(int64(len(data)))
+		}
 	}
 
 	// Store in base cache
-	pc.baseCache.Put(key, offset, data)
+	PutWithETag(pc.baseCache, key, offset, data, etag)
 
 	// Update access patterns
 	if pc.config.EnablePrediction {
@@ -339,6 +449,7 @@ func (pc *PredictiveCache) Put(key string, offset int64, data []byte) {
 // Delete removes data from cache
 func (pc *PredictiveCache) Delete(key string) {
 	pc.baseCache.Delete(key)
+	pc.discardPrefetched(key)
 
 	// Clean up prediction data
 	if pc.config.EnablePrediction {
@@ -356,6 +467,21 @@ func (pc *PredictiveCache) Evict(size int64) bool {
 	return pc.baseCache.Evict(size)
 }
 
+// Pin excludes key from eviction in the base cache
+func (pc *PredictiveCache) Pin(key string) {
+	pc.baseCache.Pin(key)
+}
+
+// Unpin makes key evictable again in the base cache
+func (pc *PredictiveCache) Unpin(key string) {
+	pc.baseCache.Unpin(key)
+}
+
+// OnEvict registers fn for evictions from the base cache
+func (pc *PredictiveCache) OnEvict(fn types.EvictionCallback) {
+	pc.baseCache.OnEvict(fn)
+}
+
 // Size returns cache size
 func (pc *PredictiveCache) Size() int64 {
 	return pc.baseCache.Size()
@@ -387,6 +513,7 @@ func (pc *PredictiveCache) GetPredictiveStats() PredictiveStats {
 		PrefetchHits:         pc.stats.PrefetchHits,
 		PrefetchWaste:        pc.stats.PrefetchWaste,
 		PrefetchEfficiency:   pc.stats.PrefetchEfficiency,
+		PrefetchGracePeriod:  pc.grace.period(),
 		EvictionsTotal:       pc.stats.EvictionsTotal,
 		EvictionsIntelligent: pc.stats.EvictionsIntelligent,
 		EvictionAccuracy:     pc.stats.EvictionAccuracy,
@@ -430,6 +557,10 @@ func (ap *AccessPredictor) RecordAccess(event AccessEvent) {
 	}
 	pattern.LastAccess = event.Timestamp
 
+	if ap.correlations != nil {
+		ap.correlations.observe(event.Key, event.Offset, event.Size, event.Timestamp)
+	}
+
 	// Recalculate pattern features
 	ap.calculatePatternFeatures(pattern)
 
@@ -444,26 +575,29 @@ func (ap *AccessPredictor) PredictNextAccess(key string) []types.PrefetchCandida
 	ap.mu.RLock()
 	defer ap.mu.RUnlock()
 
-	pattern, exists := ap.patterns[key]
-	if !exists || len(pattern.AccessHistory) < 3 {
-		return nil
-	}
+	// Correlated keys need no history of their own beyond following key
+	candidates := ap.predictCorrelated(key)
 
-	var candidates []types.PrefetchCandidate
+	pattern, exists := ap.patterns[key]
+	if exists && len(pattern.AccessHistory) >= 3 {
+		// Sequential prediction
+		if pattern.SequentialScore > 0.7 {
+			candidates = append(candidates, ap.predictSequential(pattern)...)
+		}
 
-	// Sequential prediction
-	if pattern.SequentialScore > 0.7 {
-		candidates = append(candidates, ap.predictSequential(pattern)...)
-	}
+		// Temporal prediction
+		if pattern.FrequencyScore > 0.5 {
+			candidates = append(candidates, ap.predictTemporal(pattern)...)
+		}
 
-	// Temporal prediction
-	if pattern.FrequencyScore > 0.5 {
-		candidates = append(candidates, ap.predictTemporal(pattern)...)
+		// ML-based prediction
+		if ap.model != nil {
+			candidates = append(candidates, ap.predictML(pattern)...)
+		}
 	}
 
-	// ML-based prediction
-	if ap.model != nil {
-		candidates = append(candidates, ap.predictML(pattern)...)
+	if len(candidates) == 0 {
+		return nil
 	}
 
 	// Sort by confidence and return top candidates
@@ -549,6 +683,27 @@ func (ap *AccessPredictor) predictSequential(pattern *AccessPattern) []types.Pre
 	return candidates
 }
 
+// predictCorrelated returns the keys usually read soon after key, starting
+// with the range read first last time
+func (ap *AccessPredictor) predictCorrelated(key string) []types.PrefetchCandidate {
+	if ap.correlations == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var candidates []types.PrefetchCandidate
+	for _, follower := range ap.correlations.followers(key, ap.config.ConfidenceThreshold, now) {
+		candidates = append(candidates, types.PrefetchCandidate{
+			Path:     follower.key,
+			Offset:   follower.offset,
+			Size:     follower.size,
+			Priority: int(follower.confidence * 100),
+			Deadline: now.Add(time.Minute),
+		})
+	}
+	return candidates
+}
+
 func (ap *AccessPredictor) predictTemporal(pattern *AccessPattern) []types.PrefetchCandidate {
 	// Predict based on temporal patterns (simplified)
 	// In practice, this would analyze time-based access patterns
@@ -753,14 +908,11 @@ func (pc *PredictiveCache) triggerPrefetch(candidates []types.PrefetchCandidate)
 		CreatedAt:  time.Now(),
 		Priority:   candidates[0].Priority,
 		Confidence: float64(candidates[0].Priority) / 100.0,
+		Deadline:   earliestDeadline(candidates),
 	}
 
-	select {
-	case pc.prefetcher.prefetchQueue <- job:
-		// Note: In production, this would need proper synchronization
-		pc.prefetcher.stats.JobsQueued++
-	default:
-		// Queue full, drop job
+	if pc.prefetcher.enqueue(job) {
+		atomic.AddUint64(&pc.prefetcher.stats.JobsQueued, 1)
 	}
 }
 
@@ -772,29 +924,42 @@ func (pc *PredictiveCache) startPrefetchWorkers() {
 
 func (pc *PredictiveCache) prefetchWorker() {
 	for {
-		select {
-		case job := <-pc.prefetcher.prefetchQueue:
-			pc.processPrefetchJob(job)
-		case <-pc.prefetcher.stopCh:
+		job, ok := pc.prefetcher.prefetchQueue.pop()
+		if !ok {
 			return
 		}
+		pc.processPrefetchJob(job)
 	}
 }
 
 func (pc *PredictiveCache) processPrefetchJob(job *PrefetchJob) {
 	job.StartedAt = time.Now()
 
-	for _, candidate := range job.Candidates {
-		// Check rate limiter
-		if !pc.prefetcher.rateLimiter.Allow(candidate.Size) {
-			continue
-		}
+	// Data arriving after its read is needed is wasted bandwidth
+	var missed int
+	job.Candidates, missed = unexpiredCandidates(job.Candidates, job.StartedAt)
+	if missed > 0 {
+		atomic.AddUint64(&pc.prefetcher.deadlineMisses, uint64(missed))
+	}
 
+	for i, candidate := range job.Candidates {
 		// Check if already in cache
 		if existing := pc.baseCache.Get(candidate.Path, candidate.Offset, candidate.Size); existing != nil {
 			continue
 		}
 
+		// Bound the bytes buffered by concurrent prefetches
+		if !pc.prefetcher.reserveInflight(candidate.Size) {
+			pc.prefetcher.deferCandidates(job, job.Candidates[i:])
+			break
+		}
+
+		// Check rate limiter
+		if !pc.prefetcher.rateLimiter.Allow(candidate.Size) {
+			pc.prefetcher.releaseInflight(candidate.Size)
+			continue
+		}
+
 		// Fetch from backend if available
 		if pc.prefetcher.backend != nil {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -803,15 +968,175 @@ func (pc *PredictiveCache) processPrefetchJob(job *PrefetchJob) {
 
 			if err == nil {
 				pc.baseCache.Put(candidate.Path, candidate.Offset, data)
+				pc.protectPrefetched(candidate.Path)
 				job.BytesFetched += int64(len(data))
 			}
 		}
+
+		pc.prefetcher.releaseInflight(candidate.Size)
 	}
 
 	job.CompletedAt = time.Now()
 
-	// Note: In production, this would need proper synchronization
-	pc.prefetcher.stats.JobsCompleted++
+	atomic.AddUint64(&pc.prefetcher.stats.JobsCompleted, 1)
+}
+
+// Prefetch jobs at or above this priority are re-queued when the in-flight
+// cap is reached; lower priority work is dropped
+const (
+	prefetchRequeuePriority = 50
+	maxPrefetchRequeues     = 3
+)
+
+// enqueue adds a job to the prefetch queue without blocking; a full queue
+// drops the job
+func (ip *IntelligentPrefetcher) enqueue(job *PrefetchJob) bool {
+	return ip.prefetchQueue.push(job)
+}
+
+// reserveInflight claims in-flight capacity for a fetch of the given size
+func (ip *IntelligentPrefetcher) reserveInflight(size int64) bool {
+	limit := ip.config.MaxInflightPrefetchBytes
+	for {
+		current := atomic.LoadInt64(&ip.inflightBytes)
+		next := current + size
+		if limit > 0 && next > limit {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&ip.inflightBytes, current, next) {
+			for {
+				peak := atomic.LoadInt64(&ip.peakInflightBytes)
+				if next <= peak || atomic.CompareAndSwapInt64(&ip.peakInflightBytes, peak, next) {
+					break
+				}
+			}
+			ip.reportInflight(next)
+			return true
+		}
+	}
+}
+
+// releaseInflight returns in-flight capacity once fetched data is cached,
+// re-queuing the jobs that were waiting for it
+func (ip *IntelligentPrefetcher) releaseInflight(size int64) {
+	ip.reportInflight(atomic.AddInt64(&ip.inflightBytes, -size))
+
+	ip.deferMu.Lock()
+	deferred := ip.deferred
+	ip.deferred = nil
+	ip.deferMu.Unlock()
+	for _, job := range deferred {
+		ip.requeue(job)
+	}
+}
+
+// reportInflight passes the bytes being prefetched to OnInflightPrefetch
+func (ip *IntelligentPrefetcher) reportInflight(bytes int64) {
+	if ip.config.OnInflightPrefetch != nil {
+		ip.config.OnInflightPrefetch(bytes)
+	}
+}
+
+// deferCandidates holds high priority work that could not start until
+// in-flight capacity is released, and drops the rest
+func (ip *IntelligentPrefetcher) deferCandidates(job *PrefetchJob, remaining []types.PrefetchCandidate) {
+	if job.Priority < prefetchRequeuePriority || job.Attempts >= maxPrefetchRequeues {
+		atomic.AddUint64(&ip.jobsDropped, 1)
+		return
+	}
+	deferred := &PrefetchJob{
+		Key:        job.Key,
+		Candidates: remaining,
+		Priority:   job.Priority,
+		Confidence: job.Confidence,
+		Deadline:   earliestDeadline(remaining),
+		CreatedAt:  job.CreatedAt,
+		Attempts:   job.Attempts + 1,
+	}
+
+	// Capacity released since the reservation failed has already re-queued
+	// the waiting jobs, so this one is re-queued now rather than left waiting
+	ip.deferMu.Lock()
+	current := atomic.LoadInt64(&ip.inflightBytes)
+	if current+remaining[0].Size <= ip.config.MaxInflightPrefetchBytes || current == 0 {
+		ip.deferMu.Unlock()
+		ip.requeue(deferred)
+		return
+	}
+	if len(ip.deferred) >= ip.prefetchQueue.limit {
+		ip.deferMu.Unlock()
+		atomic.AddUint64(&ip.jobsDropped, 1)
+		return
+	}
+	ip.deferred = append(ip.deferred, deferred)
+	ip.deferMu.Unlock()
+}
+
+// requeue returns a deferred job to the prefetch queue, dropping it when
+// the queue is full or closed
+func (ip *IntelligentPrefetcher) requeue(job *PrefetchJob) {
+	if ip.enqueue(job) {
+		atomic.AddUint64(&ip.jobsRequeued, 1)
+		return
+	}
+	atomic.AddUint64(&ip.jobsDropped, 1)
+}
+
+// deferredJobs returns the number of jobs waiting for in-flight capacity
+func (ip *IntelligentPrefetcher) deferredJobs() int {
+	ip.deferMu.Lock()
+	defer ip.deferMu.Unlock()
+	return len(ip.deferred)
+}
+
+// InflightPrefetchBytes returns the bytes currently being prefetched
+func (pc *PredictiveCache) InflightPrefetchBytes() int64 {
+	return atomic.LoadInt64(&pc.prefetcher.inflightBytes)
+}
+
+// GetPrefetchStats returns prefetch statistics including in-flight usage
+func (pc *PredictiveCache) GetPrefetchStats() PrefetchStats {
+	return PrefetchStats{
+		JobsQueued:        atomic.LoadUint64(&pc.prefetcher.stats.JobsQueued),
+		JobsCompleted:     atomic.LoadUint64(&pc.prefetcher.stats.JobsCompleted),
+		JobsFailed:        pc.prefetcher.stats.JobsFailed,
+		BytesPrefetched:   pc.prefetcher.stats.BytesPrefetched,
+		AverageLatency:    pc.prefetcher.stats.AverageLatency,
+		QueueDepth:        pc.prefetcher.prefetchQueue.len(),
+		WorkerUtilization: pc.prefetcher.stats.WorkerUtilization,
+		InflightBytes:     atomic.LoadInt64(&pc.prefetcher.inflightBytes),
+		PeakInflightBytes: atomic.LoadInt64(&pc.prefetcher.peakInflightBytes),
+		JobsRequeued:      atomic.LoadUint64(&pc.prefetcher.jobsRequeued),
+		JobsDropped:       atomic.LoadUint64(&pc.prefetcher.jobsDropped),
+		JobsDeferred:      pc.prefetcher.deferredJobs(),
+		DeadlineMisses:    atomic.LoadUint64(&pc.prefetcher.deadlineMisses),
+	}
+}
+
+// Prefetch Grace Implementation
+
+// protectPrefetched spares freshly prefetched key from eviction for the
+// current grace period
+func (pc *PredictiveCache) protectPrefetched(key string) {
+	until := pc.grace.prefetched(key, time.Now())
+	if protector, ok := pc.baseCache.(evictionProtector); ok {
+		protector.ProtectUntil(key, until)
+	}
+
+	pc.stats.mu.Lock()
+	pc.stats.PrefetchRequests++
+	pc.stats.mu.Unlock()
+}
+
+// discardPrefetched counts key as prefetch waste if it leaves the cache
+// before it was ever read
+func (pc *PredictiveCache) discardPrefetched(key string) {
+	if !pc.grace.discard(key) {
+		return
+	}
+	pc.stats.mu.Lock()
+	pc.stats.PrefetchWaste++
+	pc.stats.mu.Unlock()
 }
 
 // Intelligent Eviction Implementation
@@ -822,9 +1147,16 @@ func (pc *PredictiveCache) intelligentEvict(sizeNeeded int64) bool {
 		return pc.baseCache.Evict(sizeNeeded)
 	}
 
-	// Sort by eviction score (higher score = more likely to evict)
+	// Sort by eviction score (higher score = more likely to evict), least
+	// recently accessed first among equals and protected entries last
 	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].EvictionScore > candidates[j].EvictionScore
+		if candidates[i].Protected != candidates[j].Protected {
+			return candidates[j].Protected
+		}
+		if candidates[i].EvictionScore != candidates[j].EvictionScore {
+			return candidates[i].EvictionScore > candidates[j].EvictionScore
+		}
+		return candidates[i].LastAccess.Before(candidates[j].LastAccess)
 	})
 
 	totalEvicted := int64(0)
@@ -832,8 +1164,12 @@ func (pc *PredictiveCache) intelligentEvict(sizeNeeded int64) bool {
 		if totalEvicted >= sizeNeeded {
 			break
 		}
+		if candidate.Protected {
+			continue
+		}
 
 		pc.baseCache.Delete(candidate.Key)
+		pc.discardPrefetched(candidate.Key)
 		totalEvicted += candidate.Size
 
 		pc.stats.mu.Lock()
@@ -842,13 +1178,86 @@ func (pc *PredictiveCache) intelligentEvict(sizeNeeded int64) bool {
 		pc.stats.mu.Unlock()
 	}
 
-	return totalEvicted >= sizeNeeded
+	// Only protected entries are left; the base cache evicts them last
+	if totalEvicted < sizeNeeded {
+		return pc.baseCache.Evict(sizeNeeded - totalEvicted)
+	}
+	return true
+}
+
+// keyLister is implemented by caches that can enumerate their entry keys
+type keyLister interface {
+	GetKeys() []string
 }
 
+// generateEvictionCandidates scores each cached object with the configured
+// scorer. Prefetched objects within their grace period are marked
+// protected.
 func (em *IntelligentEvictionManager) generateEvictionCandidates() []*EvictionCandidate {
-	// This would analyze cache contents and generate candidates
-	// For now, return empty slice as placeholder
-	return []*EvictionCandidate{}
+	lister, ok := em.cache.(keyLister)
+	if !ok {
+		return nil
+	}
+
+	byKey := make(map[string]*EvictionCandidate)
+	for _, cacheKey := range lister.GetKeys() {
+		key := objectKeyOf(cacheKey)
+		candidate, exists := byKey[key]
+		if !exists {
+			candidate = &EvictionCandidate{Key: key, CacheLevel: "L1"}
+			byKey[key] = candidate
+		}
+		candidate.Size += cachedRangeSize(cacheKey)
+	}
+
+	candidates := make([]*EvictionCandidate, 0, len(byKey))
+	em.predictor.mu.RLock()
+	for key, candidate := range byKey {
+		if pattern, ok := em.predictor.patterns[key]; ok {
+			candidate.LastAccess = pattern.LastAccess
+			candidate.AccessCount = len(pattern.AccessHistory)
+			candidate.PredictedReuse = pattern.RecencyScore
+		}
+		candidates = append(candidates, candidate)
+	}
+	em.predictor.mu.RUnlock()
+
+	now := time.Now()
+	for _, candidate := range candidates {
+		candidate.EvictionScore = em.scorer.Score(types.CacheEntryInfo{
+			Key:         candidate.Key,
+			Size:        candidate.Size,
+			LastAccess:  candidate.LastAccess,
+			AccessCount: candidate.AccessCount,
+		})
+		candidate.Protected = em.grace.protected(candidate.Key, now)
+	}
+	return candidates
+}
+
+// Score is the "ml" eviction scorer: one minus the predicted chance that
+// the object is read again, in [0, 1]
+func (em *IntelligentEvictionManager) Score(entry types.CacheEntryInfo) float64 {
+	em.predictor.mu.RLock()
+	defer em.predictor.mu.RUnlock()
+
+	if pattern, ok := em.predictor.patterns[entry.Key]; ok {
+		return 1 - pattern.RecencyScore
+	}
+	return 1
+}
+
+// cachedRangeSize returns the size encoded in a "key:offset:size" cache key
+func cachedRangeSize(cacheKey string) int64 {
+	idx := strings.LastIndexByte(cacheKey, ':')
+	if idx < 0 {
+		return 0
+	}
+	size, err := strconv.ParseInt(cacheKey[idx+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return size
 }
 
 // Rate Limiter Implementation
@@ -905,10 +1314,19 @@ func (pc *PredictiveCache) initializeModel() {
 
 // Close shuts down the predictive cache and stops all background workers
 func (pc *PredictiveCache) Close() error {
-	if pc.config.EnablePrefetch && pc.prefetcher != nil {
-		close(pc.prefetcher.stopCh)
-		// Drain the queue to unblock any pending sends
-		close(pc.prefetcher.prefetchQueue)
-	}
+	pc.closeOnce.Do(func() {
+		if pc.config.EnablePrefetch && pc.prefetcher != nil {
+			close(pc.prefetcher.stopCh)
+			// Discard queued jobs and release idle workers
+			pc.prefetcher.prefetchQueue.close()
+		}
+	})
 	return nil
 }
+
+// SetRevalidator forwards the revalidator to the underlying cache
+func (pc *PredictiveCache) SetRevalidator(fn RevalidateFunc) {
+	if revalidator, ok := pc.baseCache.(CacheRevalidator); ok {
+		revalidator.SetRevalidator(fn)
+	}
+}
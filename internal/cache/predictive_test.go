@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// slowBackend serves zeroed blocks after a delay, tracking bytes held by
+// concurrent fetches
+type slowBackend struct {
+	mu       sync.Mutex
+	delay    time.Duration
+	inflight int64
+	peak     int64
+	fetches  int
+}
+
+func (b *slowBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	b.mu.Lock()
+	b.inflight += size
+	if b.inflight > b.peak {
+		b.peak = b.inflight
+	}
+	b.fetches++
+	b.mu.Unlock()
+
+	time.Sleep(b.delay)
+
+	b.mu.Lock()
+	b.inflight -= size
+	b.mu.Unlock()
+	return make([]byte, size), nil
+}
+
+func (b *slowBackend) PutObject(ctx context.Context, key string, data []byte) error { return nil }
+func (b *slowBackend) DeleteObject(ctx context.Context, key string) error           { return nil }
+func (b *slowBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	return &types.ObjectInfo{Key: key}, nil
+}
+func (b *slowBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	return nil, nil
+}
+func (b *slowBackend) PutObjects(ctx context.Context, objects map[string][]byte) error { return nil }
+func (b *slowBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	return nil, nil
+}
+func (b *slowBackend) HealthCheck(ctx context.Context) error { return nil }
+
+func TestPredictiveCache_MaxInflightPrefetchBytes(t *testing.T) {
+	const blockSize = 1024
+	const inflightCap = 2 * blockSize
+
+	backend := &slowBackend{delay: 20 * time.Millisecond}
+	base := NewLRUCache(&CacheConfig{MaxSize: 64 * 1024 * 1024, MaxEntries: 10000})
+	defer func() { _ = base.Close() }()
+
+	pc, err := NewPredictiveCache(&PredictiveCacheConfig{
+		BaseCache:                base,
+		Backend:                  backend,
+		EnablePrefetch:           true,
+		MaxConcurrentFetch:       8,
+		PrefetchAhead:            1,
+		PrefetchBandwidth:        1 << 40,
+		MaxInflightPrefetchBytes: inflightCap,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create predictive cache: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	// Make bandwidth a non-factor so only the in-flight cap applies
+	pc.prefetcher.rateLimiter.mu.Lock()
+	pc.prefetcher.rateLimiter.tokens = 1 << 40
+	pc.prefetcher.rateLimiter.mu.Unlock()
+
+	// Flood the prefetcher with a mix of high and low priority candidates
+	const jobs = 64
+	for i := 0; i < jobs; i++ {
+		priority := 10
+		if i%2 == 0 {
+			priority = 90
+		}
+		pc.triggerPrefetch([]types.PrefetchCandidate{{
+			Path:     "flood",
+			Offset:   int64(i) * blockSize,
+			Size:     blockSize,
+			Priority: priority,
+		}})
+	}
+
+	// Wait for the queue to drain
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := pc.GetPrefetchStats()
+		if stats.QueueDepth == 0 && stats.InflightBytes == 0 &&
+			stats.JobsCompleted == stats.JobsQueued+stats.JobsRequeued {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Prefetch queue did not drain: %+v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats := pc.GetPrefetchStats()
+	if stats.PeakInflightBytes > inflightCap {
+		t.Errorf("Peak in-flight bytes %d exceeded cap %d", stats.PeakInflightBytes, inflightCap)
+	}
+
+	backend.mu.Lock()
+	peak, fetches := backend.peak, backend.fetches
+	backend.mu.Unlock()
+
+	if peak > inflightCap {
+		t.Errorf("Backend held %d bytes concurrently, cap is %d", peak, inflightCap)
+	}
+	if fetches == 0 {
+		t.Error("Expected some prefetches to run")
+	}
+	if stats.JobsDropped == 0 {
+		t.Error("Expected flood to drop work once the in-flight cap was reached")
+	}
+	if fetches >= jobs {
+		t.Errorf("Expected the cap to shed load, but all %d candidates were fetched", fetches)
+	}
+}
+
+func TestPredictiveCache_DefersPrefetchUntilCapacityIsReleased(t *testing.T) {
+	const blockSize = 1024
+
+	backend := &slowBackend{}
+	base := NewLRUCache(&CacheConfig{MaxSize: 64 * 1024 * 1024, MaxEntries: 10000})
+	defer func() { _ = base.Close() }()
+
+	pc, err := NewPredictiveCache(&PredictiveCacheConfig{
+		BaseCache:                base,
+		Backend:                  backend,
+		EnablePrefetch:           true,
+		MaxConcurrentFetch:       1,
+		PrefetchAhead:            1,
+		PrefetchBandwidth:        1 << 40,
+		MaxInflightPrefetchBytes: blockSize,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create predictive cache: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	pc.prefetcher.rateLimiter.mu.Lock()
+	pc.prefetcher.rateLimiter.tokens = 1 << 40
+	pc.prefetcher.rateLimiter.mu.Unlock()
+
+	waitFor := func(what string, done func(PrefetchStats) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !done(pc.GetPrefetchStats()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s: %+v", what, pc.GetPrefetchStats())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// Another fetch holds all the capacity while a high priority job runs
+	if !pc.prefetcher.reserveInflight(blockSize) {
+		t.Fatal("Failed to reserve in-flight capacity")
+	}
+	pc.triggerPrefetch([]types.PrefetchCandidate{{Path: "urgent", Size: blockSize, Priority: 90}})
+	waitFor("the job to be deferred", func(s PrefetchStats) bool { return s.JobsDeferred == 1 })
+
+	time.Sleep(20 * time.Millisecond)
+	stats := pc.GetPrefetchStats()
+	if stats.JobsRequeued != 0 || stats.JobsDropped != 0 {
+		t.Errorf("Deferred job requeued %d and dropped %d times before capacity was released", stats.JobsRequeued, stats.JobsDropped)
+	}
+
+	pc.prefetcher.releaseInflight(blockSize)
+	waitFor("the deferred job to run", func(s PrefetchStats) bool {
+		return s.JobsDeferred == 0 && s.JobsCompleted == 2 && s.InflightBytes == 0
+	})
+	if data := base.Get("urgent", 0, blockSize); data == nil {
+		t.Error("Deferred candidate was not prefetched once capacity was released")
+	}
+	if stats := pc.GetPrefetchStats(); stats.JobsRequeued != 1 || stats.JobsDropped != 0 {
+		t.Errorf("Requeued %d and dropped %d jobs, want one requeue", stats.JobsRequeued, stats.JobsDropped)
+	}
+}
+
+func TestPredictiveCache_InflightUnlimitedByDefault(t *testing.T) {
+	pc, err := NewPredictiveCache(&PredictiveCacheConfig{
+		BaseCache:          NewLRUCache(nil),
+		MaxConcurrentFetch: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create predictive cache: %v", err)
+	}
+
+	if !pc.prefetcher.reserveInflight(1 << 30) {
+		t.Error("Expected reservation to succeed without a cap")
+	}
+	if got := pc.InflightPrefetchBytes(); got != 1<<30 {
+		t.Errorf("Expected 1GiB in flight, got %d", got)
+	}
+	pc.prefetcher.releaseInflight(1 << 30)
+	if got := pc.InflightPrefetchBytes(); got != 0 {
+		t.Errorf("Expected no bytes in flight after release, got %d", got)
+	}
+}
+
+func TestPredictiveCache_PrefetchesCorrelatedKeys(t *testing.T) {
+	const window = 50 * time.Millisecond
+
+	backend := &slowBackend{}
+	base := NewLRUCache(&CacheConfig{MaxSize: 64 * 1024 * 1024, MaxEntries: 10000})
+	defer func() { _ = base.Close() }()
+
+	pc, err := NewPredictiveCache(&PredictiveCacheConfig{
+		BaseCache:           base,
+		Backend:             backend,
+		EnablePrediction:    true,
+		ConfidenceThreshold: 0.7,
+		CorrelationWindow:   window,
+		EnablePrefetch:      true,
+		MaxConcurrentFetch:  1,
+		PrefetchAhead:       3,
+		PrefetchBandwidth:   1 << 40,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create predictive cache: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	pc.prefetcher.rateLimiter.mu.Lock()
+	pc.prefetcher.rateLimiter.tokens = 1 << 40
+	pc.prefetcher.rateLimiter.mu.Unlock()
+
+	drain := func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			stats := pc.GetPrefetchStats()
+			if stats.QueueDepth == 0 && stats.InflightBytes == 0 &&
+				stats.JobsCompleted == stats.JobsQueued+stats.JobsRequeued {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Prefetch queue did not drain: %+v", stats)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Each data file read is followed by a read of its index
+	for i := 0; i < 3; i++ {
+		pc.Get("sample.bam", 0, 1024)
+		pc.Get("sample.bam.bai", 0, 512)
+		time.Sleep(2 * window)
+	}
+	drain()
+
+	pc.Delete("sample.bam.bai")
+	pc.Get("unrelated", 0, 1024)
+	drain()
+	if base.Get("sample.bam.bai", 0, 512) != nil {
+		t.Fatal("Reading an unrelated key prefetched the index")
+	}
+
+	time.Sleep(2 * window)
+	pc.Get("sample.bam", 0, 1024)
+	drain()
+	if base.Get("sample.bam.bai", 0, 512) == nil {
+		t.Error("Reading the data file did not prefetch its index")
+	}
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Prefetch grace defaults
+const (
+	defaultPrefetchGracePeriod    = 5 * time.Second
+	defaultMaxPrefetchGracePeriod = time.Minute
+	minPrefetchGracePeriod        = 100 * time.Millisecond
+
+	// The adaptive grace covers this multiple of the average lead time
+	// between a prefetch and its first read
+	prefetchGraceLeadFactor = 2
+
+	// Weight of the newest lead time in the rolling average
+	prefetchLeadSmoothing = 0.2
+)
+
+// evictionProtector is implemented by caches that can keep a key from being
+// evicted for a limited time
+type evictionProtector interface {
+	ProtectUntil(key string, until time.Time)
+}
+
+// prefetchGrace tracks prefetched keys that have not been read yet and how
+// long they stay protected from eviction. The grace period starts at the
+// configured value and then follows the observed lead time between a
+// prefetch and the read it predicted.
+type prefetchGrace struct {
+	mu       sync.Mutex
+	initial  time.Duration
+	max      time.Duration
+	pending  map[string]time.Time // Prefetched, unread keys and when they landed
+	leadTime time.Duration        // Rolling average of prefetch-to-read lead times
+	samples  int
+}
+
+// newPrefetchGrace returns a tracker starting at initial and adapting up to
+// max; a negative initial disables protection
+func newPrefetchGrace(initial, maxPeriod time.Duration) *prefetchGrace {
+	if initial == 0 {
+		initial = defaultPrefetchGracePeriod
+	}
+	if maxPeriod <= 0 {
+		maxPeriod = defaultMaxPrefetchGracePeriod
+	}
+	return &prefetchGrace{
+		initial: initial,
+		max:     maxPeriod,
+		pending: make(map[string]time.Time),
+	}
+}
+
+// period returns the current grace period, or 0 when protection is disabled
+func (g *prefetchGrace) period() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.periodLocked()
+}
+
+func (g *prefetchGrace) periodLocked() time.Duration {
+	if g.initial < 0 {
+		return 0
+	}
+	period := g.initial
+	if g.samples > 0 {
+		period = max(prefetchGraceLeadFactor*g.leadTime, minPrefetchGracePeriod)
+	}
+	if period > g.max {
+		return g.max
+	}
+	return period
+}
+
+// prefetched records that key was just prefetched, returning when its
+// protection ends
+func (g *prefetchGrace) prefetched(key string, now time.Time) time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pending[key] = now
+	return now.Add(g.periodLocked())
+}
+
+// used records the first read of a prefetched key, folding its lead time
+// into the rolling average. It reports false for keys not awaiting a read.
+func (g *prefetchGrace) used(key string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	at, ok := g.pending[key]
+	if !ok {
+		return false
+	}
+	delete(g.pending, key)
+
+	lead := now.Sub(at)
+	if g.samples == 0 {
+		g.leadTime = lead
+	} else {
+		g.leadTime = time.Duration(prefetchLeadSmoothing*float64(lead) + (1-prefetchLeadSmoothing)*float64(g.leadTime))
+	}
+	g.samples++
+	return true
+}
+
+// protected reports whether key was prefetched, is unread, and is still
+// within its grace period
+func (g *prefetchGrace) protected(key string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	at, ok := g.pending[key]
+	period := g.periodLocked()
+	return ok && period > 0 && now.Before(at.Add(period))
+}
+
+// discard forgets key, reporting whether it was prefetched and never read
+func (g *prefetchGrace) discard(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.pending[key]; !ok {
+		return false
+	}
+	delete(g.pending, key)
+	return true
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// newGraceTestCache returns a predictive cache over a 4KB LRU cache whose
+// prefetches are run directly by the test
+func newGraceTestCache(t *testing.T, grace time.Duration) (*PredictiveCache, *LRUCache) {
+	t.Helper()
+
+	base := NewLRUCache(&CacheConfig{MaxSize: 4 * 1024, MaxEntries: 1000})
+	t.Cleanup(func() { _ = base.Close() })
+
+	pc, err := NewPredictiveCache(&PredictiveCacheConfig{
+		BaseCache:                 base,
+		Backend:                   &slowBackend{},
+		EnablePrediction:          true,
+		PredictionWindow:          100,
+		PrefetchAhead:             1,
+		PrefetchBandwidth:         1 << 40,
+		EnableIntelligentEviction: true,
+		PrefetchGracePeriod:       grace,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create predictive cache: %v", err)
+	}
+	t.Cleanup(func() { _ = pc.Close() })
+
+	pc.prefetcher.rateLimiter.mu.Lock()
+	pc.prefetcher.rateLimiter.tokens = 1 << 40
+	pc.prefetcher.rateLimiter.mu.Unlock()
+	return pc, base
+}
+
+func prefetchBlock(pc *PredictiveCache, key string) {
+	pc.processPrefetchJob(&PrefetchJob{
+		Candidates: []types.PrefetchCandidate{{Path: key, Size: 1024, Priority: 90}},
+	})
+}
+
+func TestPredictiveCache_PrefetchSurvivesEvictionWithinGrace(t *testing.T) {
+	pc, base := newGraceTestCache(t, 100*time.Millisecond)
+	block := make([]byte, 1024)
+
+	for _, key := range []string{"a", "b", "c"} {
+		pc.Put(key, 0, block)
+	}
+	prefetchBlock(pc, "predicted")
+
+	// Fill the cache several times over while the prefetch is protected
+	for _, key := range []string{"d", "e", "f", "g", "h"} {
+		pc.Put(key, 0, block)
+		if base.Get("predicted", 0, 1024) == nil {
+			t.Fatalf("prefetched entry evicted within its grace period while putting %s", key)
+		}
+	}
+	if size := base.Size(); size > 4*1024 {
+		t.Errorf("cache size = %d, want at most the 4KB capacity", size)
+	}
+
+	// Once the grace period passes, the unread prefetch is evicted first
+	time.Sleep(150 * time.Millisecond)
+	pc.Put("i", 0, block)
+	if base.Get("predicted", 0, 1024) != nil {
+		t.Error("prefetched entry still cached after its grace period under eviction pressure")
+	}
+	if waste := pc.GetPredictiveStats().PrefetchWaste; waste != 1 {
+		t.Errorf("prefetch waste = %d, want 1", waste)
+	}
+}
+
+func TestPredictiveCache_PrefetchReadEndsProtection(t *testing.T) {
+	pc, base := newGraceTestCache(t, time.Minute)
+	prefetchBlock(pc, "predicted")
+
+	if pc.Get("predicted", 0, 1024) == nil {
+		t.Fatal("prefetched entry not cached")
+	}
+	stats := pc.GetPredictiveStats()
+	if stats.PrefetchHits != 1 || stats.PrefetchRequests != 1 {
+		t.Errorf("prefetch hits/requests = %d/%d, want 1/1", stats.PrefetchHits, stats.PrefetchRequests)
+	}
+
+	// Read data is an ordinary entry again
+	base.mu.RLock()
+	_, protected := base.graceUntil["predicted"]
+	base.mu.RUnlock()
+	if protected {
+		t.Error("entry still protected after its predicted read")
+	}
+	if pc.grace.protected("predicted", time.Now()) {
+		t.Error("grace tracker still protects the read entry")
+	}
+}
+
+func TestPrefetchGraceAdaptsToLeadTime(t *testing.T) {
+	grace := newPrefetchGrace(5*time.Second, time.Minute)
+	if period := grace.period(); period != 5*time.Second {
+		t.Fatalf("initial period = %v, want 5s", period)
+	}
+
+	now := time.Now()
+	grace.prefetched("quick", now)
+	if !grace.used("quick", now.Add(300*time.Millisecond)) {
+		t.Fatal("first read of a prefetched key not recorded")
+	}
+	if period := grace.period(); period != 600*time.Millisecond {
+		t.Errorf("period after a 300ms lead = %v, want 600ms", period)
+	}
+	if grace.used("quick", now.Add(time.Second)) {
+		t.Error("second read counted as a prefetch hit")
+	}
+
+	// Long lead times are capped
+	grace.prefetched("slow", now)
+	grace.used("slow", now.Add(10*time.Minute))
+	if period := grace.period(); period != time.Minute {
+		t.Errorf("period after a long lead = %v, want the 1m cap", period)
+	}
+
+	disabled := newPrefetchGrace(-1, 0)
+	disabled.prefetched("k", now)
+	if disabled.protected("k", now) {
+		t.Error("negative grace period still protects prefetches")
+	}
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Prefetch queue orders
+const (
+	PrefetchOrderDeadline = "deadline" // Soonest deadline first, then highest priority
+	PrefetchOrderFIFO     = "fifo"     // In the order jobs were queued
+)
+
+// defaultPrefetchQueueSize bounds the jobs waiting for a prefetch worker
+const defaultPrefetchQueueSize = 1000
+
+// prefetchQueue holds prefetch jobs until a worker takes them. Under the
+// deadline order the most time-sensitive job is taken first, so prefetches
+// for an imminent read do not wait behind ones that are needed later. Jobs
+// without a deadline run after every job with one.
+type prefetchQueue struct {
+	mu     sync.Mutex
+	ready  *sync.Cond
+	jobs   prefetchHeap
+	limit  int
+	seq    uint64
+	closed bool
+}
+
+// newPrefetchQueue creates a queue holding up to limit jobs in the given order
+func newPrefetchQueue(order string, limit int) (*prefetchQueue, error) {
+	q := &prefetchQueue{limit: limit}
+	switch order {
+	case "", PrefetchOrderDeadline:
+		q.jobs.byDeadline = true
+	case PrefetchOrderFIFO:
+	default:
+		return nil, fmt.Errorf("invalid prefetch order: %s (must be %s or %s)", order, PrefetchOrderDeadline, PrefetchOrderFIFO)
+	}
+	if q.limit <= 0 {
+		q.limit = defaultPrefetchQueueSize
+	}
+	q.ready = sync.NewCond(&q.mu)
+	return q, nil
+}
+
+// push queues a job without blocking, returning false if the queue is full
+// or closed
+func (q *prefetchQueue) push(job *PrefetchJob) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || len(q.jobs.items) >= q.limit {
+		return false
+	}
+	q.seq++
+	heap.Push(&q.jobs, prefetchItem{job: job, seq: q.seq})
+	q.ready.Signal()
+	return true
+}
+
+// pop waits for the next job, returning false once the queue is closed
+func (q *prefetchQueue) pop() (*PrefetchJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.jobs.items) == 0 && !q.closed {
+		q.ready.Wait()
+	}
+	if q.closed {
+		return nil, false
+	}
+	return heap.Pop(&q.jobs).(prefetchItem).job, true
+}
+
+// len returns the number of queued jobs
+func (q *prefetchQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs.items)
+}
+
+// close discards queued jobs and wakes waiting workers
+func (q *prefetchQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.jobs.items = nil
+	q.ready.Broadcast()
+}
+
+// prefetchItem is a queued job and its position in arrival order
+type prefetchItem struct {
+	job *PrefetchJob
+	seq uint64
+}
+
+// prefetchHeap implements heap.Interface over queued jobs
+type prefetchHeap struct {
+	items      []prefetchItem
+	byDeadline bool
+}
+
+func (h prefetchHeap) Len() int { return len(h.items) }
+
+func (h prefetchHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.byDeadline {
+		switch {
+		case a.job.Deadline.IsZero() != b.job.Deadline.IsZero():
+			return !a.job.Deadline.IsZero()
+		case !a.job.Deadline.Equal(b.job.Deadline):
+			return a.job.Deadline.Before(b.job.Deadline)
+		case a.job.Priority != b.job.Priority:
+			return a.job.Priority > b.job.Priority
+		}
+	}
+	return a.seq < b.seq
+}
+
+func (h prefetchHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *prefetchHeap) Push(x interface{}) { h.items = append(h.items, x.(prefetchItem)) }
+
+func (h *prefetchHeap) Pop() interface{} {
+	last := len(h.items) - 1
+	item := h.items[last]
+	h.items[last] = prefetchItem{}
+	h.items = h.items[:last]
+	return item
+}
+
+// earliestDeadline returns the soonest deadline among candidates, or the
+// zero time if none has one
+func earliestDeadline(candidates []types.PrefetchCandidate) time.Time {
+	var earliest time.Time
+	for _, candidate := range candidates {
+		if !candidate.Deadline.IsZero() && (earliest.IsZero() || candidate.Deadline.Before(earliest)) {
+			earliest = candidate.Deadline
+		}
+	}
+	return earliest
+}
+
+// unexpiredCandidates returns the candidates whose deadline has not passed
+// by now and how many were dropped
+func unexpiredCandidates(candidates []types.PrefetchCandidate, now time.Time) ([]types.PrefetchCandidate, int) {
+	kept := candidates[:0:0]
+	for _, candidate := range candidates {
+		if candidate.Deadline.IsZero() || now.Before(candidate.Deadline) {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept, len(candidates) - len(kept)
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// orderBackend records the order of fetched keys. The first fetch waits
+// for release, so jobs queued meanwhile are all pending together.
+type orderBackend struct {
+	slowBackend
+	started chan struct{}
+	release chan struct{}
+
+	mu    sync.Mutex
+	order []string
+}
+
+func (b *orderBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	b.mu.Lock()
+	b.order = append(b.order, key)
+	first := len(b.order) == 1
+	b.mu.Unlock()
+
+	if first {
+		close(b.started)
+		<-b.release
+	}
+	return make([]byte, size), nil
+}
+
+func (b *orderBackend) fetched() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.order...)
+}
+
+func newOrderTestCache(t *testing.T, backend types.Backend, order string) *PredictiveCache {
+	t.Helper()
+	base := NewLRUCache(&CacheConfig{MaxSize: 1 << 20, MaxEntries: 1000})
+	t.Cleanup(func() { _ = base.Close() })
+
+	pc, err := NewPredictiveCache(&PredictiveCacheConfig{
+		BaseCache:          base,
+		Backend:            backend,
+		EnablePrefetch:     true,
+		MaxConcurrentFetch: 1,
+		PrefetchBandwidth:  1 << 40,
+		PrefetchOrder:      order,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create predictive cache: %v", err)
+	}
+	t.Cleanup(func() { _ = pc.Close() })
+
+	pc.prefetcher.rateLimiter.mu.Lock()
+	pc.prefetcher.rateLimiter.tokens = 1 << 40
+	pc.prefetcher.rateLimiter.mu.Unlock()
+	return pc
+}
+
+func TestPrefetchRunsNearestDeadlineFirst(t *testing.T) {
+	backend := &orderBackend{started: make(chan struct{}), release: make(chan struct{})}
+	pc := newOrderTestCache(t, backend, "")
+	now := time.Now()
+
+	// Occupy the only worker so the next jobs wait in the queue
+	pc.triggerPrefetch([]types.PrefetchCandidate{{Path: "busy", Size: 16}})
+	<-backend.started
+
+	pc.triggerPrefetch([]types.PrefetchCandidate{{Path: "later", Size: 16}})
+	pc.triggerPrefetch([]types.PrefetchCandidate{{Path: "far", Size: 16, Priority: 90, Deadline: now.Add(time.Minute)}})
+	pc.triggerPrefetch([]types.PrefetchCandidate{{Path: "near", Size: 16, Priority: 10, Deadline: now.Add(10 * time.Second)}})
+	close(backend.release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(backend.fetched()) < 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("prefetches did not finish: fetched %v", backend.fetched())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	want := []string{"busy", "near", "far", "later"}
+	got := backend.fetched()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("prefetch order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPrefetchQueueFIFOOrder(t *testing.T) {
+	queue, err := newPrefetchQueue(PrefetchOrderFIFO, 0)
+	if err != nil {
+		t.Fatalf("newPrefetchQueue failed: %v", err)
+	}
+	now := time.Now()
+	queue.push(&PrefetchJob{Key: "far", Deadline: now.Add(time.Minute)})
+	queue.push(&PrefetchJob{Key: "near", Deadline: now.Add(time.Second)})
+
+	for _, want := range []string{"far", "near"} {
+		if job, _ := queue.pop(); job.Key != want {
+			t.Errorf("pop = %s, want %s", job.Key, want)
+		}
+	}
+
+	if _, err := newPrefetchQueue("random", 0); err == nil {
+		t.Error("newPrefetchQueue accepted an unknown order")
+	}
+}
+
+func TestPrefetchDropsCandidatesPastDeadline(t *testing.T) {
+	backend := &slowBackend{}
+	pc := newOrderTestCache(t, backend, PrefetchOrderDeadline)
+
+	pc.processPrefetchJob(&PrefetchJob{Candidates: []types.PrefetchCandidate{
+		{Path: "stale", Size: 16, Deadline: time.Now().Add(-time.Second)},
+		{Path: "fresh", Size: 16, Deadline: time.Now().Add(time.Minute)},
+	}})
+
+	if stats := pc.GetPrefetchStats(); stats.DeadlineMisses != 1 {
+		t.Errorf("DeadlineMisses = %d, want 1", stats.DeadlineMisses)
+	}
+	backend.mu.Lock()
+	fetches := backend.fetches
+	backend.mu.Unlock()
+	if fetches != 1 {
+		t.Errorf("backend fetches = %d, want only the candidate still in time", fetches)
+	}
+}
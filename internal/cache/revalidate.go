@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// defaultRevalidateTimeout bounds a revalidation, which runs inside a Get
+const defaultRevalidateTimeout = 30 * time.Second
+
+// RevalidateFunc fetches size bytes of an object from offset, or the rest of
+// it when size is 0, only if the object changed since the given time or
+// ETag, reporting notModified when the cached copy is still current
+type RevalidateFunc func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) (data []byte, notModified bool, info *types.ObjectInfo, err error)
+
+// CacheRevalidator interface for caches that can revalidate expired entries
+type CacheRevalidator interface {
+	SetRevalidator(fn RevalidateFunc)
+}
+
+// ETagPutter is implemented by caches that record the ETag of the object
+// version an entry was read from, so its first revalidation can send
+// If-None-Match rather than rely on If-Modified-Since alone
+type ETagPutter interface {
+	PutWithETag(key string, offset int64, data []byte, etag string)
+}
+
+// PutWithETag stores data in c, recording etag when c supports it
+func PutWithETag(c types.Cache, key string, offset int64, data []byte, etag string) {
+	if putter, ok := c.(ETagPutter); ok && etag != "" {
+		putter.PutWithETag(key, offset, data, etag)
+		return
+	}
+	c.Put(key, offset, data)
+}
+
+// staleEntry captures an expired entry awaiting revalidation
+type staleEntry struct {
+	cacheKey  string
+	size      int64
+	timestamp time.Time
+	etag      string
+}
+
+// SetRevalidator enables conditional revalidation of expired entries.
+// Passing nil restores the default drop-on-expiry behaviour.
+func (c *LRUCache) SetRevalidator(fn RevalidateFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revalidator = fn
+}
+
+// revalidate checks an expired entry against the backend. An unchanged
+// object extends the entry's TTL; a changed object replaces its data with
+// the entry's range, which is all that is transferred.
+func (c *LRUCache) revalidate(key string, offset int64, stale *staleEntry) []byte {
+	c.mu.RLock()
+	revalidator := c.revalidator
+	timeout := c.config.RevalidateTimeout
+	c.mu.RUnlock()
+	if timeout <= 0 {
+		timeout = defaultRevalidateTimeout
+	}
+
+	var (
+		data        []byte
+		notModified bool
+		info        *types.ObjectInfo
+		err         error
+	)
+	if revalidator != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		data, notModified, info, err = revalidator(ctx, key, offset, stale.size, stale.timestamp, stale.etag)
+		cancel()
+	} else {
+		err = context.Canceled
+	}
+
+	c.mu.Lock()
+	c.stats.Revalidations++
+	item, exists := c.items[stale.cacheKey]
+	switch {
+	case !exists:
+		c.stats.Misses++
+		c.updateHitRate()
+		c.mu.Unlock()
+		return nil
+	case !item.timestamp.Equal(stale.timestamp):
+		// Entry was refreshed concurrently, serve it as is
+		result := c.touchLocked(item)
+		c.mu.Unlock()
+		return result
+	case err != nil:
+		c.evictItem(stale.cacheKey)
+		c.stats.Misses++
+		c.updateHitRate()
+		c.unlock()
+		return nil
+	case notModified:
+		item.timestamp = time.Now()
+		item.ttl = c.entryTTL()
+		c.stats.RevalidatedNotModified++
+		result := c.touchLocked(item)
+		c.mu.Unlock()
+		return result
+	}
+
+	// Object changed: replace the cached range with fresh data
+	c.removeItem(stale.cacheKey)
+	c.stats.Misses++
+	c.updateHitRate()
+	c.mu.Unlock()
+
+	if len(data) == 0 {
+		return nil
+	}
+	if int64(len(data)) > stale.size {
+		data = data[:stale.size]
+	}
+	result := make([]byte, len(data))
+	copy(result, data)
+
+	var etag string
+	if info != nil {
+		etag = info.ETag
+	}
+	c.PutWithETag(key, offset, result, etag)
+	return result
+}
+
+// touchLocked records a hit on item and returns a copy of its data
+func (c *LRUCache) touchLocked(item *cacheItem) []byte {
+	item.accessTime = time.Now()
+	item.accessCount++
+	item.weight = c.calculateWeight(item)
+	c.evictList.MoveToFront(item.element)
+	c.stats.Hits++
+	c.updateHitRate()
+
+	result := make([]byte, len(item.data))
+	copy(result, item.data)
+	return result
+}
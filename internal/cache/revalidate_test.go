@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+func newExpiringCache(t *testing.T) *LRUCache {
+	t.Helper()
+	cache := NewLRUCache(&CacheConfig{
+		MaxSize:         1024 * 1024,
+		MaxEntries:      100,
+		TTL:             20 * time.Millisecond,
+		CleanupInterval: time.Hour,
+	})
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache
+}
+
+func TestLRUCache_RevalidateNotModified(t *testing.T) {
+	cache := newExpiringCache(t)
+
+	calls := 0
+	var gotSince time.Time
+	cache.SetRevalidator(func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+		calls++
+		gotSince = since
+		return nil, true, nil, nil
+	})
+
+	cache.Put("obj", 0, []byte("hello"))
+	time.Sleep(30 * time.Millisecond)
+
+	data := cache.Get("obj", 0, 5)
+	if string(data) != "hello" {
+		t.Fatalf("Expected cached data after 304, got %q", data)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 revalidation, got %d", calls)
+	}
+	if gotSince.IsZero() {
+		t.Error("Expected revalidation to pass the cached timestamp")
+	}
+
+	// TTL was extended, so an immediate read is a plain hit
+	if data := cache.Get("obj", 0, 5); string(data) != "hello" {
+		t.Errorf("Expected hit after TTL extension, got %q", data)
+	}
+	if calls != 1 {
+		t.Errorf("Expected no further revalidation within extended TTL, got %d calls", calls)
+	}
+
+	stats := cache.Stats()
+	if stats.RevalidatedNotModified != 1 {
+		t.Errorf("Expected 1 not-modified revalidation, got %d", stats.RevalidatedNotModified)
+	}
+	if stats.Hits != 2 {
+		t.Errorf("Expected 2 hits, got %d", stats.Hits)
+	}
+}
+
+func TestLRUCache_RevalidateModified(t *testing.T) {
+	cache := newExpiringCache(t)
+
+	var gotETag string
+	cache.SetRevalidator(func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+		gotETag = etag
+		return []byte("HELLO"), false, &types.ObjectInfo{Key: key, ETag: `"v2"`}, nil
+	})
+
+	cache.Put("obj", 0, []byte("hello"))
+	time.Sleep(30 * time.Millisecond)
+
+	data := cache.Get("obj", 0, 5)
+	if string(data) != "HELLO" {
+		t.Fatalf("Expected replaced range after 200, got %q", data)
+	}
+
+	stats := cache.Stats()
+	if stats.RevalidatedNotModified != 0 {
+		t.Errorf("Expected no not-modified revalidations, got %d", stats.RevalidatedNotModified)
+	}
+	if stats.Revalidations != 1 {
+		t.Errorf("Expected 1 revalidation, got %d", stats.Revalidations)
+	}
+
+	// Replacement is cached with the new ETag for the next revalidation
+	if data := cache.Get("obj", 0, 5); string(data) != "HELLO" {
+		t.Errorf("Expected replaced data to be cached, got %q", data)
+	}
+	time.Sleep(30 * time.Millisecond)
+	cache.Get("obj", 0, 5)
+	if gotETag != `"v2"` {
+		t.Errorf("Expected stored ETag to be sent on revalidation, got %q", gotETag)
+	}
+}
+
+func TestLRUCache_RevalidateError(t *testing.T) {
+	cache := newExpiringCache(t)
+	cache.SetRevalidator(func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+		return nil, false, nil, context.DeadlineExceeded
+	})
+
+	cache.Put("obj", 0, []byte("hello"))
+	time.Sleep(30 * time.Millisecond)
+
+	if data := cache.Get("obj", 0, 5); data != nil {
+		t.Errorf("Expected miss on revalidation error, got %q", data)
+	}
+	if cache.Size() != 0 {
+		t.Errorf("Expected stale entry to be dropped, size %d", cache.Size())
+	}
+}
+
+func TestLRUCache_RevalidateRequestsCachedRange(t *testing.T) {
+	cache := newExpiringCache(t)
+
+	var gotOffset, gotSize int64
+	var hasDeadline bool
+	cache.SetRevalidator(func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+		gotOffset, gotSize = offset, size
+		_, hasDeadline = ctx.Deadline()
+		return []byte("WORLD"), false, &types.ObjectInfo{Key: key, ETag: `"v2"`}, nil
+	})
+
+	cache.Put("obj", 6, []byte("world"))
+	time.Sleep(30 * time.Millisecond)
+
+	if data := cache.Get("obj", 6, 5); string(data) != "WORLD" {
+		t.Fatalf("Expected replaced range after 200, got %q", data)
+	}
+	if gotOffset != 6 || gotSize != 5 {
+		t.Errorf("Expected revalidation of range 6+5, got %d+%d", gotOffset, gotSize)
+	}
+	if !hasDeadline {
+		t.Error("Expected revalidation context to carry a deadline")
+	}
+}
+
+func TestLRUCache_RevalidateSendsETagFromFill(t *testing.T) {
+	namespaces, _ := NewCacheNamespaces(newExpiringCache(t))
+	mount, _ := namespaces.Namespace("bucket")
+
+	var gotETag string
+	mount.SetRevalidator(func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+		gotETag = etag
+		return nil, true, nil, nil
+	})
+
+	PutWithETag(mount, "obj", 0, []byte("hello"), `"v1"`)
+	time.Sleep(30 * time.Millisecond)
+
+	if data := mount.Get("obj", 0, 5); string(data) != "hello" {
+		t.Fatalf("Expected cached data after 304, got %q", data)
+	}
+	if gotETag != `"v1"` {
+		t.Errorf("Expected ETag recorded on fill to be sent, got %q", gotETag)
+	}
+}
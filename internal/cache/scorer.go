@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// LRUScorer evicts the objects idle the longest first
+type LRUScorer struct{}
+
+// Score returns the seconds since the object was last read; never-read
+// objects score highest
+func (LRUScorer) Score(entry types.CacheEntryInfo) float64 {
+	if entry.LastAccess.IsZero() {
+		return math.MaxFloat64
+	}
+	return time.Since(entry.LastAccess).Seconds()
+}
+
+// LFUScorer evicts the least frequently read objects first
+type LFUScorer struct{}
+
+// Score returns a value in (0, 1] that falls as the access count grows
+func (LFUScorer) Score(entry types.CacheEntryInfo) float64 {
+	return 1 / float64(1+entry.AccessCount)
+}
+
+// NewEvictionScorer returns the built-in scorer for algorithm: "lru" or
+// "lfu". It returns nil for "ml" and "", which rank candidates by the
+// access predictor's estimate of reuse.
+func NewEvictionScorer(algorithm string) (types.EvictionScorer, error) {
+	switch algorithm {
+	case "", "ml":
+		return nil, nil
+	case "lru":
+		return LRUScorer{}, nil
+	case "lfu":
+		return LFUScorer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown eviction algorithm: %s", algorithm)
+	}
+}
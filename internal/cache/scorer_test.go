@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestPredictiveCache_EvictionAlgorithmSelectsScorer(t *testing.T) {
+	base := NewLRUCache(&CacheConfig{MaxSize: 4 * 1024, MaxEntries: 100})
+	defer func() { _ = base.Close() }()
+	pc, err := NewPredictiveCache(&PredictiveCacheConfig{
+		BaseCache:                 base,
+		EnablePrediction:          true,
+		PredictionWindow:          100,
+		EnableIntelligentEviction: true,
+		EvictionAlgorithm:         "lfu",
+	})
+	if err != nil {
+		t.Fatalf("NewPredictiveCache() error = %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	// "hot" is read often but less recently than "cold"
+	block := make([]byte, 2048)
+	pc.Put("hot", 0, block)
+	for i := 0; i < 3; i++ {
+		pc.Get("hot", 0, 2048)
+	}
+	pc.Put("cold", 0, block)
+	pc.Get("cold", 0, 2048)
+	pc.Put("new", 0, block)
+
+	if base.Get("hot", 0, 2048) == nil {
+		t.Error("frequently read entry evicted under lfu")
+	}
+	if base.Get("cold", 0, 2048) != nil {
+		t.Error("rarely read entry retained under lfu")
+	}
+}
+
+func TestNewEvictionScorerRejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := NewEvictionScorer("arc"); err == nil {
+		t.Error("NewEvictionScorer(arc) succeeded, want an error")
+	}
+	if scorer, err := NewEvictionScorer("ml"); err != nil || scorer != nil {
+		t.Errorf("NewEvictionScorer(ml) = %v, %v; want the predictor's default ranking", scorer, err)
+	}
+}
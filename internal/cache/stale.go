@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"time"
+)
+
+// staleGetter is implemented by caches that can serve expired entries
+type staleGetter interface {
+	GetStale(key string, offset, size int64, maxStaleness time.Duration) []byte
+}
+
+// GetStale returns cached data for the range even if it has expired, as
+// long as it expired no more than maxStaleness ago (any age when
+// maxStaleness is 0). Unlike Get it never revalidates or evicts, so it can
+// serve reads while the backend is unreachable.
+func (c *LRUCache) GetStale(key string, offset, size int64, maxStaleness time.Duration) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.items[c.makeCacheKey(key, offset, size)]
+	if !exists {
+		return nil
+	}
+	if c.isExpired(item) {
+		ttl := item.ttl
+		if ttl == 0 {
+			ttl = c.config.TTL
+		}
+		if maxStaleness > 0 && time.Since(item.timestamp.Add(ttl)) > maxStaleness {
+			return nil
+		}
+		c.stats.StaleHits++
+	}
+	return c.touchLocked(item)
+}
+
+// GetStale returns possibly expired data for key in this namespace from a
+// shared cache that supports stale reads
+func (c *NamespacedCache) GetStale(key string, offset, size int64, maxStaleness time.Duration) []byte {
+	getter, ok := c.base.(staleGetter)
+	if !ok {
+		return nil
+	}
+	data := getter.GetStale(c.prefix+key, offset, size, maxStaleness)
+	if data != nil {
+		c.hits.Add(1)
+	}
+	return data
+}
+
+// GetStale returns possibly expired data from the first level that holds
+// the range and supports stale reads
+func (c *MultiLevelCache) GetStale(key string, offset, size int64, maxStaleness time.Duration) []byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, level := range c.levels {
+		getter, ok := level.Cache.(staleGetter)
+		if !level.Enabled || !ok {
+			continue
+		}
+		if data := getter.GetStale(key, offset, size, maxStaleness); data != nil {
+			c.recordHit(level.Name)
+			return data
+		}
+	}
+	return nil
+}
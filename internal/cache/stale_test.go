@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetStaleServesExpiredEntries(t *testing.T) {
+	lru := NewLRUCache(&CacheConfig{MaxSize: 1 << 20, MaxEntries: 100, TTL: 10 * time.Millisecond})
+	defer func() { _ = lru.Close() }()
+
+	lru.Put("k", 0, []byte("data"))
+	if data := lru.GetStale("k", 0, 4, 0); string(data) != "data" {
+		t.Fatalf("GetStale() of fresh entry = %q", data)
+	}
+	if hits := lru.Stats().StaleHits; hits != 0 {
+		t.Errorf("stale hits after fresh read = %d, want 0", hits)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if data := lru.GetStale("k", 0, 4, time.Minute); string(data) != "data" {
+		t.Errorf("GetStale() within staleness bound = %q, want %q", data, "data")
+	}
+	if data := lru.GetStale("k", 0, 4, 5*time.Millisecond); data != nil {
+		t.Errorf("GetStale() past staleness bound = %q, want nil", data)
+	}
+	if hits := lru.Stats().StaleHits; hits != 1 {
+		t.Errorf("stale hits = %d, want 1", hits)
+	}
+
+	// Stale reads leave the entry in place; Get still evicts it
+	if data := lru.Get("k", 0, 4); data != nil {
+		t.Errorf("Get() of expired entry = %q, want nil", data)
+	}
+}
+
+func TestBlockCacheGetStale(t *testing.T) {
+	base := NewLRUCache(&CacheConfig{MaxSize: 1 << 20, MaxEntries: 100, TTL: 10 * time.Millisecond})
+	defer func() { _ = base.Close() }()
+	blocks, err := NewBlockCache(base, &BlockCacheConfig{BlockSize: 4})
+	if err != nil {
+		t.Fatalf("NewBlockCache() error = %v", err)
+	}
+
+	blocks.Put("k", 0, []byte("abcdefgh"))
+	time.Sleep(30 * time.Millisecond)
+	if data := blocks.GetStale("k", 2, 4, 0); string(data) != "cdef" {
+		t.Errorf("GetStale() across blocks = %q, want %q", data, "cdef")
+	}
+}
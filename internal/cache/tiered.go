@@ -0,0 +1,534 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+const (
+	defaultPromoteAfter = 3
+	defaultDemoteAfter  = 10 * time.Minute
+)
+
+// L2Path is one storage path of a tiered L2 cache
+type L2Path struct {
+	Directory string `yaml:"directory"`
+	Size      int64  `yaml:"size"`
+}
+
+// TieredCacheConfig represents a persistent cache split across storage
+// paths of different speeds, such as local NVMe and bulk disk
+type TieredCacheConfig struct {
+	Paths       []L2Path      `yaml:"paths"` // Fastest first
+	TTL         time.Duration `yaml:"ttl"`
+	TTLJitter   float64       `yaml:"ttl_jitter"`
+	Compression bool          `yaml:"compression"`
+
+	// Reads on a path that move an entry to the next faster path (default 3)
+	PromoteAfter int `yaml:"promote_after"`
+
+	// Entries not read for this long move to the next slower path (default 10m)
+	DemoteAfter time.Duration `yaml:"demote_after"`
+
+	// How often idle entries are demoted (default half of DemoteAfter)
+	RebalanceInterval time.Duration `yaml:"rebalance_interval"`
+}
+
+// L2PathStats reports the use of one path of a tiered cache
+type L2PathStats struct {
+	Directory   string  `json:"directory"`
+	Size        int64   `json:"size"`
+	Capacity    int64   `json:"capacity"`
+	Utilization float64 `json:"utilization"`
+	Entries     int     `json:"entries"`
+	Hits        uint64  `json:"hits"`
+	HitRate     float64 `json:"hit_rate"`   // Share of all lookups served by this path
+	Promotions  uint64  `json:"promotions"` // Entries moved onto this path from a slower one
+	Demotions   uint64  `json:"demotions"`  // Entries moved off this path to a slower one
+}
+
+// TieredCache spreads a persistent cache over several paths ordered from
+// fastest to slowest. New entries are written to the slowest path, so data
+// read once never occupies the fast one. An entry read PromoteAfter times
+// moves one path faster, demoting the least recently read entries there if
+// it is full, and an entry not read for DemoteAfter moves one path slower.
+type TieredCache struct {
+	config *TieredCacheConfig
+	paths  []*tierPath
+
+	mu      sync.Mutex
+	entries map[string]*tieredEntry
+	misses  uint64
+	now     func() time.Time
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+}
+
+// tierPath is one path of a tiered cache; its counters are guarded by the
+// TieredCache lock
+type tierPath struct {
+	directory  string
+	cache      *PersistentCache
+	hits       uint64
+	promotions uint64
+	demotions  uint64
+}
+
+// tieredEntry tracks where one cached range lives and how often it is read
+type tieredEntry struct {
+	key        string
+	offset     int64
+	size       int64
+	path       int
+	reads      int // Reads since the entry last moved or went idle
+	lastAccess time.Time
+
+	// moving is set while the entry is copied to another path; rewritten
+	// records a Put during the copy, which makes the copy stale
+	moving    bool
+	rewritten bool
+}
+
+// NewTieredCache creates a tiered cache with one persistent cache per path
+func NewTieredCache(config *TieredCacheConfig) (*TieredCache, error) {
+	if config == nil || len(config.Paths) == 0 {
+		return nil, fmt.Errorf("tiered cache requires at least one path")
+	}
+	for i, path := range config.Paths {
+		if path.Directory == "" {
+			return nil, fmt.Errorf("tiered cache path %d has no directory", i)
+		}
+		if path.Size <= 0 {
+			return nil, fmt.Errorf("tiered cache path %s must have a positive size", path.Directory)
+		}
+	}
+	if config.PromoteAfter <= 0 {
+		config.PromoteAfter = defaultPromoteAfter
+	}
+	if config.DemoteAfter <= 0 {
+		config.DemoteAfter = defaultDemoteAfter
+	}
+	if config.RebalanceInterval <= 0 {
+		config.RebalanceInterval = config.DemoteAfter / 2
+	}
+
+	cache := &TieredCache{
+		config:  config,
+		entries: make(map[string]*tieredEntry),
+		now:     time.Now,
+		stopCh:  make(chan struct{}),
+	}
+	for _, path := range config.Paths {
+		persistent, err := NewPersistentCache(&PersistentCacheConfig{
+			Directory:   path.Directory,
+			MaxSize:     path.Size,
+			TTL:         config.TTL,
+			TTLJitter:   config.TTLJitter,
+			Compression: config.Compression,
+		})
+		if err != nil {
+			_ = cache.closePaths()
+			return nil, fmt.Errorf("failed to create cache path %s: %w", path.Directory, err)
+		}
+		cache.paths = append(cache.paths, &tierPath{directory: path.Directory, cache: persistent})
+	}
+	cache.loadEntries()
+
+	go cache.rebalanceLoop()
+
+	return cache, nil
+}
+
+// Get retrieves data from whichever path holds it, promoting entries that
+// are read often
+func (c *TieredCache) Get(key string, offset, size int64) []byte {
+	id := tieredEntryID(key, offset, size)
+
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	if !ok {
+		c.misses++
+		c.mu.Unlock()
+		return nil
+	}
+	path := entry.path
+	c.mu.Unlock()
+
+	data := c.paths[path].cache.Get(key, offset, size)
+
+	c.mu.Lock()
+	if data == nil {
+		// Expired or evicted by its path, unless it moved meanwhile
+		if !entry.moving && entry.path == path && c.entries[id] == entry {
+			delete(c.entries, id)
+		}
+		c.misses++
+		c.mu.Unlock()
+		return nil
+	}
+	c.paths[path].hits++
+	entry.reads++
+	entry.lastAccess = c.now()
+	promote := path > 0 && !entry.moving && entry.path == path &&
+		entry.reads >= c.config.PromoteAfter && int64(len(data)) <= c.paths[path-1].cache.maxSize
+	if promote {
+		entry.moving = true
+	}
+	c.mu.Unlock()
+
+	if promote {
+		c.makeRoom(path-1, int64(len(data)), entry)
+		c.move(id, entry, path, path-1, data)
+	}
+	return data
+}
+
+// Put stores data on the path already holding the range, or on the slowest
+// path for a new range
+func (c *TieredCache) Put(key string, offset int64, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	id := tieredEntryID(key, offset, int64(len(data)))
+
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	if !ok {
+		entry = &tieredEntry{
+			key:    key,
+			offset: offset,
+			size:   int64(len(data)),
+			path:   len(c.paths) - 1,
+		}
+		c.entries[id] = entry
+	}
+	entry.lastAccess = c.now()
+	if entry.moving {
+		entry.rewritten = true
+	}
+	path := entry.path
+	c.mu.Unlock()
+
+	c.paths[path].cache.Put(key, offset, data)
+}
+
+// Delete removes data from every path
+func (c *TieredCache) Delete(key string) {
+	c.mu.Lock()
+	for id := range c.entries {
+		if strings.HasPrefix(id, key) {
+			delete(c.entries, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, path := range c.paths {
+		path.cache.Delete(key)
+	}
+}
+
+// Evict frees size bytes, starting with the slowest path
+func (c *TieredCache) Evict(size int64) bool {
+	freed := int64(0)
+	for i := len(c.paths) - 1; i >= 0 && freed < size; i-- {
+		before := c.paths[i].cache.Size()
+		c.paths[i].cache.Evict(size - freed)
+		freed += before - c.paths[i].cache.Size()
+	}
+	return freed >= size
+}
+
+// Size returns the bytes cached across all paths
+func (c *TieredCache) Size() int64 {
+	total := int64(0)
+	for _, path := range c.paths {
+		total += path.cache.Size()
+	}
+	return total
+}
+
+// Stats returns statistics combined across all paths
+func (c *TieredCache) Stats() types.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	combined := types.CacheStats{Misses: c.misses}
+	for _, path := range c.paths {
+		pathStats := path.cache.Stats()
+		combined.Hits += path.hits
+		combined.Evictions += pathStats.Evictions
+		combined.Size += pathStats.Size
+		combined.Capacity += pathStats.Capacity
+		combined.PinnedBytes += pathStats.PinnedBytes
+
+		// Every path pins the same keys
+		combined.PinnedKeys = max(combined.PinnedKeys, pathStats.PinnedKeys)
+	}
+	if total := combined.Hits + combined.Misses; total > 0 {
+		combined.HitRate = float64(combined.Hits) / float64(total)
+	}
+	if combined.Capacity > 0 {
+		combined.Utilization = float64(combined.Size) / float64(combined.Capacity)
+	}
+	return combined
+}
+
+// PathStats returns utilization and hits of each path, fastest first
+func (c *TieredCache) PathStats() []L2PathStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]int, len(c.paths))
+	for _, entry := range c.entries {
+		entries[entry.path]++
+	}
+	lookups := c.misses
+	for _, path := range c.paths {
+		lookups += path.hits
+	}
+
+	stats := make([]L2PathStats, len(c.paths))
+	for i, path := range c.paths {
+		pathStats := path.cache.Stats()
+		stats[i] = L2PathStats{
+			Directory:   path.directory,
+			Size:        pathStats.Size,
+			Capacity:    pathStats.Capacity,
+			Utilization: pathStats.Utilization,
+			Entries:     entries[i],
+			Hits:        path.hits,
+			Promotions:  path.promotions,
+			Demotions:   path.demotions,
+		}
+		if lookups > 0 {
+			stats[i].HitRate = float64(path.hits) / float64(lookups)
+		}
+	}
+	return stats
+}
+
+// Pin excludes key from eviction on every path
+func (c *TieredCache) Pin(key string) {
+	for _, path := range c.paths {
+		path.cache.Pin(key)
+	}
+}
+
+// Unpin makes key evictable again on every path
+func (c *TieredCache) Unpin(key string) {
+	for _, path := range c.paths {
+		path.cache.Unpin(key)
+	}
+}
+
+// OnEvict registers fn for evictions from every path. Entries moved
+// between paths are not reported.
+func (c *TieredCache) OnEvict(fn types.EvictionCallback) {
+	for _, path := range c.paths {
+		path.cache.OnEvict(fn)
+	}
+}
+
+// Clear removes all cached data from every path
+func (c *TieredCache) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]*tieredEntry)
+	c.mu.Unlock()
+
+	for _, path := range c.paths {
+		path.cache.Clear()
+	}
+}
+
+// Optimize rebalances entries between paths and optimizes each path
+func (c *TieredCache) Optimize() {
+	c.Rebalance()
+	for _, path := range c.paths {
+		path.cache.Optimize()
+	}
+}
+
+// Close stops rebalancing and closes every path
+func (c *TieredCache) Close() error {
+	c.closeOnce.Do(func() { close(c.stopCh) })
+	return c.closePaths()
+}
+
+// Rebalance moves entries not read within DemoteAfter to the next slower
+// path, and forgets entries their path has expired or evicted
+func (c *TieredCache) Rebalance() {
+	type idleEntry struct {
+		id    string
+		entry *tieredEntry
+	}
+
+	cutoff := c.now().Add(-c.config.DemoteAfter)
+	var idle []idleEntry
+
+	c.mu.Lock()
+	for id, entry := range c.entries {
+		if entry.moving {
+			continue
+		}
+		if !c.paths[entry.path].cache.contains(entry.key, entry.offset, entry.size) {
+			delete(c.entries, id)
+			continue
+		}
+		if entry.lastAccess.Before(cutoff) {
+			entry.reads = 0
+			if entry.path < len(c.paths)-1 {
+				entry.moving = true
+				idle = append(idle, idleEntry{id: id, entry: entry})
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	for _, e := range idle {
+		c.demote(e.id, e.entry)
+	}
+}
+
+// makeRoom demotes the least recently read entries of a path, other than
+// except, until need more bytes fit on it
+func (c *TieredCache) makeRoom(path int, need int64, except *tieredEntry) {
+	target := c.paths[path].cache
+	for target.Size()+need > target.maxSize {
+		id, coldest := c.coldestEntry(path, except)
+		if coldest == nil || !c.demote(id, coldest) {
+			return
+		}
+	}
+}
+
+// coldestEntry claims the least recently read entry of a path for a move
+func (c *TieredCache) coldestEntry(path int, except *tieredEntry) (string, *tieredEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		coldestID string
+		coldest   *tieredEntry
+	)
+	for id, entry := range c.entries {
+		if entry.path != path || entry.moving || entry == except {
+			continue
+		}
+		if coldest == nil || entry.lastAccess.Before(coldest.lastAccess) {
+			coldestID, coldest = id, entry
+		}
+	}
+	if coldest != nil {
+		coldest.moving = true
+	}
+	return coldestID, coldest
+}
+
+// demote moves a claimed entry to the next slower path. It reports whether
+// the entry left its path, either moved or dropped because its data was gone.
+func (c *TieredCache) demote(id string, entry *tieredEntry) bool {
+	from := entry.path
+	data := c.paths[from].cache.Get(entry.key, entry.offset, entry.size)
+	if data == nil {
+		c.mu.Lock()
+		entry.moving = false
+		if c.entries[id] == entry {
+			delete(c.entries, id)
+		}
+		c.mu.Unlock()
+		return true
+	}
+	return c.move(id, entry, from, from+1, data)
+}
+
+// move copies a claimed entry's data from one path to another and removes
+// it from the first. A Put or Delete of the entry during the copy discards
+// the copy instead. It reports whether the entry moved.
+func (c *TieredCache) move(id string, entry *tieredEntry, from, to int, data []byte) bool {
+	c.paths[to].cache.Put(entry.key, entry.offset, data)
+	copied := c.paths[to].cache.contains(entry.key, entry.offset, entry.size)
+
+	c.mu.Lock()
+	stale := entry.rewritten || c.entries[id] != entry
+	entry.moving = false
+	entry.rewritten = false
+	if !copied || stale {
+		c.mu.Unlock()
+		if copied {
+			c.paths[to].cache.remove(entry.key, entry.offset, entry.size)
+		}
+		return false
+	}
+	entry.path = to
+	entry.reads = 0
+	if to < from {
+		c.paths[to].promotions++
+	} else {
+		c.paths[from].demotions++
+	}
+	c.mu.Unlock()
+
+	c.paths[from].cache.remove(entry.key, entry.offset, entry.size)
+	return true
+}
+
+// loadEntries rebuilds the entry index from the paths' persisted indexes.
+// A range found on several paths, left by an interrupted move, is kept on
+// the fastest.
+func (c *TieredCache) loadEntries() {
+	now := c.now()
+	for i, path := range c.paths {
+		path.cache.mu.RLock()
+		var duplicates []evictedEntry
+		for cacheKey := range path.cache.index {
+			r := evictedEntryOf(cacheKey)
+			id := tieredEntryID(r.key, r.offset, r.size)
+			if _, ok := c.entries[id]; ok {
+				duplicates = append(duplicates, r)
+				continue
+			}
+			c.entries[id] = &tieredEntry{key: r.key, offset: r.offset, size: r.size, path: i, lastAccess: now}
+		}
+		path.cache.mu.RUnlock()
+
+		for _, r := range duplicates {
+			path.cache.remove(r.key, r.offset, r.size)
+		}
+	}
+}
+
+// closePaths closes every path created so far
+func (c *TieredCache) closePaths() error {
+	var errs []error
+	for _, path := range c.paths {
+		if err := path.cache.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close cache path %s: %w", path.directory, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *TieredCache) rebalanceLoop() {
+	ticker := time.NewTicker(c.config.RebalanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.Rebalance()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// tieredEntryID identifies a cached range the way PersistentCache keys it
+func tieredEntryID(key string, offset, size int64) string {
+	return fmt.Sprintf("%s:%d:%d", key, offset, size)
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+// newTestTieredCache creates a fast and a slow path of the given sizes,
+// with a clock the test advances
+func newTestTieredCache(t *testing.T, fast, slow int64) (*TieredCache, *time.Time) {
+	t.Helper()
+	cache, err := NewTieredCache(&TieredCacheConfig{
+		Paths: []L2Path{
+			{Directory: t.TempDir(), Size: fast},
+			{Directory: t.TempDir(), Size: slow},
+		},
+		PromoteAfter:      3,
+		DemoteAfter:       time.Minute,
+		RebalanceInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewTieredCache failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+// entryPath returns the path index holding a range, or -1
+func entryPath(c *TieredCache, key string, offset, size int64) int {
+	for i, path := range c.paths {
+		if path.cache.contains(key, offset, size) {
+			return i
+		}
+	}
+	return -1
+}
+
+func readTimes(t *testing.T, c *TieredCache, key string, data []byte, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if got := c.Get(key, 0, int64(len(data))); !bytes.Equal(got, data) {
+			t.Fatalf("Get(%s) = %q, want %q", key, got, data)
+		}
+	}
+}
+
+func TestTieredCacheMovesEntriesByAccess(t *testing.T) {
+	cache, now := newTestTieredCache(t, 1<<20, 1<<20)
+	hot, cold := []byte("hot entry"), []byte("cold entry")
+
+	cache.Put("hot", 0, hot)
+	cache.Put("cold", 0, cold)
+	if p := entryPath(cache, "hot", 0, int64(len(hot))); p != 1 {
+		t.Fatalf("new entry on path %d, want the slow path", p)
+	}
+
+	// Both are read often and promoted to the fast path
+	readTimes(t, cache, "hot", hot, 3)
+	readTimes(t, cache, "cold", cold, 3)
+	if p := entryPath(cache, "cold", 0, int64(len(cold))); p != 0 {
+		t.Fatalf("frequently read entry on path %d, want the fast path", p)
+	}
+
+	// Only the hot entry stays in use; the idle one is demoted
+	*now = now.Add(2 * time.Minute)
+	readTimes(t, cache, "hot", hot, 1)
+	cache.Rebalance()
+
+	if p := entryPath(cache, "hot", 0, int64(len(hot))); p != 0 {
+		t.Errorf("hot entry on path %d, want the fast path", p)
+	}
+	if p := entryPath(cache, "cold", 0, int64(len(cold))); p != 1 {
+		t.Errorf("cold entry on path %d, want the slow path", p)
+	}
+	readTimes(t, cache, "cold", cold, 1)
+
+	stats := cache.PathStats()
+	if stats[0].Promotions != 2 || stats[0].Demotions != 1 {
+		t.Errorf("fast path promotions/demotions = %d/%d, want 2/1", stats[0].Promotions, stats[0].Demotions)
+	}
+	if stats[0].Entries != 1 || stats[1].Entries != 1 {
+		t.Errorf("entries per path = %d/%d, want 1/1", stats[0].Entries, stats[1].Entries)
+	}
+	if stats[0].Hits == 0 || stats[1].Hits == 0 || math.Abs(stats[0].HitRate+stats[1].HitRate-1) > 1e-9 {
+		t.Errorf("path hits = %+v, want hits on both paths covering every lookup", stats)
+	}
+}
+
+func TestTieredCachePromotionDemotesColdestWhenFull(t *testing.T) {
+	// The fast path holds one entry
+	cache, now := newTestTieredCache(t, 1500, 1<<20)
+	first, second := bytes.Repeat([]byte("a"), 1000), bytes.Repeat([]byte("b"), 1000)
+
+	cache.Put("first", 0, first)
+	cache.Put("second", 0, second)
+	readTimes(t, cache, "first", first, 3)
+
+	*now = now.Add(time.Second)
+	readTimes(t, cache, "second", second, 3)
+
+	if p := entryPath(cache, "second", 0, 1000); p != 0 {
+		t.Errorf("promoted entry on path %d, want the fast path", p)
+	}
+	if p := entryPath(cache, "first", 0, 1000); p != 1 {
+		t.Errorf("displaced entry on path %d, want the slow path", p)
+	}
+	readTimes(t, cache, "first", first, 1)
+}
+
+func TestMultiLevelCacheTieredL2(t *testing.T) {
+	cache, err := NewMultiLevelCache(&MultiLevelConfig{
+		L2Config: &L2Config{
+			Enabled: true,
+			Paths: []L2Path{
+				{Directory: t.TempDir(), Size: 1 << 20},
+				{Directory: t.TempDir(), Size: 1 << 20},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMultiLevelCache failed: %v", err)
+	}
+
+	cache.Put("key", 0, []byte("value"))
+	if got := cache.Get("key", 0, 5); string(got) != "value" {
+		t.Errorf("Get = %q, want value", got)
+	}
+	if stats := cache.L2PathStats(); len(stats) != 2 || stats[1].Entries != 1 {
+		t.Errorf("L2PathStats = %+v, want the entry on the slow path", stats)
+	}
+}
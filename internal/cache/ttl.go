@@ -0,0 +1,19 @@
+package cache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// jitteredTTL spreads ttl by up to ±jitter (a fraction of ttl) so entries
+// inserted together do not all expire at the same moment
+func jitteredTTL(ttl time.Duration, jitter float64) time.Duration {
+	if ttl <= 0 || jitter <= 0 {
+		return ttl
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	delta := float64(ttl) * jitter * (rand.Float64()*2 - 1)
+	return max(ttl+time.Duration(delta), time.Nanosecond)
+}
@@ -34,6 +34,18 @@ func (s State) String() string {
 	}
 }
 
+// TripMode selects how a closed circuit breaker decides to trip
+type TripMode string
+
+const (
+	// TripModeConsecutive trips after FailureThreshold failures in a row
+	TripModeConsecutive TripMode = "consecutive_failures"
+	// TripModeErrorRate trips when the failure ratio over a rolling Window
+	// reaches ErrorRateThreshold, catching partial outages where successes
+	// keep interrupting runs of failures
+	TripModeErrorRate TripMode = "error_rate"
+)
+
 // Config contains circuit breaker configuration
 type Config struct {
 	// Maximum number of requests allowed to pass through when state is half-open
@@ -45,9 +57,25 @@ type Config struct {
 	// Period of the open state after which the breaker enters half-open state
 	Timeout time.Duration `yaml:"timeout"`
 
-	// Function to determine if a request should be considered a failure
+	// Function to determine if a request should be considered a failure;
+	// ignored under TripModeErrorRate
 	ReadyToTrip func(counts Counts) bool `yaml:"-"`
 
+	// How the breaker trips when ReadyToTrip is not set; empty trips on
+	// the failure ratio of the current Interval
+	Mode TripMode `yaml:"mode"`
+
+	// Failures in a row that trip a TripModeConsecutive breaker (default 5)
+	FailureThreshold uint32 `yaml:"failure_threshold"`
+
+	// Failure ratio over Window that trips a TripModeErrorRate breaker
+	// once MinimumRequests were made in it (defaults 0.5 and 20)
+	ErrorRateThreshold float64 `yaml:"error_rate_threshold"`
+	MinimumRequests    uint32  `yaml:"minimum_requests"`
+
+	// Rolling window the error rate is measured over (default Interval)
+	Window time.Duration `yaml:"window"`
+
 	// Function called when state changes
 	OnStateChange func(name string, from State, to State) `yaml:"-"`
 
@@ -74,6 +102,7 @@ type CircuitBreaker struct {
 	state  State
 	counts Counts
 	expiry time.Time
+	window *rollingWindow
 }
 
 // NewCircuitBreaker creates a new circuit breaker instance
@@ -87,8 +116,26 @@ func NewCircuitBreaker(name string, config Config) *CircuitBreaker {
 	if config.Timeout <= 0 {
 		config.Timeout = 60 * time.Second
 	}
+	if config.FailureThreshold == 0 {
+		config.FailureThreshold = 5
+	}
+	if config.ErrorRateThreshold <= 0 {
+		config.ErrorRateThreshold = 0.5
+	}
+	if config.MinimumRequests == 0 {
+		config.MinimumRequests = 20
+	}
+	if config.Window <= 0 {
+		config.Window = config.Interval
+	}
 	if config.ReadyToTrip == nil {
 		config.ReadyToTrip = defaultReadyToTrip
+		if config.Mode == TripModeConsecutive {
+			threshold := config.FailureThreshold
+			config.ReadyToTrip = func(counts Counts) bool {
+				return counts.ConsecutiveFailures >= threshold
+			}
+		}
 	}
 	if config.IsSuccessful == nil {
 		config.IsSuccessful = defaultIsSuccessful
@@ -100,6 +147,7 @@ func NewCircuitBreaker(name string, config Config) *CircuitBreaker {
 		state:  StateClosed,
 		counts: Counts{},
 		expiry: time.Now().Add(config.Interval),
+		window: newRollingWindow(config.Window),
 	}
 }
 
@@ -176,8 +224,10 @@ func (cb *CircuitBreaker) afterRequest(err error) {
 	state, _ := cb.currentState(now)
 
 	if cb.config.IsSuccessful(err) {
+		cb.window.record(now, false)
 		cb.onSuccess(state, now)
 	} else {
+		cb.window.record(now, true)
 		cb.onFailure(state, now)
 	}
 }
@@ -197,7 +247,7 @@ func (cb *CircuitBreaker) onFailure(state State, now time.Time) {
 
 	switch state {
 	case StateClosed:
-		if cb.config.ReadyToTrip(cb.counts) {
+		if cb.shouldTrip(now) {
 			cb.setState(StateOpen, now)
 		}
 	case StateHalfOpen:
@@ -205,6 +255,15 @@ func (cb *CircuitBreaker) onFailure(state State, now time.Time) {
 	}
 }
 
+// shouldTrip reports whether the closed breaker should open after a failure
+func (cb *CircuitBreaker) shouldTrip(now time.Time) bool {
+	if cb.config.Mode == TripModeErrorRate {
+		rate, requests := cb.window.rate(now)
+		return requests >= cb.config.MinimumRequests && rate >= cb.config.ErrorRateThreshold
+	}
+	return cb.config.ReadyToTrip(cb.counts)
+}
+
 // currentState returns the current state of the circuit breaker
 func (cb *CircuitBreaker) currentState(now time.Time) (State, time.Time) {
 	switch cb.state {
@@ -231,6 +290,7 @@ func (cb *CircuitBreaker) setState(state State, now time.Time) {
 
 	cb.state = state
 	cb.counts.clear()
+	cb.window.clear()
 
 	switch state {
 	case StateClosed:
@@ -263,12 +323,27 @@ func (cb *CircuitBreaker) GetCounts() Counts {
 	return cb.counts
 }
 
+// ErrorRate returns the failure ratio over the rolling window and the
+// number of requests it covers. The window restarts on every state change.
+func (cb *CircuitBreaker) ErrorRate() (float64, uint32) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.window.rate(time.Now())
+}
+
+// Mode returns how the breaker trips
+func (cb *CircuitBreaker) Mode() TripMode {
+	return cb.config.Mode
+}
+
 // Reset resets the circuit breaker to its initial state
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
 	cb.counts.clear()
+	cb.window.clear()
 	cb.setState(StateClosed, time.Now())
 }
 
@@ -320,6 +395,7 @@ type Manager struct {
 	mu       sync.RWMutex
 	breakers map[string]*CircuitBreaker
 	config   Config
+	configs  map[string]Config // Per-breaker configs overriding config
 }
 
 // NewManager creates a new circuit breaker manager
@@ -327,6 +403,20 @@ func NewManager(config Config) *Manager {
 	return &Manager{
 		breakers: make(map[string]*CircuitBreaker),
 		config:   config,
+		configs:  make(map[string]Config),
+	}
+}
+
+// Configure sets the config of the named breaker in place of the
+// manager's, such as to select its trip mode. An existing breaker of that
+// name is replaced, starting closed.
+func (m *Manager) Configure(name string, config Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configs[name] = config
+	if _, exists := m.breakers[name]; exists {
+		m.breakers[name] = NewCircuitBreaker(name, config)
 	}
 }
 
@@ -347,7 +437,11 @@ func (m *Manager) GetBreaker(name string) *CircuitBreaker {
 		return breaker
 	}
 
-	breaker := NewCircuitBreaker(name, m.config)
+	config, ok := m.configs[name]
+	if !ok {
+		config = m.config
+	}
+	breaker := NewCircuitBreaker(name, config)
 	m.breakers[name] = breaker
 	return breaker
 }
@@ -397,10 +491,14 @@ func (m *Manager) GetStats() map[string]CircuitBreakerStats {
 
 	stats := make(map[string]CircuitBreakerStats)
 	for name, breaker := range breakers {
+		errorRate, windowRequests := breaker.ErrorRate()
 		stats[name] = CircuitBreakerStats{
-			Name:   name,
-			State:  breaker.GetState(),
-			Counts: breaker.GetCounts(),
+			Name:           name,
+			State:          breaker.GetState(),
+			Mode:           breaker.Mode(),
+			Counts:         breaker.GetCounts(),
+			ErrorRate:      errorRate,
+			WindowRequests: windowRequests,
 		}
 	}
 	return stats
@@ -408,9 +506,12 @@ func (m *Manager) GetStats() map[string]CircuitBreakerStats {
 
 // CircuitBreakerStats represents statistics for a single circuit breaker
 type CircuitBreakerStats struct {
-	Name   string `json:"name"`
-	State  State  `json:"state"`
-	Counts Counts `json:"counts"`
+	Name           string   `json:"name"`
+	State          State    `json:"state"`
+	Mode           TripMode `json:"mode,omitempty"`
+	Counts         Counts   `json:"counts"`
+	ErrorRate      float64  `json:"error_rate"`      // Failure ratio over the rolling window
+	WindowRequests uint32   `json:"window_requests"` // Requests in the rolling window
 }
 
 // HealthCheck performs a health check on all circuit breakers
@@ -731,3 +731,59 @@ func TestManager_ConcurrentAccess(t *testing.T) {
 		t.Errorf("concurrent access created %d breakers, want 1", len(all))
 	}
 }
+
+func TestCircuitBreaker_ErrorRateTripsOnPartialOutage(t *testing.T) {
+	t.Parallel()
+
+	// Every other request fails: a partial outage with no run of failures
+	run := func(cb *CircuitBreaker, requests int) {
+		for i := 0; i < requests; i++ {
+			_ = cb.Execute(func() error {
+				if i%2 == 1 {
+					return errors.New("service unavailable")
+				}
+				return nil
+			})
+		}
+	}
+
+	consecutive := NewCircuitBreaker("consecutive", Config{Mode: TripModeConsecutive, FailureThreshold: 3, Window: time.Minute})
+	run(consecutive, 100)
+	if state := consecutive.GetState(); state != StateClosed {
+		t.Errorf("consecutive-failure breaker state = %v under a 50%% error rate, want closed", state)
+	}
+	if rate, requests := consecutive.ErrorRate(); rate != 0.5 || requests != 100 {
+		t.Errorf("ErrorRate() = %v over %d requests, want 0.5 over 100", rate, requests)
+	}
+
+	rateBreaker := NewCircuitBreaker("rate", Config{Mode: TripModeErrorRate, ErrorRateThreshold: 0.4, MinimumRequests: 20, Window: time.Minute})
+	run(rateBreaker, 19)
+	if state := rateBreaker.GetState(); state != StateClosed {
+		t.Fatalf("error-rate breaker tripped below the minimum request volume, state = %v", state)
+	}
+	run(rateBreaker, 21)
+	if state := rateBreaker.GetState(); state != StateOpen {
+		t.Errorf("error-rate breaker state = %v under a 50%% error rate, want open", state)
+	}
+}
+
+func TestManager_ConfigurePerBreaker(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Config{})
+	existing := manager.GetBreaker("s3-get")
+	manager.Configure("s3-get", Config{Mode: TripModeErrorRate})
+
+	cb := manager.GetBreaker("s3-get")
+	if cb == existing || cb.Mode() != TripModeErrorRate {
+		t.Fatalf("configured breaker mode = %q, want a new %q breaker", cb.Mode(), TripModeErrorRate)
+	}
+	if other := manager.GetBreaker("s3-put"); other.Mode() != "" {
+		t.Errorf("unconfigured breaker mode = %q, want the manager default", other.Mode())
+	}
+
+	_ = cb.Execute(func() error { return errors.New("fail") })
+	if stats := manager.GetStats()["s3-get"]; stats.ErrorRate != 1 || stats.WindowRequests != 1 || stats.Mode != TripModeErrorRate {
+		t.Errorf("GetStats() = %+v", stats)
+	}
+}
@@ -0,0 +1,65 @@
+package circuit
+
+import "time"
+
+// windowBuckets is how many buckets a rolling window is split into; the
+// oldest bucket expires as a whole
+const windowBuckets = 10
+
+// windowBucket counts the outcomes of requests finished in one slice of a
+// rolling window
+type windowBucket struct {
+	start    time.Time
+	requests uint32
+	failures uint32
+}
+
+// rollingWindow counts requests and failures over the most recent window
+type rollingWindow struct {
+	width   time.Duration // Of one bucket
+	buckets [windowBuckets]windowBucket
+}
+
+// newRollingWindow creates a rolling window covering window
+func newRollingWindow(window time.Duration) *rollingWindow {
+	width := window / windowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &rollingWindow{width: width}
+}
+
+// record counts a request finished at now
+func (w *rollingWindow) record(now time.Time, failed bool) {
+	start := now.Truncate(w.width)
+	b := &w.buckets[(start.UnixNano()/int64(w.width))%windowBuckets]
+	if !b.start.Equal(start) {
+		*b = windowBucket{start: start}
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+}
+
+// rate returns the failure ratio of requests in the window ending at now,
+// and how many requests that is
+func (w *rollingWindow) rate(now time.Time) (float64, uint32) {
+	cutoff := now.Truncate(w.width).Add(-w.width * (windowBuckets - 1))
+	var requests, failures uint32
+	for _, b := range w.buckets {
+		if !b.start.Before(cutoff) {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	if requests == 0 {
+		return 0, 0
+	}
+	return float64(failures) / float64(requests), requests
+}
+
+// clear forgets every counted request
+func (w *rollingWindow) clear() {
+	w.buckets = [windowBuckets]windowBucket{}
+}
@@ -17,6 +17,13 @@ const (
 	TrueValue = "true"
 )
 
+// Behaviors when the mount point directory is not empty
+const (
+	NonEmptyMountFail        = "fail"         // Refuse to mount, listing the existing entries
+	NonEmptyMountForce       = "force"        // Mount over the existing entries
+	NonEmptyMountUseNonEmpty = "use-nonempty" // Mount with the FUSE nonempty option
+)
+
 // Configuration represents the complete application configuration
 type Configuration struct {
 	Global      GlobalConfig      `yaml:"global"`
@@ -38,33 +45,169 @@ type GlobalConfig struct {
 	MetricsPort int    `yaml:"metrics_port"`
 	HealthPort  int    `yaml:"health_port"`
 	ProfilePort int    `yaml:"profile_port"`
+
+	// OnNonEmptyMount selects what happens when the mount point is not
+	// empty: "fail" (default), "force", or "use-nonempty"
+	OnNonEmptyMount string `yaml:"on_nonempty_mount"`
+
+	// CaseInsensitive matches paths regardless of case, as macOS clients
+	// expect, and refuses to create a path differing from an existing one
+	// only in case. Directories are listed to index their keys by case, so
+	// lookup misses cost a LIST request.
+	CaseInsensitive bool `yaml:"case_insensitive"`
+
+	// AutoRemount mounts the filesystem again when its FUSE connection is
+	// lost
+	AutoRemount AutoRemountConfig `yaml:"auto_remount"`
+
+	// KernelCache tunes how the kernel caches and queues requests for the
+	// mount
+	KernelCache KernelCacheConfig `yaml:"kernel_cache"`
+}
+
+// KernelCacheConfig controls the FUSE writeback cache and the kernel's
+// request queue on Linux. The writeback cache lets the kernel coalesce
+// buffered writes before they reach ObjectFS, but cached pages and file
+// sizes then go stale when another node writes the same files, so keep it
+// off for mounts shared by writers. Unset queue limits use the platform
+// defaults.
+type KernelCacheConfig struct {
+	WritebackCache      bool `yaml:"writeback_cache"`
+	MaxBackground       int  `yaml:"max_background"`       // Asynchronous requests the kernel queues
+	CongestionThreshold int  `yaml:"congestion_threshold"` // Queued requests past which writers are throttled
+}
+
+// AutoRemountConfig controls recovering a lost mount. Unset values use the
+// filesystem defaults.
+type AutoRemountConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	CheckInterval time.Duration `yaml:"check_interval"` // How often the mount is checked
+	MaxAttempts   int           `yaml:"max_attempts"`   // Remounts tried before giving up
+	Backoff       time.Duration `yaml:"backoff"`        // Delay before the first attempt, doubling per attempt
+	MaxBackoff    time.Duration `yaml:"max_backoff"`    // Longest delay between attempts
 }
 
 // PerformanceConfig represents performance-related settings
 type PerformanceConfig struct {
-	CacheSize          string          `yaml:"cache_size"`
-	WriteBufferSize    string          `yaml:"write_buffer_size"`
-	MaxConcurrency     int             `yaml:"max_concurrency"`
-	ReadAheadSize      string          `yaml:"read_ahead_size"`
-	CompressionEnabled bool            `yaml:"compression_enabled"`
-	ConnectionPoolSize int             `yaml:"connection_pool_size"`
-	PredictiveCaching  bool            `yaml:"predictive_caching"`
-	MLModelPath        string          `yaml:"ml_model_path"`
-	MultilevelCaching  bool            `yaml:"multilevel_caching"`
-	ReadAhead          ReadAheadConfig `yaml:"read_ahead"` // Advanced read-ahead configuration
+	CacheSize           string          `yaml:"cache_size"`
+	WriteBufferSize     string          `yaml:"write_buffer_size"`
+	MaxConcurrency      int             `yaml:"max_concurrency"`
+	MaxReadConcurrency  int             `yaml:"max_read_concurrency"`  // Sub-limit for reads; 0 allows up to max_concurrency
+	MaxWriteConcurrency int             `yaml:"max_write_concurrency"` // Sub-limit for writes; 0 allows up to max_concurrency
+	ReadAheadSize       string          `yaml:"read_ahead_size"`
+	CompressionEnabled  bool            `yaml:"compression_enabled"`
+	ConnectionPoolSize  int             `yaml:"connection_pool_size"`
+	PredictiveCaching   bool            `yaml:"predictive_caching"`
+	MLModelPath         string          `yaml:"ml_model_path"`
+	MultilevelCaching   bool            `yaml:"multilevel_caching"`
+	ReadAhead           ReadAheadConfig `yaml:"read_ahead"` // Advanced read-ahead configuration
+	RateLimit           RateLimitConfig `yaml:"rate_limit"` // Steady request and byte rate cap on backend calls
+}
+
+// RateLimitConfig caps the steady rate of backend calls and bytes, overall
+// and per operation class. Zero rates are unlimited.
+type RateLimitConfig struct {
+	Enabled           bool           `yaml:"enabled"`
+	RequestsPerSecond float64        `yaml:"requests_per_second"`
+	BytesPerSecond    string         `yaml:"bytes_per_second"` // e.g., "100MB"
+	Mode              string         `yaml:"mode"`             // "queue" (default) delays excess calls, "reject" fails them
+	MaxWait           time.Duration  `yaml:"max_wait"`         // Longest a queued call waits before it is rejected
+	Read              RateClassLimit `yaml:"read"`
+	Write             RateClassLimit `yaml:"write"`
+	List              RateClassLimit `yaml:"list"`
+}
+
+// RateClassLimit caps the rate of one class of backend calls
+type RateClassLimit struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	BytesPerSecond    string  `yaml:"bytes_per_second"`
 }
 
 // CacheConfig represents cache configuration
 type CacheConfig struct {
 	TTL             time.Duration         `yaml:"ttl"`
+	TTLJitter       float64               `yaml:"ttl_jitter"` // Randomize each entry's TTL by up to ±this fraction (e.g., 0.1)
 	MaxEntries      int                   `yaml:"max_entries"`
 	EvictionPolicy  string                `yaml:"eviction_policy"`
+	BlockSize       string                `yaml:"block_size"` // Align cached reads to this size (e.g., "1MB"); empty disables
+	Namespace       string                `yaml:"namespace"`  // Cache key namespace isolating this mount; defaults to the bucket name
 	PersistentCache PersistentCacheConfig `yaml:"persistent_cache"`
+
+	// Check each cache hit against the object's current size and ETag,
+	// refetching ranges of objects that changed underneath the cache. Costs
+	// one HEAD request per hit, so it is off by default.
+	VerifyReads bool `yaml:"verify_reads"`
+
+	// A path with no object but objects under it (a directory prefix) is
+	// reported as a directory, found with a one-key LIST when HEAD misses
+	// and remembered for implicit_dir_ttl (default 1m).
+	// disable_implicit_dirs skips the LIST, so such paths are not found.
+	DisableImplicitDirs bool          `yaml:"disable_implicit_dirs"`
+	ImplicitDirTTL      time.Duration `yaml:"implicit_dir_ttl"`
+
+	// Ranks cached objects for eviction: "ml" (default), "lru", "lfu" or
+	// "cost_aware", which keeps objects that are expensive to refetch longer
+	EvictionScorer string `yaml:"eviction_scorer"`
+
+	// Objects read within correlation_window of each other (default 5s),
+	// such as a data file and its index, are learned as companions, so
+	// reading one prefetches the others; a negative window disables this
+	CorrelationWindow time.Duration `yaml:"correlation_window"`
+
+	// Prefetches running at once hold at most max_inflight_prefetch bytes
+	// (e.g., 256MB); further prefetches wait for them. Empty is unlimited.
+	MaxInflightPrefetch string `yaml:"max_inflight_prefetch"`
+
+	// Files read once from start to end bypass the cache so that large
+	// scans do not evict hot data: files of at least streaming_min_size
+	// (empty disables the size check) and files under streaming_prefixes.
+	// Their bytes are reported as bypassed in the mount statistics.
+	StreamingMinSize  string   `yaml:"streaming_min_size"`
+	StreamingPrefixes []string `yaml:"streaming_prefixes"`
+
+	// What listing a directory loads ahead for its files, on backends that
+	// fetch metadata in batches
+	ListingPrefetch ListingPrefetchConfig `yaml:"listing_prefetch"`
+}
+
+// ListingPrefetchConfig selects what a directory listing loads for the
+// files it lists, so tools that list and then stat or read them find them
+// loaded. "metadata" (default) fetches their attributes, "data" also loads
+// files of at most max_data_size into the cache in the background, and
+// "off" loads nothing.
+type ListingPrefetchConfig struct {
+	Mode         string `yaml:"mode"`
+	MaxDataSize  string `yaml:"max_data_size"`  // e.g., "64KB" (default)
+	MaxDataFiles int    `yaml:"max_data_files"` // Files loaded per batch of listed files (default 32)
+	Concurrency  int    `yaml:"concurrency"`    // Loads in flight (default 4)
 }
 
 // PersistentCacheConfig represents persistent cache settings
 type PersistentCacheConfig struct {
 	Enabled   bool   `yaml:"enabled"`
+	Directory string `yaml:"directory"`
+	MaxSize   string `yaml:"max_size"` // Size, or "auto" to size from free disk space per auto_size
+
+	AutoSize PersistentCacheAutoSize `yaml:"auto_size"`
+
+	// Paths, fastest first (e.g. NVMe, then bulk disk), replace Directory
+	// and MaxSize; frequently read entries move to faster paths and idle
+	// ones to slower paths
+	Paths        []PersistentCachePath `yaml:"paths"`
+	PromoteAfter int                   `yaml:"promote_after"` // Reads that move an entry to a faster path (default 3)
+	DemoteAfter  time.Duration         `yaml:"demote_after"`  // Idle time that moves an entry to a slower path (default 10m)
+}
+
+// PersistentCacheAutoSize sizes the persistent cache from the free space of
+// its directory's filesystem when max_size is "auto"
+type PersistentCacheAutoSize struct {
+	Fraction      float64       `yaml:"fraction"`       // Share of the space the cache could use, free plus its own files (default 0.5)
+	Reserve       string        `yaml:"reserve"`        // Free space always left, evicting to keep it (default "1GB")
+	CheckInterval time.Duration `yaml:"check_interval"` // How often free space is rechecked (default 1m)
+}
+
+// PersistentCachePath is one storage path of a tiered persistent cache
+type PersistentCachePath struct {
 	Directory string `yaml:"directory"`
 	MaxSize   string `yaml:"max_size"`
 }
@@ -89,6 +232,11 @@ type ReadAheadConfig struct {
 	PrefetchBandwidthMBs int     `yaml:"prefetch_bandwidth_mbs"` // Max prefetch bandwidth (MB/s)
 	ConfidenceThreshold  float64 `yaml:"confidence_threshold"`   // Min confidence to trigger prefetch (0-1)
 
+	// Open prefetch: opening a file for reading fetches its first
+	// read_ahead_size bytes into the cache, within the prefetch caps above
+	OpenPrefetch        bool   `yaml:"open_prefetch"`          // Prefetch small files when opened
+	OpenPrefetchMaxSize string `yaml:"open_prefetch_max_size"` // Larger files are not prefetched (default 8MB)
+
 	// ML-based prediction settings (advanced)
 	EnableMLPrediction bool    `yaml:"enable_ml_prediction"` // Use ML for access prediction
 	MLModelPath        string  `yaml:"ml_model_path"`        // Path to trained ML model
@@ -107,14 +255,47 @@ type WriteBufferConfig struct {
 	MaxBuffers    int               `yaml:"max_buffers"`
 	MaxMemory     string            `yaml:"max_memory"`
 	Compression   CompressionConfig `yaml:"compression"`
+	Coalesce      CoalesceConfig    `yaml:"coalesce"`
+
+	// Fractions of max_memory: buffered writes reaching high_watermark are
+	// flushed, largest first, until usage falls to low_watermark. Zero
+	// selects the defaults of 0.9 and 0.7.
+	HighWatermark float64 `yaml:"high_watermark"`
+	LowWatermark  float64 `yaml:"low_watermark"`
+
+	// Spill stages buffered writes on disk under memory pressure
+	Spill WriteSpillConfig `yaml:"spill"`
+
+	// Closing a file waits until its written data is stored and reports
+	// upload failures to close. Off by default: close only schedules the
+	// upload, so durability requires an explicit fsync.
+	SyncOnClose bool `yaml:"sync_on_close"`
+}
+
+// WriteSpillConfig moves buffered writes to disk once in-memory buffered
+// bytes reach a watermark, uploading them from there. Staged files are
+// removed once uploaded.
+type WriteSpillConfig struct {
+	Enabled   bool    `yaml:"enabled"`
+	Directory string  `yaml:"directory"` // Staging directory (default write-spill under the persistent cache directory)
+	Watermark float64 `yaml:"watermark"` // Fraction of max_memory that starts spilling (default high_watermark)
+}
+
+// CoalesceConfig controls per-handle coalescing of small sequential writes
+type CoalesceConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Window       time.Duration `yaml:"window"`         // Max time a write waits before flushing
+	MaxSize      string        `yaml:"max_size"`       // Pending bytes that force a flush
+	MaxWriteSize string        `yaml:"max_write_size"` // Larger writes bypass coalescing
 }
 
 // CompressionConfig represents compression settings
 type CompressionConfig struct {
-	Enabled   bool   `yaml:"enabled"`
-	MinSize   string `yaml:"min_size"`
-	Algorithm string `yaml:"algorithm"`
-	Level     int    `yaml:"level"`
+	Enabled        bool     `yaml:"enabled"`
+	MinSize        string   `yaml:"min_size"`
+	Algorithm      string   `yaml:"algorithm"` // gzip or zlib
+	Level          int      `yaml:"level"`
+	SkipExtensions []string `yaml:"skip_extensions"` // Added to the built-in list of compressed formats
 }
 
 // NetworkConfig represents network configuration
@@ -122,6 +303,15 @@ type NetworkConfig struct {
 	Timeouts       TimeoutConfig        `yaml:"timeouts"`
 	Retry          RetryConfig          `yaml:"retry"`
 	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	Degradation    DegradationConfig    `yaml:"degradation"`
+}
+
+// DegradationConfig selects filesystem behavior while the backend is
+// reported unavailable
+type DegradationConfig struct {
+	OnReadUnavailable  string        `yaml:"on_read_unavailable"`  // "eio" (default) or "serve-stale"
+	OnWriteUnavailable string        `yaml:"on_write_unavailable"` // "queue" (default) or "erofs"
+	MaxStaleness       time.Duration `yaml:"max_staleness"`        // How long past expiry cached data may be served; 0 = any age
 }
 
 // TimeoutConfig represents timeout settings
@@ -143,6 +333,15 @@ type CircuitBreakerConfig struct {
 	Enabled          bool          `yaml:"enabled"`
 	FailureThreshold int           `yaml:"failure_threshold"`
 	Timeout          time.Duration `yaml:"timeout"`
+
+	// How the S3 breakers trip: "consecutive_failures" after
+	// failure_threshold failures in a row, or "error_rate" once the failure
+	// ratio over window reaches error_rate_threshold with at least
+	// minimum_requests made. Empty keeps the default ratio over each minute.
+	Mode               string        `yaml:"mode"`
+	ErrorRateThreshold float64       `yaml:"error_rate_threshold"` // Default 0.5
+	MinimumRequests    int           `yaml:"minimum_requests"`     // Default 20
+	Window             time.Duration `yaml:"window"`               // Default 1m
 }
 
 // SecurityConfig represents security settings
@@ -178,6 +377,25 @@ type MonitoringConfig struct {
 	Metrics         MetricsConfig       `yaml:"metrics"`
 	HealthChecks    HealthChecksConfig  `yaml:"health_checks"`
 	Logging         LoggingConfig       `yaml:"logging"`
+	RecentOps       RecentOpsConfig     `yaml:"recent_ops"`
+	Usage           UsageConfig         `yaml:"usage"`
+}
+
+// UsageConfig controls the lifetime byte and request totals of a mount,
+// served as JSON at /debug/usage. Totals are kept in state_file across
+// restarts; without one they start from zero on every mount.
+type UsageConfig struct {
+	StateFile    string        `yaml:"state_file"`
+	SaveInterval time.Duration `yaml:"save_interval"` // How often totals are saved (default 1m)
+	PrefixDepth  int           `yaml:"prefix_depth"`  // Key path segments grouped in the per-prefix breakdown (default 1)
+}
+
+// RecentOpsConfig keeps the latest backend operations in memory, served as
+// JSON at /debug/recent-ops for postmortem inspection
+type RecentOpsConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Size     int  `yaml:"size"`      // Operations kept, oldest dropped first (default 256)
+	HashKeys bool `yaml:"hash_keys"` // Record a hash of each key instead of the key
 }
 
 // MetricsConfig represents metrics settings
@@ -185,6 +403,8 @@ type MetricsConfig struct {
 	Enabled      bool              `yaml:"enabled"`
 	Prometheus   bool              `yaml:"prometheus"`
 	CustomLabels map[string]string `yaml:"custom_labels"`
+	Subsystem    string            `yaml:"subsystem"` // Inserted into metric names: objectfs_<subsystem>_*
+	MountID      string            `yaml:"mount_id"`  // Constant "mount" label; defaults to the cache namespace when metrics are shared
 }
 
 // HealthChecksConfig represents health check settings
@@ -194,11 +414,14 @@ type HealthChecksConfig struct {
 	Timeout  time.Duration `yaml:"timeout"`
 }
 
-// LoggingConfig represents logging settings
+// LoggingConfig represents logging settings. Structured logs are written
+// at global.log_level to global.log_file (stderr when empty).
 type LoggingConfig struct {
-	Structured bool           `yaml:"structured"`
-	Format     string         `yaml:"format"`
-	Sampling   SamplingConfig `yaml:"sampling"`
+	Structured bool              `yaml:"structured"`
+	Format     string            `yaml:"format"`      // "json" (default) or "text"
+	Fields     map[string]string `yaml:"fields"`      // Added to every record, e.g. host or cluster
+	RedactKeys []string          `yaml:"redact_keys"` // Field name substrings to redact besides the built-in credential names
+	Sampling   SamplingConfig    `yaml:"sampling"`
 }
 
 // OpenTelemetryConfig represents OpenTelemetry settings
@@ -226,6 +449,73 @@ type FeatureConfig struct {
 // StorageConfig represents storage backend configuration
 type StorageConfig struct {
 	S3 S3Config `yaml:"s3"`
+
+	// Fallback storage URIs consulted in order when a key is missing from
+	// the primary, for overlay/union mounts; writes always go to the primary
+	Fallback []string `yaml:"fallback"`
+
+	// Second bucket that also receives writes and deletes, for migrating
+	// without downtime; reads are served by the primary
+	Mirror MirrorConfig `yaml:"mirror"`
+
+	// Largest object a write may create (e.g., "100GB"); empty is unlimited.
+	// Per-prefix limits override it for matching keys.
+	MaxObjectSize         string            `yaml:"max_object_size"`
+	MaxObjectSizeByPrefix map[string]string `yaml:"max_object_size_by_prefix"`
+
+	// How directory placeholders are told apart from zero-byte files
+	DirectoryMarker DirectoryMarkerConfig `yaml:"directory_marker"`
+
+	// Keep this mount's writes to a shared dataset under its own prefix
+	CopyOnWrite CopyOnWriteConfig `yaml:"copy_on_write"`
+
+	// Delete objects after a time to live without bucket lifecycle rules
+	Expiry ExpiryConfig `yaml:"expiry"`
+}
+
+// ExpiryConfig gives objects an application-layer time to live. Objects get
+// an expiry from "expires" metadata written with them or from the rule for
+// their prefix; once expired they are hidden, and a reaper deletes them
+// every Interval unless the storage tier's deletion embargo has not passed.
+type ExpiryConfig struct {
+	Enabled  bool               `yaml:"enabled"`
+	Prefix   string             `yaml:"prefix"`   // Where expiry records are kept; default ".objectfs-expiry/"
+	Interval time.Duration      `yaml:"interval"` // How often expired objects are reaped; default 1m
+	Rules    []ExpiryRuleConfig `yaml:"rules"`
+}
+
+// ExpiryRuleConfig gives objects written under Prefix a time to live
+type ExpiryRuleConfig struct {
+	Prefix string        `yaml:"prefix"`
+	TTL    time.Duration `yaml:"ttl"`
+}
+
+// CopyOnWriteConfig redirects writes to a read-mostly shared dataset into a
+// per-mount overlay prefix of the same bucket. The first write to a key
+// copies it into the overlay; reads of edited keys are then served from
+// there, and the shared keys are unchanged until the overlay is committed.
+type CopyOnWriteConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Prefix  string `yaml:"prefix"` // Overlay key prefix, unique per mount (default ".objectfs-overlay/")
+}
+
+// DirectoryMarkerConfig decides which zero-byte objects are directory
+// placeholders. Keys ending in "/" always are; any other zero-byte object
+// is a file unless it has content_type or its metadata_key is "directory".
+type DirectoryMarkerConfig struct {
+	ContentType string `yaml:"content_type"` // Default "application/x-directory"
+	MetadataKey string `yaml:"metadata_key"` // Default "objectfs-type"
+}
+
+// MirrorConfig applies writes and deletes to a second bucket as well as the
+// primary. Keys whose mirror write fails are retried from a backlog by
+// copying the primary's current object.
+type MirrorConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	URI           string        `yaml:"uri"`            // Storage URI of the mirror bucket (e.g., "s3://new-bucket")
+	Mode          string        `yaml:"mode"`           // "best-effort" (default) logs mirror failures; "strict" fails the write
+	MaxBacklog    int           `yaml:"max_backlog"`    // Keys awaiting a mirror retry (default 10000)
+	RetryInterval time.Duration `yaml:"retry_interval"` // How often backlogged keys are retried (default 5s)
 }
 
 // S3Config represents AWS S3 configuration
@@ -235,7 +525,198 @@ type S3Config struct {
 	Profile          string             `yaml:"profile"`
 	UseAcceleration  bool               `yaml:"use_acceleration"`
 	ForcePathStyle   bool               `yaml:"force_path_style"`
+	RequesterPays    bool               `yaml:"requester_pays"`  // Accept request charges of requester-pays buckets
+	StableListings   bool               `yaml:"stable_listings"` // List whole prefixes before serving them, for correctness-sensitive consumers
 	CostOptimization S3CostOptimization `yaml:"cost_optimization"`
+	Pack             S3PackConfig       `yaml:"pack"`
+	Hedge            S3HedgeConfig      `yaml:"hedge"`
+	CostBudget       S3CostBudgetConfig `yaml:"cost_budget"`
+	ListOverlay      S3ListOverlay      `yaml:"list_overlay"`
+	ListCache        S3ListCache        `yaml:"list_cache"`
+	Retention        S3RetentionConfig  `yaml:"retention"`
+	Housekeeping     S3Housekeeping     `yaml:"housekeeping"`
+	LatencyProbe     S3LatencyProbe     `yaml:"latency_probe"`
+	Prewarm          S3Prewarm          `yaml:"prewarm"`
+	PoolHealth       S3PoolHealth       `yaml:"pool_health"`
+
+	// Regions holding replicas of the bucket that reads fail over to when
+	// the primary region fails or is slow; writes go to the primary
+	ReadReplicaRegions []string         `yaml:"read_replica_regions"`
+	ReadFailover       S3ReadFailover   `yaml:"read_failover"`
+	AccessTracking     S3AccessTracking `yaml:"access_tracking"`
+	MetadataIndex      S3MetadataIndex  `yaml:"metadata_index"`
+	TierPolicy         S3TierPolicy     `yaml:"tier_policy"`
+	Blocks             S3BlockConfig    `yaml:"blocks"`
+	Dedup              S3DedupConfig    `yaml:"dedup"`
+
+	// Keep uploaded parts of a failed multipart upload so retrying it only
+	// sends the failed parts: "abort-on-failure" (default) or "preserve-for-resume"
+	MultipartFailurePolicy string `yaml:"multipart_failure_policy"`
+
+	// Adapt the multipart threshold and part concurrency to measured
+	// upload throughput instead of using the static defaults
+	AdaptiveMultipart S3AdaptiveMultipart `yaml:"adaptive_multipart"`
+
+	// Checksum streamed uploads as they are sent, storing it in object
+	// metadata for streamed downloads to verify: "crc32c", "sha256", or empty
+	StreamChecksum string `yaml:"stream_checksum"`
+}
+
+// S3AdaptiveMultipart lowers the multipart threshold and raises part
+// concurrency while multipart uploads measure faster than single PUTs, and
+// does the reverse while they do not
+type S3AdaptiveMultipart struct {
+	Enabled        bool   `yaml:"enabled"`
+	MinThreshold   string `yaml:"min_threshold"`   // Lowest effective threshold (default 8MB)
+	MaxThreshold   string `yaml:"max_threshold"`   // Highest effective threshold (default 512MB)
+	MaxConcurrency int    `yaml:"max_concurrency"` // Most concurrent part uploads (default twice the static concurrency)
+	Window         int    `yaml:"window"`          // Recent uploads whose throughput is compared (default 16)
+}
+
+// S3ListOverlay merges recent local writes and deletes into listings, for
+// S3-compatible backends whose listings are not read-after-write consistent
+type S3ListOverlay struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window"` // How long local mutations are merged in (default 30s)
+}
+
+// S3ListCache caches listings briefly so repeated listings of the same
+// prefix, such as a polling watcher, do not each cost a LIST request. Local
+// writes and deletes under a prefix drop its cached listings.
+type S3ListCache struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"` // How long a listing is served from cache (default 5s)
+}
+
+// S3Housekeeping periodically aborts multipart uploads left incomplete, whose
+// parts are stored and billed until aborted
+type S3Housekeeping struct {
+	Enabled    bool          `yaml:"enabled"`
+	Interval   time.Duration `yaml:"interval"`    // How often to look for incomplete uploads (default 24h)
+	AbortAfter time.Duration `yaml:"abort_after"` // Age at which an incomplete upload is aborted (default 7 days)
+	DryRun     bool          `yaml:"dry_run"`     // Log the uploads that would be aborted without aborting them
+}
+
+// S3LatencyProbe periodically pings the backend to keep a rolling latency
+// estimate, used by hedging before enough reads have been timed and
+// exported as objectfs_backend_latency_seconds
+type S3LatencyProbe struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"` // How often to ping the backend (default 30s)
+}
+
+// S3Prewarm opens and validates pooled backend connections during startup,
+// before the mount is ready, so the first operation does not pay for DNS,
+// TCP and TLS setup
+type S3Prewarm struct {
+	Enabled     bool `yaml:"enabled"`
+	Connections int  `yaml:"connections"` // Connections to open (default: the connection pool size)
+}
+
+// S3PoolHealth replaces pooled backend clients that keep failing to reach
+// S3, such as one stuck behind a broken proxy, and validates idle clients
+type S3PoolHealth struct {
+	FailureThreshold   int           `yaml:"failure_threshold"`   // Consecutive failures before a client is replaced (default 3)
+	ValidationInterval time.Duration `yaml:"validation_interval"` // How often idle clients are validated (default 30s)
+}
+
+// S3ReadFailover tunes failover of reads to read_replica_regions
+type S3ReadFailover struct {
+	LatencyThreshold time.Duration     `yaml:"latency_threshold"` // Prefer replicas while the primary's pinged latency exceeds this; 0 fails over on errors only
+	Buckets          map[string]string `yaml:"buckets"`           // Replica bucket by region (default: the primary bucket's name)
+	Endpoints        map[string]string `yaml:"endpoints"`         // Endpoint by region, for S3-compatible stores
+}
+
+// S3AccessTracking records when objects were last read, which S3 does not
+// track, so tier recommendations can use access recency instead of
+// last-modified time
+type S3AccessTracking struct {
+	Enabled         bool          `yaml:"enabled"`
+	MaxEntries      int           `yaml:"max_entries"`      // Objects tracked, least recently read dropped first (default 100000)
+	Path            string        `yaml:"path"`             // Sidecar file the index is persisted to; empty keeps it in memory
+	PersistInterval time.Duration `yaml:"persist_interval"` // How often the index is saved (default 5m)
+}
+
+// S3MetadataIndex keeps a local index of object metadata, and optionally
+// tags, so objects can be found by value without scanning the bucket
+type S3MetadataIndex struct {
+	Enabled         bool          `yaml:"enabled"`
+	Prefix          string        `yaml:"prefix"`           // Objects indexed; empty indexes the whole bucket
+	Tags            bool          `yaml:"tags"`             // Also index tags, at one GetObjectTagging call per object and build
+	MaxEntries      int           `yaml:"max_entries"`      // Objects indexed (default 100000)
+	MaxAge          time.Duration `yaml:"max_age"`          // Rebuild from a listing once the index is this old (default 24h)
+	Path            string        `yaml:"path"`             // File the index is persisted to; empty keeps it in memory
+	PersistInterval time.Duration `yaml:"persist_interval"` // How often the index is saved (default 5m)
+}
+
+// S3TierPolicy overrides the built-in tier recommendations with rules, such
+// as keeping everything under reports/ in Standard regardless of access
+type S3TierPolicy struct {
+	Rules []S3TierPolicyRule `yaml:"rules"` // Evaluated in order; first match wins
+}
+
+// S3TierPolicyRule recommends a tier for objects matching all its criteria
+type S3TierPolicyRule struct {
+	Prefix          string        `yaml:"prefix"`           // Key prefix to match (empty matches all)
+	MinSize         int64         `yaml:"min_size"`         // Minimum object size in bytes
+	MaxSize         int64         `yaml:"max_size"`         // Maximum object size in bytes (0 for unlimited)
+	MinAge          time.Duration `yaml:"min_age"`          // Minimum object age
+	MaxAge          time.Duration `yaml:"max_age"`          // Maximum object age (0 for unlimited)
+	AccessFrequency string        `yaml:"access_frequency"` // Access frequency to match (empty matches all)
+	Tier            string        `yaml:"tier"`             // Tier to recommend
+	Reason          string        `yaml:"reason"`           // Reason reported with the decision
+}
+
+// S3BlockConfig splits large objects into fixed-size blocks stored as
+// separate objects, so small random writes rewrite one block instead of
+// the whole object. Split objects are only readable through objectfs.
+type S3BlockConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	BlockSize string `yaml:"block_size"` // Objects larger than this are split (default 1MB)
+	Prefix    string `yaml:"prefix"`     // Key prefix blocks are stored under (default .objectfs/blocks/)
+}
+
+// S3DedupConfig stores each distinct content once under a content hash,
+// with the written keys holding references to it, for workloads such as
+// backups that write many identical files. Deduplicated objects are only
+// readable through objectfs.
+type S3DedupConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Hash       string        `yaml:"hash"`        // Content hash in references: sha256 (default) or sha512
+	MinSize    string        `yaml:"min_size"`    // Smaller objects are stored whole (default 4KB)
+	Prefix     string        `yaml:"prefix"`      // Key prefix content and references are stored under (default .objectfs/content/)
+	GCInterval time.Duration `yaml:"gc_interval"` // How often unreferenced content is deleted (default 24h; negative disables)
+	GCGrace    time.Duration `yaml:"gc_grace"`    // Content younger than this is never collected (default 1h)
+}
+
+// S3RetentionConfig applies S3 Object Lock retention to written objects.
+// The bucket must have Object Lock enabled.
+type S3RetentionConfig struct {
+	Mode string `yaml:"mode"` // "GOVERNANCE" or "COMPLIANCE"; empty disables retention
+	Days int    `yaml:"days"` // Retention period from each write
+}
+
+// S3CostBudgetConfig caps the spend rate of cost-incurring S3 calls
+type S3CostBudgetConfig struct {
+	USDPerHour float64       `yaml:"usd_per_hour"` // Spend rate budget; 0 disables throttling
+	BurstUSD   float64       `yaml:"burst_usd"`    // Spend allowed at once (default one minute of budget)
+	Mode       string        `yaml:"mode"`         // "queue" (default) delays excess calls, "reject" fails them
+	MaxWait    time.Duration `yaml:"max_wait"`     // Longest a queued call waits before it is rejected
+}
+
+// S3HedgeConfig represents request hedging settings for reads
+type S3HedgeConfig struct {
+	Enabled    bool    `yaml:"enabled"`
+	Percentile float64 `yaml:"percentile"` // Latency percentile after which a second request fires (default 0.95)
+	MaxRate    float64 `yaml:"max_rate"`   // Maximum fraction of reads that may be hedged (default 0.05)
+}
+
+// S3PackConfig represents small-object packing settings
+type S3PackConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Threshold     string        `yaml:"threshold"`      // Pack objects smaller than this; empty uses the tier minimum
+	MaxPackSize   string        `yaml:"max_pack_size"`  // Flush once pending objects reach this size
+	FlushInterval time.Duration `yaml:"flush_interval"` // Flush pending objects at least this often
 }
 
 // S3CostOptimization represents S3 cost optimization settings
@@ -256,6 +737,37 @@ type ClusterConfig struct {
 	SeedNodes         []string `yaml:"seed_nodes"`
 	ReplicationFactor int      `yaml:"replication_factor"`
 	ConsistencyLevel  string   `yaml:"consistency_level"`
+
+	// TLS secures inter-node traffic, authenticating both ends when
+	// require_client_cert is set
+	TLS ClusterTLSConfig `yaml:"tls"`
+}
+
+// ClusterTLSConfig holds the node certificate and the CA peers must be
+// signed by. The cluster uses it as distributed.ClusterTLS, which loads the
+// certificates.
+type ClusterTLSConfig struct {
+	CertFile          string `yaml:"cert_file"`
+	KeyFile           string `yaml:"key_file"`
+	CAFile            string `yaml:"ca_file"`
+	RequireClientCert bool   `yaml:"require_client_cert"` // Reject nodes without a certificate signed by ca_file
+}
+
+// Validate checks that the settings describe a usable TLS setup
+func (t ClusterTLSConfig) Validate() error {
+	if t.CertFile == "" && t.KeyFile == "" {
+		if t.RequireClientCert {
+			return fmt.Errorf("cluster tls require_client_cert needs cert_file and key_file")
+		}
+		return nil
+	}
+	if t.CertFile == "" || t.KeyFile == "" {
+		return fmt.Errorf("cluster tls needs both cert_file and key_file")
+	}
+	if t.RequireClientCert && t.CAFile == "" {
+		return fmt.Errorf("cluster tls require_client_cert needs ca_file")
+	}
+	return nil
 }
 
 // NewDefault returns a configuration with sensible defaults
@@ -267,6 +779,8 @@ func NewDefault() *Configuration {
 			MetricsPort: 8080,
 			HealthPort:  8081,
 			ProfilePort: 6060,
+
+			OnNonEmptyMount: NonEmptyMountFail,
 		},
 		Storage: StorageConfig{
 			S3: S3Config{
@@ -330,12 +844,19 @@ func NewDefault() *Configuration {
 			FlushInterval: 30 * time.Second,
 			MaxBuffers:    1000,
 			MaxMemory:     "512MB",
+			// Compressed objects are only readable through objectfs
 			Compression: CompressionConfig{
-				Enabled:   true,
+				Enabled:   false,
 				MinSize:   "1KB",
 				Algorithm: "gzip",
 				Level:     6,
 			},
+			Coalesce: CoalesceConfig{
+				Enabled:      true,
+				Window:       100 * time.Millisecond,
+				MaxSize:      "1MB",
+				MaxWriteSize: "64KB",
+			},
 		},
 		Network: NetworkConfig{
 			Timeouts: TimeoutConfig{
@@ -399,6 +920,10 @@ func NewDefault() *Configuration {
 					Rate:    1000,
 				},
 			},
+			RecentOps: RecentOpsConfig{
+				Enabled: true,
+				Size:    256,
+			},
 		},
 		Features: FeatureConfig{
 			Prefetching:           true,
@@ -457,6 +982,10 @@ func getEnvMappings() []envMapping {
 			c.Global.LogFile = val
 			return nil
 		}},
+		{"OBJECTFS_ON_NONEMPTY_MOUNT", func(c *Configuration, val string) error {
+			c.Global.OnNonEmptyMount = val
+			return nil
+		}},
 		{"OBJECTFS_METRICS_PORT", func(c *Configuration, val string) error {
 			if port, err := strconv.Atoi(val); err == nil {
 				c.Global.MetricsPort = port
@@ -501,6 +1030,18 @@ func getEnvMappings() []envMapping {
 			}
 			return nil
 		}},
+		{"OBJECTFS_CACHE_BLOCK_SIZE", func(c *Configuration, val string) error {
+			c.Cache.BlockSize = val
+			return nil
+		}},
+
+		// Write buffer settings
+		{"OBJECTFS_WRITE_COALESCE_WINDOW", func(c *Configuration, val string) error {
+			if duration, err := time.ParseDuration(val); err == nil {
+				c.WriteBuffer.Coalesce.Window = duration
+			}
+			return nil
+		}},
 
 		// Feature flags
 		{"OBJECTFS_PREFETCHING", func(c *Configuration, val string) error {
@@ -579,6 +1120,14 @@ func (c *Configuration) Validate() error {
 	if c.Performance.MaxConcurrency <= 0 {
 		return fmt.Errorf("max_concurrency must be greater than 0")
 	}
+	for name, limit := range map[string]int{
+		"max_read_concurrency":  c.Performance.MaxReadConcurrency,
+		"max_write_concurrency": c.Performance.MaxWriteConcurrency,
+	} {
+		if limit < 0 || limit > c.Performance.MaxConcurrency {
+			return fmt.Errorf("%s must be between 0 and max_concurrency (%d), got %d", name, c.Performance.MaxConcurrency, limit)
+		}
+	}
 
 	if c.Performance.ConnectionPoolSize <= 0 {
 		return fmt.Errorf("connection_pool_size must be greater than 0")
@@ -601,6 +1150,255 @@ func (c *Configuration) Validate() error {
 			c.Global.LogLevel, strings.Join(validLogLevels, ", "))
 	}
 
+	switch strings.ToLower(c.Monitoring.Logging.Format) {
+	case "", "json", "text":
+	default:
+		return fmt.Errorf("invalid logging format: %s (must be json or text)", c.Monitoring.Logging.Format)
+	}
+
+	if c.Monitoring.RecentOps.Size < 0 {
+		return fmt.Errorf("recent_ops size must not be negative")
+	}
+	if c.Monitoring.Usage.SaveInterval < 0 || c.Monitoring.Usage.PrefixDepth < 0 {
+		return fmt.Errorf("usage save_interval and prefix_depth must not be negative")
+	}
+
+	switch c.Global.OnNonEmptyMount {
+	case "", NonEmptyMountFail, NonEmptyMountForce, NonEmptyMountUseNonEmpty:
+	default:
+		return fmt.Errorf("invalid on_nonempty_mount: %s (must be one of: %s, %s, %s)",
+			c.Global.OnNonEmptyMount, NonEmptyMountFail, NonEmptyMountForce, NonEmptyMountUseNonEmpty)
+	}
+
+	remount := c.Global.AutoRemount
+	if remount.CheckInterval < 0 || remount.MaxAttempts < 0 || remount.Backoff < 0 || remount.MaxBackoff < 0 {
+		return fmt.Errorf("auto_remount settings must not be negative")
+	}
+
+	kernel := c.Global.KernelCache
+	if kernel.MaxBackground < 0 || kernel.CongestionThreshold < 0 {
+		return fmt.Errorf("kernel_cache queue limits must not be negative")
+	}
+	if kernel.MaxBackground > 0 && kernel.CongestionThreshold > kernel.MaxBackground {
+		return fmt.Errorf("kernel_cache congestion_threshold must not exceed max_background")
+	}
+
+	expiry := c.Storage.Expiry
+	if expiry.Interval < 0 {
+		return fmt.Errorf("expiry interval must not be negative")
+	}
+	for _, rule := range expiry.Rules {
+		if rule.TTL <= 0 {
+			return fmt.Errorf("expiry rule for prefix %q must have a positive ttl", rule.Prefix)
+		}
+	}
+
+	if c.Storage.S3.ListOverlay.Window < 0 {
+		return fmt.Errorf("list_overlay window must not be negative")
+	}
+	if c.Storage.S3.ListCache.TTL < 0 {
+		return fmt.Errorf("list_cache ttl must not be negative")
+	}
+	if housekeeping := c.Storage.S3.Housekeeping; housekeeping.Interval < 0 || housekeeping.AbortAfter < 0 {
+		return fmt.Errorf("housekeeping interval and abort_after must not be negative")
+	}
+	if c.Storage.S3.LatencyProbe.Interval < 0 {
+		return fmt.Errorf("latency probe interval must not be negative")
+	}
+	if mirror := c.Storage.Mirror; mirror.Enabled {
+		if mirror.URI == "" {
+			return fmt.Errorf("mirror uri is required when mirroring is enabled")
+		}
+		if mirror.Mode != "" && mirror.Mode != "best-effort" && mirror.Mode != "strict" {
+			return fmt.Errorf("invalid mirror mode: %s (must be best-effort or strict)", mirror.Mode)
+		}
+		if mirror.MaxBacklog < 0 || mirror.RetryInterval < 0 {
+			return fmt.Errorf("mirror max_backlog and retry_interval must not be negative")
+		}
+	}
+	if c.Storage.S3.Prewarm.Connections < 0 {
+		return fmt.Errorf("prewarm connections must not be negative")
+	}
+	if health := c.Storage.S3.PoolHealth; health.FailureThreshold < 0 || health.ValidationInterval < 0 {
+		return fmt.Errorf("pool_health failure_threshold and validation_interval must not be negative")
+	}
+	if c.Storage.S3.ReadFailover.LatencyThreshold < 0 {
+		return fmt.Errorf("read_failover latency_threshold must not be negative")
+	}
+	if tracking := c.Storage.S3.AccessTracking; tracking.MaxEntries < 0 || tracking.PersistInterval < 0 {
+		return fmt.Errorf("access_tracking max_entries and persist_interval must not be negative")
+	}
+	if index := c.Storage.S3.MetadataIndex; index.MaxEntries < 0 || index.MaxAge < 0 || index.PersistInterval < 0 {
+		return fmt.Errorf("metadata_index max_entries, max_age and persist_interval must not be negative")
+	}
+
+	rate := c.Performance.RateLimit
+	for _, rps := range []float64{rate.RequestsPerSecond, rate.Read.RequestsPerSecond, rate.Write.RequestsPerSecond, rate.List.RequestsPerSecond} {
+		if rps < 0 {
+			return fmt.Errorf("rate_limit requests_per_second must not be negative")
+		}
+	}
+	if rate.MaxWait < 0 {
+		return fmt.Errorf("rate_limit max_wait must not be negative")
+	}
+	switch rate.Mode {
+	case "", "queue", "reject":
+	default:
+		return fmt.Errorf("invalid rate_limit mode: %s (must be queue or reject)", rate.Mode)
+	}
+
+	budget := c.Storage.S3.CostBudget
+	if budget.USDPerHour < 0 || budget.BurstUSD < 0 {
+		return fmt.Errorf("cost_budget usd_per_hour and burst_usd must not be negative")
+	}
+	switch budget.Mode {
+	case "", "queue", "reject":
+	default:
+		return fmt.Errorf("invalid cost_budget mode: %s (must be queue or reject)", budget.Mode)
+	}
+
+	high, low := c.WriteBuffer.HighWatermark, c.WriteBuffer.LowWatermark
+	if high < 0 || high > 1 || low < 0 || low > 1 {
+		return fmt.Errorf("write_buffer watermarks must be between 0 and 1")
+	}
+	if high > 0 && low > 0 && low >= high {
+		return fmt.Errorf("write_buffer low_watermark must be below high_watermark")
+	}
+
+	if err := c.Cluster.TLS.Validate(); err != nil {
+		return err
+	}
+
+	spill := c.WriteBuffer.Spill
+	if spill.Watermark < 0 || spill.Watermark > 1 {
+		return fmt.Errorf("write_buffer spill watermark must be between 0 and 1")
+	}
+	if spill.Enabled && spill.Directory == "" && c.Cache.PersistentCache.Directory == "" && len(c.Cache.PersistentCache.Paths) == 0 {
+		return fmt.Errorf("write_buffer spill requires a directory or a persistent_cache directory")
+	}
+
+	compression := c.WriteBuffer.Compression
+	switch compression.Algorithm {
+	case "", "gzip", "zlib":
+	default:
+		return fmt.Errorf("invalid write_buffer compression algorithm: %s (must be gzip or zlib)", compression.Algorithm)
+	}
+	if compression.Level < -1 || compression.Level > 9 {
+		return fmt.Errorf("write_buffer compression level must be between -1 and 9, got %d", compression.Level)
+	}
+
+	if c.Storage.S3.Blocks.Enabled && compression.Enabled {
+		return fmt.Errorf("storage blocks cannot be combined with write_buffer compression")
+	}
+
+	dedup := c.Storage.S3.Dedup
+	switch dedup.Hash {
+	case "", "sha256", "sha512":
+	default:
+		return fmt.Errorf("invalid dedup hash: %s (must be sha256 or sha512)", dedup.Hash)
+	}
+	if c.Storage.S3.Blocks.Enabled && dedup.Enabled {
+		return fmt.Errorf("storage blocks cannot be combined with dedup")
+	}
+
+	retention := c.Storage.S3.Retention
+	switch retention.Mode {
+	case "":
+	case "GOVERNANCE", "COMPLIANCE":
+		if retention.Days <= 0 {
+			return fmt.Errorf("retention days must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("invalid retention mode: %s (must be GOVERNANCE or COMPLIANCE)", retention.Mode)
+	}
+
+	switch c.Storage.S3.MultipartFailurePolicy {
+	case "", "abort-on-failure", "preserve-for-resume":
+	default:
+		return fmt.Errorf("invalid multipart_failure_policy: %s (must be abort-on-failure or preserve-for-resume)",
+			c.Storage.S3.MultipartFailurePolicy)
+	}
+
+	switch c.Storage.S3.StreamChecksum {
+	case "", "crc32c", "sha256":
+	default:
+		return fmt.Errorf("invalid stream_checksum: %s (must be crc32c or sha256)", c.Storage.S3.StreamChecksum)
+	}
+
+	if adaptive := c.Storage.S3.AdaptiveMultipart; adaptive.MaxConcurrency < 0 || adaptive.Window < 0 {
+		return fmt.Errorf("adaptive_multipart max_concurrency and window must not be negative")
+	}
+
+	degradation := c.Network.Degradation
+	switch degradation.OnReadUnavailable {
+	case "", "eio", "serve-stale":
+	default:
+		return fmt.Errorf("invalid degradation on_read_unavailable: %s (must be eio or serve-stale)", degradation.OnReadUnavailable)
+	}
+	switch degradation.OnWriteUnavailable {
+	case "", "queue", "erofs":
+	default:
+		return fmt.Errorf("invalid degradation on_write_unavailable: %s (must be queue or erofs)", degradation.OnWriteUnavailable)
+	}
+	if degradation.MaxStaleness < 0 {
+		return fmt.Errorf("degradation max_staleness must not be negative")
+	}
+
+	switch c.Cache.EvictionScorer {
+	case "", "ml", "lru", "lfu", "cost_aware":
+	default:
+		return fmt.Errorf("invalid cache eviction_scorer: %s (must be ml, lru, lfu or cost_aware)", c.Cache.EvictionScorer)
+	}
+
+	switch c.Network.CircuitBreaker.Mode {
+	case "", "consecutive_failures", "error_rate":
+	default:
+		return fmt.Errorf("invalid circuit_breaker mode: %s (must be consecutive_failures or error_rate)", c.Network.CircuitBreaker.Mode)
+	}
+	if rate := c.Network.CircuitBreaker.ErrorRateThreshold; rate < 0 || rate > 1 {
+		return fmt.Errorf("circuit_breaker error_rate_threshold must be between 0 and 1, got %f", rate)
+	}
+	if c.Network.CircuitBreaker.FailureThreshold < 0 || c.Network.CircuitBreaker.MinimumRequests < 0 || c.Network.CircuitBreaker.Window < 0 {
+		return fmt.Errorf("circuit_breaker failure_threshold, minimum_requests and window must not be negative")
+	}
+
+	if c.Cache.TTLJitter < 0 || c.Cache.TTLJitter > 1 {
+		return fmt.Errorf("cache ttl_jitter must be between 0 and 1, got %f", c.Cache.TTLJitter)
+	}
+	if c.Cache.ImplicitDirTTL < 0 {
+		return fmt.Errorf("cache implicit_dir_ttl must not be negative")
+	}
+	listing := c.Cache.ListingPrefetch
+	switch listing.Mode {
+	case "", "off", "metadata", "data":
+	default:
+		return fmt.Errorf("invalid cache listing_prefetch mode: %s (must be off, metadata or data)", listing.Mode)
+	}
+	if listing.MaxDataFiles < 0 || listing.Concurrency < 0 {
+		return fmt.Errorf("cache listing_prefetch max_data_files and concurrency must not be negative")
+	}
+
+	for i, path := range c.Cache.PersistentCache.Paths {
+		if path.Directory == "" || path.MaxSize == "" {
+			return fmt.Errorf("persistent_cache path %d needs a directory and max_size", i)
+		}
+	}
+	if c.Cache.PersistentCache.PromoteAfter < 0 || c.Cache.PersistentCache.DemoteAfter < 0 {
+		return fmt.Errorf("persistent_cache promote_after and demote_after must not be negative")
+	}
+	if strings.EqualFold(c.Cache.PersistentCache.MaxSize, "auto") {
+		autoSize := c.Cache.PersistentCache.AutoSize
+		if len(c.Cache.PersistentCache.Paths) > 0 {
+			return fmt.Errorf("persistent_cache max_size auto cannot be used with paths")
+		}
+		if autoSize.Fraction < 0 || autoSize.Fraction > 1 {
+			return fmt.Errorf("persistent_cache auto_size fraction must be between 0 and 1, got %f", autoSize.Fraction)
+		}
+		if autoSize.CheckInterval < 0 {
+			return fmt.Errorf("persistent_cache auto_size check_interval must not be negative")
+		}
+	}
+
 	// Validate read-ahead configuration
 	if err := c.validateReadAheadConfig(); err != nil {
 		return fmt.Errorf("read_ahead configuration invalid: %w", err)
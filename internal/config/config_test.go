@@ -82,6 +82,321 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "max_concurrency must be greater than 0",
 		},
+		{
+			name: "invalid on_nonempty_mount",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Global.OnNonEmptyMount = "overwrite"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid on_nonempty_mount: overwrite",
+		},
+		{
+			name: "negative auto_remount attempts",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Global.AutoRemount.MaxAttempts = -1
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "auto_remount settings must not be negative",
+		},
+		{
+			name: "congestion threshold above max background",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Global.KernelCache.MaxBackground = 16
+				cfg.Global.KernelCache.CongestionThreshold = 32
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "kernel_cache congestion_threshold must not exceed max_background",
+		},
+		{
+			name: "invalid listing prefetch mode",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Cache.ListingPrefetch.Mode = "everything"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid cache listing_prefetch mode: everything",
+		},
+		{
+			name: "invalid cost budget mode",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.CostBudget.Mode = "drop"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid cost_budget mode: drop",
+		},
+		{
+			name: "invalid rate limit mode",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Performance.RateLimit.Mode = "fail"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid rate_limit mode: fail",
+		},
+		{
+			name: "negative rate limit",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Performance.RateLimit.Write.RequestsPerSecond = -1
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "rate_limit requests_per_second must not be negative",
+		},
+		{
+			name: "cluster client certificates without a CA",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Cluster.TLS = ClusterTLSConfig{CertFile: "node.pem", KeyFile: "node-key.pem", RequireClientCert: true}
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "cluster tls require_client_cert needs ca_file",
+		},
+		{
+			name: "cluster certificate without a key",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Cluster.TLS = ClusterTLSConfig{CertFile: "node.pem"}
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "cluster tls needs both cert_file and key_file",
+		},
+		{
+			name: "write spill without a directory",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.WriteBuffer.Spill.Enabled = true
+				cfg.Cache.PersistentCache.Directory = ""
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "write_buffer spill requires a directory or a persistent_cache directory",
+		},
+		{
+			name: "invalid compression algorithm",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.WriteBuffer.Compression.Algorithm = "lzma"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid write_buffer compression algorithm: lzma",
+		},
+		{
+			name: "invalid dedup hash",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.Dedup.Hash = "md5"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid dedup hash: md5",
+		},
+		{
+			name: "dedup with blocks",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.Dedup.Enabled = true
+				cfg.Storage.S3.Blocks.Enabled = true
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "storage blocks cannot be combined with dedup",
+		},
+		{
+			name: "retention without days",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.Retention.Mode = "COMPLIANCE"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "retention days must be greater than 0",
+		},
+		{
+			name: "read concurrency above total",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Performance.MaxReadConcurrency = cfg.Performance.MaxConcurrency + 1
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "max_read_concurrency must be between 0 and max_concurrency",
+		},
+		{
+			name: "negative list cache ttl",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.ListCache.TTL = -time.Second
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "list_cache ttl must not be negative",
+		},
+		{
+			name: "negative housekeeping age",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.Housekeeping.AbortAfter = -time.Hour
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "housekeeping interval and abort_after must not be negative",
+		},
+		{
+			name: "invalid logging format",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Monitoring.Logging.Format = "xml"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid logging format: xml",
+		},
+		{
+			name: "negative usage prefix depth",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Monitoring.Usage.PrefixDepth = -1
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "usage save_interval and prefix_depth must not be negative",
+		},
+		{
+			name: "negative latency probe interval",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.LatencyProbe.Interval = -time.Second
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "latency probe interval must not be negative",
+		},
+		{
+			name: "mirror without uri",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.Mirror.Enabled = true
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "mirror uri is required when mirroring is enabled",
+		},
+		{
+			name: "invalid mirror mode",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.Mirror = MirrorConfig{Enabled: true, URI: "s3://new-bucket", Mode: "sometimes"}
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid mirror mode: sometimes (must be best-effort or strict)",
+		},
+		{
+			name: "expiry rule without ttl",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.Expiry = ExpiryConfig{Enabled: true, Rules: []ExpiryRuleConfig{{Prefix: "tmp/"}}}
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "expiry rule for prefix \"tmp/\" must have a positive ttl",
+		},
+		{
+			name: "negative prewarm connections",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.Prewarm.Connections = -1
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "prewarm connections must not be negative",
+		},
+		{
+			name: "negative pool health threshold",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.PoolHealth.FailureThreshold = -1
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "pool_health failure_threshold and validation_interval must not be negative",
+		},
+		{
+			name: "negative read failover threshold",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.ReadReplicaRegions = []string{"eu-west-1"}
+				cfg.Storage.S3.ReadFailover.LatencyThreshold = -time.Second
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "read_failover latency_threshold must not be negative",
+		},
+		{
+			name: "auto-sized persistent cache with paths",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Cache.PersistentCache.MaxSize = "auto"
+				cfg.Cache.PersistentCache.Paths = []PersistentCachePath{{Directory: "/nvme", MaxSize: "10GB"}}
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "persistent_cache max_size auto cannot be used with paths",
+		},
+		{
+			name: "invalid multipart failure policy",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.MultipartFailurePolicy = "retry-forever"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid multipart_failure_policy: retry-forever",
+		},
+		{
+			name: "negative adaptive multipart window",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.AdaptiveMultipart.Window = -1
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "adaptive_multipart max_concurrency and window must not be negative",
+		},
+		{
+			name: "negative metadata index max age",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.MetadataIndex.MaxAge = -time.Hour
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "metadata_index max_entries, max_age and persist_interval must not be negative",
+		},
+		{
+			name: "invalid stream checksum",
+			config: func() *Configuration {
+				cfg := NewDefault()
+				cfg.Storage.S3.StreamChecksum = "md5"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "invalid stream_checksum: md5",
+		},
 		{
 			name: "invalid connection pool size",
 			config: func() *Configuration {
@@ -103,6 +103,8 @@ Configuration file format:
 	  metrics_port: 8080
 	  health_port: 8081
 	  profile_port: 6060
+	  on_nonempty_mount: fail  # fail, force, or use-nonempty
+	  case_insensitive: false  # match paths regardless of case
 
 	performance:
 	  cache_size: "2GB"
@@ -0,0 +1,220 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// barrierRetryInterval is how long a barrier waits before resending write
+// notices to peers that have not acknowledged them
+const barrierRetryInterval = 50 * time.Millisecond
+
+// WriteNotice tells a peer that a node wrote key, so the peer stops serving
+// data it cached before the write
+type WriteNotice struct {
+	NodeID  string `json:"node_id"`
+	Key     string `json:"key"`
+	Version uint64 `json:"version"`
+}
+
+// WriteAck confirms that a node applied the write notices of a key up to
+// Version
+type WriteAck struct {
+	NodeID  string `json:"node_id"`
+	Key     string `json:"key"`
+	Version uint64 `json:"version"`
+}
+
+// WriteNoticeTransport delivers a write notice to another node and returns
+// its acknowledgement
+type WriteNoticeTransport func(ctx context.Context, nodeID string, notice *WriteNotice) (*WriteAck, error)
+
+// SetWriteNoticeTransport sets how write notices reach other nodes
+func (c *Coordinator) SetWriteNoticeTransport(transport WriteNoticeTransport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeTransport = transport
+}
+
+// InvalidateOnPeerWrites drops keys other nodes wrote from cache, so reads
+// after a peer's write fetch it from the backend
+func (c *Coordinator) InvalidateOnPeerWrites(cache types.Cache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeCache = cache
+}
+
+// RecordWrite notes that this node wrote key and notifies the alive peers
+// in the background. It returns the key's new write version.
+func (c *Coordinator) RecordWrite(key string) uint64 {
+	c.mu.Lock()
+	c.writeVersions[key]++
+	version := c.writeVersions[key]
+	transport := c.writeTransport
+	c.mu.Unlock()
+
+	if transport != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), c.config.OperationTimeout)
+			defer cancel()
+			c.sendWriteNotices(ctx, key, version, c.alivePeers())
+		}()
+	}
+	return version
+}
+
+// HandleWriteNotice applies a peer's write notice, dropping the key from
+// the invalidated cache, and returns this node's acknowledgement
+func (c *Coordinator) HandleWriteNotice(notice *WriteNotice) *WriteAck {
+	c.mu.RLock()
+	cache := c.writeCache
+	c.mu.RUnlock()
+
+	if cache != nil {
+		cache.Delete(notice.Key)
+	}
+	return &WriteAck{NodeID: c.cluster.GetNodeID(), Key: notice.Key, Version: notice.Version}
+}
+
+// Barrier blocks until this node's writes of keys are known to be applied
+// by every alive node, or by a majority of them when BarrierQuorum is set,
+// so reads served by those nodes observe the writes. Notices that were lost
+// or not yet acknowledged are resent until ctx is done. Keys this node has
+// not written return immediately.
+func (c *Coordinator) Barrier(ctx context.Context, keys []string) error {
+	for {
+		pending := c.unreplicatedWrites(keys)
+		if len(pending) == 0 {
+			return nil
+		}
+
+		c.mu.RLock()
+		transport := c.writeTransport
+		c.mu.RUnlock()
+		if transport == nil {
+			return fmt.Errorf("no transport for write notices")
+		}
+
+		for _, write := range pending {
+			c.sendWriteNotices(ctx, write.key, write.version, write.missing)
+		}
+		if len(c.unreplicatedWrites(keys)) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			pending = c.unreplicatedWrites(keys)
+			unreplicated := make([]string, 0, len(pending))
+			for _, write := range pending {
+				unreplicated = append(unreplicated, write.key)
+			}
+			sort.Strings(unreplicated)
+			return fmt.Errorf("barrier incomplete, writes not replicated for %v: %w", unreplicated, ctx.Err())
+		case <-time.After(barrierRetryInterval):
+		}
+	}
+}
+
+// unreplicatedWrite is a write whose barrier is not yet satisfied, with
+// the alive peers that have not acknowledged it
+type unreplicatedWrite struct {
+	key     string
+	version uint64
+	missing []string
+}
+
+// unreplicatedWrites returns the writes of keys whose acknowledgements do
+// not yet satisfy a barrier
+func (c *Coordinator) unreplicatedWrites(keys []string) []unreplicatedWrite {
+	peers := c.alivePeers()
+
+	// Peer acknowledgements required; this node counts towards a majority
+	required := len(peers)
+	if c.config.BarrierQuorum {
+		required = (len(peers) + 1) / 2
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var pending []unreplicatedWrite
+	for _, key := range keys {
+		version := c.writeVersions[key]
+		if version == 0 {
+			continue
+		}
+
+		var missing []string
+		for _, nodeID := range peers {
+			if c.writeAcks[key][nodeID] < version {
+				missing = append(missing, nodeID)
+			}
+		}
+		if len(peers)-len(missing) < required {
+			pending = append(pending, unreplicatedWrite{key: key, version: version, missing: missing})
+		}
+	}
+	return pending
+}
+
+// alivePeers returns the alive nodes other than this one, sorted
+func (c *Coordinator) alivePeers() []string {
+	self := c.cluster.GetNodeID()
+	var peers []string
+	for nodeID, node := range c.cluster.GetNodes() {
+		if nodeID != self && node.Status == NodeStatusAlive {
+			peers = append(peers, nodeID)
+		}
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// sendWriteNotices delivers the notice of a write to nodes in parallel,
+// recording their acknowledgements. Failed deliveries are left for a
+// barrier to retry.
+func (c *Coordinator) sendWriteNotices(ctx context.Context, key string, version uint64, nodes []string) {
+	c.mu.RLock()
+	transport := c.writeTransport
+	c.mu.RUnlock()
+	if transport == nil {
+		return
+	}
+
+	notice := &WriteNotice{NodeID: c.cluster.GetNodeID(), Key: key, Version: version}
+	var wg sync.WaitGroup
+	for _, nodeID := range nodes {
+		wg.Add(1)
+		go func(nodeID string) {
+			defer wg.Done()
+			ack, err := transport(ctx, nodeID, notice)
+			if err != nil {
+				c.logger.Debug("Write notice not delivered", "node", nodeID, "key", key, "version", version, "error", err)
+				return
+			}
+			c.recordWriteAck(ack)
+		}(nodeID)
+	}
+	wg.Wait()
+}
+
+// recordWriteAck remembers the highest version a node acknowledged for a key
+func (c *Coordinator) recordWriteAck(ack *WriteAck) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	acks, ok := c.writeAcks[ack.Key]
+	if !ok {
+		acks = make(map[string]uint64)
+		c.writeAcks[ack.Key] = acks
+	}
+	if ack.Version > acks[ack.NodeID] {
+		acks[ack.NodeID] = ack.Version
+	}
+}
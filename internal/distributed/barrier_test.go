@@ -0,0 +1,97 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/cache"
+)
+
+// readThrough reads key through c, filling it from store on a miss
+func readThrough(c *cache.LRUCache, store *sync.Map, key string) string {
+	if data := c.Get(key, 0, 0); data != nil {
+		return string(data)
+	}
+	value, _ := store.Load(key)
+	c.Put(key, 0, []byte(value.(string)))
+	return value.(string)
+}
+
+func TestBarrierMakesWriteVisibleOnPeer(t *testing.T) {
+	writer := newTestConsensus(t, "node-a")
+	reader := newTestConsensus(t, "node-b")
+	for _, cm := range []*ClusterManager{writer, reader} {
+		addReplica(cm, "node-a", 0, time.Now())
+		addReplica(cm, "node-b", 0, time.Now())
+	}
+
+	readerCache := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100, TTL: time.Hour, CleanupInterval: time.Hour})
+	t.Cleanup(func() { _ = readerCache.Close() })
+	reader.coordinator.InvalidateOnPeerWrites(readerCache)
+
+	// The first notice is lost, as on a partitioned or lagging network
+	var mu sync.Mutex
+	delivered := 0
+	writer.coordinator.SetWriteNoticeTransport(func(ctx context.Context, nodeID string, notice *WriteNotice) (*WriteAck, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered++
+		if delivered == 1 {
+			return nil, fmt.Errorf("notice to %s lost", nodeID)
+		}
+		return reader.coordinator.HandleWriteNotice(notice), nil
+	})
+
+	var store sync.Map
+	store.Store("jobs/42/output", "v1")
+	if got := readThrough(readerCache, &store, "jobs/42/output"); got != "v1" {
+		t.Fatalf("initial read = %q, want v1", got)
+	}
+
+	store.Store("jobs/42/output", "v2")
+	op := &DistributedOperation{ID: "put-output", Type: OpTypePut, Key: "jobs/42/output", Consistency: ConsistencyEventual}
+	if _, err := writer.coordinator.ExecuteOperation(context.Background(), op); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := writer.coordinator.Barrier(ctx, []string{"jobs/42/output", "jobs/42/unwritten"}); err != nil {
+		t.Fatalf("Barrier failed: %v", err)
+	}
+	if got := readThrough(readerCache, &store, "jobs/42/output"); got != "v2" {
+		t.Errorf("read on peer after barrier = %q, want v2", got)
+	}
+}
+
+func TestBarrierTimesOutWithoutAcknowledgement(t *testing.T) {
+	writer := newTestConsensus(t, "node-a")
+	addReplica(writer, "node-a", 0, time.Now())
+	addReplica(writer, "node-b", 0, time.Now())
+	addReplica(writer, "node-c", 0, time.Now())
+
+	writer.coordinator.SetWriteNoticeTransport(func(ctx context.Context, nodeID string, notice *WriteNotice) (*WriteAck, error) {
+		if nodeID == "node-c" {
+			return nil, fmt.Errorf("%s unreachable", nodeID)
+		}
+		return &WriteAck{NodeID: nodeID, Key: notice.Key, Version: notice.Version}, nil
+	})
+	writer.coordinator.RecordWrite("data/a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := writer.coordinator.Barrier(ctx, []string{"data/a"})
+	if err == nil || !strings.Contains(err.Error(), "data/a") {
+		t.Fatalf("Barrier with an unreachable peer error = %v, want it to name data/a", err)
+	}
+
+	// A majority of the three nodes has applied the write
+	writer.config.BarrierQuorum = true
+	if err := writer.coordinator.Barrier(context.Background(), []string{"data/a"}); err != nil {
+		t.Errorf("quorum Barrier failed: %v", err)
+	}
+}
@@ -5,10 +5,11 @@ import (
 	cryptorand "crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/objectfs/objectfs/internal/config"
 	"github.com/objectfs/objectfs/pkg/types"
 )
 
@@ -23,7 +24,11 @@ type ClusterManager struct {
 	coordinator *Coordinator
 	gossip      *GossipProtocol
 	consensus   *ConsensusEngine
+	load        *LocalLoadProvider
+	events      *eventBus
+	partitioned bool
 	stats       *ClusterStats
+	logger      *slog.Logger
 	stopCh      chan struct{}
 	stopped     chan struct{}
 }
@@ -44,21 +49,81 @@ type ClusterConfig struct {
 	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
 	LeadershipTTL     time.Duration `yaml:"leadership_ttl"`
 
-	// Gossip protocol
-	GossipInterval  time.Duration `yaml:"gossip_interval"`
-	GossipFanout    int           `yaml:"gossip_fanout"`
-	MaxGossipPacket int           `yaml:"max_gossip_packet"`
+	// Gossip protocol. GossipFanout fixes the number of peers contacted per
+	// round; when zero, fanout scales with log2 of the alive members within
+	// GossipFanoutMin and GossipFanoutMax. GossipBandwidth caps the bytes per
+	// second this node gossips; when zero, gossip is unlimited.
+	GossipInterval   time.Duration `yaml:"gossip_interval"`
+	GossipFanout     int           `yaml:"gossip_fanout"`
+	GossipFanoutMin  int           `yaml:"gossip_fanout_min"`
+	GossipFanoutMax  int           `yaml:"gossip_fanout_max"`
+	PushPullInterval time.Duration `yaml:"push_pull_interval"` // Full state exchange with one random peer
+	MaxGossipPacket  int           `yaml:"max_gossip_packet"`
+	GossipBandwidth  int64         `yaml:"gossip_bandwidth"`
 
 	// Cache coordination
 	CacheReplication  bool   `yaml:"cache_replication"`
 	ReplicationFactor int    `yaml:"replication_factor"`
 	ConsistencyLevel  string `yaml:"consistency_level"` // "eventual", "strong", "session"
 
+	// Writes not yet replicated wait in a queue of up to
+	// ReplicationQueueSize tasks; writes wait for space while it is full.
+	// Pending tasks persist in ReplicationQueueDir when set, and resume
+	// after a restart.
+	ReplicationQueueSize int    `yaml:"replication_queue_size"`
+	ReplicationQueueDir  string `yaml:"replication_queue_dir"`
+
+	// Consistency of prefix operations such as recursive deletes. Strong
+	// deletes apply on every node or on none.
+	PrefixConsistency string `yaml:"prefix_consistency"`
+
+	// Reads with PreferFollowers go to followers whose applied state was
+	// reported within this bound
+	FollowerReadStaleness time.Duration `yaml:"follower_read_staleness"`
+
+	// Barriers return once a majority of alive nodes applied the named
+	// writes, instead of every alive node. Use it when reads are served by
+	// a quorum rather than by any single node.
+	BarrierQuorum bool `yaml:"barrier_quorum"`
+
+	// Strong-consistency writes carry the consensus term they were issued
+	// under, and nodes reject writes older than the newest term they have
+	// seen, so a deposed leader cannot apply writes after an election
+	WriteFencing bool `yaml:"write_fencing"`
+
+	// Consistency verification reads up to VerifySampleSize keys of a
+	// prefix from every replica, at most VerifyKeysPerSecond keys a second,
+	// and rewrites divergent replicas when VerifyRepair is set
+	VerifySampleSize    int     `yaml:"verify_sample_size"`
+	VerifyKeysPerSecond float64 `yaml:"verify_keys_per_second"`
+	VerifyRepair        bool    `yaml:"verify_repair"`
+
+	// Repairs of divergent replicas run at most once per key per interval
+	// and within a repair bandwidth budget
+	RepairThrottle RepairThrottleConfig `yaml:"repair_throttle"`
+
+	// Log compaction. Once SnapshotThreshold entries, or entries holding
+	// SnapshotThresholdBytes of data, are applied past the last snapshot, the
+	// state machine is snapshotted and the log truncated. A negative
+	// threshold disables that trigger. Snapshots persist in SnapshotDir when
+	// set.
+	SnapshotThreshold      int    `yaml:"snapshot_threshold"`
+	SnapshotThresholdBytes int64  `yaml:"snapshot_threshold_bytes"`
+	SnapshotDir            string `yaml:"snapshot_dir"`
+
+	// TLS secures the TCP connections nodes exchange consensus messages
+	// over, and authenticates both ends when RequireClientCert is set
+	TLS ClusterTLS `yaml:"tls"`
+
 	// Performance settings
 	MaxConcurrentOps int           `yaml:"max_concurrent_ops"`
 	OperationTimeout time.Duration `yaml:"operation_timeout"`
 	RetryAttempts    int           `yaml:"retry_attempts"`
 	RetryBackoff     time.Duration `yaml:"retry_backoff"`
+
+	// Logger receives structured logs from every cluster component; the
+	// process default logger is used when nil
+	Logger *slog.Logger `yaml:"-"`
 }
 
 // NodeInfo represents information about a cluster node
@@ -71,6 +136,7 @@ type NodeInfo struct {
 	Metadata map[string]string `json:"metadata"`
 
 	// Resource information
+	Load             float64 `json:"load"` // Normalized 0-1 load score reported by the node
 	CPUUsage         float64 `json:"cpu_usage"`
 	MemoryUsage      float64 `json:"memory_usage"`
 	DiskUsage        float64 `json:"disk_usage"`
@@ -148,8 +214,14 @@ func applyConfigDefaults(config *ClusterConfig) {
 	if config.GossipInterval == 0 {
 		config.GossipInterval = 500 * time.Millisecond
 	}
-	if config.GossipFanout == 0 {
-		config.GossipFanout = 3
+	if config.GossipFanoutMin == 0 {
+		config.GossipFanoutMin = 2
+	}
+	if config.GossipFanoutMax == 0 {
+		config.GossipFanoutMax = 8
+	}
+	if config.PushPullInterval == 0 {
+		config.PushPullInterval = 10 * time.Second
 	}
 	if config.MaxGossipPacket == 0 {
 		config.MaxGossipPacket = 1024
@@ -157,9 +229,27 @@ func applyConfigDefaults(config *ClusterConfig) {
 	if config.ReplicationFactor == 0 {
 		config.ReplicationFactor = 3
 	}
+	if config.ReplicationQueueSize == 0 {
+		config.ReplicationQueueSize = defaultReplicationQueueSize
+	}
 	if config.ConsistencyLevel == "" {
 		config.ConsistencyLevel = "eventual"
 	}
+	if config.PrefixConsistency == "" {
+		config.PrefixConsistency = string(ConsistencyStrong)
+	}
+	if config.FollowerReadStaleness == 0 {
+		config.FollowerReadStaleness = 5 * time.Second
+	}
+	if config.VerifySampleSize == 0 {
+		config.VerifySampleSize = defaultVerifySampleSize
+	}
+	if config.VerifyKeysPerSecond == 0 {
+		config.VerifyKeysPerSecond = defaultVerifyKeysPerSecond
+	}
+	if config.SnapshotThreshold == 0 {
+		config.SnapshotThreshold = 8192
+	}
 	if config.MaxConcurrentOps == 0 {
 		config.MaxConcurrentOps = 100
 	}
@@ -174,32 +264,64 @@ func applyConfigDefaults(config *ClusterConfig) {
 	}
 }
 
+// componentLogger returns the configured logger tagged with component
+func componentLogger(config *ClusterConfig, component string) *slog.Logger {
+	logger := slog.Default()
+	if config != nil && config.Logger != nil {
+		logger = config.Logger
+	}
+	return logger.With("component", component)
+}
+
+// NewClusterConfig returns the cluster settings of the cluster section of
+// the application configuration; NewClusterManager fills in the rest
+func NewClusterConfig(app config.ClusterConfig) *ClusterConfig {
+	return &ClusterConfig{
+		NodeID:            app.NodeID,
+		ListenAddr:        app.ListenAddr,
+		AdvertiseAddr:     app.AdvertiseAddr,
+		SeedNodes:         app.SeedNodes,
+		ReplicationFactor: app.ReplicationFactor,
+		ConsistencyLevel:  app.ConsistencyLevel,
+		TLS:               ClusterTLS(app.TLS),
+	}
+}
+
 // NewClusterManager creates a new cluster manager
 func NewClusterManager(config *ClusterConfig) (*ClusterManager, error) {
 	if config == nil {
 		config = &ClusterConfig{
-			ListenAddr:        "0.0.0.0:8080",
-			AdvertiseAddr:     "127.0.0.1:8080",
-			JoinTimeout:       30 * time.Second,
-			ElectionTimeout:   5 * time.Second,
-			HeartbeatInterval: 1 * time.Second,
-			LeadershipTTL:     10 * time.Second,
-			GossipInterval:    500 * time.Millisecond,
-			GossipFanout:      3,
-			MaxGossipPacket:   1024,
-			CacheReplication:  true,
-			ReplicationFactor: 3,
-			ConsistencyLevel:  "eventual",
-			MaxConcurrentOps:  100,
-			OperationTimeout:  30 * time.Second,
-			RetryAttempts:     3,
-			RetryBackoff:      time.Second,
+			ListenAddr:            "0.0.0.0:8080",
+			AdvertiseAddr:         "127.0.0.1:8080",
+			JoinTimeout:           30 * time.Second,
+			ElectionTimeout:       5 * time.Second,
+			HeartbeatInterval:     1 * time.Second,
+			LeadershipTTL:         10 * time.Second,
+			GossipInterval:        500 * time.Millisecond,
+			GossipFanoutMin:       2,
+			GossipFanoutMax:       8,
+			PushPullInterval:      10 * time.Second,
+			MaxGossipPacket:       1024,
+			CacheReplication:      true,
+			ReplicationFactor:     3,
+			ConsistencyLevel:      "eventual",
+			PrefixConsistency:     "strong",
+			FollowerReadStaleness: 5 * time.Second,
+			SnapshotThreshold:     8192,
+			MaxConcurrentOps:      100,
+			OperationTimeout:      30 * time.Second,
+			RetryAttempts:         3,
+			RetryBackoff:          time.Second,
 		}
 	}
 
 	// Apply defaults for zero-valued fields
 	applyConfigDefaults(config)
 
+	if err := config.TLS.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Generate node ID if not provided
 	if config.NodeID == "" {
 		nodeIDBytes := make([]byte, 8)
@@ -213,11 +335,16 @@ func NewClusterManager(config *ClusterConfig) (*ClusterManager, error) {
 		config:  config,
 		nodeID:  config.NodeID,
 		nodes:   make(map[string]*NodeInfo),
+		events:  &eventBus{},
 		stats:   &ClusterStats{},
+		logger:  componentLogger(config, "cluster").With("node_id", config.NodeID),
 		stopCh:  make(chan struct{}),
 		stopped: make(chan struct{}),
 	}
 
+	// Sample local load for least-load routing across the cluster
+	cm.load = NewLocalLoadProvider(LoadProviderConfig{MaxInFlight: config.MaxConcurrentOps})
+
 	// Initialize components
 	var err error
 	cm.coordinator, err = NewCoordinator(cm, config)
@@ -243,7 +370,7 @@ func (cm *ClusterManager) Start(ctx context.Context) error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	log.Printf("Starting cluster manager for node %s", cm.nodeID)
+	cm.logger.Info("Starting cluster manager")
 
 	// Add self to nodes
 	cm.nodes[cm.nodeID] = &NodeInfo{
@@ -254,6 +381,7 @@ func (cm *ClusterManager) Start(ctx context.Context) error {
 		Version:  "1.0.0",
 		Metadata: make(map[string]string),
 	}
+	cm.statusChangedLocked(cm.nodeID, "", NodeStatusAlive)
 
 	// Start components
 	if err := cm.gossip.Start(ctx); err != nil {
@@ -277,7 +405,7 @@ func (cm *ClusterManager) Start(ctx context.Context) error {
 	go cm.monitorCluster(ctx)
 	go cm.updateStats(ctx)
 
-	log.Printf("Cluster manager started successfully")
+	cm.logger.Info("Cluster manager started")
 	return nil
 }
 
@@ -296,8 +424,9 @@ func (cm *ClusterManager) Stop() error {
 		_ = cm.consensus.Stop()
 	}
 
+	cm.events.close()
 	close(cm.stopped)
-	log.Printf("Cluster manager stopped")
+	cm.logger.Info("Cluster manager stopped")
 	return nil
 }
 
@@ -320,6 +449,30 @@ func (cm *ClusterManager) GetLeader() string {
 	return cm.leader
 }
 
+// LoadProvider returns the provider sampling this node's load
+func (cm *ClusterManager) LoadProvider() *LocalLoadProvider {
+	return cm.load
+}
+
+// AppliedIndex returns the index of the last log entry this node applied
+func (cm *ClusterManager) AppliedIndex() uint64 {
+	if cm.consensus == nil {
+		return 0
+	}
+	cm.consensus.mu.RLock()
+	defer cm.consensus.mu.RUnlock()
+	return cm.consensus.lastApplied
+}
+
+// Epoch returns the consensus term this node is in, used as the fencing
+// token of strong-consistency writes
+func (cm *ClusterManager) Epoch() uint64 {
+	if cm.consensus == nil {
+		return 0
+	}
+	return cm.consensus.GetCurrentTerm()
+}
+
 // GetNodes returns information about all known nodes
 func (cm *ClusterManager) GetNodes() map[string]*NodeInfo {
 	cm.mu.RLock()
@@ -421,18 +574,18 @@ func (cm *ClusterManager) joinCluster(ctx context.Context) {
 			continue // Don't try to join ourselves
 		}
 
-		log.Printf("Attempting to join cluster via seed node: %s", seedAddr)
+		cm.logger.Info("Joining cluster via seed node", "seed", seedAddr)
 
 		if err := cm.gossip.JoinNode(ctx, seedAddr); err != nil {
-			log.Printf("Failed to join via %s: %v", seedAddr, err)
+			cm.logger.Warn("Failed to join via seed node", "seed", seedAddr, "error", err)
 			continue
 		}
 
-		log.Printf("Successfully joined cluster via %s", seedAddr)
+		cm.logger.Info("Joined cluster", "seed", seedAddr)
 		return
 	}
 
-	log.Printf("Failed to join cluster via any seed node")
+	cm.logger.Error("Failed to join cluster via any seed node", "seeds", len(cm.config.SeedNodes))
 }
 
 func (cm *ClusterManager) monitorCluster(ctx context.Context) {
@@ -471,21 +624,16 @@ func (cm *ClusterManager) performHealthChecks() {
 		case NodeStatusAlive:
 			if timeSinceLastSeen > deadlineTimeout {
 				node.Status = NodeStatusSuspect
-				log.Printf("Node %s marked as suspect (last seen: %v ago)", nodeID, timeSinceLastSeen)
+				cm.logger.Warn("Node marked as suspect", "node", nodeID, "last_seen_ago", timeSinceLastSeen)
+				cm.statusChangedLocked(nodeID, NodeStatusAlive, NodeStatusSuspect)
 			}
 		case NodeStatusSuspect:
 			if timeSinceLastSeen > deadlineTimeout*2 {
 				node.Status = NodeStatusDead
-				log.Printf("Node %s marked as dead (last seen: %v ago)", nodeID, timeSinceLastSeen)
-
-				// If the dead node was the leader, trigger election
-				if nodeID == cm.leader {
-					cm.leader = ""
-					cm.isLeader = false
-					go func() {
-						_ = cm.consensus.TriggerElection(context.Background())
-					}()
-				}
+				cm.logger.Warn("Node marked as dead", "node", nodeID, "last_seen_ago", timeSinceLastSeen)
+
+				// Clears leadership and triggers an election if it was the leader
+				cm.statusChangedLocked(nodeID, NodeStatusSuspect, NodeStatusDead)
 			}
 		}
 	}
@@ -507,30 +655,28 @@ func (cm *ClusterManager) updateStats(ctx context.Context) {
 	}
 }
 
+// calculateClusterStats recounts nodes by status. It holds cm.mu throughout,
+// taking it before cm.stats.mu as setLeaderLocked does, so a leadership
+// change cannot be overwritten by the leader read before it.
 func (cm *ClusterManager) calculateClusterStats() {
 	cm.mu.RLock()
-	nodes := make(map[string]*NodeInfo)
-	for id, info := range cm.nodes {
-		nodes[id] = info
-	}
-	leader := cm.leader
-	cm.mu.RUnlock()
+	defer cm.mu.RUnlock()
 
 	cm.stats.mu.Lock()
 	defer cm.stats.mu.Unlock()
 
 	// Reset counters
-	cm.stats.TotalNodes = len(nodes)
+	cm.stats.TotalNodes = len(cm.nodes)
 	cm.stats.AliveNodes = 0
 	cm.stats.SuspectNodes = 0
 	cm.stats.DeadNodes = 0
-	cm.stats.CurrentLeader = leader
+	cm.stats.CurrentLeader = cm.leader
 
 	totalCacheSize := int64(0)
 	totalCacheHitRate := 0.0
 	aliveNodesCount := 0
 
-	for _, node := range nodes {
+	for _, node := range cm.nodes {
 		switch node.Status {
 		case NodeStatusAlive:
 			cm.stats.AliveNodes++
@@ -557,8 +703,10 @@ func (cm *ClusterManager) UpdateNodeInfo(nodeID string, info *NodeInfo) {
 
 	if existing, exists := cm.nodes[nodeID]; exists {
 		// Update existing node
+		previous := existing.Status
 		existing.LastSeen = info.LastSeen
 		existing.Status = info.Status
+		existing.Load = info.Load
 		existing.CPUUsage = info.CPUUsage
 		existing.MemoryUsage = info.MemoryUsage
 		existing.DiskUsage = info.DiskUsage
@@ -571,6 +719,7 @@ func (cm *ClusterManager) UpdateNodeInfo(nodeID string, info *NodeInfo) {
 		for k, v := range info.Metadata {
 			existing.Metadata[k] = v
 		}
+		cm.statusChangedLocked(nodeID, previous, info.Status)
 	} else {
 		// Add new node
 		newNode := *info
@@ -579,6 +728,12 @@ func (cm *ClusterManager) UpdateNodeInfo(nodeID string, info *NodeInfo) {
 			newNode.Metadata[k] = v
 		}
 		cm.nodes[nodeID] = &newNode
+
+		if newNode.Status == NodeStatusAlive || newNode.Status == NodeStatusJoining {
+			cm.statusChangedLocked(nodeID, "", newNode.Status)
+		} else {
+			cm.checkPartitionLocked()
+		}
 	}
 }
 
@@ -587,14 +742,23 @@ func (cm *ClusterManager) SetLeader(nodeID string) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
+	cm.setLeaderLocked(nodeID)
+}
+
+// setLeaderLocked records a leadership change and publishes it. The caller
+// must hold cm.mu.
+func (cm *ClusterManager) setLeaderLocked(nodeID string) {
 	if cm.leader != nodeID {
-		log.Printf("Leadership changed from %s to %s", cm.leader, nodeID)
+		cm.logger.Info("Leadership changed", "previous_leader", cm.leader, "leader", nodeID)
+		cm.events.publish(ClusterEvent{Type: EventLeaderChanged, Leader: nodeID, PreviousLeader: cm.leader})
 		cm.leader = nodeID
 		cm.isLeader = (nodeID == cm.nodeID)
 
 		cm.stats.mu.Lock()
-		cm.stats.LeaderElections++
-		cm.stats.LastElectionTime = time.Now()
+		if nodeID != "" {
+			cm.stats.LeaderElections++
+			cm.stats.LastElectionTime = time.Now()
+		}
 		cm.stats.CurrentLeader = nodeID
 		cm.stats.mu.Unlock()
 	}
@@ -605,15 +769,20 @@ func (cm *ClusterManager) RemoveNode(nodeID string) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	if _, exists := cm.nodes[nodeID]; exists {
+	if node, exists := cm.nodes[nodeID]; exists {
 		delete(cm.nodes, nodeID)
-		log.Printf("Node %s removed from cluster", nodeID)
+		cm.logger.Info("Node removed from cluster", "node", nodeID)
+
+		// A leaving node already announced its departure
+		if node.Status != NodeStatusLeaving {
+			cm.events.publish(ClusterEvent{Type: EventNodeLeft, NodeID: nodeID})
+		}
 
 		// If the removed node was the leader, clear leadership
 		if nodeID == cm.leader {
-			cm.leader = ""
-			cm.isLeader = false
+			cm.setLeaderLocked("")
 		}
+		cm.checkPartitionLocked()
 	}
 }
 
@@ -0,0 +1,252 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// clusterConfigKey is the value store key holding the cluster config
+	clusterConfigKey = "objectfs/cluster-config"
+
+	// configHistoryLimit bounds the earlier versions kept for rollback
+	configHistoryLimit = 16
+
+	// configProposeAttempts bounds the retries of a config change racing
+	// other changes
+	configProposeAttempts = 8
+)
+
+// ErrConfigVersionNotFound is returned by Rollback for a version that was
+// never committed or is no longer kept
+var ErrConfigVersionNotFound = errors.New("config version not found")
+
+// ClusterSettings is configuration every node must agree on
+type ClusterSettings struct {
+	ReplicationFactor int              `json:"replication_factor"`
+	ConsistencyLevel  ConsistencyLevel `json:"consistency_level"`
+
+	// Consistency of keys under a prefix, overriding ConsistencyLevel; the
+	// longest matching prefix wins
+	PrefixConsistency map[string]ConsistencyLevel `json:"prefix_consistency,omitempty"`
+
+	Lifecycle []LifecycleRule `json:"lifecycle,omitempty"`
+}
+
+// LifecycleRule expires or transitions the objects under a prefix
+type LifecycleRule struct {
+	Prefix          string        `json:"prefix"`
+	ExpireAfter     time.Duration `json:"expire_after,omitempty"`
+	TransitionAfter time.Duration `json:"transition_after,omitempty"`
+	StorageClass    string        `json:"storage_class,omitempty"` // Target of the transition
+}
+
+// Validate checks that the settings can be applied
+func (s *ClusterSettings) Validate() error {
+	if s.ReplicationFactor < 1 {
+		return fmt.Errorf("replication factor must be at least 1, got %d", s.ReplicationFactor)
+	}
+	if err := validateConsistency(s.ConsistencyLevel); err != nil {
+		return err
+	}
+	for prefix, level := range s.PrefixConsistency {
+		if err := validateConsistency(level); err != nil {
+			return fmt.Errorf("prefix %q: %w", prefix, err)
+		}
+	}
+	for i, rule := range s.Lifecycle {
+		if rule.ExpireAfter < 0 || rule.TransitionAfter < 0 {
+			return fmt.Errorf("lifecycle rule %d: ages must not be negative", i)
+		}
+		if rule.ExpireAfter == 0 && rule.TransitionAfter == 0 {
+			return fmt.Errorf("lifecycle rule %d: needs expire_after or transition_after", i)
+		}
+		if rule.TransitionAfter > 0 && rule.StorageClass == "" {
+			return fmt.Errorf("lifecycle rule %d: transition needs a storage class", i)
+		}
+	}
+	return nil
+}
+
+// validateConsistency checks that level is a known consistency level
+func validateConsistency(level ConsistencyLevel) error {
+	switch level {
+	case ConsistencyEventual, ConsistencyStrong, ConsistencySession:
+		return nil
+	}
+	return fmt.Errorf("invalid consistency level: %q (must be %s, %s or %s)", level, ConsistencyEventual, ConsistencyStrong, ConsistencySession)
+}
+
+// ConsistencyFor returns the consistency level of key
+func (s *ClusterSettings) ConsistencyFor(key string) ConsistencyLevel {
+	level, longest := s.ConsistencyLevel, -1
+	for prefix, prefixLevel := range s.PrefixConsistency {
+		if strings.HasPrefix(key, prefix) && len(prefix) > longest {
+			level, longest = prefixLevel, len(prefix)
+		}
+	}
+	return level
+}
+
+// ConfigVersion is one committed version of the cluster settings
+type ConfigVersion struct {
+	Version    uint64          `json:"version"`
+	Settings   ClusterSettings `json:"settings"`
+	ProposedBy string          `json:"proposed_by"`
+	Time       time.Time       `json:"time"`
+	RollbackOf uint64          `json:"rollback_of,omitempty"` // Version whose settings were restored
+}
+
+// configRecord is the value stored under clusterConfigKey
+type configRecord struct {
+	Current ConfigVersion   `json:"current"`
+	History []ConfigVersion `json:"history,omitempty"` // Earlier versions, oldest first
+}
+
+// ConfigStore replicates cluster settings through the consensus log, so
+// they are set once and every node applies the same version. A change is
+// committed as a single value, so a node sees either all of it or none of
+// it, and changes proposed on followers are forwarded to the leader. A
+// restarted node reloads the committed version as it replays the log.
+type ConfigStore struct {
+	store  *ReplicatedValueStore
+	nodeID string
+
+	mu        sync.RWMutex
+	record    configRecord
+	listeners []func(ConfigVersion)
+}
+
+// NewConfigStore creates a config store on a node's replicated value
+// store, loading the version it already holds
+func NewConfigStore(cluster *ClusterManager, store *ReplicatedValueStore) (*ConfigStore, error) {
+	if cluster == nil || store == nil {
+		return nil, fmt.Errorf("config store requires a cluster and a value store")
+	}
+
+	cs := &ConfigStore{store: store, nodeID: cluster.GetNodeID()}
+	store.Watch(clusterConfigKey, cs.apply)
+	return cs, nil
+}
+
+// apply loads a committed config record, notifying listeners when it
+// holds a new version
+func (cs *ConfigStore) apply(data []byte, _ uint64) {
+	if data == nil {
+		return
+	}
+	var record configRecord
+	if err := cs.store.codec.Unmarshal(data, &record); err != nil {
+		return
+	}
+
+	cs.mu.Lock()
+	if record.Current.Version <= cs.record.Current.Version {
+		cs.mu.Unlock()
+		return
+	}
+	cs.record = record
+	listeners := append([]func(ConfigVersion){}, cs.listeners...)
+	cs.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(record.Current)
+	}
+}
+
+// OnChange calls fn with each version this node commits from now on.
+// Components holding cluster settings, such as the replication factor,
+// apply them from fn.
+func (cs *ConfigStore) OnChange(fn func(ConfigVersion)) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.listeners = append(cs.listeners, fn)
+}
+
+// CommittedVersion returns the config version applied on this node, 0
+// before any config is committed
+func (cs *ConfigStore) CommittedVersion() uint64 {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.record.Current.Version
+}
+
+// Current returns the config version applied on this node
+func (cs *ConfigStore) Current() ConfigVersion {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.record.Current
+}
+
+// History returns the earlier versions kept for rollback, oldest first
+func (cs *ConfigStore) History() []ConfigVersion {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return append([]ConfigVersion(nil), cs.record.History...)
+}
+
+// ProposeConfigChange commits settings as the next config version and
+// returns it once applied on this node
+func (cs *ConfigStore) ProposeConfigChange(ctx context.Context, settings ClusterSettings) (ConfigVersion, error) {
+	if err := settings.Validate(); err != nil {
+		return ConfigVersion{}, fmt.Errorf("invalid cluster config: %w", err)
+	}
+	return cs.commit(ctx, func(configRecord) (ConfigVersion, error) {
+		return ConfigVersion{Settings: settings}, nil
+	})
+}
+
+// Rollback commits the settings of an earlier version as the next version
+func (cs *ConfigStore) Rollback(ctx context.Context, version uint64) (ConfigVersion, error) {
+	return cs.commit(ctx, func(record configRecord) (ConfigVersion, error) {
+		i := sort.Search(len(record.History), func(i int) bool {
+			return record.History[i].Version >= version
+		})
+		if i == len(record.History) || record.History[i].Version != version {
+			return ConfigVersion{}, fmt.Errorf("%w: %d", ErrConfigVersionNotFound, version)
+		}
+		return ConfigVersion{Settings: record.History[i].Settings, RollbackOf: version}, nil
+	})
+}
+
+// commit swaps in the version next builds from the committed record,
+// retrying when another change commits first
+func (cs *ConfigStore) commit(ctx context.Context, next func(configRecord) (ConfigVersion, error)) (ConfigVersion, error) {
+	var err error
+	for attempt := 0; attempt < configProposeAttempts; attempt++ {
+		var record configRecord
+		revision, getErr := cs.store.Get(ctx, clusterConfigKey, &record)
+		if getErr != nil && !errors.Is(getErr, ErrValueNotFound) {
+			return ConfigVersion{}, fmt.Errorf("failed to read cluster config: %w", getErr)
+		}
+
+		version, nextErr := next(record)
+		if nextErr != nil {
+			return ConfigVersion{}, nextErr
+		}
+		version.Version = record.Current.Version + 1
+		version.ProposedBy = cs.nodeID
+		version.Time = time.Now()
+
+		updated := configRecord{Current: version, History: record.History}
+		if record.Current.Version > 0 {
+			updated.History = append(updated.History, record.Current)
+		}
+		if excess := len(updated.History) - configHistoryLimit; excess > 0 {
+			updated.History = updated.History[excess:]
+		}
+
+		if _, err = cs.store.CompareAndSwap(ctx, clusterConfigKey, revision, updated); err == nil {
+			return version, nil
+		}
+		if !errors.Is(err, ErrRevisionMismatch) {
+			return ConfigVersion{}, fmt.Errorf("failed to commit cluster config version %d: %w", version.Version, err)
+		}
+	}
+	return ConfigVersion{}, fmt.Errorf("failed to commit cluster config after %d attempts: %w", configProposeAttempts, err)
+}
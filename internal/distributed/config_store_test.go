@@ -0,0 +1,135 @@
+package distributed
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConfigStoreReplicatesAndRollsBack(t *testing.T) {
+	ctx := simContext(t)
+	sim, stores := newReplicatedStores(t, ctx, 3)
+	configs := make(map[string]*ConfigStore)
+	for id, store := range stores {
+		cs, err := NewConfigStore(sim.Node(id), store)
+		if err != nil {
+			t.Fatalf("NewConfigStore(%s) failed: %v", id, err)
+		}
+		configs[id] = cs
+	}
+
+	var mu sync.Mutex
+	var notified []uint64
+	configs["node-3"].OnChange(func(v ConfigVersion) {
+		mu.Lock()
+		defer mu.Unlock()
+		notified = append(notified, v.Version)
+	})
+
+	if _, err := configs["node-1"].ProposeConfigChange(ctx, ClusterSettings{ReplicationFactor: 0, ConsistencyLevel: ConsistencyStrong}); err == nil {
+		t.Fatal("ProposeConfigChange accepted a replication factor of 0")
+	}
+
+	// A follower's change is forwarded to the leader and applied everywhere
+	first := ClusterSettings{
+		ReplicationFactor: 3,
+		ConsistencyLevel:  ConsistencyEventual,
+		PrefixConsistency: map[string]ConsistencyLevel{"locks/": ConsistencyStrong},
+		Lifecycle:         []LifecycleRule{{Prefix: "tmp/", ExpireAfter: 24 * time.Hour}},
+	}
+	v1, err := configs["node-2"].ProposeConfigChange(ctx, first)
+	if err != nil || v1.Version != 1 || v1.ProposedBy != "node-2" {
+		t.Fatalf("ProposeConfigChange on a follower = %+v, %v; want version 1 by node-2", v1, err)
+	}
+	waitForConfigVersion(t, sim, configs, 1)
+	for id, cs := range configs {
+		got := cs.Current().Settings
+		if got.ReplicationFactor != 3 || got.ConsistencyFor("locks/a") != ConsistencyStrong || got.ConsistencyFor("data/a") != ConsistencyEventual || len(got.Lifecycle) != 1 {
+			t.Fatalf("settings on %s = %+v, want %+v", id, got, first)
+		}
+	}
+
+	if _, err := configs["node-1"].ProposeConfigChange(ctx, ClusterSettings{ReplicationFactor: 5, ConsistencyLevel: ConsistencyStrong}); err != nil {
+		t.Fatalf("ProposeConfigChange on the leader failed: %v", err)
+	}
+	waitForConfigVersion(t, sim, configs, 2)
+
+	v3, err := configs["node-3"].Rollback(ctx, 1)
+	if err != nil || v3.Version != 3 || v3.RollbackOf != 1 {
+		t.Fatalf("Rollback(1) = %+v, %v; want version 3 restoring 1", v3, err)
+	}
+	waitForConfigVersion(t, sim, configs, 3)
+	if got := configs["node-1"].Current().Settings; got.ReplicationFactor != 3 || got.ConsistencyLevel != ConsistencyEventual {
+		t.Fatalf("settings after rollback = %+v, want those of version 1", got)
+	}
+	if history := configs["node-2"].History(); len(history) != 2 || history[0].Version != 1 || history[1].Version != 2 {
+		t.Fatalf("History() = %+v, want versions 1 and 2", history)
+	}
+	if _, err := configs["node-1"].Rollback(ctx, 42); !errors.Is(err, ErrConfigVersionNotFound) {
+		t.Fatalf("Rollback of an unknown version = %v, want ErrConfigVersionNotFound", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(notified) != 3 || notified[2] != 3 {
+		t.Fatalf("node-3 listener saw versions %v, want 1, 2 and 3", notified)
+	}
+}
+
+func TestConfigStoreReloadsAfterRestart(t *testing.T) {
+	ctx := simContext(t)
+	sim, stores := newReplicatedStores(t, ctx, 3)
+	leader, err := NewConfigStore(sim.Node("node-1"), stores["node-1"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.ProposeConfigChange(ctx, ClusterSettings{ReplicationFactor: 3, ConsistencyLevel: ConsistencyEventual}); err != nil {
+		t.Fatalf("ProposeConfigChange failed: %v", err)
+	}
+
+	// node-3 misses a change while down, then restarts with empty state
+	if err := sim.Kill("node-3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.ProposeConfigChange(ctx, ClusterSettings{ReplicationFactor: 2, ConsistencyLevel: ConsistencyStrong}); err != nil {
+		t.Fatalf("ProposeConfigChange with a node down failed: %v", err)
+	}
+	if err := sim.Restart("node-3"); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewReplicatedValueStore(sim.Node("node-3"), GobCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted, err := NewConfigStore(sim.Node("node-3"), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sim.waitFor(ctx, func() bool { return restarted.CommittedVersion() == 2 }); err != nil {
+		t.Fatalf("restarted node is at config version %d, want 2: %v", restarted.CommittedVersion(), err)
+	}
+	if got := restarted.Current().Settings; got.ReplicationFactor != 2 || got.ConsistencyLevel != ConsistencyStrong {
+		t.Fatalf("restarted node settings = %+v, want the committed version 2", got)
+	}
+}
+
+// waitForConfigVersion waits until every config store applied version
+func waitForConfigVersion(t *testing.T, sim *ClusterSimulator, configs map[string]*ConfigStore, version uint64) {
+	t.Helper()
+	err := sim.waitFor(simContext(t), func() bool {
+		for _, cs := range configs {
+			if cs.CommittedVersion() != version {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		for id, cs := range configs {
+			t.Logf("%s at config version %d", id, cs.CommittedVersion())
+		}
+		t.Fatalf("config version %d not applied on every node: %v", version, err)
+	}
+}
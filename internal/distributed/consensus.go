@@ -6,7 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"sync"
 	"time"
@@ -17,6 +17,7 @@ type ConsensusEngine struct {
 	mu          sync.RWMutex
 	cluster     *ClusterManager
 	config      *ClusterConfig
+	logger      *slog.Logger
 	state       ConsensusState
 	currentTerm uint64
 	votedFor    string
@@ -32,8 +33,21 @@ type ConsensusEngine struct {
 	electionTimer *time.Timer
 	voteCount     int
 
-	// Proposal state
+	// Proposal state; decided channels close once a proposal leaves pending
 	proposals map[string]*ConsensusProposal
+	decided   map[string]chan struct{}
+	transport ProposalTransport
+
+	// Log compaction; log[0] stands in for the entries through the latest
+	// snapshot, so the entry at index i is log[i-log[0].Index]
+	stateMachine      StateMachine
+	snapshots         *FileSnapshotStore
+	snapshot          *Snapshot
+	snapshotTransport SnapshotTransport
+
+	// Peer RPCs; while unset, votes and replication are simulated locally
+	voteTransport   VoteTransport
+	appendTransport AppendEntriesTransport
 
 	stats  *ConsensusStats
 	stopCh chan struct{}
@@ -138,6 +152,7 @@ const (
 
 // RequestVoteMessage represents a vote request
 type RequestVoteMessage struct {
+	Term         uint64 `json:"term"`
 	CandidateID  string `json:"candidate_id"`
 	LastLogIndex uint64 `json:"last_log_index"`
 	LastLogTerm  uint64 `json:"last_log_term"`
@@ -145,11 +160,13 @@ type RequestVoteMessage struct {
 
 // RequestVoteResponse represents a vote response
 type RequestVoteResponse struct {
-	VoteGranted bool `json:"vote_granted"`
+	Term        uint64 `json:"term"`
+	VoteGranted bool   `json:"vote_granted"`
 }
 
 // AppendEntriesMessage represents a log replication message
 type AppendEntriesMessage struct {
+	Term         uint64      `json:"term"`
 	LeaderID     string      `json:"leader_id"`
 	PrevLogIndex uint64      `json:"prev_log_index"`
 	PrevLogTerm  uint64      `json:"prev_log_term"`
@@ -159,28 +176,34 @@ type AppendEntriesMessage struct {
 
 // AppendEntriesResponse represents a log replication response
 type AppendEntriesResponse struct {
+	Term       uint64 `json:"term"`
 	Success    bool   `json:"success"`
 	MatchIndex uint64 `json:"match_index"`
 }
 
 // ConsensusStats tracks consensus protocol statistics
 type ConsensusStats struct {
-	mu                sync.RWMutex
-	CurrentState      string        `json:"current_state"`
-	CurrentTerm       uint64        `json:"current_term"`
-	CurrentLeader     string        `json:"current_leader"`
-	LogLength         int           `json:"log_length"`
-	CommitIndex       uint64        `json:"commit_index"`
-	LastApplied       uint64        `json:"last_applied"`
-	ElectionsStarted  int64         `json:"elections_started"`
-	ElectionsWon      int64         `json:"elections_won"`
-	VotesCast         int64         `json:"votes_cast"`
-	ProposalsReceived int64         `json:"proposals_received"`
-	ProposalsAccepted int64         `json:"proposals_accepted"`
-	LogEntriesAdded   int64         `json:"log_entries_added"`
-	HeartbeatsSent    int64         `json:"heartbeats_sent"`
-	LastElection      time.Time     `json:"last_election"`
-	Uptime            time.Duration `json:"uptime"`
+	mu                 sync.RWMutex
+	CurrentState       string        `json:"current_state"`
+	CurrentTerm        uint64        `json:"current_term"`
+	CurrentLeader      string        `json:"current_leader"`
+	LogLength          int           `json:"log_length"`
+	CommitIndex        uint64        `json:"commit_index"`
+	LastApplied        uint64        `json:"last_applied"`
+	ElectionsStarted   int64         `json:"elections_started"`
+	ElectionsWon       int64         `json:"elections_won"`
+	VotesCast          int64         `json:"votes_cast"`
+	ProposalsReceived  int64         `json:"proposals_received"`
+	ProposalsAccepted  int64         `json:"proposals_accepted"`
+	ProposalsForwarded int64         `json:"proposals_forwarded"`
+	LogEntriesAdded    int64         `json:"log_entries_added"`
+	HeartbeatsSent     int64         `json:"heartbeats_sent"`
+	SnapshotsTaken     int64         `json:"snapshots_taken"`
+	SnapshotsSent      int64         `json:"snapshots_sent"`
+	SnapshotsInstalled int64         `json:"snapshots_installed"`
+	SnapshotIndex      uint64        `json:"snapshot_index"`
+	LastElection       time.Time     `json:"last_election"`
+	Uptime             time.Duration `json:"uptime"`
 }
 
 // NewConsensusEngine creates a new consensus engine
@@ -188,6 +211,7 @@ func NewConsensusEngine(cluster *ClusterManager, config *ClusterConfig) (*Consen
 	ce := &ConsensusEngine{
 		cluster:     cluster,
 		config:      config,
+		logger:      componentLogger(config, "consensus").With("node_id", cluster.GetNodeID()),
 		state:       StateFollower,
 		currentTerm: 0,
 		votedFor:    "",
@@ -195,6 +219,7 @@ func NewConsensusEngine(cluster *ClusterManager, config *ClusterConfig) (*Consen
 		nextIndex:   make(map[string]uint64),
 		matchIndex:  make(map[string]uint64),
 		proposals:   make(map[string]*ConsensusProposal),
+		decided:     make(map[string]chan struct{}),
 		stats: &ConsensusStats{
 			CurrentState: StateFollower.String(),
 		},
@@ -210,12 +235,23 @@ func NewConsensusEngine(cluster *ClusterManager, config *ClusterConfig) (*Consen
 		Timestamp: time.Now(),
 	})
 
+	if config != nil && config.SnapshotDir != "" {
+		store, err := NewFileSnapshotStore(config.SnapshotDir)
+		if err != nil {
+			return nil, err
+		}
+		ce.snapshots = store
+		if err := ce.loadSnapshot(); err != nil {
+			return nil, err
+		}
+	}
+
 	return ce, nil
 }
 
 // Start starts the consensus engine
 func (ce *ConsensusEngine) Start(ctx context.Context) error {
-	log.Printf("Starting consensus engine for node %s", ce.cluster.GetNodeID())
+	ce.logger.Info("Starting consensus engine")
 
 	// Reset election timer
 	ce.resetElectionTimer()
@@ -237,7 +273,7 @@ func (ce *ConsensusEngine) Stop() error {
 		ce.electionTimer.Stop()
 	}
 
-	log.Printf("Consensus engine stopped")
+	ce.logger.Info("Consensus engine stopped")
 	return nil
 }
 
@@ -251,7 +287,7 @@ func (ce *ConsensusEngine) TriggerElection(ctx context.Context) error {
 		return nil
 	}
 
-	log.Printf("Triggering leader election")
+	ce.logger.Info("Triggering leader election", "term", ce.currentTerm)
 	ce.startElection()
 
 	return nil
@@ -262,15 +298,20 @@ func (ce *ConsensusEngine) ProposeChange(ctx context.Context, proposal *Consensu
 	ce.mu.Lock()
 	defer ce.mu.Unlock()
 
+	_, err := ce.proposeLocked(proposal)
+	return err
+}
+
+// proposeLocked registers and broadcasts proposal, returning a channel that
+// closes once it is decided. Callers hold ce.mu.
+func (ce *ConsensusEngine) proposeLocked(proposal *ConsensusProposal) (<-chan struct{}, error) {
 	if ce.state != StateLeader {
-		return fmt.Errorf("only leader can propose changes")
+		return nil, fmt.Errorf("only leader can propose changes: %w", ErrNotLeader)
 	}
 
 	// Generate proposal ID if not provided
 	if proposal.ID == "" {
-		proposalBytes := make([]byte, 8)
-		_, _ = cryptorand.Read(proposalBytes)
-		proposal.ID = "prop-" + hex.EncodeToString(proposalBytes)
+		proposal.ID = newProposalID()
 	}
 
 	proposal.Status = ProposalStatusPending
@@ -278,16 +319,37 @@ func (ce *ConsensusEngine) ProposeChange(ctx context.Context, proposal *Consensu
 	proposal.Timestamp = time.Now()
 
 	ce.proposals[proposal.ID] = proposal
+	decided := make(chan struct{})
+	ce.decided[proposal.ID] = decided
 
-	// Broadcast proposal to all nodes
-	ce.broadcastProposal(proposal)
+	// With replication transports set, operations are decided by the log
+	if ce.appendTransport != nil && proposal.Type == ProposalTypeOperation {
+		ce.appendOperationLocked(proposal)
+	} else {
+		ce.broadcastProposal(proposal)
+	}
 
 	ce.stats.mu.Lock()
 	ce.stats.ProposalsReceived++
 	ce.stats.mu.Unlock()
 
-	log.Printf("Proposed change: %s (type: %s)", proposal.ID, proposal.Type)
-	return nil
+	ce.logger.Debug("Proposed change", "proposal_id", proposal.ID, "type", proposal.Type)
+	return decided, nil
+}
+
+// newProposalID returns a random proposal ID
+func newProposalID() string {
+	proposalBytes := make([]byte, 8)
+	_, _ = cryptorand.Read(proposalBytes)
+	return "prop-" + hex.EncodeToString(proposalBytes)
+}
+
+// decideLocked wakes callers waiting for proposal to be decided. Callers hold ce.mu.
+func (ce *ConsensusEngine) decideLocked(proposal *ConsensusProposal) {
+	if decided, ok := ce.decided[proposal.ID]; ok {
+		close(decided)
+		delete(ce.decided, proposal.ID)
+	}
 }
 
 // Background loops
@@ -302,7 +364,7 @@ func (ce *ConsensusEngine) electionLoop(ctx context.Context) {
 		case <-ce.electionTimer.C:
 			ce.mu.Lock()
 			if ce.state != StateLeader {
-				log.Printf("Election timeout, starting new election")
+				ce.logger.Info("Election timeout, starting new election", "term", ce.currentTerm)
 				ce.startElection()
 			}
 			ce.mu.Unlock()
@@ -358,7 +420,7 @@ func (ce *ConsensusEngine) startElection() {
 
 	ce.resetElectionTimer()
 
-	log.Printf("Starting election for term %d", ce.currentTerm)
+	ce.logger.Info("Starting election", "term", ce.currentTerm)
 
 	ce.stats.mu.Lock()
 	ce.stats.ElectionsStarted++
@@ -375,6 +437,7 @@ func (ce *ConsensusEngine) sendVoteRequests() {
 	nodes := ce.cluster.GetNodes()
 
 	requestVote := &RequestVoteMessage{
+		Term:         ce.currentTerm,
 		CandidateID:  ce.cluster.GetNodeID(),
 		LastLogIndex: ce.getLastLogIndex(),
 		LastLogTerm:  ce.getLastLogTerm(),
@@ -388,9 +451,24 @@ func (ce *ConsensusEngine) sendVoteRequests() {
 }
 
 func (ce *ConsensusEngine) sendVoteRequest(nodeID string, req *RequestVoteMessage) {
-	// In a real implementation, this would send the message over the network
-	// For simulation, we'll just log and simulate responses
-	log.Printf("Sending vote request to %s for term %d", nodeID, ce.currentTerm)
+	ce.mu.RLock()
+	transport := ce.voteTransport
+	ce.mu.RUnlock()
+
+	ce.logger.Debug("Sending vote request", "peer", nodeID, "term", req.Term)
+
+	if transport != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), ce.config.OperationTimeout)
+		defer cancel()
+
+		resp, err := transport(ctx, nodeID, req)
+		if err != nil {
+			ce.logger.Debug("Failed to send vote request", "peer", nodeID, "error", err)
+			return
+		}
+		ce.handleRequestVoteResponse(nodeID, req.Term, resp)
+		return
+	}
 
 	// Simulate response (in practice, this would come from the network)
 	go func() {
@@ -409,13 +487,19 @@ func (ce *ConsensusEngine) handleVoteResponse(nodeID string, voteGranted bool) {
 	ce.mu.Lock()
 	defer ce.mu.Unlock()
 
+	ce.recordVoteLocked(nodeID, voteGranted)
+}
+
+// recordVoteLocked counts a vote for the current election and takes
+// leadership on a majority. Callers hold ce.mu.
+func (ce *ConsensusEngine) recordVoteLocked(nodeID string, voteGranted bool) {
 	if ce.state != StateCandidate {
 		return
 	}
 
 	if voteGranted {
 		ce.voteCount++
-		log.Printf("Received vote from %s (total: %d)", nodeID, ce.voteCount)
+		ce.logger.Debug("Received vote", "peer", nodeID, "votes", ce.voteCount)
 	}
 
 	// Check if we have majority
@@ -434,7 +518,7 @@ func (ce *ConsensusEngine) handleVoteResponse(nodeID string, voteGranted bool) {
 }
 
 func (ce *ConsensusEngine) becomeLeader() {
-	log.Printf("Became leader for term %d", ce.currentTerm)
+	ce.logger.Info("Became leader", "term", ce.currentTerm)
 
 	ce.state = StateLeader
 	ce.cluster.SetLeader(ce.cluster.GetNodeID())
@@ -456,9 +540,6 @@ func (ce *ConsensusEngine) becomeLeader() {
 	ce.stats.CurrentLeader = ce.cluster.GetNodeID()
 	ce.stats.mu.Unlock()
 
-	// Send initial heartbeat
-	ce.sendHeartbeats()
-
 	// Add leader election log entry
 	entry := &LogEntry{
 		Term:      ce.currentTerm,
@@ -472,7 +553,15 @@ func (ce *ConsensusEngine) becomeLeader() {
 
 	ce.stats.mu.Lock()
 	ce.stats.LogEntriesAdded++
+	ce.stats.HeartbeatsSent++
 	ce.stats.mu.Unlock()
+
+	// Send initial heartbeat; sendHeartbeats would take ce.mu, held here
+	for nodeID, node := range nodes {
+		if nodeID != ce.cluster.GetNodeID() && node.Status == NodeStatusAlive {
+			go ce.sendAppendEntries(nodeID, true)
+		}
+	}
 }
 
 // Heartbeat and log replication
@@ -502,19 +591,31 @@ func (ce *ConsensusEngine) sendAppendEntries(nodeID string, isHeartbeat bool) {
 	ce.mu.RLock()
 
 	nextIndex := ce.nextIndex[nodeID]
+
+	// The follower needs entries compacted into the snapshot
+	if first := ce.log[0].Index; first > 0 && nextIndex <= first {
+		ce.mu.RUnlock()
+		ce.sendInstallSnapshot(nodeID)
+		return
+	}
+
 	prevLogIndex := nextIndex - 1
 	prevLogTerm := uint64(0)
 
-	if prevLogIndex > 0 && prevLogIndex <= uint64(len(ce.log)) {
-		prevLogTerm = ce.log[prevLogIndex-1].Term
+	if prev := ce.entryLocked(prevLogIndex); prev != nil {
+		prevLogTerm = prev.Term
 	}
 
+	// Heartbeats over a transport also carry the entries a follower lacks,
+	// so followers that missed appends catch up
+	transport := ce.appendTransport
 	var entries []*LogEntry
-	if !isHeartbeat && nextIndex <= uint64(len(ce.log)) {
-		entries = ce.log[nextIndex-1:]
+	if (!isHeartbeat || transport != nil) && nextIndex <= ce.getLastLogIndex() {
+		entries = append([]*LogEntry(nil), ce.log[nextIndex-ce.log[0].Index:]...)
 	}
 
-	_ = &AppendEntriesMessage{
+	msg := &AppendEntriesMessage{
+		Term:         ce.currentTerm,
 		LeaderID:     ce.cluster.GetNodeID(),
 		PrevLogIndex: prevLogIndex,
 		PrevLogTerm:  prevLogTerm,
@@ -524,8 +625,20 @@ func (ce *ConsensusEngine) sendAppendEntries(nodeID string, isHeartbeat bool) {
 
 	ce.mu.RUnlock()
 
-	// In a real implementation, this would send over the network
-	log.Printf("Sending append entries to %s (heartbeat: %v)", nodeID, isHeartbeat)
+	ce.logger.Debug("Sending append entries", "peer", nodeID, "heartbeat", isHeartbeat)
+
+	if transport != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), ce.config.OperationTimeout)
+		defer cancel()
+
+		resp, err := transport(ctx, nodeID, msg)
+		if err != nil {
+			ce.logger.Debug("Failed to send append entries", "peer", nodeID, "error", err)
+			return
+		}
+		ce.handleAppendEntriesReply(nodeID, msg.Term, resp)
+		return
+	}
 
 	// Simulate response
 	go func() {
@@ -575,10 +688,16 @@ func (ce *ConsensusEngine) updateCommitIndex() {
 
 	majority := aliveNodes/2 + 1
 
-	// Find the highest log index that has been replicated to majority
-	for n := ce.commitIndex + 1; n <= uint64(len(ce.log)); n++ {
-		replicationCount := 1 // Count ourselves
+	// Find the highest log index that has been replicated to majority. Only
+	// entries from the current term commit by counting replicas; earlier
+	// entries commit along with them.
+	for n := ce.getLastLogIndex(); n > ce.commitIndex; n-- {
+		entry := ce.entryLocked(n)
+		if entry == nil || entry.Term != ce.currentTerm {
+			break
+		}
 
+		replicationCount := 1 // Count ourselves
 		for nodeID := range nodes {
 			if nodeID != ce.cluster.GetNodeID() && ce.matchIndex[nodeID] >= n {
 				replicationCount++
@@ -587,20 +706,42 @@ func (ce *ConsensusEngine) updateCommitIndex() {
 
 		if replicationCount >= majority {
 			ce.commitIndex = n
-
-			// Apply committed entries
-			for ce.lastApplied < ce.commitIndex {
-				ce.lastApplied++
-				ce.applyLogEntry(ce.log[ce.lastApplied-1])
-			}
-		} else {
 			break
 		}
 	}
+
+	ce.applyCommittedLocked()
+}
+
+// applyCommittedLocked applies entries through the commit index. An
+// operation proposal decided by the log is accepted once its entry applies.
+// Callers hold ce.mu.
+func (ce *ConsensusEngine) applyCommittedLocked() {
+	for ce.lastApplied < ce.commitIndex {
+		ce.lastApplied++
+		entry := ce.entryLocked(ce.lastApplied)
+		if entry == nil {
+			continue
+		}
+		ce.applyLogEntry(entry)
+
+		if proposal, ok := ce.proposals[entry.RequestID]; ok && proposal.Status == ProposalStatusPending {
+			proposal.Status = ProposalStatusAccepted
+
+			ce.stats.mu.Lock()
+			ce.stats.ProposalsAccepted++
+			ce.stats.mu.Unlock()
+
+			ce.logger.Info("Proposal committed", "proposal_id", proposal.ID, "index", entry.Index)
+			ce.decideLocked(proposal)
+		}
+	}
+
+	ce.maybeCompactLocked()
 }
 
 func (ce *ConsensusEngine) applyLogEntry(entry *LogEntry) {
-	log.Printf("Applying log entry: index=%d, type=%s", entry.Index, entry.Type)
+	ce.logger.Debug("Applying log entry", "index", entry.Index, "term", entry.Term, "type", entry.Type)
 
 	switch entry.Type {
 	case EntryTypeLeaderElection:
@@ -610,13 +751,17 @@ func (ce *ConsensusEngine) applyLogEntry(entry *LogEntry) {
 	case EntryTypeOperation:
 		// Apply operation
 	}
+
+	if ce.stateMachine != nil {
+		ce.stateMachine.Apply(entry)
+	}
 }
 
 // Proposal handling
 
 func (ce *ConsensusEngine) broadcastProposal(proposal *ConsensusProposal) {
 	// In a real implementation, this would broadcast to all nodes
-	log.Printf("Broadcasting proposal %s to cluster", proposal.ID)
+	ce.logger.Debug("Broadcasting proposal", "proposal_id", proposal.ID)
 
 	// For simulation, automatically accept our own proposals after a delay
 	go func() {
@@ -666,10 +811,12 @@ func (ce *ConsensusEngine) voteOnProposal(proposalID string, accept bool) {
 		ce.stats.ProposalsAccepted++
 		ce.stats.mu.Unlock()
 
-		log.Printf("Proposal %s accepted (%d/%d votes)", proposalID, acceptVotes, totalVotes)
+		ce.logger.Info("Proposal accepted", "proposal_id", proposalID, "accept_votes", acceptVotes, "total_votes", totalVotes)
+		ce.decideLocked(proposal)
 	} else if totalVotes-acceptVotes > aliveNodes-majority {
 		proposal.Status = ProposalStatusRejected
-		log.Printf("Proposal %s rejected (%d/%d votes)", proposalID, acceptVotes, totalVotes)
+		ce.logger.Info("Proposal rejected", "proposal_id", proposalID, "accept_votes", acceptVotes, "total_votes", totalVotes)
+		ce.decideLocked(proposal)
 	}
 }
 
@@ -677,17 +824,45 @@ func (ce *ConsensusEngine) executeProposal(proposal *ConsensusProposal) {
 	switch proposal.Type {
 	case ProposalTypeLeadershipChange:
 		newLeader := string(proposal.Data)
-		log.Printf("Executing leadership change to %s", newLeader)
+		ce.logger.Info("Executing leadership change", "proposal_id", proposal.ID, "leader", newLeader)
 		ce.cluster.SetLeader(newLeader)
 
 	case ProposalTypeConfigChange:
-		log.Printf("Executing configuration change")
+		ce.logger.Info("Executing configuration change", "proposal_id", proposal.ID)
 		// Apply configuration change
 
 	case ProposalTypeOperation:
-		log.Printf("Executing operation proposal")
-		// Execute operation
+		ce.logger.Debug("Executing operation proposal", "proposal_id", proposal.ID)
+		ce.appendOperationLocked(proposal)
+	}
+}
+
+// appendOperationLocked appends an accepted operation proposal to the log,
+// replicates it to alive peers, and commits it once a majority holds it.
+// Callers hold ce.mu.
+func (ce *ConsensusEngine) appendOperationLocked(proposal *ConsensusProposal) {
+	entry := &LogEntry{
+		Term:      ce.currentTerm,
+		Index:     ce.getLastLogIndex() + 1,
+		Type:      EntryTypeOperation,
+		Data:      proposal.Data,
+		Timestamp: time.Now(),
+		ClientID:  proposal.Proposer,
+		RequestID: proposal.ID,
+	}
+	ce.log = append(ce.log, entry)
+
+	ce.stats.mu.Lock()
+	ce.stats.LogEntriesAdded++
+	ce.stats.mu.Unlock()
+
+	self := ce.cluster.GetNodeID()
+	for nodeID, node := range ce.cluster.GetNodes() {
+		if nodeID != self && node.Status == NodeStatusAlive {
+			go ce.sendAppendEntries(nodeID, false)
+		}
 	}
+	ce.updateCommitIndex()
 }
 
 // Utility methods
@@ -731,8 +906,9 @@ func (ce *ConsensusEngine) cleanupExpiredProposals() {
 	for proposalID, proposal := range ce.proposals {
 		if proposal.Status == ProposalStatusPending && now.Sub(proposal.Timestamp) > 30*time.Second {
 			proposal.Status = ProposalStatusExpired
+			ce.decideLocked(proposal)
 			delete(ce.proposals, proposalID)
-			log.Printf("Proposal %s expired", proposalID)
+			ce.logger.Warn("Proposal expired", "proposal_id", proposalID)
 		}
 	}
 }
@@ -750,47 +926,60 @@ func (ce *ConsensusEngine) updateStats(ctx context.Context) {
 		case <-ce.stopCh:
 			return
 		case <-ticker.C:
+			ce.mu.RLock()
 			ce.stats.mu.Lock()
 			ce.stats.LogLength = len(ce.log)
 			ce.stats.CommitIndex = ce.commitIndex
 			ce.stats.LastApplied = ce.lastApplied
 			ce.stats.Uptime = time.Since(startTime)
 			ce.stats.mu.Unlock()
+			ce.mu.RUnlock()
 		}
 	}
 }
 
-// GetStats returns consensus engine statistics
+// GetStats returns a snapshot of consensus engine statistics. The engine
+// state and the counters are read together, taking ce.mu before
+// ce.stats.mu as every writer does, so the snapshot never pairs a state
+// with counters from either side of a transition.
 func (ce *ConsensusEngine) GetStats() *ConsensusStats {
 	ce.mu.RLock()
-	state := ce.state.String()
-	term := ce.currentTerm
-	commitIndex := ce.commitIndex
-	lastApplied := ce.lastApplied
-	logLength := len(ce.log)
-	ce.mu.RUnlock()
-
+	defer ce.mu.RUnlock()
 	ce.stats.mu.RLock()
-	stats := &ConsensusStats{
-		CurrentState:      state,
-		CurrentTerm:       term,
-		CurrentLeader:     ce.stats.CurrentLeader,
-		LogLength:         logLength,
-		CommitIndex:       commitIndex,
-		LastApplied:       lastApplied,
-		ElectionsStarted:  ce.stats.ElectionsStarted,
-		ElectionsWon:      ce.stats.ElectionsWon,
-		VotesCast:         ce.stats.VotesCast,
-		ProposalsReceived: ce.stats.ProposalsReceived,
-		ProposalsAccepted: ce.stats.ProposalsAccepted,
-		LogEntriesAdded:   ce.stats.LogEntriesAdded,
-		HeartbeatsSent:    ce.stats.HeartbeatsSent,
-		LastElection:      ce.stats.LastElection,
-		Uptime:            ce.stats.Uptime,
-	}
-	ce.stats.mu.RUnlock()
-
-	return stats
+	defer ce.stats.mu.RUnlock()
+
+	// The leader is derived from the state rather than recorded on
+	// election, so a node that stepped down does not report itself
+	self := ce.cluster.GetNodeID()
+	leader := ce.cluster.GetLeader()
+	if ce.state == StateLeader {
+		leader = self
+	} else if leader == self {
+		leader = ""
+	}
+
+	return &ConsensusStats{
+		CurrentState:       ce.state.String(),
+		CurrentTerm:        ce.currentTerm,
+		CurrentLeader:      leader,
+		LogLength:          len(ce.log),
+		CommitIndex:        ce.commitIndex,
+		LastApplied:        ce.lastApplied,
+		ElectionsStarted:   ce.stats.ElectionsStarted,
+		ElectionsWon:       ce.stats.ElectionsWon,
+		VotesCast:          ce.stats.VotesCast,
+		ProposalsReceived:  ce.stats.ProposalsReceived,
+		ProposalsAccepted:  ce.stats.ProposalsAccepted,
+		ProposalsForwarded: ce.stats.ProposalsForwarded,
+		LogEntriesAdded:    ce.stats.LogEntriesAdded,
+		HeartbeatsSent:     ce.stats.HeartbeatsSent,
+		SnapshotsTaken:     ce.stats.SnapshotsTaken,
+		SnapshotsSent:      ce.stats.SnapshotsSent,
+		SnapshotsInstalled: ce.stats.SnapshotsInstalled,
+		SnapshotIndex:      ce.log[0].Index,
+		LastElection:       ce.stats.LastElection,
+		Uptime:             ce.stats.Uptime,
+	}
 }
 
 // GetCurrentState returns the current consensus state
@@ -0,0 +1,165 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestConsensus(t *testing.T, nodeID string) *ClusterManager {
+	t.Helper()
+	cm, err := NewClusterManager(&ClusterConfig{NodeID: nodeID})
+	if err != nil {
+		t.Fatalf("NewClusterManager(%s) failed: %v", nodeID, err)
+	}
+	return cm
+}
+
+func makeLeader(cm *ClusterManager) {
+	cm.consensus.mu.Lock()
+	cm.consensus.state = StateLeader
+	cm.consensus.mu.Unlock()
+	cm.SetLeader(cm.GetNodeID())
+}
+
+func stepDown(cm *ClusterManager) {
+	cm.consensus.mu.Lock()
+	cm.consensus.state = StateFollower
+	cm.consensus.mu.Unlock()
+}
+
+func TestForwardProposalCommitsViaLeader(t *testing.T) {
+	leader := newTestConsensus(t, "leader")
+	follower := newTestConsensus(t, "follower")
+	makeLeader(leader)
+	follower.SetLeader("leader")
+
+	var calls int
+	follower.consensus.SetProposalTransport(func(ctx context.Context, leaderID string, p *ConsensusProposal) (*ConsensusProposal, error) {
+		calls++
+		if leaderID != "leader" {
+			t.Errorf("forwarded to %s, want leader", leaderID)
+		}
+		return leader.consensus.HandleForwardedProposal(ctx, p)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := follower.consensus.ForwardProposal(ctx, &ConsensusProposal{
+		Type: ProposalTypeConfigChange,
+		Data: []byte("replication_factor=5"),
+	})
+	if err != nil {
+		t.Fatalf("ForwardProposal failed: %v", err)
+	}
+	if result.Status != ProposalStatusAccepted {
+		t.Errorf("status = %s, want %s", result.Status, ProposalStatusAccepted)
+	}
+	if result.Proposer != "follower" {
+		t.Errorf("proposer = %s, want follower", result.Proposer)
+	}
+	if calls != 1 {
+		t.Errorf("transport called %d times, want 1", calls)
+	}
+	if got := follower.consensus.GetStats().ProposalsForwarded; got != 1 {
+		t.Errorf("ProposalsForwarded = %d, want 1", got)
+	}
+	if got := leader.consensus.GetStats().ProposalsAccepted; got != 1 {
+		t.Errorf("leader ProposalsAccepted = %d, want 1", got)
+	}
+}
+
+func TestForwardProposalFollowsLeaderChange(t *testing.T) {
+	oldLeader := newTestConsensus(t, "old")
+	newLeader := newTestConsensus(t, "new")
+	follower := newTestConsensus(t, "follower")
+	makeLeader(newLeader)
+	follower.SetLeader("old")
+
+	engines := map[string]*ClusterManager{"old": oldLeader, "new": newLeader}
+	var mu sync.Mutex
+	var forwardedTo []string
+	follower.consensus.SetProposalTransport(func(ctx context.Context, leaderID string, p *ConsensusProposal) (*ConsensusProposal, error) {
+		mu.Lock()
+		forwardedTo = append(forwardedTo, leaderID)
+		mu.Unlock()
+		result, err := engines[leaderID].consensus.HandleForwardedProposal(ctx, p)
+		if errors.Is(err, ErrNotLeader) {
+			// The follower learns about the new leader after the rejection
+			follower.SetLeader("new")
+		}
+		return result, err
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := follower.consensus.ForwardProposal(ctx, &ConsensusProposal{Type: ProposalTypeConfigChange})
+	if err != nil {
+		t.Fatalf("ForwardProposal failed: %v", err)
+	}
+	if result.Status != ProposalStatusAccepted {
+		t.Errorf("status = %s, want %s", result.Status, ProposalStatusAccepted)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(forwardedTo) != 2 || forwardedTo[0] != "old" || forwardedTo[1] != "new" {
+		t.Errorf("forwarded to %v, want [old new]", forwardedTo)
+	}
+}
+
+func TestHandleForwardedProposalDeduplicates(t *testing.T) {
+	leader := newTestConsensus(t, "leader")
+	makeLeader(leader)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	proposal := &ConsensusProposal{ID: "prop-retry", Type: ProposalTypeConfigChange}
+	var wg sync.WaitGroup
+	results := make([]*ConsensusProposal, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := leader.consensus.HandleForwardedProposal(ctx, proposal)
+			if err != nil {
+				t.Errorf("HandleForwardedProposal failed: %v", err)
+				return
+			}
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if result != nil && result.Status != ProposalStatusAccepted {
+			t.Errorf("result %d status = %s, want %s", i, result.Status, ProposalStatusAccepted)
+		}
+	}
+	if got := leader.consensus.GetStats().ProposalsReceived; got != 1 {
+		t.Errorf("ProposalsReceived = %d, want 1", got)
+	}
+}
+
+func TestForwardProposalRequiresTransport(t *testing.T) {
+	follower := newTestConsensus(t, "follower")
+	follower.SetLeader("leader")
+
+	_, err := follower.consensus.ForwardProposal(context.Background(), &ConsensusProposal{Type: ProposalTypeConfigChange})
+	if err == nil {
+		t.Error("expected an error without a proposal transport")
+	}
+}
+
+func TestProposeChangeOnFollower(t *testing.T) {
+	follower := newTestConsensus(t, "follower")
+	err := follower.consensus.ProposeChange(context.Background(), &ConsensusProposal{Type: ProposalTypeConfigChange})
+	if !errors.Is(err, ErrNotLeader) {
+		t.Errorf("ProposeChange error = %v, want ErrNotLeader", err)
+	}
+}
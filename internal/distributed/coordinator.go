@@ -3,9 +3,12 @@ package distributed
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
 )
 
 // Coordinator manages distributed operations across cluster nodes
@@ -13,10 +16,41 @@ type Coordinator struct {
 	mu           sync.RWMutex
 	cluster      *ClusterManager
 	config       *ClusterConfig
+	logger       *slog.Logger
 	operations   map[string]*ActiveOperation
 	replicator   *CacheReplicator
 	loadBalancer *LoadBalancer
 	stopCh       chan struct{}
+
+	// Prefix operations
+	prefixStore      PrefixStore
+	prefixTransport  PrefixTransport
+	preparedPrefixes map[string]*PrefixIntent
+
+	// Cached key ranges this node announced, and which nodes hold each
+	// announced range
+	ownershipTransport OwnershipTransport
+	announced          map[keyRange]struct{}
+	keyOwners          map[keyRange]map[string]struct{}
+
+	// Versions of keys written through this node, the versions each peer
+	// acknowledged applying, and the cache peers' writes invalidate
+	writeTransport WriteNoticeTransport
+	writeVersions  map[string]uint64
+	writeAcks      map[string]map[string]uint64
+	writeCache     types.Cache
+
+	// Newest write epoch each node accepted, under WriteFencing
+	fences map[string]uint64
+
+	// How writes reach replicas; nil simulates delivery to alive nodes
+	replicaTransport ReplicaTransport
+
+	// How consistency verification reads each node's copy of a key
+	replicaReadTransport ReplicaReadTransport
+
+	// Bounds how often and how much divergent replicas are repaired
+	repairThrottle *RepairThrottle
 }
 
 // DistributedOperation represents an operation to be executed across the cluster
@@ -33,6 +67,22 @@ type DistributedOperation struct {
 	Retries     int               `json:"retries"`
 	TargetNodes []string          `json:"target_nodes,omitempty"`
 	CreatedAt   time.Time         `json:"created_at"`
+
+	// PreferFollowers routes non-strong gets and lists to followers whose
+	// applied state is at most MaxStaleness old (default
+	// FollowerReadStaleness), keeping read load off the leader
+	PreferFollowers bool          `json:"prefer_followers,omitempty"`
+	MaxStaleness    time.Duration `json:"max_staleness,omitempty"`
+
+	// Epoch is the consensus term a write was issued under. With
+	// WriteFencing, strong-consistency writes without one are stamped with
+	// the current term, and nodes reject writes older than one they applied.
+	Epoch uint64 `json:"epoch,omitempty"`
+
+	// WriteQuorum is how many nodes, counting the one that applied it, must
+	// hold an eventual or session put before it returns. Zero or one returns
+	// as soon as one node applies the write; strong writes ignore it.
+	WriteQuorum int `json:"write_quorum,omitempty"`
 }
 
 // OperationType represents the type of distributed operation
@@ -64,6 +114,7 @@ type OperationResult struct {
 	Latency     time.Duration          `json:"latency"`
 	RetriesUsed int                    `json:"retries_used"`
 	CompletedAt time.Time              `json:"completed_at"`
+	AckLatency  time.Duration          `json:"ack_latency,omitempty"` // Time for a WriteQuorum to acknowledge
 }
 
 // NodeResult represents the result from a specific node
@@ -84,17 +135,24 @@ type ActiveOperation struct {
 	_         sync.RWMutex
 }
 
-// CacheReplicator handles cache replication across nodes
+// CacheReplicator handles cache replication across nodes. Tasks wait in
+// an ordered queue, one per write, so successive writes to a key are each
+// replicated, in the order they were made.
 type CacheReplicator struct {
-	mu           sync.RWMutex
-	cluster      *ClusterManager
-	config       *ClusterConfig
-	replications map[string]*ReplicationTask
-	stats        *ReplicationStats
+	mu       sync.RWMutex
+	cluster  *ClusterManager
+	config   *ClusterConfig
+	queue    []*ReplicationTask // Pending tasks, oldest first
+	nextID   uint64
+	capacity int
+	space    chan struct{}     // Closed when a task leaves the queue
+	path     string            // File pending tasks persist to, if any
+	stats    *ReplicationStats // Guarded by mu
 }
 
 // ReplicationTask represents a cache replication task
 type ReplicationTask struct {
+	ID          uint64    `json:"id"`
 	Key         string    `json:"key"`
 	Data        []byte    `json:"data"`
 	TargetNodes []string  `json:"target_nodes"`
@@ -110,7 +168,13 @@ type ReplicationStats struct {
 	TasksFailed        int64         `json:"tasks_failed"`
 	BytesReplicated    int64         `json:"bytes_replicated"`
 	AvgReplicationTime time.Duration `json:"avg_replication_time"`
-	ActiveTasks        int           `json:"active_tasks"`
+	ActiveTasks        int           `json:"active_tasks"`    // Tasks waiting in the replication queue
+	OldestTaskAge      time.Duration `json:"oldest_task_age"` // How long the oldest pending task has waited
+	Backpressured      int64         `json:"backpressured"`   // Writes that waited for space in a full queue
+	PersistErrors      int64         `json:"persist_errors"`  // Failed writes of the queue file
+	QuorumWrites       int64         `json:"quorum_writes"`   // Writes whose WriteQuorum acknowledged
+	QuorumFailures     int64         `json:"quorum_failures"` // Writes whose WriteQuorum was not met in time
+	AvgAckLatency      time.Duration `json:"avg_ack_latency"` // Average time for a WriteQuorum to acknowledge
 }
 
 // LoadBalancer manages load distribution across cluster nodes
@@ -138,6 +202,8 @@ type LoadBalancerStats struct {
 	NodeLoad        map[string]int64 `json:"node_load"`
 	AvgResponseTime time.Duration    `json:"avg_response_time"`
 	Imbalance       float64          `json:"imbalance"`
+	LeaderReads     int64            `json:"leader_reads"`
+	FollowerReads   int64            `json:"follower_reads"`
 }
 
 // NewCoordinator creates a new distributed operations coordinator
@@ -145,17 +211,25 @@ func NewCoordinator(cluster *ClusterManager, config *ClusterConfig) (*Coordinato
 	c := &Coordinator{
 		cluster:    cluster,
 		config:     config,
+		logger:     componentLogger(config, "coordinator").With("node_id", cluster.GetNodeID()),
 		operations: make(map[string]*ActiveOperation),
 		stopCh:     make(chan struct{}),
+
+		preparedPrefixes: make(map[string]*PrefixIntent),
+		announced:        make(map[keyRange]struct{}),
+		keyOwners:        make(map[keyRange]map[string]struct{}),
+		writeVersions:    make(map[string]uint64),
+		writeAcks:        make(map[string]map[string]uint64),
+		fences:           make(map[string]uint64),
+		repairThrottle:   NewRepairThrottle(config.RepairThrottle),
 	}
 
 	// Initialize cache replicator
-	c.replicator = &CacheReplicator{
-		cluster:      cluster,
-		config:       config,
-		replications: make(map[string]*ReplicationTask),
-		stats:        &ReplicationStats{},
+	replicator, err := newCacheReplicator(cluster, config)
+	if err != nil {
+		return nil, err
 	}
+	c.replicator = replicator
 
 	// Initialize load balancer
 	c.loadBalancer = &LoadBalancer{
@@ -171,7 +245,7 @@ func NewCoordinator(cluster *ClusterManager, config *ClusterConfig) (*Coordinato
 
 // Start starts the coordinator
 func (c *Coordinator) Start(ctx context.Context) error {
-	log.Printf("Starting distributed operations coordinator")
+	c.logger.Info("Starting distributed operations coordinator")
 
 	// Start background tasks
 	go c.cleanupOperations(ctx)
@@ -184,7 +258,7 @@ func (c *Coordinator) Start(ctx context.Context) error {
 // Stop stops the coordinator
 func (c *Coordinator) Stop() error {
 	close(c.stopCh)
-	log.Printf("Distributed operations coordinator stopped")
+	c.logger.Info("Distributed operations coordinator stopped")
 	return nil
 }
 
@@ -192,6 +266,11 @@ func (c *Coordinator) Stop() error {
 func (c *Coordinator) ExecuteOperation(ctx context.Context, op *DistributedOperation) (*OperationResult, error) {
 	start := time.Now()
 
+	// Count the operation in this node's reported load while it runs
+	if load := c.cluster.LoadProvider(); load != nil {
+		defer load.Begin()()
+	}
+
 	// Generate operation ID if not provided
 	if op.ID == "" {
 		op.ID = fmt.Sprintf("op-%d-%s", time.Now().UnixNano(), c.cluster.GetNodeID()[:8])
@@ -258,12 +337,26 @@ func (c *Coordinator) ExecuteOperation(ctx context.Context, op *DistributedOpera
 	result.CompletedAt = time.Now()
 	result.Latency = time.Since(start)
 
+	if result.Success && (op.Type == OpTypePut || op.Type == OpTypeDelete) {
+		c.RecordWrite(op.Key)
+	}
+
 	// Update load balancer stats
 	c.loadBalancer.stats.mu.Lock()
 	c.loadBalancer.stats.RequestsRouted++
 	for _, nodeID := range targetNodes {
 		c.loadBalancer.stats.NodeLoad[nodeID]++
 	}
+	if op.Type == OpTypeGet || op.Type == OpTypeList {
+		leader := c.cluster.GetLeader()
+		for _, nodeID := range targetNodes {
+			if nodeID == leader {
+				c.loadBalancer.stats.LeaderReads++
+			} else {
+				c.loadBalancer.stats.FollowerReads++
+			}
+		}
+	}
 	c.loadBalancer.stats.mu.Unlock()
 
 	return result, err
@@ -289,6 +382,10 @@ func (c *Coordinator) selectTargetNodes(op *DistributedOperation) ([]string, err
 		return nil, fmt.Errorf("no alive nodes available")
 	}
 
+	if op.PreferFollowers && (op.Type == OpTypeGet || op.Type == OpTypeList) {
+		return c.selectReadReplica(op, aliveNodes)
+	}
+
 	// Select nodes based on operation type and consistency requirements
 	switch op.Type {
 	case OpTypeGet:
@@ -330,6 +427,8 @@ func (c *Coordinator) executeStrongConsistency(ctx context.Context, activeOp *Ac
 	// For strong consistency, we need consensus from majority of nodes
 	requiredNodes := len(targetNodes)/2 + 1
 
+	c.stampEpoch(op)
+
 	// Execute operation on all target nodes synchronously
 	results := make(map[string]*NodeResult)
 	var wg sync.WaitGroup
@@ -395,6 +494,9 @@ func (c *Coordinator) executeSessionConsistency(ctx context.Context, activeOp *A
 	if len(targetNodes) > 0 {
 		primaryNode = targetNodes[0]
 	}
+	if failed := checkWriteQuorum(op, targetNodes); failed != nil {
+		return failed, nil
+	}
 
 	// Execute on primary node first
 	result := c.executeOnNode(ctx, primaryNode, op)
@@ -406,9 +508,9 @@ func (c *Coordinator) executeSessionConsistency(ctx context.Context, activeOp *A
 		NodeResults: map[string]*NodeResult{primaryNode: result},
 	}
 
-	// If write operation succeeded, asynchronously replicate to other nodes
+	// If write operation succeeded, replicate to other nodes
 	if op.Type == OpTypePut && result.Success && len(targetNodes) > 1 {
-		go c.replicateAsync(ctx, op, targetNodes[1:])
+		c.replicateWrite(ctx, activeOp, operationResult, targetNodes[1:])
 	}
 
 	return operationResult, nil
@@ -419,6 +521,9 @@ func (c *Coordinator) executeEventualConsistency(ctx context.Context, activeOp *
 	op := activeOp.Operation
 
 	// For eventual consistency, execute on any available node and replicate asynchronously
+	if failed := checkWriteQuorum(op, targetNodes); failed != nil {
+		return failed, nil
+	}
 	primaryNode := targetNodes[0]
 	result := c.executeOnNode(ctx, primaryNode, op)
 
@@ -429,9 +534,9 @@ func (c *Coordinator) executeEventualConsistency(ctx context.Context, activeOp *
 		NodeResults: map[string]*NodeResult{primaryNode: result},
 	}
 
-	// Asynchronously replicate to other nodes
+	// Replicate to other nodes, waiting only for a WriteQuorum
 	if result.Success && len(targetNodes) > 1 {
-		go c.replicateAsync(ctx, op, targetNodes[1:])
+		c.replicateWrite(ctx, activeOp, operationResult, targetNodes[1:])
 	}
 
 	return operationResult, nil
@@ -458,10 +563,18 @@ func (c *Coordinator) executeOnNode(ctx context.Context, nodeID string, op *Dist
 
 	case OpTypePut:
 		// Simulate put operation
+		if err := c.admitEpoch(nodeID, op.Epoch); err != nil {
+			result.Error = err.Error()
+			break
+		}
 		result.Success = true
 
 	case OpTypeDelete:
 		// Simulate delete operation
+		if err := c.admitEpoch(nodeID, op.Epoch); err != nil {
+			result.Error = err.Error()
+			break
+		}
 		result.Success = true
 
 	case OpTypeList:
@@ -477,25 +590,19 @@ func (c *Coordinator) executeOnNode(ctx context.Context, nodeID string, op *Dist
 	return result
 }
 
-// replicateAsync asynchronously replicates data to target nodes
-func (c *Coordinator) replicateAsync(ctx context.Context, op *DistributedOperation, targetNodes []string) {
+// replicateAsync queues a replication of op's data to target nodes. While
+// the queue is full it waits for space, failing once ctx is done.
+func (c *Coordinator) replicateAsync(ctx context.Context, op *DistributedOperation, targetNodes []string) error {
 	if c.replicator == nil {
-		return
+		return nil
 	}
 
-	task := &ReplicationTask{
+	return c.replicator.enqueue(ctx, &ReplicationTask{
 		Key:         op.Key,
 		Data:        op.Data,
 		TargetNodes: targetNodes,
 		CreatedAt:   time.Now(),
-		Attempts:    0,
-	}
-
-	c.replicator.mu.Lock()
-	c.replicator.replications[op.Key] = task
-	c.replicator.stats.TasksCreated++
-	c.replicator.stats.ActiveTasks++
-	c.replicator.mu.Unlock()
+	})
 }
 
 // Background worker methods
@@ -523,7 +630,7 @@ func (c *Coordinator) performOperationCleanup() {
 	now := time.Now()
 	for opID, activeOp := range c.operations {
 		if now.After(activeOp.Deadline) {
-			log.Printf("Cleaning up expired operation: %s", opID)
+			c.logger.Warn("Cleaning up expired operation", "operation_id", opID, "type", activeOp.Operation.Type)
 			delete(c.operations, opID)
 		}
 	}
@@ -545,53 +652,35 @@ func (c *Coordinator) replicationWorker(ctx context.Context) {
 	}
 }
 
+// processReplicationTasks makes one delivery attempt of each pending task,
+// oldest first. A task waits while an earlier task for its key is still
+// pending, so an older write never replaces a newer one on a replica.
 func (c *Coordinator) processReplicationTasks(ctx context.Context) {
-	c.replicator.mu.Lock()
-	tasks := make([]*ReplicationTask, 0, len(c.replicator.replications))
-	for _, task := range c.replicator.replications {
-		tasks = append(tasks, task)
-	}
-	c.replicator.mu.Unlock()
-
-	for _, task := range tasks {
-		c.processReplicationTask(ctx, task)
+	waiting := make(map[string]bool)
+	for _, task := range c.replicator.pending() {
+		if waiting[task.Key] {
+			continue
+		}
+		if !c.processReplicationTask(ctx, task) {
+			waiting[task.Key] = true
+		}
 	}
 }
 
-func (c *Coordinator) processReplicationTask(ctx context.Context, task *ReplicationTask) {
+// processReplicationTask sends task to its target nodes, reporting whether
+// any of them applied it
+func (c *Coordinator) processReplicationTask(ctx context.Context, task ReplicationTask) bool {
 	start := time.Now()
-	task.Attempts++
 
 	successCount := 0
 	for _, nodeID := range task.TargetNodes {
-		// Simulate replication to node
-		if c.simulateReplication(nodeID, task.Key, task.Data) {
+		if c.replicateTo(ctx, nodeID, task.Key, task.Data) == nil {
 			successCount++
 		}
 	}
 
-	c.replicator.mu.Lock()
-	if successCount > 0 {
-		c.replicator.stats.TasksCompleted++
-		c.replicator.stats.BytesReplicated += int64(len(task.Data))
-		delete(c.replicator.replications, task.Key)
-	} else if task.Attempts >= 3 {
-		c.replicator.stats.TasksFailed++
-		delete(c.replicator.replications, task.Key)
-	}
-	c.replicator.stats.ActiveTasks = len(c.replicator.replications)
-
-	// Update average replication time
-	replicationTime := time.Since(start)
-	if c.replicator.stats.AvgReplicationTime == 0 {
-		c.replicator.stats.AvgReplicationTime = replicationTime
-	} else {
-		alpha := 0.1
-		c.replicator.stats.AvgReplicationTime = time.Duration(
-			alpha*float64(replicationTime) + (1-alpha)*float64(c.replicator.stats.AvgReplicationTime),
-		)
-	}
-	c.replicator.mu.Unlock()
+	c.replicator.finish(task, successCount > 0, time.Since(start))
+	return successCount > 0
 }
 
 func (c *Coordinator) simulateReplication(nodeID, key string, data []byte) bool {
@@ -685,28 +774,39 @@ func (lb *LoadBalancer) selectRoundRobin(nodes []string, count int) ([]string, e
 }
 
 func (lb *LoadBalancer) selectLeastLoad(nodes []string, count int) ([]string, error) {
-	// Select nodes with the least load
+	// Rank nodes by the load they report plus their share of the requests
+	// routed so far, so nodes reporting equal load still take turns
 	type nodeLoad struct {
 		nodeID string
-		load   int64
+		load   float64
+	}
+
+	reported := make(map[string]float64)
+	if lb.cluster != nil {
+		for nodeID, info := range lb.cluster.GetNodes() {
+			reported[nodeID] = info.Load
+		}
 	}
 
 	nodeLoads := make([]nodeLoad, 0, len(nodes))
 	lb.stats.mu.RLock()
+	var routed int64
 	for _, nodeID := range nodes {
-		load := lb.stats.NodeLoad[nodeID]
+		routed += lb.stats.NodeLoad[nodeID]
+	}
+	for _, nodeID := range nodes {
+		load := reported[nodeID]
+		if routed > 0 {
+			load += float64(lb.stats.NodeLoad[nodeID]) / float64(routed)
+		}
 		nodeLoads = append(nodeLoads, nodeLoad{nodeID: nodeID, load: load})
 	}
 	lb.stats.mu.RUnlock()
 
 	// Sort by load (ascending)
-	for i := 0; i < len(nodeLoads)-1; i++ {
-		for j := i + 1; j < len(nodeLoads); j++ {
-			if nodeLoads[i].load > nodeLoads[j].load {
-				nodeLoads[i], nodeLoads[j] = nodeLoads[j], nodeLoads[i]
-			}
-		}
-	}
+	sort.SliceStable(nodeLoads, func(i, j int) bool {
+		return nodeLoads[i].load < nodeLoads[j].load
+	})
 
 	selected := make([]string, count)
 	for i := 0; i < count; i++ {
@@ -726,13 +826,16 @@ func (lb *LoadBalancer) selectConsistentHash(nodes []string, count int) ([]strin
 	return nodes[:count], nil
 }
 
-// GetStats returns coordinator statistics
+// GetStats returns a snapshot of coordinator statistics. Active operations,
+// replication, and load balancer counters are read together, taking c.mu,
+// then the replicator's lock, then the load balancer's stats lock, so the
+// counters agree with each other.
 func (c *Coordinator) GetStats() map[string]interface{} {
 	c.mu.RLock()
 	activeOps := len(c.operations)
-	c.mu.RUnlock()
 
-	c.replicator.stats.mu.RLock()
+	// Replication counters are updated under the replicator's lock
+	c.replicator.mu.RLock()
 	replicationStats := ReplicationStats{
 		TasksCreated:       c.replicator.stats.TasksCreated,
 		TasksCompleted:     c.replicator.stats.TasksCompleted,
@@ -740,24 +843,41 @@ func (c *Coordinator) GetStats() map[string]interface{} {
 		BytesReplicated:    c.replicator.stats.BytesReplicated,
 		AvgReplicationTime: c.replicator.stats.AvgReplicationTime,
 		ActiveTasks:        c.replicator.stats.ActiveTasks,
+		OldestTaskAge:      c.replicator.oldestAgeLocked(),
+		Backpressured:      c.replicator.stats.Backpressured,
+		PersistErrors:      c.replicator.stats.PersistErrors,
+		QuorumWrites:       c.replicator.stats.QuorumWrites,
+		QuorumFailures:     c.replicator.stats.QuorumFailures,
+		AvgAckLatency:      c.replicator.stats.AvgAckLatency,
 	}
-	c.replicator.stats.mu.RUnlock()
 
 	c.loadBalancer.stats.mu.RLock()
 	loadBalancerStats := LoadBalancerStats{
 		RequestsRouted:  c.loadBalancer.stats.RequestsRouted,
 		AvgResponseTime: c.loadBalancer.stats.AvgResponseTime,
 		Imbalance:       c.loadBalancer.stats.Imbalance,
+		LeaderReads:     c.loadBalancer.stats.LeaderReads,
+		FollowerReads:   c.loadBalancer.stats.FollowerReads,
 		NodeLoad:        make(map[string]int64),
 	}
 	for k, v := range c.loadBalancer.stats.NodeLoad {
 		loadBalancerStats.NodeLoad[k] = v
 	}
 	c.loadBalancer.stats.mu.RUnlock()
+	c.replicator.mu.RUnlock()
+	c.mu.RUnlock()
+
+	// Load score components each node reported
+	nodeLoads := make(map[string]LoadComponents)
+	for nodeID, info := range c.cluster.GetNodes() {
+		nodeLoads[nodeID] = NodeLoadComponents(info)
+	}
 
 	return map[string]interface{}{
 		"active_operations": activeOps,
 		"replication":       &replicationStats,
 		"load_balancer":     &loadBalancerStats,
+		"node_load":         nodeLoads,
+		"repair_throttle":   c.repairThrottle.Stats(),
 	}
 }
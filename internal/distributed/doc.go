@@ -7,8 +7,9 @@ The distributed package implements a distributed system layer that enables Objec
 in a clustered configuration with data replication, load balancing, and consensus. It provides
 the coordination mechanisms needed for multi-node deployments.
 
-⚠️ WARNING: This package has known race conditions and is currently under active development.
-Integration tests pending Sprint 4 (LocalStack). Not recommended for production use yet.
+⚠️ WARNING: This package is under active development and not recommended for production
+use yet. Failure scenarios are exercised in process with ClusterSimulator; tests against a
+real network are still pending.
 
 Architecture
 
@@ -81,6 +82,22 @@ Strong Consistency:
 		Consistency: distributed.ConsistencyStrong,
 	}
 
+With WriteFencing, each strong write is stamped with the consensus term it
+was issued under (DistributedOperation.Epoch). Nodes remember the newest
+term they accepted a write from and reject older ones with ErrStaleEpoch,
+so a deposed leader routing with stale state cannot apply writes once a new
+leader has written.
+
+Eventual and session puts can wait for a write quorum instead of returning
+as soon as one node applies them. With DistributedOperation.WriteQuorum set
+to W, the put returns once W nodes, counting the one that applied it, hold
+the write, so R+W>N can be tuned per operation without strong consistency.
+Replicas still in flight finish in the background. A quorum not met before
+the operation timeout fails the result; OperationResult.AckLatency reports
+how long the acknowledgments took, and the replication stats count quorum
+writes and their average ack latency. SetReplicaTransport sets how replicas
+reach peers.
+
 # Setting Up a Cluster
 
 Basic cluster configuration:
@@ -121,6 +138,9 @@ Basic cluster configuration:
 	}
 	defer coordinator.Stop()
 
+NewClusterConfig builds the settings from the cluster section of the
+application configuration, including its tls section.
+
 # Distributed Operations
 
 Execute operations across the cluster:
@@ -163,7 +183,9 @@ Round Robin (StrategyRoundRobin):
 
 Least Load (StrategyLeastLoad):
 - Selects nodes with lowest current load
-- Balances uneven workloads
+- Load is the score each node gossips plus its share of routed requests
+- Each node's LocalLoadProvider scores CPU, memory, cache use, and in-flight operations
+- Score components are gossiped in node metadata and reported in GetStats
 - Default strategy
 
 Consistent Hash (StrategyConsistentHash):
@@ -176,6 +198,13 @@ Latency Based (StrategyLatencyBased):
 - Adapts to network conditions
 - Requires latency tracking
 
+Follower Reads (DistributedOperation.PreferFollowers):
+- Gets and lists go to a follower instead of the leader
+- Followers gossip their applied log index and when they reported it
+- Only followers caught up with the leader within MaxStaleness (default FollowerReadStaleness) are eligible
+- Strong reads still go to the leader, which also serves reads no follower qualifies for
+- LeaderReads and FollowerReads are reported in the load balancer stats
+
 # Cache Replication
 
 The CacheReplicator handles asynchronous cache synchronization:
@@ -185,11 +214,30 @@ The CacheReplicator handles asynchronous cache synchronization:
 
 	// Monitor replication status
 	stats := coordinator.GetStats()
-	replicationStats := stats["replication"].(distributed.ReplicationStats)
+	replicationStats := stats["replication"].(*distributed.ReplicationStats)
 	log.Printf("Replicated: %d bytes, Active: %d tasks",
 		replicationStats.BytesReplicated,
 		replicationStats.ActiveTasks)
 
+Replication Queue (ReplicationQueueSize, ReplicationQueueDir):
+- Each write that still has replicas to reach queues its own task, so successive writes to a key all replicate, in order
+- A task waits while an earlier task for the same key is undelivered, so an older write never replaces a newer one
+- The queue holds up to ReplicationQueueSize tasks (default 10000); writes wait for space until their deadline, then fail with ErrReplicationQueueFull
+- With ReplicationQueueDir set, pending tasks persist there and resume when the coordinator restarts
+- ActiveTasks, OldestTaskAge, and Backpressured report the queue depth, how long its oldest task has waited, and how many writes waited for space
+
+Consistency Verification (VerifySampleSize, VerifyKeysPerSecond, VerifyRepair):
+- VerifyConsistency samples up to VerifySampleSize keys (default 100) spread across a prefix, at most VerifyKeysPerSecond keys a second (default 20)
+- Each key is read from every alive node through SetReplicaReadTransport and compared with the newest version most nodes agree on
+- ETag, version, and size mismatches, and keys held by fewer nodes than ReplicationFactor, are listed in a JSON-encodable ConsistencyReport
+- With VerifyRepair, divergent replicas are rewritten from the authoritative copy through the replica transport
+
+Each GetStats returns a fresh snapshot read under all the locks its values
+are written under, taken in a fixed order, so values in one snapshot agree:
+the coordinator's completed, failed, and active tasks add up to those
+created, and the consensus engine reports itself as leader only in the
+leader state.
+
 # Cluster Health Monitoring
 
 Check cluster health and node status:
@@ -216,16 +264,36 @@ The gossip protocol implements:
 
 1. Heartbeat-based failure detection
 2. Automatic leader re-election
-3. Node state propagation
+3. Node state propagation (log(N) fanout plus push-pull sync)
 4. Split-brain prevention (via quorum)
 
+GossipBandwidth budgets the bytes per second a node gossips. Each round sends
+the periodic push-pull sync first, then alive messages, shrinking the fanout
+to the peers the budget covers, then heartbeats. Heartbeats are the first to
+be deferred, and a round whose sync does not fit is deferred whole so the
+budget builds up for it. Push-pull replies are skipped when the budget is
+spent. Joins, leaves and death notices are always sent but count
+against the budget. GossipStats reports the budget,
+the smoothed EffectiveBandwidth in bytes per second and DeferredMessages.
+
 Failure Detection:
 
 	// Nodes automatically detect failures via gossip protocol
 	// Failed nodes are marked as NodeStatusSuspect or NodeStatusDead
 
-	// Subscribe to node status changes
-	// (Implementation-specific, depends on cluster manager)
+	// Subscribe to membership, leadership, and partition changes
+	for event := range cluster.Events() {
+		switch event.Type {
+		case distributed.EventNodeDead:
+			log.Printf("node %s died", event.NodeID)
+		case distributed.EventLeaderChanged:
+			log.Printf("leader %s -> %s", event.PreviousLeader, event.Leader)
+		}
+	}
+
+Each Events call returns a new buffered subscription, closed when the cluster manager
+stops. Publishing never blocks: a subscriber that falls behind misses events, which shows
+as a gap in their Seq numbers and is counted by DroppedEvents.
 
 Leader Election:
 
@@ -235,6 +303,164 @@ Leader Election:
 	// - Configuration changes
 	// - Quorum decisions
 
+Any node can submit a proposal with ForwardProposal. Followers send it to the
+leader through the transport set with SetProposalTransport, re-forwarding if
+leadership moves, and block until it is accepted, rejected, or expired:
+
+	result, err := consensus.ForwardProposal(ctx, &ConsensusProposal{
+		Type: ProposalTypeConfigChange,
+		Data: change,
+	})
+
+Committed entries are applied to the StateMachine set with SetStateMachine.
+Once SnapshotThreshold entries (or SnapshotThresholdBytes of entry data) are
+applied past the last snapshot, the engine snapshots the state machine,
+saves the snapshot to SnapshotDir, and truncates the log through it. A
+restarted node restores the state machine from the saved snapshot, and a
+follower that needs compacted entries receives the snapshot through the
+SnapshotTransport instead:
+
+	consensus.SetSnapshotTransport(func(ctx context.Context, nodeID string, msg *InstallSnapshotMessage) (*InstallSnapshotResponse, error) {
+		return sendToPeer(ctx, nodeID, msg) // Calls HandleInstallSnapshot on nodeID
+	})
+
+The log itself is held in memory; only snapshots are persisted.
+
+Votes and log entries reach peers through the transports set with
+SetVoteTransport and SetAppendEntriesTransport, which call HandleRequestVote
+and HandleAppendEntries on the peer. A node that sees a higher term steps
+down, and heartbeats carry the entries a lagging follower is missing. With an
+append transport set, an operation proposal is accepted once its log entry
+commits. Without the transports, votes and replication are simulated.
+
+# Value Stores
+
+ValueStore holds small typed values such as lock records and session maps,
+serialized by a ValueCodec (JSONCodec or GobCodec). Every write gives a key a
+new revision, and CompareAndSwap only writes if the key is still at the
+revision the caller read, failing with ErrRevisionMismatch otherwise:
+
+	var lock LockRecord
+	rev, err := store.Get(ctx, "locks/a", &lock)
+	...
+	_, err = store.CompareAndSwap(ctx, "locks/a", rev, LockRecord{Owner: nodeID})
+
+ReplicatedValueStore is strongly consistent: it becomes the consensus
+engine's state machine, so each node creates one, and writes and reads are
+ordered through the log. CacheValueStore keeps values in a types.Cache for
+state that can be lost or read stale, such as session affinity hints.
+
+ReplicatedValueStore.Watch calls a function each time a key changes on the
+node, as entries apply or a snapshot is restored.
+
+# Cluster Configuration
+
+ConfigStore keeps the settings every node must agree on, such as the
+replication factor, consistency by prefix and lifecycle rules, in the
+replicated value store. An admin sets them once from any node; followers
+forward the change to the leader, and each node applies the whole version
+when it commits:
+
+	configs, _ := distributed.NewConfigStore(cluster, store)
+	configs.OnChange(func(v distributed.ConfigVersion) { apply(v.Settings) })
+	version, err := configs.ProposeConfigChange(ctx, distributed.ClusterSettings{
+		ReplicationFactor: 3,
+		ConsistencyLevel:  distributed.ConsistencyEventual,
+		PrefixConsistency: map[string]distributed.ConsistencyLevel{"locks/": distributed.ConsistencyStrong},
+	})
+
+Versions are numbered from 1 and CommittedVersion reports the one applied on
+the node. The last 16 earlier versions are kept, and Rollback commits the
+settings of one of them as a new version. A restarted node reloads the
+committed version as it replays the log.
+
+# Transport Security
+
+Consensus messages travel between nodes over TCP. ListenPeers serves a
+node's ConsensusEngine on ListenAddr, and a PeerClient from NewPeerClient
+reaches peers at their NodeInfo address; its methods are the vote,
+append-entries, snapshot, and proposal transports:
+
+	server, _ := cluster.ListenPeers("")
+	defer server.Close()
+	peers, _ := cluster.NewPeerClient()
+	consensus.SetVoteTransport(peers.RequestVote)
+	consensus.SetAppendEntriesTransport(peers.AppendEntries)
+	consensus.SetSnapshotTransport(peers.InstallSnapshot)
+	consensus.SetProposalTransport(peers.ForwardProposal)
+
+TLS (CertFile, KeyFile, CAFile, RequireClientCert):
+- With CertFile and KeyFile set, listeners serve TLS and dialers present the node certificate
+- Peer certificates are verified against CAFile, or the system roots when it is unset
+- RequireClientCert rejects nodes without a certificate signed by CAFile; PeerServer.Rejected counts them
+- Gossip stays on UDP and is not encrypted
+
+# Prefix Operations
+
+Recursive deletes and lists run through Coordinator.ExecutePrefixOperation.
+Under PrefixConsistency "strong" (the default) a delete resolves its keys
+once, logs the intent through consensus, and has every node prepare it.
+The logged commit decision then deletes the same keys on each node in one
+step; if any node fails to prepare, the abort is logged instead and no node
+deletes anything:
+
+	coordinator.SetPrefixStore(localStore)
+	coordinator.SetPrefixTransport(sendPrefixPhase) // Calls HandlePrefixIntent on the peer
+
+	result, err := coordinator.ExecutePrefixOperation(ctx, &PrefixOperation{
+		Type:   OpTypeDelete,
+		Prefix: "projects/old/",
+	})
+
+# Key Ownership
+
+Nodes announce the cached ranges peers may fetch from them with
+Coordinator.AnnounceKey, broadcast over gossip unless an OwnershipTransport
+is set. WatchEvictions retracts an announcement as soon as the range is
+evicted locally, so KeyOwners only lists nodes that still hold the data:
+
+	coordinator.WatchEvictions(localCache)
+	_ = coordinator.AnnounceKey("videos/intro.mp4", 0, 1<<20)
+	owners := coordinator.KeyOwners("videos/intro.mp4", 0, 1<<20)
+
+# Write Barriers
+
+Under eventual consistency, a write made through one node may not be
+visible on another yet. Coordinator.Barrier gives pipelines that hand off
+work between nodes an explicit "make my writes visible" step without
+upgrading every operation to strong consistency. Each write is versioned
+and announced to peers through the WriteNoticeTransport; a peer applies the
+notice by dropping the key from the cache registered with
+InvalidateOnPeerWrites and acknowledges it. Barrier resends unacknowledged
+notices until every alive node, or a majority with BarrierQuorum, has
+acknowledged the latest write of each key:
+
+	reader.InvalidateOnPeerWrites(localCache)
+	// ... write outputs through this node ...
+	if err := coordinator.Barrier(ctx, []string{"jobs/42/output"}); err != nil {
+		return err
+	}
+	// reads of jobs/42/output on any alive node now see the write
+
+# Repair Throttling
+
+RepairThrottle bounds repairs of divergent replicas so a hot divergent key
+cannot trigger a write on every read. The coordinator runs the repairs of
+VerifyConsistency through one built from ClusterConfig.RepairThrottle and
+reports its counters under "repair_throttle" in GetStats. Admit allows a key's repair at most
+once per KeyInterval, coalescing the rest, and charges repair bytes against
+a token bucket; repairs over budget are deferred and returned by Pending.
+Stats counts repairs per key range (the first RangeDepth path segments), so
+ranges that keep diverging stand out:
+
+	throttle := distributed.NewRepairThrottle(distributed.RepairThrottleConfig{
+		KeyInterval:    30 * time.Second,
+		BytesPerSecond: 4 << 20,
+	})
+	if throttle.Admit(key, int64(len(data))) == distributed.RepairAllowed {
+		// write the repaired value
+	}
+
 # Configuration
 
 ClusterConfig controls all distributed system behavior:
@@ -247,13 +473,26 @@ ClusterConfig controls all distributed system behavior:
 		SeedNodes         []string          // Bootstrap nodes
 		ReplicationFactor int               // Data replication count
 		ConsistencyLevel  string            // Default consistency
+		TLS               ClusterTLS        // Inter-node TLS and mutual TLS
+		PrefixConsistency string            // Prefix operation consistency
+		WriteFencing      bool              // Reject strong writes from old terms
 		GossipInterval    time.Duration     // Gossip frequency
+		GossipFanout      int               // Fixed fanout (0 = adaptive)
+		GossipFanoutMin   int               // Adaptive fanout lower bound
+		GossipFanoutMax   int               // Adaptive fanout upper bound
+		GossipBandwidth   int64             // Gossip bytes/sec budget (0 = unlimited)
+		PushPullInterval  time.Duration     // Full-state sync frequency
 		FailureTimeout    time.Duration     // Failure detection timeout
 		ElectionTimeout   time.Duration     // Leader election timeout
 		OperationTimeout  time.Duration     // Default op timeout
 		RetryAttempts     int               // Default retry count
+		Logger            *slog.Logger      // Structured log destination
 	}
 
+Every component logs through ClusterConfig.Logger (or the process default
+logger) with a component and node_id field, and events carry fields such as
+term, index, peer, and proposal_id rather than formatted text.
+
 # Best Practices
 
 1. Consistency Trade-offs
@@ -286,11 +525,31 @@ Test failure scenarios:
 - Leader failure
 - Cascading failures
 
+ClusterSimulator runs N nodes in one process, wired through in-memory
+transports, so these scenarios can be scripted in tests. Partition cuts links
+between groups of nodes and Heal restores them; Kill and Restart stop a node
+and bring it back with its log. CheckInvariants fails if a term ever had two
+leaders, if the leader lost a write acknowledged as committed, or if two nodes
+applied different entries at the same index:
+
+	sim, _ := distributed.NewClusterSimulator(&distributed.SimulatorConfig{Nodes: 5})
+	defer sim.Close()
+
+	_ = sim.Elect(ctx, "node-1")
+	_ = sim.Propose(ctx, "node-1", []byte("write"))
+	sim.Partition([]string{"node-1", "node-2"}, []string{"node-3", "node-4", "node-5"})
+	_ = sim.Elect(ctx, "node-3")
+	sim.Heal()
+	_ = sim.WaitForReplication(ctx)
+	if err := sim.CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+
 Known Issues & Limitations
 
-⚠️ Race Conditions: The distributed package currently has race conditions that cause
-test timeouts. These are being addressed in Sprint 4 with comprehensive integration
-tests using LocalStack.
+⚠️ Race Conditions: The distributed package has had race conditions that caused
+test timeouts. Consensus failure scenarios now run under ClusterSimulator; the
+gossip and coordinator paths still lack tests against a real network.
 
 ⚠️ Incomplete Features:
 - Consensus engine is partially implemented
@@ -0,0 +1,175 @@
+package distributed
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClusterEventType identifies a membership or leadership change
+type ClusterEventType string
+
+const (
+	EventNodeJoined        ClusterEventType = "node_joined"
+	EventNodeAlive         ClusterEventType = "node_alive" // A suspect or dead node is reachable again
+	EventNodeSuspect       ClusterEventType = "node_suspect"
+	EventNodeDead          ClusterEventType = "node_dead"
+	EventNodeLeft          ClusterEventType = "node_left"
+	EventLeaderChanged     ClusterEventType = "leader_changed"
+	EventPartitionDetected ClusterEventType = "partition_detected"
+	EventPartitionHealed   ClusterEventType = "partition_healed"
+)
+
+// eventBufferSize is the number of events buffered per subscriber
+const eventBufferSize = 256
+
+// ClusterEvent is a change in cluster membership or leadership. Seq
+// increases by one per published event; a gap in the sequence a subscriber
+// receives means events were dropped because it fell behind.
+type ClusterEvent struct {
+	Seq            uint64           `json:"seq"`
+	Type           ClusterEventType `json:"type"`
+	NodeID         string           `json:"node_id,omitempty"`
+	Leader         string           `json:"leader,omitempty"`
+	PreviousLeader string           `json:"previous_leader,omitempty"`
+	AliveNodes     int              `json:"alive_nodes,omitempty"`
+	Time           time.Time        `json:"time"`
+}
+
+// eventBus fans events out to subscribers without blocking the publisher
+type eventBus struct {
+	mu          sync.Mutex
+	seq         uint64
+	subscribers []chan ClusterEvent
+	closed      bool
+	dropped     atomic.Int64
+}
+
+// subscribe returns a channel receiving every event published from now on
+func (b *eventBus) subscribe() <-chan ClusterEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan ClusterEvent, eventBufferSize)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subscribers = append(b.subscribers, ch)
+	return ch
+}
+
+// publish numbers event and delivers it to every subscriber with room in
+// its buffer; full subscribers miss the event
+func (b *eventBus) publish(event ClusterEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.seq++
+	event.Seq = b.seq
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// close closes every subscriber channel
+func (b *eventBus) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, ch := range b.subscribers {
+		close(ch)
+	}
+	b.subscribers = nil
+}
+
+// Events subscribes to cluster events. Each call returns a new channel,
+// closed when the cluster manager stops. Events are dropped rather than
+// delaying gossip when the subscriber does not keep up.
+func (cm *ClusterManager) Events() <-chan ClusterEvent {
+	return cm.events.subscribe()
+}
+
+// DroppedEvents returns the number of events not delivered to a subscriber
+// with a full buffer
+func (cm *ClusterManager) DroppedEvents() int64 {
+	return cm.events.dropped.Load()
+}
+
+// statusChangedLocked publishes the event for a node moving from one status
+// to another, clears leadership when the leader dies, and checks for a
+// partition. The caller must hold cm.mu.
+func (cm *ClusterManager) statusChangedLocked(nodeID string, from, to NodeStatus) {
+	if from == to {
+		return
+	}
+
+	switch to {
+	case NodeStatusAlive:
+		if from == NodeStatusSuspect || from == NodeStatusDead {
+			cm.events.publish(ClusterEvent{Type: EventNodeAlive, NodeID: nodeID})
+		} else {
+			cm.events.publish(ClusterEvent{Type: EventNodeJoined, NodeID: nodeID})
+		}
+	case NodeStatusJoining:
+		cm.events.publish(ClusterEvent{Type: EventNodeJoined, NodeID: nodeID})
+	case NodeStatusSuspect:
+		cm.events.publish(ClusterEvent{Type: EventNodeSuspect, NodeID: nodeID})
+	case NodeStatusDead:
+		cm.events.publish(ClusterEvent{Type: EventNodeDead, NodeID: nodeID})
+	case NodeStatusLeaving:
+		cm.events.publish(ClusterEvent{Type: EventNodeLeft, NodeID: nodeID})
+	}
+
+	// If the dead node was the leader, trigger election
+	if (to == NodeStatusDead || to == NodeStatusLeaving) && nodeID == cm.leader {
+		cm.setLeaderLocked("")
+		if cm.consensus != nil {
+			go func() {
+				_ = cm.consensus.TriggerElection(context.Background())
+			}()
+		}
+	}
+
+	cm.checkPartitionLocked()
+}
+
+// checkPartitionLocked publishes a partition event when the alive members
+// fall below or return to a majority of the known members. The caller must
+// hold cm.mu.
+func (cm *ClusterManager) checkPartitionLocked() {
+	members, alive := 0, 0
+	for _, node := range cm.nodes {
+		if node.Status == NodeStatusLeaving {
+			continue
+		}
+		members++
+		if node.Status == NodeStatusAlive {
+			alive++
+		}
+	}
+
+	partitioned := members > 1 && alive < members/2+1
+	switch {
+	case partitioned && !cm.partitioned:
+		cm.events.publish(ClusterEvent{Type: EventPartitionDetected, AliveNodes: alive})
+	case !partitioned && cm.partitioned:
+		cm.events.publish(ClusterEvent{Type: EventPartitionHealed, AliveNodes: alive})
+	}
+	cm.partitioned = partitioned
+}
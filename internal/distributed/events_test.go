@@ -0,0 +1,122 @@
+package distributed
+
+import (
+	"testing"
+	"time"
+)
+
+// nextEvent returns the next event on ch, failing the test after a second
+func nextEvent(t *testing.T, ch <-chan ClusterEvent) ClusterEvent {
+	t.Helper()
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a cluster event")
+		return ClusterEvent{}
+	}
+}
+
+// drainEvents discards buffered events
+func drainEvents(ch <-chan ClusterEvent) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
+
+func TestLeaderDeathPublishesDeadThenLeaderChanged(t *testing.T) {
+	cm := newTestConsensus(t, "local")
+	events := cm.Events()
+	for _, id := range []string{"local", "leader", "other"} {
+		cm.UpdateNodeInfo(id, &NodeInfo{ID: id, Status: NodeStatusAlive, LastSeen: time.Now()})
+	}
+	cm.SetLeader("leader")
+	drainEvents(events)
+
+	// The leader stops responding
+	cm.UpdateNodeInfo("leader", &NodeInfo{ID: "leader", Status: NodeStatusAlive, LastSeen: time.Now().Add(-time.Minute)})
+	cm.performHealthChecks()
+	cm.performHealthChecks()
+
+	suspect := nextEvent(t, events)
+	if suspect.Type != EventNodeSuspect || suspect.NodeID != "leader" {
+		t.Errorf("first event = %+v, want leader suspect", suspect)
+	}
+	dead := nextEvent(t, events)
+	if dead.Type != EventNodeDead || dead.NodeID != "leader" {
+		t.Errorf("second event = %+v, want leader dead", dead)
+	}
+	changed := nextEvent(t, events)
+	if changed.Type != EventLeaderChanged || changed.PreviousLeader != "leader" || changed.Leader != "" {
+		t.Errorf("third event = %+v, want leadership cleared from the dead leader", changed)
+	}
+	if dead.Seq != suspect.Seq+1 || changed.Seq != dead.Seq+1 {
+		t.Errorf("sequence numbers %d, %d, %d are not consecutive", suspect.Seq, dead.Seq, changed.Seq)
+	}
+}
+
+func TestPartitionDetectedAndHealed(t *testing.T) {
+	cm := newTestConsensus(t, "local")
+	events := cm.Events()
+	for _, id := range []string{"local", "a", "b"} {
+		cm.UpdateNodeInfo(id, &NodeInfo{ID: id, Status: NodeStatusAlive, LastSeen: time.Now()})
+	}
+	drainEvents(events)
+
+	cm.UpdateNodeInfo("a", &NodeInfo{ID: "a", Status: NodeStatusDead})
+	if event := nextEvent(t, events); event.Type != EventNodeDead {
+		t.Fatalf("event = %+v, want node dead", event)
+	}
+	drainEvents(events) // One of three down keeps a majority
+
+	cm.UpdateNodeInfo("b", &NodeInfo{ID: "b", Status: NodeStatusDead})
+	nextEvent(t, events)
+	if event := nextEvent(t, events); event.Type != EventPartitionDetected || event.AliveNodes != 1 {
+		t.Errorf("event = %+v, want partition detected with 1 alive node", event)
+	}
+
+	cm.UpdateNodeInfo("b", &NodeInfo{ID: "b", Status: NodeStatusAlive, LastSeen: time.Now()})
+	if event := nextEvent(t, events); event.Type != EventNodeAlive || event.NodeID != "b" {
+		t.Errorf("event = %+v, want node b alive", event)
+	}
+	if event := nextEvent(t, events); event.Type != EventPartitionHealed {
+		t.Errorf("event = %+v, want partition healed", event)
+	}
+}
+
+func TestSlowSubscriberDoesNotBlockPublisher(t *testing.T) {
+	cm := newTestConsensus(t, "local")
+	slow := cm.Events()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < eventBufferSize+10; i++ {
+			cm.SetLeader([]string{"a", "b"}[i%2])
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishing blocked on a subscriber that is not reading")
+	}
+
+	if dropped := cm.DroppedEvents(); dropped != 10 {
+		t.Errorf("dropped events = %d, want 10", dropped)
+	}
+	first := nextEvent(t, slow)
+	if first.Seq != 1 {
+		t.Errorf("first buffered event seq = %d, want 1", first.Seq)
+	}
+
+	// A subscriber joining later sees the sequence continue
+	late := cm.Events()
+	cm.SetLeader("c")
+	if event := nextEvent(t, late); event.Seq != uint64(eventBufferSize+11) {
+		t.Errorf("late subscriber event seq = %d, want %d", event.Seq, eventBufferSize+11)
+	}
+}
@@ -0,0 +1,39 @@
+package distributed
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStaleEpoch is returned for a write issued under an older consensus term
+// than one its target node has already accepted a write from
+var ErrStaleEpoch = errors.New("write epoch is older than the node's fence")
+
+// stampEpoch sets a write's epoch to the current consensus term when write
+// fencing is enabled and the write does not carry one yet
+func (c *Coordinator) stampEpoch(op *DistributedOperation) {
+	if !c.config.WriteFencing || op.Epoch != 0 {
+		return
+	}
+	if op.Type == OpTypePut || op.Type == OpTypeDelete {
+		op.Epoch = c.cluster.Epoch()
+	}
+}
+
+// admitEpoch checks a write's epoch against the newest one nodeID accepted,
+// raising the node's fence when the write is newer. Writes without an epoch
+// are not fenced.
+func (c *Coordinator) admitEpoch(nodeID string, epoch uint64) error {
+	if !c.config.WriteFencing || epoch == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if fence := c.fences[nodeID]; epoch < fence {
+		return fmt.Errorf("%w: epoch %d, node %s fenced at %d", ErrStaleEpoch, epoch, nodeID, fence)
+	}
+	c.fences[nodeID] = epoch
+	return nil
+}
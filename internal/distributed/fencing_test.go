@@ -0,0 +1,88 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func setTerm(cm *ClusterManager, term uint64) {
+	cm.consensus.mu.Lock()
+	cm.consensus.currentTerm = term
+	cm.consensus.mu.Unlock()
+}
+
+func TestStaleEpochWriteRejectedAfterLeaderChange(t *testing.T) {
+	cm, err := NewClusterManager(&ClusterConfig{NodeID: "leader-1", WriteFencing: true})
+	if err != nil {
+		t.Fatalf("NewClusterManager failed: %v", err)
+	}
+	makeLeader(cm)
+	setTerm(cm, 1)
+	c := cm.coordinator
+	ctx := context.Background()
+	nodes := []string{"a", "b", "c"}
+
+	write := func(epoch uint64) (*DistributedOperation, *OperationResult) {
+		op := &DistributedOperation{
+			Type:        OpTypePut,
+			Key:         "config/settings.yaml",
+			Data:        []byte("v"),
+			Consistency: ConsistencyStrong,
+			TargetNodes: nodes,
+			Epoch:       epoch,
+		}
+		result, err := c.ExecuteOperation(ctx, op)
+		if err != nil {
+			t.Fatalf("ExecuteOperation failed: %v", err)
+		}
+		return op, result
+	}
+
+	op, result := write(0)
+	if !result.Success || op.Epoch != 1 {
+		t.Fatalf("write in term 1: success = %v, epoch = %d, want true and 1", result.Success, op.Epoch)
+	}
+
+	// A new leader is elected in term 2 and writes through the same nodes
+	setTerm(cm, 2)
+	if _, result := write(0); !result.Success {
+		t.Fatalf("write in term 2 failed: %s", result.Error)
+	}
+
+	// The deposed leader's write still carries term 1
+	_, result = write(1)
+	if result.Success {
+		t.Fatal("write stamped with term 1 succeeded after term 2 writes")
+	}
+	for _, node := range nodes {
+		nr := result.NodeResults[node]
+		if nr.Success || !strings.Contains(nr.Error, ErrStaleEpoch.Error()) {
+			t.Errorf("node %s: success = %v, error = %q, want stale epoch rejection", node, nr.Success, nr.Error)
+		}
+	}
+	if err := c.admitEpoch("a", 1); !errors.Is(err, ErrStaleEpoch) {
+		t.Errorf("admitEpoch(a, 1) = %v, want ErrStaleEpoch", err)
+	}
+}
+
+func TestWriteFencingDisabledAcceptsOldEpochs(t *testing.T) {
+	cm := newTestConsensus(t, "leader-1")
+	makeLeader(cm)
+	c := cm.coordinator
+
+	for _, epoch := range []uint64{2, 1} {
+		op := &DistributedOperation{
+			Type:        OpTypePut,
+			Key:         "k",
+			Consistency: ConsistencyStrong,
+			TargetNodes: []string{"a"},
+			Epoch:       epoch,
+		}
+		result, err := c.ExecuteOperation(context.Background(), op)
+		if err != nil || !result.Success {
+			t.Fatalf("epoch %d write: err = %v, success = %v, want success", epoch, err, result.Success)
+		}
+	}
+}
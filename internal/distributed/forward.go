@@ -0,0 +1,132 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotLeader is returned when a proposal reaches a node that is not the leader
+var ErrNotLeader = errors.New("node is not the consensus leader")
+
+// leaderRetryInterval is how long ForwardProposal waits before retrying
+// while the cluster has no known leader or leadership is moving
+const leaderRetryInterval = 50 * time.Millisecond
+
+// ProposalTransport delivers a proposal to the leader's HandleForwardedProposal
+// and returns the decided proposal
+type ProposalTransport func(ctx context.Context, leaderID string, proposal *ConsensusProposal) (*ConsensusProposal, error)
+
+// SetProposalTransport sets how followers reach the leader
+func (ce *ConsensusEngine) SetProposalTransport(transport ProposalTransport) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.transport = transport
+}
+
+// ForwardProposal submits a proposal from any node. Followers forward it to
+// the current leader, re-forwarding when leadership changes. It blocks until
+// the proposal is accepted, rejected, or expired, or ctx ends; without a
+// deadline the operation timeout applies.
+func (ce *ConsensusEngine) ForwardProposal(ctx context.Context, proposal *ConsensusProposal) (*ConsensusProposal, error) {
+	if proposal.ID == "" {
+		proposal.ID = newProposalID()
+	}
+	if proposal.Proposer == "" {
+		proposal.Proposer = ce.cluster.GetNodeID()
+	}
+
+	if _, ok := ctx.Deadline(); !ok && ce.config != nil && ce.config.OperationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ce.config.OperationTimeout)
+		defer cancel()
+	}
+
+	for {
+		if ce.IsLeader() {
+			result, err := ce.HandleForwardedProposal(ctx, proposal)
+			if !errors.Is(err, ErrNotLeader) {
+				return result, err
+			}
+		} else if leader := ce.cluster.GetLeader(); leader != "" && leader != ce.cluster.GetNodeID() {
+			ce.mu.RLock()
+			transport := ce.transport
+			ce.mu.RUnlock()
+			if transport == nil {
+				return nil, fmt.Errorf("no proposal transport configured to reach leader %s", leader)
+			}
+
+			ce.stats.mu.Lock()
+			ce.stats.ProposalsForwarded++
+			ce.stats.mu.Unlock()
+
+			result, err := transport(ctx, leader, proposal)
+			if err == nil {
+				return result, nil
+			}
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("proposal %s not decided: %w", proposal.ID, ctx.Err())
+			}
+			// Retry only if the leader stepped down or has since changed
+			if !errors.Is(err, ErrNotLeader) && ce.cluster.GetLeader() == leader {
+				return nil, fmt.Errorf("failed to forward proposal %s to leader %s: %w", proposal.ID, leader, err)
+			}
+			ce.logger.Warn("Leader could not take proposal, re-forwarding", "leader", leader, "proposal_id", proposal.ID, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("proposal %s not decided: %w", proposal.ID, ctx.Err())
+		case <-time.After(leaderRetryInterval):
+		}
+	}
+}
+
+// HandleForwardedProposal proposes a change on behalf of another node and
+// waits for it to be decided. A proposal already known by ID is not
+// proposed again, so re-forwarded proposals are only applied once.
+func (ce *ConsensusEngine) HandleForwardedProposal(ctx context.Context, proposal *ConsensusProposal) (*ConsensusProposal, error) {
+	ce.mu.Lock()
+	existing, known := ce.proposals[proposal.ID]
+	var decided <-chan struct{}
+	switch {
+	case known && existing.Status != ProposalStatusPending:
+		result := copyProposal(existing)
+		ce.mu.Unlock()
+		return result, nil
+	case known:
+		decided = ce.decided[proposal.ID]
+	default:
+		local := copyProposal(proposal)
+		var err error
+		if decided, err = ce.proposeLocked(local); err != nil {
+			ce.mu.Unlock()
+			return nil, err
+		}
+		existing = local
+	}
+	ce.mu.Unlock()
+
+	select {
+	case <-decided:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("proposal %s not decided: %w", proposal.ID, ctx.Err())
+	}
+
+	ce.mu.RLock()
+	defer ce.mu.RUnlock()
+	return copyProposal(existing), nil
+}
+
+// copyProposal returns a copy of p that shares no maps with it
+func copyProposal(p *ConsensusProposal) *ConsensusProposal {
+	c := *p
+	if p.Votes != nil {
+		c.Votes = make(map[string]bool, len(p.Votes))
+		for id, vote := range p.Votes {
+			c.Votes[id] = vote
+		}
+	}
+	return &c
+}
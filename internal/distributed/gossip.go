@@ -6,7 +6,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"math/rand"
 	"net"
 	"sync"
 	"time"
@@ -17,11 +19,20 @@ type GossipProtocol struct {
 	mu         sync.RWMutex
 	cluster    *ClusterManager
 	config     *ClusterConfig
+	logger     *slog.Logger
 	localNode  *NodeInfo
 	memberlist map[string]*GossipNode
 	conn       *net.UDPConn
 	stats      *GossipStats
 	stopCh     chan struct{}
+
+	round        int64
+	lastPushPull time.Time
+	budget       *gossipBudget // nil when gossip bandwidth is unlimited
+
+	// Overridable for simulation; nil transport sends over UDP
+	now       func() time.Time
+	transport func(addr string, data []byte) error
 }
 
 // GossipNode represents a node in the gossip protocol
@@ -70,6 +81,8 @@ const (
 	MessageTypeDead            MessageType = "dead"
 	MessageTypeSync            MessageType = "sync"
 	MessageTypeGossipHeartbeat MessageType = "gossip_heartbeat"
+	MessageTypeKeyAnnounce     MessageType = "key_announce"
+	MessageTypeKeyRetract      MessageType = "key_retract"
 )
 
 // JoinMessage represents a join request
@@ -82,6 +95,7 @@ type JoinMessage struct {
 type AliveMessage struct {
 	Node        *NodeInfo `json:"node"`
 	Incarnation uint32    `json:"incarnation"`
+	Since       time.Time `json:"since,omitempty"` // When this incarnation began
 }
 
 // SuspectMessage represents a suspicion about a node
@@ -98,9 +112,11 @@ type DeadMessage struct {
 	From        string `json:"from"`
 }
 
-// SyncMessage represents a full membership sync
+// SyncMessage represents a full membership sync. A push-pull sync asks the
+// receiver to reply with its own state.
 type SyncMessage struct {
-	Nodes map[string]*GossipNode `json:"nodes"`
+	Nodes    map[string]*GossipNode `json:"nodes"`
+	PushPull bool                   `json:"push_pull,omitempty"`
 }
 
 // HeartbeatMessage represents a heartbeat
@@ -124,6 +140,27 @@ type GossipStats struct {
 	NetworkErrors       int64            `json:"network_errors"`
 	AvgMessageLatency   time.Duration    `json:"avg_message_latency"`
 	LastMessageReceived time.Time        `json:"last_message_received"`
+
+	// Dissemination
+	GossipRounds  int64 `json:"gossip_rounds"`
+	CurrentFanout int   `json:"current_fanout"`
+	PushPullSyncs int64 `json:"push_pull_syncs"`
+
+	// Bandwidth: the configured budget in bytes per second (0 when
+	// unlimited), the smoothed rate actually sent, and messages dropped from
+	// rounds to stay within the budget
+	BandwidthBudget    int64   `json:"bandwidth_budget"`
+	EffectiveBandwidth float64 `json:"effective_bandwidth"`
+	DeferredMessages   int64   `json:"deferred_messages"`
+	sampledAt          time.Time
+	sampledBytes       int64
+
+	// Convergence: gossip rounds between a membership change and this node
+	// learning of it
+	ConvergenceSamples    int64   `json:"convergence_samples"`
+	LastConvergenceRounds int64   `json:"last_convergence_rounds"`
+	MaxConvergenceRounds  int64   `json:"max_convergence_rounds"`
+	AvgConvergenceRounds  float64 `json:"avg_convergence_rounds"`
 }
 
 // NewGossipProtocol creates a new gossip protocol instance
@@ -131,11 +168,15 @@ func NewGossipProtocol(cluster *ClusterManager, config *ClusterConfig) (*GossipP
 	gp := &GossipProtocol{
 		cluster:    cluster,
 		config:     config,
+		logger:     componentLogger(config, "gossip").With("node_id", cluster.GetNodeID()),
 		memberlist: make(map[string]*GossipNode),
 		stats: &GossipStats{
-			MessagesByType: make(map[string]int64),
+			MessagesByType:  make(map[string]int64),
+			BandwidthBudget: max(config.GossipBandwidth, 0),
 		},
 		stopCh: make(chan struct{}),
+		now:    time.Now,
+		budget: newGossipBudget(config.GossipBandwidth),
 	}
 
 	// Initialize local node
@@ -143,7 +184,7 @@ func NewGossipProtocol(cluster *ClusterManager, config *ClusterConfig) (*GossipP
 		ID:       cluster.GetNodeID(),
 		Address:  config.AdvertiseAddr,
 		Status:   NodeStatusAlive,
-		LastSeen: time.Now(),
+		LastSeen: gp.now(),
 		Version:  "1.0.0",
 		Metadata: make(map[string]string),
 	}
@@ -153,7 +194,7 @@ func NewGossipProtocol(cluster *ClusterManager, config *ClusterConfig) (*GossipP
 		Info:        gp.localNode,
 		Incarnation: 1,
 		State:       StateAlive,
-		StateChange: time.Now(),
+		StateChange: gp.now(),
 	}
 
 	return gp, nil
@@ -174,7 +215,7 @@ func (gp *GossipProtocol) Start(ctx context.Context) error {
 
 	gp.conn = conn
 
-	log.Printf("Gossip protocol listening on %s", gp.config.ListenAddr)
+	gp.logger.Info("Gossip protocol listening", "addr", gp.config.ListenAddr)
 
 	// Start background goroutines
 	go gp.receiveMessages(ctx)
@@ -193,7 +234,7 @@ func (gp *GossipProtocol) Stop() error {
 		_ = gp.conn.Close()
 	}
 
-	log.Printf("Gossip protocol stopped")
+	gp.logger.Info("Gossip protocol stopped")
 	return nil
 }
 
@@ -207,7 +248,7 @@ func (gp *GossipProtocol) JoinNode(ctx context.Context, nodeAddr string) error {
 	msg := &GossipMessage{
 		Type:      MessageTypeJoin,
 		From:      gp.localNode.ID,
-		Timestamp: time.Now(),
+		Timestamp: gp.now(),
 		MessageID: gp.generateMessageID(),
 	}
 
@@ -224,19 +265,18 @@ func (gp *GossipProtocol) JoinNode(ctx context.Context, nodeAddr string) error {
 // LeaveCluster announces that this node is leaving
 func (gp *GossipProtocol) LeaveCluster(ctx context.Context) error {
 	gp.mu.Lock()
-	defer gp.mu.Unlock()
-
 	// Update our state to leaving
 	if localGossipNode, exists := gp.memberlist[gp.localNode.ID]; exists {
 		localGossipNode.State = StateLeft
-		localGossipNode.StateChange = time.Now()
+		localGossipNode.StateChange = gp.now()
 	}
+	gp.mu.Unlock()
 
 	// Broadcast leave message
 	msg := &GossipMessage{
 		Type:      MessageTypeLeave,
 		From:      gp.localNode.ID,
-		Timestamp: time.Now(),
+		Timestamp: gp.now(),
 		MessageID: gp.generateMessageID(),
 	}
 
@@ -246,6 +286,28 @@ func (gp *GossipProtocol) LeaveCluster(ctx context.Context) error {
 	return gp.broadcastMessage(msg)
 }
 
+// BroadcastKeyAnnouncement tells every live peer that this node gained or
+// lost a cached key range
+func (gp *GossipProtocol) BroadcastKeyAnnouncement(announcement *KeyAnnouncement) error {
+	msgType := MessageTypeKeyAnnounce
+	if announcement.Retract {
+		msgType = MessageTypeKeyRetract
+	}
+
+	data, err := json.Marshal(announcement)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key announcement: %w", err)
+	}
+
+	return gp.broadcastMessage(&GossipMessage{
+		Type:      msgType,
+		From:      gp.localNode.ID,
+		Data:      data,
+		Timestamp: gp.now(),
+		MessageID: gp.generateMessageID(),
+	})
+}
+
 // Background goroutines
 
 func (gp *GossipProtocol) receiveMessages(ctx context.Context) {
@@ -278,7 +340,7 @@ func (gp *GossipProtocol) receiveMessages(ctx context.Context) {
 func (gp *GossipProtocol) handleIncomingMessage(data []byte, addr *net.UDPAddr) {
 	var msg GossipMessage
 	if err := json.Unmarshal(data, &msg); err != nil {
-		log.Printf("Failed to unmarshal gossip message: %v", err)
+		gp.logger.Warn("Failed to unmarshal gossip message", "from", addr.String(), "error", err)
 		return
 	}
 
@@ -287,7 +349,7 @@ func (gp *GossipProtocol) handleIncomingMessage(data []byte, addr *net.UDPAddr)
 	gp.stats.MessagesReceived++
 	gp.stats.BytesReceived += int64(len(data))
 	gp.stats.MessagesByType[string(msg.Type)]++
-	gp.stats.LastMessageReceived = time.Now()
+	gp.stats.LastMessageReceived = gp.now()
 	gp.stats.mu.Unlock()
 
 	// Process message based on type
@@ -306,19 +368,32 @@ func (gp *GossipProtocol) handleIncomingMessage(data []byte, addr *net.UDPAddr)
 		gp.handleSyncMessage(&msg)
 	case MessageTypeGossipHeartbeat:
 		gp.handleHeartbeatMessage(&msg)
+	case MessageTypeKeyAnnounce, MessageTypeKeyRetract:
+		gp.handleKeyMessage(&msg)
+	}
+}
+
+func (gp *GossipProtocol) handleKeyMessage(msg *GossipMessage) {
+	var announcement KeyAnnouncement
+	if err := json.Unmarshal(msg.Data, &announcement); err != nil {
+		gp.logger.Warn("Failed to unmarshal gossip message", "type", msg.Type, "from", msg.From, "error", err)
+		return
+	}
+	announcement.Retract = msg.Type == MessageTypeKeyRetract
+
+	if gp.cluster.coordinator != nil {
+		gp.cluster.coordinator.HandleKeyAnnouncement(&announcement)
 	}
 }
 
 func (gp *GossipProtocol) handleJoinMessage(msg *GossipMessage) {
 	var joinMsg JoinMessage
 	if err := json.Unmarshal(msg.Data, &joinMsg); err != nil {
-		log.Printf("Failed to unmarshal join message: %v", err)
+		gp.logger.Warn("Failed to unmarshal gossip message", "type", msg.Type, "from", msg.From, "error", err)
 		return
 	}
 
 	gp.mu.Lock()
-	defer gp.mu.Unlock()
-
 	nodeID := joinMsg.Node.ID
 
 	// Add or update node in memberlist
@@ -326,26 +401,28 @@ func (gp *GossipProtocol) handleJoinMessage(msg *GossipMessage) {
 		Info:        joinMsg.Node,
 		Incarnation: joinMsg.Incarnation,
 		State:       StateAlive,
-		StateChange: time.Now(),
+		StateChange: msg.Timestamp,
 	}
 
 	// Update cluster manager
 	gp.cluster.UpdateNodeInfo(nodeID, joinMsg.Node)
+	gp.mu.Unlock()
 
-	log.Printf("Node %s joined the cluster", nodeID)
+	gp.logger.Info("Node joined the cluster", "node", nodeID)
 
 	gp.stats.mu.Lock()
 	gp.stats.NodesDiscovered++
 	gp.stats.mu.Unlock()
+	gp.recordConvergence(msg.Timestamp)
 
 	// Send sync message back to the joining node
-	_ = gp.sendSyncMessage(joinMsg.Node.Address)
+	_ = gp.sendSyncMessage(joinMsg.Node.Address, false)
 }
 
 func (gp *GossipProtocol) handleLeaveMessage(msg *GossipMessage) {
 	var leaveData map[string]string
 	if err := json.Unmarshal(msg.Data, &leaveData); err != nil {
-		log.Printf("Failed to unmarshal leave message: %v", err)
+		gp.logger.Warn("Failed to unmarshal gossip message", "type", msg.Type, "from", msg.From, "error", err)
 		return
 	}
 
@@ -356,7 +433,7 @@ func (gp *GossipProtocol) handleLeaveMessage(msg *GossipMessage) {
 
 	if gossipNode, exists := gp.memberlist[nodeID]; exists {
 		gossipNode.State = StateLeft
-		gossipNode.StateChange = time.Now()
+		gossipNode.StateChange = gp.now()
 
 		// Remove from cluster manager after a delay
 		go func() {
@@ -368,14 +445,14 @@ func (gp *GossipProtocol) handleLeaveMessage(msg *GossipMessage) {
 			gp.mu.Unlock()
 		}()
 
-		log.Printf("Node %s left the cluster", nodeID)
+		gp.logger.Info("Node left the cluster", "node", nodeID)
 	}
 }
 
 func (gp *GossipProtocol) handleAliveMessage(msg *GossipMessage) {
 	var aliveMsg AliveMessage
 	if err := json.Unmarshal(msg.Data, &aliveMsg); err != nil {
-		log.Printf("Failed to unmarshal alive message: %v", err)
+		gp.logger.Warn("Failed to unmarshal gossip message", "type", msg.Type, "from", msg.From, "error", err)
 		return
 	}
 
@@ -383,27 +460,38 @@ func (gp *GossipProtocol) handleAliveMessage(msg *GossipMessage) {
 	defer gp.mu.Unlock()
 
 	nodeID := aliveMsg.Node.ID
+	since := aliveMsg.Since
+	if since.IsZero() {
+		since = msg.Timestamp
+	}
 
 	if gossipNode, exists := gp.memberlist[nodeID]; exists {
 		// Update incarnation and state if newer
 		if aliveMsg.Incarnation > gossipNode.Incarnation {
+			gp.recordConvergence(since)
 			gossipNode.Incarnation = aliveMsg.Incarnation
 			gossipNode.State = StateAlive
-			gossipNode.StateChange = time.Now()
+			gossipNode.StateChange = since
 			gossipNode.Info = aliveMsg.Node
 			gossipNode.Suspicion = nil // Clear any suspicion
 
 			// Update cluster manager
 			aliveMsg.Node.Status = NodeStatusAlive
 			gp.cluster.UpdateNodeInfo(nodeID, aliveMsg.Node)
+		} else if aliveMsg.Incarnation == gossipNode.Incarnation && gossipNode.State == StateAlive {
+			// Load is soft state that changes without a new incarnation
+			gossipNode.Info = aliveMsg.Node
+			aliveMsg.Node.Status = NodeStatusAlive
+			gp.cluster.UpdateNodeInfo(nodeID, aliveMsg.Node)
 		}
 	} else {
 		// New node
+		gp.recordConvergence(since)
 		gp.memberlist[nodeID] = &GossipNode{
 			Info:        aliveMsg.Node,
 			Incarnation: aliveMsg.Incarnation,
 			State:       StateAlive,
-			StateChange: time.Now(),
+			StateChange: since,
 		}
 
 		// Update cluster manager
@@ -419,7 +507,7 @@ func (gp *GossipProtocol) handleAliveMessage(msg *GossipMessage) {
 func (gp *GossipProtocol) handleSuspectMessage(msg *GossipMessage) {
 	var suspectMsg SuspectMessage
 	if err := json.Unmarshal(msg.Data, &suspectMsg); err != nil {
-		log.Printf("Failed to unmarshal suspect message: %v", err)
+		gp.logger.Warn("Failed to unmarshal gossip message", "type", msg.Type, "from", msg.From, "error", err)
 		return
 	}
 
@@ -435,12 +523,12 @@ func (gp *GossipProtocol) handleSuspectMessage(msg *GossipMessage) {
 				gossipNode.Suspicion = &Suspicion{
 					Incarnation: suspectMsg.Incarnation,
 					From:        []string{suspectMsg.From},
-					Timeout:     time.Now().Add(5 * time.Second),
+					Timeout:     gp.now().Add(5 * time.Second),
 				}
 				gossipNode.State = StateSuspect
-				gossipNode.StateChange = time.Now()
+				gossipNode.StateChange = gp.now()
 
-				log.Printf("Node %s marked as suspect by %s", nodeID, suspectMsg.From)
+				gp.logger.Warn("Node marked as suspect", "node", nodeID, "reporter", suspectMsg.From)
 
 				gp.stats.mu.Lock()
 				gp.stats.SuspicionEvents++
@@ -471,7 +559,7 @@ func (gp *GossipProtocol) handleSuspectMessage(msg *GossipMessage) {
 func (gp *GossipProtocol) handleDeadMessage(msg *GossipMessage) {
 	var deadMsg DeadMessage
 	if err := json.Unmarshal(msg.Data, &deadMsg); err != nil {
-		log.Printf("Failed to unmarshal dead message: %v", err)
+		gp.logger.Warn("Failed to unmarshal gossip message", "type", msg.Type, "from", msg.From, "error", err)
 		return
 	}
 
@@ -484,10 +572,10 @@ func (gp *GossipProtocol) handleDeadMessage(msg *GossipMessage) {
 		// Only process if incarnation matches or is newer
 		if deadMsg.Incarnation >= gossipNode.Incarnation {
 			gossipNode.State = StateDead
-			gossipNode.StateChange = time.Now()
+			gossipNode.StateChange = gp.now()
 			gossipNode.Suspicion = nil
 
-			log.Printf("Node %s marked as dead by %s", nodeID, deadMsg.From)
+			gp.logger.Warn("Node marked as dead", "node", nodeID, "reporter", deadMsg.From)
 
 			gp.stats.mu.Lock()
 			gp.stats.DeathEvents++
@@ -505,12 +593,27 @@ func (gp *GossipProtocol) handleDeadMessage(msg *GossipMessage) {
 func (gp *GossipProtocol) handleSyncMessage(msg *GossipMessage) {
 	var syncMsg SyncMessage
 	if err := json.Unmarshal(msg.Data, &syncMsg); err != nil {
-		log.Printf("Failed to unmarshal sync message: %v", err)
+		gp.logger.Warn("Failed to unmarshal gossip message", "type", msg.Type, "from", msg.From, "error", err)
 		return
 	}
 
 	gp.mu.Lock()
-	defer gp.mu.Unlock()
+	defer func() {
+		var replyAddr string
+		if sender, ok := gp.memberlist[msg.From]; ok && sender.Info != nil {
+			replyAddr = sender.Info.Address
+		}
+		gp.mu.Unlock()
+
+		// Complete the push-pull exchange with our own state, unless the
+		// bandwidth budget is spent; the sender still gets ours next time
+		// one of us syncs
+		if syncMsg.PushPull && replyAddr != "" {
+			if reply, err := gp.syncMessage(false); err == nil {
+				_, _ = gp.trySendMessage(replyAddr, reply)
+			}
+		}
+	}()
 
 	// Merge membership information
 	for nodeID, remoteNode := range syncMsg.Nodes {
@@ -521,6 +624,7 @@ func (gp *GossipProtocol) handleSyncMessage(msg *GossipMessage) {
 		localNode, exists := gp.memberlist[nodeID]
 		if !exists {
 			// New node
+			gp.recordConvergence(remoteNode.StateChange)
 			gp.memberlist[nodeID] = &GossipNode{
 				Info:        remoteNode.Info,
 				Incarnation: remoteNode.Incarnation,
@@ -538,6 +642,7 @@ func (gp *GossipProtocol) handleSyncMessage(msg *GossipMessage) {
 			gp.stats.mu.Unlock()
 		} else if remoteNode.Incarnation > localNode.Incarnation {
 			// Update with newer information
+			gp.recordConvergence(remoteNode.StateChange)
 			localNode.Info = remoteNode.Info
 			localNode.Incarnation = remoteNode.Incarnation
 			localNode.State = remoteNode.State
@@ -554,7 +659,7 @@ func (gp *GossipProtocol) handleSyncMessage(msg *GossipMessage) {
 func (gp *GossipProtocol) handleHeartbeatMessage(msg *GossipMessage) {
 	var heartbeatMsg HeartbeatMessage
 	if err := json.Unmarshal(msg.Data, &heartbeatMsg); err != nil {
-		log.Printf("Failed to unmarshal heartbeat message: %v", err)
+		gp.logger.Warn("Failed to unmarshal gossip message", "type", msg.Type, "from", msg.From, "error", err)
 		return
 	}
 
@@ -574,7 +679,7 @@ func (gp *GossipProtocol) handleHeartbeatMessage(msg *GossipMessage) {
 		if gossipNode.State == StateSuspect && heartbeatMsg.Incarnation >= gossipNode.Incarnation {
 			gossipNode.State = StateAlive
 			gossipNode.Suspicion = nil
-			gossipNode.StateChange = time.Now()
+			gossipNode.StateChange = gp.now()
 
 			if gossipNode.Info != nil {
 				gossipNode.Info.Status = NodeStatusAlive
@@ -601,60 +706,120 @@ func (gp *GossipProtocol) gossipLoop(ctx context.Context) {
 }
 
 func (gp *GossipProtocol) performGossip() {
-	gp.mu.RLock()
+	now := gp.now()
+
+	gp.mu.Lock()
+	gp.round++
 	nodes := make([]*GossipNode, 0, len(gp.memberlist))
 	for _, node := range gp.memberlist {
 		if node.Info.ID != gp.localNode.ID && node.State != StateDead && node.State != StateLeft {
 			nodes = append(nodes, node)
 		}
 	}
-	gp.mu.RUnlock()
+	pushPull := gp.config.PushPullInterval > 0 && len(nodes) > 0 &&
+		now.Sub(gp.lastPushPull) >= gp.config.PushPullInterval
+	var since time.Time
+	if self, exists := gp.memberlist[gp.localNode.ID]; exists {
+		since = self.StateChange
+	}
+	gp.mu.Unlock()
+
+	gp.stats.mu.Lock()
+	gp.stats.GossipRounds++
+	gp.stats.mu.Unlock()
+	gp.recordBandwidth(now)
 
 	if len(nodes) == 0 {
 		return
 	}
 
 	// Select random nodes to gossip with
-	fanout := gp.config.GossipFanout
+	fanout := gp.fanout(len(nodes) + 1)
 	if fanout > len(nodes) {
 		fanout = len(nodes)
 	}
+	rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+
+	// Periodically exchange full state with one random peer. The sync goes
+	// before the round's other messages; when the bandwidth budget cannot
+	// cover it, the whole round is deferred so the budget builds up for it.
+	if pushPull {
+		if target := nodes[rand.Intn(len(nodes))]; target.Info != nil {
+			syncMsg, err := gp.syncMessage(true)
+			if err != nil {
+				return
+			}
+			ok, err := gp.trySendMessage(target.Info.Address, syncMsg)
+			if !ok {
+				gp.stats.mu.Lock()
+				gp.stats.DeferredMessages += int64(fanout)
+				gp.stats.CurrentFanout = 0
+				gp.stats.mu.Unlock()
+				return
+			}
+			gp.mu.Lock()
+			gp.lastPushPull = now
+			gp.mu.Unlock()
+			if err == nil {
+				gp.stats.mu.Lock()
+				gp.stats.PushPullSyncs++
+				gp.stats.mu.Unlock()
+			}
+		}
+	}
 
-	// Send alive message about ourselves
+	// Send alive message about ourselves with a fresh load sample
+	gp.refreshLocalLoad()
 	aliveMsg := &AliveMessage{
 		Node:        gp.localNode,
 		Incarnation: gp.getCurrentIncarnation(),
+		Since:       since,
 	}
 
 	msg := &GossipMessage{
 		Type:      MessageTypeAlive,
 		From:      gp.localNode.ID,
-		Timestamp: time.Now(),
+		Timestamp: now,
 		MessageID: gp.generateMessageID(),
 	}
 
 	data, _ := json.Marshal(aliveMsg)
 	msg.Data = data
 
-	// Gossip to random subset of nodes
-	for i := 0; i < fanout; i++ {
-		targetNode := nodes[i%len(nodes)]
-		if targetNode.Info != nil {
-			_ = gp.sendMessage(targetNode.Info.Address, msg)
+	// Gossip to random subset of nodes. Under a bandwidth budget the fanout
+	// shrinks to the peers the budget covers; the rest, and heartbeats, are
+	// deferred.
+	sent := 0
+	for i, targetNode := range nodes[:fanout] {
+		if targetNode.Info == nil {
+			continue
+		}
+		ok, _ := gp.trySendMessage(targetNode.Info.Address, msg)
+		if !ok {
+			gp.stats.mu.Lock()
+			gp.stats.DeferredMessages += int64(fanout - i - 1)
+			gp.stats.CurrentFanout = sent
+			gp.stats.mu.Unlock()
+			return
 		}
+		sent++
 	}
 
+	gp.stats.mu.Lock()
+	gp.stats.CurrentFanout = sent
+	gp.stats.mu.Unlock()
+
 	// Send heartbeat
 	heartbeatMsg := &HeartbeatMessage{
 		Node:        gp.localNode.ID,
-		Timestamp:   time.Now(),
+		Timestamp:   now,
 		Incarnation: gp.getCurrentIncarnation(),
 	}
 
 	heartbeatGossipMsg := &GossipMessage{
 		Type:      MessageTypeGossipHeartbeat,
 		From:      gp.localNode.ID,
-		Timestamp: time.Now(),
+		Timestamp: now,
 		MessageID: gp.generateMessageID(),
 	}
 
@@ -664,6 +829,71 @@ func (gp *GossipProtocol) performGossip() {
 	_ = gp.broadcastMessage(heartbeatGossipMsg)
 }
 
+// refreshLocalLoad samples this node's load and applied log index into the
+// info gossiped about it
+func (gp *GossipProtocol) refreshLocalLoad() {
+	provider := gp.cluster.LoadProvider()
+	if provider == nil {
+		return
+	}
+	components := provider.Sample()
+	applied := gp.cluster.AppliedIndex()
+
+	gp.mu.Lock()
+	applyLoad(gp.localNode, components)
+	applyReplicaState(gp.localNode, applied, components.Sampled)
+	info := *gp.localNode
+	info.Metadata = make(map[string]string, len(gp.localNode.Metadata))
+	for k, v := range gp.localNode.Metadata {
+		info.Metadata[k] = v
+	}
+	gp.mu.Unlock()
+
+	gp.cluster.UpdateNodeInfo(info.ID, &info)
+}
+
+// fanout returns the number of peers to gossip with per round. A fixed
+// GossipFanout takes precedence; otherwise fanout grows with log2 of the
+// alive members, bounded by GossipFanoutMin and GossipFanoutMax.
+func (gp *GossipProtocol) fanout(members int) int {
+	if gp.config.GossipFanout > 0 {
+		return gp.config.GossipFanout
+	}
+
+	fanout := int(math.Ceil(math.Log2(float64(max(members, 2)))))
+	if gp.config.GossipFanoutMin > 0 && fanout < gp.config.GossipFanoutMin {
+		fanout = gp.config.GossipFanoutMin
+	}
+	if gp.config.GossipFanoutMax > 0 && fanout > gp.config.GossipFanoutMax {
+		fanout = gp.config.GossipFanoutMax
+	}
+	return fanout
+}
+
+// recordConvergence records how many gossip rounds it took for a change made
+// at changedAt to reach this node
+func (gp *GossipProtocol) recordConvergence(changedAt time.Time) {
+	if changedAt.IsZero() || gp.config.GossipInterval <= 0 {
+		return
+	}
+
+	elapsed := gp.now().Sub(changedAt)
+	rounds := int64(math.Ceil(float64(elapsed) / float64(gp.config.GossipInterval)))
+	if rounds < 1 {
+		rounds = 1
+	}
+
+	gp.stats.mu.Lock()
+	defer gp.stats.mu.Unlock()
+
+	gp.stats.ConvergenceSamples++
+	gp.stats.LastConvergenceRounds = rounds
+	if rounds > gp.stats.MaxConvergenceRounds {
+		gp.stats.MaxConvergenceRounds = rounds
+	}
+	gp.stats.AvgConvergenceRounds += (float64(rounds) - gp.stats.AvgConvergenceRounds) / float64(gp.stats.ConvergenceSamples)
+}
+
 func (gp *GossipProtocol) suspicionTimer(ctx context.Context) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
@@ -684,7 +914,7 @@ func (gp *GossipProtocol) checkSuspicions() {
 	gp.mu.Lock()
 	defer gp.mu.Unlock()
 
-	now := time.Now()
+	now := gp.now()
 
 	for nodeID, gossipNode := range gp.memberlist {
 		if gossipNode.State == StateSuspect && gossipNode.Suspicion != nil {
@@ -694,7 +924,7 @@ func (gp *GossipProtocol) checkSuspicions() {
 				gossipNode.StateChange = now
 				gossipNode.Suspicion = nil
 
-				log.Printf("Node %s suspicion timeout, marking as dead", nodeID)
+				gp.logger.Warn("Node suspicion timed out, marking as dead", "node", nodeID)
 
 				// Broadcast dead message
 				deadMsg := &DeadMessage{
@@ -764,18 +994,18 @@ func (gp *GossipProtocol) sendMessage(addr string, msg *GossipMessage) error {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
-	if err != nil {
-		return fmt.Errorf("failed to resolve address: %w", err)
-	}
+	gp.budget.charge(gp.now(), len(data))
+	return gp.sendData(addr, msg.Type, data)
+}
 
-	conn, err := net.DialUDP("udp", nil, udpAddr)
-	if err != nil {
-		return fmt.Errorf("failed to dial: %w", err)
+// sendData sends an encoded message and records it in the stats
+func (gp *GossipProtocol) sendData(addr string, msgType MessageType, data []byte) error {
+	var err error
+	if gp.transport != nil {
+		err = gp.transport(addr, data)
+	} else {
+		err = gp.sendUDP(addr, data)
 	}
-	defer func() { _ = conn.Close() }()
-
-	_, err = conn.Write(data)
 	if err != nil {
 		gp.stats.mu.Lock()
 		gp.stats.NetworkErrors++
@@ -786,12 +1016,28 @@ func (gp *GossipProtocol) sendMessage(addr string, msg *GossipMessage) error {
 	gp.stats.mu.Lock()
 	gp.stats.MessagesSent++
 	gp.stats.BytesSent += int64(len(data))
-	gp.stats.MessagesByType[string(msg.Type)]++
+	gp.stats.MessagesByType[string(msgType)]++
 	gp.stats.mu.Unlock()
 
 	return nil
 }
 
+func (gp *GossipProtocol) sendUDP(addr string, data []byte) error {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to resolve address: %w", err)
+	}
+
+	conn, err := net.DialUDP("udp", nil, udpAddr)
+	if err != nil {
+		return fmt.Errorf("failed to dial: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, err = conn.Write(data)
+	return err
+}
+
 func (gp *GossipProtocol) broadcastMessage(msg *GossipMessage) error {
 	gp.mu.RLock()
 	nodes := make([]*NodeInfo, 0, len(gp.memberlist))
@@ -805,6 +1051,10 @@ func (gp *GossipProtocol) broadcastMessage(msg *GossipMessage) error {
 
 	for _, node := range nodes {
 		go func(addr string) {
+			if msg.Type.deferrable() {
+				_, _ = gp.trySendMessage(addr, msg)
+				return
+			}
 			_ = gp.sendMessage(addr, msg)
 		}(node.Address)
 	}
@@ -812,29 +1062,34 @@ func (gp *GossipProtocol) broadcastMessage(msg *GossipMessage) error {
 	return nil
 }
 
-func (gp *GossipProtocol) sendSyncMessage(addr string) error {
-	gp.mu.RLock()
-	nodes := make(map[string]*GossipNode)
-	for id, node := range gp.memberlist {
-		nodes[id] = node
+func (gp *GossipProtocol) sendSyncMessage(addr string, pushPull bool) error {
+	msg, err := gp.syncMessage(pushPull)
+	if err != nil {
+		return err
 	}
-	gp.mu.RUnlock()
+	return gp.sendMessage(addr, msg)
+}
 
-	syncMsg := &SyncMessage{
-		Nodes: nodes,
+// syncMessage builds a message carrying this node's full member list
+func (gp *GossipProtocol) syncMessage(pushPull bool) (*GossipMessage, error) {
+	// Marshal under the lock since handlers mutate member entries in place
+	gp.mu.RLock()
+	data, err := json.Marshal(&SyncMessage{
+		Nodes:    gp.memberlist,
+		PushPull: pushPull,
+	})
+	gp.mu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sync message: %w", err)
 	}
 
-	msg := &GossipMessage{
+	return &GossipMessage{
 		Type:      MessageTypeSync,
 		From:      gp.localNode.ID,
-		Timestamp: time.Now(),
+		Timestamp: gp.now(),
 		MessageID: gp.generateMessageID(),
-	}
-
-	data, _ := json.Marshal(syncMsg)
-	msg.Data = data
-
-	return gp.sendMessage(addr, msg)
+		Data:      data,
+	}, nil
 }
 
 func (gp *GossipProtocol) getCurrentIncarnation() uint32 {
@@ -868,6 +1123,17 @@ func (gp *GossipProtocol) GetStats() *GossipStats {
 		AvgMessageLatency:   gp.stats.AvgMessageLatency,
 		LastMessageReceived: gp.stats.LastMessageReceived,
 		MessagesByType:      make(map[string]int64),
+
+		GossipRounds:          gp.stats.GossipRounds,
+		CurrentFanout:         gp.stats.CurrentFanout,
+		PushPullSyncs:         gp.stats.PushPullSyncs,
+		BandwidthBudget:       gp.stats.BandwidthBudget,
+		EffectiveBandwidth:    gp.stats.EffectiveBandwidth,
+		DeferredMessages:      gp.stats.DeferredMessages,
+		ConvergenceSamples:    gp.stats.ConvergenceSamples,
+		LastConvergenceRounds: gp.stats.LastConvergenceRounds,
+		MaxConvergenceRounds:  gp.stats.MaxConvergenceRounds,
+		AvgConvergenceRounds:  gp.stats.AvgConvergenceRounds,
 	}
 	for k, v := range gp.stats.MessagesByType {
 		stats.MessagesByType[k] = v
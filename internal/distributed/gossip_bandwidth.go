@@ -0,0 +1,121 @@
+package distributed
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// gossipBudget is a token bucket limiting the bytes a node gossips per
+// second. It holds at most one second of budget. Messages the protocol
+// cannot skip, such as joins, leaves and death notices, are always sent and
+// charged, so deferrable messages back off until the debt is repaid.
+type gossipBudget struct {
+	mu     sync.Mutex
+	rate   float64 // Bytes per second
+	tokens float64
+	last   time.Time
+}
+
+// newGossipBudget returns a budget of rate bytes per second, or nil when
+// gossip bandwidth is unlimited
+func newGossipBudget(rate int64) *gossipBudget {
+	if rate <= 0 {
+		return nil
+	}
+	return &gossipBudget{rate: float64(rate), tokens: float64(rate)}
+}
+
+// refill adds the budget accrued since the last call. The caller holds b.mu.
+func (b *gossipBudget) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.rate)
+	}
+	b.last = now
+}
+
+// reserve takes n bytes from the budget if they fit. A message larger than
+// the whole bucket is let through once the bucket is full so it is never
+// starved; the bucket then goes into debt.
+func (b *gossipBudget) reserve(now time.Time, n int) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if b.tokens < float64(n) && b.tokens < b.rate {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// charge takes n bytes from the budget whether or not they fit
+func (b *gossipBudget) charge(now time.Time, n int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.tokens -= float64(n)
+}
+
+// deferrable reports whether a broadcast of type t may skip peers when the
+// bandwidth budget is spent. Heartbeats may; the alive and sync messages
+// performGossip sends are deferred there.
+func (t MessageType) deferrable() bool {
+	return t == MessageTypeGossipHeartbeat
+}
+
+// trySendMessage sends msg if the bandwidth budget allows it, reporting
+// whether it was sent. Deferred messages are counted in GossipStats.
+func (gp *GossipProtocol) trySendMessage(addr string, msg *GossipMessage) (bool, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if !gp.budget.reserve(gp.now(), len(data)) {
+		gp.stats.mu.Lock()
+		gp.stats.DeferredMessages++
+		gp.stats.mu.Unlock()
+		return false, nil
+	}
+	return true, gp.sendData(addr, msg.Type, data)
+}
+
+// bandwidthSampleInterval is the shortest period the effective gossip
+// bandwidth is sampled over, so single large syncs do not dominate it
+const bandwidthSampleInterval = time.Second
+
+// recordBandwidth folds the bytes sent since the last sample into the
+// effective gossip bandwidth once a sample interval has passed
+func (gp *GossipProtocol) recordBandwidth(now time.Time) {
+	gp.stats.mu.Lock()
+	defer gp.stats.mu.Unlock()
+
+	if gp.stats.sampledAt.IsZero() {
+		gp.stats.sampledAt = now
+		gp.stats.sampledBytes = gp.stats.BytesSent
+		return
+	}
+	elapsed := now.Sub(gp.stats.sampledAt)
+	if elapsed < bandwidthSampleInterval {
+		return
+	}
+
+	rate := float64(gp.stats.BytesSent-gp.stats.sampledBytes) / elapsed.Seconds()
+	if gp.stats.EffectiveBandwidth == 0 {
+		gp.stats.EffectiveBandwidth = rate
+	} else {
+		alpha := 0.1
+		gp.stats.EffectiveBandwidth = alpha*rate + (1-alpha)*gp.stats.EffectiveBandwidth
+	}
+	gp.stats.sampledAt = now
+	gp.stats.sampledBytes = gp.stats.BytesSent
+}
@@ -0,0 +1,298 @@
+package distributed
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// simNetwork delivers gossip messages between in-process nodes on a shared
+// simulated clock
+type simNetwork struct {
+	mu       sync.Mutex
+	now      time.Time
+	nodes    map[string]*GossipProtocol
+	queue    []simDelivery
+	interval time.Duration
+
+	// Applied to nodes added afterwards
+	pushPullInterval time.Duration
+	bandwidth        int64
+}
+
+type simDelivery struct {
+	addr string
+	data []byte
+}
+
+func newSimNetwork(interval time.Duration) *simNetwork {
+	return &simNetwork{
+		now:      time.Unix(1700000000, 0),
+		nodes:    make(map[string]*GossipProtocol),
+		interval: interval,
+	}
+}
+
+func (n *simNetwork) clock() time.Time {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.now
+}
+
+func (n *simNetwork) send(addr string, data []byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.nodes[addr]; !ok {
+		return fmt.Errorf("unknown address %s", addr)
+	}
+	n.queue = append(n.queue, simDelivery{addr: addr, data: append([]byte(nil), data...)})
+	return nil
+}
+
+// addNode creates a gossip node attached to the simulated network
+func (n *simNetwork) addNode(t *testing.T, id string) *GossipProtocol {
+	t.Helper()
+
+	cm, err := NewClusterManager(&ClusterConfig{
+		NodeID:           id,
+		AdvertiseAddr:    id + ":7946",
+		GossipInterval:   n.interval,
+		PushPullInterval: cmp.Or(n.pushPullInterval, n.interval),
+		GossipBandwidth:  n.bandwidth,
+	})
+	if err != nil {
+		t.Fatalf("NewClusterManager() error = %v", err)
+	}
+
+	gp := cm.gossip
+	gp.now = n.clock
+	gp.transport = n.send
+	gp.memberlist[id].StateChange = n.clock()
+
+	n.mu.Lock()
+	n.nodes[gp.localNode.Address] = gp
+	n.mu.Unlock()
+	return gp
+}
+
+// connect makes every node aware of every other node
+func (n *simNetwork) connect() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, gp := range n.nodes {
+		for _, other := range n.nodes {
+			if other == gp {
+				continue
+			}
+			info := *other.localNode
+			gp.memberlist[info.ID] = &GossipNode{
+				Info:        &info,
+				Incarnation: 1,
+				State:       StateAlive,
+				StateChange: n.now,
+			}
+		}
+	}
+}
+
+// drain delivers queued messages, including replies they trigger
+func (n *simNetwork) drain() {
+	for {
+		n.mu.Lock()
+		if len(n.queue) == 0 {
+			n.mu.Unlock()
+			return
+		}
+		delivery := n.queue[0]
+		n.queue = n.queue[1:]
+		gp := n.nodes[delivery.addr]
+		n.mu.Unlock()
+
+		gp.handleIncomingMessage(delivery.data, nil)
+	}
+}
+
+// round runs one gossip round on every node and advances the clock
+func (n *simNetwork) round() {
+	n.mu.Lock()
+	nodes := make([]*GossipProtocol, 0, len(n.nodes))
+	for _, gp := range n.nodes {
+		nodes = append(nodes, gp)
+	}
+	n.mu.Unlock()
+
+	for _, gp := range nodes {
+		gp.performGossip()
+	}
+	n.drain()
+
+	n.mu.Lock()
+	n.now = n.now.Add(n.interval)
+	n.mu.Unlock()
+}
+
+func knows(gp *GossipProtocol, id string) bool {
+	gp.mu.RLock()
+	defer gp.mu.RUnlock()
+	node, ok := gp.memberlist[id]
+	return ok && node.State == StateAlive
+}
+
+func TestGossipConvergence(t *testing.T) {
+	const clusterSize = 30
+
+	network := newSimNetwork(100 * time.Millisecond)
+	for i := 0; i < clusterSize; i++ {
+		network.addNode(t, fmt.Sprintf("node-%02d", i))
+	}
+	network.connect()
+
+	// A new node joins through a single member
+	joiner := network.addNode(t, "joiner")
+	if err := joiner.JoinNode(context.Background(), "node-00:7946"); err != nil {
+		t.Fatalf("JoinNode() error = %v", err)
+	}
+	network.drain()
+
+	members := clusterSize + 1
+	maxRounds := 2*int(math.Ceil(math.Log2(float64(members)))) + 2
+
+	rounds := 0
+	for ; rounds < maxRounds; rounds++ {
+		converged := true
+		for _, gp := range network.nodes {
+			if gp != joiner && !knows(gp, "joiner") {
+				converged = false
+				break
+			}
+		}
+		if converged {
+			break
+		}
+		network.round()
+	}
+
+	for addr, gp := range network.nodes {
+		if gp != joiner && !knows(gp, "joiner") {
+			t.Errorf("%s did not learn of joiner within %d rounds", addr, maxRounds)
+		}
+	}
+	if len(joiner.GetMemberlist()) != members {
+		t.Errorf("joiner knows %d members, want %d", len(joiner.GetMemberlist()), members)
+	}
+
+	// Every other node recorded how long the change took to reach it
+	var worst int64
+	for _, gp := range network.nodes {
+		if gp == joiner {
+			continue
+		}
+		stats := gp.GetStats()
+		if stats.ConvergenceSamples == 0 {
+			t.Errorf("node %s recorded no convergence samples", gp.localNode.ID)
+		}
+		worst = max(worst, stats.MaxConvergenceRounds)
+	}
+	if worst > int64(maxRounds)+1 {
+		t.Errorf("max convergence = %d rounds, want <= %d", worst, maxRounds+1)
+	}
+
+	stats := network.nodes["node-00:7946"].GetStats()
+	if stats.GossipRounds != int64(rounds) || stats.PushPullSyncs == 0 {
+		t.Errorf("GetStats() = rounds %d push-pull %d, want rounds %d and push-pull syncs",
+			stats.GossipRounds, stats.PushPullSyncs, rounds)
+	}
+	t.Logf("converged in %d rounds (bound %d), worst recorded %d", rounds, maxRounds, worst)
+}
+
+func TestGossipFanout(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ClusterConfig
+		members int
+		want    int
+	}{
+		{"small cluster uses minimum", ClusterConfig{GossipFanoutMin: 2, GossipFanoutMax: 8}, 3, 2},
+		{"scales with log2", ClusterConfig{GossipFanoutMin: 2, GossipFanoutMax: 8}, 30, 5},
+		{"large cluster capped", ClusterConfig{GossipFanoutMin: 2, GossipFanoutMax: 8}, 10000, 8},
+		{"fixed fanout overrides", ClusterConfig{GossipFanout: 3, GossipFanoutMin: 2, GossipFanoutMax: 8}, 10000, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gp := &GossipProtocol{config: &tt.config}
+			if got := gp.fanout(tt.members); got != tt.want {
+				t.Errorf("fanout(%d) = %d, want %d", tt.members, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGossipBandwidthBudget(t *testing.T) {
+	const (
+		clusterSize = 12
+		budget      = 4000 // bytes per second
+		interval    = 100 * time.Millisecond
+		maxRounds   = 300
+	)
+
+	network := newSimNetwork(interval)
+	network.pushPullInterval = 5 * interval
+	network.bandwidth = budget
+	for i := 0; i < clusterSize; i++ {
+		network.addNode(t, fmt.Sprintf("node-%02d", i))
+	}
+	network.connect()
+
+	joiner := network.addNode(t, "joiner")
+	if err := joiner.JoinNode(context.Background(), "node-00:7946"); err != nil {
+		t.Fatalf("JoinNode() error = %v", err)
+	}
+	network.drain()
+
+	converged := -1
+	for rounds := 0; rounds < maxRounds; rounds++ {
+		if converged < 0 {
+			all := true
+			for _, gp := range network.nodes {
+				if gp != joiner && !knows(gp, "joiner") {
+					all = false
+					break
+				}
+			}
+			if all {
+				converged = rounds
+			}
+		}
+		network.round()
+	}
+	if converged < 0 {
+		t.Fatalf("cluster did not learn of joiner within %d rounds", maxRounds)
+	}
+
+	// A node may spend its initial bucket and one oversized sync of debt
+	// beyond the steady rate
+	elapsed := (maxRounds * interval).Seconds()
+	limit := int64(budget*elapsed) + 3*budget
+	var deferred int64
+	for addr, gp := range network.nodes {
+		stats := gp.GetStats()
+		if stats.BytesSent > limit {
+			t.Errorf("%s sent %d bytes in %.0fs, want <= %d", addr, stats.BytesSent, elapsed, limit)
+		}
+		if stats.BandwidthBudget != budget || stats.EffectiveBandwidth <= 0 || stats.EffectiveBandwidth > 2*budget {
+			t.Errorf("%s bandwidth budget %d effective %.0f, want %d and (0, %d]",
+				addr, stats.BandwidthBudget, stats.EffectiveBandwidth, budget, 2*budget)
+		}
+		deferred += stats.DeferredMessages
+	}
+	if deferred == 0 {
+		t.Error("no messages were deferred under a tight budget")
+	}
+	t.Logf("converged in %d rounds under a %d B/s budget, %d messages deferred", converged, budget, deferred)
+}
@@ -0,0 +1,215 @@
+package distributed
+
+import (
+	"math"
+	"runtime/metrics"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Node metadata keys carrying the gossiped load score and its components
+const (
+	MetadataLoad         = "load"
+	MetadataLoadCPU      = "load.cpu"
+	MetadataLoadMemory   = "load.memory"
+	MetadataLoadCache    = "load.cache"
+	MetadataLoadInFlight = "load.inflight"
+)
+
+// Weights of each component in the load score; they sum to 1
+const (
+	loadWeightCPU      = 0.35
+	loadWeightMemory   = 0.25
+	loadWeightCache    = 0.10
+	loadWeightInFlight = 0.30
+)
+
+// LoadComponents are the normalized (0-1) inputs of a node's load score
+type LoadComponents struct {
+	CPU      float64   `json:"cpu"`
+	Memory   float64   `json:"memory"`
+	Cache    float64   `json:"cache"`
+	InFlight float64   `json:"in_flight"`
+	Score    float64   `json:"score"`
+	Sampled  time.Time `json:"sampled"`
+}
+
+// LoadProviderConfig configures a LocalLoadProvider
+type LoadProviderConfig struct {
+	MaxInFlight      int            // In-flight operations counted as full load (default 100)
+	MemoryLimit      uint64         // Bytes counted as full memory load (default the runtime memory limit)
+	CacheUtilization func() float64 // Fraction of cache capacity in use; nil reports 0
+}
+
+// LocalLoadProvider samples this node's CPU, memory, cache utilization,
+// and in-flight operations into a normalized load score
+type LocalLoadProvider struct {
+	config   LoadProviderConfig
+	inFlight atomic.Int64
+
+	// Samplers return utilization between 0 and 1; replaced in tests
+	cpu    func() float64
+	memory func() float64
+
+	mu         sync.Mutex
+	last       LoadComponents
+	cacheUtil  func() float64
+	cpuTotal   float64
+	cpuIdle    float64
+	cpuSampled bool
+}
+
+// NewLocalLoadProvider creates a load provider sampling the running process
+func NewLocalLoadProvider(config LoadProviderConfig) *LocalLoadProvider {
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = 100
+	}
+
+	p := &LocalLoadProvider{config: config, cacheUtil: config.CacheUtilization}
+	p.cpu = p.sampleCPU
+	p.memory = p.sampleMemory
+	return p
+}
+
+// SetCacheUtilization sets the function reporting cache utilization
+func (p *LocalLoadProvider) SetCacheUtilization(fn func() float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cacheUtil = fn
+}
+
+// Begin records the start of an operation; call the returned function when
+// it finishes
+func (p *LocalLoadProvider) Begin() func() {
+	p.inFlight.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { p.inFlight.Add(-1) })
+	}
+}
+
+// Sample measures current load
+func (p *LocalLoadProvider) Sample() LoadComponents {
+	c := LoadComponents{
+		CPU:      clampUnit(p.cpu()),
+		Memory:   clampUnit(p.memory()),
+		InFlight: clampUnit(float64(p.inFlight.Load()) / float64(p.config.MaxInFlight)),
+		Sampled:  time.Now(),
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cacheUtil != nil {
+		c.Cache = clampUnit(p.cacheUtil())
+	}
+	c.Score = loadWeightCPU*c.CPU + loadWeightMemory*c.Memory +
+		loadWeightCache*c.Cache + loadWeightInFlight*c.InFlight
+	p.last = c
+	return c
+}
+
+// Components returns the most recent sample
+func (p *LocalLoadProvider) Components() LoadComponents {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last
+}
+
+// sampleCPU returns the fraction of available CPU time used since the
+// previous sample
+func (p *LocalLoadProvider) sampleCPU() float64 {
+	samples := []metrics.Sample{
+		{Name: "/cpu/classes/total:cpu-seconds"},
+		{Name: "/cpu/classes/idle:cpu-seconds"},
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindFloat64 || samples[1].Value.Kind() != metrics.KindFloat64 {
+		return 0
+	}
+	total, idle := samples[0].Value.Float64(), samples[1].Value.Float64()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prevTotal, prevIdle, sampled := p.cpuTotal, p.cpuIdle, p.cpuSampled
+	p.cpuTotal, p.cpuIdle, p.cpuSampled = total, idle, true
+	if !sampled || total <= prevTotal {
+		return 0
+	}
+	return 1 - (idle-prevIdle)/(total-prevTotal)
+}
+
+// sampleMemory returns the fraction of the memory limit in use
+func (p *LocalLoadProvider) sampleMemory() float64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/gc/gomemlimit:bytes"},
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	limit := p.config.MemoryLimit
+	if limit == 0 && samples[1].Value.Kind() == metrics.KindUint64 {
+		// The runtime reports math.MaxInt64 when no limit is set
+		if runtimeLimit := samples[1].Value.Uint64(); runtimeLimit < math.MaxInt64 {
+			limit = runtimeLimit
+		}
+	}
+	if limit == 0 {
+		return 0
+	}
+	return float64(samples[0].Value.Uint64()) / float64(limit)
+}
+
+// clampUnit bounds v to [0, 1]
+func clampUnit(v float64) float64 {
+	switch {
+	case v < 0 || math.IsNaN(v):
+		return 0
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
+
+// applyLoad records c on info and in its gossiped metadata
+func applyLoad(info *NodeInfo, c LoadComponents) {
+	if info.Metadata == nil {
+		info.Metadata = make(map[string]string)
+	}
+	info.Load = c.Score
+	info.CPUUsage = c.CPU
+	info.MemoryUsage = c.Memory
+	for key, value := range map[string]float64{
+		MetadataLoad:         c.Score,
+		MetadataLoadCPU:      c.CPU,
+		MetadataLoadMemory:   c.Memory,
+		MetadataLoadCache:    c.Cache,
+		MetadataLoadInFlight: c.InFlight,
+	} {
+		info.Metadata[key] = strconv.FormatFloat(value, 'f', 3, 64)
+	}
+}
+
+// NodeLoadComponents returns the load components a node gossiped
+func NodeLoadComponents(info *NodeInfo) LoadComponents {
+	parse := func(key string) float64 {
+		v, _ := strconv.ParseFloat(info.Metadata[key], 64)
+		return v
+	}
+	c := LoadComponents{
+		CPU:      parse(MetadataLoadCPU),
+		Memory:   parse(MetadataLoadMemory),
+		Cache:    parse(MetadataLoadCache),
+		InFlight: parse(MetadataLoadInFlight),
+		Score:    info.Load,
+	}
+	if c.Score == 0 {
+		c.Score = parse(MetadataLoad)
+	}
+	return c
+}
@@ -0,0 +1,87 @@
+package distributed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeastLoadRoutesAwayFromLoadedNode(t *testing.T) {
+	cm := newTestConsensus(t, "local")
+	for id, load := range map[string]float64{"busy": 0.9, "idle": 0.1} {
+		cm.UpdateNodeInfo(id, &NodeInfo{ID: id, Status: NodeStatusAlive, LastSeen: time.Now(), Load: load})
+	}
+	lb := cm.coordinator.loadBalancer
+
+	routed := map[string]int{}
+	for i := 0; i < 100; i++ {
+		selected, err := lb.SelectNodes([]string{"busy", "idle"}, 1)
+		if err != nil {
+			t.Fatalf("SelectNodes failed: %v", err)
+		}
+		routed[selected[0]]++
+
+		// Routing is counted the way ExecuteOperation counts it
+		lb.stats.mu.Lock()
+		lb.stats.NodeLoad[selected[0]]++
+		lb.stats.mu.Unlock()
+	}
+
+	if routed["busy"] >= routed["idle"] {
+		t.Errorf("busy node got %d requests and idle node %d, want fewer for the busy node", routed["busy"], routed["idle"])
+	}
+	if routed["busy"] == 0 {
+		t.Error("busy node got no requests, want its share to grow as the idle node fills up")
+	}
+}
+
+func TestLeastLoadAlternatesWithoutReports(t *testing.T) {
+	cm := newTestConsensus(t, "local")
+	lb := cm.coordinator.loadBalancer
+
+	routed := map[string]int{}
+	for i := 0; i < 10; i++ {
+		selected, err := lb.SelectNodes([]string{"a", "b"}, 1)
+		if err != nil {
+			t.Fatalf("SelectNodes failed: %v", err)
+		}
+		routed[selected[0]]++
+		lb.stats.mu.Lock()
+		lb.stats.NodeLoad[selected[0]]++
+		lb.stats.mu.Unlock()
+	}
+	if routed["a"] != 5 || routed["b"] != 5 {
+		t.Errorf("routed = %v, want an even split", routed)
+	}
+}
+
+func TestLocalLoadProviderScore(t *testing.T) {
+	provider := NewLocalLoadProvider(LoadProviderConfig{
+		MaxInFlight:      4,
+		CacheUtilization: func() float64 { return 0.5 },
+	})
+	provider.cpu = func() float64 { return 1 }
+	provider.memory = func() float64 { return 2 } // Clamped to 1
+
+	done := provider.Begin()
+	provider.Begin()
+	c := provider.Sample()
+	if c.CPU != 1 || c.Memory != 1 || c.Cache != 0.5 || c.InFlight != 0.5 {
+		t.Errorf("components = %+v, want cpu 1, memory 1, cache 0.5, in-flight 0.5", c)
+	}
+	want := loadWeightCPU + loadWeightMemory + 0.5*loadWeightCache + 0.5*loadWeightInFlight
+	if c.Score < want-1e-9 || c.Score > want+1e-9 {
+		t.Errorf("score = %f, want %f", c.Score, want)
+	}
+
+	done()
+	done() // Finishing twice counts once
+	if c := provider.Sample(); c.InFlight != 0.25 {
+		t.Errorf("in-flight = %f after one operation finished, want 0.25", c.InFlight)
+	}
+
+	info := &NodeInfo{ID: "local"}
+	applyLoad(info, provider.Components())
+	if got := NodeLoadComponents(info); got.Score != info.Load || got.Cache != 0.5 || got.InFlight != 0.25 {
+		t.Errorf("gossiped components = %+v, want those sampled", got)
+	}
+}
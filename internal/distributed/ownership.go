@@ -0,0 +1,149 @@
+package distributed
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// KeyAnnouncement tells peers that a node now caches, or no longer caches,
+// a range of an object, so they know where to fetch it from
+type KeyAnnouncement struct {
+	NodeID  string `json:"node_id"`
+	Key     string `json:"key"`
+	Offset  int64  `json:"offset"`
+	Size    int64  `json:"size"`
+	Retract bool   `json:"retract,omitempty"`
+}
+
+// OwnershipTransport delivers a key announcement to the other nodes. When
+// unset, announcements are broadcast over gossip.
+type OwnershipTransport func(announcement *KeyAnnouncement) error
+
+// keyRange identifies a cached range of an object
+type keyRange struct {
+	key    string
+	offset int64
+	size   int64
+}
+
+// SetOwnershipTransport sets how key announcements reach other nodes
+func (c *Coordinator) SetOwnershipTransport(transport OwnershipTransport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ownershipTransport = transport
+}
+
+// AnnounceKey tells peers that this node caches size bytes of key starting
+// at offset
+func (c *Coordinator) AnnounceKey(key string, offset, size int64) error {
+	r := keyRange{key: key, offset: offset, size: size}
+	c.mu.Lock()
+	c.announced[r] = struct{}{}
+	c.addOwnerLocked(r, c.cluster.GetNodeID())
+	c.mu.Unlock()
+
+	return c.sendAnnouncement(&KeyAnnouncement{
+		NodeID: c.cluster.GetNodeID(),
+		Key:    key,
+		Offset: offset,
+		Size:   size,
+	})
+}
+
+// RetractKey withdraws an earlier announcement of a range, such as after it
+// was evicted. Ranges this node never announced are ignored.
+func (c *Coordinator) RetractKey(key string, offset, size int64) error {
+	r := keyRange{key: key, offset: offset, size: size}
+	c.mu.Lock()
+	if _, ok := c.announced[r]; !ok {
+		c.mu.Unlock()
+		return nil
+	}
+	delete(c.announced, r)
+	c.removeOwnerLocked(r, c.cluster.GetNodeID())
+	c.mu.Unlock()
+
+	return c.sendAnnouncement(&KeyAnnouncement{
+		NodeID:  c.cluster.GetNodeID(),
+		Key:     key,
+		Offset:  offset,
+		Size:    size,
+		Retract: true,
+	})
+}
+
+// WatchEvictions retracts the announcement of every range evicted from
+// cache, keeping peers from fetching data this node no longer holds
+func (c *Coordinator) WatchEvictions(cache types.Cache) {
+	cache.OnEvict(func(key string, offset, size int64) {
+		if err := c.RetractKey(key, offset, size); err != nil {
+			c.logger.Warn("Failed to retract evicted key", "key", key, "offset", offset, "size", size, "error", err)
+		}
+	})
+}
+
+// HandleKeyAnnouncement applies a peer's announcement or retraction to the
+// ownership map
+func (c *Coordinator) HandleKeyAnnouncement(announcement *KeyAnnouncement) {
+	r := keyRange{key: announcement.Key, offset: announcement.Offset, size: announcement.Size}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if announcement.Retract {
+		c.removeOwnerLocked(r, announcement.NodeID)
+	} else {
+		c.addOwnerLocked(r, announcement.NodeID)
+	}
+}
+
+// KeyOwners returns the nodes announced as caching the range, sorted
+func (c *Coordinator) KeyOwners(key string, offset, size int64) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	owners := c.keyOwners[keyRange{key: key, offset: offset, size: size}]
+	nodes := make([]string, 0, len(owners))
+	for nodeID := range owners {
+		nodes = append(nodes, nodeID)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func (c *Coordinator) addOwnerLocked(r keyRange, nodeID string) {
+	owners, ok := c.keyOwners[r]
+	if !ok {
+		owners = make(map[string]struct{})
+		c.keyOwners[r] = owners
+	}
+	owners[nodeID] = struct{}{}
+}
+
+func (c *Coordinator) removeOwnerLocked(r keyRange, nodeID string) {
+	owners, ok := c.keyOwners[r]
+	if !ok {
+		return
+	}
+	delete(owners, nodeID)
+	if len(owners) == 0 {
+		delete(c.keyOwners, r)
+	}
+}
+
+// sendAnnouncement delivers an announcement through the ownership transport,
+// falling back to a gossip broadcast
+func (c *Coordinator) sendAnnouncement(announcement *KeyAnnouncement) error {
+	c.mu.RLock()
+	transport := c.ownershipTransport
+	c.mu.RUnlock()
+
+	if transport != nil {
+		return transport(announcement)
+	}
+	if c.cluster.gossip == nil {
+		return fmt.Errorf("no transport for key announcements")
+	}
+	return c.cluster.gossip.BroadcastKeyAnnouncement(announcement)
+}
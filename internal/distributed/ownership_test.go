@@ -0,0 +1,61 @@
+package distributed
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/cache"
+)
+
+func TestEvictedAnnouncedKeyIsRetracted(t *testing.T) {
+	node := newTestConsensus(t, "node-1")
+	peer := newTestConsensus(t, "node-2")
+
+	var mu sync.Mutex
+	var sent []KeyAnnouncement
+	node.coordinator.SetOwnershipTransport(func(announcement *KeyAnnouncement) error {
+		mu.Lock()
+		sent = append(sent, *announcement)
+		mu.Unlock()
+		peer.coordinator.HandleKeyAnnouncement(announcement)
+		return nil
+	})
+
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 100, MaxEntries: 100, TTL: time.Hour, CleanupInterval: time.Hour})
+	t.Cleanup(func() { _ = lru.Close() })
+	node.coordinator.WatchEvictions(lru)
+
+	lru.Put("data/a", 4096, make([]byte, 60))
+	if err := node.coordinator.AnnounceKey("data/a", 4096, 60); err != nil {
+		t.Fatalf("AnnounceKey failed: %v", err)
+	}
+	if owners := peer.coordinator.KeyOwners("data/a", 4096, 60); len(owners) != 1 || owners[0] != "node-1" {
+		t.Fatalf("peer owners after announcement = %v, want [node-1]", owners)
+	}
+
+	// Unannounced evictions are not gossiped
+	lru.Put("data/b", 0, make([]byte, 60))
+	lru.Put("data/c", 0, make([]byte, 60))
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []KeyAnnouncement{
+		{NodeID: "node-1", Key: "data/a", Offset: 4096, Size: 60},
+		{NodeID: "node-1", Key: "data/a", Offset: 4096, Size: 60, Retract: true},
+	}
+	if len(sent) != len(want) {
+		t.Fatalf("announcements = %+v, want %+v", sent, want)
+	}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Errorf("announcement %d = %+v, want %+v", i, sent[i], want[i])
+		}
+	}
+	if owners := peer.coordinator.KeyOwners("data/a", 4096, 60); len(owners) != 0 {
+		t.Errorf("peer owners after eviction = %v, want none", owners)
+	}
+	if owners := node.coordinator.KeyOwners("data/a", 4096, 60); len(owners) != 0 {
+		t.Errorf("local owners after eviction = %v, want none", owners)
+	}
+}
@@ -0,0 +1,313 @@
+package distributed
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// Peer message types
+const (
+	peerMessageVote     = "request_vote"
+	peerMessageAppend   = "append_entries"
+	peerMessageSnapshot = "install_snapshot"
+	peerMessageProposal = "forward_proposal"
+)
+
+// peerRequest is a consensus message sent to a PeerServer, one per
+// connection
+type peerRequest struct {
+	Type     string                  `json:"type"`
+	Vote     *RequestVoteMessage     `json:"vote,omitempty"`
+	Append   *AppendEntriesMessage   `json:"append,omitempty"`
+	Snapshot *InstallSnapshotMessage `json:"snapshot,omitempty"`
+	Proposal *ConsensusProposal      `json:"proposal,omitempty"`
+}
+
+// peerResponse answers a peerRequest. NotLeader marks an Error caused by
+// the peer not being the leader, so the sender can re-forward.
+type peerResponse struct {
+	Vote      *RequestVoteResponse     `json:"vote,omitempty"`
+	Append    *AppendEntriesResponse   `json:"append,omitempty"`
+	Snapshot  *InstallSnapshotResponse `json:"snapshot,omitempty"`
+	Proposal  *ConsensusProposal       `json:"proposal,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+	NotLeader bool                     `json:"not_leader,omitempty"`
+}
+
+// PeerServer accepts consensus messages from other nodes over TCP, and over
+// TLS when ClusterConfig.TLS is set
+type PeerServer struct {
+	listener  net.Listener
+	consensus *ConsensusEngine
+	timeout   time.Duration
+	logger    *slog.Logger
+	wg        sync.WaitGroup
+
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	closed   bool
+	rejected int64
+}
+
+// ListenPeers serves consensus messages from other nodes on addr, or on
+// ListenAddr when addr is empty
+func (cm *ClusterManager) ListenPeers(addr string) (*PeerServer, error) {
+	if cm.consensus == nil {
+		return nil, fmt.Errorf("consensus engine not initialized")
+	}
+	if addr == "" {
+		addr = cm.config.ListenAddr
+	}
+	tlsConfig, err := cm.config.TLS.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for peers on %s: %w", addr, err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	s := &PeerServer{
+		listener:  listener,
+		consensus: cm.consensus,
+		timeout:   cm.config.OperationTimeout,
+		logger:    componentLogger(cm.config, "peer").With("node_id", cm.nodeID),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the server listens on
+func (s *PeerServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Rejected returns the connections refused during the TLS handshake, such
+// as those from nodes whose certificate is not signed by the cluster CA
+func (s *PeerServer) Rejected() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rejected
+}
+
+// Close stops accepting connections and closes those being served
+func (s *PeerServer) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *PeerServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Warn("peer accept failed", "error", err)
+			}
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// handle answers the one message a connection carries
+func (s *PeerServer) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(s.timeout))
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			s.mu.Lock()
+			s.rejected++
+			s.mu.Unlock()
+			s.logger.Warn("rejected peer connection", "peer", conn.RemoteAddr().String(), "error", err)
+			return
+		}
+	}
+
+	var req peerRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		s.logger.Warn("invalid peer message", "peer", conn.RemoteAddr().String(), "error", err)
+		return
+	}
+
+	var resp peerResponse
+	switch {
+	case req.Type == peerMessageVote && req.Vote != nil:
+		resp.Vote = s.consensus.HandleRequestVote(req.Vote)
+	case req.Type == peerMessageAppend && req.Append != nil:
+		resp.Append = s.consensus.HandleAppendEntries(req.Append)
+	case req.Type == peerMessageSnapshot && req.Snapshot != nil:
+		var err error
+		if resp.Snapshot, err = s.consensus.HandleInstallSnapshot(req.Snapshot); err != nil {
+			resp.Snapshot, resp.Error = nil, err.Error()
+		}
+	case req.Type == peerMessageProposal && req.Proposal != nil:
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		var err error
+		resp.Proposal, err = s.consensus.HandleForwardedProposal(ctx, req.Proposal)
+		cancel()
+		if err != nil {
+			resp.Proposal, resp.Error, resp.NotLeader = nil, err.Error(), errors.Is(err, ErrNotLeader)
+		}
+	default:
+		resp.Error = fmt.Sprintf("unsupported peer message type: %s", req.Type)
+	}
+	if err := json.NewEncoder(conn).Encode(&resp); err != nil {
+		s.logger.Warn("failed to answer peer message", "peer", conn.RemoteAddr().String(), "error", err)
+	}
+}
+
+// PeerClient sends consensus messages to the PeerServers of other nodes,
+// found by their NodeInfo address, over TLS when ClusterConfig.TLS is set.
+// RequestVote, AppendEntries, InstallSnapshot, and ForwardProposal serve as
+// the engine's VoteTransport, AppendEntriesTransport, SnapshotTransport, and
+// ProposalTransport.
+type PeerClient struct {
+	cluster   *ClusterManager
+	tlsConfig *tls.Config
+	timeout   time.Duration
+}
+
+// NewPeerClient creates a client for reaching other nodes
+func (cm *ClusterManager) NewPeerClient() (*PeerClient, error) {
+	tlsConfig, err := cm.config.TLS.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &PeerClient{
+		cluster:   cm,
+		tlsConfig: tlsConfig,
+		timeout:   cm.config.OperationTimeout,
+	}, nil
+}
+
+// RequestVote delivers a vote request to a peer's HandleRequestVote
+func (c *PeerClient) RequestVote(ctx context.Context, nodeID string, req *RequestVoteMessage) (*RequestVoteResponse, error) {
+	resp, err := c.send(ctx, nodeID, &peerRequest{Type: peerMessageVote, Vote: req})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Vote == nil {
+		return nil, fmt.Errorf("peer %s returned no vote", nodeID)
+	}
+	return resp.Vote, nil
+}
+
+// AppendEntries delivers log entries to a peer's HandleAppendEntries
+func (c *PeerClient) AppendEntries(ctx context.Context, nodeID string, msg *AppendEntriesMessage) (*AppendEntriesResponse, error) {
+	resp, err := c.send(ctx, nodeID, &peerRequest{Type: peerMessageAppend, Append: msg})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Append == nil {
+		return nil, fmt.Errorf("peer %s returned no append result", nodeID)
+	}
+	return resp.Append, nil
+}
+
+// InstallSnapshot delivers a snapshot to a peer's HandleInstallSnapshot
+func (c *PeerClient) InstallSnapshot(ctx context.Context, nodeID string, msg *InstallSnapshotMessage) (*InstallSnapshotResponse, error) {
+	resp, err := c.send(ctx, nodeID, &peerRequest{Type: peerMessageSnapshot, Snapshot: msg})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Snapshot == nil {
+		return nil, fmt.Errorf("peer %s returned no snapshot result", nodeID)
+	}
+	return resp.Snapshot, nil
+}
+
+// ForwardProposal delivers a proposal to the leader's
+// HandleForwardedProposal and returns the decided proposal. A leader that
+// has stepped down answers with an error wrapping ErrNotLeader.
+func (c *PeerClient) ForwardProposal(ctx context.Context, leaderID string, proposal *ConsensusProposal) (*ConsensusProposal, error) {
+	resp, err := c.send(ctx, leaderID, &peerRequest{Type: peerMessageProposal, Proposal: proposal})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Proposal == nil {
+		return nil, fmt.Errorf("peer %s returned no proposal", leaderID)
+	}
+	return resp.Proposal, nil
+}
+
+func (c *PeerClient) send(ctx context.Context, nodeID string, req *peerRequest) (*peerResponse, error) {
+	node, exists := c.cluster.GetNodes()[nodeID]
+	if !exists || node.Address == "" {
+		return nil, fmt.Errorf("no address known for peer %s", nodeID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if c.tlsConfig != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: c.tlsConfig}).DialContext(ctx, "tcp", node.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", node.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to peer %s: %w", nodeID, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send to peer %s: %w", nodeID, err)
+	}
+	var resp peerResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read reply from peer %s: %w", nodeID, err)
+	}
+	if resp.NotLeader {
+		return nil, fmt.Errorf("peer %s: %w", nodeID, ErrNotLeader)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("peer %s: %s", nodeID, resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,266 @@
+package distributed
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/config"
+)
+
+// testCA signs node certificates for 127.0.0.1
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate(%s) failed: %v", name, err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	ca := &testCA{cert: cert, key: key, file: filepath.Join(t.TempDir(), name+".pem")}
+	writePEM(t, ca.file, "CERTIFICATE", der)
+	return ca
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("WriteFile(%s) failed: %v", path, err)
+	}
+}
+
+// issue returns TLS settings for a node whose certificate ca signs and
+// that trusts the peers trusted signs
+func (ca *testCA) issue(t *testing.T, nodeID string, trusted *testCA) ClusterTLS {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: nodeID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate(%s) failed: %v", nodeID, err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	settings := ClusterTLS{
+		CertFile:          filepath.Join(dir, nodeID+".pem"),
+		KeyFile:           filepath.Join(dir, nodeID+"-key.pem"),
+		CAFile:            trusted.file,
+		RequireClientCert: true,
+	}
+	writePEM(t, settings.CertFile, "CERTIFICATE", der)
+	writePEM(t, settings.KeyFile, "EC PRIVATE KEY", keyDER)
+	return settings
+}
+
+func newTLSNode(t *testing.T, nodeID string, settings ClusterTLS) *ClusterManager {
+	t.Helper()
+	cm, err := NewClusterManager(&ClusterConfig{NodeID: nodeID, OperationTimeout: 5 * time.Second, TLS: settings})
+	if err != nil {
+		t.Fatalf("NewClusterManager(%s) failed: %v", nodeID, err)
+	}
+	return cm
+}
+
+func TestPeerTransportMutualTLS(t *testing.T) {
+	clusterCA := newTestCA(t, "cluster-ca")
+	rogueCA := newTestCA(t, "rogue-ca")
+
+	server := newTLSNode(t, "node-2", clusterCA.issue(t, "node-2", clusterCA))
+	listener, err := server.ListenPeers("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPeers() failed: %v", err)
+	}
+	defer listener.Close()
+
+	peer := &NodeInfo{ID: "node-2", Address: listener.Addr().String(), Status: NodeStatusAlive, LastSeen: time.Now()}
+	ctx := context.Background()
+
+	trusted := newTLSNode(t, "node-1", clusterCA.issue(t, "node-1", clusterCA))
+	trusted.UpdateNodeInfo("node-2", peer)
+	client, err := trusted.NewPeerClient()
+	if err != nil {
+		t.Fatalf("NewPeerClient() failed: %v", err)
+	}
+
+	vote, err := client.RequestVote(ctx, "node-2", &RequestVoteMessage{Term: 1, CandidateID: "node-1"})
+	if err != nil {
+		t.Fatalf("RequestVote() over mutual TLS failed: %v", err)
+	}
+	if !vote.VoteGranted || vote.Term != 1 {
+		t.Errorf("vote = %+v, want granted in term 1", vote)
+	}
+	appended, err := client.AppendEntries(ctx, "node-2", &AppendEntriesMessage{Term: 1, LeaderID: "node-1"})
+	if err != nil {
+		t.Fatalf("AppendEntries() over mutual TLS failed: %v", err)
+	}
+	if !appended.Success {
+		t.Errorf("heartbeat = %+v, want accepted", appended)
+	}
+
+	// A node trusting the cluster CA but holding a certificate it did not
+	// sign is refused
+	untrusted := newTLSNode(t, "node-3", rogueCA.issue(t, "node-3", clusterCA))
+	untrusted.UpdateNodeInfo("node-2", peer)
+	intruder, err := untrusted.NewPeerClient()
+	if err != nil {
+		t.Fatalf("NewPeerClient() failed: %v", err)
+	}
+	if _, err := intruder.RequestVote(ctx, "node-2", &RequestVoteMessage{Term: 2, CandidateID: "node-3"}); err == nil {
+		t.Fatal("RequestVote() with an untrusted certificate succeeded")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for listener.Rejected() != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rejected := listener.Rejected(); rejected != 1 {
+		t.Errorf("Rejected() = %d, want 1", rejected)
+	}
+	if term := server.consensus.GetCurrentTerm(); term != 1 {
+		t.Errorf("term = %d after the rejected vote request, want 1", term)
+	}
+}
+
+func TestPeerClientForwardsProposalsAndSnapshots(t *testing.T) {
+	leader := newTLSNode(t, "leader", ClusterTLS{})
+	makeLeader(leader)
+	listener, err := leader.ListenPeers("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPeers() failed: %v", err)
+	}
+	defer listener.Close()
+
+	follower := newTLSNode(t, "follower", ClusterTLS{})
+	follower.UpdateNodeInfo("leader", &NodeInfo{ID: "leader", Address: listener.Addr().String(), Status: NodeStatusAlive, LastSeen: time.Now()})
+	follower.SetLeader("leader")
+	peers, err := follower.NewPeerClient()
+	if err != nil {
+		t.Fatalf("NewPeerClient() failed: %v", err)
+	}
+	var forward ProposalTransport = peers.ForwardProposal
+	var install SnapshotTransport = peers.InstallSnapshot
+	follower.consensus.SetProposalTransport(forward)
+	ctx := context.Background()
+
+	result, err := follower.consensus.ForwardProposal(ctx, &ConsensusProposal{Type: ProposalTypeConfigChange, Data: []byte("replication_factor=5")})
+	if err != nil {
+		t.Fatalf("ForwardProposal() over the peer transport failed: %v", err)
+	}
+	if result.Status != ProposalStatusAccepted || result.Proposer != "follower" {
+		t.Errorf("result = %s from %s, want accepted from follower", result.Status, result.Proposer)
+	}
+	if got := leader.consensus.GetStats().ProposalsAccepted; got != 1 {
+		t.Errorf("leader ProposalsAccepted = %d, want 1", got)
+	}
+
+	// A leader that stepped down reports it so the proposal is re-forwarded
+	stepDown(leader)
+	if _, err := forward(ctx, "leader", &ConsensusProposal{ID: "prop-2", Type: ProposalTypeConfigChange}); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("forwarding to a former leader error = %v, want ErrNotLeader", err)
+	}
+
+	term := leader.consensus.GetCurrentTerm() + 1
+	installed, err := install(ctx, "leader", &InstallSnapshotMessage{LeaderID: "follower", Term: term, LastIncludedIndex: 50, LastIncludedTerm: term, Data: []byte("state")})
+	if err != nil {
+		t.Fatalf("InstallSnapshot() over the peer transport failed: %v", err)
+	}
+	if !installed.Success || installed.MatchIndex != 50 {
+		t.Errorf("InstallSnapshot() = %+v, want success through index 50", installed)
+	}
+}
+
+func TestClusterTLSValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings ClusterTLS
+		wantErr  bool
+	}{
+		{"disabled", ClusterTLS{}, false},
+		{"certificate without key", ClusterTLS{CertFile: "node.pem"}, true},
+		{"client certificates without CA", ClusterTLS{CertFile: "node.pem", KeyFile: "node-key.pem", RequireClientCert: true}, true},
+		{"client certificates without TLS", ClusterTLS{RequireClientCert: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.settings.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewClusterConfigMapsTLS(t *testing.T) {
+	clusterCA := newTestCA(t, "cluster-ca")
+	app := config.NewDefault().Cluster
+	app.NodeID = "node-2"
+	app.TLS = config.ClusterTLSConfig(clusterCA.issue(t, "node-2", clusterCA))
+
+	server, err := NewClusterManager(NewClusterConfig(app))
+	if err != nil {
+		t.Fatalf("NewClusterManager() failed: %v", err)
+	}
+	listener, err := server.ListenPeers("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPeers() failed: %v", err)
+	}
+	defer listener.Close()
+
+	client := newTLSNode(t, "node-1", clusterCA.issue(t, "node-1", clusterCA))
+	client.UpdateNodeInfo("node-2", &NodeInfo{ID: "node-2", Address: listener.Addr().String(), Status: NodeStatusAlive, LastSeen: time.Now()})
+	peers, err := client.NewPeerClient()
+	if err != nil {
+		t.Fatalf("NewPeerClient() failed: %v", err)
+	}
+	if _, err := peers.RequestVote(context.Background(), "node-2", &RequestVoteMessage{Term: 1, CandidateID: "node-1"}); err != nil {
+		t.Fatalf("RequestVote() to a node configured from cluster.tls failed: %v", err)
+	}
+
+	app.TLS.CAFile = ""
+	if _, err := NewClusterManager(NewClusterConfig(app)); err == nil {
+		t.Error("NewClusterManager() accepted cluster.tls require_client_cert without ca_file")
+	}
+}
@@ -0,0 +1,406 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PrefixPhase is a step of the two-phase protocol that applies a prefix
+// operation on every node
+type PrefixPhase string
+
+const (
+	PrefixPhasePrepare PrefixPhase = "prepare"
+	PrefixPhaseCommit  PrefixPhase = "commit"
+	PrefixPhaseAbort   PrefixPhase = "abort"
+)
+
+// PrefixOperation is a delete or list of every key under a prefix, such as
+// a recursive directory delete
+type PrefixOperation struct {
+	ID          string           `json:"id"`
+	Type        OperationType    `json:"type"` // OpTypeDelete or OpTypeList
+	Prefix      string           `json:"prefix"`
+	Consistency ConsistencyLevel `json:"consistency"`            // Defaults to PrefixConsistency
+	TargetNodes []string         `json:"target_nodes,omitempty"` // Defaults to this node and every alive peer
+}
+
+// PrefixIntent is a prefix operation resolved to the keys it covers. It is
+// recorded in the consensus log and sent to each node for every phase, so
+// all nodes apply exactly the same key set.
+type PrefixIntent struct {
+	ID          string        `json:"id"`
+	Type        OperationType `json:"type"`
+	Prefix      string        `json:"prefix"`
+	Keys        []string      `json:"keys"`
+	Coordinator string        `json:"coordinator"`
+	Phase       PrefixPhase   `json:"phase"`
+}
+
+// PrefixResult reports the outcome of a prefix operation
+type PrefixResult struct {
+	Success bool          `json:"success"`
+	Keys    []string      `json:"keys"`            // Keys listed, or deleted on every node
+	Nodes   []string      `json:"nodes"`           // Nodes the operation was applied on
+	Error   string        `json:"error,omitempty"` // Why the operation failed
+	Latency time.Duration `json:"latency"`
+}
+
+// PrefixStore is the key store a node applies prefix operations to
+type PrefixStore interface {
+	ListKeys(ctx context.Context, prefix string) ([]string, error)
+	// DeleteKeys removes keys, ignoring keys that no longer exist
+	DeleteKeys(ctx context.Context, keys []string) error
+}
+
+// PrefixTransport delivers a phase of a prefix operation to another node's
+// HandlePrefixIntent
+type PrefixTransport func(ctx context.Context, nodeID string, intent *PrefixIntent) error
+
+// SetPrefixStore sets the key store prefix operations apply to on this node
+func (c *Coordinator) SetPrefixStore(store PrefixStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prefixStore = store
+}
+
+// SetPrefixTransport sets how prefix operation phases reach other nodes
+func (c *Coordinator) SetPrefixTransport(transport PrefixTransport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prefixTransport = transport
+}
+
+// ExecutePrefixOperation deletes or lists every key under a prefix.
+//
+// Under strong consistency a delete runs as a two-phase commit: the keys
+// are resolved once, the intent is recorded through the consensus log, and
+// every target node prepares it. Only when all nodes prepared is the commit
+// decision logged and the deletion applied on each node in one step;
+// otherwise every node aborts and no key is deleted anywhere. A strong list
+// is ordered after earlier prefix operations by the same log. Weaker
+// consistency levels apply the operation on each node without consensus.
+func (c *Coordinator) ExecutePrefixOperation(ctx context.Context, op *PrefixOperation) (*PrefixResult, error) {
+	start := time.Now()
+
+	if op.Type != OpTypeDelete && op.Type != OpTypeList {
+		return nil, fmt.Errorf("unsupported prefix operation type: %s", op.Type)
+	}
+	if op.Type == OpTypeDelete && op.Prefix == "" {
+		return nil, fmt.Errorf("prefix delete requires a non-empty prefix")
+	}
+
+	c.mu.RLock()
+	store := c.prefixStore
+	c.mu.RUnlock()
+	if store == nil {
+		return nil, fmt.Errorf("no prefix store configured")
+	}
+
+	if op.ID == "" {
+		op.ID = fmt.Sprintf("prefix-%d-%s", start.UnixNano(), c.cluster.GetNodeID())
+	}
+	if op.Consistency == "" {
+		op.Consistency = ConsistencyLevel(c.config.PrefixConsistency)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.OperationTimeout)
+		defer cancel()
+	}
+
+	keys, err := store.ListKeys(ctx, op.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys under %q: %w", op.Prefix, err)
+	}
+	sort.Strings(keys)
+
+	intent := &PrefixIntent{
+		ID:          op.ID,
+		Type:        op.Type,
+		Prefix:      op.Prefix,
+		Keys:        keys,
+		Coordinator: c.cluster.GetNodeID(),
+	}
+	nodes := c.prefixTargets(op)
+
+	var result *PrefixResult
+	switch {
+	case op.Type == OpTypeList && op.Consistency == ConsistencyStrong:
+		result, err = c.listStrong(ctx, intent)
+	case op.Type == OpTypeList:
+		result = &PrefixResult{Success: true, Keys: keys, Nodes: []string{intent.Coordinator}}
+	case op.Consistency == ConsistencyStrong:
+		result, err = c.deleteStrong(ctx, intent, nodes)
+	default:
+		result, err = c.deleteEventual(ctx, intent, nodes)
+	}
+	if result != nil {
+		result.Latency = time.Since(start)
+	}
+	return result, err
+}
+
+// prefixTargets returns the nodes a prefix operation applies to, starting
+// with this node
+func (c *Coordinator) prefixTargets(op *PrefixOperation) []string {
+	self := c.cluster.GetNodeID()
+	targets := []string{self}
+
+	candidates := op.TargetNodes
+	if len(candidates) == 0 {
+		for nodeID, node := range c.cluster.GetNodes() {
+			if node.Status == NodeStatusAlive {
+				candidates = append(candidates, nodeID)
+			}
+		}
+		sort.Strings(candidates)
+	}
+	for _, nodeID := range candidates {
+		if nodeID != self {
+			targets = append(targets, nodeID)
+		}
+	}
+	return targets
+}
+
+// listStrong records the list in the consensus log, so it observes every
+// prefix operation decided before it, then lists this node's keys
+func (c *Coordinator) listStrong(ctx context.Context, intent *PrefixIntent) (*PrefixResult, error) {
+	intent.Phase = PrefixPhaseCommit
+	if err := c.logPrefixIntent(ctx, intent); err != nil {
+		return &PrefixResult{Error: err.Error()}, err
+	}
+
+	c.mu.RLock()
+	store := c.prefixStore
+	c.mu.RUnlock()
+	keys, err := store.ListKeys(ctx, intent.Prefix)
+	if err != nil {
+		return &PrefixResult{Error: err.Error()}, fmt.Errorf("failed to list keys under %q: %w", intent.Prefix, err)
+	}
+	sort.Strings(keys)
+	return &PrefixResult{Success: true, Keys: keys, Nodes: []string{intent.Coordinator}}, nil
+}
+
+// deleteStrong deletes the intent's keys on every node or on none
+func (c *Coordinator) deleteStrong(ctx context.Context, intent *PrefixIntent, nodes []string) (*PrefixResult, error) {
+	intent.Phase = PrefixPhasePrepare
+	if err := c.logPrefixIntent(ctx, intent); err != nil {
+		return &PrefixResult{Error: err.Error()}, err
+	}
+
+	// Every node must hold the intent before any node deletes
+	var prepared []string
+	var prepareErr error
+	for _, nodeID := range nodes {
+		if prepareErr = c.sendPrefixPhase(ctx, nodeID, intent, PrefixPhasePrepare); prepareErr != nil {
+			prepareErr = fmt.Errorf("node %s failed to prepare prefix delete %s: %w", nodeID, intent.ID, prepareErr)
+			break
+		}
+		prepared = append(prepared, nodeID)
+	}
+
+	if prepareErr != nil {
+		c.rollbackPrefix(intent, prepared)
+		c.logger.Warn("Prefix delete rolled back",
+			"operation_id", intent.ID, "prefix", intent.Prefix, "prepared", len(prepared), "nodes", len(nodes), "error", prepareErr)
+		return &PrefixResult{Error: prepareErr.Error()}, prepareErr
+	}
+
+	// The logged commit decision makes the delete final; deliver it to
+	// every node, retrying nodes that do not acknowledge it
+	intent.Phase = PrefixPhaseCommit
+	if err := c.logPrefixIntent(ctx, intent); err != nil {
+		c.rollbackPrefix(intent, prepared)
+		return &PrefixResult{Error: err.Error()}, err
+	}
+
+	var failed []string
+	var commitErr error
+	for _, nodeID := range nodes {
+		if err := c.commitPrefixWithRetry(ctx, nodeID, intent); err != nil {
+			failed = append(failed, nodeID)
+			commitErr = errors.Join(commitErr, fmt.Errorf("node %s: %w", nodeID, err))
+		}
+	}
+	if commitErr != nil {
+		err := fmt.Errorf("prefix delete %s committed but not yet applied on %s: %w", intent.ID, strings.Join(failed, ", "), commitErr)
+		return &PrefixResult{Keys: intent.Keys, Nodes: nodes, Error: err.Error()}, err
+	}
+
+	c.logger.Info("Prefix delete applied",
+		"operation_id", intent.ID, "prefix", intent.Prefix, "keys", len(intent.Keys), "nodes", len(nodes))
+	return &PrefixResult{Success: true, Keys: intent.Keys, Nodes: nodes}, nil
+}
+
+// deleteEventual deletes the intent's keys on each node independently
+func (c *Coordinator) deleteEventual(ctx context.Context, intent *PrefixIntent, nodes []string) (*PrefixResult, error) {
+	var applied []string
+	var errs error
+	for _, nodeID := range nodes {
+		if err := c.sendPrefixPhase(ctx, nodeID, intent, PrefixPhaseCommit); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("node %s: %w", nodeID, err))
+			continue
+		}
+		applied = append(applied, nodeID)
+	}
+
+	result := &PrefixResult{Success: errs == nil, Keys: intent.Keys, Nodes: applied}
+	if errs != nil {
+		result.Error = errs.Error()
+	}
+	return result, errs
+}
+
+// rollbackPrefix logs the abort decision and releases the intent on the
+// nodes that prepared it. Nodes that miss the abort never delete, because
+// they only apply committed intents.
+func (c *Coordinator) rollbackPrefix(intent *PrefixIntent, prepared []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.OperationTimeout)
+	defer cancel()
+
+	intent.Phase = PrefixPhaseAbort
+	if err := c.logPrefixIntent(ctx, intent); err != nil {
+		c.logger.Warn("Failed to log prefix abort", "operation_id", intent.ID, "error", err)
+	}
+	for _, nodeID := range prepared {
+		if err := c.sendPrefixPhase(ctx, nodeID, intent, PrefixPhaseAbort); err != nil {
+			c.logger.Warn("Failed to abort prefix operation", "operation_id", intent.ID, "node", nodeID, "error", err)
+		}
+	}
+}
+
+// commitPrefixWithRetry delivers the commit phase to nodeID, retrying with
+// the configured backoff
+func (c *Coordinator) commitPrefixWithRetry(ctx context.Context, nodeID string, intent *PrefixIntent) error {
+	var err error
+	for attempt := 0; attempt <= c.config.RetryAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(c.config.RetryBackoff):
+			}
+		}
+		if err = c.sendPrefixPhase(ctx, nodeID, intent, PrefixPhaseCommit); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// sendPrefixPhase delivers one phase of intent to nodeID, handling it
+// directly when nodeID is this node
+func (c *Coordinator) sendPrefixPhase(ctx context.Context, nodeID string, intent *PrefixIntent, phase PrefixPhase) error {
+	msg := *intent
+	msg.Phase = phase
+
+	if nodeID == c.cluster.GetNodeID() {
+		return c.HandlePrefixIntent(ctx, &msg)
+	}
+
+	c.mu.RLock()
+	transport := c.prefixTransport
+	c.mu.RUnlock()
+	if transport == nil {
+		return fmt.Errorf("no prefix transport configured to reach node %s", nodeID)
+	}
+	return transport(ctx, nodeID, &msg)
+}
+
+// logPrefixIntent records a phase of intent through the consensus log and
+// waits for it to be accepted
+func (c *Coordinator) logPrefixIntent(ctx context.Context, intent *PrefixIntent) error {
+	if c.cluster.consensus == nil {
+		return fmt.Errorf("consensus engine not initialized")
+	}
+
+	data, err := json.Marshal(intent)
+	if err != nil {
+		return fmt.Errorf("failed to encode prefix intent %s: %w", intent.ID, err)
+	}
+	proposal, err := c.cluster.consensus.ForwardProposal(ctx, &ConsensusProposal{
+		ID:   fmt.Sprintf("%s-%s", intent.ID, intent.Phase),
+		Type: ProposalTypeOperation,
+		Data: data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to log %s of prefix operation %s: %w", intent.Phase, intent.ID, err)
+	}
+	if proposal.Status != ProposalStatusAccepted {
+		return fmt.Errorf("%s of prefix operation %s was %s", intent.Phase, intent.ID, proposal.Status)
+	}
+	return nil
+}
+
+// HandlePrefixIntent applies one phase of a prefix operation on this node.
+// A prepared intent is held until its commit, which deletes all of its keys
+// at once, or its abort, which discards it.
+func (c *Coordinator) HandlePrefixIntent(ctx context.Context, intent *PrefixIntent) error {
+	c.mu.Lock()
+	store := c.prefixStore
+	if store == nil {
+		c.mu.Unlock()
+		return fmt.Errorf("no prefix store configured on node %s", c.cluster.GetNodeID())
+	}
+
+	switch intent.Phase {
+	case PrefixPhasePrepare:
+		c.preparedPrefixes[intent.ID] = intent
+		c.mu.Unlock()
+		return nil
+
+	case PrefixPhaseAbort:
+		delete(c.preparedPrefixes, intent.ID)
+		c.mu.Unlock()
+		return nil
+
+	case PrefixPhaseCommit:
+		keys := intent.Keys
+		if prepared, ok := c.preparedPrefixes[intent.ID]; ok {
+			keys = prepared.Keys
+		}
+		c.mu.Unlock()
+
+		if intent.Type != OpTypeDelete || len(keys) == 0 {
+			c.forgetPrefix(intent.ID)
+			return nil
+		}
+		if err := store.DeleteKeys(ctx, keys); err != nil {
+			return fmt.Errorf("failed to delete %d keys under %q: %w", len(keys), intent.Prefix, err)
+		}
+		c.forgetPrefix(intent.ID)
+		return nil
+
+	default:
+		c.mu.Unlock()
+		return fmt.Errorf("unknown prefix operation phase: %s", intent.Phase)
+	}
+}
+
+// forgetPrefix drops a prepared intent once it has been applied
+func (c *Coordinator) forgetPrefix(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.preparedPrefixes, id)
+}
+
+// PreparedPrefixOperations returns the IDs of intents this node has
+// prepared but not yet committed or aborted
+func (c *Coordinator) PreparedPrefixOperations() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := make([]string, 0, len(c.preparedPrefixes))
+	for id := range c.preparedPrefixes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
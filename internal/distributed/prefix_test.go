@@ -0,0 +1,198 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryPrefixStore is an in-memory key set
+type memoryPrefixStore struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func newMemoryPrefixStore(keys ...string) *memoryPrefixStore {
+	s := &memoryPrefixStore{keys: make(map[string]bool)}
+	for _, key := range keys {
+		s.keys[key] = true
+	}
+	return s
+}
+
+func (s *memoryPrefixStore) ListKeys(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *memoryPrefixStore) DeleteKeys(ctx context.Context, keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.keys, key)
+	}
+	return nil
+}
+
+func (s *memoryPrefixStore) count(prefix string) int {
+	keys, _ := s.ListKeys(context.Background(), prefix)
+	return len(keys)
+}
+
+// prefixCluster is a leader and two peers whose coordinators reach each
+// other in process. failures[node][phase] makes that many deliveries of the
+// phase to the node fail, simulating a node dropping out mid-operation.
+type prefixCluster struct {
+	leader   *ClusterManager
+	nodes    map[string]*ClusterManager
+	stores   map[string]*memoryPrefixStore
+	mu       sync.Mutex
+	failures map[string]map[PrefixPhase]int
+}
+
+func newPrefixCluster(t *testing.T) *prefixCluster {
+	t.Helper()
+
+	pc := &prefixCluster{
+		nodes:    make(map[string]*ClusterManager),
+		stores:   make(map[string]*memoryPrefixStore),
+		failures: make(map[string]map[PrefixPhase]int),
+	}
+	keys := []string{"data/a.txt", "data/sub/b.txt", "data/sub/c.txt", "other/d.txt"}
+	for _, nodeID := range []string{"node-a", "node-b", "node-c"} {
+		cm, err := NewClusterManager(&ClusterConfig{NodeID: nodeID, RetryBackoff: 10 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("NewClusterManager(%s) failed: %v", nodeID, err)
+		}
+		pc.nodes[nodeID] = cm
+		pc.stores[nodeID] = newMemoryPrefixStore(keys...)
+		cm.coordinator.SetPrefixStore(pc.stores[nodeID])
+	}
+
+	pc.leader = pc.nodes["node-a"]
+	makeLeader(pc.leader)
+	pc.leader.coordinator.SetPrefixTransport(func(ctx context.Context, nodeID string, intent *PrefixIntent) error {
+		pc.mu.Lock()
+		if pc.failures[nodeID][intent.Phase] > 0 {
+			pc.failures[nodeID][intent.Phase]--
+			pc.mu.Unlock()
+			return errors.New("connection refused")
+		}
+		pc.mu.Unlock()
+		return pc.nodes[nodeID].coordinator.HandlePrefixIntent(ctx, intent)
+	})
+	return pc
+}
+
+func (pc *prefixCluster) fail(nodeID string, phase PrefixPhase, times int) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.failures[nodeID] == nil {
+		pc.failures[nodeID] = make(map[PrefixPhase]int)
+	}
+	pc.failures[nodeID][phase] = times
+}
+
+func (pc *prefixCluster) deletePrefix(t *testing.T, prefix string) (*PrefixResult, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return pc.leader.coordinator.ExecutePrefixOperation(ctx, &PrefixOperation{
+		Type:        OpTypeDelete,
+		Prefix:      prefix,
+		TargetNodes: []string{"node-a", "node-b", "node-c"},
+	})
+}
+
+func TestPrefixDeleteAppliesOnEveryNode(t *testing.T) {
+	pc := newPrefixCluster(t)
+
+	result, err := pc.deletePrefix(t, "data/")
+	if err != nil {
+		t.Fatalf("ExecutePrefixOperation failed: %v", err)
+	}
+	if !result.Success || len(result.Keys) != 3 || len(result.Nodes) != 3 {
+		t.Errorf("result = %+v, want 3 keys deleted on 3 nodes", result)
+	}
+
+	for nodeID, store := range pc.stores {
+		if n := store.count("data/"); n != 0 {
+			t.Errorf("%s still holds %d keys under data/", nodeID, n)
+		}
+		if n := store.count("other/"); n != 1 {
+			t.Errorf("%s lost keys outside the prefix", nodeID)
+		}
+	}
+
+	// Intent and decision were both recorded through the consensus log
+	if added := pc.leader.consensus.GetStats().LogEntriesAdded; added != 2 {
+		t.Errorf("log entries added = %d, want prepare and commit", added)
+	}
+}
+
+func TestPrefixDeleteRollsBackOnNodeFailure(t *testing.T) {
+	pc := newPrefixCluster(t)
+
+	// node-c drops out after node-a and node-b have prepared
+	pc.fail("node-c", PrefixPhasePrepare, 1)
+
+	result, err := pc.deletePrefix(t, "data/")
+	if err == nil || result.Success {
+		t.Fatalf("delete succeeded despite a failed node: %+v", result)
+	}
+
+	for nodeID, store := range pc.stores {
+		if n := store.count("data/"); n != 3 {
+			t.Errorf("%s holds %d of 3 keys under data/ after rollback", nodeID, n)
+		}
+		if prepared := pc.nodes[nodeID].coordinator.PreparedPrefixOperations(); len(prepared) != 0 {
+			t.Errorf("%s still holds prepared intents %v", nodeID, prepared)
+		}
+	}
+}
+
+func TestPrefixDeleteRetriesCommitUntilApplied(t *testing.T) {
+	pc := newPrefixCluster(t)
+
+	// node-b misses the first commit delivery once the decision is logged
+	pc.fail("node-b", PrefixPhaseCommit, 1)
+
+	if _, err := pc.deletePrefix(t, "data/"); err != nil {
+		t.Fatalf("ExecutePrefixOperation failed: %v", err)
+	}
+	for nodeID, store := range pc.stores {
+		if n := store.count("data/"); n != 0 {
+			t.Errorf("%s still holds %d keys under data/", nodeID, n)
+		}
+	}
+}
+
+func TestPrefixListIsStronglyOrdered(t *testing.T) {
+	pc := newPrefixCluster(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := pc.leader.coordinator.ExecutePrefixOperation(ctx, &PrefixOperation{Type: OpTypeList, Prefix: "data/sub/"})
+	if err != nil {
+		t.Fatalf("ExecutePrefixOperation failed: %v", err)
+	}
+	if len(result.Keys) != 2 || result.Keys[0] != "data/sub/b.txt" {
+		t.Errorf("listed %v, want the two keys under data/sub/", result.Keys)
+	}
+
+	if _, err := pc.leader.coordinator.ExecutePrefixOperation(ctx, &PrefixOperation{Type: OpTypeDelete}); err == nil {
+		t.Error("expected an error deleting an empty prefix")
+	}
+}
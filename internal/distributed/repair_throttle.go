@@ -0,0 +1,228 @@
+package distributed
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repair throttle defaults
+const (
+	defaultRepairKeyInterval    = 30 * time.Second
+	defaultRepairBytesPerSecond = 4 * 1024 * 1024
+	defaultRepairRangeDepth     = 1
+)
+
+// RepairDecision is the outcome of asking to repair a key
+type RepairDecision int
+
+const (
+	// RepairAllowed means the repair may run now
+	RepairAllowed RepairDecision = iota
+	// RepairCoalesced means the key was repaired within the key interval, so
+	// the earlier repair stands in for this one
+	RepairCoalesced
+	// RepairDeferred means the repair bandwidth is exhausted; the key is
+	// kept pending until Pending hands it back
+	RepairDeferred
+)
+
+// String returns the decision's name as used in reports
+func (d RepairDecision) String() string {
+	switch d {
+	case RepairAllowed:
+		return "allowed"
+	case RepairCoalesced:
+		return "coalesced"
+	case RepairDeferred:
+		return "deferred"
+	default:
+		return fmt.Sprintf("RepairDecision(%d)", int(d))
+	}
+}
+
+// RepairThrottleConfig bounds how often and how much repair runs
+type RepairThrottleConfig struct {
+	KeyInterval    time.Duration `yaml:"key_interval"`     // Minimum time between repairs of one key (default 30s)
+	BytesPerSecond int64         `yaml:"bytes_per_second"` // Sustained repair bandwidth (default 4MB/s)
+	BurstBytes     int64         `yaml:"burst_bytes"`      // Repair bytes allowed at once (default one second of bandwidth)
+	RangeDepth     int           `yaml:"range_depth"`      // Leading key path segments grouping keys into ranges for stats (default 1)
+}
+
+// RangeRepairStats reports repair activity in one key range
+type RangeRepairStats struct {
+	Repairs    int64     `json:"repairs"`
+	Coalesced  int64     `json:"coalesced"`
+	Deferred   int64     `json:"deferred"`
+	Bytes      int64     `json:"bytes"`
+	LastRepair time.Time `json:"last_repair"`
+}
+
+// RepairThrottleStats reports repair throttling, overall and per key range.
+// A range repaired again and again holds chronically divergent data.
+type RepairThrottleStats struct {
+	Repairs   int64                        `json:"repairs"`
+	Coalesced int64                        `json:"coalesced"`
+	Deferred  int64                        `json:"deferred"`
+	Pending   int                          `json:"pending"`
+	Ranges    map[string]*RangeRepairStats `json:"ranges"`
+}
+
+// RepairThrottle keeps read repair from turning into a write storm. Each
+// key is repaired at most once per key interval, and total repair bytes
+// are bounded by a token bucket; repairs over budget are deferred, with
+// repeated deferrals of a key coalesced into one pending repair.
+type RepairThrottle struct {
+	mu         sync.Mutex
+	config     RepairThrottleConfig
+	tokens     float64
+	lastRefill time.Time
+	lastPrune  time.Time
+	repaired   map[string]time.Time // Key to when it was last repaired
+	pending    map[string]int64     // Deferred key to the bytes its repair needs
+	stats      RepairThrottleStats
+	now        func() time.Time
+}
+
+// NewRepairThrottle creates a repair throttle, filling in defaults
+func NewRepairThrottle(config RepairThrottleConfig) *RepairThrottle {
+	if config.KeyInterval <= 0 {
+		config.KeyInterval = defaultRepairKeyInterval
+	}
+	if config.BytesPerSecond <= 0 {
+		config.BytesPerSecond = defaultRepairBytesPerSecond
+	}
+	if config.BurstBytes <= 0 {
+		config.BurstBytes = config.BytesPerSecond
+	}
+	if config.RangeDepth <= 0 {
+		config.RangeDepth = defaultRepairRangeDepth
+	}
+
+	t := &RepairThrottle{
+		config:   config,
+		tokens:   float64(config.BurstBytes),
+		repaired: make(map[string]time.Time),
+		pending:  make(map[string]int64),
+		stats:    RepairThrottleStats{Ranges: make(map[string]*RangeRepairStats)},
+		now:      time.Now,
+	}
+	t.lastRefill = t.now()
+	t.lastPrune = t.lastRefill
+	return t
+}
+
+// Admit decides whether a repair writing size bytes to key may run now.
+// An allowed repair is charged against the bandwidth budget immediately.
+func (t *RepairThrottle) Admit(key string, size int64) RepairDecision {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	stats := t.rangeStatsLocked(key)
+
+	if last, ok := t.repaired[key]; ok && now.Sub(last) < t.config.KeyInterval {
+		delete(t.pending, key)
+		t.stats.Coalesced++
+		stats.Coalesced++
+		return RepairCoalesced
+	}
+
+	t.refillLocked(now)
+	// A repair larger than the burst runs once the bucket is full, so it
+	// is delayed rather than starved
+	cost := float64(min(size, t.config.BurstBytes))
+	if t.tokens < cost {
+		if _, ok := t.pending[key]; !ok {
+			t.stats.Deferred++
+			stats.Deferred++
+		}
+		t.pending[key] = size
+		return RepairDeferred
+	}
+
+	t.tokens -= cost
+	delete(t.pending, key)
+	t.repaired[key] = now
+	t.stats.Repairs++
+	stats.Repairs++
+	stats.Bytes += size
+	stats.LastRepair = now
+	if now.Sub(t.lastPrune) >= t.config.KeyInterval {
+		t.pruneLocked(now)
+	}
+	return RepairAllowed
+}
+
+// Pending returns the deferred keys, in key order, for the caller to retry
+// through Admit once bandwidth frees up
+func (t *RepairThrottle) Pending() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]string, 0, len(t.pending))
+	for key := range t.pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Stats returns repair counts overall and per key range
+func (t *RepairThrottle) Stats() RepairThrottleStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats
+	stats.Pending = len(t.pending)
+	stats.Ranges = make(map[string]*RangeRepairStats, len(t.stats.Ranges))
+	for r, rs := range t.stats.Ranges {
+		copied := *rs
+		stats.Ranges[r] = &copied
+	}
+	return stats
+}
+
+// KeyRange returns the range key's repairs are counted under: its first
+// RangeDepth path segments
+func (t *RepairThrottle) KeyRange(key string) string {
+	parts := strings.SplitAfterN(key, "/", t.config.RangeDepth+1)
+	if len(parts) <= t.config.RangeDepth {
+		return strings.Join(parts[:len(parts)-1], "")
+	}
+	return strings.Join(parts[:t.config.RangeDepth], "")
+}
+
+func (t *RepairThrottle) rangeStatsLocked(key string) *RangeRepairStats {
+	r := t.KeyRange(key)
+	stats, ok := t.stats.Ranges[r]
+	if !ok {
+		stats = &RangeRepairStats{}
+		t.stats.Ranges[r] = stats
+	}
+	return stats
+}
+
+// refillLocked adds the tokens earned since the last refill
+func (t *RepairThrottle) refillLocked(now time.Time) {
+	elapsed := now.Sub(t.lastRefill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	t.tokens = min(float64(t.config.BurstBytes), t.tokens+elapsed*float64(t.config.BytesPerSecond))
+	t.lastRefill = now
+}
+
+// pruneLocked forgets repairs older than the key interval. It runs at most
+// once per key interval, so each repaired key is scanned a bounded number
+// of times however many repairs are admitted.
+func (t *RepairThrottle) pruneLocked(now time.Time) {
+	t.lastPrune = now
+	for key, last := range t.repaired {
+		if now.Sub(last) >= t.config.KeyInterval {
+			delete(t.repaired, key)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package distributed
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestRepairThrottle(config RepairThrottleConfig) (*RepairThrottle, *time.Time) {
+	now := time.Unix(1000, 0)
+	throttle := NewRepairThrottle(config)
+	throttle.now = func() time.Time { return now }
+	throttle.lastRefill = now
+	throttle.lastPrune = now
+	return throttle, &now
+}
+
+func TestRepairThrottleRepairsKeyOncePerInterval(t *testing.T) {
+	throttle, now := newTestRepairThrottle(RepairThrottleConfig{KeyInterval: time.Minute})
+
+	repairs := 0
+	for i := 0; i < 20; i++ {
+		if throttle.Admit("hot/divergent.db", 1024) == RepairAllowed {
+			repairs++
+		}
+		*now = now.Add(time.Second)
+	}
+	if repairs != 1 {
+		t.Fatalf("20 reads within the interval triggered %d repairs, want 1", repairs)
+	}
+
+	*now = now.Add(time.Minute)
+	if got := throttle.Admit("hot/divergent.db", 1024); got != RepairAllowed {
+		t.Errorf("repair after the interval = %v, want allowed", got)
+	}
+
+	stats := throttle.Stats()
+	hot := stats.Ranges["hot/"]
+	if hot == nil || hot.Repairs != 2 || hot.Coalesced != 19 {
+		t.Errorf("hot/ range stats = %+v, want 2 repairs and 19 coalesced", hot)
+	}
+}
+
+func TestRepairThrottleDefersOverBudget(t *testing.T) {
+	throttle, now := newTestRepairThrottle(RepairThrottleConfig{BytesPerSecond: 1000})
+
+	if got := throttle.Admit("a/1", 800); got != RepairAllowed {
+		t.Fatalf("first repair = %v, want allowed", got)
+	}
+	for i := 0; i < 3; i++ {
+		if got := throttle.Admit("a/2", 800); got != RepairDeferred {
+			t.Fatalf("repair over budget = %v, want deferred", got)
+		}
+	}
+	if pending := throttle.Pending(); len(pending) != 1 || pending[0] != "a/2" {
+		t.Fatalf("pending = %v, want the deferred key once", pending)
+	}
+
+	*now = now.Add(time.Second)
+	if got := throttle.Admit("a/2", 800); got != RepairAllowed {
+		t.Errorf("repair after refill = %v, want allowed", got)
+	}
+	if stats := throttle.Stats(); stats.Deferred != 1 || stats.Pending != 0 {
+		t.Errorf("deferred = %d, pending = %d, want 1 and 0", stats.Deferred, stats.Pending)
+	}
+}
+
+func TestRepairThrottlePrunesOncePerInterval(t *testing.T) {
+	throttle, now := newTestRepairThrottle(RepairThrottleConfig{KeyInterval: time.Minute})
+
+	for i := 0; i < 10; i++ {
+		throttle.Admit(fmt.Sprintf("a/%d", i), 1)
+		*now = now.Add(10 * time.Second)
+	}
+	// Pruning ran once, a minute in, dropping only the first repair; the
+	// repairs that aged past the interval since then are still remembered
+	if remembered := len(throttle.repaired); remembered != 9 {
+		t.Fatalf("remembered %d repaired keys, want 9 after one prune", remembered)
+	}
+	throttle.Admit("a/10", 1)
+	if remembered := len(throttle.repaired); remembered != 10 {
+		t.Fatalf("remembered %d repaired keys, want 10 before the next prune is due", remembered)
+	}
+
+	*now = now.Add(20 * time.Second)
+	throttle.Admit("a/11", 1)
+	if remembered := len(throttle.repaired); remembered != 5 {
+		t.Errorf("remembered %d repaired keys, want the 5 from the last minute", remembered)
+	}
+}
@@ -0,0 +1,100 @@
+package distributed
+
+import (
+	"strconv"
+	"time"
+)
+
+// Node metadata keys carrying the gossiped consensus replica state
+const (
+	MetadataAppliedIndex = "consensus.applied"
+	MetadataAppliedAt    = "consensus.applied_at"
+)
+
+// ReplicaState is the applied log position a node reported, and when
+type ReplicaState struct {
+	AppliedIndex uint64    `json:"applied_index"`
+	ReportedAt   time.Time `json:"reported_at"`
+}
+
+// applyReplicaState records the applied index reported at now in info's
+// gossiped metadata
+func applyReplicaState(info *NodeInfo, applied uint64, now time.Time) {
+	if info.Metadata == nil {
+		info.Metadata = make(map[string]string)
+	}
+	info.Metadata[MetadataAppliedIndex] = strconv.FormatUint(applied, 10)
+	info.Metadata[MetadataAppliedAt] = strconv.FormatInt(now.UnixNano(), 10)
+}
+
+// NodeReplicaState returns the replica state a node gossiped; ok is false
+// when the node has not reported one
+func NodeReplicaState(info *NodeInfo) (state ReplicaState, ok bool) {
+	applied, err := strconv.ParseUint(info.Metadata[MetadataAppliedIndex], 10, 64)
+	if err != nil {
+		return ReplicaState{}, false
+	}
+	reportedAt, err := strconv.ParseInt(info.Metadata[MetadataAppliedAt], 10, 64)
+	if err != nil {
+		return ReplicaState{}, false
+	}
+	return ReplicaState{AppliedIndex: applied, ReportedAt: time.Unix(0, reportedAt)}, true
+}
+
+// selectReadReplica picks the node serving a PreferFollowers read. Strong
+// reads go to the leader; other reads go to a follower that has applied
+// everything the leader last reported, within the staleness bound. The
+// leader serves the read when no follower qualifies.
+func (c *Coordinator) selectReadReplica(op *DistributedOperation, aliveNodes []string) ([]string, error) {
+	leader := c.cluster.GetLeader()
+	if op.Consistency == ConsistencyStrong {
+		if leader != "" {
+			return []string{leader}, nil
+		}
+		return c.loadBalancer.SelectNodes(aliveNodes, 1)
+	}
+
+	maxStaleness := op.MaxStaleness
+	if maxStaleness <= 0 {
+		maxStaleness = c.config.FollowerReadStaleness
+	}
+
+	if followers := c.freshFollowers(leader, aliveNodes, maxStaleness); len(followers) > 0 {
+		return c.loadBalancer.SelectNodes(followers, 1)
+	}
+	if leader != "" {
+		return []string{leader}, nil
+	}
+	return c.loadBalancer.SelectNodes(aliveNodes, 1)
+}
+
+// freshFollowers returns the alive non-leader nodes whose reported replica
+// state is no older than maxStaleness and has caught up with the leader
+func (c *Coordinator) freshFollowers(leader string, aliveNodes []string, maxStaleness time.Duration) []string {
+	nodes := c.cluster.GetNodes()
+
+	// The leader's applied index is known exactly when this node leads
+	var leaderApplied uint64
+	if leader == c.cluster.GetNodeID() {
+		leaderApplied = c.cluster.AppliedIndex()
+	} else if info, ok := nodes[leader]; ok {
+		if state, ok := NodeReplicaState(info); ok {
+			leaderApplied = state.AppliedIndex
+		}
+	}
+
+	now := time.Now()
+	followers := make([]string, 0, len(aliveNodes))
+	for _, nodeID := range aliveNodes {
+		info, ok := nodes[nodeID]
+		if nodeID == leader || !ok {
+			continue
+		}
+		state, ok := NodeReplicaState(info)
+		if !ok || now.Sub(state.ReportedAt) > maxStaleness || state.AppliedIndex < leaderApplied {
+			continue
+		}
+		followers = append(followers, nodeID)
+	}
+	return followers
+}
@@ -0,0 +1,94 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// addReplica registers an alive node that reported applied at reportedAt
+func addReplica(cm *ClusterManager, id string, applied uint64, reportedAt time.Time) {
+	info := &NodeInfo{ID: id, Status: NodeStatusAlive, LastSeen: time.Now()}
+	applyReplicaState(info, applied, reportedAt)
+	cm.UpdateNodeInfo(id, info)
+}
+
+func TestPreferFollowersKeepsListsOffLeader(t *testing.T) {
+	cm := newTestConsensus(t, "leader")
+	makeLeader(cm)
+	addReplica(cm, "leader", 0, time.Now())
+	addReplica(cm, "follower-1", 0, time.Now())
+	addReplica(cm, "follower-2", 0, time.Now())
+	addReplica(cm, "stale", 0, time.Now().Add(-time.Minute))
+	c := cm.coordinator
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		op := &DistributedOperation{
+			ID:              fmt.Sprintf("list-%d", i),
+			Type:            OpTypeList,
+			Key:             "data/",
+			Consistency:     ConsistencyEventual,
+			PreferFollowers: true,
+		}
+		if _, err := c.ExecuteOperation(ctx, op); err != nil {
+			t.Fatalf("list %d failed: %v", i, err)
+		}
+	}
+
+	strong := &DistributedOperation{
+		ID:              "strong-get",
+		Type:            OpTypeGet,
+		Key:             "data/a",
+		Consistency:     ConsistencyStrong,
+		PreferFollowers: true,
+	}
+	result, err := c.ExecuteOperation(ctx, strong)
+	if err != nil {
+		t.Fatalf("strong get failed: %v", err)
+	}
+	if _, ok := result.NodeResults["leader"]; !ok || len(result.NodeResults) != 1 {
+		t.Errorf("strong get ran on %v, want only the leader", result.NodeResults)
+	}
+
+	stats := c.GetStats()["load_balancer"].(*LoadBalancerStats)
+	if stats.FollowerReads != 10 || stats.LeaderReads != 1 {
+		t.Errorf("follower reads = %d, leader reads = %d, want 10 and 1", stats.FollowerReads, stats.LeaderReads)
+	}
+	if stats.NodeLoad["stale"] != 0 {
+		t.Errorf("stale follower served %d reads, want 0", stats.NodeLoad["stale"])
+	}
+	if stats.NodeLoad["follower-1"] == 0 || stats.NodeLoad["follower-2"] == 0 {
+		t.Errorf("node load = %v, want lists spread across fresh followers", stats.NodeLoad)
+	}
+}
+
+func TestPreferFollowersFallsBackToLeader(t *testing.T) {
+	cm := newTestConsensus(t, "leader")
+	makeLeader(cm)
+	cm.consensus.mu.Lock()
+	cm.consensus.lastApplied = 5
+	cm.consensus.mu.Unlock()
+	addReplica(cm, "leader", 5, time.Now())
+	addReplica(cm, "behind", 4, time.Now())
+	addReplica(cm, "silent", 5, time.Now().Add(-time.Minute))
+
+	op := &DistributedOperation{Type: OpTypeGet, Key: "data/a", Consistency: ConsistencyEventual, PreferFollowers: true}
+	targets, err := cm.coordinator.selectTargetNodes(op)
+	if err != nil {
+		t.Fatalf("selectTargetNodes failed: %v", err)
+	}
+	if len(targets) != 1 || targets[0] != "leader" {
+		t.Errorf("targets = %v, want the leader when no follower has caught up", targets)
+	}
+
+	op.MaxStaleness = 2 * time.Minute
+	targets, err = cm.coordinator.selectTargetNodes(op)
+	if err != nil {
+		t.Fatalf("selectTargetNodes failed: %v", err)
+	}
+	if len(targets) != 1 || targets[0] != "silent" {
+		t.Errorf("targets = %v, want the caught-up follower within a wider staleness bound", targets)
+	}
+}
@@ -0,0 +1,186 @@
+package distributed
+
+import (
+	"context"
+)
+
+// VoteTransport delivers a vote request to a peer's HandleRequestVote
+type VoteTransport func(ctx context.Context, nodeID string, req *RequestVoteMessage) (*RequestVoteResponse, error)
+
+// AppendEntriesTransport delivers log entries and heartbeats to a peer's
+// HandleAppendEntries
+type AppendEntriesTransport func(ctx context.Context, nodeID string, msg *AppendEntriesMessage) (*AppendEntriesResponse, error)
+
+// SetVoteTransport sets how candidates request votes from peers. Until it
+// is set, every alive peer is assumed to grant its vote.
+func (ce *ConsensusEngine) SetVoteTransport(transport VoteTransport) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.voteTransport = transport
+}
+
+// SetAppendEntriesTransport sets how the leader replicates its log. Until it
+// is set, every alive peer is assumed to accept the leader's entries. Once
+// set, operation proposals are accepted when their log entry commits rather
+// than by a separate proposal vote.
+func (ce *ConsensusEngine) SetAppendEntriesTransport(transport AppendEntriesTransport) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.appendTransport = transport
+}
+
+// HandleRequestVote decides a candidate's vote request. The vote is granted
+// at most once per term, and only to a candidate whose log is at least as
+// up to date as this node's.
+func (ce *ConsensusEngine) HandleRequestVote(req *RequestVoteMessage) *RequestVoteResponse {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if req.Term < ce.currentTerm {
+		return &RequestVoteResponse{Term: ce.currentTerm}
+	}
+	if req.Term > ce.currentTerm {
+		ce.stepDownLocked(req.Term)
+	}
+
+	lastTerm := ce.getLastLogTerm()
+	upToDate := req.LastLogTerm > lastTerm ||
+		(req.LastLogTerm == lastTerm && req.LastLogIndex >= ce.getLastLogIndex())
+	if !upToDate || (ce.votedFor != "" && ce.votedFor != req.CandidateID) {
+		return &RequestVoteResponse{Term: ce.currentTerm}
+	}
+
+	ce.votedFor = req.CandidateID
+	ce.resetElectionTimer()
+
+	ce.stats.mu.Lock()
+	ce.stats.VotesCast++
+	ce.stats.mu.Unlock()
+
+	ce.logger.Debug("Granted vote", "candidate", req.CandidateID, "term", req.Term)
+	return &RequestVoteResponse{Term: ce.currentTerm, VoteGranted: true}
+}
+
+// handleRequestVoteResponse counts a peer's reply to a vote request sent in term
+func (ce *ConsensusEngine) handleRequestVoteResponse(nodeID string, term uint64, resp *RequestVoteResponse) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if resp.Term > ce.currentTerm {
+		ce.stepDownLocked(resp.Term)
+		return
+	}
+	// Replies to an earlier election no longer count
+	if ce.currentTerm != term {
+		return
+	}
+	ce.recordVoteLocked(nodeID, resp.VoteGranted)
+}
+
+// HandleAppendEntries applies a leader's heartbeat or log entries. Entries
+// are accepted only if the log holds the entry preceding them; conflicting
+// entries are replaced by the leader's.
+func (ce *ConsensusEngine) HandleAppendEntries(msg *AppendEntriesMessage) *AppendEntriesResponse {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if msg.Term < ce.currentTerm {
+		return &AppendEntriesResponse{Term: ce.currentTerm}
+	}
+	if msg.Term > ce.currentTerm || ce.state != StateFollower {
+		ce.stepDownLocked(msg.Term)
+	}
+	ce.cluster.SetLeader(msg.LeaderID)
+	ce.resetElectionTimer()
+
+	ce.stats.mu.Lock()
+	ce.stats.CurrentLeader = msg.LeaderID
+	ce.stats.mu.Unlock()
+
+	// Entries through the commit index match the leader's, so they are
+	// where the leader can resume
+	if msg.PrevLogIndex > ce.getLastLogIndex() {
+		return &AppendEntriesResponse{Term: ce.currentTerm, MatchIndex: ce.getLastLogIndex()}
+	}
+	if prev := ce.entryLocked(msg.PrevLogIndex); prev != nil && prev.Term != msg.PrevLogTerm {
+		return &AppendEntriesResponse{Term: ce.currentTerm, MatchIndex: ce.commitIndex}
+	}
+
+	for _, entry := range msg.Entries {
+		// Entries covered by a snapshot are committed and already match
+		if entry.Index <= ce.log[0].Index {
+			continue
+		}
+		if existing := ce.entryLocked(entry.Index); existing != nil {
+			if existing.Term == entry.Term {
+				continue
+			}
+			ce.logger.Info("Replacing conflicting log entries", "index", entry.Index, "term", existing.Term, "leader_term", entry.Term)
+			ce.log = ce.log[:entry.Index-ce.log[0].Index]
+		}
+		copied := *entry
+		ce.log = append(ce.log, &copied)
+
+		ce.stats.mu.Lock()
+		ce.stats.LogEntriesAdded++
+		ce.stats.mu.Unlock()
+	}
+
+	matchIndex := msg.PrevLogIndex + uint64(len(msg.Entries))
+	if commit := min(msg.LeaderCommit, matchIndex); commit > ce.commitIndex {
+		ce.commitIndex = commit
+		ce.applyCommittedLocked()
+	}
+
+	return &AppendEntriesResponse{Term: ce.currentTerm, Success: true, MatchIndex: matchIndex}
+}
+
+// handleAppendEntriesReply updates a follower's replication progress from
+// its reply to entries sent in term
+func (ce *ConsensusEngine) handleAppendEntriesReply(nodeID string, term uint64, resp *AppendEntriesResponse) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if resp.Term > ce.currentTerm {
+		ce.stepDownLocked(resp.Term)
+		return
+	}
+	if ce.state != StateLeader || ce.currentTerm != term {
+		return
+	}
+
+	if resp.Success {
+		if resp.MatchIndex > ce.matchIndex[nodeID] {
+			ce.matchIndex[nodeID] = resp.MatchIndex
+		}
+		ce.nextIndex[nodeID] = ce.matchIndex[nodeID] + 1
+		ce.updateCommitIndex()
+		return
+	}
+
+	// Back up to where the follower's log can match and retry
+	next := ce.nextIndex[nodeID]
+	if next > 1 {
+		next--
+	}
+	ce.nextIndex[nodeID] = max(min(next, resp.MatchIndex+1), 1)
+	go ce.sendAppendEntries(nodeID, false)
+}
+
+// stepDownLocked reverts to follower, adopting term if it is newer. Callers
+// hold ce.mu.
+func (ce *ConsensusEngine) stepDownLocked(term uint64) {
+	if term > ce.currentTerm {
+		ce.currentTerm = term
+		ce.votedFor = ""
+	}
+	if ce.state != StateFollower {
+		ce.logger.Info("Stepping down", "term", ce.currentTerm, "state", ce.state)
+		ce.state = StateFollower
+	}
+
+	ce.stats.mu.Lock()
+	ce.stats.CurrentTerm = ce.currentTerm
+	ce.stats.CurrentState = ce.state.String()
+	ce.stats.mu.Unlock()
+}
@@ -0,0 +1,239 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// replicationQueueFileName is the file holding pending replication
+	// tasks in ReplicationQueueDir
+	replicationQueueFileName = "replication-queue.json"
+
+	// defaultReplicationQueueSize bounds the pending replication tasks
+	// when ReplicationQueueSize is unset
+	defaultReplicationQueueSize = 10000
+
+	// replicationTaskAttempts bounds the passes a task is retried in before
+	// it is dropped as failed
+	replicationTaskAttempts = 3
+)
+
+// ErrReplicationQueueFull is returned when a write gives up waiting for
+// space in a full replication queue
+var ErrReplicationQueueFull = errors.New("replication queue full")
+
+// replicationQueueState is the persisted form of the queue
+type replicationQueueState struct {
+	NextID uint64            `json:"next_id"`
+	Tasks  []ReplicationTask `json:"tasks"`
+}
+
+// newCacheReplicator creates a replicator whose queue holds up to
+// ReplicationQueueSize tasks, reloading the tasks pending in
+// ReplicationQueueDir when it is set
+func newCacheReplicator(cluster *ClusterManager, config *ClusterConfig) (*CacheReplicator, error) {
+	r := &CacheReplicator{
+		cluster:  cluster,
+		config:   config,
+		capacity: config.ReplicationQueueSize,
+		space:    make(chan struct{}),
+		stats:    &ReplicationStats{},
+	}
+	if r.capacity <= 0 {
+		r.capacity = defaultReplicationQueueSize
+	}
+	if config.ReplicationQueueDir == "" {
+		return r, nil
+	}
+
+	if err := os.MkdirAll(config.ReplicationQueueDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create replication queue directory: %w", err)
+	}
+	r.path = filepath.Join(config.ReplicationQueueDir, replicationQueueFileName)
+
+	data, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replication queue: %w", err)
+	}
+	var state replicationQueueState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode replication queue: %w", err)
+	}
+	for i := range state.Tasks {
+		r.queue = append(r.queue, &state.Tasks[i])
+	}
+	r.nextID = state.NextID
+
+	// Reloaded tasks count as created, so the counters still add up
+	r.stats.TasksCreated = int64(len(r.queue))
+	r.stats.ActiveTasks = len(r.queue)
+	return r, nil
+}
+
+// enqueue appends task to the queue, assigning its ID. While the queue is
+// full it waits for space, so writes slow to the pace replicas are
+// delivered at, and fails with ErrReplicationQueueFull once ctx is done.
+func (r *CacheReplicator) enqueue(ctx context.Context, task *ReplicationTask) error {
+	waited := false
+	for {
+		r.mu.Lock()
+		if len(r.queue) < r.capacity {
+			r.nextID++
+			task.ID = r.nextID
+			r.queue = append(r.queue, task)
+			r.stats.TasksCreated++
+			r.stats.ActiveTasks = len(r.queue)
+			r.persistLocked()
+			r.mu.Unlock()
+			return nil
+		}
+		if !waited {
+			r.stats.Backpressured++
+			waited = true
+		}
+		space := r.space
+		r.mu.Unlock()
+
+		select {
+		case <-space:
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrReplicationQueueFull, ctx.Err())
+		}
+	}
+}
+
+// pending returns copies of the queued tasks, oldest first
+func (r *CacheReplicator) pending() []ReplicationTask {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := make([]ReplicationTask, len(r.queue))
+	for i, task := range r.queue {
+		tasks[i] = *task
+	}
+	return tasks
+}
+
+// finish records a delivery attempt of task. The task leaves the queue
+// once delivered or out of attempts, waking writes waiting for space.
+func (r *CacheReplicator) finish(task ReplicationTask, delivered bool, took time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexLocked(task.ID)
+	if i < 0 {
+		return
+	}
+	queued := r.queue[i]
+	queued.Attempts++
+
+	switch {
+	case delivered:
+		r.stats.TasksCompleted++
+		r.stats.BytesReplicated += int64(len(queued.Data))
+	case queued.Attempts >= replicationTaskAttempts:
+		r.stats.TasksFailed++
+	default:
+		r.persistLocked()
+		r.recordTimeLocked(took)
+		return
+	}
+
+	r.queue = append(r.queue[:i], r.queue[i+1:]...)
+	r.stats.ActiveTasks = len(r.queue)
+	r.persistLocked()
+	r.recordTimeLocked(took)
+
+	close(r.space)
+	r.space = make(chan struct{})
+}
+
+// indexLocked returns the queue position of the task with id, or -1.
+// Callers hold r.mu.
+func (r *CacheReplicator) indexLocked(id uint64) int {
+	for i, task := range r.queue {
+		if task.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordTimeLocked folds a delivery attempt's duration into the running
+// average. Callers hold r.mu.
+func (r *CacheReplicator) recordTimeLocked(took time.Duration) {
+	if r.stats.AvgReplicationTime == 0 {
+		r.stats.AvgReplicationTime = took
+		return
+	}
+	alpha := 0.1
+	r.stats.AvgReplicationTime = time.Duration(
+		alpha*float64(took) + (1-alpha)*float64(r.stats.AvgReplicationTime),
+	)
+}
+
+// oldestAgeLocked returns how long the oldest pending task has waited.
+// Callers hold r.mu.
+func (r *CacheReplicator) oldestAgeLocked() time.Duration {
+	if len(r.queue) == 0 {
+		return 0
+	}
+	return time.Since(r.queue[0].CreatedAt)
+}
+
+// persistLocked writes the pending tasks to the queue file, when one is
+// configured. The file is written in full before it is renamed into
+// place, so a crash leaves the previous queue intact. Callers hold r.mu.
+func (r *CacheReplicator) persistLocked() {
+	if r.path == "" {
+		return
+	}
+	if err := r.saveLocked(); err != nil {
+		r.stats.PersistErrors++
+		componentLogger(r.config, "replicator").Warn("Failed to persist replication queue", "error", err)
+	}
+}
+
+// saveLocked replaces the queue file with the pending tasks. Callers hold
+// r.mu.
+func (r *CacheReplicator) saveLocked() error {
+	state := replicationQueueState{NextID: r.nextID, Tasks: make([]ReplicationTask, len(r.queue))}
+	for i, task := range r.queue {
+		state.Tasks[i] = *task
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode replication queue: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), replicationQueueFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create replication queue file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write replication queue: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync replication queue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close replication queue file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to replace replication queue: %w", err)
+	}
+	return nil
+}
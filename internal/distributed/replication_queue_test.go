@@ -0,0 +1,163 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingReplicas records the replicas delivered to each node, failing
+// deliveries while down is set
+type recordingReplicas struct {
+	mu        sync.Mutex
+	down      bool
+	delivered map[string][]string
+}
+
+func newRecordingReplicas() *recordingReplicas {
+	return &recordingReplicas{delivered: make(map[string][]string)}
+}
+
+func (r *recordingReplicas) transport(ctx context.Context, nodeID, key string, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.down {
+		return fmt.Errorf("node %s unreachable", nodeID)
+	}
+	r.delivered[nodeID] = append(r.delivered[nodeID], key+"="+string(data))
+	return nil
+}
+
+func (r *recordingReplicas) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+}
+
+func (r *recordingReplicas) deliveredTo(nodeID string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.delivered[nodeID]...)
+}
+
+// putEventual writes key through c, replicating to node-2 in the background
+func putEventual(t *testing.T, c *Coordinator, key, data string, timeout time.Duration) *OperationResult {
+	t.Helper()
+	result, _ := c.ExecuteOperation(context.Background(), &DistributedOperation{
+		ID:          "put-" + key + "-" + data,
+		Type:        OpTypePut,
+		Key:         key,
+		Data:        []byte(data),
+		Consistency: ConsistencyEventual,
+		TargetNodes: []string{"node-1", "node-2"},
+		Timeout:     timeout,
+	})
+	return result
+}
+
+func TestReplicationQueueKeepsEveryWriteInOrder(t *testing.T) {
+	c := newTestConsensus(t, "node-1").coordinator
+	replicas := newRecordingReplicas()
+	c.SetReplicaTransport(replicas.transport)
+	ctx := context.Background()
+
+	for _, data := range []string{"v1", "v2"} {
+		if result := putEventual(t, c, "data/a", data, time.Second); !result.Success {
+			t.Fatalf("put %s failed: %s", data, result.Error)
+		}
+	}
+	if active := c.GetStats()["replication"].(*ReplicationStats).ActiveTasks; active != 2 {
+		t.Fatalf("ActiveTasks = %d, want a task per write", active)
+	}
+
+	// A later write to a key waits while an earlier one is undelivered
+	replicas.setDown(true)
+	c.processReplicationTasks(ctx)
+	replicas.setDown(false)
+	c.processReplicationTasks(ctx)
+
+	if got := replicas.deliveredTo("node-2"); len(got) != 2 || got[0] != "data/a=v1" || got[1] != "data/a=v2" {
+		t.Fatalf("node-2 received %v, want v1 then v2", got)
+	}
+	stats := c.GetStats()["replication"].(*ReplicationStats)
+	if stats.TasksCompleted != 2 || stats.ActiveTasks != 0 || stats.OldestTaskAge != 0 {
+		t.Errorf("replication = %d completed, %d active, oldest %v; want 2 completed and an empty queue",
+			stats.TasksCompleted, stats.ActiveTasks, stats.OldestTaskAge)
+	}
+}
+
+func TestReplicationQueueAppliesBackpressure(t *testing.T) {
+	cm := newTestConsensus(t, "node-1")
+	c, err := NewCoordinator(cm, &ClusterConfig{ReplicationQueueSize: 1, OperationTimeout: time.Second, RetryAttempts: 1, ConsistencyLevel: "eventual"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas := newRecordingReplicas()
+	c.SetReplicaTransport(replicas.transport)
+
+	if result := putEventual(t, c, "data/a", "v1", time.Second); !result.Success {
+		t.Fatalf("first put failed: %s", result.Error)
+	}
+	if result := putEventual(t, c, "data/b", "v1", 20*time.Millisecond); result.Success || !strings.Contains(result.Error, ErrReplicationQueueFull.Error()) {
+		t.Fatalf("put into a full queue = %+v, want it to fail once its deadline passes", result)
+	}
+
+	// A waiting write proceeds once the worker frees space
+	done := make(chan *OperationResult, 1)
+	go func() { done <- putEventual(t, c, "data/c", "v1", 5*time.Second) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for c.GetStats()["replication"].(*ReplicationStats).Backpressured < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("write did not wait for queue space")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.processReplicationTasks(context.Background())
+	if result := <-done; !result.Success {
+		t.Fatalf("put after space was freed failed: %s", result.Error)
+	}
+}
+
+func TestReplicationQueueSurvivesRestart(t *testing.T) {
+	cm := newTestConsensus(t, "node-1")
+	config := &ClusterConfig{ReplicationQueueDir: t.TempDir(), OperationTimeout: time.Second, RetryAttempts: 1, ConsistencyLevel: "eventual"}
+	c, err := NewCoordinator(cm, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas := newRecordingReplicas()
+	replicas.setDown(true)
+	c.SetReplicaTransport(replicas.transport)
+
+	putEventual(t, c, "data/a", "v1", time.Second)
+	putEventual(t, c, "data/a", "v2", time.Second)
+	c.processReplicationTasks(context.Background())
+
+	// The restarted coordinator resumes the pending tasks in order
+	restarted, err := NewCoordinator(cm, config)
+	if err != nil {
+		t.Fatalf("NewCoordinator after restart failed: %v", err)
+	}
+	stats := restarted.GetStats()["replication"].(*ReplicationStats)
+	if stats.ActiveTasks != 2 || stats.OldestTaskAge <= 0 {
+		t.Fatalf("restarted queue has %d tasks, oldest %v; want the 2 pending tasks", stats.ActiveTasks, stats.OldestTaskAge)
+	}
+	replicas.setDown(false)
+	restarted.SetReplicaTransport(replicas.transport)
+	restarted.processReplicationTasks(context.Background())
+	if got := replicas.deliveredTo("node-2"); len(got) != 2 || got[0] != "data/a=v1" || got[1] != "data/a=v2" {
+		t.Fatalf("node-2 received %v after restart, want v1 then v2", got)
+	}
+
+	// Delivered tasks are not replayed by a later restart
+	again, err := NewCoordinator(cm, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active := again.GetStats()["replication"].(*ReplicationStats).ActiveTasks; active != 0 {
+		t.Errorf("queue after delivery and restart has %d tasks, want 0", active)
+	}
+}
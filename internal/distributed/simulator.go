@@ -0,0 +1,518 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrNodeUnreachable is returned by the simulator's transports when a
+// message is sent to or from a killed node, or across a partition
+var ErrNodeUnreachable = errors.New("node unreachable")
+
+// SimulatorConfig configures a ClusterSimulator
+type SimulatorConfig struct {
+	// Nodes is the cluster size (default 3)
+	Nodes int
+	// HeartbeatInterval is how often leaders send heartbeats (default 10ms)
+	HeartbeatInterval time.Duration
+	// Logger receives the nodes' logs (default discards them)
+	Logger *slog.Logger
+}
+
+// ClusterSimulator runs a cluster of in-process nodes whose consensus
+// engines talk through in-memory transports, so failure scenarios can be
+// scripted and checked. Tests can cut links between nodes with Partition,
+// stop and restart nodes with Kill and Restart, and verify the cluster with
+// CheckInvariants. It is meant for tests only.
+type ClusterSimulator struct {
+	mu       sync.RWMutex
+	config   *SimulatorConfig
+	ids      []string
+	nodes    map[string]*ClusterManager
+	machines map[string]*simStateMachine
+	down     map[string]bool
+	group    map[string]int // Partition group by node; nodes in different groups cannot talk
+
+	// Invariant tracking
+	leaders   map[uint64]string // Leader observed in each term
+	splits    []error           // Terms seen with a second leader
+	committed []committedWrite  // Writes Propose reported as committed
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// committedWrite is a write acknowledged to a client
+type committedWrite struct {
+	index     uint64
+	term      uint64
+	requestID string
+}
+
+// NewClusterSimulator creates and starts a simulated cluster. Nodes are
+// named node-1 through node-N, all alive, with no leader until Elect is
+// called.
+func NewClusterSimulator(config *SimulatorConfig) (*ClusterSimulator, error) {
+	if config == nil {
+		config = &SimulatorConfig{}
+	}
+	if config.Nodes == 0 {
+		config.Nodes = 3
+	}
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = 10 * time.Millisecond
+	}
+	if config.Logger == nil {
+		config.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if config.Nodes < 1 {
+		return nil, fmt.Errorf("cluster simulator needs at least one node, got %d", config.Nodes)
+	}
+
+	s := &ClusterSimulator{
+		config:   config,
+		nodes:    make(map[string]*ClusterManager),
+		machines: make(map[string]*simStateMachine),
+		down:     make(map[string]bool),
+		group:    make(map[string]int),
+		leaders:  make(map[uint64]string),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for i := 1; i <= config.Nodes; i++ {
+		id := fmt.Sprintf("node-%d", i)
+		cm, err := NewClusterManager(&ClusterConfig{
+			NodeID:            id,
+			HeartbeatInterval: config.HeartbeatInterval,
+			ElectionTimeout:   10 * config.HeartbeatInterval,
+			OperationTimeout:  time.Second,
+			Logger:            config.Logger,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create simulated node %s: %w", id, err)
+		}
+		s.ids = append(s.ids, id)
+		s.nodes[id] = cm
+	}
+
+	for _, id := range s.ids {
+		cm := s.nodes[id]
+		for _, peer := range s.ids {
+			cm.UpdateNodeInfo(peer, &NodeInfo{ID: peer, Status: NodeStatusAlive, LastSeen: time.Now()})
+		}
+
+		machine := &simStateMachine{applied: make(map[uint64]string)}
+		if err := cm.consensus.SetStateMachine(machine); err != nil {
+			return nil, fmt.Errorf("failed to set state machine on %s: %w", id, err)
+		}
+		s.machines[id] = machine
+		s.wire(id)
+	}
+
+	go s.heartbeatLoop()
+	return s, nil
+}
+
+// wire routes a node's consensus RPCs through the simulated network
+func (s *ClusterSimulator) wire(from string) {
+	ce := s.nodes[from].consensus
+	ce.SetVoteTransport(func(ctx context.Context, to string, req *RequestVoteMessage) (*RequestVoteResponse, error) {
+		if err := s.reachable(from, to); err != nil {
+			return nil, err
+		}
+		return s.nodes[to].consensus.HandleRequestVote(req), nil
+	})
+	ce.SetAppendEntriesTransport(func(ctx context.Context, to string, msg *AppendEntriesMessage) (*AppendEntriesResponse, error) {
+		if err := s.reachable(from, to); err != nil {
+			return nil, err
+		}
+		resp := s.nodes[to].consensus.HandleAppendEntries(msg)
+		// A reply sent back across a partition formed meanwhile is lost
+		if err := s.reachable(to, from); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+	ce.SetSnapshotTransport(func(ctx context.Context, to string, msg *InstallSnapshotMessage) (*InstallSnapshotResponse, error) {
+		if err := s.reachable(from, to); err != nil {
+			return nil, err
+		}
+		return s.nodes[to].consensus.HandleInstallSnapshot(msg)
+	})
+}
+
+// reachable reports whether a message from one node can reach another
+func (s *ClusterSimulator) reachable(from, to string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.nodes[to]; !ok {
+		return fmt.Errorf("unknown node %s: %w", to, ErrNodeUnreachable)
+	}
+	if s.down[from] || s.down[to] {
+		return fmt.Errorf("%s to %s: node down: %w", from, to, ErrNodeUnreachable)
+	}
+	if s.group[from] != s.group[to] {
+		return fmt.Errorf("%s to %s: partitioned: %w", from, to, ErrNodeUnreachable)
+	}
+	return nil
+}
+
+// heartbeatLoop has every live leader send heartbeats, standing in for the
+// engines' own loops
+func (s *ClusterSimulator) heartbeatLoop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			for _, id := range s.ids {
+				if !s.isDown(id) {
+					s.nodes[id].consensus.sendHeartbeats()
+				}
+			}
+			s.recordLeaders()
+		}
+	}
+}
+
+func (s *ClusterSimulator) isDown(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.down[id]
+}
+
+// Close stops the simulator's heartbeats
+func (s *ClusterSimulator) Close() {
+	select {
+	case <-s.stopCh:
+		return
+	default:
+	}
+	close(s.stopCh)
+	<-s.done
+}
+
+// NodeIDs returns the IDs of every simulated node
+func (s *ClusterSimulator) NodeIDs() []string {
+	return append([]string(nil), s.ids...)
+}
+
+// Node returns the cluster manager of a simulated node, or nil
+func (s *ClusterSimulator) Node(id string) *ClusterManager {
+	return s.nodes[id]
+}
+
+// Partition splits the network into the given groups. Nodes in different
+// groups cannot exchange messages; nodes not listed form one more group.
+func (s *ClusterSimulator) Partition(groups ...[]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.ids {
+		s.group[id] = 0
+	}
+	for i, group := range groups {
+		for _, id := range group {
+			s.group[id] = i + 1
+		}
+	}
+}
+
+// Heal removes every partition
+func (s *ClusterSimulator) Heal() {
+	s.Partition()
+}
+
+// Kill stops a node: it neither sends nor receives messages until restarted
+func (s *ClusterSimulator) Kill(id string) error {
+	if _, ok := s.nodes[id]; !ok {
+		return fmt.Errorf("unknown node %s", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down[id] = true
+	return nil
+}
+
+// Restart brings a killed node back as if its process restarted. The term,
+// vote, and log survive, as they would on disk; the node rejoins as a
+// follower and re-applies its log as entries are committed again.
+func (s *ClusterSimulator) Restart(id string) error {
+	cm, ok := s.nodes[id]
+	if !ok {
+		return fmt.Errorf("unknown node %s", id)
+	}
+
+	ce := cm.consensus
+	ce.mu.Lock()
+	ce.state = StateFollower
+	ce.commitIndex = ce.log[0].Index
+	ce.lastApplied = ce.log[0].Index
+	ce.nextIndex = make(map[string]uint64)
+	ce.matchIndex = make(map[string]uint64)
+	ce.voteCount = 0
+	s.machines[id].reset(ce.lastApplied)
+	ce.mu.Unlock()
+	cm.SetLeader("")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.down, id)
+	return nil
+}
+
+// Elect has a node start an election and waits until it wins
+func (s *ClusterSimulator) Elect(ctx context.Context, id string) error {
+	cm, ok := s.nodes[id]
+	if !ok {
+		return fmt.Errorf("unknown node %s", id)
+	}
+	if s.isDown(id) {
+		return fmt.Errorf("cannot elect %s: %w", id, ErrNodeUnreachable)
+	}
+
+	if err := cm.consensus.TriggerElection(ctx); err != nil {
+		return fmt.Errorf("failed to start election on %s: %w", id, err)
+	}
+	if err := s.waitFor(ctx, cm.consensus.IsLeader); err != nil {
+		return fmt.Errorf("%s did not win election in term %d: %w", id, cm.consensus.GetCurrentTerm(), err)
+	}
+	s.recordLeaders()
+	return nil
+}
+
+// Propose submits a write through a node, which must be the leader, and
+// waits until it commits. A committed write is tracked by CheckInvariants.
+func (s *ClusterSimulator) Propose(ctx context.Context, id string, data []byte) error {
+	cm, ok := s.nodes[id]
+	if !ok {
+		return fmt.Errorf("unknown node %s", id)
+	}
+
+	proposal := &ConsensusProposal{ID: newProposalID(), Type: ProposalTypeOperation, Proposer: id, Data: data}
+	result, err := cm.consensus.HandleForwardedProposal(ctx, proposal)
+	if err != nil {
+		return err
+	}
+	if result.Status != ProposalStatusAccepted {
+		return fmt.Errorf("proposal %s %s", result.ID, result.Status)
+	}
+
+	ce := cm.consensus
+	ce.mu.RLock()
+	defer ce.mu.RUnlock()
+	for index := ce.commitIndex; index > ce.log[0].Index; index-- {
+		if entry := ce.entryLocked(index); entry != nil && entry.RequestID == proposal.ID {
+			s.mu.Lock()
+			s.committed = append(s.committed, committedWrite{index: entry.Index, term: entry.Term, requestID: entry.RequestID})
+			s.mu.Unlock()
+			return nil
+		}
+	}
+	return nil
+}
+
+// Leader returns the live leader with the highest term, or "" if none
+func (s *ClusterSimulator) Leader() string {
+	leader, leaderTerm := "", uint64(0)
+	for _, id := range s.ids {
+		ce := s.nodes[id].consensus
+		if s.isDown(id) || !ce.IsLeader() {
+			continue
+		}
+		if term := ce.GetCurrentTerm(); leader == "" || term > leaderTerm {
+			leader, leaderTerm = id, term
+		}
+	}
+	return leader
+}
+
+// WaitForLeader waits until a live leader is known to every live node
+// reachable from it, returning its ID
+func (s *ClusterSimulator) WaitForLeader(ctx context.Context) (string, error) {
+	var leader string
+	err := s.waitFor(ctx, func() bool {
+		leader = s.Leader()
+		if leader == "" {
+			return false
+		}
+		for _, id := range s.ids {
+			if s.reachable(leader, id) == nil && s.nodes[id].GetLeader() != leader {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return "", fmt.Errorf("no leader agreed on: %w", err)
+	}
+	return leader, nil
+}
+
+// WaitForReplication waits until every live node reachable from the leader
+// has applied everything the leader has committed
+func (s *ClusterSimulator) WaitForReplication(ctx context.Context) error {
+	err := s.waitFor(ctx, func() bool {
+		leader := s.Leader()
+		if leader == "" {
+			return false
+		}
+		commit := s.nodes[leader].AppliedIndex()
+		for _, id := range s.ids {
+			if s.reachable(leader, id) == nil && s.nodes[id].AppliedIndex() < commit {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("replication incomplete: %w", err)
+	}
+	return nil
+}
+
+// waitFor polls cond until it holds or ctx ends
+func (s *ClusterSimulator) waitFor(ctx context.Context, cond func() bool) error {
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.config.HeartbeatInterval / 2):
+		}
+	}
+	return nil
+}
+
+// recordLeaders notes the current leader of each term for CheckInvariants
+func (s *ClusterSimulator) recordLeaders() {
+	for _, id := range s.ids {
+		ce := s.nodes[id].consensus
+		ce.mu.RLock()
+		leader, term := ce.state == StateLeader, ce.currentTerm
+		ce.mu.RUnlock()
+		if !leader {
+			continue
+		}
+
+		s.mu.Lock()
+		if other, ok := s.leaders[term]; !ok {
+			s.leaders[term] = id
+		} else if other != id {
+			s.splits = append(s.splits, fmt.Errorf("term %d had two leaders: %s and %s", term, other, id))
+		}
+		s.mu.Unlock()
+	}
+}
+
+// CheckInvariants verifies the cluster's safety properties:
+//   - no term has ever had more than one leader
+//   - the current leader's log holds every write acknowledged as committed
+//   - no two nodes applied different entries at the same index
+func (s *ClusterSimulator) CheckInvariants() error {
+	s.recordLeaders()
+
+	s.mu.RLock()
+	if len(s.splits) > 0 {
+		err := s.splits[0]
+		s.mu.RUnlock()
+		return err
+	}
+	committed := append([]committedWrite(nil), s.committed...)
+	s.mu.RUnlock()
+
+	if leader := s.Leader(); leader != "" {
+		ce := s.nodes[leader].consensus
+		ce.mu.RLock()
+		for _, write := range committed {
+			// Entries compacted into a snapshot were committed
+			if write.index <= ce.log[0].Index {
+				continue
+			}
+			entry := ce.entryLocked(write.index)
+			if entry == nil || entry.Term != write.term || entry.RequestID != write.requestID {
+				ce.mu.RUnlock()
+				return fmt.Errorf("leader %s lost committed write %s at index %d", leader, write.requestID, write.index)
+			}
+		}
+		ce.mu.RUnlock()
+	}
+
+	applied := make(map[uint64]string)
+	appliedBy := make(map[uint64]string)
+	for _, id := range s.ids {
+		for index, requestID := range s.machines[id].snapshot() {
+			if other, ok := applied[index]; ok && other != requestID {
+				return fmt.Errorf("index %d applied as %q on %s but %q on %s", index, other, appliedBy[index], requestID, id)
+			}
+			applied[index], appliedBy[index] = requestID, id
+		}
+	}
+	return nil
+}
+
+// simStateMachine records which entry each simulated node applied at each
+// index
+type simStateMachine struct {
+	mu      sync.Mutex
+	applied map[uint64]string // Request ID of the operation applied at each index
+}
+
+func (m *simStateMachine) Apply(entry *LogEntry) {
+	if entry.Type != EntryTypeOperation {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applied[entry.Index] = entry.RequestID
+}
+
+func (m *simStateMachine) Snapshot() ([]byte, error) {
+	return json.Marshal(m.snapshot())
+}
+
+func (m *simStateMachine) Restore(data []byte) error {
+	applied := make(map[uint64]string)
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return fmt.Errorf("failed to decode simulated state: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applied = applied
+	return nil
+}
+
+// snapshot returns a copy of the applied entries
+func (m *simStateMachine) snapshot() map[uint64]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	applied := make(map[uint64]string, len(m.applied))
+	for index, requestID := range m.applied {
+		applied[index] = requestID
+	}
+	return applied
+}
+
+// reset forgets entries applied after index, as a restarted process would
+func (m *simStateMachine) reset(index uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.applied {
+		if i > index {
+			delete(m.applied, i)
+		}
+	}
+}
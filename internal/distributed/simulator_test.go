@@ -0,0 +1,185 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestSimulator(t *testing.T, nodes int) *ClusterSimulator {
+	t.Helper()
+	sim, err := NewClusterSimulator(&SimulatorConfig{Nodes: nodes})
+	if err != nil {
+		t.Fatalf("NewClusterSimulator failed: %v", err)
+	}
+	t.Cleanup(sim.Close)
+	return sim
+}
+
+func simContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func proposeWrites(t *testing.T, ctx context.Context, sim *ClusterSimulator, leader string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := sim.Propose(ctx, leader, []byte(fmt.Sprintf("%s-write-%d", leader, i))); err != nil {
+			t.Fatalf("Propose via %s failed: %v", leader, err)
+		}
+	}
+}
+
+func checkInvariants(t *testing.T, sim *ClusterSimulator) {
+	t.Helper()
+	if err := sim.CheckInvariants(); err != nil {
+		t.Fatalf("invariant violated: %v", err)
+	}
+}
+
+func TestClusterSimulatorPartitionHeal(t *testing.T) {
+	sim := newTestSimulator(t, 5)
+	ctx := simContext(t)
+
+	if err := sim.Elect(ctx, "node-1"); err != nil {
+		t.Fatal(err)
+	}
+	proposeWrites(t, ctx, sim, "node-1", 2)
+	if err := sim.WaitForReplication(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The old leader ends up in the minority and cannot commit
+	sim.Partition([]string{"node-1", "node-2"}, []string{"node-3", "node-4", "node-5"})
+	short, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if err := sim.Propose(short, "node-1", []byte("lost")); err == nil {
+		t.Fatal("minority leader committed a write")
+	}
+	if err := sim.Elect(short, "node-2"); err == nil {
+		t.Fatal("minority node won an election")
+	}
+
+	// The majority elects a new leader and keeps accepting writes
+	if err := sim.Elect(ctx, "node-3"); err != nil {
+		t.Fatal(err)
+	}
+	proposeWrites(t, ctx, sim, "node-3", 2)
+	checkInvariants(t, sim)
+
+	// After healing, the minority adopts the new leader's log
+	sim.Heal()
+	leader, err := sim.WaitForLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader != "node-3" {
+		t.Fatalf("leader after heal = %s, want node-3", leader)
+	}
+	if err := sim.WaitForReplication(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkInvariants(t, sim)
+
+	if sim.Node("node-1").IsLeader() {
+		t.Error("old leader did not step down after heal")
+	}
+	for _, id := range sim.NodeIDs() {
+		if got := len(sim.machines[id].snapshot()); got != 4 {
+			t.Errorf("%s applied %d writes, want the 4 committed", id, got)
+		}
+	}
+}
+
+func TestClusterSimulatorLeaderFailure(t *testing.T) {
+	sim := newTestSimulator(t, 3)
+	ctx := simContext(t)
+
+	if err := sim.Elect(ctx, "node-1"); err != nil {
+		t.Fatal(err)
+	}
+	proposeWrites(t, ctx, sim, "node-1", 3)
+	if err := sim.WaitForReplication(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sim.Kill("node-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Elect(ctx, "node-2"); err != nil {
+		t.Fatal(err)
+	}
+	proposeWrites(t, ctx, sim, "node-2", 2)
+	checkInvariants(t, sim)
+
+	// The restarted leader rejoins as a follower and catches up
+	if err := sim.Restart("node-1"); err != nil {
+		t.Fatal(err)
+	}
+	leader, err := sim.WaitForLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader != "node-2" {
+		t.Fatalf("leader after restart = %s, want node-2", leader)
+	}
+	if err := sim.WaitForReplication(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkInvariants(t, sim)
+
+	if got, want := sim.Node("node-1").AppliedIndex(), sim.Node("node-2").AppliedIndex(); got != want {
+		t.Errorf("restarted node applied index %d, want %d", got, want)
+	}
+	if got := len(sim.machines["node-1"].snapshot()); got != 5 {
+		t.Errorf("restarted node applied %d writes, want 5", got)
+	}
+}
+
+func TestClusterSimulatorUnreachable(t *testing.T) {
+	sim := newTestSimulator(t, 3)
+	ctx := simContext(t)
+
+	if err := sim.Kill("node-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Elect(ctx, "node-2"); !errors.Is(err, ErrNodeUnreachable) {
+		t.Errorf("Elect on killed node error = %v, want ErrNodeUnreachable", err)
+	}
+	if err := sim.reachable("node-1", "node-2"); !errors.Is(err, ErrNodeUnreachable) {
+		t.Errorf("message to killed node error = %v, want ErrNodeUnreachable", err)
+	}
+
+	// A single surviving peer still forms a majority of three
+	if err := sim.Elect(ctx, "node-1"); err != nil {
+		t.Fatal(err)
+	}
+	proposeWrites(t, ctx, sim, "node-1", 1)
+	checkInvariants(t, sim)
+}
+
+func TestClusterSimulatorDetectsSplitBrain(t *testing.T) {
+	sim := newTestSimulator(t, 3)
+	ctx := simContext(t)
+
+	if err := sim.Elect(ctx, "node-1"); err != nil {
+		t.Fatal(err)
+	}
+	checkInvariants(t, sim)
+
+	// Force a second leader into the same term
+	sim.Partition([]string{"node-2"})
+	ce := sim.Node("node-2").consensus
+	ce.mu.Lock()
+	ce.state = StateLeader
+	ce.currentTerm = sim.Node("node-1").consensus.GetCurrentTerm()
+	ce.mu.Unlock()
+
+	if err := sim.CheckInvariants(); err == nil {
+		t.Error("CheckInvariants missed two leaders in one term")
+	}
+}
@@ -0,0 +1,399 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotFileName is the file holding the latest snapshot in SnapshotDir
+const snapshotFileName = "snapshot.json"
+
+// Snapshot is the state machine's state as of LastIndex. It replaces every
+// log entry up to and including LastIndex.
+type Snapshot struct {
+	LastIndex uint64    `json:"last_index"`
+	LastTerm  uint64    `json:"last_term"`
+	Data      []byte    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StateMachine is the state replicated through the consensus log
+type StateMachine interface {
+	// Apply applies a committed log entry
+	Apply(entry *LogEntry)
+	// Snapshot serializes the state after every applied entry
+	Snapshot() ([]byte, error)
+	// Restore replaces the state with a serialized snapshot
+	Restore(data []byte) error
+}
+
+// InstallSnapshotMessage carries the leader's snapshot to a follower whose
+// next entry has been compacted away
+type InstallSnapshotMessage struct {
+	LeaderID          string `json:"leader_id"`
+	Term              uint64 `json:"term"`
+	LastIncludedIndex uint64 `json:"last_included_index"`
+	LastIncludedTerm  uint64 `json:"last_included_term"`
+	Data              []byte `json:"data"`
+}
+
+// InstallSnapshotResponse represents a snapshot installation response
+type InstallSnapshotResponse struct {
+	Term       uint64 `json:"term"`
+	Success    bool   `json:"success"`
+	MatchIndex uint64 `json:"match_index"`
+}
+
+// SnapshotTransport delivers a snapshot to a follower's HandleInstallSnapshot
+type SnapshotTransport func(ctx context.Context, nodeID string, msg *InstallSnapshotMessage) (*InstallSnapshotResponse, error)
+
+// FileSnapshotStore persists the latest snapshot as a file in a directory
+type FileSnapshotStore struct {
+	dir string
+}
+
+// NewFileSnapshotStore creates a snapshot store in dir
+func NewFileSnapshotStore(dir string) (*FileSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	return &FileSnapshotStore{dir: dir}, nil
+}
+
+// Save replaces the stored snapshot. The file is written in full before it
+// is renamed into place, so a crash leaves the previous snapshot intact.
+func (s *FileSnapshotStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, snapshotFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, snapshotFileName)); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load returns the stored snapshot, or nil when none has been saved
+func (s *FileSnapshotStore) Load() (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, snapshotFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// SetStateMachine sets the state committed entries are applied to. If the
+// engine started from a persisted snapshot, the state machine is restored
+// from it.
+func (ce *ConsensusEngine) SetStateMachine(sm StateMachine) error {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	ce.stateMachine = sm
+	if sm != nil && ce.snapshot != nil {
+		if err := sm.Restore(ce.snapshot.Data); err != nil {
+			return fmt.Errorf("failed to restore snapshot at index %d: %w", ce.snapshot.LastIndex, err)
+		}
+	}
+	return nil
+}
+
+// SetSnapshotTransport sets how the leader sends snapshots to followers
+func (ce *ConsensusEngine) SetSnapshotTransport(transport SnapshotTransport) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.snapshotTransport = transport
+}
+
+// Compact snapshots the state machine and truncates the log through the
+// last applied entry, regardless of the configured thresholds
+func (ce *ConsensusEngine) Compact() error {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return ce.compactLocked()
+}
+
+// loadSnapshot restores the log position from the persisted snapshot.
+// Called while the engine is constructed.
+func (ce *ConsensusEngine) loadSnapshot() error {
+	snapshot, err := ce.snapshots.Load()
+	if err != nil || snapshot == nil {
+		return err
+	}
+
+	ce.snapshot = snapshot
+	ce.log = []*LogEntry{snapshotEntry(snapshot)}
+	ce.commitIndex = snapshot.LastIndex
+	ce.lastApplied = snapshot.LastIndex
+	if snapshot.LastTerm > ce.currentTerm {
+		ce.currentTerm = snapshot.LastTerm
+	}
+	ce.logger.Info("Restored consensus log from snapshot", "index", snapshot.LastIndex, "term", snapshot.LastTerm)
+	return nil
+}
+
+// maybeCompactLocked compacts the log once the entries applied since the
+// last snapshot exceed the configured count or size. Callers hold ce.mu.
+func (ce *ConsensusEngine) maybeCompactLocked() {
+	if ce.config == nil {
+		return
+	}
+
+	applied := ce.lastApplied - ce.log[0].Index
+	overCount := ce.config.SnapshotThreshold > 0 && applied >= uint64(ce.config.SnapshotThreshold)
+	overBytes := ce.config.SnapshotThresholdBytes > 0 && ce.appliedBytesLocked() >= ce.config.SnapshotThresholdBytes
+	if !overCount && !overBytes {
+		return
+	}
+
+	if err := ce.compactLocked(); err != nil {
+		ce.logger.Error("Log compaction failed", "error", err)
+	}
+}
+
+// appliedBytesLocked returns the size of the applied entries still in the
+// log. Callers hold ce.mu.
+func (ce *ConsensusEngine) appliedBytesLocked() int64 {
+	var size int64
+	for _, entry := range ce.log[1:] {
+		if entry.Index > ce.lastApplied {
+			break
+		}
+		size += int64(len(entry.Data))
+	}
+	return size
+}
+
+// compactLocked snapshots the state machine at the last applied entry,
+// persists the snapshot, and truncates the log through it. Callers hold ce.mu.
+func (ce *ConsensusEngine) compactLocked() error {
+	index := ce.lastApplied
+	entry := ce.entryLocked(index)
+	if entry == nil || index <= ce.log[0].Index {
+		return nil
+	}
+
+	var data []byte
+	if ce.stateMachine != nil {
+		var err error
+		if data, err = ce.stateMachine.Snapshot(); err != nil {
+			return fmt.Errorf("failed to snapshot state machine: %w", err)
+		}
+	}
+
+	snapshot := &Snapshot{
+		LastIndex: index,
+		LastTerm:  entry.Term,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	if ce.snapshots != nil {
+		if err := ce.snapshots.Save(snapshot); err != nil {
+			return fmt.Errorf("failed to persist snapshot at index %d: %w", index, err)
+		}
+	}
+
+	ce.snapshot = snapshot
+	ce.truncatePrefixLocked(index, entry.Term)
+
+	ce.stats.mu.Lock()
+	ce.stats.SnapshotsTaken++
+	ce.stats.mu.Unlock()
+
+	ce.logger.Info("Compacted consensus log", "index", index)
+	return nil
+}
+
+// truncatePrefixLocked discards the log entries through index, leaving a
+// snapshot marker at index in their place. Callers hold ce.mu.
+func (ce *ConsensusEngine) truncatePrefixLocked(index, term uint64) {
+	kept := []*LogEntry{{
+		Term:      term,
+		Index:     index,
+		Type:      EntryTypeSnapshot,
+		Timestamp: time.Now(),
+	}}
+	if first := ce.log[0].Index; index >= first && index < ce.getLastLogIndex() {
+		kept = append(kept, ce.log[index-first+1:]...)
+	}
+	ce.log = kept
+}
+
+// entryLocked returns the log entry at index, or nil when it was compacted
+// or has not been written. Callers hold ce.mu.
+func (ce *ConsensusEngine) entryLocked(index uint64) *LogEntry {
+	first := ce.log[0].Index
+	if index < first || index > ce.getLastLogIndex() {
+		return nil
+	}
+	return ce.log[index-first]
+}
+
+// sendInstallSnapshot sends the latest snapshot to a follower that needs
+// entries the log no longer holds
+func (ce *ConsensusEngine) sendInstallSnapshot(nodeID string) {
+	ce.mu.RLock()
+	snapshot := ce.snapshot
+	transport := ce.snapshotTransport
+	term := ce.currentTerm
+	ce.mu.RUnlock()
+
+	if snapshot == nil {
+		return
+	}
+
+	msg := &InstallSnapshotMessage{
+		LeaderID:          ce.cluster.GetNodeID(),
+		Term:              term,
+		LastIncludedIndex: snapshot.LastIndex,
+		LastIncludedTerm:  snapshot.LastTerm,
+		Data:              snapshot.Data,
+	}
+
+	ce.stats.mu.Lock()
+	ce.stats.SnapshotsSent++
+	ce.stats.mu.Unlock()
+
+	ce.logger.Info("Sending snapshot", "peer", nodeID, "index", snapshot.LastIndex)
+
+	if transport == nil {
+		// Simulate the follower installing the snapshot
+		go func() {
+			time.Sleep(25 * time.Millisecond) // Simulate network delay
+			ce.handleInstallSnapshotResponse(nodeID, &InstallSnapshotResponse{
+				Term:       term,
+				Success:    true,
+				MatchIndex: snapshot.LastIndex,
+			})
+		}()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ce.config.OperationTimeout)
+	defer cancel()
+
+	resp, err := transport(ctx, nodeID, msg)
+	if err != nil {
+		ce.logger.Warn("Failed to send snapshot", "peer", nodeID, "error", err)
+		return
+	}
+	ce.handleInstallSnapshotResponse(nodeID, resp)
+}
+
+func (ce *ConsensusEngine) handleInstallSnapshotResponse(nodeID string, resp *InstallSnapshotResponse) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if ce.state != StateLeader {
+		return
+	}
+
+	if resp.Term > ce.currentTerm {
+		ce.currentTerm = resp.Term
+		ce.state = StateFollower
+		ce.votedFor = ""
+		return
+	}
+
+	if resp.Success && resp.MatchIndex > ce.matchIndex[nodeID] {
+		ce.matchIndex[nodeID] = resp.MatchIndex
+		ce.nextIndex[nodeID] = resp.MatchIndex + 1
+		ce.updateCommitIndex()
+	}
+}
+
+// HandleInstallSnapshot installs a snapshot sent by the leader, replacing
+// the state machine and every log entry the snapshot covers
+func (ce *ConsensusEngine) HandleInstallSnapshot(msg *InstallSnapshotMessage) (*InstallSnapshotResponse, error) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if msg.Term < ce.currentTerm {
+		return &InstallSnapshotResponse{Term: ce.currentTerm}, nil
+	}
+	if msg.Term > ce.currentTerm {
+		ce.currentTerm = msg.Term
+		ce.votedFor = ""
+	}
+	ce.state = StateFollower
+
+	// Entries through the snapshot are already applied here
+	if msg.LastIncludedIndex <= ce.commitIndex {
+		return &InstallSnapshotResponse{Term: ce.currentTerm, Success: true, MatchIndex: ce.commitIndex}, nil
+	}
+
+	snapshot := &Snapshot{
+		LastIndex: msg.LastIncludedIndex,
+		LastTerm:  msg.LastIncludedTerm,
+		Data:      msg.Data,
+		CreatedAt: time.Now(),
+	}
+	if ce.stateMachine != nil {
+		if err := ce.stateMachine.Restore(snapshot.Data); err != nil {
+			return nil, fmt.Errorf("failed to restore snapshot at index %d: %w", snapshot.LastIndex, err)
+		}
+	}
+	if ce.snapshots != nil {
+		if err := ce.snapshots.Save(snapshot); err != nil {
+			return nil, fmt.Errorf("failed to persist snapshot at index %d: %w", snapshot.LastIndex, err)
+		}
+	}
+
+	// Keep the entries following the snapshot only if the log agrees with it
+	if entry := ce.entryLocked(snapshot.LastIndex); entry != nil && entry.Term == snapshot.LastTerm {
+		ce.truncatePrefixLocked(snapshot.LastIndex, snapshot.LastTerm)
+	} else {
+		ce.log = []*LogEntry{snapshotEntry(snapshot)}
+	}
+	ce.snapshot = snapshot
+	ce.commitIndex = snapshot.LastIndex
+	ce.lastApplied = snapshot.LastIndex
+
+	ce.stats.mu.Lock()
+	ce.stats.SnapshotsInstalled++
+	ce.stats.mu.Unlock()
+
+	ce.logger.Info("Installed snapshot", "leader", msg.LeaderID, "index", snapshot.LastIndex, "term", snapshot.LastTerm)
+	return &InstallSnapshotResponse{Term: ce.currentTerm, Success: true, MatchIndex: snapshot.LastIndex}, nil
+}
+
+// snapshotEntry returns the log marker standing in for the entries a
+// snapshot covers
+func snapshotEntry(snapshot *Snapshot) *LogEntry {
+	return &LogEntry{
+		Term:      snapshot.LastTerm,
+		Index:     snapshot.LastIndex,
+		Type:      EntryTypeSnapshot,
+		Timestamp: snapshot.CreatedAt,
+	}
+}
@@ -0,0 +1,165 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// opsStateMachine records the data of applied operation entries
+type opsStateMachine struct {
+	Ops []string
+}
+
+func (s *opsStateMachine) Apply(entry *LogEntry) {
+	if entry.Type == EntryTypeOperation {
+		s.Ops = append(s.Ops, string(entry.Data))
+	}
+}
+
+func (s *opsStateMachine) Snapshot() ([]byte, error) {
+	return json.Marshal(s.Ops)
+}
+
+func (s *opsStateMachine) Restore(data []byte) error {
+	s.Ops = nil
+	return json.Unmarshal(data, &s.Ops)
+}
+
+// newSnapshotCluster creates a cluster manager whose consensus engine
+// applies to a fresh opsStateMachine
+func newSnapshotCluster(t *testing.T, config *ClusterConfig) (*ClusterManager, *opsStateMachine) {
+	t.Helper()
+	cm, err := NewClusterManager(config)
+	if err != nil {
+		t.Fatalf("NewClusterManager(%s) failed: %v", config.NodeID, err)
+	}
+	sm := &opsStateMachine{}
+	if err := cm.consensus.SetStateMachine(sm); err != nil {
+		t.Fatalf("SetStateMachine failed: %v", err)
+	}
+	return cm, sm
+}
+
+// commitOperations appends n operation entries and commits them one at a time
+func commitOperations(cm *ClusterManager, n int) {
+	ce := cm.consensus
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	for i := 0; i < n; i++ {
+		index := ce.getLastLogIndex() + 1
+		ce.log = append(ce.log, &LogEntry{
+			Term:  ce.currentTerm,
+			Index: index,
+			Type:  EntryTypeOperation,
+			Data:  []byte(fmt.Sprintf("op-%d", index)),
+		})
+		ce.updateCommitIndex()
+	}
+}
+
+func TestCompactionShrinksLog(t *testing.T) {
+	cm, sm := newSnapshotCluster(t, &ClusterConfig{NodeID: "local", SnapshotThreshold: 10})
+
+	commitOperations(cm, 25)
+
+	stats := cm.consensus.GetStats()
+	if stats.SnapshotsTaken != 2 || stats.SnapshotIndex != 20 {
+		t.Errorf("snapshots taken = %d at index %d, want 2 at index 20", stats.SnapshotsTaken, stats.SnapshotIndex)
+	}
+	if stats.LogLength != 6 {
+		t.Errorf("log length = %d, want the snapshot marker and 5 entries", stats.LogLength)
+	}
+	if stats.CommitIndex != 25 || stats.LastApplied != 25 {
+		t.Errorf("commit/applied = %d/%d, want 25/25", stats.CommitIndex, stats.LastApplied)
+	}
+	if len(sm.Ops) != 25 {
+		t.Errorf("state machine applied %d operations, want 25", len(sm.Ops))
+	}
+}
+
+func TestCompactionByBytes(t *testing.T) {
+	cm, _ := newSnapshotCluster(t, &ClusterConfig{
+		NodeID:                 "local",
+		SnapshotThreshold:      -1,
+		SnapshotThresholdBytes: 20,
+	})
+
+	// Entries "op-1" through "op-5" hold 4 bytes each
+	commitOperations(cm, 4)
+	if taken := cm.consensus.GetStats().SnapshotsTaken; taken != 0 {
+		t.Fatalf("snapshots taken below the byte threshold = %d, want 0", taken)
+	}
+	commitOperations(cm, 1)
+	if stats := cm.consensus.GetStats(); stats.SnapshotsTaken != 1 || stats.SnapshotIndex != 5 {
+		t.Errorf("snapshots taken = %d at index %d, want 1 at index 5", stats.SnapshotsTaken, stats.SnapshotIndex)
+	}
+}
+
+func TestRestartRestoresFromSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	cm, _ := newSnapshotCluster(t, &ClusterConfig{NodeID: "local", SnapshotThreshold: 10, SnapshotDir: dir})
+	commitOperations(cm, 12)
+
+	restarted, sm := newSnapshotCluster(t, &ClusterConfig{NodeID: "local", SnapshotThreshold: 10, SnapshotDir: dir})
+
+	if len(sm.Ops) != 10 || sm.Ops[9] != "op-10" {
+		t.Errorf("restored operations = %v, want op-1 through op-10", sm.Ops)
+	}
+	stats := restarted.consensus.GetStats()
+	if stats.CommitIndex != 10 || stats.LastApplied != 10 || stats.SnapshotIndex != 10 {
+		t.Errorf("commit/applied/snapshot = %d/%d/%d, want 10/10/10",
+			stats.CommitIndex, stats.LastApplied, stats.SnapshotIndex)
+	}
+
+	// New entries continue after the snapshot
+	commitOperations(restarted, 1)
+	if last := sm.Ops[len(sm.Ops)-1]; last != "op-11" {
+		t.Errorf("entry after restart applied as %q, want op-11", last)
+	}
+}
+
+func TestLaggingFollowerReceivesSnapshot(t *testing.T) {
+	leader, _ := newSnapshotCluster(t, &ClusterConfig{NodeID: "leader", SnapshotThreshold: 10})
+	follower, followerSM := newSnapshotCluster(t, &ClusterConfig{NodeID: "follower", SnapshotThreshold: 10})
+	makeLeader(leader)
+	commitOperations(leader, 12)
+
+	var sent []*InstallSnapshotMessage
+	leader.consensus.SetSnapshotTransport(func(ctx context.Context, nodeID string, msg *InstallSnapshotMessage) (*InstallSnapshotResponse, error) {
+		if nodeID != "follower" {
+			t.Errorf("snapshot sent to %s, want follower", nodeID)
+		}
+		sent = append(sent, msg)
+		return follower.consensus.HandleInstallSnapshot(msg)
+	})
+
+	// The follower has none of the leader's entries
+	leader.consensus.mu.Lock()
+	leader.consensus.nextIndex["follower"] = 1
+	leader.consensus.mu.Unlock()
+
+	leader.consensus.sendAppendEntries("follower", false)
+
+	if len(sent) != 1 || sent[0].LastIncludedIndex != 10 {
+		t.Fatalf("snapshots sent = %+v, want one through index 10", sent)
+	}
+	if len(followerSM.Ops) != 10 {
+		t.Errorf("follower restored %d operations, want 10", len(followerSM.Ops))
+	}
+	if stats := follower.consensus.GetStats(); stats.CommitIndex != 10 || stats.SnapshotsInstalled != 1 {
+		t.Errorf("follower commit index = %d with %d snapshots installed, want 10 and 1",
+			stats.CommitIndex, stats.SnapshotsInstalled)
+	}
+
+	leader.consensus.mu.RLock()
+	match, next := leader.consensus.matchIndex["follower"], leader.consensus.nextIndex["follower"]
+	leader.consensus.mu.RUnlock()
+	if match != 10 || next != 11 {
+		t.Errorf("leader match/next for follower = %d/%d, want 10/11", match, next)
+	}
+	if stats := leader.consensus.GetStats(); stats.SnapshotsSent != 1 {
+		t.Errorf("snapshots sent = %d, want 1", stats.SnapshotsSent)
+	}
+}
@@ -0,0 +1,126 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// snapshotWhile calls snapshot repeatedly until mutate returns, failing t
+// with the first invariant violation it reports
+func snapshotWhile(t *testing.T, mutate func(), snapshot func() error) {
+	t.Helper()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var violation error
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if err := snapshot(); err != nil {
+					mu.Lock()
+					if violation == nil {
+						violation = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	mutate()
+	close(done)
+	wg.Wait()
+	if violation != nil {
+		t.Fatal(violation)
+	}
+	if err := snapshot(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConsensusStatsSnapshotIsConsistent(t *testing.T) {
+	cm := newTestConsensus(t, "node-1")
+	ce := cm.consensus
+	t.Cleanup(func() {
+		ce.mu.Lock()
+		if ce.electionTimer != nil {
+			ce.electionTimer.Stop()
+		}
+		ce.mu.Unlock()
+	})
+
+	snapshot := func() error {
+		stats := ce.GetStats()
+		if stats.ElectionsWon > stats.ElectionsStarted {
+			return fmt.Errorf("snapshot has %d elections won of %d started", stats.ElectionsWon, stats.ElectionsStarted)
+		}
+		if stats.CurrentState == StateLeader.String() && stats.CurrentLeader != "node-1" {
+			return fmt.Errorf("snapshot reports a leader state with leader %q", stats.CurrentLeader)
+		}
+		if stats.CurrentState != StateLeader.String() && stats.CurrentLeader == "node-1" {
+			return fmt.Errorf("snapshot reports leader node-1 in state %s", stats.CurrentState)
+		}
+		return nil
+	}
+
+	// Each round starts and wins an election, then steps down, without
+	// releasing ce.mu
+	snapshotWhile(t, func() {
+		for i := 0; i < 200; i++ {
+			ce.mu.Lock()
+			ce.startElection()
+			ce.becomeLeader()
+			ce.stepDownLocked(ce.currentTerm)
+			ce.mu.Unlock()
+		}
+	}, snapshot)
+
+	if stats := ce.GetStats(); stats.ElectionsStarted < 200 || stats.ElectionsWon != stats.ElectionsStarted {
+		t.Errorf("stats = %d started, %d won; want every election won", stats.ElectionsStarted, stats.ElectionsWon)
+	}
+}
+
+func TestCoordinatorStatsSnapshotIsConsistent(t *testing.T) {
+	cm := newTestConsensus(t, "node-1")
+	c := cm.coordinator
+	ctx := context.Background()
+
+	snapshot := func() error {
+		stats := c.GetStats()
+		replication := stats["replication"].(*ReplicationStats)
+		settled := replication.TasksCompleted + replication.TasksFailed + int64(replication.ActiveTasks)
+		if settled != replication.TasksCreated {
+			return fmt.Errorf("replication snapshot has %d completed, %d failed, and %d active of %d created",
+				replication.TasksCompleted, replication.TasksFailed, replication.ActiveTasks, replication.TasksCreated)
+		}
+		return nil
+	}
+
+	// Replicas on unknown nodes fail, so every task retires after its
+	// third attempt
+	snapshotWhile(t, func() {
+		for i := 0; i < 100; i++ {
+			op := &DistributedOperation{Key: fmt.Sprintf("key-%d", i), Data: []byte("v")}
+			c.replicateAsync(ctx, op, []string{"missing"})
+			c.processReplicationTasks(ctx)
+		}
+		for i := 0; i < 3; i++ {
+			c.processReplicationTasks(ctx)
+		}
+	}, snapshot)
+
+	replication := c.GetStats()["replication"].(*ReplicationStats)
+	if replication.TasksCreated != 100 || replication.TasksFailed != 100 || replication.ActiveTasks != 0 {
+		t.Errorf("replication = %d created, %d failed, %d active; want 100 created and failed",
+			replication.TasksCreated, replication.TasksFailed, replication.ActiveTasks)
+	}
+}
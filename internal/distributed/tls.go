@@ -0,0 +1,93 @@
+package distributed
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/objectfs/objectfs/internal/config"
+)
+
+// ClusterTLS secures inter-node TCP traffic. With CertFile and KeyFile set,
+// peer listeners serve TLS with that certificate and dialers present it to
+// the peers they connect to. Peer certificates must be signed by a
+// certificate in CAFile, or by the system roots when it is unset. With
+// RequireClientCert, listeners reject nodes that do not present a
+// certificate signed by CAFile. Its fields are those of the cluster.tls
+// section of the application configuration.
+type ClusterTLS config.ClusterTLSConfig
+
+// Enabled reports whether inter-node traffic uses TLS
+func (t ClusterTLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Validate checks that the settings describe a usable TLS setup, as the
+// configuration does when it is loaded
+func (t ClusterTLS) Validate() error {
+	return config.ClusterTLSConfig(t).Validate()
+}
+
+// load reads the node certificate and the CA pool, which is nil when the
+// system roots apply
+func (t ClusterTLS) load() (tls.Certificate, *x509.CertPool, error) {
+	if err := t.Validate(); err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to load cluster certificate: %w", err)
+	}
+	if t.CAFile == "" {
+		return cert, nil, nil
+	}
+	pem, err := os.ReadFile(t.CAFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to read cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return tls.Certificate{}, nil, fmt.Errorf("cluster CA file %s holds no certificates", t.CAFile)
+	}
+	return cert, pool, nil
+}
+
+// ServerConfig returns the TLS configuration of inter-node listeners, or
+// nil when TLS is off
+func (t ClusterTLS) ServerConfig() (*tls.Config, error) {
+	if !t.Enabled() {
+		return nil, nil
+	}
+	cert, pool, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	clientAuth := tls.VerifyClientCertIfGiven
+	if t.RequireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   clientAuth,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientConfig returns the TLS configuration of inter-node dialers, or nil
+// when TLS is off
+func (t ClusterTLS) ClientConfig() (*tls.Config, error) {
+	if !t.Enabled() {
+		return nil, nil
+	}
+	cert, pool, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
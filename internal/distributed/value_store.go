@@ -0,0 +1,482 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+var (
+	// ErrValueNotFound is returned for a key a ValueStore holds no value for
+	ErrValueNotFound = errors.New("value not found")
+
+	// ErrRevisionMismatch is returned by CompareAndSwap when the key was
+	// written since the expected revision was read
+	ErrRevisionMismatch = errors.New("value revision has changed")
+)
+
+// ValueStore holds small typed values, such as lock records and session
+// maps, by key. Every write gives its key a new revision, which
+// CompareAndSwap checks so concurrent read-modify-write cycles cannot
+// overwrite each other.
+type ValueStore interface {
+	// Get decodes the value of key into v and returns its revision, or
+	// ErrValueNotFound when key is unset
+	Get(ctx context.Context, key string, v interface{}) (uint64, error)
+	// Put sets key to v and returns its new revision
+	Put(ctx context.Context, key string, v interface{}) (uint64, error)
+	// Delete removes key; deleting an unset key is not an error
+	Delete(ctx context.Context, key string) error
+	// CompareAndSwap sets key to v only if it is still at revision, with 0
+	// meaning unset, and returns its new revision. It fails with
+	// ErrRevisionMismatch when another write got there first.
+	CompareAndSwap(ctx context.Context, key string, revision uint64, v interface{}) (uint64, error)
+}
+
+// ValueCodec serializes the values a ValueStore holds
+type ValueCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes values as JSON
+type JSONCodec struct{}
+
+// Marshal encodes v as JSON
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON data into v
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec encodes values with encoding/gob, which is more compact than
+// JSON but can only be read back by Go
+type GobCodec struct{}
+
+// Marshal encodes v with gob
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes gob data into v
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// valueOp is the kind of a value store command
+type valueOp string
+
+const (
+	valueOpPut    valueOp = "put"
+	valueOpCAS    valueOp = "cas"
+	valueOpDelete valueOp = "delete"
+	valueOpRead   valueOp = "read" // Orders a read after every earlier write
+)
+
+// valueCommand is a value store operation recorded in the consensus log
+type valueCommand struct {
+	Op       valueOp `json:"value_op"`
+	Key      string  `json:"key,omitempty"`
+	Value    []byte  `json:"value,omitempty"`
+	Revision uint64  `json:"revision,omitempty"` // Revision a CAS expects
+}
+
+// storedValue is an encoded value and the log index that wrote it
+type storedValue struct {
+	Data     []byte `json:"data"`
+	Revision uint64 `json:"revision"`
+}
+
+// valueResult is the outcome of applying a command on the node that
+// proposed it
+type valueResult struct {
+	revision uint64
+	err      error
+}
+
+// ReplicatedValueStore is a strongly consistent ValueStore replicated
+// through the consensus log. Writes and reads are ordered by the log, and a
+// value's revision is the index of the entry that wrote it, so every node
+// agrees on it.
+type ReplicatedValueStore struct {
+	consensus *ConsensusEngine
+	codec     ValueCodec
+
+	mu       sync.RWMutex
+	values   map[string]storedValue
+	waiters  map[string]chan valueResult // By proposal ID
+	watchers map[string][]ValueWatcher
+}
+
+// ValueWatcher is called with the encoded value of a watched key and its
+// revision each time the key changes on this node, with nil data once the
+// key is deleted
+type ValueWatcher func(data []byte, revision uint64)
+
+// valueChange is a write to a watched key waiting to be reported
+type valueChange struct {
+	watchers []ValueWatcher
+	data     []byte
+	revision uint64
+}
+
+// notify reports the change to each watcher
+func (c valueChange) notify() {
+	for _, watcher := range c.watchers {
+		watcher(c.data, c.revision)
+	}
+}
+
+// NewReplicatedValueStore creates a value store replicated through the
+// cluster's consensus log. It becomes the consensus engine's state machine,
+// so every node creates one. The codec defaults to JSONCodec.
+func NewReplicatedValueStore(cluster *ClusterManager, codec ValueCodec) (*ReplicatedValueStore, error) {
+	if cluster == nil || cluster.consensus == nil {
+		return nil, fmt.Errorf("consensus engine not initialized")
+	}
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+
+	s := &ReplicatedValueStore{
+		consensus: cluster.consensus,
+		codec:     codec,
+		values:    make(map[string]storedValue),
+		waiters:   make(map[string]chan valueResult),
+		watchers:  make(map[string][]ValueWatcher),
+	}
+	if err := cluster.consensus.SetStateMachine(s); err != nil {
+		return nil, fmt.Errorf("failed to install value store: %w", err)
+	}
+	return s, nil
+}
+
+// Get decodes the value of key into v. The read is ordered through the log,
+// so it sees every write committed before it was called.
+func (s *ReplicatedValueStore) Get(ctx context.Context, key string, v interface{}) (uint64, error) {
+	if _, err := s.propose(ctx, &valueCommand{Op: valueOpRead}); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	value, ok := s.values[key]
+	s.mu.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrValueNotFound, key)
+	}
+	if err := s.codec.Unmarshal(value.Data, v); err != nil {
+		return 0, fmt.Errorf("failed to decode value %s: %w", key, err)
+	}
+	return value.Revision, nil
+}
+
+// Put sets key to v
+func (s *ReplicatedValueStore) Put(ctx context.Context, key string, v interface{}) (uint64, error) {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode value %s: %w", key, err)
+	}
+	return s.propose(ctx, &valueCommand{Op: valueOpPut, Key: key, Value: data})
+}
+
+// Delete removes key
+func (s *ReplicatedValueStore) Delete(ctx context.Context, key string) error {
+	_, err := s.propose(ctx, &valueCommand{Op: valueOpDelete, Key: key})
+	return err
+}
+
+// CompareAndSwap sets key to v if it is still at revision. The revision is
+// checked when the entry applies, so of two swaps from the same revision
+// only the one the log orders first succeeds.
+func (s *ReplicatedValueStore) CompareAndSwap(ctx context.Context, key string, revision uint64, v interface{}) (uint64, error) {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode value %s: %w", key, err)
+	}
+	return s.propose(ctx, &valueCommand{Op: valueOpCAS, Key: key, Value: data, Revision: revision})
+}
+
+// propose commits cmd through the consensus log and waits for it to apply
+// on this node, which on a follower can be after the leader decided it
+func (s *ReplicatedValueStore) propose(ctx context.Context, cmd *valueCommand) (uint64, error) {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode value %s of %s: %w", cmd.Op, cmd.Key, err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok && s.consensus.config != nil && s.consensus.config.OperationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.consensus.config.OperationTimeout)
+		defer cancel()
+	}
+
+	id := newProposalID()
+	waiter := make(chan valueResult, 1)
+	s.mu.Lock()
+	s.waiters[id] = waiter
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.waiters, id)
+		s.mu.Unlock()
+	}()
+
+	proposal, err := s.consensus.ForwardProposal(ctx, &ConsensusProposal{
+		ID:   id,
+		Type: ProposalTypeOperation,
+		Data: data,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to replicate value %s of %s: %w", cmd.Op, cmd.Key, err)
+	}
+	if proposal.Status != ProposalStatusAccepted {
+		return 0, fmt.Errorf("value %s of %s was %s", cmd.Op, cmd.Key, proposal.Status)
+	}
+
+	select {
+	case result := <-waiter:
+		return result.revision, result.err
+	case <-ctx.Done():
+		return 0, fmt.Errorf("value %s of %s committed but not yet applied on this node: %w", cmd.Op, cmd.Key, ctx.Err())
+	}
+}
+
+// Apply applies a committed value store command, ignoring log entries
+// written by other users of the log
+func (s *ReplicatedValueStore) Apply(entry *LogEntry) {
+	if entry.Type != EntryTypeOperation {
+		return
+	}
+	var cmd valueCommand
+	if err := json.Unmarshal(entry.Data, &cmd); err != nil || cmd.Op == "" {
+		return
+	}
+
+	s.mu.Lock()
+	result := s.applyLocked(entry.Index, &cmd)
+	waiter, ok := s.waiters[entry.RequestID]
+	delete(s.waiters, entry.RequestID)
+	change := s.changeLocked(&cmd, result)
+	s.mu.Unlock()
+
+	// Watchers run outside the lock so they can read the store, and before
+	// the proposer returns so it sees their effects
+	change.notify()
+	if ok {
+		waiter <- result
+	}
+}
+
+// changeLocked returns the change cmd made for the watchers of its key.
+// Callers hold s.mu.
+func (s *ReplicatedValueStore) changeLocked(cmd *valueCommand, result valueResult) valueChange {
+	watchers := s.watchers[cmd.Key]
+	if len(watchers) == 0 || result.err != nil {
+		return valueChange{}
+	}
+	switch cmd.Op {
+	case valueOpPut, valueOpCAS:
+		return valueChange{watchers: watchers, data: cmd.Value, revision: result.revision}
+	case valueOpDelete:
+		return valueChange{watchers: watchers}
+	}
+	return valueChange{}
+}
+
+// Watch calls fn each time key changes on this node, as committed entries
+// apply or a snapshot is restored. When key is already set, fn is called
+// with its current value before Watch returns.
+func (s *ReplicatedValueStore) Watch(key string, fn ValueWatcher) {
+	s.mu.Lock()
+	if s.watchers == nil {
+		s.watchers = make(map[string][]ValueWatcher)
+	}
+	s.watchers[key] = append(s.watchers[key], fn)
+	value, ok := s.values[key]
+	s.mu.Unlock()
+
+	if ok {
+		fn(value.Data, value.Revision)
+	}
+}
+
+// applyLocked applies cmd as the log entry at index. Callers hold s.mu.
+func (s *ReplicatedValueStore) applyLocked(index uint64, cmd *valueCommand) valueResult {
+	switch cmd.Op {
+	case valueOpCAS:
+		if current := s.values[cmd.Key].Revision; current != cmd.Revision {
+			return valueResult{err: fmt.Errorf("%w: %s is at revision %d, not %d", ErrRevisionMismatch, cmd.Key, current, cmd.Revision)}
+		}
+		fallthrough
+	case valueOpPut:
+		s.values[cmd.Key] = storedValue{Data: cmd.Value, Revision: index}
+		return valueResult{revision: index}
+	case valueOpDelete:
+		delete(s.values, cmd.Key)
+	}
+	return valueResult{}
+}
+
+// Snapshot serializes every value
+func (s *ReplicatedValueStore) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return json.Marshal(s.values)
+}
+
+// Restore replaces every value with those in a snapshot
+func (s *ReplicatedValueStore) Restore(data []byte) error {
+	values := make(map[string]storedValue)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("failed to decode value store snapshot: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	var changes []valueChange
+	for key, watchers := range s.watchers {
+		if value, ok := values[key]; ok {
+			changes = append(changes, valueChange{watchers: watchers, data: value.Data, revision: value.Revision})
+		} else if _, ok := s.values[key]; ok {
+			changes = append(changes, valueChange{watchers: watchers})
+		}
+	}
+	s.values = values
+	s.mu.Unlock()
+
+	for _, change := range changes {
+		change.notify()
+	}
+	return nil
+}
+
+// cacheValuePrefix namespaces value store entries in a shared cache
+const cacheValuePrefix = "objectfs-value:"
+
+// cachedValue locates a value held in the cache
+type cachedValue struct {
+	size     int64
+	revision uint64
+}
+
+// CacheValueStore is an eventually consistent ValueStore kept in a cache.
+// Revisions are local to the store, other nodes see writes only as the
+// cache propagates them, and values can be evicted, so it suits state that
+// can be rebuilt, such as session affinity hints.
+type CacheValueStore struct {
+	cache types.Cache
+	codec ValueCodec
+
+	mu       sync.Mutex
+	values   map[string]cachedValue
+	revision uint64
+}
+
+// NewCacheValueStore creates a value store kept in cache. The codec
+// defaults to JSONCodec.
+func NewCacheValueStore(cache types.Cache, codec ValueCodec) *CacheValueStore {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &CacheValueStore{
+		cache:  cache,
+		codec:  codec,
+		values: make(map[string]cachedValue),
+	}
+}
+
+// cacheKey returns the cache key for key. Quoting keeps cache keys from
+// being prefixes of each other, as cache deletes match by prefix.
+func (s *CacheValueStore) cacheKey(key string) string {
+	return cacheValuePrefix + strconv.Quote(key)
+}
+
+// Get decodes the value of key into v. An evicted value is not found.
+func (s *CacheValueStore) Get(ctx context.Context, key string, v interface{}) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[key]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrValueNotFound, key)
+	}
+	data := s.cache.Get(s.cacheKey(key), 0, value.size)
+	if data == nil {
+		delete(s.values, key)
+		return 0, fmt.Errorf("%w: %s", ErrValueNotFound, key)
+	}
+	if err := s.codec.Unmarshal(data, v); err != nil {
+		return 0, fmt.Errorf("failed to decode value %s: %w", key, err)
+	}
+	return value.revision, nil
+}
+
+// Put sets key to v
+func (s *CacheValueStore) Put(ctx context.Context, key string, v interface{}) (uint64, error) {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode value %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.putLocked(key, data), nil
+}
+
+// Delete removes key
+func (s *CacheValueStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cache.Delete(s.cacheKey(key))
+	delete(s.values, key)
+	return nil
+}
+
+// CompareAndSwap sets key to v if it is still at revision. An evicted value
+// counts as unset.
+func (s *CacheValueStore) CompareAndSwap(ctx context.Context, key string, revision uint64, v interface{}) (uint64, error) {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode value %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.values[key]
+	if ok && s.cache.Get(s.cacheKey(key), 0, current.size) == nil {
+		delete(s.values, key)
+		current = cachedValue{}
+	}
+	if current.revision != revision {
+		return 0, fmt.Errorf("%w: %s is at revision %d, not %d", ErrRevisionMismatch, key, current.revision, revision)
+	}
+	return s.putLocked(key, data), nil
+}
+
+// putLocked stores encoded data under key. Callers hold s.mu.
+func (s *CacheValueStore) putLocked(key string, data []byte) uint64 {
+	cacheKey := s.cacheKey(key)
+	s.cache.Delete(cacheKey)
+	s.cache.Put(cacheKey, 0, data)
+
+	s.revision++
+	s.values[key] = cachedValue{size: int64(len(data)), revision: s.revision}
+	return s.revision
+}
@@ -0,0 +1,169 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/objectfs/objectfs/internal/cache"
+)
+
+type lockRecord struct {
+	Owner string
+	Token uint64
+}
+
+// newReplicatedStores elects node-1 of a simulated cluster and returns a
+// value store on every node, with followers forwarding to node-1
+func newReplicatedStores(t *testing.T, ctx context.Context, nodes int) (*ClusterSimulator, map[string]*ReplicatedValueStore) {
+	t.Helper()
+	sim := newTestSimulator(t, nodes)
+	stores := make(map[string]*ReplicatedValueStore)
+	for _, id := range sim.NodeIDs() {
+		store, err := NewReplicatedValueStore(sim.Node(id), GobCodec{})
+		if err != nil {
+			t.Fatalf("NewReplicatedValueStore(%s) failed: %v", id, err)
+		}
+		stores[id] = store
+		sim.Node(id).consensus.SetProposalTransport(func(ctx context.Context, leaderID string, p *ConsensusProposal) (*ConsensusProposal, error) {
+			return sim.Node(leaderID).consensus.HandleForwardedProposal(ctx, p)
+		})
+	}
+	if err := sim.Elect(ctx, "node-1"); err != nil {
+		t.Fatal(err)
+	}
+	return sim, stores
+}
+
+func TestReplicatedValueStoreCompareAndSwap(t *testing.T) {
+	ctx := simContext(t)
+	_, stores := newReplicatedStores(t, ctx, 3)
+
+	rev, err := stores["node-2"].CompareAndSwap(ctx, "locks/a", 0, lockRecord{Owner: "node-2", Token: 1})
+	if err != nil {
+		t.Fatalf("CompareAndSwap creating an unset key failed: %v", err)
+	}
+
+	var got lockRecord
+	readRev, err := stores["node-3"].Get(ctx, "locks/a", &got)
+	if err != nil || readRev != rev || got.Owner != "node-2" {
+		t.Fatalf("Get on another node = %+v at %d, %v; want node-2 at %d", got, readRev, err, rev)
+	}
+
+	// Two nodes race to take the lock from the same revision
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	for _, id := range []string{"node-2", "node-3"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			_, err := stores[id].CompareAndSwap(ctx, "locks/a", rev, lockRecord{Owner: id, Token: 2})
+			mu.Lock()
+			errs[id] = err
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	winner := ""
+	for id, err := range errs {
+		switch {
+		case err == nil:
+			if winner != "" {
+				t.Fatalf("both %s and %s swapped from revision %d", winner, id, rev)
+			}
+			winner = id
+		case !errors.Is(err, ErrRevisionMismatch):
+			t.Fatalf("CompareAndSwap on %s failed: %v", id, err)
+		}
+	}
+	if winner == "" {
+		t.Fatal("neither concurrent CompareAndSwap succeeded")
+	}
+
+	newRev, err := stores["node-1"].Get(ctx, "locks/a", &got)
+	if err != nil || got.Owner != winner || newRev <= rev {
+		t.Fatalf("Get after the race = %+v at %d, %v; want %s after %d", got, newRev, err, winner, rev)
+	}
+
+	// The stale revision keeps failing
+	if _, err := stores["node-1"].CompareAndSwap(ctx, "locks/a", rev, lockRecord{Owner: "node-1"}); !errors.Is(err, ErrRevisionMismatch) {
+		t.Fatalf("CompareAndSwap with a stale revision = %v, want ErrRevisionMismatch", err)
+	}
+
+	if err := stores["node-3"].Delete(ctx, "locks/a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := stores["node-2"].Get(ctx, "locks/a", &got); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrValueNotFound", err)
+	}
+}
+
+func TestReplicatedValueStoreSnapshotRestore(t *testing.T) {
+	ctx := simContext(t)
+	_, stores := newReplicatedStores(t, ctx, 3)
+
+	rev, err := stores["node-1"].Put(ctx, "sessions", map[string]string{"client-1": "node-1"})
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	data, err := stores["node-1"].Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	restored := &ReplicatedValueStore{codec: GobCodec{}}
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	value := restored.values["sessions"]
+	var sessions map[string]string
+	if err := restored.codec.Unmarshal(value.Data, &sessions); err != nil || value.Revision != rev || sessions["client-1"] != "node-1" {
+		t.Fatalf("restored value = %v at %d, %v", sessions, value.Revision, err)
+	}
+}
+
+func TestCacheValueStore(t *testing.T) {
+	ctx := context.Background()
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100})
+	store := NewCacheValueStore(lru, JSONCodec{})
+
+	// Keys that prefix each other are stored independently
+	if _, err := store.Put(ctx, "lock", lockRecord{Owner: "a"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	rev, err := store.Put(ctx, "lock2", lockRecord{Owner: "b"})
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Delete(ctx, "lock"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	var got lockRecord
+	if readRev, err := store.Get(ctx, "lock2", &got); err != nil || readRev != rev || got.Owner != "b" {
+		t.Fatalf("Get = %+v at %d, %v", got, readRev, err)
+	}
+	if _, err := store.Get(ctx, "lock", &got); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("Get of a deleted key = %v, want ErrValueNotFound", err)
+	}
+
+	newRev, err := store.CompareAndSwap(ctx, "lock2", rev, lockRecord{Owner: "c", Token: 1})
+	if err != nil {
+		t.Fatalf("CompareAndSwap failed: %v", err)
+	}
+	if _, err := store.CompareAndSwap(ctx, "lock2", rev, lockRecord{Owner: "d"}); !errors.Is(err, ErrRevisionMismatch) {
+		t.Fatalf("CompareAndSwap with a stale revision = %v, want ErrRevisionMismatch", err)
+	}
+
+	// An evicted value counts as unset
+	lru.Clear()
+	if _, err := store.CompareAndSwap(ctx, "lock2", newRev, lockRecord{Owner: "e"}); !errors.Is(err, ErrRevisionMismatch) {
+		t.Fatalf("CompareAndSwap of an evicted value = %v, want ErrRevisionMismatch", err)
+	}
+	if _, err := store.CompareAndSwap(ctx, "lock2", 0, lockRecord{Owner: "e"}); err != nil {
+		t.Fatalf("CompareAndSwap creating an evicted key failed: %v", err)
+	}
+}
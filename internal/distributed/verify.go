@@ -0,0 +1,293 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Consistency verification defaults
+const (
+	defaultVerifySampleSize    = 100
+	defaultVerifyKeysPerSecond = 20
+)
+
+// Ways a replica can diverge from the authoritative copy of a key
+const (
+	DivergenceETag    = "etag"
+	DivergenceVersion = "version"
+	DivergenceSize    = "size"
+	DivergenceMissing = "missing"
+)
+
+// ReplicaObject is one node's copy of a key. Data is only needed to repair
+// other replicas from this copy.
+type ReplicaObject struct {
+	ETag    string `json:"etag"`
+	Version uint64 `json:"version"`
+	Size    int64  `json:"size"`
+	Data    []byte `json:"-"`
+}
+
+// ReplicaReadTransport reads a node's copy of a key, returning nil when the
+// node holds no copy
+type ReplicaReadTransport func(ctx context.Context, nodeID, key string) (*ReplicaObject, error)
+
+// KeyDivergence describes a sampled key whose replicas disagree
+type KeyDivergence struct {
+	Key          string                    `json:"key"`
+	Mismatches   []string                  `json:"mismatches"` // Kinds of divergence found, such as "etag" or "missing"
+	Authority    string                    `json:"authority"`  // Node whose copy the others are compared with
+	Replicas     map[string]*ReplicaObject `json:"replicas"`   // Copies by node; null where a node holds none
+	Diverged     []string                  `json:"diverged"`   // Nodes whose copy differs from the authority's
+	Repaired     []string                  `json:"repaired,omitempty"`
+	RepairErrors map[string]string         `json:"repair_errors,omitempty"`
+	Throttled    string                    `json:"throttled,omitempty"` // "coalesced" or "deferred" when the repair throttle held the repair back
+}
+
+// ConsistencyReport is the outcome of verifying the replicas of a prefix
+type ConsistencyReport struct {
+	Prefix      string            `json:"prefix"`
+	Nodes       []string          `json:"nodes"`
+	KeysListed  int               `json:"keys_listed"`
+	KeysSampled int               `json:"keys_sampled"`
+	Consistent  int               `json:"consistent"`
+	Divergent   []KeyDivergence   `json:"divergent"`
+	Repaired    int               `json:"repaired"`              // Replicas rewritten from the authoritative copy
+	Throttled   int               `json:"throttled"`             // Divergent keys whose repair the repair throttle held back
+	ReadErrors  map[string]string `json:"read_errors,omitempty"` // Replicas that could not be read, by "node/key"
+	StartedAt   time.Time         `json:"started_at"`
+	Duration    time.Duration     `json:"duration"`
+}
+
+// SetReplicaReadTransport sets how consistency verification reads the
+// copies of a key held by each node
+func (c *Coordinator) SetReplicaReadTransport(transport ReplicaReadTransport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replicaReadTransport = transport
+}
+
+// VerifyConsistency samples up to VerifySampleSize keys spread across
+// prefix and reads each from every alive node, at most VerifyKeysPerSecond
+// keys a second, reporting replicas whose ETag, version, or size differ
+// from the authoritative copy: the newest version, held by the most nodes.
+// A key held by fewer nodes than the replication factor is reported
+// missing. With VerifyRepair, divergent replicas are rewritten from the
+// authoritative copy. Nodes that cannot be read are reported, not repaired.
+func (c *Coordinator) VerifyConsistency(ctx context.Context, prefix string) (ConsistencyReport, error) {
+	report := ConsistencyReport{Prefix: prefix, StartedAt: time.Now()}
+
+	c.mu.RLock()
+	store, transport := c.prefixStore, c.replicaReadTransport
+	c.mu.RUnlock()
+	if store == nil {
+		return report, fmt.Errorf("no prefix store configured")
+	}
+	if transport == nil {
+		return report, fmt.Errorf("no replica read transport configured")
+	}
+
+	for nodeID, node := range c.cluster.GetNodes() {
+		if node.Status == NodeStatusAlive {
+			report.Nodes = append(report.Nodes, nodeID)
+		}
+	}
+	sort.Strings(report.Nodes)
+	if len(report.Nodes) == 0 {
+		return report, fmt.Errorf("no alive nodes available")
+	}
+
+	keys, err := store.ListKeys(ctx, prefix)
+	if err != nil {
+		return report, fmt.Errorf("failed to list keys under %q: %w", prefix, err)
+	}
+	report.KeysListed = len(keys)
+	sample := sampleKeys(keys, c.config.VerifySampleSize)
+
+	interval := time.Duration(float64(time.Second) / c.config.VerifyKeysPerSecond)
+	var next time.Time
+	for _, key := range sample {
+		if wait := time.Until(next); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				report.Duration = time.Since(report.StartedAt)
+				return report, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		next = time.Now().Add(interval)
+
+		report.KeysSampled++
+		divergence := c.verifyKey(ctx, key, report.Nodes, transport, &report)
+		if divergence == nil {
+			report.Consistent++
+			continue
+		}
+		if c.config.VerifyRepair {
+			report.Repaired += c.repairDivergence(ctx, divergence)
+			if divergence.Throttled != "" {
+				report.Throttled++
+			}
+		}
+		report.Divergent = append(report.Divergent, *divergence)
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+	if len(report.Divergent) > 0 {
+		c.logger.Warn("Consistency verification found divergent keys", "prefix", prefix,
+			"sampled", report.KeysSampled, "divergent", len(report.Divergent), "repaired", report.Repaired, "throttled", report.Throttled)
+	}
+	return report, nil
+}
+
+// sampleKeys picks up to size keys evenly spaced across sorted keys
+func sampleKeys(keys []string, size int) []string {
+	sort.Strings(keys)
+	if size <= 0 || len(keys) <= size {
+		return keys
+	}
+	sample := make([]string, size)
+	for i := range sample {
+		sample[i] = keys[i*len(keys)/size]
+	}
+	return sample
+}
+
+// verifyKey reads key from every node and returns how its copies diverge,
+// or nil when the readable copies agree
+func (c *Coordinator) verifyKey(ctx context.Context, key string, nodes []string, transport ReplicaReadTransport, report *ConsistencyReport) *KeyDivergence {
+	replicas := make(map[string]*ReplicaObject, len(nodes))
+	var readable []string
+	for _, nodeID := range nodes {
+		replica, err := transport(ctx, nodeID, key)
+		if err != nil {
+			if report.ReadErrors == nil {
+				report.ReadErrors = make(map[string]string)
+			}
+			report.ReadErrors[nodeID+"/"+key] = err.Error()
+			continue
+		}
+		replicas[nodeID] = replica
+		readable = append(readable, nodeID)
+	}
+
+	authority := authoritativeReplica(replicas, readable)
+	if authority == "" {
+		return nil // No node holds the key any longer
+	}
+	want := replicas[authority]
+
+	divergence := &KeyDivergence{Key: key, Authority: authority, Replicas: replicas}
+	mismatches := make(map[string]bool)
+	var missing []string
+	held := 0
+	for _, nodeID := range readable {
+		replica := replicas[nodeID]
+		if replica == nil {
+			missing = append(missing, nodeID)
+			continue
+		}
+		held++
+		diverged := false
+		if replica.ETag != want.ETag {
+			mismatches[DivergenceETag], diverged = true, true
+		}
+		if replica.Version != want.Version {
+			mismatches[DivergenceVersion], diverged = true, true
+		}
+		if replica.Size != want.Size {
+			mismatches[DivergenceSize], diverged = true, true
+		}
+		if diverged {
+			divergence.Diverged = append(divergence.Diverged, nodeID)
+		}
+	}
+
+	// Keys placed on fewer nodes than are alive only need ReplicationFactor copies
+	wantCopies := c.config.ReplicationFactor
+	if wantCopies > len(nodes) {
+		wantCopies = len(nodes)
+	}
+	if short := wantCopies - held; short > 0 && len(missing) > 0 {
+		if short > len(missing) {
+			short = len(missing)
+		}
+		mismatches[DivergenceMissing] = true
+		divergence.Diverged = append(divergence.Diverged, missing[:short]...)
+	}
+
+	if len(divergence.Diverged) == 0 {
+		return nil
+	}
+	for _, kind := range []string{DivergenceETag, DivergenceVersion, DivergenceSize, DivergenceMissing} {
+		if mismatches[kind] {
+			divergence.Mismatches = append(divergence.Mismatches, kind)
+		}
+	}
+	sort.Strings(divergence.Diverged)
+	return divergence
+}
+
+// replicaIdentity is what replicas of a key must agree on besides size
+type replicaIdentity struct {
+	version uint64
+	etag    string
+}
+
+// authoritativeReplica returns the node holding the newest version of a
+// key, preferring the ETag most nodes agree on, then the lowest node ID.
+// It returns "" when no node holds a copy.
+func authoritativeReplica(replicas map[string]*ReplicaObject, nodes []string) string {
+	votes := make(map[replicaIdentity]int)
+	for _, nodeID := range nodes {
+		if replica := replicas[nodeID]; replica != nil {
+			votes[replicaIdentity{replica.Version, replica.ETag}]++
+		}
+	}
+
+	authority := ""
+	for _, nodeID := range nodes {
+		replica := replicas[nodeID]
+		if replica == nil {
+			continue
+		}
+		if authority == "" {
+			authority = nodeID
+			continue
+		}
+		best := replicas[authority]
+		if replica.Version > best.Version || (replica.Version == best.Version &&
+			votes[replicaIdentity{replica.Version, replica.ETag}] > votes[replicaIdentity{best.Version, best.ETag}]) {
+			authority = nodeID
+		}
+	}
+	return authority
+}
+
+// repairDivergence rewrites each diverged replica from the authoritative
+// copy once the repair throttle admits the key, returning how many were
+// rewritten. A repair the throttle holds back is recorded in Throttled.
+func (c *Coordinator) repairDivergence(ctx context.Context, divergence *KeyDivergence) int {
+	data := divergence.Replicas[divergence.Authority].Data
+	if decision := c.repairThrottle.Admit(divergence.Key, int64(len(data)*len(divergence.Diverged))); decision != RepairAllowed {
+		divergence.Throttled = decision.String()
+		return 0
+	}
+	repaired := 0
+	for _, nodeID := range divergence.Diverged {
+		if err := c.replicateTo(ctx, nodeID, divergence.Key, data); err != nil {
+			if divergence.RepairErrors == nil {
+				divergence.RepairErrors = make(map[string]string)
+			}
+			divergence.RepairErrors[nodeID] = err.Error()
+			continue
+		}
+		divergence.Repaired = append(divergence.Repaired, nodeID)
+		repaired++
+	}
+	return repaired
+}
@@ -0,0 +1,233 @@
+package distributed
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryReplicas holds each node's copies of keys, stamping a write with
+// the version its content was first written at
+type memoryReplicas struct {
+	mu       sync.Mutex
+	copies   map[string]map[string]*ReplicaObject
+	versions map[string]uint64
+}
+
+func newMemoryReplicas(nodes ...string) *memoryReplicas {
+	r := &memoryReplicas{copies: make(map[string]map[string]*ReplicaObject), versions: make(map[string]uint64)}
+	for _, nodeID := range nodes {
+		r.copies[nodeID] = make(map[string]*ReplicaObject)
+	}
+	return r
+}
+
+func (r *memoryReplicas) put(nodeID, key, value string, version uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.versions[value] = version
+	r.copies[nodeID][key] = replicaOf([]byte(value), version)
+}
+
+func replicaOf(data []byte, version uint64) *ReplicaObject {
+	sum := md5.Sum(data)
+	return &ReplicaObject{ETag: hex.EncodeToString(sum[:]), Version: version, Size: int64(len(data)), Data: data}
+}
+
+func (r *memoryReplicas) read(ctx context.Context, nodeID, key string) (*ReplicaObject, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.copies[nodeID][key], nil
+}
+
+func (r *memoryReplicas) write(ctx context.Context, nodeID, key string, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.copies[nodeID][key] = replicaOf(data, r.versions[string(data)])
+	return nil
+}
+
+// newVerifyCluster returns a coordinator on node-1 of three nodes that each
+// hold every key under data/ at version 1
+func newVerifyCluster(t *testing.T, keys int) (*Coordinator, *memoryReplicas) {
+	t.Helper()
+	cm := newTestConsensus(t, "node-1")
+	nodes := []string{"node-1", "node-2", "node-3"}
+	for _, nodeID := range nodes {
+		cm.UpdateNodeInfo(nodeID, &NodeInfo{ID: nodeID, Status: NodeStatusAlive, LastSeen: time.Now()})
+	}
+
+	replicas := newMemoryReplicas(nodes...)
+	store := newMemoryPrefixStore()
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("data/%03d", i)
+		store.keys[key] = true
+		for _, nodeID := range nodes {
+			replicas.put(nodeID, key, "v1-"+key, 1)
+		}
+	}
+
+	c := cm.coordinator
+	c.SetPrefixStore(store)
+	c.SetReplicaReadTransport(replicas.read)
+	c.SetReplicaTransport(replicas.write)
+	return c, replicas
+}
+
+func TestVerifyConsistencyReportsAndRepairsDivergence(t *testing.T) {
+	c, replicas := newVerifyCluster(t, 3)
+	c.config.VerifyKeysPerSecond = 1000
+	replicas.put("node-1", "data/001", "v2-data/001", 2)
+	replicas.put("node-2", "data/001", "v2-data/001", 2)
+	replicas.put("node-3", "data/001", "stale", 1)
+	replicas.mu.Lock()
+	delete(replicas.copies["node-2"], "data/002")
+	replicas.mu.Unlock()
+
+	report, err := c.VerifyConsistency(context.Background(), "data/")
+	if err != nil {
+		t.Fatalf("VerifyConsistency() failed: %v", err)
+	}
+	if report.KeysSampled != 3 || report.Consistent != 1 || len(report.Divergent) != 2 {
+		t.Fatalf("report sampled %d keys, %d consistent and %d divergent, want 3, 1 and 2", report.KeysSampled, report.Consistent, len(report.Divergent))
+	}
+	stale := report.Divergent[0]
+	if stale.Key != "data/001" || stale.Authority != "node-1" || len(stale.Diverged) != 1 || stale.Diverged[0] != "node-3" {
+		t.Errorf("divergence = %+v, want node-3 diverging from node-1 on data/001", stale)
+	}
+	if want := []string{DivergenceETag, DivergenceVersion, DivergenceSize}; fmt.Sprint(stale.Mismatches) != fmt.Sprint(want) {
+		t.Errorf("mismatches = %v, want %v", stale.Mismatches, want)
+	}
+	if missing := report.Divergent[1]; missing.Key != "data/002" || fmt.Sprint(missing.Mismatches) != "[missing]" {
+		t.Errorf("divergence = %+v, want data/002 missing", missing)
+	}
+	if report.Repaired != 0 {
+		t.Errorf("verification without repair rewrote %d replicas", report.Repaired)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("json.Marshal(report) failed: %v", err)
+	}
+	var decoded ConsistencyReport
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Divergent) != 2 || decoded.Divergent[0].Diverged[0] != "node-3" {
+		t.Errorf("report did not round-trip through JSON: %s", data)
+	}
+
+	c.config.VerifyRepair = true
+	report, err = c.VerifyConsistency(context.Background(), "data/")
+	if err != nil {
+		t.Fatalf("VerifyConsistency() with repair failed: %v", err)
+	}
+	if report.Repaired != 2 {
+		t.Errorf("repair rewrote %d replicas, want 2", report.Repaired)
+	}
+
+	report, err = c.VerifyConsistency(context.Background(), "data/")
+	if err != nil {
+		t.Fatalf("VerifyConsistency() after repair failed: %v", err)
+	}
+	if len(report.Divergent) != 0 || report.Consistent != 3 {
+		t.Errorf("after repair %d keys diverge and %d are consistent, want 0 and 3: %+v", len(report.Divergent), report.Consistent, report.Divergent)
+	}
+}
+
+func TestVerifyConsistencyThrottlesRepeatedRepairs(t *testing.T) {
+	c, replicas := newVerifyCluster(t, 1)
+	c.config.VerifyKeysPerSecond = 1000
+	c.config.VerifyRepair = true
+	throttle, now := newTestRepairThrottle(RepairThrottleConfig{KeyInterval: time.Minute})
+	c.repairThrottle = throttle
+
+	// node-3 keeps reverting data/000, and each pass finds it divergent
+	repairs := 0
+	for i := 0; i < 5; i++ {
+		replicas.put("node-3", "data/000", "stale", 1)
+		report, err := c.VerifyConsistency(context.Background(), "data/")
+		if err != nil {
+			t.Fatalf("VerifyConsistency() failed: %v", err)
+		}
+		if len(report.Divergent) != 1 {
+			t.Fatalf("pass %d found %d divergent keys, want 1", i, len(report.Divergent))
+		}
+		if i > 0 && (report.Throttled != 1 || report.Divergent[0].Throttled != "coalesced") {
+			t.Errorf("pass %d reported %d throttled repairs (%q), want data/000 coalesced", i, report.Throttled, report.Divergent[0].Throttled)
+		}
+		repairs += report.Repaired
+		*now = now.Add(time.Second)
+	}
+	if repairs != 1 {
+		t.Fatalf("5 passes within the key interval rewrote %d replicas, want 1", repairs)
+	}
+
+	*now = now.Add(time.Minute)
+	replicas.put("node-3", "data/000", "stale", 1)
+	report, err := c.VerifyConsistency(context.Background(), "data/")
+	if err != nil {
+		t.Fatalf("VerifyConsistency() failed: %v", err)
+	}
+	if report.Repaired != 1 {
+		t.Errorf("pass after the key interval rewrote %d replicas, want 1", report.Repaired)
+	}
+	if stats := c.GetStats()["repair_throttle"].(RepairThrottleStats); stats.Repairs != 2 || stats.Coalesced != 4 {
+		t.Errorf("repair throttle stats = %+v, want 2 repairs and 4 coalesced", stats)
+	}
+}
+
+func TestVerifyConsistencyReportsDeferredRepairs(t *testing.T) {
+	c, replicas := newVerifyCluster(t, 2)
+	c.config.VerifyKeysPerSecond = 1000
+	c.config.VerifyRepair = true
+	// One repair's worth of bandwidth: the first key uses it up
+	c.repairThrottle, _ = newTestRepairThrottle(RepairThrottleConfig{BytesPerSecond: int64(len("v1-data/000"))})
+	replicas.put("node-3", "data/000", "stale", 1)
+	replicas.put("node-3", "data/001", "stale", 1)
+
+	report, err := c.VerifyConsistency(context.Background(), "data/")
+	if err != nil {
+		t.Fatalf("VerifyConsistency() failed: %v", err)
+	}
+	if report.Repaired != 1 || report.Throttled != 1 {
+		t.Fatalf("report repaired %d replicas and throttled %d keys, want 1 and 1", report.Repaired, report.Throttled)
+	}
+	deferred := report.Divergent[1]
+	if deferred.Key != "data/001" || deferred.Throttled != "deferred" || len(deferred.Repaired) != 0 {
+		t.Errorf("divergence = %+v, want the data/001 repair deferred", deferred)
+	}
+	if pending := c.repairThrottle.Pending(); len(pending) != 1 || pending[0] != "data/001" {
+		t.Errorf("pending repairs = %v, want data/001", pending)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("json.Marshal(report) failed: %v", err)
+	}
+	var decoded ConsistencyReport
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Throttled != 1 || decoded.Divergent[1].Throttled != "deferred" {
+		t.Errorf("throttled repair did not round-trip through JSON: %s", data)
+	}
+}
+
+func TestVerifyConsistencyBoundsSampleAndRate(t *testing.T) {
+	c, _ := newVerifyCluster(t, 50)
+	c.config.VerifySampleSize = 5
+	c.config.VerifyKeysPerSecond = 50
+
+	start := time.Now()
+	report, err := c.VerifyConsistency(context.Background(), "data/")
+	if err != nil {
+		t.Fatalf("VerifyConsistency() failed: %v", err)
+	}
+	if report.KeysListed != 50 || report.KeysSampled != 5 {
+		t.Errorf("report listed %d keys and sampled %d, want 50 and 5", report.KeysListed, report.KeysSampled)
+	}
+	// Five keys at 50 a second wait four 20ms intervals
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("verifying 5 keys took %v, want at least 80ms at 50 keys a second", elapsed)
+	}
+}
@@ -0,0 +1,154 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ReplicaTransport delivers a replica of a written key to a peer, returning
+// once the peer has applied it
+type ReplicaTransport func(ctx context.Context, nodeID, key string, data []byte) error
+
+// SetReplicaTransport sets how writes are replicated to peers. Until it is
+// set, every alive peer is assumed to apply the replicas it is sent.
+func (c *Coordinator) SetReplicaTransport(transport ReplicaTransport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replicaTransport = transport
+}
+
+// replicateTo sends one replica of key to nodeID
+func (c *Coordinator) replicateTo(ctx context.Context, nodeID, key string, data []byte) error {
+	c.mu.RLock()
+	transport := c.replicaTransport
+	c.mu.RUnlock()
+
+	if transport == nil {
+		if !c.simulateReplication(nodeID, key, data) {
+			return fmt.Errorf("node %s is not alive", nodeID)
+		}
+		return nil
+	}
+	return transport(ctx, nodeID, key, data)
+}
+
+// checkWriteQuorum fails a put whose WriteQuorum exceeds the nodes it was
+// routed to, before anything is written
+func checkWriteQuorum(op *DistributedOperation, targetNodes []string) *OperationResult {
+	if op.Type != OpTypePut || op.WriteQuorum <= len(targetNodes) {
+		return nil
+	}
+	return &OperationResult{
+		Success: false,
+		Error: fmt.Sprintf("write quorum of %d cannot be met by %d target nodes",
+			op.WriteQuorum, len(targetNodes)),
+	}
+}
+
+// replicateWrite replicates a successful eventual or session operation to
+// the other target nodes. Puts with a WriteQuorum above one wait until that
+// many nodes, counting the one that applied the write, hold it, or fail the
+// result once the operation deadline passes. Replicas still in flight finish
+// in the background, and replicas that fail are retried by the replication
+// worker. Writes that do not wait for a quorum still wait, until the
+// operation deadline, for space in a full replication queue.
+func (c *Coordinator) replicateWrite(ctx context.Context, activeOp *ActiveOperation, result *OperationResult, replicas []string) {
+	op := activeOp.Operation
+	if op.Type != OpTypePut || op.WriteQuorum <= 1 {
+		wait, stop := context.WithDeadline(ctx, activeOp.Deadline)
+		defer stop()
+		if err := c.replicateAsync(wait, op, replicas); err != nil {
+			result.Success = false
+			result.Error = fmt.Sprintf("failed to queue replication: %v", err)
+		}
+		return
+	}
+
+	start := time.Now()
+	acks := make(chan *NodeResult, len(replicas))
+
+	// Replicas outlive the caller's wait, bounded by the operation timeout
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), op.Timeout)
+	var wg sync.WaitGroup
+	for _, nodeID := range replicas {
+		wg.Add(1)
+		go func(nodeID string) {
+			defer wg.Done()
+			sent := time.Now()
+			err := c.replicateTo(sendCtx, nodeID, op.Key, op.Data)
+			ack := &NodeResult{NodeID: nodeID, Success: err == nil, Latency: time.Since(sent)}
+			if err != nil {
+				ack.Error = err.Error()
+			}
+			acks <- ack
+		}(nodeID)
+	}
+
+	wait, stop := context.WithDeadline(ctx, activeOp.Deadline)
+	defer stop()
+
+	needed := op.WriteQuorum - 1
+	acked, pending := 0, len(replicas)
+	var failed []string
+waiting:
+	for acked < needed && pending > 0 {
+		select {
+		case ack := <-acks:
+			pending--
+			result.NodeResults[ack.NodeID] = ack
+			if ack.Success {
+				acked++
+			} else {
+				failed = append(failed, ack.NodeID)
+			}
+		case <-wait.Done():
+			break waiting
+		}
+	}
+	ackLatency := time.Since(start)
+
+	go func() {
+		defer cancel()
+		for ; pending > 0; pending-- {
+			if ack := <-acks; !ack.Success {
+				failed = append(failed, ack.NodeID)
+			}
+		}
+		wg.Wait()
+		if len(failed) > 0 {
+			if err := c.replicateAsync(ctx, op, failed); err != nil {
+				c.logger.Warn("Dropped replicas of a quorum write", "key", op.Key, "nodes", failed, "error", err)
+			}
+		}
+	}()
+
+	met := acked >= needed
+	c.replicator.recordQuorumWrite(met, ackLatency)
+	if !met {
+		result.Success = false
+		result.Error = fmt.Sprintf("write quorum not met (%d/%d nodes acknowledged)", acked+1, op.WriteQuorum)
+		return
+	}
+	result.AckLatency = ackLatency
+}
+
+// recordQuorumWrite counts a write that waited for its quorum and folds
+// how long the acknowledgments took into the running average
+func (r *CacheReplicator) recordQuorumWrite(met bool, ackLatency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !met {
+		r.stats.QuorumFailures++
+		return
+	}
+	r.stats.QuorumWrites++
+	if r.stats.AvgAckLatency == 0 {
+		r.stats.AvgAckLatency = ackLatency
+	} else {
+		alpha := 0.1
+		r.stats.AvgAckLatency = time.Duration(alpha*float64(ackLatency) + (1-alpha)*float64(r.stats.AvgAckLatency))
+	}
+}
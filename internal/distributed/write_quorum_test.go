@@ -0,0 +1,152 @@
+package distributed
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedReplicas is a ReplicaTransport whose replicas apply only once their
+// node's gate is opened
+type gatedReplicas struct {
+	mu      sync.Mutex
+	gates   map[string]chan struct{}
+	applied []string
+}
+
+func newGatedReplicas(nodes ...string) *gatedReplicas {
+	g := &gatedReplicas{gates: make(map[string]chan struct{})}
+	for _, node := range nodes {
+		g.gates[node] = make(chan struct{})
+	}
+	return g
+}
+
+func (g *gatedReplicas) transport(ctx context.Context, nodeID, key string, data []byte) error {
+	select {
+	case <-g.gates[nodeID]:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.applied = append(g.applied, nodeID)
+	return nil
+}
+
+func (g *gatedReplicas) appliedCount() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.applied)
+}
+
+func TestWriteQuorumWaitsForReplicaAcks(t *testing.T) {
+	c := newTestConsensus(t, "node-1").coordinator
+	replicas := newGatedReplicas("node-2", "node-3")
+	c.SetReplicaTransport(replicas.transport)
+
+	op := &DistributedOperation{
+		ID:          "op-a",
+		Type:        OpTypePut,
+		Key:         "data/a",
+		Data:        []byte("v"),
+		Consistency: ConsistencyEventual,
+		TargetNodes: []string{"node-1", "node-2", "node-3"},
+		WriteQuorum: 2,
+	}
+	done := make(chan *OperationResult, 1)
+	go func() {
+		result, err := c.ExecuteOperation(context.Background(), op)
+		if err != nil {
+			t.Errorf("ExecuteOperation failed: %v", err)
+		}
+		done <- result
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("write returned before a second node acknowledged it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(replicas.gates["node-2"])
+	var result *OperationResult
+	select {
+	case result = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("write did not return once its quorum acknowledged")
+	}
+	if !result.Success || result.NodeResults["node-2"] == nil || !result.NodeResults["node-2"].Success {
+		t.Fatalf("result = %+v, want success acknowledged by node-2", result)
+	}
+	if result.AckLatency < 50*time.Millisecond {
+		t.Errorf("AckLatency = %v, want at least the 50ms node-2 was held", result.AckLatency)
+	}
+
+	// The third replica still lands after the write returned
+	close(replicas.gates["node-3"])
+	deadline := time.Now().Add(2 * time.Second)
+	for replicas.appliedCount() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("remaining replica was not applied in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := c.GetStats()["replication"].(*ReplicationStats)
+	if stats.QuorumWrites != 1 || stats.AvgAckLatency != result.AckLatency {
+		t.Errorf("quorum writes = %d, avg ack latency = %v; want 1 and %v", stats.QuorumWrites, stats.AvgAckLatency, result.AckLatency)
+	}
+}
+
+func TestWriteQuorumOfOneReplicatesInBackground(t *testing.T) {
+	c := newTestConsensus(t, "node-1").coordinator
+	replicas := newGatedReplicas("node-2", "node-3")
+	close(replicas.gates["node-2"])
+	close(replicas.gates["node-3"])
+	c.SetReplicaTransport(replicas.transport)
+	ctx := context.Background()
+
+	result, err := c.ExecuteOperation(ctx, &DistributedOperation{
+		ID:          "op-b",
+		Type:        OpTypePut,
+		Key:         "data/b",
+		Data:        []byte("v"),
+		Consistency: ConsistencySession,
+		TargetNodes: []string{"node-1", "node-2", "node-3"},
+		WriteQuorum: 1,
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("ExecuteOperation = %+v, %v", result, err)
+	}
+	if len(result.NodeResults) != 1 || replicas.appliedCount() != 0 {
+		t.Fatalf("write waited for replicas: results %v, applied %d", result.NodeResults, replicas.appliedCount())
+	}
+
+	// The replication worker delivers the queued replicas
+	deadline := time.Now().Add(2 * time.Second)
+	for c.GetStats()["replication"].(*ReplicationStats).TasksCreated == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("write did not queue its replicas")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.processReplicationTasks(ctx)
+	if replicas.appliedCount() != 2 {
+		t.Errorf("replicas applied = %d, want 2", replicas.appliedCount())
+	}
+
+	// A quorum larger than the target nodes fails before writing
+	result, _ = c.ExecuteOperation(ctx, &DistributedOperation{
+		ID:          "op-c",
+		Type:        OpTypePut,
+		Key:         "data/c",
+		Consistency: ConsistencyEventual,
+		TargetNodes: []string{"node-1", "node-2"},
+		WriteQuorum: 3,
+	})
+	if result.Success {
+		t.Error("write with an unreachable quorum succeeded")
+	}
+}
@@ -0,0 +1,110 @@
+package fuse
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Directory attribute prefetch settings
+const (
+	// statBatchSize is how many listed files have their metadata fetched
+	// in one batch while a directory is streamed
+	statBatchSize = 256
+
+	// listedAttrTTL bounds how long prefetched metadata may answer a lookup
+	listedAttrTTL = 5 * time.Second
+)
+
+// listedAttr is metadata fetched for a file while listing its directory
+type listedAttr struct {
+	info    *types.ObjectInfo
+	expires time.Time
+}
+
+// listedAttrs holds metadata prefetched for the files of listed
+// directories, so the lookups that follow a listing (as with READDIRPLUS)
+// do not each issue a HEAD request
+type listedAttrs struct {
+	mu    sync.Mutex
+	attrs map[string]listedAttr
+}
+
+// store records metadata for each key until listedAttrTTL passes
+func (a *listedAttrs) store(infos map[string]*types.ObjectInfo, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.attrs == nil {
+		a.attrs = make(map[string]listedAttr)
+	}
+	for key, info := range a.attrs {
+		if !now.Before(info.expires) {
+			delete(a.attrs, key)
+		}
+	}
+	for key, info := range infos {
+		a.attrs[key] = listedAttr{info: info, expires: now.Add(listedAttrTTL)}
+	}
+}
+
+// take returns and forgets unexpired metadata prefetched for key
+func (a *listedAttrs) take(key string, now time.Time) *types.ObjectInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	attr, ok := a.attrs[key]
+	if !ok {
+		return nil
+	}
+	delete(a.attrs, key)
+	if !now.Before(attr.expires) {
+		return nil
+	}
+	return attr.info
+}
+
+// get returns unexpired metadata prefetched for key, leaving it for the
+// lookup that follows
+func (a *listedAttrs) get(key string, now time.Time) *types.ObjectInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	attr, ok := a.attrs[key]
+	if !ok || !now.Before(attr.expires) {
+		return nil
+	}
+	return attr.info
+}
+
+// listedDirectory reports whether metadata prefetched for key shows it is
+// a directory placeholder
+func (fs *FileSystem) listedDirectory(key string) bool {
+	return fs.config.directoryMarkers().isDirectory(fs.attrs.get(key, time.Now()))
+}
+
+// prefetchAttributes fetches metadata for listed files in one batch on
+// backends that support it, unless listing prefetch is off, and in data
+// mode starts loading the small ones. Keys that fail are left to their own
+// lookup.
+func (fs *FileSystem) prefetchAttributes(ctx context.Context, keys []string) {
+	header, ok := fs.backend.(types.BatchHeader)
+	if !ok || len(keys) == 0 || !fs.config.ListingPrefetch.attributes() {
+		return
+	}
+
+	infos, errs := header.HeadObjects(ctx, keys)
+	if len(errs) > 0 {
+		log.Printf("Metadata prefetch failed for %d of %d listed files", len(errs), len(keys))
+	}
+	fs.attrs.store(infos, time.Now())
+
+	fs.stats.mu.Lock()
+	fs.stats.ListingAttrPrefetches += int64(len(infos))
+	fs.stats.mu.Unlock()
+
+	fs.prefetchListedData(infos)
+}
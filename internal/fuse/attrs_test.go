@@ -0,0 +1,101 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// batchStatBackend lists a directory of files and answers metadata in
+// batches, counting the batches and single HEADs it serves
+type batchStatBackend struct {
+	types.Backend
+	files []types.ObjectInfo
+
+	mu      sync.Mutex
+	batches []int
+	heads   int
+}
+
+func (b *batchStatBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	return b.files, nil
+}
+
+func (b *batchStatBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	b.heads++
+	b.mu.Unlock()
+	return nil, fmt.Errorf("unexpected HEAD for %s", key)
+}
+
+func (b *batchStatBackend) HeadObjects(ctx context.Context, keys []string) (map[string]*types.ObjectInfo, map[string]error) {
+	b.mu.Lock()
+	b.batches = append(b.batches, len(keys))
+	b.mu.Unlock()
+
+	infos := make(map[string]*types.ObjectInfo, len(keys))
+	for _, file := range b.files {
+		for _, key := range keys {
+			if file.Key == key {
+				info := file
+				infos[key] = &info
+			}
+		}
+	}
+	return infos, nil
+}
+
+func TestStreamedListingPrefetchesAttributesInBatches(t *testing.T) {
+	const n = statBatchSize + 10
+	backend := &batchStatBackend{}
+	objects := make(chan types.ObjectInfo, n+1)
+	for i := 0; i < n; i++ {
+		file := types.ObjectInfo{Key: fmt.Sprintf("photos/img%04d.jpg", i), Size: int64(1000 + i)}
+		backend.files = append(backend.files, file)
+		objects <- file
+	}
+	objects <- types.ObjectInfo{Key: "photos/raw/img0000.cr2"}
+	close(objects)
+	errs := make(chan error)
+	close(errs)
+
+	filesystem := NewFileSystem(backend, nil, &recordingBuffer{}, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+
+	stream := newStreamDirStream(filesystem, "photos", "photos/", objects, errs, func() {})
+	listed := 0
+	for stream.HasNext() {
+		if _, errno := stream.Next(); errno != 0 {
+			t.Fatalf("Next() errno = %v", errno)
+		}
+		listed++
+	}
+	if listed != n+1 {
+		t.Errorf("listed %d entries, want %d files and one directory", listed, n+1)
+	}
+
+	if len(backend.batches) != 2 || backend.batches[0] != statBatchSize || backend.batches[1] != 10 {
+		t.Errorf("metadata batches = %v, want [%d 10]", backend.batches, statBatchSize)
+	}
+	for _, file := range backend.files {
+		info := filesystem.attrs.take(file.Key, time.Now())
+		if info == nil || info.Size != file.Size {
+			t.Fatalf("prefetched metadata for %s = %+v, want size %d", file.Key, info, file.Size)
+		}
+	}
+	if backend.heads != 0 {
+		t.Errorf("%d single HEAD requests, want none", backend.heads)
+	}
+
+	// Prefetched metadata answers one lookup and then expires
+	filesystem.attrs.store(map[string]*types.ObjectInfo{"photos/a.jpg": {Size: 1}}, time.Now().Add(-listedAttrTTL))
+	if info := filesystem.attrs.take("photos/a.jpg", time.Now()); info != nil {
+		t.Errorf("expired metadata served: %+v", info)
+	}
+}
@@ -0,0 +1,192 @@
+package fuse
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// caseFolds indexes the paths known in a case-insensitive mount by their
+// lower-cased form, so a lookup of File.TXT finds the key file.txt. A
+// directory is indexed by listing it on the first lookup that misses the
+// index; the listing is repeated after ttl so keys other clients create are
+// found.
+type caseFolds struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	paths  map[string][]string  // Folded path to the paths with that fold, sorted
+	listed map[string]time.Time // Directory to when its listing expires
+}
+
+// newCaseFolds creates an empty index relisting directories after ttl
+func newCaseFolds(ttl time.Duration) *caseFolds {
+	return &caseFolds{
+		ttl:    ttl,
+		paths:  make(map[string][]string),
+		listed: make(map[string]time.Time),
+	}
+}
+
+// foldCase returns the form of path compared by case-insensitive lookups
+func foldCase(path string) string {
+	return strings.ToLower(strings.TrimSuffix(path, "/"))
+}
+
+// add indexes path, a file or a directory
+func (c *caseFolds) add(path string) {
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fold := foldCase(path)
+	variants := c.paths[fold]
+	i := sort.SearchStrings(variants, path)
+	if i < len(variants) && variants[i] == path {
+		return
+	}
+	variants = append(variants, "")
+	copy(variants[i+1:], variants[i:])
+	variants[i] = path
+	c.paths[fold] = variants
+}
+
+// remove drops path from the index, e.g. once it is renamed
+func (c *caseFolds) remove(path string) {
+	path = strings.TrimSuffix(path, "/")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fold := foldCase(path)
+	variants := c.paths[fold]
+	for i, variant := range variants {
+		if variant == path {
+			variants = append(variants[:i], variants[i+1:]...)
+			break
+		}
+	}
+	if len(variants) == 0 {
+		delete(c.paths, fold)
+		return
+	}
+	c.paths[fold] = variants
+}
+
+// variants returns the indexed paths equal to path up to case
+func (c *caseFolds) variants(path string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.paths[foldCase(path)]...)
+}
+
+// resolve returns path itself when it is indexed, otherwise its first
+// indexed variant
+func (c *caseFolds) resolve(path string) (string, bool) {
+	variants := c.variants(path)
+	for _, variant := range variants {
+		if variant == strings.TrimSuffix(path, "/") {
+			return path, true
+		}
+	}
+	if len(variants) == 0 {
+		return "", false
+	}
+	return variants[0], true
+}
+
+// expired reports whether dir must be listed again as of now
+func (c *caseFolds) expired(dir string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires, ok := c.listed[dir]
+	return !ok || !now.Before(expires)
+}
+
+// markListed records dir as listed at now
+func (c *caseFolds) markListed(dir string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listed[dir] = now.Add(c.ttl)
+}
+
+// indexEntry indexes the child of the directory listed with prefix that
+// holds key: key itself, or the subdirectory it is under
+func (c *caseFolds) indexEntry(prefix, key string) {
+	name := strings.TrimPrefix(key, prefix)
+	if slashIdx := strings.Index(name, "/"); slashIdx != -1 {
+		name = name[:slashIdx]
+	}
+	if name != "" {
+		c.add(prefix + name)
+	}
+}
+
+// listingPrefix returns the key prefix listing the children of dir
+func listingPrefix(dir string) string {
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		return dir + "/"
+	}
+	return dir
+}
+
+// resolveCase returns the existing path matching path up to case, listing
+// dir, its parent, when the index holds no match. Paths without a match
+// are returned unchanged, as are all paths in case-sensitive mounts.
+func (fs *FileSystem) resolveCase(ctx context.Context, dir, path string) string {
+	if fs.folds == nil {
+		return path
+	}
+	if resolved, ok := fs.folds.resolve(path); ok {
+		return resolved
+	}
+
+	fs.indexDirectory(ctx, dir)
+	if resolved, ok := fs.folds.resolve(path); ok {
+		return resolved
+	}
+	return path
+}
+
+// caseConflict reports whether a path differing from path only in case
+// exists, other than allowed, so creating path would make two keys a
+// case-insensitive client cannot tell apart
+func (fs *FileSystem) caseConflict(ctx context.Context, dir, path, allowed string) bool {
+	if fs.folds == nil {
+		return false
+	}
+
+	fs.indexDirectory(ctx, dir)
+	path, allowed = strings.TrimSuffix(path, "/"), strings.TrimSuffix(allowed, "/")
+	for _, variant := range fs.folds.variants(path) {
+		if variant != path && variant != allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// indexDirectory lists dir into the case-fold index unless it was listed
+// within the index's ttl. Only the first page of a large directory is
+// indexed.
+func (fs *FileSystem) indexDirectory(ctx context.Context, dir string) {
+	now := time.Now()
+	if !fs.folds.expired(dir, now) {
+		return
+	}
+
+	prefix := listingPrefix(dir)
+	objects, err := fs.backend.ListObjects(ctx, prefix, 1000)
+	if err != nil {
+		return // Lookups fall back to the exact path
+	}
+	for _, obj := range objects {
+		fs.folds.indexEntry(prefix, obj.Key)
+	}
+	fs.folds.markListed(dir, now)
+}
@@ -0,0 +1,179 @@
+package fuse
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// contentBackend holds object contents by key and counts the requests
+// made for them
+type contentBackend struct {
+	types.Backend
+	mu      sync.Mutex
+	objects map[string][]byte
+	heads   int
+	gets    int
+}
+
+func (b *contentBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.heads++
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	return &types.ObjectInfo{Key: key, Size: int64(len(data))}, nil
+}
+
+func (b *contentBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gets++
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	if offset >= int64(len(data)) {
+		return nil, nil
+	}
+	end := min(offset+size, int64(len(data)))
+	return append([]byte(nil), data[offset:end]...), nil
+}
+
+func (b *contentBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key] = data
+	return nil
+}
+
+func (b *contentBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var objects []types.ObjectInfo
+	for key, data := range b.objects {
+		if strings.HasPrefix(key, prefix) && len(objects) < limit {
+			objects = append(objects, types.ObjectInfo{Key: key, Size: int64(len(data))})
+		}
+	}
+	return objects, nil
+}
+
+func (b *contentBackend) has(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.objects[key]
+	return ok
+}
+
+// requests returns the HEAD and GET requests made, resetting the counts
+func (b *contentBackend) requests() (heads, gets int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	heads, gets = b.heads, b.gets
+	b.heads, b.gets = 0, 0
+	return heads, gets
+}
+
+// newContentFS returns a filesystem with a data cache, and its FUSE bridge
+func newContentFS(t *testing.T, backend types.Backend, config *Config) (*FileSystem, fuse.RawFileSystem) {
+	t.Helper()
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100})
+	t.Cleanup(func() { _ = lru.Close() })
+
+	config.DefaultMode = 0644
+	config.WriteCoalesce = &WriteCoalescerConfig{Enabled: false}
+	filesystem := NewFileSystem(backend, lru, &recordingBuffer{}, nil, config)
+	t.Cleanup(filesystem.readAhead.Stop)
+	return filesystem, fs.NewNodeFS(filesystem.Root(), &fs.Options{})
+}
+
+func newCaseFS(t *testing.T, backend types.Backend, caseInsensitive bool) fuse.RawFileSystem {
+	t.Helper()
+	_, raw := newContentFS(t, backend, &Config{CaseInsensitive: caseInsensitive})
+	return raw
+}
+
+// catRoot reads the start of the root directory entry name
+func catRoot(t *testing.T, raw fuse.RawFileSystem, name string) string {
+	t.Helper()
+	return readRoot(t, raw, name, 0, 64)
+}
+
+// readRoot reads size bytes at offset of the root directory entry name
+func readRoot(t *testing.T, raw fuse.RawFileSystem, name string, offset uint64, size int) string {
+	t.Helper()
+	entry, status := lookupRoot(raw, name)
+	if status != fuse.OK {
+		t.Fatalf("Lookup(%s) status = %v, want OK", name, status)
+	}
+
+	open := &fuse.OpenOut{}
+	if status := raw.Open(nil, &fuse.OpenIn{InHeader: fuse.InHeader{NodeId: entry.NodeId}, Flags: syscall.O_RDONLY}, open); status != fuse.OK {
+		t.Fatalf("Open(%s) status = %v", name, status)
+	}
+	buf := make([]byte, size)
+	result, status := raw.Read(nil, &fuse.ReadIn{InHeader: fuse.InHeader{NodeId: entry.NodeId}, Fh: open.Fh, Offset: offset, Size: uint32(size)}, buf)
+	if status != fuse.OK {
+		t.Fatalf("Read(%s) status = %v", name, status)
+	}
+	data, _ := result.Bytes(buf)
+	return string(data)
+}
+
+func TestCaseInsensitiveLookupAndCollisions(t *testing.T) {
+	backend := &contentBackend{objects: map[string][]byte{
+		"file.txt":       []byte("hello"),
+		"docs/Readme.md": []byte("read me"),
+	}}
+	raw := newCaseFS(t, backend, true)
+
+	if got := catRoot(t, raw, "File.TXT"); got != "hello" {
+		t.Errorf("cat File.TXT = %q, want the contents of file.txt", got)
+	}
+
+	create := &fuse.CreateIn{InHeader: fuse.InHeader{NodeId: fuse.FUSE_ROOT_ID}, Flags: syscall.O_WRONLY, Mode: 0644}
+	if status := raw.Create(nil, create, "FILE.txt", &fuse.CreateOut{}); status != fuse.Status(syscall.EEXIST) {
+		t.Errorf("Create(FILE.txt) status = %v, want EEXIST", status)
+	}
+	if backend.has("FILE.txt") {
+		t.Error("a case variant of file.txt was created")
+	}
+
+	mkdir := &fuse.MkdirIn{InHeader: fuse.InHeader{NodeId: fuse.FUSE_ROOT_ID}, Mode: 0755}
+	if status := raw.Mkdir(nil, mkdir, "DOCS", &fuse.EntryOut{}); status != fuse.Status(syscall.EEXIST) {
+		t.Errorf("Mkdir(DOCS) status = %v, want EEXIST", status)
+	}
+
+	// New paths are still created, and found under any case afterwards
+	if status := raw.Create(nil, create, "New.txt", &fuse.CreateOut{}); status != fuse.OK {
+		t.Fatalf("Create(New.txt) status = %v", status)
+	}
+	if _, status := lookupRoot(raw, "NEW.TXT"); status != fuse.OK {
+		t.Errorf("Lookup(NEW.TXT) status = %v after creating New.txt", status)
+	}
+}
+
+func TestCaseSensitiveByDefault(t *testing.T) {
+	backend := &contentBackend{objects: map[string][]byte{"file.txt": []byte("hello")}}
+	raw := newCaseFS(t, backend, false)
+
+	if _, status := lookupRoot(raw, "File.TXT"); status != fuse.ENOENT {
+		t.Errorf("Lookup(File.TXT) status = %v, want ENOENT", status)
+	}
+	create := &fuse.CreateIn{InHeader: fuse.InHeader{NodeId: fuse.FUSE_ROOT_ID}, Flags: syscall.O_WRONLY, Mode: 0644}
+	if status := raw.Create(nil, create, "File.txt", &fuse.CreateOut{}); status != fuse.OK {
+		t.Errorf("Create(File.txt) status = %v, want OK", status)
+	}
+}
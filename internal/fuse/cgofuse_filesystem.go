@@ -15,6 +15,7 @@ import (
 
 	"github.com/winfsp/cgofuse/fuse"
 
+	"github.com/objectfs/objectfs/internal/cache"
 	"github.com/objectfs/objectfs/pkg/types"
 )
 
@@ -29,8 +30,18 @@ type CgoFuseFS struct {
 	metrics     types.MetricsCollector
 	config      *Config
 
+	// Concurrent cache misses for the same range share one backend read
+	fetches fetchGroup
+
+	// Object versions cached ranges were verified against
+	versions objectVersions
+
+	// Paths found to be directories by having objects under them
+	dirs implicitDirs
+
 	// Internal state
 	mu         sync.RWMutex
+	etags      map[string]string
 	openFiles  map[uint64]*OpenFile
 	nextHandle uint64
 	host       *fuse.FileSystemHost
@@ -56,6 +67,7 @@ func NewCgoFuseFS(backend types.Backend, cache types.Cache, writeBuffer types.Wr
 		writeBuffer: writeBuffer,
 		metrics:     metrics,
 		config:      config,
+		etags:       make(map[string]string),
 		openFiles:   make(map[uint64]*OpenFile),
 		nextHandle:  1,
 	}
@@ -78,6 +90,17 @@ func (fs *CgoFuseFS) Mount(ctx context.Context) error {
 		"-o", "subtype=s3",
 		"-o", "allow_other",
 	}
+	if fs.config.NonEmpty {
+		options = append(options, "-o", "nonempty")
+	}
+
+	// Kernel writeback cache and request queue, negotiated by libfuse
+	queue := hostKernelQueue(&MountOptions{
+		WritebackCache:      fs.config.WritebackCache,
+		MaxBackground:       fs.config.MaxBackground,
+		CongestionThreshold: fs.config.CongestionThreshold,
+	})
+	options = append(options, queue.libfuseOptions()...)
 
 	// Platform-specific options
 	switch {
@@ -158,10 +181,8 @@ func (fs *CgoFuseFS) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
 	ctx := context.Background()
 	info, err := fs.backend.HeadObject(ctx, key)
 	if err != nil {
-		// Check if it might be a directory by trying to list with prefix
-		objects, listErr := fs.backend.ListObjects(ctx, key+"/", 1)
-		if listErr == nil && len(objects) > 0 {
-			// It's a directory
+		// A prefix with objects under it is a directory
+		if resolveImplicitDir(ctx, fs.backend, &fs.dirs, fs.config, key) {
 			stat.Mode = fuse.S_IFDIR | 0755
 			stat.Nlink = 2
 			return 0
@@ -170,6 +191,7 @@ func (fs *CgoFuseFS) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
 	}
 
 	// Cache the info
+	fs.dirs.remove(key)
 	fs.cacheInfo(key, info)
 	fs.fillStat(stat, info)
 	return 0
@@ -181,6 +203,11 @@ func (fs *CgoFuseFS) Open(path string, flags int) (int, uint64) {
 
 	key := strings.TrimPrefix(path, "/")
 
+	// Directories found by Getattr have no object to read
+	if fs.dirs.contains(key, time.Now()) {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+
 	fs.mu.Lock()
 	handle := fs.nextHandle
 	fs.nextHandle++
@@ -201,23 +228,40 @@ func (fs *CgoFuseFS) Read(path string, buff []byte, ofst int64, fh uint64) int {
 
 	key := strings.TrimPrefix(path, "/")
 
-	// Try cache first
+	// Try cache first, refetching ranges found stale by verification
+	ctx := context.Background()
 	if cached := fs.cache.Get(key, ofst, int64(len(buff))); cached != nil {
-		fs.metrics.RecordCacheHit(key, int64(len(cached)))
-		copy(buff, cached)
-		return len(cached)
+		if !fs.config.VerifyCachedReads || cachedRangeFresh(ctx, fs.backend, &fs.versions, key, ofst, int64(len(buff)), cached) {
+			fs.metrics.RecordCacheHit(key, int64(len(cached)))
+			copy(buff, cached)
+			return len(cached)
+		}
+		fs.cache.Delete(key)
+		fs.metrics.RecordOperation("stale_refetch", 0, int64(len(cached)), true)
 	}
 
-	// Read from S3
-	ctx := context.Background()
-	data, err := fs.backend.GetObject(ctx, key, ofst, int64(len(buff)))
+	// Read from S3, sharing the read with concurrent misses for the range
+	size := int64(len(buff))
+	blockStart, blockSize := alignRange(ofst, size, fs.fetchAlignment())
+	fetchKey := fmt.Sprintf("%s:%d:%d", key, blockStart, blockSize)
+	block, shared, err := fs.fetches.do(ctx, fetchKey, func(fetchCtx context.Context) ([]byte, error) {
+		block, err := fs.backend.GetObject(fetchCtx, key, blockStart, blockSize)
+		if err == nil {
+			cache.PutWithETag(fs.cache, key, ofst, sliceRange(block, blockStart, ofst, size), fs.etag(key))
+		}
+		return block, err
+	})
 	if err != nil {
 		return -fuse.EIO
 	}
 
-	// Cache the data
-	fs.cache.Put(key, ofst, data)
-	fs.metrics.RecordCacheMiss(key, int64(len(data)))
+	data := sliceRange(block, blockStart, ofst, size)
+	if shared {
+		cache.PutWithETag(fs.cache, key, ofst, data, fs.etag(key))
+	} else {
+		fs.metrics.RecordCacheMiss(key, int64(len(data)))
+		recordObjectSize(fs.metrics, "get", int64(len(block)))
+	}
 
 	copy(buff, data)
 	return len(data)
@@ -228,6 +272,9 @@ func (fs *CgoFuseFS) Write(path string, buff []byte, ofst int64, fh uint64) int
 	defer fs.recordOperation("write", time.Now())
 
 	key := strings.TrimPrefix(path, "/")
+	if limit := fs.config.MaxObjectSize.Limit(key); limit > 0 && ofst+int64(len(buff)) > limit {
+		return -fuse.EFBIG
+	}
 
 	// Write to buffer
 	err := fs.writeBuffer.Write(key, ofst, buff)
@@ -238,6 +285,85 @@ func (fs *CgoFuseFS) Write(path string, buff []byte, ofst int64, fh uint64) int
 	return len(buff)
 }
 
+// Utimens updates timestamps. Objects only record a last-modified time, so
+// any update touches the object without re-uploading it.
+func (fs *CgoFuseFS) Utimens(path string, tmsp []fuse.Timespec) int {
+	defer fs.recordOperation("utimens", time.Now())
+
+	if fs.config.ReadOnly {
+		return -fuse.EROFS
+	}
+	toucher, ok := fs.backend.(types.ObjectToucher)
+	if !ok {
+		return -fuse.ENOSYS
+	}
+
+	key := strings.TrimPrefix(path, "/")
+	if err := toucher.Touch(context.Background(), key); err != nil {
+		log.Printf("Touch failed for %s: %v", key, err)
+		return -fuse.EIO
+	}
+	return 0
+}
+
+// Rename moves a file with a server-side move that keeps its metadata and
+// tags. Backends without moves get EXDEV, so callers copy instead.
+func (fs *CgoFuseFS) Rename(oldpath string, newpath string) int {
+	defer fs.recordOperation("rename", time.Now())
+
+	if fs.config.ReadOnly {
+		return -fuse.EROFS
+	}
+	mover, ok := fs.backend.(types.ObjectMover)
+	if !ok {
+		return -fuse.EXDEV
+	}
+
+	src, dst := strings.TrimPrefix(oldpath, "/"), strings.TrimPrefix(newpath, "/")
+	if err := mover.MoveObject(context.Background(), src, dst); err != nil {
+		log.Printf("Rename of %s to %s failed: %v", src, dst, err)
+		return -fuse.EIO
+	}
+	return 0
+}
+
+// Flush is called on every close of a file. With SyncOnClose it waits until
+// the backend has stored the file's buffered writes.
+func (fs *CgoFuseFS) Flush(path string, fh uint64) int {
+	defer fs.recordOperation("flush", time.Now())
+
+	key := strings.TrimPrefix(path, "/")
+	if !fs.config.SyncOnClose {
+		if err := fs.writeBuffer.Flush(key); err != nil {
+			return -fuse.EIO
+		}
+		return 0
+	}
+	return fs.sync(key, "sync_on_close")
+}
+
+// Fsync waits until the backend has stored the file's buffered writes
+func (fs *CgoFuseFS) Fsync(path string, datasync bool, fh uint64) int {
+	defer fs.recordOperation("fsync", time.Now())
+
+	return fs.sync(strings.TrimPrefix(path, "/"), "fsync")
+}
+
+// sync flushes the buffered writes of key and waits for the backend,
+// recording the wait under operation
+func (fs *CgoFuseFS) sync(key, operation string) int {
+	start := time.Now()
+	err := syncBuffered(context.Background(), fs.writeBuffer, key)
+	if fs.metrics != nil {
+		fs.metrics.RecordOperation(operation, time.Since(start), 0, err == nil)
+	}
+	if err != nil {
+		log.Printf("Sync failed for %s: %v", key, err)
+		return -fuse.EIO
+	}
+	return 0
+}
+
 // Release closes a file
 func (fs *CgoFuseFS) Release(path string, fh uint64) int {
 	defer fs.recordOperation("release", time.Now())
@@ -314,11 +440,32 @@ func (fs *CgoFuseFS) getCachedInfo(key string) *types.ObjectInfo {
 }
 
 func (fs *CgoFuseFS) cacheInfo(key string, info *types.ObjectInfo) {
-	// Simple implementation - in a real system you'd cache metadata
+	// Simple implementation - in a real system you'd cache metadata. The
+	// ETag is kept so ranges read after this lookup revalidate against it.
+	fs.mu.Lock()
+	fs.etags[key] = info.ETag
+	fs.mu.Unlock()
+}
+
+// etag returns the ETag key had when last looked up
+func (fs *CgoFuseFS) etag(key string) string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.etags[key]
 }
 
 func (fs *CgoFuseFS) fillStat(stat *fuse.Stat_t, info *types.ObjectInfo) {
-	stat.Mode = fuse.S_IFREG | 0644
+	// Directory placeholders are directories, not empty files
+	if fs.config.directoryMarkers().isDirectory(info) {
+		stat.Mode = fuse.S_IFDIR | 0755
+		stat.Nlink = 2
+		return
+	}
+
+	mode, uid, gid := fs.config.permissions().ToPOSIX(*info)
+	stat.Mode = fuse.S_IFREG | mode
+	stat.Uid = uid
+	stat.Gid = gid
 	stat.Size = info.Size
 	stat.Nlink = 1
 	stat.Mtim.Sec = info.LastModified.Unix()
@@ -344,5 +491,14 @@ func (fs *CgoFuseFS) GetStats() *FilesystemStats {
 		CacheHits:    0,
 		CacheMisses:  0,
 		Errors:       0,
+		DedupedReads: fs.fetches.dedupedReads(),
+	}
+}
+
+// fetchAlignment returns the block size cache-miss reads are aligned to
+func (fs *CgoFuseFS) fetchAlignment() int64 {
+	if fs.config != nil && fs.config.FetchAlignment > 0 {
+		return fs.config.FetchAlignment
 	}
+	return defaultFetchAlignment
 }
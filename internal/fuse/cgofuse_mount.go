@@ -27,6 +27,25 @@ func NewCgoFuseMountManager(backend types.Backend, cache types.Cache, writeBuffe
 		DefaultGID:  1000, // TODO: Make configurable
 		DefaultMode: 0644,
 		CacheTTL:    config.Options.MaxRead, // Reuse for TTL
+		NonEmpty:    config.Options.NonEmpty,
+
+		WritebackCache:      config.Options.WritebackCache,
+		MaxBackground:       config.Options.MaxBackground,
+		CongestionThreshold: config.Options.CongestionThreshold,
+
+		MaxObjectSize: config.MaxObjectSize,
+		SyncOnClose:   config.SyncOnClose,
+
+		VerifyCachedReads: config.VerifyCachedReads,
+
+		DisableImplicitDirs: config.DisableImplicitDirs,
+		ImplicitDirTTL:      config.ImplicitDirTTL,
+		DirectoryMarkers:    config.DirectoryMarkers,
+
+		PermissionMapper: config.PermissionMapper,
+
+		Degradation:  config.Degradation,
+		Availability: config.Availability,
 	}
 
 	filesystem := NewCgoFuseFS(backend, cache, writeBuffer, metrics, fuseConfig)
@@ -0,0 +1,78 @@
+package fuse
+
+import (
+	"syscall"
+	"time"
+)
+
+// Degradation actions
+const (
+	// Reads the backend cannot serve fail with EIO (default)
+	DegradeReadEIO = "eio"
+	// Reads are served from cached data, even if expired
+	DegradeReadServeStale = "serve-stale"
+
+	// Writes are accepted into the write buffer and uploaded once the
+	// backend recovers (default)
+	DegradeWriteQueue = "queue"
+	// Writes fail with EROFS
+	DegradeWriteEROFS = "erofs"
+)
+
+// DegradationPolicy selects filesystem behavior while the backend reports
+// that it cannot serve reads or accept writes
+type DegradationPolicy struct {
+	OnReadUnavailable  string        `yaml:"on_read_unavailable"`  // "eio" (default) or "serve-stale"
+	OnWriteUnavailable string        `yaml:"on_write_unavailable"` // "queue" (default) or "erofs"
+	MaxStaleness       time.Duration `yaml:"max_staleness"`        // How long past expiry cached data may be served; 0 = any age
+}
+
+// BackendAvailability reports whether the backend currently serves reads
+// and accepts writes, such as from its health tracker
+type BackendAvailability interface {
+	IsReadAvailable() bool
+	IsWriteAvailable() bool
+}
+
+// staleReader is implemented by caches that can serve expired entries
+type staleReader interface {
+	GetStale(key string, offset, size int64, maxStaleness time.Duration) []byte
+}
+
+// readUnavailable reports whether the backend cannot serve reads
+func (fs *FileSystem) readUnavailable() bool {
+	return fs.config.Availability != nil && !fs.config.Availability.IsReadAvailable()
+}
+
+// writeErrno returns EROFS when writes must be refused, either because the
+// mount is read-only or because the backend cannot accept writes and the
+// policy does not queue them
+func (fs *FileSystem) writeErrno() syscall.Errno {
+	if fs.config.ReadOnly {
+		return syscall.EROFS
+	}
+	if fs.config.Degradation.OnWriteUnavailable == DegradeWriteEROFS &&
+		fs.config.Availability != nil && !fs.config.Availability.IsWriteAvailable() {
+		return syscall.EROFS
+	}
+	return 0
+}
+
+// degradedRead serves a read while the backend cannot, returning nil when
+// the policy offers no data for the range. Fresh cache entries are always
+// served; expired ones only under the serve-stale policy.
+func (fs *FileSystem) degradedRead(path string, offset, size int64) []byte {
+	policy := fs.config.Degradation
+	if policy.OnReadUnavailable == DegradeReadServeStale {
+		if reader, ok := fs.cache.(staleReader); ok {
+			data := reader.GetStale(path, offset, size, policy.MaxStaleness)
+			if data != nil {
+				fs.stats.mu.Lock()
+				fs.stats.DegradedReads++
+				fs.stats.mu.Unlock()
+			}
+			return data
+		}
+	}
+	return fs.cache.Get(path, offset, size)
+}
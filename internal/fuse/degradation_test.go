@@ -0,0 +1,156 @@
+package fuse
+
+import (
+	"context"
+	stderr "errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/health"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// trackerAvailability reports availability from a health tracker the way
+// the S3 backend does
+type trackerAvailability struct {
+	tracker *health.Tracker
+}
+
+func newTrackerAvailability() *trackerAvailability {
+	tracker := health.NewTracker(health.TrackerConfig{ErrorThreshold: 1, UnavailableThreshold: 2, RecoveryThreshold: 1})
+	tracker.RegisterComponent("s3-reads")
+	tracker.RegisterComponent("s3-writes")
+	return &trackerAvailability{tracker: tracker}
+}
+
+func (a *trackerAvailability) IsReadAvailable() bool  { return a.tracker.CanRead("s3-reads") }
+func (a *trackerAvailability) IsWriteAvailable() bool { return a.tracker.CanWrite("s3-writes") }
+
+// failReads drives the read component past the unavailable threshold
+func (a *trackerAvailability) failReads() {
+	for i := 0; i < 2; i++ {
+		a.tracker.RecordError("s3-reads", stderr.New("connection refused"))
+	}
+}
+
+// failWrites leaves the write component read-only
+func (a *trackerAvailability) failWrites() {
+	a.tracker.RecordError("s3-writes", errors.NewError(errors.ErrCodeStorageWrite, "put failed"))
+}
+
+// countingBackend counts reads and fails them all
+type countingBackend struct {
+	types.Backend
+	reads int
+}
+
+func (b *countingBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	b.reads++
+	return nil, stderr.New("backend unreachable")
+}
+
+func newDegradedFS(t *testing.T, policy DegradationPolicy, ttl time.Duration) (*FileSystem, *cache.LRUCache, *countingBackend, *trackerAvailability) {
+	t.Helper()
+
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100, TTL: ttl})
+	t.Cleanup(func() { _ = lru.Close() })
+
+	backend := &countingBackend{}
+	availability := newTrackerAvailability()
+	filesystem := NewFileSystem(backend, lru, &recordingBuffer{}, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+		Degradation:   policy,
+		Availability:  availability,
+	})
+	filesystem.readAhead.Stop()
+	filesystem.readAhead = nil
+	return filesystem, lru, backend, availability
+}
+
+func TestDegradedReadServesStaleCache(t *testing.T) {
+	filesystem, lru, backend, availability := newDegradedFS(t, DegradationPolicy{
+		OnReadUnavailable: DegradeReadServeStale,
+		MaxStaleness:      time.Minute,
+	}, 10*time.Millisecond)
+
+	lru.Put("data.bin", 0, []byte("cached"))
+	time.Sleep(20 * time.Millisecond)
+	availability.failReads()
+
+	fh := &FileHandle{fs: filesystem, handle: 1, file: &OpenFile{path: "data.bin"}}
+	result, errno := fh.Read(context.Background(), make([]byte, 6), 0)
+	if errno != 0 {
+		t.Fatalf("Read() errno = %v, want expired data served", errno)
+	}
+	if data, _ := result.Bytes(make([]byte, 6)); string(data) != "cached" {
+		t.Errorf("Read() = %q, want %q", data, "cached")
+	}
+	if backend.reads != 0 {
+		t.Errorf("backend reads = %d, want none while unavailable", backend.reads)
+	}
+	if got := filesystem.GetStats().DegradedReads; got != 1 {
+		t.Errorf("degraded reads = %d, want 1", got)
+	}
+	if got := lru.Stats().StaleHits; got != 1 {
+		t.Errorf("cache stale hits = %d, want 1", got)
+	}
+}
+
+func TestDegradedReadRespectsMaxStaleness(t *testing.T) {
+	filesystem, lru, _, availability := newDegradedFS(t, DegradationPolicy{
+		OnReadUnavailable: DegradeReadServeStale,
+		MaxStaleness:      10 * time.Millisecond,
+	}, 10*time.Millisecond)
+
+	lru.Put("data.bin", 0, []byte("cached"))
+	time.Sleep(40 * time.Millisecond)
+	availability.failReads()
+
+	fh := &FileHandle{fs: filesystem, handle: 1, file: &OpenFile{path: "data.bin"}}
+	if _, errno := fh.Read(context.Background(), make([]byte, 6), 0); errno != syscall.EIO {
+		t.Errorf("Read() errno = %v, want EIO past the staleness bound", errno)
+	}
+}
+
+func TestDegradedReadEIOPolicy(t *testing.T) {
+	filesystem, lru, backend, availability := newDegradedFS(t, DegradationPolicy{}, 10*time.Millisecond)
+
+	lru.Put("data.bin", 0, []byte("cached"))
+	time.Sleep(20 * time.Millisecond)
+	availability.failReads()
+
+	fh := &FileHandle{fs: filesystem, handle: 1, file: &OpenFile{path: "data.bin"}}
+	if _, errno := fh.Read(context.Background(), make([]byte, 6), 0); errno != syscall.EIO {
+		t.Errorf("Read() errno = %v, want EIO", errno)
+	}
+	if backend.reads != 0 {
+		t.Errorf("backend reads = %d, want none while unavailable", backend.reads)
+	}
+}
+
+func TestDegradedWritePolicies(t *testing.T) {
+	ctx := context.Background()
+
+	erofs, _, _, availability := newDegradedFS(t, DegradationPolicy{OnWriteUnavailable: DegradeWriteEROFS}, 0)
+	fh := &FileHandle{fs: erofs, handle: 1, file: &OpenFile{path: "out.log"}}
+	if _, errno := fh.Write(ctx, []byte("before"), 0); errno != 0 {
+		t.Fatalf("Write() while healthy errno = %v", errno)
+	}
+	availability.failWrites()
+	if _, errno := fh.Write(ctx, []byte("after"), 6); errno != syscall.EROFS {
+		t.Errorf("Write() under erofs policy errno = %v, want EROFS", errno)
+	}
+
+	queue, _, _, availability := newDegradedFS(t, DegradationPolicy{OnWriteUnavailable: DegradeWriteQueue}, 0)
+	availability.failWrites()
+	fh = &FileHandle{fs: queue, handle: 1, file: &OpenFile{path: "out.log"}}
+	if _, errno := fh.Write(ctx, []byte("queued"), 0); errno != 0 {
+		t.Fatalf("Write() under queue policy errno = %v", errno)
+	}
+	if writes := queue.buffer.(*recordingBuffer).snapshot(); len(writes) != 1 {
+		t.Errorf("buffered %d writes, want the write queued for upload", len(writes))
+	}
+}
@@ -0,0 +1,75 @@
+package fuse
+
+import (
+	"context"
+	"strings"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Directory marker defaults
+const (
+	DefaultDirectoryMarkerContentType = "application/x-directory"
+	DefaultDirectoryMarkerMetadataKey = "objectfs-type"
+
+	// directoryMarkerValue is the metadata value marking a placeholder
+	directoryMarkerValue = "directory"
+)
+
+// DirectoryMarkers decides which zero-byte objects are directory
+// placeholders rather than empty files. A key ending in "/" always is one.
+// Any other zero-byte object is a regular file unless it has ContentType or
+// its MetadataKey is "directory", as placeholders written by other tools
+// without the trailing slash do.
+type DirectoryMarkers struct {
+	ContentType string `yaml:"content_type"` // Default "application/x-directory"
+	MetadataKey string `yaml:"metadata_key"` // Default "objectfs-type"
+}
+
+// directoryMarkers returns the configured marker scheme with defaults
+// filled in
+func (c *Config) directoryMarkers() DirectoryMarkers {
+	var markers DirectoryMarkers
+	if c != nil {
+		markers = c.DirectoryMarkers
+	}
+	if markers.ContentType == "" {
+		markers.ContentType = DefaultDirectoryMarkerContentType
+	}
+	if markers.MetadataKey == "" {
+		markers.MetadataKey = DefaultDirectoryMarkerMetadataKey
+	}
+	return markers
+}
+
+// isDirectory reports whether info describes a directory placeholder
+func (m DirectoryMarkers) isDirectory(info *types.ObjectInfo) bool {
+	if info == nil || info.Size != 0 {
+		return false
+	}
+	if strings.HasSuffix(info.Key, "/") {
+		return true
+	}
+	if info.ContentType != "" && strings.EqualFold(contentTypeBase(info.ContentType), m.ContentType) {
+		return true
+	}
+	return info.Metadata[m.MetadataKey] == directoryMarkerValue
+}
+
+// contentTypeBase strips parameters such as charset from a content type
+func contentTypeBase(contentType string) string {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.TrimSpace(contentType)
+}
+
+// putDirectoryMarker writes the placeholder for the directory at path,
+// marked with metadata on backends that store it
+func putDirectoryMarker(ctx context.Context, backend types.Backend, markers DirectoryMarkers, path string) error {
+	key := strings.TrimSuffix(path, "/") + "/"
+	if writer, ok := backend.(types.ObjectMetadataWriter); ok {
+		return writer.PutObjectWithMetadata(ctx, key, []byte{}, map[string]string{markers.MetadataKey: directoryMarkerValue})
+	}
+	return backend.PutObject(ctx, key, []byte{})
+}
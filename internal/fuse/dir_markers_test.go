@@ -0,0 +1,155 @@
+package fuse
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// markerBackend holds zero-byte objects with their content types and
+// metadata
+type markerBackend struct {
+	types.Backend
+	mu      sync.Mutex
+	objects map[string]types.ObjectInfo
+}
+
+func (b *markerBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	info, ok := b.objects[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	info.Key = key
+	return &info, nil
+}
+
+func (b *markerBackend) HeadObjects(ctx context.Context, keys []string) (map[string]*types.ObjectInfo, map[string]error) {
+	infos := make(map[string]*types.ObjectInfo)
+	errs := make(map[string]error)
+	for _, key := range keys {
+		if info, err := b.HeadObject(ctx, key); err == nil {
+			infos[key] = info
+		} else {
+			errs[key] = err
+		}
+	}
+	return infos, errs
+}
+
+func (b *markerBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var objects []types.ObjectInfo
+	for key, info := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, types.ObjectInfo{Key: key, Size: info.Size})
+		}
+	}
+	return objects, nil
+}
+
+func (b *markerBackend) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key] = types.ObjectInfo{Size: int64(len(data)), Metadata: metadata}
+	return nil
+}
+
+// readdirModes lists the root of a new filesystem over backend
+func readdirModes(t *testing.T, backend types.Backend, config *Config) map[string]uint32 {
+	t.Helper()
+	filesystem := NewFileSystem(backend, nil, &recordingBuffer{}, nil, config)
+	t.Cleanup(filesystem.readAhead.Stop)
+
+	stream, errno := (&DirectoryNode{fs: filesystem}).Readdir(context.Background())
+	if errno != 0 {
+		t.Fatalf("Readdir errno = %v", errno)
+	}
+	modes := make(map[string]uint32)
+	for stream.HasNext() {
+		entry, errno := stream.Next()
+		if errno != 0 {
+			t.Fatalf("Readdir entry errno = %v", errno)
+		}
+		modes[entry.Name] = entry.Mode
+	}
+	return modes
+}
+
+func TestZeroByteFilesAndDirectoryPlaceholders(t *testing.T) {
+	backend := &markerBackend{objects: map[string]types.ObjectInfo{
+		"empty.txt": {},
+		"legacy":    {ContentType: "application/x-directory"},
+		"tagged":    {Metadata: map[string]string{"objectfs-type": "directory"}},
+	}}
+	raw := newPrefixFS(t, backend, &Config{})
+
+	mkdir := &fuse.MkdirIn{InHeader: fuse.InHeader{NodeId: fuse.FUSE_ROOT_ID}, Mode: 0755}
+	if status := raw.Mkdir(nil, mkdir, "made", &fuse.EntryOut{}); status != fuse.OK {
+		t.Fatalf("Mkdir(made) status = %v", status)
+	}
+	if marker := backend.objects["made/"]; marker.Metadata["objectfs-type"] != "directory" {
+		t.Fatalf("placeholder metadata = %v, want the directory marker", marker.Metadata)
+	}
+
+	want := map[string]uint32{
+		"empty.txt": fuse.S_IFREG,
+		"legacy":    fuse.S_IFDIR,
+		"tagged":    fuse.S_IFDIR,
+		"made":      fuse.S_IFDIR,
+	}
+
+	// A remount knows only what the bucket holds
+	remounted := newPrefixFS(t, backend, &Config{})
+	for _, name := range []string{"empty.txt", "legacy", "tagged", "made"} {
+		if mode := statRoot(t, remounted, name).Mode & syscall.S_IFMT; mode != want[name] {
+			t.Errorf("stat %s mode = %o, want %o", name, mode, want[name])
+		}
+	}
+	modes := readdirModes(t, backend, &Config{})
+	for name, mode := range want {
+		if modes[name] != mode {
+			t.Errorf("readdir %s mode = %o, want %o", name, modes[name], mode)
+		}
+	}
+}
+
+func TestDirectoryMarkerSchemeIsConfigurable(t *testing.T) {
+	backend := &markerBackend{objects: map[string]types.ObjectInfo{
+		"custom":  {Metadata: map[string]string{"kind": "directory"}},
+		"default": {Metadata: map[string]string{"objectfs-type": "directory"}},
+		"legacy":  {ContentType: "application/x-directory"},
+		"folder":  {ContentType: "application/x-folder; charset=binary"},
+	}}
+	config := &Config{DirectoryMarkers: DirectoryMarkers{ContentType: "application/x-folder", MetadataKey: "kind"}}
+	raw := newPrefixFS(t, backend, config)
+
+	want := map[string]uint32{
+		"custom":  fuse.S_IFDIR,
+		"default": fuse.S_IFREG,
+		"legacy":  fuse.S_IFREG,
+		"folder":  fuse.S_IFDIR,
+	}
+	for name, mode := range want {
+		if got := statRoot(t, raw, name).Mode & syscall.S_IFMT; got != mode {
+			t.Errorf("stat %s mode = %o, want %o", name, got, mode)
+		}
+	}
+
+	mkdir := &fuse.MkdirIn{InHeader: fuse.InHeader{NodeId: fuse.FUSE_ROOT_ID}, Mode: 0755}
+	if status := raw.Mkdir(nil, mkdir, "made", &fuse.EntryOut{}); status != fuse.OK {
+		t.Fatalf("Mkdir(made) status = %v", status)
+	}
+	if marker := backend.objects["made/"]; marker.Metadata["kind"] != "directory" {
+		t.Errorf("placeholder metadata = %v, want the configured marker", marker.Metadata)
+	}
+}
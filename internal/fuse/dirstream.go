@@ -0,0 +1,175 @@
+package fuse
+
+import (
+	"context"
+	"log"
+	"strings"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// streamDirStream adapts a streaming backend listing to fs.DirStream,
+// producing directory entries as listing pages arrive
+type streamDirStream struct {
+	fs     *FileSystem
+	path   string
+	prefix string
+
+	objects <-chan types.ObjectInfo
+	errs    <-chan error
+	cancel  context.CancelFunc
+
+	seen    map[string]bool
+	next    *fuse.DirEntry
+	errno   syscall.Errno
+	done    bool
+	emitted bool
+
+	// Entries held back until their files' metadata is prefetched, on
+	// backends with batch metadata support
+	batchStats bool
+	batch      []fuse.DirEntry
+}
+
+func newStreamDirStream(fs *FileSystem, path, prefix string, objects <-chan types.ObjectInfo, errs <-chan error, cancel context.CancelFunc) *streamDirStream {
+	return &streamDirStream{
+		fs:      fs,
+		path:    path,
+		prefix:  prefix,
+		objects: objects,
+		errs:    errs,
+		cancel:  cancel,
+		seen:    make(map[string]bool),
+
+		batchStats: isBatchHeader(fs.backend) && fs.config.ListingPrefetch.attributes(),
+	}
+}
+
+// isBatchHeader reports whether backend can fetch metadata in batches
+func isBatchHeader(backend types.Backend) bool {
+	_, ok := backend.(types.BatchHeader)
+	return ok
+}
+
+// HasNext reports whether another entry is available, blocking until the
+// next listing page arrives if necessary
+func (s *streamDirStream) HasNext() bool {
+	if s.next != nil {
+		return true
+	}
+	if len(s.batch) > 0 {
+		entry := s.batch[0]
+		s.batch = s.batch[1:]
+		s.next = &entry
+		return true
+	}
+	if s.done {
+		return false
+	}
+
+	for obj := range s.objects {
+		entry, ok := s.toEntry(obj)
+		if !ok {
+			continue
+		}
+		if !s.batchStats {
+			s.next = &entry
+			return true
+		}
+		if s.batch = append(s.batch, entry); len(s.batch) >= statBatchSize {
+			s.prefetchBatch()
+			return s.HasNext()
+		}
+	}
+	if len(s.batch) > 0 {
+		s.prefetchBatch()
+		return s.HasNext()
+	}
+
+	s.done = true
+	if err, ok := <-s.errs; ok && err != nil {
+		s.fs.stats.mu.Lock()
+		s.fs.stats.Errors++
+		s.fs.stats.mu.Unlock()
+
+		log.Printf("Readdir failed for %s: %v", s.path, err)
+		// Surface the error only if nothing was listed yet; otherwise the
+		// caller already holds a partial directory
+		if !s.emitted {
+			s.errno = syscall.EIO
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the next directory entry
+func (s *streamDirStream) Next() (fuse.DirEntry, syscall.Errno) {
+	if s.errno != 0 {
+		errno := s.errno
+		s.errno = 0
+		return fuse.DirEntry{}, errno
+	}
+	if s.next == nil && !s.HasNext() {
+		return fuse.DirEntry{}, syscall.ENOENT
+	}
+	entry := *s.next
+	s.next = nil
+	s.emitted = true
+	return entry, 0
+}
+
+// prefetchBatch fetches metadata for the files among the held back entries
+func (s *streamDirStream) prefetchBatch() {
+	files := make([]string, 0, len(s.batch))
+	for _, entry := range s.batch {
+		if entry.Mode == fuse.S_IFREG {
+			files = append(files, s.prefix+entry.Name)
+		}
+	}
+	s.fs.prefetchAttributes(context.Background(), files)
+	for i := range s.batch {
+		if s.batch[i].Mode == fuse.S_IFREG && s.fs.listedDirectory(s.prefix+s.batch[i].Name) {
+			s.batch[i].Mode = fuse.S_IFDIR
+		}
+	}
+}
+
+// Close stops the underlying listing and releases its goroutine
+func (s *streamDirStream) Close() {
+	s.cancel()
+	// Drain so the producer observes cancellation and exits
+	for range s.objects {
+	}
+	s.done = true
+	s.next = nil
+	s.batch = nil
+}
+
+// toEntry converts an object to a directory entry, collapsing nested keys
+// into a single subdirectory entry
+func (s *streamDirStream) toEntry(obj types.ObjectInfo) (fuse.DirEntry, bool) {
+	if s.fs.folds != nil {
+		s.fs.folds.indexEntry(s.prefix, obj.Key)
+	}
+
+	name := strings.TrimPrefix(obj.Key, s.prefix)
+
+	if slashIdx := strings.Index(name, "/"); slashIdx != -1 {
+		dirName := name[:slashIdx]
+		if dirName == "" || s.seen[dirName] {
+			return fuse.DirEntry{}, false
+		}
+		s.seen[dirName] = true
+		return fuse.DirEntry{Name: dirName, Mode: fuse.S_IFDIR}, true
+	}
+
+	if name == "" || s.seen[name] {
+		return fuse.DirEntry{}, false
+	}
+	s.seen[name] = true
+	return fuse.DirEntry{Name: name, Mode: fuse.S_IFREG}, true
+}
@@ -78,18 +78,26 @@ Complete POSIX filesystem operation support:
 File Operations:
 - open(), read(), write(), close() - Standard file I/O
 - lseek(), truncate() - File positioning and size management
-- fsync(), fdatasync() - Data synchronization
+- fsync(), fdatasync() - Data synchronization; writes are durable in the backend once fsync returns
+- close() with SyncOnClose waits for buffered writes to reach the backend and returns their errors; without it close only schedules the flush
+- VerifyCachedReads checks cache hits against the object's current size and ETag, invalidating and refetching ranges of objects that changed (counted as stale_refetch)
+- Degradation decides what happens while the backend health tracker reports it unavailable: reads fail with EIO or serve cached data up to MaxStaleness past expiry (serve-stale, counted as degraded_reads), and writes are queued in the write buffer or refused with EROFS
 - lock(), unlock() - File locking support
 
 Directory Operations:
-- opendir(), readdir(), closedir() - Directory enumeration
+- opendir(), readdir(), closedir() - Directory enumeration; on backends implementing types.BatchHeader the listed files' metadata is fetched in batches so the lookups that follow are served without a HEAD each. Config.ListingPrefetch selects this: "metadata" (default), "off", or "data", which also loads up to MaxDataFiles files of at most MaxDataSize per batch into the cache in the background so reads that follow are hits; prefetches and the lookups and reads they answered are reported as ListingAttrPrefetches, ListingAttrHits, ListingDataPrefetches and ListingDataHits
 - mkdir(), rmdir() - Directory creation and removal
-- rename() - File and directory renaming
+- stat() of a path with no object but objects under it reports a directory (found with a one-key LIST when HEAD misses and remembered for ImplicitDirTTL), so reading it fails with EISDIR rather than ENOENT; DisableImplicitDirs reports such paths as missing
+- CaseInsensitive matches lookups regardless of case (File.TXT opens file.txt) using a case-fold index of listed directories, and create, mkdir and rename fail with EEXIST rather than make a key differing from another only in case; a lookup missing the index lists its directory once per ImplicitDirTTL, and every indexed path is held in memory. Only the go-fuse mount supports it.
+- rename() - File renaming through a server-side move that keeps metadata
+  and tags when the backend implements types.ObjectMover; directories, and
+  files on backends without moves, get EXDEV so mv falls back to copying
 
 Metadata Operations:
 - stat(), fstat(), lstat() - File metadata retrieval
 - chmod(), chown() - Permission and ownership changes
-- utimes(), utime() - Timestamp modification
+- utimes(), utime() - Timestamp modification; touching a file updates the
+  object's last-modified time in place when the backend supports Touch
 - link(), symlink(), readlink() - Link management
 
 Extended Attributes:
@@ -199,18 +207,27 @@ Intelligent Caching:
 - Directory entry caching for fast lookups
 - Negative caching for non-existent files
 - Write-through and write-back caching modes
+- Concurrent misses for the same aligned block share one backend read
 
 Read-Ahead:
 - Sequential read pattern detection
 - Predictive data prefetching
 - Configurable read-ahead buffer sizes
 - Background prefetching workers
+- Open prefetch: opening a small file for reading fetches its start into
+  the cache in the background, and the first read waits for it instead of
+  issuing its own request; started prefetches and the first reads they
+  served are reported as OpenPrefetches and OpenPrefetchHits
+- Priming: PrimeKeys loads a known list of keys before a job, such as the inputs of a workflow manifest; PrimeMetadata HEADs each key so stats, including of missing keys, are answered from memory for CacheTTL, and PrimeData also reads each object into the cache in fetch-aligned blocks so reads are hits; PrimeConcurrency keys are primed at once, PrimeProgress reports the latest call, and totals are reported as PrimedKeys and PrimeFailures
+- Streaming: files selected by Config.Streaming, by minimum size or path prefix, are read around the cache when opened read-only; each handle keeps its last fetched blocks in a small ring so sequential reads fetch every block once, nothing is inserted into the cache, and the reads are reported as StreamingReads and BypassedBytes
 
 Write Optimization:
 - Write buffering and batching
 - Delayed write synchronization
 - Compression for suitable content types
 - Multipart upload for large files
+- Kernel writeback cache (MountOptions.WritebackCache, Linux only): the kernel page cache absorbs buffered writes and sends them in large batches instead of one upcall per write; cached pages and sizes are then trusted over the backend, so leave it off when several nodes write the same files. libfuse negotiates it for cgofuse mounts; go-fuse cannot and logs a warning
+- Kernel request queue (MountOptions.MaxBackground and CongestionThreshold, Linux only): how many asynchronous requests the kernel queues (default 64) and past how many writers are throttled (default three quarters of it); go-fuse mounts set the threshold through the connection's control file in /sys/fs/fuse/connections, which needs root
 
 Connection Management:
 - Connection pooling for concurrent operations
@@ -232,7 +249,11 @@ Directories to Virtual Structure:
 - Directory paths → Object key prefixes
 - Directory listings → Prefix-based object enumeration
 - Directory metadata → Synthetic metadata generation
-- Empty directories → Zero-byte marker objects
+- Empty directories → Zero-byte marker objects at "<dir>/", tagged with
+  objectfs-type=directory metadata. A zero-byte object without a trailing
+  slash is a regular file unless it carries the marker metadata or the
+  application/x-directory content type; both are configurable through
+  Config.DirectoryMarkers.
 
 Special Files:
 - Symbolic links → Stored as object metadata
@@ -250,6 +271,13 @@ Permission Storage:
 - Ownership information preserved in metadata
 - Permission inheritance for new files/directories
 
+Custom Mapping:
+- A PermissionMapper set as Config.PermissionMapper translates object
+  metadata to mode, uid, and gid for stat, and a new file's mode and
+  creator to metadata stored when it is created
+- DefaultPermissionMapper reads objectfs-mode, objectfs-uid, and
+  objectfs-gid metadata and falls back to DefaultMode/UID/GID
+
 Default Behavior:
 - Configurable default UID/GID for all operations
 - Consistent permission model across platforms
@@ -284,6 +312,16 @@ Error Recovery:
 - Consistent state maintenance
 - User notification strategies
 
+Lost Mounts:
+MountConfig.AutoRemount enables a watcher that stats the mount point every
+CheckInterval. When the stat fails, for example with ENOTCONN after the
+kernel aborted the FUSE connection, or the server has stopped, pending
+writes are flushed, the dead mount is lazily detached, and the filesystem is
+mounted again up to MaxAttempts times, waiting Backoff (doubling up to
+MaxBackoff) before each attempt. Watcher().Events() reports mount_lost,
+remounted and remount_failed; once attempts are exhausted Watcher().Err()
+returns why, and MountWatchStats counts checks, losses and remounts.
+
 # Statistics and Monitoring
 
 Comprehensive operation monitoring:
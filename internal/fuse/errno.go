@@ -0,0 +1,33 @@
+package fuse
+
+import (
+	stderrors "errors"
+	"syscall"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// errnoFor maps a storage error to the errno returned to the kernel
+func errnoFor(err error) syscall.Errno {
+	var objErr *errors.ObjectFSError
+	if !stderrors.As(err, &objErr) {
+		return syscall.EIO
+	}
+
+	switch objErr.Code {
+	case errors.ErrCodeLimitExceeded:
+		return syscall.EFBIG
+	case errors.ErrCodeObjectNotFound, errors.ErrCodeFileNotFound:
+		return syscall.ENOENT
+	case errors.ErrCodeAccessDenied, errors.ErrCodePermissionDenied:
+		// Retained objects are immutable for everyone, not just this caller
+		if _, retained := objErr.Context["retain_until"]; retained {
+			return syscall.EPERM
+		}
+		return syscall.EACCES
+	case errors.ErrCodeTierValidation:
+		return syscall.EPERM
+	default:
+		return syscall.EIO
+	}
+}
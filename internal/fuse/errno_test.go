@@ -0,0 +1,58 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+func TestWritePastMaxObjectSize(t *testing.T) {
+	buffer := &recordingBuffer{}
+	filesystem := NewFileSystem(nil, nil, buffer, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+		MaxObjectSize: types.ObjectSizeLimits{Default: 8, Prefixes: map[string]int64{"logs/": 4}},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+	ctx := context.Background()
+
+	fh := &FileHandle{fs: filesystem, handle: 1, file: &OpenFile{path: "data.bin"}}
+	if _, errno := fh.Write(ctx, []byte("12345678"), 0); errno != 0 {
+		t.Fatalf("Write() within limit errno = %v", errno)
+	}
+	if _, errno := fh.Write(ctx, []byte("9"), 8); errno != syscall.EFBIG {
+		t.Errorf("Write() past limit errno = %v, want EFBIG", errno)
+	}
+
+	logs := &FileHandle{fs: filesystem, handle: 2, file: &OpenFile{path: "logs/app.log"}}
+	if _, errno := logs.Write(ctx, []byte("12345"), 0); errno != syscall.EFBIG {
+		t.Errorf("Write() past prefix limit errno = %v, want EFBIG", errno)
+	}
+
+	if len(buffer.writes) != 1 {
+		t.Errorf("buffered %d writes, want only the write within the limit", len(buffer.writes))
+	}
+}
+
+func TestErrnoFor(t *testing.T) {
+	tests := []struct {
+		err  error
+		want syscall.Errno
+	}{
+		{errors.NewError(errors.ErrCodeLimitExceeded, "too large"), syscall.EFBIG},
+		{fmt.Errorf("flush: %w", errors.NewError(errors.ErrCodeLimitExceeded, "too large")), syscall.EFBIG},
+		{errors.NewError(errors.ErrCodeObjectNotFound, "missing"), syscall.ENOENT},
+		{errors.NewError(errors.ErrCodeTierValidation, "minimum storage period"), syscall.EPERM},
+		{errors.NewError(errors.ErrCodeAccessDenied, "denied"), syscall.EACCES},
+		{errors.NewError(errors.ErrCodeAccessDenied, "retained").WithContext("retain_until", "2030-01-01T00:00:00Z"), syscall.EPERM},
+		{fmt.Errorf("boom"), syscall.EIO},
+	}
+	for _, tt := range tests {
+		if got := errnoFor(tt.err); got != tt.want {
+			t.Errorf("errnoFor(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/hanwen/go-fuse/v2/fs"
 	"github.com/hanwen/go-fuse/v2/fuse"
 
+	"github.com/objectfs/objectfs/internal/cache"
 	"github.com/objectfs/objectfs/pkg/types"
 )
 
@@ -56,9 +57,34 @@ type FileSystem struct {
 	// Performance tracking
 	stats *Stats
 
+	// In-flight operations, closed during shutdown
+	ops opGate
+
+	// Concurrent cache misses for the same range share one backend read
+	fetches fetchGroup
+
+	// Object versions cached ranges were verified against
+	versions objectVersions
+
+	// Metadata prefetched while listing directories
+	attrs listedAttrs
+
+	// Paths found to be directories by having objects under them
+	dirs implicitDirs
+
+	// Known paths by case-folded form; nil unless CaseInsensitive
+	folds *caseFolds
+
+	// Attributes and content loaded by PrimeKeys
+	primed primedKeys
+
+	// Bounds content loads started by listings; nil unless in data mode
+	listingLoads chan struct{}
+
 	// Performance optimizations
 	readAhead      *ReadAheadManager
 	writeCoalescer *WriteCoalescer
+	openPrefetch   *openPrefetcher // nil unless enabled
 }
 
 // Config represents FUSE filesystem configuration
@@ -67,6 +93,7 @@ type Config struct {
 	MountPoint string `yaml:"mount_point"`
 	ReadOnly   bool   `yaml:"read_only"`
 	AllowOther bool   `yaml:"allow_other"`
+	NonEmpty   bool   `yaml:"nonempty"`
 
 	// FUSE options
 	DirectIO  bool   `yaml:"direct_io"`
@@ -75,21 +102,90 @@ type Config struct {
 	MaxRead   uint32 `yaml:"max_read"`
 	MaxWrite  uint32 `yaml:"max_write"`
 
+	// Kernel writeback cache and request queue, as in MountOptions
+	WritebackCache      bool `yaml:"writeback_cache"`
+	MaxBackground       int  `yaml:"max_background"`
+	CongestionThreshold int  `yaml:"congestion_threshold"`
+
 	// Filesystem behavior
 	DefaultUID  uint32        `yaml:"default_uid"`
 	DefaultGID  uint32        `yaml:"default_gid"`
 	DefaultMode uint32        `yaml:"default_mode"`
 	CacheTTL    time.Duration `yaml:"cache_ttl"`
 
+	// Translates object metadata to file permissions and ownership; nil
+	// reads objectfs-mode, objectfs-uid, and objectfs-gid metadata and
+	// falls back to the defaults above
+	PermissionMapper PermissionMapper `yaml:"-"`
+
 	// Performance settings
 	ReadAhead   uint32 `yaml:"read_ahead"`
 	WriteBuffer uint32 `yaml:"write_buffer"`
 	Concurrency int    `yaml:"concurrency"`
+
+	// Small sequential write coalescing; nil uses defaults
+	WriteCoalesce *WriteCoalescerConfig `yaml:"write_coalesce"`
+
+	// Closing a file with unwritten data waits until the backend stores it
+	// and reports failures to close. Otherwise close only schedules the
+	// upload, and durability requires an explicit fsync.
+	SyncOnClose bool `yaml:"sync_on_close"`
+
+	// Opening a small file for reading fetches its start into the cache
+	OpenPrefetch OpenPrefetchConfig `yaml:"open_prefetch"`
+
+	// Which zero-byte objects are directory placeholders; unset fields use
+	// the defaults
+	DirectoryMarkers DirectoryMarkers `yaml:"directory_markers"`
+
+	// Writes extending a file past its limit fail with EFBIG
+	MaxObjectSize types.ObjectSizeLimits `yaml:"max_object_size"`
+
+	// Cache-miss reads are widened to blocks of this size so overlapping
+	// concurrent reads share one backend request (default 128KB)
+	FetchAlignment int64 `yaml:"fetch_alignment"`
+
+	// Cache hits are checked against the object's current size and ETag
+	// with a HEAD request. Stale ranges are invalidated and refetched
+	// instead of being served, at the cost of one request per hit.
+	VerifyCachedReads bool `yaml:"verify_cached_reads"`
+
+	// A path with no object but objects under it is a directory. On a
+	// HEAD miss a one-key listing checks for children, and paths found to
+	// be directories are remembered for ImplicitDirTTL (default 1m).
+	// DisableImplicitDirs skips the listing, so such paths are not found.
+	DisableImplicitDirs bool          `yaml:"disable_implicit_dirs"`
+	ImplicitDirTTL      time.Duration `yaml:"implicit_dir_ttl"`
+
+	// Lookups match paths regardless of case, for clients that assume a
+	// case-insensitive filesystem, and creating a path that differs from an
+	// existing one only in case fails with EEXIST. Each directory is listed
+	// into a case-fold index on the first lookup that misses it, and again
+	// after ImplicitDirTTL, so misses cost a LIST request and every indexed
+	// path is held in memory.
+	CaseInsensitive bool `yaml:"case_insensitive"`
+
+	// Files read once from start to end, whose reads bypass the cache
+	Streaming StreamingConfig `yaml:"streaming"`
+
+	// Keys PrimeKeys loads at once (default 8). Primed attributes answer
+	// lookups for CacheTTL (default 5m) or until the path changes.
+	PrimeConcurrency int `yaml:"prime_concurrency"`
+
+	// What listing a directory loads for its files; content loaded for
+	// small files answers reads for CacheTTL (default 5m)
+	ListingPrefetch ListingPrefetchConfig `yaml:"listing_prefetch"`
+
+	// Behavior while Availability reports the backend cannot serve reads
+	// or accept writes. Without Availability the backend is always tried.
+	Degradation  DegradationPolicy   `yaml:"degradation"`
+	Availability BackendAvailability `yaml:"-"`
 }
 
 // OpenFile represents an open file handle
 type OpenFile struct {
 	path     string
+	etag     string
 	flags    uint32
 	mode     uint32
 	size     int64
@@ -124,6 +220,44 @@ type Stats struct {
 	// Error counts
 	Errors int64 `json:"errors"`
 
+	// Write coalescing
+	CoalescedWrites  int64 `json:"coalesced_writes"`
+	CoalescedFlushes int64 `json:"coalesced_flushes"`
+
+	// Reads served by another reader's in-flight backend request
+	DedupedReads int64 `json:"deduped_reads"`
+
+	// Cache hits found stale by read verification and refetched
+	StaleRefetches int64 `json:"stale_refetches"`
+
+	// Reads served from the cache while the backend was unavailable
+	DegradedReads int64 `json:"degraded_reads"`
+
+	// Reads of streaming files, and the bytes they returned without
+	// entering the cache
+	StreamingReads int64 `json:"streaming_reads"`
+	BypassedBytes  int64 `json:"bypassed_bytes"`
+
+	// Files whose attributes or content listings loaded, and the lookups
+	// and reads those answered
+	ListingAttrPrefetches int64 `json:"listing_attr_prefetches"`
+	ListingAttrHits       int64 `json:"listing_attr_hits"`
+	ListingDataPrefetches int64 `json:"listing_data_prefetches"`
+	ListingDataHits       int64 `json:"listing_data_hits"`
+
+	// Keys loaded by PrimeKeys, and keys it failed to load
+	PrimedKeys    int64 `json:"primed_keys"`
+	PrimeFailures int64 `json:"prime_failures"`
+
+	// Prefetches started by opens, and first reads they served
+	OpenPrefetches   int64 `json:"open_prefetches"`
+	OpenPrefetchHits int64 `json:"open_prefetch_hits"`
+
+	// Waits for written data to reach the backend on fsync or sync-on-close
+	Syncs       int64         `json:"syncs"`
+	SyncErrors  int64         `json:"sync_errors"`
+	AvgSyncTime time.Duration `json:"avg_sync_time"`
+
 	// Performance metrics
 	AvgReadTime   time.Duration `json:"avg_read_time"`
 	AvgWriteTime  time.Duration `json:"avg_write_time"`
@@ -157,7 +291,12 @@ func NewFileSystem(backend types.Backend, cache types.Cache, buffer types.WriteB
 
 	// Initialize performance optimizations
 	filesystem.readAhead = NewReadAheadManager(filesystem, nil)
-	filesystem.writeCoalescer = NewWriteCoalescer(filesystem, nil)
+	filesystem.writeCoalescer = NewWriteCoalescer(filesystem, config.WriteCoalesce)
+	filesystem.openPrefetch = newOpenPrefetcher(filesystem, config.OpenPrefetch)
+	filesystem.listingLoads = config.ListingPrefetch.newListingLoads()
+	if config.CaseInsensitive {
+		filesystem.folds = newCaseFolds(config.implicitDirTTL())
+	}
 
 	return filesystem
 }
@@ -173,18 +312,68 @@ func (fs *FileSystem) Root() fs.InodeEmbedder {
 // GetStats returns current filesystem statistics
 func (fs *FileSystem) GetStats() *Stats {
 	fs.stats.mu.RLock()
-	defer fs.stats.mu.RUnlock()
+	stats := &Stats{
+		Lookups:        fs.stats.Lookups,
+		Opens:          fs.stats.Opens,
+		Reads:          fs.stats.Reads,
+		Writes:         fs.stats.Writes,
+		BytesRead:      fs.stats.BytesRead,
+		BytesWritten:   fs.stats.BytesWritten,
+		CacheHits:      fs.stats.CacheHits,
+		CacheMisses:    fs.stats.CacheMisses,
+		Errors:         fs.stats.Errors,
+		DedupedReads:   fs.fetches.dedupedReads(),
+		StaleRefetches: fs.stats.StaleRefetches,
+		DegradedReads:  fs.stats.DegradedReads,
+		StreamingReads: fs.stats.StreamingReads,
+		BypassedBytes:  fs.stats.BypassedBytes,
+		Syncs:          fs.stats.Syncs,
+
+		ListingAttrPrefetches: fs.stats.ListingAttrPrefetches,
+		ListingAttrHits:       fs.stats.ListingAttrHits,
+		ListingDataPrefetches: fs.stats.ListingDataPrefetches,
+		ListingDataHits:       fs.stats.ListingDataHits,
+		SyncErrors:            fs.stats.SyncErrors,
+		AvgSyncTime:           fs.stats.AvgSyncTime,
+	}
+	fs.stats.mu.RUnlock()
+
+	if fs.writeCoalescer != nil {
+		coalescerStats := fs.writeCoalescer.Stats()
+		stats.CoalescedWrites = coalescerStats.CoalescedWrites
+		stats.CoalescedFlushes = coalescerStats.Flushes
+	}
 
-	return &Stats{
-		Lookups:      fs.stats.Lookups,
-		Opens:        fs.stats.Opens,
-		Reads:        fs.stats.Reads,
-		Writes:       fs.stats.Writes,
-		BytesRead:    fs.stats.BytesRead,
-		BytesWritten: fs.stats.BytesWritten,
-		CacheHits:    fs.stats.CacheHits,
-		CacheMisses:  fs.stats.CacheMisses,
-		Errors:       fs.stats.Errors,
+	if fs.openPrefetch != nil {
+		stats.OpenPrefetches, stats.OpenPrefetchHits = fs.openPrefetch.stats()
+	}
+
+	fs.primed.mu.Lock()
+	stats.PrimedKeys, stats.PrimeFailures = fs.primed.primed, fs.primed.failed
+	fs.primed.mu.Unlock()
+
+	return stats
+}
+
+// fetchAlignment returns the block size cache-miss reads are aligned to
+func (fs *FileSystem) fetchAlignment() int64 {
+	if fs.config.FetchAlignment > 0 {
+		return fs.config.FetchAlignment
+	}
+	return defaultFetchAlignment
+}
+
+// objectSizeRecorder is implemented by metrics collectors that export the
+// size distribution of transferred objects
+type objectSizeRecorder interface {
+	RecordObjectSize(operation string, size int64)
+}
+
+// recordObjectSize reports the size of a backend transfer to collectors that
+// track object sizes
+func recordObjectSize(collector types.MetricsCollector, operation string, size int64) {
+	if recorder, ok := collector.(objectSizeRecorder); ok {
+		recorder.RecordObjectSize(operation, size)
 	}
 }
 
@@ -197,6 +386,11 @@ type DirectoryNode struct {
 
 // Lookup looks up a child node by name
 func (n *DirectoryNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if errno := n.fs.beginOp(); errno != 0 {
+		return nil, errno
+	}
+	defer n.fs.endOp()
+
 	start := time.Now()
 	defer func() {
 		n.fs.recordLookupTime(time.Since(start))
@@ -206,7 +400,7 @@ func (n *DirectoryNode) Lookup(ctx context.Context, name string, out *fuse.Entry
 	n.fs.stats.Lookups++
 	n.fs.stats.mu.Unlock()
 
-	childPath := n.joinPath(name)
+	childPath := n.fs.resolveCase(ctx, n.path, n.joinPath(name))
 
 	// Check cache first
 	if cachedInfo := n.fs.getCachedInfo(childPath); cachedInfo != nil {
@@ -214,7 +408,25 @@ func (n *DirectoryNode) Lookup(ctx context.Context, name string, out *fuse.Entry
 		n.fs.stats.CacheHits++
 		n.fs.stats.mu.Unlock()
 
-		return n.createChildNode(name, cachedInfo), 0
+		return n.createChild(name, childPath, cachedInfo, out), 0
+	}
+	if listedInfo := n.fs.attrs.take(childPath, time.Now()); listedInfo != nil {
+		n.fs.stats.mu.Lock()
+		n.fs.stats.CacheHits++
+		n.fs.stats.ListingAttrHits++
+		n.fs.stats.mu.Unlock()
+
+		return n.createChild(name, childPath, listedInfo, out), 0
+	}
+	if primed, ok := n.fs.primed.get(childPath, time.Now()); ok {
+		n.fs.stats.mu.Lock()
+		n.fs.stats.CacheHits++
+		n.fs.stats.mu.Unlock()
+
+		if primed.info == nil {
+			return nil, syscall.ENOENT
+		}
+		return n.createChild(name, childPath, primed.info, out), 0
 	}
 
 	// Query backend
@@ -225,13 +437,12 @@ func (n *DirectoryNode) Lookup(ctx context.Context, name string, out *fuse.Entry
 		n.fs.stats.CacheMisses++
 		n.fs.stats.mu.Unlock()
 
-		// Try as directory by listing
-		objects, listErr := n.fs.backend.ListObjects(ctx, childPath+"/", 1)
-		if listErr != nil || len(objects) == 0 {
+		if !resolveImplicitDir(ctx, n.fs.backend, &n.fs.dirs, n.fs.config, childPath) {
 			return nil, syscall.ENOENT
 		}
 
 		// It's a directory
+		n.fs.fillDirAttr(&out.Attr)
 		return n.createDirectoryNode(name, childPath), 0
 	}
 
@@ -240,18 +451,37 @@ func (n *DirectoryNode) Lookup(ctx context.Context, name string, out *fuse.Entry
 	n.fs.stats.mu.Unlock()
 
 	// Cache the result
+	n.fs.dirs.remove(childPath)
 	n.fs.cacheInfo(childPath, info)
 
-	return n.createChildNode(name, info), 0
+	return n.createChild(name, childPath, info, out), 0
+}
+
+// Open fails for directories, which have no object to read
+func (n *DirectoryNode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	return nil, 0, syscall.EISDIR
 }
 
 // Readdir reads directory contents
 func (n *DirectoryNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	if errno := n.fs.beginOp(); errno != 0 {
+		return nil, errno
+	}
+	defer n.fs.endOp()
+
 	prefix := n.path
 	if prefix != "" && !strings.HasSuffix(prefix, "/") {
 		prefix += "/"
 	}
 
+	if streamer, ok := n.fs.backend.(types.ObjectStreamer); ok {
+		// The request context ends when Readdir returns, so the stream owns
+		// its own context and cancels it on Close
+		streamCtx, cancel := context.WithCancel(context.Background())
+		objects, errs := streamer.ListObjectsChan(streamCtx, prefix)
+		return newStreamDirStream(n.fs, n.path, prefix, objects, errs, cancel), 0
+	}
+
 	objects, err := n.fs.backend.ListObjects(ctx, prefix, 1000) // List up to 1000 objects
 	if err != nil {
 		n.fs.stats.mu.Lock()
@@ -264,8 +494,13 @@ func (n *DirectoryNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 
 	entries := make([]fuse.DirEntry, 0, len(objects))
 	seen := make(map[string]bool)
+	var files []string
 
 	for _, obj := range objects {
+		if n.fs.folds != nil {
+			n.fs.folds.indexEntry(prefix, obj.Key)
+		}
+
 		// Remove prefix to get relative name
 		name := strings.TrimPrefix(obj.Key, prefix)
 
@@ -286,6 +521,16 @@ func (n *DirectoryNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 				Name: name,
 				Mode: fuse.S_IFREG,
 			})
+			files = append(files, obj.Key)
+		}
+	}
+
+	// Fetch the files' metadata in one batch ahead of their lookups, which
+	// also reveals directory placeholders among them
+	n.fs.prefetchAttributes(ctx, files)
+	for i := range entries {
+		if entries[i].Mode == fuse.S_IFREG && n.fs.listedDirectory(prefix+entries[i].Name) {
+			entries[i].Mode = fuse.S_IFDIR
 		}
 	}
 
@@ -294,14 +539,23 @@ func (n *DirectoryNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 
 // Mkdir creates a new directory
 func (n *DirectoryNode) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
-	if n.fs.config.ReadOnly {
-		return nil, syscall.EROFS
+	if errno := n.fs.beginOp(); errno != 0 {
+		return nil, errno
+	}
+	defer n.fs.endOp()
+
+	if errno := n.fs.writeErrno(); errno != 0 {
+		return nil, errno
 	}
 
 	childPath := n.joinPath(name) + "/"
+	if n.fs.caseConflict(ctx, n.path, childPath, "") {
+		return nil, syscall.EEXIST
+	}
+	n.fs.primed.forget(n.joinPath(name))
 
-	// Create an empty object to represent the directory
-	err := n.fs.backend.PutObject(ctx, childPath, []byte{})
+	// Create a marked placeholder object to represent the directory
+	err := putDirectoryMarker(ctx, n.fs.backend, n.fs.config.directoryMarkers(), childPath)
 	if err != nil {
 		n.fs.stats.mu.Lock()
 		n.fs.stats.Errors++
@@ -310,20 +564,40 @@ func (n *DirectoryNode) Mkdir(ctx context.Context, name string, mode uint32, out
 		log.Printf("Mkdir failed for %s: %v", childPath, err)
 		return nil, syscall.EIO
 	}
+	if n.fs.folds != nil {
+		n.fs.folds.add(childPath)
+	}
 
 	return n.createDirectoryNode(name, childPath), 0
 }
 
 // Create creates a new file
 func (n *DirectoryNode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (node *fs.Inode, fh fs.FileHandle, fuseFlags uint32, errno syscall.Errno) {
-	if n.fs.config.ReadOnly {
-		return nil, nil, 0, syscall.EROFS
+	if errno := n.fs.beginOp(); errno != 0 {
+		return nil, nil, 0, errno
 	}
+	defer n.fs.endOp()
 
-	childPath := n.joinPath(name)
+	if errno := n.fs.writeErrno(); errno != 0 {
+		return nil, nil, 0, errno
+	}
 
-	// Create empty file in backend
-	err := n.fs.backend.PutObject(ctx, childPath, []byte{})
+	childPath := n.joinPath(name)
+	if n.fs.caseConflict(ctx, n.path, childPath, "") {
+		return nil, nil, 0, syscall.EEXIST
+	}
+	n.fs.primed.forget(childPath)
+
+	// Create empty file in backend, recording its permissions where the
+	// backend can store metadata
+	metadata := n.fs.creationMetadata(ctx, mode)
+	var err error
+	if writer, ok := n.fs.backend.(types.ObjectMetadataWriter); ok && len(metadata) > 0 {
+		err = writer.PutObjectWithMetadata(ctx, childPath, []byte{}, metadata)
+	} else {
+		metadata = nil
+		err = n.fs.backend.PutObject(ctx, childPath, []byte{})
+	}
 	if err != nil {
 		n.fs.stats.mu.Lock()
 		n.fs.stats.Errors++
@@ -337,11 +611,16 @@ func (n *DirectoryNode) Create(ctx context.Context, name string, flags uint32, m
 	n.fs.stats.Creates++
 	n.fs.stats.mu.Unlock()
 
+	if n.fs.folds != nil {
+		n.fs.folds.add(childPath)
+	}
+
 	// Create object info for new file
 	info := &types.ObjectInfo{
 		Key:          childPath,
 		Size:         0,
 		LastModified: time.Now(),
+		Metadata:     metadata,
 	}
 
 	// Create file node
@@ -356,7 +635,7 @@ func (n *DirectoryNode) Create(ctx context.Context, name string, flags uint32, m
 	})
 
 	// Open the file immediately
-	fh, fuseFlags, errno = fileNode.Open(ctx, flags)
+	fh, fuseFlags, errno = fileNode.open(flags)
 
 	return node, fh, fuseFlags, errno
 }
@@ -371,13 +650,24 @@ type FileNode struct {
 
 // Open opens a file
 func (f *FileNode) Open(ctx context.Context, flags uint32) (fh fs.FileHandle, fuseFlags uint32, errno syscall.Errno) {
+	if errno := f.fs.beginOp(); errno != 0 {
+		return nil, 0, errno
+	}
+	defer f.fs.endOp()
+
+	return f.open(flags)
+}
+
+func (f *FileNode) open(flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
 	f.fs.stats.mu.Lock()
 	f.fs.stats.Opens++
 	f.fs.stats.mu.Unlock()
 
 	// Check if write access on read-only filesystem
-	if f.fs.config.ReadOnly && (flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_CREAT|syscall.O_TRUNC) != 0) {
-		return nil, 0, syscall.EROFS
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_CREAT|syscall.O_TRUNC) != 0 {
+		if errno := f.fs.writeErrno(); errno != 0 {
+			return nil, 0, errno
+		}
 	}
 
 	f.fs.mu.Lock()
@@ -386,6 +676,7 @@ func (f *FileNode) Open(ctx context.Context, flags uint32) (fh fs.FileHandle, fu
 
 	openFile := &OpenFile{
 		path:        f.path,
+		etag:        f.info.ETag,
 		flags:       flags,
 		mode:        0644,
 		size:        f.info.Size,
@@ -396,28 +687,110 @@ func (f *FileNode) Open(ctx context.Context, flags uint32) (fh fs.FileHandle, fu
 	f.fs.openFiles[handle] = openFile
 	f.fs.mu.Unlock()
 
-	return &FileHandle{
+	fh := &FileHandle{
 		fs:     f.fs,
 		handle: handle,
 		file:   openFile,
-	}, 0, 0
+	}
+
+	// Files opened only for reading stream when configured to, and are
+	// never prefetched into the cache
+	if flags&syscall.O_ACCMODE == syscall.O_RDONLY && f.fs.config.Streaming.streams(f.path, f.info.Size) {
+		fh.stream = newStreamRing(f.fs.config.Streaming.RingBlocks)
+		return fh, 0, 0
+	}
+
+	// The first read of a file opened for reading is most likely at offset
+	// 0, so fetch its start before it arrives
+	if f.fs.openPrefetch != nil && readIntent(flags) && !f.fs.readUnavailable() {
+		f.fs.openPrefetch.start(f.path, f.info.Size)
+	}
+
+	return fh, 0, 0
 }
 
 // Getattr gets file attributes
 func (f *FileNode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
-	out.Mode = f.fs.config.DefaultMode
+	if errno := f.fs.beginOp(); errno != 0 {
+		return errno
+	}
+	defer f.fs.endOp()
+
+	f.fillAttr(out)
+	return 0
+}
+
+// touchAttrs are the Setattr fields that only update timestamps
+const touchAttrs = fuse.FATTR_ATIME | fuse.FATTR_MTIME | fuse.FATTR_ATIME_NOW | fuse.FATTR_MTIME_NOW |
+	fuse.FATTR_CTIME | fuse.FATTR_FH | fuse.FATTR_LOCKOWNER
+
+// Setattr updates timestamps so that touch keeps an object alive without
+// re-uploading it. Objects only record a last-modified time, which becomes
+// the time of the touch; other attribute changes are not supported.
+func (f *FileNode) Setattr(ctx context.Context, fh fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	if errno := f.fs.beginOp(); errno != 0 {
+		return errno
+	}
+	defer f.fs.endOp()
+
+	if in.Valid&^uint32(touchAttrs) != 0 {
+		return syscall.ENOTSUP
+	}
+
+	// Access times are not stored, so atime-only updates succeed unchanged
+	if in.Valid&(fuse.FATTR_MTIME|fuse.FATTR_MTIME_NOW) != 0 {
+		if errno := f.fs.writeErrno(); errno != 0 {
+			return errno
+		}
+		if errno := f.touch(ctx); errno != 0 {
+			return errno
+		}
+	}
+
+	f.fillAttr(out)
+	return 0
+}
+
+// touch updates the object's last-modified time in the backend
+func (f *FileNode) touch(ctx context.Context) syscall.Errno {
+	toucher, ok := f.fs.backend.(types.ObjectToucher)
+	if !ok {
+		return syscall.ENOTSUP
+	}
+
+	err := toucher.Touch(ctx, f.path)
+	if err != nil && errnoFor(err) != syscall.ENOENT {
+		f.fs.stats.mu.Lock()
+		f.fs.stats.Errors++
+		f.fs.stats.mu.Unlock()
+
+		log.Printf("Touch failed for %s: %v", f.path, err)
+		return errnoFor(err)
+	}
+
+	// A file whose writes are still buffered has no object yet; it gets a
+	// fresh timestamp when it is uploaded
+	if err == nil {
+		if info, headErr := f.fs.backend.HeadObject(ctx, f.path); headErr == nil {
+			f.info = info
+			return 0
+		}
+	}
+	f.info.LastModified = time.Now()
+	return 0
+}
+
+// fillAttr fills out with the file's attributes
+func (f *FileNode) fillAttr(out *fuse.AttrOut) {
+	out.Mode, out.Uid, out.Gid = f.fs.config.permissions().ToPOSIX(*f.info)
 	// Safely convert int64 to uint64 to prevent integer overflow
 	out.Size = safeInt64ToUint64(f.info.Size)
-	out.Uid = f.fs.config.DefaultUID
-	out.Gid = f.fs.config.DefaultGID
 
 	// Safely convert Unix timestamp to prevent integer overflow
 	unixTime := f.info.LastModified.Unix()
 	out.Mtime = safeInt64ToUint64(unixTime)
 	out.Atime = safeInt64ToUint64(unixTime)
 	out.Ctime = safeInt64ToUint64(unixTime)
-
-	return 0
 }
 
 // FileHandle represents an open file handle
@@ -425,10 +798,16 @@ type FileHandle struct {
 	fs     *FileSystem
 	handle uint64
 	file   *OpenFile
+	stream *streamRing // nil unless reads bypass the cache
 }
 
 // Read reads data from the file
 func (fh *FileHandle) Read(ctx context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	if errno := fh.fs.beginOp(); errno != 0 {
+		return nil, errno
+	}
+	defer fh.fs.endOp()
+
 	start := time.Now()
 	defer func() {
 		fh.fs.recordReadTime(time.Since(start))
@@ -442,8 +821,43 @@ func (fh *FileHandle) Read(ctx context.Context, dest []byte, off int64) (fuse.Re
 	fh.file.lastAccess = time.Now()
 	fh.file.accessCount++
 
+	// Serve what the degradation policy allows while the backend is down
+	if fh.fs.readUnavailable() {
+		data := fh.fs.degradedRead(fh.file.path, off, int64(len(dest)))
+		if data == nil {
+			fh.fs.stats.mu.Lock()
+			fh.fs.stats.Errors++
+			fh.fs.stats.CacheMisses++
+			fh.fs.stats.mu.Unlock()
+			return nil, syscall.EIO
+		}
+
+		fh.fs.stats.mu.Lock()
+		fh.fs.stats.CacheHits++
+		fh.fs.stats.BytesRead += int64(len(data))
+		fh.fs.stats.mu.Unlock()
+		return fuse.ReadResultData(data), 0
+	}
+
 	// Try cache first
-	if cachedData := fh.fs.cache.Get(fh.file.path, off, int64(len(dest))); cachedData != nil {
+	cachedData := fh.fs.cache.Get(fh.file.path, off, int64(len(dest)))
+	if cachedData == nil && fh.stream != nil {
+		data, errno := fh.streamRead(ctx, off, int64(len(dest)))
+		if errno != 0 {
+			return nil, errno
+		}
+		return fuse.ReadResultData(data), 0
+	}
+	if cachedData == nil && fh.fs.openPrefetch != nil {
+		cachedData = fh.fs.openPrefetch.take(ctx, fh.file.path, off, int64(len(dest)))
+	}
+	if cachedData == nil {
+		cachedData = fh.fs.primedRead(fh.file.path, off, int64(len(dest)))
+	}
+	if cachedData != nil {
+		cachedData = fh.fs.verifyCachedRead(ctx, fh.file.path, off, int64(len(dest)), cachedData)
+	}
+	if cachedData != nil {
 		fh.fs.stats.mu.Lock()
 		fh.fs.stats.CacheHits++
 		fh.fs.stats.BytesRead += int64(len(cachedData))
@@ -452,8 +866,19 @@ func (fh *FileHandle) Read(ctx context.Context, dest []byte, off int64) (fuse.Re
 		return fuse.ReadResultData(cachedData), 0
 	}
 
-	// Read from backend
-	data, err := fh.fs.backend.GetObject(ctx, fh.file.path, off, int64(len(dest)))
+	// Read the aligned block from the backend, sharing it with concurrent misses
+	size := int64(len(dest))
+	blockStart, blockSize := alignRange(off, size, fh.fs.fetchAlignment())
+	fetchKey := fmt.Sprintf("%s:%d:%d", fh.file.path, blockStart, blockSize)
+	block, shared, err := fh.fs.fetches.do(ctx, fetchKey, func(fetchCtx context.Context) ([]byte, error) {
+		block, err := fh.fs.backend.GetObject(fetchCtx, fh.file.path, blockStart, blockSize)
+		if err == nil {
+			// Cache the triggering range before waiting readers are released,
+			// recording the ETag the file was opened at for its revalidation
+			cache.PutWithETag(fh.fs.cache, fh.file.path, off, sliceRange(block, blockStart, off, size), fh.file.etag)
+		}
+		return block, err
+	})
 	if err != nil {
 		fh.fs.stats.mu.Lock()
 		fh.fs.stats.Errors++
@@ -464,17 +889,20 @@ func (fh *FileHandle) Read(ctx context.Context, dest []byte, off int64) (fuse.Re
 		return nil, syscall.EIO
 	}
 
+	data := sliceRange(block, blockStart, off, size)
+	if shared {
+		cache.PutWithETag(fh.fs.cache, fh.file.path, off, data, fh.file.etag)
+	}
+
 	fh.fs.stats.mu.Lock()
 	fh.fs.stats.CacheMisses++
 	fh.fs.stats.BytesRead += int64(len(data))
 	fh.fs.stats.mu.Unlock()
 
-	// Cache the data
-	fh.fs.cache.Put(fh.file.path, off, data)
-
 	// Record metrics
-	if fh.fs.metrics != nil {
+	if fh.fs.metrics != nil && !shared {
 		fh.fs.metrics.RecordCacheMiss(fh.file.path, int64(len(data)))
+		recordObjectSize(fh.fs.metrics, "get", int64(len(block)))
 	}
 
 	// Trigger read-ahead analysis
@@ -487,8 +915,20 @@ func (fh *FileHandle) Read(ctx context.Context, dest []byte, off int64) (fuse.Re
 
 // Write writes data to the file
 func (fh *FileHandle) Write(ctx context.Context, data []byte, off int64) (written uint32, errno syscall.Errno) {
-	if fh.fs.config.ReadOnly {
-		return 0, syscall.EROFS
+	if errno := fh.fs.writeErrno(); errno != 0 {
+		return 0, errno
+	}
+	if errno := fh.fs.beginOp(); errno != 0 {
+		return 0, errno
+	}
+	defer fh.fs.endOp()
+
+	// Reject writes past the size limit before anything is buffered
+	if limit := fh.fs.config.MaxObjectSize.Limit(fh.file.path); limit > 0 && off+int64(len(data)) > limit {
+		fh.fs.stats.mu.Lock()
+		fh.fs.stats.Errors++
+		fh.fs.stats.mu.Unlock()
+		return 0, syscall.EFBIG
 	}
 
 	start := time.Now()
@@ -496,6 +936,8 @@ func (fh *FileHandle) Write(ctx context.Context, data []byte, off int64) (writte
 		fh.fs.recordWriteTime(time.Since(start))
 	}()
 
+	fh.fs.primed.forget(fh.file.path)
+
 	fh.fs.stats.mu.Lock()
 	fh.fs.stats.Writes++
 	fh.fs.stats.BytesWritten += int64(len(data))
@@ -508,21 +950,22 @@ func (fh *FileHandle) Write(ctx context.Context, data []byte, off int64) (writte
 
 	// Try write coalescing first
 	coalesced := false
+	var err error
 	if fh.fs.writeCoalescer != nil {
-		coalesced = fh.fs.writeCoalescer.CoalesceWrite(fh.file.path, off, data)
+		coalesced, err = fh.fs.writeCoalescer.CoalesceWrite(fh.handle, fh.file.path, off, data)
 	}
 
-	if !coalesced {
+	if err == nil && !coalesced {
 		// Use write buffer for efficiency
-		err := fh.fs.buffer.Write(fh.file.path, off, data)
-		if err != nil {
-			fh.fs.stats.mu.Lock()
-			fh.fs.stats.Errors++
-			fh.fs.stats.mu.Unlock()
+		err = fh.fs.buffer.Write(fh.file.path, off, data)
+	}
+	if err != nil {
+		fh.fs.stats.mu.Lock()
+		fh.fs.stats.Errors++
+		fh.fs.stats.mu.Unlock()
 
-			log.Printf("Write failed for %s at offset %d: %v", fh.file.path, off, err)
-			return 0, syscall.EIO
-		}
+		log.Printf("Write failed for %s at offset %d: %v", fh.file.path, off, err)
+		return 0, errnoFor(err)
 	}
 
 	// Update file size if we wrote past the end
@@ -534,20 +977,76 @@ func (fh *FileHandle) Write(ctx context.Context, data []byte, off int64) (writte
 	return safeIntToUint32(len(data)), 0
 }
 
-// Flush flushes any pending writes
+// Flush is called on every close of the handle. It schedules pending writes,
+// or with SyncOnClose waits until the backend has stored them.
 func (fh *FileHandle) Flush(ctx context.Context) syscall.Errno {
+	if errno := fh.fs.beginOp(); errno != 0 {
+		return errno
+	}
+	defer fh.fs.endOp()
+
+	if fh.fs.config.SyncOnClose {
+		return fh.sync(ctx, "sync_on_close")
+	}
+	return fh.flush()
+}
+
+// Fsync writes out coalesced and buffered writes and waits until the
+// backend has stored them
+func (fh *FileHandle) Fsync(ctx context.Context, flags uint32) syscall.Errno {
+	if errno := fh.fs.beginOp(); errno != 0 {
+		return errno
+	}
+	defer fh.fs.endOp()
+
+	return fh.sync(ctx, "fsync")
+}
+
+// sync writes out the handle's coalesced range and buffered writes and
+// waits for the backend, recording the wait under operation
+func (fh *FileHandle) sync(ctx context.Context, operation string) syscall.Errno {
 	if !fh.file.dirty {
 		return 0
 	}
 
-	err := fh.fs.buffer.Flush(fh.file.path)
+	start := time.Now()
+	var err error
+	if fh.fs.writeCoalescer != nil {
+		err = fh.fs.writeCoalescer.Flush(fh.handle)
+	}
+	if err == nil {
+		err = syncBuffered(ctx, fh.fs.buffer, fh.file.path)
+	}
+	fh.fs.recordSync(operation, time.Since(start), fh.file.size, err)
+
+	if err != nil {
+		log.Printf("Sync failed for %s: %v", fh.file.path, err)
+		return errnoFor(err)
+	}
+
+	fh.file.dirty = false
+	return 0
+}
+
+func (fh *FileHandle) flush() syscall.Errno {
+	if !fh.file.dirty {
+		return 0
+	}
+
+	var err error
+	if fh.fs.writeCoalescer != nil {
+		err = fh.fs.writeCoalescer.Flush(fh.handle)
+	}
+	if err == nil {
+		err = fh.fs.buffer.Flush(fh.file.path)
+	}
 	if err != nil {
 		fh.fs.stats.mu.Lock()
 		fh.fs.stats.Errors++
 		fh.fs.stats.mu.Unlock()
 
 		log.Printf("Flush failed for %s: %v", fh.file.path, err)
-		return syscall.EIO
+		return errnoFor(err)
 	}
 
 	fh.file.dirty = false
@@ -556,14 +1055,15 @@ func (fh *FileHandle) Flush(ctx context.Context) syscall.Errno {
 
 // Release releases the file handle
 func (fh *FileHandle) Release(ctx context.Context) syscall.Errno {
-	// Flush any coalesced writes first
-	if fh.fs.writeCoalescer != nil {
-		fh.fs.writeCoalescer.FlushAll()
-	}
-
-	// Flush any pending writes
+	// Flush any pending writes, including this handle's coalesced range.
+	// Release is always admitted so handles can be cleaned up during shutdown.
+	// Its result does not reach the closing process, so errors are only logged.
 	if fh.file.dirty {
-		_ = fh.Flush(ctx)
+		if fh.fs.config.SyncOnClose {
+			_ = fh.sync(ctx, "sync_on_close")
+		} else {
+			_ = fh.flush()
+		}
 	}
 
 	// Remove from open files map
@@ -571,6 +1071,10 @@ func (fh *FileHandle) Release(ctx context.Context) syscall.Errno {
 	delete(fh.fs.openFiles, fh.handle)
 	fh.fs.mu.Unlock()
 
+	if fh.fs.openPrefetch != nil {
+		fh.fs.openPrefetch.forget(fh.file.path)
+	}
+
 	return 0
 }
 
@@ -583,9 +1087,17 @@ func (n *DirectoryNode) joinPath(name string) string {
 	return filepath.Join(n.path, name)
 }
 
-func (n *DirectoryNode) createChildNode(name string, info *types.ObjectInfo) *fs.Inode {
-	childPath := n.joinPath(name)
+// createChild returns the node for the existing child object at path, a
+// directory if the object is a placeholder and a file otherwise
+func (n *DirectoryNode) createChild(name, path string, info *types.ObjectInfo, out *fuse.EntryOut) *fs.Inode {
+	if n.fs.config.directoryMarkers().isDirectory(info) {
+		n.fs.fillDirAttr(&out.Attr)
+		return n.createDirectoryNode(name, path)
+	}
+	return n.createChildNode(name, path, info)
+}
 
+func (n *DirectoryNode) createChildNode(name, childPath string, info *types.ObjectInfo) *fs.Inode {
 	fileNode := &FileNode{
 		fs:   n.fs,
 		path: childPath,
@@ -610,6 +1122,24 @@ func (n *DirectoryNode) createDirectoryNode(name, path string) *fs.Inode {
 
 // Helper methods for FileSystem
 
+// fillDirAttr fills out with the attributes of a directory without an object
+func (fs *FileSystem) fillDirAttr(out *fuse.Attr) {
+	out.Mode = implicitDirMode
+	out.Nlink = 2
+	out.Uid = fs.config.DefaultUID
+	out.Gid = fs.config.DefaultGID
+}
+
+// creationMetadata returns the metadata recording the permissions of a file
+// created with mode by the calling process
+func (fs *FileSystem) creationMetadata(ctx context.Context, mode uint32) map[string]string {
+	uid, gid := fs.config.DefaultUID, fs.config.DefaultGID
+	if caller, ok := fuse.FromContext(ctx); ok {
+		uid, gid = caller.Uid, caller.Gid
+	}
+	return fs.config.permissions().FromPOSIX(mode, uid, gid)
+}
+
 func (fs *FileSystem) getCachedInfo(path string) *types.ObjectInfo {
 	// Try to get metadata from cache
 	if fs.cache != nil {
@@ -661,6 +1191,28 @@ func (fs *FileSystem) recordReadTime(duration time.Duration) {
 	}
 }
 
+// recordSync records a wait for written data to reach the backend
+func (fs *FileSystem) recordSync(operation string, duration time.Duration, size int64, err error) {
+	fs.stats.mu.Lock()
+	fs.stats.Syncs++
+	if err != nil {
+		fs.stats.SyncErrors++
+		fs.stats.Errors++
+	}
+	if fs.stats.Syncs == 1 {
+		fs.stats.AvgSyncTime = duration
+	} else {
+		fs.stats.AvgSyncTime = time.Duration(
+			(int64(fs.stats.AvgSyncTime)*9 + int64(duration)) / 10,
+		)
+	}
+	fs.stats.mu.Unlock()
+
+	if fs.metrics != nil {
+		fs.metrics.RecordOperation(operation, duration, size, err == nil)
+	}
+}
+
 func (fs *FileSystem) recordWriteTime(duration time.Duration) {
 	fs.stats.mu.Lock()
 	defer fs.stats.mu.Unlock()
@@ -0,0 +1,95 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall"
+)
+
+// opGate tracks in-flight filesystem operations and rejects new ones once
+// shutdown begins
+type opGate struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	drained  chan struct{}
+}
+
+// enter registers an operation, returning false once the gate is closed
+func (g *opGate) enter() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return false
+	}
+	g.inflight++
+	return true
+}
+
+// exit marks an operation registered by enter as finished
+func (g *opGate) exit() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.inflight--
+	if g.inflight == 0 && g.drained != nil {
+		close(g.drained)
+		g.drained = nil
+	}
+}
+
+// close rejects new operations and waits for in-flight ones to finish
+func (g *opGate) close(ctx context.Context) error {
+	g.mu.Lock()
+	g.closed = true
+	if g.inflight == 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	if g.drained == nil {
+		g.drained = make(chan struct{})
+	}
+	drained := g.drained
+	g.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		inflight := g.inflight
+		g.mu.Unlock()
+		return fmt.Errorf("%d filesystem operations still in flight: %w", inflight, ctx.Err())
+	}
+}
+
+// beginOp admits a filesystem operation, returning ENOTCONN during shutdown.
+// Callers must call endOp when the returned errno is zero.
+func (fs *FileSystem) beginOp() syscall.Errno {
+	if !fs.ops.enter() {
+		return syscall.ENOTCONN
+	}
+	return 0
+}
+
+// endOp finishes an operation admitted by beginOp
+func (fs *FileSystem) endOp() {
+	fs.ops.exit()
+}
+
+// Quiesce stops admitting filesystem operations, waits for in-flight ones
+// to finish, and then hands any coalesced writes to the write buffer. New
+// operations fail with ENOTCONN from then on.
+func (fs *FileSystem) Quiesce(ctx context.Context) error {
+	if err := fs.ops.close(ctx); err != nil {
+		return err
+	}
+	if fs.writeCoalescer != nil {
+		if err := fs.writeCoalescer.FlushAll(); err != nil {
+			return fmt.Errorf("failed to flush coalesced writes: %w", err)
+		}
+	}
+	return nil
+}
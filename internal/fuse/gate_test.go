@@ -0,0 +1,107 @@
+package fuse
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// blockingBuffer holds writes until released
+type blockingBuffer struct {
+	recordingBuffer
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBuffer) Write(key string, offset int64, data []byte) error {
+	b.entered <- struct{}{}
+	<-b.release
+	return b.recordingBuffer.Write(key, offset, data)
+}
+
+func TestQuiesceDrainsInFlightOperations(t *testing.T) {
+	buffer := &blockingBuffer{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	filesystem := NewFileSystem(nil, nil, buffer, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+
+	fh := &FileHandle{fs: filesystem, handle: 1, file: &OpenFile{path: "data.bin"}}
+	ctx := context.Background()
+
+	// Start a write that blocks inside the write buffer
+	writeDone := make(chan syscall.Errno, 1)
+	go func() {
+		_, errno := fh.Write(ctx, []byte("in-flight"), 0)
+		writeDone <- errno
+	}()
+	<-buffer.entered
+
+	quiesced := make(chan error, 1)
+	go func() { quiesced <- filesystem.Quiesce(ctx) }()
+
+	// Quiesce waits for the in-flight write
+	select {
+	case err := <-quiesced:
+		t.Fatalf("Quiesce() returned %v with a write in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// New operations are rejected once shutdown begins
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, errno := fh.Write(ctx, []byte("late"), 100); errno == syscall.ENOTCONN {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Write() admitted during shutdown")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if errno := fh.Fsync(ctx, 0); errno != syscall.ENOTCONN {
+		t.Errorf("Fsync() during shutdown = %v, want ENOTCONN", errno)
+	}
+
+	close(buffer.release)
+	if errno := <-writeDone; errno != 0 {
+		t.Errorf("in-flight Write() = %v", errno)
+	}
+	select {
+	case err := <-quiesced:
+		if err != nil {
+			t.Fatalf("Quiesce() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Quiesce() did not return after operations drained")
+	}
+
+	if writes := buffer.snapshot(); len(writes) != 1 || string(writes[0].data) != "in-flight" {
+		t.Errorf("buffer writes = %+v, want only the in-flight write", writes)
+	}
+
+	// Handles can still be released after shutdown
+	if errno := fh.Release(ctx); errno != 0 {
+		t.Errorf("Release() after Quiesce = %v", errno)
+	}
+}
+
+func TestQuiesceTimesOut(t *testing.T) {
+	buffer := &blockingBuffer{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(buffer.release)
+
+	filesystem := NewFileSystem(nil, nil, buffer, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+
+	fh := &FileHandle{fs: filesystem, handle: 1, file: &OpenFile{path: "data.bin"}}
+	go func() { _, _ = fh.Write(context.Background(), []byte("stuck"), 0) }()
+	<-buffer.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := filesystem.Quiesce(ctx); err == nil {
+		t.Error("Quiesce() should time out with a stuck operation")
+	}
+}
@@ -0,0 +1,89 @@
+package fuse
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// defaultImplicitDirTTL is how long a path found to have children is
+// remembered as a directory when ImplicitDirTTL is unset
+const defaultImplicitDirTTL = time.Minute
+
+// implicitDirMode is the mode reported for directories that exist only as
+// a prefix of other objects
+const implicitDirMode = fuse.S_IFDIR | 0755
+
+// implicitDirs remembers paths that have no object of their own but have
+// objects under them, so repeated lookups of such directories do not each
+// list the bucket
+type implicitDirs struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// contains reports whether path is a directory remembered past now
+func (d *implicitDirs) contains(path string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expires, ok := d.expires[path]
+	if ok && !now.Before(expires) {
+		delete(d.expires, path)
+		return false
+	}
+	return ok
+}
+
+// add remembers path as a directory until expires
+func (d *implicitDirs) add(path string, expires time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.expires == nil {
+		d.expires = make(map[string]time.Time)
+	}
+	d.expires[path] = expires
+}
+
+// remove forgets path, e.g. once an object is written there
+func (d *implicitDirs) remove(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.expires, path)
+}
+
+// implicitDirTTL returns how long a path found to be a directory is remembered
+func (c *Config) implicitDirTTL() time.Duration {
+	if c == nil || c.ImplicitDirTTL <= 0 {
+		return defaultImplicitDirTTL
+	}
+	return c.ImplicitDirTTL
+}
+
+// resolveImplicitDir reports whether key, which has no object, is a
+// directory because objects exist under it. Paths already known to be
+// directories are answered without a request; otherwise a one-key listing
+// of the prefix decides. Paths found to be missing are not remembered, so
+// objects created later by other clients are found.
+func resolveImplicitDir(ctx context.Context, backend types.Backend, dirs *implicitDirs, config *Config, key string) bool {
+	if config != nil && config.DisableImplicitDirs {
+		return false
+	}
+
+	now := time.Now()
+	if dirs.contains(key, now) {
+		return true
+	}
+
+	objects, err := backend.ListObjects(ctx, key+"/", 1)
+	if err != nil || len(objects) == 0 {
+		return false
+	}
+	dirs.add(key, now.Add(config.implicitDirTTL()))
+	return true
+}
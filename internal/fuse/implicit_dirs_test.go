@@ -0,0 +1,129 @@
+package fuse
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// prefixBackend holds objects by key and counts the listings made
+type prefixBackend struct {
+	types.Backend
+	mu      sync.Mutex
+	objects map[string]int64
+	lists   int
+}
+
+func (b *prefixBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	size, ok := b.objects[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	return &types.ObjectInfo{Key: key, Size: size, LastModified: time.Unix(1000, 0)}, nil
+}
+
+func (b *prefixBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lists++
+	var objects []types.ObjectInfo
+	for key, size := range b.objects {
+		if strings.HasPrefix(key, prefix) && len(objects) < limit {
+			objects = append(objects, types.ObjectInfo{Key: key, Size: size})
+		}
+	}
+	return objects, nil
+}
+
+func (b *prefixBackend) listCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lists
+}
+
+// lookupRoot looks name up in the root directory through the FUSE bridge
+func lookupRoot(raw fuse.RawFileSystem, name string) (*fuse.EntryOut, fuse.Status) {
+	out := &fuse.EntryOut{}
+	status := raw.Lookup(nil, &fuse.InHeader{NodeId: fuse.FUSE_ROOT_ID}, name, out)
+	return out, status
+}
+
+func newPrefixFS(t *testing.T, backend types.Backend, config *Config) fuse.RawFileSystem {
+	t.Helper()
+	filesystem := NewFileSystem(backend, nil, &recordingBuffer{}, nil, config)
+	t.Cleanup(filesystem.readAhead.Stop)
+	return fs.NewNodeFS(filesystem.Root(), &fs.Options{})
+}
+
+func TestLookupReportsPrefixAsDirectory(t *testing.T) {
+	backend := &prefixBackend{objects: map[string]int64{"data/part-0.csv": 10, "report.txt": 5}}
+	raw := newPrefixFS(t, backend, &Config{DefaultMode: 0644})
+
+	out, status := lookupRoot(raw, "data")
+	if status != fuse.OK {
+		t.Fatalf("Lookup(data) status = %v, want OK", status)
+	}
+	if out.Attr.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		t.Errorf("Lookup(data) mode = %o, want a directory", out.Attr.Mode)
+	}
+
+	// Reading a directory fails rather than finding no file
+	open := &fuse.OpenIn{InHeader: fuse.InHeader{NodeId: out.NodeId}, Flags: syscall.O_RDONLY}
+	if status := raw.Open(nil, open, &fuse.OpenOut{}); status != fuse.Status(syscall.EISDIR) {
+		t.Errorf("Open(data) status = %v, want EISDIR", status)
+	}
+
+	// Directory-ness is remembered, so later lookups skip the listing
+	lists := backend.listCount()
+	for i := 0; i < 3; i++ {
+		if _, status := lookupRoot(raw, "data"); status != fuse.OK {
+			t.Fatalf("repeated Lookup(data) status = %v", status)
+		}
+	}
+	if got := backend.listCount(); got != lists {
+		t.Errorf("repeated lookups listed %d more times, want none", got-lists)
+	}
+
+	if _, status := lookupRoot(raw, "missing"); status != fuse.ENOENT {
+		t.Errorf("Lookup(missing) status = %v, want ENOENT", status)
+	}
+	if out, status := lookupRoot(raw, "report.txt"); status != fuse.OK || out.Attr.Mode&syscall.S_IFMT != syscall.S_IFREG {
+		t.Errorf("Lookup(report.txt) = %o, %v; want a regular file", out.Attr.Mode, status)
+	}
+}
+
+func TestLookupWithoutImplicitDirs(t *testing.T) {
+	backend := &prefixBackend{objects: map[string]int64{"data/part-0.csv": 10}}
+	raw := newPrefixFS(t, backend, &Config{DisableImplicitDirs: true})
+
+	if _, status := lookupRoot(raw, "data"); status != fuse.ENOENT {
+		t.Errorf("Lookup(data) status = %v, want ENOENT", status)
+	}
+	if backend.listCount() != 0 {
+		t.Errorf("lookup listed %d times, want none", backend.listCount())
+	}
+}
+
+func TestImplicitDirsExpire(t *testing.T) {
+	var dirs implicitDirs
+	now := time.Now()
+	dirs.add("data", now.Add(time.Minute))
+
+	if !dirs.contains("data", now) {
+		t.Error("directory forgotten before its TTL")
+	}
+	if dirs.contains("data", now.Add(time.Minute)) {
+		t.Error("directory remembered past its TTL")
+	}
+}
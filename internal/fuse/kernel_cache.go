@@ -0,0 +1,84 @@
+package fuse
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+)
+
+// defaultLinuxMaxBackground is the background requests the Linux kernel
+// may queue for a mount unless MaxBackground is set. The kernel's own
+// default of 12 throttles buffered writers well before the backend is busy.
+const defaultLinuxMaxBackground = 64
+
+// kernelQueue holds the kernel request-queue settings of a mount
+type kernelQueue struct {
+	writebackCache      bool
+	maxBackground       int // 0 leaves the driver default
+	congestionThreshold int // 0 leaves the driver default
+}
+
+// kernelQueue resolves the writeback cache and background queue settings
+// for goos. Only Linux FUSE negotiates them; other platforms keep their
+// driver defaults. An unset congestion threshold is three quarters of
+// MaxBackground, as the kernel derives it.
+func (o *MountOptions) kernelQueue(goos string) kernelQueue {
+	if goos != "linux" {
+		if o.WritebackCache || o.MaxBackground > 0 || o.CongestionThreshold > 0 {
+			log.Printf("Warning: writeback_cache, max_background and congestion_threshold are not supported on %s; ignoring them", goos)
+		}
+		return kernelQueue{}
+	}
+
+	q := kernelQueue{
+		writebackCache:      o.WritebackCache,
+		maxBackground:       o.MaxBackground,
+		congestionThreshold: o.CongestionThreshold,
+	}
+	if q.maxBackground <= 0 {
+		q.maxBackground = defaultLinuxMaxBackground
+	}
+	if q.congestionThreshold <= 0 || q.congestionThreshold > q.maxBackground {
+		q.congestionThreshold = q.maxBackground * 3 / 4
+	}
+	return q
+}
+
+// libfuseOptions returns the libfuse mount arguments applying the queue
+// settings, which libfuse turns into the FUSE_INIT reply
+func (q kernelQueue) libfuseOptions() []string {
+	var options []string
+	if q.writebackCache {
+		options = append(options, "-o", "writeback_cache")
+	}
+	if q.maxBackground > 0 {
+		options = append(options, "-o", fmt.Sprintf("max_background=%d", q.maxBackground))
+	}
+	if q.congestionThreshold > 0 {
+		options = append(options, "-o", fmt.Sprintf("congestion_threshold=%d", q.congestionThreshold))
+	}
+	return options
+}
+
+// applyKernelQueue sets the queue settings go-fuse cannot pass at mount
+// time on the mounted connection. go-fuse derives the congestion threshold
+// from MaxBackground and never offers the kernel a writeback cache.
+func (m *MountManager) applyKernelQueue(q kernelQueue) {
+	if q.writebackCache {
+		log.Printf("Warning: writeback_cache is not supported by the go-fuse mount layer; build with -tags cgofuse to enable it")
+	}
+	if q.congestionThreshold == 0 || q.congestionThreshold == q.maxBackground*3/4 {
+		return
+	}
+	if err := setCongestionThreshold(m.config.MountPoint, q.congestionThreshold); err != nil {
+		log.Printf("Warning: failed to set congestion_threshold of %s: %v", m.config.MountPoint, err)
+	}
+}
+
+// hostKernelQueue resolves the queue settings of options on this platform
+func hostKernelQueue(options *MountOptions) kernelQueue {
+	if options == nil {
+		return kernelQueue{}
+	}
+	return options.kernelQueue(runtime.GOOS)
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package fuse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// fuseConnectionsDir holds a control directory per FUSE connection, named
+// after the minor device number of its mount
+var fuseConnectionsDir = "/sys/fs/fuse/connections"
+
+// setCongestionThreshold sets the congestion threshold of the FUSE
+// connection mounted at mountPoint. Writing the control file needs root.
+func setCongestionThreshold(mountPoint string, threshold int) error {
+	var st syscall.Stat_t
+	if err := syscall.Stat(mountPoint, &st); err != nil {
+		return fmt.Errorf("failed to stat mount point: %w", err)
+	}
+	// Minor number as encoded by the kernel's new_encode_dev
+	dev := uint64(st.Dev) // uint32 on some architectures
+	minor := (dev & 0xff) | ((dev >> 12) & 0xfff00)
+
+	path := filepath.Join(fuseConnectionsDir, strconv.FormatUint(minor, 10), "congestion_threshold")
+	if err := os.WriteFile(path, []byte(strconv.Itoa(threshold)), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build linux
+
+package fuse
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestSetCongestionThresholdWritesConnectionControl(t *testing.T) {
+	mountPoint := t.TempDir()
+	var st syscall.Stat_t
+	if err := syscall.Stat(mountPoint, &st); err != nil {
+		t.Fatal(err)
+	}
+	dev := uint64(st.Dev)
+	minor := (dev & 0xff) | ((dev >> 12) & 0xfff00)
+
+	connections := t.TempDir()
+	control := filepath.Join(connections, strconv.FormatUint(minor, 10))
+	if err := os.Mkdir(control, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	saved := fuseConnectionsDir
+	fuseConnectionsDir = connections
+	t.Cleanup(func() { fuseConnectionsDir = saved })
+
+	if err := setCongestionThreshold(mountPoint, 40); err != nil {
+		t.Fatalf("setCongestionThreshold() error = %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(control, "congestion_threshold")); err != nil || string(data) != "40" {
+		t.Errorf("congestion_threshold = %q, %v; want 40", data, err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package fuse
+
+import "fmt"
+
+// setCongestionThreshold is not supported on this platform
+func setCongestionThreshold(mountPoint string, threshold int) error {
+	return fmt.Errorf("setting the congestion threshold is not supported on this platform")
+}
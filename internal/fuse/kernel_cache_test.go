@@ -0,0 +1,66 @@
+package fuse
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestKernelQueueDefaultsPerPlatform(t *testing.T) {
+	tests := []struct {
+		name    string
+		options MountOptions
+		goos    string
+		want    kernelQueue
+	}{
+		{"linux defaults", MountOptions{}, "linux", kernelQueue{maxBackground: 64, congestionThreshold: 48}},
+		{"linux tuned", MountOptions{WritebackCache: true, MaxBackground: 128, CongestionThreshold: 100}, "linux", kernelQueue{writebackCache: true, maxBackground: 128, congestionThreshold: 100}},
+		{"threshold above max", MountOptions{MaxBackground: 16, CongestionThreshold: 32}, "linux", kernelQueue{maxBackground: 16, congestionThreshold: 12}},
+		{"darwin keeps driver defaults", MountOptions{WritebackCache: true, MaxBackground: 128}, "darwin", kernelQueue{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.kernelQueue(tt.goos); got != tt.want {
+				t.Errorf("kernelQueue(%s) = %+v, want %+v", tt.goos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritebackCacheSetsLibfuseInitOptions(t *testing.T) {
+	queue := (&MountOptions{WritebackCache: true, MaxBackground: 32}).kernelQueue("linux")
+	want := []string{"-o", "writeback_cache", "-o", "max_background=32", "-o", "congestion_threshold=24"}
+	if got := queue.libfuseOptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("libfuseOptions() = %v, want %v", got, want)
+	}
+
+	queue = (&MountOptions{MaxBackground: 32}).kernelQueue("linux")
+	for _, option := range queue.libfuseOptions() {
+		if option == "writeback_cache" {
+			t.Error("writeback_cache requested while disabled")
+		}
+	}
+}
+
+func TestMountPassesMaxBackground(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the request queue is tuned on Linux only")
+	}
+	filesystem := NewFileSystem(&markerBackend{}, nil, &recordingBuffer{}, nil, &Config{})
+	t.Cleanup(filesystem.readAhead.Stop)
+	manager := NewMountManager(filesystem, &MountConfig{
+		MountPoint: t.TempDir(),
+		Options:    &MountOptions{FSName: "objectfs", MaxBackground: 96},
+	})
+	layer := &fakeMountLayer{}
+	manager.layer = layer
+	if err := manager.Mount(context.Background()); err != nil {
+		t.Fatalf("Mount() error = %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Unmount() })
+
+	if got := layer.opts.MaxBackground; got != 96 {
+		t.Errorf("mounted with MaxBackground %d, want 96", got)
+	}
+}
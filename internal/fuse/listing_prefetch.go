@@ -0,0 +1,106 @@
+package fuse
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// ListingPrefetchMode selects what listing a directory loads for its files
+type ListingPrefetchMode string
+
+// Listing prefetch modes
+const (
+	ListingPrefetchOff      ListingPrefetchMode = "off"      // Listings load nothing ahead
+	ListingPrefetchMetadata ListingPrefetchMode = "metadata" // Attributes, so stats that follow are answered without a HEAD
+	ListingPrefetchData     ListingPrefetchMode = "data"     // Attributes and the content of small files, so reads are cache hits
+)
+
+// Listing prefetch defaults
+const (
+	defaultListingDataSize    = 64 * 1024
+	defaultListingDataFiles   = 32
+	defaultListingConcurrency = 4
+)
+
+// ListingPrefetchConfig controls what a readdir loads for the files it
+// lists, on backends that fetch metadata in batches. Tools that list a
+// directory and then stat or read its files find them already loaded.
+type ListingPrefetchConfig struct {
+	Mode         ListingPrefetchMode `yaml:"mode"`           // "off", "metadata" (default) or "data"
+	MaxDataSize  int64               `yaml:"max_data_size"`  // Files at most this large have content loaded in data mode (default 64KB)
+	MaxDataFiles int                 `yaml:"max_data_files"` // Files whose content one batch of listed files loads (default 32)
+	Concurrency  int                 `yaml:"concurrency"`    // Content loads in flight across listings (default 4)
+}
+
+// attributes reports whether listings prefetch attributes
+func (c ListingPrefetchConfig) attributes() bool {
+	return c.Mode != ListingPrefetchOff
+}
+
+// newListingLoads returns the semaphore bounding content loads, or nil
+// unless data mode is on
+func (c ListingPrefetchConfig) newListingLoads() chan struct{} {
+	if c.Mode != ListingPrefetchData {
+		return nil
+	}
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultListingConcurrency
+	}
+	return make(chan struct{}, concurrency)
+}
+
+// prefetchListedData loads the content of the small files among listed
+// files into the cache in the background, up to MaxDataFiles per batch.
+// Files are skipped once every loader is busy, leaving their reads to
+// fetch on demand.
+func (fs *FileSystem) prefetchListedData(infos map[string]*types.ObjectInfo) {
+	if fs.listingLoads == nil {
+		return
+	}
+	config := fs.config.ListingPrefetch
+	maxSize, maxFiles := config.MaxDataSize, config.MaxDataFiles
+	if maxSize <= 0 {
+		maxSize = defaultListingDataSize
+	}
+	if maxFiles <= 0 {
+		maxFiles = defaultListingDataFiles
+	}
+
+	keys := make([]string, 0, len(infos))
+	for key, info := range infos {
+		if info.Size > 0 && info.Size <= maxSize && !fs.config.directoryMarkers().isDirectory(info) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > maxFiles {
+		keys = keys[:maxFiles]
+	}
+
+	expires := time.Now().Add(fs.config.primeTTL())
+	for _, key := range keys {
+		select {
+		case fs.listingLoads <- struct{}{}:
+		default:
+			return
+		}
+		go func(key string, info *types.ObjectInfo) {
+			defer func() { <-fs.listingLoads }()
+
+			if _, err := fs.primeData(context.Background(), key, info.Size); err != nil {
+				log.Printf("Listing prefetch of %s failed: %v", key, err)
+				return
+			}
+			fs.primed.store(key, primedEntry{info: info, data: true, listed: true, expires: expires})
+
+			fs.stats.mu.Lock()
+			fs.stats.ListingDataPrefetches++
+			fs.stats.mu.Unlock()
+		}(key, infos[key])
+	}
+}
@@ -0,0 +1,120 @@
+package fuse
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// batchContentBackend serves content and answers metadata in batches
+// without counting them as HEAD requests
+type batchContentBackend struct {
+	*contentBackend
+}
+
+func (b *batchContentBackend) HeadObjects(ctx context.Context, keys []string) (map[string]*types.ObjectInfo, map[string]error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	infos := make(map[string]*types.ObjectInfo, len(keys))
+	for _, key := range keys {
+		if data, ok := b.objects[key]; ok {
+			infos[key] = &types.ObjectInfo{Key: key, Size: int64(len(data))}
+		}
+	}
+	return infos, nil
+}
+
+// listRoot reads the root directory through Readdir
+func listRoot(t *testing.T, filesystem *FileSystem) int {
+	t.Helper()
+	stream, errno := filesystem.Root().(*DirectoryNode).Readdir(context.Background())
+	if errno != 0 {
+		t.Fatalf("Readdir() errno = %v", errno)
+	}
+	defer stream.Close()
+
+	listed := 0
+	for stream.HasNext() {
+		if _, errno := stream.Next(); errno != 0 {
+			t.Fatalf("Next() errno = %v", errno)
+		}
+		listed++
+	}
+	return listed
+}
+
+func TestListingPrefetchAnswersStats(t *testing.T) {
+	names := []string{"a.txt", "b.txt", "c.txt"}
+	for _, mode := range []ListingPrefetchMode{ListingPrefetchMetadata, ListingPrefetchOff} {
+		t.Run(string(mode), func(t *testing.T) {
+			content := &contentBackend{objects: map[string][]byte{"a.txt": []byte("alpha"), "b.txt": []byte("bravo"), "c.txt": []byte("charlie")}}
+			filesystem, raw := newContentFS(t, &batchContentBackend{content}, &Config{
+				ListingPrefetch: ListingPrefetchConfig{Mode: mode},
+			})
+
+			if listed := listRoot(t, filesystem); listed != len(names) {
+				t.Fatalf("listed %d entries, want %d", listed, len(names))
+			}
+			content.requests()
+			for _, name := range names {
+				statRoot(t, raw, name)
+			}
+
+			heads, _ := content.requests()
+			stats := filesystem.GetStats()
+			if mode == ListingPrefetchOff {
+				if heads != len(names) || stats.ListingAttrPrefetches != 0 {
+					t.Errorf("with prefetch off, stats made %d HEADs and %d attributes were prefetched; want %d and 0", heads, stats.ListingAttrPrefetches, len(names))
+				}
+				return
+			}
+			if heads != 0 {
+				t.Errorf("stats after a listing made %d HEAD requests, want none", heads)
+			}
+			if stats.ListingAttrPrefetches != 3 || stats.ListingAttrHits != 3 {
+				t.Errorf("listing prefetched %d attributes with %d hits, want 3 and 3", stats.ListingAttrPrefetches, stats.ListingAttrHits)
+			}
+		})
+	}
+}
+
+func TestListingPrefetchLoadsSmallFiles(t *testing.T) {
+	large := strings.Repeat("0123456789", 20)
+	content := &contentBackend{objects: map[string][]byte{"small.txt": []byte("alpha"), "large.txt": []byte(large)}}
+	filesystem, raw := newContentFS(t, &batchContentBackend{content}, &Config{
+		FetchAlignment:  128,
+		ListingPrefetch: ListingPrefetchConfig{Mode: ListingPrefetchData, MaxDataSize: 100},
+	})
+
+	listRoot(t, filesystem)
+	deadline := time.Now().Add(2 * time.Second)
+	for filesystem.GetStats().ListingDataPrefetches < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("small.txt was not loaded after the listing")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	content.requests()
+
+	if got := catRoot(t, raw, "small.txt"); got != "alpha" {
+		t.Errorf("read small.txt = %q, want alpha", got)
+	}
+	if heads, gets := content.requests(); heads != 0 || gets != 0 {
+		t.Errorf("read of a listed small file made %d HEAD and %d GET requests, want none", heads, gets)
+	}
+	if got := readRoot(t, raw, "large.txt", 0, len(large)); got != large {
+		t.Errorf("read large.txt returned %d bytes, want %d", len(got), len(large))
+	}
+	if _, gets := content.requests(); gets == 0 {
+		t.Error("large.txt was loaded by the listing despite MaxDataSize")
+	}
+
+	stats := filesystem.GetStats()
+	if stats.ListingDataPrefetches != 1 || stats.ListingDataHits != 1 {
+		t.Errorf("listing loaded %d files with %d hits, want 1 and 1", stats.ListingDataPrefetches, stats.ListingDataHits)
+	}
+}
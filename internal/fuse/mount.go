@@ -6,12 +6,14 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/hanwen/go-fuse/v2/fs"
 	"github.com/hanwen/go-fuse/v2/fuse"
 	"github.com/objectfs/objectfs/pkg/status"
+	"github.com/objectfs/objectfs/pkg/types"
 )
 
 // FilesystemStats represents filesystem operation statistics
@@ -25,23 +27,79 @@ type FilesystemStats struct {
 	CacheHits    int64 `json:"cache_hits"`
 	CacheMisses  int64 `json:"cache_misses"`
 	Errors       int64 `json:"errors"`
+
+	CoalescedWrites  int64 `json:"coalesced_writes"`
+	CoalescedFlushes int64 `json:"coalesced_flushes"`
+	DedupedReads     int64 `json:"deduped_reads"`
+	OpenPrefetches   int64 `json:"open_prefetches"`
+	OpenPrefetchHits int64 `json:"open_prefetch_hits"`
+	PrimedKeys       int64 `json:"primed_keys"`
+	PrimeFailures    int64 `json:"prime_failures"`
+	StreamingReads   int64 `json:"streaming_reads"`
+	BypassedBytes    int64 `json:"bypassed_bytes"`
+
+	ListingAttrPrefetches int64 `json:"listing_attr_prefetches"`
+	ListingAttrHits       int64 `json:"listing_attr_hits"`
+	ListingDataPrefetches int64 `json:"listing_data_prefetches"`
+	ListingDataHits       int64 `json:"listing_data_hits"`
 }
 
 // MountManager manages FUSE mount operations
 type MountManager struct {
+	mu            sync.RWMutex
 	filesystem    *FileSystem
-	server        *fuse.Server
+	server        mountServer
+	layer         mountLayer
 	config        *MountConfig
 	mounted       bool
 	statusTracker *status.Tracker
 	currentOpID   string
+
+	// Recovers a lost mount; nil unless auto-remount is enabled
+	watcher *MountWatcher
 }
 
 // MountConfig contains mount-specific configuration
 type MountConfig struct {
-	MountPoint  string        `yaml:"mount_point"`
-	Options     *MountOptions `yaml:"options"`
-	Permissions *Permissions  `yaml:"permissions"`
+	MountPoint    string                 `yaml:"mount_point"`
+	Options       *MountOptions          `yaml:"options"`
+	Permissions   *Permissions           `yaml:"permissions"`
+	WriteCoalesce *WriteCoalescerConfig  `yaml:"write_coalesce"`
+	MaxObjectSize types.ObjectSizeLimits `yaml:"max_object_size"`
+	SyncOnClose   bool                   `yaml:"sync_on_close"` // Close waits until written data is stored
+
+	// Opening a small file for reading fetches its start into the cache
+	OpenPrefetch OpenPrefetchConfig `yaml:"open_prefetch"`
+
+	// Which zero-byte objects are directory placeholders
+	DirectoryMarkers DirectoryMarkers `yaml:"directory_markers"`
+
+	// Cache hits are checked against object metadata and refetched when stale
+	VerifyCachedReads bool `yaml:"verify_cached_reads"`
+
+	// Paths with objects under them are directories, remembered for ImplicitDirTTL
+	DisableImplicitDirs bool          `yaml:"disable_implicit_dirs"`
+	ImplicitDirTTL      time.Duration `yaml:"implicit_dir_ttl"`
+
+	// Lookups ignore case and case-only collisions are refused with EEXIST
+	CaseInsensitive bool `yaml:"case_insensitive"`
+
+	// Files whose reads bypass the cache
+	Streaming StreamingConfig `yaml:"streaming"`
+
+	// What listing a directory loads for its files
+	ListingPrefetch ListingPrefetchConfig `yaml:"listing_prefetch"`
+
+	// Translates object metadata to file permissions and ownership; nil
+	// uses DefaultPermissionMapper
+	PermissionMapper PermissionMapper `yaml:"-"`
+
+	// Behavior while the backend is unavailable
+	Degradation  DegradationPolicy   `yaml:"degradation"`
+	Availability BackendAvailability `yaml:"-"`
+
+	// Mounting again when the FUSE connection is lost
+	AutoRemount AutoRemountConfig `yaml:"auto_remount"`
 }
 
 // MountOptions contains FUSE mount options
@@ -51,6 +109,7 @@ type MountOptions struct {
 	AllowOther   bool `yaml:"allow_other"`
 	AllowRoot    bool `yaml:"allow_root"`
 	DefaultPerms bool `yaml:"default_permissions"`
+	NonEmpty     bool `yaml:"nonempty"` // Allow mounting over a non-empty directory
 
 	// Performance options
 	DirectIO  bool   `yaml:"direct_io"`
@@ -66,12 +125,20 @@ type MountOptions struct {
 	AttrTimeout  time.Duration `yaml:"attr_timeout"`
 	EntryTimeout time.Duration `yaml:"entry_timeout"`
 
-	// Kernel options
-	AsyncRead      bool `yaml:"async_read"`
-	WritebackCache bool `yaml:"writeback_cache"`
-	SpliceRead     bool `yaml:"splice_read"`
-	SpliceWrite    bool `yaml:"splice_write"`
-	SpliceMove     bool `yaml:"splice_move"`
+	// Kernel options. WritebackCache lets the kernel page cache absorb
+	// writes and hand them over in large batches, but the kernel then
+	// trusts its cached pages and sizes over the backend: leave it off when
+	// other nodes write the same files. MaxBackground bounds the
+	// asynchronous requests the kernel queues, and past
+	// CongestionThreshold writers are throttled. All three apply on Linux
+	// only; unset queue limits default to 64 and three quarters of it.
+	AsyncRead           bool `yaml:"async_read"`
+	WritebackCache      bool `yaml:"writeback_cache"`
+	MaxBackground       int  `yaml:"max_background"`
+	CongestionThreshold int  `yaml:"congestion_threshold"`
+	SpliceRead          bool `yaml:"splice_read"`
+	SpliceWrite         bool `yaml:"splice_write"`
+	SpliceMove          bool `yaml:"splice_move"`
 }
 
 // Permissions contains permission settings
@@ -105,6 +172,7 @@ func NewMountManager(filesystem *FileSystem, config *MountConfig) *MountManager
 
 	return &MountManager{
 		filesystem:    filesystem,
+		layer:         kernelMountLayer{},
 		config:        config,
 		statusTracker: status.NewTracker(status.DefaultTrackerConfig()),
 	}
@@ -121,7 +189,7 @@ func NewMountManagerWithTracker(filesystem *FileSystem, config *MountConfig, tra
 
 // Mount mounts the filesystem at the specified mount point
 func (m *MountManager) Mount(ctx context.Context) error {
-	if m.mounted {
+	if m.IsMounted() {
 		return fmt.Errorf("filesystem is already mounted")
 	}
 
@@ -157,7 +225,8 @@ func (m *MountManager) Mount(ctx context.Context) error {
 		log.Printf("Warning: failed to set message: %v", err)
 	}
 
-	opts := m.buildFUSEOptions()
+	queue := hostKernelQueue(m.config.Options)
+	opts := m.buildFUSEOptions(queue)
 
 	// Phase 3: Create the FUSE server
 	if err := m.statusTracker.SetPhase(op.ID, "mounting"); err != nil {
@@ -167,7 +236,7 @@ func (m *MountManager) Mount(ctx context.Context) error {
 		log.Printf("Warning: failed to set message: %v", err)
 	}
 
-	server, err := fs.Mount(m.config.MountPoint, m.filesystem.Root(), opts)
+	server, err := m.layer.mount(m.config.MountPoint, m.filesystem.Root(), opts)
 	if err != nil {
 		if trackErr := m.statusTracker.FailOperation(op.ID, fmt.Errorf("failed to mount filesystem: %w", err)); trackErr != nil {
 			log.Printf("Warning: failed to track operation failure: %v", trackErr)
@@ -175,8 +244,11 @@ func (m *MountManager) Mount(ctx context.Context) error {
 		return fmt.Errorf("failed to mount filesystem: %w", err)
 	}
 
+	m.mu.Lock()
 	m.server = server
 	m.mounted = true
+	m.mu.Unlock()
+	m.applyKernelQueue(queue)
 
 	// Phase 4: Complete
 	if err := m.statusTracker.SetPhase(op.ID, "complete"); err != nil {
@@ -197,11 +269,17 @@ func (m *MountManager) Mount(ctx context.Context) error {
 	// Start serving in background
 	go func() {
 		log.Printf("Starting FUSE server...")
-		m.server.Wait()
+		server.Wait()
 		log.Printf("FUSE server stopped")
-		m.mounted = false
+		m.mu.Lock()
+		if m.server == server {
+			m.mounted = false
+		}
+		m.mu.Unlock()
 	}()
 
+	m.startWatcher()
+
 	// Use operation context to ensure proper cancellation
 	_ = opCtx
 
@@ -210,11 +288,16 @@ func (m *MountManager) Mount(ctx context.Context) error {
 
 // Unmount unmounts the filesystem
 func (m *MountManager) Unmount() error {
-	if !m.mounted {
+	m.stopWatcher()
+
+	m.mu.RLock()
+	mounted, server := m.mounted, m.server
+	m.mu.RUnlock()
+	if !mounted {
 		return fmt.Errorf("filesystem is not mounted")
 	}
 
-	if m.server == nil {
+	if server == nil {
 		return fmt.Errorf("no active server to unmount")
 	}
 
@@ -242,7 +325,7 @@ func (m *MountManager) Unmount() error {
 		log.Printf("Warning: failed to set message: %v", err)
 	}
 
-	err := m.server.Unmount()
+	err := server.Unmount()
 	if err != nil {
 		// Try force unmount
 		if err := m.statusTracker.SetPhase(op.ID, "force-unmounting"); err != nil {
@@ -261,8 +344,10 @@ func (m *MountManager) Unmount() error {
 		}
 	}
 
+	m.mu.Lock()
 	m.mounted = false
 	m.server = nil
+	m.mu.Unlock()
 
 	// Complete the operation
 	if err := m.statusTracker.SetMessage(op.ID, "Filesystem unmounted successfully"); err != nil {
@@ -278,8 +363,21 @@ func (m *MountManager) Unmount() error {
 	return nil
 }
 
+// Quiesce rejects new filesystem operations and waits for in-flight ones
+// to finish so pending writes can be flushed before unmounting. A lost
+// mount is no longer remounted from then on.
+func (m *MountManager) Quiesce(ctx context.Context) error {
+	m.stopWatcher()
+	if m.filesystem == nil {
+		return nil
+	}
+	return m.filesystem.Quiesce(ctx)
+}
+
 // IsMount() checks if the filesystem is currently mounted
 func (m *MountManager) IsMounted() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.mounted
 }
 
@@ -290,8 +388,11 @@ func (m *MountManager) GetMountPoint() string {
 
 // Wait waits for the mount to complete
 func (m *MountManager) Wait() {
-	if m.server != nil {
-		m.server.Wait()
+	m.mu.RLock()
+	server := m.server
+	m.mu.RUnlock()
+	if server != nil {
+		server.Wait()
 	}
 }
 
@@ -309,11 +410,43 @@ func (m *MountManager) GetStats() *FilesystemStats {
 			CacheHits:    stats.CacheHits,
 			CacheMisses:  stats.CacheMisses,
 			Errors:       stats.Errors,
+
+			CoalescedWrites:  stats.CoalescedWrites,
+			CoalescedFlushes: stats.CoalescedFlushes,
+			DedupedReads:     stats.DedupedReads,
+			OpenPrefetches:   stats.OpenPrefetches,
+			OpenPrefetchHits: stats.OpenPrefetchHits,
+			PrimedKeys:       stats.PrimedKeys,
+			PrimeFailures:    stats.PrimeFailures,
+			StreamingReads:   stats.StreamingReads,
+			BypassedBytes:    stats.BypassedBytes,
+
+			ListingAttrPrefetches: stats.ListingAttrPrefetches,
+			ListingAttrHits:       stats.ListingAttrHits,
+			ListingDataPrefetches: stats.ListingDataPrefetches,
+			ListingDataHits:       stats.ListingDataHits,
 		}
 	}
 	return &FilesystemStats{}
 }
 
+// PrimeKeys loads the attributes, and in data mode the content, of keys
+// into the mounted filesystem's caches
+func (m *MountManager) PrimeKeys(ctx context.Context, keys []string, mode PrimeMode) error {
+	if m.filesystem == nil {
+		return fmt.Errorf("no filesystem to prime")
+	}
+	return m.filesystem.PrimeKeys(ctx, keys, mode)
+}
+
+// PrimeProgress returns the progress of the latest PrimeKeys call
+func (m *MountManager) PrimeProgress() PrimeProgress {
+	if m.filesystem == nil {
+		return PrimeProgress{}
+	}
+	return m.filesystem.PrimeProgress()
+}
+
 // GetStatusTracker returns the status tracker for monitoring operations
 func (m *MountManager) GetStatusTracker() *status.Tracker {
 	return m.statusTracker
@@ -339,9 +472,9 @@ func (m *MountManager) SubscribeToOperation(opID string) (<-chan status.Operatio
 
 // Remount remounts the filesystem with new options
 func (m *MountManager) Remount(newConfig *MountConfig) error {
-	wasUnmounted := !m.mounted
+	wasUnmounted := !m.IsMounted()
 
-	if m.mounted {
+	if !wasUnmounted {
 		if err := m.Unmount(); err != nil {
 			return fmt.Errorf("failed to unmount for remount: %w", err)
 		}
@@ -399,16 +532,17 @@ func (m *MountManager) validateMountPoint() error {
 	return nil
 }
 
-func (m *MountManager) buildFUSEOptions() *fs.Options {
+func (m *MountManager) buildFUSEOptions(queue kernelQueue) *fs.Options {
 	opts := &fs.Options{
 		// Server options
 		MountOptions: fuse.MountOptions{
-			Name:        m.config.Options.FSName,
-			FsName:      m.config.Options.FSName,
-			DirectMount: true,
-			Debug:       m.config.Options.Debug,
-			AllowOther:  m.config.Options.AllowOther,
-			MaxWrite:    int(m.config.Options.MaxWrite),
+			Name:          m.config.Options.FSName,
+			FsName:        m.config.Options.FSName,
+			DirectMount:   true,
+			Debug:         m.config.Options.Debug,
+			AllowOther:    m.config.Options.AllowOther,
+			MaxWrite:      int(m.config.Options.MaxWrite),
+			MaxBackground: queue.maxBackground,
 		},
 
 		// Attribute caching
@@ -429,6 +563,10 @@ func (m *MountManager) buildFUSEOptions() *fs.Options {
 		opts.Options = append(opts.Options, "allow_root")
 	}
 
+	if m.config.Options.NonEmpty {
+		opts.Options = append(opts.Options, "nonempty")
+	}
+
 	// Add custom options
 	if m.config.Options.FSName != "" {
 		opts.Options = append(opts.Options,
@@ -485,12 +623,18 @@ func indexOf(s, substr string) int {
 	return -1
 }
 
-// MountWatcher watches for mount/unmount events
+// MountWatcher checks that a mount still answers and, when auto-remount is
+// enabled, mounts the filesystem again once it is lost
 type MountWatcher struct {
 	manager  *MountManager
 	interval time.Duration
 	stopCh   chan struct{}
 	stopped  chan struct{}
+	events   chan MountEvent
+
+	mu    sync.Mutex
+	stats MountWatchStats
+	err   error // Why the watcher gave up; set once remounting fails
 }
 
 // NewMountWatcher creates a new mount watcher
@@ -504,6 +648,7 @@ func NewMountWatcher(manager *MountManager, interval time.Duration) *MountWatche
 		interval: interval,
 		stopCh:   make(chan struct{}),
 		stopped:  make(chan struct{}),
+		events:   make(chan MountEvent, mountEventBufferSize),
 	}
 }
 
@@ -529,21 +674,38 @@ func (w *MountWatcher) run() {
 		case <-w.stopCh:
 			return
 		case <-ticker.C:
-			w.checkMount()
+			if !w.checkMount() {
+				return
+			}
 		}
 	}
 }
 
-func (w *MountWatcher) checkMount() {
-	expectedMounted := w.manager.IsMounted()
-	actuallyMounted := !w.manager.isAlreadyMounted()
+// checkMount probes a mount that should be live and recovers it when it
+// is lost. It returns false once the watcher has given up on the mount.
+func (w *MountWatcher) checkMount() bool {
+	w.mu.Lock()
+	w.stats.Checks++
+	w.mu.Unlock()
 
-	if expectedMounted != actuallyMounted {
-		if expectedMounted {
-			log.Printf("Warning: filesystem should be mounted but appears unmounted")
-			// Could trigger remount here
-		} else {
-			log.Printf("Warning: filesystem should be unmounted but appears mounted")
-		}
+	m := w.manager
+	m.mu.RLock()
+	server, mounted := m.server, m.mounted
+	m.mu.RUnlock()
+	if server == nil {
+		return true // Not mounted, or unmounted on purpose
+	}
+
+	err := m.layer.probe(m.config.MountPoint)
+	if err == nil && !mounted {
+		err = fmt.Errorf("FUSE server stopped")
+	}
+	if err == nil {
+		return true
+	}
+	if !m.config.AutoRemount.Enabled {
+		log.Printf("Warning: filesystem should be mounted at %s but is not: %v", m.config.MountPoint, err)
+		return true
 	}
+	return w.recover(err)
 }
@@ -0,0 +1,203 @@
+package fuse
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Open prefetch defaults
+const (
+	defaultOpenPrefetchMaxFileSize = 8 * 1024 * 1024
+	defaultOpenPrefetchSize        = 1024 * 1024
+	defaultOpenPrefetchInFlight    = 4
+	openPrefetchTimeout            = 30 * time.Second
+)
+
+// OpenPrefetchConfig configures fetching the start of a file into the cache
+// when it is opened for reading, so the first read is likely a hit
+type OpenPrefetchConfig struct {
+	Enabled        bool  `yaml:"enabled"`
+	MaxFileSize    int64 `yaml:"max_file_size"`    // Larger files are not prefetched (default 8MB)
+	Size           int64 `yaml:"size"`             // Bytes fetched from the start; smaller files are fetched whole (default 1MB)
+	MaxInFlight    int   `yaml:"max_in_flight"`    // Concurrent prefetches; opens past it are not prefetched (default 4)
+	BytesPerSecond int64 `yaml:"bytes_per_second"` // Prefetch bandwidth; opens past it are not prefetched; 0 is unlimited
+}
+
+// openPrefetch is a prefetch started by an open, consumed by the first read
+type openPrefetch struct {
+	done   chan struct{}
+	size   int64 // Size of the file when opened
+	length int64 // Bytes cached once done; 0 if the fetch failed
+}
+
+// openPrefetcher fetches the start of files opened for reading into the
+// cache. Prefetches are opportunistic: an open that would exceed the
+// in-flight or bandwidth caps is simply not prefetched.
+type openPrefetcher struct {
+	fs     *FileSystem
+	config OpenPrefetchConfig
+	slots  chan struct{}
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*openPrefetch
+	tokens  float64
+	last    time.Time
+
+	started int64
+	hits    int64
+}
+
+func newOpenPrefetcher(fs *FileSystem, config OpenPrefetchConfig) *openPrefetcher {
+	if !config.Enabled {
+		return nil
+	}
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = defaultOpenPrefetchMaxFileSize
+	}
+	if config.Size <= 0 {
+		config.Size = defaultOpenPrefetchSize
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = defaultOpenPrefetchInFlight
+	}
+	p := &openPrefetcher{
+		fs:      fs,
+		config:  config,
+		slots:   make(chan struct{}, config.MaxInFlight),
+		now:     time.Now,
+		pending: make(map[string]*openPrefetch),
+		tokens:  float64(config.BytesPerSecond),
+	}
+	p.last = p.now()
+	return p
+}
+
+// readIntent reports whether open flags allow reading existing content
+func readIntent(flags uint32) bool {
+	return flags&syscall.O_ACCMODE != syscall.O_WRONLY && flags&syscall.O_TRUNC == 0
+}
+
+// start prefetches the first bytes of path, which is size bytes long, in
+// the background unless it is too large, already prefetched, or over a cap
+func (p *openPrefetcher) start(path string, size int64) {
+	if size <= 0 || size > p.config.MaxFileSize {
+		return
+	}
+	length := min(size, p.config.Size)
+
+	p.mu.Lock()
+	if _, ok := p.pending[path]; ok {
+		p.mu.Unlock()
+		return
+	}
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		p.mu.Unlock()
+		return
+	}
+	if !p.takeBandwidth(length) {
+		<-p.slots
+		p.mu.Unlock()
+		return
+	}
+	prefetch := &openPrefetch{done: make(chan struct{}), size: size}
+	p.pending[path] = prefetch
+	p.started++
+	p.mu.Unlock()
+
+	go func() {
+		defer func() { <-p.slots }()
+		defer close(prefetch.done)
+		prefetch.length = p.fetch(path, length)
+	}()
+}
+
+// takeBandwidth charges length bytes to the bandwidth cap, which holds up
+// to one second of transfer, reporting false if it would overdraw it
+func (p *openPrefetcher) takeBandwidth(length int64) bool {
+	rate := float64(p.config.BytesPerSecond)
+	if rate <= 0 {
+		return true
+	}
+	now := p.now()
+	p.tokens = min(rate, p.tokens+now.Sub(p.last).Seconds()*rate)
+	p.last = now
+	if p.tokens < min(float64(length), rate) {
+		return false
+	}
+	p.tokens -= float64(length)
+	return true
+}
+
+// fetch caches the first length bytes of path, returning how many it cached
+func (p *openPrefetcher) fetch(path string, length int64) int64 {
+	if cached := p.fs.cache.Get(path, 0, length); cached != nil {
+		return int64(len(cached))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), openPrefetchTimeout)
+	defer cancel()
+	start := time.Now()
+	data, err := p.fs.backend.GetObject(ctx, path, 0, length)
+	if err != nil || len(data) == 0 {
+		return 0
+	}
+	p.fs.cache.Put(path, 0, data)
+
+	if p.fs.metrics != nil {
+		p.fs.metrics.RecordOperation("open_prefetch", time.Since(start), int64(len(data)), true)
+	}
+	return int64(len(data))
+}
+
+// take serves the first read of path after it was opened from its
+// prefetch, waiting for a prefetch still in flight. It returns nil when
+// there was no prefetch or it does not cover the range.
+func (p *openPrefetcher) take(ctx context.Context, path string, offset, size int64) []byte {
+	p.mu.Lock()
+	prefetch, ok := p.pending[path]
+	delete(p.pending, path)
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	select {
+	case <-prefetch.done:
+	case <-ctx.Done():
+		return nil
+	}
+	// A range running past the prefetched bytes is only served short when
+	// they are the whole file, since a short read means end of file
+	if offset >= prefetch.length || (offset+size > prefetch.length && prefetch.length < prefetch.size) {
+		return nil
+	}
+	data := p.fs.cache.Get(path, 0, prefetch.length)
+	if int64(len(data)) != prefetch.length {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.hits++
+	p.mu.Unlock()
+	return data[offset:min(offset+size, prefetch.length)]
+}
+
+// forget drops an unread prefetch of path once its file is closed
+func (p *openPrefetcher) forget(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, path)
+}
+
+// stats returns how many prefetches were started and how many served the
+// first read
+func (p *openPrefetcher) stats() (started, hits int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.started, p.hits
+}
@@ -0,0 +1,93 @@
+package fuse
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+func newOpenPrefetchFS(t *testing.T, backend types.Backend, config OpenPrefetchConfig) (*FileSystem, *cache.LRUCache) {
+	t.Helper()
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100})
+	t.Cleanup(func() { _ = lru.Close() })
+
+	config.Enabled = true
+	filesystem := NewFileSystem(backend, lru, &recordingBuffer{}, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+		OpenPrefetch:  config,
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+	return filesystem, lru
+}
+
+func openFile(t *testing.T, filesystem *FileSystem, path string, size int64, flags uint32) *FileHandle {
+	t.Helper()
+	node := &FileNode{fs: filesystem, path: path, info: &types.ObjectInfo{Key: path, Size: size}}
+	fh, _, errno := node.Open(context.Background(), flags)
+	if errno != 0 {
+		t.Fatalf("Open(%s) errno = %v", path, errno)
+	}
+	return fh.(*FileHandle)
+}
+
+func TestOpenPrefetchCachesSmallFileBeforeFirstRead(t *testing.T) {
+	backend := &mutableBackend{}
+	backend.set([]byte("hello, world"), "")
+	filesystem, lru := newOpenPrefetchFS(t, backend, OpenPrefetchConfig{})
+
+	fh := openFile(t, filesystem, "docs/small.txt", 12, syscall.O_RDONLY)
+
+	// The open alone fetches the cold file into the cache
+	deadline := time.Now().Add(2 * time.Second)
+	for lru.Get("docs/small.txt", 0, 12) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("opening the file did not populate the cache")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := readRange(t, fh, 4096); string(got) != "hello, world" {
+		t.Fatalf("first read = %q, want the whole file", got)
+	}
+	backend.mu.Lock()
+	gets := backend.gets
+	backend.mu.Unlock()
+	if gets != 1 {
+		t.Errorf("backend served %d reads, want only the prefetch", gets)
+	}
+
+	stats := filesystem.GetStats()
+	if stats.OpenPrefetches != 1 || stats.OpenPrefetchHits != 1 {
+		t.Errorf("open prefetches = %d, hits = %d; want 1 and 1", stats.OpenPrefetches, stats.OpenPrefetchHits)
+	}
+	if stats.CacheHits != 1 {
+		t.Errorf("CacheHits = %d, want the first read counted as a hit", stats.CacheHits)
+	}
+}
+
+func TestOpenPrefetchSkipsLargeFilesWritesAndCappedOpens(t *testing.T) {
+	backend := &mutableBackend{}
+	backend.set([]byte("hello, world"), "")
+	filesystem, _ := newOpenPrefetchFS(t, backend, OpenPrefetchConfig{
+		MaxFileSize:    100,
+		BytesPerSecond: 16,
+	})
+
+	openFile(t, filesystem, "docs/large.bin", 101, syscall.O_RDONLY)
+	openFile(t, filesystem, "docs/out.txt", 12, syscall.O_WRONLY)
+	openFile(t, filesystem, "docs/trunc.txt", 12, syscall.O_RDWR|syscall.O_TRUNC)
+	if started, _ := filesystem.openPrefetch.stats(); started != 0 {
+		t.Fatalf("started %d prefetches for large files and writes, want 0", started)
+	}
+
+	// Sixteen bytes per second covers one twelve-byte file, not two
+	openFile(t, filesystem, "docs/a.txt", 12, syscall.O_RDONLY)
+	openFile(t, filesystem, "docs/b.txt", 12, syscall.O_RDONLY)
+	if started, _ := filesystem.openPrefetch.stats(); started != 1 {
+		t.Errorf("started %d prefetches within the bandwidth cap, want 1", started)
+	}
+}
@@ -2,6 +2,8 @@ package fuse
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"sync"
 	"time"
 )
@@ -202,205 +204,176 @@ func (ram *ReadAheadManager) Stop() {
 	close(ram.stopCh)
 }
 
-// WriteCoalescer optimizes write operations by coalescing small writes
+// WriteCoalescer accumulates contiguous small writes per open file handle
+// and flushes them to the write buffer as a single range once the time or
+// size window is exceeded, the handle is synced or closed, or a write breaks
+// the sequence
 type WriteCoalescer struct {
-	mu            sync.RWMutex
-	pendingWrites map[string]*CoalescedWrite
-	fs            *FileSystem
-	config        *WriteCoalescerConfig
+	mu      sync.Mutex
+	pending map[uint64]*CoalescedWrite
+	fs      *FileSystem
+	config  *WriteCoalescerConfig
+
+	coalescedWrites int64
+	flushes         int64
+	flushErrors     int64
 }
 
 // WriteCoalescerConfig configures write coalescing behavior
 type WriteCoalescerConfig struct {
-	Enabled    bool          `yaml:"enabled"`
-	WindowSize int64         `yaml:"window_size"` // Size window for coalescing
-	MaxDelay   time.Duration `yaml:"max_delay"`   // Maximum delay before forced flush
-	MinWrites  int           `yaml:"min_writes"`  // Minimum writes to trigger coalescing
-	BufferSize int64         `yaml:"buffer_size"` // Maximum buffer size per file
+	Enabled      bool          `yaml:"enabled"`
+	MaxDelay     time.Duration `yaml:"max_delay"`      // Time window before a pending range is flushed
+	BufferSize   int64         `yaml:"buffer_size"`    // Pending bytes per handle that force a flush
+	MaxWriteSize int64         `yaml:"max_write_size"` // Writes larger than this bypass coalescing
+}
+
+// WriteCoalescerStats reports write coalescing activity
+type WriteCoalescerStats struct {
+	CoalescedWrites int64 `json:"coalesced_writes"`
+	Flushes         int64 `json:"flushes"`
+	FlushErrors     int64 `json:"flush_errors"`
+	Pending         int   `json:"pending"`
 }
 
-// CoalescedWrite represents a coalesced write operation
+// CoalescedWrite is a contiguous range of writes pending for one handle
 type CoalescedWrite struct {
 	path      string
-	writes    []WriteOp
-	totalSize int64
+	offset    int64
+	data      []byte
+	writes    int
 	firstTime time.Time
-	lastTime  time.Time
-}
-
-// WriteOp represents a single write operation
-type WriteOp struct {
-	offset int64
-	data   []byte
-	time   time.Time
+	timer     *time.Timer
 }
 
 // NewWriteCoalescer creates a new write coalescer
 func NewWriteCoalescer(fs *FileSystem, config *WriteCoalescerConfig) *WriteCoalescer {
 	if config == nil {
 		config = &WriteCoalescerConfig{
-			Enabled:    true,
-			WindowSize: 64 * 1024, // 64KB
-			MaxDelay:   100 * time.Millisecond,
-			MinWrites:  3,
-			BufferSize: 1024 * 1024, // 1MB
+			Enabled:      true,
+			MaxDelay:     100 * time.Millisecond,
+			BufferSize:   1024 * 1024, // 1MB
+			MaxWriteSize: 64 * 1024,   // 64KB
 		}
 	}
 
 	return &WriteCoalescer{
-		pendingWrites: make(map[string]*CoalescedWrite),
-		fs:            fs,
-		config:        config,
+		pending: make(map[uint64]*CoalescedWrite),
+		fs:      fs,
+		config:  config,
 	}
 }
 
-// CoalesceWrite attempts to coalesce a write operation
-func (wc *WriteCoalescer) CoalesceWrite(path string, offset int64, data []byte) bool {
-	if !wc.config.Enabled {
-		return false
-	}
-
+// CoalesceWrite buffers a write for the handle. It returns false when the
+// write was not coalesced and must be written directly; any range pending
+// for the handle has been flushed first so ordering is preserved.
+func (wc *WriteCoalescer) CoalesceWrite(handle uint64, path string, offset int64, data []byte) (bool, error) {
 	wc.mu.Lock()
 	defer wc.mu.Unlock()
 
-	cw, exists := wc.pendingWrites[path]
-	if !exists {
-		// Start new coalesced write
-		cw = &CoalescedWrite{
-			path:      path,
-			writes:    make([]WriteOp, 0, 10),
-			firstTime: time.Now(),
-		}
-		wc.pendingWrites[path] = cw
-	}
+	cw := wc.pending[handle]
 
-	// Check if this write can be coalesced
-	if wc.canCoalesce(cw, offset, int64(len(data))) {
-		cw.writes = append(cw.writes, WriteOp{
-			offset: offset,
-			data:   append([]byte(nil), data...), // Copy data
-			time:   time.Now(),
-		})
-		cw.totalSize += int64(len(data))
-		cw.lastTime = time.Now()
-
-		// Check if we should flush now
-		if wc.shouldFlush(cw) {
-			wc.flushCoalescedWrite(cw)
-			delete(wc.pendingWrites, path)
+	if !wc.config.Enabled || len(data) == 0 ||
+		(wc.config.MaxWriteSize > 0 && int64(len(data)) > wc.config.MaxWriteSize) {
+		if cw != nil {
+			return false, wc.flushLocked(handle, cw)
 		}
-
-		return true
+		return false, nil
 	}
 
-	return false
-}
-
-// canCoalesce checks if a write can be coalesced with existing writes
-func (wc *WriteCoalescer) canCoalesce(cw *CoalescedWrite, offset, size int64) bool {
-	// Check buffer size limit
-	if cw.totalSize+size > wc.config.BufferSize {
-		return false
-	}
-
-	// Check time limit
-	if time.Since(cw.firstTime) > wc.config.MaxDelay {
-		return false
+	// Only contiguous appends to the same path extend a pending range
+	if cw != nil && (cw.path != path || offset != cw.offset+int64(len(cw.data))) {
+		if err := wc.flushLocked(handle, cw); err != nil {
+			return false, err
+		}
+		cw = nil
 	}
 
-	// Check if writes are within window
-	if len(cw.writes) > 0 {
-		lastWrite := cw.writes[len(cw.writes)-1]
-		distance := offset - (lastWrite.offset + int64(len(lastWrite.data)))
-		if distance > wc.config.WindowSize {
-			return false
+	if cw == nil {
+		cw = &CoalescedWrite{
+			path:      path,
+			offset:    offset,
+			data:      make([]byte, 0, len(data)),
+			firstTime: time.Now(),
 		}
+		if wc.config.MaxDelay > 0 {
+			cw.timer = time.AfterFunc(wc.config.MaxDelay, func() { wc.flushExpired(handle, cw) })
+		}
+		wc.pending[handle] = cw
 	}
 
-	return true
-}
+	cw.data = append(cw.data, data...)
+	cw.writes++
+	wc.coalescedWrites++
 
-// shouldFlush determines if coalesced writes should be flushed
-func (wc *WriteCoalescer) shouldFlush(cw *CoalescedWrite) bool {
-	// Check minimum writes threshold
-	if len(cw.writes) >= wc.config.MinWrites {
-		return true
+	if wc.config.BufferSize > 0 && int64(len(cw.data)) >= wc.config.BufferSize {
+		return true, wc.flushLocked(handle, cw)
 	}
+	return true, nil
+}
 
-	// Check time limit
-	if time.Since(cw.firstTime) >= wc.config.MaxDelay {
-		return true
-	}
+// Flush writes out any range pending for the handle
+func (wc *WriteCoalescer) Flush(handle uint64) error {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
 
-	// Check buffer size limit
-	if cw.totalSize >= wc.config.BufferSize {
-		return true
+	if cw, exists := wc.pending[handle]; exists {
+		return wc.flushLocked(handle, cw)
 	}
-
-	return false
+	return nil
 }
 
-// flushCoalescedWrite flushes a coalesced write to the buffer
-func (wc *WriteCoalescer) flushCoalescedWrite(cw *CoalescedWrite) {
-	// Sort writes by offset to ensure proper ordering
-	for i := 0; i < len(cw.writes)-1; i++ {
-		for j := i + 1; j < len(cw.writes); j++ {
-			if cw.writes[i].offset > cw.writes[j].offset {
-				cw.writes[i], cw.writes[j] = cw.writes[j], cw.writes[i]
-			}
+// FlushAll flushes all pending coalesced writes
+func (wc *WriteCoalescer) FlushAll() error {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	var firstErr error
+	for handle, cw := range wc.pending {
+		if err := wc.flushLocked(handle, cw); err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
+	return firstErr
+}
 
-	// Merge overlapping/adjacent writes
-	merged := wc.mergeWrites(cw.writes)
+// Stats returns write coalescing statistics
+func (wc *WriteCoalescer) Stats() WriteCoalescerStats {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
 
-	// Write to buffer
-	for _, write := range merged {
-		_ = wc.fs.buffer.Write(cw.path, write.offset, write.data)
+	return WriteCoalescerStats{
+		CoalescedWrites: wc.coalescedWrites,
+		Flushes:         wc.flushes,
+		FlushErrors:     wc.flushErrors,
+		Pending:         len(wc.pending),
 	}
 }
 
-// mergeWrites merges overlapping and adjacent writes
-func (wc *WriteCoalescer) mergeWrites(writes []WriteOp) []WriteOp {
-	if len(writes) <= 1 {
-		return writes
-	}
+// flushExpired flushes a range whose time window elapsed, unless it was
+// already flushed or replaced
+func (wc *WriteCoalescer) flushExpired(handle uint64, cw *CoalescedWrite) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
 
-	merged := make([]WriteOp, 0, len(writes))
-	current := writes[0]
-
-	for i := 1; i < len(writes); i++ {
-		next := writes[i]
-		currentEnd := current.offset + int64(len(current.data))
-
-		if next.offset <= currentEnd {
-			// Overlapping or adjacent, merge
-			newEnd := next.offset + int64(len(next.data))
-			if newEnd > currentEnd {
-				// Extend current write
-				newData := make([]byte, newEnd-current.offset)
-				copy(newData, current.data)
-				copy(newData[next.offset-current.offset:], next.data)
-				current.data = newData
-			}
-		} else {
-			// Not adjacent, add current and start new
-			merged = append(merged, current)
-			current = next
-		}
+	if wc.pending[handle] != cw {
+		return
+	}
+	if err := wc.flushLocked(handle, cw); err != nil {
+		log.Printf("Coalesced write flush failed for %s at offset %d: %v", cw.path, cw.offset, err)
 	}
-
-	merged = append(merged, current)
-	return merged
 }
 
-// FlushAll flushes all pending coalesced writes
-func (wc *WriteCoalescer) FlushAll() {
-	wc.mu.Lock()
-	defer wc.mu.Unlock()
+// flushLocked writes a pending range to the write buffer; wc.mu must be held
+func (wc *WriteCoalescer) flushLocked(handle uint64, cw *CoalescedWrite) error {
+	if cw.timer != nil {
+		cw.timer.Stop()
+	}
+	delete(wc.pending, handle)
 
-	for path, cw := range wc.pendingWrites {
-		wc.flushCoalescedWrite(cw)
-		delete(wc.pendingWrites, path)
+	wc.flushes++
+	if err := wc.fs.buffer.Write(cw.path, cw.offset, cw.data); err != nil {
+		wc.flushErrors++
+		return fmt.Errorf("failed to flush %d coalesced writes: %w", cw.writes, err)
 	}
+	return nil
 }
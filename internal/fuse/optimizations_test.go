@@ -0,0 +1,169 @@
+package fuse
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingBuffer is a types.WriteBuffer that records every range written
+type recordingBuffer struct {
+	mu      sync.Mutex
+	writes  []bufferedWrite
+	flushed []string
+}
+
+type bufferedWrite struct {
+	key    string
+	offset int64
+	data   []byte
+}
+
+func (b *recordingBuffer) Write(key string, offset int64, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writes = append(b.writes, bufferedWrite{key: key, offset: offset, data: append([]byte(nil), data...)})
+	return nil
+}
+
+func (b *recordingBuffer) Flush(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushed = append(b.flushed, key)
+	return nil
+}
+
+func (b *recordingBuffer) FlushAll() error { return nil }
+func (b *recordingBuffer) Size() int64     { return 0 }
+func (b *recordingBuffer) Count() int      { return 0 }
+
+func (b *recordingBuffer) snapshot() []bufferedWrite {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]bufferedWrite(nil), b.writes...)
+}
+
+func newCoalescingHandle(t *testing.T, window time.Duration) (*FileHandle, *recordingBuffer) {
+	t.Helper()
+
+	buffer := &recordingBuffer{}
+	filesystem := NewFileSystem(nil, nil, buffer, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{
+			Enabled:      true,
+			MaxDelay:     window,
+			BufferSize:   1024 * 1024,
+			MaxWriteSize: 64 * 1024,
+		},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+
+	return &FileHandle{
+		fs:     filesystem,
+		handle: 1,
+		file:   &OpenFile{path: "logs/app.log"},
+	}, buffer
+}
+
+// appendLines issues count sequential 10-byte writes starting at offset
+func appendLines(t *testing.T, fh *FileHandle, offset int64, count int) int64 {
+	t.Helper()
+
+	line := []byte("log line.\n")
+	for i := 0; i < count; i++ {
+		if n, errno := fh.Write(context.Background(), line, offset); errno != 0 || int(n) != len(line) {
+			t.Fatalf("Write(%d) = %d, %v", offset, n, errno)
+		}
+		offset += int64(len(line))
+	}
+	return offset
+}
+
+func TestWriteCoalescer_SingleFlushWithinWindow(t *testing.T) {
+	fh, buffer := newCoalescingHandle(t, 500*time.Millisecond)
+
+	appendLines(t, fh, 0, 1000)
+	if writes := buffer.snapshot(); len(writes) != 0 {
+		t.Fatalf("flushed %d ranges before the window elapsed", len(writes))
+	}
+
+	// The time window expiring flushes everything as one range
+	deadline := time.Now().Add(5 * time.Second)
+	for fh.fs.writeCoalescer.Stats().Flushes == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writes := buffer.snapshot()
+	if len(writes) != 1 {
+		t.Fatalf("buffer received %d writes, want 1", len(writes))
+	}
+	if writes[0].offset != 0 || len(writes[0].data) != 10000 || !bytes.HasPrefix(writes[0].data, []byte("log line.\nlog line.\n")) {
+		t.Errorf("flushed range = offset %d, %d bytes", writes[0].offset, len(writes[0].data))
+	}
+
+	stats := fh.fs.GetStats()
+	if stats.Writes != 1000 || stats.CoalescedWrites != 1000 || stats.CoalescedFlushes != 1 {
+		t.Errorf("GetStats() = writes %d, coalesced %d, flushes %d", stats.Writes, stats.CoalescedWrites, stats.CoalescedFlushes)
+	}
+}
+
+func TestWriteCoalescer_FsyncForcesFlush(t *testing.T) {
+	fh, buffer := newCoalescingHandle(t, time.Minute)
+
+	offset := appendLines(t, fh, 0, 500)
+	if errno := fh.Fsync(context.Background(), 0); errno != 0 {
+		t.Fatalf("Fsync() = %v", errno)
+	}
+
+	writes := buffer.snapshot()
+	if len(writes) != 1 || writes[0].offset != 0 || len(writes[0].data) != 5000 {
+		t.Fatalf("after fsync buffer received %d writes, want one 5000-byte range", len(writes))
+	}
+	if len(buffer.flushed) != 1 {
+		t.Errorf("Fsync() flushed write buffer %d times, want 1", len(buffer.flushed))
+	}
+
+	appendLines(t, fh, offset, 500)
+	if errno := fh.Release(context.Background()); errno != 0 {
+		t.Fatalf("Release() = %v", errno)
+	}
+
+	writes = buffer.snapshot()
+	if len(writes) != 2 || writes[1].offset != 5000 || len(writes[1].data) != 5000 {
+		t.Fatalf("after release buffer received %d writes, want second 5000-byte range at 5000", len(writes))
+	}
+	if stats := fh.fs.writeCoalescer.Stats(); stats.Flushes != 2 || stats.CoalescedWrites != 1000 || stats.Pending != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestWriteCoalescer_PreservesOrdering(t *testing.T) {
+	fh, buffer := newCoalescingHandle(t, time.Minute)
+	ctx := context.Background()
+
+	_, _ = fh.Write(ctx, []byte("aaaa"), 0)
+	_, _ = fh.Write(ctx, []byte("bbbb"), 4)
+
+	// A non-contiguous write starts a new range after flushing the old one
+	_, _ = fh.Write(ctx, []byte("cccc"), 100)
+
+	// A large write bypasses coalescing but lands after the pending range
+	large := bytes.Repeat([]byte("d"), 128*1024)
+	_, _ = fh.Write(ctx, large, 104)
+
+	writes := buffer.snapshot()
+	want := []struct {
+		offset int64
+		size   int
+	}{{0, 8}, {100, 4}, {104, len(large)}}
+	if len(writes) != len(want) {
+		t.Fatalf("buffer received %d writes, want %d", len(writes), len(want))
+	}
+	for i, w := range want {
+		if writes[i].offset != w.offset || len(writes[i].data) != w.size {
+			t.Errorf("write %d = offset %d size %d, want offset %d size %d",
+				i, writes[i].offset, len(writes[i].data), w.offset, w.size)
+		}
+	}
+}
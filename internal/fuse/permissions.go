@@ -0,0 +1,75 @@
+package fuse
+
+import (
+	"strconv"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// Object metadata keys the default permission mapper stores ownership in
+const (
+	modeMetadataKey = "objectfs-mode"
+	uidMetadataKey  = "objectfs-uid"
+	gidMetadataKey  = "objectfs-gid"
+)
+
+// PermissionMapper translates between object metadata and POSIX permissions
+// and ownership, so deployments can keep their existing conventions, such
+// as a creator tag or an ACL document, for who owns an object
+type PermissionMapper interface {
+	// ToPOSIX returns the permission bits, owner, and group of an object
+	ToPOSIX(info types.ObjectInfo) (mode, uid, gid uint32)
+
+	// FromPOSIX returns the metadata recording mode, uid, and gid for a new
+	// object, or nil to store none
+	FromPOSIX(mode, uid, gid uint32) map[string]string
+}
+
+// DefaultPermissionMapper reads permissions from objectfs-mode,
+// objectfs-uid, and objectfs-gid metadata, using its defaults for objects
+// without them and for fields that do not parse
+type DefaultPermissionMapper struct {
+	Mode uint32
+	UID  uint32
+	GID  uint32
+}
+
+// ToPOSIX returns the permissions recorded on info, or the defaults
+func (m DefaultPermissionMapper) ToPOSIX(info types.ObjectInfo) (mode, uid, gid uint32) {
+	mode = metadataUint32(info.Metadata, modeMetadataKey, 8, m.Mode)
+	uid = metadataUint32(info.Metadata, uidMetadataKey, 10, m.UID)
+	gid = metadataUint32(info.Metadata, gidMetadataKey, 10, m.GID)
+	return mode & 07777, uid, gid
+}
+
+// FromPOSIX records mode in octal and uid and gid in decimal
+func (m DefaultPermissionMapper) FromPOSIX(mode, uid, gid uint32) map[string]string {
+	return map[string]string{
+		modeMetadataKey: strconv.FormatUint(uint64(mode&07777), 8),
+		uidMetadataKey:  strconv.FormatUint(uint64(uid), 10),
+		gidMetadataKey:  strconv.FormatUint(uint64(gid), 10),
+	}
+}
+
+// metadataUint32 parses metadata[key] in base, returning fallback if it is
+// missing or invalid
+func metadataUint32(metadata map[string]string, key string, base int, fallback uint32) uint32 {
+	value, ok := metadata[key]
+	if !ok {
+		return fallback
+	}
+	parsed, err := strconv.ParseUint(value, base, 32)
+	if err != nil {
+		return fallback
+	}
+	return uint32(parsed)
+}
+
+// permissions returns the configured mapper, or the default mapper using
+// the configured default mode and ownership
+func (c *Config) permissions() PermissionMapper {
+	if c.PermissionMapper != nil {
+		return c.PermissionMapper
+	}
+	return DefaultPermissionMapper{Mode: c.DefaultMode, UID: c.DefaultUID, GID: c.DefaultGID}
+}
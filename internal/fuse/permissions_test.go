@@ -0,0 +1,116 @@
+package fuse
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// metadataObjects holds objects with their metadata and records the
+// metadata stored by creates
+type metadataObjects struct {
+	types.Backend
+	mu       sync.Mutex
+	metadata map[string]map[string]string
+}
+
+func (b *metadataObjects) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	metadata, ok := b.metadata[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	return &types.ObjectInfo{Key: key, Size: 1, LastModified: time.Unix(1000, 0), Metadata: metadata}, nil
+}
+
+func (b *metadataObjects) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.metadata[key] = metadata
+	return nil
+}
+
+// creatorMapper owns each object by the uid in its creator tag
+type creatorMapper struct{}
+
+func (creatorMapper) ToPOSIX(info types.ObjectInfo) (mode, uid, gid uint32) {
+	creator, _ := strconv.ParseUint(info.Metadata["creator"], 10, 32)
+	return 0600, uint32(creator), 100
+}
+
+func (creatorMapper) FromPOSIX(mode, uid, gid uint32) map[string]string {
+	return map[string]string{"creator": strconv.FormatUint(uint64(uid), 10)}
+}
+
+func statRoot(t *testing.T, raw fuse.RawFileSystem, name string) fuse.Attr {
+	t.Helper()
+	entry, status := lookupRoot(raw, name)
+	if status != fuse.OK {
+		t.Fatalf("Lookup(%s) status = %v", name, status)
+	}
+	out := &fuse.AttrOut{}
+	if status := raw.GetAttr(nil, &fuse.GetAttrIn{InHeader: fuse.InHeader{NodeId: entry.NodeId}}, out); status != fuse.OK {
+		t.Fatalf("GetAttr(%s) status = %v", name, status)
+	}
+	return out.Attr
+}
+
+func TestCustomPermissionMapperSetsOwner(t *testing.T) {
+	backend := &metadataObjects{metadata: map[string]map[string]string{
+		"report.txt": {"creator": "4021"},
+	}}
+	raw := newPrefixFS(t, backend, &Config{DefaultMode: 0644, DefaultUID: 1000, PermissionMapper: creatorMapper{}})
+
+	attr := statRoot(t, raw, "report.txt")
+	if attr.Uid != 4021 || attr.Gid != 100 {
+		t.Errorf("stat owner = %d:%d, want 4021:100 from the creator tag", attr.Uid, attr.Gid)
+	}
+	if attr.Mode&07777 != 0600 {
+		t.Errorf("stat mode = %o, want 0600", attr.Mode&07777)
+	}
+
+	// Files created through the mount are tagged with their creator
+	create := &fuse.CreateIn{
+		InHeader: fuse.InHeader{NodeId: fuse.FUSE_ROOT_ID, Caller: fuse.Caller{Owner: fuse.Owner{Uid: 77, Gid: 88}}},
+		Flags:    syscall.O_WRONLY,
+		Mode:     0640,
+	}
+	if status := raw.Create(nil, create, "new.txt", &fuse.CreateOut{}); status != fuse.OK {
+		t.Fatalf("Create(new.txt) status = %v", status)
+	}
+	backend.mu.Lock()
+	creator := backend.metadata["new.txt"]["creator"]
+	backend.mu.Unlock()
+	if creator != "77" {
+		t.Errorf("creator tag of a new file = %q, want 77", creator)
+	}
+}
+
+func TestDefaultPermissionMapper(t *testing.T) {
+	mapper := DefaultPermissionMapper{Mode: 0644, UID: 1000, GID: 1000}
+
+	mode, uid, gid := mapper.ToPOSIX(types.ObjectInfo{Key: "plain.txt"})
+	if mode != 0644 || uid != 1000 || gid != 1000 {
+		t.Errorf("ToPOSIX() without metadata = %o %d:%d, want the defaults", mode, uid, gid)
+	}
+
+	stored := mapper.FromPOSIX(0750, 42, 7)
+	mode, uid, gid = mapper.ToPOSIX(types.ObjectInfo{Metadata: stored})
+	if mode != 0750 || uid != 42 || gid != 7 {
+		t.Errorf("ToPOSIX() of stored metadata = %o %d:%d, want 750 42:7", mode, uid, gid)
+	}
+
+	mode, uid, _ = mapper.ToPOSIX(types.ObjectInfo{Metadata: map[string]string{modeMetadataKey: "rwx", uidMetadataKey: "5"}})
+	if mode != 0644 || uid != 5 {
+		t.Errorf("ToPOSIX() with an invalid mode = %o uid %d, want the default mode and uid 5", mode, uid)
+	}
+}
@@ -27,6 +27,27 @@ func CreatePlatformMountManager(backend types.Backend, cache types.Cache, writeB
 		DefaultGID:  1000,
 		DefaultMode: 0644,
 		CacheTTL:    60 * 1000000000, // 60 seconds in nanoseconds
+
+		WriteCoalesce: config.WriteCoalesce,
+		MaxObjectSize: config.MaxObjectSize,
+		SyncOnClose:   config.SyncOnClose,
+
+		OpenPrefetch: config.OpenPrefetch,
+		Streaming:    config.Streaming,
+
+		ListingPrefetch: config.ListingPrefetch,
+
+		VerifyCachedReads: config.VerifyCachedReads,
+
+		DisableImplicitDirs: config.DisableImplicitDirs,
+		ImplicitDirTTL:      config.ImplicitDirTTL,
+		DirectoryMarkers:    config.DirectoryMarkers,
+		CaseInsensitive:     config.CaseInsensitive,
+
+		PermissionMapper: config.PermissionMapper,
+
+		Degradation:  config.Degradation,
+		Availability: config.Availability,
 	}
 
 	filesystem := NewFileSystem(backend, cache, writeBuffer, metrics, fuseConfig)
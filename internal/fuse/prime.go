@@ -0,0 +1,273 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// PrimeMode selects what PrimeKeys loads for each key
+type PrimeMode string
+
+// Prime modes
+const (
+	PrimeMetadata PrimeMode = "metadata" // Attributes, so stats are answered without a HEAD
+	PrimeData     PrimeMode = "data"     // Attributes and content, so reads are cache hits
+)
+
+// Priming defaults
+const (
+	defaultPrimeConcurrency = 8
+	defaultPrimeTTL         = 5 * time.Minute
+)
+
+// PrimeProgress reports the progress of a PrimeKeys call
+type PrimeProgress struct {
+	Mode    PrimeMode `json:"mode"`
+	Total   int       `json:"total"`
+	Done    int       `json:"done"`    // Keys finished, whatever the outcome
+	Primed  int       `json:"primed"`  // Keys whose object was loaded
+	Missing int       `json:"missing"` // Keys without an object, remembered as missing
+	Failed  int       `json:"failed"`
+	Bytes   int64     `json:"bytes"` // Content loaded into the cache
+}
+
+// primedEntry is what priming learned about one key
+type primedEntry struct {
+	info    *types.ObjectInfo // Nil when the key has no object
+	data    bool              // Content is cached in fetch-aligned blocks
+	listed  bool              // Loaded by listing the directory rather than by PrimeKeys
+	expires time.Time
+}
+
+// primedKeys holds the attributes, or absence, of primed keys so lookups
+// are answered without a HEAD until the entry expires or the path changes
+type primedKeys struct {
+	mu       sync.Mutex
+	entries  map[string]primedEntry
+	progress PrimeProgress // Latest PrimeKeys call
+
+	// Lifetime totals
+	primed int64
+	failed int64
+}
+
+// store records the result of priming path until expires
+func (p *primedKeys) store(path string, entry primedEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.entries == nil {
+		p.entries = make(map[string]primedEntry)
+	}
+	p.entries[path] = entry
+}
+
+// get returns the unexpired entry primed for path
+func (p *primedKeys) get(path string, now time.Time) (primedEntry, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[path]
+	if ok && !now.Before(entry.expires) {
+		delete(p.entries, path)
+		return primedEntry{}, false
+	}
+	return entry, ok
+}
+
+// forget drops what was primed for path, once it is created, written or
+// renamed through the mount
+func (p *primedKeys) forget(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, path)
+}
+
+// begin starts the progress of a call priming total keys
+func (p *primedKeys) begin(mode PrimeMode, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress = PrimeProgress{Mode: mode, Total: total}
+}
+
+// finish records the outcome of priming one key
+func (p *primedKeys) finish(primed, missing bool, bytes int64, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.progress.Done++
+	p.progress.Bytes += bytes
+	switch {
+	case err != nil:
+		p.progress.Failed++
+		p.failed++
+	case missing:
+		p.progress.Missing++
+	case primed:
+		p.progress.Primed++
+		p.primed++
+	}
+}
+
+// primeTTL returns how long primed attributes answer lookups
+func (c *Config) primeTTL() time.Duration {
+	if c.CacheTTL > 0 {
+		return c.CacheTTL
+	}
+	return defaultPrimeTTL
+}
+
+// PrimeKeys loads a known set of keys ahead of a job that will use them,
+// such as the inputs listed in a workflow manifest. Metadata mode HEADs
+// each key so later stats are answered from memory, missing keys included;
+// data mode also reads each object into the cache so later reads are hits.
+// Up to PrimeConcurrency keys are primed at once, and PrimeProgress reports
+// how far the call has got. The error reports how many keys failed.
+func (fs *FileSystem) PrimeKeys(ctx context.Context, keys []string, mode PrimeMode) error {
+	if mode != PrimeMetadata && mode != PrimeData {
+		return fmt.Errorf("invalid prime mode: %s (must be %s or %s)", mode, PrimeMetadata, PrimeData)
+	}
+	if errno := fs.beginOp(); errno != 0 {
+		return fmt.Errorf("failed to prime keys: %w", errno)
+	}
+	defer fs.endOp()
+
+	concurrency := fs.config.PrimeConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultPrimeConcurrency
+	}
+
+	fs.primed.begin(mode, len(keys))
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failed   int
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return fmt.Errorf("priming canceled: %w", ctx.Err())
+		}
+
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fs.primeKey(ctx, key, mode); err != nil {
+				mu.Lock()
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		return fmt.Errorf("failed to prime %d of %d keys: %w", failed, len(keys), firstErr)
+	}
+	return nil
+}
+
+// PrimeProgress returns the progress of the latest PrimeKeys call
+func (fs *FileSystem) PrimeProgress() PrimeProgress {
+	fs.primed.mu.Lock()
+	defer fs.primed.mu.Unlock()
+	return fs.primed.progress
+}
+
+// primeKey loads the attributes of key, and its content in data mode
+func (fs *FileSystem) primeKey(ctx context.Context, key string, mode PrimeMode) (err error) {
+	var primed, missing bool
+	var bytes int64
+	defer func() { fs.primed.finish(primed, missing, bytes, err) }()
+
+	expires := time.Now().Add(fs.config.primeTTL())
+	info, err := fs.backend.HeadObject(ctx, key)
+	if err != nil {
+		if errnoFor(err) != syscall.ENOENT {
+			return fmt.Errorf("failed to prime %s: %w", key, err)
+		}
+		// Directories without an object are remembered by the implicit
+		// directory cache instead
+		if !resolveImplicitDir(ctx, fs.backend, &fs.dirs, fs.config, key) {
+			fs.primed.store(key, primedEntry{expires: expires})
+		}
+		missing = true
+		return nil
+	}
+
+	entry := primedEntry{info: info, expires: expires}
+	if mode == PrimeData && !fs.config.directoryMarkers().isDirectory(info) {
+		if bytes, err = fs.primeData(ctx, key, info.Size); err != nil {
+			fs.primed.store(key, entry)
+			return fmt.Errorf("failed to prime %s: %w", key, err)
+		}
+		entry.data = true
+	}
+	fs.primed.store(key, entry)
+	primed = true
+	return nil
+}
+
+// primeData caches the content of key in fetch-aligned blocks, returning
+// the bytes cached
+func (fs *FileSystem) primeData(ctx context.Context, key string, size int64) (int64, error) {
+	alignment := fs.fetchAlignment()
+	var cached int64
+	for blockStart := int64(0); blockStart < size; blockStart += alignment {
+		length := min(alignment, size-blockStart)
+		block, err := fs.backend.GetObject(ctx, key, blockStart, length)
+		if err != nil {
+			return cached, err
+		}
+		if int64(len(block)) != length {
+			return cached, fmt.Errorf("read %d of %d bytes at offset %d", len(block), length, blockStart)
+		}
+		fs.cache.Put(key, blockStart, block)
+		cached += length
+	}
+	recordObjectSize(fs.metrics, "prime", size)
+	return cached, nil
+}
+
+// primedRead serves a read of a primed file from its cached blocks,
+// returning nil unless every block covering the range is still cached
+func (fs *FileSystem) primedRead(path string, offset, size int64) []byte {
+	entry, ok := fs.primed.get(path, time.Now())
+	if !ok || !entry.data || offset >= entry.info.Size {
+		return nil
+	}
+
+	end := min(offset+size, entry.info.Size)
+	alignment := fs.fetchAlignment()
+	result := make([]byte, 0, end-offset)
+	for blockStart, _ := alignRange(offset, 0, alignment); blockStart < end; blockStart += alignment {
+		length := min(alignment, entry.info.Size-blockStart)
+		block := fs.cache.Get(path, blockStart, length)
+		if int64(len(block)) != length {
+			return nil
+		}
+		result = append(result, block[max(offset, blockStart)-blockStart:min(end, blockStart+length)-blockStart]...)
+	}
+
+	if entry.listed {
+		fs.stats.mu.Lock()
+		fs.stats.ListingDataHits++
+		fs.stats.mu.Unlock()
+	}
+	return result
+}
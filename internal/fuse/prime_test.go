@@ -0,0 +1,87 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// brokenHeads fails HEAD requests for one key
+type brokenHeads struct {
+	*contentBackend
+	broken string
+}
+
+func (b *brokenHeads) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	if key == b.broken {
+		return nil, fmt.Errorf("connection reset")
+	}
+	return b.contentBackend.HeadObject(ctx, key)
+}
+
+func TestPrimeMetadataAnswersStats(t *testing.T) {
+	content := &contentBackend{objects: map[string][]byte{"a.txt": []byte("alpha"), "b.txt": []byte("bravo")}}
+	backend := &brokenHeads{contentBackend: content, broken: "broken.txt"}
+	filesystem, raw := newContentFS(t, backend, &Config{})
+
+	err := filesystem.PrimeKeys(context.Background(), []string{"a.txt", "b.txt", "missing.txt", "broken.txt"}, PrimeMetadata)
+	if err == nil || !strings.Contains(err.Error(), "1 of 4") {
+		t.Errorf("PrimeKeys() error = %v, want one failed key", err)
+	}
+	progress := filesystem.PrimeProgress()
+	if progress.Done != 4 || progress.Primed != 2 || progress.Missing != 1 || progress.Failed != 1 {
+		t.Errorf("PrimeProgress() = %+v, want 2 primed, 1 missing, 1 failed", progress)
+	}
+	content.requests()
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if attr := statRoot(t, raw, name); attr.Size != 5 {
+			t.Errorf("stat %s size = %d, want 5", name, attr.Size)
+		}
+	}
+	if _, status := lookupRoot(raw, "missing.txt"); status != fuse.ENOENT {
+		t.Errorf("stat missing.txt = %v, want ENOENT", status)
+	}
+	if heads, _ := content.requests(); heads != 0 {
+		t.Errorf("stats of primed keys made %d HEAD requests, want none", heads)
+	}
+
+	stats := filesystem.GetStats()
+	if stats.CacheHits != 3 || stats.PrimedKeys != 2 || stats.PrimeFailures != 1 {
+		t.Errorf("stats = %d hits, %d primed, %d failed; want 3, 2, 1", stats.CacheHits, stats.PrimedKeys, stats.PrimeFailures)
+	}
+}
+
+func TestPrimeDataServesReads(t *testing.T) {
+	large := strings.Repeat("0123456789", 30)
+	backend := &contentBackend{objects: map[string][]byte{"small.txt": []byte("alpha"), "large.txt": []byte(large)}}
+	filesystem, raw := newContentFS(t, backend, &Config{FetchAlignment: 128})
+
+	if err := filesystem.PrimeKeys(context.Background(), []string{"small.txt", "large.txt"}, PrimeData); err != nil {
+		t.Fatalf("PrimeKeys() error = %v", err)
+	}
+	if progress := filesystem.PrimeProgress(); progress.Bytes != 305 {
+		t.Errorf("PrimeProgress().Bytes = %d, want 305", progress.Bytes)
+	}
+	backend.requests()
+
+	if got := catRoot(t, raw, "small.txt"); got != "alpha" {
+		t.Errorf("read small.txt = %q, want alpha", got)
+	}
+	// A range spanning cached blocks, running past the end of the file
+	if got := readRoot(t, raw, "large.txt", 100, 250); got != large[100:] {
+		t.Errorf("read large.txt at 100 = %q, want %q", got, large[100:])
+	}
+	if heads, gets := backend.requests(); heads != 0 || gets != 0 {
+		t.Errorf("reads of primed files made %d HEAD and %d GET requests, want none", heads, gets)
+	}
+
+	if err := filesystem.PrimeKeys(context.Background(), []string{"small.txt"}, "full"); err == nil {
+		t.Error("PrimeKeys() with an unknown mode succeeded")
+	}
+}
@@ -0,0 +1,278 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+)
+
+// Auto-remount defaults
+const (
+	DefaultRemountCheckInterval = 10 * time.Second
+	DefaultRemountMaxAttempts   = 5
+	DefaultRemountBackoff       = time.Second
+	DefaultRemountMaxBackoff    = 30 * time.Second
+)
+
+// AutoRemountConfig makes the mount manager watch for a lost FUSE
+// connection, such as the kernel aborting the mount, and mount the
+// filesystem again. Attempts wait Backoff, doubling up to MaxBackoff.
+type AutoRemountConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	CheckInterval time.Duration `yaml:"check_interval"` // Default 10s
+	MaxAttempts   int           `yaml:"max_attempts"`   // Default 5
+	Backoff       time.Duration `yaml:"backoff"`        // Default 1s
+	MaxBackoff    time.Duration `yaml:"max_backoff"`    // Default 30s
+}
+
+// withDefaults returns the config with unset values filled in
+func (c AutoRemountConfig) withDefaults() AutoRemountConfig {
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = DefaultRemountCheckInterval
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultRemountMaxAttempts
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = DefaultRemountBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultRemountMaxBackoff
+	}
+	return c
+}
+
+// Mount event types
+const (
+	MountEventLost          = "mount_lost"
+	MountEventRemounted     = "remounted"
+	MountEventRemountFailed = "remount_failed"
+)
+
+// mountEventBufferSize is the number of events held for a slow reader;
+// later events are dropped until it catches up
+const mountEventBufferSize = 16
+
+// MountEvent reports a lost mount and the outcome of recovering it
+type MountEvent struct {
+	Type       string    `json:"type"`
+	MountPoint string    `json:"mount_point"`
+	Attempts   int       `json:"attempts,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// MountWatchStats counts mount health checks and recoveries
+type MountWatchStats struct {
+	Checks           int64  `json:"checks"`
+	MountLosses      int64  `json:"mount_losses"`
+	RemountAttempts  int64  `json:"remount_attempts"`
+	Remounts         int64  `json:"remounts"`
+	FailedRecoveries int64  `json:"failed_recoveries"`
+	LastError        string `json:"last_error,omitempty"`
+}
+
+// mountServer is a running FUSE server
+type mountServer interface {
+	Unmount() error
+	Wait()
+}
+
+// mountLayer performs the kernel side of mounting, replaceable in tests
+type mountLayer interface {
+	mount(mountPoint string, root fs.InodeEmbedder, opts *fs.Options) (mountServer, error)
+	// probe fails when the mount no longer answers, as a stat of a mount
+	// whose FUSE connection was aborted does with ENOTCONN
+	probe(mountPoint string) error
+	// detach lazily unmounts a dead mount so the mount point can be reused
+	detach(mountPoint string) error
+}
+
+// kernelMountLayer mounts through go-fuse
+type kernelMountLayer struct{}
+
+func (kernelMountLayer) mount(mountPoint string, root fs.InodeEmbedder, opts *fs.Options) (mountServer, error) {
+	return fs.Mount(mountPoint, root, opts)
+}
+
+func (kernelMountLayer) probe(mountPoint string) error {
+	info, err := os.Stat(mountPoint)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("mount point %s is no longer a directory", mountPoint)
+	}
+	return nil
+}
+
+func (kernelMountLayer) detach(mountPoint string) error {
+	return syscall.Unmount(mountPoint, 2) // MNT_DETACH
+}
+
+// flushPending hands coalesced writes to the write buffer and uploads
+// everything buffered, so writes accepted before a mount was lost are not
+// held across the remount
+func (fs *FileSystem) flushPending() error {
+	if fs.writeCoalescer != nil {
+		if err := fs.writeCoalescer.FlushAll(); err != nil {
+			return fmt.Errorf("failed to flush coalesced writes: %w", err)
+		}
+	}
+	if fs.buffer != nil {
+		if err := fs.buffer.FlushAll(); err != nil {
+			return fmt.Errorf("failed to flush write buffer: %w", err)
+		}
+	}
+	return nil
+}
+
+// remountLost replaces a mount whose FUSE connection was lost
+func (m *MountManager) remountLost(ctx context.Context) error {
+	m.mu.Lock()
+	m.mounted = false
+	m.server = nil
+	m.mu.Unlock()
+
+	if err := m.layer.detach(m.config.MountPoint); err != nil {
+		log.Printf("Warning: failed to detach lost mount at %s: %v", m.config.MountPoint, err)
+	}
+	return m.Mount(ctx)
+}
+
+// startWatcher starts watching the mount when auto-remount is enabled
+func (m *MountManager) startWatcher() {
+	if !m.config.AutoRemount.Enabled {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.watcher == nil {
+		m.watcher = NewMountWatcher(m, m.config.AutoRemount.withDefaults().CheckInterval)
+		m.watcher.Start()
+	}
+}
+
+// stopWatcher stops watching the mount, for example before unmounting
+func (m *MountManager) stopWatcher() {
+	m.mu.Lock()
+	watcher := m.watcher
+	m.watcher = nil
+	m.mu.Unlock()
+
+	if watcher != nil {
+		watcher.Stop()
+	}
+}
+
+// Watcher returns the watcher recovering lost mounts, or nil when
+// auto-remount is disabled or the filesystem was unmounted
+func (m *MountManager) Watcher() *MountWatcher {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.watcher
+}
+
+// MountWatchStats returns the watcher's counters, or nil when no watcher
+// is running
+func (m *MountManager) MountWatchStats() *MountWatchStats {
+	watcher := m.Watcher()
+	if watcher == nil {
+		return nil
+	}
+	stats := watcher.Stats()
+	return &stats
+}
+
+// Events returns lost-mount and remount events. The channel is buffered;
+// events are dropped while it is full.
+func (w *MountWatcher) Events() <-chan MountEvent {
+	return w.events
+}
+
+// Stats returns a snapshot of the watcher's counters
+func (w *MountWatcher) Stats() MountWatchStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// Err returns why the watcher gave up on the mount, or nil while it is
+// still watching
+func (w *MountWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// publish delivers event unless the events buffer is full
+func (w *MountWatcher) publish(event MountEvent) {
+	event.MountPoint = w.manager.GetMountPoint()
+	event.Time = time.Now()
+	select {
+	case w.events <- event:
+	default:
+	}
+}
+
+// recover remounts a lost mount, retrying with backoff. It reports whether
+// the mount was recovered; once attempts are exhausted the watcher records
+// the error and stops.
+func (w *MountWatcher) recover(cause error) bool {
+	mountPoint := w.manager.GetMountPoint()
+	log.Printf("Warning: mount at %s was lost: %v", mountPoint, cause)
+
+	w.mu.Lock()
+	w.stats.MountLosses++
+	w.stats.LastError = cause.Error()
+	w.mu.Unlock()
+	w.publish(MountEvent{Type: MountEventLost, Error: cause.Error()})
+
+	if w.manager.filesystem != nil {
+		if err := w.manager.filesystem.flushPending(); err != nil {
+			log.Printf("Warning: failed to flush pending writes before remounting %s: %v", mountPoint, err)
+		}
+	}
+
+	config := w.manager.config.AutoRemount.withDefaults()
+	backoff := config.Backoff
+	var lastErr error
+	for attempt := 1; attempt <= config.MaxAttempts; attempt++ {
+		select {
+		case <-w.stopCh:
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, config.MaxBackoff)
+
+		w.mu.Lock()
+		w.stats.RemountAttempts++
+		w.mu.Unlock()
+
+		lastErr = w.manager.remountLost(context.Background())
+		if lastErr == nil {
+			log.Printf("Remounted %s after %d attempt(s)", mountPoint, attempt)
+			w.mu.Lock()
+			w.stats.Remounts++
+			w.mu.Unlock()
+			w.publish(MountEvent{Type: MountEventRemounted, Attempts: attempt})
+			return true
+		}
+		log.Printf("Warning: remount attempt %d/%d for %s failed: %v", attempt, config.MaxAttempts, mountPoint, lastErr)
+	}
+
+	err := fmt.Errorf("mount at %s was lost and %d remount attempts failed: %w", mountPoint, config.MaxAttempts, lastErr)
+	log.Printf("Error: %v", err)
+	w.mu.Lock()
+	w.stats.FailedRecoveries++
+	w.stats.LastError = err.Error()
+	w.err = err
+	w.mu.Unlock()
+	w.publish(MountEvent{Type: MountEventRemountFailed, Attempts: config.MaxAttempts, Error: err.Error()})
+	return false
+}
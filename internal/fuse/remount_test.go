@@ -0,0 +1,189 @@
+package fuse
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+)
+
+// fakeServer serves until it is unmounted or its connection is killed
+type fakeServer struct {
+	once sync.Once
+	done chan struct{}
+}
+
+func (s *fakeServer) stop() { s.once.Do(func() { close(s.done) }) }
+
+func (s *fakeServer) Unmount() error {
+	s.stop()
+	return nil
+}
+
+func (s *fakeServer) Wait() { <-s.done }
+
+// fakeMountLayer simulates the kernel side of mounting
+type fakeMountLayer struct {
+	mu       sync.Mutex
+	server   *fakeServer
+	mounts   int
+	detaches int
+	mountErr error
+	opts     *fs.Options // Options of the last mount
+}
+
+func (l *fakeMountLayer) mount(mountPoint string, root fs.InodeEmbedder, opts *fs.Options) (mountServer, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.mounts++
+	l.opts = opts
+	if l.mountErr != nil {
+		return nil, l.mountErr
+	}
+	l.server = &fakeServer{done: make(chan struct{})}
+	return l.server, nil
+}
+
+func (l *fakeMountLayer) probe(mountPoint string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.server == nil {
+		return syscall.ENOTCONN
+	}
+	return nil
+}
+
+func (l *fakeMountLayer) detach(mountPoint string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.detaches++
+	return nil
+}
+
+// kill drops the FUSE connection, failing later mounts with mountErr
+func (l *fakeMountLayer) kill(mountErr error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.server.stop()
+	l.server = nil
+	l.mountErr = mountErr
+}
+
+// flushCountingBuffer counts FlushAll calls
+type flushCountingBuffer struct {
+	recordingBuffer
+	mu      sync.Mutex
+	flushes int
+}
+
+func (b *flushCountingBuffer) FlushAll() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushes++
+	return nil
+}
+
+func newWatchedMount(t *testing.T, layer *fakeMountLayer, buffer *flushCountingBuffer) *MountManager {
+	t.Helper()
+	filesystem := NewFileSystem(&markerBackend{}, nil, buffer, nil, &Config{})
+	t.Cleanup(filesystem.readAhead.Stop)
+
+	manager := NewMountManager(filesystem, &MountConfig{
+		MountPoint: t.TempDir(),
+		Options:    &MountOptions{FSName: "objectfs"},
+		AutoRemount: AutoRemountConfig{
+			Enabled:       true,
+			CheckInterval: 5 * time.Millisecond,
+			MaxAttempts:   3,
+			Backoff:       time.Millisecond,
+			MaxBackoff:    4 * time.Millisecond,
+		},
+	})
+	manager.layer = layer
+	if err := manager.Mount(context.Background()); err != nil {
+		t.Fatalf("Mount() error = %v", err)
+	}
+	t.Cleanup(manager.stopWatcher)
+	return manager
+}
+
+// nextEvent waits for the watcher's next event
+func nextEvent(t *testing.T, watcher *MountWatcher) MountEvent {
+	t.Helper()
+	select {
+	case event := <-watcher.Events():
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a mount event")
+		return MountEvent{}
+	}
+}
+
+func TestLostMountIsRemounted(t *testing.T) {
+	layer := &fakeMountLayer{}
+	buffer := &flushCountingBuffer{}
+	manager := newWatchedMount(t, layer, buffer)
+	watcher := manager.Watcher()
+
+	layer.kill(nil)
+
+	if event := nextEvent(t, watcher); event.Type != MountEventLost {
+		t.Fatalf("first event = %+v, want %s", event, MountEventLost)
+	}
+	event := nextEvent(t, watcher)
+	if event.Type != MountEventRemounted || event.Attempts != 1 || event.MountPoint != manager.GetMountPoint() {
+		t.Fatalf("second event = %+v, want %s after 1 attempt", event, MountEventRemounted)
+	}
+
+	if !manager.IsMounted() || layer.mounts != 2 || layer.detaches != 1 {
+		t.Errorf("mounted = %v, mounts = %d, detaches = %d; want mounted after 2 mounts and 1 detach",
+			manager.IsMounted(), layer.mounts, layer.detaches)
+	}
+	if buffer.flushes == 0 {
+		t.Error("pending writes were not flushed before remounting")
+	}
+	stats := manager.MountWatchStats()
+	if stats.MountLosses != 1 || stats.Remounts != 1 || stats.RemountAttempts != 1 {
+		t.Errorf("MountWatchStats() = %+v, want 1 loss, 1 attempt and 1 remount", stats)
+	}
+
+	if err := manager.Unmount(); err != nil {
+		t.Fatalf("Unmount() error = %v", err)
+	}
+	if manager.Watcher() != nil {
+		t.Error("watcher still running after Unmount")
+	}
+}
+
+func TestRemountGivesUpAfterMaxAttempts(t *testing.T) {
+	layer := &fakeMountLayer{}
+	manager := newWatchedMount(t, layer, &flushCountingBuffer{})
+	watcher := manager.Watcher()
+
+	deviceGone := errors.New("device disconnected")
+	layer.kill(deviceGone)
+
+	if event := nextEvent(t, watcher); event.Type != MountEventLost {
+		t.Fatalf("first event = %+v, want %s", event, MountEventLost)
+	}
+	event := nextEvent(t, watcher)
+	if event.Type != MountEventRemountFailed || event.Attempts != 3 {
+		t.Fatalf("second event = %+v, want %s after 3 attempts", event, MountEventRemountFailed)
+	}
+
+	err := watcher.Err()
+	if !errors.Is(err, deviceGone) || !strings.Contains(err.Error(), "3 remount attempts failed") {
+		t.Errorf("Err() = %v, want the attempts exhausted wrapping %v", err, deviceGone)
+	}
+	if manager.IsMounted() || layer.mounts != 4 {
+		t.Errorf("mounted = %v after %d mounts, want unmounted after 4", manager.IsMounted(), layer.mounts)
+	}
+	if stats := watcher.Stats(); stats.RemountAttempts != 3 || stats.FailedRecoveries != 1 || stats.LastError != err.Error() {
+		t.Errorf("Stats() = %+v, want 3 attempts and 1 failed recovery", stats)
+	}
+}
@@ -0,0 +1,143 @@
+package fuse
+
+import (
+	"context"
+	"log"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// renameNoReplace is RENAME_NOREPLACE, which fails the rename when the
+// target exists
+const renameNoReplace = 0x1
+
+// Rename moves a file with a server-side move that keeps its metadata and
+// tags. Directories, which have no single object to move, and backends
+// without moves get EXDEV, so tools such as mv fall back to copying.
+func (n *DirectoryNode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	if errno := n.fs.beginOp(); errno != 0 {
+		return errno
+	}
+	defer n.fs.endOp()
+
+	if errno := n.fs.writeErrno(); errno != 0 {
+		return errno
+	}
+	if flags&^renameNoReplace != 0 {
+		return syscall.EINVAL
+	}
+
+	mover, ok := n.fs.backend.(types.ObjectMover)
+	if !ok {
+		return syscall.EXDEV
+	}
+	parent, ok := newParent.(*DirectoryNode)
+	if !ok {
+		return syscall.EXDEV
+	}
+	if child := n.GetChild(name); child != nil {
+		if _, isDir := child.Operations().(*DirectoryNode); isDir {
+			return syscall.EXDEV
+		}
+	}
+
+	srcPath, dstPath := n.fs.resolveCase(ctx, n.path, n.joinPath(name)), parent.joinPath(newName)
+	if n.fs.caseConflict(ctx, parent.path, dstPath, srcPath) {
+		return syscall.EEXIST
+	}
+	info, err := n.fs.backend.HeadObject(ctx, srcPath)
+	if err != nil {
+		if resolveImplicitDir(ctx, n.fs.backend, &n.fs.dirs, n.fs.config, srcPath) {
+			return syscall.EXDEV
+		}
+		return errnoFor(err)
+	}
+	if n.fs.config.directoryMarkers().isDirectory(info) {
+		return syscall.EXDEV
+	}
+	if flags&renameNoReplace != 0 {
+		if _, err := n.fs.backend.HeadObject(ctx, dstPath); err == nil {
+			return syscall.EEXIST
+		}
+	}
+
+	// Writes still buffered for the file must reach the object being moved
+	if errno := n.fs.syncPath(ctx, srcPath); errno != 0 {
+		return errno
+	}
+
+	if err := mover.MoveObject(ctx, srcPath, dstPath); err != nil {
+		n.fs.stats.mu.Lock()
+		n.fs.stats.Errors++
+		n.fs.stats.mu.Unlock()
+
+		log.Printf("Rename of %s to %s failed: %v", srcPath, dstPath, err)
+		return errnoFor(err)
+	}
+
+	n.fs.renameOpenFiles(srcPath, dstPath)
+	n.fs.primed.forget(srcPath)
+	n.fs.primed.forget(dstPath)
+	if n.fs.folds != nil {
+		n.fs.folds.remove(srcPath)
+		n.fs.folds.add(dstPath)
+	}
+	if child := n.GetChild(name); child != nil {
+		if file, ok := child.Operations().(*FileNode); ok {
+			file.path = dstPath
+			if info, err := n.fs.backend.HeadObject(ctx, dstPath); err == nil {
+				file.info = info
+			}
+		}
+	}
+	return 0
+}
+
+// syncPath waits until the buffered writes of every handle open on path
+// have reached the backend
+func (fs *FileSystem) syncPath(ctx context.Context, path string) syscall.Errno {
+	fs.mu.RLock()
+	var handles []uint64
+	for handle, file := range fs.openFiles {
+		if file.path == path && file.dirty {
+			handles = append(handles, handle)
+		}
+	}
+	fs.mu.RUnlock()
+
+	if len(handles) == 0 {
+		return 0
+	}
+
+	var err error
+	if fs.writeCoalescer != nil {
+		for _, handle := range handles {
+			if err = fs.writeCoalescer.Flush(handle); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = syncBuffered(ctx, fs.buffer, path)
+	}
+	if err != nil {
+		log.Printf("Sync before rename failed for %s: %v", path, err)
+		return errnoFor(err)
+	}
+	return 0
+}
+
+// renameOpenFiles points handles open on oldPath at newPath, so later
+// writes through them land on the moved object
+func (fs *FileSystem) renameOpenFiles(oldPath, newPath string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, file := range fs.openFiles {
+		if file.path == oldPath {
+			file.path = newPath
+		}
+	}
+}
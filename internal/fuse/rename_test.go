@@ -0,0 +1,122 @@
+package fuse
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// moveBackend holds objects by key and moves them server-side
+type moveBackend struct {
+	types.Backend
+	mu      sync.Mutex
+	objects map[string]*types.ObjectInfo
+	moves   int
+}
+
+func (b *moveBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	info, ok := b.objects[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	return info, nil
+}
+
+func (b *moveBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var objects []types.ObjectInfo
+	for key, info := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, *info)
+		}
+	}
+	return objects, nil
+}
+
+func (b *moveBackend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.moves++
+	info, ok := b.objects[srcKey]
+	if !ok {
+		return errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	moved := *info
+	moved.Key = dstKey
+	b.objects[dstKey] = &moved
+	delete(b.objects, srcKey)
+	return nil
+}
+
+// noMoveBackend hides the moves of the backend it wraps
+type noMoveBackend struct {
+	types.Backend
+}
+
+func newRenameDirs(t *testing.T, backend types.Backend) (*DirectoryNode, *DirectoryNode) {
+	t.Helper()
+	filesystem := NewFileSystem(backend, nil, &recordingBuffer{}, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+	return &DirectoryNode{fs: filesystem, path: "docs"}, &DirectoryNode{fs: filesystem, path: "archive"}
+}
+
+func TestRenameMovesObject(t *testing.T) {
+	backend := &moveBackend{objects: map[string]*types.ObjectInfo{
+		"docs/a.txt": {Key: "docs/a.txt", Size: 3, Metadata: map[string]string{"objectfs-mode": "600"}},
+		"docs/b.txt": {Key: "docs/b.txt", Size: 4},
+	}}
+	docs, archive := newRenameDirs(t, backend)
+	ctx := context.Background()
+
+	if errno := docs.Rename(ctx, "a.txt", archive, "a.txt", 0); errno != 0 {
+		t.Fatalf("Rename errno = %v", errno)
+	}
+	moved, ok := backend.objects["archive/a.txt"]
+	if !ok || moved.Metadata["objectfs-mode"] != "600" {
+		t.Fatalf("moved object = %+v, want it with its metadata", moved)
+	}
+	if _, ok := backend.objects["docs/a.txt"]; ok {
+		t.Error("source still exists after rename")
+	}
+
+	// RENAME_NOREPLACE refuses to overwrite an existing file
+	if errno := docs.Rename(ctx, "b.txt", archive, "a.txt", renameNoReplace); errno != syscall.EEXIST {
+		t.Errorf("Rename with RENAME_NOREPLACE onto an existing file errno = %v, want EEXIST", errno)
+	}
+	if errno := docs.Rename(ctx, "missing.txt", archive, "c.txt", 0); errno != syscall.ENOENT {
+		t.Errorf("Rename of a missing file errno = %v, want ENOENT", errno)
+	}
+	if backend.moves != 1 {
+		t.Errorf("backend moved %d times, want 1", backend.moves)
+	}
+
+	// Directories have no object of their own to move
+	root := &DirectoryNode{fs: docs.fs}
+	if errno := root.Rename(ctx, "archive", root, "old", 0); errno != syscall.EXDEV {
+		t.Errorf("Rename of a directory errno = %v, want EXDEV", errno)
+	}
+}
+
+func TestRenameWithoutMoverReturnsEXDEV(t *testing.T) {
+	backend := &moveBackend{objects: map[string]*types.ObjectInfo{
+		"docs/a.txt": {Key: "docs/a.txt", Size: 3},
+	}}
+	docs, archive := newRenameDirs(t, &noMoveBackend{Backend: backend})
+
+	if errno := docs.Rename(context.Background(), "a.txt", archive, "a.txt", 0); errno != syscall.EXDEV {
+		t.Errorf("Rename errno = %v, want EXDEV so callers copy instead", errno)
+	}
+	if _, ok := backend.objects["docs/a.txt"]; !ok {
+		t.Error("source removed although the backend cannot move")
+	}
+}
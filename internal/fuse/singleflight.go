@@ -0,0 +1,109 @@
+package fuse
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultFetchAlignment is the block size cache-miss reads are aligned to
+// when Config.FetchAlignment is unset
+const defaultFetchAlignment = 128 * 1024
+
+// fetchCall is a backend read shared by every caller that missed the same range
+type fetchCall struct {
+	done    chan struct{}
+	data    []byte
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// fetchGroup collapses concurrent identical cache misses into a single
+// backend read. The zero value is ready to use.
+type fetchGroup struct {
+	mu      sync.Mutex
+	calls   map[string]*fetchCall
+	deduped atomic.Int64
+}
+
+// do returns the result of fn for key, running it at most once for all
+// concurrent callers. fn runs with its own context, which is cancelled only
+// after every waiting caller has given up, so one caller's cancellation never
+// fails the others. Errors from fn are returned to every waiter. shared
+// reports whether the caller joined a read started by someone else.
+func (g *fetchGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) (data []byte, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*fetchCall)
+	}
+	call, shared := g.calls[key]
+	if shared {
+		call.waiters++
+		g.deduped.Add(1)
+	} else {
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &fetchCall{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = call
+		go g.run(fetchCtx, key, call, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.data, shared, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// Nobody is left to use the result
+			call.cancel()
+			g.forget(key, call)
+		}
+		g.mu.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (g *fetchGroup) run(ctx context.Context, key string, call *fetchCall, fn func(ctx context.Context) ([]byte, error)) {
+	defer call.cancel()
+	call.data, call.err = fn(ctx)
+
+	g.mu.Lock()
+	g.forget(key, call)
+	g.mu.Unlock()
+	close(call.done)
+}
+
+// forget removes call for key unless a newer call replaced it. Callers hold g.mu.
+func (g *fetchGroup) forget(key string, call *fetchCall) {
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+}
+
+// dedupedReads returns the number of callers served by another caller's read
+func (g *fetchGroup) dedupedReads() int64 {
+	return g.deduped.Load()
+}
+
+// alignRange expands [offset, offset+size) to whole blocks of alignment
+func alignRange(offset, size, alignment int64) (int64, int64) {
+	if alignment <= 0 {
+		return offset, size
+	}
+	start := offset - offset%alignment
+	end := offset + size
+	if rem := end % alignment; rem != 0 {
+		end += alignment - rem
+	}
+	return start, end - start
+}
+
+// sliceRange returns the part of block, fetched from blockStart, that
+// covers [offset, offset+size), clamped to the data actually returned
+func sliceRange(block []byte, blockStart, offset, size int64) []byte {
+	from := min(offset-blockStart, int64(len(block)))
+	to := min(from+size, int64(len(block)))
+	return block[from:to]
+}
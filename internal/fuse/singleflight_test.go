@@ -0,0 +1,271 @@
+package fuse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// slowBackend counts reads at offset zero and holds them until released
+type slowBackend struct {
+	types.Backend
+	reads   atomic.Int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *slowBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	if offset != 0 {
+		return nil, nil // Read-ahead of later ranges
+	}
+	if b.reads.Add(1) == 1 {
+		close(b.entered)
+	}
+	<-b.release
+	return make([]byte, size), nil
+}
+
+func TestConcurrentMissesShareOneBackendRead(t *testing.T) {
+	backend := &slowBackend{entered: make(chan struct{}), release: make(chan struct{})}
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100})
+	defer func() { _ = lru.Close() }()
+
+	filesystem := NewFileSystem(backend, lru, &recordingBuffer{}, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+
+	const readers = 16
+	var wg sync.WaitGroup
+	errnos := make(chan error, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(handle uint64) {
+			defer wg.Done()
+			fh := &FileHandle{fs: filesystem, handle: handle, file: &OpenFile{path: "cold.bin"}}
+			result, errno := fh.Read(context.Background(), make([]byte, 4096), 0)
+			if errno != 0 {
+				errnos <- errno
+				return
+			}
+			if result.Size() != 4096 {
+				t.Errorf("read %d bytes, want 4096", result.Size())
+			}
+		}(uint64(i + 1))
+	}
+
+	// Late readers either join the in-flight read or hit the cache it fills
+	<-backend.entered
+	close(backend.release)
+	wg.Wait()
+	close(errnos)
+
+	for errno := range errnos {
+		t.Errorf("Read() errno = %v", errno)
+	}
+	if got := backend.reads.Load(); got != 1 {
+		t.Errorf("backend reads = %d, want 1 for %d concurrent misses", got, readers)
+	}
+}
+
+// patternBackend returns bytes derived from their offset and counts reads
+type patternBackend struct {
+	types.Backend
+	reads   atomic.Int32
+	release chan struct{}
+}
+
+func (b *patternBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	b.reads.Add(1)
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(offset + int64(i))
+	}
+	return data, nil
+}
+
+func TestOverlappingMissesShareAlignedRead(t *testing.T) {
+	backend := &patternBackend{release: make(chan struct{})}
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100})
+	defer func() { _ = lru.Close() }()
+
+	filesystem := NewFileSystem(backend, lru, &recordingBuffer{}, nil, &Config{
+		WriteCoalesce:  &WriteCoalescerConfig{Enabled: false},
+		FetchAlignment: 64 * 1024,
+	})
+	filesystem.readAhead.Stop()
+	filesystem.readAhead = nil
+
+	const readers = 32
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			off := int64(i * 1000)
+			fh := &FileHandle{fs: filesystem, handle: uint64(i + 1), file: &OpenFile{path: "cold.bin"}}
+			result, errno := fh.Read(context.Background(), make([]byte, 4096), off)
+			if errno != 0 {
+				t.Errorf("Read(%d) errno = %v", off, errno)
+				return
+			}
+			data, _ := result.Bytes(make([]byte, 4096))
+			if len(data) != 4096 || data[0] != byte(off) || data[4095] != byte(off+4095) {
+				t.Errorf("Read(%d) returned the wrong range", off)
+			}
+		}(i)
+	}
+
+	// Hold the backend read until every reader has joined it
+	for filesystem.fetches.dedupedReads() < readers-1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(backend.release)
+	wg.Wait()
+
+	if got := backend.reads.Load(); got != 1 {
+		t.Errorf("backend reads = %d, want 1", got)
+	}
+	if got := filesystem.GetStats().DedupedReads; got != readers-1 {
+		t.Errorf("DedupedReads = %d, want %d", got, readers-1)
+	}
+}
+
+func TestFetchGroup_CancelledCallerKeepsSharedFetch(t *testing.T) {
+	var g fetchGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) ([]byte, error) {
+		close(started)
+		select {
+		case <-release:
+			return []byte("data"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, _, err := g.do(leaderCtx, "key", fetch)
+		leaderErr <- err
+	}()
+	<-started
+
+	waiter := make(chan []byte, 1)
+	go func() {
+		data, _, err := g.do(context.Background(), "key", fetch)
+		if err != nil {
+			t.Errorf("waiter error = %v", err)
+		}
+		waiter <- data
+	}()
+	for g.dedupedReads() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The caller that started the fetch gives up; the waiter must still succeed
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader error = %v, want context.Canceled", err)
+	}
+	close(release)
+
+	if data := <-waiter; string(data) != "data" {
+		t.Errorf("waiter data = %q, want data", data)
+	}
+}
+
+func TestFetchGroup_ErrorReachesAllWaiters(t *testing.T) {
+	var g fetchGroup
+	wantErr := errors.New("backend unavailable")
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	const callers = 8
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, _, err := g.do(context.Background(), "key", func(ctx context.Context) ([]byte, error) {
+				calls.Add(1)
+				<-release
+				return nil, wantErr
+			})
+			errs <- err
+		}()
+	}
+	for g.dedupedReads() < callers-1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	for i := 0; i < callers; i++ {
+		if err := <-errs; !errors.Is(err, wantErr) {
+			t.Errorf("caller error = %v, want %v", err, wantErr)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("fetch ran %d times, want 1", got)
+	}
+}
+
+func TestFetchGroup_AllCallersCancelledStopsFetch(t *testing.T) {
+	var g fetchGroup
+	fetchCancelled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = g.do(ctx, "key", func(fetchCtx context.Context) ([]byte, error) {
+			<-fetchCtx.Done()
+			close(fetchCancelled)
+			return nil, fetchCtx.Err()
+		})
+	}()
+
+	cancel()
+	<-done
+	select {
+	case <-fetchCancelled:
+	case <-time.After(time.Second):
+		t.Error("fetch kept running after every caller gave up")
+	}
+}
+
+func TestAlignRange(t *testing.T) {
+	tests := []struct {
+		offset, size, alignment int64
+		wantStart, wantSize     int64
+	}{
+		{0, 4096, 65536, 0, 65536},
+		{1000, 4096, 65536, 0, 65536},
+		{65000, 1000, 65536, 0, 131072},
+		{65536, 65536, 65536, 65536, 65536},
+		{100, 50, 0, 100, 50},
+	}
+	for _, tt := range tests {
+		start, size := alignRange(tt.offset, tt.size, tt.alignment)
+		if start != tt.wantStart || size != tt.wantSize {
+			t.Errorf("alignRange(%d, %d, %d) = %d, %d; want %d, %d",
+				tt.offset, tt.size, tt.alignment, start, size, tt.wantStart, tt.wantSize)
+		}
+	}
+
+	// Short blocks at the end of an object are clamped
+	if got := sliceRange(make([]byte, 10), 0, 8, 4); len(got) != 2 {
+		t.Errorf("sliceRange past end returned %d bytes, want 2", len(got))
+	}
+}
@@ -0,0 +1,124 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// defaultStreamRingBlocks is the number of fetched blocks a streaming
+// handle keeps
+const defaultStreamRingBlocks = 4
+
+// StreamingConfig selects files read once from start to end, such as large
+// inputs of a scan. Their reads bypass the cache: blocks are fetched from
+// the backend and kept only in a small ring per open file, so a one-shot
+// scan does not evict the hot set. Cached ranges of such files are still
+// served from the cache.
+type StreamingConfig struct {
+	MinFileSize int64    `yaml:"min_file_size"` // Files at least this large stream; 0 disables the size check
+	Prefixes    []string `yaml:"prefixes"`      // Files under these prefixes stream whatever their size
+	RingBlocks  int      `yaml:"ring_blocks"`   // Fetched blocks kept per open file (default 4)
+}
+
+// streams reports whether reads of the file at path with size bypass the
+// cache
+func (c StreamingConfig) streams(path string, size int64) bool {
+	if c.MinFileSize > 0 && size >= c.MinFileSize {
+		return true
+	}
+	for _, prefix := range c.Prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// streamBlock is a block fetched for a streaming handle
+type streamBlock struct {
+	start int64
+	data  []byte
+	eof   bool // The block ends at the end of the file
+}
+
+// streamRing holds the last blocks a streaming handle fetched, so reads
+// smaller than a block are served without fetching it again
+type streamRing struct {
+	mu     sync.Mutex
+	blocks []streamBlock
+	next   int
+}
+
+// newStreamRing creates a ring of up to size blocks
+func newStreamRing(size int) *streamRing {
+	if size <= 0 {
+		size = defaultStreamRingBlocks
+	}
+	return &streamRing{blocks: make([]streamBlock, 0, size)}
+}
+
+// get returns [offset, offset+size) from a held block, or nil unless one
+// block covers it
+func (r *streamRing) get(offset, size int64) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, block := range r.blocks {
+		end := block.start + int64(len(block.data))
+		if offset >= block.start && (offset+size <= end || block.eof && offset < end) {
+			return sliceRange(block.data, block.start, offset, size)
+		}
+	}
+	return nil
+}
+
+// add holds a fetched block, replacing the oldest when the ring is full
+func (r *streamRing) add(block streamBlock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.blocks) < cap(r.blocks) {
+		r.blocks = append(r.blocks, block)
+		return
+	}
+	r.blocks[r.next] = block
+	r.next = (r.next + 1) % len(r.blocks)
+}
+
+// streamRead serves a read of a streaming file from the handle's ring or
+// the backend, without inserting what it fetches into the cache
+func (fh *FileHandle) streamRead(ctx context.Context, off, size int64) ([]byte, syscall.Errno) {
+	data := fh.stream.get(off, size)
+	if data == nil {
+		blockStart, blockSize := alignRange(off, size, fh.fs.fetchAlignment())
+		fetchKey := fmt.Sprintf("%s:%d:%d", fh.file.path, blockStart, blockSize)
+		block, shared, err := fh.fs.fetches.do(ctx, fetchKey, func(fetchCtx context.Context) ([]byte, error) {
+			return fh.fs.backend.GetObject(fetchCtx, fh.file.path, blockStart, blockSize)
+		})
+		if err != nil {
+			fh.fs.stats.mu.Lock()
+			fh.fs.stats.Errors++
+			fh.fs.stats.mu.Unlock()
+
+			log.Printf("Streaming read failed for %s at offset %d: %v", fh.file.path, off, err)
+			return nil, syscall.EIO
+		}
+		if !shared {
+			recordObjectSize(fh.fs.metrics, "get", int64(len(block)))
+		}
+
+		fh.stream.add(streamBlock{start: blockStart, data: block, eof: int64(len(block)) < blockSize})
+		data = sliceRange(block, blockStart, off, size)
+	}
+
+	fh.fs.stats.mu.Lock()
+	fh.fs.stats.StreamingReads++
+	fh.fs.stats.BypassedBytes += int64(len(data))
+	fh.fs.stats.BytesRead += int64(len(data))
+	fh.fs.stats.mu.Unlock()
+	return data, 0
+}
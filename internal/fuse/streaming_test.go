@@ -0,0 +1,96 @@
+package fuse
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/internal/cache"
+)
+
+func TestStreamingReadsBypassCache(t *testing.T) {
+	big := bytes.Repeat([]byte("0123456789abcdef"), 64*1024) // 1MB
+	backend := &contentBackend{objects: map[string][]byte{
+		"hot.txt":  []byte("hot data"),
+		"scan.dat": big,
+	}}
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 64 * 1024, MaxEntries: 100})
+	t.Cleanup(func() { _ = lru.Close() })
+	filesystem := NewFileSystem(backend, lru, &recordingBuffer{}, nil, &Config{
+		DefaultMode:   0644,
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+		Streaming:     StreamingConfig{MinFileSize: 512 * 1024},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+	raw := fs.NewNodeFS(filesystem.Root(), &fs.Options{})
+
+	if got := readRoot(t, raw, "hot.txt", 0, 8); got != "hot data" {
+		t.Fatalf("cat hot.txt = %q", got)
+	}
+	backend.requests()
+
+	// Scan the whole file through one handle in reads smaller than a block
+	entry, status := lookupRoot(raw, "scan.dat")
+	if status != fuse.OK {
+		t.Fatalf("Lookup(scan.dat) status = %v", status)
+	}
+	open := &fuse.OpenOut{}
+	if status := raw.Open(nil, &fuse.OpenIn{InHeader: fuse.InHeader{NodeId: entry.NodeId}, Flags: syscall.O_RDONLY}, open); status != fuse.OK {
+		t.Fatalf("Open(scan.dat) status = %v", status)
+	}
+	const chunk = 16 * 1024
+	var scanned []byte
+	for off := 0; off < len(big); off += chunk {
+		buf := make([]byte, chunk)
+		result, status := raw.Read(nil, &fuse.ReadIn{InHeader: fuse.InHeader{NodeId: entry.NodeId}, Fh: open.Fh, Offset: uint64(off), Size: chunk}, buf)
+		if status != fuse.OK {
+			t.Fatalf("Read(scan.dat, %d) status = %v", off, status)
+		}
+		data, _ := result.Bytes(buf)
+		scanned = append(scanned, data...)
+	}
+	if !bytes.Equal(scanned, big) {
+		t.Fatalf("scan read %d bytes that differ from the object", len(scanned))
+	}
+
+	// Each aligned block was fetched once and served the reads within it
+	if _, gets := backend.requests(); gets != len(big)/defaultFetchAlignment {
+		t.Errorf("scan made %d GETs, want %d", gets, len(big)/defaultFetchAlignment)
+	}
+	if data := lru.Get("scan.dat", 0, chunk); data != nil {
+		t.Error("streamed range was inserted into the cache")
+	}
+	readRoot(t, raw, "hot.txt", 0, 8)
+	if _, gets := backend.requests(); gets != 0 {
+		t.Errorf("hot.txt was evicted by the scan: reading it again made %d GETs", gets)
+	}
+
+	stats := filesystem.GetStats()
+	if stats.StreamingReads != int64(len(big)/chunk) || stats.BypassedBytes != int64(len(big)) {
+		t.Errorf("StreamingReads, BypassedBytes = %d, %d; want %d, %d", stats.StreamingReads, stats.BypassedBytes, len(big)/chunk, len(big))
+	}
+}
+
+func TestStreamingConfigSelectsFiles(t *testing.T) {
+	config := StreamingConfig{MinFileSize: 1 << 30, Prefixes: []string{"scans/"}}
+	tests := []struct {
+		path string
+		size int64
+		want bool
+	}{
+		{"data/small.txt", 1024, false},
+		{"data/huge.bin", 2 << 30, true},
+		{"scans/small.txt", 1024, true},
+	}
+	for _, tt := range tests {
+		if got := config.streams(tt.path, tt.size); got != tt.want {
+			t.Errorf("streams(%s, %d) = %v, want %v", tt.path, tt.size, got, tt.want)
+		}
+	}
+	if (StreamingConfig{}).streams("data/huge.bin", 2<<30) {
+		t.Error("streaming is enabled without a size or prefix")
+	}
+}
@@ -0,0 +1,26 @@
+package fuse
+
+import (
+	"context"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// keySyncer is implemented by write buffers that can flush the buffered
+// writes of one key and wait until the backend has stored them
+type keySyncer interface {
+	SyncKey(ctx context.Context, key string) error
+}
+
+// syncBuffered flushes the buffered writes of key and waits for them to
+// reach the backend. Buffers without per-key sync wait for every buffered
+// write instead.
+func syncBuffered(ctx context.Context, buffer types.WriteBuffer, key string) error {
+	if syncer, ok := buffer.(keySyncer); ok {
+		return syncer.SyncKey(ctx, key)
+	}
+	if err := buffer.Flush(key); err != nil {
+		return err
+	}
+	return buffer.FlushAll()
+}
@@ -0,0 +1,116 @@
+package fuse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/buffer"
+)
+
+// objectStore collects the objects a write buffer flushes, failing every
+// flush while failing is set
+type objectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	failing bool
+}
+
+func (s *objectStore) put(key string, data []byte, offset int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		return errors.New("backend unavailable")
+	}
+	s.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *objectStore) get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	return data, ok
+}
+
+// newSyncOnCloseHandle returns a handle on path whose writes go through a
+// real write buffer that only flushes when asked
+func newSyncOnCloseHandle(t *testing.T, path string) (*FileHandle, *objectStore) {
+	t.Helper()
+
+	store := &objectStore{objects: make(map[string][]byte)}
+	wb, err := buffer.NewWriteBuffer(&buffer.WriteBufferConfig{
+		MaxBufferSize:  1024 * 1024,
+		MaxBuffers:     10,
+		FlushInterval:  time.Hour,
+		FlushThreshold: 1024 * 1024,
+	}, store.put)
+	if err != nil {
+		t.Fatalf("NewWriteBuffer failed: %v", err)
+	}
+	t.Cleanup(func() { _ = wb.Close() })
+
+	filesystem := NewFileSystem(nil, nil, wb, nil, &Config{
+		SyncOnClose:   true,
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+
+	return &FileHandle{
+		fs:     filesystem,
+		handle: 1,
+		file:   &OpenFile{path: path},
+	}, store
+}
+
+func TestSyncOnCloseStoresObjectBeforeCloseReturns(t *testing.T) {
+	fh, store := newSyncOnCloseHandle(t, "reports/q3.csv")
+
+	if _, errno := fh.Write(context.Background(), []byte("id,total\n1,42\n"), 0); errno != 0 {
+		t.Fatalf("Write failed: %v", errno)
+	}
+	if _, ok := store.get("reports/q3.csv"); ok {
+		t.Fatal("object stored before close")
+	}
+
+	if errno := fh.Flush(context.Background()); errno != 0 {
+		t.Fatalf("Flush failed: %v", errno)
+	}
+	data, ok := store.get("reports/q3.csv")
+	if !ok || string(data) != "id,total\n1,42\n" {
+		t.Errorf("object after close = %q (present %v), want the written data", data, ok)
+	}
+
+	stats := fh.fs.GetStats()
+	if stats.Syncs != 1 || stats.SyncErrors != 0 {
+		t.Errorf("syncs/errors = %d/%d, want 1/0", stats.Syncs, stats.SyncErrors)
+	}
+}
+
+func TestSyncOnCloseReturnsBackendError(t *testing.T) {
+	fh, store := newSyncOnCloseHandle(t, "reports/q4.csv")
+	store.failing = true
+
+	if _, errno := fh.Write(context.Background(), []byte("id,total\n"), 0); errno != 0 {
+		t.Fatalf("Write failed: %v", errno)
+	}
+	if errno := fh.Flush(context.Background()); errno == 0 {
+		t.Fatal("Flush succeeded while the backend was failing")
+	}
+	if stats := fh.fs.GetStats(); stats.SyncErrors != 1 {
+		t.Errorf("sync errors = %d, want 1", stats.SyncErrors)
+	}
+
+	// The data stays dirty so a later close can retry
+	store.mu.Lock()
+	store.failing = false
+	store.mu.Unlock()
+	if errno := fh.Flush(context.Background()); errno != 0 {
+		t.Fatalf("retried Flush failed: %v", errno)
+	}
+	if _, ok := store.get("reports/q4.csv"); !ok {
+		t.Error("object missing after the retried close")
+	}
+}
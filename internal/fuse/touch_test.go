@@ -0,0 +1,130 @@
+package fuse
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// touchBackend records touches and reports them through HeadObject
+type touchBackend struct {
+	types.Backend
+	mu       sync.Mutex
+	modified map[string]time.Time
+	touches  int
+}
+
+func (b *touchBackend) Touch(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.touches++
+	if _, ok := b.modified[key]; !ok {
+		return errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	b.modified[key] = time.Now()
+	return nil
+}
+
+func (b *touchBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	modified, ok := b.modified[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	return &types.ObjectInfo{Key: key, Size: 3, LastModified: modified}, nil
+}
+
+func newTouchNode(t *testing.T, backend types.Backend, config *Config) *FileNode {
+	t.Helper()
+	filesystem := NewFileSystem(backend, nil, &recordingBuffer{}, nil, config)
+	t.Cleanup(filesystem.readAhead.Stop)
+	return &FileNode{
+		fs:   filesystem,
+		path: "docs/a.txt",
+		info: &types.ObjectInfo{Key: "docs/a.txt", Size: 3, LastModified: time.Unix(1000, 0)},
+	}
+}
+
+func TestSetattrTouchesObject(t *testing.T) {
+	backend := &touchBackend{modified: map[string]time.Time{"docs/a.txt": time.Unix(1000, 0)}}
+	node := newTouchNode(t, backend, &Config{WriteCoalesce: &WriteCoalescerConfig{Enabled: false}})
+
+	in := &fuse.SetAttrIn{}
+	in.Valid = fuse.FATTR_ATIME | fuse.FATTR_MTIME | fuse.FATTR_ATIME_NOW | fuse.FATTR_MTIME_NOW
+	var out fuse.AttrOut
+	if errno := node.Setattr(context.Background(), nil, in, &out); errno != 0 {
+		t.Fatalf("Setattr errno = %v", errno)
+	}
+
+	if backend.touches != 1 {
+		t.Errorf("backend touched %d times, want 1", backend.touches)
+	}
+	if out.Mtime <= 1000 {
+		t.Errorf("Mtime = %d, want the time of the touch", out.Mtime)
+	}
+}
+
+func TestSetattrAtimeOnly(t *testing.T) {
+	backend := &touchBackend{modified: map[string]time.Time{"docs/a.txt": time.Unix(1000, 0)}}
+	node := newTouchNode(t, backend, &Config{WriteCoalesce: &WriteCoalescerConfig{Enabled: false}})
+
+	in := &fuse.SetAttrIn{}
+	in.Valid = fuse.FATTR_ATIME
+	var out fuse.AttrOut
+	if errno := node.Setattr(context.Background(), nil, in, &out); errno != 0 {
+		t.Fatalf("Setattr errno = %v", errno)
+	}
+	if backend.touches != 0 {
+		t.Errorf("backend touched %d times for an atime update, want 0", backend.touches)
+	}
+}
+
+func TestSetattrUnsupportedAttributes(t *testing.T) {
+	backend := &touchBackend{modified: map[string]time.Time{}}
+	node := newTouchNode(t, backend, &Config{WriteCoalesce: &WriteCoalescerConfig{Enabled: false}})
+
+	in := &fuse.SetAttrIn{}
+	in.Valid = fuse.FATTR_SIZE | fuse.FATTR_MTIME
+	var out fuse.AttrOut
+	if errno := node.Setattr(context.Background(), nil, in, &out); errno != syscall.ENOTSUP {
+		t.Errorf("Setattr(size) errno = %v, want ENOTSUP", errno)
+	}
+	if backend.touches != 0 {
+		t.Errorf("backend touched %d times, want 0", backend.touches)
+	}
+}
+
+func TestSetattrReadOnly(t *testing.T) {
+	backend := &touchBackend{modified: map[string]time.Time{"docs/a.txt": time.Unix(1000, 0)}}
+	node := newTouchNode(t, backend, &Config{ReadOnly: true, WriteCoalesce: &WriteCoalescerConfig{Enabled: false}})
+
+	in := &fuse.SetAttrIn{}
+	in.Valid = fuse.FATTR_MTIME
+	var out fuse.AttrOut
+	if errno := node.Setattr(context.Background(), nil, in, &out); errno != syscall.EROFS {
+		t.Errorf("Setattr errno = %v, want EROFS", errno)
+	}
+}
+
+func TestSetattrUnflushedFile(t *testing.T) {
+	backend := &touchBackend{modified: map[string]time.Time{}}
+	node := newTouchNode(t, backend, &Config{WriteCoalesce: &WriteCoalescerConfig{Enabled: false}})
+
+	in := &fuse.SetAttrIn{}
+	in.Valid = fuse.FATTR_MTIME | fuse.FATTR_MTIME_NOW
+	var out fuse.AttrOut
+	if errno := node.Setattr(context.Background(), nil, in, &out); errno != 0 {
+		t.Fatalf("Setattr errno = %v for a file not yet uploaded", errno)
+	}
+	if out.Mtime <= 1000 {
+		t.Errorf("Mtime = %d, want the time of the touch", out.Mtime)
+	}
+}
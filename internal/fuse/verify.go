@@ -0,0 +1,82 @@
+package fuse
+
+import (
+	"context"
+	"sync"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// objectVersions remembers which version of each object its cached ranges
+// were verified against, so a cache hit can be checked against the
+// object's current metadata
+type objectVersions struct {
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+// observe records version as current for key, reporting false when it
+// differs from the version previously recorded
+func (v *objectVersions) observe(key, version string) bool {
+	if version == "" {
+		return true
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.versions == nil {
+		v.versions = make(map[string]string)
+	}
+	previous, seen := v.versions[key]
+	v.versions[key] = version
+	return !seen || previous == version
+}
+
+// cachedRangeFresh checks data served from the cache for the size bytes at
+// offset of key against the object's current metadata. The range is stale
+// when its length differs from what the object now holds at that offset,
+// or when the object's ETag or checksum changed since its ranges were last
+// verified. Cached data is trusted when the object cannot be inspected.
+func cachedRangeFresh(ctx context.Context, backend types.Backend, versions *objectVersions, key string, offset, size int64, data []byte) bool {
+	info, err := backend.HeadObject(ctx, key)
+	if err != nil || info == nil {
+		return true
+	}
+
+	expected := size
+	if remaining := info.Size - offset; remaining < expected {
+		expected = max(remaining, 0)
+	}
+	if int64(len(data)) != expected {
+		versions.observe(key, objectVersion(info))
+		return false
+	}
+	return versions.observe(key, objectVersion(info))
+}
+
+// objectVersion identifies the content of an object from its metadata
+func objectVersion(info *types.ObjectInfo) string {
+	if info.ETag != "" {
+		return info.ETag
+	}
+	return info.Checksum
+}
+
+// verifyCachedRead returns data served from the cache for the size bytes at
+// offset of path, or nil after invalidating every cached range of path when
+// verification finds the data stale. The caller then refetches the range.
+func (fs *FileSystem) verifyCachedRead(ctx context.Context, path string, offset, size int64, data []byte) []byte {
+	if !fs.config.VerifyCachedReads || cachedRangeFresh(ctx, fs.backend, &fs.versions, path, offset, size, data) {
+		return data
+	}
+
+	fs.cache.Delete(path)
+	fs.stats.mu.Lock()
+	fs.stats.StaleRefetches++
+	fs.stats.mu.Unlock()
+	if fs.metrics != nil {
+		fs.metrics.RecordOperation("stale_refetch", 0, int64(len(data)), true)
+	}
+	return nil
+}
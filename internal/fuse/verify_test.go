@@ -0,0 +1,159 @@
+package fuse
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// mutableBackend serves one object that the test can replace
+type mutableBackend struct {
+	types.Backend
+	mu   sync.Mutex
+	data []byte
+	etag string
+	gets int
+}
+
+func (b *mutableBackend) set(data []byte, etag string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data, b.etag = data, etag
+}
+
+func (b *mutableBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gets++
+	if offset >= int64(len(b.data)) {
+		return nil, nil
+	}
+	end := min(offset+size, int64(len(b.data)))
+	return append([]byte(nil), b.data[offset:end]...), nil
+}
+
+func (b *mutableBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &types.ObjectInfo{Key: key, Size: int64(len(b.data)), ETag: b.etag}, nil
+}
+
+func newVerifyHandle(t *testing.T, backend types.Backend, verify bool) *FileHandle {
+	t.Helper()
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100})
+	t.Cleanup(func() { _ = lru.Close() })
+
+	filesystem := NewFileSystem(backend, lru, &recordingBuffer{}, nil, &Config{
+		WriteCoalesce:     &WriteCoalescerConfig{Enabled: false},
+		VerifyCachedReads: verify,
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+	return &FileHandle{fs: filesystem, handle: 1, file: &OpenFile{path: "logs/app.log"}}
+}
+
+func readRange(t *testing.T, fh *FileHandle, size int) []byte {
+	t.Helper()
+	result, errno := fh.Read(context.Background(), make([]byte, size), 0)
+	if errno != 0 {
+		t.Fatalf("Read() errno = %v", errno)
+	}
+	data, _ := result.Bytes(nil)
+	return data
+}
+
+func TestVerifiedReadRefetchesSizeChangedObject(t *testing.T) {
+	backend := &mutableBackend{}
+	backend.set(bytes.Repeat([]byte("a"), 8192), "")
+	fh := newVerifyHandle(t, backend, true)
+
+	if got := readRange(t, fh, 4096); len(got) != 4096 {
+		t.Fatalf("first read returned %d bytes, want 4096", len(got))
+	}
+
+	// The object is truncated underneath the cached range; without an ETag
+	// only the size reveals the change
+	truncated := bytes.Repeat([]byte("b"), 1000)
+	backend.set(truncated, "")
+
+	if got := readRange(t, fh, 4096); !bytes.Equal(got, truncated) {
+		t.Fatalf("read returned %d bytes of stale data, want the refetched 1000", len(got))
+	}
+	if stats := fh.fs.GetStats(); stats.StaleRefetches != 1 {
+		t.Errorf("stale refetches = %d, want 1", stats.StaleRefetches)
+	}
+
+	// The refetched range is served from the cache again
+	gets := backend.gets
+	if got := readRange(t, fh, 1000); !bytes.Equal(got, truncated) {
+		t.Fatal("verified cache hit returned the wrong data")
+	}
+	if backend.gets != gets {
+		t.Error("fresh cache hit was refetched")
+	}
+}
+
+func TestVerifiedReadRefetchesRewrittenObject(t *testing.T) {
+	backend := &mutableBackend{}
+	backend.set([]byte("version one"), `"v1"`)
+	fh := newVerifyHandle(t, backend, true)
+
+	readRange(t, fh, 11)
+	readRange(t, fh, 11) // Verified hit records the cached version
+
+	backend.set([]byte("version two"), `"v2"`)
+	if got := readRange(t, fh, 11); string(got) != "version two" {
+		t.Errorf("read = %q after a same-size rewrite, want the new content", got)
+	}
+	if stats := fh.fs.GetStats(); stats.StaleRefetches != 1 {
+		t.Errorf("stale refetches = %d, want 1", stats.StaleRefetches)
+	}
+}
+
+func TestUnverifiedReadServesCache(t *testing.T) {
+	backend := &mutableBackend{}
+	backend.set(bytes.Repeat([]byte("a"), 8192), "")
+	fh := newVerifyHandle(t, backend, false)
+
+	readRange(t, fh, 4096)
+	backend.set(bytes.Repeat([]byte("b"), 1000), "")
+
+	if got := readRange(t, fh, 4096); len(got) != 4096 {
+		t.Errorf("read returned %d bytes, want the 4096 cached without verification", len(got))
+	}
+	if stats := fh.fs.GetStats(); stats.StaleRefetches != 0 {
+		t.Errorf("stale refetches = %d without verification", stats.StaleRefetches)
+	}
+}
+
+func TestReadMissRecordsOpenETag(t *testing.T) {
+	backend := &mutableBackend{}
+	backend.set([]byte("version one"), `"v1"`)
+
+	lru := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 1 << 20, MaxEntries: 100, TTL: 20 * time.Millisecond, CleanupInterval: time.Hour})
+	t.Cleanup(func() { _ = lru.Close() })
+	var gotETag string
+	lru.SetRevalidator(func(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+		gotETag = etag
+		return nil, true, nil, nil
+	})
+
+	filesystem := NewFileSystem(backend, lru, &recordingBuffer{}, nil, &Config{
+		WriteCoalesce: &WriteCoalescerConfig{Enabled: false},
+	})
+	t.Cleanup(filesystem.readAhead.Stop)
+	fh := &FileHandle{fs: filesystem, handle: 1, file: &OpenFile{path: "logs/app.log", etag: `"v1"`}}
+
+	readRange(t, fh, 11)
+	time.Sleep(30 * time.Millisecond)
+	if got := readRange(t, fh, 11); string(got) != "version one" {
+		t.Errorf("read = %q after revalidation, want the cached content", got)
+	}
+	if gotETag != `"v1"` {
+		t.Errorf("revalidation sent ETag %q, want the one the file was opened at", gotETag)
+	}
+}
@@ -21,17 +21,26 @@ type Collector struct {
 	operationCounter  *prometheus.CounterVec
 	operationDuration *prometheus.HistogramVec
 	operationSize     *prometheus.HistogramVec
+	objectSize        *prometheus.HistogramVec
 	cacheHitCounter   *prometheus.CounterVec
 	cacheSizeGauge    *prometheus.GaugeVec
 	activeConnections prometheus.Gauge
+	queueDepth        *prometheus.GaugeVec
+	backendLatency    *prometheus.GaugeVec
+	backendReachable  *prometheus.GaugeVec
+	mirrorBacklog     prometheus.Gauge
+	mirrorLag         prometheus.Gauge
+	prefetchInflight  prometheus.Gauge
 	errorCounter      *prometheus.CounterVec
 
 	// Internal tracking
-	operations map[string]*OperationMetrics
-	lastReset  time.Time
+	operations  map[string]*OperationMetrics
+	objectSizes sizeDistribution
+	lastReset   time.Time
 
 	// HTTP server for metrics endpoint
-	server *http.Server
+	server   *http.Server
+	handlers map[string]http.Handler
 }
 
 // Config represents metrics configuration
@@ -43,8 +52,20 @@ type Config struct {
 	Namespace      string            `yaml:"namespace"`
 	Subsystem      string            `yaml:"subsystem"`
 	UpdateInterval time.Duration     `yaml:"update_interval"`
+
+	// MountID is added to every metric as a constant "mount" label, so
+	// several mounts in one process can report side by side
+	MountID string `yaml:"mount_id"`
+
+	// Registry is shared with other collectors instead of creating one.
+	// A collector with a shared registry does not serve it; its owner does,
+	// for example through RegistryHandler.
+	Registry *prometheus.Registry `yaml:"-"`
 }
 
+// MountLabel is the constant label identifying a mount's metrics
+const MountLabel = "mount"
+
 // OperationMetrics tracks metrics for a specific operation type
 type OperationMetrics struct {
 	Count         int64         `json:"count"`
@@ -74,8 +95,11 @@ func NewCollector(config *Config) (*Collector, error) {
 		return &Collector{config: config}, nil
 	}
 
-	// Create Prometheus registry
-	registry := prometheus.NewRegistry()
+	// Create Prometheus registry unless one is shared between mounts
+	registry := config.Registry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+	}
 
 	collector := &Collector{
 		config:     config,
@@ -103,11 +127,15 @@ func (c *Collector) Start(ctx context.Context) error {
 		return nil
 	}
 
+	// The owner of a shared registry serves it
+	if c.config.Registry != nil {
+		go c.updateLoop(ctx)
+		return nil
+	}
+
 	// Create HTTP server for metrics endpoint
 	mux := http.NewServeMux()
-	mux.Handle(c.config.Path, promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{
-		EnableOpenMetrics: true,
-	}))
+	mux.Handle(c.config.Path, RegistryHandler(c.registry))
 
 	// Add health check endpoint
 	mux.HandleFunc("/health", c.healthHandler)
@@ -116,6 +144,13 @@ func (c *Collector) Start(ctx context.Context) error {
 	mux.HandleFunc("/debug/metrics", c.debugMetricsHandler)
 	mux.HandleFunc("/debug/operations", c.debugOperationsHandler)
 
+	// Add endpoints registered by other components
+	c.mu.RLock()
+	for pattern, handler := range c.handlers {
+		mux.Handle(pattern, handler)
+	}
+	c.mu.RUnlock()
+
 	c.server = &http.Server{
 		Addr:              fmt.Sprintf(":%d", c.config.Port),
 		Handler:           mux,
@@ -138,6 +173,26 @@ func (c *Collector) Start(ctx context.Context) error {
 	return nil
 }
 
+// RegistryHandler serves the metrics gathered by registry, such as one
+// shared by several mounts
+func RegistryHandler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// RegisterHandler adds an endpoint to the metrics server; it must be
+// called before Start
+func (c *Collector) RegisterHandler(pattern string, handler http.Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handlers == nil {
+		c.handlers = make(map[string]http.Handler)
+	}
+	c.handlers[pattern] = handler
+}
+
 // Stop stops the metrics collection server
 func (c *Collector) Stop(ctx context.Context) error {
 	if c.server != nil {
@@ -207,6 +262,28 @@ func (c *Collector) RecordOperation(operation string, duration time.Duration, si
 	}
 }
 
+// RecordObjectSize records the size of an object transferred by a PUT or
+// GET. Operations other than ObjectSizePut and ObjectSizeGet are ignored.
+func (c *Collector) RecordObjectSize(operation string, size int64) {
+	if !c.config.Enabled || size < 0 {
+		return
+	}
+	if operation != ObjectSizePut && operation != ObjectSizeGet {
+		return
+	}
+
+	c.objectSize.With(prometheus.Labels{
+		"operation": operation,
+	}).Observe(float64(size))
+	c.objectSizes.record(operation, size)
+}
+
+// ObjectSizePercentiles returns the in-memory size distribution of
+// recorded PUTs and GETs
+func (c *Collector) ObjectSizePercentiles() map[string]SizePercentiles {
+	return c.objectSizes.percentiles()
+}
+
 // RecordCacheHit records a cache hit
 func (c *Collector) RecordCacheHit(key string, size int64) {
 	if !c.config.Enabled {
@@ -263,6 +340,65 @@ func (c *Collector) UpdateActiveConnections(count int) {
 	c.activeConnections.Set(float64(count))
 }
 
+// UpdateQueueDepth updates the number of operations of class waiting for
+// a concurrency slot
+func (c *Collector) UpdateQueueDepth(class string, depth int) {
+	if !c.config.Enabled {
+		return
+	}
+
+	c.queueDepth.With(prometheus.Labels{
+		"class": class,
+	}).Set(float64(depth))
+}
+
+// UpdateBackendLatency updates the rolling round-trip latency of backend
+func (c *Collector) UpdateBackendLatency(backend string, latency time.Duration) {
+	if !c.config.Enabled {
+		return
+	}
+
+	c.backendLatency.With(prometheus.Labels{
+		"backend": backend,
+	}).Set(latency.Seconds())
+}
+
+// UpdateBackendReachable records whether the latest ping of backend
+// reached it
+func (c *Collector) UpdateBackendReachable(backend string, reachable bool) {
+	if !c.config.Enabled {
+		return
+	}
+
+	value := 0.0
+	if reachable {
+		value = 1
+	}
+	c.backendReachable.With(prometheus.Labels{
+		"backend": backend,
+	}).Set(value)
+}
+
+// UpdateMirrorBacklog updates the number of keys whose mirror copy is out
+// of date and the age of the oldest
+func (c *Collector) UpdateMirrorBacklog(keys int, lag time.Duration) {
+	if !c.config.Enabled {
+		return
+	}
+
+	c.mirrorBacklog.Set(float64(keys))
+	c.mirrorLag.Set(lag.Seconds())
+}
+
+// UpdatePrefetchInflight updates the bytes currently being prefetched
+func (c *Collector) UpdatePrefetchInflight(bytes int64) {
+	if !c.config.Enabled {
+		return
+	}
+
+	c.prefetchInflight.Set(float64(bytes))
+}
+
 // GetMetrics returns current metrics
 func (c *Collector) GetMetrics() map[string]interface{} {
 	c.mu.RLock()
@@ -285,6 +421,7 @@ func (c *Collector) GetMetrics() map[string]interface{} {
 	}
 
 	metrics["operations"] = operations
+	metrics["object_sizes"] = c.objectSizes.percentiles()
 	metrics["last_reset"] = c.lastReset
 	metrics["uptime"] = time.Since(c.lastReset)
 
@@ -297,41 +434,59 @@ func (c *Collector) ResetMetrics() {
 	defer c.mu.Unlock()
 
 	c.operations = make(map[string]*OperationMetrics)
+	c.objectSizes.reset()
 	c.lastReset = time.Now()
 }
 
 // Helper methods
 
 func (c *Collector) initMetrics() error {
+	labels := c.constLabels()
+
 	// Operation metrics
 	c.operationCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Namespace: c.config.Namespace,
-			Subsystem: c.config.Subsystem,
-			Name:      "operations_total",
-			Help:      "Total number of operations",
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "operations_total",
+			Help:        "Total number of operations",
 		},
 		[]string{"operation", "status"},
 	)
 
 	c.operationDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Namespace: c.config.Namespace,
-			Subsystem: c.config.Subsystem,
-			Name:      "operation_duration_seconds",
-			Help:      "Duration of operations in seconds",
-			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15), // 1ms to ~32s
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "operation_duration_seconds",
+			Help:        "Duration of operations in seconds",
+			Buckets:     prometheus.ExponentialBuckets(0.001, 2, 15), // 1ms to ~32s
 		},
 		[]string{"operation"},
 	)
 
 	c.operationSize = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Namespace: c.config.Namespace,
-			Subsystem: c.config.Subsystem,
-			Name:      "operation_size_bytes",
-			Help:      "Size of operations in bytes",
-			Buckets:   prometheus.ExponentialBuckets(1024, 2, 20), // 1KB to ~1GB
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "operation_size_bytes",
+			Help:        "Size of operations in bytes",
+			Buckets:     prometheus.ExponentialBuckets(1024, 2, 20), // 1KB to ~1GB
+		},
+		[]string{"operation"},
+	)
+
+	c.objectSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "object_size_bytes",
+			Help:        "Size of objects transferred by PUT and GET requests",
+			Buckets:     objectSizeBuckets, // 1B to 1GB
 		},
 		[]string{"operation"},
 	)
@@ -339,20 +494,22 @@ func (c *Collector) initMetrics() error {
 	// Cache metrics
 	c.cacheHitCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Namespace: c.config.Namespace,
-			Subsystem: c.config.Subsystem,
-			Name:      "cache_requests_total",
-			Help:      "Total number of cache requests",
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "cache_requests_total",
+			Help:        "Total number of cache requests",
 		},
 		[]string{"type", "source"},
 	)
 
 	c.cacheSizeGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Namespace: c.config.Namespace,
-			Subsystem: c.config.Subsystem,
-			Name:      "cache_size_bytes",
-			Help:      "Current cache size in bytes",
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "cache_size_bytes",
+			Help:        "Current cache size in bytes",
 		},
 		[]string{"level"},
 	)
@@ -360,20 +517,85 @@ func (c *Collector) initMetrics() error {
 	// Connection metrics
 	c.activeConnections = prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Namespace: c.config.Namespace,
-			Subsystem: c.config.Subsystem,
-			Name:      "active_connections",
-			Help:      "Number of active connections",
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "active_connections",
+			Help:        "Number of active connections",
+		},
+	)
+
+	c.queueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "concurrency_queue_depth",
+			Help:        "Number of operations waiting for a concurrency slot",
+		},
+		[]string{"class"},
+	)
+
+	c.backendLatency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "backend_latency_seconds",
+			Help:        "Rolling round-trip latency of backend pings",
+		},
+		[]string{"backend"},
+	)
+
+	c.backendReachable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "backend_reachable",
+			Help:        "Whether the latest backend ping succeeded (1) or could not connect (0)",
+		},
+		[]string{"backend"},
+	)
+
+	c.mirrorBacklog = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "mirror_backlog",
+			Help:        "Number of keys whose mirror copy is out of date",
+		},
+	)
+
+	c.mirrorLag = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "mirror_lag_seconds",
+			Help:        "Age of the oldest change not yet applied to the mirror",
+		},
+	)
+
+	c.prefetchInflight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "prefetch_inflight_bytes",
+			Help:        "Bytes currently being prefetched into the cache",
 		},
 	)
 
 	// Error metrics
 	c.errorCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Namespace: c.config.Namespace,
-			Subsystem: c.config.Subsystem,
-			Name:      "errors_total",
-			Help:      "Total number of errors",
+			Namespace:   c.config.Namespace,
+			Subsystem:   c.config.Subsystem,
+			ConstLabels: labels,
+			Name:        "errors_total",
+			Help:        "Total number of errors",
 		},
 		[]string{"operation", "type"},
 	)
@@ -381,14 +603,33 @@ func (c *Collector) initMetrics() error {
 	return nil
 }
 
+// constLabels returns the configured labels and mount ID added to every metric
+func (c *Collector) constLabels() prometheus.Labels {
+	labels := make(prometheus.Labels, len(c.config.Labels)+1)
+	for name, value := range c.config.Labels {
+		labels[name] = value
+	}
+	if c.config.MountID != "" {
+		labels[MountLabel] = c.config.MountID
+	}
+	return labels
+}
+
 func (c *Collector) registerMetrics() error {
 	metrics := []prometheus.Collector{
 		c.operationCounter,
 		c.operationDuration,
 		c.operationSize,
+		c.objectSize,
 		c.cacheHitCounter,
 		c.cacheSizeGauge,
 		c.activeConnections,
+		c.queueDepth,
+		c.backendLatency,
+		c.backendReachable,
+		c.mirrorBacklog,
+		c.mirrorLag,
+		c.prefetchInflight,
 		c.errorCounter,
 	}
 
@@ -481,6 +722,21 @@ func (c *Collector) debugMetricsHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	writef("\n  },\n")
+	writef("  \"object_sizes\": {\n")
+
+	if sizes, ok := metrics["object_sizes"].(map[string]SizePercentiles); ok {
+		first := true
+		for name, size := range sizes {
+			if !first {
+				writef(",\n")
+			}
+			writef("    \"%s\": {\"count\": %d, \"total_bytes\": %d, \"p50_bytes\": %d, \"p90_bytes\": %d, \"p99_bytes\": %d, \"max_bytes\": %d}",
+				name, size.Count, size.Total, size.P50, size.P90, size.P99, size.Max)
+			first = false
+		}
+	}
+
 	writef("\n  }\n")
 	writef("}\n")
 }
@@ -614,3 +614,27 @@ func TestIndexOfHelper(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdatePrefetchInflight(t *testing.T) {
+	collector, err := NewCollector(nil)
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+
+	collector.UpdatePrefetchInflight(4096)
+
+	families, err := collector.registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "objectfs_prefetch_inflight_bytes" {
+			continue
+		}
+		if got := family.GetMetric()[0].GetGauge().GetValue(); got != 4096 {
+			t.Errorf("prefetch_inflight_bytes = %v, want 4096", got)
+		}
+		return
+	}
+	t.Error("prefetch_inflight_bytes gauge not registered")
+}
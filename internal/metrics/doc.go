@@ -90,6 +90,11 @@ Counters:
 Histograms:
   - objectfs_operation_duration_seconds{operation}: Operation latency distribution
   - objectfs_operation_size_bytes{operation}: Operation size distribution
+  - objectfs_object_size_bytes{operation}: Sizes of objects moved by put and get, in power-of-four buckets from 1B to 1GB
+
+Object sizes inform MultipartThreshold and tiering choices. Only the put and
+get operations are tracked, keeping the label set fixed, and approximate
+p50/p90/p99 sizes are reported under object_sizes in /debug/metrics.
 
 Gauges:
   - objectfs_cache_size_bytes{level}: Current cache size per level
@@ -131,6 +136,15 @@ The metrics server exposes several endpoints:
 	read                 15234         12         45ms        524288
 	write                 8901          3         89ms       1048576
 
+/debug/recent-ops - The latest backend operations, oldest first, with key,
+duration, result, attempts, and circuit breaker state (monitoring.recent_ops)
+
+	curl http://localhost:8080/debug/recent-ops
+	[
+	  {"seq": 4211, "time": "...", "op": "GetObject", "key": "data/a.csv",
+	   "duration": 41000000, "result": "ok", "attempts": 1, "breaker": "CLOSED"}
+	]
+
 # Configuration
 
 The Config struct controls metrics behavior:
@@ -149,6 +163,27 @@ The Config struct controls metrics behavior:
 		},
 	}
 
+# Multiple Mounts
+
+Mounts in one process share a registry and tell their metrics apart by
+MountID, which becomes a constant "mount" label. Collectors with a shared
+Registry do not start a server; the owner serves the registry once:
+
+	registry := prometheus.NewRegistry()
+	for _, id := range []string{"photos", "logs"} {
+		collector, err := metrics.NewCollector(&metrics.Config{
+			Enabled:   true,
+			Namespace: "objectfs",
+			MountID:   id,
+			Registry:  registry,
+		})
+		// ...
+	}
+	http.Handle("/metrics", metrics.RegistryHandler(registry))
+
+	objectfs_operations_total{mount="photos",operation="read",status="success"} 42
+	objectfs_operations_total{mount="logs",operation="read",status="success"} 7
+
 # Best Practices
 
 1. Operation Recording
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMountsShareRegistryWithDistinctLabels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	newMount := func(id string) *Collector {
+		c, err := NewCollector(&Config{
+			Enabled:   true,
+			Path:      "/metrics",
+			Namespace: "objectfs",
+			MountID:   id,
+			Registry:  registry,
+		})
+		if err != nil {
+			t.Fatalf("NewCollector(%s) error = %v", id, err)
+		}
+		return c
+	}
+
+	photos := newMount("photos")
+	logs := newMount("logs")
+	photos.RecordOperation("read", time.Millisecond, 1024, true)
+	photos.RecordOperation("read", time.Millisecond, 1024, true)
+	logs.RecordOperation("read", time.Millisecond, 1024, true)
+
+	recorder := httptest.NewRecorder()
+	RegistryHandler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+
+	for _, want := range []string{
+		`objectfs_operations_total{mount="photos",operation="read",status="success"} 2`,
+		`objectfs_operations_total{mount="logs",operation="read",status="success"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics missing %s", want)
+		}
+	}
+}
+
+func TestSharedRegistryRejectsDuplicateMount(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := func() *Config {
+		return &Config{Enabled: true, Namespace: "objectfs", MountID: "data", Registry: registry}
+	}
+	if _, err := NewCollector(config()); err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+	if _, err := NewCollector(config()); err == nil {
+		t.Error("second collector with the same mount ID registered, want a collision error")
+	}
+}
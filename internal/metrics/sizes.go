@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+)
+
+// Object size operations. Sizes recorded under any other operation are
+// dropped so the histogram's label set stays bounded.
+const (
+	ObjectSizePut = "put"
+	ObjectSizeGet = "get"
+)
+
+// objectSizeBuckets are the upper bounds, in bytes, of the object size
+// histogram: powers of four from 1B to 1GB plus +Inf
+var objectSizeBuckets = exponentialBounds(1, 4, 16)
+
+func exponentialBounds(start, factor float64, count int) []float64 {
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start
+		start *= factor
+	}
+	return bounds
+}
+
+// SizePercentiles summarizes the recorded sizes of one operation. Each
+// percentile is the upper bound of the histogram bucket it falls in, capped
+// at the largest recorded size.
+type SizePercentiles struct {
+	Count int64 `json:"count"`
+	Total int64 `json:"total_bytes"`
+	P50   int64 `json:"p50_bytes"`
+	P90   int64 `json:"p90_bytes"`
+	P99   int64 `json:"p99_bytes"`
+	Max   int64 `json:"max_bytes"`
+}
+
+// sizeDistribution counts object sizes per operation in the histogram's
+// buckets, so percentiles are available in memory without keeping samples
+type sizeDistribution struct {
+	mu     sync.Mutex
+	counts map[string][]int64 // Per bucket, with a final +Inf bucket
+	totals map[string]int64
+	maxes  map[string]int64
+}
+
+func (d *sizeDistribution) record(operation string, size int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.counts == nil {
+		d.counts = make(map[string][]int64)
+		d.totals = make(map[string]int64)
+		d.maxes = make(map[string]int64)
+	}
+	counts, ok := d.counts[operation]
+	if !ok {
+		counts = make([]int64, len(objectSizeBuckets)+1)
+		d.counts[operation] = counts
+	}
+
+	bucket := len(objectSizeBuckets)
+	for i, bound := range objectSizeBuckets {
+		if float64(size) <= bound {
+			bucket = i
+			break
+		}
+	}
+	counts[bucket]++
+	d.totals[operation] += size
+	d.maxes[operation] = max(d.maxes[operation], size)
+}
+
+// percentiles returns the size summary of every recorded operation
+func (d *sizeDistribution) percentiles() map[string]SizePercentiles {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	summary := make(map[string]SizePercentiles, len(d.counts))
+	for operation, counts := range d.counts {
+		var count int64
+		for _, n := range counts {
+			count += n
+		}
+		largest := d.maxes[operation]
+		summary[operation] = SizePercentiles{
+			Count: count,
+			Total: d.totals[operation],
+			P50:   bucketQuantile(counts, count, 0.50, largest),
+			P90:   bucketQuantile(counts, count, 0.90, largest),
+			P99:   bucketQuantile(counts, count, 0.99, largest),
+			Max:   largest,
+		}
+	}
+	return summary
+}
+
+func (d *sizeDistribution) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.counts, d.totals, d.maxes = nil, nil, nil
+}
+
+// bucketQuantile returns the upper bound of the bucket holding quantile q,
+// or largest when that is smaller
+func bucketQuantile(counts []int64, total int64, q float64, largest int64) int64 {
+	if total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(total)))
+	var seen int64
+	for i, n := range counts {
+		seen += n
+		if seen >= rank && i < len(objectSizeBuckets) {
+			return min(int64(objectSizeBuckets[i]), largest)
+		}
+	}
+	return largest
+}
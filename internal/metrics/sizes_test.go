@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"testing"
+)
+
+// objectSizeBucketCounts gathers the cumulative bucket counts of the object
+// size histogram for operation, keyed by upper bound
+func objectSizeBucketCounts(t *testing.T, c *Collector, operation string) (map[float64]uint64, uint64) {
+	t.Helper()
+
+	families, err := c.registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "objectfs_object_size_bytes" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() != "operation" || label.GetValue() != operation {
+					continue
+				}
+				counts := make(map[float64]uint64)
+				for _, bucket := range metric.GetHistogram().GetBucket() {
+					counts[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+				}
+				return counts, metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return nil, 0
+}
+
+func TestRecordObjectSizePopulatesBuckets(t *testing.T) {
+	c, err := NewCollector(nil)
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+
+	for _, size := range []int64{100, 3000, 3000, 5 << 20, 2 << 30} {
+		c.RecordObjectSize(ObjectSizePut, size)
+	}
+	c.RecordObjectSize(ObjectSizeGet, 64)
+
+	counts, total := objectSizeBucketCounts(t, c, ObjectSizePut)
+	if total != 5 {
+		t.Fatalf("put sample count = %d, want 5", total)
+	}
+	want := map[float64]uint64{
+		64:       0,
+		256:      1, // 100B
+		1024:     1,
+		4096:     3, // 3000B twice
+		1 << 20:  3,
+		16 << 20: 4, // 5MB
+		1 << 30:  4, // 2GB only lands in +Inf
+	}
+	for bound, count := range want {
+		if counts[bound] != count {
+			t.Errorf("put bucket le=%v count = %d, want %d", bound, counts[bound], count)
+		}
+	}
+
+	if counts, total := objectSizeBucketCounts(t, c, ObjectSizeGet); total != 1 || counts[64] != 1 {
+		t.Errorf("get histogram = %v with %d samples, want one 64B sample", counts, total)
+	}
+}
+
+func TestRecordObjectSizeBoundsCardinality(t *testing.T) {
+	c, err := NewCollector(nil)
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+
+	c.RecordObjectSize("list", 100)
+	c.RecordObjectSize("objects/"+t.Name(), 100)
+	if _, total := objectSizeBucketCounts(t, c, "list"); total != 0 {
+		t.Error("size recorded under an unknown operation")
+	}
+	if sizes := c.ObjectSizePercentiles(); len(sizes) != 0 {
+		t.Errorf("percentiles tracked for unknown operations: %v", sizes)
+	}
+}
+
+func TestObjectSizePercentiles(t *testing.T) {
+	c, err := NewCollector(nil)
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+
+	// 90 small objects and 10 large ones
+	for i := 0; i < 90; i++ {
+		c.RecordObjectSize(ObjectSizePut, 1000)
+	}
+	for i := 0; i < 10; i++ {
+		c.RecordObjectSize(ObjectSizePut, 100<<20)
+	}
+
+	put := c.ObjectSizePercentiles()[ObjectSizePut]
+	if put.Count != 100 || put.Max != 100<<20 {
+		t.Fatalf("put summary = %+v, want 100 samples up to 100MB", put)
+	}
+	if put.P50 != 1024 || put.P90 != 1024 {
+		t.Errorf("p50/p90 = %d/%d, want the 1KB bucket", put.P50, put.P90)
+	}
+	if put.P99 != 100<<20 {
+		t.Errorf("p99 = %d, want capped at the 100MB maximum", put.P99)
+	}
+
+	c.ResetMetrics()
+	if sizes := c.ObjectSizePercentiles(); len(sizes) != 0 {
+		t.Errorf("percentiles survived a reset: %v", sizes)
+	}
+}
@@ -0,0 +1,270 @@
+package s3
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Default access tracking settings
+const (
+	DefaultAccessIndexEntries    = 100000
+	DefaultAccessPersistInterval = 5 * time.Minute
+)
+
+// accessIndexVersion is the format version of persisted access indexes
+const accessIndexVersion = 1
+
+// accessEntry is the last read of one object
+type accessEntry struct {
+	Key        string    `json:"key"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+// accessIndexFile is the persisted form of an access index, least
+// recently read first
+type accessIndexFile struct {
+	Version int           `json:"version"`
+	Entries []accessEntry `json:"entries"`
+}
+
+// accessIndex records when objects were last read, which S3 itself does
+// not track. It keeps at most maxEntries objects, dropping the least
+// recently read, and is periodically saved to a sidecar file when one is
+// configured.
+type accessIndex struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // Front is the most recently read
+	maxEntries int
+	dirty      bool
+
+	path   string
+	logger *slog.Logger
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// newAccessIndex creates the access index described by config, loading any
+// index previously saved to its path. It returns nil when tracking is off.
+func newAccessIndex(config AccessTrackingConfig, logger *slog.Logger) (*accessIndex, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultAccessIndexEntries
+	}
+	if config.PersistInterval <= 0 {
+		config.PersistInterval = DefaultAccessPersistInterval
+	}
+
+	idx := &accessIndex{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: config.MaxEntries,
+		path:       config.Path,
+		logger:     logger,
+	}
+	if idx.path == "" {
+		return idx, nil
+	}
+
+	if err := idx.load(); err != nil {
+		return nil, err
+	}
+	idx.stopCh = make(chan struct{})
+	idx.done = make(chan struct{})
+	go idx.persistLoop(config.PersistInterval)
+	return idx, nil
+}
+
+// record notes that key was read at when
+func (idx *accessIndex) record(key string, when time.Time) {
+	if idx == nil {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.dirty = true
+	if elem, ok := idx.entries[key]; ok {
+		entry := elem.Value.(*accessEntry)
+		if when.After(entry.LastAccess) {
+			entry.LastAccess = when
+		}
+		idx.order.MoveToFront(elem)
+		return
+	}
+
+	idx.entries[key] = idx.order.PushFront(&accessEntry{Key: key, LastAccess: when})
+	for idx.order.Len() > idx.maxEntries {
+		oldest := idx.order.Back()
+		idx.order.Remove(oldest)
+		delete(idx.entries, oldest.Value.(*accessEntry).Key)
+	}
+}
+
+// forget drops key, such as after it is deleted
+func (idx *accessIndex) forget(key string) {
+	if idx == nil {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if elem, ok := idx.entries[key]; ok {
+		idx.order.Remove(elem)
+		delete(idx.entries, key)
+		idx.dirty = true
+	}
+}
+
+// lookup returns when key was last read
+func (idx *accessIndex) lookup(key string) (time.Time, bool) {
+	if idx == nil {
+		return time.Time{}, false
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	elem, ok := idx.entries[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return elem.Value.(*accessEntry).LastAccess, true
+}
+
+// len returns the number of objects in the index
+func (idx *accessIndex) len() int {
+	if idx == nil {
+		return 0
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.order.Len()
+}
+
+// load restores the index saved at idx.path; a missing file is an empty index
+func (idx *accessIndex) load() error {
+	data, err := os.ReadFile(idx.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read access index: %w", err)
+	}
+
+	var file accessIndexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse access index %s: %w", idx.path, err)
+	}
+	if file.Version != accessIndexVersion {
+		return fmt.Errorf("unsupported access index version %d in %s", file.Version, idx.path)
+	}
+
+	for _, entry := range file.Entries {
+		idx.record(entry.Key, entry.LastAccess)
+	}
+	idx.dirty = false
+	return nil
+}
+
+// save writes the index to idx.path if it changed since the last save
+func (idx *accessIndex) save() (err error) {
+	idx.mu.Lock()
+	if !idx.dirty {
+		idx.mu.Unlock()
+		return nil
+	}
+	file := accessIndexFile{Version: accessIndexVersion, Entries: make([]accessEntry, 0, idx.order.Len())}
+	for elem := idx.order.Back(); elem != nil; elem = elem.Prev() {
+		file.Entries = append(file.Entries, *elem.Value.(*accessEntry))
+	}
+	idx.dirty = false
+	idx.mu.Unlock()
+
+	// Keep the changes pending so the next save retries them
+	defer func() {
+		if err != nil {
+			idx.mu.Lock()
+			idx.dirty = true
+			idx.mu.Unlock()
+		}
+	}()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode access index: %w", err)
+	}
+	if err := writeFileAtomic(idx.path, data); err != nil {
+		return fmt.Errorf("failed to write access index: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory, so a crash never leaves a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// persistLoop saves the index every interval until close
+func (idx *accessIndex) persistLoop(interval time.Duration) {
+	defer close(idx.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-idx.stopCh:
+			return
+		case <-ticker.C:
+			if err := idx.save(); err != nil {
+				idx.logger.Warn("Failed to persist access index", "path", idx.path, "error", err)
+			}
+		}
+	}
+}
+
+// close stops periodic saving and saves the index a final time
+func (idx *accessIndex) close() error {
+	if idx == nil || idx.path == "" {
+		return nil
+	}
+	close(idx.stopCh)
+	<-idx.done
+	return idx.save()
+}
+
+// recencyFrequency classifies an object by how long ago it was last read
+func recencyFrequency(idle time.Duration) string {
+	switch {
+	case idle <= 24*time.Hour:
+		return AccessFrequent
+	case idle <= 30*24*time.Hour:
+		return AccessInfrequent
+	case idle <= 90*24*time.Hour:
+		return AccessArchive
+	default:
+		return AccessCold
+	}
+}
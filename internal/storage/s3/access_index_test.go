@@ -0,0 +1,99 @@
+package s3
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+func TestAccessIndexBoundedAndPersisted(t *testing.T) {
+	config := AccessTrackingConfig{
+		Enabled:         true,
+		MaxEntries:      2,
+		Path:            filepath.Join(t.TempDir(), "access.json"),
+		PersistInterval: time.Hour,
+	}
+	idx, err := newAccessIndex(config, slog.Default())
+	if err != nil {
+		t.Fatalf("newAccessIndex() error = %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	idx.record("a", base)
+	idx.record("b", base.Add(time.Minute))
+	idx.record("c", base.Add(2*time.Minute))
+	if _, ok := idx.lookup("a"); ok {
+		t.Error("least recently read object kept past MaxEntries")
+	}
+	if idx.len() != 2 {
+		t.Errorf("index holds %d objects, want 2", idx.len())
+	}
+	if err := idx.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	reloaded, err := newAccessIndex(config, slog.Default())
+	if err != nil {
+		t.Fatalf("reloading index error = %v", err)
+	}
+	defer func() { _ = reloaded.close() }()
+
+	if got, ok := reloaded.lookup("b"); !ok || !got.Equal(base.Add(time.Minute)) {
+		t.Errorf("reloaded lookup(b) = %v, %v; want %v", got, ok, base.Add(time.Minute))
+	}
+
+	// Recency order survives the reload: b is still evicted before c
+	reloaded.record("d", time.Now())
+	if _, ok := reloaded.lookup("b"); ok {
+		t.Error("reloaded index lost its recency order")
+	}
+	if _, ok := reloaded.lookup("c"); !ok {
+		t.Error("more recently read object evicted after reload")
+	}
+}
+
+func TestReadUpdatesLastAccessAndTierRecommendation(t *testing.T) {
+	server := newPresignServer(t, PresignGet, PresignPut)
+	backend := newPresignedBackend(server)
+	idx, err := newAccessIndex(AccessTrackingConfig{Enabled: true}, slog.Default())
+	if err != nil {
+		t.Fatalf("newAccessIndex() error = %v", err)
+	}
+	backend.accessIndex = idx
+	ctx := context.Background()
+
+	data := make([]byte, 1<<20)
+	if err := backend.PutObject(ctx, "results/run.parquet", data); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+	info := types.ObjectInfo{
+		Key:          "results/run.parquet",
+		Size:         int64(len(data)),
+		LastModified: time.Now().Add(-200 * 24 * time.Hour),
+		StorageClass: TierStandard,
+	}
+
+	if _, ok := backend.GetLastAccess(info.Key); ok {
+		t.Fatal("last access recorded before any read")
+	}
+	if decision := backend.ClassifyObject(info); decision.Tier != TierGlacierIR {
+		t.Fatalf("unread object written 200 days ago: tier = %s, want %s", decision.Tier, TierGlacierIR)
+	}
+
+	before := time.Now()
+	if _, err := backend.GetObject(ctx, info.Key, 0, 0); err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+
+	lastAccess, ok := backend.GetLastAccess(info.Key)
+	if !ok || lastAccess.Before(before) {
+		t.Fatalf("GetLastAccess() = %v, %v; want a time after %v", lastAccess, ok, before)
+	}
+	if decision := backend.ClassifyObject(info); decision.Tier != TierStandard {
+		t.Errorf("object read just now: tier = %s, want %s", decision.Tier, TierStandard)
+	}
+}
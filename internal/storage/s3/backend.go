@@ -8,6 +8,7 @@ import (
 	"io"
 	"log/slog"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -38,9 +39,20 @@ type Backend struct {
 	currentTier    string
 	tierInfo       StorageTierInfo
 	tierValidator  *TierValidator
+	tierPolicy     TierPolicy
 	costOptimizer  *CostOptimizer
 	pricingManager *PricingManager
 
+	// Storage tiers of objects seen outside the current tier
+	objectTiers objectTiers
+
+	// Last-read times of objects; nil when access tracking is off
+	accessIndex *accessIndex
+	recentOps   *recentOps
+
+	// Metadata and tags of objects by value; nil when indexing is off
+	metadataIndex *metadataIndex
+
 	// Circuit breaker for resilience
 	circuitManager *circuit.Manager
 
@@ -52,6 +64,33 @@ type Backend struct {
 
 	// Multipart upload management
 	multipartManager *MultipartStateManager
+
+	// Effective multipart threshold and concurrency, adapted to measured
+	// upload throughput when AdaptiveMultipart is enabled
+	multipartTuner *multipartTuner
+
+	// Request hedging for reads; nil when disabled
+	getHedger  *hedger
+	headHedger *hedger
+
+	// Rolling round-trip latency measured by Ping
+	latency latencyEstimator
+
+	// In-flight uploads and downloads for progress reporting
+	transfers transferTracker
+
+	// Spend rate limit for cost-incurring calls; nil when no budget is set
+	costBudget *costScheduler
+
+	// Object operations through presigned URLs; nil when the backend signs
+	// its own requests
+	presigned *presignedClient
+
+	// Failover of reads to replica regions; nil when none are configured
+	readFailover *readFailover
+
+	// Keys dropped for repeating across listing pages
+	listDuplicates atomic.Int64
 }
 
 // NewBackend creates a new S3 backend instance
@@ -70,7 +109,43 @@ func NewBackend(ctx context.Context, bucket string, cfg *Config) (*Backend, erro
 	}
 
 	// Initialize logger
-	logger := slog.Default().With("component", "s3-backend", "bucket", bucket)
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With("component", "s3-backend", "bucket", bucket)
+
+	if err := cfg.Retention.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.URLProvider != nil && cfg.Retention.Enabled() {
+		return nil, fmt.Errorf("retention cannot be used with presigned URLs, which do not carry Object Lock headers")
+	}
+	if err := validateMultipartFailurePolicy(cfg.MultipartFailurePolicy); err != nil {
+		return nil, err
+	}
+	if err := validateStreamChecksum(cfg.StreamChecksum); err != nil {
+		return nil, err
+	}
+
+	// Initialize tier recommendation policy
+	tierPolicy, err := NewTierPolicy(cfg.TierPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tier policy: %w", err)
+	}
+
+	accessIndex, err := newAccessIndex(cfg.AccessTracking, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load access index: %w", err)
+	}
+
+	if cfg.URLProvider != nil && cfg.MetadataIndex.Tags {
+		return nil, fmt.Errorf("metadata index tags cannot be used with presigned URLs, which do not cover GetObjectTagging")
+	}
+	metadataIndex, err := newMetadataIndex(cfg.MetadataIndex, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata index: %w", err)
+	}
 
 	// Initialize client manager
 	clientManager, err := NewClientManager(ctx, bucket, cfg, logger)
@@ -78,6 +153,14 @@ func NewBackend(ctx context.Context, bucket string, cfg *Config) (*Backend, erro
 		return nil, fmt.Errorf("failed to create client manager: %w", err)
 	}
 
+	// Sign for the region the bucket lives in, whatever region was configured
+	if cfg.URLProvider == nil {
+		clientManager, err = correctRegion(ctx, bucket, cfg, clientManager, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client manager: %w", err)
+		}
+	}
+
 	// Initialize metrics collector
 	metricsCollector := NewMetricsCollector()
 	metricsCollector.SetAccelerationEnabled(cfg.UseAccelerate)
@@ -85,6 +168,7 @@ func NewBackend(ctx context.Context, bucket string, cfg *Config) (*Backend, erro
 	// Initialize tier validator
 	tierValidator := NewTierValidator(cfg.StorageTier, cfg.TierConstraints, logger)
 	tierInfo := tierValidator.GetTierInfo()
+	tierValidator.SetPolicy(tierPolicy)
 
 	backend := &Backend{
 		bucket:           bucket,
@@ -95,6 +179,10 @@ func NewBackend(ctx context.Context, bucket string, cfg *Config) (*Backend, erro
 		currentTier:      cfg.StorageTier,
 		tierInfo:         tierInfo,
 		tierValidator:    tierValidator,
+		tierPolicy:       tierPolicy,
+		accessIndex:      accessIndex,
+		metadataIndex:    metadataIndex,
+		recentOps:        newRecentOps(cfg.RecentOps),
 	}
 
 	// Initialize pricing manager
@@ -103,8 +191,32 @@ func NewBackend(ctx context.Context, bucket string, cfg *Config) (*Backend, erro
 	// Initialize cost optimizer
 	backend.costOptimizer = NewCostOptimizer(backend, cfg.CostOptimization, logger)
 
+	// Throttle cost-incurring calls to the configured spend rate
+	backend.costBudget = newCostScheduler(cfg.CostBudget, backend.pricingManager)
+
 	// Initialize multipart upload manager
 	backend.multipartManager = NewMultipartStateManager()
+	backend.multipartTuner = newMultipartTuner(cfg.AdaptiveMultipart, cfg.MultipartThreshold, cfg.MultipartConcurrency)
+
+	if cfg.URLProvider != nil {
+		backend.presigned = newPresignedClient(cfg.URLProvider, cfg.RequestTimeout)
+	}
+
+	backend.readFailover, err = newReadFailover(cfg, bucket, clientManager, &backend.latency)
+	if err != nil {
+		_ = clientManager.Close()
+		return nil, err
+	}
+
+	// Hedge slow reads; only idempotent operations are hedged
+	if cfg.Hedge.Enabled {
+		backend.getHedger = newHedger(cfg.Hedge, metricsCollector)
+		backend.headHedger = newHedger(cfg.Hedge, metricsCollector)
+
+		// Pinged latency stands in for request latencies until enough are seen
+		backend.getHedger.baseline = backend.latency.estimate
+		backend.headHedger.baseline = backend.latency.estimate
+	}
 
 	// Initialize circuit breaker manager
 	circuitConfig := circuit.Config{
@@ -119,6 +231,21 @@ func NewBackend(ctx context.Context, bucket string, cfg *Config) (*Backend, erro
 		},
 	}
 	backend.circuitManager = circuit.NewManager(circuitConfig)
+	for name, breaker := range cfg.CircuitBreakers {
+		if breaker.MaxRequests == 0 {
+			breaker.MaxRequests = circuitConfig.MaxRequests
+		}
+		if breaker.Interval <= 0 {
+			breaker.Interval = circuitConfig.Interval
+		}
+		if breaker.Timeout <= 0 {
+			breaker.Timeout = circuitConfig.Timeout
+		}
+		if breaker.OnStateChange == nil {
+			breaker.OnStateChange = circuitConfig.OnStateChange
+		}
+		backend.circuitManager.Configure(name, breaker)
+	}
 
 	// Initialize retryer with logging callback
 	retryConfig := cfg.RetryConfig
@@ -174,17 +301,29 @@ func NewBackend(ctx context.Context, bucket string, cfg *Config) (*Backend, erro
 
 	// Test connection
 	if err := backend.HealthCheck(ctx); err != nil {
-		return nil, fmt.Errorf("S3 backend health check failed: %w", err)
+		return nil, fmt.Errorf("S3 backend health check failed for bucket %s in region %s: %w", bucket, clientManager.region, err)
+	}
+
+	// Retention needs Object Lock, which can only be enabled on new buckets
+	if cfg.Retention.Enabled() {
+		client := clientManager.GetPooledClient()
+		err := validateObjectLock(ctx, client, bucket)
+		clientManager.ReturnPooledClient(client)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return backend, nil
 }
 
 // GetObject retrieves an object or part of an object from S3 with CargoShip optimization
-func (b *Backend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+func (b *Backend) GetObject(ctx context.Context, key string, offset, size int64) (data []byte, err error) {
 	start := time.Now()
+	ctx, trace := b.recentOps.begin(ctx)
 	defer func() {
 		b.metricsCollector.RecordMetrics(time.Since(start), false)
+		b.recentOps.record(trace, "GetObject", key, start, err, b.circuitManager.GetBreaker("s3-get"))
 	}()
 
 	// Check if reads are available in current health state
@@ -197,72 +336,135 @@ func (b *Backend) GetObject(ctx context.Context, key string, offset, size int64)
 			WithContext("bucket", b.bucket).
 			WithContext("key", key)
 	}
+	if err := b.checkPresigned(PresignGet, "GetObject", key); err != nil {
+		return nil, err
+	}
+
+	// Ranged reads are charged up front; whole-object retrievals are
+	// charged for their size once it is known
+	estimated := b.costBudget.estimate("GET", b.currentTier, size)
+	if err := b.costBudget.reserve(ctx, "GetObject", key, estimated); err != nil {
+		return nil, err
+	}
 
 	breaker := b.circuitManager.GetBreaker("s3-get")
-	var data []byte
-
-	// Wrap with retry logic
-	err := b.retryer.DoWithContext(ctx, func(retryCtx context.Context) error {
-		return breaker.ExecuteWithContext(retryCtx, func(ctx context.Context) error {
-			// Build range header if needed
-			var rangeHeader *string
-			if offset > 0 || size > 0 {
-				if size > 0 {
-					rangeHeader = aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
-				} else {
-					rangeHeader = aws.String(fmt.Sprintf("bytes=%d-", offset))
+
+	total := int64(-1)
+	if size > 0 {
+		total = size
+	}
+	progress := b.transfers.start(ctx, "GetObject", key, total, b.config.ProgressInterval)
+	defer b.transfers.done(progress)
+
+	// Wrap with retry logic; primary reads that cannot reach S3 fail over
+	// to replica regions
+	err = b.readFailover.read(ctx, func(ctx context.Context) error {
+		return b.retryer.DoWithContext(ctx, func(retryCtx context.Context) error {
+			return breaker.ExecuteWithContext(retryCtx, func(ctx context.Context) error {
+				countAttempt(ctx)
+				if err := b.injectFault(ctx, FaultOpGet, key); err != nil {
+					b.healthTracker.RecordError("s3-reads", err)
+					return err
 				}
-			}
 
-			input := &s3.GetObjectInput{
-				Bucket: aws.String(b.bucket),
-				Key:    aws.String(key),
-				Range:  rangeHeader,
-			}
+				// Build range header if needed
+				var rangeHeader *string
+				if offset > 0 || size > 0 {
+					if size > 0 {
+						rangeHeader = aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
+					} else {
+						rangeHeader = aws.String(fmt.Sprintf("bytes=%d-", offset))
+					}
+				}
 
-			// Use acceleration fallback pattern for reads
-			err := b.executeWithAccelerationFallback(ctx, "GetObject", func(client *s3.Client) error {
-				result, err := client.GetObject(ctx, input)
-				if err != nil {
-					b.metricsCollector.RecordError(err)
-					translatedErr := b.translateError(err, "GetObject", key)
-					b.healthTracker.RecordError("s3-reads", translatedErr)
-					return translatedErr
+				input := &s3.GetObjectInput{
+					Bucket:       aws.String(b.bucket),
+					RequestPayer: b.config.requestPayer(),
+					Key:          aws.String(key),
+					Range:        rangeHeader,
 				}
-				defer func() { _ = result.Body.Close() }()
 
-				data, err = io.ReadAll(result.Body)
+				result, err := hedged(ctx, b.getHedger, func(ctx context.Context) ([]byte, error) {
+					if b.presigned != nil {
+						return b.getPresigned(ctx, key, aws.ToString(rangeHeader), progress)
+					}
+
+					var body []byte
+
+					// Use acceleration fallback pattern for reads
+					err := b.executeWithAccelerationFallback(ctx, "GetObject", func(client *s3.Client) error {
+						result, err := client.GetObject(ctx, input)
+						if err != nil {
+							if lostHedge(ctx) {
+								return err
+							}
+							b.metricsCollector.RecordError(err)
+							translatedErr := b.translateError(err, "GetObject", key)
+							b.healthTracker.RecordError("s3-reads", translatedErr)
+							return translatedErr
+						}
+						defer func() { _ = result.Body.Close() }()
+
+						if result.ContentLength != nil {
+							progress.setTotal(aws.ToInt64(result.ContentLength))
+						}
+						body, err = io.ReadAll(&progressReader{r: result.Body, transfer: progress})
+						if err != nil {
+							if lostHedge(ctx) {
+								return err
+							}
+							b.metricsCollector.RecordError(err)
+							readErr := fmt.Errorf("failed to read object body: %w", err)
+							b.healthTracker.RecordError("s3-reads", readErr)
+							return readErr
+						}
+
+						b.metricsCollector.RecordBytesDownloaded(int64(len(body)))
+						b.healthTracker.RecordSuccess("s3-reads")
+						return nil
+					})
+
+					return body, err
+				})
 				if err != nil {
-					b.metricsCollector.RecordError(err)
-					readErr := fmt.Errorf("failed to read object body: %w", err)
-					b.healthTracker.RecordError("s3-reads", readErr)
-					return readErr
+					return err
 				}
 
-				b.metricsCollector.RecordBytesDownloaded(int64(len(data)))
-				b.healthTracker.RecordSuccess("s3-reads")
+				data = result
 				return nil
 			})
-
-			return err
 		})
+	}, func(ctx context.Context, region *readRegion) error {
+		var err error
+		data, err = b.getFromReplica(ctx, region, key, offset, size)
+		return err
 	})
 
 	if err != nil {
 		return nil, err
 	}
+	progress.finish()
+	b.costBudget.charge(b.costBudget.estimate("GET", b.currentTier, int64(len(data))) - estimated)
 
 	// Record access pattern for cost optimization
 	b.costOptimizer.RecordAccess(key, int64(len(data)))
+	b.accessIndex.record(key, time.Now())
 
 	return data, nil
 }
 
 // PutObject stores an object in S3 with CargoShip optimization
 func (b *Backend) PutObject(ctx context.Context, key string, data []byte) error {
+	return b.putObject(ctx, key, data, nil)
+}
+
+// putObject stores an object with metadata as its user metadata
+func (b *Backend) putObject(ctx context.Context, key string, data []byte, metadata map[string]string) (err error) {
 	start := time.Now()
+	ctx, trace := b.recentOps.begin(ctx)
 	defer func() {
 		b.metricsCollector.RecordMetrics(time.Since(start), false)
+		b.recentOps.record(trace, "PutObject", key, start, err, b.circuitManager.GetBreaker("s3-put"))
 	}()
 
 	// Check if writes are available in current health state
@@ -276,6 +478,14 @@ func (b *Backend) PutObject(ctx context.Context, key string, data []byte) error
 			WithContext("key", key).
 			WithDetail("suggestion", "System is in read-only mode. Writes will be available once service recovers.")
 	}
+	if err := b.checkPresigned(PresignPut, "PutObject", key); err != nil {
+		return err
+	}
+
+	// Reject oversized objects before uploading anything
+	if limit := b.config.MaxObjectSize.Limit(key); limit > 0 && int64(len(data)) > limit {
+		return b.objectTooLarge("PutObject", key, limit)
+	}
 
 	// Validate write operation against tier constraints
 	if err := b.tierValidator.ValidateWrite(key, int64(len(data))); err != nil {
@@ -296,33 +506,64 @@ func (b *Backend) PutObject(ctx context.Context, key string, data []byte) error
 		}
 	}
 
+	// Retained objects may not be overwritten
+	if err := b.checkRetention(ctx, "PutObject", key); err != nil {
+		return err
+	}
+
+	if err := b.costBudget.reserve(ctx, "PutObject", key, b.costBudget.estimate("PUT", effectiveTier, int64(len(data)))); err != nil {
+		return err
+	}
+
 	breaker := b.circuitManager.GetBreaker("s3-put")
 
-	err := breaker.ExecuteWithContext(ctx, func(ctx context.Context) error {
+	err = breaker.ExecuteWithContext(ctx, func(ctx context.Context) error {
+		countAttempt(ctx)
+		if err := b.injectFault(ctx, FaultOpPut, key); err != nil {
+			b.healthTracker.RecordError("s3-writes", err)
+			return err
+		}
+
+		// Presigned URLs cover single requests, so objects are never split
+		if b.presigned != nil {
+			return b.putPresigned(ctx, key, data)
+		}
+
 		// Check if we should use multipart upload based on size threshold
 		dataSize := int64(len(data))
-		if dataSize >= b.config.MultipartThreshold {
+		threshold, concurrency := b.multipartTuner.settings()
+		if dataSize >= threshold {
 			b.logger.Debug("Using multipart upload for large object",
 				"key", key,
 				"size", dataSize,
-				"threshold", b.config.MultipartThreshold)
-			return b.putObjectMultipart(ctx, key, data, effectiveTier)
+				"threshold", threshold)
+			uploadStart := time.Now()
+			if err := b.putObjectMultipart(ctx, key, data, metadata, effectiveTier, threshold, concurrency); err != nil {
+				return err
+			}
+			b.multipartTuner.observe(dataSize, time.Since(uploadStart), true)
+			return nil
 		}
+		uploadStart := time.Now()
 
 		// Get storage class for effective tier
 		storageClass := ConvertTierToStorageClass(effectiveTier)
 
 		input := &s3.PutObjectInput{
 			Bucket:        aws.String(b.bucket),
+			RequestPayer:  b.config.requestPayer(),
 			Key:           aws.String(key),
 			Body:          bytes.NewReader(data),
 			ContentLength: aws.Int64(int64(len(data))),
 			ContentType:   aws.String(b.detectContentType(key)),
 			StorageClass:  storageClass,
+			Metadata:      metadata,
 		}
+		input.ObjectLockMode, input.ObjectLockRetainUntilDate = b.config.Retention.retentionHeaders(time.Now())
 
-		// Use CargoShip transporter if available for optimized uploads (4.6x performance)
-		if transporter := b.clientManager.GetTransporter(); transporter != nil {
+		// Use CargoShip transporter if available for optimized uploads (4.6x performance).
+		// It cannot set Object Lock headers, so retained writes use the S3 client.
+		if transporter := b.clientManager.GetTransporter(); transporter != nil && !b.config.Retention.Enabled() {
 			// Use CargoShip's optimized upload with BBR/CUBIC algorithms
 			cargoStorageClass := ConvertTierToCargoShipStorageClass(effectiveTier)
 			archive := cargoships3.Archive{
@@ -337,6 +578,9 @@ func (b *Backend) PutObject(ctx context.Context, key string, data []byte) error
 					"configured-tier": b.currentTier,
 				},
 			}
+			for k, v := range metadata {
+				archive.Metadata[k] = v
+			}
 
 			result, uploadErr := transporter.Upload(ctx, archive)
 			if uploadErr == nil {
@@ -347,6 +591,7 @@ func (b *Backend) PutObject(ctx context.Context, key string, data []byte) error
 					"duration", result.Duration)
 				b.metricsCollector.RecordBytesUploaded(int64(len(data)))
 				b.healthTracker.RecordSuccess("s3-writes")
+				b.multipartTuner.observe(dataSize, time.Since(uploadStart), false)
 				return nil
 			}
 
@@ -365,20 +610,32 @@ func (b *Backend) PutObject(ctx context.Context, key string, data []byte) error
 
 			b.metricsCollector.RecordBytesUploaded(int64(len(data)))
 			b.healthTracker.RecordSuccess("s3-writes")
+			b.multipartTuner.observe(dataSize, time.Since(uploadStart), false)
 			return nil
 		})
 	})
+	if err == nil {
+		b.objectTiers.record(key, effectiveTier, b.currentTier)
+		// A PUT replaces the object's tags with none
+		b.metadataIndex.put(key, metadata, nil)
+	}
 
 	return err
 }
 
 // DeleteObject removes an object from S3
-func (b *Backend) DeleteObject(ctx context.Context, key string) error {
+func (b *Backend) DeleteObject(ctx context.Context, key string) (err error) {
 	start := time.Now()
+	ctx, trace := b.recentOps.begin(ctx)
 	defer func() {
 		b.metricsCollector.RecordMetrics(time.Since(start), false)
+		b.recentOps.record(trace, "DeleteObject", key, start, err, nil)
 	}()
 
+	if err := b.checkPresigned(PresignDelete, "DeleteObject", key); err != nil {
+		return err
+	}
+
 	// Get object metadata to check creation time for tier validation
 	objectInfo, err := b.HeadObject(ctx, key)
 	if err != nil {
@@ -397,44 +654,95 @@ func (b *Backend) DeleteObject(ctx context.Context, key string) error {
 		return fmt.Errorf("tier validation failed: %w", err)
 	}
 
-	client := b.clientManager.GetPooledClient()
-	defer b.clientManager.ReturnPooledClient(client)
+	if err := b.checkRetention(ctx, "DeleteObject", key); err != nil {
+		return err
+	}
 
-	input := &s3.DeleteObjectInput{
-		Bucket: aws.String(b.bucket),
-		Key:    aws.String(key),
+	countAttempt(ctx)
+	if err := b.injectFault(ctx, FaultOpDelete, key); err != nil {
+		return err
 	}
 
-	_, err = client.DeleteObject(ctx, input)
+	if b.presigned != nil {
+		err = b.presigned.delete(ctx, key)
+	} else {
+		client := b.clientManager.GetPooledClient()
+		input := &s3.DeleteObjectInput{
+			Bucket:       aws.String(b.bucket),
+			RequestPayer: b.config.requestPayer(),
+			Key:          aws.String(key),
+		}
+		_, err = client.DeleteObject(ctx, input)
+		b.clientManager.ReleasePooledClient(client, err)
+	}
 	if err != nil {
 		b.metricsCollector.RecordError(err)
 		return b.translateError(err, "DeleteObject", key)
 	}
+	b.objectTiers.forget(key)
+	b.accessIndex.forget(key)
+	b.metadataIndex.forget(key)
 
 	return nil
 }
 
 // HeadObject retrieves metadata about an object
-func (b *Backend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+func (b *Backend) HeadObject(ctx context.Context, key string) (info *types.ObjectInfo, err error) {
 	start := time.Now()
+	ctx, trace := b.recentOps.begin(ctx)
 	defer func() {
 		b.metricsCollector.RecordMetrics(time.Since(start), false)
+		b.recentOps.record(trace, "HeadObject", key, start, err, nil)
 	}()
 
-	client := b.clientManager.GetPooledClient()
-	defer b.clientManager.ReturnPooledClient(client)
+	if err := b.checkPresigned(PresignHead, "HeadObject", key); err != nil {
+		return nil, err
+	}
+	if err := b.costBudget.reserve(ctx, "HeadObject", key, b.costBudget.estimate("HEAD", b.currentTier, 0)); err != nil {
+		return nil, err
+	}
+
+	countAttempt(ctx)
+	if err := b.injectFault(ctx, FaultOpHead, key); err != nil {
+		return nil, err
+	}
 
 	input := &s3.HeadObjectInput{
-		Bucket: aws.String(b.bucket),
-		Key:    aws.String(key),
+		Bucket:       aws.String(b.bucket),
+		RequestPayer: b.config.requestPayer(),
+		Key:          aws.String(key),
 	}
 
-	result, err := client.HeadObject(ctx, input)
+	var result *s3.HeadObjectOutput
+	err = b.readFailover.read(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = hedged(ctx, b.headHedger, func(ctx context.Context) (*s3.HeadObjectOutput, error) {
+			if b.presigned != nil {
+				return b.presigned.head(ctx, key)
+			}
+			client := b.clientManager.GetPooledClient()
+			result, err := client.HeadObject(ctx, input)
+			b.clientManager.ReleasePooledClient(client, err)
+			return result, err
+		})
+		return err
+	}, func(ctx context.Context, region *readRegion) error {
+		var err error
+		result, err = b.headFromReplica(ctx, region, key)
+		return err
+	})
 	if err != nil {
 		b.metricsCollector.RecordError(err)
 		return nil, b.translateError(err, "HeadObject", key)
 	}
 
+	info = objectInfoFromHead(key, result)
+	b.objectTiers.record(key, info.StorageClass, b.currentTier)
+	return info, nil
+}
+
+// objectInfoFromHead converts a HeadObject response to ObjectInfo
+func objectInfoFromHead(key string, result *s3.HeadObjectOutput) *types.ObjectInfo {
 	info := &types.ObjectInfo{
 		Key:          key,
 		Size:         aws.ToInt64(result.ContentLength),
@@ -442,6 +750,8 @@ func (b *Backend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo
 		ETag:         aws.ToString(result.ETag),
 		ContentType:  aws.ToString(result.ContentType),
 		Metadata:     make(map[string]string),
+		StorageClass: headStorageClass(result.StorageClass),
+		VersionID:    aws.ToString(result.VersionId),
 	}
 
 	// Copy metadata
@@ -449,7 +759,67 @@ func (b *Backend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo
 		info.Metadata[k] = v
 	}
 
-	return info, nil
+	return info
+}
+
+// objectInfoFromListing converts a ListObjectsV2 entry to ObjectInfo.
+// Listings do not carry version IDs.
+func objectInfoFromListing(obj s3types.Object) types.ObjectInfo {
+	return types.ObjectInfo{
+		Key:          aws.ToString(obj.Key),
+		Size:         aws.ToInt64(obj.Size),
+		LastModified: aws.ToTime(obj.LastModified),
+		ETag:         aws.ToString(obj.ETag),
+		Metadata:     make(map[string]string),
+		StorageClass: string(obj.StorageClass),
+	}
+}
+
+// headStorageClass normalizes the storage class of HEAD and GET responses,
+// which omit it for STANDARD objects, to match listings
+func headStorageClass(class s3types.StorageClass) string {
+	if class == "" {
+		return string(s3types.StorageClassStandard)
+	}
+	return string(class)
+}
+
+// HeadObjects retrieves metadata for keys with parallel HEAD requests, at
+// most PoolSize at a time. S3 has no batch metadata API, so each key is a
+// separate request; failures are reported per key.
+func (b *Backend) HeadObjects(ctx context.Context, keys []string) (map[string]*types.ObjectInfo, map[string]error) {
+	infos := make(map[string]*types.ObjectInfo, len(keys))
+	errs := make(map[string]error)
+
+	type result struct {
+		key  string
+		info *types.ObjectInfo
+		err  error
+	}
+
+	resultCh := make(chan result, len(keys))
+	semaphore := make(chan struct{}, max(b.config.PoolSize, 1))
+
+	for _, key := range keys {
+		go func(k string) {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			info, err := b.HeadObject(ctx, k)
+			resultCh <- result{key: k, info: info, err: err}
+		}(key)
+	}
+
+	for i := 0; i < len(keys); i++ {
+		res := <-resultCh
+		if res.err != nil {
+			errs[res.key] = res.err
+			continue
+		}
+		infos[res.key] = res.info
+	}
+
+	return infos, errs
 }
 
 // GetObjects retrieves multiple objects in batch with CargoShip optimization
@@ -540,67 +910,150 @@ func (b *Backend) PutObjects(ctx context.Context, objects map[string][]byte) err
 }
 
 // ListObjects lists objects in the bucket with the given prefix
-func (b *Backend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+func (b *Backend) ListObjects(ctx context.Context, prefix string, limit int) (objects []types.ObjectInfo, err error) {
 	start := time.Now()
+	ctx, trace := b.recentOps.begin(ctx)
 	defer func() {
 		b.metricsCollector.RecordMetrics(time.Since(start), false)
+		b.recentOps.record(trace, "ListObjects", prefix, start, err, nil)
 	}()
 
-	client := b.clientManager.GetPooledClient()
-	defer b.clientManager.ReturnPooledClient(client)
-
-	var maxKeys *int32
-	if limit > 0 {
-		// Safe conversion to prevent overflow
-		if limit > 0x7FFFFFFF {
-			maxKeys = aws.Int32(0x7FFFFFFF)
-		} else {
-			maxKeys = aws.Int32(int32(limit))
-		}
+	if err := b.checkPresigned(PresignList, "ListObjects", prefix); err != nil {
+		return nil, err
+	}
+	if err := b.costBudget.reserve(ctx, "ListObjects", prefix, b.costBudget.estimate("LIST", b.currentTier, 0)); err != nil {
+		return nil, err
 	}
 
-	input := &s3.ListObjectsV2Input{
-		Bucket:  aws.String(b.bucket),
-		Prefix:  aws.String(prefix),
-		MaxKeys: maxKeys,
+	countAttempt(ctx)
+	if err := b.injectFault(ctx, FaultOpList, prefix); err != nil {
+		return nil, err
 	}
 
-	result, err := client.ListObjectsV2(ctx, input)
+	var result *s3.ListObjectsV2Output
+	if b.presigned != nil {
+		result, err = b.presigned.list(ctx, prefix, limit)
+	} else {
+		var maxKeys *int32
+		if limit > 0 {
+			// Safe conversion to prevent overflow
+			if limit > 0x7FFFFFFF {
+				maxKeys = aws.Int32(0x7FFFFFFF)
+			} else {
+				maxKeys = aws.Int32(int32(limit))
+			}
+		}
+
+		input := &s3.ListObjectsV2Input{
+			Bucket:       aws.String(b.bucket),
+			RequestPayer: b.config.requestPayer(),
+			Prefix:       aws.String(prefix),
+			MaxKeys:      maxKeys,
+		}
+		client := b.clientManager.GetPooledClient()
+		result, err = client.ListObjectsV2(ctx, input)
+		b.clientManager.ReleasePooledClient(client, err)
+	}
 	if err != nil {
 		b.metricsCollector.RecordError(err)
 		return nil, b.translateError(err, "ListObjects", prefix)
 	}
 
-	objects := make([]types.ObjectInfo, 0, len(result.Contents))
+	objects = make([]types.ObjectInfo, 0, len(result.Contents))
 	for _, obj := range result.Contents {
-		info := types.ObjectInfo{
-			Key:          aws.ToString(obj.Key),
-			Size:         aws.ToInt64(obj.Size),
-			LastModified: aws.ToTime(obj.LastModified),
-			ETag:         aws.ToString(obj.ETag),
-			Metadata:     make(map[string]string),
-		}
+		info := objectInfoFromListing(obj)
+		b.objectTiers.record(info.Key, info.StorageClass, b.currentTier)
 		objects = append(objects, info)
 	}
 
 	return objects, nil
 }
 
-// HealthCheck verifies the backend connection
+// HealthCheck verifies the backend connection. In presigned mode there are
+// no credentials to check the bucket with, so failures surface through the
+// health tracker as operations run.
 func (b *Backend) HealthCheck(ctx context.Context) error {
+	if b.presigned != nil {
+		return nil
+	}
 	return b.clientManager.HealthCheck(ctx, b.bucket)
 }
 
 // GetMetrics returns current backend metrics
 func (b *Backend) GetMetrics() BackendMetrics {
-	return b.metricsCollector.GetMetrics()
+	metrics := b.metricsCollector.GetMetrics()
+	for _, transfer := range b.transfers.snapshot() {
+		metrics.ActiveTransfers++
+		metrics.TransferBytesDone += transfer.BytesDone
+		if transfer.BytesTotal > 0 {
+			metrics.TransferBytesTotal += transfer.BytesTotal
+		}
+	}
+	if b.costBudget != nil {
+		budget := b.costBudget.stats()
+		metrics.BudgetUSDPerHour = budget.BudgetUSDPerHour
+		metrics.SpendUSDPerHour = budget.SpendUSDPerHour
+	}
+	if b.clientManager != nil {
+		pool := b.clientManager.GetStats()
+		metrics.PoolHealthy = pool.Healthy
+		metrics.PoolUnhealthy = pool.Unhealthy
+		metrics.PoolReplaced = pool.Replaced
+	}
+	if b.readFailover != nil {
+		metrics.FailoverReads = b.readFailover.failovers.Load()
+	}
+	if b.circuitManager != nil {
+		metrics.CircuitBreakers = b.circuitManager.GetStats()
+	}
+	metrics.ListDuplicatesDropped = b.listDuplicates.Load()
+	return metrics
+}
+
+// CostBudgetStats returns spend against the cost budget, or nil when no
+// budget is set
+func (b *Backend) CostBudgetStats() *CostBudgetStats {
+	if b.costBudget == nil {
+		return nil
+	}
+	stats := b.costBudget.stats()
+	return &stats
+}
+
+// Transfers returns the progress of in-flight uploads and downloads
+func (b *Backend) Transfers() []TransferProgress {
+	return b.transfers.snapshot()
+}
+
+// HedgeStats returns request hedging activity per operation, or nil when
+// hedging is disabled
+func (b *Backend) HedgeStats() map[string]HedgeStats {
+	if b.getHedger == nil {
+		return nil
+	}
+	return map[string]HedgeStats{
+		"GetObject":  b.getHedger.Stats(),
+		"HeadObject": b.headHedger.Stats(),
+	}
 }
 
 // Close closes the backend and releases resources
 func (b *Backend) Close() error {
+	if err := b.accessIndex.close(); err != nil {
+		b.logger.Warn("Failed to persist access index", "error", err)
+	}
+	if err := b.metadataIndex.close(); err != nil {
+		b.logger.Warn("Failed to persist metadata index", "error", err)
+	}
 	return b.clientManager.Close()
 }
 
+// GetLastAccess returns when key was last read through this backend. It
+// reports false when access tracking is off or no read was recorded.
+func (b *Backend) GetLastAccess(key string) (time.Time, bool) {
+	return b.accessIndex.lookup(key)
+}
+
 // Helper methods
 
 func (b *Backend) translateError(err error, operation, key string) error {
@@ -673,15 +1126,21 @@ func (b *Backend) translateError(err error, operation, key string) error {
 		// Access denied / permission errors
 		if strings.Contains(errMsg, "AccessDenied") || strings.Contains(errMsg, "Forbidden") ||
 			strings.Contains(errMsg, "403") {
-			return errors.NewError(errors.ErrCodeAccessDenied, "access denied to S3 resource").
+			denied := errors.NewError(errors.ErrCodeAccessDenied, "access denied to S3 resource").
 				WithComponent("s3-backend").
 				WithOperation(operation).
 				WithContext("bucket", b.bucket).
 				WithContext("key", key).
 				WithDetail("required_permissions", []string{
 					"s3:GetObject", "s3:PutObject", "s3:DeleteObject", "s3:ListBucket",
-				}).
-				WithCause(err)
+				})
+			// Requester-pays buckets deny every request that does not accept
+			// the charges, even with the permissions above
+			if !b.config.RequesterPays {
+				denied = denied.WithDetail("suggestion",
+					"If the bucket is a requester-pays bucket, set requester_pays: true to accept its request charges.")
+			}
+			return denied.WithCause(err)
 		}
 
 		// Generic error with context
@@ -696,6 +1155,8 @@ func (b *Backend) translateError(err error, operation, key string) error {
 
 func (b *Backend) detectContentType(key string) string {
 	switch {
+	case strings.HasSuffix(key, "/"):
+		return "application/x-directory"
 	case strings.HasSuffix(key, ".json"):
 		return "application/json"
 	case strings.HasSuffix(key, ".xml"):
@@ -731,9 +1192,24 @@ func (b *Backend) GetAllTiers() map[string]StorageTierInfo {
 	return StorageTiers
 }
 
-// GetTierRecommendations returns tier recommendations for an object
-func (b *Backend) GetTierRecommendations(objectSize int64, accessFrequency string) []string {
-	return b.tierValidator.GetRecommendations(objectSize, accessFrequency)
+// GetTierRecommendations returns tier recommendations for the object at key,
+// matching tier policy rules against its key, size and age
+func (b *Backend) GetTierRecommendations(key string, objectSize int64, age time.Duration, accessFrequency string) []string {
+	return b.tierValidator.GetRecommendations(key, objectSize, age, accessFrequency)
+}
+
+// ClassifyObject recommends a tier for a stored object using its recorded
+// last access when access tracking is on
+func (b *Backend) ClassifyObject(info types.ObjectInfo) TierDecision {
+	return b.costOptimizer.ClassifyObject(info)
+}
+
+// RecommendTier evaluates the configured tier policy for an object
+func (b *Backend) RecommendTier(obj ObjectStats) TierDecision {
+	if obj.CurrentTier == "" {
+		obj.CurrentTier = b.currentTier
+	}
+	return b.tierValidator.policy.Recommend(obj)
 }
 
 // SetStorageTier changes the storage tier (requires restarting backend for full effect)
@@ -745,6 +1221,7 @@ func (b *Backend) SetStorageTier(tier string, constraints TierConstraints) error
 
 	// Update tier validator
 	b.tierValidator = NewTierValidator(tier, constraints, b.logger)
+	b.tierValidator.SetPolicy(b.tierPolicy)
 
 	// Update backend state
 	b.currentTier = tier
@@ -767,6 +1244,12 @@ func (b *Backend) ValidateObjectForTier(key string, size int64) error {
 	return b.tierValidator.ValidateWrite(key, size)
 }
 
+// DeletionEmbargo returns how old an object must be before DeleteObject
+// accepts it in the current tier
+func (b *Backend) DeletionEmbargo() time.Duration {
+	return b.tierValidator.DeletionEmbargo()
+}
+
 // GetTierConstraints returns the current tier constraints
 func (b *Backend) GetTierConstraints() TierConstraints {
 	return b.config.TierConstraints
@@ -893,8 +1376,9 @@ func (b *Backend) executeWithAccelerationFallback(
 	// If acceleration is not active, just execute with standard client
 	if !b.clientManager.IsAccelerationActive() {
 		client := b.clientManager.GetPooledClient()
-		defer b.clientManager.ReturnPooledClient(client)
-		return fn(client)
+		err := fn(client)
+		b.clientManager.ReleasePooledClient(client, err)
+		return err
 	}
 
 	// Try with accelerated client first
@@ -932,12 +1416,13 @@ func (b *Backend) executeWithAccelerationFallback(
 	return fn(standardClient)
 }
 
-// putObjectMultipart performs a multipart upload for large objects with parallel chunk uploads
-func (b *Backend) putObjectMultipart(ctx context.Context, key string, data []byte, tier string) error {
+// putObjectMultipart performs a multipart upload for large objects with
+// parallel chunk uploads, concurrency parts at a time
+func (b *Backend) putObjectMultipart(ctx context.Context, key string, data []byte, metadata map[string]string, tier string, threshold int64, concurrency int) error {
 	dataSize := int64(len(data))
 
 	// Calculate optimal chunk size based on file size
-	chunkSize := CalculateOptimalChunkSize(dataSize, b.config.MultipartThreshold, b.config.MultipartChunkSize)
+	chunkSize := CalculateOptimalChunkSize(dataSize, threshold, b.config.MultipartChunkSize)
 
 	b.logger.Debug("Starting multipart upload",
 		"key", key,
@@ -949,34 +1434,64 @@ func (b *Backend) putObjectMultipart(ctx context.Context, key string, data []byt
 	storageClass := ConvertTierToStorageClass(tier)
 	contentType := b.detectContentType(key)
 
-	// Initiate multipart upload
+	// Resume a preserved upload of the same data, or initiate a new one
+	checksum := contentChecksum(data)
+	var uploadState *MultipartUploadState
+	if b.config.MultipartFailurePolicy == MultipartPreserveForResume {
+		uploadState = b.multipartManager.FindResumable(b.bucket, key, dataSize, chunkSize, checksum)
+	}
+
 	var uploadID string
-	err := b.executeWithAccelerationFallback(ctx, "CreateMultipartUpload", func(client *s3.Client) error {
-		createInput := &s3.CreateMultipartUploadInput{
-			Bucket:       aws.String(b.bucket),
-			Key:          aws.String(key),
-			ContentType:  aws.String(contentType),
-			StorageClass: storageClass,
-		}
+	if uploadState != nil {
+		uploadID = uploadState.UploadID
+		b.logger.Info("Resuming multipart upload",
+			"key", key,
+			"upload_id", uploadID,
+			"completed_parts", uploadState.CompletedParts,
+			"total_parts", uploadState.TotalParts)
+	} else {
+		err := b.executeWithAccelerationFallback(ctx, "CreateMultipartUpload", func(client *s3.Client) error {
+			createInput := &s3.CreateMultipartUploadInput{
+				Bucket:       aws.String(b.bucket),
+				RequestPayer: b.config.requestPayer(),
+				Key:          aws.String(key),
+				ContentType:  aws.String(contentType),
+				StorageClass: storageClass,
+				Metadata:     metadata,
+			}
+			createInput.ObjectLockMode, createInput.ObjectLockRetainUntilDate = b.config.Retention.retentionHeaders(time.Now())
+
+			result, err := client.CreateMultipartUpload(ctx, createInput)
+			if err != nil {
+				b.metricsCollector.RecordError(err)
+				return b.translateError(err, "CreateMultipartUpload", key)
+			}
+
+			uploadID = aws.ToString(result.UploadId)
+			return nil
+		})
 
-		result, err := client.CreateMultipartUpload(ctx, createInput)
 		if err != nil {
-			b.metricsCollector.RecordError(err)
-			return b.translateError(err, "CreateMultipartUpload", key)
+			return fmt.Errorf("failed to initiate multipart upload: %w", err)
 		}
 
-		uploadID = aws.ToString(result.UploadId)
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to initiate multipart upload: %w", err)
+		// Create upload state tracker
+		uploadState = NewMultipartUploadState(uploadID, b.bucket, key, dataSize, chunkSize)
+		uploadState.Metadata[metadataContentChecksum] = checksum
+		b.multipartManager.TrackUpload(uploadState)
 	}
 
-	// Create upload state tracker
-	uploadState := NewMultipartUploadState(uploadID, b.bucket, key, dataSize, chunkSize)
-	b.multipartManager.TrackUpload(uploadState)
-	defer b.multipartManager.RemoveUpload(uploadID)
+	// Preserved uploads stay tracked so a later upload can resume them
+	preserved := false
+	defer func() {
+		if !preserved {
+			b.multipartManager.RemoveUpload(uploadID)
+		}
+	}()
+
+	progress := b.transfers.start(ctx, "PutObject", key, dataSize, b.config.ProgressInterval)
+	defer b.transfers.done(progress)
+	progress.add(uploadState.BytesUploaded)
 
 	// Calculate number of parts
 	totalParts := CalculatePartCount(dataSize, chunkSize)
@@ -985,39 +1500,16 @@ func (b *Backend) putObjectMultipart(ctx context.Context, key string, data []byt
 		"upload_id", uploadID,
 		"total_parts", totalParts)
 
-	// Upload parts in parallel with controlled concurrency
-	type partResult struct {
-		partNumber int
-		etag       string
-		size       int64
-		err        error
-	}
-
-	resultCh := make(chan partResult, totalParts)
-	semaphore := make(chan struct{}, b.config.MultipartConcurrency)
-
-	// Launch goroutines for each part
-	for partNum := 1; partNum <= totalParts; partNum++ {
-		go func(pn int) {
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			// Calculate part boundaries
-			startOffset := int64(pn-1) * chunkSize
-			endOffset := startOffset + chunkSize
-			if endOffset > dataSize {
-				endOffset = dataSize
-			}
-
-			partData := data[startOffset:endOffset]
+	// Upload the remaining parts in parallel, each with retry logic
+	completedParts, uploadErrors := uploadRemainingParts(ctx, b.multipartManager, uploadState, data, concurrency,
+		func(ctx context.Context, pn int, partData []byte) (string, error) {
 			partSize := int64(len(partData))
-
-			// Upload this part with retry logic
 			var etag string
-			uploadErr := b.retryer.DoWithContext(ctx, func(retryCtx context.Context) error {
+			err := b.retryer.DoWithContext(ctx, func(retryCtx context.Context) error {
 				return b.executeWithAccelerationFallback(retryCtx, "UploadPart", func(client *s3.Client) error {
 					uploadPartInput := &s3.UploadPartInput{
 						Bucket:        aws.String(b.bucket),
+						RequestPayer:  b.config.requestPayer(),
 						Key:           aws.String(key),
 						UploadId:      aws.String(uploadID),
 						PartNumber:    aws.Int32(int32(pn)),
@@ -1032,61 +1524,46 @@ func (b *Backend) putObjectMultipart(ctx context.Context, key string, data []byt
 					}
 
 					etag = aws.ToString(uploadResult.ETag)
-					b.multipartManager.UpdatePartStatus(uploadID, pn, partSize, etag, nil)
+					progress.add(partSize)
 
 					b.logger.Debug("Part uploaded successfully",
 						"upload_id", uploadID,
 						"part_number", pn,
-						"size", partSize,
-						"progress", fmt.Sprintf("%.1f%%", uploadState.GetProgress()))
+						"size", partSize)
 
 					return nil
 				})
 			})
-
-			if uploadErr != nil {
-				b.multipartManager.UpdatePartStatus(uploadID, pn, 0, "", uploadErr)
-			}
-
-			resultCh <- partResult{
-				partNumber: pn,
-				etag:       etag,
-				size:       partSize,
-				err:        uploadErr,
-			}
-		}(partNum)
-	}
-
-	// Collect all part results
-	completedParts := make([]s3types.CompletedPart, 0, totalParts)
-	var uploadErrors []error
-	var totalBytesUploaded int64
-
-	for i := 0; i < totalParts; i++ {
-		result := <-resultCh
-		if result.err != nil {
-			uploadErrors = append(uploadErrors, fmt.Errorf("part %d failed: %w", result.partNumber, result.err))
-			continue
-		}
-
-		completedParts = append(completedParts, s3types.CompletedPart{
-			PartNumber: aws.Int32(int32(result.partNumber)),
-			ETag:       aws.String(result.etag),
+			return etag, err
 		})
 
-		totalBytesUploaded += result.size
+	var totalBytesUploaded int64
+	for _, part := range b.multipartManager.CompletedParts(uploadID) {
+		totalBytesUploaded += part.Size
 	}
 
-	// If any parts failed, abort the multipart upload
 	if len(uploadErrors) > 0 {
 		b.multipartManager.MarkUploadFailed(uploadID)
 
+		// Keep the uploaded parts unless the upload itself is gone
+		if b.config.MultipartFailurePolicy == MultipartPreserveForResume && !isUploadGone(uploadErrors[0]) {
+			preserved = true
+			b.logger.Warn("Multipart upload parts failed; uploaded parts preserved for resume",
+				"key", key,
+				"upload_id", uploadID,
+				"failed_parts", len(uploadErrors),
+				"completed_parts", len(completedParts))
+			return fmt.Errorf("multipart upload failed: %d parts failed, %d preserved for resume: %w",
+				len(uploadErrors), len(completedParts), uploadErrors[0])
+		}
+
 		// Abort the multipart upload
 		abortErr := b.executeWithAccelerationFallback(ctx, "AbortMultipartUpload", func(client *s3.Client) error {
 			abortInput := &s3.AbortMultipartUploadInput{
-				Bucket:   aws.String(b.bucket),
-				Key:      aws.String(key),
-				UploadId: aws.String(uploadID),
+				Bucket:       aws.String(b.bucket),
+				RequestPayer: b.config.requestPayer(),
+				Key:          aws.String(key),
+				UploadId:     aws.String(uploadID),
 			}
 			_, err := client.AbortMultipartUpload(ctx, abortInput)
 			return err
@@ -1102,11 +1579,12 @@ func (b *Backend) putObjectMultipart(ctx context.Context, key string, data []byt
 	}
 
 	// Complete the multipart upload
-	err = b.executeWithAccelerationFallback(ctx, "CompleteMultipartUpload", func(client *s3.Client) error {
+	err := b.executeWithAccelerationFallback(ctx, "CompleteMultipartUpload", func(client *s3.Client) error {
 		completeInput := &s3.CompleteMultipartUploadInput{
-			Bucket:   aws.String(b.bucket),
-			Key:      aws.String(key),
-			UploadId: aws.String(uploadID),
+			Bucket:       aws.String(b.bucket),
+			RequestPayer: b.config.requestPayer(),
+			Key:          aws.String(key),
+			UploadId:     aws.String(uploadID),
 			MultipartUpload: &s3types.CompletedMultipartUpload{
 				Parts: completedParts,
 			},
@@ -1128,6 +1606,7 @@ func (b *Backend) putObjectMultipart(ctx context.Context, key string, data []byt
 
 	// Mark upload as completed
 	b.multipartManager.MarkUploadCompleted(uploadID)
+	progress.finish()
 
 	// Record metrics
 	b.metricsCollector.RecordBytesUploaded(totalBytesUploaded)
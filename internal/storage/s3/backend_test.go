@@ -2,11 +2,17 @@ package s3
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/objectfs/objectfs/pkg/types"
 )
 
 func TestConfig_Defaults(t *testing.T) {
@@ -205,3 +211,56 @@ func BenchmarkRecordMetrics(b *testing.B) {
 		backend.metricsCollector.RecordMetrics(duration, i%10 == 0) // 10% error rate
 	}
 }
+
+func TestObjectInfoFromHead(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	info := objectInfoFromHead("data/archive.tar", &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(42),
+		LastModified:  aws.Time(modified),
+		ETag:          aws.String(`"etag"`),
+		ContentType:   aws.String("application/x-tar"),
+		StorageClass:  s3types.StorageClassGlacierIr,
+		VersionId:     aws.String("v2"),
+		Metadata:      map[string]string{"owner": "ops"},
+	})
+
+	assert.Equal(t, "data/archive.tar", info.Key)
+	assert.Equal(t, int64(42), info.Size)
+	assert.Equal(t, modified, info.LastModified)
+	assert.Equal(t, `"etag"`, info.ETag)
+	assert.Equal(t, "GLACIER_IR", info.StorageClass)
+	assert.Equal(t, "v2", info.VersionID)
+	assert.Equal(t, "ops", info.Metadata["owner"])
+
+	// HEAD omits the storage class for STANDARD objects
+	standard := objectInfoFromHead("k", &s3.HeadObjectOutput{})
+	assert.Equal(t, "STANDARD", standard.StorageClass)
+	assert.Empty(t, standard.VersionID)
+}
+
+func TestObjectInfoFromListing(t *testing.T) {
+	info := objectInfoFromListing(s3types.Object{
+		Key:          aws.String("logs/app.log"),
+		Size:         aws.Int64(7),
+		ETag:         aws.String(`"list-etag"`),
+		StorageClass: s3types.ObjectStorageClassStandardIa,
+	})
+
+	assert.Equal(t, "logs/app.log", info.Key)
+	assert.Equal(t, int64(7), info.Size)
+	assert.Equal(t, `"list-etag"`, info.ETag)
+	assert.Equal(t, "STANDARD_IA", info.StorageClass)
+	assert.Empty(t, info.VersionID)
+}
+
+func TestObjectInfo_JSONOmitsEmptyVersioning(t *testing.T) {
+	data, err := json.Marshal(types.ObjectInfo{Key: "k"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "storage_class")
+	assert.NotContains(t, string(data), "version_id")
+
+	data, err = json.Marshal(types.ObjectInfo{Key: "k", StorageClass: "GLACIER", VersionID: "v1"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"storage_class":"GLACIER"`)
+	assert.Contains(t, string(data), `"version_id":"v1"`)
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	awsconfig "github.com/scttfrdmn/cargoship/pkg/aws/config"
 	cargoships3 "github.com/scttfrdmn/cargoship/pkg/aws/s3"
+
+	"github.com/objectfs/objectfs/pkg/errors"
 )
 
 // ClientManager handles S3 client creation and management
@@ -21,7 +23,50 @@ type ClientManager struct {
 	transporter        *cargoships3.Transporter
 	config             *Config
 	logger             *slog.Logger
-	accelerationActive bool // Tracks if acceleration is currently active
+	region             string // Region requests are signed for
+	accelerationActive bool   // Tracks if acceleration is currently active
+
+	// Settings clients for other regions are created from
+	awsConfig     aws.Config
+	clientOptions func(*s3.Options)
+}
+
+// ResolveCredentials loads the AWS configuration and retrieves credentials
+// from the default provider chain without contacting S3
+func ResolveCredentials(ctx context.Context, cfg *Config) error {
+	if cfg == nil {
+		cfg = NewDefaultConfig()
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
+	if err != nil {
+		return errors.NewError(errors.ErrCodeInvalidConfig, "failed to load AWS config").
+			WithComponent("s3-client").
+			WithOperation("ResolveCredentials").
+			WithCause(err)
+	}
+
+	if awsCfg.Credentials == nil {
+		return errors.NewError(errors.ErrCodeCredentialsMissing, "no AWS credential provider configured").
+			WithComponent("s3-client").
+			WithOperation("ResolveCredentials")
+	}
+
+	creds, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return errors.NewError(errors.ErrCodeCredentialsMissing, "failed to retrieve AWS credentials").
+			WithComponent("s3-client").
+			WithOperation("ResolveCredentials").
+			WithCause(err)
+	}
+	if creds.Expired() {
+		return errors.NewError(errors.ErrCodeAuthenticationFailed, "AWS credentials have expired").
+			WithComponent("s3-client").
+			WithOperation("ResolveCredentials").
+			WithContext("source", creds.Source)
+	}
+
+	return nil
 }
 
 // NewClientManager creates a new S3 client manager
@@ -35,16 +80,25 @@ func NewClientManager(ctx context.Context, bucket string, cfg *Config, logger *s
 	}
 
 	// Load AWS configuration
-	awsCfg, err := config.LoadDefaultConfig(ctx,
+	options := []func(*config.LoadOptions) error{
 		config.WithRegion(cfg.Region),
 		config.WithRetryMaxAttempts(cfg.MaxRetries),
-	)
+	}
+	if cfg.URLProvider != nil {
+		// Presigned mode holds no credentials; SDK requests go unsigned
+		options = append(options, config.WithCredentialsProvider(aws.AnonymousCredentials{}))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
+	if awsCfg.Region == "" {
+		// Locate the bucket from the region S3 answers for any bucket
+		awsCfg.Region = defaultProbeRegion
+	}
 
-	// Create standard S3 client without acceleration
-	standardClient := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+	// Options shared by the standard and pooled clients
+	clientOptions := func(o *s3.Options) {
 		if cfg.Endpoint != "" {
 			o.BaseEndpoint = aws.String(cfg.Endpoint)
 		}
@@ -54,7 +108,10 @@ func NewClientManager(ctx context.Context, bucket string, cfg *Config, logger *s
 		if cfg.UseDualStack {
 			o.EndpointOptions.UseDualStackEndpoint = aws.DualStackEndpointStateEnabled
 		}
-	})
+	}
+
+	// Create standard S3 client without acceleration
+	standardClient := s3.NewFromConfig(awsCfg, clientOptions)
 
 	// Create accelerated S3 client if Transfer Acceleration is enabled
 	var acceleratedClient *s3.Client
@@ -87,7 +144,10 @@ func NewClientManager(ctx context.Context, bucket string, cfg *Config, logger *s
 
 	// Create connection pool
 	pool, err := NewConnectionPool(cfg.PoolSize, func() (*s3.Client, error) {
-		return s3.NewFromConfig(awsCfg), nil
+		return s3.NewFromConfig(awsCfg, clientOptions), nil
+	}, cfg.PoolHealth, func(ctx context.Context, client *s3.Client) error {
+		_, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+		return err
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
@@ -123,10 +183,24 @@ func NewClientManager(ctx context.Context, bucket string, cfg *Config, logger *s
 		transporter:        transporter,
 		config:             cfg,
 		logger:             logger,
+		region:             awsCfg.Region,
 		accelerationActive: accelerationActive,
+		awsConfig:          awsCfg,
+		clientOptions:      clientOptions,
 	}, nil
 }
 
+// newRegionClient creates a client signing for region, sending requests to
+// endpoint when it is set and to the configured endpoint otherwise
+func (cm *ClientManager) newRegionClient(region, endpoint string) *s3.Client {
+	return s3.NewFromConfig(cm.awsConfig, cm.clientOptions, func(o *s3.Options) {
+		o.Region = region
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+}
+
 // GetClient returns the main S3 client
 func (cm *ClientManager) GetClient() *s3.Client {
 	return cm.client
@@ -142,6 +216,14 @@ func (cm *ClientManager) ReturnPooledClient(client *s3.Client) {
 	cm.pool.Put(client)
 }
 
+// ReleasePooledClient returns a client to the connection pool, recording
+// the outcome of the request it made so a client that keeps failing to
+// reach S3 is replaced
+func (cm *ClientManager) ReleasePooledClient(client *s3.Client, err error) {
+	cm.pool.Report(client, err)
+	cm.pool.Put(client)
+}
+
 // GetTransporter returns the CargoShip transporter if available
 func (cm *ClientManager) GetTransporter() *cargoships3.Transporter {
 	return cm.transporter
@@ -160,7 +242,6 @@ func (cm *ClientManager) IsCargoShipEnabled() bool {
 // HealthCheck verifies the client connection
 func (cm *ClientManager) HealthCheck(ctx context.Context, bucket string) error {
 	client := cm.GetPooledClient()
-	defer cm.ReturnPooledClient(client)
 
 	// Try to head the bucket
 	input := &s3.HeadBucketInput{
@@ -168,6 +249,7 @@ func (cm *ClientManager) HealthCheck(ctx context.Context, bucket string) error {
 	}
 
 	_, err := client.HeadBucket(ctx, input)
+	cm.ReleasePooledClient(client, err)
 	if err != nil {
 		return fmt.Errorf("S3 health check failed: %w", err)
 	}
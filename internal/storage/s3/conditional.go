@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// getObjectAPIClient is the subset of the S3 client used for conditional reads
+type getObjectAPIClient interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// GetObjectIfModified retrieves size bytes of an object from offset, or the
+// rest of it when size is 0, only if the object changed since the given
+// time or no longer matches etag. When the object is unchanged, notModified
+// is true and no data is transferred. Presigned URLs carry no conditional
+// headers, so it fails in presigned mode.
+func (b *Backend) GetObjectIfModified(ctx context.Context, key string, offset, size int64, since time.Time, etag string) ([]byte, bool, *types.ObjectInfo, error) {
+	if err := b.checkSigned("GetObjectIfModified", key); err != nil {
+		return nil, false, nil, err
+	}
+
+	start := time.Now()
+	defer func() {
+		b.metricsCollector.RecordMetrics(time.Since(start), false)
+	}()
+
+	if !b.healthTracker.CanRead("s3-reads") {
+		state := b.healthTracker.GetState("s3-reads")
+		return nil, false, nil, errors.NewError(errors.ErrCodeServiceUnavailable, "S3 read operations are unavailable").
+			WithComponent("s3-backend").
+			WithOperation("GetObjectIfModified").
+			WithContext("health_state", state.String()).
+			WithContext("bucket", b.bucket).
+			WithContext("key", key)
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket:       aws.String(b.bucket),
+		RequestPayer: b.config.requestPayer(),
+		Key:          aws.String(key),
+	}
+	if size > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
+	} else if offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	}
+	if etag != "" {
+		input.IfNoneMatch = aws.String(etag)
+	}
+	if !since.IsZero() {
+		input.IfModifiedSince = aws.Time(since)
+	}
+
+	breaker := b.circuitManager.GetBreaker("s3-get")
+	var (
+		data        []byte
+		notModified bool
+		info        *types.ObjectInfo
+	)
+
+	err := b.retryer.DoWithContext(ctx, func(retryCtx context.Context) error {
+		return breaker.ExecuteWithContext(retryCtx, func(ctx context.Context) error {
+			return b.executeWithAccelerationFallback(ctx, "GetObjectIfModified", func(client *s3.Client) error {
+				var err error
+				data, notModified, info, err = conditionalGetObject(ctx, client, input)
+				if err != nil {
+					b.metricsCollector.RecordError(err)
+					translatedErr := b.translateError(err, "GetObjectIfModified", key)
+					b.healthTracker.RecordError("s3-reads", translatedErr)
+					return translatedErr
+				}
+
+				b.metricsCollector.RecordBytesDownloaded(int64(len(data)))
+				b.healthTracker.RecordSuccess("s3-reads")
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	if !notModified {
+		b.costOptimizer.RecordAccess(key, int64(len(data)))
+	}
+
+	return data, notModified, info, nil
+}
+
+// conditionalGetObject issues a conditional GET, treating a 304 response as
+// an unchanged object rather than an error. The returned info describes the
+// whole object even when input requests a range.
+func conditionalGetObject(ctx context.Context, client getObjectAPIClient, input *s3.GetObjectInput) ([]byte, bool, *types.ObjectInfo, error) {
+	result, err := client.GetObject(ctx, input)
+	if err != nil {
+		if isNotModifiedError(err) {
+			return nil, true, nil, nil
+		}
+		return nil, false, nil, err
+	}
+	defer func() { _ = result.Body.Close() }()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("failed to read object body: %w", err)
+	}
+
+	info := &types.ObjectInfo{
+		Key:          aws.ToString(input.Key),
+		Size:         int64(len(data)),
+		LastModified: aws.ToTime(result.LastModified),
+		ETag:         aws.ToString(result.ETag),
+		ContentType:  aws.ToString(result.ContentType),
+		Metadata:     result.Metadata,
+		StorageClass: headStorageClass(result.StorageClass),
+		VersionID:    aws.ToString(result.VersionId),
+	}
+	if total, ok := contentRangeTotal(aws.ToString(result.ContentRange)); ok {
+		info.Size = total
+	}
+	if info.Metadata == nil {
+		info.Metadata = make(map[string]string)
+	}
+
+	return data, false, info, nil
+}
+
+// contentRangeTotal returns the object size from a Content-Range header such
+// as "bytes 0-99/1234"
+func contentRangeTotal(contentRange string) (int64, bool) {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok || total == "*" {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+// isNotModifiedError reports whether err represents an HTTP 304 response
+func isNotModifiedError(err error) bool {
+	var statusErr interface{ HTTPStatusCode() int }
+	return stderr.As(err, &statusErr) && statusErr.HTTPStatusCode() == http.StatusNotModified
+}
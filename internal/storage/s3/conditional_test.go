@@ -0,0 +1,119 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// statusError mimics SDK response errors carrying an HTTP status code
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string       { return http.StatusText(e.code) }
+func (e *statusError) HTTPStatusCode() int { return e.code }
+
+// fakeGetClient returns a canned GetObject response
+type fakeGetClient struct {
+	body         string
+	contentRange string
+	err          error
+	input        *s3.GetObjectInput
+}
+
+func (f *fakeGetClient) GetObject(ctx context.Context, input *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.input = input
+	if f.err != nil {
+		return nil, f.err
+	}
+	output := &s3.GetObjectOutput{
+		Body: io.NopCloser(strings.NewReader(f.body)),
+		ETag: aws.String(`"abc"`),
+	}
+	if f.contentRange != "" {
+		output.ContentRange = aws.String(f.contentRange)
+	}
+	return output, nil
+}
+
+func TestConditionalGetObject_NotModified(t *testing.T) {
+	client := &fakeGetClient{err: &statusError{code: http.StatusNotModified}}
+	since := time.Now().Add(-time.Hour)
+	input := &s3.GetObjectInput{
+		Bucket:          aws.String("bucket"),
+		Key:             aws.String("key"),
+		IfNoneMatch:     aws.String(`"abc"`),
+		IfModifiedSince: aws.Time(since),
+	}
+
+	data, notModified, info, err := conditionalGetObject(context.Background(), client, input)
+	if err != nil {
+		t.Fatalf("Expected 304 to be treated as success, got %v", err)
+	}
+	if !notModified {
+		t.Error("Expected notModified for 304 response")
+	}
+	if data != nil || info != nil {
+		t.Error("Expected no data or info for 304 response")
+	}
+	if aws.ToString(client.input.IfNoneMatch) != `"abc"` {
+		t.Errorf("Expected If-None-Match to be sent, got %q", aws.ToString(client.input.IfNoneMatch))
+	}
+}
+
+func TestConditionalGetObject_Modified(t *testing.T) {
+	client := &fakeGetClient{body: "fresh data"}
+	input := &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")}
+
+	data, notModified, info, err := conditionalGetObject(context.Background(), client, input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if notModified {
+		t.Error("Expected modified object")
+	}
+	if string(data) != "fresh data" {
+		t.Errorf("Expected fresh data, got %q", data)
+	}
+	if info == nil || info.ETag != `"abc"` || info.Size != int64(len(data)) {
+		t.Errorf("Unexpected object info: %+v", info)
+	}
+}
+
+func TestConditionalGetObject_RangeReportsObjectSize(t *testing.T) {
+	client := &fakeGetClient{body: "fresh", contentRange: "bytes 6-10/1234"}
+	input := &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key"), Range: aws.String("bytes=6-10")}
+
+	data, _, info, err := conditionalGetObject(context.Background(), client, input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "fresh" {
+		t.Errorf("Expected ranged data, got %q", data)
+	}
+	if info == nil || info.Size != 1234 {
+		t.Errorf("Expected object size from Content-Range, got %+v", info)
+	}
+}
+
+func TestConditionalGetObject_Error(t *testing.T) {
+	client := &fakeGetClient{err: &statusError{code: http.StatusForbidden}}
+	input := &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")}
+
+	_, notModified, _, err := conditionalGetObject(context.Background(), client, input)
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) {
+		t.Errorf("Expected status error to propagate, got %v", err)
+	}
+	if notModified {
+		t.Error("Expected notModified to be false on error")
+	}
+}
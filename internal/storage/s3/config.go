@@ -1,9 +1,14 @@
 package s3
 
 import (
+	"log/slog"
 	"time"
 
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/objectfs/objectfs/internal/circuit"
 	"github.com/objectfs/objectfs/pkg/retry"
+	"github.com/objectfs/objectfs/pkg/types"
 )
 
 // Config represents S3 backend configuration
@@ -21,6 +26,13 @@ type Config struct {
 	RequestTimeout time.Duration `yaml:"request_timeout"`
 	PoolSize       int           `yaml:"pool_size"`
 
+	// Replacement of pooled clients that cannot reach S3
+	PoolHealth PoolHealthConfig `yaml:"pool_health"`
+
+	// Settings of the s3-get and s3-put circuit breakers in place of the
+	// defaults, such as to trip on error rate; zero timings keep the defaults
+	CircuitBreakers map[string]circuit.Config `yaml:"circuit_breakers"`
+
 	// Retry configuration
 	RetryConfig retry.Config `yaml:"retry_config"`
 
@@ -29,6 +41,10 @@ type Config struct {
 	UseDualStack  bool `yaml:"use_dual_stack"`
 	DisableSSL    bool `yaml:"disable_ssl"`
 
+	// Accept the request and transfer charges of requester-pays buckets,
+	// which reject requests without x-amz-request-payer with 403 AccessDenied
+	RequesterPays bool `yaml:"requester_pays"`
+
 	// CargoShip optimization settings
 	EnableCargoShipOptimization bool    `yaml:"enable_cargoship_optimization"`
 	TargetThroughput            float64 `yaml:"target_throughput"`  // MB/s
@@ -39,11 +55,65 @@ type Config struct {
 	MultipartChunkSize   int64 `yaml:"multipart_chunk_size"`  // Chunk size for multipart uploads (bytes)
 	MultipartConcurrency int   `yaml:"multipart_concurrency"` // Number of concurrent part uploads
 
+	// Adapts the threshold and concurrency above to measured throughput
+	AdaptiveMultipart AdaptiveMultipartConfig `yaml:"adaptive_multipart"`
+
+	// What happens to uploaded parts when a part fails after retries:
+	// "abort-on-failure" (default) or "preserve-for-resume"
+	MultipartFailurePolicy string `yaml:"multipart_failure_policy"`
+
+	// Checksum computed while PutObjectStream reads its input and stored in
+	// the object's metadata, then verified by GetObjectStream: "crc32c",
+	// "sha256", or empty for none
+	StreamChecksum string `yaml:"stream_checksum"`
+
+	// Minimum time between progress callbacks for one transfer (default 100ms)
+	ProgressInterval time.Duration `yaml:"progress_interval"`
+
+	// Object size guard, checked before and during uploads
+	MaxObjectSize types.ObjectSizeLimits `yaml:"max_object_size"`
+
 	// S3 Storage Tier Configuration
 	StorageTier      string           `yaml:"storage_tier"`      // "STANDARD", "STANDARD_IA", "ONEZONE_IA", etc.
 	TierConstraints  TierConstraints  `yaml:"tier_constraints"`  // Tier-specific constraints
 	CostOptimization CostOptimization `yaml:"cost_optimization"` // Cost optimization settings
+	TierPolicy       TierPolicyConfig `yaml:"tier_policy"`       // Rules for tier recommendations
 	PricingConfig    PricingConfig    `yaml:"pricing_config"`    // Custom pricing configuration
+	Pack             PackConfig       `yaml:"pack"`              // Small-object packing
+	Hedge            HedgeConfig      `yaml:"hedge"`             // Request hedging for reads
+	CostBudget       CostBudgetConfig `yaml:"cost_budget"`       // Spend rate limit for cost-incurring calls
+	Retention        RetentionConfig  `yaml:"retention"`         // Object Lock retention of written objects
+
+	// Regions holding replicas of the bucket, such as cross-region
+	// replication targets, that reads fail over to when the primary region
+	// fails or is slow. Writes always go to the primary region.
+	ReadReplicaRegions []string           `yaml:"read_replica_regions"`
+	ReadFailover       ReadFailoverConfig `yaml:"read_failover"`
+
+	// Streamed listings list the whole prefix before sending any object,
+	// so slow consumers do not stretch the listing across modifications
+	StableListings bool `yaml:"stable_listings"`
+
+	// Last-read times of objects, for tiering by access recency
+	AccessTracking AccessTrackingConfig `yaml:"access_tracking"`
+
+	// Local index of object metadata and tags for FindByMetadata and FindByTag
+	MetadataIndex MetadataIndexConfig `yaml:"metadata_index"`
+
+	// The latest operations, kept in memory for postmortem inspection
+	RecentOps RecentOpsConfig `yaml:"recent_ops"`
+
+	// Issues presigned URLs for object operations. When set, the backend
+	// holds no long-lived credentials: supported operations are plain HTTP
+	// requests to the URLs, and the rest fail. It can only be set from code.
+	URLProvider URLProvider `yaml:"-"`
+
+	// Forces failures and delays for chaos testing. It can only be set from
+	// code, never from a configuration file, and is nil in production.
+	FaultInjector FaultInjector `yaml:"-"`
+
+	// Structured logger for backend events; nil uses slog.Default()
+	Logger *slog.Logger `yaml:"-"`
 }
 
 // GetOptimalChunkSize returns the optimal chunk size for a given file size
@@ -51,6 +121,14 @@ func (c *Config) GetOptimalChunkSize(fileSize int64) int64 {
 	return CalculateOptimalChunkSize(fileSize, c.MultipartThreshold, c.MultipartChunkSize)
 }
 
+// requestPayer returns the RequestPayer set on object requests
+func (c *Config) requestPayer() s3types.RequestPayer {
+	if c.RequesterPays {
+		return s3types.RequestPayerRequester
+	}
+	return ""
+}
+
 // ShouldUseMultipart determines if a file should use multipart upload
 func (c *Config) ShouldUseMultipart(fileSize int64) bool {
 	return fileSize > c.MultipartThreshold
@@ -66,6 +144,40 @@ type TierConstraints struct {
 	TransitionDelay    time.Duration `yaml:"transition_delay"`     // Delay before transitioning to this tier
 }
 
+// AccessTrackingConfig defines recording of when objects were last read,
+// which S3 does not track itself
+type AccessTrackingConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	MaxEntries      int           `yaml:"max_entries"`      // Objects tracked; the least recently read are dropped first (default 100000)
+	Path            string        `yaml:"path"`             // Sidecar file the index is persisted to; empty keeps it in memory
+	PersistInterval time.Duration `yaml:"persist_interval"` // How often the index is saved to Path (default 5m)
+}
+
+// MetadataIndexConfig configures the local index of user metadata, and
+// optionally tags, that answers FindByMetadata and FindByTag without
+// scanning the bucket
+type MetadataIndexConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	Prefix          string        `yaml:"prefix"`           // Objects indexed; empty indexes the whole bucket
+	Tags            bool          `yaml:"tags"`             // Also index tags, with a GetObjectTagging call per object on each build
+	MaxEntries      int           `yaml:"max_entries"`      // Objects indexed; past this the index reports itself truncated (default 100000)
+	MaxAge          time.Duration `yaml:"max_age"`          // Queries rebuild an index built longer ago than this (default 24h)
+	Path            string        `yaml:"path"`             // File the index is persisted to; empty keeps it in memory
+	PersistInterval time.Duration `yaml:"persist_interval"` // How often the index is saved to Path (default 5m)
+}
+
+// PackConfig defines small-object packing. Objects below the threshold are
+// aggregated into shared pack objects to avoid per-object minimum billable
+// sizes on infrequent-access and archive tiers.
+type PackConfig struct {
+	Enabled       bool          `yaml:"enabled"`        // Enable small-object packing
+	Threshold     int64         `yaml:"threshold"`      // Pack objects smaller than this (0 uses the tier minimum)
+	MaxPackSize   int64         `yaml:"max_pack_size"`  // Flush pending objects once they reach this size
+	FlushInterval time.Duration `yaml:"flush_interval"` // Flush pending objects at least this often
+	Prefix        string        `yaml:"prefix"`         // Key prefix for pack and index objects
+	CompactRatio  float64       `yaml:"compact_ratio"`  // Rewrite a pack once live bytes fall below this fraction
+}
+
 // CostOptimization defines cost optimization settings
 type CostOptimization struct {
 	EnableAutoTiering     bool             `yaml:"enable_auto_tiering"`     // Automatically transition objects between tiers
@@ -91,6 +203,23 @@ type ObjectFilter struct {
 	MaxSize int64 `yaml:"max_size"` // Maximum object size in bytes (-1 for unlimited)
 }
 
+// TierPolicyConfig defines rule-based tier recommendations
+type TierPolicyConfig struct {
+	Rules []TierPolicyRule `yaml:"rules"` // Evaluated in order; first match wins
+}
+
+// TierPolicyRule maps objects matching its criteria to a storage tier
+type TierPolicyRule struct {
+	Prefix          string        `yaml:"prefix"`           // Key prefix to match (empty matches all)
+	MinSize         int64         `yaml:"min_size"`         // Minimum object size in bytes
+	MaxSize         int64         `yaml:"max_size"`         // Maximum object size in bytes (0 for unlimited)
+	MinAge          time.Duration `yaml:"min_age"`          // Minimum object age
+	MaxAge          time.Duration `yaml:"max_age"`          // Maximum object age (0 for unlimited)
+	AccessFrequency string        `yaml:"access_frequency"` // Access frequency to match (empty matches all)
+	Tier            string        `yaml:"tier"`             // Tier to recommend
+	Reason          string        `yaml:"reason"`           // Reason reported with the decision
+}
+
 // PricingConfig defines custom pricing configuration for S3 costs
 type PricingConfig struct {
 	UsePricingAPI      bool                   `yaml:"use_pricing_api"`      // Fetch current AWS pricing via API
@@ -229,6 +358,23 @@ func NewDefaultConfig() *Config {
 		MultipartConcurrency:        8,                 // Match pool size for concurrent uploads
 		StorageTier:                 TierStandard,      // Default to Standard tier
 		TierConstraints:             TierConstraints{}, // Use tier defaults
+		MultipartFailurePolicy:      MultipartAbortOnFailure,
+		RecentOps:                   RecentOpsConfig{Size: defaultRecentOpsSize},
+		Pack: PackConfig{
+			Enabled:       false,
+			MaxPackSize:   8 * 1024 * 1024, // 8MB
+			FlushInterval: 30 * time.Second,
+			Prefix:        ".objectfs/packs/",
+			CompactRatio:  0.5,
+		},
+		Hedge: HedgeConfig{
+			Enabled:      false,
+			Percentile:   0.95,
+			InitialDelay: 100 * time.Millisecond,
+			MinDelay:     5 * time.Millisecond,
+			MaxRate:      0.05,
+			Window:       256,
+		},
 		CostOptimization: CostOptimization{
 			EnableAutoTiering:     false,
 			LifecycleManagement:   false,
@@ -0,0 +1,201 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// defaultCopyConcurrency bounds concurrent server-side copies in CopyPrefix
+const defaultCopyConcurrency = 16
+
+// copyAPIClient is the subset of the S3 client used to copy prefixes
+type copyAPIClient interface {
+	s3.ListObjectsV2APIClient
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+}
+
+// CopyOptions controls CopyPrefix
+type CopyOptions struct {
+	Concurrency int                   // Concurrent server-side copies (default 16)
+	DryRun      bool                  // Report what would be copied without copying
+	Filter      func(key string) bool // Copy only source keys it accepts; nil copies all
+}
+
+// CopyResult summarizes a CopyPrefix run
+type CopyResult struct {
+	Copied  int      `json:"copied"`
+	Skipped int      `json:"skipped"` // Destinations already matching their source
+	Failed  int      `json:"failed"`
+	Bytes   int64    `json:"bytes"` // Bytes copied, or that would be copied in a dry run
+	Keys    []string `json:"keys"`  // Destination keys copied or, in a dry run, planned
+}
+
+// CopyPrefix copies every object under srcPrefix to the same relative key
+// under dstPrefix with bounded concurrent server-side copies, so no data
+// passes through this host. Destinations whose ETag already matches their
+// source are skipped, which lets an interrupted copy be resumed by running
+// it again. Storage class and metadata are preserved.
+func (b *Backend) CopyPrefix(ctx context.Context, srcPrefix, dstPrefix string, opts CopyOptions) (CopyResult, error) {
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	result, err := copyPrefix(ctx, client, b.bucket, b.config.requestPayer(), srcPrefix, dstPrefix, opts)
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+	}
+	if !opts.DryRun {
+		for _, key := range result.Keys {
+			b.metadataIndex.invalidate(key)
+		}
+	}
+	return result, err
+}
+
+// copyPrefix lists the destination, then streams the source listing into a
+// pool of CopyObject workers
+func copyPrefix(ctx context.Context, client copyAPIClient, bucket string, payer s3types.RequestPayer, srcPrefix, dstPrefix string, opts CopyOptions) (CopyResult, error) {
+	var result CopyResult
+	if strings.HasPrefix(dstPrefix, srcPrefix) || strings.HasPrefix(srcPrefix, dstPrefix) {
+		return result, errors.NewError(errors.ErrCodeValidationFailed, "source and destination prefixes overlap").
+			WithComponent("s3-backend").
+			WithOperation("CopyPrefix").
+			WithContext("source", srcPrefix).
+			WithContext("destination", dstPrefix)
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultCopyConcurrency
+	}
+
+	// Objects already under the destination record progress of earlier runs
+	existing := make(map[string]types.ObjectInfo)
+	dstObjects, dstErrs := streamListObjects(ctx, client, &s3.ListObjectsV2Input{
+		Bucket:       aws.String(bucket),
+		Prefix:       aws.String(dstPrefix),
+		RequestPayer: payer,
+	}, nil, nil, nil)
+	for obj := range dstObjects {
+		existing[obj.Key] = obj
+	}
+	if err := <-dstErrs; err != nil {
+		return result, fmt.Errorf("failed to list destination %s: %w", dstPrefix, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures []error
+		sem      = make(chan struct{}, opts.Concurrency)
+	)
+
+	srcObjects, srcErrs := streamListObjects(ctx, client, &s3.ListObjectsV2Input{
+		Bucket:       aws.String(bucket),
+		Prefix:       aws.String(srcPrefix),
+		RequestPayer: payer,
+	}, nil, nil, nil)
+	for src := range srcObjects {
+		if opts.Filter != nil && !opts.Filter(src.Key) {
+			continue
+		}
+		dstKey := dstPrefix + strings.TrimPrefix(src.Key, srcPrefix)
+		if dst, ok := existing[dstKey]; ok && copyIsCurrent(src, dst) {
+			result.Skipped++
+			continue
+		}
+		if opts.DryRun {
+			result.Keys = append(result.Keys, dstKey)
+			result.Bytes += src.Size
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(src types.ObjectInfo, dstKey string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := copyObject(ctx, client, bucket, payer, src, dstKey)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed++
+				failures = append(failures, fmt.Errorf("copy %s to %s: %w", src.Key, dstKey, err))
+				return
+			}
+			result.Copied++
+			result.Bytes += src.Size
+			result.Keys = append(result.Keys, dstKey)
+		}(src, dstKey)
+	}
+	wg.Wait()
+
+	sort.Strings(result.Keys)
+	if err := <-srcErrs; err != nil && ctx.Err() == nil {
+		return result, fmt.Errorf("failed to list source %s: %w", srcPrefix, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	return result, stderr.Join(failures...)
+}
+
+// copyIsCurrent reports whether dst already holds a copy of src. Copies of
+// multipart uploads get a new ETag, so those match on size and age instead.
+func copyIsCurrent(src, dst types.ObjectInfo) bool {
+	if src.ETag != "" && src.ETag == dst.ETag {
+		return true
+	}
+	multipart := strings.Contains(src.ETag, "-")
+	return multipart && src.Size == dst.Size && !dst.LastModified.Before(src.LastModified)
+}
+
+// copyObject copies src to dstKey within bucket, keeping its storage class
+// and metadata
+func copyObject(ctx context.Context, client copyAPIClient, bucket string, payer s3types.RequestPayer, src types.ObjectInfo, dstKey string) error {
+	if src.Size > maxCopyObjectSize {
+		return errors.NewError(errors.ErrCodeLimitExceeded, "object is too large for a single copy").
+			WithComponent("s3-backend").
+			WithOperation("CopyPrefix").
+			WithContext("key", src.Key).
+			WithDetail("size", src.Size).
+			WithDetail("max_size", int64(maxCopyObjectSize))
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(bucket),
+		Key:               aws.String(dstKey),
+		CopySource:        aws.String(copySource(bucket, src.Key)),
+		MetadataDirective: s3types.MetadataDirectiveCopy,
+		RequestPayer:      payer,
+	}
+	if src.StorageClass != "" {
+		input.StorageClass = s3types.StorageClass(src.StorageClass)
+	}
+	if src.ETag != "" {
+		input.CopySourceIfMatch = aws.String(src.ETag)
+	}
+
+	_, err := client.CopyObject(ctx, input)
+	return err
+}
@@ -0,0 +1,183 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeCopyBucket is an in-memory bucket supporting paged listings and
+// server-side copies
+type fakeCopyBucket struct {
+	mu      sync.Mutex
+	objects map[string]s3types.Object
+	copied  []string
+
+	// onCopy runs after each successful copy with the number made so far
+	onCopy func(copies int)
+}
+
+func newFakeCopyBucket(keys ...string) *fakeCopyBucket {
+	f := &fakeCopyBucket{objects: make(map[string]s3types.Object)}
+	for i, key := range keys {
+		f.objects[key] = s3types.Object{
+			Key:          aws.String(key),
+			Size:         aws.Int64(int64(100 + i)),
+			ETag:         aws.String(fmt.Sprintf(`"etag-%d"`, i)),
+			StorageClass: s3types.ObjectStorageClassStandardIa,
+		}
+	}
+	return f
+}
+
+func (f *fakeCopyBucket) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, aws.ToString(input.Prefix)) && key > aws.ToString(input.ContinuationToken) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// Two keys per page to exercise pagination
+	out := &s3.ListObjectsV2Output{}
+	if len(keys) > 2 {
+		keys = keys[:2]
+		out.IsTruncated = aws.Bool(true)
+		out.NextContinuationToken = aws.String(keys[1])
+	}
+	for _, key := range keys {
+		out.Contents = append(out.Contents, f.objects[key])
+	}
+	return out, nil
+}
+
+func (f *fakeCopyBucket) CopyObject(ctx context.Context, input *s3.CopyObjectInput, _ ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+
+	source, err := url.PathUnescape(strings.TrimPrefix(aws.ToString(input.CopySource), "bucket/"))
+	if err != nil {
+		f.mu.Unlock()
+		return nil, err
+	}
+	src, ok := f.objects[source]
+	if !ok {
+		f.mu.Unlock()
+		return nil, &s3types.NoSuchKey{}
+	}
+	if input.CopySourceIfMatch != nil && aws.ToString(input.CopySourceIfMatch) != aws.ToString(src.ETag) {
+		f.mu.Unlock()
+		return nil, errors.New("PreconditionFailed")
+	}
+
+	dst := src
+	dst.Key = input.Key
+	dst.StorageClass = s3types.ObjectStorageClass(input.StorageClass)
+	f.objects[aws.ToString(input.Key)] = dst
+	f.copied = append(f.copied, aws.ToString(input.Key))
+	copies := len(f.copied)
+	f.mu.Unlock()
+
+	if f.onCopy != nil {
+		f.onCopy(copies)
+	}
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func TestCopyPrefixResumesAfterInterruption(t *testing.T) {
+	bucket := newFakeCopyBucket("src/a", "src/b", "src/c", "src/d/e", "src/d/f", "src/g", "other/x")
+
+	// Interrupt the first run after three copies
+	ctx, cancel := context.WithCancel(context.Background())
+	bucket.onCopy = func(copies int) {
+		if copies == 3 {
+			cancel()
+		}
+	}
+	first, err := copyPrefix(ctx, bucket, "bucket", "", "src/", "dst/", CopyOptions{Concurrency: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("interrupted copy error = %v, want context.Canceled", err)
+	}
+	if first.Copied != 3 {
+		t.Fatalf("interrupted copy made %d copies, want 3", first.Copied)
+	}
+
+	bucket.onCopy = nil
+	bucket.copied = nil
+	second, err := copyPrefix(context.Background(), bucket, "bucket", "", "src/", "dst/", CopyOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatalf("resumed copy failed: %v", err)
+	}
+	if second.Skipped != 3 || second.Copied != 3 {
+		t.Errorf("resumed copy skipped %d and copied %d, want 3 and 3", second.Skipped, second.Copied)
+	}
+
+	sort.Strings(bucket.copied)
+	for _, key := range bucket.copied {
+		for _, done := range first.Keys {
+			if key == done {
+				t.Errorf("resumed copy recopied %s", key)
+			}
+		}
+	}
+
+	for _, key := range []string{"dst/a", "dst/b", "dst/c", "dst/d/e", "dst/d/f", "dst/g"} {
+		obj, ok := bucket.objects[key]
+		if !ok {
+			t.Errorf("%s missing after resumed copy", key)
+			continue
+		}
+		if obj.StorageClass != s3types.ObjectStorageClassStandardIa {
+			t.Errorf("%s storage class = %s, want STANDARD_IA", key, obj.StorageClass)
+		}
+	}
+	if _, ok := bucket.objects["dst/x"]; ok {
+		t.Error("copied a key from outside the source prefix")
+	}
+}
+
+func TestCopyPrefixDryRunAndFilter(t *testing.T) {
+	bucket := newFakeCopyBucket("src/a.log", "src/b.txt", "src/c.log")
+
+	result, err := copyPrefix(context.Background(), bucket, "bucket", "", "src/", "dst/", CopyOptions{
+		DryRun: true,
+		Filter: func(key string) bool { return strings.HasSuffix(key, ".log") },
+	})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(result.Keys) != 2 || result.Keys[0] != "dst/a.log" || result.Keys[1] != "dst/c.log" {
+		t.Errorf("planned keys = %v, want [dst/a.log dst/c.log]", result.Keys)
+	}
+	if result.Bytes != 100+102 {
+		t.Errorf("planned bytes = %d, want %d", result.Bytes, 100+102)
+	}
+	if len(bucket.copied) != 0 {
+		t.Errorf("dry run copied %v", bucket.copied)
+	}
+}
+
+func TestCopyPrefixRejectsOverlap(t *testing.T) {
+	bucket := newFakeCopyBucket("src/a")
+	if _, err := copyPrefix(context.Background(), bucket, "bucket", "", "src/", "src/backup/", CopyOptions{}); err == nil {
+		t.Error("expected an error when the destination is inside the source")
+	}
+}
@@ -0,0 +1,255 @@
+package s3
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// Cost budget modes for calls that would exceed the spend rate
+const (
+	CostBudgetQueue  = "queue"  // Delay the call until the budget allows it
+	CostBudgetReject = "reject" // Fail the call with ErrCodeQuotaExceeded
+)
+
+// costRateWindow is the trailing window the reported spend rate covers
+const costRateWindow = time.Minute
+
+// CostBudgetConfig caps the spend rate of cost-incurring requests such as
+// archive retrievals and data transfer out
+type CostBudgetConfig struct {
+	USDPerHour float64       `yaml:"usd_per_hour"` // Spend rate budget; 0 disables the scheduler
+	BurstUSD   float64       `yaml:"burst_usd"`    // Spend allowed at once (default one minute of budget)
+	Mode       string        `yaml:"mode"`         // "queue" (default) or "reject"
+	MaxWait    time.Duration `yaml:"max_wait"`     // Longest a queued call waits before it is rejected (default 1m)
+}
+
+// CostBudgetStats reports spend against the configured budget
+type CostBudgetStats struct {
+	BudgetUSDPerHour float64 `json:"budget_usd_per_hour"`
+	SpendUSDPerHour  float64 `json:"spend_usd_per_hour"` // Over the trailing minute
+	SpentUSD         float64 `json:"spent_usd"`
+	Queued           int64   `json:"queued"`
+	Rejected         int64   `json:"rejected"`
+}
+
+type costSample struct {
+	at   time.Time
+	cost float64
+}
+
+// costScheduler throttles calls with a token bucket denominated in USD.
+// Queued calls reserve their cost up front, so they are admitted in order
+// and the long-run spend rate never exceeds the budget.
+type costScheduler struct {
+	config  CostBudgetConfig
+	pricing *PricingManager
+	rate    float64 // USD per second
+
+	now  func() time.Time
+	wait func(ctx context.Context, d time.Duration) error
+
+	mu            sync.Mutex
+	pricingByTier map[string]TierPricing
+	tokens        float64
+	last          time.Time
+	samples       []costSample
+	spent         float64
+	queued        int64
+	rejected      int64
+}
+
+// newCostScheduler returns a scheduler for config, or nil when no budget is set
+func newCostScheduler(config CostBudgetConfig, pricing *PricingManager) *costScheduler {
+	if config.USDPerHour <= 0 {
+		return nil
+	}
+	if config.BurstUSD <= 0 {
+		config.BurstUSD = config.USDPerHour / 60
+	}
+	if config.Mode == "" {
+		config.Mode = CostBudgetQueue
+	}
+	if config.MaxWait <= 0 {
+		config.MaxWait = time.Minute
+	}
+
+	cs := &costScheduler{
+		config:  config,
+		pricing: pricing,
+		rate:    config.USDPerHour / time.Hour.Seconds(),
+		now:     time.Now,
+		wait:    sleepContext,
+		tokens:  config.BurstUSD,
+
+		pricingByTier: make(map[string]TierPricing),
+	}
+	cs.last = cs.now()
+	return cs
+}
+
+// sleepContext waits for d or until ctx ends
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// estimate returns the expected cost in USD of one request of requestType
+// ("GET", "PUT", "HEAD", "LIST") moving bytes to or from tier
+func (cs *costScheduler) estimate(requestType, tier string, bytes int64) float64 {
+	if cs == nil {
+		return 0
+	}
+	pricing, ok := cs.tierPricing(tier)
+	if !ok {
+		return 0
+	}
+
+	gb := float64(bytes) / (1024 * 1024 * 1024)
+	switch requestType {
+	case "GET":
+		transferOut := cs.pricing.config.AdditionalCosts.DataTransferOut.FirstTBPerGB
+		return pricing.RequestCosts.GetRequestCost + gb*(pricing.RetrievalCostPerGB+transferOut)
+	case "PUT":
+		return pricing.RequestCosts.PutRequestCost
+	case "HEAD":
+		return pricing.RequestCosts.HeadRequestCost
+	case "LIST":
+		return pricing.RequestCosts.ListRequestCost
+	default:
+		return 0
+	}
+}
+
+// tierPricing returns the discounted pricing of tier, looked up once per
+// tier so estimates never wait on the pricing API
+func (cs *costScheduler) tierPricing(tier string) (TierPricing, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if pricing, ok := cs.pricingByTier[tier]; ok {
+		return pricing, true
+	}
+	pricing, err := cs.pricing.GetTierPricing(tier)
+	if err != nil {
+		return TierPricing{}, false
+	}
+	cs.pricingByTier[tier] = pricing
+	return pricing, true
+}
+
+// reserve admits a call costing cost USD, queuing or rejecting it when the
+// budget is exhausted
+func (cs *costScheduler) reserve(ctx context.Context, operation, key string, cost float64) error {
+	if cs == nil || cost <= 0 {
+		return nil
+	}
+
+	cs.mu.Lock()
+	now := cs.now()
+	cs.refillLocked(now)
+
+	// A full bucket admits calls larger than the burst, so they are not
+	// starved forever
+	if cs.tokens >= cost || cs.tokens >= cs.config.BurstUSD {
+		cs.tokens -= cost
+		cs.recordLocked(now, cost)
+		cs.mu.Unlock()
+		return nil
+	}
+
+	delay := time.Duration((cost - cs.tokens) / cs.rate * float64(time.Second))
+	if cs.config.Mode == CostBudgetReject || delay > cs.config.MaxWait {
+		cs.rejected++
+		cs.mu.Unlock()
+		return errors.NewError(errors.ErrCodeQuotaExceeded, "cost budget exceeded").
+			WithComponent("s3-backend").
+			WithOperation(operation).
+			WithContext("key", key).
+			WithDetail("estimated_cost_usd", cost).
+			WithDetail("budget_usd_per_hour", cs.config.USDPerHour).
+			WithDetail("retry_after", delay.String())
+	}
+	cs.tokens -= cost
+	cs.queued++
+	cs.mu.Unlock()
+
+	if err := cs.wait(ctx, delay); err != nil {
+		cs.mu.Lock()
+		cs.tokens += cost
+		cs.mu.Unlock()
+		return err
+	}
+
+	cs.mu.Lock()
+	cs.recordLocked(cs.now(), cost)
+	cs.mu.Unlock()
+	return nil
+}
+
+// charge records cost discovered after a call completed, such as the
+// retrieval of an object whose size was not known up front
+func (cs *costScheduler) charge(cost float64) {
+	if cs == nil || cost <= 0 {
+		return
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	now := cs.now()
+	cs.refillLocked(now)
+	cs.tokens -= cost
+	cs.recordLocked(now, cost)
+}
+
+func (cs *costScheduler) refillLocked(now time.Time) {
+	if elapsed := now.Sub(cs.last).Seconds(); elapsed > 0 {
+		cs.tokens += elapsed * cs.rate
+		if cs.tokens > cs.config.BurstUSD {
+			cs.tokens = cs.config.BurstUSD
+		}
+		cs.last = now
+	}
+}
+
+func (cs *costScheduler) recordLocked(now time.Time, cost float64) {
+	cs.spent += cost
+	cs.samples = append(cs.samples, costSample{at: now, cost: cost})
+	cs.pruneLocked(now)
+}
+
+func (cs *costScheduler) pruneLocked(now time.Time) {
+	cutoff := now.Add(-costRateWindow)
+	i := 0
+	for i < len(cs.samples) && !cs.samples[i].at.After(cutoff) {
+		i++
+	}
+	cs.samples = cs.samples[i:]
+}
+
+// stats returns spend against the budget
+func (cs *costScheduler) stats() CostBudgetStats {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.pruneLocked(cs.now())
+	var recent float64
+	for _, sample := range cs.samples {
+		recent += sample.cost
+	}
+	return CostBudgetStats{
+		BudgetUSDPerHour: cs.config.USDPerHour,
+		SpendUSDPerHour:  recent * float64(time.Hour/costRateWindow),
+		SpentUSD:         cs.spent,
+		Queued:           cs.queued,
+		Rejected:         cs.rejected,
+	}
+}
@@ -0,0 +1,136 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	objerrors "github.com/objectfs/objectfs/pkg/errors"
+)
+
+// newTestCostScheduler returns a scheduler on a fake clock that advances
+// whenever a queued call waits
+func newTestCostScheduler(t *testing.T, config CostBudgetConfig) (*costScheduler, *time.Time) {
+	t.Helper()
+	cs := newCostScheduler(config, NewPricingManager(PricingConfig{}, slog.Default()))
+	if cs == nil {
+		t.Fatal("expected a cost scheduler")
+	}
+	now := time.Unix(1700000000, 0)
+	cs.now = func() time.Time { return now }
+	cs.wait = func(ctx context.Context, d time.Duration) error {
+		now = now.Add(d)
+		return nil
+	}
+	cs.last = now
+	return cs, &now
+}
+
+func TestCostBudgetThrottlesRetrievalBurst(t *testing.T) {
+	config := CostBudgetConfig{USDPerHour: 3.60} // $0.001 per second
+	cs, now := newTestCostScheduler(t, config)
+	start := *now
+
+	const gb = 1024 * 1024 * 1024
+	cost := cs.estimate("GET", TierGlacier, gb)
+	if cost < 0.02 {
+		t.Fatalf("estimated Glacier retrieval cost = %f, want at least 0.02", cost)
+	}
+
+	const retrievals = 50
+	for i := 0; i < retrievals; i++ {
+		if err := cs.reserve(context.Background(), "GetObject", "archive/object", cost); err != nil {
+			t.Fatalf("retrieval %d rejected: %v", i, err)
+		}
+	}
+
+	elapsed := now.Sub(start).Seconds()
+	spent := cost * retrievals
+	burst := config.USDPerHour / 60
+	allowed := burst + elapsed*cs.rate
+	if spent > allowed+1e-9 {
+		t.Errorf("spent $%.4f in %.0fs, budget allows $%.4f", spent, elapsed, allowed)
+	}
+	if spent < allowed-cost {
+		t.Errorf("spent $%.4f in %.0fs, throttled below the budget of $%.4f", spent, elapsed, allowed)
+	}
+
+	stats := cs.stats()
+	if stats.Queued == 0 {
+		t.Error("expected calls to be queued")
+	}
+	if stats.Rejected != 0 {
+		t.Errorf("Rejected = %d, want 0", stats.Rejected)
+	}
+	if stats.SpentUSD < spent-1e-9 {
+		t.Errorf("SpentUSD = %f, want %f", stats.SpentUSD, spent)
+	}
+	// Once the burst is used up, the trailing rate settles at the budget
+	if stats.SpendUSDPerHour > config.USDPerHour*1.1 {
+		t.Errorf("SpendUSDPerHour = %f, want at most about %f", stats.SpendUSDPerHour, config.USDPerHour)
+	}
+}
+
+func TestCostBudgetRejectMode(t *testing.T) {
+	cs, _ := newTestCostScheduler(t, CostBudgetConfig{USDPerHour: 3.60, Mode: CostBudgetReject})
+
+	cost := cs.config.BurstUSD / 2
+	for i := 0; i < 2; i++ {
+		if err := cs.reserve(context.Background(), "GetObject", "key", cost); err != nil {
+			t.Fatalf("call %d within burst rejected: %v", i, err)
+		}
+	}
+
+	err := cs.reserve(context.Background(), "GetObject", "key", cost)
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeQuotaExceeded, "")) {
+		t.Fatalf("expected ErrCodeQuotaExceeded, got %v", err)
+	}
+	if got := cs.stats().Rejected; got != 1 {
+		t.Errorf("Rejected = %d, want 1", got)
+	}
+}
+
+func TestCostBudgetRejectsPastMaxWait(t *testing.T) {
+	cs, _ := newTestCostScheduler(t, CostBudgetConfig{USDPerHour: 3.60, MaxWait: 10 * time.Second})
+
+	if err := cs.reserve(context.Background(), "GetObject", "key", cs.config.BurstUSD); err != nil {
+		t.Fatalf("first call rejected: %v", err)
+	}
+	// Refilling $0.02 at $0.001/s takes 20s, past MaxWait
+	err := cs.reserve(context.Background(), "GetObject", "key", 0.02)
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeQuotaExceeded, "")) {
+		t.Fatalf("expected ErrCodeQuotaExceeded, got %v", err)
+	}
+}
+
+func TestCostBudgetCancelledWaitReturnsTokens(t *testing.T) {
+	cs, _ := newTestCostScheduler(t, CostBudgetConfig{USDPerHour: 3.60})
+	cs.wait = func(ctx context.Context, d time.Duration) error {
+		return context.Canceled
+	}
+
+	if err := cs.reserve(context.Background(), "GetObject", "key", cs.config.BurstUSD); err != nil {
+		t.Fatalf("first call rejected: %v", err)
+	}
+	if err := cs.reserve(context.Background(), "GetObject", "key", 0.01); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if cs.tokens != 0 {
+		t.Errorf("tokens = %f after cancelled wait, want 0", cs.tokens)
+	}
+}
+
+func TestCostBudgetDisabled(t *testing.T) {
+	cs := newCostScheduler(CostBudgetConfig{}, nil)
+	if cs != nil {
+		t.Fatal("expected no scheduler without a budget")
+	}
+	if err := cs.reserve(context.Background(), "GetObject", "key", 1); err != nil {
+		t.Errorf("disabled scheduler rejected a call: %v", err)
+	}
+	if cost := cs.estimate("GET", TierGlacier, 1<<30); cost != 0 {
+		t.Errorf("disabled scheduler estimated %f, want 0", cost)
+	}
+}
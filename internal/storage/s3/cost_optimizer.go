@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"math"
 	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
 )
 
 // Access Frequency Constants
@@ -20,6 +22,7 @@ type CostOptimizer struct {
 	logger         *slog.Logger
 	accessPatterns map[string]*AccessPattern
 	costThreshold  float64
+	policy         TierPolicy
 }
 
 // AccessPattern tracks object access patterns for cost optimization
@@ -36,12 +39,18 @@ type AccessPattern struct {
 
 // NewCostOptimizer creates a new cost optimizer
 func NewCostOptimizer(backend *Backend, config CostOptimization, logger *slog.Logger) *CostOptimizer {
+	var policy TierPolicy = DefaultTierPolicy{}
+	if backend != nil && backend.tierPolicy != nil {
+		policy = backend.tierPolicy
+	}
+
 	return &CostOptimizer{
 		backend:        backend,
 		config:         config,
 		logger:         logger,
 		accessPatterns: make(map[string]*AccessPattern),
 		costThreshold:  config.CostThreshold,
+		policy:         policy,
 	}
 }
 
@@ -145,8 +154,9 @@ func (co *CostOptimizer) analyzeObject(pattern *AccessPattern) *TierOptimization
 	currentCost := co.calculateObjectCost(pattern.ObjectSize, pattern.CurrentTier)
 
 	// Find optimal tier based on access pattern
-	optimalTier := co.findOptimalTier(pattern, accessFreq)
-	if optimalTier == pattern.CurrentTier {
+	decision := co.recommendTier(pattern, accessFreq)
+	optimalTier := decision.Tier
+	if optimalTier == "" || optimalTier == pattern.CurrentTier {
 		return nil // Already optimal
 	}
 
@@ -162,7 +172,7 @@ func (co *CostOptimizer) analyzeObject(pattern *AccessPattern) *TierOptimization
 		ObjectKey:               pattern.ObjectKey,
 		FromTier:                pattern.CurrentTier,
 		ToTier:                  optimalTier,
-		Reason:                  co.generateOptimizationReason(pattern, accessFreq),
+		Reason:                  decision.Reason,
 		EstimatedMonthlySavings: savings,
 		ConfidenceLevel:         co.calculateConfidence(pattern),
 		ObjectSize:              pattern.ObjectSize,
@@ -193,34 +203,43 @@ func (co *CostOptimizer) categorizeAccessFrequency(pattern *AccessPattern) strin
 
 // findOptimalTier finds the most cost-effective tier for an access pattern
 func (co *CostOptimizer) findOptimalTier(pattern *AccessPattern, accessFreq string) string {
-	objectSizeGB := float64(pattern.ObjectSize) / (1024 * 1024 * 1024)
+	return co.recommendTier(pattern, accessFreq).Tier
+}
 
-	// Handle Standard tier overhead: small objects often stay in Standard
-	if pattern.ObjectSize < 128*1024 && accessFreq != AccessNever {
-		return TierStandard // Avoid IA minimum charges for small, accessed objects
+// recommendTier evaluates the tier policy for an access pattern
+func (co *CostOptimizer) recommendTier(pattern *AccessPattern, accessFreq string) TierDecision {
+	return co.policy.Recommend(ObjectStats{
+		Key:             pattern.ObjectKey,
+		Size:            pattern.ObjectSize,
+		Age:             time.Since(pattern.FirstAccessTime),
+		AccessFrequency: accessFreq,
+		CurrentTier:     pattern.CurrentTier,
+		LastAccess:      pattern.LastAccessTime,
+	})
+}
+
+// ClassifyObject recommends a tier for a stored object by how recently it
+// was read, so write-once data that is still read is not archived. Objects
+// with no recorded read are classified by their last-modified time.
+func (co *CostOptimizer) ClassifyObject(info types.ObjectInfo) TierDecision {
+	lastAccess := info.LastModified
+	if accessed, ok := co.backend.accessIndex.lookup(info.Key); ok && accessed.After(lastAccess) {
+		lastAccess = accessed
 	}
 
-	switch accessFreq {
-	case AccessFrequent:
-		return TierStandard
-	case AccessInfrequent:
-		if pattern.ObjectSize >= 128*1024 { // Meet IA minimum size
-			return TierStandardIA
-		}
-		return TierStandard
-	case AccessArchive:
-		if pattern.ObjectSize >= 128*1024 {
-			return TierGlacierIR
-		}
-		return TierStandardIA
-	case AccessCold, AccessNever:
-		if objectSizeGB > 1.0 { // Large objects benefit more from deep archive
-			return TierGlacier
-		}
-		return TierGlacierIR
-	default:
-		return TierIntelligent // Let AWS decide
+	currentTier := info.StorageClass
+	if currentTier == "" {
+		currentTier = co.backend.currentTier
 	}
+
+	return co.policy.Recommend(ObjectStats{
+		Key:             info.Key,
+		Size:            info.Size,
+		Age:             time.Since(info.LastModified),
+		AccessFrequency: recencyFrequency(time.Since(lastAccess)),
+		CurrentTier:     currentTier,
+		LastAccess:      lastAccess,
+	})
 }
 
 // calculateObjectCost calculates monthly storage cost for an object in a tier
@@ -256,22 +275,6 @@ func (co *CostOptimizer) calculateObjectCost(objectSize int64, tier string) floa
 	return co.backend.pricingManager.CalculateVolumeDiscount(tier, objectSizeGB, baseCost)
 }
 
-// generateOptimizationReason generates a human-readable reason for optimization
-func (co *CostOptimizer) generateOptimizationReason(pattern *AccessPattern, accessFreq string) string {
-	switch accessFreq {
-	case AccessFrequent:
-		return "High access frequency - Standard tier optimal"
-	case AccessInfrequent:
-		return "Infrequent access pattern - IA tier more cost-effective"
-	case AccessArchive:
-		return "Archive access pattern - Glacier tier significant savings"
-	case AccessCold, AccessNever:
-		return "Rarely accessed - Deep archive substantial cost reduction"
-	default:
-		return "Access pattern suggests tier optimization opportunity"
-	}
-}
-
 // calculateConfidence calculates confidence level for optimization suggestion
 func (co *CostOptimizer) calculateConfidence(pattern *AccessPattern) float64 {
 	// Base confidence on data quality
@@ -121,6 +121,12 @@ Enterprise Pricing Support:
 - Custom enterprise rates
 - Multi-region cost analysis
 
+Cost-Aware Cache Eviction:
+Backend.EvictionScorer returns a CostAwareScorer for the cache that scores
+each object by its idle time per USD of refetching it, priced with the
+PricingManager for the tier the object was last seen in. Of two equally
+idle objects, a GLACIER one is kept longer than a STANDARD one.
+
 # Configuration
 
 Flexible configuration options:
@@ -190,9 +196,23 @@ CargoShip Integration:
 - Concurrent stream optimization
 - Advanced retry mechanisms
 
+Region Detection:
+- NewBackend locates the bucket with a HEAD request and reads its region from the x-amz-bucket-region header
+- A wrong or missing Region is corrected, with a warning, instead of failing on PermanentRedirect
+- Resolved regions are cached per endpoint and bucket for later backends
+- Buckets whose region cannot be told fail the health check with the bucket and region named
+
+Read Failover:
+- ReadReplicaRegions names regions holding replicas of the bucket; ReadFailover.Buckets and Endpoints locate them
+- GetObject and HeadObject retry on a replica when the primary gets no response, or go there first while the primary's pinged latency exceeds ReadFailover.LatencyThreshold
+- Healthy replicas are preferred in order of the latency Ping measures for each region
+- Writes always go to the primary; ReadRegions reports each region's health and recent operations the region that served each read
+
 Connection Pooling:
 - Configurable pool size (default: 8 connections)
-- Health monitoring and replacement
+- Health monitoring and replacement: a client failing to reach S3 pool_health.failure_threshold times in a row (default 3) is replaced when returned
+- Idle clients are validated with a HEAD of the bucket every pool_health.validation_interval (default 30s)
+- PoolStats and BackendMetrics report healthy, unhealthy and replaced clients
 - Load balancing across connections
 - Connection lifetime management
 
@@ -202,6 +222,115 @@ Tier-Aware Operations:
 - Retrieval cost prediction
 - Access pattern learning
 
+Request Hedging (Config.Hedge, off by default):
+- GetObject and HeadObject fire a second request once the first exceeds the recent p95 latency
+- The first response wins and the slower request is cancelled
+- At most MaxRate of reads are hedged to bound load amplification
+- HedgesFired and HedgeWins are reported in BackendMetrics
+- Until enough reads are timed, the delay is twice the latency measured by Ping
+
+Latency Probe:
+- Ping times a HEAD on the bucket and folds it into a rolling latency estimate
+- An unreachable backend returns a CONNECTION_FAILED error instead of a duration
+- LatencyEstimate and LatencyStats report the estimate, samples, and failures
+
+Transfer Progress:
+- WithProgress attaches a ProgressCallback to GetObject, PutObject, and PutObjectStream
+- Callbacks fire at part and chunk boundaries, at most once per ProgressInterval
+- Callbacks run outside the transfer's lock, so they may call Transfers or GetMetrics; byte counts never go backwards
+- In-flight totals are reported in BackendMetrics; Transfers lists each transfer
+
+Touch:
+- Touch copies an object onto itself to reset its last-modified time and lifecycle clocks
+- Storage class, content headers, and user metadata are preserved
+- Objects within their tier's minimum storage period are refused to avoid early deletion charges
+
+Move:
+- MoveObject copies an object server-side to a new key, then deletes the source
+- User metadata, content headers, tags, and storage class are preserved; ACLs are not
+- A failed copy leaves the source alone; a failed delete is reported with both copies kept
+
+Prefix Copy:
+- CopyPrefix copies every object under a prefix with concurrent server-side CopyObject calls
+- Destinations whose ETag already matches their source are skipped, so rerunning resumes an interrupted copy
+- DryRun reports the keys and bytes that would be copied; Filter restricts the source keys
+
+Multipart Failure Policy (Config.MultipartFailurePolicy):
+- abort-on-failure (default) aborts the whole upload when any part still fails after retries
+- preserve-for-resume keeps the uploaded parts; the next upload of the same key and content sends only the failed parts
+- Preserved uploads are only aborted by housekeeping or a bucket lifecycle rule
+- MultipartUploads reports each tracked upload with its completed parts and part failures
+
+Adaptive Multipart Threshold (Config.AdaptiveMultipart, off by default):
+- Throughput of single PUTs and multipart uploads within a factor of two of the threshold is measured over a window of recent uploads
+- While multipart uploads are at least 20% faster, the threshold halves and part concurrency grows, down to MinThreshold and up to MaxConcurrency
+- Otherwise the threshold doubles and concurrency shrinks, up to MaxThreshold, as on slow or high-latency links
+- Disabled, MultipartThreshold and MultipartConcurrency apply as configured; MultipartTuning reports the effective values either way
+
+Streaming Checksums (Config.StreamChecksum, off by default):
+- PutObjectStream hashes its input with CRC32C or SHA-256 as the parts are read, with no second pass
+- The checksum is stored as checksum-<algorithm> user metadata by copying the completed object onto itself
+- GetObjectStream hashes the body as it is read; the final read fails with ErrCodeStorageRead on a mismatch
+- Objects over 5 GiB, which a single copy cannot rewrite, are uploaded without a stored checksum
+- ObjectChecksum computes the same value over a whole buffer
+
+Paginated Listings:
+- ListObjectsChan drops keys repeated on the next page while the bucket changes, reported as BackendMetrics.ListDuplicatesDropped
+- Objects created after a listing started may be missed; callers needing them list again
+- ListSnapshot lists a whole prefix into an index sorted by key before returning it
+- Config.StableListings makes ListObjectsChan serve from such a snapshot instead of from pages as they arrive
+
+Incomplete Upload Housekeeping:
+- ListIncompleteUploads lists multipart uploads under a prefix that were never completed or aborted
+- AbortIncompleteUploads aborts those initiated longer ago than a threshold and returns how many it aborted
+- Uploads this backend is still sending are skipped; aborted uploads preserved for resume are forgotten
+
+Retention (Config.Retention, off by default):
+- Objects are written with S3 Object Lock in GOVERNANCE or COMPLIANCE mode for a number of days
+- Objects written by PutObject, multipart uploads, and PutObjectStream are all retained
+- Deletes, overwrites, and touches of a still-retained object fail with ErrCodeAccessDenied
+- MoveObject fails before copying when either its source or destination is retained
+- GetObjectRetention and PutObjectRetention read and extend retention per object
+- NewBackend refuses retention on buckets without Object Lock enabled
+
+Access Tracking (Config.AccessTracking, off by default):
+- Each GetObject records the object's last-read time, which S3 does not track
+- GetLastAccess reports it; ClassifyObject recommends a tier by read recency instead of last-modified time
+- The index keeps at most MaxEntries objects, dropping the least recently read
+- With a Path it is saved to that sidecar file every PersistInterval and on Close, and reloaded by NewBackend
+
+Metadata Index (Config.MetadataIndex, off by default):
+- FindByMetadata and FindByTag return the keys under Prefix with a metadata field or tag equal to a value, without scanning the bucket
+- The first query builds the index from a listing and a HEAD of each object (plus GetObjectTagging with Tags), as does the first once it is MaxAge old
+- Writes, deletes, moves, and prefix copies through the backend update it; streamed and copied keys are fetched by the next query
+- It holds at most MaxEntries objects; MetadataIndexStats reports its age, pending keys, and whether it was truncated
+- With a Path it is saved every PersistInterval and on Close, and reloaded by NewBackend
+
+Presigned URLs (Config.URLProvider, set from code):
+- GET, PUT, HEAD, DELETE, and LIST are plain HTTP requests to URLs issued per operation by a URLProvider
+- The node holds no long-lived credentials; SDK requests for other features go unsigned and are denied
+- Operations the provider does not support fail up front without touching health tracking
+- A URL that is expired or rejected with 403 is replaced by a fresh one once; PresignedRefreshes counts these
+- Uploads are single requests, and retention cannot be combined with this mode
+- ListObjectsChan streams the single page a LIST URL returns; GetObjectIfModified and Touch, which no URL covers, fail up front
+
+Requester Pays (Config.RequesterPays, off by default):
+- Object reads, writes, listings, and multipart uploads send x-amz-request-payer: requester
+- Required for requester-pays buckets, such as many public science datasets, which bill the caller
+- Without it those buckets answer 403 AccessDenied; the translated error suggests enabling requester_pays
+
+Fault Injection (Config.FaultInjector, chaos testing only):
+- A FaultInjector is consulted before GET, PUT, HEAD, DELETE, and LIST calls, and before each page of a streamed listing
+- Injected errors and delays pass through the circuit breaker, retry, and health tracking paths
+- ProbabilisticInjector fails at configured rates; ScriptedInjector replays a fixed sequence
+- The injector can only be set from code, so configuration files cannot enable it
+
+Cost Budget (Config.CostBudget, off by default):
+- GET, PUT, HEAD, and LIST calls are priced with the PricingManager before they run
+- Streamed listings (ListObjectsChan, ListSnapshot) reserve each page, and a rejected page ends the stream with ErrCodeQuotaExceeded
+- A token bucket refilled at USDPerHour admits calls; excess calls queue or fail with ErrCodeQuotaExceeded
+- Spend rate against the budget is reported in BackendMetrics and CostBudgetStats
+
 # Enterprise Features
 
 Advanced enterprise capabilities:
@@ -253,6 +382,8 @@ Robust error handling and recovery:
 Transient Error Recovery:
 - Exponential backoff retry logic
 - Circuit breaker patterns
+- Config.CircuitBreakers makes the s3-get and s3-put breakers trip on consecutive failures or on rolling error rate
+- BackendMetrics.CircuitBreakers reports each breaker's state and rolling error rate
 - Connection pool failover
 - Graceful degradation
 
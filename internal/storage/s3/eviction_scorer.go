@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// minRefetchCost keeps free tiers from dividing cost-aware scores by zero
+const minRefetchCost = 1e-9
+
+// CostAwareScorer is an eviction scorer that prefers evicting cached
+// objects that are cheap to fetch again. An object's score is its idle time
+// divided by the cost of one GET of its cached bytes, so of two equally
+// idle objects the one in a tier with retrieval fees, such as GLACIER, is
+// kept longer.
+type CostAwareScorer struct {
+	pricing *PricingManager
+	tierOf  func(key string) string
+
+	mu            sync.Mutex
+	pricingByTier map[string]TierPricing
+}
+
+// NewCostAwareScorer returns a scorer pricing refetches with pricing and
+// tierOf, which reports the storage tier of an object key
+func NewCostAwareScorer(pricing *PricingManager, tierOf func(key string) string) *CostAwareScorer {
+	return &CostAwareScorer{
+		pricing:       pricing,
+		tierOf:        tierOf,
+		pricingByTier: make(map[string]TierPricing),
+	}
+}
+
+// Score returns the seconds the object has been idle per USD it would cost
+// to refetch; never-read objects score highest
+func (s *CostAwareScorer) Score(entry types.CacheEntryInfo) float64 {
+	if entry.LastAccess.IsZero() {
+		return math.MaxFloat64
+	}
+	idle := 1 + time.Since(entry.LastAccess).Seconds()
+	return idle / s.refetchCost(s.tierOf(entry.Key), entry.Size)
+}
+
+// refetchCost returns the USD cost of one GET of size bytes from tier
+func (s *CostAwareScorer) refetchCost(tier string, size int64) float64 {
+	pricing, ok := s.tierPricing(tier)
+	if !ok {
+		return minRefetchCost
+	}
+	gb := float64(size) / (1024 * 1024 * 1024)
+	transferOut := s.pricing.config.AdditionalCosts.DataTransferOut.FirstTBPerGB
+	cost := pricing.RequestCosts.GetRequestCost + gb*(pricing.RetrievalCostPerGB+transferOut)
+	return max(cost, minRefetchCost)
+}
+
+// tierPricing returns the discounted pricing of tier, looked up once per
+// tier so eviction never waits on the pricing API
+func (s *CostAwareScorer) tierPricing(tier string) (TierPricing, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pricing, ok := s.pricingByTier[tier]; ok {
+		return pricing, true
+	}
+	pricing, err := s.pricing.GetTierPricing(tier)
+	if err != nil {
+		return TierPricing{}, false
+	}
+	s.pricingByTier[tier] = pricing
+	return pricing, true
+}
+
+// objectTiers remembers the storage tier of objects seen in HEAD responses
+// and listings. Only tiers other than the backend's default are kept, so
+// the table stays small for buckets written by this backend.
+type objectTiers struct {
+	mu    sync.RWMutex
+	byKey map[string]string
+}
+
+// record notes that key is stored in tier
+func (t *objectTiers) record(key, tier, defaultTier string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tier == "" || tier == defaultTier {
+		delete(t.byKey, key)
+		return
+	}
+	if t.byKey == nil {
+		t.byKey = make(map[string]string)
+	}
+	t.byKey[key] = tier
+}
+
+// forget drops what is known about key, such as after it is deleted
+func (t *objectTiers) forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.byKey, key)
+}
+
+// lookup returns the recorded tier of key, or defaultTier when none was seen
+func (t *objectTiers) lookup(key, defaultTier string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if tier, ok := t.byKey[key]; ok {
+		return tier
+	}
+	return defaultTier
+}
+
+// EvictionScorer returns a cost-aware eviction scorer for objects in this
+// bucket, pricing each object by the storage tier it was last seen in
+func (b *Backend) EvictionScorer() *CostAwareScorer {
+	return NewCostAwareScorer(b.pricingManager, func(key string) string {
+		return b.objectTiers.lookup(key, b.currentTier)
+	})
+}
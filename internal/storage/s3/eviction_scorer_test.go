@@ -0,0 +1,70 @@
+package s3
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/cache"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+func TestCostAwareScorerRetainsGlacierObject(t *testing.T) {
+	var tiers objectTiers
+	tiers.record("archive/scan.tif", TierGlacier, TierStandard)
+	tiers.record("data/scan.tif", TierStandard, TierStandard)
+	scorer := NewCostAwareScorer(NewPricingManager(PricingConfig{}, slog.Default()), func(key string) string {
+		return tiers.lookup(key, TierStandard)
+	})
+
+	lastAccess := time.Now().Add(-time.Minute)
+	glacier := scorer.Score(types.CacheEntryInfo{Key: "archive/scan.tif", Size: 2048, LastAccess: lastAccess})
+	standard := scorer.Score(types.CacheEntryInfo{Key: "data/scan.tif", Size: 2048, LastAccess: lastAccess})
+	if glacier >= standard {
+		t.Fatalf("glacier score %v >= standard score %v, want the cheaper refetch evicted first", glacier, standard)
+	}
+
+	base := cache.NewLRUCache(&cache.CacheConfig{MaxSize: 4 * 1024, MaxEntries: 100})
+	defer func() { _ = base.Close() }()
+	pc, err := cache.NewPredictiveCache(&cache.PredictiveCacheConfig{
+		BaseCache:                 base,
+		EnablePrediction:          true,
+		PredictionWindow:          100,
+		EnableIntelligentEviction: true,
+		Scorer:                    scorer,
+	})
+	if err != nil {
+		t.Fatalf("NewPredictiveCache() error = %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	// The Glacier object is older, so plain LRU would evict it first
+	block := make([]byte, 2048)
+	pc.Put("archive/scan.tif", 0, block)
+	pc.Put("data/scan.tif", 0, block)
+	pc.Put("data/next.tif", 0, block)
+
+	if base.Get("archive/scan.tif", 0, 2048) == nil {
+		t.Error("Glacier object evicted, want it retained as the expensive refetch")
+	}
+	if base.Get("data/scan.tif", 0, 2048) != nil {
+		t.Error("Standard object retained, want it evicted as the cheap refetch")
+	}
+}
+
+func TestObjectTiersKeepsOnlyNonDefaultTiers(t *testing.T) {
+	var tiers objectTiers
+	tiers.record("cold", TierDeepArchive, TierStandard)
+	tiers.record("warm", TierStandard, TierStandard)
+	if got := tiers.lookup("cold", TierStandard); got != TierDeepArchive {
+		t.Errorf("lookup(cold) = %s, want %s", got, TierDeepArchive)
+	}
+	if len(tiers.byKey) != 1 {
+		t.Errorf("recorded %d tiers, want only the non-default one", len(tiers.byKey))
+	}
+
+	tiers.forget("cold")
+	if got := tiers.lookup("cold", TierStandard); got != TierStandard {
+		t.Errorf("lookup after forget = %s, want the default tier", got)
+	}
+}
@@ -0,0 +1,165 @@
+package s3
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// Operations consulted with a FaultInjector
+const (
+	FaultOpGet    = "GetObject"
+	FaultOpPut    = "PutObject"
+	FaultOpHead   = "HeadObject"
+	FaultOpDelete = "DeleteObject"
+	FaultOpList   = "ListObjects"
+
+	// FaultOpAny matches every operation in a ProbabilisticInjector
+	FaultOpAny = "*"
+)
+
+// Fault is a synthetic failure or delay for one operation
+type Fault struct {
+	Delay time.Duration // Added before the operation runs
+	Err   error         // Returned instead of running the operation
+}
+
+// FaultInjector is consulted before each backend operation so chaos tests
+// can force failures and latency through the breaker, retry, and health
+// paths. The zero Fault lets the operation run normally.
+type FaultInjector interface {
+	Inject(ctx context.Context, operation, key string) Fault
+}
+
+// InjectedError returns the error an injected failure of operation reports.
+// Writes fail with ErrCodeStorageWrite so they drive the health tracker to
+// read-only mode like real write failures.
+func InjectedError(operation, key string) error {
+	code := errors.ErrCodeStorageRead
+	if operation == FaultOpPut || operation == FaultOpDelete {
+		code = errors.ErrCodeStorageWrite
+	}
+	return errors.NewError(code, "injected fault").
+		WithComponent("fault-injector").
+		WithOperation(operation).
+		WithContext("key", key)
+}
+
+// injectFault applies the configured fault for operation, if any
+func (b *Backend) injectFault(ctx context.Context, operation, key string) error {
+	if b.config == nil || b.config.FaultInjector == nil {
+		return nil
+	}
+
+	fault := b.config.FaultInjector.Inject(ctx, operation, key)
+	if fault.Delay > 0 {
+		if err := sleepContext(ctx, fault.Delay); err != nil {
+			return err
+		}
+	}
+	if fault.Err != nil {
+		b.metricsCollector.RecordError(fault.Err)
+	}
+	return fault.Err
+}
+
+// FaultRate sets how often a ProbabilisticInjector fails or delays an operation
+type FaultRate struct {
+	ErrorRate float64       // Fraction of calls that fail
+	DelayRate float64       // Fraction of calls that are delayed
+	Delay     time.Duration // Delay added to delayed calls
+	Err       error         // Error returned; defaults to InjectedError
+}
+
+// ProbabilisticInjector fails and delays operations at configured rates
+type ProbabilisticInjector struct {
+	rates map[string]FaultRate
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewProbabilisticInjector creates an injector with per-operation rates.
+// Operations without a rate use the FaultOpAny rate when one is set. The
+// seed makes runs repeatable.
+func NewProbabilisticInjector(rates map[string]FaultRate, seed int64) *ProbabilisticInjector {
+	return &ProbabilisticInjector{
+		rates: rates,
+		rng:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Inject draws a fault for operation
+func (p *ProbabilisticInjector) Inject(ctx context.Context, operation, key string) Fault {
+	rate, ok := p.rates[operation]
+	if !ok {
+		if rate, ok = p.rates[FaultOpAny]; !ok {
+			return Fault{}
+		}
+	}
+
+	p.mu.Lock()
+	failRoll, delayRoll := p.rng.Float64(), p.rng.Float64()
+	p.mu.Unlock()
+
+	var fault Fault
+	if delayRoll < rate.DelayRate {
+		fault.Delay = rate.Delay
+	}
+	if failRoll < rate.ErrorRate {
+		fault.Err = rate.Err
+		if fault.Err == nil {
+			fault.Err = InjectedError(operation, key)
+		}
+	}
+	return fault
+}
+
+// ScriptedInjector returns a fixed sequence of faults per operation, for
+// deterministic tests. Operations run normally once their script is used up.
+type ScriptedInjector struct {
+	mu      sync.Mutex
+	scripts map[string][]Fault
+}
+
+// NewScriptedInjector creates an injector with empty scripts
+func NewScriptedInjector() *ScriptedInjector {
+	return &ScriptedInjector{scripts: make(map[string][]Fault)}
+}
+
+// Script appends faults to the sequence for operation
+func (s *ScriptedInjector) Script(operation string, faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[operation] = append(s.scripts[operation], faults...)
+}
+
+// FailNext scripts the next n calls of operation to fail with InjectedError
+func (s *ScriptedInjector) FailNext(operation string, n int) {
+	for i := 0; i < n; i++ {
+		s.Script(operation, Fault{Err: InjectedError(operation, "")})
+	}
+}
+
+// Remaining returns how many scripted faults operation has left
+func (s *ScriptedInjector) Remaining(operation string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.scripts[operation])
+}
+
+// Inject returns the next scripted fault for operation
+func (s *ScriptedInjector) Inject(ctx context.Context, operation, key string) Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	script := s.scripts[operation]
+	if len(script) == 0 {
+		return Fault{}
+	}
+	s.scripts[operation] = script[1:]
+	return script[0]
+}
@@ -0,0 +1,141 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/circuit"
+	objerrors "github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/health"
+	"github.com/objectfs/objectfs/pkg/retry"
+)
+
+// newFaultBackend builds a backend whose operations fail through injector
+// before reaching S3
+func newFaultBackend(injector FaultInjector) *Backend {
+	cfg := NewDefaultConfig()
+	cfg.FaultInjector = injector
+	cfg.RetryConfig.MaxAttempts = 1
+	logger := slog.Default()
+
+	backend := &Backend{
+		bucket:           "bucket",
+		config:           cfg,
+		logger:           logger,
+		metricsCollector: NewMetricsCollector(),
+		currentTier:      cfg.StorageTier,
+		tierValidator:    NewTierValidator(cfg.StorageTier, cfg.TierConstraints, logger),
+		retryer:          retry.New(cfg.RetryConfig),
+		circuitManager: circuit.NewManager(circuit.Config{
+			MaxRequests: 1,
+			Timeout:     time.Minute,
+			ReadyToTrip: func(counts circuit.Counts) bool {
+				return counts.ConsecutiveFailures >= 5
+			},
+		}),
+		healthTracker: health.NewTracker(health.DefaultConfig()),
+	}
+	backend.healthTracker.RegisterComponent("s3-reads")
+	backend.healthTracker.RegisterComponent("s3-writes")
+	return backend
+}
+
+func TestInjectedWriteFailuresMakeBackendReadOnly(t *testing.T) {
+	injector := NewScriptedInjector()
+	injector.FailNext(FaultOpPut, 10)
+	backend := newFaultBackend(injector)
+	ctx := context.Background()
+
+	threshold := health.DefaultConfig().ErrorThreshold
+	for i := 0; i < threshold; i++ {
+		err := backend.PutObject(ctx, "docs/a.txt", []byte("data"))
+		if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeStorageWrite, "")) {
+			t.Fatalf("PutObject %d error = %v, want an injected write failure", i, err)
+		}
+	}
+
+	if state := backend.healthTracker.GetState("s3-writes"); state != health.StateReadOnly {
+		t.Fatalf("s3-writes state = %s, want read-only", state)
+	}
+	if backend.IsWriteAvailable() {
+		t.Error("writes still available in read-only mode")
+	}
+	if !backend.IsReadAvailable() {
+		t.Error("reads unavailable after write failures only")
+	}
+
+	// Writes are refused before reaching the injector
+	remaining := injector.Remaining(FaultOpPut)
+	err := backend.PutObject(ctx, "docs/a.txt", []byte("data"))
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeServiceUnavailable, "")) {
+		t.Errorf("PutObject in read-only mode error = %v, want service unavailable", err)
+	}
+	if injector.Remaining(FaultOpPut) != remaining {
+		t.Error("read-only backend still attempted the write")
+	}
+}
+
+func TestInjectedReadFailuresTripBreaker(t *testing.T) {
+	injector := NewScriptedInjector()
+	injector.FailNext(FaultOpGet, 20)
+	backend := newFaultBackend(injector)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if _, err := backend.GetObject(ctx, "docs/a.txt", 0, 0); err == nil {
+			t.Fatalf("GetObject %d succeeded, want an injected failure", i)
+		}
+	}
+
+	if state := backend.circuitManager.GetBreaker("s3-get").GetState(); state != circuit.StateOpen {
+		t.Fatalf("s3-get breaker state = %s, want open", state)
+	}
+	if _, err := backend.GetObject(ctx, "docs/a.txt", 0, 0); err == nil {
+		t.Error("GetObject succeeded with the breaker open")
+	}
+	if got := injector.Remaining(FaultOpGet); got != 15 {
+		t.Errorf("%d scripted faults left, want 15 with the breaker short-circuiting", got)
+	}
+}
+
+func TestInjectedDelayHonoursContext(t *testing.T) {
+	injector := NewScriptedInjector()
+	injector.Script(FaultOpHead, Fault{Delay: time.Minute})
+	backend := newFaultBackend(injector)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := backend.HeadObject(ctx, "docs/a.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("HeadObject error = %v, want the context deadline", err)
+	}
+}
+
+func TestProbabilisticInjectorRates(t *testing.T) {
+	injector := NewProbabilisticInjector(map[string]FaultRate{
+		FaultOpPut: {ErrorRate: 0.5},
+		FaultOpAny: {DelayRate: 1, Delay: time.Second},
+	}, 1)
+	ctx := context.Background()
+
+	failures := 0
+	for i := 0; i < 1000; i++ {
+		if injector.Inject(ctx, FaultOpPut, "key").Err != nil {
+			failures++
+		}
+	}
+	if failures < 400 || failures > 600 {
+		t.Errorf("%d of 1000 puts failed, want about half", failures)
+	}
+
+	fault := injector.Inject(ctx, FaultOpGet, "key")
+	if fault.Err != nil || fault.Delay != time.Second {
+		t.Errorf("GetObject fault = %+v, want the wildcard delay only", fault)
+	}
+
+	if fault := NewProbabilisticInjector(nil, 1).Inject(ctx, FaultOpGet, "key"); fault != (Fault{}) {
+		t.Errorf("injector without rates returned %+v", fault)
+	}
+}
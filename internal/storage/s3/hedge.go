@@ -0,0 +1,237 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// errHedgeLost cancels the slower of two hedged requests
+var errHedgeLost = errors.New("hedged request lost to a faster attempt")
+
+// HedgeConfig configures request hedging for idempotent reads
+type HedgeConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Percentile   float64       `yaml:"percentile"`    // Latency percentile after which a hedge fires (e.g., 0.95)
+	InitialDelay time.Duration `yaml:"initial_delay"` // Hedge delay until enough latency samples exist
+	MinDelay     time.Duration `yaml:"min_delay"`     // Lower bound for the adaptive delay
+	MaxRate      float64       `yaml:"max_rate"`      // Maximum fraction of requests that may be hedged
+	Window       int           `yaml:"window"`        // Number of recent latencies used for the percentile
+}
+
+// HedgeStats reports hedging activity for one operation
+type HedgeStats struct {
+	Requests int64         `json:"requests"`
+	Fired    int64         `json:"fired"`
+	Won      int64         `json:"won"`
+	Delay    time.Duration `json:"delay"`
+}
+
+// minHedgeSamples is the number of latencies needed before the delay adapts
+const minHedgeSamples = 20
+
+// baselineHedgeFactor scales the pinged round-trip latency into a hedge
+// delay until enough request latencies have been observed
+const baselineHedgeFactor = 2
+
+// hedger fires a second attempt when the first is slower than the recent
+// latency percentile, within a budget that bounds load amplification
+type hedger struct {
+	config  HedgeConfig
+	metrics *MetricsCollector
+
+	// Round-trip latency from backend pings; 0 while unknown
+	baseline func() time.Duration
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	filled    bool
+	budget    float64
+	stats     HedgeStats
+}
+
+func newHedger(config HedgeConfig, metrics *MetricsCollector) *hedger {
+	if config.Percentile <= 0 || config.Percentile >= 1 {
+		config.Percentile = 0.95
+	}
+	if config.InitialDelay <= 0 {
+		config.InitialDelay = 100 * time.Millisecond
+	}
+	if config.MinDelay <= 0 {
+		config.MinDelay = 5 * time.Millisecond
+	}
+	if config.MaxRate <= 0 {
+		config.MaxRate = 0.05
+	}
+	if config.Window <= 0 {
+		config.Window = 256
+	}
+
+	return &hedger{
+		config:    config,
+		metrics:   metrics,
+		latencies: make([]time.Duration, config.Window),
+	}
+}
+
+// delay returns the current hedge delay from the latency distribution
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.delayLocked()
+}
+
+func (h *hedger) delayLocked() time.Duration {
+	count := h.next
+	if h.filled {
+		count = len(h.latencies)
+	}
+	if count < minHedgeSamples {
+		if h.baseline != nil {
+			if rtt := h.baseline(); rtt > 0 {
+				return max(baselineHedgeFactor*rtt, h.config.MinDelay)
+			}
+		}
+		return h.config.InitialDelay
+	}
+
+	samples := append([]time.Duration(nil), h.latencies[:count]...)
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	d := samples[int(float64(count-1)*h.config.Percentile)]
+	return max(d, h.config.MinDelay)
+}
+
+// observe records the latency of a completed attempt
+func (h *hedger) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.latencies[h.next] = latency
+	h.next++
+	if h.next == len(h.latencies) {
+		h.next = 0
+		h.filled = true
+	}
+}
+
+// admit counts a request and earns hedge budget for it
+func (h *hedger) admit() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stats.Requests++
+	h.budget = min(h.budget+h.config.MaxRate, 1)
+}
+
+// tryFire spends budget for a hedge, reporting false when the rate cap
+// has been reached
+func (h *hedger) tryFire() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.budget < 1-1e-9 {
+		return false
+	}
+	h.budget = max(h.budget-1, 0)
+	h.stats.Fired++
+	if h.metrics != nil {
+		h.metrics.RecordHedgeFired()
+	}
+	return true
+}
+
+func (h *hedger) recordWin() {
+	h.mu.Lock()
+	h.stats.Won++
+	h.mu.Unlock()
+
+	if h.metrics != nil {
+		h.metrics.RecordHedgeWin()
+	}
+}
+
+// Stats returns hedging activity and the current delay
+func (h *hedger) Stats() HedgeStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := h.stats
+	stats.Delay = h.delayLocked()
+	return stats
+}
+
+// lostHedge reports whether ctx was cancelled because another hedged
+// attempt finished first, so the failure is not a backend error
+func lostHedge(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errHedgeLost)
+}
+
+// hedgeResult is the outcome of one attempt
+type hedgeResult[T any] struct {
+	value  T
+	err    error
+	hedged bool
+}
+
+// hedged runs fn, firing a second identical attempt if the first has not
+// returned within the hedge delay. The first success wins and the other
+// attempt is cancelled with errHedgeLost. A nil hedger runs fn once.
+func hedged[T any](ctx context.Context, h *hedger, fn func(ctx context.Context) (T, error)) (T, error) {
+	if h == nil {
+		return fn(ctx)
+	}
+	h.admit()
+
+	results := make(chan hedgeResult[T], 2)
+	cancels := make([]context.CancelCauseFunc, 0, 2)
+	launch := func(hedge bool) {
+		attemptCtx, cancel := context.WithCancelCause(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			start := time.Now()
+			value, err := fn(attemptCtx)
+			if err == nil {
+				h.observe(time.Since(start))
+			}
+			results <- hedgeResult[T]{value: value, err: err, hedged: hedge}
+		}()
+	}
+	defer func() {
+		for _, cancel := range cancels {
+			cancel(errHedgeLost)
+		}
+	}()
+
+	launch(false)
+	timer := time.NewTimer(h.delay())
+	defer timer.Stop()
+
+	pending := 1
+	var lastErr error
+	for {
+		select {
+		case <-timer.C:
+			if pending > 0 && len(cancels) == 1 && h.tryFire() {
+				launch(true)
+				pending++
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				if result.hedged {
+					h.recordWin()
+				}
+				return result.value, nil
+			}
+			lastErr = result.err
+			if pending == 0 {
+				var zero T
+				return zero, lastErr
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedged_FastHedgeBeatsSlowFirstRequest(t *testing.T) {
+	metrics := NewMetricsCollector()
+	h := newHedger(HedgeConfig{InitialDelay: 10 * time.Millisecond, MaxRate: 1}, metrics)
+
+	var attempts atomic.Int32
+	slowCause := make(chan error, 1)
+	start := time.Now()
+	data, err := hedged(context.Background(), h, func(ctx context.Context) ([]byte, error) {
+		if attempts.Add(1) == 1 {
+			// The first request stalls until it is cancelled
+			select {
+			case <-ctx.Done():
+				slowCause <- context.Cause(ctx)
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return []byte("slow"), nil
+			}
+		}
+		return []byte("fast"), nil
+	})
+
+	if err != nil || string(data) != "fast" {
+		t.Fatalf("hedged() = %q, %v; want fast", data, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("hedged() took %v, want the hedge to return early", elapsed)
+	}
+
+	select {
+	case cause := <-slowCause:
+		if !errors.Is(cause, errHedgeLost) {
+			t.Errorf("slow request cancelled with %v, want errHedgeLost", cause)
+		}
+	case <-time.After(time.Second):
+		t.Error("slow request was not cancelled")
+	}
+
+	if stats := h.Stats(); stats.Requests != 1 || stats.Fired != 1 || stats.Won != 1 {
+		t.Errorf("Stats() = %+v, want one fired and won hedge", stats)
+	}
+	if m := metrics.GetMetrics(); m.HedgesFired != 1 || m.HedgeWins != 1 {
+		t.Errorf("metrics = fired %d wins %d, want 1 and 1", m.HedgesFired, m.HedgeWins)
+	}
+}
+
+func TestHedged_RateCapped(t *testing.T) {
+	h := newHedger(HedgeConfig{InitialDelay: time.Millisecond, MinDelay: time.Millisecond, MaxRate: 0.2}, nil)
+
+	// Few enough requests that the delay stays at its initial value
+	const requests = 10
+	for i := 0; i < requests; i++ {
+		_, err := hedged(context.Background(), h, func(ctx context.Context) (int, error) {
+			time.Sleep(10 * time.Millisecond)
+			return i, nil
+		})
+		if err != nil {
+			t.Fatalf("hedged() error = %v", err)
+		}
+	}
+
+	// Every request was slow enough to hedge, but only 20% may be
+	if stats := h.Stats(); stats.Fired != 2 {
+		t.Errorf("fired %d hedges for %d slow requests, want 2", stats.Fired, requests)
+	}
+}
+
+func TestHedged_FirstErrorBeforeDelay(t *testing.T) {
+	h := newHedger(HedgeConfig{InitialDelay: time.Second, MaxRate: 1}, nil)
+
+	wantErr := errors.New("not found")
+	calls := 0
+	_, err := hedged(context.Background(), h, func(ctx context.Context) (string, error) {
+		calls++
+		return "", wantErr
+	})
+
+	if !errors.Is(err, wantErr) {
+		t.Errorf("hedged() error = %v, want %v", err, wantErr)
+	}
+	if calls != 1 || h.Stats().Fired != 0 {
+		t.Errorf("calls = %d, fired = %d; failures before the delay should not hedge", calls, h.Stats().Fired)
+	}
+}
+
+func TestHedger_AdaptiveDelay(t *testing.T) {
+	h := newHedger(HedgeConfig{Percentile: 0.95, InitialDelay: 50 * time.Millisecond, MinDelay: time.Millisecond}, nil)
+
+	if got := h.delay(); got != 50*time.Millisecond {
+		t.Errorf("delay() without samples = %v, want initial delay", got)
+	}
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	if got := h.delay(); got != 95*time.Millisecond {
+		t.Errorf("delay() = %v, want p95 of 95ms", got)
+	}
+
+	// The delay tracks the recent distribution
+	for i := 0; i < 256; i++ {
+		h.observe(2 * time.Millisecond)
+	}
+	if got := h.delay(); got != 2*time.Millisecond {
+		t.Errorf("delay() after faster requests = %v, want 2ms", got)
+	}
+}
+
+func TestHedged_Disabled(t *testing.T) {
+	calls := 0
+	got, err := hedged(context.Background(), nil, func(ctx context.Context) (string, error) {
+		calls++
+		return "ok", nil
+	})
+	if err != nil || got != "ok" || calls != 1 {
+		t.Errorf("hedged(nil) = %q, %v after %d calls", got, err, calls)
+	}
+}
@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// multipartHousekeepingAPIClient is the subset of the S3 client used to find
+// and abort incomplete multipart uploads
+type multipartHousekeepingAPIClient interface {
+	s3.ListMultipartUploadsAPIClient
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// ListIncompleteUploads returns the multipart uploads under prefix that
+// were started but never completed or aborted
+func (b *Backend) ListIncompleteUploads(ctx context.Context, prefix string) ([]types.IncompleteUpload, error) {
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	uploads, err := listIncompleteUploads(ctx, client, b.bucket, prefix)
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+		return nil, b.translateError(err, "ListIncompleteUploads", prefix)
+	}
+	return uploads, nil
+}
+
+// AbortIncompleteUploads aborts the incomplete multipart uploads initiated
+// more than olderThan ago, freeing their stored parts, and returns how many
+// were aborted. Uploads this backend is still sending are left alone.
+func (b *Backend) AbortIncompleteUploads(ctx context.Context, olderThan time.Duration) (int, error) {
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	active := make(map[string]bool)
+	for _, state := range b.multipartManager.GetInProgressUploads() {
+		active[state.UploadID] = true
+	}
+
+	aborted, err := abortIncompleteUploads(ctx, client, b.bucket, olderThan, time.Now(), func(upload types.IncompleteUpload) bool {
+		return !active[upload.UploadID]
+	})
+	for _, uploadID := range aborted {
+		// A failed upload preserved for resume can no longer be resumed
+		b.multipartManager.RemoveUpload(uploadID)
+	}
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+	}
+	return len(aborted), err
+}
+
+// listIncompleteUploads lists every incomplete multipart upload under prefix
+func listIncompleteUploads(ctx context.Context, client s3.ListMultipartUploadsAPIClient, bucket, prefix string) ([]types.IncompleteUpload, error) {
+	input := &s3.ListMultipartUploadsInput{Bucket: aws.String(bucket)}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	var uploads []types.IncompleteUpload
+	paginator := s3.NewListMultipartUploadsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, upload := range page.Uploads {
+			uploads = append(uploads, types.IncompleteUpload{
+				Key:          aws.ToString(upload.Key),
+				UploadID:     aws.ToString(upload.UploadId),
+				Initiated:    aws.ToTime(upload.Initiated),
+				StorageClass: string(upload.StorageClass),
+			})
+		}
+	}
+	return uploads, nil
+}
+
+// abortIncompleteUploads aborts the incomplete uploads initiated before
+// now-olderThan that eligible accepts, returning the IDs of those aborted.
+// Failed aborts do not stop the rest; their errors are combined.
+func abortIncompleteUploads(ctx context.Context, client multipartHousekeepingAPIClient, bucket string, olderThan time.Duration, now time.Time, eligible func(types.IncompleteUpload) bool) ([]string, error) {
+	uploads, err := listIncompleteUploads(ctx, client, bucket, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incomplete uploads: %w", err)
+	}
+
+	cutoff := now.Add(-olderThan)
+	var (
+		aborted []string
+		errs    []error
+	)
+	for _, upload := range uploads {
+		if !upload.Initiated.Before(cutoff) || !eligible(upload) {
+			continue
+		}
+
+		_, err := client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(upload.Key),
+			UploadId: aws.String(upload.UploadID),
+		})
+		if err != nil {
+			if isUploadGone(err) {
+				continue // Completed or aborted since it was listed
+			}
+			errs = append(errs, fmt.Errorf("failed to abort upload %s of %s: %w", upload.UploadID, upload.Key, err))
+			continue
+		}
+		log.Printf("Aborted incomplete multipart upload %s of %s initiated %s",
+			upload.UploadID, upload.Key, upload.Initiated.Format(time.RFC3339))
+		aborted = append(aborted, upload.UploadID)
+	}
+	return aborted, stderr.Join(errs...)
+}
@@ -0,0 +1,128 @@
+package s3
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// fakeUploadsClient reports incomplete uploads one per page and records
+// aborts
+type fakeUploadsClient struct {
+	uploads []s3types.MultipartUpload
+	aborted []string
+}
+
+func (f *fakeUploadsClient) ListMultipartUploads(ctx context.Context, input *s3.ListMultipartUploadsInput, _ ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error) {
+	start := 0
+	if marker := aws.ToString(input.UploadIdMarker); marker != "" {
+		for i, upload := range f.uploads {
+			if aws.ToString(upload.UploadId) == marker {
+				start = i + 1
+			}
+		}
+	}
+
+	output := &s3.ListMultipartUploadsOutput{IsTruncated: aws.Bool(false)}
+	for i := start; i < len(f.uploads); i++ {
+		upload := f.uploads[i]
+		if input.Prefix != nil && !strings.HasPrefix(aws.ToString(upload.Key), aws.ToString(input.Prefix)) {
+			continue
+		}
+		output.Uploads = []s3types.MultipartUpload{upload}
+		if i < len(f.uploads)-1 {
+			output.IsTruncated = aws.Bool(true)
+			output.NextKeyMarker = upload.Key
+			output.NextUploadIdMarker = upload.UploadId
+		}
+		break
+	}
+	return output, nil
+}
+
+func (f *fakeUploadsClient) AbortMultipartUpload(ctx context.Context, input *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	uploadID := aws.ToString(input.UploadId)
+	for _, id := range f.aborted {
+		if id == uploadID {
+			return nil, &s3types.NoSuchUpload{}
+		}
+	}
+	f.aborted = append(f.aborted, uploadID)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func newFakeUploadsClient(now time.Time) *fakeUploadsClient {
+	upload := func(key, id string, age time.Duration) s3types.MultipartUpload {
+		return s3types.MultipartUpload{
+			Key:          aws.String(key),
+			UploadId:     aws.String(id),
+			Initiated:    aws.Time(now.Add(-age)),
+			StorageClass: s3types.StorageClassStandard,
+		}
+	}
+	return &fakeUploadsClient{uploads: []s3types.MultipartUpload{
+		upload("logs/a.bin", "stale-1", 10*24*time.Hour),
+		upload("logs/b.bin", "fresh-1", time.Hour),
+		upload("data/c.bin", "stale-2", 8*24*time.Hour),
+		upload("data/d.bin", "active", 30*24*time.Hour),
+	}}
+}
+
+func TestListIncompleteUploads(t *testing.T) {
+	now := time.Now()
+	client := newFakeUploadsClient(now)
+
+	all, err := listIncompleteUploads(context.Background(), client, "bucket", "")
+	if err != nil {
+		t.Fatalf("listIncompleteUploads failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("listed %d uploads across pages, want 4", len(all))
+	}
+	if all[0].Key != "logs/a.bin" || all[0].UploadID != "stale-1" || !all[0].Initiated.Equal(now.Add(-10*24*time.Hour)) {
+		t.Errorf("first upload = %+v", all[0])
+	}
+
+	logs, err := listIncompleteUploads(context.Background(), client, "bucket", "logs/")
+	if err != nil {
+		t.Fatalf("listIncompleteUploads(logs/) failed: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("listed %d uploads under logs/, want 2", len(logs))
+	}
+}
+
+func TestAbortIncompleteUploadsOnlyAbortsStale(t *testing.T) {
+	now := time.Now()
+	client := newFakeUploadsClient(now)
+
+	aborted, err := abortIncompleteUploads(context.Background(), client, "bucket", 7*24*time.Hour, now,
+		func(upload types.IncompleteUpload) bool { return upload.UploadID != "active" })
+	if err != nil {
+		t.Fatalf("abortIncompleteUploads failed: %v", err)
+	}
+
+	sort.Strings(aborted)
+	if len(aborted) != 2 || aborted[0] != "stale-1" || aborted[1] != "stale-2" {
+		t.Errorf("aborted %v, want stale-1 and stale-2", aborted)
+	}
+	if len(client.aborted) != 2 {
+		t.Errorf("AbortMultipartUpload called for %v, want only the stale uploads", client.aborted)
+	}
+
+	// Uploads already gone are not counted again
+	client.uploads = client.uploads[:1]
+	aborted, err = abortIncompleteUploads(context.Background(), client, "bucket", 7*24*time.Hour, now,
+		func(types.IncompleteUpload) bool { return true })
+	if err != nil || len(aborted) != 0 {
+		t.Errorf("second run aborted %v (err %v), want nothing", aborted, err)
+	}
+}
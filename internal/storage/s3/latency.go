@@ -0,0 +1,117 @@
+package s3
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// latencySmoothing is the weight of the newest sample in the rolling
+// latency estimate
+const latencySmoothing = 0.2
+
+// LatencyStats reports the round-trip latency measured by Ping
+type LatencyStats struct {
+	Last      time.Duration `json:"last"`      // Latency of the most recent successful ping
+	Estimate  time.Duration `json:"estimate"`  // Rolling average of successful pings
+	Samples   int64         `json:"samples"`   // Successful pings
+	Failures  int64         `json:"failures"`  // Pings that could not reach the backend
+	Reachable bool          `json:"reachable"` // Whether the most recent ping succeeded
+	LastPing  time.Time     `json:"last_ping"`
+}
+
+// bucketPingAPIClient is the subset of the S3 client used to measure
+// round-trip latency
+type bucketPingAPIClient interface {
+	HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
+}
+
+// latencyEstimator keeps a rolling estimate of backend round-trip latency.
+// Failed pings are counted separately so an unreachable backend is never
+// mistaken for a slow one.
+type latencyEstimator struct {
+	mu    sync.RWMutex
+	stats LatencyStats
+}
+
+// probe measures one HEAD of bucket and folds a successful measurement into
+// the estimate
+func (e *latencyEstimator) probe(ctx context.Context, client bucketPingAPIClient, bucket string) (time.Duration, error) {
+	start := time.Now()
+	_, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	latency := time.Since(start)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.stats.LastPing = time.Now()
+	if err != nil {
+		e.stats.Failures++
+		e.stats.Reachable = false
+		return 0, err
+	}
+
+	e.stats.Last = latency
+	e.stats.Reachable = true
+	if e.stats.Samples == 0 {
+		e.stats.Estimate = latency
+	} else {
+		e.stats.Estimate = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(e.stats.Estimate))
+	}
+	e.stats.Samples++
+	return latency, nil
+}
+
+// estimate returns the rolling latency, or 0 before the first successful ping
+func (e *latencyEstimator) estimate() time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.stats.Estimate
+}
+
+// Stats returns the latest measurements
+func (e *latencyEstimator) Stats() LatencyStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.stats
+}
+
+// Ping measures the round-trip latency of a HEAD on the bucket and updates
+// the rolling estimate. Unlike HealthCheck it quantifies latency; a backend
+// that cannot be reached returns a CONNECTION_FAILED error rather than a
+// long duration. Replica regions reads fail over to are pinged as well.
+func (b *Backend) Ping(ctx context.Context) (time.Duration, error) {
+	b.readFailover.probe(ctx)
+
+	client := b.clientManager.GetPooledClient()
+	latency, err := b.latency.probe(ctx, client, b.bucket)
+	b.clientManager.ReleasePooledClient(client, err)
+	if b.readFailover != nil && ctx.Err() == nil {
+		b.readFailover.primary.observe(err, false)
+	}
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+		return 0, errors.NewError(errors.ErrCodeConnectionFailed, "backend unreachable").
+			WithComponent("s3-backend").
+			WithOperation("Ping").
+			WithContext("bucket", b.bucket).
+			WithCause(err)
+	}
+	return latency, nil
+}
+
+// LatencyEstimate returns the rolling round-trip latency measured by Ping,
+// or 0 before the first successful ping
+func (b *Backend) LatencyEstimate() time.Duration {
+	return b.latency.estimate()
+}
+
+// LatencyStats returns the measurements taken by Ping
+func (b *Backend) LatencyStats() LatencyStats {
+	return b.latency.Stats()
+}
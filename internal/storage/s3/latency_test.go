@@ -0,0 +1,108 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakePingClient answers HeadBucket after delay, or fails while err is set
+type fakePingClient struct {
+	delay time.Duration
+	err   error
+}
+
+func (f *fakePingClient) HeadBucket(ctx context.Context, input *s3.HeadBucketInput, _ ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	time.Sleep(f.delay)
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func TestLatencyProbeMeasuresRoundTrip(t *testing.T) {
+	var estimator latencyEstimator
+	client := &fakePingClient{delay: 5 * time.Millisecond}
+
+	latency, err := estimator.probe(context.Background(), client, "bucket")
+	if err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if latency < 5*time.Millisecond || latency > time.Second {
+		t.Errorf("latency = %v, want about 5ms", latency)
+	}
+	if estimate := estimator.estimate(); estimate != latency {
+		t.Errorf("estimate after one ping = %v, want %v", estimate, latency)
+	}
+}
+
+func TestLatencyProbeUpdatesRollingEstimate(t *testing.T) {
+	var estimator latencyEstimator
+	client := &fakePingClient{delay: time.Millisecond}
+
+	if _, err := estimator.probe(context.Background(), client, "bucket"); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	first := estimator.estimate()
+
+	// Slower pings pull the estimate up gradually
+	client.delay = 20 * time.Millisecond
+	previous := first
+	for i := 0; i < 3; i++ {
+		latency, err := estimator.probe(context.Background(), client, "bucket")
+		if err != nil {
+			t.Fatalf("probe failed: %v", err)
+		}
+		estimate := estimator.estimate()
+		if estimate <= previous || estimate >= latency {
+			t.Errorf("estimate after ping %d = %v, want between %v and %v", i+2, estimate, previous, latency)
+		}
+		previous = estimate
+	}
+
+	if stats := estimator.Stats(); stats.Samples != 4 || !stats.Reachable {
+		t.Errorf("stats = %+v, want 4 reachable samples", stats)
+	}
+}
+
+func TestLatencyProbeSeparatesFailures(t *testing.T) {
+	var estimator latencyEstimator
+	client := &fakePingClient{delay: time.Millisecond}
+	if _, err := estimator.probe(context.Background(), client, "bucket"); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	before := estimator.estimate()
+
+	client.err = stderr.New("dial tcp: connection refused")
+	if latency, err := estimator.probe(context.Background(), client, "bucket"); err == nil || latency != 0 {
+		t.Fatalf("probe of unreachable backend = %v, %v; want an error", latency, err)
+	}
+
+	stats := estimator.Stats()
+	if stats.Reachable || stats.Failures != 1 {
+		t.Errorf("stats = %+v, want one failure and unreachable", stats)
+	}
+	if stats.Estimate != before || stats.Samples != 1 {
+		t.Errorf("failed ping changed the estimate to %v over %d samples", stats.Estimate, stats.Samples)
+	}
+}
+
+func TestHedgerUsesPingedLatencyUntilWarm(t *testing.T) {
+	var estimator latencyEstimator
+	h := newHedger(HedgeConfig{InitialDelay: time.Second, MinDelay: time.Millisecond}, nil)
+	h.baseline = estimator.estimate
+
+	if d := h.delay(); d != time.Second {
+		t.Errorf("delay before any ping = %v, want the initial delay", d)
+	}
+
+	if _, err := estimator.probe(context.Background(), &fakePingClient{delay: 10 * time.Millisecond}, "bucket"); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if d, want := h.delay(), baselineHedgeFactor*estimator.estimate(); d != want {
+		t.Errorf("delay after ping = %v, want %v", d, want)
+	}
+}
@@ -0,0 +1,284 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// ListObjectsChan streams objects under prefix across all result pages.
+// The object channel is closed when listing completes, fails, or ctx is
+// cancelled; at most one error is delivered on the error channel.
+// Consumers that stop reading early must cancel ctx so the producer exits.
+//
+// A bucket modified during the listing can repeat a key on the next page,
+// which is dropped, and objects created after the listing started may be
+// missed. With Config.StableListings the whole prefix is listed before the
+// first object is sent, as by ListSnapshot. In presigned mode the one
+// page a presigned LIST URL returns is streamed.
+func (b *Backend) ListObjectsChan(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	if b.presigned != nil {
+		return b.streamPresignedListing(ctx, prefix)
+	}
+	start := time.Now()
+	ctx, trace := b.recentOps.begin(ctx)
+	client := b.clientManager.GetPooledClient()
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:       aws.String(b.bucket),
+		RequestPayer: b.config.requestPayer(),
+		Prefix:       aws.String(prefix),
+	}
+
+	var objects <-chan types.ObjectInfo
+	var errs <-chan error
+	if b.config.StableListings {
+		objects, errs = streamSnapshot(ctx, client, input, &b.listDuplicates, b.beforeListPage("ListObjectsChan", prefix), func(err error) {
+			b.clientManager.ReleasePooledClient(client, err)
+		})
+	} else {
+		objects, errs = streamListObjects(ctx, client, input, &b.listDuplicates, b.beforeListPage("ListObjectsChan", prefix), func(err error) {
+			b.clientManager.ReleasePooledClient(client, err)
+		})
+	}
+
+	// Translate backend errors so callers see the same errors as
+	// ListObjects, and record the listing once it ends
+	translated := make(chan error, 1)
+	go func() {
+		defer close(translated)
+		err := <-errs
+		var objErr *errors.ObjectFSError
+		if err != nil && ctx.Err() == nil && !stderr.As(err, &objErr) {
+			b.metricsCollector.RecordError(err)
+			err = b.translateError(err, "ListObjectsChan", prefix)
+		}
+		b.recentOps.record(trace, "ListObjectsChan", prefix, start, err, nil)
+		if err != nil {
+			translated <- err
+		}
+	}()
+
+	return objects, translated
+}
+
+// streamPresignedListing sends the objects ListObjects returns for prefix
+// on a background goroutine
+func (b *Backend) streamPresignedListing(ctx context.Context, prefix string) (<-chan types.ObjectInfo, <-chan error) {
+	objects := make(chan types.ObjectInfo)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			close(objects)
+			close(errs)
+		}()
+
+		listed, err := b.ListObjects(ctx, prefix, 0)
+		if err != nil {
+			errs <- err
+			return
+		}
+		for _, obj := range listed {
+			select {
+			case objects <- obj:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return objects, errs
+}
+
+// beforeListPage returns the check made before each page of a listing of
+// prefix, which reserves the page's cost against the cost budget, counts
+// the page as an attempt of the traced listing, and applies any injected
+// LIST fault
+func (b *Backend) beforeListPage(operation, prefix string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := b.costBudget.reserve(ctx, operation, prefix, b.costBudget.estimate("LIST", b.currentTier, 0)); err != nil {
+			return err
+		}
+		countAttempt(ctx)
+		return b.injectFault(ctx, FaultOpList, prefix)
+	}
+}
+
+// ListSnapshot lists every object under prefix into an index sorted by key
+// before returning it, for callers that need one consistent listing rather
+// than objects as their pages arrive. Keys repeated across pages appear
+// once; objects created after the listing started may be missing.
+func (b *Backend) ListSnapshot(ctx context.Context, prefix string) (objects []types.ObjectInfo, err error) {
+	if b.presigned != nil {
+		objects, err = b.ListObjects(ctx, prefix, 0)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+		return objects, nil
+	}
+
+	start := time.Now()
+	ctx, trace := b.recentOps.begin(ctx)
+	defer func() {
+		b.recentOps.record(trace, "ListSnapshot", prefix, start, err, nil)
+	}()
+
+	client := b.clientManager.GetPooledClient()
+	objects, err = listSnapshot(ctx, client, &s3.ListObjectsV2Input{
+		Bucket:       aws.String(b.bucket),
+		RequestPayer: b.config.requestPayer(),
+		Prefix:       aws.String(prefix),
+	}, &b.listDuplicates, b.beforeListPage("ListSnapshot", prefix), func(err error) {
+		b.clientManager.ReleasePooledClient(client, err)
+	})
+	if err != nil {
+		var objErr *errors.ObjectFSError
+		if ctx.Err() == nil && !stderr.As(err, &objErr) {
+			b.metricsCollector.RecordError(err)
+			err = b.translateError(err, "ListSnapshot", prefix)
+		}
+		return nil, err
+	}
+	return objects, nil
+}
+
+// pageKeys remembers the keys of the current and previous listing page.
+// Pagination of a bucket under modification repeats keys near the page
+// boundary, so two pages find repeats without holding every key listed.
+type pageKeys struct {
+	previous map[string]struct{}
+	current  map[string]struct{}
+}
+
+// nextPage starts remembering the keys of a new page
+func (p *pageKeys) nextPage() {
+	p.previous, p.current = p.current, make(map[string]struct{})
+}
+
+// repeated records key and reports whether it was already listed
+func (p *pageKeys) repeated(key string) bool {
+	if _, ok := p.previous[key]; ok {
+		return true
+	}
+	if _, ok := p.current[key]; ok {
+		return true
+	}
+	p.current[key] = struct{}{}
+	return false
+}
+
+// listSnapshot lists every object under input's prefix, sorted by key.
+// done is invoked as by streamListObjects.
+func listSnapshot(ctx context.Context, client s3.ListObjectsV2APIClient, input *s3.ListObjectsV2Input, duplicates *atomic.Int64, beforePage func(context.Context) error, done func(error)) ([]types.ObjectInfo, error) {
+	objects, errs := streamListObjects(ctx, client, input, duplicates, beforePage, done)
+	var snapshot []types.ObjectInfo
+	for obj := range objects {
+		snapshot = append(snapshot, obj)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Key < snapshot[j].Key })
+	return snapshot, nil
+}
+
+// streamSnapshot lists every object under input's prefix, then sends them
+// in key order on a background goroutine. done is invoked as by
+// streamListObjects once every page has been requested, before the
+// objects are sent.
+func streamSnapshot(ctx context.Context, client s3.ListObjectsV2APIClient, input *s3.ListObjectsV2Input, duplicates *atomic.Int64, beforePage func(context.Context) error, done func(error)) (<-chan types.ObjectInfo, <-chan error) {
+	objects := make(chan types.ObjectInfo)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			close(objects)
+			close(errs)
+		}()
+
+		snapshot, err := listSnapshot(ctx, client, input, duplicates, beforePage, done)
+		if err != nil {
+			errs <- err
+			return
+		}
+		for _, obj := range snapshot {
+			select {
+			case objects <- obj:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return objects, errs
+}
+
+// streamListObjects pages through ListObjectsV2 results on a background
+// goroutine, sending each object as soon as its page arrives. Keys repeated
+// across pages are dropped and counted in duplicates, when it is not nil.
+// beforePage, when not nil, runs before each page is requested and stops
+// the listing with its error. done is invoked once the producer goroutine
+// has exited, with the error of the last page request: nil when every
+// request made succeeded, even if beforePage or ctx ended the listing.
+func streamListObjects(ctx context.Context, client s3.ListObjectsV2APIClient, input *s3.ListObjectsV2Input, duplicates *atomic.Int64, beforePage func(context.Context) error, done func(error)) (<-chan types.ObjectInfo, <-chan error) {
+	objects := make(chan types.ObjectInfo)
+	errs := make(chan error, 1)
+
+	go func() {
+		var requestErr error
+		defer func() {
+			close(objects)
+			close(errs)
+			if done != nil {
+				done(requestErr)
+			}
+		}()
+
+		var seen pageKeys
+		paginator := s3.NewListObjectsV2Paginator(client, input)
+		for paginator.HasMorePages() {
+			if beforePage != nil {
+				if err := beforePage(ctx); err != nil {
+					errs <- err
+					return
+				}
+			}
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				requestErr = err
+				errs <- err
+				return
+			}
+
+			seen.nextPage()
+			for _, obj := range page.Contents {
+				if seen.repeated(aws.ToString(obj.Key)) {
+					if duplicates != nil {
+						duplicates.Add(1)
+					}
+					continue
+				}
+				select {
+				case objects <- objectInfoFromListing(obj):
+				case <-ctx.Done():
+					errs <- ctx.Err()
+					return
+				}
+			}
+		}
+	}()
+
+	return objects, errs
+}
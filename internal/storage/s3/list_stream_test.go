@@ -0,0 +1,369 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	objerrors "github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// fakeListClient serves fixed pages of ListObjectsV2 results
+type fakeListClient struct {
+	pages        [][]string
+	err          error
+	calls        int
+	storageClass s3types.ObjectStorageClass
+}
+
+func (f *fakeListClient) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	page := 0
+	if input.ContinuationToken != nil {
+		_, _ = fmt.Sscanf(aws.ToString(input.ContinuationToken), "%d", &page)
+	}
+	f.calls++
+
+	if f.err != nil && page == len(f.pages) {
+		return nil, f.err
+	}
+
+	out := &s3.ListObjectsV2Output{}
+	for _, key := range f.pages[page] {
+		out.Contents = append(out.Contents, s3types.Object{Key: aws.String(key), Size: aws.Int64(1), StorageClass: f.storageClass})
+	}
+	if page+1 < len(f.pages) || f.err != nil {
+		out.IsTruncated = aws.Bool(true)
+		out.NextContinuationToken = aws.String(fmt.Sprintf("%d", page+1))
+	}
+	return out, nil
+}
+
+func TestStreamListObjects_AllPages(t *testing.T) {
+	client := &fakeListClient{
+		pages:        [][]string{{"a", "b"}, {"c"}, {"d", "e"}},
+		storageClass: s3types.ObjectStorageClassIntelligentTiering,
+	}
+
+	objects, errs := streamListObjects(context.Background(), client, &s3.ListObjectsV2Input{Bucket: aws.String("bucket")}, nil, nil, nil)
+
+	var keys []string
+	for obj := range objects {
+		keys = append(keys, obj.Key)
+		if obj.StorageClass != "INTELLIGENT_TIERING" {
+			t.Errorf("Object %s storage class = %q, want INTELLIGENT_TIERING", obj.Key, obj.StorageClass)
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(keys) != 5 {
+		t.Errorf("Expected 5 objects across pages, got %d: %v", len(keys), keys)
+	}
+	if client.calls != 3 {
+		t.Errorf("Expected 3 page requests, got %d", client.calls)
+	}
+}
+
+func TestStreamListObjects_Error(t *testing.T) {
+	listErr := errors.New("list failed")
+	client := &fakeListClient{pages: [][]string{{"a"}}, err: listErr}
+
+	objects, errs := streamListObjects(context.Background(), client, &s3.ListObjectsV2Input{Bucket: aws.String("bucket")}, nil, nil, nil)
+
+	count := 0
+	for range objects {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 object before error, got %d", count)
+	}
+	if err := <-errs; !errors.Is(err, listErr) {
+		t.Errorf("Expected list error, got %v", err)
+	}
+}
+
+func TestStreamListObjects_CancelAfterFirstPage(t *testing.T) {
+	client := &fakeListClient{pages: [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	exited := make(chan struct{})
+	objects, errs := streamListObjects(ctx, client, &s3.ListObjectsV2Input{Bucket: aws.String("bucket")}, nil, nil, func(error) {
+		close(exited)
+	})
+
+	// Consume the first page only, then abandon the stream
+	for i := 0; i < 2; i++ {
+		if _, ok := <-objects; !ok {
+			t.Fatalf("Stream closed early after %d objects", i)
+		}
+	}
+	cancel()
+
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Producer goroutine did not exit after cancellation")
+	}
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if client.calls >= len(client.pages) {
+		t.Errorf("Expected listing to stop before the last page, got %d calls", client.calls)
+	}
+}
+
+func TestStreamListObjects_DropsKeysRepeatedAcrossPages(t *testing.T) {
+	// "b" and "d" shift onto the next page as keys are added before them
+	client := &fakeListClient{pages: [][]string{{"a", "b"}, {"b", "c", "d"}, {"d", "e"}}}
+	var duplicates atomic.Int64
+
+	objects, errs := streamListObjects(context.Background(), client, &s3.ListObjectsV2Input{Bucket: aws.String("bucket")}, &duplicates, nil, nil)
+
+	var keys []string
+	for obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(keys, ","); got != "a,b,c,d,e" {
+		t.Errorf("Listed keys %s, want each of a,b,c,d,e once", got)
+	}
+	if duplicates.Load() != 2 {
+		t.Errorf("Dropped %d duplicates, want 2", duplicates.Load())
+	}
+}
+
+func TestListSnapshot_SortedAndDeduplicated(t *testing.T) {
+	client := &fakeListClient{pages: [][]string{{"a", "c"}, {"c", "b"}, {"d"}}}
+	var duplicates atomic.Int64
+
+	snapshot, err := listSnapshot(context.Background(), client, &s3.ListObjectsV2Input{Bucket: aws.String("bucket")}, &duplicates, nil, nil)
+	if err != nil {
+		t.Fatalf("listSnapshot failed: %v", err)
+	}
+	var keys []string
+	for _, obj := range snapshot {
+		keys = append(keys, obj.Key)
+	}
+	if got := strings.Join(keys, ","); got != "a,b,c,d" || duplicates.Load() != 1 {
+		t.Errorf("Snapshot %s with %d duplicates, want a,b,c,d with 1", got, duplicates.Load())
+	}
+
+	// Every page is listed before the first object is sent
+	client = &fakeListClient{pages: [][]string{{"a"}, {"b"}, {"c"}}}
+	objects, errs := streamSnapshot(context.Background(), client, &s3.ListObjectsV2Input{Bucket: aws.String("bucket")}, nil, nil, nil)
+	if obj := <-objects; obj.Key != "a" || client.calls != 3 {
+		t.Errorf("First object %s after %d page requests, want a after 3", obj.Key, client.calls)
+	}
+	for range objects {
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+// pagedListServer is an S3 endpoint listing its bucket in fixed pages. A
+// nil page fails with 503 Service Unavailable.
+type pagedListServer struct {
+	*httptest.Server
+	bucket string
+	pages  [][]string
+
+	mu    sync.Mutex
+	lists int
+}
+
+func newPagedListServer(t *testing.T, bucket string, pages ...[]string) *pagedListServer {
+	setTestCredentials(t)
+	s := &pagedListServer{bucket: bucket, pages: pages}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *pagedListServer) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(bucketRegionHeader, "us-east-1")
+	query := r.URL.Query()
+	if query.Get("list-type") == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	s.mu.Lock()
+	s.lists++
+	s.mu.Unlock()
+
+	page, _ := strconv.Atoi(query.Get("continuation-token"))
+	if s.pages[page] == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var contents strings.Builder
+	for _, key := range s.pages[page] {
+		fmt.Fprintf(&contents, "<Contents><Key>%s</Key><Size>1</Size></Contents>", key)
+	}
+	next := ""
+	if page+1 < len(s.pages) {
+		next = fmt.Sprintf("<NextContinuationToken>%d</NextContinuationToken>", page+1)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprintf(w, "<ListBucketResult><Name>%s</Name><KeyCount>%d</KeyCount><IsTruncated>%t</IsTruncated>%s%s</ListBucketResult>",
+		s.bucket, len(s.pages[page]), next != "", next, contents.String())
+}
+
+func (s *pagedListServer) listRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lists
+}
+
+func newPagedListBackend(t *testing.T, server *pagedListServer, configure func(*Config)) *Backend {
+	t.Helper()
+	cfg := newRegionConfig(server.URL, "us-east-1")
+	if configure != nil {
+		configure(cfg)
+	}
+	backend, err := NewBackend(context.Background(), server.bucket, cfg)
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	return backend
+}
+
+// drainListing returns the keys a streamed listing sent and its error
+func drainListing(objects <-chan types.ObjectInfo, errs <-chan error) ([]string, error) {
+	var keys []string
+	for obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys, <-errs
+}
+
+func TestListObjectsChanReservesCostBudgetPerPage(t *testing.T) {
+	server := newPagedListServer(t, "data", []string{"a", "b"}, []string{"c"}, []string{"d"})
+	backend := newPagedListBackend(t, server, nil)
+
+	// A burst covering one page and a negligible refill admit only the first
+	page := backend.costBudget.estimate("LIST", backend.currentTier, 0)
+	backend.costBudget = newCostScheduler(CostBudgetConfig{USDPerHour: 1e-9, BurstUSD: page * 1.5, Mode: CostBudgetReject}, backend.pricingManager)
+
+	keys, err := drainListing(backend.ListObjectsChan(context.Background(), ""))
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeQuotaExceeded, "")) {
+		t.Fatalf("ListObjectsChan() error = %v, want ErrCodeQuotaExceeded", err)
+	}
+	if strings.Join(keys, ",") != "a,b" || server.listRequests() != 1 {
+		t.Errorf("listed %v in %d requests, want the first page only", keys, server.listRequests())
+	}
+	if rejected := backend.costBudget.stats().Rejected; rejected != 1 {
+		t.Errorf("Rejected = %d, want the second page rejected", rejected)
+	}
+}
+
+func TestListObjectsChanInjectsListFaultsPerPage(t *testing.T) {
+	server := newPagedListServer(t, "data", []string{"a", "b"}, []string{"c"})
+	injector := NewScriptedInjector()
+	injector.Script(FaultOpList, Fault{}, Fault{Err: InjectedError(FaultOpList, "")})
+	backend := newPagedListBackend(t, server, func(cfg *Config) {
+		cfg.FaultInjector = injector
+	})
+
+	keys, err := drainListing(backend.ListObjectsChan(context.Background(), ""))
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeStorageRead, "")) {
+		t.Fatalf("ListObjectsChan() error = %v, want the injected fault", err)
+	}
+	if strings.Join(keys, ",") != "a,b" || server.listRequests() != 1 {
+		t.Errorf("listed %v in %d requests, want the fault to stop the listing before the second page", keys, server.listRequests())
+	}
+	if remaining := injector.Remaining(FaultOpList); remaining != 0 {
+		t.Errorf("%d LIST faults left unused, want one consulted per page", remaining)
+	}
+}
+
+func TestStreamedListingsRecordRecentOperations(t *testing.T) {
+	server := newPagedListServer(t, "data", []string{"logs/a", "logs/b"}, []string{"logs/c"}, []string{"logs/d"})
+	backend := newPagedListBackend(t, server, nil)
+	backend.recentOps = newRecentOps(RecentOpsConfig{Size: 4})
+
+	if _, err := drainListing(backend.ListObjectsChan(context.Background(), "logs/")); err != nil {
+		t.Fatalf("ListObjectsChan() error = %v", err)
+	}
+	if _, err := backend.ListSnapshot(context.Background(), "logs/"); err != nil {
+		t.Fatalf("ListSnapshot() error = %v", err)
+	}
+
+	ops := backend.RecentOperations()
+	want := []string{"ListObjectsChan", "ListSnapshot"}
+	if len(ops) != len(want) {
+		t.Fatalf("RecentOperations() returned %d records, want %d: %+v", len(ops), len(want), ops)
+	}
+	for i, op := range want {
+		if ops[i].Op != op || ops[i].Key != "logs/" || ops[i].Result != "ok" {
+			t.Errorf("record %d = %s %s %s, want %s logs/ ok", i, ops[i].Op, ops[i].Key, ops[i].Result, op)
+		}
+		if ops[i].Attempts != 3 {
+			t.Errorf("record %d attempts = %d, want one per page", i, ops[i].Attempts)
+		}
+	}
+}
+
+func TestStreamListObjects_DoneReportsRequestError(t *testing.T) {
+	client := &fakeListClient{pages: [][]string{{"a"}}, err: errors.New("connection reset")}
+	var reported error
+	_, err := drainListing(streamListObjects(context.Background(), client, &s3.ListObjectsV2Input{Bucket: aws.String("bucket")}, nil, nil, func(err error) {
+		reported = err
+	}))
+	if err == nil || reported != err {
+		t.Errorf("done reported %v for a listing that failed with %v", reported, err)
+	}
+
+	// A listing stopped before its next request made no failed request
+	stop := errors.New("over budget")
+	pages := 0
+	client = &fakeListClient{pages: [][]string{{"a"}, {"b"}}}
+	reported = stop
+	_, err = drainListing(streamListObjects(context.Background(), client, &s3.ListObjectsV2Input{Bucket: aws.String("bucket")}, nil, func(context.Context) error {
+		if pages++; pages > 1 {
+			return stop
+		}
+		return nil
+	}, func(err error) {
+		reported = err
+	}))
+	if err != stop || reported != nil {
+		t.Errorf("done reported %v for a listing stopped with %v, want nil", reported, err)
+	}
+}
+
+func TestListObjectsChanReportsPageErrorsToThePool(t *testing.T) {
+	server := newPagedListServer(t, "data", []string{"a"}, nil)
+	backend := newPagedListBackend(t, server, func(cfg *Config) {
+		cfg.MaxRetries = 1
+	})
+
+	if _, err := drainListing(backend.ListObjectsChan(context.Background(), "")); err == nil {
+		t.Fatal("ListObjectsChan() succeeded over a failing page")
+	}
+	if stats := backend.clientManager.GetPool().Stats(); stats.LastError == "" {
+		t.Error("pool recorded no error for the client whose page request failed")
+	}
+}
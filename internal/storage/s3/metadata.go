@@ -0,0 +1,9 @@
+package s3
+
+import "context"
+
+// PutObjectWithMetadata stores an object with user metadata, which HeadObject
+// reports back in ObjectInfo.Metadata
+func (b *Backend) PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	return b.putObject(ctx, key, data, metadata)
+}
@@ -0,0 +1,594 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// Default metadata index settings
+const (
+	DefaultMetadataIndexEntries = 100000
+	DefaultMetadataIndexMaxAge  = 24 * time.Hour
+)
+
+// metadataIndexVersion is the format version of persisted metadata indexes
+const metadataIndexVersion = 1
+
+// metadataIndexWorkers bounds the HEAD and tagging requests of one build
+const metadataIndexWorkers = 8
+
+// metadataIndexDisabled builds the error queries return when no index, or
+// no index of tags, is kept
+func metadataIndexDisabled(operation, what string) error {
+	return errors.NewError(errors.ErrCodeOperationFailed, what+" index is disabled").
+		WithComponent("s3-backend").
+		WithOperation(operation).
+		WithDetail("suggestion", "Enable metadata_index in the S3 configuration")
+}
+
+// MetadataIndexStats reports how complete and current the metadata index is
+type MetadataIndexStats struct {
+	Enabled   bool          `json:"enabled"`
+	Entries   int           `json:"entries"`
+	BuiltAt   time.Time     `json:"built_at"`  // Last full build from a listing; zero until the first query
+	Age       time.Duration `json:"age"`       // Time since BuiltAt; writes since are applied incrementally
+	Pending   int           `json:"pending"`   // Written keys whose metadata the next query fetches
+	Truncated bool          `json:"truncated"` // More objects exist than MaxEntries, so queries may miss keys
+	Builds    int64         `json:"builds"`
+}
+
+// metadataEntry is the indexed metadata and tags of one object
+type metadataEntry struct {
+	Key      string            `json:"key"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// metadataIndexFile is the persisted form of a metadata index
+type metadataIndexFile struct {
+	Version   int             `json:"version"`
+	Prefix    string          `json:"prefix"`
+	BuiltAt   time.Time       `json:"built_at"`
+	Truncated bool            `json:"truncated"`
+	Entries   []metadataEntry `json:"entries"`
+	Pending   []string        `json:"pending,omitempty"`
+}
+
+// valueIndex maps a metadata or tag name to its values, and each value to
+// the keys carrying it
+type valueIndex map[string]map[string]map[string]struct{}
+
+func (vi valueIndex) add(fields map[string]string, key string) {
+	for name, value := range fields {
+		values := vi[name]
+		if values == nil {
+			values = make(map[string]map[string]struct{})
+			vi[name] = values
+		}
+		if values[value] == nil {
+			values[value] = make(map[string]struct{})
+		}
+		values[value][key] = struct{}{}
+	}
+}
+
+func (vi valueIndex) remove(fields map[string]string, key string) {
+	for name, value := range fields {
+		delete(vi[name][value], key)
+		if len(vi[name][value]) == 0 {
+			delete(vi[name], value)
+		}
+		if len(vi[name]) == 0 {
+			delete(vi, name)
+		}
+	}
+}
+
+// metadataIndex maps user metadata and tag values under a prefix to the
+// keys carrying them. It is built lazily from a listing and HEAD of every
+// object by the first query, and again once older than MaxAge; between
+// builds, writes through the backend update it. Keys written without known
+// metadata are pending until the next query fetches them. It holds at most
+// MaxEntries objects and is periodically saved to a file when one is
+// configured.
+type metadataIndex struct {
+	config MetadataIndexConfig
+	logger *slog.Logger
+
+	// Serializes builds and pending refreshes
+	buildMu sync.Mutex
+
+	mu         sync.Mutex
+	entries    map[string]*metadataEntry
+	byMetadata valueIndex
+	byTag      valueIndex
+	pending    map[string]struct{}
+	builtAt    time.Time
+	building   bool
+	truncated  bool
+	builds     int64
+	dirty      bool
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// newMetadataIndex creates the metadata index described by config, loading
+// any index previously saved to its path. It returns nil when indexing is
+// off.
+func newMetadataIndex(config MetadataIndexConfig, logger *slog.Logger) (*metadataIndex, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultMetadataIndexEntries
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = DefaultMetadataIndexMaxAge
+	}
+	if config.PersistInterval <= 0 {
+		config.PersistInterval = DefaultAccessPersistInterval
+	}
+
+	idx := &metadataIndex{config: config, logger: logger}
+	idx.reset()
+	if config.Path == "" {
+		return idx, nil
+	}
+
+	if err := idx.load(); err != nil {
+		return nil, err
+	}
+	idx.stopCh = make(chan struct{})
+	idx.done = make(chan struct{})
+	go idx.persistLoop(config.PersistInterval)
+	return idx, nil
+}
+
+// reset empties the index
+func (idx *metadataIndex) reset() {
+	idx.entries = make(map[string]*metadataEntry)
+	idx.byMetadata = make(valueIndex)
+	idx.byTag = make(valueIndex)
+	idx.pending = make(map[string]struct{})
+}
+
+// covers reports whether key is under the indexed prefix
+func (idx *metadataIndex) covers(key string) bool {
+	return idx != nil && strings.HasPrefix(key, idx.config.Prefix)
+}
+
+// put indexes the metadata and tags key was written with. S3 stores user
+// metadata names in lower case.
+func (idx *metadataIndex) put(key string, metadata, tags map[string]string) {
+	if !idx.covers(key) {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	// A build in progress may have read the object before this write
+	if idx.building {
+		idx.pending[key] = struct{}{}
+	}
+	idx.putLocked(key, metadata, tags)
+}
+
+func (idx *metadataIndex) putLocked(key string, metadata, tags map[string]string) {
+	idx.removeLocked(key)
+	idx.dirty = true
+	if len(idx.entries) >= idx.config.MaxEntries {
+		idx.truncated = true
+		return
+	}
+
+	entry := &metadataEntry{Key: key, Metadata: make(map[string]string, len(metadata)), Tags: tags}
+	for name, value := range metadata {
+		entry.Metadata[strings.ToLower(name)] = value
+	}
+	idx.entries[key] = entry
+	idx.byMetadata.add(entry.Metadata, key)
+	idx.byTag.add(entry.Tags, key)
+}
+
+// invalidate marks key as written with metadata the index does not know,
+// to be fetched by the next query. Past MaxEntries pending keys, the next
+// query rebuilds the index instead.
+func (idx *metadataIndex) invalidate(key string) {
+	if !idx.covers(key) {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(key)
+	idx.dirty = true
+	idx.pending[key] = struct{}{}
+	if len(idx.pending) > idx.config.MaxEntries {
+		idx.pending = make(map[string]struct{})
+		idx.builtAt = time.Time{}
+	}
+}
+
+// forget drops key, such as after it is deleted
+func (idx *metadataIndex) forget(key string) {
+	if !idx.covers(key) {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(key)
+	delete(idx.pending, key)
+	if idx.building {
+		idx.pending[key] = struct{}{}
+	}
+	idx.dirty = true
+}
+
+func (idx *metadataIndex) removeLocked(key string) {
+	entry, ok := idx.entries[key]
+	if !ok {
+		return
+	}
+	idx.byMetadata.remove(entry.Metadata, key)
+	idx.byTag.remove(entry.Tags, key)
+	delete(idx.entries, key)
+}
+
+// find returns the keys whose metadata field, or tag when tags is set, has
+// value, sorted
+func (idx *metadataIndex) find(tags bool, field, value string) []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	values := idx.byMetadata
+	if tags {
+		values = idx.byTag
+	}
+	keys := make([]string, 0, len(values[field][value]))
+	for key := range values[field][value] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stats returns the size and staleness of the index
+func (idx *metadataIndex) stats() MetadataIndexStats {
+	if idx == nil {
+		return MetadataIndexStats{}
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	stats := MetadataIndexStats{
+		Enabled:   true,
+		Entries:   len(idx.entries),
+		BuiltAt:   idx.builtAt,
+		Pending:   len(idx.pending),
+		Truncated: idx.truncated,
+		Builds:    idx.builds,
+	}
+	if !idx.builtAt.IsZero() {
+		stats.Age = time.Since(idx.builtAt)
+	}
+	return stats
+}
+
+// refresh brings the index up to date for a query: it is rebuilt when it
+// was never built or is older than MaxAge, then pending keys are fetched
+func (b *Backend) refreshMetadataIndex(ctx context.Context) error {
+	idx := b.metadataIndex
+	idx.buildMu.Lock()
+	defer idx.buildMu.Unlock()
+
+	idx.mu.Lock()
+	stale := idx.builtAt.IsZero() || time.Since(idx.builtAt) > idx.config.MaxAge
+	idx.mu.Unlock()
+	if stale {
+		if err := b.rebuildMetadataIndex(ctx); err != nil {
+			return err
+		}
+	}
+
+	idx.mu.Lock()
+	pending := make([]string, 0, len(idx.pending))
+	for key := range idx.pending {
+		pending = append(pending, key)
+	}
+	idx.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	entries, err := b.fetchMetadataEntries(ctx, pending)
+	if err != nil {
+		return err
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, key := range pending {
+		delete(idx.pending, key)
+		if entry, ok := entries[key]; ok {
+			idx.putLocked(key, entry.Metadata, entry.Tags)
+		} else {
+			idx.removeLocked(key)
+		}
+	}
+	idx.dirty = true
+	return nil
+}
+
+// rebuildMetadataIndex replaces the index with the metadata of every
+// object under the prefix, up to MaxEntries
+func (b *Backend) rebuildMetadataIndex(ctx context.Context) error {
+	idx := b.metadataIndex
+	start := time.Now()
+
+	idx.mu.Lock()
+	idx.building = true
+	idx.mu.Unlock()
+	defer func() {
+		idx.mu.Lock()
+		idx.building = false
+		idx.mu.Unlock()
+	}()
+
+	objects, err := b.ListSnapshot(ctx, idx.config.Prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list objects to index: %w", err)
+	}
+	truncated := len(objects) > idx.config.MaxEntries
+	if truncated {
+		objects = objects[:idx.config.MaxEntries]
+	}
+	keys := make([]string, len(objects))
+	for i, obj := range objects {
+		keys[i] = obj.Key
+	}
+
+	entries, err := b.fetchMetadataEntries(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	pending := idx.pending
+	idx.reset()
+	idx.pending = pending
+	for _, key := range keys {
+		if entry, ok := entries[key]; ok {
+			idx.putLocked(key, entry.Metadata, entry.Tags)
+		}
+	}
+	idx.truncated = truncated
+	idx.builtAt = start
+	idx.builds++
+	idx.dirty = true
+
+	b.logger.Info("Built metadata index",
+		"prefix", idx.config.Prefix,
+		"objects", len(idx.entries),
+		"truncated", truncated,
+		"duration", time.Since(start))
+	return nil
+}
+
+// fetchMetadataEntries reads the metadata, and tags when they are indexed,
+// of keys. Keys that no longer exist are left out.
+func (b *Backend) fetchMetadataEntries(ctx context.Context, keys []string) (map[string]*metadataEntry, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		entries  = make(map[string]*metadataEntry, len(keys))
+		firstErr error
+		sem      = make(chan struct{}, metadataIndexWorkers)
+	)
+	for _, key := range keys {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entry, err := b.fetchMetadataEntry(ctx, key)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if entry != nil {
+				entries[key] = entry
+			}
+		}(key)
+	}
+	wg.Wait()
+	return entries, firstErr
+}
+
+// fetchMetadataEntry reads the metadata and tags of key, returning nil when
+// it no longer exists
+func (b *Backend) fetchMetadataEntry(ctx context.Context, key string) (*metadataEntry, error) {
+	info, err := b.HeadObject(ctx, key)
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to index %s: %w", key, err)
+	}
+	entry := &metadataEntry{Key: key, Metadata: info.Metadata}
+	if !b.metadataIndex.config.Tags {
+		return entry, nil
+	}
+
+	client := b.clientManager.GetPooledClient()
+	result, err := client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket:       aws.String(b.bucket),
+		RequestPayer: b.config.requestPayer(),
+		Key:          aws.String(key),
+	})
+	b.clientManager.ReleasePooledClient(client, err)
+	if err != nil {
+		if err := b.translateError(err, "GetObjectTagging", key); isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to index tags of %s: %w", key, err)
+	}
+	entry.Tags = make(map[string]string, len(result.TagSet))
+	for _, tag := range result.TagSet {
+		entry.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return entry, nil
+}
+
+// FindByMetadata returns the keys under the indexed prefix whose user
+// metadata field name has value, sorted. The first query builds the index,
+// as does the first once it is older than MaxAge; other queries are
+// answered locally. Metadata names are matched case-insensitively.
+func (b *Backend) FindByMetadata(ctx context.Context, name, value string) ([]string, error) {
+	if b.metadataIndex == nil {
+		return nil, metadataIndexDisabled("FindByMetadata", "metadata")
+	}
+	if err := b.refreshMetadataIndex(ctx); err != nil {
+		return nil, err
+	}
+	return b.metadataIndex.find(false, strings.ToLower(name), value), nil
+}
+
+// FindByTag returns the keys under the indexed prefix tagged name=value,
+// sorted, when the index includes tags
+func (b *Backend) FindByTag(ctx context.Context, name, value string) ([]string, error) {
+	if b.metadataIndex == nil || !b.metadataIndex.config.Tags {
+		return nil, metadataIndexDisabled("FindByTag", "tag")
+	}
+	if err := b.refreshMetadataIndex(ctx); err != nil {
+		return nil, err
+	}
+	return b.metadataIndex.find(true, name, value), nil
+}
+
+// MetadataIndexStats returns the size and staleness of the metadata index
+func (b *Backend) MetadataIndexStats() MetadataIndexStats {
+	return b.metadataIndex.stats()
+}
+
+// load restores the index saved at its path. A missing file, or one saved
+// for another prefix, leaves the index to be built by the first query.
+func (idx *metadataIndex) load() error {
+	data, err := os.ReadFile(idx.config.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read metadata index: %w", err)
+	}
+
+	var file metadataIndexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse metadata index %s: %w", idx.config.Path, err)
+	}
+	if file.Version != metadataIndexVersion {
+		return fmt.Errorf("unsupported metadata index version %d in %s", file.Version, idx.config.Path)
+	}
+	if file.Prefix != idx.config.Prefix {
+		return nil
+	}
+
+	for _, entry := range file.Entries {
+		idx.putLocked(entry.Key, entry.Metadata, entry.Tags)
+	}
+	for _, key := range file.Pending {
+		idx.pending[key] = struct{}{}
+	}
+	idx.builtAt = file.BuiltAt
+	idx.truncated = file.Truncated
+	idx.dirty = false
+	return nil
+}
+
+// save writes the index to its path if it changed since the last save
+func (idx *metadataIndex) save() (err error) {
+	idx.mu.Lock()
+	if !idx.dirty {
+		idx.mu.Unlock()
+		return nil
+	}
+	file := metadataIndexFile{
+		Version:   metadataIndexVersion,
+		Prefix:    idx.config.Prefix,
+		BuiltAt:   idx.builtAt,
+		Truncated: idx.truncated,
+		Entries:   make([]metadataEntry, 0, len(idx.entries)),
+	}
+	for _, entry := range idx.entries {
+		file.Entries = append(file.Entries, *entry)
+	}
+	for key := range idx.pending {
+		file.Pending = append(file.Pending, key)
+	}
+	idx.dirty = false
+	idx.mu.Unlock()
+
+	// Keep the changes pending so the next save retries them
+	defer func() {
+		if err != nil {
+			idx.mu.Lock()
+			idx.dirty = true
+			idx.mu.Unlock()
+		}
+	}()
+
+	sort.Slice(file.Entries, func(i, j int) bool { return file.Entries[i].Key < file.Entries[j].Key })
+	sort.Strings(file.Pending)
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata index: %w", err)
+	}
+	if err := writeFileAtomic(idx.config.Path, data); err != nil {
+		return fmt.Errorf("failed to write metadata index: %w", err)
+	}
+	return nil
+}
+
+// persistLoop saves the index every interval until close
+func (idx *metadataIndex) persistLoop(interval time.Duration) {
+	defer close(idx.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-idx.stopCh:
+			return
+		case <-ticker.C:
+			if err := idx.save(); err != nil {
+				idx.logger.Warn("Failed to persist metadata index", "path", idx.config.Path, "error", err)
+			}
+		}
+	}
+}
+
+// close stops periodic saving and saves the index a final time
+func (idx *metadataIndex) close() error {
+	if idx == nil || idx.config.Path == "" {
+		return nil
+	}
+	close(idx.stopCh)
+	<-idx.done
+	return idx.save()
+}
@@ -0,0 +1,265 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// metadataObject is an object stored by metadataServer
+type metadataObject struct {
+	body     []byte
+	metadata map[string]string
+}
+
+// metadataServer is an S3 endpoint keeping objects with their user
+// metadata, and counting the requests the metadata index makes
+type metadataServer struct {
+	*httptest.Server
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]metadataObject
+	uploads map[string]metadataObject
+	heads   int
+	lists   int
+}
+
+func newMetadataServer(t *testing.T, bucket string) *metadataServer {
+	setTestCredentials(t)
+	s := &metadataServer{bucket: bucket, objects: make(map[string]metadataObject), uploads: make(map[string]metadataObject)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *metadataServer) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(bucketRegionHeader, "us-east-1")
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+s.bucket), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key == "" {
+		if r.URL.Query().Get("list-type") == "" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		s.lists++
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for key := range s.objects {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		var contents strings.Builder
+		for _, key := range keys {
+			fmt.Fprintf(&contents, "<Contents><Key>%s</Key><Size>%d</Size></Contents>", key, len(s.objects[key].body))
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, "<ListBucketResult><Name>%s</Name><KeyCount>%d</KeyCount><IsTruncated>false</IsTruncated>%s</ListBucketResult>",
+			s.bucket, len(keys), contents.String())
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		// Multipart uploads carry their metadata on the initiating request
+		s.uploads[key] = metadataObject{metadata: userMetadata(r.Header)}
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>",
+			s.bucket, key, key)
+		return
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("ETag", `"part"`)
+		w.WriteHeader(http.StatusOK)
+		return
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.objects[key] = s.uploads[key]
+		delete(s.uploads, key)
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>\"etag\"</ETag></CompleteMultipartUploadResult>",
+			s.bucket, key)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.objects[key] = metadataObject{body: body, metadata: userMetadata(r.Header)}
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		obj, ok := s.objects[key]
+		if r.Method == http.MethodHead {
+			s.heads++
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for field, value := range obj.metadata {
+			w.Header().Set("X-Amz-Meta-"+field, value)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.body)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.body)
+		}
+	}
+}
+
+// userMetadata returns the x-amz-meta- headers of a request
+func userMetadata(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for name, values := range header {
+		if field, ok := strings.CutPrefix(strings.ToLower(name), "x-amz-meta-"); ok {
+			metadata[field] = values[0]
+		}
+	}
+	return metadata
+}
+
+// seed stores an object directly, as if written by another client
+func (s *metadataServer) seed(key string, metadata map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = metadataObject{body: []byte(key), metadata: metadata}
+}
+
+// requests returns and resets the HEAD and LIST requests served
+func (s *metadataServer) requests() (heads, lists int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	heads, lists = s.heads, s.lists
+	s.heads, s.lists = 0, 0
+	return heads, lists
+}
+
+func newMetadataIndexBackend(t *testing.T, server *metadataServer, index MetadataIndexConfig) *Backend {
+	t.Helper()
+	cfg := newRegionConfig(server.URL, "us-east-1")
+	cfg.MetadataIndex = index
+	backend, err := NewBackend(context.Background(), server.bucket, cfg)
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	return backend
+}
+
+func findByMetadata(t *testing.T, backend *Backend, name, value string) []string {
+	t.Helper()
+	keys, err := backend.FindByMetadata(context.Background(), name, value)
+	if err != nil {
+		t.Fatalf("FindByMetadata(%s, %s) error = %v", name, value, err)
+	}
+	return keys
+}
+
+func TestFindByMetadataReturnsMatchingKeys(t *testing.T) {
+	server := newMetadataServer(t, "data")
+	server.seed("runs/existing.csv", map[string]string{"project": "alpha"})
+	backend := newMetadataIndexBackend(t, server, MetadataIndexConfig{Enabled: true, Prefix: "runs/"})
+	defer func() { _ = backend.Close() }()
+	ctx := context.Background()
+
+	writes := map[string]string{"runs/a.csv": "alpha", "runs/b.csv": "beta", "runs/c.csv": "alpha-2", "other/d.csv": "alpha"}
+	for key, project := range writes {
+		if err := backend.PutObjectWithMetadata(ctx, key, []byte(key), map[string]string{"Project": project}); err != nil {
+			t.Fatalf("PutObjectWithMetadata(%s) error = %v", key, err)
+		}
+	}
+
+	want := []string{"runs/a.csv", "runs/existing.csv"}
+	if got := findByMetadata(t, backend, "project", "alpha"); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindByMetadata(project, alpha) = %v, want %v", got, want)
+	}
+	if _, lists := server.requests(); lists != 1 {
+		t.Errorf("first query listed the bucket %d times, want 1", lists)
+	}
+
+	// Later writes and deletes update the index without another scan
+	if err := backend.PutObjectWithMetadata(ctx, "runs/b.csv", []byte("b"), map[string]string{"project": "alpha"}); err != nil {
+		t.Fatalf("PutObjectWithMetadata() error = %v", err)
+	}
+	if err := backend.DeleteObject(ctx, "runs/a.csv"); err != nil {
+		t.Fatalf("DeleteObject() error = %v", err)
+	}
+	server.requests()
+
+	want = []string{"runs/b.csv", "runs/existing.csv"}
+	if got := findByMetadata(t, backend, "PROJECT", "alpha"); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindByMetadata(PROJECT, alpha) after writes = %v, want %v", got, want)
+	}
+	if got := findByMetadata(t, backend, "project", "beta"); len(got) != 0 {
+		t.Errorf("FindByMetadata(project, beta) = %v, want none after the overwrite", got)
+	}
+	if heads, lists := server.requests(); heads != 0 || lists != 0 {
+		t.Errorf("queries after writes made %d HEAD and %d LIST requests, want none", heads, lists)
+	}
+
+	stats := backend.MetadataIndexStats()
+	if stats.Entries != 3 || stats.Builds != 1 || stats.BuiltAt.IsZero() || stats.Truncated {
+		t.Errorf("MetadataIndexStats() = %+v, want 3 entries from one build", stats)
+	}
+	if _, err := backend.FindByTag(ctx, "team", "genomics"); err == nil {
+		t.Error("FindByTag() succeeded with tag indexing off")
+	}
+}
+
+func TestMetadataIndexSurvivesRestart(t *testing.T) {
+	server := newMetadataServer(t, "data")
+	for i := 0; i < 3; i++ {
+		server.seed(fmt.Sprintf("runs/%d.csv", i), map[string]string{"stage": "raw"})
+	}
+	index := MetadataIndexConfig{Enabled: true, Path: t.TempDir() + "/metadata-index.json"}
+
+	backend := newMetadataIndexBackend(t, server, index)
+	if got := findByMetadata(t, backend, "stage", "raw"); len(got) != 3 {
+		t.Fatalf("FindByMetadata(stage, raw) = %v, want 3 keys", got)
+	}
+	if err := backend.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	server.requests()
+
+	restarted := newMetadataIndexBackend(t, server, index)
+	defer func() { _ = restarted.Close() }()
+	if got := findByMetadata(t, restarted, "stage", "raw"); len(got) != 3 {
+		t.Errorf("FindByMetadata(stage, raw) after restart = %v, want 3 keys", got)
+	}
+	if heads, lists := server.requests(); heads != 0 || lists != 0 {
+		t.Errorf("query after restart made %d HEAD and %d LIST requests, want none", heads, lists)
+	}
+}
+
+func TestMetadataIndexIsBounded(t *testing.T) {
+	server := newMetadataServer(t, "data")
+	for i := 0; i < 5; i++ {
+		server.seed(fmt.Sprintf("runs/%d.csv", i), map[string]string{"stage": "raw"})
+	}
+	backend := newMetadataIndexBackend(t, server, MetadataIndexConfig{Enabled: true, MaxEntries: 3})
+	defer func() { _ = backend.Close() }()
+
+	if got := findByMetadata(t, backend, "stage", "raw"); len(got) != 3 {
+		t.Errorf("FindByMetadata(stage, raw) = %v, want the 3 indexed keys", got)
+	}
+	if stats := backend.MetadataIndexStats(); stats.Entries != 3 || !stats.Truncated {
+		t.Errorf("MetadataIndexStats() = %+v, want 3 entries and truncated", stats)
+	}
+}
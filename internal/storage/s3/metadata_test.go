@@ -0,0 +1,56 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestPutObjectWithMetadataStoresUserMetadata(t *testing.T) {
+	server := newMetadataServer(t, "data")
+	cfg := newRegionConfig(server.URL, "us-east-1")
+	cfg.MultipartThreshold = 1024
+	cfg.MultipartChunkSize = 1024
+	backend, err := NewBackend(context.Background(), server.bucket, cfg)
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	defer func() { _ = backend.Close() }()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		key  string
+		size int
+	}{
+		{"single request", "runs/small.csv", 100},
+		{"multipart upload", "runs/large.csv", 1500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte("x"), tt.size)
+			if err := backend.PutObjectWithMetadata(ctx, tt.key, data, map[string]string{"project": "alpha"}); err != nil {
+				t.Fatalf("PutObjectWithMetadata() error = %v", err)
+			}
+			info, err := backend.HeadObject(ctx, tt.key)
+			if err != nil {
+				t.Fatalf("HeadObject() error = %v", err)
+			}
+			if got := info.Metadata["project"]; got != "alpha" {
+				t.Errorf("metadata project = %q, want alpha", got)
+			}
+		})
+	}
+
+	// Plain puts store no user metadata
+	if err := backend.PutObject(ctx, "runs/plain.csv", []byte("plain")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+	info, err := backend.HeadObject(ctx, "runs/plain.csv")
+	if err != nil {
+		t.Fatalf("HeadObject() error = %v", err)
+	}
+	if len(info.Metadata) != 0 {
+		t.Errorf("PutObject() stored metadata %v, want none", info.Metadata)
+	}
+}
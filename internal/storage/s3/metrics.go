@@ -3,6 +3,8 @@ package s3
 import (
 	"sync"
 	"time"
+
+	"github.com/objectfs/objectfs/internal/circuit"
 )
 
 // BackendMetrics tracks S3 backend performance metrics
@@ -30,6 +32,33 @@ type BackendMetrics struct {
 	MultipartBytes            int64         `json:"multipart_bytes"`             // Total bytes uploaded via multipart
 	AveragePartSize           int64         `json:"average_part_size"`           // Average part size in bytes
 	MultipartLatency          time.Duration `json:"multipart_latency"`           // Average multipart upload latency
+
+	// Request hedging metrics
+	HedgesFired int64 `json:"hedges_fired"` // Second attempts started for slow reads
+	HedgeWins   int64 `json:"hedge_wins"`   // Hedged attempts that returned first
+
+	// In-flight transfer progress, aggregated across uploads and downloads
+	ActiveTransfers    int   `json:"active_transfers"`
+	TransferBytesDone  int64 `json:"transfer_bytes_done"`
+	TransferBytesTotal int64 `json:"transfer_bytes_total"` // Excludes transfers of unknown size
+
+	// Spend rate against the cost budget; both zero when no budget is set
+	BudgetUSDPerHour float64 `json:"budget_usd_per_hour"`
+	SpendUSDPerHour  float64 `json:"spend_usd_per_hour"`
+
+	// Health of pooled clients
+	PoolHealthy   int   `json:"pool_healthy"`
+	PoolUnhealthy int   `json:"pool_unhealthy"`
+	PoolReplaced  int64 `json:"pool_replaced"`
+
+	// Reads served by a replica region instead of the primary
+	FailoverReads int64 `json:"failover_reads"`
+
+	// Keys dropped for repeating across the pages of a listing
+	ListDuplicatesDropped int64 `json:"list_duplicates_dropped"`
+
+	// State, trip mode and rolling error rate of each circuit breaker
+	CircuitBreakers map[string]circuit.CircuitBreakerStats `json:"circuit_breakers,omitempty"`
 }
 
 // MetricsCollector handles metrics collection and aggregation for S3 backend
@@ -165,6 +194,22 @@ func (mc *MetricsCollector) RecordFallbackEvent() {
 	mc.metrics.FallbackEvents++
 }
 
+// RecordHedgeFired records a hedged second attempt being started
+func (mc *MetricsCollector) RecordHedgeFired() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.HedgesFired++
+}
+
+// RecordHedgeWin records a hedged attempt beating the original request
+func (mc *MetricsCollector) RecordHedgeWin() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.HedgeWins++
+}
+
 // SetAccelerationEnabled sets whether acceleration is enabled
 func (mc *MetricsCollector) SetAccelerationEnabled(enabled bool) {
 	mc.mu.Lock()
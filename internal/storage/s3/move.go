@@ -0,0 +1,136 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// moveAPIClient is the subset of the S3 client used to move objects
+type moveAPIClient interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+}
+
+// MoveObject moves srcKey to dstKey with a server-side copy that keeps the
+// source's user metadata, content headers, tags, and storage class, followed
+// by a delete of the source. If the copy fails the source is left alone; if
+// the delete fails the error is returned with both copies in place, so data
+// is never lost. Object ACLs are not copied; the destination gets the
+// bucket's default ownership.
+func (b *Backend) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	if srcKey == dstKey {
+		return nil
+	}
+
+	start := time.Now()
+	defer func() {
+		b.metricsCollector.RecordMetrics(time.Since(start), false)
+	}()
+
+	if b.presigned != nil {
+		return errors.NewError(errors.ErrCodeOperationFailed, "server-side move is not available with presigned URLs").
+			WithComponent("s3-backend").
+			WithOperation("MoveObject").
+			WithContext("key", srcKey)
+	}
+
+	if !b.healthTracker.CanWrite("s3-writes") {
+		state := b.healthTracker.GetState("s3-writes")
+		return errors.NewError(errors.ErrCodeServiceUnavailable, "S3 write operations are unavailable").
+			WithComponent("s3-backend").
+			WithOperation("MoveObject").
+			WithContext("health_state", state.String()).
+			WithContext("bucket", b.bucket).
+			WithContext("key", srcKey)
+	}
+
+	// The copy overwrites dstKey and the delete removes srcKey, so neither
+	// may be retained; checking only once the copy is made would leave both
+	for _, key := range []string{srcKey, dstKey} {
+		if err := b.checkRetention(ctx, "MoveObject", key); err != nil {
+			return err
+		}
+	}
+
+	if err := b.costBudget.reserve(ctx, "MoveObject", dstKey, b.costBudget.estimate("PUT", b.currentTier, 0)); err != nil {
+		return err
+	}
+
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	storageClass, err := moveObject(ctx, client, b.bucket, b.config.requestPayer(), srcKey, dstKey, b.DeleteObject)
+	if storageClass != "" {
+		b.objectTiers.record(dstKey, storageClass, b.currentTier)
+		b.metadataIndex.invalidate(dstKey)
+	}
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+		var objErr *errors.ObjectFSError
+		if !stderr.As(err, &objErr) {
+			err = b.translateError(err, "MoveObject", srcKey)
+			b.healthTracker.RecordError("s3-writes", err)
+		}
+		return err
+	}
+
+	b.healthTracker.RecordSuccess("s3-writes")
+	return nil
+}
+
+// moveObject copies srcKey to dstKey, then removes srcKey. It returns the
+// storage class of the copy once the copy has succeeded, even when removing
+// the source then fails.
+func moveObject(ctx context.Context, client moveAPIClient, bucket string, payer s3types.RequestPayer, srcKey, dstKey string, remove func(ctx context.Context, key string) error) (string, error) {
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(srcKey),
+		RequestPayer: payer,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if size := aws.ToInt64(head.ContentLength); size > maxCopyObjectSize {
+		return "", errors.NewError(errors.ErrCodeLimitExceeded, "object is too large to move with a single copy").
+			WithComponent("s3-backend").
+			WithOperation("MoveObject").
+			WithContext("key", srcKey).
+			WithDetail("size", size).
+			WithDetail("max_size", int64(maxCopyObjectSize))
+	}
+
+	// A copy defaults to STANDARD, so the source's class is set explicitly;
+	// metadata, content headers, and tags are copied as they are
+	storageClass := headStorageClass(head.StorageClass)
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(bucket),
+		Key:               aws.String(dstKey),
+		CopySource:        aws.String(copySource(bucket, srcKey)),
+		MetadataDirective: s3types.MetadataDirectiveCopy,
+		TaggingDirective:  s3types.TaggingDirectiveCopy,
+		StorageClass:      s3types.StorageClass(storageClass),
+		RequestPayer:      payer,
+	}
+	// Copy only the version that was inspected, so a concurrent write to
+	// the source is not deleted without having been copied
+	if head.ETag != nil {
+		input.CopySourceIfMatch = head.ETag
+	}
+	if _, err := client.CopyObject(ctx, input); err != nil {
+		return "", err
+	}
+
+	if err := remove(ctx, srcKey); err != nil {
+		return storageClass, fmt.Errorf("copied %s to %s but failed to delete the source: %w", srcKey, dstKey, err)
+	}
+	return storageClass, nil
+}
@@ -0,0 +1,162 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeMoveObject is an object in a fakeMoveBucket
+type fakeMoveObject struct {
+	etag         string
+	contentType  string
+	metadata     map[string]string
+	tags         string
+	storageClass s3types.StorageClass
+}
+
+// fakeMoveBucket is an in-memory bucket applying CopyObject directives the
+// way S3 does
+type fakeMoveBucket struct {
+	mu      sync.Mutex
+	objects map[string]fakeMoveObject
+}
+
+func (f *fakeMoveBucket) HeadObject(ctx context.Context, input *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, ok := f.objects[aws.ToString(input.Key)]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	out := &s3.HeadObjectOutput{
+		ETag:          aws.String(obj.etag),
+		ContentType:   aws.String(obj.contentType),
+		ContentLength: aws.Int64(3),
+		Metadata:      obj.metadata,
+	}
+	// S3 omits the class of STANDARD objects
+	if obj.storageClass != s3types.StorageClassStandard {
+		out.StorageClass = obj.storageClass
+	}
+	return out, nil
+}
+
+func (f *fakeMoveBucket) CopyObject(ctx context.Context, input *s3.CopyObjectInput, _ ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source, err := url.PathUnescape(strings.TrimPrefix(aws.ToString(input.CopySource), "bucket/"))
+	if err != nil {
+		return nil, err
+	}
+	src, ok := f.objects[source]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	if input.CopySourceIfMatch != nil && aws.ToString(input.CopySourceIfMatch) != src.etag {
+		return nil, errors.New("PreconditionFailed")
+	}
+
+	dst := fakeMoveObject{etag: src.etag, storageClass: s3types.StorageClassStandard}
+	if input.MetadataDirective != s3types.MetadataDirectiveReplace {
+		dst.contentType, dst.metadata = src.contentType, src.metadata
+	}
+	if input.TaggingDirective != s3types.TaggingDirectiveReplace {
+		dst.tags = src.tags
+	}
+	if input.StorageClass != "" {
+		dst.storageClass = input.StorageClass
+	}
+	f.objects[aws.ToString(input.Key)] = dst
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (f *fakeMoveBucket) remove(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, key)
+	return nil
+}
+
+func newFakeMoveBucket() *fakeMoveBucket {
+	return &fakeMoveBucket{objects: map[string]fakeMoveObject{
+		"reports/q1 draft.csv": {
+			etag:         `"etag-1"`,
+			contentType:  "text/csv",
+			metadata:     map[string]string{"owner": "finance", "objectfs-mode": "640"},
+			tags:         "project=atlas&retain=7y",
+			storageClass: s3types.StorageClassStandardIa,
+		},
+	}}
+}
+
+func TestMoveObjectPreservesMetadataTagsAndStorageClass(t *testing.T) {
+	bucket := newFakeMoveBucket()
+
+	class, err := moveObject(context.Background(), bucket, "bucket", "", "reports/q1 draft.csv", "reports/q1.csv", bucket.remove)
+	if err != nil {
+		t.Fatalf("moveObject failed: %v", err)
+	}
+	if class != string(s3types.StorageClassStandardIa) {
+		t.Errorf("returned storage class %q, want STANDARD_IA", class)
+	}
+
+	if _, ok := bucket.objects["reports/q1 draft.csv"]; ok {
+		t.Error("source still exists after a successful move")
+	}
+	moved, ok := bucket.objects["reports/q1.csv"]
+	if !ok {
+		t.Fatal("destination missing after move")
+	}
+	if moved.metadata["owner"] != "finance" || moved.metadata["objectfs-mode"] != "640" || moved.contentType != "text/csv" {
+		t.Errorf("metadata = %v, content type %q; want the source's", moved.metadata, moved.contentType)
+	}
+	if moved.tags != "project=atlas&retain=7y" {
+		t.Errorf("tags = %q, want the source's", moved.tags)
+	}
+	if moved.storageClass != s3types.StorageClassStandardIa {
+		t.Errorf("storage class = %s, want STANDARD_IA", moved.storageClass)
+	}
+}
+
+func TestMoveObjectKeepsBothCopiesWhenDeleteFails(t *testing.T) {
+	bucket := newFakeMoveBucket()
+	deleteErr := errors.New("AccessDenied")
+	remove := func(ctx context.Context, key string) error { return deleteErr }
+
+	class, err := moveObject(context.Background(), bucket, "bucket", "", "reports/q1 draft.csv", "reports/q1.csv", remove)
+	if !errors.Is(err, deleteErr) {
+		t.Fatalf("moveObject error = %v, want the delete failure", err)
+	}
+	if class == "" {
+		t.Error("storage class not reported for the completed copy")
+	}
+	if len(bucket.objects) != 2 {
+		t.Errorf("bucket holds %d objects, want the source and its copy", len(bucket.objects))
+	}
+}
+
+func TestMoveObjectLeavesSourceWhenCopyFails(t *testing.T) {
+	bucket := newFakeMoveBucket()
+	removed := false
+	remove := func(ctx context.Context, key string) error {
+		removed = true
+		return nil
+	}
+
+	if _, err := moveObject(context.Background(), bucket, "bucket", "", "reports/missing.csv", "reports/q1.csv", remove); err == nil {
+		t.Fatal("moving a missing object succeeded")
+	}
+	if removed {
+		t.Error("source deleted although the copy failed")
+	}
+}
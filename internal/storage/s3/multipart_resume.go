@@ -0,0 +1,110 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"hash/crc64"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Multipart failure policies
+const (
+	// MultipartAbortOnFailure aborts the whole upload when any part fails
+	MultipartAbortOnFailure = "abort-on-failure"
+	// MultipartPreserveForResume keeps the uploaded parts when a part fails,
+	// so the next upload of the same data only sends the failed parts
+	MultipartPreserveForResume = "preserve-for-resume"
+)
+
+// Upload state metadata key holding the checksum of the uploaded data
+const metadataContentChecksum = "content_crc64"
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// validateMultipartFailurePolicy checks a configured failure policy
+func validateMultipartFailurePolicy(policy string) error {
+	switch policy {
+	case "", MultipartAbortOnFailure, MultipartPreserveForResume:
+		return nil
+	}
+	return fmt.Errorf("invalid multipart failure policy: %s (must be %s or %s)",
+		policy, MultipartAbortOnFailure, MultipartPreserveForResume)
+}
+
+// contentChecksum fingerprints data so a preserved upload is only resumed
+// with identical content
+func contentChecksum(data []byte) string {
+	return strconv.FormatUint(crc64.Checksum(data, crc64Table), 16)
+}
+
+// isUploadGone reports whether err means the multipart upload no longer
+// exists, so its parts cannot be resumed
+func isUploadGone(err error) bool {
+	return isErrorType[*s3types.NoSuchUpload](err) || isAPIError(err, "NoSuchUpload")
+}
+
+// MultipartUploads returns the tracked multipart uploads with their part
+// success and failure counts, including failed uploads preserved for resume
+func (b *Backend) MultipartUploads() []MultipartUploadState {
+	return b.multipartManager.Snapshot()
+}
+
+// partUploader uploads one part, including retries, and returns its ETag
+type partUploader func(ctx context.Context, partNumber int, body []byte) (string, error)
+
+// uploadRemainingParts uploads every part of data that state has not
+// recorded as completed, at most concurrency at a time. Results are recorded
+// through manager. It returns the completed parts of the whole upload in
+// part order, and one error per part that failed.
+func uploadRemainingParts(ctx context.Context, manager *MultipartStateManager, state *MultipartUploadState, data []byte, concurrency int, upload partUploader) ([]s3types.CompletedPart, []error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	remaining := manager.RemainingParts(state.UploadID)
+
+	type partResult struct {
+		partNumber int
+		err        error
+	}
+	resultCh := make(chan partResult, len(remaining))
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, partNum := range remaining {
+		go func(pn int) {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			// Calculate part boundaries
+			startOffset := int64(pn-1) * state.ChunkSize
+			endOffset := startOffset + state.ChunkSize
+			if endOffset > int64(len(data)) {
+				endOffset = int64(len(data))
+			}
+			partData := data[startOffset:endOffset]
+
+			etag, err := upload(ctx, pn, partData)
+			manager.UpdatePartStatus(state.UploadID, pn, int64(len(partData)), etag, err)
+			resultCh <- partResult{partNumber: pn, err: err}
+		}(partNum)
+	}
+
+	var uploadErrors []error
+	for range remaining {
+		if result := <-resultCh; result.err != nil {
+			uploadErrors = append(uploadErrors, fmt.Errorf("part %d failed: %w", result.partNumber, result.err))
+		}
+	}
+
+	completed := manager.CompletedParts(state.UploadID)
+	completedParts := make([]s3types.CompletedPart, 0, len(completed))
+	for _, part := range completed {
+		completedParts = append(completedParts, s3types.CompletedPart{
+			PartNumber: aws.Int32(int32(part.PartNumber)),
+			ETag:       aws.String(part.ETag),
+		})
+	}
+	return completedParts, uploadErrors
+}
@@ -0,0 +1,93 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// recordingUploader uploads parts in memory, failing the parts in fail
+type recordingUploader struct {
+	mu       sync.Mutex
+	fail     map[int]bool
+	uploaded map[int][]byte
+	calls    []int
+}
+
+func (r *recordingUploader) upload(ctx context.Context, pn int, body []byte) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, pn)
+	if r.fail[pn] {
+		return "", errors.New("connection reset")
+	}
+	r.uploaded[pn] = append([]byte(nil), body...)
+	return fmt.Sprintf("etag-%d", pn), nil
+}
+
+func TestPreserveForResumeKeepsCompletedParts(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10) // 100 bytes in 4 parts of 32
+	manager := NewMultipartStateManager()
+	state := NewMultipartUploadState("upload-1", "bucket", "big.bin", int64(len(data)), 32)
+	state.Metadata[metadataContentChecksum] = contentChecksum(data)
+	manager.TrackUpload(state)
+	ctx := context.Background()
+
+	first := &recordingUploader{fail: map[int]bool{3: true}, uploaded: map[int][]byte{}}
+	completed, errs := uploadRemainingParts(ctx, manager, state, data, 2, first.upload)
+	if len(errs) != 1 || len(completed) != 3 {
+		t.Fatalf("first attempt: %d errors and %d completed parts, want 1 and 3", len(errs), len(completed))
+	}
+	manager.MarkUploadFailed(state.UploadID)
+
+	// A retry of the same data finds the preserved upload
+	resumed := manager.FindResumable("bucket", "big.bin", int64(len(data)), 32, contentChecksum(data))
+	if resumed != state {
+		t.Fatalf("FindResumable = %v, want the failed upload", resumed)
+	}
+	if other := manager.FindResumable("bucket", "big.bin", int64(len(data)), 32, contentChecksum([]byte("changed"))); other != nil {
+		t.Error("upload with different content resumed the preserved upload")
+	}
+
+	second := &recordingUploader{uploaded: map[int][]byte{}}
+	completed, errs = uploadRemainingParts(ctx, manager, resumed, data, 2, second.upload)
+	if len(errs) != 0 {
+		t.Fatalf("resumed attempt failed: %v", errs)
+	}
+	if len(second.calls) != 1 || second.calls[0] != 3 {
+		t.Errorf("resumed attempt uploaded parts %v, want only part 3", second.calls)
+	}
+	if !bytes.Equal(second.uploaded[3], data[64:96]) {
+		t.Errorf("part 3 body = %q, want bytes 64-96", second.uploaded[3])
+	}
+	if len(completed) != 4 {
+		t.Fatalf("completed parts = %d, want 4", len(completed))
+	}
+	for i, part := range completed {
+		if got, want := *part.PartNumber, int32(i+1); got != want {
+			t.Errorf("completed[%d] is part %d, want part %d", i, got, want)
+		}
+		if got, want := *part.ETag, fmt.Sprintf("etag-%d", i+1); got != want {
+			t.Errorf("part %d ETag = %s, want %s", i+1, got, want)
+		}
+	}
+
+	if resumed.CompletedParts != 4 || resumed.PartFailures != 1 || resumed.BytesUploaded != int64(len(data)) {
+		t.Errorf("counts = %d completed, %d failures, %d bytes; want 4, 1, %d",
+			resumed.CompletedParts, resumed.PartFailures, resumed.BytesUploaded, len(data))
+	}
+}
+
+func TestValidateMultipartFailurePolicy(t *testing.T) {
+	for _, policy := range []string{"", MultipartAbortOnFailure, MultipartPreserveForResume} {
+		if err := validateMultipartFailurePolicy(policy); err != nil {
+			t.Errorf("policy %q rejected: %v", policy, err)
+		}
+	}
+	if err := validateMultipartFailurePolicy("retry-forever"); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
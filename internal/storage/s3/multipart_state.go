@@ -28,6 +28,7 @@ type MultipartUploadState struct {
 	StartedAt      time.Time             `json:"started_at"`
 	LastUpdatedAt  time.Time             `json:"last_updated_at"`
 	CompletedParts int                   `json:"completed_parts"`
+	PartFailures   int                   `json:"part_failures"` // Parts that failed after retries, counted per attempt
 	TotalParts     int                   `json:"total_parts"`
 	BytesUploaded  int64                 `json:"bytes_uploaded"`
 	Status         MultipartUploadStatus `json:"status"`
@@ -78,14 +79,16 @@ func (s *MultipartUploadState) MarkPartCompleted(partNumber int, size int64, eta
 	}
 
 	part := s.Parts[partNumber]
+	if !part.Completed {
+		s.CompletedParts++
+		s.BytesUploaded += size
+	}
 	part.Size = size
 	part.ETag = etag
 	part.Completed = true
 	part.LastModified = time.Now()
 	part.Error = ""
 
-	s.CompletedParts++
-	s.BytesUploaded += size
 	s.LastUpdatedAt = time.Now()
 	s.Status = UploadStatusInProgress
 }
@@ -104,6 +107,7 @@ func (s *MultipartUploadState) MarkPartFailed(partNumber int, err error) {
 	part.LastModified = time.Now()
 	part.Error = err.Error()
 
+	s.PartFailures++
 	s.LastUpdatedAt = time.Now()
 }
 
@@ -212,6 +216,53 @@ func (m *MultipartStateManager) MarkUploadFailed(uploadID string) {
 	}
 }
 
+// RemainingParts returns the part numbers of an upload not yet uploaded
+func (m *MultipartStateManager) RemainingParts(uploadID string) []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, exists := m.uploads[uploadID]
+	if !exists {
+		return nil
+	}
+	return state.GetRemainingParts()
+}
+
+// CompletedParts returns copies of the uploaded parts of an upload in part order
+func (m *MultipartStateManager) CompletedParts(uploadID string) []UploadPart {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, exists := m.uploads[uploadID]
+	if !exists {
+		return nil
+	}
+	completed := make([]UploadPart, 0, state.CompletedParts)
+	for _, part := range state.GetCompletedParts() {
+		completed = append(completed, *part)
+	}
+	return completed
+}
+
+// FindResumable returns a failed upload of the same key, size, chunk size,
+// and content checksum whose uploaded parts were preserved, and marks it in
+// progress again. It returns nil when there is none.
+func (m *MultipartStateManager) FindResumable(bucket, key string, totalSize, chunkSize int64, checksum string) *MultipartUploadState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, state := range m.uploads {
+		if state.Status == UploadStatusFailed && state.Bucket == bucket && state.Key == key &&
+			state.TotalSize == totalSize && state.ChunkSize == chunkSize &&
+			state.Metadata[metadataContentChecksum] == checksum {
+			state.Status = UploadStatusInProgress
+			state.LastUpdatedAt = time.Now()
+			return state
+		}
+	}
+	return nil
+}
+
 // RemoveUpload removes a tracked upload from the manager
 func (m *MultipartStateManager) RemoveUpload(uploadID string) {
 	m.mu.Lock()
@@ -232,6 +283,29 @@ func (m *MultipartStateManager) GetAllUploads() []*MultipartUploadState {
 	return uploads
 }
 
+// Snapshot returns copies of all tracked uploads, safe to read while the
+// uploads continue
+func (m *MultipartStateManager) Snapshot() []MultipartUploadState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	uploads := make([]MultipartUploadState, 0, len(m.uploads))
+	for _, state := range m.uploads {
+		upload := *state
+		upload.Parts = make(map[int]*UploadPart, len(state.Parts))
+		for pn, part := range state.Parts {
+			partCopy := *part
+			upload.Parts[pn] = &partCopy
+		}
+		upload.Metadata = make(map[string]string, len(state.Metadata))
+		for k, v := range state.Metadata {
+			upload.Metadata[k] = v
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads
+}
+
 // GetInProgressUploads returns all uploads that are currently in progress
 func (m *MultipartStateManager) GetInProgressUploads() []*MultipartUploadState {
 	m.mu.RLock()
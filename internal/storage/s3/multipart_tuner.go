@@ -0,0 +1,204 @@
+package s3
+
+import (
+	"sync"
+	"time"
+)
+
+// Adaptive multipart defaults
+const (
+	defaultAdaptiveMinThreshold = 8 * 1024 * 1024
+	defaultAdaptiveMaxThreshold = 512 * 1024 * 1024
+	defaultAdaptiveWindow       = 16
+
+	// minMultipartThreshold keeps parts above the S3 minimum part size
+	minMultipartThreshold = 5 * 1024 * 1024
+
+	// multipartGain is how much faster than single requests multipart
+	// uploads must be for parallelism to count as helping
+	multipartGain = 1.2
+
+	// minTuneSamples is the uploads of each kind measured before the
+	// threshold first moves, and the uploads measured between moves
+	minTuneSamples = 3
+)
+
+// AdaptiveMultipartConfig tunes the multipart threshold and part
+// concurrency from the measured throughput of recent uploads. While
+// disabled, MultipartThreshold and MultipartConcurrency apply as set.
+type AdaptiveMultipartConfig struct {
+	Enabled        bool  `yaml:"enabled"`
+	MinThreshold   int64 `yaml:"min_threshold"`   // Lowest effective threshold (default 8MB)
+	MaxThreshold   int64 `yaml:"max_threshold"`   // Highest effective threshold (default 512MB)
+	MaxConcurrency int   `yaml:"max_concurrency"` // Most concurrent part uploads (default twice MultipartConcurrency)
+	Window         int   `yaml:"window"`          // Recent uploads of each kind whose throughput is compared (default 16)
+}
+
+// MultipartTuning reports the multipart settings uploads currently use and
+// the throughput they were derived from
+type MultipartTuning struct {
+	Adaptive            bool    `json:"adaptive"`
+	Threshold           int64   `json:"threshold"`
+	Concurrency         int     `json:"concurrency"`
+	SingleThroughput    float64 `json:"single_throughput"`    // Bytes/sec of recent single-request uploads
+	MultipartThroughput float64 `json:"multipart_throughput"` // Bytes/sec of recent multipart uploads
+	Adjustments         int64   `json:"adjustments"`
+}
+
+// multipartTuner moves the multipart threshold toward whichever upload
+// kind measures faster. When multipart uploads outpace single requests,
+// as on fast links where parallel parts add bandwidth, the threshold is
+// halved and concurrency raised; when they do not, as on slow or
+// high-latency links where extra requests only add round trips, the
+// threshold is doubled and concurrency lowered.
+type multipartTuner struct {
+	config AdaptiveMultipartConfig
+
+	mu          sync.Mutex
+	threshold   int64
+	concurrency int
+	single      throughputWindow
+	multipart   throughputWindow
+	sinceMove   int
+	adjustments int64
+}
+
+// throughputWindow holds the throughput of recent uploads
+type throughputWindow struct {
+	rates  []float64
+	next   int
+	filled bool
+}
+
+func (w *throughputWindow) add(rate float64) {
+	w.rates[w.next] = rate
+	w.next++
+	if w.next == len(w.rates) {
+		w.next = 0
+		w.filled = true
+	}
+}
+
+func (w *throughputWindow) count() int {
+	if w.filled {
+		return len(w.rates)
+	}
+	return w.next
+}
+
+// mean returns the average throughput in the window, or 0 when empty
+func (w *throughputWindow) mean() float64 {
+	count := w.count()
+	if count == 0 {
+		return 0
+	}
+	var sum float64
+	for _, rate := range w.rates[:count] {
+		sum += rate
+	}
+	return sum / float64(count)
+}
+
+func newMultipartTuner(config AdaptiveMultipartConfig, threshold int64, concurrency int) *multipartTuner {
+	if config.MinThreshold < minMultipartThreshold {
+		config.MinThreshold = defaultAdaptiveMinThreshold
+	}
+	if config.MaxThreshold <= 0 {
+		config.MaxThreshold = defaultAdaptiveMaxThreshold
+	}
+	if config.MaxThreshold < config.MinThreshold {
+		config.MaxThreshold = config.MinThreshold
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = 2 * concurrency
+	}
+	if config.Window <= 0 {
+		config.Window = defaultAdaptiveWindow
+	}
+
+	t := &multipartTuner{
+		config:      config,
+		threshold:   threshold,
+		concurrency: concurrency,
+		single:      throughputWindow{rates: make([]float64, config.Window)},
+		multipart:   throughputWindow{rates: make([]float64, config.Window)},
+	}
+	if config.Enabled {
+		t.threshold = min(max(threshold, config.MinThreshold), config.MaxThreshold)
+		t.concurrency = min(concurrency, config.MaxConcurrency)
+	}
+	return t
+}
+
+// settings returns the multipart threshold and part concurrency uploads use
+func (t *multipartTuner) settings() (int64, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.threshold, t.concurrency
+}
+
+// observe records a successful upload of size bytes that took took, and
+// in adaptive mode moves the threshold once enough uploads of each kind
+// are measured
+func (t *multipartTuner) observe(size int64, took time.Duration, multipart bool) {
+	if size <= 0 || took <= 0 {
+		return
+	}
+	rate := float64(size) / took.Seconds()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Larger uploads spend less of their time on request latency, so only
+	// uploads near the threshold are compared: within a factor of two of it
+	if size < t.threshold/2 || size > t.threshold*2 {
+		return
+	}
+	if multipart {
+		t.multipart.add(rate)
+	} else {
+		t.single.add(rate)
+	}
+	t.sinceMove++
+	if !t.config.Enabled || t.sinceMove < minTuneSamples ||
+		t.single.count() < minTuneSamples || t.multipart.count() < minTuneSamples {
+		return
+	}
+
+	threshold, concurrency := t.threshold, t.concurrency
+	if t.multipart.mean() >= t.single.mean()*multipartGain {
+		threshold = max(threshold/2, t.config.MinThreshold)
+		concurrency = min(concurrency+1, t.config.MaxConcurrency)
+	} else {
+		threshold = min(threshold*2, t.config.MaxThreshold)
+		concurrency = max(concurrency-1, 1)
+	}
+	if threshold != t.threshold || concurrency != t.concurrency {
+		t.threshold, t.concurrency = threshold, concurrency
+		t.adjustments++
+	}
+	t.sinceMove = 0
+}
+
+// stats returns the current tuning
+func (t *multipartTuner) stats() MultipartTuning {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return MultipartTuning{
+		Adaptive:            t.config.Enabled,
+		Threshold:           t.threshold,
+		Concurrency:         t.concurrency,
+		SingleThroughput:    t.single.mean(),
+		MultipartThroughput: t.multipart.mean(),
+		Adjustments:         t.adjustments,
+	}
+}
+
+// MultipartTuning returns the effective multipart threshold and part
+// concurrency, and the upload throughput measured to choose them
+func (b *Backend) MultipartTuning() MultipartTuning {
+	return b.multipartTuner.stats()
+}
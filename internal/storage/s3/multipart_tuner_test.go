@@ -0,0 +1,82 @@
+package s3
+
+import (
+	"testing"
+	"time"
+)
+
+// simulatedLink times uploads over a link with a round-trip latency, a
+// per-connection bandwidth, and a total capacity
+type simulatedLink struct {
+	rtt        time.Duration
+	streamRate float64 // Bytes/sec one connection carries
+	capacity   float64 // Bytes/sec all connections share
+}
+
+// upload returns how long uploading size bytes takes, as one request or as
+// parts of partSize sent concurrency at a time
+func (l simulatedLink) upload(size int64, multipart bool, partSize int64, concurrency int) time.Duration {
+	seconds := func(rate float64) time.Duration {
+		return time.Duration(float64(size) / rate * float64(time.Second))
+	}
+	if !multipart {
+		return l.rtt + seconds(min(l.streamRate, l.capacity))
+	}
+	parts := (size + partSize - 1) / partSize
+	rounds := (int(parts) + concurrency - 1) / concurrency
+	// Initiating and completing the upload add two round trips
+	return time.Duration(rounds+2)*l.rtt + seconds(min(l.streamRate*float64(concurrency), l.capacity))
+}
+
+// drive uploads objects on either side of the tuner's threshold over link
+func (l simulatedLink) drive(tuner *multipartTuner, uploads int) {
+	for i := 0; i < uploads; i++ {
+		threshold, concurrency := tuner.settings()
+		single := threshold * 9 / 10
+		tuner.observe(single, l.upload(single, false, 0, concurrency), false)
+		large := threshold * 3 / 2
+		tuner.observe(large, l.upload(large, true, 8*1024*1024, concurrency), true)
+	}
+}
+
+func TestAdaptiveMultipartFollowsThroughput(t *testing.T) {
+	const mb = 1024 * 1024
+	fast := simulatedLink{rtt: 20 * time.Millisecond, streamRate: 20 * mb, capacity: 1024 * mb}
+	slow := simulatedLink{rtt: 200 * time.Millisecond, streamRate: 2 * mb, capacity: 2 * mb}
+
+	tuner := newMultipartTuner(AdaptiveMultipartConfig{Enabled: true, MaxConcurrency: 16}, 32*mb, 8)
+
+	fast.drive(tuner, 6)
+	onFast := tuner.stats()
+	if onFast.Threshold >= 32*mb || onFast.Concurrency <= 8 {
+		t.Errorf("on a fast link threshold = %dMB and concurrency = %d, want below 32MB and above 8", onFast.Threshold/mb, onFast.Concurrency)
+	}
+	if onFast.MultipartThroughput <= onFast.SingleThroughput {
+		t.Errorf("fast link measured multipart at %.0f bytes/sec and single at %.0f, want multipart faster", onFast.MultipartThroughput, onFast.SingleThroughput)
+	}
+
+	slow.drive(tuner, 40)
+	onSlow := tuner.stats()
+	if onSlow.Threshold <= onFast.Threshold || onSlow.Concurrency >= onFast.Concurrency {
+		t.Errorf("on a slow link threshold = %dMB and concurrency = %d, want above %dMB and below %d",
+			onSlow.Threshold/mb, onSlow.Concurrency, onFast.Threshold/mb, onFast.Concurrency)
+	}
+	if onSlow.Threshold > defaultAdaptiveMaxThreshold {
+		t.Errorf("threshold = %dMB, want at most the %dMB maximum", onSlow.Threshold/mb, defaultAdaptiveMaxThreshold/mb)
+	}
+}
+
+func TestStaticMultipartThresholdIgnoresThroughput(t *testing.T) {
+	const mb = 1024 * 1024
+	tuner := newMultipartTuner(AdaptiveMultipartConfig{}, 32*mb, 8)
+
+	simulatedLink{rtt: 20 * time.Millisecond, streamRate: 20 * mb, capacity: 1024 * mb}.drive(tuner, 10)
+
+	stats := tuner.stats()
+	if stats.Adaptive || stats.Threshold != 32*mb || stats.Concurrency != 8 || stats.Adjustments != 0 {
+		t.Errorf("static tuning = %+v, want the configured 32MB threshold and concurrency 8", stats)
+	}
+	if stats.SingleThroughput == 0 || stats.MultipartThroughput == 0 {
+		t.Errorf("static tuning measured no throughput: %+v", stats)
+	}
+}
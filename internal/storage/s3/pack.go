@@ -0,0 +1,845 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	stderr "errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+const (
+	packDataSuffix  = ".pack"
+	packIndexSuffix = ".idx"
+)
+
+// packEntry locates a packed object within its pack
+type packEntry struct {
+	Pack     string    `json:"-"`
+	Offset   int64     `json:"offset"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// packIndex is the persisted index stored alongside each pack
+type packIndex struct {
+	Pack    string               `json:"pack"`
+	Size    int64                `json:"size"`
+	Entries map[string]packEntry `json:"entries"`
+}
+
+// packState tracks the live entries of a pack
+type packState struct {
+	size int64
+	live map[string]packEntry
+}
+
+// liveBytes returns the bytes still referenced by the index
+func (p *packState) liveBytes() int64 {
+	var total int64
+	for _, entry := range p.live {
+		total += entry.Size
+	}
+	return total
+}
+
+// pendingObject is a small write awaiting the next flush
+type pendingObject struct {
+	data     []byte
+	modified time.Time
+	version  uint64
+}
+
+// PackStats reports small-object packing activity
+type PackStats struct {
+	Packs          int   `json:"packs"`
+	PackedObjects  int   `json:"packed_objects"`
+	PackedBytes    int64 `json:"packed_bytes"`
+	LiveBytes      int64 `json:"live_bytes"`
+	PendingObjects int   `json:"pending_objects"`
+	PendingBytes   int64 `json:"pending_bytes"`
+	Flushes        int64 `json:"flushes"`
+	Compactions    int64 `json:"compactions"`
+}
+
+// Packer aggregates small objects into shared pack objects with an index,
+// transparently unpacking them on read. Objects at or above the threshold
+// pass straight through to the underlying backend.
+type Packer struct {
+	backend   types.Backend
+	config    PackConfig
+	threshold int64
+	logger    *slog.Logger
+
+	mu           sync.RWMutex
+	pending      map[string]pendingObject
+	pendingBytes int64
+	index        map[string]packEntry
+	packs        map[string]*packState
+	version      uint64
+	seq          uint64
+
+	// Serialises flushes and pack rewrites
+	flushMu sync.Mutex
+
+	flushes     atomic.Int64
+	compactions atomic.Int64
+
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewPacker creates a packer over backend. When config.Threshold is zero the
+// minimum billable object size of tier is used.
+func NewPacker(backend types.Backend, config *PackConfig, tier string) (*Packer, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	if config == nil {
+		config = &NewDefaultConfig().Pack
+	}
+
+	cfg := *config
+	if cfg.Threshold == 0 {
+		cfg.Threshold = StorageTiers[tier].MinObjectSize
+	}
+	if cfg.Threshold <= 0 {
+		return nil, fmt.Errorf("pack threshold must be greater than 0 (tier %q has no minimum object size)", tier)
+	}
+	if cfg.MaxPackSize <= 0 {
+		cfg.MaxPackSize = 8 * 1024 * 1024
+	}
+	if cfg.MaxPackSize < cfg.Threshold {
+		return nil, fmt.Errorf("max pack size %d must be at least the threshold %d", cfg.MaxPackSize, cfg.Threshold)
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = ".objectfs/packs/"
+	}
+	if !strings.HasSuffix(cfg.Prefix, "/") {
+		cfg.Prefix += "/"
+	}
+	if cfg.CompactRatio < 0 || cfg.CompactRatio > 1 {
+		return nil, fmt.Errorf("compact ratio must be between 0 and 1, got %f", cfg.CompactRatio)
+	}
+
+	p := &Packer{
+		backend:   backend,
+		config:    cfg,
+		threshold: cfg.Threshold,
+		logger:    slog.Default().With("component", "s3-packer"),
+		pending:   make(map[string]pendingObject),
+		index:     make(map[string]packEntry),
+		packs:     make(map[string]*packState),
+		stopCh:    make(chan struct{}),
+	}
+
+	if cfg.FlushInterval > 0 {
+		p.wg.Add(1)
+		go p.flushLoop()
+	}
+
+	return p, nil
+}
+
+// Load rebuilds the pack index from the indexes stored in the backend
+func (p *Packer) Load(ctx context.Context) error {
+	objects, err := p.listPacks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list packs: %w", err)
+	}
+
+	var indexes []packIndex
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.Key, packIndexSuffix) {
+			continue
+		}
+
+		data, err := p.backend.GetObject(ctx, obj.Key, 0, 0)
+		if err != nil {
+			return fmt.Errorf("failed to read pack index %s: %w", obj.Key, err)
+		}
+
+		var idx packIndex
+		if err := json.Unmarshal(data, &idx); err != nil {
+			return fmt.Errorf("failed to decode pack index %s: %w", obj.Key, err)
+		}
+		indexes = append(indexes, idx)
+	}
+
+	// Pack IDs sort chronologically, so later packs supersede earlier ones
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Pack < indexes[j].Pack })
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, idx := range indexes {
+		state := &packState{size: idx.Size, live: make(map[string]packEntry, len(idx.Entries))}
+		for key, entry := range idx.Entries {
+			entry.Pack = idx.Pack
+			if old, ok := p.index[key]; ok {
+				delete(p.packs[old.Pack].live, key)
+			}
+			p.index[key] = entry
+			state.live[key] = entry
+		}
+		p.packs[idx.Pack] = state
+	}
+
+	return nil
+}
+
+// listPacks lists every object under the pack prefix, streaming when the
+// backend supports it so listings are not truncated to a single page
+func (p *Packer) listPacks(ctx context.Context) ([]types.ObjectInfo, error) {
+	streamer, ok := p.backend.(types.ObjectStreamer)
+	if !ok {
+		return p.backend.ListObjects(ctx, p.config.Prefix, 0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objCh, errCh := streamer.ListObjectsChan(ctx, p.config.Prefix)
+	var objects []types.ObjectInfo
+	for obj := range objCh {
+		objects = append(objects, obj)
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// GetObject reads from pending writes, then packs, then the backend
+func (p *Packer) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	p.mu.RLock()
+	pending, isPending := p.pending[key]
+	entry, isPacked := p.index[key]
+	p.mu.RUnlock()
+
+	switch {
+	case isPending:
+		lo, hi := packRange(int64(len(pending.data)), offset, size)
+		return append([]byte(nil), pending.data[lo:hi]...), nil
+
+	case isPacked:
+		lo, hi := packRange(entry.Size, offset, size)
+		if hi == lo {
+			return []byte{}, nil
+		}
+		data, err := p.backend.GetObject(ctx, p.dataKey(entry.Pack), entry.Offset+lo, hi-lo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from pack %s: %w", key, entry.Pack, err)
+		}
+		return data, nil
+
+	default:
+		return p.backend.GetObject(ctx, key, offset, size)
+	}
+}
+
+// PutObject buffers small objects for packing and writes larger ones directly
+func (p *Packer) PutObject(ctx context.Context, key string, data []byte) error {
+	if strings.HasPrefix(key, p.config.Prefix) {
+		return fmt.Errorf("key %s is reserved for packs", key)
+	}
+
+	if int64(len(data)) >= p.threshold {
+		if err := p.backend.PutObject(ctx, key, data); err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		p.removePendingLocked(key)
+		p.mu.Unlock()
+		return p.dropPacked(ctx, key)
+	}
+
+	p.mu.Lock()
+	p.removePendingLocked(key)
+	p.version++
+	p.pending[key] = pendingObject{
+		data:     append([]byte(nil), data...),
+		modified: time.Now(),
+		version:  p.version,
+	}
+	p.pendingBytes += int64(len(data))
+	full := p.pendingBytes >= p.config.MaxPackSize
+	p.mu.Unlock()
+
+	if full {
+		return p.Flush(ctx)
+	}
+	return nil
+}
+
+// DeleteObject removes an object whether pending, packed, or stored directly.
+// Packs left mostly empty by the deletion are compacted.
+func (p *Packer) DeleteObject(ctx context.Context, key string) error {
+	p.mu.Lock()
+	wasPending := p.removePendingLocked(key)
+	_, wasPacked := p.index[key]
+	p.mu.Unlock()
+
+	if wasPacked {
+		if err := p.dropPacked(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	// Remove any directly stored copy; packed-only keys have none
+	err := p.backend.DeleteObject(ctx, key)
+	if err != nil && (wasPending || wasPacked) && isObjectNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Touch updates the last-modified time of key. Pending objects are stamped
+// in memory; packed objects touch their whole pack, since that is the stored
+// object lifecycle rules apply to.
+func (p *Packer) Touch(ctx context.Context, key string) error {
+	p.mu.Lock()
+	if pending, ok := p.pending[key]; ok {
+		pending.modified = time.Now()
+		p.pending[key] = pending
+		p.mu.Unlock()
+		return nil
+	}
+	entry, isPacked := p.index[key]
+	p.mu.Unlock()
+
+	toucher, ok := p.backend.(types.ObjectToucher)
+	if !ok {
+		return fmt.Errorf("backend does not support touch")
+	}
+	if !isPacked {
+		return toucher.Touch(ctx, key)
+	}
+
+	if err := toucher.Touch(ctx, p.dataKey(entry.Pack)); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if current, ok := p.index[key]; ok && current.Pack == entry.Pack {
+		current.Modified = time.Now()
+		p.index[key] = current
+	}
+	p.mu.Unlock()
+	return nil
+}
+
+// MoveObject moves srcKey to dstKey. Objects stored directly are moved by
+// the backend; pending and packed objects, which carry no metadata of their
+// own, are rewritten under dstKey and then deleted.
+func (p *Packer) MoveObject(ctx context.Context, srcKey, dstKey string) error {
+	if srcKey == dstKey {
+		return nil
+	}
+	if strings.HasPrefix(dstKey, p.config.Prefix) {
+		return fmt.Errorf("key %s is reserved for packs", dstKey)
+	}
+
+	p.mu.RLock()
+	_, isPending := p.pending[srcKey]
+	_, isPacked := p.index[srcKey]
+	p.mu.RUnlock()
+
+	if isPending || isPacked {
+		data, err := p.GetObject(ctx, srcKey, 0, 0)
+		if err != nil {
+			return err
+		}
+		if err := p.PutObject(ctx, dstKey, data); err != nil {
+			return fmt.Errorf("failed to copy %s to %s: %w", srcKey, dstKey, err)
+		}
+		if err := p.DeleteObject(ctx, srcKey); err != nil {
+			return fmt.Errorf("copied %s to %s but failed to delete the source: %w", srcKey, dstKey, err)
+		}
+		return nil
+	}
+
+	mover, ok := p.backend.(types.ObjectMover)
+	if !ok {
+		return fmt.Errorf("backend does not support move")
+	}
+	if err := mover.MoveObject(ctx, srcKey, dstKey); err != nil {
+		return err
+	}
+
+	// The moved object replaces any pending or packed copy of dstKey
+	p.mu.Lock()
+	p.removePendingLocked(dstKey)
+	p.mu.Unlock()
+	return p.dropPacked(ctx, dstKey)
+}
+
+// WriteAt writes part of key. Objects stored directly are written in place
+// by the backend; pending and packed objects are rewritten whole, which may
+// move them out of their pack.
+func (p *Packer) WriteAt(ctx context.Context, key string, offset int64, data []byte) error {
+	p.mu.RLock()
+	_, isPending := p.pending[key]
+	_, isPacked := p.index[key]
+	p.mu.RUnlock()
+
+	writer, ok := p.backend.(types.RangeWriter)
+	if !isPending && !isPacked {
+		if !ok {
+			return fmt.Errorf("backend does not support range writes")
+		}
+		return writer.WriteAt(ctx, key, offset, data)
+	}
+
+	current, err := p.GetObject(ctx, key, 0, 0)
+	if err != nil {
+		return err
+	}
+	if end := offset + int64(len(data)); end > int64(len(current)) {
+		grown := make([]byte, end)
+		copy(grown, current)
+		current = grown
+	}
+	copy(current[offset:], data)
+	return p.PutObject(ctx, key, current)
+}
+
+// HeadObject returns metadata for pending, packed, or stored objects
+func (p *Packer) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	p.mu.RLock()
+	pending, isPending := p.pending[key]
+	entry, isPacked := p.index[key]
+	p.mu.RUnlock()
+
+	switch {
+	case isPending:
+		return &types.ObjectInfo{Key: key, Size: int64(len(pending.data)), LastModified: pending.modified}, nil
+	case isPacked:
+		return p.packedInfo(key, entry), nil
+	default:
+		return p.backend.HeadObject(ctx, key)
+	}
+}
+
+// GetObjects retrieves multiple objects, unpacking packed entries
+func (p *Packer) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	results := make(map[string][]byte, len(keys))
+	var direct []string
+
+	for _, key := range keys {
+		p.mu.RLock()
+		_, isPending := p.pending[key]
+		_, isPacked := p.index[key]
+		p.mu.RUnlock()
+
+		if !isPending && !isPacked {
+			direct = append(direct, key)
+			continue
+		}
+
+		data, err := p.GetObject(ctx, key, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		results[key] = data
+	}
+
+	if len(direct) > 0 {
+		found, err := p.backend.GetObjects(ctx, direct)
+		if err != nil && len(results) == 0 {
+			return nil, err
+		}
+		for key, data := range found {
+			results[key] = data
+		}
+	}
+
+	return results, nil
+}
+
+// PutObjects stores multiple objects
+func (p *Packer) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		if err := p.PutObject(ctx, key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListObjects merges packed and pending entries into the backend listing,
+// hiding the pack objects themselves
+func (p *Packer) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	stored, err := p.backend.ListObjects(ctx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]types.ObjectInfo, len(stored))
+	for _, obj := range stored {
+		if strings.HasPrefix(obj.Key, p.config.Prefix) {
+			continue
+		}
+		merged[obj.Key] = obj
+	}
+
+	p.mu.RLock()
+	for key, entry := range p.index {
+		if strings.HasPrefix(key, prefix) {
+			merged[key] = *p.packedInfo(key, entry)
+		}
+	}
+	for key, pending := range p.pending {
+		if strings.HasPrefix(key, prefix) {
+			merged[key] = types.ObjectInfo{Key: key, Size: int64(len(pending.data)), LastModified: pending.modified}
+		}
+	}
+	p.mu.RUnlock()
+
+	objects := make([]types.ObjectInfo, 0, len(merged))
+	for _, obj := range merged {
+		objects = append(objects, obj)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	if limit > 0 && len(objects) > limit {
+		objects = objects[:limit]
+	}
+	return objects, nil
+}
+
+// HealthCheck checks the underlying backend
+func (p *Packer) HealthCheck(ctx context.Context) error {
+	return p.backend.HealthCheck(ctx)
+}
+
+// Flush writes all pending objects into a new pack
+func (p *Packer) Flush(ctx context.Context) error {
+	p.flushMu.Lock()
+	defer p.flushMu.Unlock()
+
+	p.mu.RLock()
+	batch := make(map[string]pendingObject, len(p.pending))
+	for key, obj := range p.pending {
+		batch[key] = obj
+	}
+	p.mu.RUnlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	contents := make(map[string][]byte, len(batch))
+	modified := make(map[string]time.Time, len(batch))
+	for key, obj := range batch {
+		contents[key] = obj.data
+		modified[key] = obj.modified
+	}
+
+	packID, entries, err := p.writePack(ctx, contents, modified)
+	if err != nil {
+		return err
+	}
+
+	// Commit entries unless the key changed while the pack was written
+	superseded := make(map[string]struct{})
+	var stale bool
+	p.mu.Lock()
+	state := p.packs[packID]
+	for key, entry := range entries {
+		current, ok := p.pending[key]
+		if !ok || current.version != batch[key].version {
+			delete(state.live, key)
+			stale = true
+			continue
+		}
+		p.removePendingLocked(key)
+		if old, ok := p.index[key]; ok {
+			delete(p.packs[old.Pack].live, key)
+			superseded[old.Pack] = struct{}{}
+		}
+		p.index[key] = entry
+	}
+	p.mu.Unlock()
+
+	p.flushes.Add(1)
+
+	if stale {
+		superseded[packID] = struct{}{}
+	}
+	for id := range superseded {
+		if err := p.maintainPack(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops background flushing and flushes pending objects
+func (p *Packer) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.stopCh)
+		p.wg.Wait()
+		err = p.Flush(context.Background())
+	})
+	return err
+}
+
+// Threshold returns the size below which objects are packed
+func (p *Packer) Threshold() int64 {
+	return p.threshold
+}
+
+// Stats returns packing statistics
+func (p *Packer) Stats() PackStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := PackStats{
+		Packs:          len(p.packs),
+		PackedObjects:  len(p.index),
+		PendingObjects: len(p.pending),
+		PendingBytes:   p.pendingBytes,
+		Flushes:        p.flushes.Load(),
+		Compactions:    p.compactions.Load(),
+	}
+	for _, state := range p.packs {
+		stats.PackedBytes += state.size
+		stats.LiveBytes += state.liveBytes()
+	}
+	return stats
+}
+
+// flushLoop periodically flushes pending objects
+func (p *Packer) flushLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			if err := p.Flush(context.Background()); err != nil {
+				p.logger.Warn("Failed to flush pending objects", "error", err)
+			}
+		}
+	}
+}
+
+// writePack stores contents as a new pack followed by its index, which
+// commits the pack. The pack is registered with all entries live.
+func (p *Packer) writePack(ctx context.Context, contents map[string][]byte, modified map[string]time.Time) (string, map[string]packEntry, error) {
+	keys := make([]string, 0, len(contents))
+	var total int64
+	for key, data := range contents {
+		keys = append(keys, key)
+		total += int64(len(data))
+	}
+	sort.Strings(keys)
+
+	p.mu.Lock()
+	p.seq++
+	packID := fmt.Sprintf("%016x%08x", time.Now().UnixNano(), p.seq)
+	p.mu.Unlock()
+
+	buf := make([]byte, 0, total)
+	entries := make(map[string]packEntry, len(keys))
+	for _, key := range keys {
+		entries[key] = packEntry{
+			Pack:     packID,
+			Offset:   int64(len(buf)),
+			Size:     int64(len(contents[key])),
+			Modified: modified[key],
+		}
+		buf = append(buf, contents[key]...)
+	}
+
+	if err := p.backend.PutObject(ctx, p.dataKey(packID), buf); err != nil {
+		return "", nil, fmt.Errorf("failed to write pack %s: %w", packID, err)
+	}
+	if err := p.putIndex(ctx, packID, int64(len(buf)), entries); err != nil {
+		_ = p.backend.DeleteObject(ctx, p.dataKey(packID))
+		return "", nil, err
+	}
+
+	live := make(map[string]packEntry, len(entries))
+	for key, entry := range entries {
+		live[key] = entry
+	}
+
+	p.mu.Lock()
+	p.packs[packID] = &packState{size: int64(len(buf)), live: live}
+	p.mu.Unlock()
+
+	return packID, entries, nil
+}
+
+// dropPacked removes key from its pack and maintains the pack
+func (p *Packer) dropPacked(ctx context.Context, key string) error {
+	p.flushMu.Lock()
+	defer p.flushMu.Unlock()
+
+	p.mu.Lock()
+	entry, ok := p.index[key]
+	if ok {
+		delete(p.index, key)
+		delete(p.packs[entry.Pack].live, key)
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return p.maintainPack(ctx, entry.Pack)
+}
+
+// maintainPack persists a pack's live entries after removals, deleting the
+// pack once empty and compacting it once live bytes drop below the compact
+// ratio. Callers must hold flushMu.
+func (p *Packer) maintainPack(ctx context.Context, packID string) error {
+	p.mu.RLock()
+	state, ok := p.packs[packID]
+	if !ok {
+		p.mu.RUnlock()
+		return nil
+	}
+	size := state.size
+	liveBytes := state.liveBytes()
+	live := make(map[string]packEntry, len(state.live))
+	for key, entry := range state.live {
+		live[key] = entry
+	}
+	p.mu.RUnlock()
+
+	switch {
+	case len(live) == 0:
+		return p.deletePack(ctx, packID)
+	case float64(liveBytes) < p.config.CompactRatio*float64(size):
+		return p.compactPack(ctx, packID, live)
+	default:
+		return p.putIndex(ctx, packID, size, live)
+	}
+}
+
+// compactPack rewrites the live entries of a pack into a new pack
+func (p *Packer) compactPack(ctx context.Context, packID string, live map[string]packEntry) error {
+	data, err := p.backend.GetObject(ctx, p.dataKey(packID), 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read pack %s for compaction: %w", packID, err)
+	}
+
+	contents := make(map[string][]byte, len(live))
+	modified := make(map[string]time.Time, len(live))
+	for key, entry := range live {
+		if entry.Offset+entry.Size > int64(len(data)) {
+			return fmt.Errorf("pack %s is truncated at entry %s", packID, key)
+		}
+		contents[key] = data[entry.Offset : entry.Offset+entry.Size]
+		modified[key] = entry.Modified
+	}
+
+	newID, entries, err := p.writePack(ctx, contents, modified)
+	if err != nil {
+		return err
+	}
+
+	// Repoint entries that still reference the old pack
+	p.mu.Lock()
+	newState := p.packs[newID]
+	for key, entry := range entries {
+		if current, ok := p.index[key]; ok && current.Pack == packID {
+			p.index[key] = entry
+		} else {
+			delete(newState.live, key)
+		}
+	}
+	p.mu.Unlock()
+
+	p.compactions.Add(1)
+	return p.deletePack(ctx, packID)
+}
+
+// deletePack removes a pack and its index from the backend
+func (p *Packer) deletePack(ctx context.Context, packID string) error {
+	// Delete the index first so a partial failure never leaves dangling entries
+	if err := p.backend.DeleteObject(ctx, p.indexKey(packID)); err != nil && !isObjectNotFound(err) {
+		return fmt.Errorf("failed to delete pack index %s: %w", packID, err)
+	}
+	if err := p.backend.DeleteObject(ctx, p.dataKey(packID)); err != nil && !isObjectNotFound(err) {
+		return fmt.Errorf("failed to delete pack %s: %w", packID, err)
+	}
+
+	p.mu.Lock()
+	delete(p.packs, packID)
+	p.mu.Unlock()
+	return nil
+}
+
+// putIndex persists the index for a pack
+func (p *Packer) putIndex(ctx context.Context, packID string, size int64, entries map[string]packEntry) error {
+	data, err := json.Marshal(packIndex{Pack: packID, Size: size, Entries: entries})
+	if err != nil {
+		return fmt.Errorf("failed to encode pack index %s: %w", packID, err)
+	}
+	if err := p.backend.PutObject(ctx, p.indexKey(packID), data); err != nil {
+		return fmt.Errorf("failed to write pack index %s: %w", packID, err)
+	}
+	return nil
+}
+
+// removePendingLocked drops key from the pending set. Callers must hold mu.
+func (p *Packer) removePendingLocked(key string) bool {
+	obj, ok := p.pending[key]
+	if ok {
+		p.pendingBytes -= int64(len(obj.data))
+		delete(p.pending, key)
+	}
+	return ok
+}
+
+func (p *Packer) packedInfo(key string, entry packEntry) *types.ObjectInfo {
+	return &types.ObjectInfo{
+		Key:          key,
+		Size:         entry.Size,
+		LastModified: entry.Modified,
+		Metadata:     map[string]string{"pack": entry.Pack},
+	}
+}
+
+func (p *Packer) dataKey(packID string) string {
+	return p.config.Prefix + packID + packDataSuffix
+}
+
+func (p *Packer) indexKey(packID string) string {
+	return p.config.Prefix + packID + packIndexSuffix
+}
+
+// packRange clamps a read of size bytes at offset to an object of length n.
+// A non-positive size reads to the end.
+func packRange(n, offset, size int64) (int64, int64) {
+	lo := min(max(offset, 0), n)
+	hi := n
+	if size > 0 {
+		hi = min(lo+size, n)
+	}
+	return lo, hi
+}
+
+// isObjectNotFound reports whether err means the object does not exist
+func isObjectNotFound(err error) bool {
+	var objErr *errors.ObjectFSError
+	return stderr.As(err, &objErr) && objErr.Code == errors.ErrCodeObjectNotFound
+}
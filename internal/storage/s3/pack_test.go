@@ -0,0 +1,323 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+	"github.com/objectfs/objectfs/pkg/types"
+)
+
+// memBackend is an in-memory types.Backend recording range reads
+type memBackend struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	reads   []string
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{objects: make(map[string][]byte)}
+}
+
+func (m *memBackend) GetObject(ctx context.Context, key string, offset, size int64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads = append(m.reads, key)
+
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	end := int64(len(data))
+	if size > 0 && offset+size < end {
+		end = offset + size
+	}
+	return append([]byte(nil), data[offset:end]...), nil
+}
+
+func (m *memBackend) PutObject(ctx context.Context, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *memBackend) DeleteObject(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[key]; !ok {
+		return errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memBackend) HeadObject(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeObjectNotFound, "object not found")
+	}
+	return &types.ObjectInfo{Key: key, Size: int64(len(data))}, nil
+}
+
+func (m *memBackend) GetObjects(ctx context.Context, keys []string) (map[string][]byte, error) {
+	results := make(map[string][]byte)
+	for _, key := range keys {
+		if data, err := m.GetObject(ctx, key, 0, 0); err == nil {
+			results[key] = data
+		}
+	}
+	return results, nil
+}
+
+func (m *memBackend) PutObjects(ctx context.Context, objects map[string][]byte) error {
+	for key, data := range objects {
+		_ = m.PutObject(ctx, key, data)
+	}
+	return nil
+}
+
+func (m *memBackend) ListObjects(ctx context.Context, prefix string, limit int) ([]types.ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var objects []types.ObjectInfo
+	for key, data := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, types.ObjectInfo{Key: key, Size: int64(len(data))})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (m *memBackend) HealthCheck(ctx context.Context) error { return nil }
+
+// packObjects returns the keys of stored pack data objects
+func (m *memBackend) packObjects() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var packs []string
+	for key := range m.objects {
+		if strings.HasSuffix(key, packDataSuffix) {
+			packs = append(packs, key)
+		}
+	}
+	sort.Strings(packs)
+	return packs
+}
+
+func newTestPacker(t *testing.T, backend *memBackend) *Packer {
+	t.Helper()
+
+	packer, err := NewPacker(backend, &PackConfig{
+		Enabled:      true,
+		Threshold:    16,
+		MaxPackSize:  1024,
+		Prefix:       ".packs/",
+		CompactRatio: 0.5,
+	}, TierStandardIA)
+	if err != nil {
+		t.Fatalf("NewPacker() error = %v", err)
+	}
+	t.Cleanup(func() { _ = packer.Close() })
+	return packer
+}
+
+func TestPacker_WritePackRead(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemBackend()
+	packer := newTestPacker(t, backend)
+
+	small := map[string]string{
+		"logs/a.txt": "alpha",
+		"logs/b.txt": "bravo!",
+		"logs/c.txt": "charlie",
+	}
+	for key, data := range small {
+		if err := packer.PutObject(ctx, key, []byte(data)); err != nil {
+			t.Fatalf("PutObject(%s) error = %v", key, err)
+		}
+	}
+	large := bytes.Repeat([]byte("x"), 32)
+	if err := packer.PutObject(ctx, "logs/large.bin", large); err != nil {
+		t.Fatalf("PutObject(large) error = %v", err)
+	}
+
+	// Pending objects are readable before the flush
+	if data, err := packer.GetObject(ctx, "logs/a.txt", 0, 0); err != nil || string(data) != "alpha" {
+		t.Errorf("GetObject(pending) = %q, %v", data, err)
+	}
+
+	if err := packer.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if packs := backend.packObjects(); len(packs) != 1 {
+		t.Fatalf("expected one pack, got %v", packs)
+	}
+	if _, ok := backend.objects["logs/a.txt"]; ok {
+		t.Error("small object stored directly instead of packed")
+	}
+	if !bytes.Equal(backend.objects["logs/large.bin"], large) {
+		t.Error("large object not stored directly")
+	}
+
+	for key, want := range small {
+		data, err := packer.GetObject(ctx, key, 0, 0)
+		if err != nil || string(data) != want {
+			t.Errorf("GetObject(%s) = %q, %v, want %q", key, data, err, want)
+		}
+	}
+	if data, _ := packer.GetObject(ctx, "logs/c.txt", 2, 3); string(data) != "arl" {
+		t.Errorf("GetObject(c, 2, 3) = %q, want %q", data, "arl")
+	}
+	if info, err := packer.HeadObject(ctx, "logs/b.txt"); err != nil || info.Size != 6 {
+		t.Errorf("HeadObject(b) = %+v, %v", info, err)
+	}
+
+	objects, err := packer.ListObjects(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	want := []string{"logs/a.txt", "logs/b.txt", "logs/c.txt", "logs/large.bin"}
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("ListObjects() = %v, want %v", keys, want)
+	}
+
+	// A fresh packer recovers packed entries from the stored indexes
+	reloaded := newTestPacker(t, backend)
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if data, err := reloaded.GetObject(ctx, "logs/b.txt", 0, 0); err != nil || string(data) != "bravo!" {
+		t.Errorf("GetObject after Load = %q, %v", data, err)
+	}
+}
+
+func TestPacker_DeleteCompactsPack(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemBackend()
+	packer := newTestPacker(t, backend)
+
+	for i := 0; i < 4; i++ {
+		key := fmt.Sprintf("k%d", i)
+		if err := packer.PutObject(ctx, key, bytes.Repeat([]byte{byte('a' + i)}, 10)); err != nil {
+			t.Fatalf("PutObject(%s) error = %v", key, err)
+		}
+	}
+	if err := packer.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	original := backend.packObjects()
+
+	// Dropping to half live leaves the pack in place with an updated index
+	if err := packer.DeleteObject(ctx, "k0"); err != nil {
+		t.Fatalf("DeleteObject(k0) error = %v", err)
+	}
+	if err := packer.DeleteObject(ctx, "k1"); err != nil {
+		t.Fatalf("DeleteObject(k1) error = %v", err)
+	}
+	if packs := backend.packObjects(); fmt.Sprint(packs) != fmt.Sprint(original) {
+		t.Fatalf("pack rewritten before reaching compact ratio: %v", packs)
+	}
+
+	// Dropping below half live rewrites the remaining entry into a new pack
+	if err := packer.DeleteObject(ctx, "k2"); err != nil {
+		t.Fatalf("DeleteObject(k2) error = %v", err)
+	}
+	packs := backend.packObjects()
+	if len(packs) != 1 || packs[0] == original[0] {
+		t.Fatalf("expected compaction into a new pack, got %v", packs)
+	}
+	if got := len(backend.objects[packs[0]]); got != 10 {
+		t.Errorf("compacted pack size = %d, want 10", got)
+	}
+
+	stats := packer.Stats()
+	if stats.Compactions != 1 || stats.PackedObjects != 1 || stats.LiveBytes != 10 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	if data, err := packer.GetObject(ctx, "k3", 0, 0); err != nil || string(data) != "dddddddddd" {
+		t.Errorf("GetObject(k3) after compaction = %q, %v", data, err)
+	}
+	if _, err := packer.GetObject(ctx, "k1", 0, 0); err == nil {
+		t.Error("GetObject(k1) should fail after deletion")
+	}
+
+	// Deletions and compaction survive a reload
+	reloaded := newTestPacker(t, backend)
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if objects, _ := reloaded.ListObjects(ctx, "", 0); len(objects) != 1 || objects[0].Key != "k3" {
+		t.Errorf("ListObjects after reload = %+v", objects)
+	}
+
+	// Deleting the last entry removes the pack entirely
+	if err := packer.DeleteObject(ctx, "k3"); err != nil {
+		t.Fatalf("DeleteObject(k3) error = %v", err)
+	}
+	if len(backend.objects) != 0 {
+		t.Errorf("backend not empty after deleting all entries: %v", backend.objects)
+	}
+}
+
+func TestPacker_OverwriteSupersedesPackedEntry(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemBackend()
+	packer := newTestPacker(t, backend)
+
+	_ = packer.PutObject(ctx, "k", []byte("old"))
+	_ = packer.PutObject(ctx, "other", []byte("keep"))
+	if err := packer.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// Growing past the threshold moves the object out of the pack
+	large := bytes.Repeat([]byte("n"), 20)
+	if err := packer.PutObject(ctx, "k", large); err != nil {
+		t.Fatalf("PutObject(large) error = %v", err)
+	}
+	if data, _ := packer.GetObject(ctx, "k", 0, 0); !bytes.Equal(data, large) {
+		t.Errorf("GetObject(k) = %q, want direct object", data)
+	}
+
+	reloaded := newTestPacker(t, backend)
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if data, _ := reloaded.GetObject(ctx, "k", 0, 0); !bytes.Equal(data, large) {
+		t.Errorf("stale packed entry shadowed direct object after reload: %q", data)
+	}
+}
+
+func TestNewPacker_TierThreshold(t *testing.T) {
+	backend := newMemBackend()
+
+	packer, err := NewPacker(backend, &PackConfig{MaxPackSize: 1024 * 1024}, TierGlacier)
+	if err != nil {
+		t.Fatalf("NewPacker() error = %v", err)
+	}
+	defer func() { _ = packer.Close() }()
+	if packer.Threshold() != 40*1024 {
+		t.Errorf("Threshold() = %d, want Glacier minimum %d", packer.Threshold(), 40*1024)
+	}
+
+	if _, err := NewPacker(backend, &PackConfig{}, TierStandard); err == nil {
+		t.Error("NewPacker should fail for a tier without a minimum and no threshold")
+	}
+}
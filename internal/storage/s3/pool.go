@@ -2,6 +2,7 @@ package s3
 
 import (
 	"context"
+	stderr "errors"
 	"fmt"
 	"sync"
 	"time"
@@ -9,6 +10,44 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
+// Pool health defaults
+const (
+	DefaultPoolFailureThreshold   = 3
+	DefaultPoolValidationInterval = 30 * time.Second
+	DefaultPoolValidationTimeout  = 5 * time.Second
+)
+
+// PoolHealthConfig controls how pooled clients are checked and replaced.
+// A client whose requests fail to reach S3 FailureThreshold times in a row
+// is replaced with a new one, with its own connections, when it is
+// returned. Idle clients are validated every ValidationInterval and
+// replaced when validation cannot reach S3.
+type PoolHealthConfig struct {
+	FailureThreshold   int           `yaml:"failure_threshold"`   // Default 3
+	ValidationInterval time.Duration `yaml:"validation_interval"` // Default 30s
+	ValidationTimeout  time.Duration `yaml:"validation_timeout"`  // Default 5s
+}
+
+// withDefaults returns the config with unset values filled in
+func (c PoolHealthConfig) withDefaults() PoolHealthConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = DefaultPoolFailureThreshold
+	}
+	if c.ValidationInterval <= 0 {
+		c.ValidationInterval = DefaultPoolValidationInterval
+	}
+	if c.ValidationTimeout <= 0 {
+		c.ValidationTimeout = DefaultPoolValidationTimeout
+	}
+	return c
+}
+
+// clientHealth tracks the outcomes of one pooled client's requests
+type clientHealth struct {
+	failures    int // Consecutive connection failures
+	lastSuccess time.Time
+}
+
 // ConnectionPool manages a pool of S3 client connections
 type ConnectionPool struct {
 	mu          sync.RWMutex
@@ -20,6 +59,9 @@ type ConnectionPool struct {
 
 	// Health checking
 	healthCheck *HealthChecker
+	health      PoolHealthConfig
+	validate    func(ctx context.Context, client *s3.Client) error
+	clients     map[*s3.Client]*clientHealth
 
 	// Statistics
 	stats PoolStats
@@ -40,6 +82,13 @@ type PoolStats struct {
 	LastCreated time.Time `json:"last_created"`
 	LastError   string    `json:"last_error"`
 	LastErrorAt time.Time `json:"last_error_at"`
+
+	// Client health
+	Healthy            int   `json:"healthy"`
+	Unhealthy          int   `json:"unhealthy"` // In use, replaced when returned
+	Replaced           int64 `json:"replaced"`
+	Validations        int64 `json:"validations"`
+	ValidationFailures int64 `json:"validation_failures"`
 }
 
 // HealthChecker monitors connection health
@@ -51,8 +100,10 @@ type HealthChecker struct {
 	stopped  chan struct{}
 }
 
-// NewConnectionPool creates a new connection pool
-func NewConnectionPool(maxSize int, factory func() (*s3.Client, error)) (*ConnectionPool, error) {
+// NewConnectionPool creates a new connection pool. validate sends a cheap
+// request through an idle client to check it can reach S3; when nil, idle
+// clients are not validated.
+func NewConnectionPool(maxSize int, factory func() (*s3.Client, error), health PoolHealthConfig, validate func(ctx context.Context, client *s3.Client) error) (*ConnectionPool, error) {
 	if maxSize <= 0 {
 		maxSize = 8 // Default pool size
 	}
@@ -65,6 +116,9 @@ func NewConnectionPool(maxSize int, factory func() (*s3.Client, error)) (*Connec
 		connections: make(chan *s3.Client, maxSize),
 		factory:     factory,
 		maxSize:     maxSize,
+		health:      health.withDefaults(),
+		validate:    validate,
+		clients:     make(map[*s3.Client]*clientHealth),
 		stats: PoolStats{
 			MaxSize: maxSize,
 		},
@@ -73,8 +127,8 @@ func NewConnectionPool(maxSize int, factory func() (*s3.Client, error)) (*Connec
 	// Initialize health checker
 	pool.healthCheck = &HealthChecker{
 		pool:     pool,
-		interval: 30 * time.Second,
-		timeout:  5 * time.Second,
+		interval: pool.health.ValidationInterval,
+		timeout:  pool.health.ValidationTimeout,
 		stopCh:   make(chan struct{}),
 		stopped:  make(chan struct{}),
 	}
@@ -143,18 +197,30 @@ func (p *ConnectionPool) GetWithTimeout(timeout time.Duration) *s3.Client {
 	}
 }
 
-// Put returns a connection to the pool
+// Put returns a connection to the pool. An unhealthy connection is
+// discarded and a new one added in its place.
 func (p *ConnectionPool) Put(conn *s3.Client) {
 	if conn == nil {
 		return
 	}
 
-	p.mu.RLock()
+	p.mu.Lock()
 	if p.closed {
-		p.mu.RUnlock()
+		p.mu.Unlock()
 		return
 	}
-	p.mu.RUnlock()
+	if health := p.clients[conn]; health != nil && health.failures >= p.health.FailureThreshold {
+		delete(p.clients, conn)
+		p.currentSize--
+		p.stats.Active--
+		p.stats.Destroyed++
+		p.mu.Unlock()
+
+		closeIdleConnections(conn)
+		p.replace()
+		return
+	}
+	p.mu.Unlock()
 
 	select {
 	case p.connections <- conn:
@@ -164,12 +230,98 @@ func (p *ConnectionPool) Put(conn *s3.Client) {
 	default:
 		// Pool is full, discard the connection
 		p.mu.Lock()
+		delete(p.clients, conn)
 		p.stats.Destroyed++
 		p.currentSize--
 		p.mu.Unlock()
 	}
 }
 
+// Report records the outcome of a request conn made. Failures to get a
+// response from S3 count toward replacing conn; any response resets them.
+func (p *ConnectionPool) Report(conn *s3.Client, err error) {
+	if conn == nil || stderr.Is(err, context.Canceled) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	health := p.clients[conn]
+	if health == nil {
+		health = &clientHealth{}
+		p.clients[conn] = health
+	}
+	if isConnectionFailure(err) {
+		health.failures++
+		p.stats.LastError = err.Error()
+		p.stats.LastErrorAt = time.Now()
+	} else {
+		health.failures = 0
+		health.lastSuccess = time.Now()
+	}
+}
+
+// replace adds a new connection in place of a discarded one
+func (p *ConnectionPool) replace() {
+	if !p.canCreateConnection() {
+		return
+	}
+	conn, err := p.createConnection()
+	if err != nil {
+		p.mu.Lock()
+		p.stats.Errors++
+		p.stats.LastError = err.Error()
+		p.stats.LastErrorAt = time.Now()
+		p.mu.Unlock()
+		return
+	}
+
+	p.mu.Lock()
+	p.stats.Replaced++
+	p.mu.Unlock()
+	p.Put(conn)
+}
+
+// takeIdle removes an idle connection from the pool, or returns nil when
+// there is none
+func (p *ConnectionPool) takeIdle() *s3.Client {
+	select {
+	case conn, ok := <-p.connections:
+		if !ok {
+			return nil
+		}
+		p.mu.Lock()
+		p.stats.Active++
+		p.mu.Unlock()
+		return conn
+	default:
+		return nil
+	}
+}
+
+// isConnectionFailure reports whether err means a request got no response
+// from S3, such as a refused or reset connection, a timeout, or a 5xx from
+// a proxy in front of it. An error response from S3, like a missing key,
+// shows the connection works. Canceled requests say nothing either way.
+func isConnectionFailure(err error) bool {
+	if err == nil || stderr.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr interface{ HTTPStatusCode() int }
+	if stderr.As(err, &statusErr) {
+		return statusErr.HTTPStatusCode() >= 500
+	}
+	return true
+}
+
+// closeIdleConnections closes the idle connections of a discarded client's
+// HTTP client, which would otherwise stay open until they time out
+func closeIdleConnections(conn *s3.Client) {
+	if closer, ok := conn.Options().HTTPClient.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
 // Stats returns current pool statistics
 func (p *ConnectionPool) Stats() PoolStats {
 	p.mu.RLock()
@@ -178,6 +330,12 @@ func (p *ConnectionPool) Stats() PoolStats {
 	stats := p.stats
 	stats.Total = p.currentSize
 	stats.Idle = len(p.connections)
+	for _, health := range p.clients {
+		if health.failures >= p.health.FailureThreshold {
+			stats.Unhealthy++
+		}
+	}
+	stats.Healthy = max(stats.Total-stats.Unhealthy, 0)
 
 	return stats
 }
@@ -228,7 +386,8 @@ func (p *ConnectionPool) Resize(newSize int) error {
 	drainLoop:
 		for i := 0; i < excess; i++ {
 			select {
-			case <-p.connections:
+			case conn := <-p.connections:
+				delete(p.clients, conn)
 				p.currentSize--
 				p.stats.Destroyed++
 			default:
@@ -315,49 +474,59 @@ func (hc *HealthChecker) run() {
 	}
 }
 
+// checkHealth validates idle connections that have not succeeded within
+// the last interval, replacing those that cannot reach S3
 func (hc *HealthChecker) checkHealth() {
-	// Get a sample of connections to test
-	testCount := 3
-	if hc.pool.Stats().Idle < testCount {
-		testCount = hc.pool.Stats().Idle
+	pool := hc.pool
+	if pool.validate == nil {
+		return
 	}
 
-	var unhealthyCount int
-	for i := 0; i < testCount; i++ {
-		conn := hc.pool.GetWithTimeout(1 * time.Second)
+	idle := pool.Stats().Idle
+	for i := 0; i < idle; i++ {
+		conn := pool.takeIdle()
 		if conn == nil {
-			continue
+			return
 		}
 
-		healthy := hc.testConnection(conn)
-		if !healthy {
-			unhealthyCount++
-			// Don't put unhealthy connection back
-			hc.pool.mu.Lock()
-			hc.pool.currentSize--
-			hc.pool.stats.Destroyed++
-			hc.pool.mu.Unlock()
-		} else {
-			hc.pool.Put(conn)
+		pool.mu.RLock()
+		health := pool.clients[conn]
+		recent := health != nil && time.Since(health.lastSuccess) < hc.interval
+		pool.mu.RUnlock()
+		if !recent {
+			hc.testConnection(conn)
 		}
-	}
-
-	// If too many connections are unhealthy, we might want to recreate some
-	if unhealthyCount > testCount/2 {
-		// Log warning or trigger pool recreation
-		hc.pool.mu.Lock()
-		hc.pool.stats.LastError = fmt.Sprintf("Found %d unhealthy connections", unhealthyCount)
-		hc.pool.stats.LastErrorAt = time.Now()
-		hc.pool.mu.Unlock()
+		pool.Put(conn)
 	}
 }
 
-func (hc *HealthChecker) testConnection(conn *s3.Client) bool {
+// testConnection validates conn, marking it unhealthy when it cannot
+// reach S3 so Put replaces it
+func (hc *HealthChecker) testConnection(conn *s3.Client) {
 	ctx, cancel := context.WithTimeout(context.Background(), hc.timeout)
 	defer cancel()
+	err := hc.pool.validate(ctx, conn)
+
+	pool := hc.pool
+	pool.mu.Lock()
+	pool.stats.Validations++
+	pool.mu.Unlock()
+	if !isConnectionFailure(err) {
+		pool.Report(conn, nil)
+		return
+	}
 
-	// Simple health check - list buckets (requires minimal permissions)
-	// In a real implementation, you might want a more specific health check
-	_, err := conn.ListBuckets(ctx, &s3.ListBucketsInput{})
-	return err == nil
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.stats.ValidationFailures++
+	pool.stats.LastError = fmt.Sprintf("idle connection failed validation: %v", err)
+	pool.stats.LastErrorAt = time.Now()
+	health := pool.clients[conn]
+	if health == nil {
+		health = &clientHealth{}
+		pool.clients[conn] = health
+	}
+	// Nothing else is using an idle connection, so one failed validation
+	// is enough to replace it
+	health.failures = max(health.failures, pool.health.FailureThreshold)
 }
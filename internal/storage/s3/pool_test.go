@@ -0,0 +1,102 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func newTestPool(t *testing.T, size int, validate func(ctx context.Context, client *s3.Client) error) *ConnectionPool {
+	t.Helper()
+	pool, err := NewConnectionPool(size, func() (*s3.Client, error) {
+		return s3.New(s3.Options{Region: "us-east-1"}), nil
+	}, PoolHealthConfig{FailureThreshold: 3}, validate)
+	if err != nil {
+		t.Fatalf("NewConnectionPool() error = %v", err)
+	}
+	t.Cleanup(func() { _ = pool.Close() })
+	return pool
+}
+
+func TestPoolReplacesFailingClient(t *testing.T) {
+	pool := newTestPool(t, 2, nil)
+
+	failing := pool.Get()
+	if failing == nil {
+		t.Fatal("Get() returned nil")
+	}
+	for i := 0; i < 2; i++ {
+		pool.Report(failing, stderr.New("read: connection reset by peer"))
+	}
+	pool.Report(failing, &statusError{code: http.StatusNotFound})
+	for i := 0; i < 2; i++ {
+		pool.Report(failing, stderr.New("read: connection reset by peer"))
+	}
+	if stats := pool.Stats(); stats.Unhealthy != 0 {
+		t.Fatalf("a response from S3 should reset failures, got %d unhealthy", stats.Unhealthy)
+	}
+
+	pool.Report(failing, &statusError{code: http.StatusBadGateway})
+	if stats := pool.Stats(); stats.Unhealthy != 1 {
+		t.Fatalf("Unhealthy = %d after %d consecutive failures, want 1", stats.Unhealthy, 3)
+	}
+	pool.Put(failing)
+
+	stats := pool.Stats()
+	if stats.Replaced != 1 || stats.Unhealthy != 0 || stats.Healthy != 1 || stats.Idle != 1 {
+		t.Fatalf("unexpected stats after returning a failing client: %+v", stats)
+	}
+	if replacement := pool.Get(); replacement == failing {
+		t.Fatal("the failing client is still pooled")
+	}
+}
+
+func TestPoolIgnoresCanceledRequests(t *testing.T) {
+	pool := newTestPool(t, 1, nil)
+
+	client := pool.Get()
+	for i := 0; i < 5; i++ {
+		pool.Report(client, context.Canceled)
+	}
+	pool.Put(client)
+
+	if stats := pool.Stats(); stats.Replaced != 0 {
+		t.Fatalf("canceled requests should not replace a client, got %+v", stats)
+	}
+}
+
+func TestPoolValidationReplacesIdleClient(t *testing.T) {
+	var mu sync.Mutex
+	var broken *s3.Client
+	pool := newTestPool(t, 2, func(ctx context.Context, client *s3.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if client == broken {
+			return stderr.New("dial tcp: i/o timeout")
+		}
+		return nil
+	})
+
+	first, second := pool.Get(), pool.Get()
+	mu.Lock()
+	broken = first
+	mu.Unlock()
+	pool.Put(first)
+	pool.Put(second)
+
+	pool.healthCheck.checkHealth()
+
+	stats := pool.Stats()
+	if stats.Validations != 2 || stats.ValidationFailures != 1 || stats.Replaced != 1 {
+		t.Fatalf("unexpected stats after validation: %+v", stats)
+	}
+	for i := 0; i < 2; i++ {
+		if client := pool.Get(); client == first {
+			t.Fatal("the client that failed validation is still pooled")
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// PresignOp identifies an operation a presigned URL is issued for
+type PresignOp string
+
+// Operations a URLProvider may support
+const (
+	PresignGet    PresignOp = "GET"
+	PresignPut    PresignOp = "PUT"
+	PresignHead   PresignOp = "HEAD"
+	PresignDelete PresignOp = "DELETE"
+	PresignList   PresignOp = "LIST" // ListObjectsV2 of the bucket; key is the prefix
+)
+
+// PresignedRequest is a presigned URL and the headers its signature covers
+type PresignedRequest struct {
+	URL     string
+	Header  http.Header // Sent unchanged with the request
+	Expires time.Time   // Zero when the provider does not say
+}
+
+// URLProvider issues short-lived presigned URLs, typically from a control
+// plane, so that data-plane nodes never hold long-lived credentials
+type URLProvider interface {
+	// Supports reports whether the provider issues URLs for op
+	Supports(op PresignOp) bool
+
+	// PresignURL returns a fresh URL for op on key
+	PresignURL(ctx context.Context, op PresignOp, key string) (*PresignedRequest, error)
+}
+
+// presignedClient performs object operations as plain HTTP requests to
+// URLs from a URLProvider
+type presignedClient struct {
+	provider   URLProvider
+	httpClient *http.Client
+
+	// URLs re-requested after being rejected as expired
+	refreshes atomic.Int64
+}
+
+func newPresignedClient(provider URLProvider, timeout time.Duration) *presignedClient {
+	return &presignedClient{
+		provider:   provider,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// do sends op on key to a presigned URL. A URL that is already expired or
+// rejected with 403, the status S3 gives expired signatures, is replaced by
+// a fresh one once.
+func (c *presignedClient) do(ctx context.Context, op PresignOp, key string, body []byte, header http.Header) (*http.Response, error) {
+	method := string(op)
+	if op == PresignList {
+		method = http.MethodGet
+	}
+
+	for attempt := 0; ; attempt++ {
+		presigned, err := c.provider.PresignURL(ctx, op, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain presigned %s URL: %w", op, err)
+		}
+		if attempt == 0 && !presigned.Expires.IsZero() && !time.Now().Before(presigned.Expires) {
+			c.refreshes.Add(1)
+			continue
+		}
+
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, presigned.URL, reader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid presigned %s URL: %w", op, err)
+		}
+		for name, values := range presigned.Header {
+			req.Header[name] = values
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusForbidden && attempt == 0 {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			c.refreshes.Add(1)
+			continue
+		}
+		if resp.StatusCode/100 != 2 {
+			defer func() { _ = resp.Body.Close() }()
+			return nil, presignStatusError(op, key, resp)
+		}
+		return resp, nil
+	}
+}
+
+// presignStatusError converts a failed response to the error the SDK
+// would return, so translateError and callers treat both modes alike
+func presignStatusError(op PresignOp, key string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		switch op {
+		case PresignGet:
+			return &s3types.NoSuchKey{Message: aws.String(key)}
+		case PresignHead, PresignDelete:
+			return &s3types.NotFound{Message: aws.String(key)}
+		}
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("presigned %s %s: %s: %s", op, key, resp.Status, strings.TrimSpace(string(detail)))
+}
+
+// get reads key, or the byte range given as a Range header value
+func (c *presignedClient) get(ctx context.Context, key, byteRange string, progress *transfer) ([]byte, error) {
+	var header http.Header
+	if byteRange != "" {
+		header = http.Header{"Range": {byteRange}}
+	}
+	resp, err := c.do(ctx, PresignGet, key, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.ContentLength >= 0 {
+		progress.setTotal(resp.ContentLength)
+	}
+	body, err := io.ReadAll(&progressReader{r: resp.Body, transfer: progress})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object body: %w", err)
+	}
+	return body, nil
+}
+
+// put stores data at key
+func (c *presignedClient) put(ctx context.Context, key string, data []byte) error {
+	resp, err := c.do(ctx, PresignPut, key, data, nil)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// delete removes key
+func (c *presignedClient) delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, PresignDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// head returns the metadata of key in the form of an SDK response
+func (c *presignedClient) head(ctx context.Context, key string) (*s3.HeadObjectOutput, error) {
+	resp, err := c.do(ctx, PresignHead, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	output := &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(resp.ContentLength),
+		ETag:          aws.String(resp.Header.Get("ETag")),
+		ContentType:   aws.String(resp.Header.Get("Content-Type")),
+		StorageClass:  s3types.StorageClass(resp.Header.Get("X-Amz-Storage-Class")),
+		Metadata:      make(map[string]string),
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		output.LastModified = aws.Time(modified)
+	}
+	if version := resp.Header.Get("X-Amz-Version-Id"); version != "" {
+		output.VersionId = aws.String(version)
+	}
+	for name, values := range resp.Header {
+		if meta, ok := strings.CutPrefix(strings.ToLower(name), "x-amz-meta-"); ok && len(values) > 0 {
+			output.Metadata[meta] = values[0]
+		}
+	}
+	return output, nil
+}
+
+// listBucketResult is the ListObjectsV2 response body
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+		StorageClass string    `xml:"StorageClass"`
+	} `xml:"Contents"`
+}
+
+// list returns the objects under prefix in the form of an SDK response.
+// The page size is fixed by the URL's signed query, so at most limit
+// objects are kept when limit is positive.
+func (c *presignedClient) list(ctx context.Context, prefix string, limit int) (*s3.ListObjectsV2Output, error) {
+	resp, err := c.do(ctx, PresignList, prefix, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result listBucketResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode listing: %w", err)
+	}
+	if limit > 0 && len(result.Contents) > limit {
+		result.Contents = result.Contents[:limit]
+	}
+
+	output := &s3.ListObjectsV2Output{Contents: make([]s3types.Object, 0, len(result.Contents))}
+	for _, obj := range result.Contents {
+		output.Contents = append(output.Contents, s3types.Object{
+			Key:          aws.String(obj.Key),
+			LastModified: aws.Time(obj.LastModified),
+			ETag:         aws.String(obj.ETag),
+			Size:         aws.Int64(obj.Size),
+			StorageClass: s3types.ObjectStorageClass(obj.StorageClass),
+		})
+	}
+	return output, nil
+}
+
+// getPresigned reads key through a presigned URL, recording the outcome
+// like SDK reads
+func (b *Backend) getPresigned(ctx context.Context, key, byteRange string, progress *transfer) ([]byte, error) {
+	body, err := b.presigned.get(ctx, key, byteRange, progress)
+	if err != nil {
+		if lostHedge(ctx) {
+			return nil, err
+		}
+		b.metricsCollector.RecordError(err)
+		translatedErr := b.translateError(err, "GetObject", key)
+		b.healthTracker.RecordError("s3-reads", translatedErr)
+		return nil, translatedErr
+	}
+
+	b.metricsCollector.RecordBytesDownloaded(int64(len(body)))
+	b.healthTracker.RecordSuccess("s3-reads")
+	return body, nil
+}
+
+// putPresigned stores data at key through a presigned URL, recording the
+// outcome like SDK writes
+func (b *Backend) putPresigned(ctx context.Context, key string, data []byte) error {
+	if err := b.presigned.put(ctx, key, data); err != nil {
+		b.metricsCollector.RecordError(err)
+		translatedErr := b.translateError(err, "PutObject", key)
+		b.healthTracker.RecordError("s3-writes", translatedErr)
+		return translatedErr
+	}
+
+	b.metricsCollector.RecordBytesUploaded(int64(len(data)))
+	b.healthTracker.RecordSuccess("s3-writes")
+	return nil
+}
+
+// checkPresigned fails operation up front when the backend is in
+// presigned mode and the provider issues no URLs for op
+func (b *Backend) checkPresigned(op PresignOp, operation, key string) error {
+	if b.presigned == nil || b.presigned.provider.Supports(op) {
+		return nil
+	}
+	return errors.NewError(errors.ErrCodeOperationFailed, "operation not supported by the presigned URL provider").
+		WithComponent("s3-backend").
+		WithOperation(operation).
+		WithContext("bucket", b.bucket).
+		WithContext("key", key)
+}
+
+// checkSigned fails operation up front when the backend is in presigned
+// mode, for operations no presigned URL covers
+func (b *Backend) checkSigned(operation, key string) error {
+	if b.presigned == nil {
+		return nil
+	}
+	return errors.NewError(errors.ErrCodeOperationFailed, "operation not supported with presigned URLs").
+		WithComponent("s3-backend").
+		WithOperation(operation).
+		WithContext("bucket", b.bucket).
+		WithContext("key", key)
+}
+
+// Presigned reports whether the backend sends its operations to presigned
+// URLs instead of signing them
+func (b *Backend) Presigned() bool {
+	return b.presigned != nil
+}
+
+// PresignedRefreshes returns how many presigned URLs were replaced after
+// expiring, or 0 when the backend is not in presigned mode
+func (b *Backend) PresignedRefreshes() int64 {
+	if b.presigned == nil {
+		return 0
+	}
+	return b.presigned.refreshes.Load()
+}
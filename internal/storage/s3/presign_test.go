@@ -0,0 +1,233 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// presignServer is an object store that only accepts requests carrying a
+// token minted by its URL provider
+type presignServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	tokens  map[string]bool
+	next    int
+	expired int // URLs still to be issued with an expired token
+	calls   int
+	ops     map[PresignOp]bool
+}
+
+func newPresignServer(t *testing.T, ops ...PresignOp) *presignServer {
+	s := &presignServer{
+		objects: make(map[string][]byte),
+		tokens:  make(map[string]bool),
+		ops:     make(map[PresignOp]bool),
+	}
+	for _, op := range ops {
+		s.ops[op] = true
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *presignServer) Supports(op PresignOp) bool { return s.ops[op] }
+
+func (s *presignServer) PresignURL(ctx context.Context, op PresignOp, key string) (*PresignedRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	s.next++
+	token := strconv.Itoa(s.next)
+	if s.expired > 0 {
+		s.expired--
+	} else {
+		s.tokens[token] = true
+	}
+	return &PresignedRequest{URL: fmt.Sprintf("%s/%s?op=%s&token=%s", s.URL, key, op, token)}, nil
+}
+
+func (s *presignServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.tokens[r.URL.Query().Get("token")] {
+		http.Error(w, "<Error><Code>AccessDenied</Code><Message>Request has expired</Message></Error>", http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if r.URL.Query().Get("op") == string(PresignList) {
+		var keys []string
+		for k := range s.objects {
+			if strings.HasPrefix(k, key) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprint(w, "<ListBucketResult>")
+		for _, k := range keys {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key><Size>%d</Size></Contents>", k, len(s.objects[k]))
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		s.objects[key] = data
+	case http.MethodGet, http.MethodHead:
+		data, ok := s.objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		http.ServeContent(w, r, key, time.Time{}, strings.NewReader(string(data)))
+	}
+}
+
+func (s *presignServer) providerCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func newPresignedBackend(provider URLProvider) *Backend {
+	backend := newFaultBackend(nil)
+	backend.costOptimizer = NewCostOptimizer(backend, backend.config.CostOptimization, backend.logger)
+	backend.presigned = newPresignedClient(provider, 5*time.Second)
+	return backend
+}
+
+func TestPresignedGetPut(t *testing.T) {
+	server := newPresignServer(t, PresignGet, PresignPut, PresignHead)
+	backend := newPresignedBackend(server)
+	ctx := context.Background()
+
+	if err := backend.PutObject(ctx, "docs/a.txt", []byte("hello presigned")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+
+	data, err := backend.GetObject(ctx, "docs/a.txt", 0, 0)
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	if string(data) != "hello presigned" {
+		t.Errorf("GetObject() = %q, want %q", data, "hello presigned")
+	}
+
+	data, err = backend.GetObject(ctx, "docs/a.txt", 6, 9)
+	if err != nil {
+		t.Fatalf("ranged GetObject() error = %v", err)
+	}
+	if string(data) != "presigned" {
+		t.Errorf("ranged GetObject() = %q, want %q", data, "presigned")
+	}
+
+	info, err := backend.HeadObject(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatalf("HeadObject() error = %v", err)
+	}
+	if info.Size != int64(len("hello presigned")) {
+		t.Errorf("HeadObject() size = %d, want %d", info.Size, len("hello presigned"))
+	}
+
+	if _, err := backend.GetObject(ctx, "docs/missing.txt", 0, 0); err == nil {
+		t.Error("GetObject() of a missing object succeeded")
+	}
+}
+
+func TestPresignedExpiredURLIsRefreshed(t *testing.T) {
+	server := newPresignServer(t, PresignGet, PresignPut)
+	backend := newPresignedBackend(server)
+	ctx := context.Background()
+
+	if err := backend.PutObject(ctx, "data.bin", []byte("payload")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+
+	server.expired = 1
+	before := server.providerCalls()
+	data, err := backend.GetObject(ctx, "data.bin", 0, 0)
+	if err != nil {
+		t.Fatalf("GetObject() with an expired URL error = %v", err)
+	}
+	if string(data) != "payload" {
+		t.Errorf("GetObject() = %q, want %q", data, "payload")
+	}
+	if calls := server.providerCalls() - before; calls != 2 {
+		t.Errorf("provider calls = %d, want the expired URL replaced once", calls)
+	}
+	if got := backend.PresignedRefreshes(); got != 1 {
+		t.Errorf("PresignedRefreshes() = %d, want 1", got)
+	}
+}
+
+func TestPresignedUnsupportedOperation(t *testing.T) {
+	server := newPresignServer(t, PresignGet)
+	backend := newPresignedBackend(server)
+
+	if err := backend.PutObject(context.Background(), "data.bin", []byte("payload")); err == nil {
+		t.Fatal("PutObject() succeeded without a provider for PUT")
+	}
+	if _, err := backend.ListObjects(context.Background(), "", 0); err == nil {
+		t.Fatal("ListObjects() succeeded without a provider for LIST")
+	}
+	if calls := server.providerCalls(); calls != 0 {
+		t.Errorf("provider calls = %d, want unsupported operations refused up front", calls)
+	}
+	if !backend.IsWriteAvailable() {
+		t.Error("unsupported operations degraded backend health")
+	}
+}
+
+func TestPresignedListingStreamsAndSignedOnlyOperationsFail(t *testing.T) {
+	server := newPresignServer(t, PresignGet, PresignPut, PresignList)
+	backend := newPresignedBackend(server)
+	ctx := context.Background()
+
+	for _, key := range []string{"docs/b.txt", "docs/a.txt", "other.txt"} {
+		if err := backend.PutObject(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("PutObject(%s) error = %v", key, err)
+		}
+	}
+
+	objects, errs := backend.ListObjectsChan(ctx, "docs/")
+	var keys []string
+	for obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("ListObjectsChan() error = %v", err)
+	}
+	if strings.Join(keys, ",") != "docs/a.txt,docs/b.txt" {
+		t.Errorf("ListObjectsChan() = %v, want the docs/ keys from the presigned listing", keys)
+	}
+
+	before := server.providerCalls()
+	if _, _, _, err := backend.GetObjectIfModified(ctx, "docs/a.txt", 0, 0, time.Now(), ""); err == nil {
+		t.Error("GetObjectIfModified() succeeded in presigned mode")
+	}
+	if err := backend.Touch(ctx, "docs/a.txt"); err == nil {
+		t.Error("Touch() succeeded in presigned mode")
+	}
+	if calls := server.providerCalls() - before; calls != 0 {
+		t.Errorf("provider calls = %d, want signed-only operations refused up front", calls)
+	}
+	if !backend.IsReadAvailable() || !backend.IsWriteAvailable() {
+		t.Error("signed-only operations degraded backend health")
+	}
+}
@@ -0,0 +1,76 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Prewarm fills the connection pool with up to connections clients and
+// sends a HEAD of the bucket through each at the same time, so DNS lookups,
+// TCP connections and TLS handshakes happen now rather than on the first
+// filesystem operation. A count of zero or more than the pool size warms
+// the whole pool. The underlying HTTP transport keeps the connections idle
+// for reuse up to its own per-host limit.
+func (cm *ClientManager) Prewarm(ctx context.Context, bucket string, connections int) error {
+	if size := cm.pool.Stats().MaxSize; connections <= 0 || connections > size {
+		connections = size
+	}
+
+	// Hold every client until all requests are done, so each request has
+	// its own connection instead of reusing one another finished with
+	clients := make([]*s3.Client, 0, connections)
+	defer func() {
+		for _, client := range clients {
+			cm.ReturnPooledClient(client)
+		}
+	}()
+	for len(clients) < connections {
+		client := cm.GetPooledClient()
+		if client == nil {
+			return fmt.Errorf("prewarm: only %d of %d pooled connections available", len(clients), connections)
+		}
+		clients = append(clients, client)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(clients))
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client *s3.Client) {
+			defer wg.Done()
+			_, errs[i] = client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+		}(i, client)
+	}
+	wg.Wait()
+
+	failed := 0
+	var firstErr error
+	for _, err := range errs {
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("prewarm: %d of %d connections failed: %w", failed, connections, firstErr)
+	}
+
+	cm.logger.Debug("Prewarmed S3 connections", "connections", connections)
+	return nil
+}
+
+// Prewarm establishes and validates connections to the bucket ahead of the
+// first operation. In presigned mode requests go through the presigned
+// URLs' own HTTP client, so there is nothing to warm.
+func (b *Backend) Prewarm(ctx context.Context, connections int) error {
+	if b.presigned != nil {
+		return nil
+	}
+	return b.clientManager.Prewarm(ctx, b.bucket, connections)
+}
@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// connServer is an S3 endpoint that counts the connections clients open.
+// While a barrier is set, bucket HEADs wait until that many have arrived,
+// so concurrent requests cannot finish early and share a connection.
+type connServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	opened  int
+	barrier int
+	arrived int
+}
+
+func newConnServer(t *testing.T) *connServer {
+	setTestCredentials(t)
+	s := &connServer{}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serve))
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.mu.Lock()
+			s.opened++
+			s.mu.Unlock()
+		}
+	}
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *connServer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/warm-bucket" {
+		s.mu.Lock()
+		s.arrived++
+		deadline := time.Now().Add(2 * time.Second)
+		for s.arrived < s.barrier && time.Now().Before(deadline) {
+			s.mu.Unlock()
+			time.Sleep(time.Millisecond)
+			s.mu.Lock()
+		}
+		s.mu.Unlock()
+		w.Header().Set(bucketRegionHeader, "us-east-1")
+	}
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *connServer) setBarrier(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.barrier = n
+	s.arrived = 0
+}
+
+func (s *connServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.opened
+}
+
+func TestPrewarmOpensRequestedConnections(t *testing.T) {
+	server := newConnServer(t)
+	ctx := context.Background()
+
+	backend, err := NewBackend(ctx, "warm-bucket", newRegionConfig(server.URL, "us-east-1"))
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	server.setBarrier(4)
+	if err := backend.Prewarm(ctx, 4); err != nil {
+		t.Fatalf("Prewarm() error = %v", err)
+	}
+	server.setBarrier(0)
+
+	if stats := backend.clientManager.GetStats(); stats.Idle != 4 {
+		t.Errorf("idle pooled connections = %d, want 4", stats.Idle)
+	}
+	opened := server.connections()
+	if opened < 4 {
+		t.Errorf("connections opened = %d, want at least 4", opened)
+	}
+
+	// The first real operation reuses a warm connection
+	if _, err := backend.HeadObject(ctx, "file.txt"); err != nil {
+		t.Fatalf("HeadObject() error = %v", err)
+	}
+	if got := server.connections(); got != opened {
+		t.Errorf("first operation opened %d new connections, want none", got-opened)
+	}
+}
+
+func TestPrewarmCapsAtPoolSize(t *testing.T) {
+	server := newConnServer(t)
+	ctx := context.Background()
+
+	cfg := newRegionConfig(server.URL, "us-east-1")
+	cfg.PoolSize = 2
+	backend, err := NewBackend(ctx, "warm-bucket", cfg)
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	if err := backend.Prewarm(ctx, 10); err != nil {
+		t.Fatalf("Prewarm() error = %v", err)
+	}
+	if stats := backend.clientManager.GetStats(); stats.Idle != 2 {
+		t.Errorf("idle pooled connections = %d, want the pool size of 2", stats.Idle)
+	}
+}
@@ -0,0 +1,261 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultProgressInterval is the minimum time between progress callbacks
+// for one transfer, so fast transfers do not spend their time reporting
+const defaultProgressInterval = 100 * time.Millisecond
+
+// ProgressCallback receives transfer progress at part and chunk boundaries.
+// bytesTotal is -1 while the size is not yet known.
+type ProgressCallback func(bytesDone, bytesTotal int64)
+
+type progressContextKey struct{}
+
+// WithProgress returns a context that reports progress of the GetObject,
+// PutObject, and PutObjectStream calls it is passed to
+func WithProgress(ctx context.Context, fn ProgressCallback) context.Context {
+	return context.WithValue(ctx, progressContextKey{}, fn)
+}
+
+// progressFromContext returns the callback registered with WithProgress
+func progressFromContext(ctx context.Context) ProgressCallback {
+	fn, _ := ctx.Value(progressContextKey{}).(ProgressCallback)
+	return fn
+}
+
+// TransferProgress describes one in-flight transfer
+type TransferProgress struct {
+	Operation  string    `json:"operation"`
+	Key        string    `json:"key"`
+	BytesDone  int64     `json:"bytes_done"`
+	BytesTotal int64     `json:"bytes_total"` // -1 when unknown
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// transfer tracks the progress of one upload or download. Callbacks are
+// made outside the transfer's lock, so they may inspect transfers, and in
+// the order their counts were taken, so byte counts never go backwards.
+type transfer struct {
+	mu       sync.Mutex
+	progress TransferProgress
+	callback ProgressCallback
+	interval time.Duration
+
+	lastReport    time.Time
+	reportedDone  int64
+	reportedTotal int64
+	reportSeq     uint64 // Counts reports taken under mu
+
+	// reportMu serializes callbacks; delivered is the latest report made
+	reportMu  sync.Mutex
+	delivered uint64
+}
+
+// progressReport is one callback's byte counts, numbered in the order
+// they were taken
+type progressReport struct {
+	seq        uint64
+	bytesDone  int64
+	bytesTotal int64
+}
+
+func newTransfer(operation, key string, total int64, callback ProgressCallback, interval time.Duration) *transfer {
+	return &transfer{
+		progress: TransferProgress{
+			Operation:  operation,
+			Key:        key,
+			BytesTotal: total,
+			StartedAt:  time.Now(),
+		},
+		callback:      callback,
+		interval:      interval,
+		reportedDone:  -1,
+		reportedTotal: -1,
+	}
+}
+
+// add records n more bytes transferred, for transfers made of parallel parts
+func (t *transfer) add(n int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.progress.BytesDone += n
+	report, ok := t.maybeReportLocked()
+	t.mu.Unlock()
+
+	if ok {
+		t.deliver(report)
+	}
+}
+
+// advance raises the bytes transferred to done. Hedged attempts each
+// report their own count, so the furthest one wins.
+func (t *transfer) advance(done int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if done <= t.progress.BytesDone {
+		t.mu.Unlock()
+		return
+	}
+	t.progress.BytesDone = done
+	report, ok := t.maybeReportLocked()
+	t.mu.Unlock()
+
+	if ok {
+		t.deliver(report)
+	}
+}
+
+// setTotal records the size once it becomes known
+func (t *transfer) setTotal(total int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.progress.BytesTotal = total
+	t.mu.Unlock()
+}
+
+// finish reports the final byte count of a successful transfer
+func (t *transfer) finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.progress.BytesTotal < 0 {
+		t.progress.BytesTotal = t.progress.BytesDone
+	}
+	var report progressReport
+	var ok bool
+	if t.reportedDone != t.progress.BytesDone || t.reportedTotal != t.progress.BytesTotal {
+		report, ok = t.reportLocked()
+	}
+	t.mu.Unlock()
+
+	if ok {
+		t.deliver(report)
+	}
+}
+
+func (t *transfer) maybeReportLocked() (progressReport, bool) {
+	if t.callback == nil {
+		return progressReport{}, false
+	}
+	complete := t.progress.BytesTotal >= 0 && t.progress.BytesDone >= t.progress.BytesTotal
+	if complete || time.Since(t.lastReport) >= t.interval {
+		return t.reportLocked()
+	}
+	return progressReport{}, false
+}
+
+// reportLocked takes the counts for a callback, which deliver makes once
+// mu is released
+func (t *transfer) reportLocked() (progressReport, bool) {
+	if t.callback == nil {
+		return progressReport{}, false
+	}
+	t.lastReport = time.Now()
+	t.reportedDone = t.progress.BytesDone
+	t.reportedTotal = t.progress.BytesTotal
+	t.reportSeq++
+	return progressReport{
+		seq:        t.reportSeq,
+		bytesDone:  t.progress.BytesDone,
+		bytesTotal: t.progress.BytesTotal,
+	}, true
+}
+
+// deliver calls the callback with report unless a later report has
+// already been delivered
+func (t *transfer) deliver(report progressReport) {
+	t.reportMu.Lock()
+	defer t.reportMu.Unlock()
+
+	if report.seq <= t.delivered {
+		return
+	}
+	t.delivered = report.seq
+	t.callback(report.bytesDone, report.bytesTotal)
+}
+
+func (t *transfer) snapshot() TransferProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.progress
+}
+
+// progressReader advances a transfer as a response body is read
+type progressReader struct {
+	r        io.Reader
+	transfer *transfer
+	read     int64
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 {
+		p.read += int64(n)
+		p.transfer.advance(p.read)
+	}
+	return n, err
+}
+
+// transferTracker registers in-flight transfers. The zero value is ready to use.
+type transferTracker struct {
+	mu     sync.Mutex
+	active map[*transfer]uint64 // Start order
+	next   uint64
+}
+
+// start registers a transfer, reporting to the callback in ctx if any
+func (tt *transferTracker) start(ctx context.Context, operation, key string, total int64, interval time.Duration) *transfer {
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	t := newTransfer(operation, key, total, progressFromContext(ctx), interval)
+
+	tt.mu.Lock()
+	if tt.active == nil {
+		tt.active = make(map[*transfer]uint64)
+	}
+	tt.next++
+	tt.active[t] = tt.next
+	tt.mu.Unlock()
+	return t
+}
+
+// done unregisters a transfer
+func (tt *transferTracker) done(t *transfer) {
+	tt.mu.Lock()
+	delete(tt.active, t)
+	tt.mu.Unlock()
+}
+
+// snapshot returns in-flight transfers, oldest first
+func (tt *transferTracker) snapshot() []TransferProgress {
+	tt.mu.Lock()
+	transfers := make([]*transfer, 0, len(tt.active))
+	for t := range tt.active {
+		transfers = append(transfers, t)
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		return tt.active[transfers[i]] < tt.active[transfers[j]]
+	})
+	tt.mu.Unlock()
+
+	progress := make([]TransferProgress, 0, len(transfers))
+	for _, t := range transfers {
+		progress = append(progress, t.snapshot())
+	}
+	return progress
+}
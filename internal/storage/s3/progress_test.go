@@ -0,0 +1,170 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// progressRecorder collects progress callbacks
+type progressRecorder struct {
+	mu    sync.Mutex
+	done  []int64
+	total []int64
+}
+
+func (r *progressRecorder) record(bytesDone, bytesTotal int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = append(r.done, bytesDone)
+	r.total = append(r.total, bytesTotal)
+}
+
+// check verifies byte counts only increase and end at want
+func (r *progressRecorder) check(t *testing.T, want int64) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.done) == 0 {
+		t.Fatal("no progress callbacks")
+	}
+	for i := 1; i < len(r.done); i++ {
+		if r.done[i] < r.done[i-1] {
+			t.Errorf("progress went backwards: %v", r.done)
+		}
+	}
+	last := len(r.done) - 1
+	if r.done[last] != want || r.total[last] != want {
+		t.Errorf("final progress = %d/%d, want %d/%d", r.done[last], r.total[last], want, want)
+	}
+}
+
+func TestStreamMultipartUpload_ReportsProgress(t *testing.T) {
+	recorder := &progressRecorder{}
+	ctx := WithProgress(context.Background(), recorder.record)
+	var tracker transferTracker
+	progress := tracker.start(ctx, "PutObjectStream", "big.bin", -1, time.Nanosecond)
+
+	client := &fakeMultipartClient{}
+	upload := streamUpload{bucket: "bucket", key: "big.bin", partSize: 4, progress: progress}
+	written, err := streamMultipartUpload(ctx, client, upload, strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatalf("streamMultipartUpload() error = %v", err)
+	}
+	progress.finish()
+
+	recorder.check(t, written)
+	if len(recorder.done) < 3 {
+		t.Errorf("got %d callbacks, want one per part and a final one", len(recorder.done))
+	}
+}
+
+func TestTransfer_ParallelPartsSumToTotal(t *testing.T) {
+	recorder := &progressRecorder{}
+	const parts, partSize = 16, 1024
+	progress := newTransfer("PutObject", "big.bin", parts*partSize, recorder.record, time.Nanosecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < parts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			progress.add(partSize)
+		}()
+	}
+	wg.Wait()
+	progress.finish()
+
+	recorder.check(t, parts*partSize)
+}
+
+func TestTransfer_RateLimited(t *testing.T) {
+	recorder := &progressRecorder{}
+	progress := newTransfer("GetObject", "big.bin", 1000, recorder.record, time.Hour)
+
+	for i := 0; i < 1000; i++ {
+		progress.add(1)
+	}
+	progress.finish()
+
+	// The first chunk reports immediately, the rest only on completion
+	if got := len(recorder.done); got != 2 {
+		t.Errorf("got %d callbacks for 1000 chunks, want 2", got)
+	}
+	recorder.check(t, 1000)
+}
+
+func TestProgressReader_HedgedAttemptsStayMonotonic(t *testing.T) {
+	recorder := &progressRecorder{}
+	progress := newTransfer("GetObject", "big.bin", 8, recorder.record, time.Nanosecond)
+
+	// Two attempts read the same body; only the furthest one counts
+	first := &progressReader{r: strings.NewReader("abcdefgh"), transfer: progress}
+	second := &progressReader{r: strings.NewReader("abcdefgh"), transfer: progress}
+	buf := make([]byte, 4)
+	_, _ = first.Read(buf)
+	_, _ = first.Read(buf)
+	_, _ = io.ReadAll(second)
+	progress.finish()
+
+	recorder.check(t, 8)
+}
+
+func TestProgressCallbackCanInspectTransfers(t *testing.T) {
+	server := newPresignServer(t, PresignPut, PresignGet)
+	backend := newPresignedBackend(server)
+
+	var seen []TransferProgress
+	ctx := WithProgress(context.Background(), func(bytesDone, bytesTotal int64) {
+		seen = append(seen, backend.Transfers()...)
+		_ = backend.GetMetrics()
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		if err := backend.PutObject(ctx, "a.bin", []byte("payload")); err != nil {
+			done <- err
+			return
+		}
+		_, err := backend.GetObject(ctx, "a.bin", 0, 0)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("transfer error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("progress callback deadlocked inspecting transfers")
+	}
+	if len(seen) == 0 {
+		t.Error("progress callback saw no in-flight transfers")
+	}
+}
+
+func TestTransferTracker_Snapshot(t *testing.T) {
+	var tracker transferTracker
+	upload := tracker.start(context.Background(), "PutObject", "a.bin", 100, 0)
+	download := tracker.start(context.Background(), "GetObject", "b.bin", -1, 0)
+	upload.add(40)
+	download.advance(10)
+
+	transfers := tracker.snapshot()
+	if len(transfers) != 2 {
+		t.Fatalf("snapshot() returned %d transfers, want 2", len(transfers))
+	}
+	if transfers[0].Key != "a.bin" || transfers[0].BytesDone != 40 || transfers[0].BytesTotal != 100 {
+		t.Errorf("upload progress = %+v", transfers[0])
+	}
+
+	tracker.done(upload)
+	tracker.done(download)
+	if got := len(tracker.snapshot()); got != 0 {
+		t.Errorf("snapshot() after done returned %d transfers, want 0", got)
+	}
+}
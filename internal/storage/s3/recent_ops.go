@@ -0,0 +1,143 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/objectfs/objectfs/internal/circuit"
+)
+
+// defaultRecentOpsSize is the number of operations kept by default
+const defaultRecentOpsSize = 256
+
+// RecentOpsConfig defines the ring buffer of recent backend operations
+// kept for postmortem inspection
+type RecentOpsConfig struct {
+	Size     int  `yaml:"size"`      // Operations kept, oldest overwritten first; 0 disables recording
+	HashKeys bool `yaml:"hash_keys"` // Record a SHA-256 prefix of each key instead of the key
+}
+
+// OperationRecord is one recorded backend operation
+type OperationRecord struct {
+	Seq      uint64        `json:"seq"`
+	Time     time.Time     `json:"time"`
+	Op       string        `json:"op"`
+	Key      string        `json:"key"`
+	Duration time.Duration `json:"duration"`
+	Result   string        `json:"result"`            // "ok" or the error
+	Attempts int32         `json:"attempts"`          // Requests sent; 0 when rejected before sending
+	Breaker  string        `json:"breaker,omitempty"` // Circuit breaker state after the operation
+	Region   string        `json:"region,omitempty"`  // Region that served a read when replica regions are configured
+}
+
+// recentOps is a fixed-size ring of the latest operations. Recording takes
+// no lock: a sequence number claims a slot and the record is published
+// with an atomic store.
+type recentOps struct {
+	slots    []atomic.Pointer[OperationRecord]
+	next     atomic.Uint64
+	hashKeys bool
+}
+
+// newRecentOps returns a ring for config, or nil when recording is disabled
+func newRecentOps(config RecentOpsConfig) *recentOps {
+	if config.Size <= 0 {
+		return nil
+	}
+	return &recentOps{
+		slots:    make([]atomic.Pointer[OperationRecord], config.Size),
+		hashKeys: config.HashKeys,
+	}
+}
+
+// opTrace counts the requests one operation sends
+type opTrace struct {
+	attempts atomic.Int32
+	region   atomic.Pointer[string]
+}
+
+type opTraceKey struct{}
+
+// begin returns ctx carrying a trace of the operation's attempts
+func (r *recentOps) begin(ctx context.Context) (context.Context, *opTrace) {
+	if r == nil {
+		return ctx, nil
+	}
+	trace := &opTrace{}
+	return context.WithValue(ctx, opTraceKey{}, trace), trace
+}
+
+// countAttempt notes that the traced operation of ctx sends a request
+func countAttempt(ctx context.Context) {
+	if trace, ok := ctx.Value(opTraceKey{}).(*opTrace); ok {
+		trace.attempts.Add(1)
+	}
+}
+
+// servedBy notes the region that answered the traced read of ctx
+func servedBy(ctx context.Context, region string) {
+	if trace, ok := ctx.Value(opTraceKey{}).(*opTrace); ok {
+		trace.region.Store(&region)
+	}
+}
+
+// record adds a finished operation; breaker may be nil
+func (r *recentOps) record(trace *opTrace, op, key string, start time.Time, err error, breaker *circuit.CircuitBreaker) {
+	if r == nil {
+		return
+	}
+
+	rec := &OperationRecord{
+		Time:     start,
+		Op:       op,
+		Key:      key,
+		Duration: time.Since(start),
+		Result:   "ok",
+		Attempts: trace.attempts.Load(),
+	}
+	if r.hashKeys {
+		sum := sha256.Sum256([]byte(key))
+		rec.Key = hex.EncodeToString(sum[:8])
+	}
+	if err != nil {
+		rec.Result = err.Error()
+	}
+	if breaker != nil {
+		rec.Breaker = breaker.GetState().String()
+	}
+	if region := trace.region.Load(); region != nil {
+		rec.Region = *region
+	}
+
+	rec.Seq = r.next.Add(1)
+	r.slots[(rec.Seq-1)%uint64(len(r.slots))].Store(rec)
+}
+
+// snapshot returns the recorded operations, oldest first
+func (r *recentOps) snapshot() []OperationRecord {
+	if r == nil {
+		return []OperationRecord{}
+	}
+
+	// Slots being overwritten may briefly hold a record older than the
+	// window; those are skipped
+	last := r.next.Load()
+	records := make([]OperationRecord, 0, len(r.slots))
+	for i := range r.slots {
+		rec := r.slots[i].Load()
+		if rec != nil && rec.Seq+uint64(len(r.slots)) > last {
+			records = append(records, *rec)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Seq < records[j].Seq })
+	return records
+}
+
+// RecentOperations returns the latest backend operations, oldest first
+func (b *Backend) RecentOperations() []OperationRecord {
+	return b.recentOps.snapshot()
+}
@@ -0,0 +1,69 @@
+package s3
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecentOpsKeepsLatestInOrder(t *testing.T) {
+	server := newPresignServer(t, PresignGet, PresignPut, PresignHead)
+	backend := newPresignedBackend(server)
+	backend.recentOps = newRecentOps(RecentOpsConfig{Size: 3})
+	ctx := context.Background()
+
+	if err := backend.PutObject(ctx, "logs/a", []byte("first")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+	if _, err := backend.GetObject(ctx, "logs/a", 0, 0); err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	if _, err := backend.HeadObject(ctx, "logs/a"); err != nil {
+		t.Fatalf("HeadObject() error = %v", err)
+	}
+	if _, err := backend.GetObject(ctx, "logs/missing", 0, 0); err == nil {
+		t.Fatal("GetObject() of a missing key succeeded")
+	}
+	if err := backend.PutObject(ctx, "logs/b", []byte("second")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+
+	ops := backend.RecentOperations()
+	want := []struct{ op, key string }{
+		{"HeadObject", "logs/a"},
+		{"GetObject", "logs/missing"},
+		{"PutObject", "logs/b"},
+	}
+	if len(ops) != len(want) {
+		t.Fatalf("RecentOperations() returned %d records, want %d: %+v", len(ops), len(want), ops)
+	}
+	for i, w := range want {
+		if ops[i].Op != w.op || ops[i].Key != w.key {
+			t.Errorf("record %d = %s %s, want %s %s", i, ops[i].Op, ops[i].Key, w.op, w.key)
+		}
+		if i > 0 && ops[i].Seq <= ops[i-1].Seq {
+			t.Errorf("record %d seq %d not after %d", i, ops[i].Seq, ops[i-1].Seq)
+		}
+		if ops[i].Attempts != 1 {
+			t.Errorf("record %d attempts = %d, want 1", i, ops[i].Attempts)
+		}
+	}
+	if ops[1].Result == "ok" || ops[2].Result != "ok" {
+		t.Errorf("results = %q, %q; want an error then ok", ops[1].Result, ops[2].Result)
+	}
+	if ops[1].Breaker != "CLOSED" {
+		t.Errorf("GetObject breaker state = %q, want CLOSED", ops[1].Breaker)
+	}
+}
+
+func TestRecentOpsHashesKeys(t *testing.T) {
+	ring := newRecentOps(RecentOpsConfig{Size: 4, HashKeys: true})
+	ctx, trace := ring.begin(context.Background())
+	countAttempt(ctx)
+	ring.record(trace, "GetObject", "customers/alice/ssn.txt", time.Now(), nil, nil)
+
+	ops := ring.snapshot()
+	if len(ops) != 1 || ops[0].Key == "customers/alice/ssn.txt" || len(ops[0].Key) != 16 {
+		t.Errorf("recorded key = %+v, want a 16-character hash", ops)
+	}
+}
@@ -0,0 +1,77 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"log/slog"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// defaultProbeRegion signs requests when no region is configured; S3
+// answers requests for buckets in other regions with their region
+const defaultProbeRegion = "us-east-1"
+
+// bucketRegionHeader carries a bucket's region on S3 responses, including
+// the PermanentRedirect and AuthorizationHeaderMalformed errors returned
+// for requests signed for the wrong region
+const bucketRegionHeader = "X-Amz-Bucket-Region"
+
+// bucketRegions caches resolved bucket regions by endpoint and bucket, so
+// later backends for the same bucket skip the probe
+var bucketRegions sync.Map
+
+// resolveBucketRegion returns the region bucket lives in, probing it with a
+// HEAD request when it is not cached. It returns "" when the region cannot
+// be told, such as from S3-compatible stores that do not report it.
+func resolveBucketRegion(ctx context.Context, client *s3.Client, endpoint, bucket string) string {
+	cacheKey := endpoint + "/" + bucket
+	if region, ok := bucketRegions.Load(cacheKey); ok {
+		return region.(string)
+	}
+
+	var region string
+	result, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	if err == nil {
+		region = aws.ToString(result.BucketRegion)
+	} else {
+		region = regionFromError(err)
+	}
+
+	if region != "" {
+		bucketRegions.Store(cacheKey, region)
+	}
+	return region
+}
+
+// regionFromError returns the bucket region reported on a failed response
+func regionFromError(err error) string {
+	var responseErr *awshttp.ResponseError
+	if !stderr.As(err, &responseErr) || responseErr.Response == nil {
+		return ""
+	}
+	return responseErr.Response.Header.Get(bucketRegionHeader)
+}
+
+// correctRegion returns a client manager signing for the region bucket
+// lives in. When the configured region is wrong or missing, cfg.Region is
+// updated and the client manager recreated, so requests are not answered
+// with redirects. Buckets whose region cannot be told are left to fail the
+// health check.
+func correctRegion(ctx context.Context, bucket string, cfg *Config, clientManager *ClientManager, logger *slog.Logger) (*ClientManager, error) {
+	region := resolveBucketRegion(ctx, clientManager.standardClient, cfg.Endpoint, bucket)
+	if region == "" || region == clientManager.region {
+		return clientManager, nil
+	}
+
+	logger.Warn("Configured region does not match the bucket, using the bucket's region",
+		"configured_region", cfg.Region,
+		"bucket_region", region)
+
+	cfg.Region = region
+	_ = clientManager.Close()
+	return NewClientManager(ctx, bucket, cfg, logger)
+}
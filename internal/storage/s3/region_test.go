@@ -0,0 +1,119 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// regionServer is an S3 endpoint for one bucket that, like S3, redirects
+// requests signed for any other region
+type regionServer struct {
+	*httptest.Server
+	bucket string
+	region string
+
+	mu         sync.Mutex
+	redirected int
+}
+
+// setTestCredentials gives SDK clients static credentials and keeps them
+// from reading the host's AWS configuration
+func setTestCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func newRegionServer(t *testing.T, bucket, region string) *regionServer {
+	setTestCredentials(t)
+	s := &regionServer{bucket: bucket, region: region}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *regionServer) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/"+s.bucket) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set(bucketRegionHeader, s.region)
+	if !strings.Contains(r.Header.Get("Authorization"), "/"+s.region+"/s3/") {
+		s.mu.Lock()
+		s.redirected++
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *regionServer) redirects() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.redirected
+}
+
+func newRegionConfig(endpoint, region string) *Config {
+	cfg := NewDefaultConfig()
+	cfg.Endpoint = endpoint
+	cfg.ForcePathStyle = true
+	cfg.Region = region
+	cfg.MaxRetries = 1
+	cfg.RetryConfig.MaxAttempts = 1
+	cfg.EnableCargoShipOptimization = false
+	return cfg
+}
+
+func TestNewBackendCorrectsWrongRegion(t *testing.T) {
+	server := newRegionServer(t, "data-bucket", "eu-central-1")
+	ctx := context.Background()
+
+	backend, err := NewBackend(ctx, "data-bucket", newRegionConfig(server.URL, "us-west-2"))
+	if err != nil {
+		t.Fatalf("NewBackend() with the wrong region error = %v", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	if backend.config.Region != "eu-central-1" {
+		t.Errorf("config region = %s, want eu-central-1", backend.config.Region)
+	}
+	if _, err := backend.HeadObject(ctx, "file.txt"); err != nil {
+		t.Errorf("HeadObject() after correction error = %v", err)
+	}
+
+	// The resolved region is cached for later backends of the same bucket
+	redirects := server.redirects()
+	other, err := NewBackend(ctx, "data-bucket", newRegionConfig(server.URL, ""))
+	if err != nil {
+		t.Fatalf("NewBackend() without a region error = %v", err)
+	}
+	defer func() { _ = other.Close() }()
+	if got := server.redirects() - redirects; got != 0 {
+		t.Errorf("redirects for a cached bucket = %d, want none", got)
+	}
+	if other.config.Region != "eu-central-1" {
+		t.Errorf("config region = %s, want eu-central-1", other.config.Region)
+	}
+}
+
+func TestNewBackendInaccessibleBucketFails(t *testing.T) {
+	server := newRegionServer(t, "data-bucket", "eu-central-1")
+
+	_, err := NewBackend(context.Background(), "private-bucket", newRegionConfig(server.URL, "us-west-2"))
+	if err == nil {
+		t.Fatal("NewBackend() of an inaccessible bucket succeeded")
+	}
+	if !strings.Contains(err.Error(), "private-bucket") || !strings.Contains(err.Error(), "403") {
+		t.Errorf("NewBackend() error = %v, want the bucket and status named", err)
+	}
+}
@@ -0,0 +1,271 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ReadFailoverConfig tunes how reads fail over to ReadReplicaRegions
+type ReadFailoverConfig struct {
+	// Reads go to a replica first while the primary's pinged latency
+	// exceeds this; zero fails over only when primary reads fail
+	LatencyThreshold time.Duration `yaml:"latency_threshold"`
+
+	// Replica bucket by region, for replication into differently named
+	// buckets; default the primary bucket's name
+	Buckets map[string]string `yaml:"buckets"`
+
+	// Endpoint by region for S3-compatible stores; default the configured
+	// endpoint
+	Endpoints map[string]string `yaml:"endpoints"`
+}
+
+// ReadRegionStats reports the health of one region reads are served from
+type ReadRegionStats struct {
+	Region   string        `json:"region"`
+	Bucket   string        `json:"bucket"`
+	Primary  bool          `json:"primary"`
+	Healthy  bool          `json:"healthy"`  // Whether its latest read or ping reached S3
+	Latency  time.Duration `json:"latency"`  // Rolling pinged latency; 0 before the first ping
+	Reads    int64         `json:"reads"`    // Reads it answered
+	Failures int64         `json:"failures"` // Reads that could not reach it
+}
+
+// readRegion is the primary region or a replica region reads can be
+// served from
+type readRegion struct {
+	region  string
+	bucket  string
+	client  *s3.Client // nil for the primary, which reads through the pool
+	latency *latencyEstimator
+
+	mu       sync.Mutex
+	healthy  bool
+	reads    int64
+	failures int64
+}
+
+// observe records the outcome of a read or ping of the region
+func (r *readRegion) observe(err error, read bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if isConnectionFailure(err) {
+		r.healthy = false
+		if read {
+			r.failures++
+		}
+		return
+	}
+	r.healthy = true
+	if read {
+		r.reads++
+	}
+}
+
+// stats returns a snapshot of the region's health
+func (r *readRegion) stats(primary bool) ReadRegionStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return ReadRegionStats{
+		Region:   r.region,
+		Bucket:   r.bucket,
+		Primary:  primary,
+		Healthy:  r.healthy,
+		Latency:  r.latency.estimate(),
+		Reads:    r.reads,
+		Failures: r.failures,
+	}
+}
+
+// readFailover serves reads from the primary region and fails over to
+// replica regions, preferring the healthy replica with the lowest pinged
+// latency. Writes never go through it.
+type readFailover struct {
+	primary   *readRegion
+	replicas  []*readRegion
+	threshold time.Duration
+
+	failovers atomic.Int64 // Reads served by a replica
+}
+
+// newReadFailover returns the failover for cfg's replica regions, or nil
+// when none are configured
+func newReadFailover(cfg *Config, bucket string, clientManager *ClientManager, primaryLatency *latencyEstimator) (*readFailover, error) {
+	if len(cfg.ReadReplicaRegions) == 0 {
+		return nil, nil
+	}
+	if cfg.URLProvider != nil {
+		return nil, fmt.Errorf("read replica regions cannot be used with presigned URLs")
+	}
+
+	f := &readFailover{
+		primary:   &readRegion{region: clientManager.region, bucket: bucket, latency: primaryLatency, healthy: true},
+		threshold: cfg.ReadFailover.LatencyThreshold,
+	}
+	seen := map[string]bool{clientManager.region: true}
+	for _, region := range cfg.ReadReplicaRegions {
+		if region == "" || seen[region] {
+			return nil, fmt.Errorf("invalid read replica region %q: regions must be named once and differ from the primary region %s", region, clientManager.region)
+		}
+		seen[region] = true
+
+		replicaBucket := cfg.ReadFailover.Buckets[region]
+		if replicaBucket == "" {
+			replicaBucket = bucket
+		}
+		f.replicas = append(f.replicas, &readRegion{
+			region:  region,
+			bucket:  replicaBucket,
+			client:  clientManager.newRegionClient(region, cfg.ReadFailover.Endpoints[region]),
+			latency: &latencyEstimator{},
+			healthy: true,
+		})
+	}
+	return f, nil
+}
+
+// order returns the regions to try a read in. The primary comes first
+// unless it is unhealthy or slower than the latency threshold; replicas
+// follow healthy first, then by pinged latency, unmeasured ones last.
+func (f *readFailover) order() []*readRegion {
+	replicas := append([]*readRegion(nil), f.replicas...)
+	rank := make(map[*readRegion]ReadRegionStats, len(replicas))
+	for _, replica := range replicas {
+		rank[replica] = replica.stats(false)
+	}
+	sort.SliceStable(replicas, func(i, j int) bool {
+		a, b := rank[replicas[i]], rank[replicas[j]]
+		if a.Healthy != b.Healthy {
+			return a.Healthy
+		}
+		if (a.Latency == 0) != (b.Latency == 0) {
+			return a.Latency != 0
+		}
+		return a.Latency < b.Latency
+	})
+
+	primary := f.primary.stats(true)
+	demoted := !primary.Healthy || (f.threshold > 0 && primary.Latency > f.threshold)
+	if demoted && len(replicas) > 0 && rank[replicas[0]].Healthy {
+		return append(replicas, f.primary)
+	}
+	return append([]*readRegion{f.primary}, replicas...)
+}
+
+// read runs a read against each region in order until one answers.
+// Errors that show the primary was reached, such as a missing key, are
+// returned without failing over; a replica that cannot answer, including
+// one replication has not caught up with, passes the read on.
+func (f *readFailover) read(ctx context.Context, primary func(ctx context.Context) error, replica func(ctx context.Context, region *readRegion) error) error {
+	if f == nil {
+		return primary(ctx)
+	}
+
+	var primaryErr, lastErr error
+	for _, region := range f.order() {
+		var err error
+		if region == f.primary {
+			err = primary(ctx)
+			primaryErr = err
+		} else {
+			err = replica(ctx, region)
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		region.observe(err, true)
+		if err == nil {
+			servedBy(ctx, region.region)
+			if region != f.primary {
+				f.failovers.Add(1)
+			}
+			return nil
+		}
+		if region == f.primary && !isConnectionFailure(err) {
+			return err
+		}
+		lastErr = err
+	}
+	if primaryErr != nil {
+		return primaryErr
+	}
+	return lastErr
+}
+
+// probe pings every replica region, updating its latency and health
+func (f *readFailover) probe(ctx context.Context) {
+	if f == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, replica := range f.replicas {
+		wg.Add(1)
+		go func(replica *readRegion) {
+			defer wg.Done()
+			_, err := replica.latency.probe(ctx, replica.client, replica.bucket)
+			if ctx.Err() == nil {
+				replica.observe(err, false)
+			}
+		}(replica)
+	}
+	wg.Wait()
+}
+
+// getFromReplica reads key, or the part of it from offset, from region
+func (b *Backend) getFromReplica(ctx context.Context, region *readRegion, key string, offset, size int64) ([]byte, error) {
+	countAttempt(ctx)
+	input := &s3.GetObjectInput{
+		Bucket:       aws.String(region.bucket),
+		RequestPayer: b.config.requestPayer(),
+		Key:          aws.String(key),
+	}
+	if size > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
+	} else if offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	result, err := region.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, b.translateError(err, "GetObject", key)
+	}
+	defer func() { _ = result.Body.Close() }()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object body from %s: %w", region.region, err)
+	}
+	b.metricsCollector.RecordBytesDownloaded(int64(len(data)))
+	return data, nil
+}
+
+// headFromReplica reads the metadata of key from region
+func (b *Backend) headFromReplica(ctx context.Context, region *readRegion, key string) (*s3.HeadObjectOutput, error) {
+	countAttempt(ctx)
+	return region.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(region.bucket),
+		RequestPayer: b.config.requestPayer(),
+		Key:          aws.String(key),
+	})
+}
+
+// ReadRegions returns the health of the primary and replica regions reads
+// are served from, or nil when no replica regions are configured
+func (b *Backend) ReadRegions() []ReadRegionStats {
+	if b.readFailover == nil {
+		return nil
+	}
+	stats := []ReadRegionStats{b.readFailover.primary.stats(true)}
+	for _, replica := range b.readFailover.replicas {
+		stats = append(stats, replica.stats(false))
+	}
+	return stats
+}
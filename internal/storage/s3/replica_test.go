@@ -0,0 +1,232 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// replicaServer is an S3 endpoint for one region's copy of a bucket. It
+// can be made slow or made to fail every request.
+type replicaServer struct {
+	*httptest.Server
+	bucket  string
+	region  string
+	objects map[string]string
+
+	mu      sync.Mutex
+	delay   time.Duration
+	failing bool
+	gets    int
+}
+
+func newReplicaServer(t *testing.T, bucket, region string, objects map[string]string) *replicaServer {
+	setTestCredentials(t)
+	s := &replicaServer{bucket: bucket, region: region, objects: objects}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *replicaServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delay, failing := s.delay, s.failing
+	if r.Method == http.MethodGet {
+		s.gets++
+	}
+	s.mu.Unlock()
+
+	time.Sleep(delay)
+	w.Header().Set(bucketRegionHeader, s.region)
+	if failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+s.bucket), "/")
+	if key == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	body, ok := s.objects[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	status := http.StatusOK
+	var start, end int
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil {
+		body = body[start : end+1]
+		status = http.StatusPartialContent
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func (s *replicaServer) set(delay time.Duration, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = delay
+	s.failing = failing
+}
+
+func (s *replicaServer) getCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gets
+}
+
+// newFailoverBackend returns a backend reading from primary with the given
+// replicas, keyed by region
+func newFailoverBackend(t *testing.T, primary *replicaServer, threshold time.Duration, replicas ...*replicaServer) *Backend {
+	t.Helper()
+	cfg := newRegionConfig(primary.URL, primary.region)
+	cfg.RecentOps = RecentOpsConfig{Size: 16}
+	cfg.ReadFailover = ReadFailoverConfig{
+		LatencyThreshold: threshold,
+		Buckets:          map[string]string{},
+		Endpoints:        map[string]string{},
+	}
+	for _, replica := range replicas {
+		cfg.ReadReplicaRegions = append(cfg.ReadReplicaRegions, replica.region)
+		cfg.ReadFailover.Buckets[replica.region] = replica.bucket
+		cfg.ReadFailover.Endpoints[replica.region] = replica.URL
+	}
+
+	backend, err := NewBackend(context.Background(), primary.bucket, cfg)
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+	return backend
+}
+
+// lastRegion returns the region that served the latest recorded operation
+func lastRegion(b *Backend) string {
+	ops := b.RecentOperations()
+	if len(ops) == 0 {
+		return ""
+	}
+	return ops[len(ops)-1].Region
+}
+
+func TestReadsFailOverToReplicaRegion(t *testing.T) {
+	objects := map[string]string{"data/a.txt": "replicated"}
+	primary := newReplicaServer(t, "data", "us-east-1", objects)
+	replica := newReplicaServer(t, "data-replica", "eu-west-1", objects)
+	backend := newFailoverBackend(t, primary, 0, replica)
+	ctx := context.Background()
+
+	data, err := backend.GetObject(ctx, "data/a.txt", 0, 0)
+	if err != nil || string(data) != "replicated" {
+		t.Fatalf("GetObject() from a healthy primary = %q, %v", data, err)
+	}
+	if region := lastRegion(backend); region != "us-east-1" {
+		t.Errorf("healthy primary read served by %q, want us-east-1", region)
+	}
+
+	primary.set(0, true)
+	data, err = backend.GetObject(ctx, "data/a.txt", 2, 4)
+	if err != nil {
+		t.Fatalf("GetObject() with a failing primary error = %v", err)
+	}
+	if string(data) != "plic" {
+		t.Errorf("ranged replica read = %q, want %q", data, "plic")
+	}
+	if region := lastRegion(backend); region != "eu-west-1" {
+		t.Errorf("failed-over read served by %q, want eu-west-1", region)
+	}
+
+	info, err := backend.HeadObject(ctx, "data/a.txt")
+	if err != nil || info.Size != int64(len("replicated")) {
+		t.Fatalf("HeadObject() with a failing primary = %+v, %v", info, err)
+	}
+
+	// The primary is now known to be down, so reads skip it
+	before := primary.getCount()
+	if _, err := backend.GetObject(ctx, "data/a.txt", 0, 0); err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	if primary.getCount() != before {
+		t.Error("an unhealthy primary was tried before a healthy replica")
+	}
+
+	regions := backend.ReadRegions()
+	if len(regions) != 2 || regions[0].Healthy || !regions[1].Healthy || regions[1].Reads != 3 {
+		t.Errorf("ReadRegions() = %+v", regions)
+	}
+	if metrics := backend.GetMetrics(); metrics.FailoverReads != 3 {
+		t.Errorf("FailoverReads = %d, want 3", metrics.FailoverReads)
+	}
+
+	// A key missing from a healthy primary is not looked for elsewhere
+	primary.set(0, false)
+	if _, err := backend.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if _, err := backend.GetObject(ctx, "data/missing.txt", 0, 0); err == nil {
+		t.Fatal("GetObject() of a missing key succeeded")
+	}
+	if region := lastRegion(backend); region != "" {
+		t.Errorf("missing key reported as served by %q", region)
+	}
+}
+
+func TestReadFailoverFollowsLatency(t *testing.T) {
+	objects := map[string]string{"a.txt": "a"}
+	primary := newReplicaServer(t, "data", "us-east-1", objects)
+	west := newReplicaServer(t, "data", "us-west-2", objects)
+	europe := newReplicaServer(t, "data", "eu-west-1", objects)
+	backend := newFailoverBackend(t, primary, 20*time.Millisecond, west, europe)
+	ctx := context.Background()
+
+	read := func() string {
+		t.Helper()
+		if _, err := backend.GetObject(ctx, "a.txt", 0, 0); err != nil {
+			t.Fatalf("GetObject() error = %v", err)
+		}
+		return lastRegion(backend)
+	}
+
+	// A primary slower than the threshold hands reads to the fastest replica
+	primary.set(60*time.Millisecond, false)
+	west.set(30*time.Millisecond, false)
+	if _, err := backend.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if region := read(); region != "eu-west-1" {
+		t.Errorf("read served by %q, want the fastest replica eu-west-1", region)
+	}
+
+	// The choice moves as measured latencies change
+	west.set(0, false)
+	europe.set(30*time.Millisecond, false)
+	for i := 0; i < 10; i++ {
+		if _, err := backend.Ping(ctx); err != nil {
+			t.Fatalf("Ping() error = %v", err)
+		}
+	}
+	if region := read(); region != "us-west-2" {
+		t.Errorf("read served by %q, want the now fastest replica us-west-2", region)
+	}
+
+	// Once the primary is fast again it serves reads
+	primary.set(0, false)
+	for i := 0; i < 15; i++ {
+		if _, err := backend.Ping(ctx); err != nil {
+			t.Fatalf("Ping() error = %v", err)
+		}
+	}
+	if region := read(); region != "us-east-1" {
+		t.Errorf("read served by %q, want the primary", region)
+	}
+}
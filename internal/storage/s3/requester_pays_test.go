@@ -0,0 +1,150 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	objerrors "github.com/objectfs/objectfs/pkg/errors"
+)
+
+// requesterPaysServer is an S3 endpoint for a requester-pays bucket. Like
+// S3, it denies object requests that do not accept the charges.
+type requesterPaysServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	payer map[string]string // Request payer header by operation
+}
+
+func newRequesterPaysServer(t *testing.T) *requesterPaysServer {
+	setTestCredentials(t)
+	s := &requesterPaysServer{payer: make(map[string]string)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *requesterPaysServer) serve(w http.ResponseWriter, r *http.Request) {
+	// Bucket requests carry no request payer
+	if r.URL.Path == "/open-data" && r.URL.Query().Get("list-type") == "" {
+		w.Header().Set(bucketRegionHeader, "us-east-1")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	op := r.Method
+	if r.URL.Query().Get("list-type") != "" {
+		op = "LIST"
+	}
+	payer := r.Header.Get("X-Amz-Request-Payer")
+	s.mu.Lock()
+	s.payer[op] = payer
+	s.mu.Unlock()
+
+	if payer != "requester" {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusForbidden)
+		if r.Method != http.MethodHead {
+			_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>"))
+		}
+		return
+	}
+
+	switch op {
+	case "LIST":
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<ListBucketResult><Name>open-data</Name><KeyCount>1</KeyCount><IsTruncated>false</IsTruncated>` +
+			`<Contents><Key>genomes/chr1.fa</Key><Size>4</Size></Contents></ListBucketResult>`))
+	case http.MethodGet:
+		_, _ = w.Write([]byte("ACGT"))
+	case http.MethodHead:
+		w.Header().Set("Content-Length", "4")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *requesterPaysServer) payers() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payers := make(map[string]string, len(s.payer))
+	for op, payer := range s.payer {
+		payers[op] = payer
+	}
+	return payers
+}
+
+func TestRequesterPaysSetsRequestPayer(t *testing.T) {
+	server := newRequesterPaysServer(t)
+	ctx := context.Background()
+
+	cfg := newRegionConfig(server.URL, "us-east-1")
+	cfg.RequesterPays = true
+	backend, err := NewBackend(ctx, "open-data", cfg)
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	if err := backend.PutObject(ctx, "results/run.txt", []byte("done")); err != nil {
+		t.Errorf("PutObject() error = %v", err)
+	}
+	if _, err := backend.GetObject(ctx, "genomes/chr1.fa", 0, 0); err != nil {
+		t.Errorf("GetObject() error = %v", err)
+	}
+	if _, err := backend.HeadObject(ctx, "genomes/chr1.fa"); err != nil {
+		t.Errorf("HeadObject() error = %v", err)
+	}
+	if _, err := backend.ListObjects(ctx, "genomes/", 10); err != nil {
+		t.Errorf("ListObjects() error = %v", err)
+	}
+
+	payers := server.payers()
+	for _, op := range []string{http.MethodPut, http.MethodGet, http.MethodHead, "LIST"} {
+		if payers[op] != "requester" {
+			t.Errorf("%s request payer = %q, want requester", op, payers[op])
+		}
+	}
+
+	// Streamed uploads set it on every multipart request
+	client := &fakeMultipartClient{}
+	upload := streamUpload{bucket: "open-data", key: "results/big.bin", partSize: 4, requestPayer: cfg.requestPayer()}
+	if _, err := streamMultipartUpload(ctx, client, upload, strings.NewReader("0123456789")); err != nil {
+		t.Fatalf("streamMultipartUpload() error = %v", err)
+	}
+	for _, payer := range client.payers {
+		if payer != s3types.RequestPayerRequester {
+			t.Errorf("multipart request payers = %v, want requester on each", client.payers)
+			break
+		}
+	}
+}
+
+func TestRequesterPaysMissingSuggestsFlag(t *testing.T) {
+	server := newRequesterPaysServer(t)
+	ctx := context.Background()
+
+	backend, err := NewBackend(ctx, "open-data", newRegionConfig(server.URL, "us-east-1"))
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	_, err = backend.GetObject(ctx, "genomes/chr1.fa", 0, 0)
+	var objErr *objerrors.ObjectFSError
+	if !errors.As(err, &objErr) || objErr.Code != objerrors.ErrCodeAccessDenied {
+		t.Fatalf("GetObject() without requester pays error = %v, want ErrCodeAccessDenied", err)
+	}
+	if suggestion, _ := objErr.Details["suggestion"].(string); !strings.Contains(suggestion, "requester_pays") {
+		t.Errorf("suggestion = %q, want it to point at requester_pays", suggestion)
+	}
+}
@@ -0,0 +1,207 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// Object Lock retention modes
+const (
+	RetentionGovernance = "GOVERNANCE" // Users with bypass permission may remove retention
+	RetentionCompliance = "COMPLIANCE" // No one may remove retention until it expires
+)
+
+// RetentionConfig applies S3 Object Lock retention to every object written
+type RetentionConfig struct {
+	Mode string `yaml:"mode"` // GOVERNANCE or COMPLIANCE; empty disables retention
+	Days int    `yaml:"days"` // Retention period from the time of each write
+}
+
+// Enabled reports whether objects are written with retention
+func (r RetentionConfig) Enabled() bool {
+	return r.Mode != "" && r.Days > 0
+}
+
+// Validate checks the retention mode and period
+func (r RetentionConfig) Validate() error {
+	if r.Mode == "" {
+		return nil
+	}
+	if r.Mode != RetentionGovernance && r.Mode != RetentionCompliance {
+		return fmt.Errorf("invalid retention mode: %s (must be %s or %s)", r.Mode, RetentionGovernance, RetentionCompliance)
+	}
+	if r.Days <= 0 {
+		return fmt.Errorf("retention days must be greater than 0")
+	}
+	return nil
+}
+
+// ObjectRetention is the Object Lock retention of one object
+type ObjectRetention struct {
+	Mode        string    `json:"mode"`
+	RetainUntil time.Time `json:"retain_until"`
+}
+
+// retentionAPIClient is the subset of the S3 client used for Object Lock
+type retentionAPIClient interface {
+	GetObjectLockConfiguration(ctx context.Context, params *s3.GetObjectLockConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetObjectLockConfigurationOutput, error)
+	GetObjectRetention(ctx context.Context, params *s3.GetObjectRetentionInput, optFns ...func(*s3.Options)) (*s3.GetObjectRetentionOutput, error)
+	PutObjectRetention(ctx context.Context, params *s3.PutObjectRetentionInput, optFns ...func(*s3.Options)) (*s3.PutObjectRetentionOutput, error)
+}
+
+// GetObjectRetention returns the retention of key, or nil when it has none
+func (b *Backend) GetObjectRetention(ctx context.Context, key string) (*ObjectRetention, error) {
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	retention, err := getObjectRetention(ctx, client, b.bucket, key)
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+		return nil, b.translateError(err, "GetObjectRetention", key)
+	}
+	return retention, nil
+}
+
+// PutObjectRetention sets the retention of key. S3 only allows compliance
+// retention to be extended, never shortened.
+func (b *Backend) PutObjectRetention(ctx context.Context, key string, retention ObjectRetention) error {
+	if retention.Mode != RetentionGovernance && retention.Mode != RetentionCompliance {
+		return errors.NewError(errors.ErrCodeValidationFailed, "invalid retention mode").
+			WithComponent("s3-backend").
+			WithOperation("PutObjectRetention").
+			WithContext("key", key).
+			WithContext("mode", retention.Mode)
+	}
+
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	_, err := client.PutObjectRetention(ctx, &s3.PutObjectRetentionInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+		Retention: &s3types.ObjectLockRetention{
+			Mode:            s3types.ObjectLockRetentionMode(retention.Mode),
+			RetainUntilDate: aws.Time(retention.RetainUntil),
+		},
+	})
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+		return b.translateError(err, "PutObjectRetention", key)
+	}
+	return nil
+}
+
+// checkRetention rejects mutation of key while it is retained
+func (b *Backend) checkRetention(ctx context.Context, operation, key string) error {
+	if !b.config.Retention.Enabled() {
+		return nil
+	}
+
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	if err := retentionGuard(ctx, client, b.bucket, operation, key, time.Now()); err != nil {
+		b.metricsCollector.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// retentionHeaders returns the Object Lock mode and retain-until date for
+// an object written at now
+func (r RetentionConfig) retentionHeaders(now time.Time) (s3types.ObjectLockMode, *time.Time) {
+	if !r.Enabled() {
+		return "", nil
+	}
+	return s3types.ObjectLockMode(r.Mode), aws.Time(now.AddDate(0, 0, r.Days))
+}
+
+// getObjectRetention returns the retention of key, or nil when it has none
+func getObjectRetention(ctx context.Context, client retentionAPIClient, bucket, key string) (*ObjectRetention, error) {
+	result, err := client.GetObjectRetention(ctx, &s3.GetObjectRetentionInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNoRetention(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if result.Retention == nil || result.Retention.RetainUntilDate == nil {
+		return nil, nil
+	}
+	return &ObjectRetention{
+		Mode:        string(result.Retention.Mode),
+		RetainUntil: aws.ToTime(result.Retention.RetainUntilDate),
+	}, nil
+}
+
+// retentionGuard returns ErrCodeAccessDenied when key is retained at now.
+// Missing objects and objects without retention may be mutated.
+func retentionGuard(ctx context.Context, client retentionAPIClient, bucket, operation, key string, now time.Time) error {
+	retention, err := getObjectRetention(ctx, client, bucket, key)
+	if err != nil {
+		if isErrorType[*s3types.NoSuchKey](err) || isAPIError(err, "NoSuchKey", "NotFound") {
+			return nil
+		}
+		return fmt.Errorf("failed to check retention of %s: %w", key, err)
+	}
+	if retention == nil || !now.Before(retention.RetainUntil) {
+		return nil
+	}
+
+	return errors.NewError(errors.ErrCodeAccessDenied, "object is under retention").
+		WithComponent("s3-backend").
+		WithOperation(operation).
+		WithContext("key", key).
+		WithContext("retention_mode", retention.Mode).
+		WithContext("retain_until", retention.RetainUntil.UTC().Format(time.RFC3339))
+}
+
+// validateObjectLock checks that bucket has Object Lock enabled
+func validateObjectLock(ctx context.Context, client retentionAPIClient, bucket string) error {
+	result, err := client.GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil && !isAPIError(err, "ObjectLockConfigurationNotFoundError") {
+		return fmt.Errorf("failed to read object lock configuration: %w", err)
+	}
+	if err != nil || result.ObjectLockConfiguration == nil ||
+		result.ObjectLockConfiguration.ObjectLockEnabled != s3types.ObjectLockEnabledEnabled {
+		return errors.NewError(errors.ErrCodeInvalidConfig, "retention requires a bucket with object lock enabled").
+			WithComponent("s3-backend").
+			WithOperation("NewBackend").
+			WithContext("bucket", bucket)
+	}
+	return nil
+}
+
+// isNoRetention reports whether err means the object has no retention set
+func isNoRetention(err error) bool {
+	return isAPIError(err, "NoSuchObjectLockConfiguration", "ObjectLockConfigurationNotFoundError")
+}
+
+// isAPIError reports whether err is an S3 API error with one of codes
+func isAPIError(err error, codes ...string) bool {
+	var apiErr smithy.APIError
+	if !stderr.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range codes {
+		if strings.EqualFold(apiErr.ErrorCode(), code) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	objerrors "github.com/objectfs/objectfs/pkg/errors"
+)
+
+// fakeRetentionClient holds Object Lock retention per key
+type fakeRetentionClient struct {
+	lockEnabled bool
+	retention   map[string]ObjectRetention
+}
+
+func (f *fakeRetentionClient) GetObjectLockConfiguration(ctx context.Context, input *s3.GetObjectLockConfigurationInput, _ ...func(*s3.Options)) (*s3.GetObjectLockConfigurationOutput, error) {
+	if !f.lockEnabled {
+		return nil, &smithy.GenericAPIError{Code: "ObjectLockConfigurationNotFoundError"}
+	}
+	return &s3.GetObjectLockConfigurationOutput{
+		ObjectLockConfiguration: &s3types.ObjectLockConfiguration{ObjectLockEnabled: s3types.ObjectLockEnabledEnabled},
+	}, nil
+}
+
+func (f *fakeRetentionClient) GetObjectRetention(ctx context.Context, input *s3.GetObjectRetentionInput, _ ...func(*s3.Options)) (*s3.GetObjectRetentionOutput, error) {
+	retention, ok := f.retention[aws.ToString(input.Key)]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchObjectLockConfiguration"}
+	}
+	return &s3.GetObjectRetentionOutput{
+		Retention: &s3types.ObjectLockRetention{
+			Mode:            s3types.ObjectLockRetentionMode(retention.Mode),
+			RetainUntilDate: aws.Time(retention.RetainUntil),
+		},
+	}, nil
+}
+
+func (f *fakeRetentionClient) PutObjectRetention(ctx context.Context, input *s3.PutObjectRetentionInput, _ ...func(*s3.Options)) (*s3.PutObjectRetentionOutput, error) {
+	f.retention[aws.ToString(input.Key)] = ObjectRetention{
+		Mode:        string(input.Retention.Mode),
+		RetainUntil: aws.ToTime(input.Retention.RetainUntilDate),
+	}
+	return &s3.PutObjectRetentionOutput{}, nil
+}
+
+func TestRetentionGuardRejectsUntilExpiry(t *testing.T) {
+	written := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mode, until := RetentionConfig{Mode: RetentionCompliance, Days: 30}.retentionHeaders(written)
+	client := &fakeRetentionClient{retention: map[string]ObjectRetention{
+		"records/a.pdf": {Mode: string(mode), RetainUntil: *until},
+	}}
+	ctx := context.Background()
+
+	err := retentionGuard(ctx, client, "bucket", "DeleteObject", "records/a.pdf", written.AddDate(0, 0, 29))
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeAccessDenied, "")) {
+		t.Fatalf("delete during retention error = %v, want access denied", err)
+	}
+
+	if err := retentionGuard(ctx, client, "bucket", "DeleteObject", "records/a.pdf", written.AddDate(0, 0, 30)); err != nil {
+		t.Errorf("delete after retention expired failed: %v", err)
+	}
+	if err := retentionGuard(ctx, client, "bucket", "DeleteObject", "records/unlocked.txt", written); err != nil {
+		t.Errorf("delete of an object without retention failed: %v", err)
+	}
+}
+
+func TestValidateObjectLock(t *testing.T) {
+	ctx := context.Background()
+	if err := validateObjectLock(ctx, &fakeRetentionClient{lockEnabled: true}, "bucket"); err != nil {
+		t.Errorf("bucket with object lock rejected: %v", err)
+	}
+	err := validateObjectLock(ctx, &fakeRetentionClient{}, "bucket")
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeInvalidConfig, "")) {
+		t.Errorf("bucket without object lock error = %v, want invalid config", err)
+	}
+}
+
+func TestRetentionConfigValidate(t *testing.T) {
+	tests := []struct {
+		config  RetentionConfig
+		wantErr bool
+	}{
+		{RetentionConfig{}, false},
+		{RetentionConfig{Mode: RetentionGovernance, Days: 7}, false},
+		{RetentionConfig{Mode: RetentionCompliance}, true},
+		{RetentionConfig{Mode: "LEGAL_HOLD", Days: 7}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
+
+// retentionServer is an S3 endpoint for a bucket with Object Lock enabled,
+// where retained keys are under compliance retention. It records the
+// writes it is sent.
+type retentionServer struct {
+	*httptest.Server
+	retained map[string]bool
+
+	mu       sync.Mutex
+	writes   []string
+	lockMode string // Object Lock mode requested for the last multipart upload
+}
+
+func newRetentionServer(t *testing.T, retained ...string) *retentionServer {
+	setTestCredentials(t)
+	s := &retentionServer{retained: make(map[string]bool)}
+	for _, key := range retained {
+		s.retained[key] = true
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *retentionServer) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(bucketRegionHeader, "us-east-1")
+	query := r.URL.Query()
+	key := strings.TrimPrefix(r.URL.Path, "/locked/")
+	w.Header().Set("Content-Type", "application/xml")
+
+	switch {
+	case query.Has("object-lock"):
+		fmt.Fprint(w, "<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>")
+	case query.Has("retention"):
+		if !s.retained[key] {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchObjectLockConfiguration</Code></Error>")
+			return
+		}
+		until := time.Now().AddDate(1, 0, 0).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, "<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>%s</RetainUntilDate></Retention>", until)
+	case r.Method == http.MethodHead && key != "":
+		w.Header().Set("Content-Length", "4")
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.record("copy " + key)
+		fmt.Fprint(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+	case r.Method == http.MethodDelete:
+		s.record("delete " + key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.record("create " + key)
+		s.mu.Lock()
+		s.lockMode = r.Header.Get("X-Amz-Object-Lock-Mode")
+		s.mu.Unlock()
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Key>%s</Key><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>", key)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		w.Header().Set("ETag", `"part"`)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Key>%s</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`, key)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *retentionServer) record(write string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = append(s.writes, write)
+}
+
+func newRetentionBackend(t *testing.T, server *retentionServer) *Backend {
+	t.Helper()
+	cfg := newRegionConfig(server.URL, "us-east-1")
+	cfg.Retention = RetentionConfig{Mode: RetentionCompliance, Days: 30}
+	backend, err := NewBackend(context.Background(), "locked", cfg)
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	return backend
+}
+
+func TestMoveObjectChecksRetentionBeforeCopying(t *testing.T) {
+	server := newRetentionServer(t, "records/retained.pdf")
+	backend := newRetentionBackend(t, server)
+	ctx := context.Background()
+	denied := objerrors.NewError(objerrors.ErrCodeAccessDenied, "")
+
+	if err := backend.MoveObject(ctx, "records/retained.pdf", "records/moved.pdf"); !errors.Is(err, denied) {
+		t.Errorf("moving a retained source error = %v, want access denied", err)
+	}
+	if err := backend.MoveObject(ctx, "records/new.pdf", "records/retained.pdf"); !errors.Is(err, denied) {
+		t.Errorf("moving onto a retained destination error = %v, want access denied", err)
+	}
+	if len(server.writes) != 0 {
+		t.Fatalf("refused moves sent writes %v, want none", server.writes)
+	}
+
+	if err := backend.MoveObject(ctx, "records/new.pdf", "records/moved.pdf"); err != nil {
+		t.Fatalf("MoveObject() of unretained keys error = %v", err)
+	}
+	if strings.Join(server.writes, ",") != "copy records/moved.pdf,delete records/new.pdf" {
+		t.Errorf("writes = %v, want a copy then a delete", server.writes)
+	}
+}
+
+func TestPutObjectStreamAppliesRetention(t *testing.T) {
+	server := newRetentionServer(t, "records/retained.pdf")
+	backend := newRetentionBackend(t, server)
+	ctx := context.Background()
+
+	err := backend.PutObjectStream(ctx, "records/retained.pdf", strings.NewReader("data"))
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeAccessDenied, "")) {
+		t.Errorf("streaming over a retained object error = %v, want access denied", err)
+	}
+	if len(server.writes) != 0 {
+		t.Fatalf("refused stream sent writes %v, want none", server.writes)
+	}
+
+	if err := backend.PutObjectStream(ctx, "records/new.pdf", strings.NewReader("data")); err != nil {
+		t.Fatalf("PutObjectStream() error = %v", err)
+	}
+	if server.lockMode != RetentionCompliance {
+		t.Errorf("multipart upload Object Lock mode = %q, want %s", server.lockMode, RetentionCompliance)
+	}
+}
@@ -0,0 +1,243 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	stderr "errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// errObjectTooLarge reports a write that would exceed the object size limit
+var errObjectTooLarge = stderr.New("object exceeds maximum size")
+
+// multipartAPIClient is the subset of the S3 client used for streaming uploads
+type multipartAPIClient interface {
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	checksumAPIClient
+}
+
+// streamUpload describes a multipart upload of unknown length
+type streamUpload struct {
+	bucket       string
+	key          string
+	contentType  string
+	storageClass s3types.StorageClass
+	requestPayer s3types.RequestPayer
+	partSize     int64
+	maxSize      int64     // 0 for unlimited
+	checksum     string    // Streaming checksum algorithm; empty for none
+	progress     *transfer // nil when not reporting progress
+
+	// Object Lock retention of the object; empty when retention is off
+	lockMode    s3types.ObjectLockMode
+	retainUntil *time.Time
+}
+
+// PutObjectStream uploads an object of unknown length from r using a
+// multipart upload. Once the stream passes the object size limit for key,
+// the upload is aborted and no further parts are sent. With a configured
+// StreamChecksum, the content is hashed as it is read and the checksum is
+// stored in the object's metadata once the upload completes.
+func (b *Backend) PutObjectStream(ctx context.Context, key string, r io.Reader) error {
+	start := time.Now()
+	defer func() {
+		b.metricsCollector.RecordMetrics(time.Since(start), false)
+	}()
+
+	if !b.healthTracker.CanWrite("s3-writes") {
+		state := b.healthTracker.GetState("s3-writes")
+		return errors.NewError(errors.ErrCodeServiceUnavailable, "S3 write operations are unavailable").
+			WithComponent("s3-backend").
+			WithOperation("PutObjectStream").
+			WithContext("health_state", state.String()).
+			WithContext("bucket", b.bucket).
+			WithContext("key", key)
+	}
+
+	if err := b.checkRetention(ctx, "PutObjectStream", key); err != nil {
+		return err
+	}
+
+	partSize := b.config.MultipartChunkSize
+	if partSize <= 0 {
+		partSize = 16 * 1024 * 1024
+	}
+	upload := streamUpload{
+		bucket:       b.bucket,
+		key:          key,
+		contentType:  b.detectContentType(key),
+		storageClass: ConvertTierToStorageClass(b.currentTier),
+		requestPayer: b.config.requestPayer(),
+		partSize:     partSize,
+		maxSize:      b.config.MaxObjectSize.Limit(key),
+		checksum:     b.config.StreamChecksum,
+		progress:     b.transfers.start(ctx, "PutObjectStream", key, -1, b.config.ProgressInterval),
+	}
+	upload.lockMode, upload.retainUntil = b.config.Retention.retentionHeaders(time.Now())
+	defer b.transfers.done(upload.progress)
+
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	written, err := streamMultipartUpload(ctx, client, upload, r)
+	if err != nil {
+		if stderr.Is(err, errObjectTooLarge) {
+			return b.objectTooLarge("PutObjectStream", key, upload.maxSize)
+		}
+		b.metricsCollector.RecordError(err)
+		translatedErr := b.translateError(err, "PutObjectStream", key)
+		b.healthTracker.RecordError("s3-writes", translatedErr)
+		return translatedErr
+	}
+
+	// Multipart uploads need at least one part, so empty streams use PutObject
+	if written == 0 {
+		var metadata map[string]string
+		if upload.checksum != "" {
+			sum, _ := ObjectChecksum(upload.checksum, nil)
+			metadata = checksumMetadata(upload.checksum, sum)
+		}
+		return b.putObject(ctx, key, nil, metadata)
+	}
+
+	upload.progress.finish()
+	b.metricsCollector.RecordBytesUploaded(written)
+	b.healthTracker.RecordSuccess("s3-writes")
+	b.metadataIndex.invalidate(key)
+	return nil
+}
+
+// objectTooLarge builds the error returned when a write exceeds the limit
+func (b *Backend) objectTooLarge(operation, key string, limit int64) error {
+	return errors.NewError(errors.ErrCodeLimitExceeded, "object exceeds maximum size").
+		WithComponent("s3-backend").
+		WithOperation(operation).
+		WithContext("bucket", b.bucket).
+		WithContext("key", key).
+		WithDetail("max_object_size", limit)
+}
+
+// streamMultipartUpload reads r in parts and uploads them in order,
+// returning the bytes written. The upload is created on the first non-empty
+// part and aborted if reading, uploading, or the size limit fails. With a
+// checksum algorithm, r is hashed through a tee and the checksum is recorded
+// once the upload completes, unless the object is too large to copy.
+func streamMultipartUpload(ctx context.Context, client multipartAPIClient, upload streamUpload, r io.Reader) (int64, error) {
+	var (
+		uploadID string
+		parts    []s3types.CompletedPart
+		written  int64
+	)
+
+	checksum := newChecksumHash(upload.checksum)
+	if checksum != nil {
+		r = io.TeeReader(r, checksum)
+	}
+
+	abort := func(cause error) (int64, error) {
+		if uploadID == "" {
+			return written, cause
+		}
+		// Clean up even when the caller's context was cancelled
+		_, abortErr := client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:       aws.String(upload.bucket),
+			Key:          aws.String(upload.key),
+			UploadId:     aws.String(uploadID),
+			RequestPayer: upload.requestPayer,
+		})
+		if abortErr != nil {
+			return written, fmt.Errorf("%w (abort failed: %v)", cause, abortErr)
+		}
+		return written, cause
+	}
+
+	buf := make([]byte, upload.partSize)
+	for partNumber := int32(1); ; partNumber++ {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return abort(fmt.Errorf("failed to read upload stream: %w", readErr))
+		}
+		if n == 0 {
+			break
+		}
+
+		if upload.maxSize > 0 && written+int64(n) > upload.maxSize {
+			return abort(fmt.Errorf("%w: limit is %d bytes", errObjectTooLarge, upload.maxSize))
+		}
+
+		if uploadID == "" {
+			created, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+				Bucket:       aws.String(upload.bucket),
+				Key:          aws.String(upload.key),
+				ContentType:  aws.String(upload.contentType),
+				StorageClass: upload.storageClass,
+				RequestPayer: upload.requestPayer,
+
+				ObjectLockMode:            upload.lockMode,
+				ObjectLockRetainUntilDate: upload.retainUntil,
+			})
+			if err != nil {
+				return 0, err
+			}
+			uploadID = aws.ToString(created.UploadId)
+		}
+
+		result, err := client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(upload.bucket),
+			Key:           aws.String(upload.key),
+			UploadId:      aws.String(uploadID),
+			PartNumber:    aws.Int32(partNumber),
+			Body:          bytes.NewReader(buf[:n]),
+			ContentLength: aws.Int64(int64(n)),
+			RequestPayer:  upload.requestPayer,
+		})
+		if err != nil {
+			return abort(err)
+		}
+		parts = append(parts, s3types.CompletedPart{
+			PartNumber: aws.Int32(partNumber),
+			ETag:       result.ETag,
+		})
+		written += int64(n)
+		upload.progress.add(int64(n))
+
+		if readErr != nil {
+			break // Short read means the stream ended
+		}
+	}
+
+	if uploadID == "" {
+		return 0, nil
+	}
+
+	completed, err := client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(upload.bucket),
+		Key:             aws.String(upload.key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: parts},
+		RequestPayer:    upload.requestPayer,
+	})
+	if err != nil {
+		return abort(err)
+	}
+
+	if checksum != nil && written <= maxCopyObjectSize {
+		sum := hex.EncodeToString(checksum.Sum(nil))
+		if err := recordChecksum(ctx, client, upload, completed.ETag, sum); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
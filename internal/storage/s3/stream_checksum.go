@@ -0,0 +1,198 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// Streaming checksum algorithms
+const (
+	ChecksumCRC32C = "crc32c"
+	ChecksumSHA256 = "sha256"
+)
+
+// metadataChecksumPrefix prefixes the user metadata key holding a streamed
+// object's checksum; the algorithm name follows it
+const metadataChecksumPrefix = "checksum-"
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// validateStreamChecksum checks a configured streaming checksum algorithm
+func validateStreamChecksum(algorithm string) error {
+	switch algorithm {
+	case "", ChecksumCRC32C, ChecksumSHA256:
+		return nil
+	}
+	return fmt.Errorf("invalid stream checksum: %s (must be %s or %s)", algorithm, ChecksumCRC32C, ChecksumSHA256)
+}
+
+// newChecksumHash returns a hash for algorithm, or nil when it is unknown
+func newChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case ChecksumCRC32C:
+		return crc32.New(crc32cTable)
+	case ChecksumSHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
+// ObjectChecksum returns the hex checksum of data with algorithm, matching
+// the value PutObjectStream stores for the same content
+func ObjectChecksum(algorithm string, data []byte) (string, error) {
+	h := newChecksumHash(algorithm)
+	if h == nil {
+		return "", validateStreamChecksum(algorithm)
+	}
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checksumMetadata returns the user metadata recording sum under algorithm
+func checksumMetadata(algorithm, sum string) map[string]string {
+	return map[string]string{metadataChecksumPrefix + algorithm: sum}
+}
+
+// storedChecksum returns the first checksum recorded in metadata, preferring
+// SHA-256, or empty strings when there is none
+func storedChecksum(metadata map[string]string) (algorithm, sum string) {
+	for _, algorithm := range []string{ChecksumSHA256, ChecksumCRC32C} {
+		if sum := metadata[metadataChecksumPrefix+algorithm]; sum != "" {
+			return algorithm, sum
+		}
+	}
+	return "", ""
+}
+
+// checksumAPIClient is the subset of the S3 client used to record the
+// checksum of a completed streaming upload
+type checksumAPIClient interface {
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+}
+
+// recordChecksum stores sum in the metadata of the object a streaming upload
+// just completed. The object is copied onto itself, and only if it is still
+// the version with etag, so a concurrent write keeps its own metadata.
+func recordChecksum(ctx context.Context, client checksumAPIClient, upload streamUpload, etag *string, sum string) error {
+	_, err := client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(upload.bucket),
+		Key:               aws.String(upload.key),
+		CopySource:        aws.String(copySource(upload.bucket, upload.key)),
+		CopySourceIfMatch: etag,
+		MetadataDirective: s3types.MetadataDirectiveReplace,
+		Metadata:          checksumMetadata(upload.checksum, sum),
+		ContentType:       aws.String(upload.contentType),
+		StorageClass:      upload.storageClass,
+		RequestPayer:      upload.requestPayer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record %s checksum: %w", upload.checksum, err)
+	}
+	return nil
+}
+
+// verifyingReader hashes an object body as it is read and, at the end of the
+// body, fails the read if the content does not match the stored checksum
+type verifyingReader struct {
+	body      io.ReadCloser
+	tee       io.Reader
+	hash      hash.Hash
+	algorithm string
+	want      string
+	key       string
+}
+
+// newVerifyingReader wraps body to verify it against the checksum in
+// metadata, or returns body unchanged when none was recorded
+func newVerifyingReader(body io.ReadCloser, key string, metadata map[string]string) io.ReadCloser {
+	algorithm, want := storedChecksum(metadata)
+	h := newChecksumHash(algorithm)
+	if h == nil {
+		return body
+	}
+	return &verifyingReader{
+		body:      body,
+		tee:       io.TeeReader(body, h),
+		hash:      h,
+		algorithm: algorithm,
+		want:      want,
+		key:       key,
+	}
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.tee.Read(p)
+	if err == io.EOF {
+		if got := hex.EncodeToString(v.hash.Sum(nil)); got != v.want {
+			return n, errors.NewError(errors.ErrCodeStorageRead, "object checksum mismatch").
+				WithComponent("s3-backend").
+				WithOperation("GetObjectStream").
+				WithContext("key", v.key).
+				WithDetail("algorithm", v.algorithm).
+				WithDetail("expected", v.want).
+				WithDetail("actual", got)
+		}
+	}
+	return n, err
+}
+
+func (v *verifyingReader) Close() error {
+	return v.body.Close()
+}
+
+// GetObjectStream opens an object for reading without buffering it. When the
+// object was written by PutObjectStream with a checksum, the body is hashed
+// as it is read and the final read fails with ErrCodeStorageRead if the
+// content does not match. The caller must close the returned body.
+func (b *Backend) GetObjectStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	start := time.Now()
+	defer func() {
+		b.metricsCollector.RecordMetrics(time.Since(start), false)
+	}()
+
+	if !b.healthTracker.CanRead("s3-reads") {
+		state := b.healthTracker.GetState("s3-reads")
+		return nil, errors.NewError(errors.ErrCodeServiceUnavailable, "S3 read operations are unavailable").
+			WithComponent("s3-backend").
+			WithOperation("GetObjectStream").
+			WithContext("health_state", state.String()).
+			WithContext("bucket", b.bucket).
+			WithContext("key", key)
+	}
+
+	if err := b.costBudget.reserve(ctx, "GetObjectStream", key, b.costBudget.estimate("GET", b.currentTier, 0)); err != nil {
+		return nil, err
+	}
+
+	client := b.clientManager.GetPooledClient()
+	defer b.clientManager.ReturnPooledClient(client)
+
+	result, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:       aws.String(b.bucket),
+		Key:          aws.String(key),
+		RequestPayer: b.config.requestPayer(),
+	})
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+		translatedErr := b.translateError(err, "GetObjectStream", key)
+		b.healthTracker.RecordError("s3-reads", translatedErr)
+		return nil, translatedErr
+	}
+
+	b.metricsCollector.RecordBytesDownloaded(aws.ToInt64(result.ContentLength))
+	b.healthTracker.RecordSuccess("s3-reads")
+	b.accessIndex.record(key, time.Now())
+	return newVerifyingReader(result.Body, key, result.Metadata), nil
+}
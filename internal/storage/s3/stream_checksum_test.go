@@ -0,0 +1,67 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	stderr "errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+func TestStreamChecksumMatchesFullBufferChecksum(t *testing.T) {
+	data := bytes.Repeat([]byte("objectfs streaming checksum "), 100)
+
+	for _, algorithm := range []string{ChecksumCRC32C, ChecksumSHA256} {
+		want, err := ObjectChecksum(algorithm, data)
+		if err != nil {
+			t.Fatalf("ObjectChecksum(%s) error = %v", algorithm, err)
+		}
+
+		// Odd part sizes and one-byte reads exercise the incremental hash
+		client := &fakeMultipartClient{}
+		upload := streamUpload{bucket: "bucket", key: "data.bin", partSize: 333, checksum: algorithm}
+		if _, err := streamMultipartUpload(context.Background(), client, upload, iotest.OneByteReader(bytes.NewReader(data))); err != nil {
+			t.Fatalf("%s: streamMultipartUpload() error = %v", algorithm, err)
+		}
+		got := client.metadata[metadataChecksumPrefix+algorithm]
+		if got != want {
+			t.Errorf("%s: streamed checksum = %q, want the full-buffer checksum %q", algorithm, got, want)
+		}
+
+		// Downloading the uploaded content verifies against the stored checksum
+		body := newVerifyingReader(io.NopCloser(bytes.NewReader(client.body)), "data.bin", client.metadata)
+		if read, err := io.ReadAll(body); err != nil || !bytes.Equal(read, data) {
+			t.Errorf("%s: verified download = %d bytes, err = %v", algorithm, len(read), err)
+		}
+	}
+
+	if _, err := ObjectChecksum("md5", data); err == nil {
+		t.Error("ObjectChecksum accepted an unsupported algorithm")
+	}
+}
+
+func TestStreamChecksumDetectsCorruption(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+	corrupted := append([]byte(nil), data...)
+	corrupted[10] ^= 0x01
+
+	for _, algorithm := range []string{ChecksumCRC32C, ChecksumSHA256} {
+		sum, _ := ObjectChecksum(algorithm, data)
+		body := newVerifyingReader(io.NopCloser(bytes.NewReader(corrupted)), "fox.txt", checksumMetadata(algorithm, sum))
+
+		_, err := io.ReadAll(body)
+		var objErr *errors.ObjectFSError
+		if !stderr.As(err, &objErr) || objErr.Code != errors.ErrCodeStorageRead {
+			t.Errorf("%s: reading corrupted content error = %v, want a checksum mismatch", algorithm, err)
+		}
+	}
+
+	// Objects written without a checksum are passed through unverified
+	body := newVerifyingReader(io.NopCloser(bytes.NewReader(corrupted)), "fox.txt", nil)
+	if _, err := io.ReadAll(body); err != nil {
+		t.Errorf("reading an object without a checksum error = %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeMultipartClient records multipart upload calls
+type fakeMultipartClient struct {
+	created   int
+	uploaded  int64
+	parts     int
+	completed bool
+	abortedID string
+	payers    []s3types.RequestPayer
+	body      []byte            // Content of the uploaded parts
+	metadata  map[string]string // Metadata of the last CopyObject
+}
+
+func (f *fakeMultipartClient) CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.created++
+	f.payers = append(f.payers, input.RequestPayer)
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (f *fakeMultipartClient) UploadPart(ctx context.Context, input *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	part, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.body = append(f.body, part...)
+	f.uploaded += int64(len(part))
+	f.payers = append(f.payers, input.RequestPayer)
+	f.parts++
+	return &s3.UploadPartOutput{ETag: aws.String("etag")}, nil
+}
+
+func (f *fakeMultipartClient) CompleteMultipartUpload(ctx context.Context, input *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.completed = true
+	f.payers = append(f.payers, input.RequestPayer)
+	return &s3.CompleteMultipartUploadOutput{ETag: aws.String("etag-complete")}, nil
+}
+
+func (f *fakeMultipartClient) CopyObject(ctx context.Context, input *s3.CopyObjectInput, _ ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	if aws.ToString(input.CopySourceIfMatch) != "etag-complete" {
+		return nil, errors.New("copy of an object other than the completed upload")
+	}
+	f.metadata = input.Metadata
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (f *fakeMultipartClient) AbortMultipartUpload(ctx context.Context, input *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.abortedID = aws.ToString(input.UploadId)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func TestStreamMultipartUpload_AbortsPastLimit(t *testing.T) {
+	client := &fakeMultipartClient{}
+	upload := streamUpload{bucket: "bucket", key: "big.bin", partSize: 4, maxSize: 10}
+
+	written, err := streamMultipartUpload(context.Background(), client, upload, strings.NewReader(strings.Repeat("x", 20)))
+	if !errors.Is(err, errObjectTooLarge) {
+		t.Fatalf("streamMultipartUpload() error = %v, want errObjectTooLarge", err)
+	}
+	if client.abortedID != "upload-1" {
+		t.Errorf("aborted upload %q, want upload-1", client.abortedID)
+	}
+	if client.completed {
+		t.Error("upload past the limit was completed")
+	}
+	if client.uploaded > upload.maxSize || written > upload.maxSize {
+		t.Errorf("uploaded %d bytes (written %d), want at most %d", client.uploaded, written, upload.maxSize)
+	}
+}
+
+func TestStreamMultipartUpload_CompletesWithinLimit(t *testing.T) {
+	client := &fakeMultipartClient{}
+	upload := streamUpload{bucket: "bucket", key: "ok.bin", partSize: 4, maxSize: 10}
+
+	written, err := streamMultipartUpload(context.Background(), client, upload, strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatalf("streamMultipartUpload() error = %v", err)
+	}
+	if written != 10 || client.parts != 3 || !client.completed {
+		t.Errorf("written = %d, parts = %d, completed = %v; want 10, 3, true", written, client.parts, client.completed)
+	}
+	if client.abortedID != "" {
+		t.Errorf("upload within the limit was aborted")
+	}
+}
+
+func TestStreamMultipartUpload_EmptyStream(t *testing.T) {
+	client := &fakeMultipartClient{}
+	written, err := streamMultipartUpload(context.Background(), client, streamUpload{partSize: 4}, strings.NewReader(""))
+	if err != nil || written != 0 || client.created != 0 {
+		t.Errorf("empty stream: written = %d, err = %v, created = %d", written, err, client.created)
+	}
+}
@@ -0,0 +1,211 @@
+package s3
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ObjectStats describes an object for tier policy evaluation
+type ObjectStats struct {
+	Key             string        `json:"key"`
+	Size            int64         `json:"size"`
+	Age             time.Duration `json:"age"`
+	AccessFrequency string        `json:"access_frequency"`
+	CurrentTier     string        `json:"current_tier"`
+	LastAccess      time.Time     `json:"last_access,omitempty"` // Zero when unknown
+}
+
+// TierDecision is the outcome of a tier policy evaluation
+type TierDecision struct {
+	Tier            string   `json:"tier"`            // Recommended tier (empty to keep the current tier)
+	Reason          string   `json:"reason"`          // Human-readable reason for the decision
+	Recommendations []string `json:"recommendations"` // Advisory notes for the caller
+}
+
+// TierPolicy decides which storage tier an object belongs in
+type TierPolicy interface {
+	Recommend(obj ObjectStats) TierDecision
+}
+
+// NewTierPolicy creates the tier policy described by cfg, falling back to
+// the default heuristics when no rules are configured
+func NewTierPolicy(cfg TierPolicyConfig) (TierPolicy, error) {
+	if len(cfg.Rules) == 0 {
+		return DefaultTierPolicy{}, nil
+	}
+	return NewRuleBasedTierPolicy(cfg.Rules, DefaultTierPolicy{})
+}
+
+// DefaultTierPolicy implements the built-in size and access frequency heuristics
+type DefaultTierPolicy struct{}
+
+// Recommend returns the heuristic tier decision for an object
+func (DefaultTierPolicy) Recommend(obj ObjectStats) TierDecision {
+	return TierDecision{
+		Tier:            defaultOptimalTier(obj.Size, obj.AccessFrequency),
+		Reason:          defaultTierReason(obj.AccessFrequency),
+		Recommendations: defaultTierRecommendations(obj.Size, obj.AccessFrequency, obj.CurrentTier),
+	}
+}
+
+// defaultOptimalTier finds the most cost-effective tier for an access pattern
+func defaultOptimalTier(objectSize int64, accessFreq string) string {
+	objectSizeGB := float64(objectSize) / (1024 * 1024 * 1024)
+
+	// Handle Standard tier overhead: small objects often stay in Standard
+	if objectSize < 128*1024 && accessFreq != AccessNever {
+		return TierStandard // Avoid IA minimum charges for small, accessed objects
+	}
+
+	switch accessFreq {
+	case AccessFrequent:
+		return TierStandard
+	case AccessInfrequent:
+		if objectSize >= 128*1024 { // Meet IA minimum size
+			return TierStandardIA
+		}
+		return TierStandard
+	case AccessArchive:
+		if objectSize >= 128*1024 {
+			return TierGlacierIR
+		}
+		return TierStandardIA
+	case AccessCold, AccessNever:
+		if objectSizeGB > 1.0 { // Large objects benefit more from deep archive
+			return TierGlacier
+		}
+		return TierGlacierIR
+	default:
+		return TierIntelligent // Let AWS decide
+	}
+}
+
+// defaultTierReason generates a human-readable reason for a heuristic decision
+func defaultTierReason(accessFreq string) string {
+	switch accessFreq {
+	case AccessFrequent:
+		return "High access frequency - Standard tier optimal"
+	case AccessInfrequent:
+		return "Infrequent access pattern - IA tier more cost-effective"
+	case AccessArchive:
+		return "Archive access pattern - Glacier tier significant savings"
+	case AccessCold, AccessNever:
+		return "Rarely accessed - Deep archive substantial cost reduction"
+	default:
+		return "Access pattern suggests tier optimization opportunity"
+	}
+}
+
+// defaultTierRecommendations returns advisory notes based on size and access patterns
+func defaultTierRecommendations(objectSize int64, accessFrequency, currentTier string) []string {
+	recommendations := make([]string, 0, 3)
+
+	// Size-based recommendations
+	if objectSize < 128*1024 {
+		recommendations = append(recommendations, "Consider Standard tier for small objects to avoid IA minimum charges")
+	}
+
+	// Access pattern recommendations
+	switch accessFrequency {
+	case AccessFrequent:
+		if currentTier != TierStandard {
+			recommendations = append(recommendations, "Consider Standard tier for frequently accessed data")
+		}
+	case AccessInfrequent:
+		if currentTier == TierStandard {
+			recommendations = append(recommendations, "Consider Standard-IA or One Zone-IA for cost savings")
+		}
+	case AccessArchive:
+		if currentTier != TierGlacierIR && currentTier != TierGlacier {
+			recommendations = append(recommendations, "Consider Glacier tiers for archive data")
+		}
+	case "unknown":
+		if currentTier != TierIntelligent {
+			recommendations = append(recommendations, "Consider Intelligent Tiering for unknown access patterns")
+		}
+	}
+
+	return recommendations
+}
+
+// RuleBasedTierPolicy applies user-defined rules in order, deferring to a
+// fallback policy when no rule matches
+type RuleBasedTierPolicy struct {
+	rules    []TierPolicyRule
+	fallback TierPolicy
+}
+
+// NewRuleBasedTierPolicy creates a rule-based tier policy
+func NewRuleBasedTierPolicy(rules []TierPolicyRule, fallback TierPolicy) (*RuleBasedTierPolicy, error) {
+	for i, rule := range rules {
+		if _, exists := StorageTiers[rule.Tier]; !exists {
+			return nil, fmt.Errorf("tier policy rule %d: unsupported storage tier: %s", i, rule.Tier)
+		}
+		if rule.MaxSize > 0 && rule.MaxSize < rule.MinSize {
+			return nil, fmt.Errorf("tier policy rule %d: max_size must be greater than min_size", i)
+		}
+		if rule.MaxAge > 0 && rule.MaxAge < rule.MinAge {
+			return nil, fmt.Errorf("tier policy rule %d: max_age must be greater than min_age", i)
+		}
+	}
+
+	if fallback == nil {
+		fallback = DefaultTierPolicy{}
+	}
+
+	return &RuleBasedTierPolicy{
+		rules:    rules,
+		fallback: fallback,
+	}, nil
+}
+
+// Recommend returns the decision of the first matching rule
+func (p *RuleBasedTierPolicy) Recommend(obj ObjectStats) TierDecision {
+	for _, rule := range p.rules {
+		if !rule.matches(obj) {
+			continue
+		}
+
+		reason := rule.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("Matched tier policy rule for prefix %q", rule.Prefix)
+		}
+
+		decision := TierDecision{
+			Tier:            rule.Tier,
+			Reason:          reason,
+			Recommendations: make([]string, 0, 1),
+		}
+		if obj.CurrentTier != rule.Tier {
+			decision.Recommendations = append(decision.Recommendations,
+				fmt.Sprintf("Use %s tier: %s", rule.Tier, reason))
+		}
+		return decision
+	}
+
+	return p.fallback.Recommend(obj)
+}
+
+// matches reports whether an object satisfies every criterion of the rule
+func (r TierPolicyRule) matches(obj ObjectStats) bool {
+	if r.Prefix != "" && !strings.HasPrefix(obj.Key, r.Prefix) {
+		return false
+	}
+	if obj.Size < r.MinSize {
+		return false
+	}
+	if r.MaxSize > 0 && obj.Size > r.MaxSize {
+		return false
+	}
+	if obj.Age < r.MinAge {
+		return false
+	}
+	if r.MaxAge > 0 && obj.Age > r.MaxAge {
+		return false
+	}
+	if r.AccessFrequency != "" && r.AccessFrequency != obj.AccessFrequency {
+		return false
+	}
+	return true
+}
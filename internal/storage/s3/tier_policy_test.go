@@ -0,0 +1,218 @@
+package s3
+
+import (
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestDefaultTierPolicy_Parity(t *testing.T) {
+	policy := DefaultTierPolicy{}
+
+	tests := []struct {
+		name      string
+		size      int64
+		frequency string
+		current   string
+		wantTier  string
+		wantRecs  []string
+	}{
+		{
+			name:      "small frequent object",
+			size:      64 * 1024,
+			frequency: AccessFrequent,
+			current:   TierStandardIA,
+			wantTier:  TierStandard,
+			wantRecs: []string{
+				"Consider Standard tier for small objects to avoid IA minimum charges",
+				"Consider Standard tier for frequently accessed data",
+			},
+		},
+		{
+			name:      "infrequent object in standard",
+			size:      1024 * 1024,
+			frequency: AccessInfrequent,
+			current:   TierStandard,
+			wantTier:  TierStandardIA,
+			wantRecs:  []string{"Consider Standard-IA or One Zone-IA for cost savings"},
+		},
+		{
+			name:      "archive object",
+			size:      1024 * 1024,
+			frequency: AccessArchive,
+			current:   TierStandard,
+			wantTier:  TierGlacierIR,
+			wantRecs:  []string{"Consider Glacier tiers for archive data"},
+		},
+		{
+			name:      "large cold object",
+			size:      2 * 1024 * 1024 * 1024,
+			frequency: AccessCold,
+			current:   TierStandard,
+			wantTier:  TierGlacier,
+			wantRecs:  []string{},
+		},
+		{
+			name:      "unknown access pattern",
+			size:      1024 * 1024,
+			frequency: "unknown",
+			current:   TierStandard,
+			wantTier:  TierIntelligent,
+			wantRecs:  []string{"Consider Intelligent Tiering for unknown access patterns"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Recommend(ObjectStats{
+				Size:            tt.size,
+				AccessFrequency: tt.frequency,
+				CurrentTier:     tt.current,
+			})
+
+			if decision.Tier != tt.wantTier {
+				t.Errorf("Expected tier %s, got %s", tt.wantTier, decision.Tier)
+			}
+			if !reflect.DeepEqual(decision.Recommendations, tt.wantRecs) {
+				t.Errorf("Expected recommendations %v, got %v", tt.wantRecs, decision.Recommendations)
+			}
+		})
+	}
+}
+
+func TestRuleBasedTierPolicy_PrefixOverride(t *testing.T) {
+	var cfg TierPolicyConfig
+	data := []byte(`
+rules:
+  - prefix: reports/
+    tier: STANDARD
+    reason: reports stay hot
+  - min_size: 1048576
+    min_age: 720h
+    tier: GLACIER_IR
+`)
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("Failed to parse tier policy: %v", err)
+	}
+
+	policy, err := NewTierPolicy(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create tier policy: %v", err)
+	}
+
+	// Archive access would normally move the object to Glacier
+	decision := policy.Recommend(ObjectStats{
+		Key:             "reports/2024/q1.csv",
+		Size:            10 * 1024 * 1024,
+		Age:             365 * 24 * time.Hour,
+		AccessFrequency: AccessArchive,
+		CurrentTier:     TierStandard,
+	})
+	if decision.Tier != TierStandard {
+		t.Errorf("Expected prefix rule to keep Standard tier, got %s", decision.Tier)
+	}
+	if decision.Reason != "reports stay hot" {
+		t.Errorf("Expected rule reason, got %q", decision.Reason)
+	}
+	if len(decision.Recommendations) != 0 {
+		t.Errorf("Expected no recommendations when already in rule tier, got %v", decision.Recommendations)
+	}
+
+	// Size and age rule
+	decision = policy.Recommend(ObjectStats{
+		Key:         "data/big.bin",
+		Size:        2 * 1024 * 1024,
+		Age:         60 * 24 * time.Hour,
+		CurrentTier: TierStandard,
+	})
+	if decision.Tier != TierGlacierIR {
+		t.Errorf("Expected size/age rule to select Glacier IR, got %s", decision.Tier)
+	}
+
+	// No rule matches, fall back to default heuristics
+	decision = policy.Recommend(ObjectStats{
+		Key:             "data/small.txt",
+		Size:            1024 * 1024,
+		Age:             24 * time.Hour,
+		AccessFrequency: AccessInfrequent,
+		CurrentTier:     TierStandard,
+	})
+	if decision.Tier != TierStandardIA {
+		t.Errorf("Expected fallback to default policy, got %s", decision.Tier)
+	}
+}
+
+func TestGetTierRecommendations_MatchesPrefixAndAgeRules(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	policy, err := NewTierPolicy(TierPolicyConfig{Rules: []TierPolicyRule{
+		{Prefix: "reports/", Tier: TierStandard, Reason: "reports stay hot"},
+		{MinAge: 720 * time.Hour, Tier: TierGlacierIR, Reason: "old data is archived"},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create tier policy: %v", err)
+	}
+	validator := NewTierValidator(TierStandardIA, TierConstraints{}, logger)
+	validator.SetPolicy(policy)
+	backend := &Backend{currentTier: TierStandardIA, tierValidator: validator}
+
+	tests := []struct {
+		name string
+		key  string
+		age  time.Duration
+		want []string
+	}{
+		{"prefix rule", "reports/q1.csv", time.Hour, []string{"Use STANDARD tier: reports stay hot"}},
+		{"age rule", "data/old.bin", 60 * 24 * time.Hour, []string{"Use GLACIER_IR tier: old data is archived"}},
+		{"no rule", "data/new.bin", time.Hour, []string{"Consider Standard tier for frequently accessed data"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backend.GetTierRecommendations(tt.key, 1024*1024, tt.age, AccessFrequent)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetTierRecommendations(%s) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleBasedTierPolicy_InvalidRules(t *testing.T) {
+	if _, err := NewRuleBasedTierPolicy([]TierPolicyRule{{Tier: "BOGUS"}}, nil); err == nil {
+		t.Error("Expected error for unsupported tier")
+	}
+	if _, err := NewRuleBasedTierPolicy([]TierPolicyRule{{Tier: TierStandard, MinSize: 10, MaxSize: 5}}, nil); err == nil {
+		t.Error("Expected error for inverted size range")
+	}
+}
+
+func TestCostOptimizer_UsesTierPolicy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	policy, err := NewRuleBasedTierPolicy([]TierPolicyRule{
+		{Prefix: "reports/", Tier: TierStandard, Reason: "reports stay hot"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create tier policy: %v", err)
+	}
+
+	backend := &Backend{currentTier: TierStandardIA, tierPolicy: policy}
+	backend.pricingManager = NewPricingManager(PricingConfig{}, logger)
+	optimizer := NewCostOptimizer(backend, CostOptimization{MonitorAccessPatterns: true}, logger)
+
+	pattern := &AccessPattern{
+		ObjectKey:       "reports/archive.csv",
+		AccessCount:     1,
+		FirstAccessTime: time.Now().Add(-200 * 24 * time.Hour),
+		ObjectSize:      10 * 1024 * 1024,
+		CurrentTier:     TierGlacierIR,
+	}
+
+	decision := optimizer.recommendTier(pattern, AccessCold)
+	if decision.Tier != TierStandard {
+		t.Errorf("Expected optimizer to honour prefix rule, got %s", decision.Tier)
+	}
+}
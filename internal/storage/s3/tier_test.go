@@ -154,7 +154,7 @@ func TestTierRecommendations(t *testing.T) {
 		validator := NewTierValidator(TierStandardIA, TierConstraints{}, logger)
 
 		// Small objects should recommend Standard tier
-		recommendations := validator.GetRecommendations(64*1024, "unknown") // 64KB
+		recommendations := validator.GetRecommendations("data/small.bin", 64*1024, 0, "unknown") // 64KB
 		found := false
 		for _, rec := range recommendations {
 			if rec == "Consider Standard tier for small objects to avoid IA minimum charges" {
@@ -171,7 +171,7 @@ func TestTierRecommendations(t *testing.T) {
 		validator := NewTierValidator(TierStandard, TierConstraints{}, logger)
 
 		// Infrequent access should recommend IA tiers
-		recommendations := validator.GetRecommendations(1024*1024, "infrequent") // 1MB
+		recommendations := validator.GetRecommendations("data/medium.bin", 1024*1024, 0, "infrequent") // 1MB
 		found := false
 		for _, rec := range recommendations {
 			if rec == "Consider Standard-IA or One Zone-IA for cost savings" {
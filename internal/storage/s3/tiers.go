@@ -129,6 +129,7 @@ type TierValidator struct {
 	tier        string
 	constraints TierConstraints
 	tierInfo    StorageTierInfo
+	policy      TierPolicy
 	logger      *slog.Logger
 }
 
@@ -145,6 +146,7 @@ func NewTierValidator(tier string, constraints TierConstraints, logger *slog.Log
 		tier:        tier,
 		constraints: constraints,
 		tierInfo:    tierInfo,
+		policy:      DefaultTierPolicy{},
 		logger:      logger,
 	}
 }
@@ -176,10 +178,7 @@ func (tv *TierValidator) ValidateWrite(key string, dataSize int64) error {
 // ValidateDelete validates a delete operation against tier constraints
 func (tv *TierValidator) ValidateDelete(key string, objectAge time.Duration) error {
 	// Check deletion embargo
-	embargo := tv.tierInfo.DeletionEmbargo
-	if tv.constraints.DeletionEmbargo > 0 {
-		embargo = tv.constraints.DeletionEmbargo
-	}
+	embargo := tv.DeletionEmbargo()
 
 	if embargo > 0 && objectAge < embargo {
 		return fmt.Errorf("object %s cannot be deleted before %v (current age: %v) due to %s tier constraints",
@@ -198,41 +197,39 @@ func (tv *TierValidator) ValidateDelete(key string, objectAge time.Duration) err
 	return nil
 }
 
+// DeletionEmbargo returns how old an object must be before it may be
+// deleted, from the tier constraints or else the tier itself
+func (tv *TierValidator) DeletionEmbargo() time.Duration {
+	if tv.constraints.DeletionEmbargo > 0 {
+		return tv.constraints.DeletionEmbargo
+	}
+	return tv.tierInfo.DeletionEmbargo
+}
+
 // GetTierInfo returns information about the current tier
 func (tv *TierValidator) GetTierInfo() StorageTierInfo {
 	return tv.tierInfo
 }
 
-// GetRecommendations returns tier recommendations based on access patterns
-func (tv *TierValidator) GetRecommendations(objectSize int64, accessFrequency string) []string {
-	recommendations := make([]string, 0, 3)
-
-	// Size-based recommendations
-	if objectSize < 128*1024 {
-		recommendations = append(recommendations, "Consider Standard tier for small objects to avoid IA minimum charges")
-	}
+// GetRecommendations returns tier recommendations for the object at key of
+// the given size and age based on its access pattern
+func (tv *TierValidator) GetRecommendations(key string, objectSize int64, age time.Duration, accessFrequency string) []string {
+	decision := tv.policy.Recommend(ObjectStats{
+		Key:             key,
+		Size:            objectSize,
+		Age:             age,
+		AccessFrequency: accessFrequency,
+		CurrentTier:     tv.tier,
+	})
+	return decision.Recommendations
+}
 
-	// Access pattern recommendations
-	switch accessFrequency {
-	case AccessFrequent:
-		if tv.tier != TierStandard {
-			recommendations = append(recommendations, "Consider Standard tier for frequently accessed data")
-		}
-	case AccessInfrequent:
-		if tv.tier == TierStandard {
-			recommendations = append(recommendations, "Consider Standard-IA or One Zone-IA for cost savings")
-		}
-	case AccessArchive:
-		if tv.tier != TierGlacierIR && tv.tier != TierGlacier {
-			recommendations = append(recommendations, "Consider Glacier tiers for archive data")
-		}
-	case "unknown":
-		if tv.tier != TierIntelligent {
-			recommendations = append(recommendations, "Consider Intelligent Tiering for unknown access patterns")
-		}
+// SetPolicy replaces the tier policy used for recommendations
+func (tv *TierValidator) SetPolicy(policy TierPolicy) {
+	if policy == nil {
+		policy = DefaultTierPolicy{}
 	}
-
-	return recommendations
+	tv.policy = policy
 }
 
 // ConvertTierToStorageClass converts our tier constants to AWS SDK storage class types
@@ -0,0 +1,145 @@
+package s3
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/objectfs/objectfs/pkg/errors"
+)
+
+// maxCopyObjectSize is the largest object a single CopyObject can copy
+const maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+
+// touchAPIClient is the subset of the S3 client used to touch objects
+type touchAPIClient interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+}
+
+// Touch updates an object's last-modified time without re-uploading it, which
+// resets lifecycle expiration clocks. The object is copied onto itself, keeping
+// its storage class, content headers, and user metadata. Objects still within
+// their tier's minimum storage period are refused, since replacing them is
+// billed as an early deletion. It fails in presigned mode, which has no
+// URL for CopyObject.
+func (b *Backend) Touch(ctx context.Context, key string) error {
+	if err := b.checkSigned("Touch", key); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	defer func() {
+		b.metricsCollector.RecordMetrics(time.Since(start), false)
+	}()
+
+	if !b.healthTracker.CanWrite("s3-writes") {
+		state := b.healthTracker.GetState("s3-writes")
+		return errors.NewError(errors.ErrCodeServiceUnavailable, "S3 write operations are unavailable").
+			WithComponent("s3-backend").
+			WithOperation("Touch").
+			WithContext("health_state", state.String()).
+			WithContext("bucket", b.bucket).
+			WithContext("key", key)
+	}
+
+	if err := b.checkRetention(ctx, "Touch", key); err != nil {
+		return err
+	}
+
+	if err := b.costBudget.reserve(ctx, "Touch", key, b.costBudget.estimate("PUT", b.currentTier, 0)); err != nil {
+		return err
+	}
+
+	client := b.clientManager.GetPooledClient()
+	err := touchObject(ctx, client, b.bucket, b.config.requestPayer(), key, time.Now())
+	b.clientManager.ReleasePooledClient(client, err)
+	if err != nil {
+		b.metricsCollector.RecordError(err)
+		var objErr *errors.ObjectFSError
+		if !stderr.As(err, &objErr) {
+			err = b.translateError(err, "Touch", key)
+			b.healthTracker.RecordError("s3-writes", err)
+		}
+		return err
+	}
+
+	b.healthTracker.RecordSuccess("s3-writes")
+	return nil
+}
+
+// touchObject copies an object onto itself, replacing its metadata with the
+// current values so S3 accepts the in-place copy
+func touchObject(ctx context.Context, client touchAPIClient, bucket string, payer s3types.RequestPayer, key string, now time.Time) error {
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		RequestPayer: payer,
+	})
+	if err != nil {
+		return err
+	}
+
+	storageClass := headStorageClass(head.StorageClass)
+	if tier, ok := StorageTiers[storageClass]; ok && tier.MinimumStorageDays > 0 {
+		minimum := time.Duration(tier.MinimumStorageDays) * 24 * time.Hour
+		if age := now.Sub(aws.ToTime(head.LastModified)); age < minimum {
+			return errors.NewError(errors.ErrCodeTierValidation,
+				fmt.Sprintf("touching %s before its %d-day minimum storage period would incur an early deletion charge", key, tier.MinimumStorageDays)).
+				WithComponent("s3-backend").
+				WithOperation("Touch").
+				WithContext("key", key).
+				WithContext("storage_class", storageClass).
+				WithDetail("age", age.String())
+		}
+	}
+
+	if size := aws.ToInt64(head.ContentLength); size > maxCopyObjectSize {
+		return errors.NewError(errors.ErrCodeLimitExceeded, "object is too large to touch with a single copy").
+			WithComponent("s3-backend").
+			WithOperation("Touch").
+			WithContext("key", key).
+			WithDetail("size", size).
+			WithDetail("max_size", int64(maxCopyObjectSize))
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:             aws.String(bucket),
+		Key:                aws.String(key),
+		CopySource:         aws.String(copySource(bucket, key)),
+		MetadataDirective:  s3types.MetadataDirectiveReplace,
+		Metadata:           head.Metadata,
+		StorageClass:       s3types.StorageClass(storageClass),
+		ContentType:        head.ContentType,
+		ContentEncoding:    head.ContentEncoding,
+		ContentDisposition: head.ContentDisposition,
+		ContentLanguage:    head.ContentLanguage,
+		CacheControl:       head.CacheControl,
+		Expires:            head.Expires,
+		RequestPayer:       payer,
+	}
+	// Copy only the version that was inspected, so a concurrent write is not
+	// silently replaced by stale metadata
+	if head.ETag != nil {
+		input.CopySourceIfMatch = head.ETag
+	}
+
+	_, err = client.CopyObject(ctx, input)
+	return err
+}
+
+// copySource returns the URL-encoded CopySource value for bucket and key
+func copySource(bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return bucket + "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,153 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	objerrors "github.com/objectfs/objectfs/pkg/errors"
+)
+
+// fakeTouchClient stores object metadata and applies in-place copies the
+// way S3 does, stamping the copy with the current time
+type fakeTouchClient struct {
+	now     time.Time
+	objects map[string]*s3.HeadObjectOutput
+	copies  []*s3.CopyObjectInput
+}
+
+func (f *fakeTouchClient) HeadObject(ctx context.Context, input *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	head, ok := f.objects[aws.ToString(input.Key)]
+	if !ok {
+		return nil, &s3types.NotFound{}
+	}
+	copied := *head
+	return &copied, nil
+}
+
+func (f *fakeTouchClient) CopyObject(ctx context.Context, input *s3.CopyObjectInput, _ ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	f.copies = append(f.copies, input)
+	head, ok := f.objects[aws.ToString(input.Key)]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	if input.MetadataDirective != s3types.MetadataDirectiveReplace {
+		return nil, errors.New("InvalidRequest: copy onto itself without changing metadata")
+	}
+	if input.CopySourceIfMatch != nil && aws.ToString(input.CopySourceIfMatch) != aws.ToString(head.ETag) {
+		return nil, errors.New("PreconditionFailed")
+	}
+
+	storageClass := input.StorageClass
+	if storageClass == "" {
+		storageClass = s3types.StorageClassStandard
+	}
+	f.objects[aws.ToString(input.Key)] = &s3.HeadObjectOutput{
+		ContentLength: head.ContentLength,
+		ETag:          head.ETag,
+		LastModified:  aws.Time(f.now),
+		Metadata:      input.Metadata,
+		ContentType:   input.ContentType,
+		StorageClass:  s3types.StorageClass(storageClass),
+	}
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func TestTouchUpdatesLastModified(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := created.Add(45 * 24 * time.Hour)
+	client := &fakeTouchClient{
+		now: now,
+		objects: map[string]*s3.HeadObjectOutput{
+			"data/report 1.csv": {
+				ContentLength: aws.Int64(1024),
+				ETag:          aws.String(`"abc"`),
+				LastModified:  aws.Time(created),
+				Metadata:      map[string]string{"owner": "analytics"},
+				ContentType:   aws.String("text/csv"),
+				StorageClass:  s3types.StorageClassStandardIa,
+			},
+		},
+	}
+
+	if err := touchObject(context.Background(), client, "bucket", "", "data/report 1.csv", now); err != nil {
+		t.Fatalf("touchObject failed: %v", err)
+	}
+
+	head, err := client.HeadObject(context.Background(), &s3.HeadObjectInput{Key: aws.String("data/report 1.csv")})
+	if err != nil {
+		t.Fatalf("HeadObject failed: %v", err)
+	}
+	if got := aws.ToTime(head.LastModified); !got.Equal(now) {
+		t.Errorf("LastModified = %v, want %v", got, now)
+	}
+	if head.StorageClass != s3types.StorageClassStandardIa {
+		t.Errorf("StorageClass = %s, want STANDARD_IA", head.StorageClass)
+	}
+	if head.Metadata["owner"] != "analytics" {
+		t.Errorf("Metadata = %v, want owner preserved", head.Metadata)
+	}
+	if aws.ToString(head.ContentType) != "text/csv" {
+		t.Errorf("ContentType = %s, want text/csv", aws.ToString(head.ContentType))
+	}
+	if got := aws.ToString(client.copies[0].CopySource); got != "bucket/data/report%201.csv" {
+		t.Errorf("CopySource = %s, want bucket/data/report%%201.csv", got)
+	}
+}
+
+func TestTouchRefusesEarlyDeletionPenalty(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := created.Add(10 * 24 * time.Hour)
+	client := &fakeTouchClient{
+		now: now,
+		objects: map[string]*s3.HeadObjectOutput{
+			"archive.bin": {
+				ContentLength: aws.Int64(1024),
+				LastModified:  aws.Time(created),
+				StorageClass:  s3types.StorageClassGlacierIr,
+			},
+		},
+	}
+
+	err := touchObject(context.Background(), client, "bucket", "", "archive.bin", now)
+	if !errors.Is(err, objerrors.NewError(objerrors.ErrCodeTierValidation, "")) {
+		t.Fatalf("expected ErrCodeTierValidation, got %v", err)
+	}
+	if len(client.copies) != 0 {
+		t.Errorf("copied %d times, want none within the minimum storage period", len(client.copies))
+	}
+}
+
+func TestTouchStandardObject(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := created.Add(time.Minute)
+	client := &fakeTouchClient{
+		now: now,
+		objects: map[string]*s3.HeadObjectOutput{
+			"fresh.txt": {ContentLength: aws.Int64(3), LastModified: aws.Time(created)},
+		},
+	}
+
+	// Standard has no minimum storage period, so recent objects can be touched
+	if err := touchObject(context.Background(), client, "bucket", "", "fresh.txt", now); err != nil {
+		t.Fatalf("touchObject failed: %v", err)
+	}
+	if got := client.copies[0].StorageClass; got != s3types.StorageClassStandard {
+		t.Errorf("copy StorageClass = %s, want STANDARD", got)
+	}
+}
+
+func TestTouchMissingObject(t *testing.T) {
+	client := &fakeTouchClient{objects: map[string]*s3.HeadObjectOutput{}}
+
+	err := touchObject(context.Background(), client, "bucket", "", "missing", time.Now())
+	var notFound *s3types.NotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
@@ -412,6 +412,10 @@ func (e *ObjectFSError) GetRecommendation() string {
 			"Check your IAM policy grants s3:GetObject, s3:PutObject, and s3:ListBucket permissions.",
 		ErrCodePermissionDenied: "Insufficient permissions for this operation. " +
 			"Verify file system permissions or AWS IAM policy.",
+		ErrCodePathInvalid: "The path is missing or invalid. " +
+			"Create the mount point directory or correct the path before mounting.",
+		ErrCodeNotEmpty: "The mount point contains files that would be hidden while mounted. " +
+			"Use an empty directory as the mount point.",
 		ErrCodeInvalidConfig: "Configuration validation failed. " +
 			"Check your configuration file syntax and required parameters.",
 		ErrCodeOperationTimeout: "Operation took too long to complete. " +
@@ -24,6 +24,60 @@ type Backend interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// ObjectStreamer is implemented by backends that can stream listings
+// incrementally instead of returning a single bounded page
+type ObjectStreamer interface {
+	ListObjectsChan(ctx context.Context, prefix string) (<-chan ObjectInfo, <-chan error)
+}
+
+// BatchHeader is implemented by backends that can fetch metadata for many
+// keys at once. Each key gets either an ObjectInfo or an error, so one
+// missing object does not fail the batch.
+type BatchHeader interface {
+	HeadObjects(ctx context.Context, keys []string) (map[string]*ObjectInfo, map[string]error)
+}
+
+// ObjectToucher is implemented by backends that can update an object's
+// last-modified time without rewriting its contents
+type ObjectToucher interface {
+	Touch(ctx context.Context, key string) error
+}
+
+// ObjectMover is implemented by backends that can move an object to a new
+// key, keeping its metadata, without the caller rewriting its contents
+type ObjectMover interface {
+	MoveObject(ctx context.Context, srcKey, dstKey string) error
+}
+
+// ObjectMetadataWriter is implemented by backends that can store user
+// metadata alongside an object's contents
+type ObjectMetadataWriter interface {
+	PutObjectWithMetadata(ctx context.Context, key string, data []byte, metadata map[string]string) error
+}
+
+// RangeWriter is implemented by backends that can overwrite part of an
+// object in place, extending it when the write ends past its size
+type RangeWriter interface {
+	WriteAt(ctx context.Context, key string, offset int64, data []byte) error
+}
+
+// MultipartHousekeeper is implemented by backends that can find and abort
+// multipart uploads that were started but never completed, whose parts are
+// stored and billed until aborted
+type MultipartHousekeeper interface {
+	ListIncompleteUploads(ctx context.Context, prefix string) ([]IncompleteUpload, error)
+	AbortIncompleteUploads(ctx context.Context, olderThan time.Duration) (int, error)
+}
+
+// LatencyProber is implemented by backends that can measure their
+// round-trip latency. Ping fails when the backend cannot be reached, so a
+// failure is never reported as a long latency; LatencyEstimate returns the
+// rolling latency of successful pings, or 0 before the first.
+type LatencyProber interface {
+	Ping(ctx context.Context) (time.Duration, error)
+	LatencyEstimate() time.Duration
+}
+
 // DistributedCoordinator manages distributed operations across cluster nodes
 type DistributedCoordinator interface {
 	// Execute a distributed operation
@@ -41,6 +95,32 @@ type Cache interface {
 	Evict(size int64) bool
 	Size() int64
 	Stats() CacheStats
+
+	// Pin excludes key from eviction until Unpin is called
+	Pin(key string)
+	Unpin(key string)
+
+	// OnEvict registers fn to run, outside the cache lock, for every entry
+	// evicted for space or expiry. Delete and Clear do not trigger it.
+	OnEvict(fn EvictionCallback)
+}
+
+// EvictionCallback receives the object key and byte range of an evicted
+// cache entry
+type EvictionCallback func(key string, offset, size int64)
+
+// CacheEntryInfo describes a cached object considered for eviction
+type CacheEntryInfo struct {
+	Key         string    // Object key as stored in the cache
+	Size        int64     // Cached bytes across all ranges of the object
+	LastAccess  time.Time // Zero when the object was never read
+	AccessCount int       // Recent reads of the object
+}
+
+// EvictionScorer ranks cached objects for eviction. A higher score means
+// the object is evicted sooner.
+type EvictionScorer interface {
+	Score(entry CacheEntryInfo) float64
 }
 
 // WriteBuffer defines the write buffering interface
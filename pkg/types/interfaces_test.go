@@ -79,6 +79,10 @@ func (m *mockCache) Stats() CacheStats {
 	return CacheStats{}
 }
 
+func (m *mockCache) Pin(key string)              {}
+func (m *mockCache) Unpin(key string)            {}
+func (m *mockCache) OnEvict(fn EvictionCallback) {}
+
 type mockWriteBuffer struct{}
 
 func (m *mockWriteBuffer) Write(key string, offset int64, data []byte) error {
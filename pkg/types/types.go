@@ -1,6 +1,7 @@
 package types
 
 import (
+	"strings"
 	"time"
 
 	"github.com/objectfs/objectfs/internal/config"
@@ -15,6 +16,37 @@ type ObjectInfo struct {
 	ContentType  string            `json:"content_type"`
 	Metadata     map[string]string `json:"metadata"`
 	Checksum     string            `json:"checksum"`
+	StorageClass string            `json:"storage_class,omitempty"`
+	VersionID    string            `json:"version_id,omitempty"`
+}
+
+// ObjectSizeLimits caps the size of objects written through a mount. Zero
+// means unlimited; the longest matching prefix overrides Default.
+type ObjectSizeLimits struct {
+	Default  int64            `json:"default" yaml:"default"`
+	Prefixes map[string]int64 `json:"prefixes,omitempty" yaml:"prefixes"`
+}
+
+// Limit returns the maximum size for key, or 0 when unlimited
+func (l ObjectSizeLimits) Limit(key string) int64 {
+	limit := l.Default
+	matched := -1
+	for prefix, size := range l.Prefixes {
+		if strings.HasPrefix(key, prefix) && len(prefix) > matched {
+			limit = size
+			matched = len(prefix)
+		}
+	}
+	return limit
+}
+
+// IncompleteUpload is a multipart upload that was started but neither
+// completed nor aborted
+type IncompleteUpload struct {
+	Key          string    `json:"key"`
+	UploadID     string    `json:"upload_id"`
+	Initiated    time.Time `json:"initiated"`
+	StorageClass string    `json:"storage_class,omitempty"`
 }
 
 // CacheStats represents cache performance statistics
@@ -26,6 +58,17 @@ type CacheStats struct {
 	Capacity    int64   `json:"capacity"`
 	HitRate     float64 `json:"hit_rate"`
 	Utilization float64 `json:"utilization"`
+
+	// Revalidation of expired entries against the backend
+	Revalidations          uint64 `json:"revalidations"`
+	RevalidatedNotModified uint64 `json:"revalidated_not_modified"`
+
+	// Expired entries served because the backend was unavailable
+	StaleHits uint64 `json:"stale_hits"`
+
+	// Entries excluded from eviction
+	PinnedKeys  int   `json:"pinned_keys"`
+	PinnedBytes int64 `json:"pinned_bytes"`
 }
 
 // AccessPattern represents file access patterns for ML prediction
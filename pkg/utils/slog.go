@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces the value of a redacted log field
+const RedactedValue = "[REDACTED]"
+
+// defaultRedactKeys are substrings of field names whose values are always
+// redacted, matched case-insensitively
+var defaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "credential", "authorization",
+	"api_key", "apikey", "access_key", "private_key", "session_key",
+}
+
+// awsAccessKeyPattern matches AWS access key IDs embedded in any value
+var awsAccessKeyPattern = regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`)
+
+// SlogConfig configures a structured logger built by NewSlogLogger
+type SlogConfig struct {
+	Level      string            // TRACE, DEBUG, INFO, WARN, ERROR or FATAL (default INFO)
+	Format     string            // "json" (default) or "text"
+	Output     io.Writer         // Destination (default stderr)
+	Fields     map[string]string // Added to every record, e.g. host or deployment
+	RedactKeys []string          // Field name substrings redacted in addition to the defaults
+}
+
+// NewSlogLogger builds a JSON or text slog.Logger that redacts
+// credential-like fields. Fields whose name contains a sensitive word such
+// as "secret" or "token" are replaced with RedactedValue, and AWS access key
+// IDs are masked wherever they appear in a string value.
+func NewSlogLogger(config *SlogConfig) (*slog.Logger, error) {
+	if config == nil {
+		config = &SlogConfig{}
+	}
+
+	level := slog.LevelInfo
+	if config.Level != "" {
+		parsed, err := ParseLogLevel(config.Level)
+		if err != nil {
+			return nil, err
+		}
+		level = slogLevel(parsed)
+	}
+
+	output := config.Output
+	if output == nil {
+		output = os.Stderr
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: newRedactor(config.RedactKeys),
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(output, opts)
+	case "text":
+		handler = slog.NewTextHandler(output, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format: %s", config.Format)
+	}
+
+	logger := slog.New(handler)
+	for key, value := range config.Fields {
+		logger = logger.With(key, value)
+	}
+	return logger, nil
+}
+
+// OpenLogOutput returns the writer for a log destination: "" or "stderr",
+// "stdout", or a file path opened for appending. The closer is nil for the
+// standard streams.
+func OpenLogOutput(destination string) (io.Writer, io.Closer, error) {
+	switch destination {
+	case "", "stderr":
+		return os.Stderr, nil, nil
+	case "stdout":
+		return os.Stdout, nil, nil
+	}
+
+	if err := ValidatePath(destination, true); err != nil {
+		return nil, nil, fmt.Errorf("invalid log file path: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Clean(destination), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, file, nil
+}
+
+// slogLevel maps a LogLevel onto the nearest slog level
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case TRACE, DEBUG:
+		return slog.LevelDebug
+	case WARN:
+		return slog.LevelWarn
+	case ERROR, FATAL:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// newRedactor returns a ReplaceAttr function redacting fields whose name
+// contains one of the default or extra sensitive words
+func newRedactor(extra []string) func(groups []string, attr slog.Attr) slog.Attr {
+	keys := make([]string, 0, len(defaultRedactKeys)+len(extra))
+	keys = append(keys, defaultRedactKeys...)
+	for _, key := range extra {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return func(groups []string, attr slog.Attr) slog.Attr {
+		name := strings.ToLower(attr.Key)
+		for _, key := range keys {
+			if strings.Contains(name, key) {
+				return slog.String(attr.Key, RedactedValue)
+			}
+		}
+
+		if attr.Value.Kind() == slog.KindString {
+			if value := attr.Value.String(); awsAccessKeyPattern.MatchString(value) {
+				return slog.String(attr.Key, awsAccessKeyPattern.ReplaceAllString(value, RedactedValue))
+			}
+		}
+		return attr
+	}
+}
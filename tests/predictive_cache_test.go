@@ -206,6 +206,10 @@ func (c *MockBaseCache) Evict(size int64) bool {
 	return evicted >= size
 }
 
+func (c *MockBaseCache) Pin(key string)                    {}
+func (c *MockBaseCache) Unpin(key string)                  {}
+func (c *MockBaseCache) OnEvict(fn types.EvictionCallback) {}
+
 func (c *MockBaseCache) Size() int64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -217,6 +218,210 @@ func TestWriteBufferUnit(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestWriteBufferMemoryPressure(t *testing.T) {
+	var flushedMu sync.Mutex
+	var flushed []string
+	flushCallback := func(key string, data []byte, offset int64) error {
+		flushedMu.Lock()
+		defer flushedMu.Unlock()
+		flushed = append(flushed, key)
+		return nil
+	}
+
+	// Per-file thresholds are out of reach, so only the global cap flushes
+	config := &buffer.WriteBufferConfig{
+		MaxBufferSize:  1 << 20,
+		MaxBuffers:     10,
+		FlushInterval:  time.Hour,
+		FlushThreshold: 1 << 20,
+		AsyncFlush:     true,
+		BatchSize:      100,
+		MaxWriteDelay:  time.Hour,
+		MaxMemory:      10000,
+		HighWatermark:  0.9,
+		LowWatermark:   0.7,
+	}
+
+	writeBuffer, err := buffer.NewWriteBuffer(config, flushCallback)
+	require.NoError(t, err)
+	defer func() { _ = writeBuffer.Close() }()
+
+	sizes := []struct {
+		key  string
+		size int
+	}{
+		{"a", 1500}, {"b", 2000}, {"c", 2000}, {"d", 1800},
+	}
+	for _, s := range sizes {
+		require.NoError(t, writeBuffer.Write(s.key, 0, make([]byte, s.size)))
+	}
+	assert.Equal(t, int64(7300), writeBuffer.Size())
+	assert.Zero(t, writeBuffer.GetStats().PressureFlushes, "below the high watermark nothing is forced")
+
+	// Crossing 9000 bytes flushes the largest buffers, oldest first on ties,
+	// until at most 7000 bytes remain
+	require.NoError(t, writeBuffer.Write("e", 0, make([]byte, 1800)))
+
+	require.Eventually(t, func() bool {
+		return writeBuffer.Size() <= 7000
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, int64(5100), writeBuffer.Size())
+	assert.Equal(t, uint64(2), writeBuffer.GetStats().PressureFlushes)
+	flushedMu.Lock()
+	assert.Equal(t, []string{"b", "c"}, flushed)
+	flushedMu.Unlock()
+}
+
+func TestWriteBufferSpillToDisk(t *testing.T) {
+	release := make(chan struct{})
+	var flushedMu sync.Mutex
+	flushed := make(map[string][]byte)
+	flushCallback := func(key string, data []byte, offset int64) error {
+		<-release
+		flushedMu.Lock()
+		defer flushedMu.Unlock()
+		flushed[key] = append([]byte(nil), data...)
+		return nil
+	}
+
+	spillDir := t.TempDir()
+	config := &buffer.WriteBufferConfig{
+		MaxBufferSize:  1 << 20,
+		MaxBuffers:     10,
+		FlushInterval:  time.Hour,
+		FlushThreshold: 1 << 20,
+		AsyncFlush:     true,
+		BatchSize:      100,
+		MaxWriteDelay:  time.Hour,
+		MaxMemory:      10000,
+		LowWatermark:   0.3,
+		SpillDir:       spillDir,
+		SpillWatermark: 0.5,
+	}
+
+	writeBuffer, err := buffer.NewWriteBuffer(config, flushCallback)
+	require.NoError(t, err)
+	defer func() { _ = writeBuffer.Close() }()
+
+	fill := func(b byte, size int) []byte {
+		return []byte(strings.Repeat(string(b), size))
+	}
+	require.NoError(t, writeBuffer.Write("a", 0, fill('a', 2000)))
+	require.NoError(t, writeBuffer.Write("b", 0, fill('b', 2000)))
+	assert.Zero(t, writeBuffer.GetStats().SpilledBytes, "below the spill watermark nothing is staged")
+
+	// Crossing 5000 bytes in memory spills the oldest of the largest buffers
+	// until at most 3000 bytes remain in memory
+	require.NoError(t, writeBuffer.Write("c", 0, fill('c', 2000)))
+	stats := writeBuffer.GetStats()
+	assert.Equal(t, int64(4000), stats.SpilledBytes)
+	assert.Equal(t, int64(4000), stats.StagedBytes)
+	assert.Zero(t, stats.PressureFlushes, "spilling relieves the pressure without flushing")
+	staged, err := os.ReadDir(spillDir)
+	require.NoError(t, err)
+	assert.Len(t, staged, 2)
+
+	// Writes continuing a spilled buffer upload after its staged bytes
+	require.NoError(t, writeBuffer.Write("b", 2000, fill('B', 500)))
+	assert.Equal(t, int64(6500), writeBuffer.Size())
+
+	close(release)
+	require.Eventually(t, func() bool {
+		flushedMu.Lock()
+		defer flushedMu.Unlock()
+		return len(flushed) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	flushedMu.Lock()
+	assert.Equal(t, fill('a', 2000), flushed["a"])
+	assert.Equal(t, append(fill('b', 2000), fill('B', 500)...), flushed["b"])
+	flushedMu.Unlock()
+
+	require.Eventually(t, func() bool {
+		return writeBuffer.GetStats().StagedBytes == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	stats = writeBuffer.GetStats()
+	assert.Equal(t, uint64(2), stats.SpillReads)
+	assert.Equal(t, int64(2000), stats.PendingBytes, "c stays buffered in memory")
+	staged, err = os.ReadDir(spillDir)
+	require.NoError(t, err)
+	assert.Empty(t, staged, "uploaded spill files are removed")
+}
+
+func TestWriteBufferConcurrentSpill(t *testing.T) {
+	type chunk struct {
+		offset int64
+		data   []byte
+	}
+	var flushedMu sync.Mutex
+	flushed := make(map[string][]chunk)
+	flushCallback := func(key string, data []byte, offset int64) error {
+		flushedMu.Lock()
+		defer flushedMu.Unlock()
+		flushed[key] = append(flushed[key], chunk{offset, append([]byte(nil), data...)})
+		return nil
+	}
+
+	spillDir := t.TempDir()
+	config := &buffer.WriteBufferConfig{
+		MaxBufferSize:  1 << 20,
+		MaxBuffers:     10,
+		FlushInterval:  time.Hour,
+		FlushThreshold: 1 << 20,
+		AsyncFlush:     true,
+		BatchSize:      1000,
+		MaxWriteDelay:  5 * time.Second,
+		MaxMemory:      10000,
+		LowWatermark:   0.3,
+		SpillDir:       spillDir,
+		SpillWatermark: 0.5,
+	}
+
+	writeBuffer, err := buffer.NewWriteBuffer(config, flushCallback)
+	require.NoError(t, err)
+	defer func() { _ = writeBuffer.Close() }()
+
+	// Writers to different files spill and flush each other's buffers
+	const writers, writes, size = 8, 20, 500
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			key := "file-" + strconv.Itoa(w)
+			for i := 0; i < writes; i++ {
+				data := []byte(strings.Repeat(strconv.Itoa(i%10), size))
+				assert.NoError(t, writeBuffer.Write(key, int64(i*size), data))
+			}
+		}(w)
+	}
+	wg.Wait()
+	require.NoError(t, writeBuffer.Sync(context.Background()))
+
+	flushedMu.Lock()
+	defer flushedMu.Unlock()
+	for w := 0; w < writers; w++ {
+		key := "file-" + strconv.Itoa(w)
+		var got []byte
+		for _, c := range flushed[key] {
+			require.Equal(t, int64(len(got)), c.offset, "%s flushed out of order", key)
+			got = append(got, c.data...)
+		}
+		require.Len(t, got, writes*size, "%s lost bytes", key)
+		for i := 0; i < writes; i++ {
+			assert.Equal(t, strings.Repeat(strconv.Itoa(i%10), size), string(got[i*size:(i+1)*size]), "%s write %d", key, i)
+		}
+	}
+
+	stats := writeBuffer.GetStats()
+	assert.Positive(t, stats.SpilledBytes, "the writes crossed the spill watermark")
+	assert.Zero(t, stats.StagedBytes)
+	staged, err := os.ReadDir(spillDir)
+	require.NoError(t, err)
+	assert.Empty(t, staged, "uploaded spill files are removed")
+}
+
 func TestBufferManagerUnit(t *testing.T) {
 	config := &buffer.ManagerConfig{
 		WriteBufferConfig: &buffer.WriteBufferConfig{